@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+)
+
+func TestEncryptKey(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "ai-agent-bridge-ca")
+	build := exec.Command("go", "build", "-o", bin, ".")
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	_, keyPath, err := pki.GenerateJWTKeypair(dir, "jwt")
+	if err != nil {
+		t.Fatalf("GenerateJWTKeypair: %v", err)
+	}
+	passFile := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(passFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(env []string, args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := exec.Command(bin, args...)
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+		return out.String(), err
+	}
+	if out, err := run(nil, "encrypt-key", "--key", keyPath, "--passphrase-file", passFile); err != nil {
+		t.Fatalf("encrypt-key: %v\n%s", err, out)
+	}
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "ENCRYPTED PRIVATE KEY") {
+		t.Fatalf("key not encrypted:\n%s", data)
+	}
+
+	noPass := []string{pki.PassphraseEnv + "=", pki.PassphraseFileEnv + "="}
+	if out, err := run(noPass, "jwt-mint", "--key", keyPath, "--issuer", "ci"); err == nil || !strings.Contains(out, pki.PassphraseEnv) {
+		t.Fatalf("jwt-mint without passphrase: err=%v\n%s", err, out)
+	}
+	if out, err := run([]string{pki.PassphraseFileEnv + "=" + passFile}, "jwt-mint", "--key", keyPath, "--issuer", "ci"); err != nil || strings.Count(out, ".") != 2 {
+		t.Fatalf("jwt-mint with passphrase file: err=%v\n%s", err, out)
+	}
+}
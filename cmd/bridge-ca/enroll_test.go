@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+)
+
+func TestServeAndEnroll(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "ai-agent-bridge-ca")
+	build := exec.Command("go", "build", "-o", bin, ".")
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	certs := filepath.Join(dir, "certs")
+	caCertPath, caKeyPath, err := pki.InitCA("test-ca", certs)
+	if err != nil {
+		t.Fatalf("InitCA: %v", err)
+	}
+	caCert, caKey, err := pki.LoadCA(caCertPath, caKeyPath)
+	if err != nil {
+		t.Fatalf("LoadCA: %v", err)
+	}
+	serverCert, serverKey, err := pki.IssueCert(caCert, caKey, pki.CertTypeServer, "enroll.local", []string{"127.0.0.1"}, certs)
+	if err != nil {
+		t.Fatalf("IssueCert: %v", err)
+	}
+	tokens := filepath.Join(dir, "tokens")
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		cmd := exec.Command(bin, args...)
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out.String())
+		}
+		return out.String()
+	}
+	out := run("enroll-token", "--cn", "host-1", "--tokens", tokens, "--ca", caCertPath)
+	token := regexp.MustCompile(`Token:\s+(\S+)`).FindStringSubmatch(out)
+	fingerprint := regexp.MustCompile(`CA fingerprint: (\S+)`).FindStringSubmatch(out)
+	if token == nil || fingerprint == nil {
+		t.Fatalf("enroll-token output:\n%s", out)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+	var serveOut bytes.Buffer
+	serve := exec.Command(bin, "serve", "--listen", addr, "--ca", caCertPath, "--ca-key", caKeyPath,
+		"--cert", serverCert, "--key", serverKey, "--bundle", caCertPath, "--tokens", tokens)
+	serve.Stdout = &serveOut
+	serve.Stderr = &serveOut
+	if err := serve.Start(); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = serve.Process.Kill()
+		_ = serve.Wait()
+	})
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("serve did not start: %v\n%s", err, serveOut.String())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	clientDir := filepath.Join(dir, "client")
+	run("enroll", "--server", "https://"+addr, "--token", token[1], "--ca-fingerprint", fingerprint[1], "--out", clientDir)
+	pair, err := tls.LoadX509KeyPair(filepath.Join(clientDir, "host-1.crt"), filepath.Join(clientDir, "host-1.key"))
+	if err != nil {
+		t.Fatalf("enrolled key pair: %v", err)
+	}
+	if pair.Leaf.Subject.CommonName != "host-1" {
+		t.Fatalf("CN = %q", pair.Leaf.Subject.CommonName)
+	}
+	bundle, err := os.ReadFile(filepath.Join(clientDir, "ca-bundle.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := x509.VerifyOptions{Roots: pki.NewCertPoolFromPEM(bundle), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}
+	if _, err := pair.Leaf.Verify(opts); err != nil {
+		t.Fatalf("enrolled certificate does not verify against ca-bundle.crt: %v", err)
+	}
+
+	// The token is spent.
+	cmd := exec.Command(bin, "enroll", "--server", "https://"+addr, "--token", token[1], "--bundle", caCertPath, "--out", clientDir)
+	if out, err := cmd.CombinedOutput(); err == nil {
+		t.Fatalf("second enroll succeeded:\n%s", out)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+)
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "ai-agent-bridge-ca")
+	build := exec.Command("go", "build", "-o", bin, ".")
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	certs := filepath.Join(dir, "certs")
+	caCertPath, caKeyPath, err := pki.InitCA("test-ca", certs)
+	if err != nil {
+		t.Fatalf("InitCA: %v", err)
+	}
+	caCert, caKey, err := pki.LoadCA(caCertPath, caKeyPath)
+	if err != nil {
+		t.Fatalf("LoadCA: %v", err)
+	}
+	serverCert, _, err := pki.IssueCert(caCert, caKey, pki.CertTypeServer, "bridge.local", []string{"bridge.local", "127.0.0.1"}, certs)
+	if err != nil {
+		t.Fatalf("IssueCert: %v", err)
+	}
+
+	run := func(args ...string) (string, int) {
+		var out bytes.Buffer
+		cmd := exec.Command(bin, append([]string{"inspect"}, args...)...)
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return out.String(), exitErr.ExitCode()
+		}
+		if err != nil {
+			t.Fatalf("inspect: %v", err)
+		}
+		return out.String(), 0
+	}
+
+	out, code := run(serverCert)
+	if code != 0 || !strings.Contains(out, "bridge.local,127.0.0.1") || !strings.Contains(out, "serverAuth") {
+		t.Fatalf("table: code=%d\n%s", code, out)
+	}
+
+	// The directory holds the CA and server certificates; keys are skipped.
+	out, code = run("--json", certs)
+	if code != 0 {
+		t.Fatalf("json: code=%d\n%s", code, out)
+	}
+	var got []inspectedCert
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, out)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d certificates\n%s", len(got), out)
+	}
+	for _, c := range got {
+		if c.Subject == "CN=bridge.local" && (c.DaysLeft < 88 || len(c.IPAddresses) != 1 || c.ExtKeyUsage[0] != "serverAuth") {
+			t.Fatalf("server cert: %+v", c)
+		}
+	}
+
+	// The server certificate is valid for 90 days.
+	if out, code = run("--warn-days", "100", serverCert); code != exitExpiring {
+		t.Fatalf("--warn-days 100: code=%d\n%s", code, out)
+	}
+	if out, code = run("--warn-days", "30", serverCert); code != 0 {
+		t.Fatalf("--warn-days 30: code=%d\n%s", code, out)
+	}
+	if out, code = run(filepath.Join(dir, "missing.crt")); code != 1 {
+		t.Fatalf("missing file: code=%d\n%s", code, out)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+)
+
+func TestJWTMint(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "ai-agent-bridge-ca")
+	build := exec.Command("go", "build", "-o", bin, ".")
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	pubPath, keyPath, err := pki.GenerateJWTKeypair(dir, "jwt")
+	if err != nil {
+		t.Fatalf("GenerateJWTKeypair: %v", err)
+	}
+	pub, err := pki.LoadEd25519PublicKey(pubPath)
+	if err != nil {
+		t.Fatalf("LoadEd25519PublicKey: %v", err)
+	}
+
+	var out, errOut bytes.Buffer
+	cmd := exec.Command(bin, "jwt-mint", "--key", keyPath, "--issuer", "ci", "--project", "p1", "--scopes", "events:read", "--ttl", "2m")
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("jwt-mint: %v\n%s", err, errOut.String())
+	}
+
+	v := &auth.JWTVerifier{Audience: "bridge", MaxTTL: 5 * time.Minute, Keys: map[string][]auth.IssuerKey{"ci": {{Key: pub}}}}
+	claims, err := v.Verify(strings.TrimSpace(out.String()))
+	if err != nil {
+		t.Fatalf("Verify minted token: %v", err)
+	}
+	if claims.ProjectID != "p1" || claims.Subject != "ci" || claims.HasScope(auth.ScopeSessionsControl) {
+		t.Fatalf("claims = project %q subject %q scopes %v", claims.ProjectID, claims.Subject, claims.Scopes)
+	}
+
+	if err := exec.Command(bin, "jwt-mint", "--key", keyPath).Run(); err == nil {
+		t.Fatal("jwt-mint without --issuer succeeded")
+	}
+}
@@ -1,12 +1,33 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/enroll"
 	"github.com/markcallen/ai-agent-bridge/internal/pki"
+	"golang.org/x/term"
 )
 
 var version = "dev"
@@ -23,6 +44,8 @@ func main() {
 	switch cmd {
 	case "init":
 		cmdInit()
+	case "intermediate":
+		cmdIntermediate()
 	case "issue":
 		cmdIssue()
 	case "cross-sign":
@@ -31,8 +54,22 @@ func main() {
 		cmdBundle()
 	case "jwt-keygen":
 		cmdJWTKeygen()
+	case "jwt-mint":
+		cmdJWTMint()
 	case "verify":
 		cmdVerify()
+	case "inspect":
+		cmdInspect()
+	case "revoke":
+		cmdRevoke()
+	case "encrypt-key":
+		cmdEncryptKey()
+	case "enroll-token":
+		cmdEnrollToken()
+	case "serve":
+		cmdServe()
+	case "enroll":
+		cmdEnroll()
 	case "help", "--help", "-h":
 		usage()
 	case "--version", "-version":
@@ -50,15 +87,26 @@ func usage() {
 
 Commands:
   init         Initialize a new CA
+  intermediate Create an intermediate CA signed by the root CA
   issue        Issue a server or client certificate
   cross-sign   Cross-sign an external CA certificate
   bundle       Build a trust bundle from multiple CA certs
   jwt-keygen   Generate Ed25519 keypair for JWT signing
+  jwt-mint     Print a signed JWT for debugging with curl or grpcurl
   verify       Verify a certificate against a trust bundle
+  inspect      Show certificates and the days until they expire
+  revoke       Add a certificate to the CA's revocation list (CRL)
+  encrypt-key  Encrypt a private key with a passphrase (PKCS#8)
+  enroll-token Create a one-time token for enrolling a client
+  serve        Run the enrollment server that redeems enrollment tokens
+  enroll       Get a client certificate from an enrollment server
 
 Flags:
   --version    Print version and exit
 
+Encrypted private keys are read with the passphrase in BRIDGE_KEY_PASSPHRASE,
+or the file BRIDGE_KEY_PASSPHRASE_FILE names; otherwise it is prompted for.
+
 Run 'ai-agent-bridge-ca <command> --help' for details.
 `)
 }
@@ -86,13 +134,45 @@ func cmdInit() {
 	fmt.Printf("CA private key: %s\n", keyPath)
 }
 
+func cmdIntermediate() {
+	fs := flag.NewFlagSet("intermediate", flag.ExitOnError)
+	name := fs.String("name", "", "Intermediate CA common name (required)")
+	caCert := fs.String("ca", "", "Root CA certificate path (required)")
+	caKey := fs.String("ca-key", "", "Root CA private key path (required)")
+	out := fs.String("out", "certs/", "Output directory")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: parse intermediate flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *name == "" || *caCert == "" || *caKey == "" {
+		fmt.Fprintln(os.Stderr, "error: --name, --ca, and --ca-key are required")
+		os.Exit(1)
+	}
+
+	ca, key, err := pki.LoadCAWithPassphrase(*caCert, *caKey, pki.PromptPassphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	certPath, keyPath, err := pki.InitIntermediateCA(ca, key, *name, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Intermediate certificate: %s\n", certPath)
+	fmt.Printf("Intermediate private key: %s\n", keyPath)
+}
+
 func cmdIssue() {
 	fs := flag.NewFlagSet("issue", flag.ExitOnError)
 	certType := fs.String("type", "", "Certificate type: server or client (required)")
 	cn := fs.String("cn", "", "Common name (required)")
 	san := fs.String("san", "", "Subject alternative names (comma-separated)")
-	caCert := fs.String("ca", "", "CA certificate path (required)")
-	caKey := fs.String("ca-key", "", "CA private key path (required)")
+	ou := fs.String("ou", "", "Organizational units (comma-separated), e.g. the daemon's auth.admin_cert_ou")
+	caCert := fs.String("ca", "", "Issuing CA certificate path, the root or an intermediate (required)")
+	caKey := fs.String("ca-key", "", "Issuing CA private key path (required)")
 	out := fs.String("out", "certs/", "Output directory")
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fmt.Fprintf(os.Stderr, "error: parse issue flags: %v\n", err)
@@ -115,7 +195,7 @@ func cmdIssue() {
 		os.Exit(1)
 	}
 
-	ca, key, err := pki.LoadCA(*caCert, *caKey)
+	ca, key, err := pki.LoadCAWithPassphrase(*caCert, *caKey, pki.PromptPassphrase)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
@@ -126,12 +206,20 @@ func cmdIssue() {
 		sans = strings.Split(*san, ",")
 	}
 
-	certPath, keyPath, err := pki.IssueCert(ca, key, ct, *cn, sans, *out)
+	var ous []string
+	if *ou != "" {
+		ous = strings.Split(*ou, ",")
+	}
+
+	certPath, keyPath, err := pki.IssueCertOU(ca, key, ct, *cn, ous, sans, *out)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 	fmt.Printf("Certificate: %s\n", certPath)
+	if !pki.IsRootCA(ca) {
+		fmt.Printf("Full chain:  %s\n", pki.FullchainPath(certPath))
+	}
 	fmt.Printf("Private key: %s\n", keyPath)
 }
 
@@ -151,7 +239,7 @@ func cmdCrossSign() {
 		os.Exit(1)
 	}
 
-	sCA, sKey, err := pki.LoadCA(*signerCA, *signerKey)
+	sCA, sKey, err := pki.LoadCAWithPassphrase(*signerCA, *signerKey, pki.PromptPassphrase)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error loading signer CA: %v\n", err)
 		os.Exit(1)
@@ -216,9 +304,62 @@ func cmdJWTKeygen() {
 	fmt.Printf("Private key: %s\n", privPath)
 }
 
+func cmdJWTMint() {
+	fs := flag.NewFlagSet("jwt-mint", flag.ExitOnError)
+	keyPath := fs.String("key", "", "Ed25519 JWT signing key, or a PKCS#11 URI (required)")
+	issuer := fs.String("issuer", "", "JWT issuer claim (required)")
+	audience := fs.String("audience", "bridge", "JWT audience claim")
+	subject := fs.String("subject", "", "JWT subject claim (default: the issuer)")
+	project := fs.String("project", "", "Project ID claim")
+	scopes := fs.String("scopes", "", "Comma-separated scopes, e.g. events:read (default: unrestricted)")
+	session := fs.String("session", "", "Session ID claim; limits the token to that session")
+	ttl := fs.Duration("ttl", 5*time.Minute, "Token lifetime")
+	kid := fs.String("kid", "", "Key ID header (default: the key's JWK thumbprint)")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: parse jwt-mint flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *keyPath == "" || *issuer == "" {
+		fmt.Fprintln(os.Stderr, "error: --key and --issuer are required")
+		os.Exit(1)
+	}
+	if *ttl <= 0 {
+		fmt.Fprintln(os.Stderr, "error: --ttl must be positive")
+		os.Exit(1)
+	}
+
+	key, err := pki.LoadJWTSigner(*keyPath, pki.PromptPassphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	iss := &auth.JWTIssuer{
+		Issuer:    *issuer,
+		Audience:  *audience,
+		Key:       key,
+		TTL:       *ttl,
+		KeyID:     *kid,
+		SessionID: *session,
+	}
+	if *scopes != "" {
+		iss.Scopes = strings.Split(*scopes, ",")
+	}
+	sub := *subject
+	if sub == "" {
+		sub = *issuer
+	}
+	tok, err := iss.Mint(sub, *project)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(tok)
+}
+
 func cmdVerify() {
 	fs := flag.NewFlagSet("verify", flag.ExitOnError)
-	certPath := fs.String("cert", "", "Certificate to verify (required)")
+	certPath := fs.String("cert", "", "Certificate to verify, optionally followed by its intermediates (required)")
 	bundlePath := fs.String("bundle", "", "Trust bundle (required)")
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fmt.Fprintf(os.Stderr, "error: parse verify flags: %v\n", err)
@@ -230,7 +371,7 @@ func cmdVerify() {
 		os.Exit(1)
 	}
 
-	cert, err := pki.LoadCert(*certPath)
+	chain, err := pki.LoadCertChain(*certPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error loading cert: %v\n", err)
 		os.Exit(1)
@@ -248,10 +389,528 @@ func cmdVerify() {
 		os.Exit(1)
 	}
 
-	_, err = cert.Verify(pki.VerifyOpts(pool))
+	opts := pki.VerifyOpts(pool)
+	opts.Intermediates = x509.NewCertPool()
+	for _, c := range chain[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	_, err = chain[0].Verify(opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "FAIL: %v\n", err)
 		os.Exit(1)
 	}
 	fmt.Printf("OK: %s verified against bundle\n", *certPath)
 }
+
+// Exit codes of inspect, for cron jobs and CI checks.
+const (
+	exitExpiring = 2 // a certificate expires within --warn-days
+	exitExpired  = 3 // a certificate has expired
+)
+
+// inspectedCert is one certificate as inspect reports it.
+type inspectedCert struct {
+	File        string    `json:"file"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	Serial      string    `json:"serial"`
+	IsCA        bool      `json:"is_ca"`
+	DNSNames    []string  `json:"dns_names,omitempty"`
+	IPAddresses []string  `json:"ip_addresses,omitempty"`
+	URIs        []string  `json:"uris,omitempty"`
+	KeyUsage    []string  `json:"key_usage,omitempty"`
+	ExtKeyUsage []string  `json:"ext_key_usage,omitempty"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	DaysLeft    int       `json:"days_left"`
+
+	commonName, issuerName string
+}
+
+var keyUsageNames = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "digitalSignature"},
+	{x509.KeyUsageContentCommitment, "contentCommitment"},
+	{x509.KeyUsageKeyEncipherment, "keyEncipherment"},
+	{x509.KeyUsageDataEncipherment, "dataEncipherment"},
+	{x509.KeyUsageKeyAgreement, "keyAgreement"},
+	{x509.KeyUsageCertSign, "certSign"},
+	{x509.KeyUsageCRLSign, "crlSign"},
+}
+
+var extKeyUsageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:             "any",
+	x509.ExtKeyUsageServerAuth:      "serverAuth",
+	x509.ExtKeyUsageClientAuth:      "clientAuth",
+	x509.ExtKeyUsageCodeSigning:     "codeSigning",
+	x509.ExtKeyUsageEmailProtection: "emailProtection",
+	x509.ExtKeyUsageTimeStamping:    "timeStamping",
+	x509.ExtKeyUsageOCSPSigning:     "ocspSigning",
+}
+
+func newInspectedCert(file string, cert *x509.Certificate, now time.Time) inspectedCert {
+	c := inspectedCert{
+		File:       file,
+		Subject:    cert.Subject.String(),
+		Issuer:     cert.Issuer.String(),
+		Serial:     cert.SerialNumber.String(),
+		IsCA:       cert.IsCA,
+		DNSNames:   cert.DNSNames,
+		NotBefore:  cert.NotBefore,
+		NotAfter:   cert.NotAfter,
+		DaysLeft:   int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24)),
+		commonName: cert.Subject.CommonName,
+		issuerName: cert.Issuer.CommonName,
+	}
+	for _, ip := range cert.IPAddresses {
+		c.IPAddresses = append(c.IPAddresses, ip.String())
+	}
+	for _, u := range cert.URIs {
+		c.URIs = append(c.URIs, u.String())
+	}
+	for _, ku := range keyUsageNames {
+		if cert.KeyUsage&ku.usage != 0 {
+			c.KeyUsage = append(c.KeyUsage, ku.name)
+		}
+	}
+	for _, eku := range cert.ExtKeyUsage {
+		name, ok := extKeyUsageNames[eku]
+		if !ok {
+			name = fmt.Sprintf("unknown(%d)", eku)
+		}
+		c.ExtKeyUsage = append(c.ExtKeyUsage, name)
+	}
+	if c.commonName == "" {
+		c.commonName = c.Subject
+	}
+	if c.issuerName == "" {
+		c.issuerName = c.Issuer
+	}
+	return c
+}
+
+// inspectFiles lists the certificate files at path: the file itself, or
+// the .crt, .pem and .cert files in a directory.
+func inspectFiles(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		switch filepath.Ext(e.Name()) {
+		case ".crt", ".pem", ".cert":
+			if e.Type().IsRegular() {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+func cmdInspect() {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print JSON instead of a table")
+	warnDays := fs.Int("warn-days", 0, fmt.Sprintf("Exit %d when a certificate expires within this many days", exitExpiring))
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: ai-agent-bridge-ca inspect [flags] <cert|bundle|dir>...
+
+Shows every certificate in the given files, or in the .crt, .pem and .cert
+files of the given directories. Exits %d when a certificate has expired and,
+with --warn-days, %d when one expires within that many days.
+
+Flags:
+`, exitExpired, exitExpiring)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: parse inspect flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "error: a certificate, bundle, or directory is required")
+		os.Exit(1)
+	}
+	if *warnDays < 0 {
+		fmt.Fprintln(os.Stderr, "error: --warn-days must not be negative")
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	certs := []inspectedCert{}
+	for _, arg := range fs.Args() {
+		files, err := inspectFiles(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, file := range files {
+			chain, err := pki.LoadCertChain(file)
+			if err != nil {
+				if file != arg {
+					continue // keys and other PEM files in a directory
+				}
+				fmt.Fprintf(os.Stderr, "error: %s: %v\n", file, err)
+				os.Exit(1)
+			}
+			for _, cert := range chain {
+				certs = append(certs, newInspectedCert(file, cert, now))
+			}
+		}
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(certs); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		fmt.Printf("%5s  %-10s  %-24s  %-24s  %-21s  %-24s  %s\n", "DAYS", "EXPIRES", "SUBJECT", "ISSUER", "USAGE", "SANS", "FILE")
+		for _, c := range certs {
+			usage := strings.Join(c.ExtKeyUsage, ",")
+			if c.IsCA {
+				usage = "ca"
+			} else if usage == "" {
+				usage = "-"
+			}
+			sans := strings.Join(append(append(append([]string{}, c.DNSNames...), c.IPAddresses...), c.URIs...), ",")
+			if sans == "" {
+				sans = "-"
+			}
+			fmt.Printf("%5d  %-10s  %-24s  %-24s  %-21s  %-24s  %s\n", c.DaysLeft, c.NotAfter.Format(time.DateOnly), c.commonName, c.issuerName, usage, sans, c.File)
+		}
+	}
+
+	code := 0
+	for _, c := range certs {
+		switch {
+		case !now.Before(c.NotAfter):
+			code = exitExpired
+		case *warnDays > 0 && c.DaysLeft < *warnDays && code == 0:
+			code = exitExpiring
+		}
+	}
+	os.Exit(code)
+}
+
+// crlReasons maps --reason values to RFC 5280 CRLReason codes.
+var crlReasons = map[string]int{
+	"unspecified":            0,
+	"key-compromise":         1,
+	"ca-compromise":          2,
+	"affiliation-changed":    3,
+	"superseded":             4,
+	"cessation-of-operation": 5,
+}
+
+func cmdRevoke() {
+	fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+	caCert := fs.String("ca", "", "CA certificate path (required)")
+	caKey := fs.String("ca-key", "", "CA private key path (required)")
+	crlPath := fs.String("crl", "certs/ca.crl", "CRL file to create or update")
+	certPath := fs.String("cert", "", "Certificate to revoke")
+	serialFlag := fs.String("serial", "", "Serial number to revoke (decimal, or hex with 0x prefix); alternative to --cert")
+	reason := fs.String("reason", "unspecified", "Revocation reason: unspecified, key-compromise, ca-compromise, affiliation-changed, superseded, cessation-of-operation")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: parse revoke flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *caCert == "" || *caKey == "" || (*certPath == "") == (*serialFlag == "") {
+		fmt.Fprintln(os.Stderr, "error: --ca, --ca-key, and exactly one of --cert or --serial are required")
+		os.Exit(1)
+	}
+	code, ok := crlReasons[*reason]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: unknown --reason %q\n", *reason)
+		os.Exit(1)
+	}
+
+	ca, key, err := pki.LoadCAWithPassphrase(*caCert, *caKey, pki.PromptPassphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var serial *big.Int
+	if *certPath != "" {
+		cert, err := pki.LoadCert(*certPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error loading cert: %v\n", err)
+			os.Exit(1)
+		}
+		if err := cert.CheckSignatureFrom(ca); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s was not issued by this CA: %v\n", *certPath, err)
+			os.Exit(1)
+		}
+		serial = cert.SerialNumber
+	} else {
+		var ok bool
+		serial, ok = new(big.Int).SetString(*serialFlag, 0)
+		if !ok || serial.Sign() <= 0 {
+			fmt.Fprintf(os.Stderr, "error: invalid --serial %q\n", *serialFlag)
+			os.Exit(1)
+		}
+	}
+
+	if err := pki.RevokeCert(ca, key, *crlPath, serial, code); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Revoked serial %s\n", serial)
+	fmt.Printf("CRL: %s\n", *crlPath)
+}
+
+func cmdEncryptKey() {
+	fs := flag.NewFlagSet("encrypt-key", flag.ExitOnError)
+	keyPath := fs.String("key", "", "Private key to encrypt in place (required)")
+	passFile := fs.String("passphrase-file", "", "File holding the new passphrase (default: BRIDGE_KEY_PASSPHRASE, BRIDGE_KEY_PASSPHRASE_FILE, or a prompt)")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: parse encrypt-key flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *keyPath == "" {
+		fmt.Fprintln(os.Stderr, "error: --key is required")
+		os.Exit(1)
+	}
+
+	// An encrypted key is re-encrypted, e.g. to change its passphrase.
+	key, err := pki.LoadPrivateKey(*keyPath, pki.PromptPassphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	passphrase, err := newPassphrase(*passFile, *keyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(passphrase) == 0 {
+		fmt.Fprintln(os.Stderr, "error: empty passphrase")
+		os.Exit(1)
+	}
+	if err := pki.WritePrivateKey(*keyPath, key, passphrase); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Encrypted private key: %s\n", *keyPath)
+}
+
+func cmdEnrollToken() {
+	fs := flag.NewFlagSet("enroll-token", flag.ExitOnError)
+	cn := fs.String("cn", "", "Common name of the client certificate (required)")
+	ou := fs.String("ou", "", "Organizational units (comma-separated), e.g. the daemon's auth.admin_cert_ou")
+	ttl := fs.Duration("ttl", 24*time.Hour, "How long the token can be redeemed")
+	tokens := fs.String("tokens", "certs/enroll-tokens", "Token directory shared with serve")
+	caCert := fs.String("ca", "", "CA certificate whose fingerprint enrolling hosts pin (optional)")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: parse enroll-token flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *cn == "" {
+		fmt.Fprintln(os.Stderr, "error: --cn is required")
+		os.Exit(1)
+	}
+	if *ttl <= 0 {
+		fmt.Fprintln(os.Stderr, "error: --ttl must be positive")
+		os.Exit(1)
+	}
+
+	tok := enroll.Token{CommonName: *cn, ExpiresAt: time.Now().Add(*ttl).UTC()}
+	if *ou != "" {
+		tok.OrganizationalUnits = strings.Split(*ou, ",")
+	}
+	token, err := enroll.CreateToken(*tokens, tok)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Token:   %s\n", token)
+	fmt.Printf("Expires: %s\n", tok.ExpiresAt.Format(time.RFC3339))
+	if *caCert != "" {
+		ca, err := pki.LoadCert(*caCert)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error loading CA: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("CA fingerprint: %s\n", enroll.Fingerprint(ca))
+	}
+}
+
+func cmdServe() {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", ":8443", "Address to listen on")
+	caCert := fs.String("ca", "", "Issuing CA certificate path (required)")
+	caKey := fs.String("ca-key", "", "Issuing CA private key path (required)")
+	certPath := fs.String("cert", "", "TLS certificate of this server, e.g. a fullchain file (required)")
+	keyPath := fs.String("key", "", "TLS private key of this server (required)")
+	bundlePath := fs.String("bundle", "", "Trust bundle handed to enrolled clients (required)")
+	tokens := fs.String("tokens", "certs/enroll-tokens", "Token directory shared with enroll-token")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: parse serve flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *caCert == "" || *caKey == "" || *certPath == "" || *keyPath == "" || *bundlePath == "" {
+		fmt.Fprintln(os.Stderr, "error: --ca, --ca-key, --cert, --key, and --bundle are required")
+		os.Exit(1)
+	}
+
+	ca, key, err := pki.LoadCAWithPassphrase(*caCert, *caKey, pki.PromptPassphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	pair, err := pki.LoadX509KeyPair(*certPath, *keyPath, pki.PromptPassphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading TLS certificate: %v\n", err)
+		os.Exit(1)
+	}
+	bundle, err := os.ReadFile(*bundlePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading bundle: %v\n", err)
+		os.Exit(1)
+	}
+	if pki.NewCertPoolFromPEM(bundle) == nil {
+		fmt.Fprintln(os.Stderr, "error: failed to parse trust bundle")
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	s := &enroll.Server{CACert: ca, CAKey: key, Bundle: bundle, TokenDir: *tokens, Logger: logger}
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           s.Handler(),
+		TLSConfig:         &tls.Config{Certificates: []tls.Certificate{pair}, MinVersion: tls.VersionTLS12},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	logger.Info("enrollment server listening", "addr", *listen, "ca", ca.Subject.CommonName)
+	if err := srv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func cmdEnroll() {
+	fs := flag.NewFlagSet("enroll", flag.ExitOnError)
+	server := fs.String("server", "", "Enrollment server URL, e.g. https://ca.example.com:8443 (required)")
+	token := fs.String("token", "", "Enrollment token (required)")
+	fingerprint := fs.String("ca-fingerprint", "", "SHA-256 fingerprint of the CA to trust the server's bundle by")
+	bundlePath := fs.String("bundle", "", "Trust bundle to verify the server with, instead of --ca-fingerprint")
+	out := fs.String("out", "certs/", "Output directory")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: parse enroll flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *server == "" || *token == "" || (*fingerprint == "") == (*bundlePath == "") {
+		fmt.Fprintln(os.Stderr, "error: --server, --token, and exactly one of --ca-fingerprint or --bundle are required")
+		os.Exit(1)
+	}
+
+	c := &enroll.Client{URL: *server, CAFingerprint: *fingerprint}
+	if *bundlePath != "" {
+		var err error
+		if c.Bundle, err = os.ReadFile(*bundlePath); err != nil {
+			fmt.Fprintf(os.Stderr, "error reading bundle: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: generate key: %v\n", err)
+		os.Exit(1)
+	}
+	resp, err := c.Enroll(context.Background(), *token, key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	block, _ := pem.Decode([]byte(resp.Certificate)) // checked by Enroll
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	baseName := strings.ReplaceAll(cert.Subject.CommonName, " ", "-")
+	keyPath := filepath.Join(*out, baseName+".key")
+	certPath := filepath.Join(*out, baseName+".crt")
+	bundleOut := filepath.Join(*out, "ca-bundle.crt")
+	if err := pki.WritePrivateKey(keyPath, key, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	for path, data := range map[string]string{certPath: resp.Certificate, bundleOut: resp.CABundle} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("Certificate: %s\n", certPath)
+	fmt.Printf("Private key: %s\n", keyPath)
+	fmt.Printf("Trust bundle: %s\n", bundleOut)
+}
+
+// newPassphrase returns the passphrase to encrypt keyPath with: the
+// contents of file, the passphrase from the environment, or one typed
+// twice on the terminal.
+func newPassphrase(file, keyPath string) ([]byte, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read passphrase file: %w", err)
+		}
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+	p, err := pki.EnvPassphrase(keyPath)
+	if !errors.Is(err, pki.ErrPassphraseRequired) {
+		return p, err
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("no passphrase: use --passphrase-file or set %s", pki.PassphraseEnv)
+	}
+	fmt.Fprintf(os.Stderr, "New passphrase for %s: ", keyPath)
+	p, err = term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("read passphrase: %w", err)
+	}
+	fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+	again, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("read passphrase: %w", err)
+	}
+	if !bytes.Equal(p, again) {
+		return nil, errors.New("passphrases do not match")
+	}
+	return p, nil
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/spf13/cobra"
+)
+
+func newAdminCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Operator actions on the server (AdminService)",
+		Long: `Call AdminService, which is separate from the session API and needs an
+admin caller. Against the local server bridgectl mints tokens with the
+"admin" scope itself; against a --target, use a JWT key whose tokens the
+daemon accepts, or a client certificate with the daemon's
+auth.admin_cert_ou.`,
+	}
+	cmd.AddCommand(
+		newAdminStopSessionCmd(),
+		newAdminDrainCmd(),
+		newAdminReloadCmd(),
+		newAdminMetricsCmd(),
+		newAdminRevokeTokenCmd(),
+		newAdminRegisterProviderCmd(),
+		newAdminUnregisterProviderCmd(),
+		newAdminLogLevelCmd(),
+		newAdminPurgeSessionCmd(),
+	)
+	return cmd
+}
+
+// runAdmin calls fn with an AdminService client and a 10 second deadline.
+func runAdmin(fn func(ctx context.Context, admin bridgev1.AdminServiceClient) error) error {
+	client, err := connectAdminClient(10 * time.Second)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return fn(ctx, client.Admin())
+}
+
+func newAdminStopSessionCmd() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "stop-session <session-id>",
+		Short: "Stop any session, whatever its project",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdmin(func(ctx context.Context, admin bridgev1.AdminServiceClient) error {
+				resp, err := admin.StopSession(ctx, &bridgev1.AdminStopSessionRequest{SessionId: args[0], Force: force})
+				if err != nil {
+					return fmt.Errorf("stop session: %w", err)
+				}
+				fmt.Printf("Stopping session %s (project %s)\n", args[0], resp.ProjectId)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "kill the agent instead of asking it to exit")
+	return cmd
+}
+
+func newAdminPurgeSessionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "purge-session <session-id>",
+		Short: "Delete everything the server keeps about a stopped session",
+		Long: `Delete a stopped session's buffered output, archived transcript,
+schedule transcripts, persisted record, provider logs and workspace, e.g.
+for a deletion request. A running session must be stopped first. The RPC audit records
+naming the session are kept until their retention period ends.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdmin(func(ctx context.Context, admin bridgev1.AdminServiceClient) error {
+				resp, err := admin.PurgeSession(ctx, &bridgev1.PurgeSessionRequest{SessionId: args[0]})
+				if err != nil {
+					return fmt.Errorf("purge session: %w", err)
+				}
+				fmt.Printf("Session %s (project %s) purged.\n", args[0], resp.ProjectId)
+				return nil
+			})
+		},
+	}
+}
+
+func newAdminDrainCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "drain",
+		Short: "Mark the server not ready and refuse new sessions until restart",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdmin(func(ctx context.Context, admin bridgev1.AdminServiceClient) error {
+				resp, err := admin.Drain(ctx, &bridgev1.DrainRequest{})
+				if err != nil {
+					return fmt.Errorf("drain: %w", err)
+				}
+				if resp.AlreadyDraining {
+					fmt.Println("Server was already draining.")
+				} else {
+					fmt.Println("Server is draining.")
+				}
+				return nil
+			})
+		},
+	}
+}
+
+func newAdminReloadCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reload",
+		Short: "Re-read the server's config file, as SIGHUP does",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdmin(func(ctx context.Context, admin bridgev1.AdminServiceClient) error {
+				if _, err := admin.ReloadConfig(ctx, &bridgev1.ReloadConfigRequest{}); err != nil {
+					return fmt.Errorf("reload: %w", err)
+				}
+				fmt.Println("Configuration reloaded.")
+				return nil
+			})
+		},
+	}
+}
+
+func newAdminMetricsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "metrics",
+		Short: "Print a snapshot of the server's counters",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdmin(func(ctx context.Context, admin bridgev1.AdminServiceClient) error {
+				resp, err := admin.GetMetrics(ctx, &bridgev1.GetMetricsRequest{})
+				if err != nil {
+					return fmt.Errorf("metrics: %w", err)
+				}
+				fmt.Printf("Instance:  %s\n", resp.ServerInstanceId)
+				fmt.Printf("Started:   %s\n", resp.StartedAt.AsTime().Local().Format(time.RFC3339))
+				fmt.Printf("Draining:  %t\n", resp.Draining)
+				fmt.Printf("Revoked:   %d tokens\n", resp.RevokedTokens)
+				printCounts("Sessions by status:", resp.SessionsByStatus)
+				printCounts("Live sessions by project:", resp.SessionsByProject)
+				if len(resp.RedactionHits) > 0 {
+					fmt.Println("Redaction hits:")
+					for _, k := range slices.Sorted(maps.Keys(resp.RedactionHits)) {
+						fmt.Printf("  %-24s %d\n", k, resp.RedactionHits[k])
+					}
+				}
+				if len(resp.RateLimiters) > 0 {
+					fmt.Println("Rate limiters:")
+					fmt.Printf("  %-16s %-14s %8s %6s %8s %8s %8s\n", "PROJECT", "LIMITER", "RATE", "BURST", "BUCKETS", "REJECTED", "BATCH")
+					for _, rl := range resp.RateLimiters {
+						project := rl.ProjectId
+						if project == "" {
+							project = "*"
+						}
+						fmt.Printf("  %-16s %-14s %8g %6d %8d %8d %8d\n", project, rl.Name, rl.Rate, rl.Burst, rl.ActiveBuckets, rl.Rejected, rl.RejectedBatch)
+					}
+				}
+				if len(resp.ProviderReadiness) > 0 {
+					fmt.Println("Provider readiness:")
+					fmt.Printf("  %-16s %8s %9s %9s %9s\n", "PROVIDER", "READY", "TIMED OUT", "MEAN", "MAX")
+					for _, pr := range resp.ProviderReadiness {
+						mean := time.Duration(pr.MeanReadyMs) * time.Millisecond
+						longest := time.Duration(pr.MaxReadyMs) * time.Millisecond
+						fmt.Printf("  %-16s %8d %9d %9s %9s\n", pr.Provider, pr.Ready, pr.TimedOut, mean, longest)
+					}
+				}
+				return nil
+			})
+		},
+	}
+}
+
+func printCounts(title string, counts map[string]uint32) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Println(title)
+	for _, k := range slices.Sorted(maps.Keys(counts)) {
+		fmt.Printf("  %-24s %d\n", k, counts[k])
+	}
+}
+
+func newAdminRevokeTokenCmd() *cobra.Command {
+	var id, subject, reason string
+	cmd := &cobra.Command{
+		Use:   "revoke-token",
+		Short: "Reject a token by ID, or every token of a subject issued so far",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if id == "" && subject == "" {
+				return fmt.Errorf("--id or --subject is required")
+			}
+			return runAdmin(func(ctx context.Context, admin bridgev1.AdminServiceClient) error {
+				_, err := admin.RevokeToken(ctx, &bridgev1.RevokeTokenRequest{TokenId: id, Subject: subject, Reason: reason})
+				if err != nil {
+					return fmt.Errorf("revoke token: %w", err)
+				}
+				fmt.Println("Token revoked.")
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&id, "id", "", "token ID (jti claim)")
+	cmd.Flags().StringVar(&subject, "subject", "", "revoke every token of this subject issued up to now")
+	cmd.Flags().StringVar(&reason, "reason", "", "reason recorded in the audit log")
+	return cmd
+}
+
+func newAdminRegisterProviderCmd() *cobra.Command {
+	var (
+		req     bridgev1.RegisterProviderRequest
+		timeout time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "register-provider <provider-id>",
+		Short: "Add a provider at runtime; it is kept across restarts",
+		Long: `Add a stdio provider without editing the config file. The definition is
+kept in providers.yaml in the server's state directory and registered again
+after a restart. Providers defined in the config file cannot be replaced,
+and sessions already running keep the provider they started with.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req.ProviderId = args[0]
+			req.StartupTimeoutMs = uint32(timeout.Milliseconds())
+			return runAdmin(func(ctx context.Context, admin bridgev1.AdminServiceClient) error {
+				resp, err := admin.RegisterProvider(ctx, &req)
+				if err != nil {
+					return fmt.Errorf("register provider: %w", err)
+				}
+				if resp.Replaced {
+					fmt.Printf("Provider %s replaced.\n", args[0])
+				} else {
+					fmt.Printf("Provider %s registered.\n", args[0])
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&req.Binary, "binary", "", "agent executable (required)")
+	cmd.Flags().StringArrayVar(&req.Args, "arg", nil, "argument passed to the agent (repeatable)")
+	cmd.Flags().BoolVar(&req.StreamJson, "stream-json", false, "run the agent over pipes with stream-JSON output instead of a PTY")
+	cmd.Flags().StringVar(&req.JsonFormat, "json-format", "", "stream-JSON dialect: claude or opencode")
+	cmd.Flags().StringVar(&req.PromptPattern, "prompt-pattern", "", "regex matching the agent's prompt in PTY output")
+	cmd.Flags().StringVar(&req.StartupProbe, "startup-probe", "", "prompt, output or none")
+	cmd.Flags().DurationVar(&timeout, "startup-timeout", 0, "how long the agent may take to start (default 60s)")
+	cmd.Flags().StringArrayVar(&req.RequiredEnv, "require-env", nil, "environment variable the agent needs (repeatable)")
+	cmd.Flags().BoolVar(&req.Replace, "replace", false, "overwrite an earlier registration with the same ID")
+	_ = cmd.MarkFlagRequired("binary")
+	return cmd
+}
+
+func newAdminUnregisterProviderCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unregister-provider <provider-id>",
+		Short: "Remove a provider added with register-provider",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdmin(func(ctx context.Context, admin bridgev1.AdminServiceClient) error {
+				if _, err := admin.UnregisterProvider(ctx, &bridgev1.UnregisterProviderRequest{ProviderId: args[0]}); err != nil {
+					return fmt.Errorf("unregister provider: %w", err)
+				}
+				fmt.Printf("Provider %s removed.\n", args[0])
+				return nil
+			})
+		},
+	}
+}
+
+func newAdminLogLevelCmd() *cobra.Command {
+	var component string
+	cmd := &cobra.Command{
+		Use:   "log-level [debug|info|warn|error]",
+		Short: "Show or change the server's log levels",
+		Long: `With no argument, print the default log level and the level of each
+component. With a level, set the default level, or with --component the
+level of one component (auth, supervisor, provider or server).
+
+The change lasts until the server restarts or reloads its config file,
+which sets the levels in logging.level and logging.components again.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &bridgev1.SetLogLevelRequest{Component: component}
+			if len(args) == 1 {
+				req.Level = args[0]
+			} else if component != "" {
+				return fmt.Errorf("--component needs a level")
+			}
+			return runAdmin(func(ctx context.Context, admin bridgev1.AdminServiceClient) error {
+				resp, err := admin.SetLogLevel(ctx, req)
+				if err != nil {
+					return fmt.Errorf("set log level: %w", err)
+				}
+				fmt.Printf("%-12s %s\n", "default", resp.Levels[""])
+				for _, c := range slices.Sorted(maps.Keys(resp.Levels)) {
+					if c != "" {
+						fmt.Printf("%-12s %s\n", c, resp.Levels[c])
+					}
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&component, "component", "", "component whose level is set: auth, supervisor, provider or server")
+	return cmd
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/markcallen/ai-agent-bridge/internal/localserver"
+)
+
+// remoteConfig describes how to reach a bridge daemon that was not started
+// by this bridgectl (for example a systemd-managed daemon on another host).
+// Operators keep it in a YAML file so the mTLS/JWT flags do not have to be
+// repeated on every command:
+//
+//	target: "bridge.internal:9445"
+//	ca_bundle: /etc/ai-agent-bridge/certs/ca-bundle.crt
+//	cert: /etc/ai-agent-bridge/certs/ops.crt
+//	key: /etc/ai-agent-bridge/certs/ops.key
+//	server_name: bridge.internal
+//	jwt_key: /etc/ai-agent-bridge/certs/jwt-signing.key
+//	jwt_issuer: ops
+//	jwt_audience: bridge
+type remoteConfig struct {
+	Target      string `yaml:"target"`
+	CABundle    string `yaml:"ca_bundle"`
+	Cert        string `yaml:"cert"`
+	Key         string `yaml:"key"`
+	ServerName  string `yaml:"server_name"`
+	JWTKey      string `yaml:"jwt_key"`
+	JWTIssuer   string `yaml:"jwt_issuer"`
+	JWTAudience string `yaml:"jwt_audience"`
+	// scopes are minted into JWTs. Commands set them; they are not read
+	// from the config file.
+	scopes []string
+}
+
+// remote holds the effective remote connection settings after merging the
+// config file with command-line flags. connectClient uses it instead of
+// local server discovery when remote.Target is set.
+var remote remoteConfig
+
+// defaultRemoteConfigPath is read when --client-config is not given.
+func defaultRemoteConfigPath() string {
+	return filepath.Join(localserver.StateDir(), "bridgectl.yaml")
+}
+
+// loadRemoteConfig reads a remoteConfig from path. A missing file is not an
+// error when the path was not explicitly requested.
+func loadRemoteConfig(path string, explicit bool) (remoteConfig, error) {
+	var cfg remoteConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("read bridgectl config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse bridgectl config %q: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// addRemoteFlags registers the shared connection flags on root and resolves
+// them against the config file before any subcommand runs. Flags take
+// precedence over file values.
+func addRemoteFlags(root *cobra.Command) {
+	var (
+		configPath string
+		flags      remoteConfig
+	)
+	pf := root.PersistentFlags()
+	pf.StringVar(&configPath, "client-config", "", "bridgectl connection config file (default ~/.ai-agent-bridge/bridgectl.yaml)")
+	pf.StringVar(&flags.Target, "target", "", "remote daemon address (host:port); skips local server discovery")
+	pf.StringVar(&flags.CABundle, "ca-bundle", "", "CA bundle used to verify the remote daemon")
+	pf.StringVar(&flags.Cert, "cert", "", "client certificate for mTLS")
+	pf.StringVar(&flags.Key, "key", "", "client private key for mTLS")
+	pf.StringVar(&flags.ServerName, "server-name", "", "expected server name in the daemon certificate")
+	pf.StringVar(&flags.JWTKey, "jwt-key", "", "Ed25519 private key used to mint JWTs")
+	pf.StringVar(&flags.JWTIssuer, "jwt-issuer", "", "JWT issuer claim")
+	pf.StringVar(&flags.JWTAudience, "jwt-audience", "", "JWT audience claim (default bridge)")
+
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		path, explicit := configPath, configPath != ""
+		if !explicit {
+			path = defaultRemoteConfigPath()
+		}
+		cfg, err := loadRemoteConfig(path, explicit)
+		if err != nil {
+			return err
+		}
+		remote = mergeRemoteConfig(cfg, flags)
+		return nil
+	}
+}
+
+// mergeRemoteConfig overlays non-empty fields of override onto base.
+func mergeRemoteConfig(base, override remoteConfig) remoteConfig {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&base.Target, override.Target)
+	set(&base.CABundle, override.CABundle)
+	set(&base.Cert, override.Cert)
+	set(&base.Key, override.Key)
+	set(&base.ServerName, override.ServerName)
+	set(&base.JWTKey, override.JWTKey)
+	set(&base.JWTIssuer, override.JWTIssuer)
+	set(&base.JWTAudience, override.JWTAudience)
+	return base
+}
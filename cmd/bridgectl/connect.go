@@ -4,15 +4,21 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
 	"github.com/markcallen/ai-agent-bridge/internal/localserver"
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
 	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
 )
 
 // connectClient discovers the local server and returns a connected
 // bridgeclient.Client. It auto-detects whether the server is running in
 // local (insecure) or secure (mTLS+JWT) mode and configures credentials
-// accordingly.
+// accordingly. When a remote target is configured (--target or the
+// bridgectl config file) it dials that instead.
 func connectClient(stateDir string, timeout time.Duration) (*bridgeclient.Client, error) {
+	if remote.Target != "" {
+		return dialRemote(remote, timeout)
+	}
 	if stateDir == "" {
 		stateDir = localserver.StateDir()
 	}
@@ -22,11 +28,29 @@ func connectClient(stateDir string, timeout time.Duration) (*bridgeclient.Client
 		return nil, fmt.Errorf("no ai-agent-bridge server running")
 	}
 
-	return dialClient(target, mode, stateDir, timeout)
+	return dialClient(target, mode, stateDir, timeout, nil)
+}
+
+// connectAdminClient is connectClient for AdminService calls: minted JWTs
+// carry the admin scope. In local mode no token is needed.
+func connectAdminClient(timeout time.Duration) (*bridgeclient.Client, error) {
+	scopes := []string{auth.ScopeAdmin}
+	if remote.Target != "" {
+		rc := remote
+		rc.scopes = scopes
+		return dialRemote(rc, timeout)
+	}
+	stateDir := localserver.StateDir()
+	target, mode := localserver.DiscoverTarget(stateDir)
+	if target == "" {
+		return nil, fmt.Errorf("no ai-agent-bridge server running")
+	}
+	return dialClient(target, mode, stateDir, timeout, scopes)
 }
 
-// dialClient creates a bridgeclient for the given target and mode.
-func dialClient(target string, mode localserver.ServerMode, stateDir string, timeout time.Duration) (*bridgeclient.Client, error) {
+// dialClient creates a bridgeclient for the given target and mode. scopes
+// are minted into the JWTs of secure mode; nil mints unrestricted tokens.
+func dialClient(target string, mode localserver.ServerMode, stateDir string, timeout time.Duration, scopes []string) (*bridgeclient.Client, error) {
 	var opts []bridgeclient.Option
 	opts = append(opts, bridgeclient.WithTarget(target))
 	if timeout > 0 {
@@ -37,16 +61,19 @@ func dialClient(target string, mode localserver.ServerMode, stateDir string, tim
 		mat := localserver.LoadPKIMaterial(stateDir)
 		opts = append(opts,
 			bridgeclient.WithMTLS(bridgeclient.MTLSConfig{
-				CABundlePath: mat.CABundlePath,
-				CertPath:     mat.LocalClientCert,
-				KeyPath:      mat.LocalClientKey,
-				ServerName:   "server",
+				CABundlePath:  mat.CABundlePath,
+				CertPath:      mat.LocalClientCert,
+				KeyPath:       mat.LocalClientKey,
+				ServerName:    "server",
+				KeyPassphrase: pki.PromptPassphrase,
 			}),
 			bridgeclient.WithJWT(bridgeclient.JWTConfig{
 				PrivateKeyPath: mat.JWTSigningKey,
 				Issuer:         "local",
 				Audience:       "bridge",
 				TTL:            5 * time.Minute,
+				Scopes:         scopes,
+				KeyPassphrase:  pki.PromptPassphrase,
 			}),
 		)
 	}
@@ -57,3 +84,41 @@ func dialClient(target string, mode localserver.ServerMode, stateDir string, tim
 	}
 	return client, nil
 }
+
+// dialRemote creates a bridgeclient for an explicitly configured daemon.
+// mTLS is enabled when a CA bundle is given; JWT minting when a signing key
+// is given.
+func dialRemote(rc remoteConfig, timeout time.Duration) (*bridgeclient.Client, error) {
+	opts := []bridgeclient.Option{bridgeclient.WithTarget(rc.Target)}
+	if timeout > 0 {
+		opts = append(opts, bridgeclient.WithTimeout(timeout))
+	}
+	if rc.CABundle != "" {
+		opts = append(opts, bridgeclient.WithMTLS(bridgeclient.MTLSConfig{
+			CABundlePath:  rc.CABundle,
+			CertPath:      rc.Cert,
+			KeyPath:       rc.Key,
+			ServerName:    rc.ServerName,
+			KeyPassphrase: pki.PromptPassphrase,
+		}))
+	}
+	if rc.JWTKey != "" {
+		audience := rc.JWTAudience
+		if audience == "" {
+			audience = "bridge"
+		}
+		opts = append(opts, bridgeclient.WithJWT(bridgeclient.JWTConfig{
+			PrivateKeyPath: rc.JWTKey,
+			Issuer:         rc.JWTIssuer,
+			Audience:       audience,
+			TTL:            5 * time.Minute,
+			Scopes:         rc.scopes,
+			KeyPassphrase:  pki.PromptPassphrase,
+		}))
+	}
+	client, err := bridgeclient.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", rc.Target, err)
+	}
+	return client, nil
+}
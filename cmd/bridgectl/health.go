@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/spf13/cobra"
+)
+
+func newHealthCmd() *cobra.Command {
+	var liveness, watch bool
+	cmd := &cobra.Command{
+		Use:   "health",
+		Short: "Check daemon and provider health",
+		Long: `Call the Health RPC and print the daemon status, per-provider
+availability and the result of each readiness check. Exits non-zero when the
+daemon is unreachable or not serving, so it can be used from monitoring
+scripts.
+
+--liveness only checks that the daemon answers. --watch keeps the stream
+open and prints the health again whenever it changes, until interrupted.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := connectClient("", 5*time.Second)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+
+			check := bridgev1.HealthCheck_HEALTH_CHECK_READINESS
+			if liveness {
+				check = bridgev1.HealthCheck_HEALTH_CHECK_LIVENESS
+			}
+
+			if watch {
+				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				err := client.WatchHealth(ctx, check, func(resp *bridgev1.HealthResponse) error {
+					fmt.Printf("%s\n", time.Now().Format(time.RFC3339))
+					printHealth(resp)
+					return nil
+				})
+				if err != nil && !errors.Is(err, context.Canceled) {
+					return fmt.Errorf("health watch: %w", err)
+				}
+				return nil
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			resp, err := client.CheckHealth(ctx, check)
+			if err != nil {
+				return fmt.Errorf("health: %w", err)
+			}
+			printHealth(resp)
+			if resp.Status != "serving" {
+				return fmt.Errorf("server not serving (status %q)", resp.Status)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&liveness, "liveness", false, "only check that the daemon answers")
+	cmd.Flags().BoolVar(&watch, "watch", false, "print health changes until interrupted")
+	return cmd
+}
+
+func printHealth(resp *bridgev1.HealthResponse) {
+	fmt.Printf("Status:    %s\n", resp.Status)
+	fmt.Printf("Instance:  %s\n", resp.ServerInstanceId)
+	providers := resp.Providers
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Provider < providers[j].Provider })
+	for _, p := range providers {
+		if p.Available {
+			fmt.Printf("  %-16s ok\n", p.Provider)
+		} else {
+			fmt.Printf("  %-16s unavailable: %s\n", p.Provider, p.Error)
+		}
+	}
+	if len(resp.Checks) > 0 {
+		fmt.Printf("Checks:\n")
+	}
+	for _, c := range resp.Checks {
+		if c.Ok {
+			fmt.Printf("  %-16s ok\n", c.Name)
+		} else {
+			fmt.Printf("  %-16s failed: %s\n", c.Name, c.Error)
+		}
+	}
+}
@@ -22,10 +22,18 @@ across terminal windows.`,
 		Version:       version,
 	}
 
+	addRemoteFlags(root)
+
 	root.AddCommand(
 		newRunCmd(),
 		newSessionCmd(),
 		newServerCmd(),
+		newProvidersCmd(),
+		newHealthCmd(),
+		newReplayAgentCmd(),
+		newAdminCmd(),
+		newMCPCmd(),
+		newTopCmd(),
 	)
 
 	if err := root.Execute(); err != nil {
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/mcp"
+	"github.com/spf13/cobra"
+)
+
+func newMCPCmd() *cobra.Command {
+	var (
+		project string
+		timeout time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve the bridge to MCP clients over stdio",
+		Long: `mcp runs a Model Context Protocol server on stdin and stdout, so MCP
+clients such as Claude Desktop and IDEs can drive the bridge. Sessions of
+--project are listed as resources (bridge://sessions/<id> and its
+/transcript), and the start_session, send_input and stop_session tools
+manage them.
+
+Register it with the client as a stdio server, e.g. in Claude Desktop's
+claude_desktop_config.json:
+
+  {"mcpServers": {"bridge": {"command": "bridgectl", "args": ["mcp"]}}}
+
+Against the local server, one is started if none is running. Use
+--target and the usual client flags to serve a remote daemon.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ensureServer(); err != nil {
+				return err
+			}
+			client, err := connectClient("", timeout)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+			client.SetProject(project)
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			srv := mcp.NewServer(client, mcp.Options{
+				ProjectID: project,
+				Version:   version,
+				Logger:    slog.New(slog.NewTextHandler(os.Stderr, nil)),
+			})
+			if err := srv.Serve(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("mcp: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&project, "project", "local", "project sessions are started in and listed for")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "timeout for each bridge call")
+	return cmd
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newProvidersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "providers",
+		Short: "List providers registered with the server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := connectClient("", 10*time.Second)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			resp, err := client.ListProviders(ctx)
+			if err != nil {
+				return fmt.Errorf("list providers: %w", err)
+			}
+			if len(resp.Providers) == 0 {
+				fmt.Println("No providers registered.")
+				return nil
+			}
+
+			fmt.Printf("%-16s  %-11s  %-12s  %s\n", "PROVIDER", "STATUS", "VERSION", "BINARY")
+			for _, p := range resp.Providers {
+				status := "available"
+				if !p.Available {
+					status = "unavailable"
+				}
+				version := p.Version
+				if version == "" {
+					version = "-"
+				}
+				fmt.Printf("%-16s  %-11s  %-12s  %s\n", p.Provider, status, version, p.Binary)
+				if p.Error != "" {
+					fmt.Printf("  %s\n", p.Error)
+				}
+				for _, v := range p.Variants {
+					status, version := "available", v.Version
+					if !v.Available {
+						status = "unavailable"
+					}
+					if version == "" {
+						version = "-"
+					}
+					name := "  @" + v.Name
+					if v.Default {
+						name += " *"
+					}
+					fmt.Printf("%-16s  %-11s  %-12s  %s\n", name, status, version, v.Binary)
+				}
+			}
+			return nil
+		},
+	}
+	return cmd
+}
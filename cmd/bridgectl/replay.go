@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/replay"
+	"github.com/spf13/cobra"
+)
+
+func newReplayAgentCmd() *cobra.Command {
+	var (
+		format   string
+		speed    float64
+		maxDelay time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "replay-agent <transcript>",
+		Short: "Act as an agent that replays a recorded transcript",
+		Long: `replay-agent is a mock agent for deterministic tests. It reads a JSONL
+transcript, as written by "session export --format jsonl" or the
+ExportTranscript RPC, and plays it back: output recorded before the first
+input is written at once, and the output recorded after each input is
+written when the next line arrives on stdin, with the recorded timing.
+
+Configure it as a provider and pass the transcript per session with the
+"arg:transcript" agent option, or fix it in the provider args:
+
+  providers:
+    replay:
+      binary: bridgectl
+      args: ["replay-agent", "--speed", "0"]
+      stream_json: true
+      validate_startup: false
+
+--format stream-json (default) replays thinking, tool use and response
+completion as claude stream-json events and needs stream_json: true.
+--format raw writes only output text, for PTY providers.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("open transcript: %w", err)
+			}
+			events, err := replay.Load(f)
+			_ = f.Close()
+			if err != nil {
+				return fmt.Errorf("load transcript: %w", err)
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return replay.Play(ctx, events, os.Stdin, os.Stdout, os.Stderr, replay.Options{
+				Format:   format,
+				Speed:    speed,
+				MaxDelay: maxDelay,
+			})
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", replay.FormatStreamJSON, "output format: stream-json or raw")
+	cmd.Flags().Float64Var(&speed, "speed", 1, "playback speed relative to the recording; 0 plays without delays")
+	cmd.Flags().DurationVar(&maxDelay, "max-delay", 0, "cap on any single recorded gap (0 = no cap)")
+	return cmd
+}
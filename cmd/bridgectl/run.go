@@ -32,6 +32,7 @@ func newRunCmd() *cobra.Command {
 		project      string
 		timeout      time.Duration
 		noTTY        bool
+		conversation string
 	)
 
 	cmd := &cobra.Command{
@@ -46,7 +47,12 @@ Press ctrl-] to detach from the session without stopping it.
 Use 'bridgectl session attach <id>' to reattach later.
 
 Use --no-tty to run without a terminal, reading from stdin and writing to
-stdout. Useful for scripting, piping input, and automated tests.`,
+stdout. Useful for scripting, piping input, and automated tests.
+
+Use --conversation to link the session to earlier runs with the same ID.
+'bridgectl session export --conversation <id>' prints their combined
+transcript, and a run with the same provider in the same directory as the
+previous one continues the agent's thread when the provider can resume.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			dir := "."
@@ -61,9 +67,9 @@ stdout. Useful for scripting, piping input, and automated tests.`,
 				return fmt.Errorf("directory %q: %w", absDir, err)
 			}
 			if noTTY {
-				return runSessionNoTTY(absDir, providerName, project, timeout)
+				return runSessionNoTTY(absDir, providerName, project, conversation, timeout)
 			}
-			return runSession(absDir, providerName, project, timeout)
+			return runSession(absDir, providerName, project, conversation, timeout)
 		},
 	}
 
@@ -71,11 +77,12 @@ stdout. Useful for scripting, piping input, and automated tests.`,
 	cmd.Flags().StringVar(&project, "project", "local", "project ID")
 	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 30*time.Minute, "session timeout")
 	cmd.Flags().BoolVar(&noTTY, "no-tty", false, "run without a terminal (for scripting and tests)")
+	cmd.Flags().StringVar(&conversation, "conversation", "", "conversation ID linking this session to earlier ones")
 
 	return cmd
 }
 
-func runSession(dir, providerName, project string, timeout time.Duration) error {
+func runSession(dir, providerName, project, conversation string, timeout time.Duration) error {
 	// Validate terminal before starting a session to avoid orphaning a
 	// provider process when stdin is not interactive.
 	fd := int(os.Stdin.Fd())
@@ -102,12 +109,13 @@ func runSession(dir, providerName, project string, timeout time.Duration) error
 	defer cancel()
 
 	if _, err := client.StartSession(ctx, &bridgev1.StartSessionRequest{
-		ProjectId:   project,
-		SessionId:   sessionID,
-		RepoPath:    dir,
-		Provider:    providerName,
-		InitialCols: cols,
-		InitialRows: rows,
+		ProjectId:      project,
+		SessionId:      sessionID,
+		RepoPath:       dir,
+		Provider:       providerName,
+		InitialCols:    cols,
+		InitialRows:    rows,
+		ConversationId: conversation,
 	}); err != nil {
 		return fmt.Errorf("start session: %w", err)
 	}
@@ -226,10 +234,15 @@ func runSession(dir, providerName, project string, timeout time.Duration) error
 }
 
 // ensureServer ensures a bridge server is running. If none is found, it spawns
-// "bridgectl server start" as a background process in local mode and
-// waits for it to become healthy. For secure mode, the user must start the
-// server explicitly with --listen.
+// "bridgectl server start --allow-insecure" as a background process in local
+// mode and waits for it to become healthy. For secure mode, the user must start the
+// server explicitly with --listen. Nothing is spawned when a remote target
+// is configured.
 func ensureServer() error {
+	// A remote daemon is managed elsewhere; never spawn a local one for it.
+	if remote.Target != "" {
+		return nil
+	}
 	// Check for existing server (local or secure).
 	target, _ := localserver.DiscoverTarget("")
 	if target != "" {
@@ -242,7 +255,7 @@ func ensureServer() error {
 		return fmt.Errorf("find executable: %w", err)
 	}
 
-	cmd := exec.Command(self, "server", "start")
+	cmd := exec.Command(self, "server", "start", "--allow-insecure")
 	setDetachedProcess(cmd)
 	cmd.Stdout = nil
 	cmd.Stderr = nil
@@ -267,7 +280,7 @@ func ensureServer() error {
 // runSessionNoTTY runs a session without a terminal, forwarding raw stdin to
 // the provider and writing output to stdout. Used for scripting, piping, and
 // automated tests (e.g. the echo provider in CI).
-func runSessionNoTTY(dir, providerName, project string, timeout time.Duration) error {
+func runSessionNoTTY(dir, providerName, project, conversation string, timeout time.Duration) error {
 	if err := ensureServer(); err != nil {
 		return err
 	}
@@ -285,12 +298,13 @@ func runSessionNoTTY(dir, providerName, project string, timeout time.Duration) e
 	defer cancel()
 
 	if _, err := client.StartSession(ctx, &bridgev1.StartSessionRequest{
-		ProjectId:   project,
-		SessionId:   sessionID,
-		RepoPath:    dir,
-		Provider:    providerName,
-		InitialCols: 80,
-		InitialRows: 24,
+		ProjectId:      project,
+		SessionId:      sessionID,
+		RepoPath:       dir,
+		Provider:       providerName,
+		InitialCols:    80,
+		InitialRows:    24,
+		ConversationId: conversation,
 	}); err != nil {
 		return fmt.Errorf("start session: %w", err)
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/markcallen/ai-agent-bridge/internal/localserver"
+	"github.com/markcallen/ai-agent-bridge/internal/systemd"
 )
 
 func newServerCmd() *cobra.Command {
@@ -34,58 +36,62 @@ func newServerCmd() *cobra.Command {
 
 func newServerStartCmd() *cobra.Command {
 	var (
-		listenAddr string
-		serverSANs []string
-		configPath string
-		dbPath     string
-		globalRPS  float64
-		logLevel   string
-		logFormat  string
+		listenAddr    string
+		allowInsecure bool
+		serverSANs    []string
+		configPath    string
+		dbPath        string
+		globalRPS     float64
+		logLevel      string
+		logFormat     string
+		reflect       bool
+		healthAddr    string
+		uiAddr        string
+		drain         time.Duration
 	)
 
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start the bridge server in the foreground",
-		Long: `Start the bridge server. By default it runs in local mode on a unix
-socket with no authentication. Use --listen to bind to a TCP address
-with mTLS + JWT for remote access (e.g. over a WireGuard VPN).
+		Long: `Start the bridge server. Use --listen to bind to a TCP address with
+mTLS + JWT for remote access (e.g. over a WireGuard VPN). Without it the
+server runs in local mode on a unix socket with no authentication, which
+must be asked for with --allow-insecure (or server.insecure_dev_mode);
+its health and dashboard listeners are then limited to loopback.
 
 In secure mode, PKI material (CA, server cert, JWT keypair) is
-auto-generated on first start and stored in ~/.ai-agent-bridge/certs/.`,
+auto-generated on first start and stored in ~/.ai-agent-bridge/certs/.
+
+Send SIGHUP to reload --config: providers, fallbacks, rate limits,
+allowed paths, and JWT keys are updated in place; running sessions and
+the listener are kept.
+
+On SIGTERM or SIGINT the server first fails its readiness probes for
+--shutdown-drain, so load balancers stop routing to it, then stops. A
+second signal skips the rest of the drain.
+
+Under systemd the server reports READY=1, RELOADING=1 and STOPPING=1,
+pings the watchdog when WatchdogSec= is set, and serves a socket passed
+by socket activation instead of binding its own.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if localserver.IsServerRunning("") {
+			// Probing an activated socket would only reach this process's
+			// own unserved backlog; systemd already ensures one instance.
+			if !systemd.SocketActivated() && localserver.IsServerRunning("") {
 				return fmt.Errorf("server already running")
 			}
 
-			// Build logger from --log-level and --log-format.
-			level := slog.LevelWarn
-			switch strings.ToLower(logLevel) {
-			case "debug":
-				level = slog.LevelDebug
-			case "info":
-				level = slog.LevelInfo
-			case "warn", "warning":
-				level = slog.LevelWarn
-			case "error":
-				level = slog.LevelError
-			}
-			// Secure mode and explicit log-level both default to info-level output.
-			if listenAddr != "" && logLevel == "" {
-				level = slog.LevelInfo
-			}
-			var logger *slog.Logger
-			if logFormat == "json" {
-				logger = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
-			} else {
-				logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
-			}
-
 			cfg := localserver.Config{
-				ListenAddr: listenAddr,
-				ServerSANs: serverSANs,
-				ConfigPath: configPath,
-				DBPath:     dbPath,
-				Logger:     logger,
+				ListenAddr:    listenAddr,
+				AllowInsecure: allowInsecure,
+				ServerSANs:    serverSANs,
+				ConfigPath:    configPath,
+				DBPath:        dbPath,
+				LogLevel:      logLevel,
+				LogFormat:     logFormat,
+				Reflection:    reflect,
+				HealthListen:  healthAddr,
+				UIListen:      uiAddr,
+				ShutdownDrain: drain,
 			}
 			if globalRPS > 0 {
 				cfg.RateLimits.GlobalRPS = globalRPS
@@ -102,23 +108,48 @@ auto-generated on first start and stored in ~/.ai-agent-bridge/certs/.`,
 			}
 			fmt.Fprintf(os.Stderr, "ai-agent-bridge server listening — %s (pid %d)\n", mode, os.Getpid())
 
-			// Block until signal.
+			// Block until signal. SIGHUP reloads the config file without
+			// dropping sessions or the listener.
 			sigCh := make(chan os.Signal, 1)
-			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-			sig := <-sigCh
-			fmt.Fprintf(os.Stderr, "\nReceived %s, shutting down...\n", sig)
+			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+			for sig := range sigCh {
+				if sig == syscall.SIGHUP {
+					if err := srv.Reload(); err != nil {
+						slog.Error("config reload failed; keeping current configuration", "error", err)
+					}
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "\nReceived %s, shutting down...\n", sig)
+				break
+			}
+			drainCtx, skipDrain := context.WithCancel(context.Background())
+			go func() {
+				for sig := range sigCh {
+					if sig != syscall.SIGHUP {
+						skipDrain()
+						return
+					}
+				}
+			}()
+			srv.Drain(drainCtx)
+			skipDrain()
 			srv.Stop()
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&listenAddr, "listen", "", "TCP address for secure mode (e.g. 10.0.0.1:9445 or 0.0.0.0:9445)")
+	cmd.Flags().BoolVar(&allowInsecure, "allow-insecure", false, "allow local mode without --listen: a unix socket with no TLS or authentication (development only)")
 	cmd.Flags().StringSliceVar(&serverSANs, "san", nil, "additional server cert SANs (DNS names or IPs)")
 	cmd.Flags().StringVar(&configPath, "config", "", "path to YAML config file (merged with flag values; flags take precedence)")
 	cmd.Flags().StringVar(&dbPath, "db-path", "", "path to BoltDB session store for persistence across restarts")
 	cmd.Flags().Float64Var(&globalRPS, "rate-limit-global-rps", 0, "override global RPS rate limit (default 100)")
-	cmd.Flags().StringVar(&logLevel, "log-level", "", "log level: debug, info, warn, error (default warn; info when --listen is set)")
-	cmd.Flags().StringVar(&logFormat, "log-format", "text", "log format: text or json")
+	cmd.Flags().StringVar(&logLevel, "log-level", "", "log level: debug, info, warn, error (default logging.level, else warn; info when --listen is set)")
+	cmd.Flags().StringVar(&logFormat, "log-format", "", "log format: text or json (default logging.format, else text)")
+	cmd.Flags().BoolVar(&reflect, "reflection", false, "serve gRPC reflection for grpcurl/evans (development only)")
+	cmd.Flags().StringVar(&healthAddr, "health-listen", "", "serve unauthenticated HTTP /livez and /readyz probes on this address (e.g. :8080)")
+	cmd.Flags().StringVar(&uiAddr, "ui-listen", "", "serve the read-only web dashboard on this address (e.g. 127.0.0.1:8090)")
+	cmd.Flags().DurationVar(&drain, "shutdown-drain", 0, "how long to fail readiness before stopping on SIGTERM/SIGINT")
 
 	return cmd
 }
@@ -266,7 +297,7 @@ Copy these files to the remote machine and use them with the Go SDK.`,
 			fmt.Printf("  JWT signing key: %s\n", clientJWTKey)
 			fmt.Println()
 			fmt.Println("The server will accept tokens from this client on next restart.")
-			fmt.Println("If the server is already running, restart it to load the new key.")
+			fmt.Println("If the server is already running, send it SIGHUP to load the new key.")
 			fmt.Println()
 			fmt.Println("Example Go SDK usage:")
 			fmt.Println()
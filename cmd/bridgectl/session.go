@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -27,6 +29,10 @@ func newSessionCmd() *cobra.Command {
 	cmd.AddCommand(
 		newSessionListCmd(),
 		newSessionAttachCmd(),
+		newSessionTailCmd(),
+		newSessionExportCmd(),
+		newSessionHandoffCmd(),
+		newSessionShareCmd(),
 		newSessionStopCmd(),
 	)
 
@@ -68,7 +74,11 @@ func newSessionListCmd() *cobra.Command {
 			for _, s := range resp.Sessions {
 				status := sessionStatusString(s.Status)
 				created := s.CreatedAt.AsTime().Format("15:04:05")
-				fmt.Printf("%-36s  %-10s  %-10s  %s\n", s.SessionId, s.Provider, status, created)
+				provider := s.Provider
+				if s.Variant != "" {
+					provider += "@" + s.Variant
+				}
+				fmt.Printf("%-36s  %-10s  %-10s  %s\n", s.SessionId, provider, status, created)
 			}
 			return nil
 		},
@@ -108,6 +118,300 @@ func newSessionAttachCmd() *cobra.Command {
 	return cmd
 }
 
+func newSessionTailCmd() *cobra.Command {
+	var (
+		afterSeq   uint64
+		showEvents bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tail <session-id>",
+		Short: "Stream a session's output without attaching a terminal",
+		Long: `Attach to a session as a read-only observer and copy its output to
+stdout until the session exits or you press ctrl-c. Retained output is
+replayed first; use --after-seq to skip what you have already seen.
+
+With --events, every attach event is printed as one line (sequence, type,
+and a short summary) instead of raw output bytes.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tailSession(args[0], afterSeq, showEvents)
+		},
+	}
+
+	cmd.Flags().Uint64Var(&afterSeq, "after-seq", 0, "only replay output after this sequence number")
+	cmd.Flags().BoolVar(&showEvents, "events", false, "print one line per event instead of raw output")
+	return cmd
+}
+
+func newSessionExportCmd() *cobra.Command {
+	var (
+		format       string
+		output       string
+		conversation bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export <session-id>",
+		Short: "Export a session transcript as markdown or JSONL",
+		Long: `Render a session's prompts and output as a markdown document (the
+default) or as JSONL with one event per line. Archived sessions can be
+exported too. The transcript is written to stdout unless --output is set.
+
+With --conversation the argument is a conversation ID, and the transcripts
+of all its sessions are exported as one, in the order they started.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var f bridgev1.TranscriptFormat
+			switch format {
+			case "md", "markdown":
+				f = bridgev1.TranscriptFormat_TRANSCRIPT_FORMAT_MARKDOWN
+			case "jsonl":
+				f = bridgev1.TranscriptFormat_TRANSCRIPT_FORMAT_JSONL
+			default:
+				return fmt.Errorf("unknown format %q (use md or jsonl)", format)
+			}
+
+			client, err := connectClient("", 10*time.Second)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			var content []byte
+			if conversation {
+				resp, err := client.GetConversation(ctx, &bridgev1.GetConversationRequest{
+					ConversationId: args[0],
+					Format:         f,
+				})
+				if err != nil {
+					return fmt.Errorf("get conversation: %w", err)
+				}
+				content = resp.Content
+			} else {
+				resp, err := client.ExportTranscript(ctx, &bridgev1.ExportTranscriptRequest{
+					SessionId: args[0],
+					Format:    f,
+				})
+				if err != nil {
+					return fmt.Errorf("export transcript: %w", err)
+				}
+				content = resp.Content
+			}
+			if output == "" {
+				_, err = os.Stdout.Write(content)
+				return err
+			}
+			return os.WriteFile(output, content, 0o600)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "md", "transcript format: md or jsonl")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write the transcript to this file instead of stdout")
+	cmd.Flags().BoolVar(&conversation, "conversation", false, "treat the argument as a conversation ID and export all its sessions")
+	return cmd
+}
+
+func newSessionHandoffCmd() *cobra.Command {
+	var (
+		to         string
+		keepSource bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "handoff <session-id>",
+		Short: "Move a session to another bridge instance",
+		Long: `Export a session's state from the current bridge and import it into the
+bridge at --to, using the same TLS and JWT settings. The source session is
+stopped and drained first unless --keep-source is set. The agent process is
+restarted on the destination; buffered output and seq numbers carry over,
+so clients reattach there with the last seq they saw.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if to == "" {
+				return errors.New("--to is required")
+			}
+			src, err := connectClient("", 30*time.Second)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = src.Close() }()
+			dstCfg := remote
+			dstCfg.Target = to
+			dst, err := dialRemote(dstCfg, 30*time.Second)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = dst.Close() }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			state, err := src.ExportSessionState(ctx, &bridgev1.ExportSessionStateRequest{
+				SessionId: args[0],
+				Stop:      !keepSource,
+			})
+			if err != nil {
+				return fmt.Errorf("export session state: %w", err)
+			}
+			resp, err := dst.ImportSessionState(ctx, &bridgev1.ImportSessionStateRequest{State: state.State})
+			if err != nil {
+				return fmt.Errorf("import session state into %s: %w", to, err)
+			}
+			fmt.Printf("Session %s handed off to %s (last seq %d, status %s)\n",
+				resp.GetSession().GetSessionId(), to, state.LastSeq,
+				strings.TrimPrefix(resp.GetSession().GetStatus().String(), "SESSION_STATUS_"))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "destination bridge address (host:port)")
+	cmd.Flags().BoolVar(&keepSource, "keep-source", false, "leave the source session running (output after the export is not transferred)")
+	return cmd
+}
+
+func tailSession(sessionID string, afterSeq uint64, showEvents bool) error {
+	client, err := connectClient("", 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	stream, err := client.AttachSession(ctx, &bridgev1.AttachSessionRequest{
+		SessionId: sessionID,
+		ClientId:  uuid.NewString(),
+		AfterSeq:  afterSeq,
+		Role:      bridgev1.AttachRole_ATTACH_ROLE_OBSERVER,
+	})
+	if err != nil {
+		return fmt.Errorf("attach: %w", err)
+	}
+
+	err = stream.RecvAll(ctx, func(ev *bridgev1.AttachSessionEvent) error {
+		if showEvents {
+			_, writeErr := fmt.Println(formatAttachEvent(ev))
+			return writeErr
+		}
+		switch ev.Type {
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:
+			_, writeErr := os.Stdout.Write(ev.Payload)
+			return writeErr
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_REPLAY_GAP:
+			_, writeErr := fmt.Fprintf(os.Stderr, "[ai-agent-bridge] replay gap: oldest=%d last=%d\n", ev.OldestSeq, ev.LastSeq)
+			return writeErr
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_EXIT:
+			_, writeErr := fmt.Fprintf(os.Stderr, "[ai-agent-bridge] session exited (code %d)\n", ev.ExitCode)
+			return writeErr
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WARNING:
+			if ev.Severity == bridgev1.Severity_SEVERITY_PROGRESS {
+				return nil
+			}
+			_, writeErr := fmt.Fprintf(os.Stderr, "[%s] %s\n", severityName(ev.Severity), ev.Payload)
+			return writeErr
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ERROR:
+			return errors.New(ev.Error)
+		default:
+			return nil
+		}
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("tail: %w", err)
+	}
+	return nil
+}
+
+// formatAttachEvent renders ev as a single line for `session tail --events`.
+func formatAttachEvent(ev *bridgev1.AttachSessionEvent) string {
+	name := strings.TrimPrefix(ev.Type.String(), "ATTACH_EVENT_TYPE_")
+	var detail string
+	switch ev.Type {
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:
+		detail = strconv.Quote(string(ev.Payload))
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_THINKING:
+		detail = strconv.Quote(ev.ThinkingText)
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED, bridgev1.AttachEventType_ATTACH_EVENT_TYPE_REPLAY_GAP:
+		detail = fmt.Sprintf("oldest=%d last=%d", ev.OldestSeq, ev.LastSeq)
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_EXIT:
+		detail = fmt.Sprintf("exit_code=%d", ev.ExitCode)
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ERROR:
+		detail = ev.Error
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WRITER_CLAIMED, bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WRITER_RELEASED:
+		detail = "client=" + ev.WriterClientId
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WARNING:
+		detail = fmt.Sprintf("severity=%s %s", severityName(ev.Severity), strconv.Quote(string(ev.Payload)))
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_USAGE:
+		u := ev.GetUsage()
+		detail = fmt.Sprintf("input_tokens=%d output_tokens=%d cost_usd=%.4f turns=%d", u.GetInputTokens(), u.GetOutputTokens(), u.GetCostUsd(), u.GetTurns())
+	}
+	line := fmt.Sprintf("%d %s", ev.Seq, name)
+	if ev.Replay {
+		line += " (replay)"
+	}
+	if detail != "" {
+		line += " " + detail
+	}
+	return line
+}
+
+// severityName returns the lower-case name of a stderr severity.
+func severityName(s bridgev1.Severity) string {
+	return strings.ToLower(strings.TrimPrefix(s.String(), "SEVERITY_"))
+}
+
+func newSessionShareCmd() *cobra.Command {
+	var ttl time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "share <session-id>",
+		Short: "Print a short-lived token that can only watch a session",
+		Long: `Mint a read-only token scoped to one session and print it. Whoever holds
+it can attach to the session as an observer until it expires, without any
+other access to the project. Only a secure-mode bridge mints tokens.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if ttl < 0 || ttl%time.Second != 0 {
+				return fmt.Errorf("--ttl must be a positive number of seconds")
+			}
+			client, err := connectClient("", 10*time.Second)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			resp, err := client.MintSessionToken(ctx, &bridgev1.MintSessionTokenRequest{
+				SessionId:  args[0],
+				TtlSeconds: uint32(ttl / time.Second),
+			})
+			if err != nil {
+				return fmt.Errorf("mint session token: %w", err)
+			}
+			fmt.Println(resp.GetToken())
+			fmt.Fprintf(os.Stderr, "Expires %s; revoke with token ID %s.\n", resp.GetExpiresAt().AsTime().Local().Format(time.RFC3339), resp.GetTokenId())
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&ttl, "ttl", 0, "token lifetime (default and maximum: the bridge's jwt max TTL)")
+	return cmd
+}
+
 func newSessionStopCmd() *cobra.Command {
 	var force bool
 
@@ -133,6 +437,10 @@ func newSessionStopCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("stop session: %w", err)
 			}
+			if force {
+				fmt.Printf("Session %s force-stopped.\n", sessionID)
+				return nil
+			}
 			fmt.Printf("Session %s stopped.\n", sessionID)
 			return nil
 		},
@@ -282,6 +590,12 @@ func attachSession(sessionID string, role bridgev1.AttachRole, takeOver bool) er
 		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WRITER_RELEASED:
 			_, writeErr := fmt.Fprintf(os.Stderr, "\r\n[ai-agent-bridge] writer released by %s\r\n", ev.WriterClientId)
 			return writeErr
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WARNING:
+			if ev.Severity == bridgev1.Severity_SEVERITY_PROGRESS {
+				return nil
+			}
+			_, writeErr := fmt.Fprintf(os.Stderr, "\r\n[%s] %s\r\n", severityName(ev.Severity), ev.Payload)
+			return writeErr
 		default:
 			return nil
 		}
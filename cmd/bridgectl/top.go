@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func newTopCmd() *cobra.Command {
+	var (
+		project  string
+		interval time.Duration
+		once     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Show a live view of the bridge's sessions",
+		Long: `top lists the sessions of --project with their state, attached clients,
+event rate, replay buffer use, token usage and last line of output, and
+redraws the list every --interval until ctrl-c.
+
+The list comes from ListSessions; each live session is also watched as a
+read-only observer to count its events and catch its output. With --once,
+or when stdout is not a terminal, one snapshot is printed after a single
+interval instead.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			client, err := connectClient("", 0)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+			client.SetProject(project)
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if once || !term.IsTerminal(int(os.Stdout.Fd())) {
+				return runTopOnce(ctx, client, project, interval, os.Stdout)
+			}
+			return runTop(ctx, client, project, interval)
+		},
+	}
+
+	cmd.Flags().StringVar(&project, "project", "local", "project whose sessions are shown")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "how often the view is redrawn")
+	cmd.Flags().BoolVar(&once, "once", false, "print one snapshot and exit")
+	return cmd
+}
+
+// runTopOnce watches the sessions of project for one interval, so that
+// rates and output are filled, and writes one snapshot to w.
+func runTopOnce(ctx context.Context, client *bridgeclient.Client, project string, interval time.Duration, w io.Writer) error {
+	mon := newTopMonitor(client, project)
+	defer mon.close()
+	if err := mon.refresh(ctx); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(interval):
+	}
+	if err := mon.refresh(ctx); err != nil {
+		return err
+	}
+	mon.render(w, 0, time.Now())
+	return nil
+}
+
+// runTop redraws the view on the terminal every interval until ctx is done.
+func runTop(ctx context.Context, client *bridgeclient.Client, project string, interval time.Duration) error {
+	mon := newTopMonitor(client, project)
+	defer mon.close()
+	fd := int(os.Stdout.Fd())
+	// Draw on the alternate screen so the shell's scrollback is left as it
+	// was.
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		var buf bytes.Buffer
+		buf.WriteString("\x1b[H\x1b[2J")
+		if err := mon.refresh(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(&buf, "list sessions: %v\n", err)
+		} else {
+			width, _, _ := term.GetSize(fd)
+			mon.render(&buf, width, time.Now())
+		}
+		_, _ = os.Stdout.Write(bytes.ReplaceAll(buf.Bytes(), []byte("\n"), []byte("\x1b[K\n")))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// topMonitor tracks the sessions shown by top.
+type topMonitor struct {
+	client  *bridgeclient.Client
+	project string
+
+	mu       sync.Mutex
+	sessions map[string]*topSession
+	order    []string
+}
+
+// topSession is one session's row. attached, events and lastLine are
+// updated by the session's watcher; rate is recomputed on each refresh.
+type topSession struct {
+	info     *bridgev1.GetSessionResponse
+	cancel   context.CancelFunc
+	attached bool
+
+	events     uint64
+	prevEvents uint64
+	prevAt     time.Time
+	rate       float64
+	partial    []byte
+	lastLine   string
+}
+
+func newTopMonitor(client *bridgeclient.Client, project string) *topMonitor {
+	return &topMonitor{client: client, project: project, sessions: make(map[string]*topSession)}
+}
+
+// refresh lists the sessions, starts watching new live ones, stops
+// watching those that ended and updates the event rates.
+func (m *topMonitor) refresh(ctx context.Context) error {
+	listCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	resp, err := m.client.ListSessions(listCtx, &bridgev1.ListSessionsRequest{ProjectId: m.project})
+	cancel()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen := make(map[string]bool, len(resp.Sessions))
+	m.order = m.order[:0]
+	for _, info := range resp.Sessions {
+		id := info.SessionId
+		seen[id] = true
+		m.order = append(m.order, id)
+		ts, ok := m.sessions[id]
+		if !ok {
+			ts = &topSession{prevAt: now}
+			m.sessions[id] = ts
+		}
+		ts.info = info
+		if elapsed := now.Sub(ts.prevAt).Seconds(); elapsed > 0 && ok {
+			ts.rate = float64(ts.events-ts.prevEvents) / elapsed
+		}
+		ts.prevEvents, ts.prevAt = ts.events, now
+		live := sessionLive(info.Status)
+		switch {
+		case live && ts.cancel == nil:
+			watchCtx, cancel := context.WithCancel(ctx)
+			ts.cancel = cancel
+			go m.watch(watchCtx, ts, info)
+		case !live && ts.cancel != nil:
+			ts.cancel()
+			ts.cancel = nil
+			ts.attached = false
+		}
+	}
+	for id, ts := range m.sessions {
+		if !seen[id] {
+			if ts.cancel != nil {
+				ts.cancel()
+			}
+			delete(m.sessions, id)
+		}
+	}
+	slices.SortStableFunc(m.order, func(a, b string) int {
+		return m.sessions[b].info.CreatedAt.AsTime().Compare(m.sessions[a].info.CreatedAt.AsTime())
+	})
+	return nil
+}
+
+func sessionLive(s bridgev1.SessionStatus) bool {
+	switch s {
+	case bridgev1.SessionStatus_SESSION_STATUS_STARTING,
+		bridgev1.SessionStatus_SESSION_STATUS_RUNNING,
+		bridgev1.SessionStatus_SESSION_STATUS_ATTACHED:
+		return true
+	}
+	return false
+}
+
+// watch observes a session from its newest event on, counting events and
+// keeping the last line of output, until ctx is done or the session ends.
+// Replay is skipped except for the last chunk, so that the row shows some
+// output straight away.
+func (m *topMonitor) watch(ctx context.Context, ts *topSession, info *bridgev1.GetSessionResponse) {
+	after := info.LastSeq
+	if after > 0 {
+		after--
+	}
+	stream, err := m.client.AttachSession(ctx, &bridgev1.AttachSessionRequest{
+		SessionId: info.SessionId,
+		ClientId:  "top-" + uuid.NewString(),
+		AfterSeq:  after,
+		Role:      bridgev1.AttachRole_ATTACH_ROLE_OBSERVER,
+	})
+	if err != nil {
+		return
+	}
+	_ = stream.RecvAll(ctx, func(ev *bridgev1.AttachSessionEvent) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		events := []*bridgev1.AttachSessionEvent{ev}
+		if ev.Type == bridgev1.AttachEventType_ATTACH_EVENT_TYPE_BATCH {
+			events = ev.GetBatch().GetEvents()
+		}
+		for _, ev := range events {
+			if ev.Type == bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED {
+				ts.attached = true
+			}
+			if !ev.Replay && ev.Type != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED {
+				ts.events++
+			}
+			if ev.Type == bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT {
+				ts.addOutput(ev.Payload)
+			}
+		}
+		return nil
+	})
+}
+
+// topEscapes matches terminal control sequences and other control
+// characters, which would garble the view.
+var topEscapes = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b.|[\x00-\x08\x0b-\x1f\x7f]`)
+
+// addOutput appends output to the session's partial line and keeps the
+// last non-blank complete line.
+func (ts *topSession) addOutput(p []byte) {
+	ts.partial = append(ts.partial, p...)
+	for {
+		i := bytes.IndexByte(ts.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(topEscapes.ReplaceAllString(string(ts.partial[:i]), "")); line != "" {
+			ts.lastLine = line
+		}
+		ts.partial = ts.partial[i+1:]
+	}
+	// An agent waiting at a prompt may not end its last line.
+	if line := strings.TrimSpace(topEscapes.ReplaceAllString(string(ts.partial), "")); line != "" {
+		ts.lastLine = line
+	}
+	if len(ts.partial) > 4096 {
+		ts.partial = ts.partial[len(ts.partial)-4096:]
+	}
+}
+
+// render writes the view, cutting lines to width columns when width > 0.
+func (m *topMonitor) render(w io.Writer, width int, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var live int
+	for _, ts := range m.sessions {
+		if sessionLive(ts.info.Status) {
+			live++
+		}
+	}
+	line := func(s string) {
+		if width > 0 && len([]rune(s)) > width {
+			s = string([]rune(s)[:width])
+		}
+		fmt.Fprintln(w, s)
+	}
+	line(fmt.Sprintf("bridgectl top — project %s — %d sessions, %d live — %s", m.project, len(m.sessions), live, now.Format("15:04:05")))
+	line("")
+	line(fmt.Sprintf("%-8s  %-10s  %-9s  %7s  %7s  %-17s  %-15s  %s", "SESSION", "PROVIDER", "STATUS", "CLIENTS", "EV/S", "BUFFER", "TOKENS IN/OUT", "LAST OUTPUT"))
+	for _, id := range m.order {
+		ts := m.sessions[id]
+		info := ts.info
+		clients := int(info.ObserverCount)
+		if info.ActiveWriterClientId != "" {
+			clients++
+		}
+		if ts.attached && ts.cancel != nil {
+			clients-- // not counting top's own observer
+		}
+		tokens := "-"
+		if u := info.Usage; u.GetInputTokens()+u.GetOutputTokens() > 0 {
+			tokens = fmt.Sprintf("%s/%s", formatCount(u.GetInputTokens()), formatCount(u.GetOutputTokens()))
+		}
+		last := ts.lastLine
+		if info.Error != "" {
+			last = "error: " + info.Error
+		}
+		line(fmt.Sprintf("%-8s  %-10s  %-9s  %7d  %7.1f  %-17s  %-15s  %s",
+			shortID(id), info.Provider, sessionStatusString(info.Status), max(clients, 0), ts.rate,
+			formatBuffer(info.BufferBytes, info.BufferCapacity), tokens, last))
+	}
+	if len(m.order) == 0 {
+		line("No sessions.")
+	}
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// formatBuffer shows buffer use as a percentage and the bytes held.
+func formatBuffer(held, capacity uint64) string {
+	if capacity == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%3d%% %s/%s", held*100/capacity, formatSize(held), formatSize(capacity))
+}
+
+func formatSize(n uint64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fG", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fM", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fK", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+func formatCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// close stops every watcher.
+func (m *topMonitor) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, ts := range m.sessions {
+		if ts.cancel != nil {
+			ts.cancel()
+		}
+	}
+}
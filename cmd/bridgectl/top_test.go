@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgetest"
+)
+
+func TestTopRender(t *testing.T) {
+	running := &topSession{
+		info: &bridgev1.GetSessionResponse{
+			SessionId:            "3f1c7a52-8d4b-4e09-a6f2-5b0e9c1d7a38",
+			Provider:             "claude",
+			Status:               bridgev1.SessionStatus_SESSION_STATUS_RUNNING,
+			ActiveWriterClientId: "web",
+			ObserverCount:        2,
+			BufferBytes:          512 << 10,
+			BufferCapacity:       8 << 20,
+			Usage:                &bridgev1.Usage{InputTokens: 12_500, OutputTokens: 800},
+		},
+		cancel:   func() {},
+		attached: true,
+		rate:     2.5,
+	}
+	running.addOutput([]byte("\x1b[1mbuilding\x1b[0m...\r\n"))
+	running.addOutput([]byte("all tests pass"))
+	failed := &topSession{info: &bridgev1.GetSessionResponse{
+		SessionId: "9d2e",
+		Provider:  "codex",
+		Status:    bridgev1.SessionStatus_SESSION_STATUS_FAILED,
+		Error:     "exit status 1",
+	}}
+	m := &topMonitor{
+		project:  "dev",
+		sessions: map[string]*topSession{running.info.SessionId: running, "9d2e": failed},
+		order:    []string{running.info.SessionId, "9d2e"},
+	}
+
+	var buf bytes.Buffer
+	m.render(&buf, 0, time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC))
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("render wrote %d lines:\n%s", len(lines), buf.String())
+	}
+	if lines[0] != "bridgectl top — project dev — 2 sessions, 1 live — 15:04:05" {
+		t.Fatalf("header=%q", lines[0])
+	}
+	// The writer and the other observer count; top's own observer does not.
+	for _, want := range []string{"3f1c7a52", "claude", "running", "      2", "    2.5", "6% 512.0K/8.0M", "12.5k/800", "all tests pass"} {
+		if !strings.Contains(lines[3], want) {
+			t.Fatalf("row %q lacks %q", lines[3], want)
+		}
+	}
+	if !strings.Contains(lines[4], "9d2e") || !strings.HasSuffix(lines[4], "error: exit status 1") {
+		t.Fatalf("failed row=%q", lines[4])
+	}
+
+	buf.Reset()
+	m.render(&buf, 20, time.Now())
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		if n := len([]rune(line)); n > 20 {
+			t.Fatalf("line of %d runes at width 20: %q", n, line)
+		}
+	}
+
+	buf.Reset()
+	(&topMonitor{project: "dev", sessions: map[string]*topSession{}}).render(&buf, 0, time.Now())
+	if !strings.Contains(buf.String(), "No sessions.") {
+		t.Fatalf("empty view=%q", buf.String())
+	}
+}
+
+func TestTopOnce(t *testing.T) {
+	b := bridgetest.New(t, bridgetest.WithProvider("greeter", "sh", "-c", "echo hello from greeter; exec cat"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sessionID := uuid.NewString()
+	if _, err := b.Client.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId: "test",
+		SessionId: sessionID,
+		RepoPath:  t.TempDir(),
+		Provider:  "greeter",
+	}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	for {
+		info, err := b.Client.GetSession(ctx, &bridgev1.GetSessionRequest{SessionId: sessionID})
+		if err != nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		if info.LastSeq > 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the greeting")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := runTopOnce(ctx, b.Client, "test", 200*time.Millisecond, &buf); err != nil {
+		t.Fatalf("runTopOnce: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"project test — 1 sessions, 1 live", shortID(sessionID), "greeter", "running", "hello from greeter"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("top output lacks %q:\n%s", want, out)
+		}
+	}
+
+	// Sessions of other projects are not listed.
+	buf.Reset()
+	if err := runTopOnce(ctx, b.Client, "other", 10*time.Millisecond, &buf); err != nil {
+		t.Fatalf("runTopOnce: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No sessions.") {
+		t.Fatalf("other project view=%q", buf.String())
+	}
+}
@@ -3,6 +3,7 @@ package cli_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -68,7 +69,8 @@ func TestServerStartStop(t *testing.T) {
 
 	// Start server via the localserver package directly.
 	srv, err := localserver.Start(localserver.Config{
-		StateDir: stateDir,
+		AllowInsecure: true,
+		StateDir:      stateDir,
 	})
 	require.NoError(t, err, "server should start")
 	defer srv.Stop()
@@ -106,7 +108,8 @@ func TestEchoSessionLifecycle(t *testing.T) {
 	repoDir := t.TempDir()
 
 	srv, err := localserver.Start(localserver.Config{
-		StateDir: stateDir,
+		AllowInsecure: true,
+		StateDir:      stateDir,
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -198,7 +201,8 @@ func TestAutoServerDiscovery(t *testing.T) {
 
 	// Start first server.
 	srv, err := localserver.Start(localserver.Config{
-		StateDir: stateDir,
+		AllowInsecure: true,
+		StateDir:      stateDir,
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -231,7 +235,8 @@ func TestMultipleSessionsSameServer(t *testing.T) {
 	repoDir := t.TempDir()
 
 	srv, err := localserver.Start(localserver.Config{
-		StateDir: stateDir,
+		AllowInsecure: true,
+		StateDir:      stateDir,
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -295,7 +300,8 @@ func TestServerDoesNotDoubleStart(t *testing.T) {
 	stateDir := testStateDir(t)
 
 	srv1, err := localserver.Start(localserver.Config{
-		StateDir: stateDir,
+		AllowInsecure: true,
+		StateDir:      stateDir,
 	})
 	require.NoError(t, err)
 	defer srv1.Stop()
@@ -366,7 +372,8 @@ func TestCLIServerStatus(t *testing.T) {
 
 	// Start server.
 	srv, err := localserver.Start(localserver.Config{
-		StateDir: stateDir,
+		AllowInsecure: true,
+		StateDir:      stateDir,
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -391,7 +398,8 @@ func TestProviderEchoAvailable(t *testing.T) {
 
 	stateDir := testStateDir(t)
 	srv, err := localserver.Start(localserver.Config{
-		StateDir: stateDir,
+		AllowInsecure: true,
+		StateDir:      stateDir,
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -416,6 +424,47 @@ func TestProviderEchoAvailable(t *testing.T) {
 	assert.True(t, found, "echo provider should be listed in health response")
 }
 
+// TestCLIProvidersAndHealth verifies the operator commands against a running
+// server, both via local discovery and via an explicit --target.
+func TestCLIProvidersAndHealth(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+
+	stateDir := testStateDir(t)
+	srv, err := localserver.Start(localserver.Config{
+		AllowInsecure: true,
+		StateDir:      stateDir,
+	})
+	require.NoError(t, err)
+	defer srv.Stop()
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(cliBinary, args...)
+		cmd.Env = append(os.Environ(), "AI_AGENT_BRIDGE_STATE_DIR="+stateDir)
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		require.NoError(t, cmd.Run(), "bridgectl %v: %s", args, out.String())
+		return out.String()
+	}
+
+	out := run("providers")
+	assert.Contains(t, out, "PROVIDER")
+	assert.Contains(t, out, "echo")
+
+	out = run("health")
+	assert.Contains(t, out, "Status:    serving")
+	assert.Contains(t, out, "echo")
+
+	// An explicit target in the client config file bypasses discovery.
+	cfgPath := filepath.Join(t.TempDir(), "bridgectl.yaml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte("target: "+srv.Target()+"\n"), 0o600))
+	out = run("--client-config", cfgPath, "health")
+	assert.Contains(t, out, "serving")
+}
+
 // TestCleanShutdownCleansFiles verifies state files are removed on stop.
 func TestCleanShutdownCleansFiles(t *testing.T) {
 	if testing.Short() {
@@ -424,7 +473,8 @@ func TestCleanShutdownCleansFiles(t *testing.T) {
 
 	stateDir := testStateDir(t)
 	srv, err := localserver.Start(localserver.Config{
-		StateDir: stateDir,
+		AllowInsecure: true,
+		StateDir:      stateDir,
 	})
 	require.NoError(t, err)
 
@@ -470,7 +520,8 @@ func TestStaleSocketRecovery(t *testing.T) {
 
 	// Start should succeed by replacing the stale socket.
 	srv, err := localserver.Start(localserver.Config{
-		StateDir: stateDir,
+		AllowInsecure: true,
+		StateDir:      stateDir,
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -488,7 +539,8 @@ func TestSessionAttachAndInput(t *testing.T) {
 	repoDir := t.TempDir()
 
 	srv, err := localserver.Start(localserver.Config{
-		StateDir: stateDir,
+		AllowInsecure: true,
+		StateDir:      stateDir,
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -793,3 +845,106 @@ func TestSecureModeCleanup(t *testing.T) {
 	_, err = os.Stat(filepath.Join(stateDir, "server.mode"))
 	assert.True(t, os.IsNotExist(err), "mode file should be removed after stop")
 }
+
+// errStopRecv ends a RecvAll loop from its callback.
+var errStopRecv = errors.New("stop receiving")
+
+// TestReplayAgentSession runs a session against "bridgectl replay-agent"
+// configured as a stream-JSON provider and checks that the recorded turn is
+// replayed as typed events when input arrives.
+func TestReplayAgentSession(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("stream-JSON providers are not supported on Windows")
+	}
+
+	stateDir := testStateDir(t)
+	dir := t.TempDir()
+	transcript := filepath.Join(dir, "session.jsonl")
+	require.NoError(t, os.WriteFile(transcript, []byte(
+		`{"seq":1,"type":"input","timestamp":"2026-03-01T12:00:00Z","text":"hi"}`+"\n"+
+			`{"seq":2,"type":"thinking","timestamp":"2026-03-01T12:00:01Z","text":"REPLAY_THINKING"}`+"\n"+
+			`{"seq":3,"type":"output","timestamp":"2026-03-01T12:00:02Z","text":"REPLAY_OUTPUT"}`+"\n"+
+			`{"seq":4,"type":"response_complete","timestamp":"2026-03-01T12:00:02Z"}`+"\n"), 0o600))
+	configPath := filepath.Join(dir, "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+providers:
+  replay:
+    binary: "`+cliBinary+`"
+    args: ["replay-agent", "--speed", "0"]
+    stream_json: true
+    validate_startup: false
+`), 0o600))
+
+	// A config file puts the server in secure mode.
+	srv, err := localserver.Start(localserver.Config{
+		StateDir:   stateDir,
+		ConfigPath: configPath,
+		ListenAddr: "127.0.0.1:0",
+		ServerSANs: []string{"127.0.0.1"},
+	})
+	require.NoError(t, err)
+	defer srv.Stop()
+
+	target, _ := localserver.DiscoverTarget(stateDir)
+	client := secureClient(t, target, stateDir)
+	defer func() { _ = client.Close() }()
+	client.SetProject("test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	sessionID := uuid.NewString()
+	_, err = client.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId: "test",
+		SessionId: sessionID,
+		RepoPath:  t.TempDir(),
+		Provider:  "replay",
+		AgentOpts: map[string]string{"arg:transcript": transcript},
+	})
+	require.NoError(t, err)
+
+	stream, err := client.AttachSession(ctx, &bridgev1.AttachSessionRequest{
+		SessionId: sessionID,
+		ClientId:  uuid.NewString(),
+	})
+	require.NoError(t, err)
+
+	var types []bridgev1.AttachEventType
+	var text strings.Builder
+	err = stream.RecvAll(ctx, func(ev *bridgev1.AttachSessionEvent) error {
+		switch ev.Type {
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED:
+			_, err := client.WriteInput(ctx, &bridgev1.WriteInputRequest{
+				SessionId: sessionID,
+				ClientId:  stream.ClientID(),
+				Data:      []byte(`{"type":"user","message":{"role":"user","content":"hi"}}` + "\n"),
+			})
+			return err
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_THINKING:
+			text.WriteString(ev.ThinkingText)
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:
+			text.Write(ev.Payload)
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE:
+			types = append(types, ev.Type)
+			return errStopRecv
+		default:
+			return nil
+		}
+		types = append(types, ev.Type)
+		return nil
+	})
+	require.ErrorIs(t, err, errStopRecv)
+	assert.Equal(t, []bridgev1.AttachEventType{
+		bridgev1.AttachEventType_ATTACH_EVENT_TYPE_THINKING,
+		bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT,
+		bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE,
+	}, types)
+	assert.Equal(t, "REPLAY_THINKINGREPLAY_OUTPUT", text.String())
+
+	_, err = client.StopSession(ctx, &bridgev1.StopSessionRequest{SessionId: sessionID, Force: true})
+	require.NoError(t, err)
+}
@@ -105,13 +105,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	var interrupts interruptTracker
 	sigCh := make(chan os.Signal, 2)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGWINCH)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	setupSigwinch(sigCh)
 	defer signal.Stop(sigCh)
 	go func() {
 		for sig := range sigCh {
-			switch sig {
-			case syscall.SIGWINCH:
+			switch {
+			case isSigwinch(sig):
 				cols, rows := currentTTYSize()
 				_, _ = client.ResizeSession(context.Background(), &bridgev1.ResizeSessionRequest{
 					SessionId: sessionID,
@@ -119,6 +121,18 @@ func main() {
 					Cols:      cols,
 					Rows:      rows,
 				})
+			case sig == os.Interrupt:
+				// Ctrl-C while the agent is streaming aborts the response;
+				// pressed again, or while idle, it quits.
+				if interrupts.shouldCancel(time.Now()) {
+					_, _ = client.CancelResponse(context.Background(), &bridgev1.CancelResponseRequest{
+						SessionId: sessionID,
+						ClientId:  stream.ClientID(),
+					})
+					fmt.Fprint(os.Stderr, "\r\n[bridge] response cancelled; press Ctrl-C again to quit\r\n")
+					continue
+				}
+				fallthrough
 			default:
 				cancel()
 				stopCtx, stopCancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -157,8 +171,12 @@ func main() {
 	err = stream.RecvAll(ctx, func(ev *bridgev1.AttachSessionEvent) error {
 		switch ev.Type {
 		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:
+			interrupts.output(time.Now())
 			_, err := os.Stdout.Write(ev.Payload)
 			return err
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE:
+			interrupts.responseComplete()
+			return nil
 		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_REPLAY_GAP:
 			_, err := fmt.Fprintf(os.Stderr, "\r\n[bridge] replay gap: oldest=%d last=%d\r\n", ev.OldestSeq, ev.LastSeq)
 			return err
@@ -175,6 +193,42 @@ func main() {
 	}
 }
 
+// streamingWindow is how recently output must have arrived for Ctrl-C to
+// cancel the response instead of quitting.
+const streamingWindow = 2 * time.Second
+
+// interruptTracker decides what Ctrl-C does: cancel the agent's response
+// while it is streaming, and quit when it is idle or Ctrl-C is repeated.
+type interruptTracker struct {
+	mu         sync.Mutex
+	lastOutput time.Time
+	lastCancel time.Time
+}
+
+func (t *interruptTracker) output(now time.Time) {
+	t.mu.Lock()
+	t.lastOutput = now
+	t.mu.Unlock()
+}
+
+func (t *interruptTracker) responseComplete() {
+	t.mu.Lock()
+	t.lastOutput = time.Time{}
+	t.mu.Unlock()
+}
+
+// shouldCancel reports whether Ctrl-C at now should cancel the response
+// rather than quit.
+func (t *interruptTracker) shouldCancel(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.lastOutput) >= streamingWindow || now.Sub(t.lastCancel) < streamingWindow {
+		return false
+	}
+	t.lastCancel = now
+	return true
+}
+
 func setRawTTY() (func(), error) {
 	if _, err := os.Stat("/dev/tty"); err != nil {
 		return func() {}, err
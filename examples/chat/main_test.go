@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"testing"
+	"time"
 )
 
 func TestBytesTrimSpace(t *testing.T) {
@@ -46,3 +47,33 @@ func TestNormalizeTTYInput(t *testing.T) {
 		t.Fatal("normalizeTTYInput should return a copy instead of mutating the input slice")
 	}
 }
+
+func TestInterruptTracker(t *testing.T) {
+	t.Parallel()
+
+	var tr interruptTracker
+	start := time.Now()
+	if tr.shouldCancel(start) {
+		t.Fatal("Ctrl-C before any output should quit")
+	}
+
+	tr.output(start)
+	if !tr.shouldCancel(start.Add(time.Second)) {
+		t.Fatal("Ctrl-C while streaming should cancel the response")
+	}
+	tr.output(start.Add(1500 * time.Millisecond))
+	if tr.shouldCancel(start.Add(2 * time.Second)) {
+		t.Fatal("a second Ctrl-C right after cancelling should quit")
+	}
+
+	tr.output(start.Add(10 * time.Second))
+	if !tr.shouldCancel(start.Add(11 * time.Second)) {
+		t.Fatal("Ctrl-C during a later response should cancel it")
+	}
+
+	tr.output(start.Add(20 * time.Second))
+	tr.responseComplete()
+	if tr.shouldCancel(start.Add(20 * time.Second)) {
+		t.Fatal("Ctrl-C after the response completed should quit")
+	}
+}
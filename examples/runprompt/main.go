@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	target := flag.String("target", "127.0.0.1:9445", "bridge gRPC address")
+	project := flag.String("project", "dev", "project ID")
+	provider := flag.String("provider", "claude-chat", "provider name")
+	timeout := flag.Duration("timeout", 15*time.Minute, "overall run timeout")
+	idle := flag.Duration("idle", 0, "finish once the agent has been silent this long (for PTY providers that never signal RESPONSE_COMPLETE); 0 disables")
+	prompt := flag.String("prompt", "", "prompt text (default: remaining arguments, or stdin)")
+	promptFile := flag.String("prompt-file", "", "read the prompt from this file")
+	format := flag.String("format", "text", "report format: text or json")
+	outputFile := flag.String("output-file", "", "write the report to this file instead of stdout")
+	annotations := flag.Bool("annotations", false, "print GitHub Actions annotations for findings and the verdict on stdout")
+	verdictPattern := flag.String("verdict-pattern", defaultVerdictPattern, "regexp whose first group captures PASS or FAIL from the agent output")
+	requireVerdict := flag.Bool("require-verdict", true, "exit with an error when the agent gives no verdict (-require-verdict=false treats it as a pass)")
+	scriptFile := flag.String("script", "", "YAML or JSON script of prompts with per-step expectations, run in order in one session")
+	cacert := flag.String("cacert", "", "path to CA bundle")
+	cert := flag.String("cert", "", "path to client certificate")
+	key := flag.String("key", "", "path to client private key")
+	servername := flag.String("servername", "", "TLS server name override")
+	jwtKey := flag.String("jwt-key", "", "path to Ed25519 JWT signing key")
+	jwtIssuer := flag.String("jwt-issuer", "", "JWT issuer claim")
+	jwtAudience := flag.String("jwt-audience", "bridge", "JWT audience claim")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "usage: runprompt [flags] <repo-path> [prompt...]\n       runprompt [flags] -script <file> <repo-path>")
+		return exitError
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q (want text or json)\n", *format)
+		return exitError
+	}
+	verdictRE, err := regexp.Compile(*verdictPattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid verdict pattern: %v\n", err)
+		return exitError
+	}
+	repoPath := flag.Arg(0)
+	var (
+		sc      *script
+		prompts []string
+	)
+	if *scriptFile != "" {
+		if sc, err = loadScript(*scriptFile); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load script: %v\n", err)
+			return exitError
+		}
+		prompts = sc.prompts()
+	} else {
+		text, err := readPrompt(*prompt, *promptFile, flag.Args()[1:], os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read prompt: %v\n", err)
+			return exitError
+		}
+		prompts = []string{text}
+	}
+
+	opts := []bridgeclient.Option{
+		bridgeclient.WithTarget(*target),
+		bridgeclient.WithTimeout(*timeout),
+	}
+	if *cacert != "" && *cert != "" && *key != "" {
+		opts = append(opts, bridgeclient.WithMTLS(bridgeclient.MTLSConfig{
+			CABundlePath: *cacert,
+			CertPath:     *cert,
+			KeyPath:      *key,
+			ServerName:   *servername,
+		}))
+	}
+	if *jwtKey != "" {
+		opts = append(opts, bridgeclient.WithJWT(bridgeclient.JWTConfig{
+			PrivateKeyPath: *jwtKey,
+			Issuer:         *jwtIssuer,
+			Audience:       *jwtAudience,
+		}))
+	}
+
+	client, err := bridgeclient.New(opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		return exitError
+	}
+	defer func() { _ = client.Close() }()
+	client.SetProject(*project)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	r := report{SessionID: uuid.NewString(), Provider: *provider}
+	answers, err := runTurns(ctx, client, *project, r.SessionID, *provider, repoPath, prompts, *idle)
+	r.Output = strings.Join(answers, "\n\n")
+	if sc != nil {
+		r.Steps = sc.evaluate(answers)
+	}
+	switch {
+	case err != nil:
+		r.Error = err.Error()
+		r.ExitCode = exitError
+	case sc != nil:
+		// A script's verdict comes from its expectations, not the agent.
+		r.Verdict = scriptVerdict(r.Steps)
+		r.ExitCode = exitCodeFor(r.Verdict, true)
+	default:
+		r.Verdict = parseVerdict(verdictRE, r.Output)
+		r.ExitCode = exitCodeFor(r.Verdict, *requireVerdict)
+	}
+	r.Findings = parseFindings(r.Output)
+
+	var w io.Writer = os.Stdout
+	// The Actions runner acts on workflow commands in stdout, so a text
+	// report is fenced off from it. JSON escapes newlines, so no agent text
+	// can start a line there.
+	stopCommands := *format == "text" && (*annotations || os.Getenv("GITHUB_ACTIONS") == "true")
+	if *outputFile != "" {
+		stopCommands = false
+		f, err := os.Create(*outputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create output file: %v\n", err)
+			return exitError
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	write := func(w io.Writer) error { return writeReport(w, *format, r) }
+	if stopCommands {
+		err = writeStopped(w, write)
+	} else {
+		err = write(w)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		return exitError
+	}
+	if *annotations {
+		if err := writeAnnotations(os.Stdout, r); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write annotations: %v\n", err)
+			return exitError
+		}
+	}
+	if r.Error != "" {
+		fmt.Fprintf(os.Stderr, "run failed: %s\n", r.Error)
+	}
+	return r.ExitCode
+}
+
+// readPrompt picks the prompt from --prompt, --prompt-file, the remaining
+// arguments, or stdin, in that order.
+func readPrompt(flagPrompt, file string, args []string, stdin io.Reader) (string, error) {
+	var text string
+	switch {
+	case flagPrompt != "":
+		text = flagPrompt
+	case file != "":
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		text = string(b)
+	case len(args) > 0:
+		text = strings.Join(args, " ")
+	default:
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", err
+		}
+		text = string(b)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("prompt is empty")
+	}
+	return text, nil
+}
+
+// runTurns starts a session and sends each prompt in turn once the session
+// is attached, sending the next one after the agent answers the previous. An
+// answer ends when the agent signals RESPONSE_COMPLETE or stays silent for
+// the idle window. It returns the answers it collected; the session is
+// always stopped.
+func runTurns(ctx context.Context, client *bridgeclient.Client, project, sessionID, provider, repoPath string, prompts []string, idle time.Duration) ([]string, error) {
+	if _, err := client.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId:   project,
+		SessionId:   sessionID,
+		RepoPath:    repoPath,
+		Provider:    provider,
+		InitialCols: 200,
+		InitialRows: 50,
+	}); err != nil {
+		return nil, fmt.Errorf("start session: %w", err)
+	}
+	defer func() {
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, _ = client.StopSession(stopCtx, &bridgev1.StopSessionRequest{SessionId: sessionID, Force: true})
+		stopCancel()
+	}()
+
+	stream, err := client.AttachSession(ctx, &bridgev1.AttachSessionRequest{
+		SessionId: sessionID,
+		ClientId:  uuid.NewString(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("attach session: %w", err)
+	}
+
+	// Events are relayed to this goroutine so the idle timer can end a turn
+	// while the stream stays open for the next one.
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := make(chan *bridgev1.AttachSessionEvent)
+	done := make(chan error, 1)
+	go func() {
+		done <- stream.RecvAll(streamCtx, func(ev *bridgev1.AttachSessionEvent) error {
+			select {
+			case events <- ev:
+				return nil
+			case <-streamCtx.Done():
+				return streamCtx.Err()
+			}
+		})
+	}()
+
+	idleTimer := time.NewTimer(time.Hour)
+	idleTimer.Stop()
+	defer idleTimer.Stop()
+	resetIdle := func() {
+		if idle > 0 {
+			idleTimer.Reset(idle)
+		}
+	}
+
+	var (
+		out     strings.Builder
+		answers = make([]string, 0, len(prompts))
+		sent    int
+	)
+	send := func() error {
+		if _, err := client.WriteInput(ctx, &bridgev1.WriteInputRequest{
+			SessionId: sessionID,
+			ClientId:  stream.ClientID(),
+			Data:      []byte(prompts[sent] + "\r"),
+		}); err != nil {
+			return fmt.Errorf("send prompt %d: %w", sent+1, err)
+		}
+		sent++
+		resetIdle()
+		return nil
+	}
+	// finish records the current answer and reports whether every prompt
+	// has been answered.
+	finish := func() bool {
+		answers = append(answers, strings.TrimSpace(out.String()))
+		out.Reset()
+		idleTimer.Stop()
+		return len(answers) == len(prompts)
+	}
+	// next ends the current turn and sends the following prompt, if any.
+	next := func() (bool, error) {
+		if finish() {
+			return true, nil
+		}
+		return false, send()
+	}
+
+	// fail returns err along with the answers so far, including a partial
+	// answer to the prompt in flight.
+	fail := func(err error) ([]string, error) {
+		if sent > len(answers) && out.Len() > 0 {
+			finish()
+		}
+		return answers, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fail(ctx.Err())
+		case err := <-done:
+			if err == nil {
+				err = errors.New("event stream ended before the agent answered")
+			}
+			return fail(err)
+		case <-idleTimer.C:
+			if sent > len(answers) {
+				if last, err := next(); last || err != nil {
+					return answers, err
+				}
+			}
+		case ev := <-events:
+			switch ev.Type {
+			case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED:
+				if sent == 0 {
+					if err := send(); err != nil {
+						return fail(err)
+					}
+				}
+			case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:
+				out.WriteString(cleanOutput(ev.Payload))
+				resetIdle()
+			case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE:
+				if sent > len(answers) {
+					if last, err := next(); last || err != nil {
+						return answers, err
+					}
+				}
+			case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_EXIT:
+				if sent > len(answers) && finish() {
+					return answers, nil
+				}
+				return answers, errors.New("session exited before every prompt was answered")
+			case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ERROR:
+				return fail(errors.New(ev.Error))
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParseVerdict(t *testing.T) {
+	t.Parallel()
+
+	re := regexp.MustCompile(defaultVerdictPattern)
+	cases := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{name: "pass", output: "looks good\nVERDICT: PASS\n", want: "pass"},
+		{name: "fail is case insensitive", output: "verdict: fail", want: "fail"},
+		{name: "last verdict wins", output: "VERDICT: FAIL\nrechecked\nVERDICT: PASS", want: "pass"},
+		{name: "mid-line mention ignored", output: "I will print VERDICT: PASS at the end", want: ""},
+		{name: "none", output: "no opinion", want: ""},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := parseVerdict(re, tc.output); got != tc.want {
+				t.Fatalf("parseVerdict(%q) = %q, want %q", tc.output, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExitCodeFor(t *testing.T) {
+	t.Parallel()
+
+	if got := exitCodeFor("pass", true); got != exitPass {
+		t.Fatalf("pass = %d, want %d", got, exitPass)
+	}
+	if got := exitCodeFor("fail", false); got != exitFail {
+		t.Fatalf("fail = %d, want %d", got, exitFail)
+	}
+	if got := exitCodeFor("", false); got != exitPass {
+		t.Fatalf("missing verdict = %d, want %d", got, exitPass)
+	}
+	if got := exitCodeFor("", true); got != exitError {
+		t.Fatalf("missing required verdict = %d, want %d", got, exitError)
+	}
+}
+
+func TestParseFindings(t *testing.T) {
+	t.Parallel()
+
+	out := "summary\ninternal/foo.go:12:3: warning: unchecked error\n  cmd/main.go:4: error: nil deref\nnot: a finding\n"
+	got := parseFindings(out)
+	want := []finding{
+		{File: "internal/foo.go", Line: 12, Column: 3, Level: "warning", Message: "unchecked error"},
+		{File: "cmd/main.go", Line: 4, Level: "error", Message: "nil deref"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseFindings = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("finding %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWriteReportJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, "json", report{SessionID: "s1", Provider: "claude-chat", Verdict: "fail", ExitCode: exitFail, Output: "VERDICT: FAIL"}); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("report is not JSON: %v", err)
+	}
+	if got["verdict"] != "fail" || got["exit_code"] != float64(exitFail) {
+		t.Fatalf("report = %v", got)
+	}
+	if findings, ok := got["findings"].([]any); !ok || len(findings) != 0 {
+		t.Fatalf("findings = %v, want empty array", got["findings"])
+	}
+	if err := writeReport(&buf, "yaml", report{}); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
+
+func TestWriteAnnotations(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	r := report{
+		Verdict:  "fail",
+		Findings: []finding{{File: "a,b.go", Line: 3, Column: 1, Level: "error", Message: "50% wrong\nreally"}},
+	}
+	if err := writeAnnotations(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("annotations = %q, want 2 lines", buf.String())
+	}
+	if want := "::error file=a%2Cb.go,line=3,col=1::50%25 wrong%0Areally"; lines[0] != want {
+		t.Fatalf("finding annotation = %q, want %q", lines[0], want)
+	}
+	if want := "::error title=ai-agent-bridge::agent verdict: FAIL"; lines[1] != want {
+		t.Fatalf("verdict annotation = %q, want %q", lines[1], want)
+	}
+}
+
+func TestReadPrompt(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "prompt.txt")
+	if err := os.WriteFile(file, []byte("  from file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		flag  string
+		file  string
+		args  []string
+		stdin string
+		want  string
+	}{
+		{name: "flag wins", flag: "from flag", file: file, args: []string{"x"}, want: "from flag"},
+		{name: "file", file: file, args: []string{"x"}, want: "from file"},
+		{name: "args", args: []string{"review", "this"}, want: "review this"},
+		{name: "stdin", stdin: "from stdin\n", want: "from stdin"},
+	}
+	for _, tc := range cases {
+		got, err := readPrompt(tc.flag, tc.file, tc.args, strings.NewReader(tc.stdin))
+		if err != nil || got != tc.want {
+			t.Fatalf("%s: readPrompt = %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+	if _, err := readPrompt("", "", nil, strings.NewReader("  ")); err == nil {
+		t.Fatal("empty prompt accepted")
+	}
+}
+
+func TestLoadScript(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "script.yaml")
+	if err := os.WriteFile(yamlFile, []byte(`steps:
+  - name: greet
+    prompt: say hello
+    expect: ["(?i)hello"]
+  - prompt: now fail
+    reject: ["panic"]
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := loadScript(yamlFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sc.prompts(); len(got) != 2 || got[0] != "say hello" || got[1] != "now fail" {
+		t.Fatalf("prompts = %q", got)
+	}
+	if sc.Steps[1].Name != "step 2" {
+		t.Fatalf("default step name = %q, want %q", sc.Steps[1].Name, "step 2")
+	}
+
+	jsonFile := filepath.Join(dir, "script.json")
+	if err := os.WriteFile(jsonFile, []byte(`{"steps":[{"prompt":"hi","expect":["ok"]}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadScript(jsonFile); err != nil {
+		t.Fatalf("JSON script rejected: %v", err)
+	}
+
+	for name, body := range map[string]string{
+		"no steps":      "steps: []\n",
+		"empty prompt":  "steps:\n  - prompt: \"  \"\n",
+		"bad expect":    "steps:\n  - prompt: hi\n    expect: [\"(\"]\n",
+		"bad reject":    "steps:\n  - prompt: hi\n    reject: [\"[\"]\n",
+		"invalid yaml":  "steps: [",
+		"wrong shape":   "steps: hello\n",
+		"missing field": "{}\n",
+	} {
+		file := filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".yaml")
+		if err := os.WriteFile(file, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadScript(file); err == nil {
+			t.Fatalf("%s: script accepted", name)
+		}
+	}
+}
+
+func TestScriptEvaluate(t *testing.T) {
+	t.Parallel()
+
+	sc := &script{Steps: []scriptStep{
+		{Name: "one", Prompt: "a", expect: []*regexp.Regexp{regexp.MustCompile(`done`)}},
+		{Name: "two", Prompt: "b", reject: []*regexp.Regexp{regexp.MustCompile(`(?i)error`)}},
+		{Name: "three", Prompt: "c"},
+	}}
+	results := sc.evaluate([]string{"all done", "Error: nope"})
+	if !results[0].Passed {
+		t.Fatalf("step one = %+v, want pass", results[0])
+	}
+	if results[1].Passed || len(results[1].Failures) != 1 {
+		t.Fatalf("step two = %+v, want one failure", results[1])
+	}
+	if results[2].Passed || results[2].Failures[0] != "not run" {
+		t.Fatalf("step three = %+v, want not run", results[2])
+	}
+	if got := scriptVerdict(results); got != "fail" {
+		t.Fatalf("verdict = %q, want fail", got)
+	}
+	if got := scriptVerdict(results[:1]); got != "pass" {
+		t.Fatalf("verdict = %q, want pass", got)
+	}
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, "text", report{Steps: results}); err != nil {
+		t.Fatal(err)
+	}
+	want := "PASS 1 one\nFAIL 2 two\n    expected output not to match \"(?i)error\"\nFAIL 3 three\n    not run\n"
+	if buf.String() != want {
+		t.Fatalf("text report = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteStopped(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	r := report{Output: "::error::injected\n::add-mask::x"}
+	err := writeStopped(&buf, func(w io.Writer) error { return writeReport(w, "text", r) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("output = %q, want 4 lines", buf.String())
+	}
+	token, ok := strings.CutPrefix(lines[0], "::stop-commands::")
+	if !ok || len(token) != 32 {
+		t.Fatalf("first line = %q, want ::stop-commands::<token>", lines[0])
+	}
+	if strings.Contains(r.Output, token) {
+		t.Fatal("token appears in the output it fences")
+	}
+	if want := "::" + token + "::"; lines[3] != want {
+		t.Fatalf("last line = %q, want %q", lines[3], want)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Exit codes. A CI job fails when the agent says so, and distinguishes that
+// from the run itself going wrong.
+const (
+	exitPass  = 0
+	exitFail  = 1
+	exitError = 2
+)
+
+var (
+	// ansiEscape matches the CSI and two-character escape sequences a PTY
+	// agent emits.
+	ansiEscape = regexp.MustCompile(`\x1b(?:\[[0-9;?=<>]*[a-zA-Z~]|[@-Z\x5c-_])`)
+	// findingLine matches compiler-style findings such as
+	// "internal/foo.go:12:3: warning: unchecked error".
+	findingLine = regexp.MustCompile(`^\s*([^\s:]+):(\d+)(?::(\d+))?:\s*(error|warning|notice):\s*(.+?)\s*$`)
+)
+
+// defaultVerdictPattern matches a "VERDICT: PASS" or "VERDICT: FAIL" line.
+const defaultVerdictPattern = `(?im)^\s*VERDICT:\s*(PASS|FAIL)\b`
+
+// finding is one file-level annotation reported by the agent.
+type finding struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// report is the outcome of one run. It is what --format json prints.
+type report struct {
+	SessionID string       `json:"session_id"`
+	Provider  string       `json:"provider"`
+	Verdict   string       `json:"verdict"`
+	ExitCode  int          `json:"exit_code"`
+	Output    string       `json:"output"`
+	Findings  []finding    `json:"findings"`
+	Steps     []stepResult `json:"steps,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// cleanOutput turns raw PTY bytes into plain text.
+func cleanOutput(b []byte) string {
+	s := ansiEscape.ReplaceAllString(string(b), "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "")
+}
+
+// parseVerdict returns "pass" or "fail" from the last line in output that
+// matches pattern, or "" when the agent gave no verdict. pattern must have
+// one capture group holding PASS or FAIL.
+func parseVerdict(pattern *regexp.Regexp, output string) string {
+	matches := pattern.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 || len(matches[len(matches)-1]) < 2 {
+		return ""
+	}
+	return strings.ToLower(matches[len(matches)-1][1])
+}
+
+// parseFindings extracts compiler-style finding lines from output.
+func parseFindings(output string) []finding {
+	var out []finding
+	for _, line := range strings.Split(output, "\n") {
+		m := findingLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		f := finding{File: m[1], Level: m[4], Message: m[5]}
+		f.Line, _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			f.Column, _ = strconv.Atoi(m[3])
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// exitCodeFor maps a verdict to the process exit code. A missing verdict
+// passes unless requireVerdict is set.
+func exitCodeFor(verdict string, requireVerdict bool) int {
+	switch verdict {
+	case "pass":
+		return exitPass
+	case "fail":
+		return exitFail
+	default:
+		if requireVerdict {
+			return exitError
+		}
+		return exitPass
+	}
+}
+
+// writeReport writes r in the given format ("text" or "json").
+func writeReport(w io.Writer, format string, r report) error {
+	switch format {
+	case "json":
+		if r.Findings == nil {
+			r.Findings = []finding{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "text":
+		if len(r.Steps) > 0 {
+			return writeSteps(w, r.Steps)
+		}
+		out := r.Output
+		if out != "" && !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		_, err := io.WriteString(w, out)
+		return err
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// writeSteps writes a one-line result per script step, followed by the
+// reasons any step failed.
+func writeSteps(w io.Writer, steps []stepResult) error {
+	for i, st := range steps {
+		status := "PASS"
+		if !st.Passed {
+			status = "FAIL"
+		}
+		if _, err := fmt.Fprintf(w, "%s %d %s\n", status, i+1, st.Name); err != nil {
+			return err
+		}
+		for _, f := range st.Failures {
+			if _, err := fmt.Fprintf(w, "    %s\n", f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeAnnotations writes GitHub Actions workflow commands so findings show
+// up inline on the pull request and the verdict in the job log.
+func writeAnnotations(w io.Writer, r report) error {
+	for _, f := range r.Findings {
+		props := "file=" + escapeProperty(f.File) + ",line=" + strconv.Itoa(f.Line)
+		if f.Column > 0 {
+			props += ",col=" + strconv.Itoa(f.Column)
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", f.Level, props, escapeData(f.Message)); err != nil {
+			return err
+		}
+	}
+	for _, st := range r.Steps {
+		if st.Passed {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "::error title=%s::%s\n", escapeProperty(st.Name), escapeData(strings.Join(st.Failures, "\n"))); err != nil {
+			return err
+		}
+	}
+	var line string
+	switch {
+	case r.Error != "":
+		line = "::error title=ai-agent-bridge::" + escapeData(r.Error)
+	case r.Verdict == "fail":
+		line = "::error title=ai-agent-bridge::agent verdict: FAIL"
+	case r.Verdict == "pass":
+		line = "::notice title=ai-agent-bridge::agent verdict: PASS"
+	default:
+		line = "::warning title=ai-agent-bridge::agent gave no verdict"
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// writeStopped runs write with GitHub Actions workflow commands disabled on
+// w, so agent output cannot inject "::" commands of its own into the job.
+// The resume token is random, so the output cannot guess it and turn
+// commands back on early.
+func writeStopped(w io.Writer, write func(io.Writer) error) error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(b)
+	if _, err := fmt.Fprintf(w, "::stop-commands::%s\n", token); err != nil {
+		return err
+	}
+	if err := write(w); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "::%s::\n", token)
+	return err
+}
+
+// escapeData escapes a workflow command message.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// script is an ordered list of prompts sent to one session, each with
+// expectations about the agent's answer. It is read from YAML or JSON.
+type script struct {
+	Steps []scriptStep `yaml:"steps"`
+}
+
+// scriptStep is one turn of a script. Every Expect pattern must match the
+// agent's answer and no Reject pattern may.
+type scriptStep struct {
+	Name   string   `yaml:"name"`
+	Prompt string   `yaml:"prompt"`
+	Expect []string `yaml:"expect"`
+	Reject []string `yaml:"reject"`
+
+	expect []*regexp.Regexp
+	reject []*regexp.Regexp
+}
+
+// stepResult is the outcome of one script step in the report.
+type stepResult struct {
+	Name     string   `json:"name"`
+	Prompt   string   `json:"prompt"`
+	Output   string   `json:"output"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// loadScript reads and validates a script file. JSON is accepted because it
+// is a subset of YAML.
+func loadScript(path string) (*script, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s script
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(s.Steps) == 0 {
+		return nil, errors.New("script has no steps")
+	}
+	for i := range s.Steps {
+		st := &s.Steps[i]
+		if st.Name == "" {
+			st.Name = fmt.Sprintf("step %d", i+1)
+		}
+		st.Prompt = strings.TrimSpace(st.Prompt)
+		if st.Prompt == "" {
+			return nil, fmt.Errorf("%s: prompt is empty", st.Name)
+		}
+		if st.expect, err = compilePatterns(st.Expect); err != nil {
+			return nil, fmt.Errorf("%s: expect: %w", st.Name, err)
+		}
+		if st.reject, err = compilePatterns(st.Reject); err != nil {
+			return nil, fmt.Errorf("%s: reject: %w", st.Name, err)
+		}
+	}
+	return &s, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// prompts returns the step prompts in order.
+func (s *script) prompts() []string {
+	out := make([]string, len(s.Steps))
+	for i, st := range s.Steps {
+		out[i] = st.Prompt
+	}
+	return out
+}
+
+// evaluate checks each step against the matching answer in outputs. Steps
+// without an answer, because the run ended early, fail as not run.
+func (s *script) evaluate(outputs []string) []stepResult {
+	results := make([]stepResult, len(s.Steps))
+	for i, st := range s.Steps {
+		res := stepResult{Name: st.Name, Prompt: st.Prompt}
+		if i >= len(outputs) {
+			res.Failures = []string{"not run"}
+			results[i] = res
+			continue
+		}
+		res.Output = outputs[i]
+		for _, re := range st.expect {
+			if !re.MatchString(res.Output) {
+				res.Failures = append(res.Failures, fmt.Sprintf("expected output to match %q", re.String()))
+			}
+		}
+		for _, re := range st.reject {
+			if re.MatchString(res.Output) {
+				res.Failures = append(res.Failures, fmt.Sprintf("expected output not to match %q", re.String()))
+			}
+		}
+		res.Passed = len(res.Failures) == 0
+		results[i] = res
+	}
+	return results
+}
+
+// scriptVerdict is "pass" when every step passed and "fail" otherwise.
+func scriptVerdict(results []stepResult) string {
+	for _, r := range results {
+		if !r.Passed {
+			return "fail"
+		}
+	}
+	return "pass"
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+)
+
+// finalizer keeps an AgentSession around until its bridge session is
+// stopped.
+const finalizer = crdGroup + "/stop-session"
+
+// Phases reported in AgentSession status.
+const (
+	phasePending = "Pending"
+	phaseRunning = "Running"
+	phaseStopped = "Stopped"
+	phaseFailed  = "Failed"
+)
+
+// bridgeAPI is the part of bridgeclient.Client the controller uses.
+type bridgeAPI interface {
+	StartSession(context.Context, *bridgev1.StartSessionRequest) (*bridgev1.StartSessionResponse, error)
+	StopSession(context.Context, *bridgev1.StopSessionRequest) (*bridgev1.StopSessionResponse, error)
+	GetSession(context.Context, *bridgev1.GetSessionRequest) (*bridgev1.GetSessionResponse, error)
+}
+
+// kubeAPI is the part of kubeClient the controller uses.
+type kubeAPI interface {
+	list(ctx context.Context, namespace string) ([]agentSession, error)
+	patchFinalizers(ctx context.Context, s agentSession, finalizers []string) error
+	patchStatus(ctx context.Context, s agentSession, st agentSessionStatus) error
+}
+
+type controller struct {
+	kube      kubeAPI
+	bridge    bridgeAPI
+	namespace string
+	logger    *slog.Logger
+}
+
+// run reconciles every AgentSession each interval until ctx is done.
+func (c *controller) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.reconcileAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *controller) reconcileAll(ctx context.Context) {
+	items, err := c.kube.list(ctx, c.namespace)
+	if err != nil {
+		c.logger.Error("list agentsessions", "error", err)
+		return
+	}
+	for _, s := range items {
+		if err := c.reconcile(ctx, s); err != nil {
+			c.logger.Warn("reconcile agentsession", "name", s.Metadata.Name, "error", err)
+		}
+	}
+}
+
+// reconcile drives one AgentSession towards its desired state. The bridge
+// session ID is the object's UID, so StartSession is idempotent across
+// controller restarts and a recreated object gets a new session.
+func (c *controller) reconcile(ctx context.Context, s agentSession) error {
+	sessionID := s.Metadata.UID
+	hasFinalizer := slices.Contains(s.Metadata.Finalizers, finalizer)
+
+	if s.Metadata.DeletionTimestamp != "" {
+		if !hasFinalizer {
+			return nil
+		}
+		if s.Status.SessionID != "" {
+			_, err := c.bridge.StopSession(ctx, &bridgev1.StopSessionRequest{SessionId: sessionID})
+			if err != nil && !errors.Is(err, bridgeclient.ErrSessionNotFound) {
+				return err
+			}
+		}
+		rest := slices.DeleteFunc(slices.Clone(s.Metadata.Finalizers), func(f string) bool { return f == finalizer })
+		return c.kube.patchFinalizers(ctx, s, rest)
+	}
+
+	if !hasFinalizer {
+		// Add the finalizer before starting anything, so a session is
+		// never started for an object that can vanish without a stop.
+		return c.kube.patchFinalizers(ctx, s, append(slices.Clone(s.Metadata.Finalizers), finalizer))
+	}
+
+	if s.Status.SessionID == "" {
+		req := &bridgev1.StartSessionRequest{
+			ProjectId: s.Spec.Project,
+			SessionId: sessionID,
+			Provider:  s.Spec.Provider,
+			RepoPath:  s.Spec.RepoPath,
+			AgentOpts: s.Spec.AgentOpts,
+		}
+		if s.Spec.Repo != nil {
+			req.RepoSource = &bridgev1.RepoSource{Url: s.Spec.Repo.URL, Ref: s.Spec.Repo.Ref}
+		}
+		_, err := c.bridge.StartSession(ctx, req)
+		switch {
+		case err == nil, errors.Is(err, bridgeclient.ErrSessionAlreadyExists):
+			return c.setStatus(ctx, s, agentSessionStatus{Phase: phaseRunning, SessionID: sessionID})
+		case errors.Is(err, bridgeclient.ErrPermissionDenied), errors.Is(err, bridgeclient.ErrUnauthorized):
+			// Retrying will not help until the spec or RBAC changes.
+			return c.setStatus(ctx, s, agentSessionStatus{Phase: phaseFailed, Message: err.Error()})
+		default:
+			_ = c.setStatus(ctx, s, agentSessionStatus{Phase: phasePending, Message: err.Error()})
+			return err
+		}
+	}
+
+	if s.Status.Phase == phaseStopped || s.Status.Phase == phaseFailed {
+		return nil
+	}
+	info, err := c.bridge.GetSession(ctx, &bridgev1.GetSessionRequest{SessionId: sessionID})
+	if errors.Is(err, bridgeclient.ErrSessionNotFound) {
+		return c.setStatus(ctx, s, agentSessionStatus{Phase: phaseStopped, SessionID: sessionID, Message: "session no longer exists on the bridge"})
+	}
+	if err != nil {
+		return err
+	}
+	st := agentSessionStatus{Phase: phaseRunning, SessionID: sessionID}
+	switch info.Status {
+	case bridgev1.SessionStatus_SESSION_STATUS_STOPPED:
+		st.Phase = phaseStopped
+	case bridgev1.SessionStatus_SESSION_STATUS_FAILED:
+		st.Phase = phaseFailed
+		st.Message = info.Error
+	}
+	return c.setStatus(ctx, s, st)
+}
+
+// setStatus patches the status only when it changed, so steady-state polls
+// do not write to the API server.
+func (c *controller) setStatus(ctx context.Context, s agentSession, st agentSessionStatus) error {
+	if s.Status == st {
+		return nil
+	}
+	c.logger.Info("agentsession status", "name", s.Metadata.Name, "phase", st.Phase, "session_id", st.SessionID)
+	return c.kube.patchStatus(ctx, s, st)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	crdGroup    = "bridge.markcallen.dev"
+	crdVersion  = "v1alpha1"
+	crdResource = "agentsessions"
+)
+
+// agentSession is the subset of the AgentSession custom resource the
+// controller reads and writes.
+type agentSession struct {
+	Metadata objectMeta         `json:"metadata"`
+	Spec     agentSessionSpec   `json:"spec"`
+	Status   agentSessionStatus `json:"status"`
+}
+
+type objectMeta struct {
+	Name              string   `json:"name"`
+	Namespace         string   `json:"namespace"`
+	UID               string   `json:"uid"`
+	ResourceVersion   string   `json:"resourceVersion,omitempty"`
+	DeletionTimestamp string   `json:"deletionTimestamp,omitempty"`
+	Finalizers        []string `json:"finalizers,omitempty"`
+}
+
+type agentSessionSpec struct {
+	Project   string            `json:"project"`
+	Provider  string            `json:"provider"`
+	RepoPath  string            `json:"repoPath,omitempty"`
+	Repo      *repoSpec         `json:"repo,omitempty"`
+	AgentOpts map[string]string `json:"agentOpts,omitempty"`
+}
+
+type repoSpec struct {
+	URL string `json:"url"`
+	Ref string `json:"ref,omitempty"`
+}
+
+type agentSessionStatus struct {
+	Phase     string `json:"phase,omitempty"`
+	SessionID string `json:"sessionId,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// kubeClient talks to the Kubernetes API with the pod's service account.
+// It only needs list and patch on agentsessions and agentsessions/status.
+type kubeClient struct {
+	baseURL   string
+	tokenPath string
+	http      *http.Client
+}
+
+// inClusterClient builds a kubeClient from the service account Kubernetes
+// mounts into every pod.
+func inClusterClient(apiServer, saDir string) (*kubeClient, error) {
+	pem, err := os.ReadFile(saDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("read service account ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("service account ca: no certificates found")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool}
+	return &kubeClient{
+		baseURL:   strings.TrimSuffix(apiServer, "/"),
+		tokenPath: saDir + "/token",
+		http:      &http.Client{Transport: transport},
+	}, nil
+}
+
+func (k *kubeClient) resourceURL(namespace, name, sub string) string {
+	u := fmt.Sprintf("%s/apis/%s/%s/namespaces/%s/%s", k.baseURL, crdGroup, crdVersion, url.PathEscape(namespace), crdResource)
+	if name != "" {
+		u += "/" + url.PathEscape(name)
+	}
+	if sub != "" {
+		u += "/" + sub
+	}
+	return u
+}
+
+// list returns the AgentSessions in namespace.
+func (k *kubeClient) list(ctx context.Context, namespace string) ([]agentSession, error) {
+	var out struct {
+		Items []agentSession `json:"items"`
+	}
+	if err := k.do(ctx, http.MethodGet, k.resourceURL(namespace, "", ""), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Items, nil
+}
+
+// patchFinalizers replaces the object's finalizers. The resourceVersion
+// makes the patch fail if the object changed since it was read.
+func (k *kubeClient) patchFinalizers(ctx context.Context, s agentSession, finalizers []string) error {
+	if finalizers == nil {
+		finalizers = []string{}
+	}
+	patch := map[string]any{"metadata": map[string]any{
+		"finalizers":      finalizers,
+		"resourceVersion": s.Metadata.ResourceVersion,
+	}}
+	return k.do(ctx, http.MethodPatch, k.resourceURL(s.Metadata.Namespace, s.Metadata.Name, ""), patch, nil)
+}
+
+// patchStatus writes the status subresource.
+func (k *kubeClient) patchStatus(ctx context.Context, s agentSession, st agentSessionStatus) error {
+	patch := map[string]any{"status": st}
+	return k.do(ctx, http.MethodPatch, k.resourceURL(s.Metadata.Namespace, s.Metadata.Name, "status"), patch, nil)
+}
+
+func (k *kubeClient) do(ctx context.Context, method, u string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	}
+	// The kubelet rotates the token in place, so it is read per request.
+	if k.tokenPath != "" {
+		token, err := os.ReadFile(k.tokenPath)
+		if err != nil {
+			return fmt.Errorf("read service account token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	resp, err := k.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: %s: %s", method, u, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+)
+
+// serviceAccountDir is where Kubernetes mounts the pod's service account.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+func main() {
+	target := flag.String("target", "ai-agent-bridge:9445", "bridge gRPC address")
+	namespace := flag.String("namespace", "", "namespace to watch (default: the pod's own)")
+	apiServer := flag.String("api-server", "https://kubernetes.default.svc", "Kubernetes API server URL")
+	interval := flag.Duration("interval", 10*time.Second, "how often AgentSessions are reconciled")
+	timeout := flag.Duration("timeout", 30*time.Second, "bridge RPC timeout")
+	tokenFile := flag.String("token-file", "/var/run/secrets/bridge/token", "projected service account token sent to the bridge")
+	cacert := flag.String("cacert", "", "path to CA bundle")
+	cert := flag.String("cert", "", "path to client certificate")
+	key := flag.String("key", "", "path to client private key")
+	servername := flag.String("servername", "", "TLS server name override")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	if *namespace == "" {
+		data, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "-namespace not set and not running in a pod: %v\n", err)
+			os.Exit(1)
+		}
+		*namespace = strings.TrimSpace(string(data))
+	}
+
+	kube, err := inClusterClient(*apiServer, serviceAccountDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kubernetes client: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := []bridgeclient.Option{
+		bridgeclient.WithTarget(*target),
+		bridgeclient.WithTimeout(*timeout),
+		bridgeclient.WithTokenFile(*tokenFile),
+	}
+	if *cacert != "" && *cert != "" && *key != "" {
+		opts = append(opts, bridgeclient.WithMTLS(bridgeclient.MTLSConfig{
+			CABundlePath: *cacert,
+			CertPath:     *cert,
+			KeyPath:      *key,
+			ServerName:   *servername,
+		}))
+	}
+	client, err := bridgeclient.New(opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = client.Close() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	c := &controller{kube: kube, bridge: client, namespace: *namespace, logger: logger}
+	logger.Info("reconciling agentsessions", "namespace", *namespace, "target", *target)
+	c.run(ctx, *interval)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+)
+
+// fakeAPIServer stores AgentSessions and applies merge patches to them.
+type fakeAPIServer struct {
+	mu      sync.Mutex
+	objects map[string]*agentSession
+	patches []string
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	const prefix = "/apis/" + crdGroup + "/" + crdVersion + "/namespaces/default/" + crdResource
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == prefix:
+		var items []agentSession
+		for _, name := range slices.Sorted(maps.Keys(f.objects)) {
+			items = append(items, *f.objects[name])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items})
+	case r.Method == http.MethodPatch:
+		if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+			http.Error(w, "bad content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.patches = append(f.patches, r.URL.Path)
+		name := strings.TrimPrefix(r.URL.Path, prefix+"/")
+		name, status := strings.CutSuffix(name, "/status")
+		obj, ok := f.objects[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var patch agentSession
+		if err := json.Unmarshal(body, &patch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if status {
+			obj.Status = patch.Status
+		} else {
+			obj.Metadata.Finalizers = patch.Metadata.Finalizers
+			if obj.Metadata.DeletionTimestamp != "" && len(obj.Metadata.Finalizers) == 0 {
+				delete(f.objects, name)
+			}
+		}
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+type fakeBridge struct {
+	started []*bridgev1.StartSessionRequest
+	stopped []string
+	status  bridgev1.SessionStatus
+	missing bool
+}
+
+func (b *fakeBridge) StartSession(_ context.Context, req *bridgev1.StartSessionRequest) (*bridgev1.StartSessionResponse, error) {
+	b.started = append(b.started, req)
+	return &bridgev1.StartSessionResponse{SessionId: req.SessionId}, nil
+}
+
+func (b *fakeBridge) StopSession(_ context.Context, req *bridgev1.StopSessionRequest) (*bridgev1.StopSessionResponse, error) {
+	b.stopped = append(b.stopped, req.SessionId)
+	return &bridgev1.StopSessionResponse{}, nil
+}
+
+func (b *fakeBridge) GetSession(_ context.Context, req *bridgev1.GetSessionRequest) (*bridgev1.GetSessionResponse, error) {
+	if b.missing {
+		return nil, bridgeclient.ErrSessionNotFound
+	}
+	return &bridgev1.GetSessionResponse{SessionId: req.SessionId, Status: b.status}, nil
+}
+
+func TestControllerLifecycle(t *testing.T) {
+	const uid = "6f1c2a52-0b7e-4f7e-9a53-0d2f6f1b9c11"
+	api := &fakeAPIServer{objects: map[string]*agentSession{
+		"review": {
+			Metadata: objectMeta{Name: "review", Namespace: "default", UID: uid},
+			Spec:     agentSessionSpec{Project: "alpha", Provider: "claude", Repo: &repoSpec{URL: "https://github.com/example/repo.git"}},
+		},
+	}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	fb := &fakeBridge{status: bridgev1.SessionStatus_SESSION_STATUS_RUNNING}
+	c := &controller{
+		kube:      &kubeClient{baseURL: srv.URL, http: srv.Client()},
+		bridge:    fb,
+		namespace: "default",
+		logger:    slog.New(slog.DiscardHandler),
+	}
+	ctx := context.Background()
+	obj := func() *agentSession {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		return api.objects["review"]
+	}
+
+	// The finalizer goes on before the session starts.
+	c.reconcileAll(ctx)
+	if !slices.Contains(obj().Metadata.Finalizers, finalizer) || len(fb.started) != 0 {
+		t.Fatalf("after first pass: finalizers=%v started=%d", obj().Metadata.Finalizers, len(fb.started))
+	}
+
+	c.reconcileAll(ctx)
+	if len(fb.started) != 1 {
+		t.Fatalf("started %d sessions, want 1", len(fb.started))
+	}
+	req := fb.started[0]
+	if req.SessionId != uid || req.ProjectId != "alpha" || req.Provider != "claude" || req.GetRepoSource().GetUrl() != "https://github.com/example/repo.git" {
+		t.Fatalf("StartSession request=%+v", req)
+	}
+	if st := obj().Status; st.Phase != phaseRunning || st.SessionID != uid {
+		t.Fatalf("status=%+v want Running", st)
+	}
+
+	// Steady state writes nothing.
+	patches := len(api.patches)
+	c.reconcileAll(ctx)
+	if len(api.patches) != patches || len(fb.started) != 1 {
+		t.Fatalf("steady state patched %d times and started %d sessions", len(api.patches)-patches, len(fb.started))
+	}
+
+	fb.status = bridgev1.SessionStatus_SESSION_STATUS_STOPPED
+	c.reconcileAll(ctx)
+	if st := obj().Status; st.Phase != phaseStopped {
+		t.Fatalf("status=%+v want Stopped", st)
+	}
+
+	api.mu.Lock()
+	api.objects["review"].Metadata.DeletionTimestamp = "2026-01-01T00:00:00Z"
+	api.mu.Unlock()
+	c.reconcileAll(ctx)
+	if !slices.Equal(fb.stopped, []string{uid}) {
+		t.Fatalf("stopped=%v want %s", fb.stopped, uid)
+	}
+	if obj() != nil {
+		t.Fatal("object still present after its finalizer was removed")
+	}
+}
+
+func TestControllerSessionGone(t *testing.T) {
+	const uid = "0b7e6f1c-2a52-4f7e-9a53-0d2f6f1b9c11"
+	api := &fakeAPIServer{objects: map[string]*agentSession{
+		"gone": {
+			Metadata: objectMeta{Name: "gone", Namespace: "default", UID: uid, Finalizers: []string{finalizer}},
+			Status:   agentSessionStatus{Phase: phaseRunning, SessionID: uid},
+		},
+	}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	c := &controller{
+		kube:      &kubeClient{baseURL: srv.URL, http: srv.Client()},
+		bridge:    &fakeBridge{missing: true},
+		namespace: "default",
+		logger:    slog.New(slog.DiscardHandler),
+	}
+	c.reconcileAll(context.Background())
+	if st := api.objects["gone"].Status; st.Phase != phaseStopped || st.Message == "" {
+		t.Fatalf("status=%+v want Stopped with a message", st)
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+)
+
+const (
+	// updateInterval throttles chat.update calls while a response streams;
+	// Slack rate-limits edits to roughly one per second per channel.
+	updateInterval = 1500 * time.Millisecond
+	// maxReplyLen keeps each reply comfortably under Slack's message size
+	// limit. Longer responses continue in a new threaded reply.
+	maxReplyLen = 3500
+	// seenEvents bounds the set of message timestamps remembered to drop the
+	// duplicate message/app_mention pair Slack sends for a mention.
+	seenEvents = 1024
+)
+
+var (
+	// ansiEscape matches the CSI and two-character escape sequences a PTY
+	// agent emits; Slack renders plain text only.
+	ansiEscape = regexp.MustCompile(`\x1b(?:\[[0-9;?=<>]*[a-zA-Z~]|[@-Z\x5c-_])`)
+	// mention matches a leading <@U123> user mention addressed to the bot.
+	mention = regexp.MustCompile(`^\s*<@[A-Z0-9]+>\s*`)
+)
+
+// bot maps Slack threads to bridge sessions. The first mention of the bot
+// starts a session for the thread; later messages in that thread become
+// session input, and agent output is posted back as threaded replies.
+type bot struct {
+	client   *bridgeclient.Client
+	slack    poster
+	project  string
+	provider string
+	repoPath string
+	allow    allowlist
+
+	mu      sync.Mutex
+	threads map[string]*thread
+	seen    map[string]struct{}
+	order   []string
+}
+
+func newBot(client *bridgeclient.Client, slack poster, project, provider, repoPath string, allow allowlist) *bot {
+	return &bot{
+		client:   client,
+		slack:    slack,
+		project:  project,
+		provider: provider,
+		repoPath: repoPath,
+		allow:    allow,
+		threads:  make(map[string]*thread),
+		seen:     make(map[string]struct{}),
+	}
+}
+
+// thread is one Slack thread bound to a bridge session.
+type thread struct {
+	channel   string
+	threadTS  string
+	sessionID string
+	clientID  string
+	reply     *replyBuffer
+}
+
+func threadKey(channel, threadTS string) string {
+	return channel + "/" + threadTS
+}
+
+// allowlist names the Slack users and channels the bot answers. Anyone who
+// can mention the bot would otherwise be able to run an agent in the repo.
+type allowlist struct {
+	users    map[string]struct{}
+	channels map[string]struct{}
+}
+
+// parseAllowlist builds an allowlist from comma-separated user and channel
+// IDs.
+func parseAllowlist(users, channels string) allowlist {
+	return allowlist{users: idSet(users), channels: idSet(channels)}
+}
+
+func idSet(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			set[id] = struct{}{}
+		}
+	}
+	return set
+}
+
+func (a allowlist) empty() bool {
+	return len(a.users) == 0 && len(a.channels) == 0
+}
+
+// permits reports whether a message from user in channel may reach the
+// agent: the user is listed, or the message was posted in a listed channel.
+func (a allowlist) permits(user, channel string) bool {
+	if _, ok := a.users[user]; ok {
+		return true
+	}
+	_, ok := a.channels[channel]
+	return ok
+}
+
+// handleEvent routes one Slack message event. It is called on its own
+// goroutine so the Events API request can be acknowledged immediately.
+func (b *bot) handleEvent(ctx context.Context, ev slackEvent) {
+	if ev.BotID != "" || ev.Subtype != "" || ev.User == "" {
+		return
+	}
+	if ev.Type != "message" && ev.Type != "app_mention" {
+		return
+	}
+	if !b.allow.permits(ev.User, ev.Channel) {
+		if ev.Type == "app_mention" {
+			slog.Warn("ignoring mention from user not in allowlist", "user", ev.User, "channel", ev.Channel)
+		}
+		return
+	}
+	if !b.markSeen(ev.Channel + "/" + ev.TS) {
+		return
+	}
+	text := strings.TrimSpace(mention.ReplaceAllString(ev.Text, ""))
+	if text == "" {
+		return
+	}
+
+	key := threadKey(ev.Channel, ev.threadTS())
+	b.mu.Lock()
+	t := b.threads[key]
+	b.mu.Unlock()
+	if t == nil {
+		// Only a mention starts a session; ordinary channel chatter is ignored.
+		if ev.Type != "app_mention" {
+			return
+		}
+		var err error
+		if t, err = b.startThread(ctx, ev.Channel, ev.threadTS()); err != nil {
+			slog.Error("start session failed", "channel", ev.Channel, "thread_ts", ev.threadTS(), "error", err)
+			_, _ = b.slack.PostMessage(ctx, ev.Channel, ev.threadTS(), fmt.Sprintf(":warning: could not start session: %v", err))
+			return
+		}
+	}
+
+	if _, err := b.client.WriteInput(ctx, &bridgev1.WriteInputRequest{
+		SessionId: t.sessionID,
+		ClientId:  t.clientID,
+		Data:      []byte(text + "\r"),
+	}); err != nil {
+		slog.Error("send input failed", "session_id", t.sessionID, "error", err)
+		_, _ = b.slack.PostMessage(ctx, t.channel, t.threadTS, fmt.Sprintf(":warning: could not send input: %v", err))
+	}
+}
+
+// markSeen records id and reports whether it was new.
+func (b *bot) markSeen(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.seen[id]; ok {
+		return false
+	}
+	b.seen[id] = struct{}{}
+	b.order = append(b.order, id)
+	if len(b.order) > seenEvents {
+		delete(b.seen, b.order[0])
+		b.order = b.order[1:]
+	}
+	return true
+}
+
+func (b *bot) startThread(ctx context.Context, channel, threadTS string) (*thread, error) {
+	t := &thread{
+		channel:   channel,
+		threadTS:  threadTS,
+		sessionID: uuid.NewString(),
+		clientID:  uuid.NewString(),
+		reply:     newReplyBuffer(b.slack, channel, threadTS),
+	}
+	if _, err := b.client.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId: b.project,
+		SessionId: t.sessionID,
+		RepoPath:  b.repoPath,
+		Provider:  b.provider,
+	}); err != nil {
+		return nil, err
+	}
+	stream, err := b.client.AttachSession(ctx, &bridgev1.AttachSessionRequest{
+		SessionId: t.sessionID,
+		ClientId:  t.clientID,
+	})
+	if err != nil {
+		_, _ = b.client.StopSession(ctx, &bridgev1.StopSessionRequest{SessionId: t.sessionID, Force: true})
+		return nil, err
+	}
+
+	key := threadKey(channel, threadTS)
+	b.mu.Lock()
+	b.threads[key] = t
+	b.mu.Unlock()
+	slog.Info("session started", "session_id", t.sessionID, "channel", channel, "thread_ts", threadTS)
+
+	go b.run(ctx, key, t, stream)
+	return t, nil
+}
+
+// run relays session events to the thread until the session exits or the
+// stream fails, then forgets the thread so the next mention starts afresh.
+func (b *bot) run(ctx context.Context, key string, t *thread, stream *bridgeclient.OutputStream) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		ticker := time.NewTicker(updateInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				t.reply.flush(runCtx)
+			}
+		}
+	}()
+
+	err := stream.RecvAll(runCtx, func(ev *bridgev1.AttachSessionEvent) error {
+		return t.handle(runCtx, ev)
+	})
+	t.reply.complete(ctx)
+	if err != nil && !errors.Is(err, errStreamDone) && ctx.Err() == nil {
+		slog.Warn("session stream ended", "session_id", t.sessionID, "error", err)
+		_, _ = b.slack.PostMessage(ctx, t.channel, t.threadTS, fmt.Sprintf(":warning: session stream ended: %v", err))
+	}
+
+	b.mu.Lock()
+	if b.threads[key] == t {
+		delete(b.threads, key)
+	}
+	b.mu.Unlock()
+}
+
+// handle applies one attach event to the thread's reply. It returns
+// errStreamDone once the session has exited.
+func (t *thread) handle(ctx context.Context, ev *bridgev1.AttachSessionEvent) error {
+	switch ev.Type {
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:
+		if ev.Replay {
+			return nil
+		}
+		t.reply.append(ctx, cleanOutput(ev.Payload))
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE:
+		t.reply.complete(ctx)
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_EXIT:
+		t.reply.complete(ctx)
+		_, _ = t.reply.slack.PostMessage(ctx, t.channel, t.threadTS, fmt.Sprintf("_session exited (code %d)_", ev.ExitCode))
+		return errStreamDone
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ERROR:
+		_, _ = t.reply.slack.PostMessage(ctx, t.channel, t.threadTS, ":warning: "+ev.Error)
+	}
+	return nil
+}
+
+// errStreamDone stops RecvAll without reporting a failure.
+var errStreamDone = errors.New("session exited")
+
+// cleanOutput turns raw PTY bytes into plain text suitable for Slack.
+func cleanOutput(b []byte) string {
+	s := ansiEscape.ReplaceAllString(string(b), "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "")
+}
+
+// replyBuffer accumulates a streaming response and mirrors it into a single
+// threaded reply, posting it on first flush and editing it thereafter.
+type replyBuffer struct {
+	slack    poster
+	channel  string
+	threadTS string
+
+	mu     sync.Mutex
+	ts     string // timestamp of the reply being edited; empty until posted
+	text   string // full text of the current reply
+	posted string // text as last sent to Slack
+}
+
+func newReplyBuffer(slack poster, channel, threadTS string) *replyBuffer {
+	return &replyBuffer{slack: slack, channel: channel, threadTS: threadTS}
+}
+
+// append adds streamed output. When the reply would exceed maxReplyLen it is
+// finalized and the remainder continues in a new reply.
+func (r *replyBuffer) append(ctx context.Context, s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.text += s
+	for len(r.text) > maxReplyLen {
+		head, rest := r.text[:maxReplyLen], r.text[maxReplyLen:]
+		r.text = head
+		r.flushLocked(ctx)
+		r.ts, r.text, r.posted = "", rest, ""
+	}
+}
+
+// flush sends any text not yet mirrored to Slack.
+func (r *replyBuffer) flush(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushLocked(ctx)
+}
+
+// complete flushes the reply and starts a fresh one for the next response.
+func (r *replyBuffer) complete(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushLocked(ctx)
+	r.ts, r.text, r.posted = "", "", ""
+}
+
+func (r *replyBuffer) flushLocked(ctx context.Context) {
+	text := strings.TrimSpace(r.text)
+	if text == "" || text == r.posted {
+		return
+	}
+	if r.ts == "" {
+		ts, err := r.slack.PostMessage(ctx, r.channel, r.threadTS, text)
+		if err != nil {
+			slog.Warn("post reply failed", "channel", r.channel, "thread_ts", r.threadTS, "error", err)
+			return
+		}
+		r.ts = ts
+	} else if err := r.slack.UpdateMessage(ctx, r.channel, r.ts, text); err != nil {
+		slog.Warn("update reply failed", "channel", r.channel, "ts", r.ts, "error", err)
+		return
+	}
+	r.posted = text
+}
+
+// stopAll stops every session the bot started.
+func (b *bot) stopAll(ctx context.Context) {
+	b.mu.Lock()
+	threads := make([]*thread, 0, len(b.threads))
+	for _, t := range b.threads {
+		threads = append(threads, t)
+	}
+	b.mu.Unlock()
+	for _, t := range threads {
+		_, _ = b.client.StopSession(ctx, &bridgev1.StopSessionRequest{SessionId: t.sessionID, Force: true})
+	}
+}
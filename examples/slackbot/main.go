@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+)
+
+// maxEventBody caps the size of an Events API request body.
+const maxEventBody = 1 << 20
+
+func main() {
+	target := flag.String("target", "127.0.0.1:9445", "bridge gRPC address")
+	project := flag.String("project", "dev", "project ID")
+	provider := flag.String("provider", "claude", "interactive provider name")
+	listen := flag.String("listen", ":3001", "address for the Slack Events API endpoint")
+	timeout := flag.Duration("timeout", 30*time.Second, "bridge RPC timeout")
+	allowUsers := flag.String("allow-users", "", "comma-separated Slack user IDs allowed to talk to the agent")
+	allowChannels := flag.String("allow-channels", "", "comma-separated Slack channel IDs in which anyone may talk to the agent")
+	cacert := flag.String("cacert", "", "path to CA bundle")
+	cert := flag.String("cert", "", "path to client certificate")
+	key := flag.String("key", "", "path to client private key")
+	servername := flag.String("servername", "", "TLS server name override")
+	jwtKey := flag.String("jwt-key", "", "path to Ed25519 JWT signing key")
+	jwtIssuer := flag.String("jwt-issuer", "", "JWT issuer claim")
+	jwtAudience := flag.String("jwt-audience", "bridge", "JWT audience claim")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "usage: slackbot [flags] <repo-path>")
+		os.Exit(1)
+	}
+	repoPath := flag.Arg(0)
+	allow := parseAllowlist(*allowUsers, *allowChannels)
+	if allow.empty() {
+		fmt.Fprintln(os.Stderr, "-allow-users or -allow-channels must be set")
+		os.Exit(1)
+	}
+
+	botToken := os.Getenv("SLACK_BOT_TOKEN")
+	signingSecret := os.Getenv("SLACK_SIGNING_SECRET")
+	if botToken == "" || signingSecret == "" {
+		fmt.Fprintln(os.Stderr, "SLACK_BOT_TOKEN and SLACK_SIGNING_SECRET must be set")
+		os.Exit(1)
+	}
+
+	opts := []bridgeclient.Option{
+		bridgeclient.WithTarget(*target),
+		bridgeclient.WithTimeout(*timeout),
+	}
+	if *cacert != "" && *cert != "" && *key != "" {
+		opts = append(opts, bridgeclient.WithMTLS(bridgeclient.MTLSConfig{
+			CABundlePath: *cacert,
+			CertPath:     *cert,
+			KeyPath:      *key,
+			ServerName:   *servername,
+		}))
+	}
+	if *jwtKey != "" {
+		opts = append(opts, bridgeclient.WithJWT(bridgeclient.JWTConfig{
+			PrivateKeyPath: *jwtKey,
+			Issuer:         *jwtIssuer,
+			Audience:       *jwtAudience,
+		}))
+	}
+
+	client, err := bridgeclient.New(opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = client.Close() }()
+	client.SetProject(*project)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	b := newBot(client, newSlackAPI(botToken), *project, *provider, repoPath, allow)
+	mux := http.NewServeMux()
+	mux.Handle("/slack/events", eventsHandler(ctx, signingSecret, b.handleEvent))
+	srv := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		b.stopAll(shutdownCtx)
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("slackbot listening", "addr", *listen, "provider", *provider, "repo_path", repoPath)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "server failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// eventsHandler serves the Slack Events API endpoint. It verifies the request
+// signature, answers URL verification challenges, and hands message events to
+// handle on a new goroutine so Slack receives its acknowledgement within the
+// three-second window.
+func eventsHandler(ctx context.Context, signingSecret string, handle func(context.Context, slackEvent)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxEventBody))
+		if err != nil {
+			http.Error(w, "read body", http.StatusBadRequest)
+			return
+		}
+		if err := verifySlackSignature(signingSecret, r.Header, body, time.Now()); err != nil {
+			slog.Warn("rejected slack request", "error", err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		var env slackEnvelope
+		if err := json.Unmarshal(body, &env); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		switch env.Type {
+		case "url_verification":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = io.WriteString(w, env.Challenge)
+			return
+		case "event_callback":
+			// Slack redelivers events it thinks were not acknowledged in
+			// time; the original is already being handled.
+			if r.Header.Get("X-Slack-Retry-Num") == "" {
+				go handle(ctx, env.Event)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signedRequest(t *testing.T, secret, body string, ts time.Time) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/slack/events", strings.NewReader(body))
+	stamp := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + stamp + ":" + body))
+	req.Header.Set("X-Slack-Request-Timestamp", stamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	body := `{"type":"event_callback"}`
+	req := signedRequest(t, "secret", body, now)
+	if err := verifySlackSignature("secret", req.Header, []byte(body), now); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := verifySlackSignature("other", req.Header, []byte(body), now); err == nil {
+		t.Fatal("signature with the wrong secret accepted")
+	}
+	if err := verifySlackSignature("secret", req.Header, []byte(body+" "), now); err == nil {
+		t.Fatal("signature over a modified body accepted")
+	}
+	if err := verifySlackSignature("secret", req.Header, []byte(body), now.Add(10*time.Minute)); err == nil {
+		t.Fatal("stale timestamp accepted")
+	}
+	if err := verifySlackSignature("secret", http.Header{}, []byte(body), now); err == nil {
+		t.Fatal("missing headers accepted")
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan slackEvent, 1)
+	h := eventsHandler(context.Background(), "secret", func(_ context.Context, ev slackEvent) {
+		events <- ev
+	})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, signedRequest(t, "secret", `{"type":"url_verification","challenge":"abc"}`, time.Now()))
+	if rec.Code != http.StatusOK || rec.Body.String() != "abc" {
+		t.Fatalf("url_verification = %d %q, want 200 %q", rec.Code, rec.Body.String(), "abc")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, signedRequest(t, "wrong", `{"type":"url_verification","challenge":"abc"}`, time.Now()))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad signature status = %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, signedRequest(t, "secret", `{"type":"event_callback","event":{"type":"app_mention","channel":"C1","user":"U1","text":"hi","ts":"1.2"}}`, time.Now()))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("event_callback status = %d, want 200", rec.Code)
+	}
+	select {
+	case ev := <-events:
+		if ev.Channel != "C1" || ev.threadTS() != "1.2" {
+			t.Fatalf("event = %+v, want channel C1 thread 1.2", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event was not dispatched")
+	}
+}
+
+func TestCleanOutput(t *testing.T) {
+	t.Parallel()
+
+	got := cleanOutput([]byte("\x1b[1mbold\x1b[0m line\r\nnext\rline"))
+	if want := "bold line\nnextline"; got != want {
+		t.Fatalf("cleanOutput = %q, want %q", got, want)
+	}
+}
+
+type fakePoster struct {
+	posts   []string
+	updates []string
+}
+
+func (f *fakePoster) PostMessage(_ context.Context, _, _, text string) (string, error) {
+	f.posts = append(f.posts, text)
+	return strconv.Itoa(len(f.posts)), nil
+}
+
+func (f *fakePoster) UpdateMessage(_ context.Context, _, _, text string) error {
+	f.updates = append(f.updates, text)
+	return nil
+}
+
+func TestReplyBuffer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := &fakePoster{}
+	r := newReplyBuffer(p, "C1", "1.0")
+
+	r.append(ctx, "hello")
+	r.flush(ctx)
+	r.flush(ctx)
+	r.append(ctx, " world")
+	r.complete(ctx)
+	if len(p.posts) != 1 || p.posts[0] != "hello" {
+		t.Fatalf("posts = %q, want [hello]", p.posts)
+	}
+	if len(p.updates) != 1 || p.updates[0] != "hello world" {
+		t.Fatalf("updates = %q, want [hello world]", p.updates)
+	}
+
+	r.append(ctx, "second")
+	r.complete(ctx)
+	if len(p.posts) != 2 || p.posts[1] != "second" {
+		t.Fatalf("after RESPONSE_COMPLETE the next response should start a new reply, posts = %q", p.posts)
+	}
+
+	r.append(ctx, strings.Repeat("x", maxReplyLen+10))
+	r.complete(ctx)
+	if len(p.posts) != 4 || len(p.posts[2]) != maxReplyLen || p.posts[3] != strings.Repeat("x", 10) {
+		t.Fatalf("long response was not split across replies: %d posts", len(p.posts))
+	}
+}
+
+func TestBotMarkSeen(t *testing.T) {
+	t.Parallel()
+
+	b := newBot(nil, &fakePoster{}, "dev", "claude", "/repo", allowlist{})
+	if !b.markSeen("C1/1.0") {
+		t.Fatal("first sighting reported as duplicate")
+	}
+	if b.markSeen("C1/1.0") {
+		t.Fatal("duplicate app_mention/message pair not detected")
+	}
+	for i := 0; i < seenEvents; i++ {
+		b.markSeen(strconv.Itoa(i))
+	}
+	if !b.markSeen("C1/1.0") {
+		t.Fatal("seen set should forget the oldest entries")
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	t.Parallel()
+
+	a := parseAllowlist(" U1, U2 ,", "C9")
+	cases := []struct {
+		user, channel string
+		want          bool
+	}{
+		{"U1", "C1", true},
+		{"U2", "C1", true},
+		{"U3", "C9", true},
+		{"U3", "C1", false},
+		{"", "C1", false},
+	}
+	for _, tc := range cases {
+		if got := a.permits(tc.user, tc.channel); got != tc.want {
+			t.Errorf("permits(%q, %q) = %v, want %v", tc.user, tc.channel, got, tc.want)
+		}
+	}
+	if a.empty() || !parseAllowlist("", " , ").empty() {
+		t.Fatal("empty() misreports")
+	}
+}
+
+func TestBotIgnoresUnlistedUsers(t *testing.T) {
+	t.Parallel()
+
+	p := &fakePoster{}
+	// A nil bridge client would panic if the event got as far as starting
+	// a session.
+	b := newBot(nil, p, "dev", "claude", "/repo", parseAllowlist("U1", ""))
+	b.handleEvent(context.Background(), slackEvent{Type: "app_mention", User: "U2", Channel: "C1", TS: "1.0", Text: "<@UBOT> hi"})
+	if len(p.posts) != 0 || len(b.seen) != 0 {
+		t.Fatal("mention from an unlisted user was handled")
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxSignatureAge is how old a request timestamp may be before it is rejected
+// as a possible replay, matching Slack's recommendation.
+const maxSignatureAge = 5 * time.Minute
+
+// verifySlackSignature checks the X-Slack-Signature header of an Events API
+// request against the app's signing secret.
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	tsHeader := header.Get("X-Slack-Request-Timestamp")
+	sig := header.Get("X-Slack-Signature")
+	if tsHeader == "" || sig == "" {
+		return errors.New("missing slack signature headers")
+	}
+	ts, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid slack timestamp: %w", err)
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > maxSignatureAge || age < -maxSignatureAge {
+		return errors.New("slack request timestamp out of range")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", tsHeader)
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(sig)) {
+		return errors.New("slack signature mismatch")
+	}
+	return nil
+}
+
+// slackEnvelope is the outer body of an Events API request.
+type slackEnvelope struct {
+	Type      string     `json:"type"`
+	Challenge string     `json:"challenge"`
+	Event     slackEvent `json:"event"`
+}
+
+// slackEvent is the subset of a message or app_mention event the bot uses.
+type slackEvent struct {
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype"`
+	Channel  string `json:"channel"`
+	User     string `json:"user"`
+	BotID    string `json:"bot_id"`
+	Text     string `json:"text"`
+	TS       string `json:"ts"`
+	ThreadTS string `json:"thread_ts"`
+}
+
+// threadTS returns the timestamp of the thread the event belongs to. A
+// top-level message starts a new thread rooted at itself.
+func (e slackEvent) threadTS() string {
+	if e.ThreadTS != "" {
+		return e.ThreadTS
+	}
+	return e.TS
+}
+
+// poster posts and edits threaded replies. slackAPI implements it against the
+// Slack Web API; tests substitute a fake.
+type poster interface {
+	PostMessage(ctx context.Context, channel, threadTS, text string) (string, error)
+	UpdateMessage(ctx context.Context, channel, ts, text string) error
+}
+
+// slackAPI is a minimal Slack Web API client authenticated with a bot token.
+type slackAPI struct {
+	token   string
+	baseURL string
+	http    *http.Client
+}
+
+func newSlackAPI(token string) *slackAPI {
+	return &slackAPI{
+		token:   token,
+		baseURL: "https://slack.com/api",
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// PostMessage posts text as a reply in the given thread and returns the new
+// message's timestamp.
+func (s *slackAPI) PostMessage(ctx context.Context, channel, threadTS, text string) (string, error) {
+	var resp struct {
+		TS string `json:"ts"`
+	}
+	err := s.call(ctx, "chat.postMessage", map[string]string{
+		"channel":   channel,
+		"thread_ts": threadTS,
+		"text":      text,
+	}, &resp)
+	return resp.TS, err
+}
+
+// UpdateMessage replaces the text of a message the bot posted earlier.
+func (s *slackAPI) UpdateMessage(ctx context.Context, channel, ts, text string) error {
+	return s.call(ctx, "chat.update", map[string]string{
+		"channel": channel,
+		"ts":      ts,
+		"text":    text,
+	}, nil)
+}
+
+func (s *slackAPI) call(ctx context.Context, method string, args map[string]string, out any) error {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("slack %s: decode response: %w", method, err)
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return fmt.Errorf("slack %s: decode response: %w", method, err)
+	}
+	if !status.OK {
+		return fmt.Errorf("slack %s: %s", method, status.Error)
+	}
+	if out != nil {
+		return json.Unmarshal(raw, out)
+	}
+	return nil
+}
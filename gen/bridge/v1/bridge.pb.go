@@ -156,31 +156,125 @@ const (
 	// ATTACH_EVENT_TYPE_WRITER_RELEASED is sent to all observers when the active
 	// writer releases the slot.
 	AttachEventType_ATTACH_EVENT_TYPE_WRITER_RELEASED AttachEventType = 8
+	// ATTACH_EVENT_TYPE_RESPONSE_COMPLETE marks the end of an agent response.
+	// Only emitted by stream-JSON providers whose protocol signals turn
+	// completion (claude "result", opencode "step_finish"). Carries no payload.
+	AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE AttachEventType = 9
+	// ATTACH_EVENT_TYPE_USAGE carries the session's running token and cost
+	// total in `usage`. Sent live after each provider usage report; never
+	// replayed.
+	AttachEventType_ATTACH_EVENT_TYPE_USAGE AttachEventType = 10
+	// ATTACH_EVENT_TYPE_WARNING carries one line a stream-JSON provider wrote
+	// to stderr in payload, classified by severity.
+	AttachEventType_ATTACH_EVENT_TYPE_WARNING AttachEventType = 11
+	// ATTACH_EVENT_TYPE_INPUT_ACKED is sent when WriteInput accepts an input.
+	// input_id matches WriteInputResponse.input_id; later OUTPUT, THINKING,
+	// WARNING and RESPONSE_COMPLETE events carry the input_id they respond to.
+	AttachEventType_ATTACH_EVENT_TYPE_INPUT_ACKED AttachEventType = 12
+	// ATTACH_EVENT_TYPE_REPO_DIRTY is sent live when the uncommitted changes
+	// in the session's git repo differ from the last check. It has no payload;
+	// call GitStatus for details. Never replayed.
+	AttachEventType_ATTACH_EVENT_TYPE_REPO_DIRTY AttachEventType = 13
+	// ATTACH_EVENT_TYPE_SESSION_RESTARTED is sent when the agent crashed and
+	// the bridge relaunched it under its restart policy. exit_code is the
+	// crashed process's exit code and restart_count the session's restarts so
+	// far. Sequence numbers continue across the restart.
+	AttachEventType_ATTACH_EVENT_TYPE_SESSION_RESTARTED AttachEventType = 14
+	// ATTACH_EVENT_TYPE_BATCH carries several events in `batch` when the
+	// client attached with max_batch_size. seq is the last seq in the batch.
+	AttachEventType_ATTACH_EVENT_TYPE_BATCH AttachEventType = 15
+	// ATTACH_EVENT_TYPE_TOOL_USE is sent when the agent calls a tool; payload
+	// holds the tool's name and data_json the call. Only emitted by
+	// stream-JSON providers.
+	AttachEventType_ATTACH_EVENT_TYPE_TOOL_USE AttachEventType = 16
+	// ATTACH_EVENT_TYPE_EXTENSION stands in for an event type newer than the
+	// client's protocol_version. extension_type names the original type and
+	// payload and data_json carry the original's; seq, timestamp and input_id
+	// are kept. Clients that do not recognise extension_type should ignore it.
+	AttachEventType_ATTACH_EVENT_TYPE_EXTENSION AttachEventType = 17
+	// ATTACH_EVENT_TYPE_APPROVAL_REQUESTED is sent when the agent of a project
+	// that requires approval waits for a tool call to be allowed; payload
+	// holds the tool's name and data_json the request, whose id is answered
+	// with ResolveApproval. Only emitted by stream-JSON providers.
+	AttachEventType_ATTACH_EVENT_TYPE_APPROVAL_REQUESTED AttachEventType = 18
+	// ATTACH_EVENT_TYPE_APPROVAL_RESOLVED is sent when a request was allowed
+	// or denied; payload holds the tool's name and data_json the decision.
+	AttachEventType_ATTACH_EVENT_TYPE_APPROVAL_RESOLVED AttachEventType = 19
+	// ATTACH_EVENT_TYPE_CONTROL_CHANGED is sent when a client took control
+	// with TakeControl. writer_client_id is the new controller and data_json
+	// holds the previous one and the reason. Unlike WRITER_CLAIMED it is
+	// replayed.
+	AttachEventType_ATTACH_EVENT_TYPE_CONTROL_CHANGED AttachEventType = 20
+	// ATTACH_EVENT_TYPE_BUDGET_EXCEEDED is sent on each live session of a
+	// project when its usage reaches the project's daily budget. payload
+	// describes the cap reached and data_json holds the budget and spending.
+	// Until the budget resets, StartSession and SendInput fail with
+	// RESOURCE_EXHAUSTED.
+	AttachEventType_ATTACH_EVENT_TYPE_BUDGET_EXCEEDED AttachEventType = 21
+	// ATTACH_EVENT_TYPE_OUTPUT_TRUNCATED is sent when a response reaches the
+	// bridge's output limits. payload describes the limit reached and
+	// data_json the response's size; the rest of the response is dropped.
+	AttachEventType_ATTACH_EVENT_TYPE_OUTPUT_TRUNCATED AttachEventType = 22
+	// ATTACH_EVENT_TYPE_POLICY_VIOLATION is sent when the bridge's content
+	// policy blocks or redacts a prompt or a piece of output. payload
+	// describes the rule that matched and data_json the action taken; the
+	// flagged text is not included.
+	AttachEventType_ATTACH_EVENT_TYPE_POLICY_VIOLATION AttachEventType = 23
 )
 
 // Enum value maps for AttachEventType.
 var (
 	AttachEventType_name = map[int32]string{
-		0: "ATTACH_EVENT_TYPE_UNSPECIFIED",
-		1: "ATTACH_EVENT_TYPE_ATTACHED",
-		2: "ATTACH_EVENT_TYPE_OUTPUT",
-		3: "ATTACH_EVENT_TYPE_REPLAY_GAP",
-		4: "ATTACH_EVENT_TYPE_SESSION_EXIT",
-		5: "ATTACH_EVENT_TYPE_ERROR",
-		6: "ATTACH_EVENT_TYPE_THINKING",
-		7: "ATTACH_EVENT_TYPE_WRITER_CLAIMED",
-		8: "ATTACH_EVENT_TYPE_WRITER_RELEASED",
+		0:  "ATTACH_EVENT_TYPE_UNSPECIFIED",
+		1:  "ATTACH_EVENT_TYPE_ATTACHED",
+		2:  "ATTACH_EVENT_TYPE_OUTPUT",
+		3:  "ATTACH_EVENT_TYPE_REPLAY_GAP",
+		4:  "ATTACH_EVENT_TYPE_SESSION_EXIT",
+		5:  "ATTACH_EVENT_TYPE_ERROR",
+		6:  "ATTACH_EVENT_TYPE_THINKING",
+		7:  "ATTACH_EVENT_TYPE_WRITER_CLAIMED",
+		8:  "ATTACH_EVENT_TYPE_WRITER_RELEASED",
+		9:  "ATTACH_EVENT_TYPE_RESPONSE_COMPLETE",
+		10: "ATTACH_EVENT_TYPE_USAGE",
+		11: "ATTACH_EVENT_TYPE_WARNING",
+		12: "ATTACH_EVENT_TYPE_INPUT_ACKED",
+		13: "ATTACH_EVENT_TYPE_REPO_DIRTY",
+		14: "ATTACH_EVENT_TYPE_SESSION_RESTARTED",
+		15: "ATTACH_EVENT_TYPE_BATCH",
+		16: "ATTACH_EVENT_TYPE_TOOL_USE",
+		17: "ATTACH_EVENT_TYPE_EXTENSION",
+		18: "ATTACH_EVENT_TYPE_APPROVAL_REQUESTED",
+		19: "ATTACH_EVENT_TYPE_APPROVAL_RESOLVED",
+		20: "ATTACH_EVENT_TYPE_CONTROL_CHANGED",
+		21: "ATTACH_EVENT_TYPE_BUDGET_EXCEEDED",
+		22: "ATTACH_EVENT_TYPE_OUTPUT_TRUNCATED",
+		23: "ATTACH_EVENT_TYPE_POLICY_VIOLATION",
 	}
 	AttachEventType_value = map[string]int32{
-		"ATTACH_EVENT_TYPE_UNSPECIFIED":     0,
-		"ATTACH_EVENT_TYPE_ATTACHED":        1,
-		"ATTACH_EVENT_TYPE_OUTPUT":          2,
-		"ATTACH_EVENT_TYPE_REPLAY_GAP":      3,
-		"ATTACH_EVENT_TYPE_SESSION_EXIT":    4,
-		"ATTACH_EVENT_TYPE_ERROR":           5,
-		"ATTACH_EVENT_TYPE_THINKING":        6,
-		"ATTACH_EVENT_TYPE_WRITER_CLAIMED":  7,
-		"ATTACH_EVENT_TYPE_WRITER_RELEASED": 8,
+		"ATTACH_EVENT_TYPE_UNSPECIFIED":        0,
+		"ATTACH_EVENT_TYPE_ATTACHED":           1,
+		"ATTACH_EVENT_TYPE_OUTPUT":             2,
+		"ATTACH_EVENT_TYPE_REPLAY_GAP":         3,
+		"ATTACH_EVENT_TYPE_SESSION_EXIT":       4,
+		"ATTACH_EVENT_TYPE_ERROR":              5,
+		"ATTACH_EVENT_TYPE_THINKING":           6,
+		"ATTACH_EVENT_TYPE_WRITER_CLAIMED":     7,
+		"ATTACH_EVENT_TYPE_WRITER_RELEASED":    8,
+		"ATTACH_EVENT_TYPE_RESPONSE_COMPLETE":  9,
+		"ATTACH_EVENT_TYPE_USAGE":              10,
+		"ATTACH_EVENT_TYPE_WARNING":            11,
+		"ATTACH_EVENT_TYPE_INPUT_ACKED":        12,
+		"ATTACH_EVENT_TYPE_REPO_DIRTY":         13,
+		"ATTACH_EVENT_TYPE_SESSION_RESTARTED":  14,
+		"ATTACH_EVENT_TYPE_BATCH":              15,
+		"ATTACH_EVENT_TYPE_TOOL_USE":           16,
+		"ATTACH_EVENT_TYPE_EXTENSION":          17,
+		"ATTACH_EVENT_TYPE_APPROVAL_REQUESTED": 18,
+		"ATTACH_EVENT_TYPE_APPROVAL_RESOLVED":  19,
+		"ATTACH_EVENT_TYPE_CONTROL_CHANGED":    20,
+		"ATTACH_EVENT_TYPE_BUDGET_EXCEEDED":    21,
+		"ATTACH_EVENT_TYPE_OUTPUT_TRUNCATED":   22,
+		"ATTACH_EVENT_TYPE_POLICY_VIOLATION":   23,
 	}
 )
 
@@ -211,17 +305,255 @@ func (AttachEventType) EnumDescriptor() ([]byte, []int) {
 	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{2}
 }
 
+// Severity classifies a provider stderr line (ATTACH_EVENT_TYPE_WARNING).
+type Severity int32
+
+const (
+	Severity_SEVERITY_UNSPECIFIED Severity = 0
+	Severity_SEVERITY_PROGRESS    Severity = 1
+	Severity_SEVERITY_WARNING     Severity = 2
+	Severity_SEVERITY_ERROR       Severity = 3
+)
+
+// Enum value maps for Severity.
+var (
+	Severity_name = map[int32]string{
+		0: "SEVERITY_UNSPECIFIED",
+		1: "SEVERITY_PROGRESS",
+		2: "SEVERITY_WARNING",
+		3: "SEVERITY_ERROR",
+	}
+	Severity_value = map[string]int32{
+		"SEVERITY_UNSPECIFIED": 0,
+		"SEVERITY_PROGRESS":    1,
+		"SEVERITY_WARNING":     2,
+		"SEVERITY_ERROR":       3,
+	}
+)
+
+func (x Severity) Enum() *Severity {
+	p := new(Severity)
+	*p = x
+	return p
+}
+
+func (x Severity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Severity) Descriptor() protoreflect.EnumDescriptor {
+	return file_bridge_v1_bridge_proto_enumTypes[3].Descriptor()
+}
+
+func (Severity) Type() protoreflect.EnumType {
+	return &file_bridge_v1_bridge_proto_enumTypes[3]
+}
+
+func (x Severity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Severity.Descriptor instead.
+func (Severity) EnumDescriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{3}
+}
+
+// InputPriority is the rate-limit lane of a WriteInput call.
+type InputPriority int32
+
+const (
+	// INPUT_PRIORITY_UNSPECIFIED is treated as INTERACTIVE.
+	InputPriority_INPUT_PRIORITY_UNSPECIFIED InputPriority = 0
+	// INPUT_PRIORITY_INTERACTIVE is input a person is waiting on.
+	InputPriority_INPUT_PRIORITY_INTERACTIVE InputPriority = 1
+	// INPUT_PRIORITY_BATCH is automated input, e.g. from an orchestrator. It
+	// may not use the share of the global and per-project buckets that
+	// rate_limits.interactive_reserve holds back for interactive input.
+	InputPriority_INPUT_PRIORITY_BATCH InputPriority = 2
+)
+
+// Enum value maps for InputPriority.
+var (
+	InputPriority_name = map[int32]string{
+		0: "INPUT_PRIORITY_UNSPECIFIED",
+		1: "INPUT_PRIORITY_INTERACTIVE",
+		2: "INPUT_PRIORITY_BATCH",
+	}
+	InputPriority_value = map[string]int32{
+		"INPUT_PRIORITY_UNSPECIFIED": 0,
+		"INPUT_PRIORITY_INTERACTIVE": 1,
+		"INPUT_PRIORITY_BATCH":       2,
+	}
+)
+
+func (x InputPriority) Enum() *InputPriority {
+	p := new(InputPriority)
+	*p = x
+	return p
+}
+
+func (x InputPriority) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (InputPriority) Descriptor() protoreflect.EnumDescriptor {
+	return file_bridge_v1_bridge_proto_enumTypes[4].Descriptor()
+}
+
+func (InputPriority) Type() protoreflect.EnumType {
+	return &file_bridge_v1_bridge_proto_enumTypes[4]
+}
+
+func (x InputPriority) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use InputPriority.Descriptor instead.
+func (InputPriority) EnumDescriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{4}
+}
+
+type TranscriptFormat int32
+
+const (
+	TranscriptFormat_TRANSCRIPT_FORMAT_UNSPECIFIED TranscriptFormat = 0 // treated as MARKDOWN
+	TranscriptFormat_TRANSCRIPT_FORMAT_MARKDOWN    TranscriptFormat = 1
+	TranscriptFormat_TRANSCRIPT_FORMAT_JSONL       TranscriptFormat = 2
+)
+
+// Enum value maps for TranscriptFormat.
+var (
+	TranscriptFormat_name = map[int32]string{
+		0: "TRANSCRIPT_FORMAT_UNSPECIFIED",
+		1: "TRANSCRIPT_FORMAT_MARKDOWN",
+		2: "TRANSCRIPT_FORMAT_JSONL",
+	}
+	TranscriptFormat_value = map[string]int32{
+		"TRANSCRIPT_FORMAT_UNSPECIFIED": 0,
+		"TRANSCRIPT_FORMAT_MARKDOWN":    1,
+		"TRANSCRIPT_FORMAT_JSONL":       2,
+	}
+)
+
+func (x TranscriptFormat) Enum() *TranscriptFormat {
+	p := new(TranscriptFormat)
+	*p = x
+	return p
+}
+
+func (x TranscriptFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TranscriptFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_bridge_v1_bridge_proto_enumTypes[5].Descriptor()
+}
+
+func (TranscriptFormat) Type() protoreflect.EnumType {
+	return &file_bridge_v1_bridge_proto_enumTypes[5]
+}
+
+func (x TranscriptFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TranscriptFormat.Descriptor instead.
+func (TranscriptFormat) EnumDescriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{5}
+}
+
+// HealthCheck selects what a health probe checks.
+type HealthCheck int32
+
+const (
+	// HEALTH_CHECK_UNSPECIFIED is READINESS.
+	HealthCheck_HEALTH_CHECK_UNSPECIFIED HealthCheck = 0
+	// HEALTH_CHECK_LIVENESS only reports that the daemon is answering. It runs
+	// no checks and is always "serving".
+	HealthCheck_HEALTH_CHECK_LIVENESS HealthCheck = 1
+	// HEALTH_CHECK_READINESS runs the readiness checks: at least one provider
+	// available, the server certificate unexpired and enough free disk for
+	// workspaces. Provider results are cached for a few seconds.
+	HealthCheck_HEALTH_CHECK_READINESS HealthCheck = 2
+)
+
+// Enum value maps for HealthCheck.
+var (
+	HealthCheck_name = map[int32]string{
+		0: "HEALTH_CHECK_UNSPECIFIED",
+		1: "HEALTH_CHECK_LIVENESS",
+		2: "HEALTH_CHECK_READINESS",
+	}
+	HealthCheck_value = map[string]int32{
+		"HEALTH_CHECK_UNSPECIFIED": 0,
+		"HEALTH_CHECK_LIVENESS":    1,
+		"HEALTH_CHECK_READINESS":   2,
+	}
+)
+
+func (x HealthCheck) Enum() *HealthCheck {
+	p := new(HealthCheck)
+	*p = x
+	return p
+}
+
+func (x HealthCheck) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HealthCheck) Descriptor() protoreflect.EnumDescriptor {
+	return file_bridge_v1_bridge_proto_enumTypes[6].Descriptor()
+}
+
+func (HealthCheck) Type() protoreflect.EnumType {
+	return &file_bridge_v1_bridge_proto_enumTypes[6]
+}
+
+func (x HealthCheck) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HealthCheck.Descriptor instead.
+func (HealthCheck) EnumDescriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{6}
+}
+
 type StartSessionRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
-	SessionId     string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	RepoPath      string                 `protobuf:"bytes,3,opt,name=repo_path,json=repoPath,proto3" json:"repo_path,omitempty"`
-	Provider      string                 `protobuf:"bytes,4,opt,name=provider,proto3" json:"provider,omitempty"`
-	AgentOpts     map[string]string      `protobuf:"bytes,5,rep,name=agent_opts,json=agentOpts,proto3" json:"agent_opts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	InitialCols   uint32                 `protobuf:"varint,6,opt,name=initial_cols,json=initialCols,proto3" json:"initial_cols,omitempty"`
-	InitialRows   uint32                 `protobuf:"varint,7,opt,name=initial_rows,json=initialRows,proto3" json:"initial_rows,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId   string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	SessionId   string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	RepoPath    string                 `protobuf:"bytes,3,opt,name=repo_path,json=repoPath,proto3" json:"repo_path,omitempty"`
+	Provider    string                 `protobuf:"bytes,4,opt,name=provider,proto3" json:"provider,omitempty"`
+	AgentOpts   map[string]string      `protobuf:"bytes,5,rep,name=agent_opts,json=agentOpts,proto3" json:"agent_opts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	InitialCols uint32                 `protobuf:"varint,6,opt,name=initial_cols,json=initialCols,proto3" json:"initial_cols,omitempty"`
+	InitialRows uint32                 `protobuf:"varint,7,opt,name=initial_rows,json=initialRows,proto3" json:"initial_rows,omitempty"`
+	// Extra environment variables for the agent process. Every key must be
+	// permitted by the daemon's allowed_env config; otherwise the request fails
+	// with PERMISSION_DENIED.
+	Env map[string]string `protobuf:"bytes,8,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Clone this repo into a bridge-managed workspace instead of using
+	// repo_path. Exactly one of repo_path and repo_source must be set.
+	RepoSource *RepoSource `protobuf:"bytes,9,opt,name=repo_source,json=repoSource,proto3" json:"repo_source,omitempty"`
+	// Snapshot the repo before the agent starts so RollbackWorkspace can
+	// discard its changes. The repo must be a git work tree.
+	Snapshot bool `protobuf:"varint,10,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	// protocol_version is the newest event protocol the client understands.
+	// The response reports the version the bridge will use; see
+	// AttachSessionRequest.protocol_version.
+	ProtocolVersion uint32 `protobuf:"varint,11,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// writer_subjects, when set, are the only token subjects that may control
+	// the session: send input, claim the writer role, stop it and so on.
+	// Other subjects, even with sessions:control, may only observe it. The
+	// caller's subject is always included.
+	WriterSubjects []string `protobuf:"bytes,12,rep,name=writer_subjects,json=writerSubjects,proto3" json:"writer_subjects,omitempty"`
+	// conversation_id links the session to the earlier sessions of a
+	// conversation, e.g. a series of one-shot agent runs, for
+	// GetConversation. The conversation's sessions take turns: none may be
+	// running. A session with the same provider, variant and repo_path as the
+	// latest one continues its agent's thread when the provider can resume.
+	ConversationId string `protobuf:"bytes,13,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *StartSessionRequest) Reset() {
@@ -303,18 +635,137 @@ func (x *StartSessionRequest) GetInitialRows() uint32 {
 	return 0
 }
 
-type StartSessionResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	Status        SessionStatus          `protobuf:"varint,2,opt,name=status,proto3,enum=bridge.v1.SessionStatus" json:"status,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+func (x *StartSessionRequest) GetEnv() map[string]string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *StartSessionRequest) GetRepoSource() *RepoSource {
+	if x != nil {
+		return x.RepoSource
+	}
+	return nil
+}
+
+func (x *StartSessionRequest) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *StartSessionRequest) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *StartSessionRequest) GetWriterSubjects() []string {
+	if x != nil {
+		return x.WriterSubjects
+	}
+	return nil
+}
+
+func (x *StartSessionRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// RepoSource is a git repo cloned when a session starts. The URL must match
+// the daemon's workspaces.allowed_urls.
+type RepoSource struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Branch or tag to check out; empty uses the remote's default branch.
+	Ref string `protobuf:"bytes,2,opt,name=ref,proto3" json:"ref,omitempty"`
+	// Shallow-clone this many commits; 0 clones the full history.
+	Depth uint32 `protobuf:"varint,3,opt,name=depth,proto3" json:"depth,omitempty"`
+	// Remove the workspace as soon as the session stops instead of when it
+	// is archived.
+	CleanOnStop   bool `protobuf:"varint,4,opt,name=clean_on_stop,json=cleanOnStop,proto3" json:"clean_on_stop,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *RepoSource) Reset() {
+	*x = RepoSource{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RepoSource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RepoSource) ProtoMessage() {}
+
+func (x *RepoSource) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RepoSource.ProtoReflect.Descriptor instead.
+func (*RepoSource) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RepoSource) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *RepoSource) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *RepoSource) GetDepth() uint32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *RepoSource) GetCleanOnStop() bool {
+	if x != nil {
+		return x.CleanOnStop
+	}
+	return false
+}
+
+type StartSessionResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Status    SessionStatus          `protobuf:"varint,2,opt,name=status,proto3,enum=bridge.v1.SessionStatus" json:"status,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// protocol_version is the lower of the request's protocol_version and the
+	// bridge's own, or the bridge's own when the request did not set one.
+	ProtocolVersion uint32 `protobuf:"varint,4,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
 func (x *StartSessionResponse) Reset() {
 	*x = StartSessionResponse{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[1]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -326,7 +777,7 @@ func (x *StartSessionResponse) String() string {
 func (*StartSessionResponse) ProtoMessage() {}
 
 func (x *StartSessionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[1]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -339,7 +790,7 @@ func (x *StartSessionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StartSessionResponse.ProtoReflect.Descriptor instead.
 func (*StartSessionResponse) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{1}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *StartSessionResponse) GetSessionId() string {
@@ -363,6 +814,13 @@ func (x *StartSessionResponse) GetCreatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *StartSessionResponse) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
 type StopSessionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
@@ -373,7 +831,7 @@ type StopSessionRequest struct {
 
 func (x *StopSessionRequest) Reset() {
 	*x = StopSessionRequest{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[2]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -385,7 +843,7 @@ func (x *StopSessionRequest) String() string {
 func (*StopSessionRequest) ProtoMessage() {}
 
 func (x *StopSessionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[2]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -398,7 +856,7 @@ func (x *StopSessionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StopSessionRequest.ProtoReflect.Descriptor instead.
 func (*StopSessionRequest) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{2}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *StopSessionRequest) GetSessionId() string {
@@ -424,7 +882,7 @@ type StopSessionResponse struct {
 
 func (x *StopSessionResponse) Reset() {
 	*x = StopSessionResponse{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[3]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -436,7 +894,7 @@ func (x *StopSessionResponse) String() string {
 func (*StopSessionResponse) ProtoMessage() {}
 
 func (x *StopSessionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[3]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -449,7 +907,7 @@ func (x *StopSessionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StopSessionResponse.ProtoReflect.Descriptor instead.
 func (*StopSessionResponse) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{3}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *StopSessionResponse) GetStatus() SessionStatus {
@@ -468,7 +926,7 @@ type GetSessionRequest struct {
 
 func (x *GetSessionRequest) Reset() {
 	*x = GetSessionRequest{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[4]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -480,7 +938,7 @@ func (x *GetSessionRequest) String() string {
 func (*GetSessionRequest) ProtoMessage() {}
 
 func (x *GetSessionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[4]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -493,7 +951,7 @@ func (x *GetSessionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetSessionRequest.ProtoReflect.Descriptor instead.
 func (*GetSessionRequest) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{4}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetSessionRequest) GetSessionId() string {
@@ -525,13 +983,37 @@ type GetSessionResponse struct {
 	ActiveWriterClientId string `protobuf:"bytes,16,opt,name=active_writer_client_id,json=activeWriterClientId,proto3" json:"active_writer_client_id,omitempty"`
 	// observer_count is the number of read-only observers currently attached.
 	ObserverCount int32 `protobuf:"varint,17,opt,name=observer_count,json=observerCount,proto3" json:"observer_count,omitempty"`
+	// usage is the token and cost total reported by the provider so far.
+	// Only stream-JSON providers report usage.
+	Usage *Usage `protobuf:"bytes,18,opt,name=usage,proto3" json:"usage,omitempty"`
+	// restart_count is how many times the agent was relaunched after
+	// crashing.
+	RestartCount int32 `protobuf:"varint,19,opt,name=restart_count,json=restartCount,proto3" json:"restart_count,omitempty"`
+	// pending_approvals are the tool calls the agent is waiting to have
+	// approved, oldest first.
+	PendingApprovals []*Approval `protobuf:"bytes,20,rep,name=pending_approvals,json=pendingApprovals,proto3" json:"pending_approvals,omitempty"`
+	// writer_subjects are the subjects allowed to control the session; empty
+	// when any subject with sessions:control may.
+	WriterSubjects []string `protobuf:"bytes,21,rep,name=writer_subjects,json=writerSubjects,proto3" json:"writer_subjects,omitempty"`
+	// buffer_bytes is how much output the session's in-memory replay buffer
+	// holds, and buffer_capacity how much it can hold before evicting the
+	// oldest.
+	BufferBytes    uint64 `protobuf:"varint,22,opt,name=buffer_bytes,json=bufferBytes,proto3" json:"buffer_bytes,omitempty"`
+	BufferCapacity uint64 `protobuf:"varint,23,opt,name=buffer_capacity,json=bufferCapacity,proto3" json:"buffer_capacity,omitempty"`
+	// variant is the provider variant the session runs, chosen with the
+	// "variant" agent_opt; empty for providers without variants.
+	Variant        string `protobuf:"bytes,24,opt,name=variant,proto3" json:"variant,omitempty"`
+	ConversationId string `protobuf:"bytes,25,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// resumed_from is the earlier session of the conversation whose agent
+	// thread this session continues; empty when it started a new thread.
+	ResumedFrom   string `protobuf:"bytes,26,opt,name=resumed_from,json=resumedFrom,proto3" json:"resumed_from,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetSessionResponse) Reset() {
 	*x = GetSessionResponse{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[5]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -543,7 +1025,7 @@ func (x *GetSessionResponse) String() string {
 func (*GetSessionResponse) ProtoMessage() {}
 
 func (x *GetSessionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[5]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -556,7 +1038,7 @@ func (x *GetSessionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetSessionResponse.ProtoReflect.Descriptor instead.
 func (*GetSessionResponse) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{5}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetSessionResponse) GetSessionId() string {
@@ -678,121 +1160,98 @@ func (x *GetSessionResponse) GetObserverCount() int32 {
 	return 0
 }
 
-type ListSessionsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *GetSessionResponse) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
 }
 
-func (x *ListSessionsRequest) Reset() {
-	*x = ListSessionsRequest{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[6]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *GetSessionResponse) GetRestartCount() int32 {
+	if x != nil {
+		return x.RestartCount
+	}
+	return 0
 }
 
-func (x *ListSessionsRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *GetSessionResponse) GetPendingApprovals() []*Approval {
+	if x != nil {
+		return x.PendingApprovals
+	}
+	return nil
 }
 
-func (*ListSessionsRequest) ProtoMessage() {}
+func (x *GetSessionResponse) GetWriterSubjects() []string {
+	if x != nil {
+		return x.WriterSubjects
+	}
+	return nil
+}
 
-func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[6]
+func (x *GetSessionResponse) GetBufferBytes() uint64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.BufferBytes
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
-func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{6}
+func (x *GetSessionResponse) GetBufferCapacity() uint64 {
+	if x != nil {
+		return x.BufferCapacity
+	}
+	return 0
 }
 
-func (x *ListSessionsRequest) GetProjectId() string {
+func (x *GetSessionResponse) GetVariant() string {
 	if x != nil {
-		return x.ProjectId
+		return x.Variant
 	}
 	return ""
 }
 
-type ListSessionsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Sessions      []*GetSessionResponse  `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *ListSessionsResponse) Reset() {
-	*x = ListSessionsResponse{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[7]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *ListSessionsResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*ListSessionsResponse) ProtoMessage() {}
-
-func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[7]
+func (x *GetSessionResponse) GetConversationId() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.ConversationId
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
-func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{7}
+	return ""
 }
 
-func (x *ListSessionsResponse) GetSessions() []*GetSessionResponse {
+func (x *GetSessionResponse) GetResumedFrom() string {
 	if x != nil {
-		return x.Sessions
+		return x.ResumedFrom
 	}
-	return nil
+	return ""
 }
 
-type AttachSessionRequest struct {
-	state     protoimpl.MessageState `protogen:"open.v1"`
-	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	AfterSeq  uint64                 `protobuf:"varint,2,opt,name=after_seq,json=afterSeq,proto3" json:"after_seq,omitempty"`
-	ClientId  string                 `protobuf:"bytes,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
-	// role controls whether this client attaches as a writer or observer.
-	// Defaults to ATTACH_ROLE_WRITER for backwards compatibility.
-	Role          AttachRole `protobuf:"varint,4,opt,name=role,proto3,enum=bridge.v1.AttachRole" json:"role,omitempty"`
+// Approval is a tool call awaiting ResolveApproval.
+type Approval struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ToolName string                 `protobuf:"bytes,2,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	// input_json is the tool's arguments as a JSON object.
+	InputJson []byte `protobuf:"bytes,3,opt,name=input_json,json=inputJson,proto3" json:"input_json,omitempty"`
+	// tool_use_id is the tool call the request is for, when the provider
+	// reports it.
+	ToolUseId     string `protobuf:"bytes,4,opt,name=tool_use_id,json=toolUseId,proto3" json:"tool_use_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AttachSessionRequest) Reset() {
-	*x = AttachSessionRequest{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[8]
+func (x *Approval) Reset() {
+	*x = Approval{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AttachSessionRequest) String() string {
+func (x *Approval) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AttachSessionRequest) ProtoMessage() {}
+func (*Approval) ProtoMessage() {}
 
-func (x *AttachSessionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[8]
+func (x *Approval) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -803,78 +1262,68 @@ func (x *AttachSessionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AttachSessionRequest.ProtoReflect.Descriptor instead.
-func (*AttachSessionRequest) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use Approval.ProtoReflect.Descriptor instead.
+func (*Approval) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *AttachSessionRequest) GetSessionId() string {
+func (x *Approval) GetId() string {
 	if x != nil {
-		return x.SessionId
+		return x.Id
 	}
 	return ""
 }
 
-func (x *AttachSessionRequest) GetAfterSeq() uint64 {
+func (x *Approval) GetToolName() string {
 	if x != nil {
-		return x.AfterSeq
+		return x.ToolName
 	}
-	return 0
+	return ""
 }
 
-func (x *AttachSessionRequest) GetClientId() string {
+func (x *Approval) GetInputJson() []byte {
 	if x != nil {
-		return x.ClientId
+		return x.InputJson
 	}
-	return ""
+	return nil
 }
 
-func (x *AttachSessionRequest) GetRole() AttachRole {
+func (x *Approval) GetToolUseId() string {
 	if x != nil {
-		return x.Role
+		return x.ToolUseId
 	}
-	return AttachRole_ATTACH_ROLE_UNSPECIFIED
+	return ""
 }
 
-type AttachSessionEvent struct {
+// Usage is an accumulated token and cost total for one session.
+type Usage struct {
 	state        protoimpl.MessageState `protogen:"open.v1"`
-	Type         AttachEventType        `protobuf:"varint,1,opt,name=type,proto3,enum=bridge.v1.AttachEventType" json:"type,omitempty"`
-	Seq          uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
-	Timestamp    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	SessionId    string                 `protobuf:"bytes,4,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	Payload      []byte                 `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
-	Replay       bool                   `protobuf:"varint,6,opt,name=replay,proto3" json:"replay,omitempty"`
-	OldestSeq    uint64                 `protobuf:"varint,7,opt,name=oldest_seq,json=oldestSeq,proto3" json:"oldest_seq,omitempty"`
-	LastSeq      uint64                 `protobuf:"varint,8,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
-	ExitRecorded bool                   `protobuf:"varint,9,opt,name=exit_recorded,json=exitRecorded,proto3" json:"exit_recorded,omitempty"`
-	ExitCode     int32                  `protobuf:"varint,10,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
-	Error        string                 `protobuf:"bytes,11,opt,name=error,proto3" json:"error,omitempty"`
-	Cols         uint32                 `protobuf:"varint,12,opt,name=cols,proto3" json:"cols,omitempty"`
-	Rows         uint32                 `protobuf:"varint,13,opt,name=rows,proto3" json:"rows,omitempty"`
-	// thinking_text is set when type == ATTACH_EVENT_TYPE_THINKING (issue #1).
-	ThinkingText string `protobuf:"bytes,14,opt,name=thinking_text,json=thinkingText,proto3" json:"thinking_text,omitempty"`
-	// writer_client_id is set on WRITER_CLAIMED / WRITER_RELEASED events to
-	// identify which client claimed or released the writer slot.
-	WriterClientId string `protobuf:"bytes,15,opt,name=writer_client_id,json=writerClientId,proto3" json:"writer_client_id,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	InputTokens  int64                  `protobuf:"varint,1,opt,name=input_tokens,json=inputTokens,proto3" json:"input_tokens,omitempty"`
+	OutputTokens int64                  `protobuf:"varint,2,opt,name=output_tokens,json=outputTokens,proto3" json:"output_tokens,omitempty"`
+	CostUsd      float64                `protobuf:"fixed64,3,opt,name=cost_usd,json=costUsd,proto3" json:"cost_usd,omitempty"`
+	// duration_ms is provider-reported time spent producing responses.
+	DurationMs int64 `protobuf:"varint,4,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	// turns is the number of completed agent responses.
+	Turns         int32 `protobuf:"varint,5,opt,name=turns,proto3" json:"turns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AttachSessionEvent) Reset() {
-	*x = AttachSessionEvent{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[9]
+func (x *Usage) Reset() {
+	*x = Usage{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AttachSessionEvent) String() string {
+func (x *Usage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AttachSessionEvent) ProtoMessage() {}
+func (*Usage) ProtoMessage() {}
 
-func (x *AttachSessionEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[9]
+func (x *Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -885,139 +1334,134 @@ func (x *AttachSessionEvent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AttachSessionEvent.ProtoReflect.Descriptor instead.
-func (*AttachSessionEvent) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use Usage.ProtoReflect.Descriptor instead.
+func (*Usage) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *AttachSessionEvent) GetType() AttachEventType {
+func (x *Usage) GetInputTokens() int64 {
 	if x != nil {
-		return x.Type
+		return x.InputTokens
 	}
-	return AttachEventType_ATTACH_EVENT_TYPE_UNSPECIFIED
+	return 0
 }
 
-func (x *AttachSessionEvent) GetSeq() uint64 {
+func (x *Usage) GetOutputTokens() int64 {
 	if x != nil {
-		return x.Seq
+		return x.OutputTokens
 	}
 	return 0
 }
 
-func (x *AttachSessionEvent) GetTimestamp() *timestamppb.Timestamp {
+func (x *Usage) GetCostUsd() float64 {
 	if x != nil {
-		return x.Timestamp
+		return x.CostUsd
 	}
-	return nil
+	return 0
 }
 
-func (x *AttachSessionEvent) GetSessionId() string {
+func (x *Usage) GetDurationMs() int64 {
 	if x != nil {
-		return x.SessionId
+		return x.DurationMs
 	}
-	return ""
+	return 0
 }
 
-func (x *AttachSessionEvent) GetPayload() []byte {
+func (x *Usage) GetTurns() int32 {
 	if x != nil {
-		return x.Payload
+		return x.Turns
 	}
-	return nil
+	return 0
 }
 
-func (x *AttachSessionEvent) GetReplay() bool {
-	if x != nil {
-		return x.Replay
-	}
-	return false
+type GetSessionHistoryRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Only events with seq > after_seq are returned.
+	AfterSeq uint64 `protobuf:"varint,2,opt,name=after_seq,json=afterSeq,proto3" json:"after_seq,omitempty"`
+	// protocol_version maps newer event types to EXTENSION, as for
+	// AttachSessionRequest.protocol_version.
+	ProtocolVersion uint32 `protobuf:"varint,3,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *AttachSessionEvent) GetOldestSeq() uint64 {
-	if x != nil {
-		return x.OldestSeq
-	}
-	return 0
+func (x *GetSessionHistoryRequest) Reset() {
+	*x = GetSessionHistoryRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *AttachSessionEvent) GetLastSeq() uint64 {
-	if x != nil {
-		return x.LastSeq
-	}
-	return 0
+func (x *GetSessionHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *AttachSessionEvent) GetExitRecorded() bool {
+func (*GetSessionHistoryRequest) ProtoMessage() {}
+
+func (x *GetSessionHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[9]
 	if x != nil {
-		return x.ExitRecorded
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *AttachSessionEvent) GetExitCode() int32 {
-	if x != nil {
-		return x.ExitCode
-	}
-	return 0
+// Deprecated: Use GetSessionHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetSessionHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *AttachSessionEvent) GetError() string {
+func (x *GetSessionHistoryRequest) GetSessionId() string {
 	if x != nil {
-		return x.Error
+		return x.SessionId
 	}
 	return ""
 }
 
-func (x *AttachSessionEvent) GetCols() uint32 {
+func (x *GetSessionHistoryRequest) GetAfterSeq() uint64 {
 	if x != nil {
-		return x.Cols
+		return x.AfterSeq
 	}
 	return 0
 }
 
-func (x *AttachSessionEvent) GetRows() uint32 {
+func (x *GetSessionHistoryRequest) GetProtocolVersion() uint32 {
 	if x != nil {
-		return x.Rows
+		return x.ProtocolVersion
 	}
 	return 0
 }
 
-func (x *AttachSessionEvent) GetThinkingText() string {
-	if x != nil {
-		return x.ThinkingText
-	}
-	return ""
-}
-
-func (x *AttachSessionEvent) GetWriterClientId() string {
-	if x != nil {
-		return x.WriterClientId
-	}
-	return ""
-}
-
-type WriteInputRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	ClientId      string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
-	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+type GetSessionHistoryResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Session *GetSessionResponse    `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	// Output events in seq order, as they would be replayed by AttachSession.
+	Events []*AttachSessionEvent `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	// archived_at is set when the session was read from the archive.
+	ArchivedAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=archived_at,json=archivedAt,proto3" json:"archived_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WriteInputRequest) Reset() {
-	*x = WriteInputRequest{}
+func (x *GetSessionHistoryResponse) Reset() {
+	*x = GetSessionHistoryResponse{}
 	mi := &file_bridge_v1_bridge_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WriteInputRequest) String() string {
+func (x *GetSessionHistoryResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WriteInputRequest) ProtoMessage() {}
+func (*GetSessionHistoryResponse) ProtoMessage() {}
 
-func (x *WriteInputRequest) ProtoReflect() protoreflect.Message {
+func (x *GetSessionHistoryResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_bridge_v1_bridge_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1029,54 +1473,54 @@ func (x *WriteInputRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WriteInputRequest.ProtoReflect.Descriptor instead.
-func (*WriteInputRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetSessionHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetSessionHistoryResponse) Descriptor() ([]byte, []int) {
 	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *WriteInputRequest) GetSessionId() string {
+func (x *GetSessionHistoryResponse) GetSession() *GetSessionResponse {
 	if x != nil {
-		return x.SessionId
+		return x.Session
 	}
-	return ""
+	return nil
 }
 
-func (x *WriteInputRequest) GetClientId() string {
+func (x *GetSessionHistoryResponse) GetEvents() []*AttachSessionEvent {
 	if x != nil {
-		return x.ClientId
+		return x.Events
 	}
-	return ""
+	return nil
 }
 
-func (x *WriteInputRequest) GetData() []byte {
+func (x *GetSessionHistoryResponse) GetArchivedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Data
+		return x.ArchivedAt
 	}
 	return nil
 }
 
-type WriteInputResponse struct {
+type ExportTranscriptRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Accepted      bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
-	BytesWritten  uint32                 `protobuf:"varint,2,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Format        TranscriptFormat       `protobuf:"varint,2,opt,name=format,proto3,enum=bridge.v1.TranscriptFormat" json:"format,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WriteInputResponse) Reset() {
-	*x = WriteInputResponse{}
+func (x *ExportTranscriptRequest) Reset() {
+	*x = ExportTranscriptRequest{}
 	mi := &file_bridge_v1_bridge_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WriteInputResponse) String() string {
+func (x *ExportTranscriptRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WriteInputResponse) ProtoMessage() {}
+func (*ExportTranscriptRequest) ProtoMessage() {}
 
-func (x *WriteInputResponse) ProtoReflect() protoreflect.Message {
+func (x *ExportTranscriptRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_bridge_v1_bridge_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1088,49 +1532,50 @@ func (x *WriteInputResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WriteInputResponse.ProtoReflect.Descriptor instead.
-func (*WriteInputResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ExportTranscriptRequest.ProtoReflect.Descriptor instead.
+func (*ExportTranscriptRequest) Descriptor() ([]byte, []int) {
 	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *WriteInputResponse) GetAccepted() bool {
+func (x *ExportTranscriptRequest) GetSessionId() string {
 	if x != nil {
-		return x.Accepted
+		return x.SessionId
 	}
-	return false
+	return ""
 }
 
-func (x *WriteInputResponse) GetBytesWritten() uint32 {
+func (x *ExportTranscriptRequest) GetFormat() TranscriptFormat {
 	if x != nil {
-		return x.BytesWritten
+		return x.Format
 	}
-	return 0
+	return TranscriptFormat_TRANSCRIPT_FORMAT_UNSPECIFIED
 }
 
-type ResizeSessionRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	ClientId      string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
-	Cols          uint32                 `protobuf:"varint,3,opt,name=cols,proto3" json:"cols,omitempty"`
-	Rows          uint32                 `protobuf:"varint,4,opt,name=rows,proto3" json:"rows,omitempty"`
+type ExportTranscriptResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Content []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	// content_type is text/markdown or application/x-ndjson.
+	ContentType string `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// filename is a suggested file name, e.g. "<session_id>.md".
+	Filename      string `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ResizeSessionRequest) Reset() {
-	*x = ResizeSessionRequest{}
+func (x *ExportTranscriptResponse) Reset() {
+	*x = ExportTranscriptResponse{}
 	mi := &file_bridge_v1_bridge_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResizeSessionRequest) String() string {
+func (x *ExportTranscriptResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResizeSessionRequest) ProtoMessage() {}
+func (*ExportTranscriptResponse) ProtoMessage() {}
 
-func (x *ResizeSessionRequest) ProtoReflect() protoreflect.Message {
+func (x *ExportTranscriptResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_bridge_v1_bridge_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1142,60 +1587,54 @@ func (x *ResizeSessionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResizeSessionRequest.ProtoReflect.Descriptor instead.
-func (*ResizeSessionRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ExportTranscriptResponse.ProtoReflect.Descriptor instead.
+func (*ExportTranscriptResponse) Descriptor() ([]byte, []int) {
 	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *ResizeSessionRequest) GetSessionId() string {
+func (x *ExportTranscriptResponse) GetContent() []byte {
 	if x != nil {
-		return x.SessionId
+		return x.Content
 	}
-	return ""
+	return nil
 }
 
-func (x *ResizeSessionRequest) GetClientId() string {
+func (x *ExportTranscriptResponse) GetContentType() string {
 	if x != nil {
-		return x.ClientId
+		return x.ContentType
 	}
 	return ""
 }
 
-func (x *ResizeSessionRequest) GetCols() uint32 {
-	if x != nil {
-		return x.Cols
-	}
-	return 0
-}
-
-func (x *ResizeSessionRequest) GetRows() uint32 {
+func (x *ExportTranscriptResponse) GetFilename() string {
 	if x != nil {
-		return x.Rows
+		return x.Filename
 	}
-	return 0
+	return ""
 }
 
-type ResizeSessionResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Applied       bool                   `protobuf:"varint,1,opt,name=applied,proto3" json:"applied,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type GetConversationRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Format         TranscriptFormat       `protobuf:"varint,2,opt,name=format,proto3,enum=bridge.v1.TranscriptFormat" json:"format,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *ResizeSessionResponse) Reset() {
-	*x = ResizeSessionResponse{}
+func (x *GetConversationRequest) Reset() {
+	*x = GetConversationRequest{}
 	mi := &file_bridge_v1_bridge_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResizeSessionResponse) String() string {
+func (x *GetConversationRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResizeSessionResponse) ProtoMessage() {}
+func (*GetConversationRequest) ProtoMessage() {}
 
-func (x *ResizeSessionResponse) ProtoReflect() protoreflect.Message {
+func (x *GetConversationRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_bridge_v1_bridge_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1207,43 +1646,53 @@ func (x *ResizeSessionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResizeSessionResponse.ProtoReflect.Descriptor instead.
-func (*ResizeSessionResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetConversationRequest.ProtoReflect.Descriptor instead.
+func (*GetConversationRequest) Descriptor() ([]byte, []int) {
 	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *ResizeSessionResponse) GetApplied() bool {
+func (x *GetConversationRequest) GetConversationId() string {
 	if x != nil {
-		return x.Applied
+		return x.ConversationId
 	}
-	return false
+	return ""
 }
 
-type ClaimWriterRequest struct {
-	state     protoimpl.MessageState `protogen:"open.v1"`
-	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	ClientId  string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
-	// force, when true, evicts the current writer rather than returning
-	// ALREADY_EXISTS. The evicted writer receives a WRITER_RELEASED event.
-	Force         bool `protobuf:"varint,3,opt,name=force,proto3" json:"force,omitempty"`
+func (x *GetConversationRequest) GetFormat() TranscriptFormat {
+	if x != nil {
+		return x.Format
+	}
+	return TranscriptFormat_TRANSCRIPT_FORMAT_UNSPECIFIED
+}
+
+type GetConversationResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// sessions are the conversation's sessions in the order they started.
+	Sessions []*GetSessionResponse `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	// content is the combined transcript. JSONL events carry the session_id
+	// of the session they belong to.
+	Content     []byte `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	ContentType string `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// filename is a suggested file name, e.g. "<conversation_id>.md".
+	Filename      string `protobuf:"bytes,4,opt,name=filename,proto3" json:"filename,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ClaimWriterRequest) Reset() {
-	*x = ClaimWriterRequest{}
+func (x *GetConversationResponse) Reset() {
+	*x = GetConversationResponse{}
 	mi := &file_bridge_v1_bridge_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ClaimWriterRequest) String() string {
+func (x *GetConversationResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClaimWriterRequest) ProtoMessage() {}
+func (*GetConversationResponse) ProtoMessage() {}
 
-func (x *ClaimWriterRequest) ProtoReflect() protoreflect.Message {
+func (x *GetConversationResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_bridge_v1_bridge_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1255,56 +1704,64 @@ func (x *ClaimWriterRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClaimWriterRequest.ProtoReflect.Descriptor instead.
-func (*ClaimWriterRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetConversationResponse.ProtoReflect.Descriptor instead.
+func (*GetConversationResponse) Descriptor() ([]byte, []int) {
 	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *ClaimWriterRequest) GetSessionId() string {
+func (x *GetConversationResponse) GetSessions() []*GetSessionResponse {
 	if x != nil {
-		return x.SessionId
+		return x.Sessions
 	}
-	return ""
+	return nil
 }
 
-func (x *ClaimWriterRequest) GetClientId() string {
+func (x *GetConversationResponse) GetContent() []byte {
 	if x != nil {
-		return x.ClientId
+		return x.Content
+	}
+	return nil
+}
+
+func (x *GetConversationResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
 	}
 	return ""
 }
 
-func (x *ClaimWriterRequest) GetForce() bool {
+func (x *GetConversationResponse) GetFilename() string {
 	if x != nil {
-		return x.Force
+		return x.Filename
 	}
-	return false
+	return ""
 }
 
-type ClaimWriterResponse struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// claimed is true when the caller now holds the writer slot.
-	Claimed bool `protobuf:"varint,1,opt,name=claimed,proto3" json:"claimed,omitempty"`
-	// previous_writer_client_id is set when force evicted another writer.
-	PreviousWriterClientId string `protobuf:"bytes,2,opt,name=previous_writer_client_id,json=previousWriterClientId,proto3" json:"previous_writer_client_id,omitempty"`
-	unknownFields          protoimpl.UnknownFields
-	sizeCache              protoimpl.SizeCache
+// GetResponseRequest names an input by input_id or, when that is empty, by
+// input_seq, the seq of its INPUT_ACKED event.
+type GetResponseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	InputId       string                 `protobuf:"bytes,2,opt,name=input_id,json=inputId,proto3" json:"input_id,omitempty"`
+	InputSeq      uint64                 `protobuf:"varint,3,opt,name=input_seq,json=inputSeq,proto3" json:"input_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ClaimWriterResponse) Reset() {
-	*x = ClaimWriterResponse{}
+func (x *GetResponseRequest) Reset() {
+	*x = GetResponseRequest{}
 	mi := &file_bridge_v1_bridge_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ClaimWriterResponse) String() string {
+func (x *GetResponseRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClaimWriterResponse) ProtoMessage() {}
+func (*GetResponseRequest) ProtoMessage() {}
 
-func (x *ClaimWriterResponse) ProtoReflect() protoreflect.Message {
+func (x *GetResponseRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_bridge_v1_bridge_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1316,100 +1773,4979 @@ func (x *ClaimWriterResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClaimWriterResponse.ProtoReflect.Descriptor instead.
-func (*ClaimWriterResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetResponseRequest.ProtoReflect.Descriptor instead.
+func (*GetResponseRequest) Descriptor() ([]byte, []int) {
 	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *ClaimWriterResponse) GetClaimed() bool {
+func (x *GetResponseRequest) GetSessionId() string {
 	if x != nil {
-		return x.Claimed
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *GetResponseRequest) GetInputId() string {
+	if x != nil {
+		return x.InputId
+	}
+	return ""
+}
+
+func (x *GetResponseRequest) GetInputSeq() uint64 {
+	if x != nil {
+		return x.InputSeq
+	}
+	return 0
+}
+
+type GetResponseResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	InputId string                 `protobuf:"bytes,1,opt,name=input_id,json=inputId,proto3" json:"input_id,omitempty"`
+	// input_seq is the seq of the input's INPUT_ACKED event.
+	InputSeq uint64 `protobuf:"varint,2,opt,name=input_seq,json=inputSeq,proto3" json:"input_seq,omitempty"`
+	// prompt is the input's text, unwrapped from stream-JSON.
+	Prompt string `protobuf:"bytes,3,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	// text is the OUTPUT attributed to the input, with terminal escape
+	// sequences removed.
+	Text     string `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	Thinking string `protobuf:"bytes,5,opt,name=thinking,proto3" json:"thinking,omitempty"`
+	// complete is set once RESPONSE_COMPLETE was sent for the input. PTY
+	// providers never send it; their response runs up to the next input.
+	Complete bool `protobuf:"varint,6,opt,name=complete,proto3" json:"complete,omitempty"`
+	// last_seq is the seq of the last event read for the response.
+	LastSeq       uint64 `protobuf:"varint,7,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResponseResponse) Reset() {
+	*x = GetResponseResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResponseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResponseResponse) ProtoMessage() {}
+
+func (x *GetResponseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResponseResponse.ProtoReflect.Descriptor instead.
+func (*GetResponseResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetResponseResponse) GetInputId() string {
+	if x != nil {
+		return x.InputId
+	}
+	return ""
+}
+
+func (x *GetResponseResponse) GetInputSeq() uint64 {
+	if x != nil {
+		return x.InputSeq
+	}
+	return 0
+}
+
+func (x *GetResponseResponse) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *GetResponseResponse) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *GetResponseResponse) GetThinking() string {
+	if x != nil {
+		return x.Thinking
+	}
+	return ""
+}
+
+func (x *GetResponseResponse) GetComplete() bool {
+	if x != nil {
+		return x.Complete
 	}
 	return false
 }
 
-func (x *ClaimWriterResponse) GetPreviousWriterClientId() string {
+func (x *GetResponseResponse) GetLastSeq() uint64 {
 	if x != nil {
-		return x.PreviousWriterClientId
+		return x.LastSeq
+	}
+	return 0
+}
+
+type ExportSessionStateRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// stop stops the session and waits for its output to drain before
+	// exporting, so no output is lost in the handoff.
+	Stop          bool `protobuf:"varint,2,opt,name=stop,proto3" json:"stop,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportSessionStateRequest) Reset() {
+	*x = ExportSessionStateRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportSessionStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportSessionStateRequest) ProtoMessage() {}
+
+func (x *ExportSessionStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportSessionStateRequest.ProtoReflect.Descriptor instead.
+func (*ExportSessionStateRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ExportSessionStateRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
 	}
 	return ""
 }
 
-type ReleaseWriterRequest struct {
+func (x *ExportSessionStateRequest) GetStop() bool {
+	if x != nil {
+		return x.Stop
+	}
+	return false
+}
+
+type ExportSessionStateResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// state is an opaque, versioned blob for ImportSessionState. It includes
+	// the session's environment and output, so treat it as a secret.
+	State         []byte `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	LastSeq       uint64 `protobuf:"varint,2,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportSessionStateResponse) Reset() {
+	*x = ExportSessionStateResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportSessionStateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportSessionStateResponse) ProtoMessage() {}
+
+func (x *ExportSessionStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportSessionStateResponse.ProtoReflect.Descriptor instead.
+func (*ExportSessionStateResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ExportSessionStateResponse) GetState() []byte {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *ExportSessionStateResponse) GetLastSeq() uint64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+type ImportSessionStateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	ClientId      string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	State         []byte                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReleaseWriterRequest) Reset() {
-	*x = ReleaseWriterRequest{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[16]
+func (x *ImportSessionStateRequest) Reset() {
+	*x = ImportSessionStateRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReleaseWriterRequest) String() string {
+func (x *ImportSessionStateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReleaseWriterRequest) ProtoMessage() {}
-
-func (x *ReleaseWriterRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[16]
+func (*ImportSessionStateRequest) ProtoMessage() {}
+
+func (x *ImportSessionStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportSessionStateRequest.ProtoReflect.Descriptor instead.
+func (*ImportSessionStateRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ImportSessionStateRequest) GetState() []byte {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+type ImportSessionStateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Session       *GetSessionResponse    `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportSessionStateResponse) Reset() {
+	*x = ImportSessionStateResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportSessionStateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportSessionStateResponse) ProtoMessage() {}
+
+func (x *ImportSessionStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportSessionStateResponse.ProtoReflect.Descriptor instead.
+func (*ImportSessionStateResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ImportSessionStateResponse) GetSession() *GetSessionResponse {
+	if x != nil {
+		return x.Session
+	}
+	return nil
+}
+
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListSessionsRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*GetSessionResponse  `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*GetSessionResponse {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type AttachSessionRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	AfterSeq  uint64                 `protobuf:"varint,2,opt,name=after_seq,json=afterSeq,proto3" json:"after_seq,omitempty"`
+	ClientId  string                 `protobuf:"bytes,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// role controls whether this client attaches as a writer or observer.
+	// Defaults to ATTACH_ROLE_WRITER for backwards compatibility.
+	Role AttachRole `protobuf:"varint,4,opt,name=role,proto3,enum=bridge.v1.AttachRole" json:"role,omitempty"`
+	// event_types, when set, limits the stream to events of these types.
+	// ATTACHED, REPLAY_GAP, SESSION_EXIT and ERROR are always sent. Agent
+	// stdout arrives as OUTPUT (or THINKING) and stream-JSON stderr as
+	// WARNING, so this also selects output streams.
+	EventTypes []AttachEventType `protobuf:"varint,5,rep,packed,name=event_types,json=eventTypes,proto3,enum=bridge.v1.AttachEventType" json:"event_types,omitempty"`
+	// match, when set, is an RE2 regular expression. OUTPUT, THINKING and
+	// WARNING events are sent only when their text matches; other events are
+	// not affected. PTY output is matched per chunk, including any terminal
+	// escape sequences.
+	Match string `protobuf:"bytes,6,opt,name=match,proto3" json:"match,omitempty"`
+	// max_batch_size, when greater than 1, has the server group events after
+	// ATTACHED into BATCH events of up to this many (at most 1000). Useful
+	// for consumers that archive output rather than render it live.
+	MaxBatchSize uint32 `protobuf:"varint,7,opt,name=max_batch_size,json=maxBatchSize,proto3" json:"max_batch_size,omitempty"`
+	// max_batch_delay_ms is how long a partial batch waits for more events
+	// before it is sent. Defaults to 100; at most 10000.
+	MaxBatchDelayMs uint32 `protobuf:"varint,8,opt,name=max_batch_delay_ms,json=maxBatchDelayMs,proto3" json:"max_batch_delay_ms,omitempty"`
+	// protocol_version is the newest event protocol the client understands.
+	// Event types introduced after it are sent as ATTACH_EVENT_TYPE_EXTENSION.
+	// Zero, the value older clients send, disables the mapping and sends every
+	// event type as is.
+	ProtocolVersion uint32 `protobuf:"varint,9,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AttachSessionRequest) Reset() {
+	*x = AttachSessionRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachSessionRequest) ProtoMessage() {}
+
+func (x *AttachSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachSessionRequest.ProtoReflect.Descriptor instead.
+func (*AttachSessionRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AttachSessionRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *AttachSessionRequest) GetAfterSeq() uint64 {
+	if x != nil {
+		return x.AfterSeq
+	}
+	return 0
+}
+
+func (x *AttachSessionRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *AttachSessionRequest) GetRole() AttachRole {
+	if x != nil {
+		return x.Role
+	}
+	return AttachRole_ATTACH_ROLE_UNSPECIFIED
+}
+
+func (x *AttachSessionRequest) GetEventTypes() []AttachEventType {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+func (x *AttachSessionRequest) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+func (x *AttachSessionRequest) GetMaxBatchSize() uint32 {
+	if x != nil {
+		return x.MaxBatchSize
+	}
+	return 0
+}
+
+func (x *AttachSessionRequest) GetMaxBatchDelayMs() uint32 {
+	if x != nil {
+		return x.MaxBatchDelayMs
+	}
+	return 0
+}
+
+func (x *AttachSessionRequest) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+type AttachSessionEvent struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Type         AttachEventType        `protobuf:"varint,1,opt,name=type,proto3,enum=bridge.v1.AttachEventType" json:"type,omitempty"`
+	Seq          uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	Timestamp    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	SessionId    string                 `protobuf:"bytes,4,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Payload      []byte                 `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+	Replay       bool                   `protobuf:"varint,6,opt,name=replay,proto3" json:"replay,omitempty"`
+	OldestSeq    uint64                 `protobuf:"varint,7,opt,name=oldest_seq,json=oldestSeq,proto3" json:"oldest_seq,omitempty"`
+	LastSeq      uint64                 `protobuf:"varint,8,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	ExitRecorded bool                   `protobuf:"varint,9,opt,name=exit_recorded,json=exitRecorded,proto3" json:"exit_recorded,omitempty"`
+	ExitCode     int32                  `protobuf:"varint,10,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	Error        string                 `protobuf:"bytes,11,opt,name=error,proto3" json:"error,omitempty"`
+	Cols         uint32                 `protobuf:"varint,12,opt,name=cols,proto3" json:"cols,omitempty"`
+	Rows         uint32                 `protobuf:"varint,13,opt,name=rows,proto3" json:"rows,omitempty"`
+	// thinking_text is set when type == ATTACH_EVENT_TYPE_THINKING (issue #1).
+	ThinkingText string `protobuf:"bytes,14,opt,name=thinking_text,json=thinkingText,proto3" json:"thinking_text,omitempty"`
+	// writer_client_id is set on WRITER_CLAIMED / WRITER_RELEASED events to
+	// identify which client claimed or released the writer slot.
+	WriterClientId string `protobuf:"bytes,15,opt,name=writer_client_id,json=writerClientId,proto3" json:"writer_client_id,omitempty"`
+	// usage is set when type == ATTACH_EVENT_TYPE_USAGE.
+	Usage *Usage `protobuf:"bytes,16,opt,name=usage,proto3" json:"usage,omitempty"`
+	// severity is set when type == ATTACH_EVENT_TYPE_WARNING.
+	Severity Severity `protobuf:"varint,17,opt,name=severity,proto3,enum=bridge.v1.Severity" json:"severity,omitempty"`
+	// input_id is set on INPUT_ACKED events and on output produced after the
+	// session's first input. For PTY sessions it names the most recent input;
+	// for stream-JSON sessions, the input the response answers.
+	InputId string `protobuf:"bytes,18,opt,name=input_id,json=inputId,proto3" json:"input_id,omitempty"`
+	// restart_count is set when type == ATTACH_EVENT_TYPE_SESSION_RESTARTED.
+	RestartCount int32 `protobuf:"varint,19,opt,name=restart_count,json=restartCount,proto3" json:"restart_count,omitempty"`
+	// batch is set when type == ATTACH_EVENT_TYPE_BATCH.
+	Batch *AttachSessionEventBatch `protobuf:"bytes,20,opt,name=batch,proto3" json:"batch,omitempty"`
+	// protocol_version is set on ATTACHED events: the version the stream
+	// uses, or the bridge's own when the request did not set one.
+	ProtocolVersion uint32 `protobuf:"varint,21,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// extension_type is set when type == ATTACH_EVENT_TYPE_EXTENSION to the
+	// name of the original event type, e.g. "ATTACH_EVENT_TYPE_TOOL_USE".
+	ExtensionType string `protobuf:"bytes,22,opt,name=extension_type,json=extensionType,proto3" json:"extension_type,omitempty"`
+	// data_json is a JSON object of machine-readable detail accompanying the
+	// event's display text, so that consumers need not parse it out of
+	// payload. TOOL_USE events carry {"id", "name", "input"}. Empty when the
+	// event has none.
+	DataJson []byte `protobuf:"bytes,23,opt,name=data_json,json=dataJson,proto3" json:"data_json,omitempty"`
+	// attachments is set on INPUT_ACKED events to the repo paths of the files
+	// sent with the input.
+	Attachments []string `protobuf:"bytes,24,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	// request_id is set on events recording a client's action, INPUT_ACKED,
+	// APPROVAL_RESOLVED and CONTROL_CHANGED, to the ID of the RPC that took
+	// it: the caller's x-request-id metadata, or one the bridge generated and
+	// returned in the x-request-id response header.
+	RequestId     string `protobuf:"bytes,25,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachSessionEvent) Reset() {
+	*x = AttachSessionEvent{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachSessionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachSessionEvent) ProtoMessage() {}
+
+func (x *AttachSessionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachSessionEvent.ProtoReflect.Descriptor instead.
+func (*AttachSessionEvent) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *AttachSessionEvent) GetType() AttachEventType {
+	if x != nil {
+		return x.Type
+	}
+	return AttachEventType_ATTACH_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *AttachSessionEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *AttachSessionEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *AttachSessionEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *AttachSessionEvent) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *AttachSessionEvent) GetReplay() bool {
+	if x != nil {
+		return x.Replay
+	}
+	return false
+}
+
+func (x *AttachSessionEvent) GetOldestSeq() uint64 {
+	if x != nil {
+		return x.OldestSeq
+	}
+	return 0
+}
+
+func (x *AttachSessionEvent) GetLastSeq() uint64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+func (x *AttachSessionEvent) GetExitRecorded() bool {
+	if x != nil {
+		return x.ExitRecorded
+	}
+	return false
+}
+
+func (x *AttachSessionEvent) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *AttachSessionEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *AttachSessionEvent) GetCols() uint32 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+func (x *AttachSessionEvent) GetRows() uint32 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *AttachSessionEvent) GetThinkingText() string {
+	if x != nil {
+		return x.ThinkingText
+	}
+	return ""
+}
+
+func (x *AttachSessionEvent) GetWriterClientId() string {
+	if x != nil {
+		return x.WriterClientId
+	}
+	return ""
+}
+
+func (x *AttachSessionEvent) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+func (x *AttachSessionEvent) GetSeverity() Severity {
+	if x != nil {
+		return x.Severity
+	}
+	return Severity_SEVERITY_UNSPECIFIED
+}
+
+func (x *AttachSessionEvent) GetInputId() string {
+	if x != nil {
+		return x.InputId
+	}
+	return ""
+}
+
+func (x *AttachSessionEvent) GetRestartCount() int32 {
+	if x != nil {
+		return x.RestartCount
+	}
+	return 0
+}
+
+func (x *AttachSessionEvent) GetBatch() *AttachSessionEventBatch {
+	if x != nil {
+		return x.Batch
+	}
+	return nil
+}
+
+func (x *AttachSessionEvent) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *AttachSessionEvent) GetExtensionType() string {
+	if x != nil {
+		return x.ExtensionType
+	}
+	return ""
+}
+
+func (x *AttachSessionEvent) GetDataJson() []byte {
+	if x != nil {
+		return x.DataJson
+	}
+	return nil
+}
+
+func (x *AttachSessionEvent) GetAttachments() []string {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+func (x *AttachSessionEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+// AttachSessionEventBatch is a group of attach events, in stream order.
+type AttachSessionEventBatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*AttachSessionEvent  `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachSessionEventBatch) Reset() {
+	*x = AttachSessionEventBatch{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachSessionEventBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachSessionEventBatch) ProtoMessage() {}
+
+func (x *AttachSessionEventBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachSessionEventBatch.ProtoReflect.Descriptor instead.
+func (*AttachSessionEventBatch) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *AttachSessionEventBatch) GetEvents() []*AttachSessionEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type WriteInputRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ClientId  string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Data      []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Priority  InputPriority          `protobuf:"varint,4,opt,name=priority,proto3,enum=bridge.v1.InputPriority" json:"priority,omitempty"`
+	// attachments are files sent with the prompt. The bridge references each
+	// one at the start of data, as "@" and its repo path.
+	Attachments []*InputAttachment `protobuf:"bytes,5,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	// template names a prompt template from the bridge's config, sent
+	// instead of data, which must then be empty. The bridge renders it with
+	// vars and submits it as the session's agent expects.
+	Template      string            `protobuf:"bytes,6,opt,name=template,proto3" json:"template,omitempty"`
+	Vars          map[string]string `protobuf:"bytes,7,rep,name=vars,proto3" json:"vars,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteInputRequest) Reset() {
+	*x = WriteInputRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteInputRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteInputRequest) ProtoMessage() {}
+
+func (x *WriteInputRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteInputRequest.ProtoReflect.Descriptor instead.
+func (*WriteInputRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *WriteInputRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *WriteInputRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *WriteInputRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *WriteInputRequest) GetPriority() InputPriority {
+	if x != nil {
+		return x.Priority
+	}
+	return InputPriority_INPUT_PRIORITY_UNSPECIFIED
+}
+
+func (x *WriteInputRequest) GetAttachments() []*InputAttachment {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+func (x *WriteInputRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *WriteInputRequest) GetVars() map[string]string {
+	if x != nil {
+		return x.Vars
+	}
+	return nil
+}
+
+// InputAttachment is a file sent with an input. Set path to a file already
+// in the session's repo, or name and content to have the bridge write the
+// file to .bridge/attachments/<input_id>/<name> in the repo.
+type InputAttachment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Content       []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InputAttachment) Reset() {
+	*x = InputAttachment{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InputAttachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InputAttachment) ProtoMessage() {}
+
+func (x *InputAttachment) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InputAttachment.ProtoReflect.Descriptor instead.
+func (*InputAttachment) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *InputAttachment) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *InputAttachment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *InputAttachment) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+// SendInputStreamRequest is one chunk of a streamed input. session_id,
+// client_id, priority and total_size are read from the first message only.
+type SendInputStreamRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ClientId  string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// data is the next chunk, at most 1 MiB.
+	Data     []byte        `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Priority InputPriority `protobuf:"varint,4,opt,name=priority,proto3,enum=bridge.v1.InputPriority" json:"priority,omitempty"`
+	// total_size, when set, is the size of the whole input. The server then
+	// refuses an oversized input before it is sent and checks that the
+	// chunks add up to it.
+	TotalSize     uint64 `protobuf:"varint,5,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendInputStreamRequest) Reset() {
+	*x = SendInputStreamRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendInputStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendInputStreamRequest) ProtoMessage() {}
+
+func (x *SendInputStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendInputStreamRequest.ProtoReflect.Descriptor instead.
+func (*SendInputStreamRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *SendInputStreamRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SendInputStreamRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *SendInputStreamRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *SendInputStreamRequest) GetPriority() InputPriority {
+	if x != nil {
+		return x.Priority
+	}
+	return InputPriority_INPUT_PRIORITY_UNSPECIFIED
+}
+
+func (x *SendInputStreamRequest) GetTotalSize() uint64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+type WriteInputResponse struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Accepted     bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	BytesWritten uint32                 `protobuf:"varint,2,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	// input_id identifies this input in the session's attach events.
+	InputId string `protobuf:"bytes,3,opt,name=input_id,json=inputId,proto3" json:"input_id,omitempty"`
+	// queued is set when the input waits for the agent's current response to
+	// complete (sessions.input_queue_depth).
+	Queued bool `protobuf:"varint,4,opt,name=queued,proto3" json:"queued,omitempty"`
+	// seq is the seq of the session's ATTACH_EVENT_TYPE_INPUT_ACKED event for
+	// this input. Attaching with after_seq = seq - 1 reads the response.
+	Seq           uint64 `protobuf:"varint,5,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteInputResponse) Reset() {
+	*x = WriteInputResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteInputResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteInputResponse) ProtoMessage() {}
+
+func (x *WriteInputResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteInputResponse.ProtoReflect.Descriptor instead.
+func (*WriteInputResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *WriteInputResponse) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *WriteInputResponse) GetBytesWritten() uint32 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
+}
+
+func (x *WriteInputResponse) GetInputId() string {
+	if x != nil {
+		return x.InputId
+	}
+	return ""
+}
+
+func (x *WriteInputResponse) GetQueued() bool {
+	if x != nil {
+		return x.Queued
+	}
+	return false
+}
+
+func (x *WriteInputResponse) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type BroadcastInputRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// session_ids are the sessions to send to, at most 32, without
+	// duplicates.
+	SessionIds []string      `protobuf:"bytes,1,rep,name=session_ids,json=sessionIds,proto3" json:"session_ids,omitempty"`
+	ClientId   string        `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Data       []byte        `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Priority   InputPriority `protobuf:"varint,4,opt,name=priority,proto3,enum=bridge.v1.InputPriority" json:"priority,omitempty"`
+	// attachments are sent with the input to every session, as in
+	// WriteInput. Paths are resolved in each session's repo.
+	Attachments []*InputAttachment `protobuf:"bytes,5,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	// template and vars are sent instead of data, as in WriteInput.
+	Template      string            `protobuf:"bytes,6,opt,name=template,proto3" json:"template,omitempty"`
+	Vars          map[string]string `protobuf:"bytes,7,rep,name=vars,proto3" json:"vars,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BroadcastInputRequest) Reset() {
+	*x = BroadcastInputRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BroadcastInputRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BroadcastInputRequest) ProtoMessage() {}
+
+func (x *BroadcastInputRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BroadcastInputRequest.ProtoReflect.Descriptor instead.
+func (*BroadcastInputRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *BroadcastInputRequest) GetSessionIds() []string {
+	if x != nil {
+		return x.SessionIds
+	}
+	return nil
+}
+
+func (x *BroadcastInputRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *BroadcastInputRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *BroadcastInputRequest) GetPriority() InputPriority {
+	if x != nil {
+		return x.Priority
+	}
+	return InputPriority_INPUT_PRIORITY_UNSPECIFIED
+}
+
+func (x *BroadcastInputRequest) GetAttachments() []*InputAttachment {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+func (x *BroadcastInputRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *BroadcastInputRequest) GetVars() map[string]string {
+	if x != nil {
+		return x.Vars
+	}
+	return nil
+}
+
+type BroadcastInputResult struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Accepted  bool                   `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	InputId   string                 `protobuf:"bytes,3,opt,name=input_id,json=inputId,proto3" json:"input_id,omitempty"`
+	// seq is the seq of the session's ATTACH_EVENT_TYPE_INPUT_ACKED event, as
+	// in WriteInputResponse.
+	Seq          uint64 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	Queued       bool   `protobuf:"varint,5,opt,name=queued,proto3" json:"queued,omitempty"`
+	BytesWritten uint32 `protobuf:"varint,6,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	// error and code describe why the session refused the input; code is a
+	// google.rpc.Code, as WriteInput would have returned.
+	Error         string `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	Code          int32  `protobuf:"varint,8,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BroadcastInputResult) Reset() {
+	*x = BroadcastInputResult{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BroadcastInputResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BroadcastInputResult) ProtoMessage() {}
+
+func (x *BroadcastInputResult) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BroadcastInputResult.ProtoReflect.Descriptor instead.
+func (*BroadcastInputResult) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *BroadcastInputResult) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *BroadcastInputResult) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *BroadcastInputResult) GetInputId() string {
+	if x != nil {
+		return x.InputId
+	}
+	return ""
+}
+
+func (x *BroadcastInputResult) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *BroadcastInputResult) GetQueued() bool {
+	if x != nil {
+		return x.Queued
+	}
+	return false
+}
+
+func (x *BroadcastInputResult) GetBytesWritten() uint32 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
+}
+
+func (x *BroadcastInputResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *BroadcastInputResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+type BroadcastInputResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// results has one entry per session, in the order of session_ids.
+	Results       []*BroadcastInputResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BroadcastInputResponse) Reset() {
+	*x = BroadcastInputResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BroadcastInputResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BroadcastInputResponse) ProtoMessage() {}
+
+func (x *BroadcastInputResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BroadcastInputResponse.ProtoReflect.Descriptor instead.
+func (*BroadcastInputResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *BroadcastInputResponse) GetResults() []*BroadcastInputResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type TerminalOpen struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// client_id identifies the terminal; generated when empty.
+	ClientId string `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// after_seq resumes output after this seq, as in AttachSession.
+	AfterSeq uint64 `protobuf:"varint,3,opt,name=after_seq,json=afterSeq,proto3" json:"after_seq,omitempty"`
+	// role defaults to ATTACH_ROLE_WRITER. Observers may not send input.
+	Role          AttachRole `protobuf:"varint,4,opt,name=role,proto3,enum=bridge.v1.AttachRole" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminalOpen) Reset() {
+	*x = TerminalOpen{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminalOpen) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminalOpen) ProtoMessage() {}
+
+func (x *TerminalOpen) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminalOpen.ProtoReflect.Descriptor instead.
+func (*TerminalOpen) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *TerminalOpen) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *TerminalOpen) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *TerminalOpen) GetAfterSeq() uint64 {
+	if x != nil {
+		return x.AfterSeq
+	}
+	return 0
+}
+
+func (x *TerminalOpen) GetRole() AttachRole {
+	if x != nil {
+		return x.Role
+	}
+	return AttachRole_ATTACH_ROLE_UNSPECIFIED
+}
+
+type TerminalResize struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cols          uint32                 `protobuf:"varint,1,opt,name=cols,proto3" json:"cols,omitempty"`
+	Rows          uint32                 `protobuf:"varint,2,opt,name=rows,proto3" json:"rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminalResize) Reset() {
+	*x = TerminalResize{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminalResize) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminalResize) ProtoMessage() {}
+
+func (x *TerminalResize) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminalResize.ProtoReflect.Descriptor instead.
+func (*TerminalResize) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *TerminalResize) GetCols() uint32 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+func (x *TerminalResize) GetRows() uint32 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+type AttachTerminalRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*AttachTerminalRequest_Open
+	//	*AttachTerminalRequest_Input
+	//	*AttachTerminalRequest_Resize
+	Frame         isAttachTerminalRequest_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachTerminalRequest) Reset() {
+	*x = AttachTerminalRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachTerminalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachTerminalRequest) ProtoMessage() {}
+
+func (x *AttachTerminalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachTerminalRequest.ProtoReflect.Descriptor instead.
+func (*AttachTerminalRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *AttachTerminalRequest) GetFrame() isAttachTerminalRequest_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *AttachTerminalRequest) GetOpen() *TerminalOpen {
+	if x != nil {
+		if x, ok := x.Frame.(*AttachTerminalRequest_Open); ok {
+			return x.Open
+		}
+	}
+	return nil
+}
+
+func (x *AttachTerminalRequest) GetInput() []byte {
+	if x != nil {
+		if x, ok := x.Frame.(*AttachTerminalRequest_Input); ok {
+			return x.Input
+		}
+	}
+	return nil
+}
+
+func (x *AttachTerminalRequest) GetResize() *TerminalResize {
+	if x != nil {
+		if x, ok := x.Frame.(*AttachTerminalRequest_Resize); ok {
+			return x.Resize
+		}
+	}
+	return nil
+}
+
+type isAttachTerminalRequest_Frame interface {
+	isAttachTerminalRequest_Frame()
+}
+
+type AttachTerminalRequest_Open struct {
+	Open *TerminalOpen `protobuf:"bytes,1,opt,name=open,proto3,oneof"`
+}
+
+type AttachTerminalRequest_Input struct {
+	// input is raw bytes for the agent's terminal, e.g. keystrokes.
+	Input []byte `protobuf:"bytes,2,opt,name=input,proto3,oneof"`
+}
+
+type AttachTerminalRequest_Resize struct {
+	Resize *TerminalResize `protobuf:"bytes,3,opt,name=resize,proto3,oneof"`
+}
+
+func (*AttachTerminalRequest_Open) isAttachTerminalRequest_Frame() {}
+
+func (*AttachTerminalRequest_Input) isAttachTerminalRequest_Frame() {}
+
+func (*AttachTerminalRequest_Resize) isAttachTerminalRequest_Frame() {}
+
+type TerminalAttached struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ClientId  string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Role      AttachRole             `protobuf:"varint,2,opt,name=role,proto3,enum=bridge.v1.AttachRole" json:"role,omitempty"`
+	Cols      uint32                 `protobuf:"varint,3,opt,name=cols,proto3" json:"cols,omitempty"`
+	Rows      uint32                 `protobuf:"varint,4,opt,name=rows,proto3" json:"rows,omitempty"`
+	OldestSeq uint64                 `protobuf:"varint,5,opt,name=oldest_seq,json=oldestSeq,proto3" json:"oldest_seq,omitempty"`
+	LastSeq   uint64                 `protobuf:"varint,6,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	// replay_gap is set when output after after_seq was evicted; replay starts
+	// at oldest_seq, so the terminal should be reset first.
+	ReplayGap     bool `protobuf:"varint,7,opt,name=replay_gap,json=replayGap,proto3" json:"replay_gap,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminalAttached) Reset() {
+	*x = TerminalAttached{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminalAttached) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminalAttached) ProtoMessage() {}
+
+func (x *TerminalAttached) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminalAttached.ProtoReflect.Descriptor instead.
+func (*TerminalAttached) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *TerminalAttached) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *TerminalAttached) GetRole() AttachRole {
+	if x != nil {
+		return x.Role
+	}
+	return AttachRole_ATTACH_ROLE_UNSPECIFIED
+}
+
+func (x *TerminalAttached) GetCols() uint32 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+func (x *TerminalAttached) GetRows() uint32 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *TerminalAttached) GetOldestSeq() uint64 {
+	if x != nil {
+		return x.OldestSeq
+	}
+	return 0
+}
+
+func (x *TerminalAttached) GetLastSeq() uint64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+func (x *TerminalAttached) GetReplayGap() bool {
+	if x != nil {
+		return x.ReplayGap
+	}
+	return false
+}
+
+type TerminalOutput struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Seq   uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	// data is raw PTY bytes; escape sequences may span messages.
+	Data          []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Replay        bool   `protobuf:"varint,3,opt,name=replay,proto3" json:"replay,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminalOutput) Reset() {
+	*x = TerminalOutput{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminalOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminalOutput) ProtoMessage() {}
+
+func (x *TerminalOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminalOutput.ProtoReflect.Descriptor instead.
+func (*TerminalOutput) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *TerminalOutput) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *TerminalOutput) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *TerminalOutput) GetReplay() bool {
+	if x != nil {
+		return x.Replay
+	}
+	return false
+}
+
+type TerminalExit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExitRecorded  bool                   `protobuf:"varint,1,opt,name=exit_recorded,json=exitRecorded,proto3" json:"exit_recorded,omitempty"`
+	ExitCode      int32                  `protobuf:"varint,2,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminalExit) Reset() {
+	*x = TerminalExit{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminalExit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminalExit) ProtoMessage() {}
+
+func (x *TerminalExit) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminalExit.ProtoReflect.Descriptor instead.
+func (*TerminalExit) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *TerminalExit) GetExitRecorded() bool {
+	if x != nil {
+		return x.ExitRecorded
+	}
+	return false
+}
+
+func (x *TerminalExit) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+type AttachTerminalResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*AttachTerminalResponse_Attached
+	//	*AttachTerminalResponse_Output
+	//	*AttachTerminalResponse_Exit
+	Frame         isAttachTerminalResponse_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachTerminalResponse) Reset() {
+	*x = AttachTerminalResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachTerminalResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachTerminalResponse) ProtoMessage() {}
+
+func (x *AttachTerminalResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachTerminalResponse.ProtoReflect.Descriptor instead.
+func (*AttachTerminalResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *AttachTerminalResponse) GetFrame() isAttachTerminalResponse_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *AttachTerminalResponse) GetAttached() *TerminalAttached {
+	if x != nil {
+		if x, ok := x.Frame.(*AttachTerminalResponse_Attached); ok {
+			return x.Attached
+		}
+	}
+	return nil
+}
+
+func (x *AttachTerminalResponse) GetOutput() *TerminalOutput {
+	if x != nil {
+		if x, ok := x.Frame.(*AttachTerminalResponse_Output); ok {
+			return x.Output
+		}
+	}
+	return nil
+}
+
+func (x *AttachTerminalResponse) GetExit() *TerminalExit {
+	if x != nil {
+		if x, ok := x.Frame.(*AttachTerminalResponse_Exit); ok {
+			return x.Exit
+		}
+	}
+	return nil
+}
+
+type isAttachTerminalResponse_Frame interface {
+	isAttachTerminalResponse_Frame()
+}
+
+type AttachTerminalResponse_Attached struct {
+	// attached is always the first response.
+	Attached *TerminalAttached `protobuf:"bytes,1,opt,name=attached,proto3,oneof"`
+}
+
+type AttachTerminalResponse_Output struct {
+	Output *TerminalOutput `protobuf:"bytes,2,opt,name=output,proto3,oneof"`
+}
+
+type AttachTerminalResponse_Exit struct {
+	// exit is the last response when the agent process exits.
+	Exit *TerminalExit `protobuf:"bytes,3,opt,name=exit,proto3,oneof"`
+}
+
+func (*AttachTerminalResponse_Attached) isAttachTerminalResponse_Frame() {}
+
+func (*AttachTerminalResponse_Output) isAttachTerminalResponse_Frame() {}
+
+func (*AttachTerminalResponse_Exit) isAttachTerminalResponse_Frame() {}
+
+type ResizeSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ClientId      string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Cols          uint32                 `protobuf:"varint,3,opt,name=cols,proto3" json:"cols,omitempty"`
+	Rows          uint32                 `protobuf:"varint,4,opt,name=rows,proto3" json:"rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResizeSessionRequest) Reset() {
+	*x = ResizeSessionRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResizeSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResizeSessionRequest) ProtoMessage() {}
+
+func (x *ResizeSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResizeSessionRequest.ProtoReflect.Descriptor instead.
+func (*ResizeSessionRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ResizeSessionRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ResizeSessionRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *ResizeSessionRequest) GetCols() uint32 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+func (x *ResizeSessionRequest) GetRows() uint32 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+type ResizeSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Applied       bool                   `protobuf:"varint,1,opt,name=applied,proto3" json:"applied,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResizeSessionResponse) Reset() {
+	*x = ResizeSessionResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResizeSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResizeSessionResponse) ProtoMessage() {}
+
+func (x *ResizeSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResizeSessionResponse.ProtoReflect.Descriptor instead.
+func (*ResizeSessionResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ResizeSessionResponse) GetApplied() bool {
+	if x != nil {
+		return x.Applied
+	}
+	return false
+}
+
+type CancelResponseRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// client_id must hold the writer slot.
+	ClientId      string `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelResponseRequest) Reset() {
+	*x = CancelResponseRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelResponseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelResponseRequest) ProtoMessage() {}
+
+func (x *CancelResponseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelResponseRequest.ProtoReflect.Descriptor instead.
+func (*CancelResponseRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *CancelResponseRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *CancelResponseRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+type CancelResponseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Delivered     bool                   `protobuf:"varint,1,opt,name=delivered,proto3" json:"delivered,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelResponseResponse) Reset() {
+	*x = CancelResponseResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelResponseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelResponseResponse) ProtoMessage() {}
+
+func (x *CancelResponseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelResponseResponse.ProtoReflect.Descriptor instead.
+func (*CancelResponseResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *CancelResponseResponse) GetDelivered() bool {
+	if x != nil {
+		return x.Delivered
+	}
+	return false
+}
+
+type ResolveApprovalRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// client_id must hold the writer slot.
+	ClientId string `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// approval_id is the id from the request's data_json.
+	ApprovalId string `protobuf:"bytes,3,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"`
+	// approve allows the tool call; false denies it.
+	Approve bool `protobuf:"varint,4,opt,name=approve,proto3" json:"approve,omitempty"`
+	// message tells the agent why a call was denied. Ignored on approval.
+	Message       string `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveApprovalRequest) Reset() {
+	*x = ResolveApprovalRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveApprovalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveApprovalRequest) ProtoMessage() {}
+
+func (x *ResolveApprovalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveApprovalRequest.ProtoReflect.Descriptor instead.
+func (*ResolveApprovalRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *ResolveApprovalRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ResolveApprovalRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *ResolveApprovalRequest) GetApprovalId() string {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return ""
+}
+
+func (x *ResolveApprovalRequest) GetApprove() bool {
+	if x != nil {
+		return x.Approve
+	}
+	return false
+}
+
+func (x *ResolveApprovalRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ResolveApprovalResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Delivered     bool                   `protobuf:"varint,1,opt,name=delivered,proto3" json:"delivered,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveApprovalResponse) Reset() {
+	*x = ResolveApprovalResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveApprovalResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveApprovalResponse) ProtoMessage() {}
+
+func (x *ResolveApprovalResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveApprovalResponse.ProtoReflect.Descriptor instead.
+func (*ResolveApprovalResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *ResolveApprovalResponse) GetDelivered() bool {
+	if x != nil {
+		return x.Delivered
+	}
+	return false
+}
+
+type ReadFileRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// path is relative to the session's repo_path.
+	Path          string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadFileRequest) Reset() {
+	*x = ReadFileRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadFileRequest) ProtoMessage() {}
+
+func (x *ReadFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadFileRequest.ProtoReflect.Descriptor instead.
+func (*ReadFileRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ReadFileRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ReadFileRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type ReadFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	ModifiedAt    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadFileResponse) Reset() {
+	*x = ReadFileResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadFileResponse) ProtoMessage() {}
+
+func (x *ReadFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadFileResponse.ProtoReflect.Descriptor instead.
+func (*ReadFileResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ReadFileResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ReadFileResponse) GetModifiedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ModifiedAt
+	}
+	return nil
+}
+
+type WriteFileRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// path is relative to the session's repo_path. Missing parent
+	// directories are created.
+	Path          string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Data          []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteFileRequest) Reset() {
+	*x = WriteFileRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteFileRequest) ProtoMessage() {}
+
+func (x *WriteFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteFileRequest.ProtoReflect.Descriptor instead.
+func (*WriteFileRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *WriteFileRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *WriteFileRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *WriteFileRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type WriteFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BytesWritten  uint32                 `protobuf:"varint,1,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteFileResponse) Reset() {
+	*x = WriteFileResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteFileResponse) ProtoMessage() {}
+
+func (x *WriteFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteFileResponse.ProtoReflect.Descriptor instead.
+func (*WriteFileResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *WriteFileResponse) GetBytesWritten() uint32 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
+}
+
+type ListDirRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// path is relative to the session's repo_path; empty lists the repo root.
+	Path          string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDirRequest) Reset() {
+	*x = ListDirRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDirRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDirRequest) ProtoMessage() {}
+
+func (x *ListDirRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDirRequest.ProtoReflect.Descriptor instead.
+func (*ListDirRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *ListDirRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ListDirRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type DirEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	IsDir         bool                   `protobuf:"varint,2,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	ModifiedAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DirEntry) Reset() {
+	*x = DirEntry{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DirEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DirEntry) ProtoMessage() {}
+
+func (x *DirEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DirEntry.ProtoReflect.Descriptor instead.
+func (*DirEntry) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *DirEntry) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DirEntry) GetIsDir() bool {
+	if x != nil {
+		return x.IsDir
+	}
+	return false
+}
+
+func (x *DirEntry) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *DirEntry) GetModifiedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ModifiedAt
+	}
+	return nil
+}
+
+type ListDirResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*DirEntry            `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDirResponse) Reset() {
+	*x = ListDirResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDirResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDirResponse) ProtoMessage() {}
+
+func (x *ListDirResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDirResponse.ProtoReflect.Descriptor instead.
+func (*ListDirResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *ListDirResponse) GetEntries() []*DirEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type GitStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GitStatusRequest) Reset() {
+	*x = GitStatusRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GitStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GitStatusRequest) ProtoMessage() {}
+
+func (x *GitStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GitStatusRequest.ProtoReflect.Descriptor instead.
+func (*GitStatusRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *GitStatusRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type GitFileStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// path is relative to the top of the work tree.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// orig_path is the source path of a rename or copy.
+	OrigPath string `protobuf:"bytes,2,opt,name=orig_path,json=origPath,proto3" json:"orig_path,omitempty"`
+	// index_status and worktree_status are the X and Y codes of
+	// `git status --porcelain`, e.g. "M", "A", "?".
+	IndexStatus    string `protobuf:"bytes,3,opt,name=index_status,json=indexStatus,proto3" json:"index_status,omitempty"`
+	WorktreeStatus string `protobuf:"bytes,4,opt,name=worktree_status,json=worktreeStatus,proto3" json:"worktree_status,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GitFileStatus) Reset() {
+	*x = GitFileStatus{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GitFileStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GitFileStatus) ProtoMessage() {}
+
+func (x *GitFileStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GitFileStatus.ProtoReflect.Descriptor instead.
+func (*GitFileStatus) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *GitFileStatus) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GitFileStatus) GetOrigPath() string {
+	if x != nil {
+		return x.OrigPath
+	}
+	return ""
+}
+
+func (x *GitFileStatus) GetIndexStatus() string {
+	if x != nil {
+		return x.IndexStatus
+	}
+	return ""
+}
+
+func (x *GitFileStatus) GetWorktreeStatus() string {
+	if x != nil {
+		return x.WorktreeStatus
+	}
+	return ""
+}
+
+type GitStatusResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// branch is empty when HEAD is detached.
+	Branch string `protobuf:"bytes,1,opt,name=branch,proto3" json:"branch,omitempty"`
+	// head is empty before the first commit.
+	Head          string           `protobuf:"bytes,2,opt,name=head,proto3" json:"head,omitempty"`
+	Clean         bool             `protobuf:"varint,3,opt,name=clean,proto3" json:"clean,omitempty"`
+	Files         []*GitFileStatus `protobuf:"bytes,4,rep,name=files,proto3" json:"files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GitStatusResponse) Reset() {
+	*x = GitStatusResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GitStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GitStatusResponse) ProtoMessage() {}
+
+func (x *GitStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GitStatusResponse.ProtoReflect.Descriptor instead.
+func (*GitStatusResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *GitStatusResponse) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *GitStatusResponse) GetHead() string {
+	if x != nil {
+		return x.Head
+	}
+	return ""
+}
+
+func (x *GitStatusResponse) GetClean() bool {
+	if x != nil {
+		return x.Clean
+	}
+	return false
+}
+
+func (x *GitStatusResponse) GetFiles() []*GitFileStatus {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+type GitDiffRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// staged diffs the index instead of the working tree.
+	Staged bool `protobuf:"varint,2,opt,name=staged,proto3" json:"staged,omitempty"`
+	// base is a revision to diff against; empty diffs against the index
+	// (or HEAD when staged).
+	Base string `protobuf:"bytes,3,opt,name=base,proto3" json:"base,omitempty"`
+	// paths limits the diff to these repo-relative paths.
+	Paths         []string `protobuf:"bytes,4,rep,name=paths,proto3" json:"paths,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GitDiffRequest) Reset() {
+	*x = GitDiffRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GitDiffRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GitDiffRequest) ProtoMessage() {}
+
+func (x *GitDiffRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GitDiffRequest.ProtoReflect.Descriptor instead.
+func (*GitDiffRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *GitDiffRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *GitDiffRequest) GetStaged() bool {
+	if x != nil {
+		return x.Staged
+	}
+	return false
+}
+
+func (x *GitDiffRequest) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *GitDiffRequest) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+type GitDiffResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Diff  []byte                 `protobuf:"bytes,1,opt,name=diff,proto3" json:"diff,omitempty"`
+	// truncated is set when the diff was cut at files.max_size_bytes.
+	Truncated     bool `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GitDiffResponse) Reset() {
+	*x = GitDiffResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GitDiffResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GitDiffResponse) ProtoMessage() {}
+
+func (x *GitDiffResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GitDiffResponse.ProtoReflect.Descriptor instead.
+func (*GitDiffResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *GitDiffResponse) GetDiff() []byte {
+	if x != nil {
+		return x.Diff
+	}
+	return nil
+}
+
+func (x *GitDiffResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type GitCommitRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Message   string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// all stages every change, untracked files included. Otherwise paths
+	// are staged; with neither, the current index is committed.
+	All           bool     `protobuf:"varint,3,opt,name=all,proto3" json:"all,omitempty"`
+	Paths         []string `protobuf:"bytes,4,rep,name=paths,proto3" json:"paths,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GitCommitRequest) Reset() {
+	*x = GitCommitRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GitCommitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GitCommitRequest) ProtoMessage() {}
+
+func (x *GitCommitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GitCommitRequest.ProtoReflect.Descriptor instead.
+func (*GitCommitRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *GitCommitRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *GitCommitRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *GitCommitRequest) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+func (x *GitCommitRequest) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+type GitCommitResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commit        string                 `protobuf:"bytes,1,opt,name=commit,proto3" json:"commit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GitCommitResponse) Reset() {
+	*x = GitCommitResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GitCommitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GitCommitResponse) ProtoMessage() {}
+
+func (x *GitCommitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GitCommitResponse.ProtoReflect.Descriptor instead.
+func (*GitCommitResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *GitCommitResponse) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+type RollbackWorkspaceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RollbackWorkspaceRequest) Reset() {
+	*x = RollbackWorkspaceRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollbackWorkspaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollbackWorkspaceRequest) ProtoMessage() {}
+
+func (x *RollbackWorkspaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollbackWorkspaceRequest.ProtoReflect.Descriptor instead.
+func (*RollbackWorkspaceRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *RollbackWorkspaceRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type RollbackWorkspaceResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Commit HEAD points at again; empty if the repo had no commits.
+	Head string `protobuf:"bytes,1,opt,name=head,proto3" json:"head,omitempty"`
+	// Commit holding the restored working tree, kept under
+	// refs/bridge/snapshots/<session_id> until the session is archived.
+	SnapshotCommit string `protobuf:"bytes,2,opt,name=snapshot_commit,json=snapshotCommit,proto3" json:"snapshot_commit,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RollbackWorkspaceResponse) Reset() {
+	*x = RollbackWorkspaceResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollbackWorkspaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollbackWorkspaceResponse) ProtoMessage() {}
+
+func (x *RollbackWorkspaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollbackWorkspaceResponse.ProtoReflect.Descriptor instead.
+func (*RollbackWorkspaceResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *RollbackWorkspaceResponse) GetHead() string {
+	if x != nil {
+		return x.Head
+	}
+	return ""
+}
+
+func (x *RollbackWorkspaceResponse) GetSnapshotCommit() string {
+	if x != nil {
+		return x.SnapshotCommit
+	}
+	return ""
+}
+
+type ClaimWriterRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ClientId  string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// force, when true, evicts the current writer rather than returning
+	// ALREADY_EXISTS. The evicted writer receives a WRITER_RELEASED event.
+	Force         bool `protobuf:"varint,3,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClaimWriterRequest) Reset() {
+	*x = ClaimWriterRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimWriterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimWriterRequest) ProtoMessage() {}
+
+func (x *ClaimWriterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimWriterRequest.ProtoReflect.Descriptor instead.
+func (*ClaimWriterRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *ClaimWriterRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ClaimWriterRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *ClaimWriterRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type ClaimWriterResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// claimed is true when the caller now holds the writer slot.
+	Claimed bool `protobuf:"varint,1,opt,name=claimed,proto3" json:"claimed,omitempty"`
+	// previous_writer_client_id is set when force evicted another writer.
+	PreviousWriterClientId string `protobuf:"bytes,2,opt,name=previous_writer_client_id,json=previousWriterClientId,proto3" json:"previous_writer_client_id,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *ClaimWriterResponse) Reset() {
+	*x = ClaimWriterResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimWriterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimWriterResponse) ProtoMessage() {}
+
+func (x *ClaimWriterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimWriterResponse.ProtoReflect.Descriptor instead.
+func (*ClaimWriterResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *ClaimWriterResponse) GetClaimed() bool {
+	if x != nil {
+		return x.Claimed
+	}
+	return false
+}
+
+func (x *ClaimWriterResponse) GetPreviousWriterClientId() string {
+	if x != nil {
+		return x.PreviousWriterClientId
+	}
+	return ""
+}
+
+type ReleaseWriterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ClientId      string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseWriterRequest) Reset() {
+	*x = ReleaseWriterRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseWriterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseWriterRequest) ProtoMessage() {}
+
+func (x *ReleaseWriterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseWriterRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseWriterRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *ReleaseWriterRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ReleaseWriterRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+type ReleaseWriterResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// released is true when the caller successfully released the writer slot.
+	Released      bool `protobuf:"varint,1,opt,name=released,proto3" json:"released,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseWriterResponse) Reset() {
+	*x = ReleaseWriterResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseWriterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseWriterResponse) ProtoMessage() {}
+
+func (x *ReleaseWriterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseWriterResponse.ProtoReflect.Descriptor instead.
+func (*ReleaseWriterResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *ReleaseWriterResponse) GetReleased() bool {
+	if x != nil {
+		return x.Released
+	}
+	return false
+}
+
+type TakeControlRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// client_id must be attached to the session.
+	ClientId string `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// reason is recorded on the CONTROL_CHANGED event, e.g. "agent stuck in
+	// a loop".
+	Reason        string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TakeControlRequest) Reset() {
+	*x = TakeControlRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TakeControlRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TakeControlRequest) ProtoMessage() {}
+
+func (x *TakeControlRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TakeControlRequest.ProtoReflect.Descriptor instead.
+func (*TakeControlRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *TakeControlRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *TakeControlRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *TakeControlRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type TakeControlResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// taken is true when the caller now controls the session.
+	Taken bool `protobuf:"varint,1,opt,name=taken,proto3" json:"taken,omitempty"`
+	// previous_controller_client_id is the writer that lost control, if any.
+	PreviousControllerClientId string `protobuf:"bytes,2,opt,name=previous_controller_client_id,json=previousControllerClientId,proto3" json:"previous_controller_client_id,omitempty"`
+	unknownFields              protoimpl.UnknownFields
+	sizeCache                  protoimpl.SizeCache
+}
+
+func (x *TakeControlResponse) Reset() {
+	*x = TakeControlResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TakeControlResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TakeControlResponse) ProtoMessage() {}
+
+func (x *TakeControlResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TakeControlResponse.ProtoReflect.Descriptor instead.
+func (*TakeControlResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *TakeControlResponse) GetTaken() bool {
+	if x != nil {
+		return x.Taken
+	}
+	return false
+}
+
+func (x *TakeControlResponse) GetPreviousControllerClientId() string {
+	if x != nil {
+		return x.PreviousControllerClientId
+	}
+	return ""
+}
+
+type MintSessionTokenRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// ttl_seconds is the token's lifetime; 0 uses the longest the bridge
+	// allows, which is also the limit.
+	TtlSeconds    uint32 `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MintSessionTokenRequest) Reset() {
+	*x = MintSessionTokenRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MintSessionTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MintSessionTokenRequest) ProtoMessage() {}
+
+func (x *MintSessionTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MintSessionTokenRequest.ProtoReflect.Descriptor instead.
+func (*MintSessionTokenRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *MintSessionTokenRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *MintSessionTokenRequest) GetTtlSeconds() uint32 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type MintSessionTokenResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// token is a JWT with the events:read scope and a session_id claim. It
+	// is URL-safe, so it can be embedded in a share link.
+	Token     string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// token_id is the token's jti; AdminService.RevokeToken revokes it.
+	TokenId       string `protobuf:"bytes,3,opt,name=token_id,json=tokenId,proto3" json:"token_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MintSessionTokenResponse) Reset() {
+	*x = MintSessionTokenResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MintSessionTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MintSessionTokenResponse) ProtoMessage() {}
+
+func (x *MintSessionTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MintSessionTokenResponse.ProtoReflect.Descriptor instead.
+func (*MintSessionTokenResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *MintSessionTokenResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *MintSessionTokenResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *MintSessionTokenResponse) GetTokenId() string {
+	if x != nil {
+		return x.TokenId
+	}
+	return ""
+}
+
+type HealthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Check         HealthCheck            `protobuf:"varint,1,opt,name=check,proto3,enum=bridge.v1.HealthCheck" json:"check,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *HealthRequest) GetCheck() HealthCheck {
+	if x != nil {
+		return x.Check
+	}
+	return HealthCheck_HEALTH_CHECK_UNSPECIFIED
+}
+
+type HealthResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// status is "serving", or "not_serving" when a readiness check failed.
+	Status    string            `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Providers []*ProviderHealth `protobuf:"bytes,2,rep,name=providers,proto3" json:"providers,omitempty"`
+	// server_instance_id is a UUID generated once at daemon startup.
+	// Clients can compare this value across Health calls to detect a daemon
+	// restart (a changed ID means the process restarted and all in-memory
+	// session state has been lost).
+	ServerInstanceId string `protobuf:"bytes,3,opt,name=server_instance_id,json=serverInstanceId,proto3" json:"server_instance_id,omitempty"`
+	// redaction_hits counts the values each redaction pattern has replaced
+	// in logs and session output since startup, keyed by built-in detector
+	// name or "redact_patterns[i]".
+	RedactionHits map[string]uint64 `protobuf:"bytes,4,rep,name=redaction_hits,json=redactionHits,proto3" json:"redaction_hits,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// checks holds the result of each readiness check. Empty for liveness.
+	Checks        []*HealthCheckResult `protobuf:"bytes,5,rep,name=checks,proto3" json:"checks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *HealthResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetProviders() []*ProviderHealth {
+	if x != nil {
+		return x.Providers
+	}
+	return nil
+}
+
+func (x *HealthResponse) GetServerInstanceId() string {
+	if x != nil {
+		return x.ServerInstanceId
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetRedactionHits() map[string]uint64 {
+	if x != nil {
+		return x.RedactionHits
+	}
+	return nil
+}
+
+func (x *HealthResponse) GetChecks() []*HealthCheckResult {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+type HealthCheckResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name is "providers", "certificate" or "disk".
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Ok            bool   `protobuf:"varint,2,opt,name=ok,proto3" json:"ok,omitempty"`
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckResult) Reset() {
+	*x = HealthCheckResult{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckResult) ProtoMessage() {}
+
+func (x *HealthCheckResult) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckResult.ProtoReflect.Descriptor instead.
+func (*HealthCheckResult) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *HealthCheckResult) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HealthCheckResult) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *HealthCheckResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ProviderHealth struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Available     bool                   `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProviderHealth) Reset() {
+	*x = ProviderHealth{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProviderHealth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProviderHealth) ProtoMessage() {}
+
+func (x *ProviderHealth) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[73]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProviderHealth.ProtoReflect.Descriptor instead.
+func (*ProviderHealth) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{73}
+}
+
+func (x *ProviderHealth) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *ProviderHealth) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+func (x *ProviderHealth) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ListProvidersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProvidersRequest) Reset() {
+	*x = ListProvidersRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProvidersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProvidersRequest) ProtoMessage() {}
+
+func (x *ListProvidersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[74]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProvidersRequest.ProtoReflect.Descriptor instead.
+func (*ListProvidersRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{74}
+}
+
+type ListProvidersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Providers     []*ProviderInfo        `protobuf:"bytes,1,rep,name=providers,proto3" json:"providers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProvidersResponse) Reset() {
+	*x = ListProvidersResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProvidersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProvidersResponse) ProtoMessage() {}
+
+func (x *ListProvidersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[75]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProvidersResponse.ProtoReflect.Descriptor instead.
+func (*ListProvidersResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *ListProvidersResponse) GetProviders() []*ProviderInfo {
+	if x != nil {
+		return x.Providers
+	}
+	return nil
+}
+
+type ProviderInfo struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Provider  string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Available bool                   `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
+	Binary    string                 `protobuf:"bytes,3,opt,name=binary,proto3" json:"binary,omitempty"`
+	Version   string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	// error says why the provider is unavailable, e.g. that its installed
+	// version is outside min_version/max_version.
+	Error string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	// variants are the provider's variants, the default first; empty for
+	// providers without variants. available is true while any of them is.
+	Variants      []*ProviderVariant `protobuf:"bytes,6,rep,name=variants,proto3" json:"variants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProviderInfo) Reset() {
+	*x = ProviderInfo{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[76]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProviderInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProviderInfo) ProtoMessage() {}
+
+func (x *ProviderInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[76]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProviderInfo.ProtoReflect.Descriptor instead.
+func (*ProviderInfo) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *ProviderInfo) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *ProviderInfo) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+func (x *ProviderInfo) GetBinary() string {
+	if x != nil {
+		return x.Binary
+	}
+	return ""
+}
+
+func (x *ProviderInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ProviderInfo) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ProviderInfo) GetVariants() []*ProviderVariant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+// ProviderVariant is one variant of a provider, selected with the
+// "variant" agent_opt of StartSession.
+type ProviderVariant struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Available     bool                   `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
+	Binary        string                 `protobuf:"bytes,3,opt,name=binary,proto3" json:"binary,omitempty"`
+	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	Default       bool                   `protobuf:"varint,6,opt,name=default,proto3" json:"default,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProviderVariant) Reset() {
+	*x = ProviderVariant{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[77]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProviderVariant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProviderVariant) ProtoMessage() {}
+
+func (x *ProviderVariant) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[77]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProviderVariant.ProtoReflect.Descriptor instead.
+func (*ProviderVariant) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{77}
+}
+
+func (x *ProviderVariant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ProviderVariant) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+func (x *ProviderVariant) GetBinary() string {
+	if x != nil {
+		return x.Binary
+	}
+	return ""
+}
+
+func (x *ProviderVariant) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ProviderVariant) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ProviderVariant) GetDefault() bool {
+	if x != nil {
+		return x.Default
+	}
+	return false
+}
+
+type AdminStopSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Force         bool                   `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdminStopSessionRequest) Reset() {
+	*x = AdminStopSessionRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[78]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdminStopSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminStopSessionRequest) ProtoMessage() {}
+
+func (x *AdminStopSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[78]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminStopSessionRequest.ProtoReflect.Descriptor instead.
+func (*AdminStopSessionRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{78}
+}
+
+func (x *AdminStopSessionRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *AdminStopSessionRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type AdminStopSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Status        SessionStatus          `protobuf:"varint,2,opt,name=status,proto3,enum=bridge.v1.SessionStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdminStopSessionResponse) Reset() {
+	*x = AdminStopSessionResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[79]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdminStopSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminStopSessionResponse) ProtoMessage() {}
+
+func (x *AdminStopSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[79]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminStopSessionResponse.ProtoReflect.Descriptor instead.
+func (*AdminStopSessionResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{79}
+}
+
+func (x *AdminStopSessionResponse) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *AdminStopSessionResponse) GetStatus() SessionStatus {
+	if x != nil {
+		return x.Status
+	}
+	return SessionStatus_SESSION_STATUS_UNSPECIFIED
+}
+
+type DrainRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DrainRequest) Reset() {
+	*x = DrainRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[80]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DrainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainRequest) ProtoMessage() {}
+
+func (x *DrainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[80]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainRequest.ProtoReflect.Descriptor instead.
+func (*DrainRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{80}
+}
+
+type DrainResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// already_draining is set when an earlier Drain or shutdown started it.
+	AlreadyDraining bool `protobuf:"varint,1,opt,name=already_draining,json=alreadyDraining,proto3" json:"already_draining,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DrainResponse) Reset() {
+	*x = DrainResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[81]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DrainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainResponse) ProtoMessage() {}
+
+func (x *DrainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[81]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainResponse.ProtoReflect.Descriptor instead.
+func (*DrainResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{81}
+}
+
+func (x *DrainResponse) GetAlreadyDraining() bool {
+	if x != nil {
+		return x.AlreadyDraining
+	}
+	return false
+}
+
+type ReloadConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadConfigRequest) Reset() {
+	*x = ReloadConfigRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[82]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigRequest) ProtoMessage() {}
+
+func (x *ReloadConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[82]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigRequest.ProtoReflect.Descriptor instead.
+func (*ReloadConfigRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{82}
+}
+
+type ReloadConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadConfigResponse) Reset() {
+	*x = ReloadConfigResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[83]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigResponse) ProtoMessage() {}
+
+func (x *ReloadConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[83]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigResponse.ProtoReflect.Descriptor instead.
+func (*ReloadConfigResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{83}
+}
+
+type GetMetricsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMetricsRequest) Reset() {
+	*x = GetMetricsRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[84]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricsRequest) ProtoMessage() {}
+
+func (x *GetMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[84]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetricsRequest.ProtoReflect.Descriptor instead.
+func (*GetMetricsRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{84}
+}
+
+type GetMetricsResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ServerInstanceId string                 `protobuf:"bytes,1,opt,name=server_instance_id,json=serverInstanceId,proto3" json:"server_instance_id,omitempty"`
+	StartedAt        *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	Draining         bool                   `protobuf:"varint,3,opt,name=draining,proto3" json:"draining,omitempty"`
+	// sessions_by_status counts live and stopped sessions, keyed by the
+	// SessionStatus name without its prefix, e.g. "running".
+	SessionsByStatus map[string]uint32 `protobuf:"bytes,4,rep,name=sessions_by_status,json=sessionsByStatus,proto3" json:"sessions_by_status,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// sessions_by_project counts live sessions per project.
+	SessionsByProject map[string]uint32 `protobuf:"bytes,5,rep,name=sessions_by_project,json=sessionsByProject,proto3" json:"sessions_by_project,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// redaction_hits is as in HealthResponse.
+	RedactionHits map[string]uint64 `protobuf:"bytes,6,rep,name=redaction_hits,json=redactionHits,proto3" json:"redaction_hits,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// revoked_tokens counts the revocations currently in force.
+	RevokedTokens uint32 `protobuf:"varint,7,opt,name=revoked_tokens,json=revokedTokens,proto3" json:"revoked_tokens,omitempty"`
+	// rate_limiters lists the shared limiters, then those of projects with
+	// their own rate_limits.
+	RateLimiters []*RateLimiterState `protobuf:"bytes,8,rep,name=rate_limiters,json=rateLimiters,proto3" json:"rate_limiters,omitempty"`
+	// provider_readiness lists, per provider with a prompt pattern, how
+	// long PTY agents took to print their first prompt.
+	ProviderReadiness []*ProviderReadiness `protobuf:"bytes,9,rep,name=provider_readiness,json=providerReadiness,proto3" json:"provider_readiness,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *GetMetricsResponse) Reset() {
+	*x = GetMetricsResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[85]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricsResponse) ProtoMessage() {}
+
+func (x *GetMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[85]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetricsResponse.ProtoReflect.Descriptor instead.
+func (*GetMetricsResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{85}
+}
+
+func (x *GetMetricsResponse) GetServerInstanceId() string {
+	if x != nil {
+		return x.ServerInstanceId
+	}
+	return ""
+}
+
+func (x *GetMetricsResponse) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *GetMetricsResponse) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+func (x *GetMetricsResponse) GetSessionsByStatus() map[string]uint32 {
+	if x != nil {
+		return x.SessionsByStatus
+	}
+	return nil
+}
+
+func (x *GetMetricsResponse) GetSessionsByProject() map[string]uint32 {
+	if x != nil {
+		return x.SessionsByProject
+	}
+	return nil
+}
+
+func (x *GetMetricsResponse) GetRedactionHits() map[string]uint64 {
+	if x != nil {
+		return x.RedactionHits
+	}
+	return nil
+}
+
+func (x *GetMetricsResponse) GetRevokedTokens() uint32 {
+	if x != nil {
+		return x.RevokedTokens
+	}
+	return 0
+}
+
+func (x *GetMetricsResponse) GetRateLimiters() []*RateLimiterState {
+	if x != nil {
+		return x.RateLimiters
+	}
+	return nil
+}
+
+func (x *GetMetricsResponse) GetProviderReadiness() []*ProviderReadiness {
+	if x != nil {
+		return x.ProviderReadiness
+	}
+	return nil
+}
+
+// ProviderReadiness is the startup latency of one provider's agents since
+// the server started.
+type ProviderReadiness struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Provider string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	// ready counts the agent processes whose prompt appeared.
+	Ready uint64 `protobuf:"varint,2,opt,name=ready,proto3" json:"ready,omitempty"`
+	// timed_out counts the agents killed by the provider's ready_timeout.
+	TimedOut uint64 `protobuf:"varint,3,opt,name=timed_out,json=timedOut,proto3" json:"timed_out,omitempty"`
+	// mean_ready_ms and max_ready_ms are over the ready agents.
+	MeanReadyMs   uint32 `protobuf:"varint,4,opt,name=mean_ready_ms,json=meanReadyMs,proto3" json:"mean_ready_ms,omitempty"`
+	MaxReadyMs    uint32 `protobuf:"varint,5,opt,name=max_ready_ms,json=maxReadyMs,proto3" json:"max_ready_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProviderReadiness) Reset() {
+	*x = ProviderReadiness{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[86]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProviderReadiness) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProviderReadiness) ProtoMessage() {}
+
+func (x *ProviderReadiness) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[86]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProviderReadiness.ProtoReflect.Descriptor instead.
+func (*ProviderReadiness) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{86}
+}
+
+func (x *ProviderReadiness) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *ProviderReadiness) GetReady() uint64 {
+	if x != nil {
+		return x.Ready
+	}
+	return 0
+}
+
+func (x *ProviderReadiness) GetTimedOut() uint64 {
+	if x != nil {
+		return x.TimedOut
+	}
+	return 0
+}
+
+func (x *ProviderReadiness) GetMeanReadyMs() uint32 {
+	if x != nil {
+		return x.MeanReadyMs
+	}
+	return 0
+}
+
+func (x *ProviderReadiness) GetMaxReadyMs() uint32 {
+	if x != nil {
+		return x.MaxReadyMs
+	}
+	return 0
+}
+
+// RateLimiterState is the configuration and activity of one rate limiter.
+type RateLimiterState struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name is "global", "start_session" (a bucket per client),
+	// "write_input" (a bucket per session) or "project" (a bucket per
+	// project).
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// project_id is set on the limiters of a project with its own
+	// rate_limits and empty on the shared ones.
+	ProjectId string `protobuf:"bytes,2,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	// rate is in requests per second; zero means the limiter is off.
+	Rate  float64 `protobuf:"fixed64,3,opt,name=rate,proto3" json:"rate,omitempty"`
+	Burst uint32  `protobuf:"varint,4,opt,name=burst,proto3" json:"burst,omitempty"`
+	// active_buckets counts the keys seen within the last hour.
+	ActiveBuckets uint32 `protobuf:"varint,5,opt,name=active_buckets,json=activeBuckets,proto3" json:"active_buckets,omitempty"`
+	// rejected counts the requests refused since the limiter was created,
+	// i.e. since the server started or the project's override was added.
+	Rejected uint64 `protobuf:"varint,6,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	// interactive_reserve is the share of the burst batch input cannot use.
+	InteractiveReserve float64 `protobuf:"fixed64,7,opt,name=interactive_reserve,json=interactiveReserve,proto3" json:"interactive_reserve,omitempty"`
+	// rejected_batch counts the batch inputs among rejected.
+	RejectedBatch uint64 `protobuf:"varint,8,opt,name=rejected_batch,json=rejectedBatch,proto3" json:"rejected_batch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimiterState) Reset() {
+	*x = RateLimiterState{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[87]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimiterState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimiterState) ProtoMessage() {}
+
+func (x *RateLimiterState) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[87]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimiterState.ProtoReflect.Descriptor instead.
+func (*RateLimiterState) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{87}
+}
+
+func (x *RateLimiterState) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RateLimiterState) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *RateLimiterState) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *RateLimiterState) GetBurst() uint32 {
+	if x != nil {
+		return x.Burst
+	}
+	return 0
+}
+
+func (x *RateLimiterState) GetActiveBuckets() uint32 {
+	if x != nil {
+		return x.ActiveBuckets
+	}
+	return 0
+}
+
+func (x *RateLimiterState) GetRejected() uint64 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *RateLimiterState) GetInteractiveReserve() float64 {
+	if x != nil {
+		return x.InteractiveReserve
+	}
+	return 0
+}
+
+func (x *RateLimiterState) GetRejectedBatch() uint64 {
+	if x != nil {
+		return x.RejectedBatch
+	}
+	return 0
+}
+
+type RevokeTokenRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// token_id rejects the token whose jti claim matches.
+	TokenId string `protobuf:"bytes,1,opt,name=token_id,json=tokenId,proto3" json:"token_id,omitempty"`
+	// subject rejects every token of the subject issued up to now. Tokens
+	// issued later are accepted again.
+	Subject string `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`
+	// reason is recorded in the audit log.
+	Reason        string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeTokenRequest) Reset() {
+	*x = RevokeTokenRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[88]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeTokenRequest) ProtoMessage() {}
+
+func (x *RevokeTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[88]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeTokenRequest.ProtoReflect.Descriptor instead.
+func (*RevokeTokenRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{88}
+}
+
+func (x *RevokeTokenRequest) GetTokenId() string {
+	if x != nil {
+		return x.TokenId
+	}
+	return ""
+}
+
+func (x *RevokeTokenRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *RevokeTokenRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type RevokeTokenResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeTokenResponse) Reset() {
+	*x = RevokeTokenResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[89]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeTokenResponse) ProtoMessage() {}
+
+func (x *RevokeTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[89]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeTokenResponse.ProtoReflect.Descriptor instead.
+func (*RevokeTokenResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{89}
+}
+
+type RegisterProviderRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ProviderId string                 `protobuf:"bytes,1,opt,name=provider_id,json=providerId,proto3" json:"provider_id,omitempty"`
+	Binary     string                 `protobuf:"bytes,2,opt,name=binary,proto3" json:"binary,omitempty"`
+	Args       []string               `protobuf:"bytes,3,rep,name=args,proto3" json:"args,omitempty"`
+	// stream_json runs the agent over stdin/stdout pipes instead of a PTY.
+	StreamJson bool `protobuf:"varint,4,opt,name=stream_json,json=streamJson,proto3" json:"stream_json,omitempty"`
+	// json_format is the stream-JSON dialect: claude (default) or opencode.
+	JsonFormat string `protobuf:"bytes,5,opt,name=json_format,json=jsonFormat,proto3" json:"json_format,omitempty"`
+	// prompt_pattern matches the agent's prompt in PTY output.
+	PromptPattern string `protobuf:"bytes,6,opt,name=prompt_pattern,json=promptPattern,proto3" json:"prompt_pattern,omitempty"`
+	// startup_probe is prompt, output or none.
+	StartupProbe string `protobuf:"bytes,7,opt,name=startup_probe,json=startupProbe,proto3" json:"startup_probe,omitempty"`
+	// startup_timeout_ms is how long the agent may take to start; 0 uses 60s.
+	StartupTimeoutMs uint32 `protobuf:"varint,8,opt,name=startup_timeout_ms,json=startupTimeoutMs,proto3" json:"startup_timeout_ms,omitempty"`
+	// required_env names variables the agent needs from the daemon's
+	// environment.
+	RequiredEnv []string `protobuf:"bytes,9,rep,name=required_env,json=requiredEnv,proto3" json:"required_env,omitempty"`
+	// replace overwrites a provider registered earlier with the same ID.
+	Replace       bool `protobuf:"varint,10,opt,name=replace,proto3" json:"replace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterProviderRequest) Reset() {
+	*x = RegisterProviderRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[90]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterProviderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterProviderRequest) ProtoMessage() {}
+
+func (x *RegisterProviderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[90]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterProviderRequest.ProtoReflect.Descriptor instead.
+func (*RegisterProviderRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{90}
+}
+
+func (x *RegisterProviderRequest) GetProviderId() string {
+	if x != nil {
+		return x.ProviderId
+	}
+	return ""
+}
+
+func (x *RegisterProviderRequest) GetBinary() string {
+	if x != nil {
+		return x.Binary
+	}
+	return ""
+}
+
+func (x *RegisterProviderRequest) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *RegisterProviderRequest) GetStreamJson() bool {
+	if x != nil {
+		return x.StreamJson
+	}
+	return false
+}
+
+func (x *RegisterProviderRequest) GetJsonFormat() string {
+	if x != nil {
+		return x.JsonFormat
+	}
+	return ""
+}
+
+func (x *RegisterProviderRequest) GetPromptPattern() string {
+	if x != nil {
+		return x.PromptPattern
+	}
+	return ""
+}
+
+func (x *RegisterProviderRequest) GetStartupProbe() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.StartupProbe
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use ReleaseWriterRequest.ProtoReflect.Descriptor instead.
-func (*ReleaseWriterRequest) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{16}
+func (x *RegisterProviderRequest) GetStartupTimeoutMs() uint32 {
+	if x != nil {
+		return x.StartupTimeoutMs
+	}
+	return 0
 }
 
-func (x *ReleaseWriterRequest) GetSessionId() string {
+func (x *RegisterProviderRequest) GetRequiredEnv() []string {
 	if x != nil {
-		return x.SessionId
+		return x.RequiredEnv
 	}
-	return ""
+	return nil
 }
 
-func (x *ReleaseWriterRequest) GetClientId() string {
+func (x *RegisterProviderRequest) GetReplace() bool {
 	if x != nil {
-		return x.ClientId
+		return x.Replace
 	}
-	return ""
+	return false
 }
 
-type ReleaseWriterResponse struct {
+type RegisterProviderResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// released is true when the caller successfully released the writer slot.
-	Released      bool `protobuf:"varint,1,opt,name=released,proto3" json:"released,omitempty"`
+	// replaced is set when an earlier registration was overwritten.
+	Replaced      bool `protobuf:"varint,1,opt,name=replaced,proto3" json:"replaced,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReleaseWriterResponse) Reset() {
-	*x = ReleaseWriterResponse{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[17]
+func (x *RegisterProviderResponse) Reset() {
+	*x = RegisterProviderResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[91]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReleaseWriterResponse) String() string {
+func (x *RegisterProviderResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReleaseWriterResponse) ProtoMessage() {}
+func (*RegisterProviderResponse) ProtoMessage() {}
 
-func (x *ReleaseWriterResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[17]
+func (x *RegisterProviderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[91]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1420,39 +6756,40 @@ func (x *ReleaseWriterResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReleaseWriterResponse.ProtoReflect.Descriptor instead.
-func (*ReleaseWriterResponse) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use RegisterProviderResponse.ProtoReflect.Descriptor instead.
+func (*RegisterProviderResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{91}
 }
 
-func (x *ReleaseWriterResponse) GetReleased() bool {
+func (x *RegisterProviderResponse) GetReplaced() bool {
 	if x != nil {
-		return x.Released
+		return x.Replaced
 	}
 	return false
 }
 
-type HealthRequest struct {
+type UnregisterProviderRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProviderId    string                 `protobuf:"bytes,1,opt,name=provider_id,json=providerId,proto3" json:"provider_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthRequest) Reset() {
-	*x = HealthRequest{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[18]
+func (x *UnregisterProviderRequest) Reset() {
+	*x = UnregisterProviderRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[92]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthRequest) String() string {
+func (x *UnregisterProviderRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthRequest) ProtoMessage() {}
+func (*UnregisterProviderRequest) ProtoMessage() {}
 
-func (x *HealthRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[18]
+func (x *UnregisterProviderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[92]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1463,39 +6800,39 @@ func (x *HealthRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
-func (*HealthRequest) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use UnregisterProviderRequest.ProtoReflect.Descriptor instead.
+func (*UnregisterProviderRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{92}
 }
 
-type HealthResponse struct {
-	state     protoimpl.MessageState `protogen:"open.v1"`
-	Status    string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
-	Providers []*ProviderHealth      `protobuf:"bytes,2,rep,name=providers,proto3" json:"providers,omitempty"`
-	// server_instance_id is a UUID generated once at daemon startup.
-	// Clients can compare this value across Health calls to detect a daemon
-	// restart (a changed ID means the process restarted and all in-memory
-	// session state has been lost).
-	ServerInstanceId string `protobuf:"bytes,3,opt,name=server_instance_id,json=serverInstanceId,proto3" json:"server_instance_id,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *UnregisterProviderRequest) GetProviderId() string {
+	if x != nil {
+		return x.ProviderId
+	}
+	return ""
 }
 
-func (x *HealthResponse) Reset() {
-	*x = HealthResponse{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[19]
+type UnregisterProviderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnregisterProviderResponse) Reset() {
+	*x = UnregisterProviderResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[93]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthResponse) String() string {
+func (x *UnregisterProviderResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthResponse) ProtoMessage() {}
+func (*UnregisterProviderResponse) ProtoMessage() {}
 
-func (x *HealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[19]
+func (x *UnregisterProviderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[93]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1506,56 +6843,38 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
-func (*HealthResponse) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{19}
-}
-
-func (x *HealthResponse) GetStatus() string {
-	if x != nil {
-		return x.Status
-	}
-	return ""
-}
-
-func (x *HealthResponse) GetProviders() []*ProviderHealth {
-	if x != nil {
-		return x.Providers
-	}
-	return nil
-}
-
-func (x *HealthResponse) GetServerInstanceId() string {
-	if x != nil {
-		return x.ServerInstanceId
-	}
-	return ""
+// Deprecated: Use UnregisterProviderResponse.ProtoReflect.Descriptor instead.
+func (*UnregisterProviderResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{93}
 }
 
-type ProviderHealth struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
-	Available     bool                   `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
-	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+type SetLogLevelRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// component is one of auth, supervisor, provider and server; empty sets
+	// the default level, which components without their own follow.
+	Component string `protobuf:"bytes,1,opt,name=component,proto3" json:"component,omitempty"`
+	// level is debug, info, warn or error. Empty changes nothing, so that
+	// the current levels can be read.
+	Level         string `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProviderHealth) Reset() {
-	*x = ProviderHealth{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[20]
+func (x *SetLogLevelRequest) Reset() {
+	*x = SetLogLevelRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[94]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProviderHealth) String() string {
+func (x *SetLogLevelRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProviderHealth) ProtoMessage() {}
+func (*SetLogLevelRequest) ProtoMessage() {}
 
-func (x *ProviderHealth) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[20]
+func (x *SetLogLevelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[94]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1566,53 +6885,49 @@ func (x *ProviderHealth) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProviderHealth.ProtoReflect.Descriptor instead.
-func (*ProviderHealth) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use SetLogLevelRequest.ProtoReflect.Descriptor instead.
+func (*SetLogLevelRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{94}
 }
 
-func (x *ProviderHealth) GetProvider() string {
+func (x *SetLogLevelRequest) GetComponent() string {
 	if x != nil {
-		return x.Provider
+		return x.Component
 	}
 	return ""
 }
 
-func (x *ProviderHealth) GetAvailable() bool {
-	if x != nil {
-		return x.Available
-	}
-	return false
-}
-
-func (x *ProviderHealth) GetError() string {
+func (x *SetLogLevelRequest) GetLevel() string {
 	if x != nil {
-		return x.Error
+		return x.Level
 	}
 	return ""
 }
 
-type ListProvidersRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+type SetLogLevelResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// levels are the levels in effect after the change, by component, with
+	// the default under "".
+	Levels        map[string]string `protobuf:"bytes,1,rep,name=levels,proto3" json:"levels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListProvidersRequest) Reset() {
-	*x = ListProvidersRequest{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[21]
+func (x *SetLogLevelResponse) Reset() {
+	*x = SetLogLevelResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[95]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListProvidersRequest) String() string {
+func (x *SetLogLevelResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListProvidersRequest) ProtoMessage() {}
+func (*SetLogLevelResponse) ProtoMessage() {}
 
-func (x *ListProvidersRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[21]
+func (x *SetLogLevelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[95]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1623,33 +6938,40 @@ func (x *ListProvidersRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListProvidersRequest.ProtoReflect.Descriptor instead.
-func (*ListProvidersRequest) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{21}
+// Deprecated: Use SetLogLevelResponse.ProtoReflect.Descriptor instead.
+func (*SetLogLevelResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{95}
 }
 
-type ListProvidersResponse struct {
+func (x *SetLogLevelResponse) GetLevels() map[string]string {
+	if x != nil {
+		return x.Levels
+	}
+	return nil
+}
+
+type PurgeSessionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Providers     []*ProviderInfo        `protobuf:"bytes,1,rep,name=providers,proto3" json:"providers,omitempty"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListProvidersResponse) Reset() {
-	*x = ListProvidersResponse{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[22]
+func (x *PurgeSessionRequest) Reset() {
+	*x = PurgeSessionRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[96]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListProvidersResponse) String() string {
+func (x *PurgeSessionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListProvidersResponse) ProtoMessage() {}
+func (*PurgeSessionRequest) ProtoMessage() {}
 
-func (x *ListProvidersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[22]
+func (x *PurgeSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[96]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1660,43 +6982,40 @@ func (x *ListProvidersResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListProvidersResponse.ProtoReflect.Descriptor instead.
-func (*ListProvidersResponse) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{22}
+// Deprecated: Use PurgeSessionRequest.ProtoReflect.Descriptor instead.
+func (*PurgeSessionRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{96}
 }
 
-func (x *ListProvidersResponse) GetProviders() []*ProviderInfo {
+func (x *PurgeSessionRequest) GetSessionId() string {
 	if x != nil {
-		return x.Providers
+		return x.SessionId
 	}
-	return nil
+	return ""
 }
 
-type ProviderInfo struct {
+type PurgeSessionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
-	Available     bool                   `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
-	Binary        string                 `protobuf:"bytes,3,opt,name=binary,proto3" json:"binary,omitempty"`
-	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProviderInfo) Reset() {
-	*x = ProviderInfo{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[23]
+func (x *PurgeSessionResponse) Reset() {
+	*x = PurgeSessionResponse{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[97]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProviderInfo) String() string {
+func (x *PurgeSessionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProviderInfo) ProtoMessage() {}
+func (*PurgeSessionResponse) ProtoMessage() {}
 
-func (x *ProviderInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[23]
+func (x *PurgeSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[97]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1707,35 +7026,14 @@ func (x *ProviderInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProviderInfo.ProtoReflect.Descriptor instead.
-func (*ProviderInfo) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{23}
-}
-
-func (x *ProviderInfo) GetProvider() string {
-	if x != nil {
-		return x.Provider
-	}
-	return ""
-}
-
-func (x *ProviderInfo) GetAvailable() bool {
-	if x != nil {
-		return x.Available
-	}
-	return false
-}
-
-func (x *ProviderInfo) GetBinary() string {
-	if x != nil {
-		return x.Binary
-	}
-	return ""
+// Deprecated: Use PurgeSessionResponse.ProtoReflect.Descriptor instead.
+func (*PurgeSessionResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{97}
 }
 
-func (x *ProviderInfo) GetVersion() string {
+func (x *PurgeSessionResponse) GetProjectId() string {
 	if x != nil {
-		return x.Version
+		return x.ProjectId
 	}
 	return ""
 }
@@ -1744,7 +7042,7 @@ var File_bridge_v1_bridge_proto protoreflect.FileDescriptor
 
 const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"\n" +
-	"\x16bridge/v1/bridge.proto\x12\tbridge.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xde\x02\n" +
+	"\x16bridge/v1/bridge.proto\x12\tbridge.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa2\x05\n" +
 	"\x13StartSessionRequest\x12\x1d\n" +
 	"\n" +
 	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x1d\n" +
@@ -1755,16 +7053,34 @@ const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"\n" +
 	"agent_opts\x18\x05 \x03(\v2-.bridge.v1.StartSessionRequest.AgentOptsEntryR\tagentOpts\x12!\n" +
 	"\finitial_cols\x18\x06 \x01(\rR\vinitialCols\x12!\n" +
-	"\finitial_rows\x18\a \x01(\rR\vinitialRows\x1a<\n" +
+	"\finitial_rows\x18\a \x01(\rR\vinitialRows\x129\n" +
+	"\x03env\x18\b \x03(\v2'.bridge.v1.StartSessionRequest.EnvEntryR\x03env\x126\n" +
+	"\vrepo_source\x18\t \x01(\v2\x15.bridge.v1.RepoSourceR\n" +
+	"repoSource\x12\x1a\n" +
+	"\bsnapshot\x18\n" +
+	" \x01(\bR\bsnapshot\x12)\n" +
+	"\x10protocol_version\x18\v \x01(\rR\x0fprotocolVersion\x12'\n" +
+	"\x0fwriter_subjects\x18\f \x03(\tR\x0ewriterSubjects\x12'\n" +
+	"\x0fconversation_id\x18\r \x01(\tR\x0econversationId\x1a<\n" +
 	"\x0eAgentOptsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa2\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a6\n" +
+	"\bEnvEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"j\n" +
+	"\n" +
+	"RepoSource\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x10\n" +
+	"\x03ref\x18\x02 \x01(\tR\x03ref\x12\x14\n" +
+	"\x05depth\x18\x03 \x01(\rR\x05depth\x12\"\n" +
+	"\rclean_on_stop\x18\x04 \x01(\bR\vcleanOnStop\"\xcd\x01\n" +
 	"\x14StartSessionResponse\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x120\n" +
 	"\x06status\x18\x02 \x01(\x0e2\x18.bridge.v1.SessionStatusR\x06status\x129\n" +
 	"\n" +
-	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"I\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12)\n" +
+	"\x10protocol_version\x18\x04 \x01(\rR\x0fprotocolVersion\"I\n" +
 	"\x12StopSessionRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x14\n" +
@@ -1773,7 +7089,7 @@ const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"\x06status\x18\x01 \x01(\x0e2\x18.bridge.v1.SessionStatusR\x06status\"2\n" +
 	"\x11GetSessionRequest\x12\x1d\n" +
 	"\n" +
-	"session_id\x18\x01 \x01(\tR\tsessionId\"\xf8\x04\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\xe2\a\n" +
 	"\x12GetSessionResponse\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1d\n" +
@@ -1797,18 +7113,96 @@ const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"\x04cols\x18\x0e \x01(\rR\x04cols\x12\x12\n" +
 	"\x04rows\x18\x0f \x01(\rR\x04rows\x125\n" +
 	"\x17active_writer_client_id\x18\x10 \x01(\tR\x14activeWriterClientId\x12%\n" +
-	"\x0eobserver_count\x18\x11 \x01(\x05R\robserverCount\"4\n" +
+	"\x0eobserver_count\x18\x11 \x01(\x05R\robserverCount\x12&\n" +
+	"\x05usage\x18\x12 \x01(\v2\x10.bridge.v1.UsageR\x05usage\x12#\n" +
+	"\rrestart_count\x18\x13 \x01(\x05R\frestartCount\x12@\n" +
+	"\x11pending_approvals\x18\x14 \x03(\v2\x13.bridge.v1.ApprovalR\x10pendingApprovals\x12'\n" +
+	"\x0fwriter_subjects\x18\x15 \x03(\tR\x0ewriterSubjects\x12!\n" +
+	"\fbuffer_bytes\x18\x16 \x01(\x04R\vbufferBytes\x12'\n" +
+	"\x0fbuffer_capacity\x18\x17 \x01(\x04R\x0ebufferCapacity\x12\x18\n" +
+	"\avariant\x18\x18 \x01(\tR\avariant\x12'\n" +
+	"\x0fconversation_id\x18\x19 \x01(\tR\x0econversationId\x12!\n" +
+	"\fresumed_from\x18\x1a \x01(\tR\vresumedFrom\"v\n" +
+	"\bApproval\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\ttool_name\x18\x02 \x01(\tR\btoolName\x12\x1d\n" +
+	"\n" +
+	"input_json\x18\x03 \x01(\fR\tinputJson\x12\x1e\n" +
+	"\vtool_use_id\x18\x04 \x01(\tR\ttoolUseId\"\xa1\x01\n" +
+	"\x05Usage\x12!\n" +
+	"\finput_tokens\x18\x01 \x01(\x03R\vinputTokens\x12#\n" +
+	"\routput_tokens\x18\x02 \x01(\x03R\foutputTokens\x12\x19\n" +
+	"\bcost_usd\x18\x03 \x01(\x01R\acostUsd\x12\x1f\n" +
+	"\vduration_ms\x18\x04 \x01(\x03R\n" +
+	"durationMs\x12\x14\n" +
+	"\x05turns\x18\x05 \x01(\x05R\x05turns\"\x81\x01\n" +
+	"\x18GetSessionHistoryRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tafter_seq\x18\x02 \x01(\x04R\bafterSeq\x12)\n" +
+	"\x10protocol_version\x18\x03 \x01(\rR\x0fprotocolVersion\"\xc8\x01\n" +
+	"\x19GetSessionHistoryResponse\x127\n" +
+	"\asession\x18\x01 \x01(\v2\x1d.bridge.v1.GetSessionResponseR\asession\x125\n" +
+	"\x06events\x18\x02 \x03(\v2\x1d.bridge.v1.AttachSessionEventR\x06events\x12;\n" +
+	"\varchived_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"archivedAt\"m\n" +
+	"\x17ExportTranscriptRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x123\n" +
+	"\x06format\x18\x02 \x01(\x0e2\x1b.bridge.v1.TranscriptFormatR\x06format\"s\n" +
+	"\x18ExportTranscriptResponse\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\fR\acontent\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x1a\n" +
+	"\bfilename\x18\x03 \x01(\tR\bfilename\"v\n" +
+	"\x16GetConversationRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x123\n" +
+	"\x06format\x18\x02 \x01(\x0e2\x1b.bridge.v1.TranscriptFormatR\x06format\"\xad\x01\n" +
+	"\x17GetConversationResponse\x129\n" +
+	"\bsessions\x18\x01 \x03(\v2\x1d.bridge.v1.GetSessionResponseR\bsessions\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\x12\x1a\n" +
+	"\bfilename\x18\x04 \x01(\tR\bfilename\"k\n" +
+	"\x12GetResponseRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x19\n" +
+	"\binput_id\x18\x02 \x01(\tR\ainputId\x12\x1b\n" +
+	"\tinput_seq\x18\x03 \x01(\x04R\binputSeq\"\xcc\x01\n" +
+	"\x13GetResponseResponse\x12\x19\n" +
+	"\binput_id\x18\x01 \x01(\tR\ainputId\x12\x1b\n" +
+	"\tinput_seq\x18\x02 \x01(\x04R\binputSeq\x12\x16\n" +
+	"\x06prompt\x18\x03 \x01(\tR\x06prompt\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x12\x1a\n" +
+	"\bthinking\x18\x05 \x01(\tR\bthinking\x12\x1a\n" +
+	"\bcomplete\x18\x06 \x01(\bR\bcomplete\x12\x19\n" +
+	"\blast_seq\x18\a \x01(\x04R\alastSeq\"N\n" +
+	"\x19ExportSessionStateRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04stop\x18\x02 \x01(\bR\x04stop\"M\n" +
+	"\x1aExportSessionStateResponse\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\fR\x05state\x12\x19\n" +
+	"\blast_seq\x18\x02 \x01(\x04R\alastSeq\"1\n" +
+	"\x19ImportSessionStateRequest\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\fR\x05state\"U\n" +
+	"\x1aImportSessionStateResponse\x127\n" +
+	"\asession\x18\x01 \x01(\v2\x1d.bridge.v1.GetSessionResponseR\asession\"4\n" +
 	"\x13ListSessionsRequest\x12\x1d\n" +
 	"\n" +
 	"project_id\x18\x01 \x01(\tR\tprojectId\"Q\n" +
 	"\x14ListSessionsResponse\x129\n" +
-	"\bsessions\x18\x01 \x03(\v2\x1d.bridge.v1.GetSessionResponseR\bsessions\"\x9a\x01\n" +
+	"\bsessions\x18\x01 \x03(\v2\x1d.bridge.v1.GetSessionResponseR\bsessions\"\xeb\x02\n" +
 	"\x14AttachSessionRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
 	"\tafter_seq\x18\x02 \x01(\x04R\bafterSeq\x12\x1b\n" +
 	"\tclient_id\x18\x03 \x01(\tR\bclientId\x12)\n" +
-	"\x04role\x18\x04 \x01(\x0e2\x15.bridge.v1.AttachRoleR\x04role\"\xea\x03\n" +
+	"\x04role\x18\x04 \x01(\x0e2\x15.bridge.v1.AttachRoleR\x04role\x12;\n" +
+	"\vevent_types\x18\x05 \x03(\x0e2\x1a.bridge.v1.AttachEventTypeR\n" +
+	"eventTypes\x12\x14\n" +
+	"\x05match\x18\x06 \x01(\tR\x05match\x12$\n" +
+	"\x0emax_batch_size\x18\a \x01(\rR\fmaxBatchSize\x12+\n" +
+	"\x12max_batch_delay_ms\x18\b \x01(\rR\x0fmaxBatchDelayMs\x12)\n" +
+	"\x10protocol_version\x18\t \x01(\rR\x0fprotocolVersion\"\xed\x06\n" +
 	"\x12AttachSessionEvent\x12.\n" +
 	"\x04type\x18\x01 \x01(\x0e2\x1a.bridge.v1.AttachEventTypeR\x04type\x12\x10\n" +
 	"\x03seq\x18\x02 \x01(\x04R\x03seq\x128\n" +
@@ -1827,15 +7221,110 @@ const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"\x04cols\x18\f \x01(\rR\x04cols\x12\x12\n" +
 	"\x04rows\x18\r \x01(\rR\x04rows\x12#\n" +
 	"\rthinking_text\x18\x0e \x01(\tR\fthinkingText\x12(\n" +
-	"\x10writer_client_id\x18\x0f \x01(\tR\x0ewriterClientId\"c\n" +
+	"\x10writer_client_id\x18\x0f \x01(\tR\x0ewriterClientId\x12&\n" +
+	"\x05usage\x18\x10 \x01(\v2\x10.bridge.v1.UsageR\x05usage\x12/\n" +
+	"\bseverity\x18\x11 \x01(\x0e2\x13.bridge.v1.SeverityR\bseverity\x12\x19\n" +
+	"\binput_id\x18\x12 \x01(\tR\ainputId\x12#\n" +
+	"\rrestart_count\x18\x13 \x01(\x05R\frestartCount\x128\n" +
+	"\x05batch\x18\x14 \x01(\v2\".bridge.v1.AttachSessionEventBatchR\x05batch\x12)\n" +
+	"\x10protocol_version\x18\x15 \x01(\rR\x0fprotocolVersion\x12%\n" +
+	"\x0eextension_type\x18\x16 \x01(\tR\rextensionType\x12\x1b\n" +
+	"\tdata_json\x18\x17 \x01(\fR\bdataJson\x12 \n" +
+	"\vattachments\x18\x18 \x03(\tR\vattachments\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x19 \x01(\tR\trequestId\"P\n" +
+	"\x17AttachSessionEventBatch\x125\n" +
+	"\x06events\x18\x01 \x03(\v2\x1d.bridge.v1.AttachSessionEventR\x06events\"\xe8\x02\n" +
 	"\x11WriteInputRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
 	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x12\n" +
-	"\x04data\x18\x03 \x01(\fR\x04data\"U\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x124\n" +
+	"\bpriority\x18\x04 \x01(\x0e2\x18.bridge.v1.InputPriorityR\bpriority\x12<\n" +
+	"\vattachments\x18\x05 \x03(\v2\x1a.bridge.v1.InputAttachmentR\vattachments\x12\x1a\n" +
+	"\btemplate\x18\x06 \x01(\tR\btemplate\x12:\n" +
+	"\x04vars\x18\a \x03(\v2&.bridge.v1.WriteInputRequest.VarsEntryR\x04vars\x1a7\n" +
+	"\tVarsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"S\n" +
+	"\x0fInputAttachment\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\"\xbd\x01\n" +
+	"\x16SendInputStreamRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x124\n" +
+	"\bpriority\x18\x04 \x01(\x0e2\x18.bridge.v1.InputPriorityR\bpriority\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x05 \x01(\x04R\ttotalSize\"\x9a\x01\n" +
 	"\x12WriteInputResponse\x12\x1a\n" +
 	"\baccepted\x18\x01 \x01(\bR\baccepted\x12#\n" +
-	"\rbytes_written\x18\x02 \x01(\rR\fbytesWritten\"z\n" +
+	"\rbytes_written\x18\x02 \x01(\rR\fbytesWritten\x12\x19\n" +
+	"\binput_id\x18\x03 \x01(\tR\ainputId\x12\x16\n" +
+	"\x06queued\x18\x04 \x01(\bR\x06queued\x12\x10\n" +
+	"\x03seq\x18\x05 \x01(\x04R\x03seq\"\xf2\x02\n" +
+	"\x15BroadcastInputRequest\x12\x1f\n" +
+	"\vsession_ids\x18\x01 \x03(\tR\n" +
+	"sessionIds\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x124\n" +
+	"\bpriority\x18\x04 \x01(\x0e2\x18.bridge.v1.InputPriorityR\bpriority\x12<\n" +
+	"\vattachments\x18\x05 \x03(\v2\x1a.bridge.v1.InputAttachmentR\vattachments\x12\x1a\n" +
+	"\btemplate\x18\x06 \x01(\tR\btemplate\x12>\n" +
+	"\x04vars\x18\a \x03(\v2*.bridge.v1.BroadcastInputRequest.VarsEntryR\x04vars\x1a7\n" +
+	"\tVarsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xe5\x01\n" +
+	"\x14BroadcastInputResult\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1a\n" +
+	"\baccepted\x18\x02 \x01(\bR\baccepted\x12\x19\n" +
+	"\binput_id\x18\x03 \x01(\tR\ainputId\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\x12\x16\n" +
+	"\x06queued\x18\x05 \x01(\bR\x06queued\x12#\n" +
+	"\rbytes_written\x18\x06 \x01(\rR\fbytesWritten\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12\x12\n" +
+	"\x04code\x18\b \x01(\x05R\x04code\"S\n" +
+	"\x16BroadcastInputResponse\x129\n" +
+	"\aresults\x18\x01 \x03(\v2\x1f.bridge.v1.BroadcastInputResultR\aresults\"\x92\x01\n" +
+	"\fTerminalOpen\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x1b\n" +
+	"\tafter_seq\x18\x03 \x01(\x04R\bafterSeq\x12)\n" +
+	"\x04role\x18\x04 \x01(\x0e2\x15.bridge.v1.AttachRoleR\x04role\"8\n" +
+	"\x0eTerminalResize\x12\x12\n" +
+	"\x04cols\x18\x01 \x01(\rR\x04cols\x12\x12\n" +
+	"\x04rows\x18\x02 \x01(\rR\x04rows\"\x9c\x01\n" +
+	"\x15AttachTerminalRequest\x12-\n" +
+	"\x04open\x18\x01 \x01(\v2\x17.bridge.v1.TerminalOpenH\x00R\x04open\x12\x16\n" +
+	"\x05input\x18\x02 \x01(\fH\x00R\x05input\x123\n" +
+	"\x06resize\x18\x03 \x01(\v2\x19.bridge.v1.TerminalResizeH\x00R\x06resizeB\a\n" +
+	"\x05frame\"\xdb\x01\n" +
+	"\x10TerminalAttached\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12)\n" +
+	"\x04role\x18\x02 \x01(\x0e2\x15.bridge.v1.AttachRoleR\x04role\x12\x12\n" +
+	"\x04cols\x18\x03 \x01(\rR\x04cols\x12\x12\n" +
+	"\x04rows\x18\x04 \x01(\rR\x04rows\x12\x1d\n" +
+	"\n" +
+	"oldest_seq\x18\x05 \x01(\x04R\toldestSeq\x12\x19\n" +
+	"\blast_seq\x18\x06 \x01(\x04R\alastSeq\x12\x1d\n" +
+	"\n" +
+	"replay_gap\x18\a \x01(\bR\treplayGap\"N\n" +
+	"\x0eTerminalOutput\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x16\n" +
+	"\x06replay\x18\x03 \x01(\bR\x06replay\"P\n" +
+	"\fTerminalExit\x12#\n" +
+	"\rexit_recorded\x18\x01 \x01(\bR\fexitRecorded\x12\x1b\n" +
+	"\texit_code\x18\x02 \x01(\x05R\bexitCode\"\xc0\x01\n" +
+	"\x16AttachTerminalResponse\x129\n" +
+	"\battached\x18\x01 \x01(\v2\x1b.bridge.v1.TerminalAttachedH\x00R\battached\x123\n" +
+	"\x06output\x18\x02 \x01(\v2\x19.bridge.v1.TerminalOutputH\x00R\x06output\x12-\n" +
+	"\x04exit\x18\x03 \x01(\v2\x17.bridge.v1.TerminalExitH\x00R\x04exitB\a\n" +
+	"\x05frame\"z\n" +
 	"\x14ResizeSessionRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
@@ -1843,7 +7332,86 @@ const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"\x04cols\x18\x03 \x01(\rR\x04cols\x12\x12\n" +
 	"\x04rows\x18\x04 \x01(\rR\x04rows\"1\n" +
 	"\x15ResizeSessionResponse\x12\x18\n" +
-	"\aapplied\x18\x01 \x01(\bR\aapplied\"f\n" +
+	"\aapplied\x18\x01 \x01(\bR\aapplied\"S\n" +
+	"\x15CancelResponseRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\tR\bclientId\"6\n" +
+	"\x16CancelResponseResponse\x12\x1c\n" +
+	"\tdelivered\x18\x01 \x01(\bR\tdelivered\"\xa9\x01\n" +
+	"\x16ResolveApprovalRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x1f\n" +
+	"\vapproval_id\x18\x03 \x01(\tR\n" +
+	"approvalId\x12\x18\n" +
+	"\aapprove\x18\x04 \x01(\bR\aapprove\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\"7\n" +
+	"\x17ResolveApprovalResponse\x12\x1c\n" +
+	"\tdelivered\x18\x01 \x01(\bR\tdelivered\"D\n" +
+	"\x0fReadFileRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"c\n" +
+	"\x10ReadFileResponse\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12;\n" +
+	"\vmodified_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"modifiedAt\"Y\n" +
+	"\x10WriteFileRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"8\n" +
+	"\x11WriteFileResponse\x12#\n" +
+	"\rbytes_written\x18\x01 \x01(\rR\fbytesWritten\"C\n" +
+	"\x0eListDirRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"\x86\x01\n" +
+	"\bDirEntry\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x15\n" +
+	"\x06is_dir\x18\x02 \x01(\bR\x05isDir\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12;\n" +
+	"\vmodified_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"modifiedAt\"@\n" +
+	"\x0fListDirResponse\x12-\n" +
+	"\aentries\x18\x01 \x03(\v2\x13.bridge.v1.DirEntryR\aentries\"1\n" +
+	"\x10GitStatusRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\x8c\x01\n" +
+	"\rGitFileStatus\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1b\n" +
+	"\torig_path\x18\x02 \x01(\tR\borigPath\x12!\n" +
+	"\findex_status\x18\x03 \x01(\tR\vindexStatus\x12'\n" +
+	"\x0fworktree_status\x18\x04 \x01(\tR\x0eworktreeStatus\"\x85\x01\n" +
+	"\x11GitStatusResponse\x12\x16\n" +
+	"\x06branch\x18\x01 \x01(\tR\x06branch\x12\x12\n" +
+	"\x04head\x18\x02 \x01(\tR\x04head\x12\x14\n" +
+	"\x05clean\x18\x03 \x01(\bR\x05clean\x12.\n" +
+	"\x05files\x18\x04 \x03(\v2\x18.bridge.v1.GitFileStatusR\x05files\"q\n" +
+	"\x0eGitDiffRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06staged\x18\x02 \x01(\bR\x06staged\x12\x12\n" +
+	"\x04base\x18\x03 \x01(\tR\x04base\x12\x14\n" +
+	"\x05paths\x18\x04 \x03(\tR\x05paths\"C\n" +
+	"\x0fGitDiffResponse\x12\x12\n" +
+	"\x04diff\x18\x01 \x01(\fR\x04diff\x12\x1c\n" +
+	"\ttruncated\x18\x02 \x01(\bR\ttruncated\"s\n" +
+	"\x10GitCommitRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x10\n" +
+	"\x03all\x18\x03 \x01(\bR\x03all\x12\x14\n" +
+	"\x05paths\x18\x04 \x03(\tR\x05paths\"+\n" +
+	"\x11GitCommitResponse\x12\x16\n" +
+	"\x06commit\x18\x01 \x01(\tR\x06commit\"9\n" +
+	"\x18RollbackWorkspaceRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"X\n" +
+	"\x19RollbackWorkspaceResponse\x12\x12\n" +
+	"\x04head\x18\x01 \x01(\tR\x04head\x12'\n" +
+	"\x0fsnapshot_commit\x18\x02 \x01(\tR\x0esnapshotCommit\"f\n" +
 	"\x12ClaimWriterRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
@@ -1857,24 +7425,152 @@ const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
 	"\tclient_id\x18\x02 \x01(\tR\bclientId\"3\n" +
 	"\x15ReleaseWriterResponse\x12\x1a\n" +
-	"\breleased\x18\x01 \x01(\bR\breleased\"\x0f\n" +
-	"\rHealthRequest\"\x8f\x01\n" +
+	"\breleased\x18\x01 \x01(\bR\breleased\"h\n" +
+	"\x12TakeControlRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"n\n" +
+	"\x13TakeControlResponse\x12\x14\n" +
+	"\x05taken\x18\x01 \x01(\bR\x05taken\x12A\n" +
+	"\x1dprevious_controller_client_id\x18\x02 \x01(\tR\x1apreviousControllerClientId\"Y\n" +
+	"\x17MintSessionTokenRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1f\n" +
+	"\vttl_seconds\x18\x02 \x01(\rR\n" +
+	"ttlSeconds\"\x86\x01\n" +
+	"\x18MintSessionTokenResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x19\n" +
+	"\btoken_id\x18\x03 \x01(\tR\atokenId\"=\n" +
+	"\rHealthRequest\x12,\n" +
+	"\x05check\x18\x01 \x01(\x0e2\x16.bridge.v1.HealthCheckR\x05check\"\xdc\x02\n" +
 	"\x0eHealthResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x127\n" +
 	"\tproviders\x18\x02 \x03(\v2\x19.bridge.v1.ProviderHealthR\tproviders\x12,\n" +
-	"\x12server_instance_id\x18\x03 \x01(\tR\x10serverInstanceId\"`\n" +
+	"\x12server_instance_id\x18\x03 \x01(\tR\x10serverInstanceId\x12S\n" +
+	"\x0eredaction_hits\x18\x04 \x03(\v2,.bridge.v1.HealthResponse.RedactionHitsEntryR\rredactionHits\x124\n" +
+	"\x06checks\x18\x05 \x03(\v2\x1c.bridge.v1.HealthCheckResultR\x06checks\x1a@\n" +
+	"\x12RedactionHitsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"M\n" +
+	"\x11HealthCheckResult\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02ok\x18\x02 \x01(\bR\x02ok\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"`\n" +
 	"\x0eProviderHealth\x12\x1a\n" +
 	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x1c\n" +
 	"\tavailable\x18\x02 \x01(\bR\tavailable\x12\x14\n" +
 	"\x05error\x18\x03 \x01(\tR\x05error\"\x16\n" +
 	"\x14ListProvidersRequest\"N\n" +
 	"\x15ListProvidersResponse\x125\n" +
-	"\tproviders\x18\x01 \x03(\v2\x17.bridge.v1.ProviderInfoR\tproviders\"z\n" +
+	"\tproviders\x18\x01 \x03(\v2\x17.bridge.v1.ProviderInfoR\tproviders\"\xc8\x01\n" +
 	"\fProviderInfo\x12\x1a\n" +
 	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x1c\n" +
 	"\tavailable\x18\x02 \x01(\bR\tavailable\x12\x16\n" +
 	"\x06binary\x18\x03 \x01(\tR\x06binary\x12\x18\n" +
-	"\aversion\x18\x04 \x01(\tR\aversion*\xd9\x01\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x126\n" +
+	"\bvariants\x18\x06 \x03(\v2\x1a.bridge.v1.ProviderVariantR\bvariants\"\xa5\x01\n" +
+	"\x0fProviderVariant\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\tavailable\x18\x02 \x01(\bR\tavailable\x12\x16\n" +
+	"\x06binary\x18\x03 \x01(\tR\x06binary\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x18\n" +
+	"\adefault\x18\x06 \x01(\bR\adefault\"N\n" +
+	"\x17AdminStopSessionRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x14\n" +
+	"\x05force\x18\x02 \x01(\bR\x05force\"k\n" +
+	"\x18AdminStopSessionResponse\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x120\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x18.bridge.v1.SessionStatusR\x06status\"\x0e\n" +
+	"\fDrainRequest\":\n" +
+	"\rDrainResponse\x12)\n" +
+	"\x10already_draining\x18\x01 \x01(\bR\x0falreadyDraining\"\x15\n" +
+	"\x13ReloadConfigRequest\"\x16\n" +
+	"\x14ReloadConfigResponse\"\x13\n" +
+	"\x11GetMetricsRequest\"\xbe\x06\n" +
+	"\x12GetMetricsResponse\x12,\n" +
+	"\x12server_instance_id\x18\x01 \x01(\tR\x10serverInstanceId\x129\n" +
+	"\n" +
+	"started_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12\x1a\n" +
+	"\bdraining\x18\x03 \x01(\bR\bdraining\x12a\n" +
+	"\x12sessions_by_status\x18\x04 \x03(\v23.bridge.v1.GetMetricsResponse.SessionsByStatusEntryR\x10sessionsByStatus\x12d\n" +
+	"\x13sessions_by_project\x18\x05 \x03(\v24.bridge.v1.GetMetricsResponse.SessionsByProjectEntryR\x11sessionsByProject\x12W\n" +
+	"\x0eredaction_hits\x18\x06 \x03(\v20.bridge.v1.GetMetricsResponse.RedactionHitsEntryR\rredactionHits\x12%\n" +
+	"\x0erevoked_tokens\x18\a \x01(\rR\rrevokedTokens\x12@\n" +
+	"\rrate_limiters\x18\b \x03(\v2\x1b.bridge.v1.RateLimiterStateR\frateLimiters\x12K\n" +
+	"\x12provider_readiness\x18\t \x03(\v2\x1c.bridge.v1.ProviderReadinessR\x11providerReadiness\x1aC\n" +
+	"\x15SessionsByStatusEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\x1aD\n" +
+	"\x16SessionsByProjectEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\x1a@\n" +
+	"\x12RedactionHitsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"\xa8\x01\n" +
+	"\x11ProviderReadiness\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x14\n" +
+	"\x05ready\x18\x02 \x01(\x04R\x05ready\x12\x1b\n" +
+	"\ttimed_out\x18\x03 \x01(\x04R\btimedOut\x12\"\n" +
+	"\rmean_ready_ms\x18\x04 \x01(\rR\vmeanReadyMs\x12 \n" +
+	"\fmax_ready_ms\x18\x05 \x01(\rR\n" +
+	"maxReadyMs\"\x8a\x02\n" +
+	"\x10RateLimiterState\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x02 \x01(\tR\tprojectId\x12\x12\n" +
+	"\x04rate\x18\x03 \x01(\x01R\x04rate\x12\x14\n" +
+	"\x05burst\x18\x04 \x01(\rR\x05burst\x12%\n" +
+	"\x0eactive_buckets\x18\x05 \x01(\rR\ractiveBuckets\x12\x1a\n" +
+	"\brejected\x18\x06 \x01(\x04R\brejected\x12/\n" +
+	"\x13interactive_reserve\x18\a \x01(\x01R\x12interactiveReserve\x12%\n" +
+	"\x0erejected_batch\x18\b \x01(\x04R\rrejectedBatch\"a\n" +
+	"\x12RevokeTokenRequest\x12\x19\n" +
+	"\btoken_id\x18\x01 \x01(\tR\atokenId\x12\x18\n" +
+	"\asubject\x18\x02 \x01(\tR\asubject\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\x15\n" +
+	"\x13RevokeTokenResponse\"\xdf\x02\n" +
+	"\x17RegisterProviderRequest\x12\x1f\n" +
+	"\vprovider_id\x18\x01 \x01(\tR\n" +
+	"providerId\x12\x16\n" +
+	"\x06binary\x18\x02 \x01(\tR\x06binary\x12\x12\n" +
+	"\x04args\x18\x03 \x03(\tR\x04args\x12\x1f\n" +
+	"\vstream_json\x18\x04 \x01(\bR\n" +
+	"streamJson\x12\x1f\n" +
+	"\vjson_format\x18\x05 \x01(\tR\n" +
+	"jsonFormat\x12%\n" +
+	"\x0eprompt_pattern\x18\x06 \x01(\tR\rpromptPattern\x12#\n" +
+	"\rstartup_probe\x18\a \x01(\tR\fstartupProbe\x12,\n" +
+	"\x12startup_timeout_ms\x18\b \x01(\rR\x10startupTimeoutMs\x12!\n" +
+	"\frequired_env\x18\t \x03(\tR\vrequiredEnv\x12\x18\n" +
+	"\areplace\x18\n" +
+	" \x01(\bR\areplace\"6\n" +
+	"\x18RegisterProviderResponse\x12\x1a\n" +
+	"\breplaced\x18\x01 \x01(\bR\breplaced\"<\n" +
+	"\x19UnregisterProviderRequest\x12\x1f\n" +
+	"\vprovider_id\x18\x01 \x01(\tR\n" +
+	"providerId\"\x1c\n" +
+	"\x1aUnregisterProviderResponse\"H\n" +
+	"\x12SetLogLevelRequest\x12\x1c\n" +
+	"\tcomponent\x18\x01 \x01(\tR\tcomponent\x12\x14\n" +
+	"\x05level\x18\x02 \x01(\tR\x05level\"\x94\x01\n" +
+	"\x13SetLogLevelResponse\x12B\n" +
+	"\x06levels\x18\x01 \x03(\v2*.bridge.v1.SetLogLevelResponse.LevelsEntryR\x06levels\x1a9\n" +
+	"\vLevelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"4\n" +
+	"\x13PurgeSessionRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"5\n" +
+	"\x14PurgeSessionResponse\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId*\xd9\x01\n" +
 	"\rSessionStatus\x12\x1e\n" +
 	"\x1aSESSION_STATUS_UNSPECIFIED\x10\x00\x12\x1b\n" +
 	"\x17SESSION_STATUS_STARTING\x10\x01\x12\x1a\n" +
@@ -1887,7 +7583,7 @@ const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"AttachRole\x12\x1b\n" +
 	"\x17ATTACH_ROLE_UNSPECIFIED\x10\x00\x12\x16\n" +
 	"\x12ATTACH_ROLE_WRITER\x10\x01\x12\x18\n" +
-	"\x14ATTACH_ROLE_OBSERVER\x10\x02*\xc2\x02\n" +
+	"\x14ATTACH_ROLE_OBSERVER\x10\x02*\xe4\x06\n" +
 	"\x0fAttachEventType\x12!\n" +
 	"\x1dATTACH_EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1e\n" +
 	"\x1aATTACH_EVENT_TYPE_ATTACHED\x10\x01\x12\x1c\n" +
@@ -1897,21 +7593,86 @@ const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"\x17ATTACH_EVENT_TYPE_ERROR\x10\x05\x12\x1e\n" +
 	"\x1aATTACH_EVENT_TYPE_THINKING\x10\x06\x12$\n" +
 	" ATTACH_EVENT_TYPE_WRITER_CLAIMED\x10\a\x12%\n" +
-	"!ATTACH_EVENT_TYPE_WRITER_RELEASED\x10\b2\xf1\x06\n" +
+	"!ATTACH_EVENT_TYPE_WRITER_RELEASED\x10\b\x12'\n" +
+	"#ATTACH_EVENT_TYPE_RESPONSE_COMPLETE\x10\t\x12\x1b\n" +
+	"\x17ATTACH_EVENT_TYPE_USAGE\x10\n" +
+	"\x12\x1d\n" +
+	"\x19ATTACH_EVENT_TYPE_WARNING\x10\v\x12!\n" +
+	"\x1dATTACH_EVENT_TYPE_INPUT_ACKED\x10\f\x12 \n" +
+	"\x1cATTACH_EVENT_TYPE_REPO_DIRTY\x10\r\x12'\n" +
+	"#ATTACH_EVENT_TYPE_SESSION_RESTARTED\x10\x0e\x12\x1b\n" +
+	"\x17ATTACH_EVENT_TYPE_BATCH\x10\x0f\x12\x1e\n" +
+	"\x1aATTACH_EVENT_TYPE_TOOL_USE\x10\x10\x12\x1f\n" +
+	"\x1bATTACH_EVENT_TYPE_EXTENSION\x10\x11\x12(\n" +
+	"$ATTACH_EVENT_TYPE_APPROVAL_REQUESTED\x10\x12\x12'\n" +
+	"#ATTACH_EVENT_TYPE_APPROVAL_RESOLVED\x10\x13\x12%\n" +
+	"!ATTACH_EVENT_TYPE_CONTROL_CHANGED\x10\x14\x12%\n" +
+	"!ATTACH_EVENT_TYPE_BUDGET_EXCEEDED\x10\x15\x12&\n" +
+	"\"ATTACH_EVENT_TYPE_OUTPUT_TRUNCATED\x10\x16\x12&\n" +
+	"\"ATTACH_EVENT_TYPE_POLICY_VIOLATION\x10\x17*e\n" +
+	"\bSeverity\x12\x18\n" +
+	"\x14SEVERITY_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11SEVERITY_PROGRESS\x10\x01\x12\x14\n" +
+	"\x10SEVERITY_WARNING\x10\x02\x12\x12\n" +
+	"\x0eSEVERITY_ERROR\x10\x03*i\n" +
+	"\rInputPriority\x12\x1e\n" +
+	"\x1aINPUT_PRIORITY_UNSPECIFIED\x10\x00\x12\x1e\n" +
+	"\x1aINPUT_PRIORITY_INTERACTIVE\x10\x01\x12\x18\n" +
+	"\x14INPUT_PRIORITY_BATCH\x10\x02*r\n" +
+	"\x10TranscriptFormat\x12!\n" +
+	"\x1dTRANSCRIPT_FORMAT_UNSPECIFIED\x10\x00\x12\x1e\n" +
+	"\x1aTRANSCRIPT_FORMAT_MARKDOWN\x10\x01\x12\x1b\n" +
+	"\x17TRANSCRIPT_FORMAT_JSONL\x10\x02*b\n" +
+	"\vHealthCheck\x12\x1c\n" +
+	"\x18HEALTH_CHECK_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15HEALTH_CHECK_LIVENESS\x10\x01\x12\x1a\n" +
+	"\x16HEALTH_CHECK_READINESS\x10\x022\xc8\x14\n" +
 	"\rBridgeService\x12O\n" +
 	"\fStartSession\x12\x1e.bridge.v1.StartSessionRequest\x1a\x1f.bridge.v1.StartSessionResponse\x12L\n" +
 	"\vStopSession\x12\x1d.bridge.v1.StopSessionRequest\x1a\x1e.bridge.v1.StopSessionResponse\x12I\n" +
 	"\n" +
 	"GetSession\x12\x1c.bridge.v1.GetSessionRequest\x1a\x1d.bridge.v1.GetSessionResponse\x12O\n" +
-	"\fListSessions\x12\x1e.bridge.v1.ListSessionsRequest\x1a\x1f.bridge.v1.ListSessionsResponse\x12Q\n" +
+	"\fListSessions\x12\x1e.bridge.v1.ListSessionsRequest\x1a\x1f.bridge.v1.ListSessionsResponse\x12^\n" +
+	"\x11GetSessionHistory\x12#.bridge.v1.GetSessionHistoryRequest\x1a$.bridge.v1.GetSessionHistoryResponse\x12[\n" +
+	"\x10ExportTranscript\x12\".bridge.v1.ExportTranscriptRequest\x1a#.bridge.v1.ExportTranscriptResponse\x12X\n" +
+	"\x0fGetConversation\x12!.bridge.v1.GetConversationRequest\x1a\".bridge.v1.GetConversationResponse\x12L\n" +
+	"\vGetResponse\x12\x1d.bridge.v1.GetResponseRequest\x1a\x1e.bridge.v1.GetResponseResponse\x12a\n" +
+	"\x12ExportSessionState\x12$.bridge.v1.ExportSessionStateRequest\x1a%.bridge.v1.ExportSessionStateResponse\x12a\n" +
+	"\x12ImportSessionState\x12$.bridge.v1.ImportSessionStateRequest\x1a%.bridge.v1.ImportSessionStateResponse\x12Q\n" +
 	"\rAttachSession\x12\x1f.bridge.v1.AttachSessionRequest\x1a\x1d.bridge.v1.AttachSessionEvent0\x01\x12I\n" +
 	"\n" +
-	"WriteInput\x12\x1c.bridge.v1.WriteInputRequest\x1a\x1d.bridge.v1.WriteInputResponse\x12R\n" +
-	"\rResizeSession\x12\x1f.bridge.v1.ResizeSessionRequest\x1a .bridge.v1.ResizeSessionResponse\x12L\n" +
+	"WriteInput\x12\x1c.bridge.v1.WriteInputRequest\x1a\x1d.bridge.v1.WriteInputResponse\x12U\n" +
+	"\x0fSendInputStream\x12!.bridge.v1.SendInputStreamRequest\x1a\x1d.bridge.v1.WriteInputResponse(\x01\x12U\n" +
+	"\x0eBroadcastInput\x12 .bridge.v1.BroadcastInputRequest\x1a!.bridge.v1.BroadcastInputResponse\x12Y\n" +
+	"\x0eAttachTerminal\x12 .bridge.v1.AttachTerminalRequest\x1a!.bridge.v1.AttachTerminalResponse(\x010\x01\x12R\n" +
+	"\rResizeSession\x12\x1f.bridge.v1.ResizeSessionRequest\x1a .bridge.v1.ResizeSessionResponse\x12U\n" +
+	"\x0eCancelResponse\x12 .bridge.v1.CancelResponseRequest\x1a!.bridge.v1.CancelResponseResponse\x12X\n" +
+	"\x0fResolveApproval\x12!.bridge.v1.ResolveApprovalRequest\x1a\".bridge.v1.ResolveApprovalResponse\x12L\n" +
 	"\vClaimWriter\x12\x1d.bridge.v1.ClaimWriterRequest\x1a\x1e.bridge.v1.ClaimWriterResponse\x12R\n" +
-	"\rReleaseWriter\x12\x1f.bridge.v1.ReleaseWriterRequest\x1a .bridge.v1.ReleaseWriterResponse\x12=\n" +
-	"\x06Health\x12\x18.bridge.v1.HealthRequest\x1a\x19.bridge.v1.HealthResponse\x12R\n" +
-	"\rListProviders\x12\x1f.bridge.v1.ListProvidersRequest\x1a .bridge.v1.ListProvidersResponseB>Z<github.com/markcallen/ai-agent-bridge/gen/bridge/v1;bridgev1b\x06proto3"
+	"\rReleaseWriter\x12\x1f.bridge.v1.ReleaseWriterRequest\x1a .bridge.v1.ReleaseWriterResponse\x12L\n" +
+	"\vTakeControl\x12\x1d.bridge.v1.TakeControlRequest\x1a\x1e.bridge.v1.TakeControlResponse\x12[\n" +
+	"\x10MintSessionToken\x12\".bridge.v1.MintSessionTokenRequest\x1a#.bridge.v1.MintSessionTokenResponse\x12C\n" +
+	"\bReadFile\x12\x1a.bridge.v1.ReadFileRequest\x1a\x1b.bridge.v1.ReadFileResponse\x12F\n" +
+	"\tWriteFile\x12\x1b.bridge.v1.WriteFileRequest\x1a\x1c.bridge.v1.WriteFileResponse\x12@\n" +
+	"\aListDir\x12\x19.bridge.v1.ListDirRequest\x1a\x1a.bridge.v1.ListDirResponse\x12F\n" +
+	"\tGitStatus\x12\x1b.bridge.v1.GitStatusRequest\x1a\x1c.bridge.v1.GitStatusResponse\x12@\n" +
+	"\aGitDiff\x12\x19.bridge.v1.GitDiffRequest\x1a\x1a.bridge.v1.GitDiffResponse\x12F\n" +
+	"\tGitCommit\x12\x1b.bridge.v1.GitCommitRequest\x1a\x1c.bridge.v1.GitCommitResponse\x12^\n" +
+	"\x11RollbackWorkspace\x12#.bridge.v1.RollbackWorkspaceRequest\x1a$.bridge.v1.RollbackWorkspaceResponse\x12=\n" +
+	"\x06Health\x12\x18.bridge.v1.HealthRequest\x1a\x19.bridge.v1.HealthResponse\x12D\n" +
+	"\vHealthWatch\x12\x18.bridge.v1.HealthRequest\x1a\x19.bridge.v1.HealthResponse0\x01\x12R\n" +
+	"\rListProviders\x12\x1f.bridge.v1.ListProvidersRequest\x1a .bridge.v1.ListProvidersResponse2\xeb\x05\n" +
+	"\fAdminService\x12V\n" +
+	"\vStopSession\x12\".bridge.v1.AdminStopSessionRequest\x1a#.bridge.v1.AdminStopSessionResponse\x12:\n" +
+	"\x05Drain\x12\x17.bridge.v1.DrainRequest\x1a\x18.bridge.v1.DrainResponse\x12O\n" +
+	"\fReloadConfig\x12\x1e.bridge.v1.ReloadConfigRequest\x1a\x1f.bridge.v1.ReloadConfigResponse\x12I\n" +
+	"\n" +
+	"GetMetrics\x12\x1c.bridge.v1.GetMetricsRequest\x1a\x1d.bridge.v1.GetMetricsResponse\x12L\n" +
+	"\vRevokeToken\x12\x1d.bridge.v1.RevokeTokenRequest\x1a\x1e.bridge.v1.RevokeTokenResponse\x12[\n" +
+	"\x10RegisterProvider\x12\".bridge.v1.RegisterProviderRequest\x1a#.bridge.v1.RegisterProviderResponse\x12a\n" +
+	"\x12UnregisterProvider\x12$.bridge.v1.UnregisterProviderRequest\x1a%.bridge.v1.UnregisterProviderResponse\x12L\n" +
+	"\vSetLogLevel\x12\x1d.bridge.v1.SetLogLevelRequest\x1a\x1e.bridge.v1.SetLogLevelResponse\x12O\n" +
+	"\fPurgeSession\x12\x1e.bridge.v1.PurgeSessionRequest\x1a\x1f.bridge.v1.PurgeSessionResponseB>Z<github.com/markcallen/ai-agent-bridge/gen/bridge/v1;bridgev1b\x06proto3"
 
 var (
 	file_bridge_v1_bridge_proto_rawDescOnce sync.Once
@@ -1925,80 +7686,274 @@ func file_bridge_v1_bridge_proto_rawDescGZIP() []byte {
 	return file_bridge_v1_bridge_proto_rawDescData
 }
 
-var file_bridge_v1_bridge_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_bridge_v1_bridge_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_bridge_v1_bridge_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
+var file_bridge_v1_bridge_proto_msgTypes = make([]protoimpl.MessageInfo, 107)
 var file_bridge_v1_bridge_proto_goTypes = []any{
-	(SessionStatus)(0),            // 0: bridge.v1.SessionStatus
-	(AttachRole)(0),               // 1: bridge.v1.AttachRole
-	(AttachEventType)(0),          // 2: bridge.v1.AttachEventType
-	(*StartSessionRequest)(nil),   // 3: bridge.v1.StartSessionRequest
-	(*StartSessionResponse)(nil),  // 4: bridge.v1.StartSessionResponse
-	(*StopSessionRequest)(nil),    // 5: bridge.v1.StopSessionRequest
-	(*StopSessionResponse)(nil),   // 6: bridge.v1.StopSessionResponse
-	(*GetSessionRequest)(nil),     // 7: bridge.v1.GetSessionRequest
-	(*GetSessionResponse)(nil),    // 8: bridge.v1.GetSessionResponse
-	(*ListSessionsRequest)(nil),   // 9: bridge.v1.ListSessionsRequest
-	(*ListSessionsResponse)(nil),  // 10: bridge.v1.ListSessionsResponse
-	(*AttachSessionRequest)(nil),  // 11: bridge.v1.AttachSessionRequest
-	(*AttachSessionEvent)(nil),    // 12: bridge.v1.AttachSessionEvent
-	(*WriteInputRequest)(nil),     // 13: bridge.v1.WriteInputRequest
-	(*WriteInputResponse)(nil),    // 14: bridge.v1.WriteInputResponse
-	(*ResizeSessionRequest)(nil),  // 15: bridge.v1.ResizeSessionRequest
-	(*ResizeSessionResponse)(nil), // 16: bridge.v1.ResizeSessionResponse
-	(*ClaimWriterRequest)(nil),    // 17: bridge.v1.ClaimWriterRequest
-	(*ClaimWriterResponse)(nil),   // 18: bridge.v1.ClaimWriterResponse
-	(*ReleaseWriterRequest)(nil),  // 19: bridge.v1.ReleaseWriterRequest
-	(*ReleaseWriterResponse)(nil), // 20: bridge.v1.ReleaseWriterResponse
-	(*HealthRequest)(nil),         // 21: bridge.v1.HealthRequest
-	(*HealthResponse)(nil),        // 22: bridge.v1.HealthResponse
-	(*ProviderHealth)(nil),        // 23: bridge.v1.ProviderHealth
-	(*ListProvidersRequest)(nil),  // 24: bridge.v1.ListProvidersRequest
-	(*ListProvidersResponse)(nil), // 25: bridge.v1.ListProvidersResponse
-	(*ProviderInfo)(nil),          // 26: bridge.v1.ProviderInfo
-	nil,                           // 27: bridge.v1.StartSessionRequest.AgentOptsEntry
-	(*timestamppb.Timestamp)(nil), // 28: google.protobuf.Timestamp
+	(SessionStatus)(0),                 // 0: bridge.v1.SessionStatus
+	(AttachRole)(0),                    // 1: bridge.v1.AttachRole
+	(AttachEventType)(0),               // 2: bridge.v1.AttachEventType
+	(Severity)(0),                      // 3: bridge.v1.Severity
+	(InputPriority)(0),                 // 4: bridge.v1.InputPriority
+	(TranscriptFormat)(0),              // 5: bridge.v1.TranscriptFormat
+	(HealthCheck)(0),                   // 6: bridge.v1.HealthCheck
+	(*StartSessionRequest)(nil),        // 7: bridge.v1.StartSessionRequest
+	(*RepoSource)(nil),                 // 8: bridge.v1.RepoSource
+	(*StartSessionResponse)(nil),       // 9: bridge.v1.StartSessionResponse
+	(*StopSessionRequest)(nil),         // 10: bridge.v1.StopSessionRequest
+	(*StopSessionResponse)(nil),        // 11: bridge.v1.StopSessionResponse
+	(*GetSessionRequest)(nil),          // 12: bridge.v1.GetSessionRequest
+	(*GetSessionResponse)(nil),         // 13: bridge.v1.GetSessionResponse
+	(*Approval)(nil),                   // 14: bridge.v1.Approval
+	(*Usage)(nil),                      // 15: bridge.v1.Usage
+	(*GetSessionHistoryRequest)(nil),   // 16: bridge.v1.GetSessionHistoryRequest
+	(*GetSessionHistoryResponse)(nil),  // 17: bridge.v1.GetSessionHistoryResponse
+	(*ExportTranscriptRequest)(nil),    // 18: bridge.v1.ExportTranscriptRequest
+	(*ExportTranscriptResponse)(nil),   // 19: bridge.v1.ExportTranscriptResponse
+	(*GetConversationRequest)(nil),     // 20: bridge.v1.GetConversationRequest
+	(*GetConversationResponse)(nil),    // 21: bridge.v1.GetConversationResponse
+	(*GetResponseRequest)(nil),         // 22: bridge.v1.GetResponseRequest
+	(*GetResponseResponse)(nil),        // 23: bridge.v1.GetResponseResponse
+	(*ExportSessionStateRequest)(nil),  // 24: bridge.v1.ExportSessionStateRequest
+	(*ExportSessionStateResponse)(nil), // 25: bridge.v1.ExportSessionStateResponse
+	(*ImportSessionStateRequest)(nil),  // 26: bridge.v1.ImportSessionStateRequest
+	(*ImportSessionStateResponse)(nil), // 27: bridge.v1.ImportSessionStateResponse
+	(*ListSessionsRequest)(nil),        // 28: bridge.v1.ListSessionsRequest
+	(*ListSessionsResponse)(nil),       // 29: bridge.v1.ListSessionsResponse
+	(*AttachSessionRequest)(nil),       // 30: bridge.v1.AttachSessionRequest
+	(*AttachSessionEvent)(nil),         // 31: bridge.v1.AttachSessionEvent
+	(*AttachSessionEventBatch)(nil),    // 32: bridge.v1.AttachSessionEventBatch
+	(*WriteInputRequest)(nil),          // 33: bridge.v1.WriteInputRequest
+	(*InputAttachment)(nil),            // 34: bridge.v1.InputAttachment
+	(*SendInputStreamRequest)(nil),     // 35: bridge.v1.SendInputStreamRequest
+	(*WriteInputResponse)(nil),         // 36: bridge.v1.WriteInputResponse
+	(*BroadcastInputRequest)(nil),      // 37: bridge.v1.BroadcastInputRequest
+	(*BroadcastInputResult)(nil),       // 38: bridge.v1.BroadcastInputResult
+	(*BroadcastInputResponse)(nil),     // 39: bridge.v1.BroadcastInputResponse
+	(*TerminalOpen)(nil),               // 40: bridge.v1.TerminalOpen
+	(*TerminalResize)(nil),             // 41: bridge.v1.TerminalResize
+	(*AttachTerminalRequest)(nil),      // 42: bridge.v1.AttachTerminalRequest
+	(*TerminalAttached)(nil),           // 43: bridge.v1.TerminalAttached
+	(*TerminalOutput)(nil),             // 44: bridge.v1.TerminalOutput
+	(*TerminalExit)(nil),               // 45: bridge.v1.TerminalExit
+	(*AttachTerminalResponse)(nil),     // 46: bridge.v1.AttachTerminalResponse
+	(*ResizeSessionRequest)(nil),       // 47: bridge.v1.ResizeSessionRequest
+	(*ResizeSessionResponse)(nil),      // 48: bridge.v1.ResizeSessionResponse
+	(*CancelResponseRequest)(nil),      // 49: bridge.v1.CancelResponseRequest
+	(*CancelResponseResponse)(nil),     // 50: bridge.v1.CancelResponseResponse
+	(*ResolveApprovalRequest)(nil),     // 51: bridge.v1.ResolveApprovalRequest
+	(*ResolveApprovalResponse)(nil),    // 52: bridge.v1.ResolveApprovalResponse
+	(*ReadFileRequest)(nil),            // 53: bridge.v1.ReadFileRequest
+	(*ReadFileResponse)(nil),           // 54: bridge.v1.ReadFileResponse
+	(*WriteFileRequest)(nil),           // 55: bridge.v1.WriteFileRequest
+	(*WriteFileResponse)(nil),          // 56: bridge.v1.WriteFileResponse
+	(*ListDirRequest)(nil),             // 57: bridge.v1.ListDirRequest
+	(*DirEntry)(nil),                   // 58: bridge.v1.DirEntry
+	(*ListDirResponse)(nil),            // 59: bridge.v1.ListDirResponse
+	(*GitStatusRequest)(nil),           // 60: bridge.v1.GitStatusRequest
+	(*GitFileStatus)(nil),              // 61: bridge.v1.GitFileStatus
+	(*GitStatusResponse)(nil),          // 62: bridge.v1.GitStatusResponse
+	(*GitDiffRequest)(nil),             // 63: bridge.v1.GitDiffRequest
+	(*GitDiffResponse)(nil),            // 64: bridge.v1.GitDiffResponse
+	(*GitCommitRequest)(nil),           // 65: bridge.v1.GitCommitRequest
+	(*GitCommitResponse)(nil),          // 66: bridge.v1.GitCommitResponse
+	(*RollbackWorkspaceRequest)(nil),   // 67: bridge.v1.RollbackWorkspaceRequest
+	(*RollbackWorkspaceResponse)(nil),  // 68: bridge.v1.RollbackWorkspaceResponse
+	(*ClaimWriterRequest)(nil),         // 69: bridge.v1.ClaimWriterRequest
+	(*ClaimWriterResponse)(nil),        // 70: bridge.v1.ClaimWriterResponse
+	(*ReleaseWriterRequest)(nil),       // 71: bridge.v1.ReleaseWriterRequest
+	(*ReleaseWriterResponse)(nil),      // 72: bridge.v1.ReleaseWriterResponse
+	(*TakeControlRequest)(nil),         // 73: bridge.v1.TakeControlRequest
+	(*TakeControlResponse)(nil),        // 74: bridge.v1.TakeControlResponse
+	(*MintSessionTokenRequest)(nil),    // 75: bridge.v1.MintSessionTokenRequest
+	(*MintSessionTokenResponse)(nil),   // 76: bridge.v1.MintSessionTokenResponse
+	(*HealthRequest)(nil),              // 77: bridge.v1.HealthRequest
+	(*HealthResponse)(nil),             // 78: bridge.v1.HealthResponse
+	(*HealthCheckResult)(nil),          // 79: bridge.v1.HealthCheckResult
+	(*ProviderHealth)(nil),             // 80: bridge.v1.ProviderHealth
+	(*ListProvidersRequest)(nil),       // 81: bridge.v1.ListProvidersRequest
+	(*ListProvidersResponse)(nil),      // 82: bridge.v1.ListProvidersResponse
+	(*ProviderInfo)(nil),               // 83: bridge.v1.ProviderInfo
+	(*ProviderVariant)(nil),            // 84: bridge.v1.ProviderVariant
+	(*AdminStopSessionRequest)(nil),    // 85: bridge.v1.AdminStopSessionRequest
+	(*AdminStopSessionResponse)(nil),   // 86: bridge.v1.AdminStopSessionResponse
+	(*DrainRequest)(nil),               // 87: bridge.v1.DrainRequest
+	(*DrainResponse)(nil),              // 88: bridge.v1.DrainResponse
+	(*ReloadConfigRequest)(nil),        // 89: bridge.v1.ReloadConfigRequest
+	(*ReloadConfigResponse)(nil),       // 90: bridge.v1.ReloadConfigResponse
+	(*GetMetricsRequest)(nil),          // 91: bridge.v1.GetMetricsRequest
+	(*GetMetricsResponse)(nil),         // 92: bridge.v1.GetMetricsResponse
+	(*ProviderReadiness)(nil),          // 93: bridge.v1.ProviderReadiness
+	(*RateLimiterState)(nil),           // 94: bridge.v1.RateLimiterState
+	(*RevokeTokenRequest)(nil),         // 95: bridge.v1.RevokeTokenRequest
+	(*RevokeTokenResponse)(nil),        // 96: bridge.v1.RevokeTokenResponse
+	(*RegisterProviderRequest)(nil),    // 97: bridge.v1.RegisterProviderRequest
+	(*RegisterProviderResponse)(nil),   // 98: bridge.v1.RegisterProviderResponse
+	(*UnregisterProviderRequest)(nil),  // 99: bridge.v1.UnregisterProviderRequest
+	(*UnregisterProviderResponse)(nil), // 100: bridge.v1.UnregisterProviderResponse
+	(*SetLogLevelRequest)(nil),         // 101: bridge.v1.SetLogLevelRequest
+	(*SetLogLevelResponse)(nil),        // 102: bridge.v1.SetLogLevelResponse
+	(*PurgeSessionRequest)(nil),        // 103: bridge.v1.PurgeSessionRequest
+	(*PurgeSessionResponse)(nil),       // 104: bridge.v1.PurgeSessionResponse
+	nil,                                // 105: bridge.v1.StartSessionRequest.AgentOptsEntry
+	nil,                                // 106: bridge.v1.StartSessionRequest.EnvEntry
+	nil,                                // 107: bridge.v1.WriteInputRequest.VarsEntry
+	nil,                                // 108: bridge.v1.BroadcastInputRequest.VarsEntry
+	nil,                                // 109: bridge.v1.HealthResponse.RedactionHitsEntry
+	nil,                                // 110: bridge.v1.GetMetricsResponse.SessionsByStatusEntry
+	nil,                                // 111: bridge.v1.GetMetricsResponse.SessionsByProjectEntry
+	nil,                                // 112: bridge.v1.GetMetricsResponse.RedactionHitsEntry
+	nil,                                // 113: bridge.v1.SetLogLevelResponse.LevelsEntry
+	(*timestamppb.Timestamp)(nil),      // 114: google.protobuf.Timestamp
 }
 var file_bridge_v1_bridge_proto_depIdxs = []int32{
-	27, // 0: bridge.v1.StartSessionRequest.agent_opts:type_name -> bridge.v1.StartSessionRequest.AgentOptsEntry
-	0,  // 1: bridge.v1.StartSessionResponse.status:type_name -> bridge.v1.SessionStatus
-	28, // 2: bridge.v1.StartSessionResponse.created_at:type_name -> google.protobuf.Timestamp
-	0,  // 3: bridge.v1.StopSessionResponse.status:type_name -> bridge.v1.SessionStatus
-	0,  // 4: bridge.v1.GetSessionResponse.status:type_name -> bridge.v1.SessionStatus
-	28, // 5: bridge.v1.GetSessionResponse.created_at:type_name -> google.protobuf.Timestamp
-	28, // 6: bridge.v1.GetSessionResponse.stopped_at:type_name -> google.protobuf.Timestamp
-	8,  // 7: bridge.v1.ListSessionsResponse.sessions:type_name -> bridge.v1.GetSessionResponse
-	1,  // 8: bridge.v1.AttachSessionRequest.role:type_name -> bridge.v1.AttachRole
-	2,  // 9: bridge.v1.AttachSessionEvent.type:type_name -> bridge.v1.AttachEventType
-	28, // 10: bridge.v1.AttachSessionEvent.timestamp:type_name -> google.protobuf.Timestamp
-	23, // 11: bridge.v1.HealthResponse.providers:type_name -> bridge.v1.ProviderHealth
-	26, // 12: bridge.v1.ListProvidersResponse.providers:type_name -> bridge.v1.ProviderInfo
-	3,  // 13: bridge.v1.BridgeService.StartSession:input_type -> bridge.v1.StartSessionRequest
-	5,  // 14: bridge.v1.BridgeService.StopSession:input_type -> bridge.v1.StopSessionRequest
-	7,  // 15: bridge.v1.BridgeService.GetSession:input_type -> bridge.v1.GetSessionRequest
-	9,  // 16: bridge.v1.BridgeService.ListSessions:input_type -> bridge.v1.ListSessionsRequest
-	11, // 17: bridge.v1.BridgeService.AttachSession:input_type -> bridge.v1.AttachSessionRequest
-	13, // 18: bridge.v1.BridgeService.WriteInput:input_type -> bridge.v1.WriteInputRequest
-	15, // 19: bridge.v1.BridgeService.ResizeSession:input_type -> bridge.v1.ResizeSessionRequest
-	17, // 20: bridge.v1.BridgeService.ClaimWriter:input_type -> bridge.v1.ClaimWriterRequest
-	19, // 21: bridge.v1.BridgeService.ReleaseWriter:input_type -> bridge.v1.ReleaseWriterRequest
-	21, // 22: bridge.v1.BridgeService.Health:input_type -> bridge.v1.HealthRequest
-	24, // 23: bridge.v1.BridgeService.ListProviders:input_type -> bridge.v1.ListProvidersRequest
-	4,  // 24: bridge.v1.BridgeService.StartSession:output_type -> bridge.v1.StartSessionResponse
-	6,  // 25: bridge.v1.BridgeService.StopSession:output_type -> bridge.v1.StopSessionResponse
-	8,  // 26: bridge.v1.BridgeService.GetSession:output_type -> bridge.v1.GetSessionResponse
-	10, // 27: bridge.v1.BridgeService.ListSessions:output_type -> bridge.v1.ListSessionsResponse
-	12, // 28: bridge.v1.BridgeService.AttachSession:output_type -> bridge.v1.AttachSessionEvent
-	14, // 29: bridge.v1.BridgeService.WriteInput:output_type -> bridge.v1.WriteInputResponse
-	16, // 30: bridge.v1.BridgeService.ResizeSession:output_type -> bridge.v1.ResizeSessionResponse
-	18, // 31: bridge.v1.BridgeService.ClaimWriter:output_type -> bridge.v1.ClaimWriterResponse
-	20, // 32: bridge.v1.BridgeService.ReleaseWriter:output_type -> bridge.v1.ReleaseWriterResponse
-	22, // 33: bridge.v1.BridgeService.Health:output_type -> bridge.v1.HealthResponse
-	25, // 34: bridge.v1.BridgeService.ListProviders:output_type -> bridge.v1.ListProvidersResponse
-	24, // [24:35] is the sub-list for method output_type
-	13, // [13:24] is the sub-list for method input_type
-	13, // [13:13] is the sub-list for extension type_name
-	13, // [13:13] is the sub-list for extension extendee
-	0,  // [0:13] is the sub-list for field type_name
+	105, // 0: bridge.v1.StartSessionRequest.agent_opts:type_name -> bridge.v1.StartSessionRequest.AgentOptsEntry
+	106, // 1: bridge.v1.StartSessionRequest.env:type_name -> bridge.v1.StartSessionRequest.EnvEntry
+	8,   // 2: bridge.v1.StartSessionRequest.repo_source:type_name -> bridge.v1.RepoSource
+	0,   // 3: bridge.v1.StartSessionResponse.status:type_name -> bridge.v1.SessionStatus
+	114, // 4: bridge.v1.StartSessionResponse.created_at:type_name -> google.protobuf.Timestamp
+	0,   // 5: bridge.v1.StopSessionResponse.status:type_name -> bridge.v1.SessionStatus
+	0,   // 6: bridge.v1.GetSessionResponse.status:type_name -> bridge.v1.SessionStatus
+	114, // 7: bridge.v1.GetSessionResponse.created_at:type_name -> google.protobuf.Timestamp
+	114, // 8: bridge.v1.GetSessionResponse.stopped_at:type_name -> google.protobuf.Timestamp
+	15,  // 9: bridge.v1.GetSessionResponse.usage:type_name -> bridge.v1.Usage
+	14,  // 10: bridge.v1.GetSessionResponse.pending_approvals:type_name -> bridge.v1.Approval
+	13,  // 11: bridge.v1.GetSessionHistoryResponse.session:type_name -> bridge.v1.GetSessionResponse
+	31,  // 12: bridge.v1.GetSessionHistoryResponse.events:type_name -> bridge.v1.AttachSessionEvent
+	114, // 13: bridge.v1.GetSessionHistoryResponse.archived_at:type_name -> google.protobuf.Timestamp
+	5,   // 14: bridge.v1.ExportTranscriptRequest.format:type_name -> bridge.v1.TranscriptFormat
+	5,   // 15: bridge.v1.GetConversationRequest.format:type_name -> bridge.v1.TranscriptFormat
+	13,  // 16: bridge.v1.GetConversationResponse.sessions:type_name -> bridge.v1.GetSessionResponse
+	13,  // 17: bridge.v1.ImportSessionStateResponse.session:type_name -> bridge.v1.GetSessionResponse
+	13,  // 18: bridge.v1.ListSessionsResponse.sessions:type_name -> bridge.v1.GetSessionResponse
+	1,   // 19: bridge.v1.AttachSessionRequest.role:type_name -> bridge.v1.AttachRole
+	2,   // 20: bridge.v1.AttachSessionRequest.event_types:type_name -> bridge.v1.AttachEventType
+	2,   // 21: bridge.v1.AttachSessionEvent.type:type_name -> bridge.v1.AttachEventType
+	114, // 22: bridge.v1.AttachSessionEvent.timestamp:type_name -> google.protobuf.Timestamp
+	15,  // 23: bridge.v1.AttachSessionEvent.usage:type_name -> bridge.v1.Usage
+	3,   // 24: bridge.v1.AttachSessionEvent.severity:type_name -> bridge.v1.Severity
+	32,  // 25: bridge.v1.AttachSessionEvent.batch:type_name -> bridge.v1.AttachSessionEventBatch
+	31,  // 26: bridge.v1.AttachSessionEventBatch.events:type_name -> bridge.v1.AttachSessionEvent
+	4,   // 27: bridge.v1.WriteInputRequest.priority:type_name -> bridge.v1.InputPriority
+	34,  // 28: bridge.v1.WriteInputRequest.attachments:type_name -> bridge.v1.InputAttachment
+	107, // 29: bridge.v1.WriteInputRequest.vars:type_name -> bridge.v1.WriteInputRequest.VarsEntry
+	4,   // 30: bridge.v1.SendInputStreamRequest.priority:type_name -> bridge.v1.InputPriority
+	4,   // 31: bridge.v1.BroadcastInputRequest.priority:type_name -> bridge.v1.InputPriority
+	34,  // 32: bridge.v1.BroadcastInputRequest.attachments:type_name -> bridge.v1.InputAttachment
+	108, // 33: bridge.v1.BroadcastInputRequest.vars:type_name -> bridge.v1.BroadcastInputRequest.VarsEntry
+	38,  // 34: bridge.v1.BroadcastInputResponse.results:type_name -> bridge.v1.BroadcastInputResult
+	1,   // 35: bridge.v1.TerminalOpen.role:type_name -> bridge.v1.AttachRole
+	40,  // 36: bridge.v1.AttachTerminalRequest.open:type_name -> bridge.v1.TerminalOpen
+	41,  // 37: bridge.v1.AttachTerminalRequest.resize:type_name -> bridge.v1.TerminalResize
+	1,   // 38: bridge.v1.TerminalAttached.role:type_name -> bridge.v1.AttachRole
+	43,  // 39: bridge.v1.AttachTerminalResponse.attached:type_name -> bridge.v1.TerminalAttached
+	44,  // 40: bridge.v1.AttachTerminalResponse.output:type_name -> bridge.v1.TerminalOutput
+	45,  // 41: bridge.v1.AttachTerminalResponse.exit:type_name -> bridge.v1.TerminalExit
+	114, // 42: bridge.v1.ReadFileResponse.modified_at:type_name -> google.protobuf.Timestamp
+	114, // 43: bridge.v1.DirEntry.modified_at:type_name -> google.protobuf.Timestamp
+	58,  // 44: bridge.v1.ListDirResponse.entries:type_name -> bridge.v1.DirEntry
+	61,  // 45: bridge.v1.GitStatusResponse.files:type_name -> bridge.v1.GitFileStatus
+	114, // 46: bridge.v1.MintSessionTokenResponse.expires_at:type_name -> google.protobuf.Timestamp
+	6,   // 47: bridge.v1.HealthRequest.check:type_name -> bridge.v1.HealthCheck
+	80,  // 48: bridge.v1.HealthResponse.providers:type_name -> bridge.v1.ProviderHealth
+	109, // 49: bridge.v1.HealthResponse.redaction_hits:type_name -> bridge.v1.HealthResponse.RedactionHitsEntry
+	79,  // 50: bridge.v1.HealthResponse.checks:type_name -> bridge.v1.HealthCheckResult
+	83,  // 51: bridge.v1.ListProvidersResponse.providers:type_name -> bridge.v1.ProviderInfo
+	84,  // 52: bridge.v1.ProviderInfo.variants:type_name -> bridge.v1.ProviderVariant
+	0,   // 53: bridge.v1.AdminStopSessionResponse.status:type_name -> bridge.v1.SessionStatus
+	114, // 54: bridge.v1.GetMetricsResponse.started_at:type_name -> google.protobuf.Timestamp
+	110, // 55: bridge.v1.GetMetricsResponse.sessions_by_status:type_name -> bridge.v1.GetMetricsResponse.SessionsByStatusEntry
+	111, // 56: bridge.v1.GetMetricsResponse.sessions_by_project:type_name -> bridge.v1.GetMetricsResponse.SessionsByProjectEntry
+	112, // 57: bridge.v1.GetMetricsResponse.redaction_hits:type_name -> bridge.v1.GetMetricsResponse.RedactionHitsEntry
+	94,  // 58: bridge.v1.GetMetricsResponse.rate_limiters:type_name -> bridge.v1.RateLimiterState
+	93,  // 59: bridge.v1.GetMetricsResponse.provider_readiness:type_name -> bridge.v1.ProviderReadiness
+	113, // 60: bridge.v1.SetLogLevelResponse.levels:type_name -> bridge.v1.SetLogLevelResponse.LevelsEntry
+	7,   // 61: bridge.v1.BridgeService.StartSession:input_type -> bridge.v1.StartSessionRequest
+	10,  // 62: bridge.v1.BridgeService.StopSession:input_type -> bridge.v1.StopSessionRequest
+	12,  // 63: bridge.v1.BridgeService.GetSession:input_type -> bridge.v1.GetSessionRequest
+	28,  // 64: bridge.v1.BridgeService.ListSessions:input_type -> bridge.v1.ListSessionsRequest
+	16,  // 65: bridge.v1.BridgeService.GetSessionHistory:input_type -> bridge.v1.GetSessionHistoryRequest
+	18,  // 66: bridge.v1.BridgeService.ExportTranscript:input_type -> bridge.v1.ExportTranscriptRequest
+	20,  // 67: bridge.v1.BridgeService.GetConversation:input_type -> bridge.v1.GetConversationRequest
+	22,  // 68: bridge.v1.BridgeService.GetResponse:input_type -> bridge.v1.GetResponseRequest
+	24,  // 69: bridge.v1.BridgeService.ExportSessionState:input_type -> bridge.v1.ExportSessionStateRequest
+	26,  // 70: bridge.v1.BridgeService.ImportSessionState:input_type -> bridge.v1.ImportSessionStateRequest
+	30,  // 71: bridge.v1.BridgeService.AttachSession:input_type -> bridge.v1.AttachSessionRequest
+	33,  // 72: bridge.v1.BridgeService.WriteInput:input_type -> bridge.v1.WriteInputRequest
+	35,  // 73: bridge.v1.BridgeService.SendInputStream:input_type -> bridge.v1.SendInputStreamRequest
+	37,  // 74: bridge.v1.BridgeService.BroadcastInput:input_type -> bridge.v1.BroadcastInputRequest
+	42,  // 75: bridge.v1.BridgeService.AttachTerminal:input_type -> bridge.v1.AttachTerminalRequest
+	47,  // 76: bridge.v1.BridgeService.ResizeSession:input_type -> bridge.v1.ResizeSessionRequest
+	49,  // 77: bridge.v1.BridgeService.CancelResponse:input_type -> bridge.v1.CancelResponseRequest
+	51,  // 78: bridge.v1.BridgeService.ResolveApproval:input_type -> bridge.v1.ResolveApprovalRequest
+	69,  // 79: bridge.v1.BridgeService.ClaimWriter:input_type -> bridge.v1.ClaimWriterRequest
+	71,  // 80: bridge.v1.BridgeService.ReleaseWriter:input_type -> bridge.v1.ReleaseWriterRequest
+	73,  // 81: bridge.v1.BridgeService.TakeControl:input_type -> bridge.v1.TakeControlRequest
+	75,  // 82: bridge.v1.BridgeService.MintSessionToken:input_type -> bridge.v1.MintSessionTokenRequest
+	53,  // 83: bridge.v1.BridgeService.ReadFile:input_type -> bridge.v1.ReadFileRequest
+	55,  // 84: bridge.v1.BridgeService.WriteFile:input_type -> bridge.v1.WriteFileRequest
+	57,  // 85: bridge.v1.BridgeService.ListDir:input_type -> bridge.v1.ListDirRequest
+	60,  // 86: bridge.v1.BridgeService.GitStatus:input_type -> bridge.v1.GitStatusRequest
+	63,  // 87: bridge.v1.BridgeService.GitDiff:input_type -> bridge.v1.GitDiffRequest
+	65,  // 88: bridge.v1.BridgeService.GitCommit:input_type -> bridge.v1.GitCommitRequest
+	67,  // 89: bridge.v1.BridgeService.RollbackWorkspace:input_type -> bridge.v1.RollbackWorkspaceRequest
+	77,  // 90: bridge.v1.BridgeService.Health:input_type -> bridge.v1.HealthRequest
+	77,  // 91: bridge.v1.BridgeService.HealthWatch:input_type -> bridge.v1.HealthRequest
+	81,  // 92: bridge.v1.BridgeService.ListProviders:input_type -> bridge.v1.ListProvidersRequest
+	85,  // 93: bridge.v1.AdminService.StopSession:input_type -> bridge.v1.AdminStopSessionRequest
+	87,  // 94: bridge.v1.AdminService.Drain:input_type -> bridge.v1.DrainRequest
+	89,  // 95: bridge.v1.AdminService.ReloadConfig:input_type -> bridge.v1.ReloadConfigRequest
+	91,  // 96: bridge.v1.AdminService.GetMetrics:input_type -> bridge.v1.GetMetricsRequest
+	95,  // 97: bridge.v1.AdminService.RevokeToken:input_type -> bridge.v1.RevokeTokenRequest
+	97,  // 98: bridge.v1.AdminService.RegisterProvider:input_type -> bridge.v1.RegisterProviderRequest
+	99,  // 99: bridge.v1.AdminService.UnregisterProvider:input_type -> bridge.v1.UnregisterProviderRequest
+	101, // 100: bridge.v1.AdminService.SetLogLevel:input_type -> bridge.v1.SetLogLevelRequest
+	103, // 101: bridge.v1.AdminService.PurgeSession:input_type -> bridge.v1.PurgeSessionRequest
+	9,   // 102: bridge.v1.BridgeService.StartSession:output_type -> bridge.v1.StartSessionResponse
+	11,  // 103: bridge.v1.BridgeService.StopSession:output_type -> bridge.v1.StopSessionResponse
+	13,  // 104: bridge.v1.BridgeService.GetSession:output_type -> bridge.v1.GetSessionResponse
+	29,  // 105: bridge.v1.BridgeService.ListSessions:output_type -> bridge.v1.ListSessionsResponse
+	17,  // 106: bridge.v1.BridgeService.GetSessionHistory:output_type -> bridge.v1.GetSessionHistoryResponse
+	19,  // 107: bridge.v1.BridgeService.ExportTranscript:output_type -> bridge.v1.ExportTranscriptResponse
+	21,  // 108: bridge.v1.BridgeService.GetConversation:output_type -> bridge.v1.GetConversationResponse
+	23,  // 109: bridge.v1.BridgeService.GetResponse:output_type -> bridge.v1.GetResponseResponse
+	25,  // 110: bridge.v1.BridgeService.ExportSessionState:output_type -> bridge.v1.ExportSessionStateResponse
+	27,  // 111: bridge.v1.BridgeService.ImportSessionState:output_type -> bridge.v1.ImportSessionStateResponse
+	31,  // 112: bridge.v1.BridgeService.AttachSession:output_type -> bridge.v1.AttachSessionEvent
+	36,  // 113: bridge.v1.BridgeService.WriteInput:output_type -> bridge.v1.WriteInputResponse
+	36,  // 114: bridge.v1.BridgeService.SendInputStream:output_type -> bridge.v1.WriteInputResponse
+	39,  // 115: bridge.v1.BridgeService.BroadcastInput:output_type -> bridge.v1.BroadcastInputResponse
+	46,  // 116: bridge.v1.BridgeService.AttachTerminal:output_type -> bridge.v1.AttachTerminalResponse
+	48,  // 117: bridge.v1.BridgeService.ResizeSession:output_type -> bridge.v1.ResizeSessionResponse
+	50,  // 118: bridge.v1.BridgeService.CancelResponse:output_type -> bridge.v1.CancelResponseResponse
+	52,  // 119: bridge.v1.BridgeService.ResolveApproval:output_type -> bridge.v1.ResolveApprovalResponse
+	70,  // 120: bridge.v1.BridgeService.ClaimWriter:output_type -> bridge.v1.ClaimWriterResponse
+	72,  // 121: bridge.v1.BridgeService.ReleaseWriter:output_type -> bridge.v1.ReleaseWriterResponse
+	74,  // 122: bridge.v1.BridgeService.TakeControl:output_type -> bridge.v1.TakeControlResponse
+	76,  // 123: bridge.v1.BridgeService.MintSessionToken:output_type -> bridge.v1.MintSessionTokenResponse
+	54,  // 124: bridge.v1.BridgeService.ReadFile:output_type -> bridge.v1.ReadFileResponse
+	56,  // 125: bridge.v1.BridgeService.WriteFile:output_type -> bridge.v1.WriteFileResponse
+	59,  // 126: bridge.v1.BridgeService.ListDir:output_type -> bridge.v1.ListDirResponse
+	62,  // 127: bridge.v1.BridgeService.GitStatus:output_type -> bridge.v1.GitStatusResponse
+	64,  // 128: bridge.v1.BridgeService.GitDiff:output_type -> bridge.v1.GitDiffResponse
+	66,  // 129: bridge.v1.BridgeService.GitCommit:output_type -> bridge.v1.GitCommitResponse
+	68,  // 130: bridge.v1.BridgeService.RollbackWorkspace:output_type -> bridge.v1.RollbackWorkspaceResponse
+	78,  // 131: bridge.v1.BridgeService.Health:output_type -> bridge.v1.HealthResponse
+	78,  // 132: bridge.v1.BridgeService.HealthWatch:output_type -> bridge.v1.HealthResponse
+	82,  // 133: bridge.v1.BridgeService.ListProviders:output_type -> bridge.v1.ListProvidersResponse
+	86,  // 134: bridge.v1.AdminService.StopSession:output_type -> bridge.v1.AdminStopSessionResponse
+	88,  // 135: bridge.v1.AdminService.Drain:output_type -> bridge.v1.DrainResponse
+	90,  // 136: bridge.v1.AdminService.ReloadConfig:output_type -> bridge.v1.ReloadConfigResponse
+	92,  // 137: bridge.v1.AdminService.GetMetrics:output_type -> bridge.v1.GetMetricsResponse
+	96,  // 138: bridge.v1.AdminService.RevokeToken:output_type -> bridge.v1.RevokeTokenResponse
+	98,  // 139: bridge.v1.AdminService.RegisterProvider:output_type -> bridge.v1.RegisterProviderResponse
+	100, // 140: bridge.v1.AdminService.UnregisterProvider:output_type -> bridge.v1.UnregisterProviderResponse
+	102, // 141: bridge.v1.AdminService.SetLogLevel:output_type -> bridge.v1.SetLogLevelResponse
+	104, // 142: bridge.v1.AdminService.PurgeSession:output_type -> bridge.v1.PurgeSessionResponse
+	102, // [102:143] is the sub-list for method output_type
+	61,  // [61:102] is the sub-list for method input_type
+	61,  // [61:61] is the sub-list for extension type_name
+	61,  // [61:61] is the sub-list for extension extendee
+	0,   // [0:61] is the sub-list for field type_name
 }
 
 func init() { file_bridge_v1_bridge_proto_init() }
@@ -2006,15 +7961,25 @@ func file_bridge_v1_bridge_proto_init() {
 	if File_bridge_v1_bridge_proto != nil {
 		return
 	}
+	file_bridge_v1_bridge_proto_msgTypes[35].OneofWrappers = []any{
+		(*AttachTerminalRequest_Open)(nil),
+		(*AttachTerminalRequest_Input)(nil),
+		(*AttachTerminalRequest_Resize)(nil),
+	}
+	file_bridge_v1_bridge_proto_msgTypes[39].OneofWrappers = []any{
+		(*AttachTerminalResponse_Attached)(nil),
+		(*AttachTerminalResponse_Output)(nil),
+		(*AttachTerminalResponse_Exit)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bridge_v1_bridge_proto_rawDesc), len(file_bridge_v1_bridge_proto_rawDesc)),
-			NumEnums:      3,
-			NumMessages:   25,
+			NumEnums:      7,
+			NumMessages:   107,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_bridge_v1_bridge_proto_goTypes,
 		DependencyIndexes: file_bridge_v1_bridge_proto_depIdxs,
@@ -19,17 +19,38 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	BridgeService_StartSession_FullMethodName  = "/bridge.v1.BridgeService/StartSession"
-	BridgeService_StopSession_FullMethodName   = "/bridge.v1.BridgeService/StopSession"
-	BridgeService_GetSession_FullMethodName    = "/bridge.v1.BridgeService/GetSession"
-	BridgeService_ListSessions_FullMethodName  = "/bridge.v1.BridgeService/ListSessions"
-	BridgeService_AttachSession_FullMethodName = "/bridge.v1.BridgeService/AttachSession"
-	BridgeService_WriteInput_FullMethodName    = "/bridge.v1.BridgeService/WriteInput"
-	BridgeService_ResizeSession_FullMethodName = "/bridge.v1.BridgeService/ResizeSession"
-	BridgeService_ClaimWriter_FullMethodName   = "/bridge.v1.BridgeService/ClaimWriter"
-	BridgeService_ReleaseWriter_FullMethodName = "/bridge.v1.BridgeService/ReleaseWriter"
-	BridgeService_Health_FullMethodName        = "/bridge.v1.BridgeService/Health"
-	BridgeService_ListProviders_FullMethodName = "/bridge.v1.BridgeService/ListProviders"
+	BridgeService_StartSession_FullMethodName       = "/bridge.v1.BridgeService/StartSession"
+	BridgeService_StopSession_FullMethodName        = "/bridge.v1.BridgeService/StopSession"
+	BridgeService_GetSession_FullMethodName         = "/bridge.v1.BridgeService/GetSession"
+	BridgeService_ListSessions_FullMethodName       = "/bridge.v1.BridgeService/ListSessions"
+	BridgeService_GetSessionHistory_FullMethodName  = "/bridge.v1.BridgeService/GetSessionHistory"
+	BridgeService_ExportTranscript_FullMethodName   = "/bridge.v1.BridgeService/ExportTranscript"
+	BridgeService_GetConversation_FullMethodName    = "/bridge.v1.BridgeService/GetConversation"
+	BridgeService_GetResponse_FullMethodName        = "/bridge.v1.BridgeService/GetResponse"
+	BridgeService_ExportSessionState_FullMethodName = "/bridge.v1.BridgeService/ExportSessionState"
+	BridgeService_ImportSessionState_FullMethodName = "/bridge.v1.BridgeService/ImportSessionState"
+	BridgeService_AttachSession_FullMethodName      = "/bridge.v1.BridgeService/AttachSession"
+	BridgeService_WriteInput_FullMethodName         = "/bridge.v1.BridgeService/WriteInput"
+	BridgeService_SendInputStream_FullMethodName    = "/bridge.v1.BridgeService/SendInputStream"
+	BridgeService_BroadcastInput_FullMethodName     = "/bridge.v1.BridgeService/BroadcastInput"
+	BridgeService_AttachTerminal_FullMethodName     = "/bridge.v1.BridgeService/AttachTerminal"
+	BridgeService_ResizeSession_FullMethodName      = "/bridge.v1.BridgeService/ResizeSession"
+	BridgeService_CancelResponse_FullMethodName     = "/bridge.v1.BridgeService/CancelResponse"
+	BridgeService_ResolveApproval_FullMethodName    = "/bridge.v1.BridgeService/ResolveApproval"
+	BridgeService_ClaimWriter_FullMethodName        = "/bridge.v1.BridgeService/ClaimWriter"
+	BridgeService_ReleaseWriter_FullMethodName      = "/bridge.v1.BridgeService/ReleaseWriter"
+	BridgeService_TakeControl_FullMethodName        = "/bridge.v1.BridgeService/TakeControl"
+	BridgeService_MintSessionToken_FullMethodName   = "/bridge.v1.BridgeService/MintSessionToken"
+	BridgeService_ReadFile_FullMethodName           = "/bridge.v1.BridgeService/ReadFile"
+	BridgeService_WriteFile_FullMethodName          = "/bridge.v1.BridgeService/WriteFile"
+	BridgeService_ListDir_FullMethodName            = "/bridge.v1.BridgeService/ListDir"
+	BridgeService_GitStatus_FullMethodName          = "/bridge.v1.BridgeService/GitStatus"
+	BridgeService_GitDiff_FullMethodName            = "/bridge.v1.BridgeService/GitDiff"
+	BridgeService_GitCommit_FullMethodName          = "/bridge.v1.BridgeService/GitCommit"
+	BridgeService_RollbackWorkspace_FullMethodName  = "/bridge.v1.BridgeService/RollbackWorkspace"
+	BridgeService_Health_FullMethodName             = "/bridge.v1.BridgeService/Health"
+	BridgeService_HealthWatch_FullMethodName        = "/bridge.v1.BridgeService/HealthWatch"
+	BridgeService_ListProviders_FullMethodName      = "/bridge.v1.BridgeService/ListProviders"
 )
 
 // BridgeServiceClient is the client API for BridgeService service.
@@ -40,9 +61,48 @@ type BridgeServiceClient interface {
 	StopSession(ctx context.Context, in *StopSessionRequest, opts ...grpc.CallOption) (*StopSessionResponse, error)
 	GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*GetSessionResponse, error)
 	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	// GetSessionHistory returns the transcript of a session, including sessions
+	// that have been archived and pruned from memory.
+	GetSessionHistory(ctx context.Context, in *GetSessionHistoryRequest, opts ...grpc.CallOption) (*GetSessionHistoryResponse, error)
+	// ExportTranscript renders a session's prompts and output as a document
+	// suitable for attaching to pull requests or incident notes.
+	ExportTranscript(ctx context.Context, in *ExportTranscriptRequest, opts ...grpc.CallOption) (*ExportTranscriptResponse, error)
+	// GetConversation returns the sessions linked by a conversation_id and
+	// their transcripts, combined in the order the sessions started.
+	GetConversation(ctx context.Context, in *GetConversationRequest, opts ...grpc.CallOption) (*GetConversationResponse, error)
+	// GetResponse returns the agent's reply to one input, assembled from the
+	// session's retained output.
+	GetResponse(ctx context.Context, in *GetResponseRequest, opts ...grpc.CallOption) (*GetResponseResponse, error)
+	// ExportSessionState and ImportSessionState move a session between bridge
+	// instances (e.g. during a rolling upgrade). The agent process is restarted
+	// on the importing instance; buffered output and seq numbering carry over.
+	ExportSessionState(ctx context.Context, in *ExportSessionStateRequest, opts ...grpc.CallOption) (*ExportSessionStateResponse, error)
+	ImportSessionState(ctx context.Context, in *ImportSessionStateRequest, opts ...grpc.CallOption) (*ImportSessionStateResponse, error)
 	AttachSession(ctx context.Context, in *AttachSessionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AttachSessionEvent], error)
 	WriteInput(ctx context.Context, in *WriteInputRequest, opts ...grpc.CallOption) (*WriteInputResponse, error)
+	// SendInputStream sends one input too large for WriteInput, such as a
+	// long diff or log, in chunks. The server reassembles the chunks and
+	// delivers them to the agent as a single input once the client closes
+	// the stream. The total is capped by input.max_stream_bytes.
+	SendInputStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SendInputStreamRequest, WriteInputResponse], error)
+	// BroadcastInput sends the same input to several sessions at once, e.g.
+	// to compare providers on one repo, and returns each session's result.
+	// Sessions are written to concurrently; one failing does not stop the
+	// others.
+	BroadcastInput(ctx context.Context, in *BroadcastInputRequest, opts ...grpc.CallOption) (*BroadcastInputResponse, error)
+	// AttachTerminal connects a terminal emulator such as xterm.js to a PTY
+	// session. The server streams raw output bytes; the client streams
+	// keystrokes and resizes. The first request must be `open`. The terminal
+	// is one more attached client, so it shares the session with
+	// AttachSession subscribers and the writer slot rules apply.
+	AttachTerminal(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AttachTerminalRequest, AttachTerminalResponse], error)
 	ResizeSession(ctx context.Context, in *ResizeSessionRequest, opts ...grpc.CallOption) (*ResizeSessionResponse, error)
+	// CancelResponse interrupts the agent's in-flight response without
+	// stopping the session, like pressing Ctrl-C in its terminal.
+	CancelResponse(ctx context.Context, in *CancelResponseRequest, opts ...grpc.CallOption) (*CancelResponseResponse, error)
+	// ResolveApproval allows or denies a tool call the agent is waiting on
+	// (ATTACH_EVENT_TYPE_APPROVAL_REQUESTED).
+	ResolveApproval(ctx context.Context, in *ResolveApprovalRequest, opts ...grpc.CallOption) (*ResolveApprovalResponse, error)
 	// ClaimWriter promotes the caller from OBSERVER to WRITER, taking the active
 	// writer slot. Returns ErrWriterConflict (ALREADY_EXISTS) when another client
 	// already holds the slot.
@@ -50,7 +110,34 @@ type BridgeServiceClient interface {
 	// ReleaseWriter demotes the caller from WRITER to OBSERVER, freeing the slot
 	// so another client can claim it.
 	ReleaseWriter(ctx context.Context, in *ReleaseWriterRequest, opts ...grpc.CallOption) (*ReleaseWriterResponse, error)
+	// TakeControl makes the caller the session's controller: it takes the
+	// writer slot like a forced ClaimWriter and holds it, so that forced
+	// ClaimWriter calls fail until it releases the slot or detaches. For a
+	// human taking over from a bot-driven client.
+	TakeControl(ctx context.Context, in *TakeControlRequest, opts ...grpc.CallOption) (*TakeControlResponse, error)
+	// MintSessionToken returns a short-lived token that can only observe one
+	// session, for sharing a live session without project-wide credentials.
+	MintSessionToken(ctx context.Context, in *MintSessionTokenRequest, opts ...grpc.CallOption) (*MintSessionTokenResponse, error)
+	// ReadFile, WriteFile and ListDir operate on paths relative to the
+	// session's repo_path. Paths may not leave the repo, including through
+	// symlinks, and file contents are capped by the daemon's files policy.
+	ReadFile(ctx context.Context, in *ReadFileRequest, opts ...grpc.CallOption) (*ReadFileResponse, error)
+	WriteFile(ctx context.Context, in *WriteFileRequest, opts ...grpc.CallOption) (*WriteFileResponse, error)
+	ListDir(ctx context.Context, in *ListDirRequest, opts ...grpc.CallOption) (*ListDirResponse, error)
+	// GitStatus, GitDiff and GitCommit run git in the session's repo_path,
+	// which must be inside a git work tree. Commits use the daemon's
+	// configured author identity and skip repo hooks.
+	GitStatus(ctx context.Context, in *GitStatusRequest, opts ...grpc.CallOption) (*GitStatusResponse, error)
+	GitDiff(ctx context.Context, in *GitDiffRequest, opts ...grpc.CallOption) (*GitDiffResponse, error)
+	GitCommit(ctx context.Context, in *GitCommitRequest, opts ...grpc.CallOption) (*GitCommitResponse, error)
+	// RollbackWorkspace restores a stopped session's repo to the snapshot
+	// taken when it started with snapshot set.
+	RollbackWorkspace(ctx context.Context, in *RollbackWorkspaceRequest, opts ...grpc.CallOption) (*RollbackWorkspaceResponse, error)
+	// Health and HealthWatch need no credentials, so orchestrators can probe
+	// the daemon. HealthWatch sends the current health, then a new response
+	// whenever it changes.
 	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	HealthWatch(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HealthResponse], error)
 	ListProviders(ctx context.Context, in *ListProvidersRequest, opts ...grpc.CallOption) (*ListProvidersResponse, error)
 }
 
@@ -102,6 +189,66 @@ func (c *bridgeServiceClient) ListSessions(ctx context.Context, in *ListSessions
 	return out, nil
 }
 
+func (c *bridgeServiceClient) GetSessionHistory(ctx context.Context, in *GetSessionHistoryRequest, opts ...grpc.CallOption) (*GetSessionHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSessionHistoryResponse)
+	err := c.cc.Invoke(ctx, BridgeService_GetSessionHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) ExportTranscript(ctx context.Context, in *ExportTranscriptRequest, opts ...grpc.CallOption) (*ExportTranscriptResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportTranscriptResponse)
+	err := c.cc.Invoke(ctx, BridgeService_ExportTranscript_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) GetConversation(ctx context.Context, in *GetConversationRequest, opts ...grpc.CallOption) (*GetConversationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetConversationResponse)
+	err := c.cc.Invoke(ctx, BridgeService_GetConversation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) GetResponse(ctx context.Context, in *GetResponseRequest, opts ...grpc.CallOption) (*GetResponseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetResponseResponse)
+	err := c.cc.Invoke(ctx, BridgeService_GetResponse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) ExportSessionState(ctx context.Context, in *ExportSessionStateRequest, opts ...grpc.CallOption) (*ExportSessionStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportSessionStateResponse)
+	err := c.cc.Invoke(ctx, BridgeService_ExportSessionState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) ImportSessionState(ctx context.Context, in *ImportSessionStateRequest, opts ...grpc.CallOption) (*ImportSessionStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportSessionStateResponse)
+	err := c.cc.Invoke(ctx, BridgeService_ImportSessionState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *bridgeServiceClient) AttachSession(ctx context.Context, in *AttachSessionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AttachSessionEvent], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &BridgeService_ServiceDesc.Streams[0], BridgeService_AttachSession_FullMethodName, cOpts...)
@@ -131,6 +278,42 @@ func (c *bridgeServiceClient) WriteInput(ctx context.Context, in *WriteInputRequ
 	return out, nil
 }
 
+func (c *bridgeServiceClient) SendInputStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SendInputStreamRequest, WriteInputResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BridgeService_ServiceDesc.Streams[1], BridgeService_SendInputStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SendInputStreamRequest, WriteInputResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BridgeService_SendInputStreamClient = grpc.ClientStreamingClient[SendInputStreamRequest, WriteInputResponse]
+
+func (c *bridgeServiceClient) BroadcastInput(ctx context.Context, in *BroadcastInputRequest, opts ...grpc.CallOption) (*BroadcastInputResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BroadcastInputResponse)
+	err := c.cc.Invoke(ctx, BridgeService_BroadcastInput_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) AttachTerminal(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AttachTerminalRequest, AttachTerminalResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BridgeService_ServiceDesc.Streams[2], BridgeService_AttachTerminal_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AttachTerminalRequest, AttachTerminalResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BridgeService_AttachTerminalClient = grpc.BidiStreamingClient[AttachTerminalRequest, AttachTerminalResponse]
+
 func (c *bridgeServiceClient) ResizeSession(ctx context.Context, in *ResizeSessionRequest, opts ...grpc.CallOption) (*ResizeSessionResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ResizeSessionResponse)
@@ -141,6 +324,26 @@ func (c *bridgeServiceClient) ResizeSession(ctx context.Context, in *ResizeSessi
 	return out, nil
 }
 
+func (c *bridgeServiceClient) CancelResponse(ctx context.Context, in *CancelResponseRequest, opts ...grpc.CallOption) (*CancelResponseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelResponseResponse)
+	err := c.cc.Invoke(ctx, BridgeService_CancelResponse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) ResolveApproval(ctx context.Context, in *ResolveApprovalRequest, opts ...grpc.CallOption) (*ResolveApprovalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResolveApprovalResponse)
+	err := c.cc.Invoke(ctx, BridgeService_ResolveApproval_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *bridgeServiceClient) ClaimWriter(ctx context.Context, in *ClaimWriterRequest, opts ...grpc.CallOption) (*ClaimWriterResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ClaimWriterResponse)
@@ -161,6 +364,96 @@ func (c *bridgeServiceClient) ReleaseWriter(ctx context.Context, in *ReleaseWrit
 	return out, nil
 }
 
+func (c *bridgeServiceClient) TakeControl(ctx context.Context, in *TakeControlRequest, opts ...grpc.CallOption) (*TakeControlResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TakeControlResponse)
+	err := c.cc.Invoke(ctx, BridgeService_TakeControl_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) MintSessionToken(ctx context.Context, in *MintSessionTokenRequest, opts ...grpc.CallOption) (*MintSessionTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MintSessionTokenResponse)
+	err := c.cc.Invoke(ctx, BridgeService_MintSessionToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) ReadFile(ctx context.Context, in *ReadFileRequest, opts ...grpc.CallOption) (*ReadFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReadFileResponse)
+	err := c.cc.Invoke(ctx, BridgeService_ReadFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) WriteFile(ctx context.Context, in *WriteFileRequest, opts ...grpc.CallOption) (*WriteFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteFileResponse)
+	err := c.cc.Invoke(ctx, BridgeService_WriteFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) ListDir(ctx context.Context, in *ListDirRequest, opts ...grpc.CallOption) (*ListDirResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDirResponse)
+	err := c.cc.Invoke(ctx, BridgeService_ListDir_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) GitStatus(ctx context.Context, in *GitStatusRequest, opts ...grpc.CallOption) (*GitStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GitStatusResponse)
+	err := c.cc.Invoke(ctx, BridgeService_GitStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) GitDiff(ctx context.Context, in *GitDiffRequest, opts ...grpc.CallOption) (*GitDiffResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GitDiffResponse)
+	err := c.cc.Invoke(ctx, BridgeService_GitDiff_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) GitCommit(ctx context.Context, in *GitCommitRequest, opts ...grpc.CallOption) (*GitCommitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GitCommitResponse)
+	err := c.cc.Invoke(ctx, BridgeService_GitCommit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bridgeServiceClient) RollbackWorkspace(ctx context.Context, in *RollbackWorkspaceRequest, opts ...grpc.CallOption) (*RollbackWorkspaceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RollbackWorkspaceResponse)
+	err := c.cc.Invoke(ctx, BridgeService_RollbackWorkspace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *bridgeServiceClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(HealthResponse)
@@ -171,6 +464,25 @@ func (c *bridgeServiceClient) Health(ctx context.Context, in *HealthRequest, opt
 	return out, nil
 }
 
+func (c *bridgeServiceClient) HealthWatch(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HealthResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BridgeService_ServiceDesc.Streams[3], BridgeService_HealthWatch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[HealthRequest, HealthResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BridgeService_HealthWatchClient = grpc.ServerStreamingClient[HealthResponse]
+
 func (c *bridgeServiceClient) ListProviders(ctx context.Context, in *ListProvidersRequest, opts ...grpc.CallOption) (*ListProvidersResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListProvidersResponse)
@@ -189,9 +501,48 @@ type BridgeServiceServer interface {
 	StopSession(context.Context, *StopSessionRequest) (*StopSessionResponse, error)
 	GetSession(context.Context, *GetSessionRequest) (*GetSessionResponse, error)
 	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	// GetSessionHistory returns the transcript of a session, including sessions
+	// that have been archived and pruned from memory.
+	GetSessionHistory(context.Context, *GetSessionHistoryRequest) (*GetSessionHistoryResponse, error)
+	// ExportTranscript renders a session's prompts and output as a document
+	// suitable for attaching to pull requests or incident notes.
+	ExportTranscript(context.Context, *ExportTranscriptRequest) (*ExportTranscriptResponse, error)
+	// GetConversation returns the sessions linked by a conversation_id and
+	// their transcripts, combined in the order the sessions started.
+	GetConversation(context.Context, *GetConversationRequest) (*GetConversationResponse, error)
+	// GetResponse returns the agent's reply to one input, assembled from the
+	// session's retained output.
+	GetResponse(context.Context, *GetResponseRequest) (*GetResponseResponse, error)
+	// ExportSessionState and ImportSessionState move a session between bridge
+	// instances (e.g. during a rolling upgrade). The agent process is restarted
+	// on the importing instance; buffered output and seq numbering carry over.
+	ExportSessionState(context.Context, *ExportSessionStateRequest) (*ExportSessionStateResponse, error)
+	ImportSessionState(context.Context, *ImportSessionStateRequest) (*ImportSessionStateResponse, error)
 	AttachSession(*AttachSessionRequest, grpc.ServerStreamingServer[AttachSessionEvent]) error
 	WriteInput(context.Context, *WriteInputRequest) (*WriteInputResponse, error)
+	// SendInputStream sends one input too large for WriteInput, such as a
+	// long diff or log, in chunks. The server reassembles the chunks and
+	// delivers them to the agent as a single input once the client closes
+	// the stream. The total is capped by input.max_stream_bytes.
+	SendInputStream(grpc.ClientStreamingServer[SendInputStreamRequest, WriteInputResponse]) error
+	// BroadcastInput sends the same input to several sessions at once, e.g.
+	// to compare providers on one repo, and returns each session's result.
+	// Sessions are written to concurrently; one failing does not stop the
+	// others.
+	BroadcastInput(context.Context, *BroadcastInputRequest) (*BroadcastInputResponse, error)
+	// AttachTerminal connects a terminal emulator such as xterm.js to a PTY
+	// session. The server streams raw output bytes; the client streams
+	// keystrokes and resizes. The first request must be `open`. The terminal
+	// is one more attached client, so it shares the session with
+	// AttachSession subscribers and the writer slot rules apply.
+	AttachTerminal(grpc.BidiStreamingServer[AttachTerminalRequest, AttachTerminalResponse]) error
 	ResizeSession(context.Context, *ResizeSessionRequest) (*ResizeSessionResponse, error)
+	// CancelResponse interrupts the agent's in-flight response without
+	// stopping the session, like pressing Ctrl-C in its terminal.
+	CancelResponse(context.Context, *CancelResponseRequest) (*CancelResponseResponse, error)
+	// ResolveApproval allows or denies a tool call the agent is waiting on
+	// (ATTACH_EVENT_TYPE_APPROVAL_REQUESTED).
+	ResolveApproval(context.Context, *ResolveApprovalRequest) (*ResolveApprovalResponse, error)
 	// ClaimWriter promotes the caller from OBSERVER to WRITER, taking the active
 	// writer slot. Returns ErrWriterConflict (ALREADY_EXISTS) when another client
 	// already holds the slot.
@@ -199,7 +550,34 @@ type BridgeServiceServer interface {
 	// ReleaseWriter demotes the caller from WRITER to OBSERVER, freeing the slot
 	// so another client can claim it.
 	ReleaseWriter(context.Context, *ReleaseWriterRequest) (*ReleaseWriterResponse, error)
+	// TakeControl makes the caller the session's controller: it takes the
+	// writer slot like a forced ClaimWriter and holds it, so that forced
+	// ClaimWriter calls fail until it releases the slot or detaches. For a
+	// human taking over from a bot-driven client.
+	TakeControl(context.Context, *TakeControlRequest) (*TakeControlResponse, error)
+	// MintSessionToken returns a short-lived token that can only observe one
+	// session, for sharing a live session without project-wide credentials.
+	MintSessionToken(context.Context, *MintSessionTokenRequest) (*MintSessionTokenResponse, error)
+	// ReadFile, WriteFile and ListDir operate on paths relative to the
+	// session's repo_path. Paths may not leave the repo, including through
+	// symlinks, and file contents are capped by the daemon's files policy.
+	ReadFile(context.Context, *ReadFileRequest) (*ReadFileResponse, error)
+	WriteFile(context.Context, *WriteFileRequest) (*WriteFileResponse, error)
+	ListDir(context.Context, *ListDirRequest) (*ListDirResponse, error)
+	// GitStatus, GitDiff and GitCommit run git in the session's repo_path,
+	// which must be inside a git work tree. Commits use the daemon's
+	// configured author identity and skip repo hooks.
+	GitStatus(context.Context, *GitStatusRequest) (*GitStatusResponse, error)
+	GitDiff(context.Context, *GitDiffRequest) (*GitDiffResponse, error)
+	GitCommit(context.Context, *GitCommitRequest) (*GitCommitResponse, error)
+	// RollbackWorkspace restores a stopped session's repo to the snapshot
+	// taken when it started with snapshot set.
+	RollbackWorkspace(context.Context, *RollbackWorkspaceRequest) (*RollbackWorkspaceResponse, error)
+	// Health and HealthWatch need no credentials, so orchestrators can probe
+	// the daemon. HealthWatch sends the current health, then a new response
+	// whenever it changes.
 	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	HealthWatch(*HealthRequest, grpc.ServerStreamingServer[HealthResponse]) error
 	ListProviders(context.Context, *ListProvidersRequest) (*ListProvidersResponse, error)
 	mustEmbedUnimplementedBridgeServiceServer()
 }
@@ -223,24 +601,87 @@ func (UnimplementedBridgeServiceServer) GetSession(context.Context, *GetSessionR
 func (UnimplementedBridgeServiceServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListSessions not implemented")
 }
+func (UnimplementedBridgeServiceServer) GetSessionHistory(context.Context, *GetSessionHistoryRequest) (*GetSessionHistoryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSessionHistory not implemented")
+}
+func (UnimplementedBridgeServiceServer) ExportTranscript(context.Context, *ExportTranscriptRequest) (*ExportTranscriptResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExportTranscript not implemented")
+}
+func (UnimplementedBridgeServiceServer) GetConversation(context.Context, *GetConversationRequest) (*GetConversationResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetConversation not implemented")
+}
+func (UnimplementedBridgeServiceServer) GetResponse(context.Context, *GetResponseRequest) (*GetResponseResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetResponse not implemented")
+}
+func (UnimplementedBridgeServiceServer) ExportSessionState(context.Context, *ExportSessionStateRequest) (*ExportSessionStateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExportSessionState not implemented")
+}
+func (UnimplementedBridgeServiceServer) ImportSessionState(context.Context, *ImportSessionStateRequest) (*ImportSessionStateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ImportSessionState not implemented")
+}
 func (UnimplementedBridgeServiceServer) AttachSession(*AttachSessionRequest, grpc.ServerStreamingServer[AttachSessionEvent]) error {
 	return status.Error(codes.Unimplemented, "method AttachSession not implemented")
 }
 func (UnimplementedBridgeServiceServer) WriteInput(context.Context, *WriteInputRequest) (*WriteInputResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method WriteInput not implemented")
 }
+func (UnimplementedBridgeServiceServer) SendInputStream(grpc.ClientStreamingServer[SendInputStreamRequest, WriteInputResponse]) error {
+	return status.Error(codes.Unimplemented, "method SendInputStream not implemented")
+}
+func (UnimplementedBridgeServiceServer) BroadcastInput(context.Context, *BroadcastInputRequest) (*BroadcastInputResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method BroadcastInput not implemented")
+}
+func (UnimplementedBridgeServiceServer) AttachTerminal(grpc.BidiStreamingServer[AttachTerminalRequest, AttachTerminalResponse]) error {
+	return status.Error(codes.Unimplemented, "method AttachTerminal not implemented")
+}
 func (UnimplementedBridgeServiceServer) ResizeSession(context.Context, *ResizeSessionRequest) (*ResizeSessionResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ResizeSession not implemented")
 }
+func (UnimplementedBridgeServiceServer) CancelResponse(context.Context, *CancelResponseRequest) (*CancelResponseResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CancelResponse not implemented")
+}
+func (UnimplementedBridgeServiceServer) ResolveApproval(context.Context, *ResolveApprovalRequest) (*ResolveApprovalResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ResolveApproval not implemented")
+}
 func (UnimplementedBridgeServiceServer) ClaimWriter(context.Context, *ClaimWriterRequest) (*ClaimWriterResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ClaimWriter not implemented")
 }
 func (UnimplementedBridgeServiceServer) ReleaseWriter(context.Context, *ReleaseWriterRequest) (*ReleaseWriterResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ReleaseWriter not implemented")
 }
+func (UnimplementedBridgeServiceServer) TakeControl(context.Context, *TakeControlRequest) (*TakeControlResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method TakeControl not implemented")
+}
+func (UnimplementedBridgeServiceServer) MintSessionToken(context.Context, *MintSessionTokenRequest) (*MintSessionTokenResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method MintSessionToken not implemented")
+}
+func (UnimplementedBridgeServiceServer) ReadFile(context.Context, *ReadFileRequest) (*ReadFileResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReadFile not implemented")
+}
+func (UnimplementedBridgeServiceServer) WriteFile(context.Context, *WriteFileRequest) (*WriteFileResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method WriteFile not implemented")
+}
+func (UnimplementedBridgeServiceServer) ListDir(context.Context, *ListDirRequest) (*ListDirResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListDir not implemented")
+}
+func (UnimplementedBridgeServiceServer) GitStatus(context.Context, *GitStatusRequest) (*GitStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GitStatus not implemented")
+}
+func (UnimplementedBridgeServiceServer) GitDiff(context.Context, *GitDiffRequest) (*GitDiffResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GitDiff not implemented")
+}
+func (UnimplementedBridgeServiceServer) GitCommit(context.Context, *GitCommitRequest) (*GitCommitResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GitCommit not implemented")
+}
+func (UnimplementedBridgeServiceServer) RollbackWorkspace(context.Context, *RollbackWorkspaceRequest) (*RollbackWorkspaceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RollbackWorkspace not implemented")
+}
 func (UnimplementedBridgeServiceServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Health not implemented")
 }
+func (UnimplementedBridgeServiceServer) HealthWatch(*HealthRequest, grpc.ServerStreamingServer[HealthResponse]) error {
+	return status.Error(codes.Unimplemented, "method HealthWatch not implemented")
+}
 func (UnimplementedBridgeServiceServer) ListProviders(context.Context, *ListProvidersRequest) (*ListProvidersResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListProviders not implemented")
 }
@@ -337,179 +778,1064 @@ func _BridgeService_ListSessions_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BridgeService_AttachSession_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(AttachSessionRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func _BridgeService_GetSessionHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSessionHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	return srv.(BridgeServiceServer).AttachSession(m, &grpc.GenericServerStream[AttachSessionRequest, AttachSessionEvent]{ServerStream: stream})
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).GetSessionHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_GetSessionHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).GetSessionHistory(ctx, req.(*GetSessionHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type BridgeService_AttachSessionServer = grpc.ServerStreamingServer[AttachSessionEvent]
-
-func _BridgeService_WriteInput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(WriteInputRequest)
+func _BridgeService_ExportTranscript_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportTranscriptRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BridgeServiceServer).WriteInput(ctx, in)
+		return srv.(BridgeServiceServer).ExportTranscript(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: BridgeService_WriteInput_FullMethodName,
+		FullMethod: BridgeService_ExportTranscript_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BridgeServiceServer).WriteInput(ctx, req.(*WriteInputRequest))
+		return srv.(BridgeServiceServer).ExportTranscript(ctx, req.(*ExportTranscriptRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BridgeService_ResizeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ResizeSessionRequest)
+func _BridgeService_GetConversation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConversationRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BridgeServiceServer).ResizeSession(ctx, in)
+		return srv.(BridgeServiceServer).GetConversation(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: BridgeService_ResizeSession_FullMethodName,
+		FullMethod: BridgeService_GetConversation_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BridgeServiceServer).ResizeSession(ctx, req.(*ResizeSessionRequest))
+		return srv.(BridgeServiceServer).GetConversation(ctx, req.(*GetConversationRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BridgeService_ClaimWriter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ClaimWriterRequest)
+func _BridgeService_GetResponse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResponseRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BridgeServiceServer).ClaimWriter(ctx, in)
+		return srv.(BridgeServiceServer).GetResponse(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: BridgeService_ClaimWriter_FullMethodName,
+		FullMethod: BridgeService_GetResponse_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BridgeServiceServer).ClaimWriter(ctx, req.(*ClaimWriterRequest))
+		return srv.(BridgeServiceServer).GetResponse(ctx, req.(*GetResponseRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BridgeService_ReleaseWriter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ReleaseWriterRequest)
+func _BridgeService_ExportSessionState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportSessionStateRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BridgeServiceServer).ReleaseWriter(ctx, in)
+		return srv.(BridgeServiceServer).ExportSessionState(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: BridgeService_ReleaseWriter_FullMethodName,
+		FullMethod: BridgeService_ExportSessionState_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BridgeServiceServer).ReleaseWriter(ctx, req.(*ReleaseWriterRequest))
+		return srv.(BridgeServiceServer).ExportSessionState(ctx, req.(*ExportSessionStateRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BridgeService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(HealthRequest)
+func _BridgeService_ImportSessionState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportSessionStateRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BridgeServiceServer).Health(ctx, in)
+		return srv.(BridgeServiceServer).ImportSessionState(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: BridgeService_Health_FullMethodName,
+		FullMethod: BridgeService_ImportSessionState_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BridgeServiceServer).Health(ctx, req.(*HealthRequest))
+		return srv.(BridgeServiceServer).ImportSessionState(ctx, req.(*ImportSessionStateRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BridgeService_ListProviders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ListProvidersRequest)
+func _BridgeService_AttachSession_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AttachSessionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BridgeServiceServer).AttachSession(m, &grpc.GenericServerStream[AttachSessionRequest, AttachSessionEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BridgeService_AttachSessionServer = grpc.ServerStreamingServer[AttachSessionEvent]
+
+func _BridgeService_WriteInput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteInputRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BridgeServiceServer).ListProviders(ctx, in)
+		return srv.(BridgeServiceServer).WriteInput(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: BridgeService_ListProviders_FullMethodName,
+		FullMethod: BridgeService_WriteInput_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BridgeServiceServer).ListProviders(ctx, req.(*ListProvidersRequest))
+		return srv.(BridgeServiceServer).WriteInput(ctx, req.(*WriteInputRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-// BridgeService_ServiceDesc is the grpc.ServiceDesc for BridgeService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var BridgeService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "bridge.v1.BridgeService",
-	HandlerType: (*BridgeServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "StartSession",
-			Handler:    _BridgeService_StartSession_Handler,
-		},
-		{
+func _BridgeService_SendInputStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BridgeServiceServer).SendInputStream(&grpc.GenericServerStream[SendInputStreamRequest, WriteInputResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BridgeService_SendInputStreamServer = grpc.ClientStreamingServer[SendInputStreamRequest, WriteInputResponse]
+
+func _BridgeService_BroadcastInput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BroadcastInputRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).BroadcastInput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_BroadcastInput_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).BroadcastInput(ctx, req.(*BroadcastInputRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_AttachTerminal_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BridgeServiceServer).AttachTerminal(&grpc.GenericServerStream[AttachTerminalRequest, AttachTerminalResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BridgeService_AttachTerminalServer = grpc.BidiStreamingServer[AttachTerminalRequest, AttachTerminalResponse]
+
+func _BridgeService_ResizeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResizeSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).ResizeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_ResizeSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).ResizeSession(ctx, req.(*ResizeSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_CancelResponse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelResponseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).CancelResponse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_CancelResponse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).CancelResponse(ctx, req.(*CancelResponseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_ResolveApproval_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveApprovalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).ResolveApproval(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_ResolveApproval_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).ResolveApproval(ctx, req.(*ResolveApprovalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_ClaimWriter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClaimWriterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).ClaimWriter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_ClaimWriter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).ClaimWriter(ctx, req.(*ClaimWriterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_ReleaseWriter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseWriterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).ReleaseWriter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_ReleaseWriter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).ReleaseWriter(ctx, req.(*ReleaseWriterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_TakeControl_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TakeControlRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).TakeControl(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_TakeControl_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).TakeControl(ctx, req.(*TakeControlRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_MintSessionToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MintSessionTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).MintSessionToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_MintSessionToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).MintSessionToken(ctx, req.(*MintSessionTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_ReadFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).ReadFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_ReadFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).ReadFile(ctx, req.(*ReadFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_WriteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).WriteFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_WriteFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).WriteFile(ctx, req.(*WriteFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_ListDir_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDirRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).ListDir(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_ListDir_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).ListDir(ctx, req.(*ListDirRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_GitStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GitStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).GitStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_GitStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).GitStatus(ctx, req.(*GitStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_GitDiff_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GitDiffRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).GitDiff(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_GitDiff_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).GitDiff(ctx, req.(*GitDiffRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_GitCommit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GitCommitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).GitCommit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_GitCommit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).GitCommit(ctx, req.(*GitCommitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_RollbackWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackWorkspaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).RollbackWorkspace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_RollbackWorkspace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).RollbackWorkspace(ctx, req.(*RollbackWorkspaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BridgeService_HealthWatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HealthRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BridgeServiceServer).HealthWatch(m, &grpc.GenericServerStream[HealthRequest, HealthResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BridgeService_HealthWatchServer = grpc.ServerStreamingServer[HealthResponse]
+
+func _BridgeService_ListProviders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProvidersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BridgeServiceServer).ListProviders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BridgeService_ListProviders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BridgeServiceServer).ListProviders(ctx, req.(*ListProvidersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BridgeService_ServiceDesc is the grpc.ServiceDesc for BridgeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BridgeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bridge.v1.BridgeService",
+	HandlerType: (*BridgeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartSession",
+			Handler:    _BridgeService_StartSession_Handler,
+		},
+		{
 			MethodName: "StopSession",
 			Handler:    _BridgeService_StopSession_Handler,
 		},
 		{
-			MethodName: "GetSession",
-			Handler:    _BridgeService_GetSession_Handler,
+			MethodName: "GetSession",
+			Handler:    _BridgeService_GetSession_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _BridgeService_ListSessions_Handler,
+		},
+		{
+			MethodName: "GetSessionHistory",
+			Handler:    _BridgeService_GetSessionHistory_Handler,
+		},
+		{
+			MethodName: "ExportTranscript",
+			Handler:    _BridgeService_ExportTranscript_Handler,
+		},
+		{
+			MethodName: "GetConversation",
+			Handler:    _BridgeService_GetConversation_Handler,
+		},
+		{
+			MethodName: "GetResponse",
+			Handler:    _BridgeService_GetResponse_Handler,
+		},
+		{
+			MethodName: "ExportSessionState",
+			Handler:    _BridgeService_ExportSessionState_Handler,
+		},
+		{
+			MethodName: "ImportSessionState",
+			Handler:    _BridgeService_ImportSessionState_Handler,
+		},
+		{
+			MethodName: "WriteInput",
+			Handler:    _BridgeService_WriteInput_Handler,
+		},
+		{
+			MethodName: "BroadcastInput",
+			Handler:    _BridgeService_BroadcastInput_Handler,
+		},
+		{
+			MethodName: "ResizeSession",
+			Handler:    _BridgeService_ResizeSession_Handler,
+		},
+		{
+			MethodName: "CancelResponse",
+			Handler:    _BridgeService_CancelResponse_Handler,
+		},
+		{
+			MethodName: "ResolveApproval",
+			Handler:    _BridgeService_ResolveApproval_Handler,
+		},
+		{
+			MethodName: "ClaimWriter",
+			Handler:    _BridgeService_ClaimWriter_Handler,
+		},
+		{
+			MethodName: "ReleaseWriter",
+			Handler:    _BridgeService_ReleaseWriter_Handler,
+		},
+		{
+			MethodName: "TakeControl",
+			Handler:    _BridgeService_TakeControl_Handler,
+		},
+		{
+			MethodName: "MintSessionToken",
+			Handler:    _BridgeService_MintSessionToken_Handler,
+		},
+		{
+			MethodName: "ReadFile",
+			Handler:    _BridgeService_ReadFile_Handler,
+		},
+		{
+			MethodName: "WriteFile",
+			Handler:    _BridgeService_WriteFile_Handler,
+		},
+		{
+			MethodName: "ListDir",
+			Handler:    _BridgeService_ListDir_Handler,
+		},
+		{
+			MethodName: "GitStatus",
+			Handler:    _BridgeService_GitStatus_Handler,
+		},
+		{
+			MethodName: "GitDiff",
+			Handler:    _BridgeService_GitDiff_Handler,
+		},
+		{
+			MethodName: "GitCommit",
+			Handler:    _BridgeService_GitCommit_Handler,
+		},
+		{
+			MethodName: "RollbackWorkspace",
+			Handler:    _BridgeService_RollbackWorkspace_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _BridgeService_Health_Handler,
+		},
+		{
+			MethodName: "ListProviders",
+			Handler:    _BridgeService_ListProviders_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AttachSession",
+			Handler:       _BridgeService_AttachSession_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SendInputStream",
+			Handler:       _BridgeService_SendInputStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "AttachTerminal",
+			Handler:       _BridgeService_AttachTerminal_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "HealthWatch",
+			Handler:       _BridgeService_HealthWatch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bridge/v1/bridge.proto",
+}
+
+const (
+	AdminService_StopSession_FullMethodName        = "/bridge.v1.AdminService/StopSession"
+	AdminService_Drain_FullMethodName              = "/bridge.v1.AdminService/Drain"
+	AdminService_ReloadConfig_FullMethodName       = "/bridge.v1.AdminService/ReloadConfig"
+	AdminService_GetMetrics_FullMethodName         = "/bridge.v1.AdminService/GetMetrics"
+	AdminService_RevokeToken_FullMethodName        = "/bridge.v1.AdminService/RevokeToken"
+	AdminService_RegisterProvider_FullMethodName   = "/bridge.v1.AdminService/RegisterProvider"
+	AdminService_UnregisterProvider_FullMethodName = "/bridge.v1.AdminService/UnregisterProvider"
+	AdminService_SetLogLevel_FullMethodName        = "/bridge.v1.AdminService/SetLogLevel"
+	AdminService_PurgeSession_FullMethodName       = "/bridge.v1.AdminService/PurgeSession"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AdminService holds operator actions. It is served on the same listener
+// as BridgeService but every RPC requires an admin caller: a token with the
+// "admin" scope, or a client certificate whose OU is auth.admin_cert_ou.
+// A token without a scopes claim is not an admin token, so project tokens
+// can never call it.
+type AdminServiceClient interface {
+	// StopSession stops any session, whatever its project.
+	StopSession(ctx context.Context, in *AdminStopSessionRequest, opts ...grpc.CallOption) (*AdminStopSessionResponse, error)
+	// Drain marks the daemon not ready and refuses new sessions until it
+	// restarts. Running sessions and streams keep working.
+	Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*DrainResponse, error)
+	// ReloadConfig re-reads the config file, as SIGHUP does.
+	ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error)
+	// GetMetrics returns a snapshot of the daemon's counters.
+	GetMetrics(ctx context.Context, in *GetMetricsRequest, opts ...grpc.CallOption) (*GetMetricsResponse, error)
+	// RevokeToken rejects tokens by ID or subject from now on.
+	RevokeToken(ctx context.Context, in *RevokeTokenRequest, opts ...grpc.CallOption) (*RevokeTokenResponse, error)
+	// RegisterProvider adds a provider definition at runtime. It is kept in
+	// the state dir and registered again after a restart.
+	RegisterProvider(ctx context.Context, in *RegisterProviderRequest, opts ...grpc.CallOption) (*RegisterProviderResponse, error)
+	// UnregisterProvider removes a provider added with RegisterProvider.
+	UnregisterProvider(ctx context.Context, in *UnregisterProviderRequest, opts ...grpc.CallOption) (*UnregisterProviderResponse, error)
+	// SetLogLevel changes the daemon's log level, or one component's, until
+	// the next restart or config reload.
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
+	// PurgeSession deletes everything the daemon keeps about a stopped
+	// session: its output, transcript, persisted record, provider logs and
+	// workspace. It serves deletion requests; running sessions must be
+	// stopped first.
+	PurgeSession(ctx context.Context, in *PurgeSessionRequest, opts ...grpc.CallOption) (*PurgeSessionResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) StopSession(ctx context.Context, in *AdminStopSessionRequest, opts ...grpc.CallOption) (*AdminStopSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminStopSessionResponse)
+	err := c.cc.Invoke(ctx, AdminService_StopSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*DrainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DrainResponse)
+	err := c.cc.Invoke(ctx, AdminService_Drain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReloadConfigResponse)
+	err := c.cc.Invoke(ctx, AdminService_ReloadConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) GetMetrics(ctx context.Context, in *GetMetricsRequest, opts ...grpc.CallOption) (*GetMetricsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMetricsResponse)
+	err := c.cc.Invoke(ctx, AdminService_GetMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RevokeToken(ctx context.Context, in *RevokeTokenRequest, opts ...grpc.CallOption) (*RevokeTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeTokenResponse)
+	err := c.cc.Invoke(ctx, AdminService_RevokeToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RegisterProvider(ctx context.Context, in *RegisterProviderRequest, opts ...grpc.CallOption) (*RegisterProviderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterProviderResponse)
+	err := c.cc.Invoke(ctx, AdminService_RegisterProvider_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) UnregisterProvider(ctx context.Context, in *UnregisterProviderRequest, opts ...grpc.CallOption) (*UnregisterProviderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnregisterProviderResponse)
+	err := c.cc.Invoke(ctx, AdminService_UnregisterProvider_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetLogLevelResponse)
+	err := c.cc.Invoke(ctx, AdminService_SetLogLevel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) PurgeSession(ctx context.Context, in *PurgeSessionRequest, opts ...grpc.CallOption) (*PurgeSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeSessionResponse)
+	err := c.cc.Invoke(ctx, AdminService_PurgeSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+//
+// AdminService holds operator actions. It is served on the same listener
+// as BridgeService but every RPC requires an admin caller: a token with the
+// "admin" scope, or a client certificate whose OU is auth.admin_cert_ou.
+// A token without a scopes claim is not an admin token, so project tokens
+// can never call it.
+type AdminServiceServer interface {
+	// StopSession stops any session, whatever its project.
+	StopSession(context.Context, *AdminStopSessionRequest) (*AdminStopSessionResponse, error)
+	// Drain marks the daemon not ready and refuses new sessions until it
+	// restarts. Running sessions and streams keep working.
+	Drain(context.Context, *DrainRequest) (*DrainResponse, error)
+	// ReloadConfig re-reads the config file, as SIGHUP does.
+	ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error)
+	// GetMetrics returns a snapshot of the daemon's counters.
+	GetMetrics(context.Context, *GetMetricsRequest) (*GetMetricsResponse, error)
+	// RevokeToken rejects tokens by ID or subject from now on.
+	RevokeToken(context.Context, *RevokeTokenRequest) (*RevokeTokenResponse, error)
+	// RegisterProvider adds a provider definition at runtime. It is kept in
+	// the state dir and registered again after a restart.
+	RegisterProvider(context.Context, *RegisterProviderRequest) (*RegisterProviderResponse, error)
+	// UnregisterProvider removes a provider added with RegisterProvider.
+	UnregisterProvider(context.Context, *UnregisterProviderRequest) (*UnregisterProviderResponse, error)
+	// SetLogLevel changes the daemon's log level, or one component's, until
+	// the next restart or config reload.
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
+	// PurgeSession deletes everything the daemon keeps about a stopped
+	// session: its output, transcript, persisted record, provider logs and
+	// workspace. It serves deletion requests; running sessions must be
+	// stopped first.
+	PurgeSession(context.Context, *PurgeSessionRequest) (*PurgeSessionResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) StopSession(context.Context, *AdminStopSessionRequest) (*AdminStopSessionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method StopSession not implemented")
+}
+func (UnimplementedAdminServiceServer) Drain(context.Context, *DrainRequest) (*DrainResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Drain not implemented")
+}
+func (UnimplementedAdminServiceServer) ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReloadConfig not implemented")
+}
+func (UnimplementedAdminServiceServer) GetMetrics(context.Context, *GetMetricsRequest) (*GetMetricsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetMetrics not implemented")
+}
+func (UnimplementedAdminServiceServer) RevokeToken(context.Context, *RevokeTokenRequest) (*RevokeTokenResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RevokeToken not implemented")
+}
+func (UnimplementedAdminServiceServer) RegisterProvider(context.Context, *RegisterProviderRequest) (*RegisterProviderResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RegisterProvider not implemented")
+}
+func (UnimplementedAdminServiceServer) UnregisterProvider(context.Context, *UnregisterProviderRequest) (*UnregisterProviderResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method UnregisterProvider not implemented")
+}
+func (UnimplementedAdminServiceServer) SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetLogLevel not implemented")
+}
+func (UnimplementedAdminServiceServer) PurgeSession(context.Context, *PurgeSessionRequest) (*PurgeSessionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PurgeSession not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call panics, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_StopSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminStopSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).StopSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_StopSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).StopSession(ctx, req.(*AdminStopSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_Drain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).Drain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_Drain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).Drain(ctx, req.(*DrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ReloadConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ReloadConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ReloadConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ReloadConfig(ctx, req.(*ReloadConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_GetMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMetricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetMetrics(ctx, req.(*GetMetricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RevokeToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RevokeToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RevokeToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RevokeToken(ctx, req.(*RevokeTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RegisterProvider_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterProviderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RegisterProvider(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RegisterProvider_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RegisterProvider(ctx, req.(*RegisterProviderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_UnregisterProvider_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnregisterProviderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).UnregisterProvider(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_UnregisterProvider_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).UnregisterProvider(ctx, req.(*UnregisterProviderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetLogLevel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_PurgeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).PurgeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_PurgeSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).PurgeSession(ctx, req.(*PurgeSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bridge.v1.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StopSession",
+			Handler:    _AdminService_StopSession_Handler,
 		},
 		{
-			MethodName: "ListSessions",
-			Handler:    _BridgeService_ListSessions_Handler,
+			MethodName: "Drain",
+			Handler:    _AdminService_Drain_Handler,
 		},
 		{
-			MethodName: "WriteInput",
-			Handler:    _BridgeService_WriteInput_Handler,
+			MethodName: "ReloadConfig",
+			Handler:    _AdminService_ReloadConfig_Handler,
 		},
 		{
-			MethodName: "ResizeSession",
-			Handler:    _BridgeService_ResizeSession_Handler,
+			MethodName: "GetMetrics",
+			Handler:    _AdminService_GetMetrics_Handler,
 		},
 		{
-			MethodName: "ClaimWriter",
-			Handler:    _BridgeService_ClaimWriter_Handler,
+			MethodName: "RevokeToken",
+			Handler:    _AdminService_RevokeToken_Handler,
 		},
 		{
-			MethodName: "ReleaseWriter",
-			Handler:    _BridgeService_ReleaseWriter_Handler,
+			MethodName: "RegisterProvider",
+			Handler:    _AdminService_RegisterProvider_Handler,
 		},
 		{
-			MethodName: "Health",
-			Handler:    _BridgeService_Health_Handler,
+			MethodName: "UnregisterProvider",
+			Handler:    _AdminService_UnregisterProvider_Handler,
 		},
 		{
-			MethodName: "ListProviders",
-			Handler:    _BridgeService_ListProviders_Handler,
+			MethodName: "SetLogLevel",
+			Handler:    _AdminService_SetLogLevel_Handler,
 		},
-	},
-	Streams: []grpc.StreamDesc{
 		{
-			StreamName:    "AttachSession",
-			Handler:       _BridgeService_AttachSession_Handler,
-			ServerStreams: true,
+			MethodName: "PurgeSession",
+			Handler:    _AdminService_PurgeSession_Handler,
 		},
 	},
+	Streams:  []grpc.StreamDesc{},
 	Metadata: "bridge/v1/bridge.proto",
 }
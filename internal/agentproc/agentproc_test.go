@@ -0,0 +1,114 @@
+package agentproc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// The test binary runs itself as the agent when this variable is set.
+const helperEnv = "AGENTPROC_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		fmt.Println("agent ready")
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func helperCommand(t *testing.T) *exec.Cmd {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Executable: %v", err)
+	}
+	cmd := exec.Command(exe, "-test.run=^$")
+	cmd.Env = append(os.Environ(), helperEnv+"=1")
+	return cmd
+}
+
+// waitExit waits for cmd to exit, failing the test if it does not.
+func waitExit(t *testing.T, cmd *exec.Cmd) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("agent still running")
+	}
+	ReleaseProcessGroup(cmd.Process.Pid)
+	if Alive(cmd.Process.Pid) {
+		t.Fatal("Alive after exit")
+	}
+}
+
+func TestStartTerminal(t *testing.T) {
+	cmd := helperCommand(t)
+	term, err := StartTerminal(cmd, 80, 24)
+	if err != nil {
+		t.Fatalf("StartTerminal: %v", err)
+	}
+	defer func() { _ = term.Close() }()
+
+	// The output carries terminal control sequences; look for the text.
+	found := make(chan error, 1)
+	go func() {
+		var seen bytes.Buffer
+		buf := make([]byte, 1024)
+		for {
+			n, err := term.Read(buf)
+			seen.Write(buf[:n])
+			if bytes.Contains(seen.Bytes(), []byte("agent ready")) {
+				found <- nil
+				return
+			}
+			if err != nil {
+				found <- fmt.Errorf("read: %v; output %q", err, seen.Bytes())
+				return
+			}
+		}
+	}()
+	select {
+	case err := <-found:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no output from agent")
+	}
+
+	if err := term.Resize(100, 30); err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	if !Alive(cmd.Process.Pid) {
+		t.Fatal("agent not alive")
+	}
+	if err := KillProcessGroup(cmd.Process.Pid); err != nil {
+		t.Fatalf("KillProcessGroup: %v", err)
+	}
+	waitExit(t, cmd)
+}
+
+func TestKillProcessGroup(t *testing.T) {
+	cmd := helperCommand(t)
+	SetProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := TrackProcessGroup(cmd); err != nil {
+		t.Fatalf("TrackProcessGroup: %v", err)
+	}
+	if err := KillProcessGroup(cmd.Process.Pid); err != nil {
+		t.Fatalf("KillProcessGroup: %v", err)
+	}
+	waitExit(t, cmd)
+}
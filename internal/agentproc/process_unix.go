@@ -0,0 +1,53 @@
+//go:build !windows
+
+package agentproc
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// SetProcessGroup makes cmd the leader of a new process group, so that
+// signalling the group reaches the agent and every process it starts. PTY
+// agents get one from their terminal's session.
+func SetProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// TrackProcessGroup is called once cmd has started. Process groups need no
+// tracking on Unix.
+func TrackProcessGroup(*exec.Cmd) error { return nil }
+
+// ReleaseProcessGroup is called once the agent led by pid has exited.
+func ReleaseProcessGroup(int) {}
+
+// TerminateProcessGroup asks the agent led by pid, and the processes it
+// started, to exit.
+func TerminateProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGTERM)
+}
+
+// KillProcessGroup kills the agent led by pid and the processes it
+// started.
+func KillProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGKILL)
+}
+
+// Interrupt sends p the equivalent of Ctrl-C.
+func Interrupt(p *os.Process) error {
+	return p.Signal(os.Interrupt)
+}
+
+// Alive reports whether the process pid exists.
+func Alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
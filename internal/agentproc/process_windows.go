@@ -0,0 +1,163 @@
+//go:build windows
+
+package agentproc
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code GetExitCodeProcess reports for a running
+// process.
+const stillActive = 259
+
+// Windows has no process groups that can be signalled. Each agent instead
+// runs in a job object, which kills every process in it when the bridge
+// kills the agent or closes the job. The job of each agent is kept by its
+// process ID, with its pseudoconsole for PTY agents.
+var processGroups struct {
+	sync.Mutex
+	m map[int]*processGroup
+}
+
+type processGroup struct {
+	job     windows.Handle
+	console *conPTY // PTY agents only
+}
+
+// SetProcessGroup starts cmd in a new console process group, so that it
+// can be sent CTRL_BREAK_EVENT without the bridge receiving it too.
+func SetProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &windows.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.CREATE_NEW_PROCESS_GROUP
+}
+
+// newJob returns a job object that kills its processes when it is closed.
+func newJob() (windows.Handle, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		_ = windows.CloseHandle(job)
+		return 0, err
+	}
+	return job, nil
+}
+
+// TrackProcessGroup puts the agent cmd started into a job object of its
+// own. Processes it started before then are not in the job; PTY agents,
+// which are started suspended, have none.
+func TrackProcessGroup(cmd *exec.Cmd) error {
+	pid := cmd.Process.Pid
+	processGroups.Lock()
+	_, ok := processGroups.m[pid]
+	processGroups.Unlock()
+	if ok {
+		return nil
+	}
+	job, err := newJob()
+	if err != nil {
+		return err
+	}
+	h, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		_ = windows.CloseHandle(job)
+		return err
+	}
+	defer windows.CloseHandle(h)
+	if err := windows.AssignProcessToJobObject(job, h); err != nil {
+		_ = windows.CloseHandle(job)
+		return err
+	}
+	addProcessGroup(pid, &processGroup{job: job})
+	return nil
+}
+
+func addProcessGroup(pid int, g *processGroup) {
+	processGroups.Lock()
+	defer processGroups.Unlock()
+	if processGroups.m == nil {
+		processGroups.m = make(map[int]*processGroup)
+	}
+	processGroups.m[pid] = g
+}
+
+func lookupProcessGroup(pid int) *processGroup {
+	processGroups.Lock()
+	defer processGroups.Unlock()
+	return processGroups.m[pid]
+}
+
+// ReleaseProcessGroup is called once the agent pid has exited. Closing its
+// job kills the processes it left running.
+func ReleaseProcessGroup(pid int) {
+	processGroups.Lock()
+	g := processGroups.m[pid]
+	delete(processGroups.m, pid)
+	processGroups.Unlock()
+	if g != nil {
+		_ = windows.CloseHandle(g.job)
+	}
+}
+
+// TerminateProcessGroup asks the agent pid, and the processes it started,
+// to exit: a PTY agent's pseudoconsole is closed, which sends its
+// processes CTRL_CLOSE_EVENT, and other agents are sent CTRL_BREAK_EVENT.
+func TerminateProcessGroup(pid int) error {
+	if g := lookupProcessGroup(pid); g != nil && g.console != nil {
+		go g.console.closeConsole()
+		return nil
+	}
+	return windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(pid))
+}
+
+// KillProcessGroup kills the agent pid and the processes it started.
+// Agents the bridge did not start, such as those recovered after a
+// restart, have no job and only the agent itself is killed.
+func KillProcessGroup(pid int) error {
+	if g := lookupProcessGroup(pid); g != nil {
+		return windows.TerminateJobObject(g.job, 1)
+	}
+	h, err := windows.OpenProcess(windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(h)
+	return windows.TerminateProcess(h, 1)
+}
+
+// Interrupt sends p CTRL_BREAK_EVENT, the nearest to Ctrl-C that
+// Windows delivers to another process group.
+func Interrupt(p *os.Process) error {
+	return windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(p.Pid))
+}
+
+// Alive reports whether the process pid is running.
+func Alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	defer windows.CloseHandle(h)
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
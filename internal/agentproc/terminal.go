@@ -0,0 +1,14 @@
+// Package agentproc starts agent processes on a terminal and stops them
+// with the processes they start, on Unix and Windows. On Unix an agent
+// leads a process group and runs on a PTY; on Windows it runs in a job
+// object and on a pseudoconsole (ConPTY).
+package agentproc
+
+import "io"
+
+// Terminal is the controlling side of an agent's terminal. What the agent
+// prints is read from it and keystrokes are written to it.
+type Terminal interface {
+	io.ReadWriteCloser
+	Resize(cols, rows uint16) error
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package agentproc
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/creack/pty"
+)
+
+// ptyTerminal is the master side of a Unix PTY.
+type ptyTerminal struct {
+	*os.File
+}
+
+func (t ptyTerminal) Resize(cols, rows uint16) error {
+	return pty.Setsize(t.File, &pty.Winsize{Cols: cols, Rows: rows})
+}
+
+// StartTerminal starts cmd on a new PTY of the given size, as the leader of
+// a new session and process group.
+func StartTerminal(cmd *exec.Cmd, cols, rows uint16) (Terminal, error) {
+	f, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: cols, Rows: rows})
+	if err != nil {
+		return nil, err
+	}
+	return ptyTerminal{f}, nil
+}
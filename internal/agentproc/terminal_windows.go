@@ -0,0 +1,193 @@
+//go:build windows
+
+package agentproc
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// conPTY is a Windows pseudoconsole (ConPTY). The agent's console reads
+// keystrokes from one pipe and writes what it displays, as VT sequences,
+// to another.
+type conPTY struct {
+	console     windows.Handle
+	in          *os.File // write end of the console's input
+	out         *os.File // read end of the console's output
+	consoleOnce sync.Once
+	closeOnce   sync.Once
+}
+
+func (c *conPTY) Read(p []byte) (int, error)  { return c.out.Read(p) }
+func (c *conPTY) Write(p []byte) (int, error) { return c.in.Write(p) }
+
+func (c *conPTY) Resize(cols, rows uint16) error {
+	return windows.ResizePseudoConsole(c.console, windows.Coord{X: int16(cols), Y: int16(rows)})
+}
+
+// closeConsole closes the pseudoconsole. Its processes are sent
+// CTRL_CLOSE_EVENT, and its output pipe reports EOF once what it still
+// holds has been read.
+func (c *conPTY) closeConsole() {
+	c.consoleOnce.Do(func() { windows.ClosePseudoConsole(c.console) })
+}
+
+func (c *conPTY) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		_ = c.in.Close()
+		// ClosePseudoConsole blocks until its output has been read.
+		go func() { _, _ = io.Copy(io.Discard, c.out) }()
+		c.closeConsole()
+		err = c.out.Close()
+	})
+	return err
+}
+
+// StartTerminal starts cmd attached to a new pseudoconsole of the given
+// size, in a job object of its own. os/exec cannot attach a process to a
+// pseudoconsole, so the process is created directly and cmd.Process set to
+// it; cmd.Wait then works as usual, but cmd's context does not kill it.
+func StartTerminal(cmd *exec.Cmd, cols, rows uint16) (Terminal, error) {
+	if cmd.Err != nil {
+		return nil, cmd.Err
+	}
+	if cmd.Process != nil {
+		return nil, errors.New("exec: already started")
+	}
+	var inR, inW, outR, outW windows.Handle
+	if err := windows.CreatePipe(&inR, &inW, nil, 0); err != nil {
+		return nil, err
+	}
+	if err := windows.CreatePipe(&outR, &outW, nil, 0); err != nil {
+		_ = windows.CloseHandle(inR)
+		_ = windows.CloseHandle(inW)
+		return nil, err
+	}
+	var console windows.Handle
+	err := windows.CreatePseudoConsole(windows.Coord{X: int16(cols), Y: int16(rows)}, inR, outW, 0, &console)
+	// The console keeps its own copies of its ends of the pipes.
+	_ = windows.CloseHandle(inR)
+	_ = windows.CloseHandle(outW)
+	if err != nil {
+		_ = windows.CloseHandle(inW)
+		_ = windows.CloseHandle(outR)
+		return nil, err
+	}
+	c := &conPTY{console: console, in: os.NewFile(uintptr(inW), "conpty-in"), out: os.NewFile(uintptr(outR), "conpty-out")}
+
+	pi, job, err := startConsoleProcess(cmd, console)
+	if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	p, err := os.FindProcess(int(pi.ProcessId))
+	if err != nil {
+		_ = windows.TerminateJobObject(job, 1)
+		_ = windows.CloseHandle(job)
+		_ = windows.CloseHandle(pi.Process)
+		_ = c.Close()
+		return nil, err
+	}
+	cmd.Process = p
+	addProcessGroup(p.Pid, &processGroup{job: job, console: c})
+	// The console's output pipe stays open while the console does, even
+	// after the agent exits, so it is closed then to end the read loop.
+	go func() {
+		_, _ = windows.WaitForSingleObject(pi.Process, windows.INFINITE)
+		_ = windows.CloseHandle(pi.Process)
+		c.closeConsole()
+	}()
+	return c, nil
+}
+
+// startConsoleProcess creates the process cmd describes attached to
+// console and assigns it to a new job before it runs. The caller owns the
+// returned process and job handles.
+func startConsoleProcess(cmd *exec.Cmd, console windows.Handle) (windows.ProcessInformation, windows.Handle, error) {
+	var pi windows.ProcessInformation
+	attrs, err := windows.NewProcThreadAttributeList(1)
+	if err != nil {
+		return pi, 0, err
+	}
+	defer attrs.Delete()
+	// The attribute's value is the console handle itself, not a pointer
+	// to it.
+	if err := attrs.Update(windows.PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE, *(*unsafe.Pointer)(unsafe.Pointer(&console)), unsafe.Sizeof(console)); err != nil {
+		return pi, 0, err
+	}
+	si := windows.StartupInfoEx{ProcThreadAttributeList: attrs.List()}
+	si.Cb = uint32(unsafe.Sizeof(si))
+	// No standard handles, so the agent does not inherit the bridge's: the
+	// console is its terminal.
+	si.Flags = windows.STARTF_USESTDHANDLES
+
+	path, err := windows.UTF16PtrFromString(cmd.Path)
+	if err != nil {
+		return pi, 0, err
+	}
+	line := windows.ComposeCommandLine(cmd.Args)
+	if cmd.SysProcAttr != nil && cmd.SysProcAttr.CmdLine != "" {
+		line = cmd.SysProcAttr.CmdLine
+	}
+	cmdLine, err := windows.UTF16PtrFromString(line)
+	if err != nil {
+		return pi, 0, err
+	}
+	var dir *uint16
+	if cmd.Dir != "" {
+		if dir, err = windows.UTF16PtrFromString(cmd.Dir); err != nil {
+			return pi, 0, err
+		}
+	}
+	env, err := environmentBlock(cmd.Environ())
+	if err != nil {
+		return pi, 0, err
+	}
+	job, err := newJob()
+	if err != nil {
+		return pi, 0, err
+	}
+	flags := uint32(windows.EXTENDED_STARTUPINFO_PRESENT | windows.CREATE_UNICODE_ENVIRONMENT | windows.CREATE_SUSPENDED)
+	if err := windows.CreateProcess(path, cmdLine, nil, nil, false, flags, env, dir, &si.StartupInfo, &pi); err != nil {
+		_ = windows.CloseHandle(job)
+		return pi, 0, err
+	}
+	defer windows.CloseHandle(pi.Thread)
+	if err := windows.AssignProcessToJobObject(job, pi.Process); err != nil {
+		_ = windows.TerminateProcess(pi.Process, 1)
+		_ = windows.CloseHandle(pi.Process)
+		_ = windows.CloseHandle(job)
+		return pi, 0, err
+	}
+	if _, err := windows.ResumeThread(pi.Thread); err != nil {
+		_ = windows.TerminateJobObject(job, 1)
+		_ = windows.CloseHandle(pi.Process)
+		_ = windows.CloseHandle(job)
+		return pi, 0, err
+	}
+	return pi, job, nil
+}
+
+// environmentBlock encodes env as a Unicode environment block.
+func environmentBlock(env []string) (*uint16, error) {
+	var block []uint16
+	for _, kv := range env {
+		u, err := windows.UTF16FromString(kv)
+		if err != nil {
+			return nil, err
+		}
+		block = append(block, u...)
+	}
+	if len(block) == 0 {
+		block = append(block, 0)
+	}
+	block = append(block, 0)
+	return &block[0], nil
+}
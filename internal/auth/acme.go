@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"crypto/tls"
+	"slices"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEConfig configures server certificates obtained from an ACME CA such
+// as Let's Encrypt. Client certificates are still verified against the
+// bridge-ca trust bundle.
+type ACMEConfig struct {
+	// Domains are the DNS names certificates are requested for. Handshakes
+	// for any other server name are refused.
+	Domains []string
+	// Email is the optional contact address registered with the CA.
+	Email string
+	// DirectoryURL is the ACME directory. Empty uses Let's Encrypt.
+	DirectoryURL string
+	// CacheDir stores the ACME account key and issued certificates so they
+	// survive restarts.
+	CacheDir string
+}
+
+// NewACMEManager returns a manager that obtains certificates for
+// cfg.Domains on first use and renews them in the background before they
+// expire. Its HTTPHandler serves HTTP-01 challenges; TLS-ALPN-01
+// challenges are answered by CertReloader.TLSConfig.
+func NewACMEManager(cfg ACMEConfig) *autocert.Manager {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		Email:      cfg.Email,
+	}
+	if cfg.DirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	}
+	return m
+}
+
+// isACMEChallenge reports whether hello is a TLS-ALPN-01 validation
+// request from the ACME CA.
+func isACMEChallenge(hello *tls.ClientHelloInfo) bool {
+	return slices.Contains(hello.SupportedProtos, acme.ALPNProto)
+}
+
+// acmeChallengeConfig answers a TLS-ALPN-01 validation. The CA presents no
+// client certificate and the connection carries no gRPC traffic, so client
+// auth is not required.
+func acmeChallengeConfig(m *autocert.Manager) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: m.GetCertificate,
+		NextProtos:     []string{acme.ALPNProto},
+	}
+}
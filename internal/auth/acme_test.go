@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+	"golang.org/x/crypto/acme"
+)
+
+func TestCertReloaderACME(t *testing.T) {
+	dir := t.TempDir()
+	caCertPath, caKeyPath, err := pki.InitCA("test-ca", dir)
+	if err != nil {
+		t.Fatalf("InitCA: %v", err)
+	}
+	caCert, caKey := mustLoadCA(t, caCertPath, caKeyPath), mustLoadCAKey(t, caCertPath, caKeyPath)
+	clientCert, clientKey, err := pki.IssueCert(caCert, caKey, pki.CertTypeClient, "client", nil, dir)
+	if err != nil {
+		t.Fatalf("Issue client cert: %v", err)
+	}
+	bundle := filepath.Join(dir, "bundle.crt")
+	if err := pki.BuildBundle(bundle, caCertPath); err != nil {
+		t.Fatalf("BuildBundle: %v", err)
+	}
+
+	// Seed the autocert cache as if the CA had already issued a
+	// certificate, so no ACME server is contacted. autocert picks ECDSA
+	// only from TLS 1.2 cipher suites, so TLS 1.3-only clients are served
+	// the RSA certificate, cached under "<domain>+rsa".
+	const domain = "bridge.example.test"
+	serverKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, caCert, &serverKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cacheDir := filepath.Join(dir, "acme")
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	entry := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(serverKey)})
+	entry = append(entry, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	if err := os.WriteFile(filepath.Join(cacheDir, domain+"+rsa"), entry, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	r, err := NewCertReloader(TLSConfig{
+		CABundlePath: bundle,
+		ACME:         NewACMEManager(ACMEConfig{Domains: []string{domain}, CacheDir: cacheDir}),
+	}, slogDiscardLogger())
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+
+	withCert, err := ClientTLSConfig(TLSConfig{CABundlePath: bundle, CertPath: clientCert, KeyPath: clientKey, ServerName: domain})
+	if err != nil {
+		t.Fatalf("ClientTLSConfig: %v", err)
+	}
+	if err := handshake(t, r.TLSConfig(), withCert); err != nil {
+		t.Fatalf("handshake with ACME certificate: %v", err)
+	}
+
+	// Client certificates are still required.
+	noCert := withCert.Clone()
+	noCert.Certificates = nil
+	if err := handshake(t, r.TLSConfig(), noCert); err == nil {
+		t.Fatal("handshake without a client certificate succeeded")
+	}
+
+	// TLS-ALPN-01 validations get a challenge-only config without mTLS.
+	cfg, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{SupportedProtos: []string{acme.ALPNProto}})
+	if err != nil {
+		t.Fatalf("GetConfigForClient: %v", err)
+	}
+	if cfg.ClientAuth != tls.NoClientCert || len(cfg.NextProtos) != 1 || cfg.NextProtos[0] != acme.ALPNProto {
+		t.Fatalf("challenge config = %+v, want acme-tls/1 only without client auth", cfg)
+	}
+}
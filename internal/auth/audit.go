@@ -5,12 +5,14 @@ import (
 	"log/slog"
 	"reflect"
 
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-// UnaryAuditInterceptor logs RPC outcomes with caller and request scope metadata.
+// UnaryAuditInterceptor logs RPC outcomes with caller, connection and
+// request scope metadata.
 func UnaryAuditInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 		resp, err := handler(ctx, req)
@@ -29,11 +31,16 @@ func UnaryAuditInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
 			"rpc_method", info.FullMethod,
 			"project_id", projectID,
 			"session_id", sessionID,
-			"caller_cn", callerCommonName(ctx),
+			logging.RequestIDKey, logging.RequestID(ctx),
 		}
+		fields = append(fields, connectionFields(ctx)...)
 		if claims != nil {
 			fields = append(fields, "caller_sub", claims.Subject)
 		}
+		// File RPCs name the repo path they touched.
+		if path := requestStringField(req, "Path"); path != "" {
+			fields = append(fields, "path", path)
+		}
 		if err != nil {
 			st, _ := status.FromError(err)
 			fields = append(fields, "result", "error", "code", st.Code().String(), "reason", st.Message())
@@ -46,7 +53,8 @@ func UnaryAuditInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
 	}
 }
 
-// StreamAuditInterceptor logs stream RPC outcomes with caller metadata.
+// StreamAuditInterceptor logs stream RPC outcomes with caller and
+// connection metadata.
 func StreamAuditInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
 	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		err := handler(srv, ss)
@@ -55,7 +63,8 @@ func StreamAuditInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
 			return err
 		}
 		claims, _ := ClaimsFromContext(ss.Context())
-		fields := []any{"rpc_method", info.FullMethod, "caller_cn", callerCommonName(ss.Context())}
+		fields := []any{"rpc_method", info.FullMethod, logging.RequestIDKey, logging.RequestID(ss.Context())}
+		fields = append(fields, connectionFields(ss.Context())...)
 		if claims != nil {
 			fields = append(fields, "caller_sub", claims.Subject, "project_id", claims.ProjectID)
 		}
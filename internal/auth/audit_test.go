@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestAuditConnectionFields(t *testing.T) {
+	spiffeID, _ := url.Parse("spiffe://example.org/ci")
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(4711),
+		Subject:      pkix.Name{CommonName: "orchestrator-1", OrganizationalUnit: []string{"runners"}},
+		Issuer:       pkix.Name{CommonName: "bridge-ca"},
+		URIs:         []*url.URL{spiffeID},
+	}
+	ctx := peer.NewContext(authContextWithClaims(context.Background(), "project-a", "user-a"), &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.IPv4(10, 0, 0, 7), Port: 51234},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}},
+	})
+	want := map[string]any{
+		"caller_sub":    "user-a",
+		"caller_cn":     "orchestrator-1",
+		"caller_serial": "4711",
+		"caller_issuer": "bridge-ca",
+		"caller_ou":     []any{"runners"},
+		"caller_uris":   []any{"spiffe://example.org/ci"},
+		"remote_addr":   "10.0.0.7:51234",
+	}
+	check := func(t *testing.T, buf *bytes.Buffer) {
+		t.Helper()
+		var rec map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+			t.Fatalf("audit record %q: %v", buf.String(), err)
+		}
+		for k, v := range want {
+			got, _ := json.Marshal(rec[k])
+			exp, _ := json.Marshal(v)
+			if !bytes.Equal(got, exp) {
+				t.Errorf("%s = %s want %s", k, got, exp)
+			}
+		}
+	}
+
+	t.Run("unary", func(t *testing.T) {
+		var buf bytes.Buffer
+		unary := UnaryAuditInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)))
+		if _, err := unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/bridge.v1.BridgeService/ListSessions"}, func(context.Context, any) (any, error) {
+			return "ok", nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		check(t, &buf)
+	})
+	t.Run("stream", func(t *testing.T) {
+		var buf bytes.Buffer
+		stream := StreamAuditInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)))
+		if err := stream(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/bridge.v1.BridgeService/AttachSession"}, func(any, grpc.ServerStream) error {
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		check(t, &buf)
+	})
+}
@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unscopedAuditDir holds the audit records that name no project.
+const unscopedAuditDir = "_unscoped"
+
+// AuditLog keeps RPC audit records on disk as JSON lines, in one file per
+// project per UTC day: <dir>/<project>/<YYYY-MM-DD>.jsonl. Splitting by
+// project and day lets each project's records be deleted once its
+// retention period has passed; see SetRetention and Prune.
+type AuditLog struct {
+	dir string
+
+	mu    sync.Mutex
+	files map[string]*auditFile // by project directory name
+	// retention is how long records are kept; zero keeps them forever.
+	retention time.Duration
+	projects  map[string]time.Duration
+}
+
+type auditFile struct {
+	day string
+	f   *os.File
+}
+
+// OpenAuditLog creates dir if needed and returns an audit log rooted there.
+func OpenAuditLog(dir string) (*AuditLog, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create audit log dir %q: %w", dir, err)
+	}
+	return &AuditLog{dir: dir, files: make(map[string]*auditFile)}, nil
+}
+
+// SetRetention sets how long records are kept: def for every project, or
+// the project's entry in projects. Zero def keeps records forever; a zero
+// project entry uses def and a negative one keeps them forever.
+func (l *AuditLog) SetRetention(def time.Duration, projects map[string]time.Duration) {
+	l.mu.Lock()
+	l.retention = max(def, 0)
+	l.projects = projects
+	l.mu.Unlock()
+}
+
+func (l *AuditLog) retentionFor(projectID string) time.Duration {
+	if d, ok := l.projects[projectID]; ok && d != 0 {
+		return max(d, 0)
+	}
+	return l.retention
+}
+
+// auditDirName returns the directory of a project's records. The project
+// ID is escaped so that it is always a single, visible path element.
+func auditDirName(projectID string) string {
+	if projectID == "" {
+		return unscopedAuditDir
+	}
+	name := url.PathEscape(projectID)
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		name = fmt.Sprintf("%%%02X", name[0]) + name[1:]
+	}
+	return name
+}
+
+// Write appends line, one JSON record, to projectID's file for the day of t.
+func (l *AuditLog) Write(projectID string, t time.Time, line []byte) error {
+	name := auditDirName(projectID)
+	day := t.UTC().Format(time.DateOnly)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	af := l.files[name]
+	if af == nil || af.day != day {
+		if af != nil {
+			_ = af.f.Close()
+			delete(l.files, name)
+		}
+		dir := filepath.Join(l.dir, name)
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("create audit log dir %q: %w", dir, err)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, day+".jsonl"), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("open audit log: %w", err)
+		}
+		af = &auditFile{day: day, f: f}
+		l.files[name] = af
+	}
+	if _, err := af.f.Write(line); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes the files whose records are all older than their
+// project's retention and returns how many it deleted. A day's file is
+// deleted once the whole day is past the retention period.
+func (l *AuditLog) Prune(now time.Time) (int, error) {
+	projects, err := os.ReadDir(l.dir)
+	if err != nil {
+		return 0, fmt.Errorf("read audit log dir %q: %w", l.dir, err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	pruned := 0
+	var errs []error
+	for _, p := range projects {
+		if !p.IsDir() {
+			continue
+		}
+		projectID := ""
+		if p.Name() != unscopedAuditDir {
+			if projectID, err = url.PathUnescape(p.Name()); err != nil {
+				continue
+			}
+		}
+		keep := l.retentionFor(projectID)
+		if keep <= 0 {
+			continue
+		}
+		dir := filepath.Join(l.dir, p.Name())
+		days, err := os.ReadDir(dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, d := range days {
+			name, ok := strings.CutSuffix(d.Name(), ".jsonl")
+			if !ok {
+				continue
+			}
+			day, err := time.Parse(time.DateOnly, name)
+			if err != nil || now.Sub(day.Add(24*time.Hour)) <= keep {
+				continue
+			}
+			if af := l.files[p.Name()]; af != nil && af.day == name {
+				_ = af.f.Close()
+				delete(l.files, p.Name())
+			}
+			if err := os.Remove(filepath.Join(dir, d.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+				continue
+			}
+			pruned++
+		}
+	}
+	return pruned, errors.Join(errs...)
+}
+
+// Run prunes the log every interval until ctx is done.
+func (l *AuditLog) Run(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			pruned, err := l.Prune(now)
+			if err != nil {
+				logger.Warn("audit log: failed to prune", "error", err)
+			}
+			if pruned > 0 {
+				logger.Info("audit log: pruned expired files", "count", pruned)
+			}
+		}
+	}
+}
+
+// Close closes the open files. Later writes reopen them.
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var errs []error
+	for name, af := range l.files {
+		errs = append(errs, af.f.Close())
+		delete(l.files, name)
+	}
+	return errors.Join(errs...)
+}
+
+// Handler returns a slog.Handler that writes the info and higher records
+// it is given to the log, filed under their project_id attribute.
+func (l *AuditLog) Handler() slog.Handler {
+	return &auditLogHandler{log: l}
+}
+
+type auditLogHandler struct {
+	log *AuditLog
+	// with replays WithAttrs and WithGroup onto the JSON handler that
+	// formats each record.
+	with      []func(slog.Handler) slog.Handler
+	projectID string
+	grouped   bool
+}
+
+func (h *auditLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func (h *auditLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	projectID := h.projectID
+	if !h.grouped {
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "project_id" {
+				projectID = a.Value.String()
+				return false
+			}
+			return true
+		})
+	}
+	var buf bytes.Buffer
+	var jh slog.Handler = slog.NewJSONHandler(&buf, nil)
+	for _, with := range h.with {
+		jh = with(jh)
+	}
+	if err := jh.Handle(ctx, r); err != nil {
+		return err
+	}
+	return h.log.Write(projectID, r.Time, buf.Bytes())
+}
+
+func (h *auditLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.with = append(h.with[:len(h.with):len(h.with)], func(jh slog.Handler) slog.Handler { return jh.WithAttrs(attrs) })
+	if !h.grouped {
+		for _, a := range attrs {
+			if a.Key == "project_id" {
+				h2.projectID = a.Value.String()
+			}
+		}
+	}
+	return &h2
+}
+
+func (h *auditLogHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.with = append(h.with[:len(h.with):len(h.with)], func(jh slog.Handler) slog.Handler { return jh.WithGroup(name) })
+	h2.grouped = true
+	return &h2
+}
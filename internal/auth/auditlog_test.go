@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	log, err := OpenAuditLog(dir)
+	if err != nil {
+		t.Fatalf("OpenAuditLog: %v", err)
+	}
+	t.Cleanup(func() { _ = log.Close() })
+
+	logger := slog.New(log.Handler())
+	logger.Info("rpc audit", "rpc_method", "/bridge.v1.BridgeService/StartSession", "project_id", "acme")
+	logger.Info("rpc audit", "rpc_method", "/bridge.v1.BridgeService/Health", "project_id", "")
+	logger.With("project_id", ".hidden").Warn("rpc audit", "result", "error")
+	logger.Debug("rpc audit", "project_id", "acme")
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	data, err := os.ReadFile(filepath.Join(dir, "acme", today+".jsonl"))
+	if err != nil {
+		t.Fatalf("read acme log: %v", err)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatalf("acme log is not one JSON record: %v\n%s", err, data)
+	}
+	if rec["rpc_method"] != "/bridge.v1.BridgeService/StartSession" || rec["project_id"] != "acme" {
+		t.Fatalf("acme record = %v", rec)
+	}
+	for _, name := range []string{unscopedAuditDir, "%2Ehidden"} {
+		if _, err := os.Stat(filepath.Join(dir, name, today+".jsonl")); err != nil {
+			t.Errorf("%s log: %v", name, err)
+		}
+	}
+
+	// Files of days past the retention period are deleted; acme keeps
+	// its records for longer and "forever" for good.
+	old := time.Now().AddDate(0, 0, -10).UTC().Format(time.DateOnly)
+	for _, name := range []string{"acme", unscopedAuditDir, "forever"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, old+".jsonl"), []byte("{}\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log.SetRetention(7*24*time.Hour, map[string]time.Duration{"acme": 30 * 24 * time.Hour, "forever": -1})
+	pruned, err := log.Prune(time.Now())
+	if err != nil || pruned != 1 {
+		t.Fatalf("Prune = %d, %v; want 1", pruned, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, unscopedAuditDir, old+".jsonl")); !os.IsNotExist(err) {
+		t.Errorf("expired unscoped log kept: %v", err)
+	}
+	for _, name := range []string{"acme", "forever"} {
+		if _, err := os.Stat(filepath.Join(dir, name, old+".jsonl")); err != nil {
+			t.Errorf("%s log pruned: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, unscopedAuditDir, today+".jsonl")); err != nil {
+		t.Errorf("today's log pruned: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+)
+
+// AnyProject in CertBinding.Projects allows every project.
+const AnyProject = "*"
+
+// CertBinding ties client certificates to the projects their tokens may
+// name. A certificate matches when every non-empty selector does.
+type CertBinding struct {
+	CommonName string
+	// OU matches any of the certificate's organizational units.
+	OU string
+	// URI matches any of the certificate's URI SANs, such as a SPIFFE ID.
+	URI      string
+	Projects []string
+}
+
+func (b CertBinding) matches(cert *x509.Certificate) bool {
+	if b.CommonName != "" && cert.Subject.CommonName != b.CommonName {
+		return false
+	}
+	if b.OU != "" && !slices.Contains(cert.Subject.OrganizationalUnit, b.OU) {
+		return false
+	}
+	if b.URI != "" && !slices.ContainsFunc(cert.URIs, func(u *url.URL) bool { return u.String() == b.URI }) {
+		return false
+	}
+	return true
+}
+
+// CertBindings restricts which client certificates a token may be used
+// from, so that a stolen token is useless without the certificate of a
+// workload allowed its project. With no bindings nothing is restricted.
+type CertBindings struct {
+	mu       sync.RWMutex
+	bindings []CertBinding
+}
+
+// Set replaces the bindings.
+func (c *CertBindings) Set(bindings []CertBinding) {
+	c.mu.Lock()
+	c.bindings = bindings
+	c.mu.Unlock()
+}
+
+// check reports whether a token for projectID may be used from cert. A
+// token without a project, such as an admin token, only needs a
+// certificate that matches some binding.
+func (c *CertBindings) check(cert *x509.Certificate, projectID string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.bindings) == 0 {
+		return nil
+	}
+	if cert == nil {
+		return errors.New("client certificate required for project binding")
+	}
+	matched := false
+	for _, b := range c.bindings {
+		if !b.matches(cert) {
+			continue
+		}
+		matched = true
+		if projectID == "" || slices.Contains(b.Projects, AnyProject) || slices.Contains(b.Projects, projectID) {
+			return nil
+		}
+	}
+	if !matched {
+		return fmt.Errorf("client certificate %q is not bound to any project", cert.Subject.CommonName)
+	}
+	return fmt.Errorf("client certificate %q is not bound to project %q", cert.Subject.CommonName, projectID)
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestCertBindings(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	bindings := &CertBindings{}
+	verifier := &JWTVerifier{
+		Audience:     "bridge",
+		Keys:         map[string][]IssuerKey{"ci": {{Key: pub}}},
+		CertBindings: bindings,
+	}
+	spiffeID, _ := url.Parse("spiffe://example.org/ops")
+	certs := map[string]*x509.Certificate{
+		"orchestrator": {Subject: pkix.Name{CommonName: "orchestrator-1", OrganizationalUnit: []string{"runners"}}},
+		"ops":          {Subject: pkix.Name{CommonName: "ops"}, URIs: []*url.URL{spiffeID}},
+		"other":        {Subject: pkix.Name{CommonName: "unrelated"}},
+	}
+	call := func(cert, project string) error {
+		t.Helper()
+		iss := &JWTIssuer{Issuer: "ci", Audience: "bridge", Key: priv, TTL: time.Minute}
+		token, err := iss.Mint("ci", project)
+		if err != nil {
+			t.Fatalf("Mint: %v", err)
+		}
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+		if c := certs[cert]; c != nil {
+			ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{c},
+				VerifiedChains:   [][]*x509.Certificate{{c}},
+			}}})
+		}
+		_, err = UnaryJWTInterceptor(verifier, nil)(ctx, "req", &grpc.UnaryServerInfo{FullMethod: "/bridge.v1.BridgeService/ListSessions"}, func(context.Context, any) (any, error) {
+			return "ok", nil
+		})
+		return err
+	}
+
+	// Without bindings any certificate may use any token.
+	if err := call("other", "alpha"); err != nil {
+		t.Fatalf("no bindings: %v", err)
+	}
+
+	bindings.Set([]CertBinding{
+		{CommonName: "orchestrator-1", OU: "runners", Projects: []string{"alpha", "beta"}},
+		{URI: "spiffe://example.org/ops", Projects: []string{AnyProject}},
+	})
+	for _, tt := range []struct {
+		cert, project string
+		want          codes.Code
+	}{
+		{"orchestrator", "alpha", codes.OK},
+		{"orchestrator", "beta", codes.OK},
+		{"orchestrator", "gamma", codes.PermissionDenied},
+		{"orchestrator", "", codes.OK},
+		{"ops", "gamma", codes.OK},
+		{"other", "alpha", codes.PermissionDenied},
+		{"other", "", codes.PermissionDenied},
+		{"", "alpha", codes.PermissionDenied},
+	} {
+		if err := call(tt.cert, tt.project); status.Code(err) != tt.want {
+			t.Errorf("cert=%q project=%q: code=%v want %v (%v)", tt.cert, tt.project, status.Code(err), tt.want, err)
+		}
+	}
+
+	// Every selector of a binding must match.
+	bindings.Set([]CertBinding{{CommonName: "orchestrator-1", OU: "ops", Projects: []string{"alpha"}}})
+	if err := call("orchestrator", "alpha"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("partial match: code=%v want PermissionDenied", status.Code(err))
+	}
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+)
+
+// CertReloader serves the server certificate, client CA bundle and CRL
+// from files and picks up new versions when they change on disk, so
+// renewed certificates and revocations take effect without a restart.
+// Only new TLS handshakes see the change; established connections are left
+// alone. With TLSConfig.ACME set, the server certificate comes from the
+// ACME manager instead of files.
+type CertReloader struct {
+	cfg    TLSConfig
+	logger *slog.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	revoked revocationSet
+	stamps  [5]fileStamp // bundle, cert, key, CRL, SPIFFE bundle as last loaded
+}
+
+// fileStamp identifies a file version by size and modification time.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// NewCertReloader loads the files named by cfg. It fails if they cannot be
+// loaded, as ServerTLSConfig does.
+func NewCertReloader(cfg TLSConfig, logger *slog.Logger) (*CertReloader, error) {
+	r := &CertReloader{cfg: cfg, logger: logger}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) currentStamps() ([5]fileStamp, error) {
+	var out [5]fileStamp
+	spiffeBundle := ""
+	if r.cfg.SPIFFE != nil {
+		spiffeBundle = r.cfg.SPIFFE.BundlePath
+	}
+	for i, path := range []string{r.cfg.CABundlePath, r.cfg.CertPath, r.cfg.KeyPath, r.cfg.CRLPath, spiffeBundle} {
+		if path == "" || (r.cfg.ACME != nil && (i == 1 || i == 2)) {
+			continue
+		}
+		if i == 2 && pki.IsPKCS11URI(path) {
+			continue // the key lives in a token
+		}
+		fi, err := os.Stat(path)
+		if i == 3 && errors.Is(err, os.ErrNotExist) {
+			continue // no CRL yet
+		}
+		if err != nil {
+			return out, err
+		}
+		out[i] = fileStamp{size: fi.Size(), modTime: fi.ModTime()}
+	}
+	return out, nil
+}
+
+// Reload re-reads the files if any changed since the last successful load
+// and reports whether new material was installed. On error the previous
+// certificate, bundle and CRL stay in use; a certificate and key that do
+// not match, e.g. halfway through a renewal, are retried on the next call.
+func (r *CertReloader) Reload() (bool, error) {
+	stamps, err := r.currentStamps()
+	if err != nil {
+		return false, fmt.Errorf("stat tls files: %w", err)
+	}
+	r.mu.RLock()
+	unchanged := r.pool != nil && stamps == r.stamps
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	pool, err := loadCAPool(r.cfg.CABundlePath)
+	if err != nil {
+		return false, err
+	}
+	var spiffeRoots *x509.CertPool
+	if r.cfg.SPIFFE != nil {
+		spiffeRoots, err = loadCAPool(r.cfg.SPIFFE.BundlePath)
+		if err != nil {
+			return false, fmt.Errorf("spiffe bundle: %w", err)
+		}
+		// Load the bundle a second time into the client CA pool; CertPool
+		// has no merge.
+		extra, err := os.ReadFile(r.cfg.SPIFFE.BundlePath)
+		if err != nil {
+			return false, fmt.Errorf("spiffe bundle: %w", err)
+		}
+		pool.AppendCertsFromPEM(extra)
+	}
+	var cert *tls.Certificate
+	if r.cfg.ACME == nil {
+		kp, err := pki.LoadX509KeyPair(r.cfg.CertPath, r.cfg.KeyPath, r.cfg.KeyPassphrase)
+		if err != nil {
+			return false, fmt.Errorf("load server keypair: %w", err)
+		}
+		cert = &kp
+	}
+	revoked, err := loadRevocations(r.cfg.CRLPath, r.cfg.CABundlePath)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	r.cert = cert
+	r.pool = pool
+	r.revoked = revoked
+	r.stamps = stamps
+	r.mu.Unlock()
+	if spiffeRoots != nil {
+		r.cfg.SPIFFE.setRoots(spiffeRoots)
+	}
+	return true, nil
+}
+
+// NotAfter returns the expiry of the server certificate in use, or the zero
+// time when certificates come from ACME.
+func (r *CertReloader) NotAfter() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cert == nil || r.cert.Leaf == nil {
+		return time.Time{}
+	}
+	return r.cert.Leaf.NotAfter
+}
+
+// Run checks the files every interval until ctx is done.
+func (r *CertReloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			switch {
+			case err != nil:
+				r.logger.Warn("tls reload failed; keeping current certificate", "cert", r.cfg.CertPath, "error", err)
+			case reloaded:
+				r.logger.Info("tls certificate reloaded", "cert", r.cfg.CertPath)
+			}
+		}
+	}
+}
+
+// TLSConfig returns a server config with the same policy as
+// ServerTLSConfig that resolves the certificate and client CAs per
+// handshake.
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			if r.cfg.ACME != nil && isACMEChallenge(hello) {
+				return acmeChallengeConfig(r.cfg.ACME), nil
+			}
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			cfg := &tls.Config{
+				MinVersion:            tls.VersionTLS13,
+				ClientAuth:            tls.RequireAndVerifyClientCert,
+				ClientCAs:             r.pool,
+				VerifyPeerCertificate: r.revoked.verifyPeer,
+				// gRPC requires HTTP/2 to be negotiated over ALPN.
+				NextProtos: []string{"h2"},
+			}
+			if r.cfg.ACME != nil {
+				cfg.GetCertificate = r.cfg.ACME.GetCertificate
+			} else {
+				cfg.Certificates = []tls.Certificate{*r.cert}
+			}
+			return cfg, nil
+		},
+	}
+}
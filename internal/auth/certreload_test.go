@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+)
+
+func servedCert(t *testing.T, r *CertReloader) *x509.Certificate {
+	t.Helper()
+	cfg, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("GetConfigForClient: %v", err)
+	}
+	if cfg.ClientAuth != tls.RequireAndVerifyClientCert || cfg.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("served config relaxes mTLS policy: %+v", cfg)
+	}
+	leaf, err := x509.ParseCertificate(cfg.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return leaf
+}
+
+func TestCertReloaderPicksUpRenewedCert(t *testing.T) {
+	dir := t.TempDir()
+	caCert, caKey, err := pki.InitCA("test-ca", dir)
+	if err != nil {
+		t.Fatalf("InitCA: %v", err)
+	}
+	issue := func() (string, string) {
+		t.Helper()
+		certPath, keyPath, err := pki.IssueCert(mustLoadCA(t, caCert, caKey), mustLoadCAKey(t, caCert, caKey), pki.CertTypeServer, "bridge.local", []string{"bridge.local"}, dir)
+		if err != nil {
+			t.Fatalf("IssueCert: %v", err)
+		}
+		return certPath, keyPath
+	}
+	certPath, keyPath := issue()
+	bundle := filepath.Join(dir, "bundle.crt")
+	if err := pki.BuildBundle(bundle, caCert); err != nil {
+		t.Fatalf("BuildBundle: %v", err)
+	}
+
+	r, err := NewCertReloader(TLSConfig{CABundlePath: bundle, CertPath: certPath, KeyPath: keyPath}, slogDiscardLogger())
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+	first := servedCert(t, r)
+	if !r.NotAfter().Equal(first.NotAfter) {
+		t.Fatalf("NotAfter=%v want %v", r.NotAfter(), first.NotAfter)
+	}
+	if reloaded, err := r.Reload(); err != nil || reloaded {
+		t.Fatalf("Reload unchanged files = %v, %v want false, nil", reloaded, err)
+	}
+
+	// Renew in place; bump mtimes so coarse filesystem timestamps still
+	// register the change.
+	issue()
+	future := time.Now().Add(time.Minute)
+	for _, p := range []string{certPath, keyPath} {
+		if err := os.Chtimes(p, future, future); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	}
+	if reloaded, err := r.Reload(); err != nil || !reloaded {
+		t.Fatalf("Reload renewed files = %v, %v want true, nil", reloaded, err)
+	}
+	second := servedCert(t, r)
+	if second.SerialNumber.Cmp(first.SerialNumber) == 0 {
+		t.Fatal("served certificate did not change after renewal")
+	}
+
+	// A key that does not match the certificate, as during a non-atomic
+	// renewal, is rejected and the current certificate stays.
+	_, stray, err := pki.IssueCert(mustLoadCA(t, caCert, caKey), mustLoadCAKey(t, caCert, caKey), pki.CertTypeServer, "stray", nil, t.TempDir())
+	if err != nil {
+		t.Fatalf("IssueCert stray: %v", err)
+	}
+	data, err := os.ReadFile(stray)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if err := os.WriteFile(keyPath, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := r.Reload(); err == nil {
+		t.Fatal("Reload accepted a mismatched key")
+	}
+	if got := servedCert(t, r); got.SerialNumber.Cmp(second.SerialNumber) != 0 {
+		t.Fatal("failed reload replaced the served certificate")
+	}
+}
+
+func TestNewCertReloaderRequiresValidFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewCertReloader(TLSConfig{
+		CABundlePath: filepath.Join(dir, "missing.crt"),
+		CertPath:     filepath.Join(dir, "missing.crt"),
+		KeyPath:      filepath.Join(dir, "missing.key"),
+	}, slogDiscardLogger()); err == nil {
+		t.Fatal("NewCertReloader accepted missing files")
+	}
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+)
+
+// revocationSet holds revoked certificate serials keyed by issuer.
+type revocationSet map[string]map[string]bool
+
+// loadRevocations reads the CRLs at crlPath and checks that each was
+// signed by a CA in the bundle. An empty crlPath or a missing file yields
+// an empty set.
+func loadRevocations(crlPath, bundlePath string) (revocationSet, error) {
+	if crlPath == "" {
+		return nil, nil
+	}
+	crls, err := pki.LoadCRLs(crlPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cas, err := loadCACerts(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(revocationSet)
+	for _, crl := range crls {
+		if !signedByAny(crl, cas) {
+			return nil, fmt.Errorf("crl %s: issuer %q is not a CA in the trust bundle", crlPath, crl.Issuer)
+		}
+		issuer := string(crl.RawIssuer)
+		if set[issuer] == nil {
+			set[issuer] = make(map[string]bool)
+		}
+		for _, e := range crl.RevokedCertificateEntries {
+			set[issuer][e.SerialNumber.String()] = true
+		}
+	}
+	return set, nil
+}
+
+func signedByAny(crl *x509.RevocationList, cas []*x509.Certificate) bool {
+	for _, ca := range cas {
+		if string(ca.RawSubject) == string(crl.RawIssuer) && crl.CheckSignatureFrom(ca) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func loadCACerts(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read ca bundle: %w", err)
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse ca bundle: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+}
+
+func (r revocationSet) revoked(cert *x509.Certificate) bool {
+	return r[string(cert.RawIssuer)][cert.SerialNumber.String()]
+}
+
+// verifyPeer is a tls.Config.VerifyPeerCertificate hook. It runs after
+// chain verification and rejects the peer if any verified chain contains a
+// revoked certificate.
+func (r revocationSet) verifyPeer(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(r) == 0 {
+		return nil
+	}
+	for _, chain := range verifiedChains {
+		for _, cert := range chain {
+			if r.revoked(cert) {
+				return fmt.Errorf("certificate %q (serial %s) has been revoked", cert.Subject.CommonName, cert.SerialNumber)
+			}
+		}
+	}
+	return nil
+}
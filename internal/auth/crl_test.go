@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+)
+
+// handshake runs an mTLS handshake over an in-memory connection and returns
+// the server-side error.
+func handshake(t *testing.T, server, client *tls.Config) error {
+	t.Helper()
+	sc, cc := net.Pipe()
+	defer func() { _ = sc.Close() }()
+	defer func() { _ = cc.Close() }()
+	clientDone := make(chan error, 1)
+	go func() {
+		conn := tls.Client(cc, client)
+		err := conn.Handshake()
+		if err == nil {
+			// A TLS 1.3 client finishes before the server has checked its
+			// certificate; read so the server's alert is not left blocked
+			// on the synchronous pipe.
+			_, err = conn.Read(make([]byte, 1))
+		}
+		clientDone <- err
+	}()
+	_ = sc.SetDeadline(time.Now().Add(5 * time.Second))
+	err := tls.Server(sc, server).Handshake()
+	_ = sc.Close()
+	<-clientDone
+	return err
+}
+
+func TestServerTLSConfigEnforcesCRL(t *testing.T) {
+	dir := t.TempDir()
+	caCertPath, caKeyPath, err := pki.InitCA("test-ca", dir)
+	if err != nil {
+		t.Fatalf("InitCA: %v", err)
+	}
+	caCert, caKey := mustLoadCA(t, caCertPath, caKeyPath), mustLoadCAKey(t, caCertPath, caKeyPath)
+	serverCert, serverKey, err := pki.IssueCert(caCert, caKey, pki.CertTypeServer, "bridge.local", []string{"bridge.local"}, dir)
+	if err != nil {
+		t.Fatalf("Issue server cert: %v", err)
+	}
+	goodCert, goodKey, err := pki.IssueCert(caCert, caKey, pki.CertTypeClient, "good-client", nil, dir)
+	if err != nil {
+		t.Fatalf("Issue good client: %v", err)
+	}
+	badCert, badKey, err := pki.IssueCert(caCert, caKey, pki.CertTypeClient, "bad-client", nil, dir)
+	if err != nil {
+		t.Fatalf("Issue bad client: %v", err)
+	}
+	bundle := filepath.Join(dir, "bundle.crt")
+	if err := pki.BuildBundle(bundle, caCertPath); err != nil {
+		t.Fatalf("BuildBundle: %v", err)
+	}
+	crlPath := filepath.Join(dir, "ca.crl")
+	serverCfg := TLSConfig{CABundlePath: bundle, CertPath: serverCert, KeyPath: serverKey, CRLPath: crlPath}
+
+	clientTLS := func(cert, key string) *tls.Config {
+		cfg, err := ClientTLSConfig(TLSConfig{CABundlePath: bundle, CertPath: cert, KeyPath: key, ServerName: "bridge.local"})
+		if err != nil {
+			t.Fatalf("ClientTLSConfig: %v", err)
+		}
+		return cfg
+	}
+
+	// Before any revocation the CRL file does not exist.
+	reloader, err := NewCertReloader(serverCfg, slogDiscardLogger())
+	if err != nil {
+		t.Fatalf("NewCertReloader without CRL file: %v", err)
+	}
+	if err := handshake(t, reloader.TLSConfig(), clientTLS(badCert, badKey)); err != nil {
+		t.Fatalf("handshake before revocation: %v", err)
+	}
+
+	bad, err := pki.LoadCert(badCert)
+	if err != nil {
+		t.Fatalf("LoadCert: %v", err)
+	}
+	if err := pki.RevokeCert(caCert, caKey, crlPath, bad.SerialNumber, 1); err != nil {
+		t.Fatalf("RevokeCert: %v", err)
+	}
+
+	static, err := ServerTLSConfig(serverCfg)
+	if err != nil {
+		t.Fatalf("ServerTLSConfig: %v", err)
+	}
+	if _, err := reloader.Reload(); err != nil {
+		t.Fatalf("Reload after revocation: %v", err)
+	}
+	for name, server := range map[string]*tls.Config{"static": static, "reloader": reloader.TLSConfig()} {
+		if err := handshake(t, server, clientTLS(badCert, badKey)); err == nil {
+			t.Fatalf("%s: revoked client certificate accepted", name)
+		}
+		if err := handshake(t, server, clientTLS(goodCert, goodKey)); err != nil {
+			t.Fatalf("%s: good client rejected: %v", name, err)
+		}
+	}
+
+	// A CRL signed by a CA outside the bundle is refused.
+	otherDir := t.TempDir()
+	otherCertPath, otherKeyPath, err := pki.InitCA("other-ca", otherDir)
+	if err != nil {
+		t.Fatalf("InitCA other: %v", err)
+	}
+	foreignCRL := filepath.Join(otherDir, "ca.crl")
+	if err := pki.RevokeCert(mustLoadCA(t, otherCertPath, otherKeyPath), mustLoadCAKey(t, otherCertPath, otherKeyPath), foreignCRL, bad.SerialNumber, 0); err != nil {
+		t.Fatalf("RevokeCert foreign: %v", err)
+	}
+	data, err := os.ReadFile(foreignCRL)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if err := os.WriteFile(crlPath, data, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := ServerTLSConfig(serverCfg); err == nil {
+		t.Fatal("ServerTLSConfig accepted a CRL from a foreign CA")
+	}
+	if _, err := reloader.Reload(); err == nil {
+		t.Fatal("Reload accepted a CRL from a foreign CA")
+	}
+	if err := handshake(t, reloader.TLSConfig(), clientTLS(badCert, badKey)); err == nil {
+		t.Fatal("failed CRL reload dropped existing revocations")
+	}
+}
+
+func TestIntermediateIssuedCertificates(t *testing.T) {
+	dir := t.TempDir()
+	rootPath, rootKeyPath, err := pki.InitCA("root-ca", dir)
+	if err != nil {
+		t.Fatalf("InitCA: %v", err)
+	}
+	interPath, interKeyPath, err := pki.InitIntermediateCA(mustLoadCA(t, rootPath, rootKeyPath), mustLoadCAKey(t, rootPath, rootKeyPath), "issuing-ca", dir)
+	if err != nil {
+		t.Fatalf("InitIntermediateCA: %v", err)
+	}
+	inter, interKey := mustLoadCA(t, interPath, interKeyPath), mustLoadCAKey(t, interPath, interKeyPath)
+	serverCert, serverKey, err := pki.IssueCert(inter, interKey, pki.CertTypeServer, "bridge.local", []string{"bridge.local"}, dir)
+	if err != nil {
+		t.Fatalf("Issue server cert: %v", err)
+	}
+	clientCert, clientKey, err := pki.IssueCert(inter, interKey, pki.CertTypeClient, "client", nil, dir)
+	if err != nil {
+		t.Fatalf("Issue client cert: %v", err)
+	}
+
+	// Peers that trust only the root verify each other's fullchain.
+	clientCfg, err := ClientTLSConfig(TLSConfig{CABundlePath: rootPath, CertPath: pki.FullchainPath(clientCert), KeyPath: clientKey, ServerName: "bridge.local"})
+	if err != nil {
+		t.Fatalf("ClientTLSConfig: %v", err)
+	}
+	serverCfg := TLSConfig{CABundlePath: rootPath, CertPath: pki.FullchainPath(serverCert), KeyPath: serverKey}
+	server, err := ServerTLSConfig(serverCfg)
+	if err != nil {
+		t.Fatalf("ServerTLSConfig: %v", err)
+	}
+	if err := handshake(t, server, clientCfg); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	// A CRL from the intermediate is accepted once it is in the bundle.
+	client, err := pki.LoadCert(clientCert)
+	if err != nil {
+		t.Fatalf("LoadCert: %v", err)
+	}
+	serverCfg.CRLPath = filepath.Join(dir, "intermediate.crl")
+	if err := pki.RevokeCert(inter, interKey, serverCfg.CRLPath, client.SerialNumber, 1); err != nil {
+		t.Fatalf("RevokeCert: %v", err)
+	}
+	serverCfg.CABundlePath = filepath.Join(dir, "bundle.crt")
+	if err := pki.BuildBundle(serverCfg.CABundlePath, rootPath, interPath); err != nil {
+		t.Fatalf("BuildBundle: %v", err)
+	}
+	if server, err = ServerTLSConfig(serverCfg); err != nil {
+		t.Fatalf("ServerTLSConfig with CRL: %v", err)
+	}
+	if err := handshake(t, server, clientCfg); err == nil {
+		t.Fatal("revoked intermediate-issued client accepted")
+	}
+}
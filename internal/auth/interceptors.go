@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"strings"
 
+	jwt "github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -28,11 +29,10 @@ func ContextWithClaims(ctx context.Context, claims *BridgeClaims) context.Contex
 // UnaryJWTInterceptor returns a gRPC unary interceptor that verifies JWTs.
 func UnaryJWTInterceptor(v *JWTVerifier, logger *slog.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-		// Skip auth for health checks
-		if info.FullMethod == "/bridge.v1.BridgeService/Health" {
+		if healthMethod(info.FullMethod) {
 			return handler(ctx, req)
 		}
-		claims, err := extractAndVerify(ctx, v)
+		claims, err := authenticate(ctx, v, info.FullMethod)
 		if err != nil {
 			if logger != nil {
 				logger.Warn("auth decision", "result", "deny", "rpc_method", info.FullMethod, "reason", err.Error(), "caller_cn", callerCommonName(ctx))
@@ -49,7 +49,10 @@ func UnaryJWTInterceptor(v *JWTVerifier, logger *slog.Logger) grpc.UnaryServerIn
 // StreamJWTInterceptor returns a gRPC stream interceptor that verifies JWTs.
 func StreamJWTInterceptor(v *JWTVerifier, logger *slog.Logger) grpc.StreamServerInterceptor {
 	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		claims, err := extractAndVerify(ss.Context(), v)
+		if healthMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		claims, err := authenticate(ss.Context(), v, info.FullMethod)
 		if err != nil {
 			if logger != nil {
 				logger.Warn("auth decision", "result", "deny", "rpc_method", info.FullMethod, "reason", err.Error(), "caller_cn", callerCommonName(ss.Context()))
@@ -67,6 +70,42 @@ func StreamJWTInterceptor(v *JWTVerifier, logger *slog.Logger) grpc.StreamServer
 	}
 }
 
+// healthMethod reports whether fullMethod is a health check, either the
+// bridge's own or the standard grpc.health.v1 service. Health checks need no
+// credentials so that orchestrators can probe the daemon.
+func healthMethod(fullMethod string) bool {
+	switch fullMethod {
+	case "/bridge.v1.BridgeService/Health", "/bridge.v1.BridgeService/HealthWatch":
+		return true
+	}
+	return strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/")
+}
+
+// adminMethod reports whether fullMethod belongs to AdminService.
+func adminMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/bridge.v1.AdminService/")
+}
+
+// authenticate returns the caller's claims for fullMethod. AdminService
+// methods additionally require an admin caller: one presenting a client
+// certificate with v.AdminCertOU, or a token with ScopeAdmin.
+func authenticate(ctx context.Context, v *JWTVerifier, fullMethod string) (*BridgeClaims, error) {
+	if !adminMethod(fullMethod) {
+		return extractAndVerify(ctx, v)
+	}
+	if v.AdminCertOU != "" && callerHasOU(ctx, v.AdminCertOU) {
+		return &BridgeClaims{Admin: true, RegisteredClaims: jwt.RegisteredClaims{Subject: callerCommonName(ctx)}}, nil
+	}
+	claims, err := extractAndVerify(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.IsAdmin() {
+		return nil, status.Error(codes.PermissionDenied, "admin scope or admin client certificate required")
+	}
+	return claims, nil
+}
+
 func extractAndVerify(ctx context.Context, v *JWTVerifier) (*BridgeClaims, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
@@ -75,6 +114,15 @@ func extractAndVerify(ctx context.Context, v *JWTVerifier) (*BridgeClaims, error
 
 	vals := md.Get("authorization")
 	if len(vals) == 0 {
+		if v.SPIFFE != nil {
+			claims, err := v.SPIFFE.fromPeer(ctx)
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			if claims != nil {
+				return claims, nil
+			}
+		}
 		return nil, status.Error(codes.Unauthenticated, "missing authorization header")
 	}
 
@@ -87,6 +135,9 @@ func extractAndVerify(ctx context.Context, v *JWTVerifier) (*BridgeClaims, error
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
 	}
+	if err := v.CertBindings.check(callerCert(ctx), claims.ProjectID); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
 
 	return claims, nil
 }
@@ -100,9 +151,10 @@ func parseBearerToken(authz string) (string, error) {
 }
 
 // UnaryPassthroughInterceptor injects empty claims for dev mode when JWT auth is disabled.
+// Whoever can reach such a server owns it, so the claims are admin.
 func UnaryPassthroughInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-		return handler(ContextWithClaims(ctx, &BridgeClaims{}), req)
+		return handler(ContextWithClaims(ctx, &BridgeClaims{Admin: true}), req)
 	}
 }
 
@@ -111,7 +163,7 @@ func StreamPassthroughInterceptor() grpc.StreamServerInterceptor {
 	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		wrapped := &wrappedStream{
 			ServerStream: ss,
-			ctx:          ContextWithClaims(ss.Context(), &BridgeClaims{}),
+			ctx:          ContextWithClaims(ss.Context(), &BridgeClaims{Admin: true}),
 		}
 		return handler(srv, wrapped)
 	}
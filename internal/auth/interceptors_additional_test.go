@@ -54,7 +54,7 @@ func TestJWTInterceptorsAndHelpers(t *testing.T) {
 	verifier := &JWTVerifier{
 		Audience: "bridge",
 		MaxTTL:   time.Minute,
-		Keys:     map[string]ed25519.PublicKey{"issuer-a": pub},
+		Keys:     map[string][]IssuerKey{"issuer-a": {{Key: pub}}},
 	}
 
 	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
@@ -96,6 +96,18 @@ func TestJWTInterceptorsAndHelpers(t *testing.T) {
 	if err != nil {
 		t.Fatalf("StreamJWTInterceptor: %v", err)
 	}
+	err = stream(nil, &testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/bridge.v1.BridgeService/HealthWatch"}, func(any, grpc.ServerStream) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamJWTInterceptor HealthWatch without credentials: %v", err)
+	}
+	_, err = unary(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, func(context.Context, any) (any, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("UnaryJWTInterceptor grpc.health.v1 Check without credentials: %v", err)
+	}
 
 	if got, err := parseBearerToken("Bearer token-value"); err != nil || got != "token-value" {
 		t.Fatalf("parseBearerToken got=%q err=%v", got, err)
@@ -105,6 +117,74 @@ func TestJWTInterceptorsAndHelpers(t *testing.T) {
 	}
 }
 
+func TestAdminMethodsRequireAdmin(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	revocations, err := LoadTokenRevocations("")
+	if err != nil {
+		t.Fatalf("LoadTokenRevocations: %v", err)
+	}
+	verifier := &JWTVerifier{
+		Audience:    "bridge",
+		Keys:        map[string][]IssuerKey{"ops": {{Key: pub}}},
+		Revocations: revocations,
+		AdminCertOU: "bridge-admins",
+	}
+	mint := func(scopes ...string) context.Context {
+		t.Helper()
+		iss := &JWTIssuer{Issuer: "ops", Audience: "bridge", Key: priv, TTL: time.Minute, Scopes: scopes}
+		token, err := iss.Mint("operator", "")
+		if err != nil {
+			t.Fatalf("Mint: %v", err)
+		}
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	}
+	withCert := func(ctx context.Context, ou string) context.Context {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "ops-laptop", OrganizationalUnit: []string{ou}}}
+		return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		}}})
+	}
+	unary := UnaryJWTInterceptor(verifier, nil)
+	call := func(ctx context.Context, method string) error {
+		_, err := unary(ctx, "req", &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, any) (any, error) {
+			return "ok", nil
+		})
+		return err
+	}
+	const drain = "/bridge.v1.AdminService/Drain"
+
+	if err := call(mint(), drain); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("unrestricted project token: code=%v want PermissionDenied", status.Code(err))
+	}
+	if err := call(mint(ScopeSessionsControl), drain); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("sessions:control token: code=%v want PermissionDenied", status.Code(err))
+	}
+	if err := call(mint(ScopeAdmin), drain); err != nil {
+		t.Fatalf("admin token: %v", err)
+	}
+	if err := call(withCert(context.Background(), "bridge-admins"), drain); err != nil {
+		t.Fatalf("admin certificate without token: %v", err)
+	}
+	if err := call(withCert(context.Background(), "developers"), drain); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("other OU without token: code=%v want Unauthenticated", status.Code(err))
+	}
+	// The admin OU only matters for AdminService.
+	if err := call(withCert(context.Background(), "bridge-admins"), "/bridge.v1.BridgeService/ListProviders"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("admin certificate on BridgeService: code=%v want Unauthenticated", status.Code(err))
+	}
+
+	if err := revocations.RevokeSubject("operator", time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("RevokeSubject: %v", err)
+	}
+	if err := call(mint(ScopeAdmin), drain); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("revoked token: code=%v want Unauthenticated", status.Code(err))
+	}
+}
+
 func TestPassthroughAndCallerCommonName(t *testing.T) {
 	unary := UnaryPassthroughInterceptor()
 	_, err := unary(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/bridge.v1.BridgeService/ListProviders"}, func(ctx context.Context, req any) (any, error) {
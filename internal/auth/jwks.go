@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// maxJWKSSize bounds the JWKS document read from the endpoint.
+	maxJWKSSize = 1 << 20
+	// jwksFetchTimeout bounds a single JWKS request.
+	jwksFetchTimeout = 10 * time.Second
+	// jwksMinRefresh rate-limits refreshes triggered by unknown key IDs, so
+	// tokens with made-up kids cannot hammer the endpoint.
+	jwksMinRefresh = 30 * time.Second
+)
+
+// JWKSCache fetches public keys from a JWKS endpoint and caches them by key
+// ID. Ed25519, RSA and ECDSA (P-256, P-384) keys are supported; other key
+// types are ignored.
+type JWKSCache struct {
+	// URL is the JWKS endpoint.
+	URL string
+	// Issuer, when set, restricts the cached keys to tokens whose iss claim
+	// matches. Empty accepts any issuer.
+	Issuer string
+	// Client is used for fetches. Nil uses http.DefaultClient.
+	Client *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	refreshMu sync.Mutex
+}
+
+// jwk is the subset of RFC 7517/7518/8037 fields used for signing keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Refresh fetches the JWKS document and replaces the cached keys. The cache
+// is left unchanged when the fetch or parse fails.
+func (c *JWKSCache) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	return c.refresh(ctx)
+}
+
+func (c *JWKSCache) refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return fmt.Errorf("jwks request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch jwks: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize+1))
+	if err != nil {
+		return fmt.Errorf("read jwks: %w", err)
+	}
+	if len(body) > maxJWKSSize {
+		return fmt.Errorf("jwks document exceeds %d bytes", maxJWKSSize)
+	}
+	keys, err := ParseJWKS(body)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+// ParseJWKS returns the signing keys in a JWKS document, keyed by kid. Keys
+// without a kid, of an unsupported type, or not meant for signatures are
+// skipped.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("parse jwks: key %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// publicKey decodes k. It returns nil, nil for unsupported key types.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch {
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	case k.Kty == "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA public key")
+		}
+		exp := int(new(big.Int).SetBytes(e).Int64())
+		if exp < 3 {
+			return nil, errors.New("invalid RSA public key")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}
+		if pub.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key is %d bits, want at least 2048", pub.N.BitLen())
+		}
+		return pub, nil
+	case k.Kty == "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, nil
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("invalid EC public key")
+		}
+		// Validate the point via the uncompressed SEC 1 encoding.
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC public key")
+		}
+		point := append(append([]byte{4}, x...), y...)
+		pub, err := ecdsa.ParseUncompressedPublicKey(curve, point)
+		if err != nil {
+			return nil, errors.New("invalid EC public key")
+		}
+		return pub, nil
+	default:
+		return nil, nil
+	}
+}
+
+// Key returns the cached key for kid. On a miss it refreshes the cache,
+// at most once per jwksMinRefresh, so keys published after the last
+// periodic refresh are picked up.
+func (c *JWKSCache) Key(kid string) (crypto.PublicKey, bool) {
+	if key, ok := c.cached(kid); ok {
+		return key, true
+	}
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	// Another caller may have refreshed while we waited.
+	if key, ok := c.cached(kid); ok {
+		return key, true
+	}
+	c.mu.RLock()
+	recent := time.Since(c.fetchedAt) < jwksMinRefresh
+	c.mu.RUnlock()
+	if recent {
+		return nil, false
+	}
+	if err := c.refresh(context.Background()); err != nil {
+		return nil, false
+	}
+	return c.cached(kid)
+}
+
+func (c *JWKSCache) cached(kid string) (crypto.PublicKey, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// Run refreshes the cache every interval until ctx is done. Failed
+// refreshes are logged and the previous keys stay in use.
+func (c *JWKSCache) Run(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil && ctx.Err() == nil {
+				logger.Warn("jwks refresh failed", "url", c.URL, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+func jwksDoc(keys map[string]ed25519.PublicKey) string {
+	doc := `{"keys":[{"kty":"oct","kid":"hmac-1","k":"c2VjcmV0"}`
+	for kid, pub := range keys {
+		doc += fmt.Sprintf(`,{"kty":"OKP","crv":"Ed25519","use":"sig","kid":%q,"x":%q}`,
+			kid, base64.RawURLEncoding.EncodeToString(pub))
+	}
+	return doc + `]}`
+}
+
+func mintWithKid(t *testing.T, priv ed25519.PrivateKey, kid, issuer string) string {
+	t.Helper()
+	now := time.Now()
+	tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, BridgeClaims{
+		ProjectID: "project-abc",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   "user-1",
+			Audience:  jwt.ClaimStrings{"bridge"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	})
+	tok.Header["kid"] = kid
+	s, err := tok.SignedString(priv)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return s
+}
+
+func TestParseJWKS(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	keys, err := ParseJWKS([]byte(jwksDoc(map[string]ed25519.PublicKey{"k1": pub})))
+	if err != nil {
+		t.Fatalf("ParseJWKS: %v", err)
+	}
+	if k, ok := keys["k1"].(ed25519.PublicKey); len(keys) != 1 || !ok || !k.Equal(pub) {
+		t.Fatalf("keys=%v want only k1", keys)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{name: "not json", doc: "<html>"},
+		{name: "bad x", doc: `{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"k","x":"c2hvcnQ"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseJWKS([]byte(tt.doc)); err == nil {
+				t.Fatal("ParseJWKS succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestJWTVerifyWithJWKS(t *testing.T) {
+	pub1, priv1, _ := ed25519.GenerateKey(rand.Reader)
+	pub2, priv2, _ := ed25519.GenerateKey(rand.Reader)
+	filePub, filePriv, _ := ed25519.GenerateKey(rand.Reader)
+
+	var doc atomic.Value
+	doc.Store(jwksDoc(map[string]ed25519.PublicKey{"k1": pub1}))
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write([]byte(doc.Load().(string)))
+	}))
+	defer srv.Close()
+
+	cache := &JWKSCache{URL: srv.URL, Issuer: "idp"}
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	verifier := &JWTVerifier{
+		Audience: "bridge",
+		MaxTTL:   10 * time.Minute,
+		Keys:     map[string][]IssuerKey{"file-issuer": {{Key: filePub}}},
+		JWKS:     cache,
+	}
+
+	if _, err := verifier.Verify(mintWithKid(t, priv1, "k1", "idp")); err != nil {
+		t.Fatalf("Verify jwks token: %v", err)
+	}
+	if _, err := verifier.Verify(mintWithKid(t, priv1, "k1", "other")); err == nil {
+		t.Fatal("Verify accepted a jwks key for a different issuer")
+	}
+	fileToken, _ := (&JWTIssuer{Issuer: "file-issuer", Audience: "bridge", Key: filePriv, TTL: time.Minute}).Mint("u", "p")
+	if _, err := verifier.Verify(fileToken); err != nil {
+		t.Fatalf("Verify file-key token: %v", err)
+	}
+
+	// A rotated-in key is not cached yet and the last fetch is recent, so
+	// the token is rejected without another request.
+	doc.Store(jwksDoc(map[string]ed25519.PublicKey{"k2": pub2}))
+	before := fetches.Load()
+	if _, err := verifier.Verify(mintWithKid(t, priv2, "k2", "idp")); err == nil {
+		t.Fatal("Verify accepted an unknown kid")
+	}
+	if fetches.Load() != before {
+		t.Fatal("unknown kid refetched within the minimum refresh interval")
+	}
+
+	// Once the interval has passed, a miss triggers a refresh.
+	cache.mu.Lock()
+	cache.fetchedAt = time.Now().Add(-jwksMinRefresh)
+	cache.mu.Unlock()
+	if _, err := verifier.Verify(mintWithKid(t, priv2, "k2", "idp")); err != nil {
+		t.Fatalf("Verify rotated key: %v", err)
+	}
+	if _, err := verifier.Verify(mintWithKid(t, priv1, "k1", "idp")); err == nil {
+		t.Fatal("Verify accepted a key removed from the jwks")
+	}
+}
+
+func TestJWKSRefreshKeepsKeysOnError(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(jwksDoc(map[string]ed25519.PublicKey{"k1": pub})))
+	}))
+	defer srv.Close()
+
+	cache := &JWKSCache{URL: srv.URL}
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	fail.Store(true)
+	if err := cache.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh succeeded against a failing endpoint")
+	}
+	if _, ok := cache.Key("k1"); !ok {
+		t.Fatal("failed refresh dropped cached keys")
+	}
+}
@@ -1,34 +1,108 @@
 package auth
 
 import (
+	"crypto"
 	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Token scopes. A token without a scopes claim has every scope.
+const (
+	// ScopeEventsRead allows reading sessions and observing their output.
+	ScopeEventsRead = "events:read"
+	// ScopeSessionsControl allows starting, stopping and driving sessions,
+	// and everything ScopeEventsRead allows.
+	ScopeSessionsControl = "sessions:control"
+	// ScopeAdmin allows calling AdminService. Unlike the other scopes it
+	// must be listed explicitly; a token without a scopes claim lacks it.
+	ScopeAdmin = "admin"
 )
 
 // BridgeClaims are the JWT claims required for bridge API access.
 type BridgeClaims struct {
 	ProjectID string `json:"project_id"`
+	// Scopes limits what the token may do. Empty means unrestricted, so
+	// tokens minted before scopes existed keep working.
+	Scopes jwt.ClaimStrings `json:"scopes,omitempty"`
+	// SessionID, when set, limits the token to one session: it cannot
+	// start sessions or reach any other.
+	SessionID string `json:"session_id,omitempty"`
+	// Admin is set for callers authenticated as operators by other means
+	// than a token: a client certificate with the admin OU, or the local
+	// socket. It is never read from a token.
+	Admin bool `json:"-"`
 	jwt.RegisteredClaims
 }
 
+// HasScope reports whether the claims grant scope. ScopeSessionsControl
+// implies ScopeEventsRead. ScopeAdmin is only granted by IsAdmin.
+func (c *BridgeClaims) HasScope(scope string) bool {
+	if scope == ScopeAdmin {
+		return c.IsAdmin()
+	}
+	if len(c.Scopes) == 0 || slices.Contains(c.Scopes, scope) {
+		return true
+	}
+	return scope == ScopeEventsRead && slices.Contains(c.Scopes, ScopeSessionsControl)
+}
+
+// IsAdmin reports whether the caller may use AdminService.
+func (c *BridgeClaims) IsAdmin() bool {
+	return c.Admin || slices.Contains(c.Scopes, ScopeAdmin)
+}
+
 // JWTIssuer mints Ed25519-signed JWTs for bridge authentication.
 type JWTIssuer struct {
 	Issuer   string
 	Audience string
-	Key      ed25519.PrivateKey
-	TTL      time.Duration
+	// Key is an Ed25519 private key, or a signer for one held in a
+	// hardware token.
+	Key crypto.Signer
+	TTL time.Duration
+	// Scopes, when set, is minted into every token.
+	Scopes []string
+	// SessionID, when set, is minted into every token.
+	SessionID string
+	// KeyID is put in the kid header of every token so that verifiers
+	// holding several keys for the issuer pick the right one. Empty uses
+	// KeyID(Key.Public()).
+	KeyID string
+}
+
+// KeyID returns the RFC 7638 JWK thumbprint of an Ed25519 public key, the
+// kid used for keys that are not given one explicitly.
+func KeyID(pub ed25519.PublicKey) string {
+	jwk := `{"crv":"Ed25519","kty":"OKP","x":"` + base64.RawURLEncoding.EncodeToString(pub) + `"}`
+	sum := sha256.Sum256([]byte(jwk))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
 }
 
 // Mint creates a new JWT with the given subject and project ID.
 func (j *JWTIssuer) Mint(sub, projectID string) (string, error) {
+	tok, _, err := j.MintClaims(sub, projectID)
+	return tok, err
+}
+
+// MintClaims is Mint that also returns the claims it signed, e.g. for the
+// token's ID and expiry.
+func (j *JWTIssuer) MintClaims(sub, projectID string) (string, *BridgeClaims, error) {
 	now := time.Now()
 	claims := BridgeClaims{
 		ProjectID: projectID,
+		Scopes:    j.Scopes,
+		SessionID: j.SessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			// The ID lets an operator revoke this one token.
+			ID:        uuid.NewString(),
 			Issuer:    j.Issuer,
 			Subject:   sub,
 			Audience:  jwt.ClaimStrings{j.Audience},
@@ -37,19 +111,129 @@ func (j *JWTIssuer) Mint(sub, projectID string) (string, error) {
 		},
 	}
 	tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
-	return tok.SignedString(j.Key)
+	kid := j.KeyID
+	if kid == "" {
+		kid = KeyID(j.Key.Public().(ed25519.PublicKey))
+	}
+	tok.Header["kid"] = kid
+	signed, err := tok.SignedString(j.Key)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, &claims, nil
 }
 
 // JWTVerifier verifies Ed25519-signed JWTs from multiple issuers.
 type JWTVerifier struct {
 	Audience string
 	MaxTTL   time.Duration
-	// Keys maps issuer name to their Ed25519 public key.
-	Keys map[string]ed25519.PublicKey
+	// Keys maps issuer name to its Ed25519 public keys. An issuer has
+	// several while it rotates keys. Use SetKeys to change it while the
+	// verifier is in use.
+	Keys map[string][]IssuerKey
+	// JWKS, when set, verifies tokens that carry a kid header against keys
+	// fetched from a JWKS endpoint. Tokens without a kid still use Keys.
+	JWKS *JWKSCache
+	// OIDC, when set, verifies tokens whose iss matches OIDC.Issuer. MaxTTL
+	// does not apply to them; the provider controls token lifetime.
+	OIDC *OIDCProvider
+	// Kubernetes, when set, verifies projected service account tokens whose
+	// iss matches Kubernetes.Issuer. Like OIDC, MaxTTL does not apply.
+	Kubernetes *KubernetesTokens
+	// SPIFFE, when set, authenticates requests that carry no token by the
+	// client's X.509 SVID instead.
+	SPIFFE *SPIFFEIdentities
+	// Revocations, when set, rejects revoked tokens whichever way they
+	// were verified.
+	Revocations *TokenRevocations
+	// AdminCertOU, when set, makes callers whose client certificate has
+	// this organizational unit admins for AdminService, with or without a
+	// token.
+	AdminCertOU string
+	// CertBindings, when set, limits the projects a token may name to
+	// those bound to the caller's client certificate.
+	CertBindings *CertBindings
+
+	mu sync.RWMutex
+}
+
+// IssuerKey is one public key of an issuer. During a rotation the old and
+// new keys are both configured with overlapping validity windows, so tokens
+// signed with either verify until the old key's window closes.
+type IssuerKey struct {
+	// KID matches the kid header of tokens signed with the key. Empty uses
+	// KeyID(Key).
+	KID string
+	Key ed25519.PublicKey
+	// NotBefore and NotAfter bound when the key is accepted. Zero values
+	// leave that side open.
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+func (k IssuerKey) kid() string {
+	if k.KID != "" {
+		return k.KID
+	}
+	return KeyID(k.Key)
+}
+
+func (k IssuerKey) validAt(t time.Time) bool {
+	return (k.NotBefore.IsZero() || !t.Before(k.NotBefore)) && (k.NotAfter.IsZero() || t.Before(k.NotAfter))
+}
+
+// SetKeys replaces the issuer key set. It is safe to call concurrently with
+// Verify, e.g. when the daemon reloads its configuration.
+func (v *JWTVerifier) SetKeys(keys map[string][]IssuerKey) {
+	v.mu.Lock()
+	v.Keys = keys
+	v.mu.Unlock()
+}
+
+// keys returns the issuer's keys valid now, and whether the issuer is known
+// at all.
+func (v *JWTVerifier) keys(issuer string) ([]IssuerKey, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	all, ok := v.Keys[issuer]
+	now := time.Now()
+	var valid []IssuerKey
+	for _, k := range all {
+		if k.validAt(now) {
+			valid = append(valid, k)
+		}
+	}
+	return valid, ok
 }
 
 // Verify parses and validates a JWT token string.
 func (v *JWTVerifier) Verify(tokenString string) (*BridgeClaims, error) {
+	claims, err := v.verify(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if v.Revocations.Revoked(claims) {
+		return nil, errors.New("token revoked")
+	}
+	return claims, nil
+}
+
+func (v *JWTVerifier) verify(tokenString string) (*BridgeClaims, error) {
+	if v.OIDC != nil || v.Kubernetes != nil {
+		// The issuer is read unverified only to pick the verification path;
+		// the OIDC and Kubernetes paths check it again along with the
+		// signature.
+		var peek jwt.RegisteredClaims
+		if _, _, err := jwt.NewParser().ParseUnverified(tokenString, &peek); err == nil {
+			switch {
+			case v.OIDC != nil && peek.Issuer == v.OIDC.Issuer:
+				return v.OIDC.verify(tokenString)
+			case v.Kubernetes != nil && peek.Issuer == v.Kubernetes.Issuer:
+				return v.Kubernetes.verify(tokenString)
+			}
+		}
+	}
+
 	parser := jwt.NewParser(
 		jwt.WithValidMethods([]string{"EdDSA"}),
 		jwt.WithAudience(v.Audience),
@@ -61,11 +245,38 @@ func (v *JWTVerifier) Verify(tokenString string) (*BridgeClaims, error) {
 		if err != nil || issuer == "" {
 			return nil, errors.New("missing issuer")
 		}
-		key, ok := v.Keys[issuer]
-		if !ok {
+		keys, known := v.keys(issuer)
+		kid, _ := t.Header["kid"].(string)
+		if kid != "" {
+			for _, k := range keys {
+				if k.kid() == kid {
+					return k.Key, nil
+				}
+			}
+		}
+		if kid != "" && v.JWKS != nil {
+			if v.JWKS.Issuer != "" && v.JWKS.Issuer != issuer {
+				return nil, fmt.Errorf("issuer %s is not served by the jwks endpoint", issuer)
+			}
+			key, ok := v.JWKS.Key(kid)
+			if !ok {
+				return nil, fmt.Errorf("unknown key id: %s", kid)
+			}
+			return key, nil
+		}
+		if !known {
 			return nil, fmt.Errorf("unknown issuer: %s", issuer)
 		}
-		return key, nil
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("no key of issuer %s is valid now", issuer)
+		}
+		// Tokens without a kid, or with one the issuer's keys are not
+		// configured under, are tried against each of its current keys.
+		set := jwt.VerificationKeySet{Keys: make([]jwt.VerificationKey, len(keys))}
+		for i, k := range keys {
+			set.Keys[i] = k.Key
+		}
+		return set, nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("verify jwt: %w", err)
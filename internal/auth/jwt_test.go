@@ -28,9 +28,7 @@ func TestJWTMintAndVerify(t *testing.T) {
 	verifier := &JWTVerifier{
 		Audience: "bridge",
 		MaxTTL:   10 * time.Minute,
-		Keys: map[string]ed25519.PublicKey{
-			"test-issuer": pub,
-		},
+		Keys:     map[string][]IssuerKey{"test-issuer": {{Key: pub}}},
 	}
 
 	claims, err := verifier.Verify(token)
@@ -60,9 +58,7 @@ func TestJWTWrongIssuer(t *testing.T) {
 
 	verifier := &JWTVerifier{
 		Audience: "bridge",
-		Keys: map[string]ed25519.PublicKey{
-			"good-issuer": pub2,
-		},
+		Keys:     map[string][]IssuerKey{"good-issuer": {{Key: pub2}}},
 	}
 
 	_, err := verifier.Verify(token)
@@ -85,7 +81,7 @@ func TestJWTExceedsMaxTTL(t *testing.T) {
 	verifier := &JWTVerifier{
 		Audience: "bridge",
 		MaxTTL:   5 * time.Minute,
-		Keys:     map[string]ed25519.PublicKey{"test": pub},
+		Keys:     map[string][]IssuerKey{"test": {{Key: pub}}},
 	}
 
 	_, err := verifier.Verify(token)
@@ -107,7 +103,7 @@ func TestJWTWrongAudience(t *testing.T) {
 
 	verifier := &JWTVerifier{
 		Audience: "bridge",
-		Keys:     map[string]ed25519.PublicKey{"test": pub},
+		Keys:     map[string][]IssuerKey{"test": {{Key: pub}}},
 	}
 
 	_, err := verifier.Verify(token)
@@ -115,3 +111,123 @@ func TestJWTWrongAudience(t *testing.T) {
 		t.Error("expected error for wrong audience")
 	}
 }
+
+func TestJWTSessionID(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	issuer := &JWTIssuer{Issuer: "dash", Audience: "bridge", Key: priv, TTL: time.Minute, SessionID: "0b9c4b7e-6a52-4a36-9d4c-52f1f4a6f2b1"}
+	token, err := issuer.Mint("viewer", "project-abc")
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+	verifier := &JWTVerifier{Audience: "bridge", Keys: map[string][]IssuerKey{"dash": {{Key: pub}}}}
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.SessionID != issuer.SessionID {
+		t.Fatalf("session_id=%q want %q", claims.SessionID, issuer.SessionID)
+	}
+}
+
+func TestJWTScopes(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	issuer := &JWTIssuer{Issuer: "dash", Audience: "bridge", Key: priv, TTL: time.Minute, Scopes: []string{ScopeEventsRead}}
+	token, err := issuer.Mint("dashboard", "project-abc")
+	if err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+	verifier := &JWTVerifier{Audience: "bridge", Keys: map[string][]IssuerKey{"dash": {{Key: pub}}}}
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !claims.HasScope(ScopeEventsRead) || claims.HasScope(ScopeSessionsControl) {
+		t.Fatalf("scopes=%v want events:read only", claims.Scopes)
+	}
+
+	tests := []struct {
+		name   string
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{name: "no scopes claim is unrestricted", scope: ScopeSessionsControl, want: true},
+		{name: "read only", scopes: []string{ScopeEventsRead}, scope: ScopeSessionsControl, want: false},
+		{name: "control implies read", scopes: []string{ScopeSessionsControl}, scope: ScopeEventsRead, want: true},
+		{name: "admin grants nothing else", scopes: []string{ScopeAdmin}, scope: ScopeEventsRead, want: false},
+		{name: "no scopes claim is not admin", scope: ScopeAdmin, want: false},
+		{name: "admin scope", scopes: []string{ScopeAdmin}, scope: ScopeAdmin, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &BridgeClaims{Scopes: tt.scopes}
+			if got := c.HasScope(tt.scope); got != tt.want {
+				t.Fatalf("HasScope(%q) with %v = %v want %v", tt.scope, tt.scopes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	oldPub, oldPriv, _ := ed25519.GenerateKey(rand.Reader)
+	newPub, newPriv, _ := ed25519.GenerateKey(rand.Reader)
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	now := time.Now()
+
+	oldIssuer := &JWTIssuer{Issuer: "ci", Audience: "bridge", Key: oldPriv, TTL: time.Minute}
+	newIssuer := &JWTIssuer{Issuer: "ci", Audience: "bridge", Key: newPriv, TTL: time.Minute, KeyID: "ci-2"}
+	oldToken, err := oldIssuer.Mint("ci", "p1")
+	if err != nil {
+		t.Fatalf("Mint old: %v", err)
+	}
+	newToken, err := newIssuer.Mint("ci", "p1")
+	if err != nil {
+		t.Fatalf("Mint new: %v", err)
+	}
+
+	// Both keys are valid during the overlap.
+	verifier := &JWTVerifier{Audience: "bridge", Keys: map[string][]IssuerKey{"ci": {
+		{Key: oldPub, NotAfter: now.Add(time.Hour)},
+		{KID: "ci-2", Key: newPub, NotBefore: now.Add(-time.Hour)},
+	}}}
+	for name, tok := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := verifier.Verify(tok); err != nil {
+			t.Fatalf("Verify %s token during overlap: %v", name, err)
+		}
+	}
+
+	// Once the old key's window closes, only the new key verifies.
+	verifier.SetKeys(map[string][]IssuerKey{"ci": {
+		{Key: oldPub, NotAfter: now.Add(-time.Minute)},
+		{KID: "ci-2", Key: newPub},
+	}})
+	if _, err := verifier.Verify(oldToken); err == nil {
+		t.Fatal("old token verified after its key expired")
+	}
+	if _, err := verifier.Verify(newToken); err != nil {
+		t.Fatalf("Verify new token after rotation: %v", err)
+	}
+
+	// A kid the verifier does not know falls back to the issuer's keys.
+	verifier.SetKeys(map[string][]IssuerKey{"ci": {{KID: "renamed", Key: newPub}, {Key: otherPub}}})
+	if _, err := verifier.Verify(newToken); err != nil {
+		t.Fatalf("Verify with unmatched kid: %v", err)
+	}
+
+	// An issuer whose keys are all outside their window rejects everything.
+	verifier.SetKeys(map[string][]IssuerKey{"ci": {{Key: newPub, NotBefore: now.Add(time.Hour)}}})
+	if _, err := verifier.Verify(newToken); err == nil {
+		t.Fatal("token verified before its key became valid")
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	// RFC 8037 appendix A.3.
+	pub := ed25519.PublicKey{
+		0xd7, 0x5a, 0x98, 0x01, 0x82, 0xb1, 0x0a, 0xb7, 0xd5, 0x4b, 0xfe, 0xd3, 0xc9, 0x64, 0x07, 0x3a,
+		0x0e, 0xe1, 0x72, 0xf3, 0xda, 0xa6, 0x23, 0x25, 0xaf, 0x02, 0x1a, 0x68, 0xf7, 0x07, 0x51, 0x1a,
+	}
+	if got, want := KeyID(pub), "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"; got != want {
+		t.Fatalf("KeyID = %q, want %q", got, want)
+	}
+}
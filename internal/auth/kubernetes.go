@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// Paths of the service account credentials Kubernetes mounts into pods.
+const (
+	KubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	KubernetesCAPath    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// KubernetesTokens accepts projected service account tokens, so that pods
+// in a cluster authenticate with the token the kubelet mounts for them
+// instead of a bridge-minted JWT. Each service account is mapped to the
+// project it may access.
+type KubernetesTokens struct {
+	// Issuer must equal the token's iss claim, i.e. the API server's
+	// --service-account-issuer.
+	Issuer string
+	// Audience is the audience requested in the pod's projected token
+	// volume.
+	Audience string
+	JWKS     *JWKSCache
+
+	mu       sync.RWMutex
+	projects map[string]string
+}
+
+// SetProjects replaces the service account to project mapping. Keys are
+// "namespace/name", or "namespace/*" for every service account of a
+// namespace. Service accounts without an entry are rejected.
+func (k *KubernetesTokens) SetProjects(projects map[string]string) {
+	k.mu.Lock()
+	k.projects = projects
+	k.mu.Unlock()
+}
+
+func (k *KubernetesTokens) project(namespace, name string) (string, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if p, ok := k.projects[namespace+"/"+name]; ok {
+		return p, true
+	}
+	p, ok := k.projects[namespace+"/*"]
+	return p, ok
+}
+
+// verify validates a projected service account token and maps its service
+// account to BridgeClaims. The token's subject is kept as the caller's
+// subject, e.g. "system:serviceaccount:team-a:runner".
+func (k *KubernetesTokens) verify(tokenString string) (*BridgeClaims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithAudience(k.Audience),
+		jwt.WithIssuer(k.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	mc := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(tokenString, mc, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("missing kid header")
+		}
+		key, ok := k.JWKS.Key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %s", kid)
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verify kubernetes token: %w", err)
+	}
+
+	sub, _ := mc.GetSubject()
+	rest, ok := strings.CutPrefix(sub, "system:serviceaccount:")
+	namespace, name, ok2 := strings.Cut(rest, ":")
+	if !ok || !ok2 || namespace == "" || name == "" {
+		return nil, fmt.Errorf("verify kubernetes token: subject %q is not a service account", sub)
+	}
+	projectID, ok := k.project(namespace, name)
+	if !ok || projectID == "" {
+		return nil, fmt.Errorf("verify kubernetes token: service account %s/%s is not mapped to a project", namespace, name)
+	}
+
+	claims := &BridgeClaims{ProjectID: projectID}
+	claims.Issuer, _ = mc.GetIssuer()
+	claims.Subject = sub
+	claims.Audience, _ = mc.GetAudience()
+	claims.IssuedAt, _ = mc.GetIssuedAt()
+	claims.ExpiresAt, _ = mc.GetExpirationTime()
+	return claims, nil
+}
+
+// KubernetesAPIClient returns an HTTP client for fetching the API server's
+// service account JWKS from inside a pod: it trusts the CA in caPath and
+// sends the token in tokenPath as a bearer token, re-reading it on every
+// request since the kubelet rotates it. Empty paths use the mounted
+// service account's.
+func KubernetesAPIClient(caPath, tokenPath string) (*http.Client, error) {
+	if caPath == "" {
+		caPath = KubernetesCAPath
+	}
+	if tokenPath == "" {
+		tokenPath = KubernetesTokenPath
+	}
+	pem, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("read kubernetes ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("kubernetes ca %s: no certificates found", caPath)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool}
+	return &http.Client{Transport: &bearerFileTransport{path: tokenPath, next: transport}}, nil
+}
+
+// bearerFileTransport authorizes requests with the token read from path.
+type bearerFileTransport struct {
+	path string
+	next http.RoundTripper
+}
+
+func (t *bearerFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := os.ReadFile(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("read service account token: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTVerifyKubernetes(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ec key: %v", err)
+	}
+	srv := newOIDCServer(t, &rsaKey.PublicKey, &ecKey.PublicKey)
+	cache := &JWKSCache{URL: srv.URL + "/keys"}
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	const issuer = "https://kubernetes.default.svc.cluster.local"
+	k8s := &KubernetesTokens{Issuer: issuer, Audience: "ai-agent-bridge", JWKS: cache}
+	k8s.SetProjects(map[string]string{
+		"team-a/runner": "alpha",
+		"team-b/*":      "beta",
+	})
+	verifier := &JWTVerifier{Audience: "bridge", MaxTTL: 5 * time.Minute, Kubernetes: k8s}
+
+	now := time.Now()
+	claims := func(sub string, mut func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss": issuer,
+			"sub": sub,
+			"aud": []string{"ai-agent-bridge"},
+			"iat": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		if mut != nil {
+			mut(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		wantProject string
+		wantErr     string
+	}{
+		{name: "exact account", token: mintOIDC(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims("system:serviceaccount:team-a:runner", nil)), wantProject: "alpha"},
+		{name: "namespace wildcard", token: mintOIDC(t, jwt.SigningMethodES256, ecKey, "ec-1", claims("system:serviceaccount:team-b:ci", nil)), wantProject: "beta"},
+		{name: "unmapped account", token: mintOIDC(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims("system:serviceaccount:team-a:other", nil)), wantErr: "not mapped"},
+		{name: "not a service account", token: mintOIDC(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims("alice", nil)), wantErr: "not a service account"},
+		{name: "wrong audience", token: mintOIDC(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims("system:serviceaccount:team-a:runner", func(c jwt.MapClaims) { c["aud"] = "https://kubernetes.default.svc" })), wantErr: "audience"},
+		{name: "expired", token: mintOIDC(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims("system:serviceaccount:team-a:runner", func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() })), wantErr: "expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.token)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify err=%v want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if got.ProjectID != tt.wantProject || !strings.HasPrefix(got.Subject, "system:serviceaccount:") {
+				t.Fatalf("claims=%+v want project %s", got, tt.wantProject)
+			}
+		})
+	}
+}
+
+func TestKubernetesAPIClientSendsCurrentToken(t *testing.T) {
+	var gotAuth []string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.crt")
+	tokenPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600); err != nil {
+		t.Fatalf("write ca: %v", err)
+	}
+	if err := os.WriteFile(tokenPath, []byte("first\n"), 0o600); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+	client, err := KubernetesAPIClient(caPath, tokenPath)
+	if err != nil {
+		t.Fatalf("KubernetesAPIClient: %v", err)
+	}
+	cache := &JWKSCache{URL: srv.URL, Client: client}
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	// The kubelet rotates the projected token in place.
+	if err := os.WriteFile(tokenPath, []byte("second"), 0o600); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if len(gotAuth) != 2 || gotAuth[0] != "Bearer first" || gotAuth[1] != "Bearer second" {
+		t.Fatalf("Authorization headers=%q", gotAuth)
+	}
+}
@@ -5,6 +5,10 @@ import (
 	"crypto/x509"
 	"fmt"
 	"os"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
 )
 
 // TLSConfig holds paths for mTLS configuration.
@@ -13,6 +17,22 @@ type TLSConfig struct {
 	CertPath     string // Server or client certificate
 	KeyPath      string // Server or client private key
 	ServerName   string // For client-side server name verification
+	// KeyPassphrase supplies the passphrase of an encrypted KeyPath. Nil
+	// uses pki.EnvPassphrase.
+	KeyPassphrase pki.Passphrase
+	// CRLPath, server side only, names a file of PEM CRLs issued by CAs in
+	// the bundle. Client certificates listed in it are rejected. A missing
+	// file means nothing has been revoked yet.
+	CRLPath string
+	// ACME, used by CertReloader only, supplies the server certificate in
+	// place of CertPath and KeyPath.
+	ACME *autocert.Manager
+	// SPIFFE, used by CertReloader only, also accepts client certificates
+	// issued from SPIFFE.BundlePath and keeps that bundle current.
+	SPIFFE *SPIFFEIdentities
+	// SystemRoots, client side only, also trusts the system root CAs for
+	// the server certificate, e.g. for a bridge with an ACME certificate.
+	SystemRoots bool
 }
 
 // ServerTLSConfig returns a TLS config that REQUIRES and verifies client certs (mTLS).
@@ -23,16 +43,22 @@ func ServerTLSConfig(cfg TLSConfig) (*tls.Config, error) {
 		return nil, err
 	}
 
-	cert, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath)
+	cert, err := pki.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath, cfg.KeyPassphrase)
 	if err != nil {
 		return nil, fmt.Errorf("load server keypair: %w", err)
 	}
 
+	revoked, err := loadRevocations(cfg.CRLPath, cfg.CABundlePath)
+	if err != nil {
+		return nil, err
+	}
+
 	return &tls.Config{
-		MinVersion:   tls.VersionTLS13,
-		Certificates: []tls.Certificate{cert},
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-		ClientCAs:    caPool,
+		MinVersion:            tls.VersionTLS13,
+		Certificates:          []tls.Certificate{cert},
+		ClientAuth:            tls.RequireAndVerifyClientCert,
+		ClientCAs:             caPool,
+		VerifyPeerCertificate: revoked.verifyPeer,
 	}, nil
 }
 
@@ -43,8 +69,20 @@ func ClientTLSConfig(cfg TLSConfig) (*tls.Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if cfg.SystemRoots {
+		sys, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("load system roots: %w", err)
+		}
+		caPEM, err := os.ReadFile(cfg.CABundlePath)
+		if err != nil {
+			return nil, fmt.Errorf("read ca bundle: %w", err)
+		}
+		sys.AppendCertsFromPEM(caPEM)
+		caPool = sys
+	}
 
-	cert, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath)
+	cert, err := pki.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath, cfg.KeyPassphrase)
 	if err != nil {
 		return nil, fmt.Errorf("load client keypair: %w", err)
 	}
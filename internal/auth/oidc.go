@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultProjectClaim is the OIDC claim mapped to project_id when
+// OIDCProvider.ProjectClaim is empty.
+const DefaultProjectClaim = "project_id"
+
+// OIDCProvider accepts RS256 and ES256 tokens issued by an external OpenID
+// Connect provider, such as a corporate SSO. Signing keys come from the
+// provider's JWKS.
+type OIDCProvider struct {
+	// Issuer must equal the token's iss claim exactly.
+	Issuer string
+	// Audience is the required aud claim, usually the OIDC client ID.
+	Audience string
+	// ProjectClaim names the string claim used as project_id. Tokens
+	// without it are rejected.
+	ProjectClaim string
+	JWKS         *JWKSCache
+}
+
+// DiscoverJWKSURL returns the jwks_uri from the issuer's OpenID Connect
+// discovery document.
+func DiscoverJWKSURL(ctx context.Context, issuer string, client *http.Client) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return "", fmt.Errorf("oidc discovery request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oidc discovery: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oidc discovery: %s", resp.Status)
+	}
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&doc); err != nil {
+		return "", fmt.Errorf("oidc discovery: %w", err)
+	}
+	// OpenID Connect Discovery 1.0 §4.3: the document must name the issuer
+	// it was fetched for.
+	if doc.Issuer != issuer {
+		return "", fmt.Errorf("oidc discovery: issuer %q does not match %q", doc.Issuer, issuer)
+	}
+	if doc.JWKSURI == "" {
+		return "", errors.New("oidc discovery: jwks_uri missing")
+	}
+	return doc.JWKSURI, nil
+}
+
+// oidcScopes reads the scopes claim. A malformed claim is an error rather
+// than unrestricted access.
+func oidcScopes(mc jwt.MapClaims) (jwt.ClaimStrings, error) {
+	v, ok := mc["scopes"]
+	if !ok {
+		return nil, nil
+	}
+	var scopes jwt.ClaimStrings
+	raw, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(raw, &scopes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid scopes claim: %w", err)
+	}
+	return scopes, nil
+}
+
+// verify validates an OIDC token and maps it to BridgeClaims.
+func (p *OIDCProvider) verify(tokenString string) (*BridgeClaims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithAudience(p.Audience),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	mc := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(tokenString, mc, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("missing kid header")
+		}
+		key, ok := p.JWKS.Key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %s", kid)
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verify oidc token: %w", err)
+	}
+
+	claimName := p.ProjectClaim
+	if claimName == "" {
+		claimName = DefaultProjectClaim
+	}
+	// An empty project_id grants access to every project, so the claim is
+	// required rather than defaulted.
+	projectID, _ := mc[claimName].(string)
+	if projectID == "" {
+		return nil, fmt.Errorf("verify oidc token: missing %s claim", claimName)
+	}
+
+	scopes, err := oidcScopes(mc)
+	if err != nil {
+		return nil, fmt.Errorf("verify oidc token: %w", err)
+	}
+
+	claims := &BridgeClaims{ProjectID: projectID, Scopes: scopes}
+	claims.Issuer, _ = mc.GetIssuer()
+	claims.Subject, _ = mc.GetSubject()
+	claims.Audience, _ = mc.GetAudience()
+	claims.IssuedAt, _ = mc.GetIssuedAt()
+	claims.ExpiresAt, _ = mc.GetExpirationTime()
+	return claims, nil
+}
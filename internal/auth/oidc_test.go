@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// newOIDCServer serves a discovery document and a JWKS with one RSA and one
+// P-256 key.
+func newOIDCServer(t *testing.T, rsaPub *rsa.PublicKey, ecPub *ecdsa.PublicKey) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": srv.URL, "jwks_uri": srv.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		size := 32
+		x, y := make([]byte, size), make([]byte, size)
+		ecPub.X.FillBytes(x)
+		ecPub.Y.FillBytes(y)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaPub.N.Bytes()), "e": b64(big.NewInt(int64(rsaPub.E)).Bytes())},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(x), "y": b64(y)},
+		}})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func mintOIDC(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	tok.Header["kid"] = kid
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return s
+}
+
+func TestJWTVerifyOIDC(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ec key: %v", err)
+	}
+	srv := newOIDCServer(t, &rsaKey.PublicKey, &ecKey.PublicKey)
+
+	jwksURL, err := DiscoverJWKSURL(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("DiscoverJWKSURL: %v", err)
+	}
+	cache := &JWKSCache{URL: jwksURL}
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	verifier := &JWTVerifier{
+		Audience: "bridge",
+		MaxTTL:   5 * time.Minute,
+		OIDC:     &OIDCProvider{Issuer: srv.URL, Audience: "bridge-client", ProjectClaim: "team", JWKS: cache},
+	}
+
+	now := time.Now()
+	claims := func(mut func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":  srv.URL,
+			"sub":  "alice@example.com",
+			"aud":  "bridge-client",
+			"iat":  now.Unix(),
+			"exp":  now.Add(time.Hour).Unix(), // longer than MaxTTL
+			"team": "payments",
+		}
+		if mut != nil {
+			mut(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{name: "rs256", token: mintOIDC(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims(nil))},
+		{name: "es256", token: mintOIDC(t, jwt.SigningMethodES256, ecKey, "ec-1", claims(nil))},
+		{name: "wrong audience", token: mintOIDC(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims(func(c jwt.MapClaims) { c["aud"] = "other" })), wantErr: "audience"},
+		{name: "missing project claim", token: mintOIDC(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims(func(c jwt.MapClaims) { delete(c, "team") })), wantErr: "missing team claim"},
+		{name: "expired", token: mintOIDC(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims(func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() })), wantErr: "expired"},
+		{name: "key type mismatch", token: mintOIDC(t, jwt.SigningMethodES256, ecKey, "rsa-1", claims(nil)), wantErr: "verify oidc token"},
+		{name: "hs256 rejected", token: mintOIDC(t, jwt.SigningMethodHS256, []byte("secret"), "rsa-1", claims(nil)), wantErr: "signing method"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.token)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify err=%v want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if got.ProjectID != "payments" || got.Subject != "alice@example.com" {
+				t.Fatalf("claims=%+v want project payments for alice", got)
+			}
+		})
+	}
+}
+
+func TestDiscoverJWKSURLRejectsIssuerMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"issuer":"https://evil.example.com","jwks_uri":"https://evil.example.com/keys"}`))
+	}))
+	defer srv.Close()
+	if _, err := DiscoverJWKSURL(context.Background(), srv.URL, nil); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("DiscoverJWKSURL err=%v want issuer mismatch", err)
+	}
+}
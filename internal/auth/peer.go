@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+	"crypto/x509"
+	"slices"
 
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/peer"
@@ -21,3 +23,59 @@ func callerCommonName(ctx context.Context) string {
 	}
 	return tlsInfo.State.PeerCertificates[0].Subject.CommonName
 }
+
+// callerCert returns the caller's verified client certificate, or nil.
+func callerCert(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p == nil {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return nil
+	}
+	return tlsInfo.State.VerifiedChains[0][0]
+}
+
+// connectionFields returns audit attributes describing the caller's
+// connection: its remote address and, over mTLS, the client certificate's
+// subject, serial, issuer and URI SANs. They tie the token subject of a
+// record to the transport identity that presented it.
+func connectionFields(ctx context.Context) []any {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p == nil {
+		return []any{"caller_cn", ""}
+	}
+	var fields []any
+	if p.Addr != nil {
+		fields = append(fields, "remote_addr", p.Addr.String())
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return append(fields, "caller_cn", "")
+	}
+	cert := tlsInfo.State.PeerCertificates[0]
+	fields = append(fields,
+		"caller_cn", cert.Subject.CommonName,
+		"caller_serial", cert.SerialNumber.String(),
+		"caller_issuer", cert.Issuer.CommonName,
+	)
+	if len(cert.Subject.OrganizationalUnit) > 0 {
+		fields = append(fields, "caller_ou", cert.Subject.OrganizationalUnit)
+	}
+	if len(cert.URIs) > 0 {
+		uris := make([]string, len(cert.URIs))
+		for i, u := range cert.URIs {
+			uris[i] = u.String()
+		}
+		fields = append(fields, "caller_uris", uris)
+	}
+	return fields
+}
+
+// callerHasOU reports whether the caller's client certificate lists ou as
+// an organizational unit.
+func callerHasOU(ctx context.Context, ou string) bool {
+	cert := callerCert(ctx)
+	return cert != nil && slices.Contains(cert.Subject.OrganizationalUnit, ou)
+}
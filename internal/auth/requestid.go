@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader is the metadata key a caller may set to choose the ID of
+// an RPC. The server returns the ID in the response header of the same
+// name.
+const RequestIDHeader = "x-request-id"
+
+// maxRequestIDLen bounds caller-chosen request IDs.
+const maxRequestIDLen = 128
+
+// requestIDFor returns the caller's x-request-id when it is usable, or a
+// new random ID.
+func requestIDFor(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, id := range md.Get(RequestIDHeader) {
+		if validRequestID(id) {
+			return id
+		}
+	}
+	return uuid.NewString()
+}
+
+// validRequestID accepts printable ASCII without spaces, so that an ID
+// cannot break up a log line.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// UnaryRequestIDInterceptor gives every RPC a request ID, the caller's
+// x-request-id or a new one, stores it in the context for the handler and
+// later interceptors (see logging.RequestID), and returns it in the
+// x-request-id response header. It must come first in the chain so that
+// the audit record carries the ID.
+func UnaryRequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		id := requestIDFor(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+		return handler(logging.ContextWithRequestID(ctx, id), req)
+	}
+}
+
+// StreamRequestIDInterceptor is UnaryRequestIDInterceptor for streams.
+func StreamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := requestIDFor(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(RequestIDHeader, id))
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: logging.ContextWithRequestID(ss.Context(), id)})
+	}
+}
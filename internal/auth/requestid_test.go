@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// headerStream records the headers set on it.
+type headerStream struct {
+	testServerStream
+	header metadata.MD
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestRequestIDInterceptors(t *testing.T) {
+	unary := UnaryRequestIDInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/bridge.v1.BridgeService/WriteInput"}
+	idOf := func(ctx context.Context) string {
+		var got string
+		_, _ = unary(ctx, nil, info, func(ctx context.Context, _ any) (any, error) {
+			got = logging.RequestID(ctx)
+			return nil, nil
+		})
+		return got
+	}
+
+	incoming := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, "ticket-4711"))
+	if got := idOf(incoming); got != "ticket-4711" {
+		t.Fatalf("caller's request ID = %q want ticket-4711", got)
+	}
+	generated := idOf(context.Background())
+	if generated == "" || generated == idOf(context.Background()) {
+		t.Fatalf("generated request IDs %q are not unique", generated)
+	}
+	for _, bad := range []string{"two words", "line\nbreak", strings.Repeat("x", maxRequestIDLen+1)} {
+		incoming := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, bad))
+		if got := idOf(incoming); got == bad || got == "" {
+			t.Fatalf("request ID %q kept as %q", bad, got)
+		}
+	}
+
+	// The stream interceptor returns the ID in the response header, and the
+	// audit interceptor after it logs the ID.
+	var buf bytes.Buffer
+	audit := StreamAuditInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)))
+	ss := &headerStream{testServerStream: testServerStream{ctx: metadata.NewIncomingContext(
+		authContextWithClaims(context.Background(), "project-a", "user-a"),
+		metadata.Pairs(RequestIDHeader, "ticket-4712"),
+	)}}
+	streamInfo := &grpc.StreamServerInfo{FullMethod: "/bridge.v1.BridgeService/AttachSession"}
+	err := StreamRequestIDInterceptor()(nil, ss, streamInfo, func(srv any, ss grpc.ServerStream) error {
+		return audit(srv, ss, streamInfo, func(any, grpc.ServerStream) error { return nil })
+	})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if got := ss.header.Get(RequestIDHeader); len(got) != 1 || got[0] != "ticket-4712" {
+		t.Fatalf("response header = %v", ss.header)
+	}
+	if !strings.Contains(buf.String(), `"request_id":"ticket-4712"`) {
+		t.Fatalf("audit record %s has no request_id", buf.String())
+	}
+}
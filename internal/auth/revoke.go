@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TokenRevocations rejects tokens revoked through AdminService.RevokeToken.
+// A token is revoked by its jti, or by subject: every token of the subject
+// issued at or before the revocation. Revocations are kept in a JSON file
+// so they survive restarts.
+type TokenRevocations struct {
+	path string
+
+	mu sync.RWMutex
+	// IDs maps revoked jti values to when they were revoked; Subjects maps
+	// subjects to the cutoff for their tokens' iat.
+	IDs      map[string]time.Time `json:"ids,omitempty"`
+	Subjects map[string]time.Time `json:"subjects,omitempty"`
+}
+
+// LoadTokenRevocations reads the revocations kept at path. A missing file
+// yields an empty set. An empty path keeps revocations in memory only.
+func LoadTokenRevocations(path string) (*TokenRevocations, error) {
+	r := &TokenRevocations{path: path, IDs: map[string]time.Time{}, Subjects: map[string]time.Time{}}
+	if path == "" {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read token revocations: %w", err)
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("parse token revocations %s: %w", path, err)
+	}
+	if r.IDs == nil {
+		r.IDs = map[string]time.Time{}
+	}
+	if r.Subjects == nil {
+		r.Subjects = map[string]time.Time{}
+	}
+	return r, nil
+}
+
+// RevokeID rejects the token whose jti is id.
+func (r *TokenRevocations) RevokeID(id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.IDs[id] = at
+	return r.saveLocked()
+}
+
+// RevokeSubject rejects the subject's tokens issued at or before at.
+func (r *TokenRevocations) RevokeSubject(subject string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Subjects[subject] = at
+	return r.saveLocked()
+}
+
+// Revoked reports whether claims belong to a revoked token. A nil
+// TokenRevocations revokes nothing.
+func (r *TokenRevocations) Revoked(claims *BridgeClaims) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if claims.ID != "" {
+		if _, ok := r.IDs[claims.ID]; ok {
+			return true
+		}
+	}
+	cutoff, ok := r.Subjects[claims.Subject]
+	if !ok {
+		return false
+	}
+	// A token without iat cannot be placed after the cutoff.
+	return claims.IssuedAt == nil || !claims.IssuedAt.After(cutoff)
+}
+
+// Len returns the number of revocations in force.
+func (r *TokenRevocations) Len() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.IDs) + len(r.Subjects)
+}
+
+func (r *TokenRevocations) saveLocked() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("write token revocations: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".revoked-*")
+	if err != nil {
+		return fmt.Errorf("write token revocations: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write token revocations: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write token revocations: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("write token revocations: %w", err)
+	}
+	return nil
+}
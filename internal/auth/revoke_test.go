@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+func TestTokenRevocations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revoked-tokens.json")
+	r, err := LoadTokenRevocations(path)
+	if err != nil {
+		t.Fatalf("LoadTokenRevocations: %v", err)
+	}
+	cutoff := time.Now()
+	claims := func(id, sub string, iat time.Time) *BridgeClaims {
+		return &BridgeClaims{RegisteredClaims: jwt.RegisteredClaims{ID: id, Subject: sub, IssuedAt: jwt.NewNumericDate(iat)}}
+	}
+	if r.Revoked(claims("tok-1", "alice", cutoff)) {
+		t.Fatal("empty set revoked a token")
+	}
+	if err := r.RevokeID("tok-1", cutoff); err != nil {
+		t.Fatalf("RevokeID: %v", err)
+	}
+	if err := r.RevokeSubject("bob", cutoff); err != nil {
+		t.Fatalf("RevokeSubject: %v", err)
+	}
+
+	// Revocations survive a reload from disk.
+	r, err = LoadTokenRevocations(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	tests := []struct {
+		name   string
+		claims *BridgeClaims
+		want   bool
+	}{
+		{name: "revoked id", claims: claims("tok-1", "alice", cutoff), want: true},
+		{name: "other id", claims: claims("tok-2", "alice", cutoff), want: false},
+		{name: "subject before cutoff", claims: claims("tok-3", "bob", cutoff.Add(-time.Minute)), want: true},
+		{name: "subject after cutoff", claims: claims("tok-4", "bob", cutoff.Add(time.Minute)), want: false},
+		{name: "subject without iat", claims: &BridgeClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "bob"}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Revoked(tt.claims); got != tt.want {
+				t.Fatalf("Revoked=%v want %v", got, tt.want)
+			}
+		})
+	}
+	if r.Len() != 2 {
+		t.Fatalf("Len=%d want 2", r.Len())
+	}
+	var none *TokenRevocations
+	if none.Revoked(claims("tok-1", "bob", cutoff)) || none.Len() != 0 {
+		t.Fatal("nil TokenRevocations revoked a token")
+	}
+}
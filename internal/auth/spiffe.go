@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// SPIFFEIdentities authenticates SPIRE workloads by their X.509 SVID. A
+// client certificate that chains to the trust domain's bundle and carries
+// a mapped SPIFFE ID is accepted without a JWT.
+type SPIFFEIdentities struct {
+	// TrustDomain is the only trust domain whose IDs are accepted, e.g.
+	// "example.org".
+	TrustDomain string
+	// BundlePath is the PEM X.509 bundle of TrustDomain, as written by the
+	// SPIRE agent. CertReloader loads it and keeps it current.
+	BundlePath string
+
+	mu       sync.RWMutex
+	roots    *x509.CertPool
+	projects map[string]string
+}
+
+// SetProjects replaces the SPIFFE ID to project mapping. IDs without an
+// entry are not authenticated by their SVID.
+func (s *SPIFFEIdentities) SetProjects(projects map[string]string) {
+	s.mu.Lock()
+	s.projects = projects
+	s.mu.Unlock()
+}
+
+func (s *SPIFFEIdentities) setRoots(roots *x509.CertPool) {
+	s.mu.Lock()
+	s.roots = roots
+	s.mu.Unlock()
+}
+
+// svidID returns the SPIFFE ID of cert and its trust domain. An X.509 SVID
+// carries exactly one URI SAN with the spiffe scheme.
+func svidID(cert *x509.Certificate) (string, string, bool) {
+	if len(cert.URIs) != 1 || cert.URIs[0].Scheme != "spiffe" {
+		return "", "", false
+	}
+	return cert.URIs[0].String(), cert.URIs[0].Host, true
+}
+
+// fromPeer authenticates the connection's client certificate. It returns
+// nil claims and a nil error when the certificate is not an SVID, so the
+// caller can fall back to other credentials.
+func (s *SPIFFEIdentities) fromPeer(ctx context.Context) (*BridgeClaims, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p == nil {
+		return nil, nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return nil, nil
+	}
+	return s.authenticate(tlsInfo.State)
+}
+
+func (s *SPIFFEIdentities) authenticate(state tls.ConnectionState) (*BridgeClaims, error) {
+	leaf := state.PeerCertificates[0]
+	id, trustDomain, ok := svidID(leaf)
+	if !ok {
+		return nil, nil
+	}
+	if trustDomain != s.TrustDomain {
+		return nil, fmt.Errorf("spiffe id %s is not in trust domain %s", id, s.TrustDomain)
+	}
+
+	s.mu.RLock()
+	roots, projectID := s.roots, s.projects[id]
+	s.mu.RUnlock()
+	if roots == nil {
+		return nil, errors.New("spiffe bundle not loaded")
+	}
+	// The TLS layer also accepts bridge-ca certificates, so the SPIFFE ID
+	// only counts when the chain leads to the trust domain's own bundle.
+	intermediates := x509.NewCertPool()
+	for _, c := range state.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return nil, fmt.Errorf("spiffe svid %s: %w", id, err)
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("spiffe id %s is not mapped to a project", id)
+	}
+
+	claims := &BridgeClaims{ProjectID: projectID}
+	claims.Issuer = "spiffe://" + s.TrustDomain
+	claims.Subject = id
+	return claims, nil
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// svidCA creates a self-signed SPIRE-style CA for trustDomain.
+func svidCA(t *testing.T, trustDomain string) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		URIs:                  []*url.URL{{Scheme: "spiffe", Host: trustDomain}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+// issueSVID returns a client certificate for id signed by ca.
+func issueSVID(t *testing.T, ca *x509.Certificate, caKey crypto.Signer, id string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	u, _ := url.Parse(id)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		URIs:         []*url.URL{u},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func peerContext(cert tls.Certificate) context.Context {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{})
+	return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert.Leaf}},
+	}})
+}
+
+func TestSPIFFEIdentities(t *testing.T) {
+	dir := t.TempDir()
+	caCertPath, caKeyPath, err := pki.InitCA("bridge-ca", dir)
+	if err != nil {
+		t.Fatalf("InitCA: %v", err)
+	}
+	bridgeCA, bridgeKey := mustLoadCA(t, caCertPath, caKeyPath), mustLoadCAKey(t, caCertPath, caKeyPath)
+	serverCert, serverKey, err := pki.IssueCert(bridgeCA, bridgeKey, pki.CertTypeServer, "bridge.local", []string{"bridge.local"}, dir)
+	if err != nil {
+		t.Fatalf("IssueCert: %v", err)
+	}
+	bundle := filepath.Join(dir, "bundle.crt")
+	if err := pki.BuildBundle(bundle, caCertPath); err != nil {
+		t.Fatalf("BuildBundle: %v", err)
+	}
+
+	spireCA, spireKey := svidCA(t, "example.org")
+	spireBundle := filepath.Join(dir, "spire-bundle.pem")
+	if err := os.WriteFile(spireBundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: spireCA.Raw}), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	ids := &SPIFFEIdentities{TrustDomain: "example.org", BundlePath: spireBundle}
+	ids.SetProjects(map[string]string{
+		"spiffe://example.org/ci/runner": "project-ci",
+		"spiffe://other.org/ci/runner":   "project-ci",
+	})
+	r, err := NewCertReloader(TLSConfig{CABundlePath: bundle, CertPath: serverCert, KeyPath: serverKey, SPIFFE: ids}, slogDiscardLogger())
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+
+	runner := issueSVID(t, spireCA, spireKey, "spiffe://example.org/ci/runner")
+	pool := x509.NewCertPool()
+	pool.AddCert(bridgeCA)
+	if err := handshake(t, r.TLSConfig(), &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		Certificates: []tls.Certificate{runner},
+		RootCAs:      pool,
+		ServerName:   "bridge.local",
+	}); err != nil {
+		t.Fatalf("handshake with SVID: %v", err)
+	}
+
+	verifier := &JWTVerifier{Audience: "bridge", SPIFFE: ids}
+	claims, err := extractAndVerify(peerContext(runner), verifier)
+	if err != nil {
+		t.Fatalf("extractAndVerify SVID: %v", err)
+	}
+	if claims.ProjectID != "project-ci" || claims.Subject != "spiffe://example.org/ci/runner" {
+		t.Fatalf("claims = %+v", claims)
+	}
+
+	// A certificate with a spiffe URI from the bridge CA is not an SVID of
+	// the trust domain, even though TLS accepts it.
+	bridgeIssued := issueSVID(t, bridgeCA, bridgeKey, "spiffe://example.org/ci/runner")
+	otherCA, otherKey := svidCA(t, "other.org")
+	tests := []struct {
+		name    string
+		cert    tls.Certificate
+		wantErr string
+	}{
+		{name: "unmapped id", cert: issueSVID(t, spireCA, spireKey, "spiffe://example.org/ci/other"), wantErr: "not mapped to a project"},
+		{name: "foreign trust domain", cert: issueSVID(t, spireCA, spireKey, "spiffe://other.org/ci/runner"), wantErr: "not in trust domain"},
+		{name: "other CA", cert: issueSVID(t, otherCA, otherKey, "spiffe://example.org/ci/runner"), wantErr: "spiffe svid"},
+		{name: "bridge CA", cert: bridgeIssued, wantErr: "spiffe svid"},
+		{name: "not an svid", cert: issueSVID(t, bridgeCA, bridgeKey, "https://example.org/x"), wantErr: "missing authorization header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := extractAndVerify(peerContext(tt.cert), verifier)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err=%v want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+)
+
+// Approval is a tool call the agent is waiting for a human to allow or
+// deny. It is the Data of a ChunkTypeApprovalRequested chunk.
+type Approval struct {
+	// ID is the provider's identifier for the request; ResolveApproval
+	// answers it.
+	ID       string          `json:"id"`
+	ToolName string          `json:"tool_name"`
+	Input    json.RawMessage `json:"input,omitempty"`
+	// ToolUseID is the tool call the request is for, if the provider
+	// reports it.
+	ToolUseID string `json:"tool_use_id,omitempty"`
+}
+
+// ApprovalResolution is the Data of a ChunkTypeApprovalResolved chunk.
+type ApprovalResolution struct {
+	ID       string `json:"id"`
+	Approved bool   `json:"approved"`
+	Message  string `json:"message,omitempty"`
+	// ClientID is the writer that resolved the request.
+	ClientID string `json:"client_id"`
+}
+
+// ApprovalProvider is implemented by providers whose agent can ask the
+// bridge to approve tool calls instead of deciding by itself.
+type ApprovalProvider interface {
+	// ApprovalArgs are the arguments that make the agent send its
+	// permission prompts to the bridge. Empty means it cannot.
+	ApprovalArgs() []string
+}
+
+// providerSupportsApproval reports whether provider can route permission
+// prompts to the bridge.
+func providerSupportsApproval(provider Provider) bool {
+	ap, ok := provider.(ApprovalProvider)
+	return ok && len(ap.ApprovalArgs()) > 0
+}
+
+// defaultDenyMessage is sent to the agent when a request is denied without
+// a message.
+const defaultDenyMessage = "The user denied this action."
+
+// streamApprovalResponseFor returns the stdin message that answers approval
+// request a of a stream-JSON dialect, or nil when the dialect has none.
+func streamApprovalResponseFor(format string, a Approval, approve bool, message string) []byte {
+	switch format {
+	case JSONFormatOpenCode:
+		return nil
+	default:
+		var decision map[string]any
+		if approve {
+			input := a.Input
+			if len(input) == 0 {
+				input = json.RawMessage("{}")
+			}
+			decision = map[string]any{"behavior": "allow", "updatedInput": input}
+		} else {
+			decision = map[string]any{"behavior": "deny", "message": message}
+		}
+		msg, err := json.Marshal(map[string]any{
+			"type": "control_response",
+			"response": map[string]any{
+				"subtype":    "success",
+				"request_id": a.ID,
+				"response":   decision,
+			},
+		})
+		if err != nil {
+			return nil
+		}
+		return append(msg, '\n')
+	}
+}
+
+// requestApproval records a as pending and broadcasts it as a
+// ChunkTypeApprovalRequested chunk. The agent waits until it is resolved.
+func (s *Supervisor) requestApproval(ms *managedSession, a Approval) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		logger().Warn("encode approval request", "session_id", ms.info.SessionID, "error", err)
+		return
+	}
+	ms.mu.Lock()
+	ms.approvals = append(ms.approvals, a)
+	ms.mu.Unlock()
+	logger().Info("agent requested approval", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "approval_id", a.ID, "tool", a.ToolName)
+	s.appendChunkData(ms, []byte(a.ToolName), ChunkTypeApprovalRequested, data)
+}
+
+// ResolveApproval answers the pending approval request approvalID of a
+// stream-JSON session, allowing or denying the tool call. Like input, it
+// requires clientID to hold the writer slot. A denial tells the agent
+// message, or a generic refusal when it is empty; approvals ignore it. The
+// resolution is recorded with ctx's request ID.
+func (s *Supervisor) ResolveApproval(ctx context.Context, sessionID, clientID, approvalID string, approve bool, message string) error {
+	s.mu.RLock()
+	ms, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	if approve {
+		message = ""
+	} else if message == "" {
+		message = defaultDenyMessage
+	}
+	ms.mu.Lock()
+	if err := ms.checkWriterLocked(clientID); err != nil {
+		ms.mu.Unlock()
+		return err
+	}
+	i := slices.IndexFunc(ms.approvals, func(a Approval) bool { return a.ID == approvalID })
+	if i < 0 {
+		ms.mu.Unlock()
+		return fmt.Errorf("%w: %q", ErrApprovalNotFound, approvalID)
+	}
+	a := ms.approvals[i]
+	msg := streamApprovalResponseFor(ms.jsonFormat, a, approve, message)
+	if msg == nil {
+		ms.mu.Unlock()
+		return fmt.Errorf("%w: provider cannot answer approval requests", ErrInvalidArgument)
+	}
+	ms.approvals = slices.Delete(ms.approvals, i, i+1)
+	ms.lastActivity = time.Now()
+	stdin := ms.stdin
+	ms.mu.Unlock()
+
+	if _, err := stdin.Write(msg); err != nil {
+		if errors.Is(err, os.ErrClosed) || errors.Is(err, syscall.EPIPE) {
+			return fmt.Errorf("%w: %q", ErrSessionNotRunning, sessionID)
+		}
+		return err
+	}
+	requestID := logging.RequestID(ctx)
+	logger().Info("approval resolved", "session_id", sessionID, "approval_id", approvalID, "approved", approve, "client_id", clientID, logging.RequestIDKey, requestID)
+	data, err := json.Marshal(ApprovalResolution{ID: approvalID, Approved: approve, Message: message, ClientID: clientID})
+	if err != nil {
+		return err
+	}
+	s.appendRequestChunk(ms, []byte(a.ToolName), ChunkTypeApprovalResolved, data, requestID)
+	return nil
+}
+
+// dropApprovals discards the requests of an agent process that exited;
+// no one is left to answer them.
+func (ms *managedSession) dropApprovals() {
+	ms.mu.Lock()
+	ms.approvals = nil
+	ms.mu.Unlock()
+}
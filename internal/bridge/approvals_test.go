@@ -0,0 +1,125 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// approvalScriptProvider is a stream-JSON script that can ask for approval.
+type approvalScriptProvider struct{ streamJSONScriptProvider }
+
+func (p *approvalScriptProvider) ApprovalArgs() []string {
+	return []string{"--permission-prompt-tool", "stdio"}
+}
+
+// waitForChunkType returns the next chunk of type ctype on ch.
+func waitForChunkType(t *testing.T, ch <-chan OutputChunk, ctype ChunkType) OutputChunk {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case chunk := <-ch:
+			if chunk.Type == ctype {
+				return chunk
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for chunk type %d", ctype)
+		}
+	}
+}
+
+func TestResolveApproval(t *testing.T) {
+	registry := NewRegistry()
+	agent := &approvalScriptProvider{streamJSONScriptProvider{scriptProvider{
+		testProvider: testProvider{id: "agent"},
+		script: `echo '{"type":"control_request","request_id":"req-1","request":{"subtype":"can_use_tool","tool_name":"Bash","input":{"command":"ls"}}}'
+			read -r line; case "$line" in *'"behavior":"allow","updatedInput":{"command":"ls"}'*) echo allowed;; esac
+			echo '{"type":"control_request","request_id":"req-2","request":{"subtype":"can_use_tool","tool_name":"Write","input":{"path":"x"}}}'
+			read -r line; case "$line" in *'"behavior":"deny","message":"no writes"'*) echo denied;; esac
+			while :; do sleep 0.1; done`,
+	}}}
+	for _, p := range []Provider{agent, &testProvider{id: "plain"}} {
+		if err := registry.Register(p); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	policy := DefaultPolicy()
+	policy.Projects = map[string]ProjectPolicy{"proj": {RequireApproval: true}}
+	sup := NewSupervisor(registry, policy, 64*1024, time.Minute)
+	defer sup.Close()
+
+	// A provider that cannot ask for approval is refused.
+	if _, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "proj",
+		SessionID: "approval-plain",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "plain"},
+	}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("Start without approval support err=%v want %v", err, ErrInvalidArgument)
+	}
+
+	if _, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "proj",
+		SessionID: "approval-1",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "agent"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer func() { _ = sup.Stop("approval-1", true) }()
+	state, err := sup.Attach("approval-1", "client-a", 0, AttachRoleWriter)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	live := make(chan OutputChunk, 64)
+	for _, c := range state.Replay {
+		live <- c
+	}
+	go func() {
+		for c := range state.Live {
+			live <- c
+		}
+	}()
+
+	chunk := waitForChunkType(t, live, ChunkTypeApprovalRequested)
+	var req Approval
+	if err := json.Unmarshal(chunk.Data, &req); err != nil || req.ID != "req-1" || string(chunk.Payload) != "Bash" {
+		t.Fatalf("approval request %s %s, err %v", chunk.Payload, chunk.Data, err)
+	}
+	info, err := sup.Get("approval-1")
+	if err != nil || len(info.PendingApprovals) != 1 || info.PendingApprovals[0].ToolName != "Bash" {
+		t.Fatalf("pending approvals = %+v, err %v", info.PendingApprovals, err)
+	}
+
+	if err := sup.ResolveApproval(context.Background(), "approval-1", "client-b", "req-1", true, ""); !errors.Is(err, ErrClientMismatch) {
+		t.Fatalf("ResolveApproval wrong client err=%v want %v", err, ErrClientMismatch)
+	}
+	if err := sup.ResolveApproval(context.Background(), "approval-1", "client-a", "req-9", true, ""); !errors.Is(err, ErrApprovalNotFound) {
+		t.Fatalf("ResolveApproval unknown id err=%v want %v", err, ErrApprovalNotFound)
+	}
+	if err := sup.ResolveApproval(context.Background(), "approval-1", "client-a", "req-1", true, "ignored"); err != nil {
+		t.Fatalf("ResolveApproval: %v", err)
+	}
+	chunk = waitForChunkType(t, live, ChunkTypeApprovalResolved)
+	var res ApprovalResolution
+	if err := json.Unmarshal(chunk.Data, &res); err != nil || !res.Approved || res.ClientID != "client-a" || res.Message != "" {
+		t.Fatalf("approval resolution %s, err %v", chunk.Data, err)
+	}
+	waitForChunk(t, live, "allowed")
+
+	waitForChunkType(t, live, ChunkTypeApprovalRequested)
+	if err := sup.ResolveApproval(context.Background(), "approval-1", "client-a", "req-2", false, "no writes"); err != nil {
+		t.Fatalf("ResolveApproval deny: %v", err)
+	}
+	waitForChunk(t, live, "denied")
+	// A resolved request cannot be answered twice.
+	if err := sup.ResolveApproval(context.Background(), "approval-1", "client-a", "req-2", true, ""); !errors.Is(err, ErrApprovalNotFound) {
+		t.Fatalf("ResolveApproval twice err=%v want %v", err, ErrApprovalNotFound)
+	}
+	if info, _ := sup.Get("approval-1"); len(info.PendingApprovals) != 0 {
+		t.Fatalf("pending approvals after resolving = %+v", info.PendingApprovals)
+	}
+}
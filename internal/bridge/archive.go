@@ -0,0 +1,433 @@
+package bridge
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchivedSession is the snapshot of a terminal session written to a
+// SessionArchive: its final metadata and every output chunk that was still
+// buffered in memory when it was archived, plus the input written to it.
+type ArchivedSession struct {
+	Info       SessionInfo
+	Chunks     []OutputChunk
+	Inputs     []InputRecord `json:",omitempty"`
+	ArchivedAt time.Time
+}
+
+// SessionArchive stores snapshots of terminal sessions once they are pruned
+// from the supervisor's memory.
+type SessionArchive interface {
+	Save(session ArchivedSession) error
+	// Load returns the archived session, or ErrSessionNotFound when no
+	// snapshot exists for sessionID.
+	Load(sessionID string) (*ArchivedSession, error)
+	// Prune deletes the snapshots for which expired reports true, given
+	// the session ID and when it was archived, and returns their session
+	// IDs.
+	Prune(expired func(sessionID string, archivedAt time.Time) bool) ([]string, error)
+	// Delete removes the snapshot for sessionID, or returns
+	// ErrSessionNotFound when none exists.
+	Delete(sessionID string) error
+}
+
+// FileArchive implements SessionArchive with one JSON file per session in a
+// directory. Files are written atomically and readable only by the daemon
+// user, since transcripts may contain sensitive agent output. With an
+// encryption key they are also encrypted; see SetEncryptionKey.
+type FileArchive struct {
+	dir   string
+	key   []byte
+	keyID string
+}
+
+// NewFileArchive creates dir if needed and returns an archive rooted there.
+func NewFileArchive(dir string) (*FileArchive, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create archive dir %q: %w", dir, err)
+	}
+	return &FileArchive{dir: dir}, nil
+}
+
+// ArchiveKeySize is the size of a FileArchive encryption key in bytes.
+const ArchiveKeySize = 32
+
+// SetEncryptionKey makes Save encrypt snapshots. Each snapshot is sealed
+// with AES-256-GCM under its own random data key, which is stored with it
+// wrapped by key; the session ID is authenticated, so a file cannot be
+// passed off as another session's. Load reads both encrypted snapshots and
+// plain ones written before a key was set.
+func (a *FileArchive) SetEncryptionKey(key []byte) error {
+	if len(key) != ArchiveKeySize {
+		return fmt.Errorf("archive encryption key must be %d bytes, got %d", ArchiveKeySize, len(key))
+	}
+	sum := sha256.Sum256(key)
+	a.key = key
+	a.keyID = hex.EncodeToString(sum[:8])
+	return nil
+}
+
+// sealedArchive is the on-disk form of an encrypted snapshot.
+type sealedArchive struct {
+	// KeyID identifies the key that wrapped DataKey, so that a snapshot
+	// read with the wrong key fails with a clear error.
+	KeyID      string `json:"key_id"`
+	DataKey    []byte `json:"data_key"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// seal encrypts data with a fresh data key and wraps that with a.key.
+func (a *FileArchive) seal(sessionID string, data []byte) ([]byte, error) {
+	dataKey := make([]byte, ArchiveKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	ciphertext, err := gcmSeal(dataKey, data, []byte(sessionID))
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := gcmSeal(a.key, dataKey, []byte(sessionID))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealedArchive{KeyID: a.keyID, DataKey: wrapped, Ciphertext: ciphertext})
+}
+
+// open decrypts a snapshot written by seal.
+func (a *FileArchive) open(sessionID string, sealed sealedArchive) ([]byte, error) {
+	if a.key == nil {
+		return nil, errors.New("snapshot is encrypted and no archive encryption key is set")
+	}
+	if sealed.KeyID != a.keyID {
+		return nil, fmt.Errorf("snapshot is encrypted with key %s, not the configured key %s", sealed.KeyID, a.keyID)
+	}
+	dataKey, err := gcmOpen(a.key, sealed.DataKey, []byte(sessionID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return gcmOpen(dataKey, sealed.Ciphertext, []byte(sessionID))
+}
+
+// gcmSeal encrypts plaintext with AES-GCM under key and returns the nonce
+// followed by the ciphertext.
+func gcmSeal(key, plaintext, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func gcmOpen(key, sealed, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additionalData)
+}
+
+func (a *FileArchive) path(sessionID string) (string, error) {
+	if sessionID == "" || sessionID != filepath.Base(sessionID) || strings.HasPrefix(sessionID, ".") {
+		return "", fmt.Errorf("%w: invalid session id %q", ErrInvalidArgument, sessionID)
+	}
+	return filepath.Join(a.dir, sessionID+".json"), nil
+}
+
+// Save writes session to <dir>/<session_id>.json, replacing any previous
+// snapshot.
+func (a *FileArchive) Save(session ArchivedSession) error {
+	path, err := a.path(session.Info.SessionID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("marshal archived session %q: %w", session.Info.SessionID, err)
+	}
+	if a.key != nil {
+		if data, err = a.seal(session.Info.SessionID, data); err != nil {
+			return fmt.Errorf("encrypt archived session %q: %w", session.Info.SessionID, err)
+		}
+	}
+	tmp, err := os.CreateTemp(a.dir, ".archive-*")
+	if err != nil {
+		return fmt.Errorf("archive session %q: %w", session.Info.SessionID, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("archive session %q: %w", session.Info.SessionID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("archive session %q: %w", session.Info.SessionID, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("archive session %q: %w", session.Info.SessionID, err)
+	}
+	return nil
+}
+
+// Load reads the snapshot for sessionID.
+func (a *FileArchive) Load(sessionID string) (*ArchivedSession, error) {
+	path, err := a.path(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+		}
+		return nil, fmt.Errorf("read archived session %q: %w", sessionID, err)
+	}
+	var sealed sealedArchive
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return nil, fmt.Errorf("unmarshal archived session %q: %w", sessionID, err)
+	}
+	if sealed.Ciphertext != nil {
+		if data, err = a.open(sessionID, sealed); err != nil {
+			return nil, fmt.Errorf("decrypt archived session %q: %w", sessionID, err)
+		}
+	}
+	var session ArchivedSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("unmarshal archived session %q: %w", sessionID, err)
+	}
+	return &session, nil
+}
+
+// Prune deletes the snapshot files for which expired reports true, given
+// the time they were last written.
+func (a *FileArchive) Prune(expired func(sessionID string, archivedAt time.Time) bool) ([]string, error) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read archive dir %q: %w", a.dir, err)
+	}
+	var pruned []string
+	var errs []error
+	for _, e := range entries {
+		sessionID, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !expired(sessionID, info.ModTime()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(a.dir, e.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		pruned = append(pruned, sessionID)
+	}
+	return pruned, errors.Join(errs...)
+}
+
+// Delete removes the snapshot file for sessionID.
+func (a *FileArchive) Delete(sessionID string) error {
+	path, err := a.path(sessionID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+		}
+		return fmt.Errorf("delete archived session %q: %w", sessionID, err)
+	}
+	return nil
+}
+
+// WithArchive enables archival: terminal sessions that have been stopped for
+// longer than ttl, and have no attached clients, are snapshotted to archive
+// and removed from memory. Their metadata stays visible through Get and List
+// and their transcript through History and Attach.
+func WithArchive(archive SessionArchive, ttl time.Duration) SupervisorOption {
+	return func(s *Supervisor) {
+		s.archive = archive
+		s.archiveTTL = ttl
+	}
+}
+
+// WithArchiveRetention deletes archived sessions once they have been in the
+// archive for d. Zero keeps them forever.
+func WithArchiveRetention(d time.Duration) SupervisorOption {
+	return func(s *Supervisor) {
+		s.archiveRetention = d
+	}
+}
+
+// archiveExpired archives and prunes every terminal session whose StoppedAt
+// is older than its project's archive TTL. Sessions whose snapshot fails to
+// save stay in memory and are retried on the next pass. Archived sessions
+// past their project's retention period are then deleted.
+func (s *Supervisor) archiveExpired(now time.Time) {
+	if s.archive == nil {
+		return
+	}
+	policy := s.currentPolicy()
+	defer s.pruneArchive(now, &policy)
+	s.mu.RLock()
+	var expired []*managedSession
+	for _, ms := range s.sessions {
+		ms.mu.Lock()
+		terminal := ms.info.State == SessionStateStopped || ms.info.State == SessionStateFailed
+		ttl, _ := s.retention(&policy, ms.info.ProjectID)
+		if terminal && !ms.info.StoppedAt.IsZero() && now.Sub(ms.info.StoppedAt) >= ttl && len(ms.observers) == 0 {
+			expired = append(expired, ms)
+		}
+		ms.mu.Unlock()
+	}
+	s.mu.RUnlock()
+
+	for _, ms := range expired {
+		snapshot := ms.snapshotHistory()
+		snapshot.ArchivedAt = now.UTC()
+		if err := s.archive.Save(snapshot); err != nil {
+			logger().Warn("session archive: failed to archive session", "session_id", snapshot.Info.SessionID, "error", err)
+			continue
+		}
+		// A client may have attached, or the session been replaced, since
+		// it was found expired; archived marks it removed for Attach.
+		s.mu.Lock()
+		ms.mu.Lock()
+		stillExpired := s.sessions[snapshot.Info.SessionID] == ms &&
+			(ms.info.State == SessionStateStopped || ms.info.State == SessionStateFailed) &&
+			len(ms.observers) == 0
+		if stillExpired {
+			ms.archived = true
+			delete(s.sessions, snapshot.Info.SessionID)
+		}
+		ms.mu.Unlock()
+		s.mu.Unlock()
+		if !stillExpired {
+			continue
+		}
+		s.histMu.Lock()
+		s.history[snapshot.Info.SessionID] = snapshot.Info
+		s.histMu.Unlock()
+		logger().Info("session archived", "session_id", snapshot.Info.SessionID, "chunks", len(snapshot.Chunks))
+		s.dropSnapshot(ms)
+		s.removeWorkspace(ms)
+		if err := ms.buf.Close(); err != nil {
+			logger().Warn("session archive: failed to remove spill segment", "session_id", snapshot.Info.SessionID, "error", err)
+		}
+	}
+}
+
+// pruneArchive deletes archived sessions older than their project's
+// retention period, along with their persisted records, provider logs and
+// schedule transcripts.
+func (s *Supervisor) pruneArchive(now time.Time, policy *Policy) {
+	if !s.prunesArchive(policy) {
+		return
+	}
+	pruned, err := s.archive.Prune(func(sessionID string, archivedAt time.Time) bool {
+		s.histMu.RLock()
+		info := s.history[sessionID]
+		s.histMu.RUnlock()
+		_, keep := s.retention(policy, info.ProjectID)
+		return keep > 0 && now.Sub(archivedAt) > keep
+	})
+	if err != nil {
+		logger().Warn("session archive: failed to prune archive", "error", err)
+	}
+	if len(pruned) == 0 {
+		return
+	}
+	s.histMu.Lock()
+	for _, sessionID := range pruned {
+		delete(s.history, sessionID)
+	}
+	s.histMu.Unlock()
+	for _, sessionID := range pruned {
+		s.removeProviderLogs(sessionID)
+		if err := s.removeScheduleTranscripts(sessionID); err != nil {
+			logger().Warn("session archive: failed to delete schedule transcripts", "session_id", sessionID, "error", err)
+		}
+		if s.store == nil {
+			continue
+		}
+		if err := s.store.Delete(sessionID); err != nil {
+			logger().Warn("session archive: failed to delete persisted session", "session_id", sessionID, "error", err)
+		}
+	}
+	logger().Info("session archive: pruned archived sessions", "count", len(pruned))
+}
+
+// History returns the transcript of a session: its metadata and output
+// chunks. Sessions still in memory are snapshotted from their live buffer;
+// pruned sessions are read from the archive, then from the session store.
+func (s *Supervisor) History(sessionID string) (*ArchivedSession, error) {
+	s.mu.RLock()
+	ms, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if ok {
+		snapshot := ms.snapshotHistory()
+		return &snapshot, nil
+	}
+	return s.loadHistory(sessionID)
+}
+
+// snapshotHistory returns the session's metadata and in-memory chunks taken
+// under a single lock so the two are consistent. Chunks spilled to disk are
+// left out, so a snapshot is never larger than the buffer.
+func (ms *managedSession) snapshotHistory() ArchivedSession {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	info := ms.info
+	info.OldestSeq = ms.buf.memoryOldestSeq()
+	info.LastSeq = ms.buf.LastSeq()
+	inputs := append([]InputRecord(nil), ms.inputs...)
+	return ArchivedSession{Info: info, Chunks: ms.buf.After(0), Inputs: inputs}
+}
+
+// loadHistory reads a session that is no longer in memory from the archive
+// or, failing that, from the session store.
+func (s *Supervisor) loadHistory(sessionID string) (*ArchivedSession, error) {
+	if s.archive != nil {
+		session, err := s.archive.Load(sessionID)
+		if err == nil || !errors.Is(err, ErrSessionNotFound) {
+			return session, err
+		}
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	s.histMu.RLock()
+	info, ok := s.history[sessionID]
+	s.histMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	chunks, err := s.store.LoadChunks(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("load chunks for %q: %w", sessionID, err)
+	}
+	return &ArchivedSession{Info: info, Chunks: chunks}, nil
+}
@@ -0,0 +1,247 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileArchiveRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+	archive, err := NewFileArchive(dir)
+	if err != nil {
+		t.Fatalf("NewFileArchive: %v", err)
+	}
+
+	want := ArchivedSession{
+		Info:       SessionInfo{SessionID: "s1", ProjectID: "p", State: SessionStateStopped},
+		Chunks:     []OutputChunk{{Seq: 1, Payload: []byte("hello")}},
+		ArchivedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if err := archive.Save(want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "s1.json"))
+	if err != nil {
+		t.Fatalf("Stat archived file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("archived file perm=%o want 600", perm)
+	}
+
+	got, err := archive.Load("s1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Info.ProjectID != "p" || len(got.Chunks) != 1 || string(got.Chunks[0].Payload) != "hello" || !got.ArchivedAt.Equal(want.ArchivedAt) {
+		t.Fatalf("Load=%+v want %+v", got, want)
+	}
+
+	if _, err := archive.Load("missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Load missing error=%v want %v", err, ErrSessionNotFound)
+	}
+	for _, id := range []string{"../escape", "a/b", ".hidden", ""} {
+		if _, err := archive.Load(id); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("Load(%q) error=%v want %v", id, err, ErrInvalidArgument)
+		}
+	}
+}
+
+func TestFileArchiveEncryption(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+	archive, err := NewFileArchive(dir)
+	if err != nil {
+		t.Fatalf("NewFileArchive: %v", err)
+	}
+	plain := ArchivedSession{Info: SessionInfo{SessionID: "plain"}, Chunks: []OutputChunk{{Seq: 1, Payload: []byte("old")}}}
+	if err := archive.Save(plain); err != nil {
+		t.Fatalf("Save plain: %v", err)
+	}
+	if err := archive.SetEncryptionKey([]byte("short")); err == nil {
+		t.Fatal("SetEncryptionKey accepted a short key")
+	}
+	key := bytes.Repeat([]byte{7}, ArchiveKeySize)
+	if err := archive.SetEncryptionKey(key); err != nil {
+		t.Fatalf("SetEncryptionKey: %v", err)
+	}
+
+	secret := ArchivedSession{Info: SessionInfo{SessionID: "s1"}, Chunks: []OutputChunk{{Seq: 1, Payload: []byte("proprietary source")}}}
+	if err := archive.Save(secret); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "s1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("proprietary")) || bytes.Contains(raw, []byte("cHJvcHJpZXRhcnk")) {
+		t.Fatalf("archived file holds the plaintext: %s", raw)
+	}
+	got, err := archive.Load("s1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if string(got.Chunks[0].Payload) != "proprietary source" {
+		t.Fatalf("Load payload=%q", got.Chunks[0].Payload)
+	}
+	// Snapshots written before the key was set still load.
+	if got, err := archive.Load("plain"); err != nil || string(got.Chunks[0].Payload) != "old" {
+		t.Fatalf("Load plain=%+v err=%v", got, err)
+	}
+
+	// A snapshot renamed to another session fails authentication.
+	if err := os.WriteFile(filepath.Join(dir, "s2.json"), raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := archive.Load("s2"); err == nil {
+		t.Fatal("Load of a renamed snapshot succeeded")
+	}
+
+	other, err := NewFileArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Load("s1"); err == nil {
+		t.Fatal("Load without a key succeeded")
+	}
+	if err := other.SetEncryptionKey(bytes.Repeat([]byte{8}, ArchiveKeySize)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Load("s1"); err == nil || !strings.Contains(err.Error(), "not the configured key") {
+		t.Fatalf("Load with the wrong key err=%v", err)
+	}
+}
+
+func TestSupervisorArchivesExpiredSessions(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&testProvider{id: "fake"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	archive, err := NewFileArchive(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileArchive: %v", err)
+	}
+	sup := NewSupervisor(registry, DefaultPolicy(), 1024, time.Minute, WithArchive(archive, time.Minute))
+	t.Cleanup(sup.Close)
+
+	if _, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "proj-a",
+		SessionID: "archive-1",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "fake"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	state, err := sup.Attach("archive-1", "client-a", 0, AttachRoleWriter)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if _, err := sup.WriteInput("archive-1", "client-a", []byte("hello\n")); err != nil {
+		t.Fatalf("WriteInput: %v", err)
+	}
+	waitForChunk(t, state.Live, "hello")
+	if err := sup.Detach("archive-1", "client-a"); err != nil {
+		t.Fatalf("Detach: %v", err)
+	}
+	if err := sup.Stop("archive-1", true); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	waitForStopped(t, sup, "archive-1")
+
+	// Within the TTL the session stays in memory.
+	sup.archiveExpired(time.Now())
+	sup.mu.RLock()
+	_, live := sup.sessions["archive-1"]
+	sup.mu.RUnlock()
+	if !live {
+		t.Fatal("session archived before its TTL elapsed")
+	}
+
+	sup.archiveExpired(time.Now().Add(2 * time.Minute))
+	sup.mu.RLock()
+	_, live = sup.sessions["archive-1"]
+	sup.mu.RUnlock()
+	if live {
+		t.Fatal("expired session still in memory after archival")
+	}
+
+	info, err := sup.Get("archive-1")
+	if err != nil || info.ProjectID != "proj-a" {
+		t.Fatalf("Get after archival info=%+v err=%v", info, err)
+	}
+	if got := sup.List("proj-a"); len(got) != 1 {
+		t.Fatalf("List after archival len=%d want 1", len(got))
+	}
+
+	history, err := sup.History("archive-1")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if history.ArchivedAt.IsZero() || !containsPayload(history.Chunks, "hello") {
+		t.Fatalf("History=%+v want archived transcript containing hello", history)
+	}
+
+	replay, err := sup.Attach("archive-1", "client-b", 0, AttachRoleObserver)
+	if err != nil {
+		t.Fatalf("Attach archived: %v", err)
+	}
+	if !containsPayload(replay.Replay, "hello") {
+		t.Fatalf("Attach archived replay missing output: %+v", replay.Replay)
+	}
+	if _, ok := <-replay.Live; ok {
+		t.Fatal("Attach archived live channel should be closed")
+	}
+
+	if _, err := sup.History("never-existed"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("History unknown error=%v want %v", err, ErrSessionNotFound)
+	}
+}
+
+func TestSupervisorPrunesArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive, err := NewFileArchive(dir)
+	if err != nil {
+		t.Fatalf("NewFileArchive: %v", err)
+	}
+	sup := NewSupervisor(NewRegistry(), DefaultPolicy(), 1024, time.Minute,
+		WithArchive(archive, time.Minute), WithArchiveRetention(24*time.Hour))
+	t.Cleanup(sup.Close)
+
+	now := time.Now()
+	for id, age := range map[string]time.Duration{"old": 48 * time.Hour, "recent": time.Hour} {
+		info := SessionInfo{SessionID: id, ProjectID: "proj-a", State: SessionStateStopped}
+		if err := archive.Save(ArchivedSession{Info: info, ArchivedAt: now.Add(-age)}); err != nil {
+			t.Fatalf("Save %s: %v", id, err)
+		}
+		if err := os.Chtimes(filepath.Join(dir, id+".json"), now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+		sup.histMu.Lock()
+		sup.history[id] = info
+		sup.histMu.Unlock()
+	}
+
+	sup.archiveExpired(now)
+	if _, err := sup.History("old"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("History(old) error=%v want %v", err, ErrSessionNotFound)
+	}
+	if _, err := sup.Get("old"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Get(old) error=%v want %v", err, ErrSessionNotFound)
+	}
+	if _, err := sup.History("recent"); err != nil {
+		t.Fatalf("History(recent): %v", err)
+	}
+}
+
+func containsPayload(chunks []OutputChunk, needle string) bool {
+	for _, c := range chunks {
+		if bytes.Contains(c.Payload, []byte(needle)) {
+			return true
+		}
+	}
+	return false
+}
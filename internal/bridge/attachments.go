@@ -0,0 +1,287 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// AttachmentDir is where inline attachments are written, relative to the
+// session's repo. Each input's files go in a subdirectory named by its ID.
+const AttachmentDir = ".bridge/attachments"
+
+// Attachment is a file sent with an input. Path names a file already in the
+// session's repo; otherwise Name and Content are written into the repo for
+// the agent to read.
+type Attachment struct {
+	Path    string
+	Name    string
+	Content []byte
+}
+
+// SendInputWithAttachments is SendInput for a prompt that comes with files.
+// Inline attachments are written to AttachmentDir/<input-id>/ in the
+// session's repo, and a reference to each file, "@" and its repo path, is
+// added to the start of the prompt. The paths are reported on the input's
+// ChunkTypeInputAcked chunk.
+func (s *Supervisor) SendInputWithAttachments(ctx context.Context, sessionID, clientID string, data []byte, attachments []Attachment) (InputAck, error) {
+	policy := s.currentPolicy()
+	if err := policy.ValidateInputBytes(data); err != nil {
+		return InputAck{}, err
+	}
+	return s.sendPrompt(ctx, policy, sessionID, clientID, data, attachments)
+}
+
+// sendPrompt delivers a prompt with its attachments. The session's first
+// prompt also gets its repo summary, as an attachment in front of the
+// others.
+func (s *Supervisor) sendPrompt(ctx context.Context, policy Policy, sessionID, clientID string, data []byte, attachments []Attachment) (InputAck, error) {
+	id := uuid.NewString()
+	paths, err := attachmentPaths(id, attachments)
+	if err != nil {
+		return InputAck{}, err
+	}
+	inline := 0
+	for _, a := range attachments {
+		inline += len(a.Content)
+	}
+	if err := policy.ValidateAttachmentSize(inline); err != nil {
+		return InputAck{}, err
+	}
+
+	// Check the writer before touching the repo, so that a client that may
+	// not write leaves no files behind.
+	s.mu.RLock()
+	ms, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return InputAck{}, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	ms.mu.Lock()
+	err = ms.checkWriterLocked(clientID)
+	streamJSON := ms.streamJSON
+	ms.mu.Unlock()
+	if err != nil {
+		return InputAck{}, err
+	}
+	if data, attachments, err = s.checkPrompt(ctx, policy, ms, clientID, data, attachments, streamJSON); err != nil {
+		return InputAck{}, err
+	}
+
+	preamble := ""
+	ms.mu.Lock()
+	// The summary waits for an input it can be referenced from; a
+	// stream-JSON control message, say, is sent without it.
+	if ms.preamble != "" && !slices.Contains(paths, ms.preamble) {
+		if _, rerr := referenceAttachments(data, []string{ms.preamble}, streamJSON); rerr == nil {
+			preamble, ms.preamble = ms.preamble, ""
+		}
+	}
+	ms.mu.Unlock()
+	if preamble != "" {
+		attachments = append([]Attachment{{Path: preamble}}, attachments...)
+		paths = append([]string{preamble}, paths...)
+	}
+	if len(attachments) == 0 {
+		return s.sendInput(ctx, policy, sessionID, clientID, id, data, nil)
+	}
+
+	ack, err := s.sendAttached(ctx, policy, sessionID, clientID, id, data, attachments, paths, streamJSON)
+	if err != nil && ack.Bytes == 0 && preamble != "" {
+		ms.mu.Lock()
+		if ms.preamble == "" {
+			ms.preamble = preamble
+		}
+		ms.mu.Unlock()
+	}
+	return ack, err
+}
+
+// checkPrompt applies the content policy to a prompt and the content of
+// its inline attachments.
+func (s *Supervisor) checkPrompt(ctx context.Context, policy Policy, ms *managedSession, clientID string, data []byte, attachments []Attachment, streamJSON bool) ([]byte, []Attachment, error) {
+	if !policy.ContentPolicy.enabled() {
+		return data, attachments, nil
+	}
+	data, err := s.checkInput(ctx, policy, ms, clientID, data, streamJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	checked := slices.Clone(attachments)
+	for i, a := range checked {
+		if len(a.Content) == 0 {
+			continue
+		}
+		if checked[i].Content, err = s.checkInput(ctx, policy, ms, clientID, a.Content, false); err != nil {
+			return nil, nil, err
+		}
+	}
+	return data, checked, nil
+}
+
+// sendAttached references paths, the repo paths of attachments, from data,
+// writes the inline attachments and delivers the input.
+func (s *Supervisor) sendAttached(ctx context.Context, policy Policy, sessionID, clientID, id string, data []byte, attachments []Attachment, paths []string, streamJSON bool) (InputAck, error) {
+	data, err := referenceAttachments(data, paths, streamJSON)
+	if err != nil {
+		return InputAck{}, err
+	}
+	if err := s.materializeAttachments(sessionID, id, attachments); err != nil {
+		return InputAck{}, err
+	}
+	ack, err := s.sendInput(ctx, policy, sessionID, clientID, id, data, paths)
+	if err != nil && ack.Bytes == 0 {
+		s.removeAttachments(sessionID, id)
+	}
+	return ack, err
+}
+
+// attachmentPaths checks attachments and returns the repo path, with
+// forward slashes, that each one will have once input id is delivered.
+func attachmentPaths(id string, attachments []Attachment) ([]string, error) {
+	paths := make([]string, 0, len(attachments))
+	seen := make(map[string]bool, len(attachments))
+	for _, a := range attachments {
+		var p string
+		switch {
+		case a.Path != "" && (a.Name != "" || len(a.Content) > 0):
+			return nil, fmt.Errorf("%w: attachment %q sets both path and inline content", ErrInvalidArgument, a.Path)
+		case a.Path != "":
+			if !filepath.IsLocal(a.Path) {
+				return nil, fmt.Errorf("%w: attachment path %q must be relative to the repo and stay inside it", ErrInvalidArgument, a.Path)
+			}
+			p = filepath.ToSlash(filepath.Clean(a.Path))
+		case a.Name != "":
+			if !filepath.IsLocal(a.Name) || filepath.Base(a.Name) != a.Name {
+				return nil, fmt.Errorf("%w: attachment name %q must be a plain file name", ErrInvalidArgument, a.Name)
+			}
+			p = path.Join(AttachmentDir, id, a.Name)
+		default:
+			return nil, fmt.Errorf("%w: attachment needs a path or a name", ErrInvalidArgument)
+		}
+		// A reference ends at the first space, so the agent would read a
+		// path with spaces as a shorter one.
+		if strings.ContainsFunc(p, unicode.IsSpace) {
+			return nil, fmt.Errorf("%w: attachment %q contains whitespace", ErrInvalidArgument, p)
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("%w: attachment %q is listed twice", ErrInvalidArgument, p)
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// referenceAttachments adds a reference to each path to the start of the
+// prompt in data. A PTY prompt is typed after the references; a stream-JSON
+// input must be one user message, whose content gets them.
+func referenceAttachments(data []byte, paths []string, streamJSON bool) ([]byte, error) {
+	refs := make([]string, len(paths))
+	for i, p := range paths {
+		refs[i] = "@" + p
+	}
+	prefix := strings.Join(refs, " ")
+	if !streamJSON {
+		return append([]byte(prefix+" "), data...), nil
+	}
+
+	errNotUser := fmt.Errorf("%w: attachments need the input to be a single stream-JSON user message", ErrInvalidArgument)
+	line := bytes.TrimRight(data, "\r\n")
+	if bytes.ContainsAny(line, "\r\n") {
+		return nil, errNotUser
+	}
+	var msg map[string]json.RawMessage
+	if json.Unmarshal(line, &msg) != nil || string(msg["type"]) != `"user"` {
+		return nil, errNotUser
+	}
+	var body map[string]json.RawMessage
+	if json.Unmarshal(msg["message"], &body) != nil {
+		return nil, errNotUser
+	}
+	var (
+		text    string
+		blocks  []json.RawMessage
+		content []byte
+		err     error
+	)
+	switch {
+	case json.Unmarshal(body["content"], &text) == nil:
+		content, err = json.Marshal(prefix + "\n\n" + text)
+	case json.Unmarshal(body["content"], &blocks) == nil:
+		ref, _ := json.Marshal(map[string]string{"type": "text", "text": prefix})
+		content, err = json.Marshal(append([]json.RawMessage{ref}, blocks...))
+	default:
+		return nil, errNotUser
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	body["content"] = content
+	if msg["message"], err = json.Marshal(body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	out, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	return append(out, '\n'), nil
+}
+
+// materializeAttachments checks that the repo files attachments name exist
+// and writes the inline ones under AttachmentDir/id.
+func (s *Supervisor) materializeAttachments(sessionID, id string, attachments []Attachment) error {
+	root, err := s.openSessionRoot(sessionID, "")
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	dir := filepath.Join(filepath.FromSlash(AttachmentDir), id)
+	created := false
+	for _, a := range attachments {
+		if a.Path != "" {
+			fi, err := root.Stat(filepath.Clean(a.Path))
+			if err != nil {
+				return fileError(a.Path, err)
+			}
+			if !fi.Mode().IsRegular() {
+				return fmt.Errorf("%w: attachment %q is not a regular file", ErrInvalidArgument, a.Path)
+			}
+			continue
+		}
+		if !created {
+			if err := root.MkdirAll(dir, 0o755); err != nil {
+				return fileError(AttachmentDir, err)
+			}
+			created = true
+		}
+		if err := root.WriteFile(filepath.Join(dir, a.Name), a.Content, 0o644); err != nil {
+			_ = root.RemoveAll(dir)
+			return fileError(a.Name, err)
+		}
+	}
+	if created {
+		logger().Info("input attachments written", "session_id", sessionID, "input_id", id, "dir", filepath.ToSlash(dir))
+	}
+	return nil
+}
+
+// removeAttachments deletes the inline attachments of an input that was
+// not delivered.
+func (s *Supervisor) removeAttachments(sessionID, id string) {
+	root, err := s.openSessionRoot(sessionID, "")
+	if err != nil {
+		return
+	}
+	defer root.Close()
+	_ = root.RemoveAll(filepath.Join(filepath.FromSlash(AttachmentDir), id))
+}
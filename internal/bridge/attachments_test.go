@@ -0,0 +1,137 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReferenceAttachments(t *testing.T) {
+	paths := []string{"main.go", ".bridge/attachments/id/notes.txt"}
+
+	got, err := referenceAttachments([]byte("explain\r"), paths, false)
+	if err != nil || string(got) != "@main.go @.bridge/attachments/id/notes.txt explain\r" {
+		t.Fatalf("PTY input=%q err=%v", got, err)
+	}
+
+	got, err = referenceAttachments([]byte(`{"type":"user","message":{"role":"user","content":"explain"}}`+"\n"), paths, true)
+	if err != nil {
+		t.Fatalf("stream-JSON string content: %v", err)
+	}
+	if text := inputText(string(got)); text != "@main.go @.bridge/attachments/id/notes.txt\n\nexplain" {
+		t.Fatalf("stream-JSON text=%q", text)
+	}
+	if !strings.HasSuffix(string(got), "}\n") || !strings.Contains(string(got), `"role":"user"`) {
+		t.Fatalf("stream-JSON input=%q", got)
+	}
+
+	got, err = referenceAttachments([]byte(`{"type":"user","message":{"role":"user","content":[{"type":"text","text":"explain"}]}}`), paths[:1], true)
+	if err != nil {
+		t.Fatalf("stream-JSON block content: %v", err)
+	}
+	if text := inputText(string(got)); text != "@main.go\nexplain" {
+		t.Fatalf("stream-JSON blocks text=%q", text)
+	}
+
+	for _, data := range []string{"plain text\n", `{"type":"control"}`, "{\"type\":\"user\",\"message\":{\"content\":\"a\"}}\n{\"type\":\"user\",\"message\":{\"content\":\"b\"}}\n"} {
+		if _, err := referenceAttachments([]byte(data), paths, true); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("stream-JSON input %q err=%v want ErrInvalidArgument", data, err)
+		}
+	}
+}
+
+func TestAttachmentPaths(t *testing.T) {
+	got, err := attachmentPaths("id", []Attachment{{Path: "./src/../main.go"}, {Name: "notes.txt", Content: []byte("x")}})
+	if err != nil || !slices.Equal(got, []string{"main.go", ".bridge/attachments/id/notes.txt"}) {
+		t.Fatalf("paths=%v err=%v", got, err)
+	}
+	for name, list := range map[string][]Attachment{
+		"escape":    {{Path: "../secret"}},
+		"absolute":  {{Path: "/etc/passwd"}},
+		"both":      {{Path: "main.go", Name: "main.go"}},
+		"empty":     {{}},
+		"dir name":  {{Name: "a/b.txt"}},
+		"space":     {{Name: "my notes.txt"}},
+		"duplicate": {{Path: "main.go"}, {Path: "./main.go"}},
+	} {
+		if _, err := attachmentPaths("id", list); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("%s: err=%v want ErrInvalidArgument", name, err)
+		}
+	}
+}
+
+func TestSendInputWithAttachments(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&testProvider{id: "fake"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	supervisor := NewSupervisor(registry, DefaultPolicy(), 1024, time.Minute)
+	defer supervisor.Close()
+
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := supervisor.Start(context.Background(), SessionConfig{
+		ProjectID: "project-a",
+		SessionID: "session-a",
+		RepoPath:  repo,
+		Options:   map[string]string{"provider": "fake"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer func() { _ = supervisor.Stop("session-a", true) }()
+	state, err := supervisor.Attach("session-a", "client-a", 0, AttachRoleWriter)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+
+	if _, err := supervisor.SendInputWithAttachments(context.Background(), "session-a", "client-b", []byte("hi\n"), []Attachment{{Name: "notes.txt", Content: []byte("x")}}); !errors.Is(err, ErrClientMismatch) {
+		t.Fatalf("non-writer err=%v want ErrClientMismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".bridge")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("non-writer left attachments behind: %v", err)
+	}
+	if _, err := supervisor.SendInputWithAttachments(context.Background(), "session-a", "client-a", []byte("hi\n"), []Attachment{{Path: "missing.go"}}); !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("missing path err=%v want ErrFileNotFound", err)
+	}
+
+	ack, err := supervisor.SendInputWithAttachments(context.Background(), "session-a", "client-a", []byte("review\n"), []Attachment{
+		{Path: "main.go"},
+		{Name: "notes.txt", Content: []byte("check the error paths")},
+	})
+	if err != nil {
+		t.Fatalf("SendInputWithAttachments: %v", err)
+	}
+	inline := ".bridge/attachments/" + ack.ID + "/notes.txt"
+	if data, err := os.ReadFile(filepath.Join(repo, filepath.FromSlash(inline))); err != nil || string(data) != "check the error paths" {
+		t.Fatalf("inline attachment=%q err=%v", data, err)
+	}
+	waitForChunk(t, state.Live, "review")
+	ms := supervisor.sessions["session-a"]
+	if got := string(ms.inputs[len(ms.inputs)-1].Data); got != "@main.go @"+inline+" review\n" {
+		t.Fatalf("delivered input=%q", got)
+	}
+
+	var acked *OutputChunk
+	for _, c := range ms.buf.After(0) {
+		if c.Type == ChunkTypeInputAcked && c.InputID == ack.ID {
+			acked = &c
+		}
+	}
+	if acked == nil || !slices.Equal(acked.Attachments, []string{"main.go", inline}) {
+		t.Fatalf("INPUT_ACKED chunk=%+v, want attachments", acked)
+	}
+
+	policy := DefaultPolicy()
+	policy.MaxAttachmentBytes = 4
+	supervisor.SetPolicy(policy)
+	if _, err := supervisor.SendInputWithAttachments(context.Background(), "session-a", "client-a", []byte("hi\n"), []Attachment{{Name: "big.txt", Content: []byte("12345")}}); !errors.Is(err, ErrInputTooLarge) {
+		t.Fatalf("oversized attachment err=%v want ErrInputTooLarge", err)
+	}
+}
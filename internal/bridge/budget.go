@@ -0,0 +1,153 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Budget caps what a project's sessions may spend per UTC day, as reported
+// in their Usage. Zero fields are unlimited.
+type Budget struct {
+	DailyUSD    float64
+	DailyTokens int64
+}
+
+// BudgetStatus describes a project that has reached its budget. It is the
+// Data of a ChunkTypeBudgetExceeded chunk.
+type BudgetStatus struct {
+	ProjectID string `json:"project_id"`
+	// Limit is the cap reached: "daily_usd" or "daily_tokens".
+	Limit       string    `json:"limit"`
+	DailyUSD    float64   `json:"daily_usd,omitempty"`
+	DailyTokens int64     `json:"daily_tokens,omitempty"`
+	SpentUSD    float64   `json:"spent_usd"`
+	SpentTokens int64     `json:"spent_tokens"`
+	ResetAt     time.Time `json:"reset_at"`
+}
+
+func (st BudgetStatus) String() string {
+	if st.Limit == "daily_tokens" {
+		return fmt.Sprintf("project %q spent %d tokens of its daily_tokens budget of %d; resets at %s", st.ProjectID, st.SpentTokens, st.DailyTokens, st.ResetAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("project %q spent $%.2f of its daily_usd budget of $%.2f; resets at %s", st.ProjectID, st.SpentUSD, st.DailyUSD, st.ResetAt.Format(time.RFC3339))
+}
+
+// BudgetExceededError is returned by Start and SendInput when the project
+// has spent its budget for the day. It wraps ErrBudgetExceeded.
+type BudgetExceededError struct {
+	BudgetStatus
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrBudgetExceeded, e.BudgetStatus)
+}
+
+func (e *BudgetExceededError) Unwrap() error { return ErrBudgetExceeded }
+
+// projectSpend is a project's usage over one UTC day.
+type projectSpend struct {
+	day   time.Time
+	spent Usage
+	// over is set once the day's spending reached the budget and the
+	// project's sessions were told.
+	over bool
+}
+
+// status reports whether spent reaches b, and how.
+func (b Budget) status(projectID string, sp *projectSpend) (BudgetStatus, bool) {
+	tokens := sp.spent.InputTokens + sp.spent.OutputTokens
+	st := BudgetStatus{
+		ProjectID:   projectID,
+		DailyUSD:    b.DailyUSD,
+		DailyTokens: b.DailyTokens,
+		SpentUSD:    sp.spent.CostUSD,
+		SpentTokens: tokens,
+		ResetAt:     sp.day.Add(24 * time.Hour),
+	}
+	switch {
+	case b.DailyUSD > 0 && sp.spent.CostUSD >= b.DailyUSD:
+		st.Limit = "daily_usd"
+	case b.DailyTokens > 0 && tokens >= b.DailyTokens:
+		st.Limit = "daily_tokens"
+	default:
+		return st, false
+	}
+	return st, true
+}
+
+// spendLocked returns projectID's spending today, starting a new day when
+// the last one is over. The caller holds s.budgetMu.
+func (s *Supervisor) spendLocked(projectID string) *projectSpend {
+	day := nowUTC().Truncate(24 * time.Hour)
+	if s.spend == nil {
+		s.spend = make(map[string]*projectSpend)
+	}
+	sp := s.spend[projectID]
+	if sp == nil || !sp.day.Equal(day) {
+		sp = &projectSpend{day: day}
+		s.spend[projectID] = sp
+	}
+	return sp
+}
+
+// checkBudget returns a BudgetExceededError when projectID has spent its
+// budget for the day.
+func (s *Supervisor) checkBudget(projectID string) error {
+	policy := s.currentPolicy()
+	b := policy.Projects[projectID].Budget
+	if b == (Budget{}) {
+		return nil
+	}
+	s.budgetMu.Lock()
+	st, over := b.status(projectID, s.spendLocked(projectID))
+	s.budgetMu.Unlock()
+	if over {
+		return &BudgetExceededError{st}
+	}
+	return nil
+}
+
+// addSpend adds delta to projectID's spending today. It reports the
+// project's status, and whether delta is what took it over its budget.
+func (s *Supervisor) addSpend(projectID string, delta Usage) (BudgetStatus, bool) {
+	policy := s.currentPolicy()
+	b := policy.Projects[projectID].Budget
+	s.budgetMu.Lock()
+	defer s.budgetMu.Unlock()
+	sp := s.spendLocked(projectID)
+	sp.spent = sp.spent.Add(delta)
+	st, over := b.status(projectID, sp)
+	crossed := over && !sp.over
+	// A raised budget arms the event again.
+	sp.over = over
+	return st, crossed
+}
+
+// chargeBudget adds usage reported by a session of projectID to the
+// project's spending and, when it takes the project over its budget, emits
+// a ChunkTypeBudgetExceeded chunk on each of the project's live sessions.
+func (s *Supervisor) chargeBudget(projectID string, delta Usage) {
+	st, crossed := s.addSpend(projectID, delta)
+	if !crossed {
+		return
+	}
+	logger().Warn("project budget exceeded", "project_id", projectID, "limit", st.Limit, "spent_usd", st.SpentUSD, "spent_tokens", st.SpentTokens)
+	data, err := json.Marshal(st)
+	if err != nil {
+		return
+	}
+	s.mu.RLock()
+	var live []*managedSession
+	for _, ms := range s.sessions {
+		ms.mu.Lock()
+		if ms.info.ProjectID == projectID && ms.info.State != SessionStateStopped && ms.info.State != SessionStateFailed {
+			live = append(live, ms)
+		}
+		ms.mu.Unlock()
+	}
+	s.mu.RUnlock()
+	for _, ms := range live {
+		s.appendChunkData(ms, []byte(st.String()), ChunkTypeBudgetExceeded, data)
+	}
+}
@@ -0,0 +1,117 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProjectBudget(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.Projects = map[string]ProjectPolicy{
+		"capped": {Budget: Budget{DailyUSD: 0.4}},
+		"tokens": {Budget: Budget{DailyTokens: 100}},
+	}
+	registry := NewRegistry()
+	_ = registry.Register(&testProvider{id: "fake"})
+	sup := NewSupervisor(registry, policy, 64*1024, time.Minute)
+	t.Cleanup(sup.Close)
+
+	session := func(id, project string) *managedSession {
+		ms := &managedSession{
+			buf:        NewByteBuffer(64 * 1024),
+			observers:  map[string]*observerEntry{},
+			stdin:      nopWriteCloser{io.Discard},
+			streamJSON: true,
+			info:       SessionInfo{SessionID: id, ProjectID: project, ActiveWriterClientID: "writer"},
+		}
+		sup.mu.Lock()
+		sup.sessions[id] = ms
+		sup.mu.Unlock()
+		t.Cleanup(func() {
+			// The session has no process for Close to stop.
+			sup.mu.Lock()
+			delete(sup.sessions, id)
+			sup.mu.Unlock()
+		})
+		return ms
+	}
+	respond := func(ms *managedSession, lines ...string) {
+		pr, pw := io.Pipe()
+		go func() {
+			_, _ = pw.Write([]byte(strings.Join(lines, "\n") + "\n"))
+			_ = pw.Close()
+		}()
+		sup.readLoopStreamJSON(ms, pr, nil, nil)
+	}
+	budgetEvents := func(ms *managedSession) []OutputChunk {
+		var out []OutputChunk
+		for _, c := range ms.buf.After(0) {
+			if c.Type == ChunkTypeBudgetExceeded {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+
+	a, b, other := session("a", "capped"), session("b", "capped"), session("c", "tokens")
+	respond(a, `{"type":"result","total_cost_usd":0.25}`)
+	if _, err := sup.SendInput(context.Background(), "a", "writer", []byte("more\n")); err != nil {
+		t.Fatalf("SendInput under budget: %v", err)
+	}
+	respond(b, `{"type":"result","total_cost_usd":0.2}`)
+
+	// The second session's spending took the project over: both of its
+	// sessions are told once, and the other project is not.
+	for _, ms := range []*managedSession{a, b} {
+		events := budgetEvents(ms)
+		if len(events) != 1 {
+			t.Fatalf("session %s budget events = %d, want 1", ms.info.SessionID, len(events))
+		}
+		var st BudgetStatus
+		if err := json.Unmarshal(events[0].Data, &st); err != nil {
+			t.Fatalf("budget event data: %v", err)
+		}
+		if st.ProjectID != "capped" || st.Limit != "daily_usd" || st.SpentUSD < 0.45 || !st.ResetAt.After(time.Now()) {
+			t.Fatalf("budget status = %+v", st)
+		}
+	}
+	if len(budgetEvents(other)) != 0 {
+		t.Fatal("a project under its budget got a budget event")
+	}
+
+	_, err := sup.SendInput(context.Background(), "a", "writer", []byte("more\n"))
+	var exceeded *BudgetExceededError
+	if !errors.As(err, &exceeded) || !errors.Is(err, ErrBudgetExceeded) || exceeded.DailyUSD != 0.4 {
+		t.Fatalf("SendInput over budget err = %v, want a BudgetExceededError", err)
+	}
+	_, err = sup.Start(context.Background(), SessionConfig{
+		ProjectID: "capped",
+		SessionID: "d",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "fake"},
+	})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Start over budget err = %v, want %v", err, ErrBudgetExceeded)
+	}
+
+	respond(other, `{"type":"result","usage":{"input_tokens":80,"output_tokens":30}}`)
+	if _, err := sup.SendInput(context.Background(), "c", "writer", []byte("more\n")); !errors.As(err, &exceeded) || exceeded.Limit != "daily_tokens" || exceeded.SpentTokens != 110 {
+		t.Fatalf("SendInput over token budget err = %v", err)
+	}
+
+	// Raising the budget lets the project continue.
+	policy.Projects["capped"] = ProjectPolicy{Budget: Budget{DailyUSD: 1}}
+	sup.SetPolicy(policy)
+	if _, err := sup.SendInput(context.Background(), "a", "writer", []byte("more\n")); err != nil {
+		t.Fatalf("SendInput after raising the budget: %v", err)
+	}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
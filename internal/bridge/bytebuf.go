@@ -6,12 +6,15 @@ import (
 )
 
 // ByteBuffer is a bounded ring-like buffer of PTY output chunks with byte-based retention.
+// A buffer created with NewSpillingByteBuffer writes evicted chunks to a
+// segment file instead of dropping them.
 type ByteBuffer struct {
 	mu       sync.RWMutex
 	capacity int
 	total    int
 	nextSeq  uint64
 	chunks   []OutputChunk
+	spill    *spillSegment // nil when evicted chunks are dropped
 }
 
 func NewByteBuffer(capacity int) *ByteBuffer {
@@ -24,29 +27,46 @@ func NewByteBuffer(capacity int) *ByteBuffer {
 	}
 }
 
+// NewSpillingByteBuffer returns a buffer that keeps capacity bytes of the
+// newest chunks in memory and moves older ones to a segment of files named
+// <path>.<n>, deleting the oldest once they hold more than maxBytes
+// (DefaultSpillMaxBytes when maxBytes <= 0). Call Close to delete them.
+func NewSpillingByteBuffer(capacity int, path string, maxBytes int64) *ByteBuffer {
+	if maxBytes <= 0 {
+		maxBytes = DefaultSpillMaxBytes
+	}
+	b := NewByteBuffer(capacity)
+	b.spill = &spillSegment{path: path, maxBytes: maxBytes}
+	return b
+}
+
 func (b *ByteBuffer) Append(payload []byte) OutputChunk {
 	return b.AppendTyped(payload, ChunkTypeOutput)
 }
 
 // AppendTyped adds a payload with an explicit ChunkType to the buffer.
 func (b *ByteBuffer) AppendTyped(payload []byte, ctype ChunkType) OutputChunk {
+	return b.appendNew(OutputChunk{Payload: payload, Type: ctype})
+}
+
+// AppendStderr adds a classified ChunkTypeStderr line to the buffer.
+func (b *ByteBuffer) AppendStderr(payload []byte, severity Severity) OutputChunk {
+	return b.appendNew(OutputChunk{Payload: payload, Type: ChunkTypeStderr, Severity: severity})
+}
+
+// appendNew assigns the next seq and a timestamp to chunk and appends it.
+func (b *ByteBuffer) appendNew(chunk OutputChunk) OutputChunk {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	copied := append([]byte(nil), payload...)
-	chunk := OutputChunk{
-		Seq:       b.nextSeq,
-		Timestamp: nowUTC(),
-		Payload:   copied,
-		Type:      ctype,
-	}
+	chunk.Seq = b.nextSeq
+	chunk.Timestamp = nowUTC()
+	chunk.Payload = append([]byte(nil), chunk.Payload...)
+	chunk.Data = cloneData(chunk.Data)
 	b.nextSeq++
 	b.chunks = append(b.chunks, chunk)
-	b.total += len(copied)
-	for b.total > b.capacity && len(b.chunks) > 0 {
-		b.total -= len(b.chunks[0].Payload)
-		b.chunks = b.chunks[1:]
-	}
+	b.total += chunk.size()
+	b.evict()
 	return chunk
 }
 
@@ -57,44 +77,135 @@ func (b *ByteBuffer) AppendChunk(chunk OutputChunk) OutputChunk {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	copied := OutputChunk{
-		Seq:       chunk.Seq,
-		Timestamp: chunk.Timestamp,
-		Payload:   append([]byte(nil), chunk.Payload...),
-		Type:      chunk.Type,
-	}
+	copied := chunk
+	copied.Payload = append([]byte(nil), chunk.Payload...)
+	copied.Data = cloneData(chunk.Data)
 	b.chunks = append(b.chunks, copied)
-	b.total += len(copied.Payload)
+	b.total += copied.size()
 	if copied.Seq >= b.nextSeq {
 		b.nextSeq = copied.Seq + 1
 	}
+	b.evict()
+	return copied
+}
+
+// cloneData copies a chunk's Data, keeping nil as nil.
+func cloneData(data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+	return append([]byte(nil), data...)
+}
+
+// evict drops the oldest chunks, or moves them to the spill segment, until
+// the buffer is within capacity. Caller must hold b.mu.
+func (b *ByteBuffer) evict() {
 	for b.total > b.capacity && len(b.chunks) > 0 {
-		b.total -= len(b.chunks[0].Payload)
+		if b.spill != nil {
+			if err := b.spill.write(b.chunks[0]); err != nil {
+				// Chunks in a partial segment can't be served without a gap,
+				// so fall back to dropping them all.
+				logger().Warn("output buffer: spilling disabled", "path", b.spill.path, "error", err)
+				_ = b.spill.remove()
+				b.spill = nil
+			}
+		}
+		b.total -= b.chunks[0].size()
 		b.chunks = b.chunks[1:]
 	}
-	return copied
 }
 
+// Close deletes the spill segment, if any. Chunks that were spilled are no
+// longer available afterwards.
+func (b *ByteBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.spill == nil {
+		return nil
+	}
+	err := b.spill.remove()
+	b.spill = nil
+	return err
+}
+
+// ResumeAfter makes the next appended chunk follow seq. It is used when a
+// session handed off from another bridge instance has had chunks evicted,
+// so numbering continues even if none were transferred.
+func (b *ByteBuffer) ResumeAfter(seq uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if seq >= b.nextSeq {
+		b.nextSeq = seq + 1
+	}
+}
+
+// After returns copies of the in-memory chunks with a seq greater than
+// afterSeq. Spilled chunks are read back through Replay.
 func (b *ByteBuffer) After(afterSeq uint64) []OutputChunk {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
+	return b.afterLocked(afterSeq)
+}
 
-	out := make([]OutputChunk, 0, len(b.chunks))
+// Replay splits the chunks after afterSeq into those still in memory and,
+// when older ones were spilled to disk, a SpillReplay that pages through
+// them up to the first in-memory chunk.
+func (b *ByteBuffer) Replay(afterSeq uint64) (*SpillReplay, []OutputChunk) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	memory := b.afterLocked(afterSeq)
+	if b.spill == nil || len(b.spill.files) == 0 {
+		return nil, memory
+	}
+	end := b.nextSeq
+	if len(b.chunks) > 0 {
+		end = b.chunks[0].Seq
+	}
+	// Chunks already deleted from disk are reported by the caller's
+	// ReplayGap, not again by the SpillReplay.
+	last := max(afterSeq, b.spill.oldestSeq()-1)
+	if last+1 >= end {
+		return nil, memory
+	}
+	return &SpillReplay{buf: b, last: last, end: end}, memory
+}
+
+func (b *ByteBuffer) afterLocked(afterSeq uint64) []OutputChunk {
+	var out []OutputChunk
 	for _, chunk := range b.chunks {
 		if chunk.Seq <= afterSeq {
 			continue
 		}
-		out = append(out, OutputChunk{
-			Seq:       chunk.Seq,
-			Timestamp: chunk.Timestamp,
-			Payload:   append([]byte(nil), chunk.Payload...),
-			Type:      chunk.Type,
-		})
+		chunk.Payload = append([]byte(nil), chunk.Payload...)
+		out = append(out, chunk)
 	}
 	return out
 }
 
+// Size returns the bytes of output held in memory and the capacity past
+// which the oldest is evicted.
+func (b *ByteBuffer) Size() (held, capacity int) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.total, b.capacity
+}
+
 func (b *ByteBuffer) OldestSeq() uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.spill != nil {
+		if seq := b.spill.oldestSeq(); seq != 0 {
+			return seq
+		}
+	}
+	if len(b.chunks) == 0 {
+		return 0
+	}
+	return b.chunks[0].Seq
+}
+
+// memoryOldestSeq is OldestSeq ignoring spilled chunks.
+func (b *ByteBuffer) memoryOldestSeq() uint64 {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	if len(b.chunks) == 0 {
@@ -107,6 +218,9 @@ func (b *ByteBuffer) LastSeq() uint64 {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	if len(b.chunks) == 0 {
+		if b.spill != nil {
+			return b.spill.lastSeq
+		}
 		return 0
 	}
 	return b.chunks[len(b.chunks)-1].Seq
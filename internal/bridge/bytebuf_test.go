@@ -21,4 +21,7 @@ func TestByteBufferEvictsOldestByBytes(t *testing.T) {
 	if string(items[0].Payload) != "de" || string(items[1].Payload) != "fg" {
 		t.Fatalf("unexpected payloads: %q %q", items[0].Payload, items[1].Payload)
 	}
+	if held, capacity := buf.Size(); held != 4 || capacity != 5 {
+		t.Fatalf("Size=%d/%d want 4/5", held, capacity)
+	}
 }
@@ -0,0 +1,93 @@
+package bridge
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultCoalesceMaxBytes caps a coalesced chunk when WithOutputCoalescing
+// is given no limit. It matches the size of one PTY read.
+const DefaultCoalesceMaxBytes = 8 << 10
+
+// WithOutputCoalescing merges adjacent output fragments into one chunk, so
+// agents that print a token at a time produce fewer events. A fragment is
+// held for at most interval before it is published; the chunk is published
+// early once it reaches maxBytes (DefaultCoalesceMaxBytes when maxBytes <= 0)
+// or when a chunk of another type or input follows it. Sequence numbers are
+// assigned as chunks are published, so they stay in order.
+func WithOutputCoalescing(interval time.Duration, maxBytes int) SupervisorOption {
+	return func(s *Supervisor) {
+		if maxBytes <= 0 {
+			maxBytes = DefaultCoalesceMaxBytes
+		}
+		s.coalesceInterval = interval
+		s.coalesceMaxBytes = maxBytes
+	}
+}
+
+// sessionCoalescer holds the output fragment being merged for a session.
+type sessionCoalescer struct {
+	mu      sync.Mutex // held while publishing so chunks keep their order
+	pending *OutputChunk
+	timer   *time.Timer
+}
+
+// emitChunk appends chunk to the session buffer and fans it out, merging
+// output and thinking text with the fragments next to it when coalescing is
+// on. It returns the chunk's seq, or 0 while the chunk is held to be
+// merged.
+func (s *Supervisor) emitChunk(ms *managedSession, chunk OutputChunk) uint64 {
+	if s.coalesceInterval <= 0 {
+		chunk = ms.buf.appendNew(chunk)
+		s.publishChunk(ms, chunk)
+		return chunk.Seq
+	}
+	co := &ms.coalesce
+	co.mu.Lock()
+	defer co.mu.Unlock()
+	if (chunk.Type != ChunkTypeOutput && chunk.Type != ChunkTypeThinking) || chunk.Data != nil {
+		s.flushCoalescedLocked(ms)
+		chunk = ms.buf.appendNew(chunk)
+		s.publishChunk(ms, chunk)
+		return chunk.Seq
+	}
+	if p := co.pending; p != nil && (p.Type != chunk.Type || p.InputID != chunk.InputID || len(p.Payload)+len(chunk.Payload) > s.coalesceMaxBytes) {
+		s.flushCoalescedLocked(ms)
+	}
+	if co.pending == nil {
+		chunk.Payload = append([]byte(nil), chunk.Payload...)
+		co.pending = &chunk
+		if co.timer == nil {
+			co.timer = time.AfterFunc(s.coalesceInterval, func() { s.flushCoalesced(ms) })
+		} else {
+			co.timer.Reset(s.coalesceInterval)
+		}
+	} else {
+		co.pending.Payload = append(co.pending.Payload, chunk.Payload...)
+	}
+	if len(co.pending.Payload) >= s.coalesceMaxBytes {
+		s.flushCoalescedLocked(ms)
+	}
+	return 0
+}
+
+// flushCoalesced publishes the fragment being merged. It is called when the
+// flush interval passes and when the session's output ends.
+func (s *Supervisor) flushCoalesced(ms *managedSession) {
+	ms.coalesce.mu.Lock()
+	defer ms.coalesce.mu.Unlock()
+	s.flushCoalescedLocked(ms)
+}
+
+func (s *Supervisor) flushCoalescedLocked(ms *managedSession) {
+	co := &ms.coalesce
+	if co.pending == nil {
+		return
+	}
+	if co.timer != nil {
+		co.timer.Stop()
+	}
+	chunk := *co.pending
+	co.pending = nil
+	s.publishChunk(ms, ms.buf.appendNew(chunk))
+}
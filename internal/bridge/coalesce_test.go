@@ -0,0 +1,74 @@
+package bridge
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEmitChunkCoalesces(t *testing.T) {
+	s := NewSupervisor(NewRegistry(), DefaultPolicy(), 1024, time.Minute, WithOutputCoalescing(time.Hour, 8))
+	defer s.Close()
+	ms := &managedSession{buf: NewByteBuffer(1024), info: SessionInfo{SessionID: "session-a"}}
+
+	s.emitChunk(ms, OutputChunk{Type: ChunkTypeOutput, Payload: []byte("a"), InputID: "in-1"})
+	s.emitChunk(ms, OutputChunk{Type: ChunkTypeOutput, Payload: []byte("b"), InputID: "in-1"})
+	if n := len(ms.buf.After(0)); n != 0 {
+		t.Fatalf("published %d chunks before a flush", n)
+	}
+	// Another input's output starts a new chunk; a marker publishes both.
+	s.emitChunk(ms, OutputChunk{Type: ChunkTypeOutput, Payload: []byte("c"), InputID: "in-2"})
+	s.emitChunk(ms, OutputChunk{Type: ChunkTypeResponseComplete, InputID: "in-2"})
+	// Reaching the size cap publishes without waiting.
+	s.emitChunk(ms, OutputChunk{Type: ChunkTypeOutput, Payload: []byte("12345"), InputID: "in-2"})
+	s.emitChunk(ms, OutputChunk{Type: ChunkTypeOutput, Payload: []byte("678"), InputID: "in-2"})
+	s.emitChunk(ms, OutputChunk{Type: ChunkTypeThinking, Payload: []byte("hm"), InputID: "in-2"})
+	s.flushCoalesced(ms)
+
+	want := []struct {
+		ctype   ChunkType
+		payload string
+	}{
+		{ChunkTypeOutput, "ab"},
+		{ChunkTypeOutput, "c"},
+		{ChunkTypeResponseComplete, ""},
+		{ChunkTypeOutput, "12345678"},
+		{ChunkTypeThinking, "hm"},
+	}
+	chunks := ms.buf.After(0)
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d: %+v", len(chunks), len(want), chunks)
+	}
+	for i, c := range chunks {
+		if c.Type != want[i].ctype || string(c.Payload) != want[i].payload || c.Seq != uint64(i+1) {
+			t.Fatalf("chunk %d = %v %q seq %d, want %v %q", i, c.Type, c.Payload, c.Seq, want[i].ctype, want[i].payload)
+		}
+	}
+}
+
+func TestOutputCoalescingFlushInterval(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&testProvider{id: "fake"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s := NewSupervisor(registry, DefaultPolicy(), 1024, time.Minute, WithOutputCoalescing(20*time.Millisecond, 0))
+	defer s.Close()
+	if _, err := s.Start(context.Background(), SessionConfig{
+		ProjectID: "project-a",
+		SessionID: "session-a",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "fake"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer func() { _ = s.Stop("session-a", true) }()
+	state, err := s.Attach("session-a", "client-a", 0, AttachRoleWriter)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if _, err := s.WriteInput("session-a", "client-a", []byte("coalesced\n")); err != nil {
+		t.Fatalf("WriteInput: %v", err)
+	}
+	// The echo is held for the flush interval and then published.
+	waitForChunk(t, state.Live, "coalesced")
+}
@@ -0,0 +1,396 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Actions a ContentRule or ContentClassifier takes on text it flags.
+const (
+	// ContentActionBlock refuses an input, or drops a piece of output.
+	ContentActionBlock = "block"
+	// ContentActionRedact replaces the flagged text and lets the rest
+	// through.
+	ContentActionRedact = "redact"
+)
+
+// Directions of agent traffic a content policy checks.
+const (
+	// ContentDirectionInput is a prompt sent to the agent.
+	ContentDirectionInput = "input"
+	// ContentDirectionOutput is the agent's output and thinking text.
+	ContentDirectionOutput = "output"
+)
+
+// contentRedaction replaces text redacted by a ContentRule.
+const contentRedaction = "[REDACTED]"
+
+// defaultClassifyTimeout bounds a ContentClassifier call when the policy
+// sets no timeout.
+const defaultClassifyTimeout = 2 * time.Second
+
+// ContentPolicy checks prompts sent to agents and the text agents print,
+// so that data that must not leave, or enter, an agent is blocked or
+// redacted. Rules run first, in order, then the classifier. The zero value
+// checks nothing.
+type ContentPolicy struct {
+	Rules []ContentRule
+	// Classifier, when set, is asked about text going in
+	// ClassifierDirections, or in both directions when that is empty.
+	Classifier           ContentClassifier
+	ClassifierDirections []string
+	// ClassifierTimeout bounds each Classify call. Zero uses 2s.
+	ClassifierTimeout time.Duration
+	// FailClosed blocks text the classifier could not check. Otherwise
+	// the text is let through and the failure is logged.
+	FailClosed bool
+}
+
+// ContentRule flags text matching Pattern.
+type ContentRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Action is ContentActionBlock or ContentActionRedact.
+	Action string
+	// Directions are the ContentDirection* the rule checks. Empty checks
+	// both.
+	Directions []string
+}
+
+func (r ContentRule) checks(direction string) bool {
+	return len(r.Directions) == 0 || slices.Contains(r.Directions, direction)
+}
+
+// ContentCheck is text a ContentClassifier is asked about.
+type ContentCheck struct {
+	SessionID string `json:"session_id"`
+	ProjectID string `json:"project_id"`
+	Provider  string `json:"provider"`
+	// Direction is ContentDirectionInput or ContentDirectionOutput.
+	Direction string `json:"direction"`
+	Text      string `json:"text"`
+}
+
+// ContentVerdict is a ContentClassifier's answer.
+type ContentVerdict struct {
+	// Action is ContentActionBlock, ContentActionRedact, or empty to let
+	// the text through.
+	Action string `json:"action"`
+	// Text replaces the checked text when Action is ContentActionRedact.
+	Text string `json:"text,omitempty"`
+	// Rule and Reason say why, for the POLICY_VIOLATION event.
+	Rule   string `json:"rule,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ContentClassifier is an external check, such as a DLP service, that a
+// ContentPolicy consults after its rules.
+type ContentClassifier interface {
+	Classify(ctx context.Context, check ContentCheck) (ContentVerdict, error)
+}
+
+// PolicyViolation describes text a ContentPolicy blocked or redacted. It
+// is the Data of a ChunkTypePolicyViolation chunk; the text itself is not
+// recorded.
+type PolicyViolation struct {
+	Direction string `json:"direction"`
+	Action    string `json:"action"`
+	// Source is "rule" or "classifier".
+	Source string `json:"source"`
+	Rule   string `json:"rule,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// ClientID is the client whose input was checked.
+	ClientID string `json:"client_id,omitempty"`
+}
+
+func (v PolicyViolation) String() string {
+	verb := "redacted"
+	if v.Action == ContentActionBlock {
+		verb = "blocked"
+	}
+	s := fmt.Sprintf("content policy %s %s", verb, v.Direction)
+	if v.Rule != "" {
+		s += fmt.Sprintf(" (%s %q)", v.Source, v.Rule)
+	} else {
+		s += fmt.Sprintf(" (%s)", v.Source)
+	}
+	if v.Reason != "" {
+		s += ": " + v.Reason
+	}
+	return s
+}
+
+// PolicyViolationError is returned by SendInput, SendInputWithAttachments
+// and SendStreamInput when the content policy blocks an input. It wraps
+// ErrPolicyViolation.
+type PolicyViolationError struct {
+	PolicyViolation
+}
+
+func (e *PolicyViolationError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrPolicyViolation, e.PolicyViolation)
+}
+
+func (e *PolicyViolationError) Unwrap() error { return ErrPolicyViolation }
+
+func (p ContentPolicy) enabled() bool {
+	return len(p.Rules) > 0 || p.Classifier != nil
+}
+
+// checks reports whether p checks text going in direction.
+func (p ContentPolicy) checks(direction string) bool {
+	for _, r := range p.Rules {
+		if r.checks(direction) {
+			return true
+		}
+	}
+	return p.Classifier != nil && (len(p.ClassifierDirections) == 0 || slices.Contains(p.ClassifierDirections, direction))
+}
+
+// patterns returns the patterns of the rules that check direction.
+func (p ContentPolicy) patterns(direction string) []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for _, r := range p.Rules {
+		if r.checks(direction) {
+			res = append(res, r.Pattern)
+		}
+	}
+	return res
+}
+
+// check applies p to text going in check.Direction. It returns the text to
+// let through, what was flagged, and whether the text is blocked.
+func (p ContentPolicy) check(ctx context.Context, check ContentCheck) (string, []PolicyViolation, bool) {
+	var violations []PolicyViolation
+	for _, r := range p.Rules {
+		if !r.checks(check.Direction) || !r.Pattern.MatchString(check.Text) {
+			continue
+		}
+		v := PolicyViolation{Direction: check.Direction, Action: r.Action, Source: "rule", Rule: r.Name}
+		violations = append(violations, v)
+		if r.Action == ContentActionBlock {
+			return "", violations, true
+		}
+		check.Text = r.Pattern.ReplaceAllLiteralString(check.Text, contentRedaction)
+	}
+	if p.Classifier == nil || (len(p.ClassifierDirections) > 0 && !slices.Contains(p.ClassifierDirections, check.Direction)) {
+		return check.Text, violations, false
+	}
+	timeout := p.ClassifierTimeout
+	if timeout <= 0 {
+		timeout = defaultClassifyTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	verdict, err := p.Classifier.Classify(ctx, check)
+	if err != nil {
+		if p.FailClosed {
+			v := PolicyViolation{Direction: check.Direction, Action: ContentActionBlock, Source: "classifier", Reason: "classifier unavailable"}
+			return "", append(violations, v), true
+		}
+		logger().Warn("content classifier failed; text let through", "session_id", check.SessionID, "direction", check.Direction, "error", err)
+		return check.Text, violations, false
+	}
+	switch verdict.Action {
+	case ContentActionBlock:
+		v := PolicyViolation{Direction: check.Direction, Action: ContentActionBlock, Source: "classifier", Rule: verdict.Rule, Reason: verdict.Reason}
+		return "", append(violations, v), true
+	case ContentActionRedact:
+		v := PolicyViolation{Direction: check.Direction, Action: ContentActionRedact, Source: "classifier", Rule: verdict.Rule, Reason: verdict.Reason}
+		return verdict.Text, append(violations, v), false
+	}
+	return check.Text, violations, false
+}
+
+// checkInput applies the content policy to a prompt for ms. It returns the
+// prompt to send, with flagged text redacted, or a *PolicyViolationError
+// when the prompt is blocked. Stream-JSON prompts have the text of their
+// user messages checked; other stream-JSON messages pass unchecked.
+func (s *Supervisor) checkInput(ctx context.Context, policy Policy, ms *managedSession, clientID string, data []byte, streamJSON bool) ([]byte, error) {
+	cp := policy.ContentPolicy
+	if !cp.enabled() {
+		return data, nil
+	}
+	var blocked *PolicyViolation
+	checkText := func(text string) string {
+		if blocked != nil {
+			return text
+		}
+		out, violations, block := cp.check(ctx, ContentCheck{
+			SessionID: ms.info.SessionID,
+			ProjectID: ms.info.ProjectID,
+			Provider:  ms.info.Provider,
+			Direction: ContentDirectionInput,
+			Text:      text,
+		})
+		for _, v := range violations {
+			v.ClientID = clientID
+			s.reportViolation(ms, v)
+		}
+		if block {
+			v := violations[len(violations)-1]
+			v.ClientID = clientID
+			blocked = &v
+		}
+		return out
+	}
+	if streamJSON {
+		data = rewriteUserText(data, checkText)
+	} else {
+		data = []byte(checkText(string(data)))
+	}
+	if blocked != nil {
+		return nil, &PolicyViolationError{PolicyViolation: *blocked}
+	}
+	return data, nil
+}
+
+// checkOutput applies the content policy to a piece of the agent's output
+// or thinking text. It returns the text to publish, or nil when the piece
+// is blocked. Output reaches it through the session's output stream, which
+// holds back a rule match running into text not yet read, so a match split
+// across two reads of the agent's output is still seen whole.
+func (s *Supervisor) checkOutput(ms *managedSession, payload []byte) []byte {
+	cp := s.currentPolicy().ContentPolicy
+	if !cp.enabled() || len(payload) == 0 {
+		return payload
+	}
+	out, violations, block := cp.check(context.Background(), ContentCheck{
+		SessionID: ms.info.SessionID,
+		ProjectID: ms.info.ProjectID,
+		Provider:  ms.info.Provider,
+		Direction: ContentDirectionOutput,
+		Text:      string(payload),
+	})
+	if len(violations) == 0 {
+		return payload
+	}
+	for _, v := range violations {
+		s.reportViolation(ms, v)
+	}
+	if block {
+		return nil
+	}
+	return []byte(out)
+}
+
+// reportViolation logs v and appends it to ms as a
+// ChunkTypePolicyViolation chunk. It does not take the session's redaction
+// lock, which output checks are made under.
+func (s *Supervisor) reportViolation(ms *managedSession, v PolicyViolation) {
+	logger().Warn("content policy violation", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "direction", v.Direction, "action", v.Action, "source", v.Source, "rule", v.Rule, "client_id", v.ClientID)
+	payload := []byte(v.String())
+	data, _ := json.Marshal(v)
+	if s.redactor != nil {
+		payload, data = s.redactor.RedactBytes(payload), s.redactor.RedactJSON(data)
+	}
+	s.emitChunk(ms, OutputChunk{Payload: payload, Type: ChunkTypePolicyViolation, Data: data, InputID: ms.currentInput()})
+}
+
+// rewriteUserText passes the text of each stream-JSON user message in data
+// through fn and returns the rewritten messages. Lines that are not user
+// messages, or whose text fn keeps, are kept byte for byte.
+func rewriteUserText(data []byte, fn func(string) string) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	var out bytes.Buffer
+	for _, line := range lines {
+		body := bytes.TrimRight(line, "\n")
+		var msg map[string]any
+		if json.Unmarshal(body, &msg) != nil || msg["type"] != "user" {
+			out.Write(line)
+			continue
+		}
+		changed := false
+		rewrite := func(text string) string {
+			out := fn(text)
+			changed = changed || out != text
+			return out
+		}
+		m, _ := msg["message"].(map[string]any)
+		switch content := m["content"].(type) {
+		case string:
+			m["content"] = rewrite(content)
+		case []any:
+			for _, b := range content {
+				if blk, ok := b.(map[string]any); ok && blk["type"] == "text" {
+					if text, ok := blk["text"].(string); ok {
+						blk["text"] = rewrite(text)
+					}
+				}
+			}
+		}
+		if !changed {
+			out.Write(line)
+			continue
+		}
+		rewritten, err := json.Marshal(msg)
+		if err != nil {
+			out.Write(line)
+			continue
+		}
+		out.Write(rewritten)
+		out.Write(line[len(body):])
+	}
+	return out.Bytes()
+}
+
+// maxClassifierResponse bounds an HTTPContentClassifier response.
+const maxClassifierResponse = 1 << 20
+
+// HTTPContentClassifier asks an HTTP service about text. It POSTs the
+// ContentCheck as JSON and expects a ContentVerdict back; any status other
+// than 200 is an error.
+type HTTPContentClassifier struct {
+	URL string
+	// Token, when set, is sent as a bearer token.
+	Token string
+	// Client is used for requests. Nil uses http.DefaultClient.
+	Client *http.Client
+}
+
+func (c HTTPContentClassifier) Classify(ctx context.Context, check ContentCheck) (ContentVerdict, error) {
+	body, err := json.Marshal(check)
+	if err != nil {
+		return ContentVerdict{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return ContentVerdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ContentVerdict{}, fmt.Errorf("content classifier: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ContentVerdict{}, fmt.Errorf("content classifier: status %s", resp.Status)
+	}
+	var verdict ContentVerdict
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxClassifierResponse)).Decode(&verdict); err != nil {
+		return ContentVerdict{}, fmt.Errorf("content classifier: decode response: %w", err)
+	}
+	switch verdict.Action {
+	case "", "allow", ContentActionBlock, ContentActionRedact:
+	default:
+		return ContentVerdict{}, fmt.Errorf("content classifier: unknown action %q", verdict.Action)
+	}
+	if verdict.Action == "allow" {
+		verdict.Action = ""
+	}
+	return verdict, nil
+}
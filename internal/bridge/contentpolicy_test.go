@@ -0,0 +1,260 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type fakeClassifier struct {
+	verdict ContentVerdict
+	err     error
+	checks  []ContentCheck
+}
+
+func (c *fakeClassifier) Classify(_ context.Context, check ContentCheck) (ContentVerdict, error) {
+	c.checks = append(c.checks, check)
+	return c.verdict, c.err
+}
+
+func TestContentPolicyCheck(t *testing.T) {
+	rules := []ContentRule{
+		{Name: "key", Pattern: regexp.MustCompile(`key-\d+`), Action: ContentActionRedact},
+		{Name: "drop", Pattern: regexp.MustCompile(`DROP TABLE`), Action: ContentActionBlock, Directions: []string{ContentDirectionInput}},
+	}
+	input := ContentCheck{Direction: ContentDirectionInput, Text: "use key-1 and key-22"}
+	output := ContentCheck{Direction: ContentDirectionOutput, Text: "DROP TABLE users"}
+
+	p := ContentPolicy{Rules: rules}
+	text, violations, blocked := p.check(context.Background(), input)
+	if text != "use [REDACTED] and [REDACTED]" || blocked || len(violations) != 1 || violations[0].Rule != "key" || violations[0].Action != ContentActionRedact {
+		t.Fatalf("redact: text=%q violations=%+v blocked=%v", text, violations, blocked)
+	}
+	input.Text = "key-1; DROP TABLE users"
+	if _, violations, blocked = p.check(context.Background(), input); !blocked || len(violations) != 2 || violations[1].Rule != "drop" {
+		t.Fatalf("block: violations=%+v blocked=%v", violations, blocked)
+	}
+	// The block rule checks input only.
+	if text, violations, blocked = p.check(context.Background(), output); text != output.Text || blocked || len(violations) != 0 {
+		t.Fatalf("output: text=%q violations=%+v blocked=%v", text, violations, blocked)
+	}
+
+	classifier := &fakeClassifier{verdict: ContentVerdict{Action: ContentActionRedact, Text: "clean", Rule: "pii", Reason: "email address"}}
+	p = ContentPolicy{Rules: rules, Classifier: classifier, ClassifierDirections: []string{ContentDirectionOutput}}
+	if text, violations, _ = p.check(context.Background(), ContentCheck{Direction: ContentDirectionOutput, Text: "mail key-1"}); text != "clean" || len(violations) != 2 || violations[1].Source != "classifier" || violations[1].Reason != "email address" {
+		t.Fatalf("classifier redact: text=%q violations=%+v", text, violations)
+	}
+	// The classifier sees the text after the rules redacted it.
+	if len(classifier.checks) != 1 || classifier.checks[0].Text != "mail [REDACTED]" {
+		t.Fatalf("classifier checks=%+v", classifier.checks)
+	}
+	if _, _, blocked = p.check(context.Background(), ContentCheck{Direction: ContentDirectionInput, Text: "hi"}); blocked || len(classifier.checks) != 1 {
+		t.Fatalf("classifier asked about input: checks=%+v", classifier.checks)
+	}
+
+	classifier.err = errors.New("down")
+	if text, _, blocked = p.check(context.Background(), ContentCheck{Direction: ContentDirectionOutput, Text: "hi"}); blocked || text != "hi" {
+		t.Fatalf("fail open: text=%q blocked=%v", text, blocked)
+	}
+	p.FailClosed = true
+	if _, violations, blocked = p.check(context.Background(), ContentCheck{Direction: ContentDirectionOutput, Text: "hi"}); !blocked || violations[0].Reason != "classifier unavailable" {
+		t.Fatalf("fail closed: violations=%+v blocked=%v", violations, blocked)
+	}
+}
+
+func TestRewriteUserText(t *testing.T) {
+	upper := func(s string) string { return string(bytes.ToUpper([]byte(s))) }
+	control := `{"type":"control_response","response":{"x":"keep"}}` + "\n"
+	data := `{"type":"user","message":{"role":"user","content":"hi"}}` + "\n" +
+		`{"type":"user","message":{"role":"user","content":[{"type":"text","text":"there"},{"type":"image","source":"a"}]}}` + "\n" + control
+	got := string(rewriteUserText([]byte(data), upper))
+	want := `{"message":{"content":"HI","role":"user"},"type":"user"}` + "\n" +
+		`{"message":{"content":[{"text":"THERE","type":"text"},{"source":"a","type":"image"}],"role":"user"},"type":"user"}` + "\n" + control
+	if got != want {
+		t.Fatalf("rewriteUserText=\n%s\nwant\n%s", got, want)
+	}
+	// Messages whose text is kept are not re-encoded.
+	same := `{"type":"user", "message":{"content":"ok"}}` + "\n"
+	if got := string(rewriteUserText([]byte(same), func(s string) string { return s })); got != same {
+		t.Fatalf("unchanged message rewritten: %q", got)
+	}
+}
+
+func TestHTTPContentClassifier(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var check ContentCheck
+		if err := json.NewDecoder(r.Body).Decode(&check); err != nil || r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		switch check.Text {
+		case "ok":
+			_, _ = w.Write([]byte(`{"action":"allow"}`))
+		case "odd":
+			_, _ = w.Write([]byte(`{"action":"quarantine"}`))
+		default:
+			_, _ = w.Write([]byte(`{"action":"block","rule":"pii","reason":"ssn"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := HTTPContentClassifier{URL: srv.URL, Token: "tok"}
+	if v, err := c.Classify(context.Background(), ContentCheck{Text: "ok"}); err != nil || v.Action != "" {
+		t.Fatalf("allow: verdict=%+v err=%v", v, err)
+	}
+	if v, err := c.Classify(context.Background(), ContentCheck{Text: "123-45-6789"}); err != nil || v.Action != ContentActionBlock || v.Rule != "pii" || v.Reason != "ssn" {
+		t.Fatalf("block: verdict=%+v err=%v", v, err)
+	}
+	if _, err := c.Classify(context.Background(), ContentCheck{Text: "odd"}); err == nil {
+		t.Fatal("unknown action accepted")
+	}
+	if _, err := (HTTPContentClassifier{URL: srv.URL}).Classify(context.Background(), ContentCheck{Text: "ok"}); err == nil {
+		t.Fatal("error status accepted")
+	}
+}
+
+func TestContentPolicySession(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&testProvider{id: "fake"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	policy := DefaultPolicy()
+	policy.ContentPolicy = ContentPolicy{Rules: []ContentRule{
+		{Name: "token", Pattern: regexp.MustCompile(`secret-\d+`), Action: ContentActionRedact, Directions: []string{ContentDirectionInput}},
+		{Name: "drop", Pattern: regexp.MustCompile(`DROP TABLE`), Action: ContentActionBlock, Directions: []string{ContentDirectionInput}},
+		{Name: "leak", Pattern: regexp.MustCompile(`leak \d+`), Action: ContentActionRedact, Directions: []string{ContentDirectionOutput}},
+	}}
+	supervisor := NewSupervisor(registry, policy, 1024, time.Minute)
+	defer supervisor.Close()
+	if _, err := supervisor.Start(context.Background(), SessionConfig{
+		ProjectID: "project-a",
+		SessionID: "session-a",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "fake"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer func() { _ = supervisor.Stop("session-a", true) }()
+	state, err := supervisor.Attach("session-a", "client-a", 0, AttachRoleWriter)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+
+	_, err = supervisor.SendInputWithAttachments(context.Background(), "session-a", "client-a", []byte("DROP TABLE users\n"), nil)
+	var pv *PolicyViolationError
+	if !errors.As(err, &pv) || !errors.Is(err, ErrPolicyViolation) || pv.Rule != "drop" || pv.ClientID != "client-a" {
+		t.Fatalf("blocked input err=%v", err)
+	}
+	chunk := waitForChunkType(t, state.Live, ChunkTypePolicyViolation)
+	var v PolicyViolation
+	if err := json.Unmarshal(chunk.Data, &v); err != nil || v.Action != ContentActionBlock || v.Direction != ContentDirectionInput {
+		t.Fatalf("violation=%+v err=%v", v, err)
+	}
+
+	if _, err := supervisor.SendInputWithAttachments(context.Background(), "session-a", "client-a", []byte("use secret-42\n"), nil); err != nil {
+		t.Fatalf("SendInputWithAttachments: %v", err)
+	}
+	waitForChunk(t, state.Live, "use [REDACTED]")
+	if got := string(supervisor.sessions["session-a"].inputs[0].Data); got != "use [REDACTED]\n" {
+		t.Fatalf("input=%q", got)
+	}
+
+	if _, err := supervisor.SendInputWithAttachments(context.Background(), "session-a", "client-a", []byte("leak 7\n"), nil); err != nil {
+		t.Fatalf("SendInputWithAttachments: %v", err)
+	}
+	chunk = waitForChunkType(t, state.Live, ChunkTypePolicyViolation)
+	if err := json.Unmarshal(chunk.Data, &v); err != nil || v.Rule != "leak" || v.Direction != ContentDirectionOutput {
+		t.Fatalf("output violation=%+v err=%v", v, err)
+	}
+	h, err := supervisor.History("session-a")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	for _, c := range h.Chunks {
+		if c.Type == ChunkTypeOutput && bytes.Contains(c.Payload, []byte("leak 7")) {
+			t.Fatalf("output not redacted: %q", c.Payload)
+		}
+	}
+
+	// Output rules see a match split across two reads whole.
+	ms := supervisor.sessions["session-a"]
+	supervisor.appendChunk(ms, []byte("now lea"), ChunkTypeOutput)
+	supervisor.appendChunk(ms, []byte("k 8 done"), ChunkTypeOutput)
+	supervisor.flushRedaction(ms)
+	waitForChunkType(t, state.Live, ChunkTypePolicyViolation)
+	if h, err = supervisor.History("session-a"); err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	var out []byte
+	for _, c := range h.Chunks {
+		if c.Type == ChunkTypeOutput {
+			out = append(out, c.Payload...)
+		}
+	}
+	if !bytes.Contains(out, []byte("now [REDACTED] done")) {
+		t.Fatalf("split output not redacted: %q", out)
+	}
+}
+
+func TestTerminalInputChecksLines(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&testProvider{id: "fake"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	policy := DefaultPolicy()
+	policy.ContentPolicy = ContentPolicy{Rules: []ContentRule{
+		{Name: "token", Pattern: regexp.MustCompile(`secret-\d+`), Action: ContentActionRedact},
+		{Name: "drop", Pattern: regexp.MustCompile(`DROP TABLE`), Action: ContentActionBlock},
+	}}
+	supervisor := NewSupervisor(registry, policy, 1024, time.Minute)
+	defer supervisor.Close()
+	if _, err := supervisor.Start(context.Background(), SessionConfig{
+		ProjectID: "project-a",
+		SessionID: "session-a",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "fake"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer func() { _ = supervisor.Stop("session-a", true) }()
+	if _, err := supervisor.Attach("session-a", "client-a", 0, AttachRoleWriter); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	ms := supervisor.sessions["session-a"]
+	input := supervisor.NewTerminalInput("session-a", "client-a")
+
+	// Keys typed one at a time are held until Enter and checked as a line.
+	for _, key := range "DROP TABLE users" {
+		if err := input.Write(context.Background(), []byte(string(key))); err != nil {
+			t.Fatalf("Write %q: %v", key, err)
+		}
+	}
+	if len(ms.inputs) != 0 {
+		t.Fatalf("keys sent before the end of the line: %+v", ms.inputs)
+	}
+	if err := input.Write(context.Background(), []byte("\r")); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("blocked line err=%v", err)
+	}
+	if len(ms.inputs) != 0 {
+		t.Fatalf("blocked line sent: %+v", ms.inputs)
+	}
+
+	if err := input.Write(context.Background(), []byte("use sec")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := input.Write(context.Background(), []byte("ret-1\rnext")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(ms.inputs) != 1 || string(ms.inputs[0].Data) != "use [REDACTED]\r" {
+		t.Fatalf("inputs=%+v", ms.inputs)
+	}
+	if string(input.held) != "next" {
+		t.Fatalf("held=%q", input.held)
+	}
+}
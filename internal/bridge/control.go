@@ -0,0 +1,55 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+)
+
+// ControlChange is the Data of a ChunkTypeControlChanged chunk.
+type ControlChange struct {
+	// From is the writer that lost control; empty when the slot was free.
+	From   string `json:"from,omitempty"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// TakeControl makes clientID, which must be attached, the session's writer
+// (its controller), evicting the current writer like a forced ClaimWriter.
+// Unlike ClaimWriter it holds the slot: until clientID releases it or
+// detaches, only another TakeControl can move it, so a bot that reclaims
+// the writer role in a loop cannot wrest control back from a human who
+// took over. The change is recorded as a ChunkTypeControlChanged chunk with
+// reason and ctx's request ID. The caller announces the writer change as
+// for ClaimWriter.
+func (s *Supervisor) TakeControl(ctx context.Context, sessionID, clientID, reason string) (*ClaimWriterResult, error) {
+	s.mu.RLock()
+	ms, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	ms.mu.Lock()
+	held := ms.info.ActiveWriterClientID == clientID
+	result, err := s.claimWriterLocked(ms, clientID, true)
+	if err != nil {
+		ms.mu.Unlock()
+		return nil, err
+	}
+	ms.controlTaken = true
+	ms.mu.Unlock()
+	if held {
+		return result, nil
+	}
+
+	requestID := logging.RequestID(ctx)
+	logger().Info("session control taken", "session_id", sessionID, "client_id", clientID, "previous_client_id", result.PreviousWriterClientID, "reason", reason, logging.RequestIDKey, requestID)
+	data, err := json.Marshal(ControlChange{From: result.PreviousWriterClientID, To: clientID, Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+	s.appendRequestChunk(ms, []byte(clientID), ChunkTypeControlChanged, data, requestID)
+	return result, nil
+}
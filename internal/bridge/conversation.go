@@ -0,0 +1,115 @@
+package bridge
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Conversation returns the sessions of a conversation, live or from
+// history, in the order they were started.
+func (s *Supervisor) Conversation(conversationID string) []SessionInfo {
+	var out []SessionInfo
+	for _, info := range s.List("") {
+		if info.ConversationID == conversationID {
+			out = append(out, info)
+		}
+	}
+	slices.SortFunc(out, func(a, b SessionInfo) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.SessionID, b.SessionID)
+	})
+	return out
+}
+
+// ConversationHistory returns the transcripts of a conversation's sessions
+// in the order they were started.
+func (s *Supervisor) ConversationHistory(conversationID string) ([]*ArchivedSession, error) {
+	sessions := s.Conversation(conversationID)
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("%w: conversation %q has no sessions", ErrSessionNotFound, conversationID)
+	}
+	out := make([]*ArchivedSession, 0, len(sessions))
+	for _, info := range sessions {
+		h, err := s.History(info.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+// joinConversation checks that a session of cfg.ProjectID may join
+// cfg.ConversationID: the conversation must belong to the project and have
+// no session still running, since its sessions take turns. It returns the
+// conversation's latest session, or nil when cfg starts the conversation.
+func (s *Supervisor) joinConversation(cfg SessionConfig) (*SessionInfo, error) {
+	if cfg.ConversationID == "" {
+		return nil, nil
+	}
+	sessions := s.Conversation(cfg.ConversationID)
+	for _, info := range sessions {
+		if info.ProjectID != cfg.ProjectID {
+			return nil, fmt.Errorf("%w: conversation %q belongs to another project", ErrPermissionDenied, cfg.ConversationID)
+		}
+		if info.State != SessionStateStopped && info.State != SessionStateFailed {
+			return nil, fmt.Errorf("%w: conversation %q has session %q still running", ErrSessionRunning, cfg.ConversationID, info.SessionID)
+		}
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	return &sessions[len(sessions)-1], nil
+}
+
+// resumesThread reports whether a session running provider in cfg.RepoPath
+// continues the agent thread of prev, the conversation's latest session.
+// That takes a provider whose CLI can resume a thread by ID, e.g. `claude
+// --resume <id>`, the ID of prev's thread, and the same provider, variant
+// and directory as prev, since the CLIs keep their threads per directory.
+// Resuming by ID rather than the last thread in the directory keeps another
+// session that ran there since, perhaps another project's, out of the
+// conversation.
+func resumesThread(prev *SessionInfo, cfg SessionConfig, provider Provider, providerID, variant string) bool {
+	if prev == nil || prev.ThreadID == "" || prev.Provider != providerID || prev.Variant != variant || prev.RepoPath != cfg.RepoPath {
+		return false
+	}
+	rp, ok := provider.(ResumeProvider)
+	return ok && rp.Resumable()
+}
+
+// RenderConversation renders the transcripts of a conversation's sessions,
+// in order, as one document. Markdown nests each session's transcript
+// under the conversation's heading; JSONL adds a session_id to each event.
+func RenderConversation(conversationID string, sessions []*ArchivedSession, format string) ([]byte, error) {
+	switch format {
+	case TranscriptMarkdown, "":
+		var b strings.Builder
+		fmt.Fprintf(&b, "# Conversation %s\n", conversationID)
+		for _, h := range sessions {
+			b.WriteString("\n")
+			writeMarkdown(&b, h, 2)
+		}
+		return []byte(b.String()), nil
+	case TranscriptJSONL:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, h := range sessions {
+			for _, ev := range transcriptEvents(h) {
+				ev.SessionID = h.Info.SessionID
+				if err := enc.Encode(ev); err != nil {
+					return nil, fmt.Errorf("encode transcript event session=%s seq=%d: %w", h.Info.SessionID, ev.Seq, err)
+				}
+			}
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported transcript format %q", ErrInvalidArgument, format)
+	}
+}
@@ -0,0 +1,152 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSupervisorConversation(t *testing.T) {
+	registry := NewRegistry()
+	for _, p := range []Provider{
+		&resumeScriptProvider{
+			scriptProvider: scriptProvider{testProvider: testProvider{id: "agent"}, script: `echo new thread`},
+			resume:         `echo resumed thread $1`,
+		},
+		&resumeScriptProvider{
+			scriptProvider: scriptProvider{testProvider: testProvider{id: "unnamed"}, script: `echo new thread`},
+			resume:         `echo resumed thread $1`,
+			noThread:       true,
+		},
+		&testProvider{id: "other"},
+	} {
+		if err := registry.Register(p); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	sup := NewSupervisor(registry, DefaultPolicy(), 64*1024, time.Minute)
+	t.Cleanup(sup.Close)
+	repo := t.TempDir()
+	start := func(sessionID, projectID, provider, repoPath string) (*SessionInfo, error) {
+		return sup.Start(context.Background(), SessionConfig{
+			ProjectID:      projectID,
+			SessionID:      sessionID,
+			RepoPath:       repoPath,
+			Options:        map[string]string{"provider": provider},
+			ConversationID: "conv-1",
+		})
+	}
+	waitEnded := func(sessionID string) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for time.Now().Before(deadline) {
+			info, err := sup.Get(sessionID)
+			if err == nil && (info.State == SessionStateStopped || info.State == SessionStateFailed) {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %s to end", sessionID)
+	}
+
+	first, err := start("s1", "proj", "agent", repo)
+	if err != nil {
+		t.Fatalf("Start s1: %v", err)
+	}
+	if first.ConversationID != "conv-1" || first.ResumedFrom != "" {
+		t.Fatalf("s1 info=%+v", first)
+	}
+	waitEnded("s1")
+
+	// Same provider and directory: the agent's thread is resumed.
+	second, err := start("s2", "proj", "agent", repo)
+	if err != nil {
+		t.Fatalf("Start s2: %v", err)
+	}
+	if second.ResumedFrom != "s1" || second.ThreadID == "" || second.ThreadID != first.ThreadID {
+		t.Fatalf("s2 ResumedFrom=%q ThreadID=%q want s1's thread %q", second.ResumedFrom, second.ThreadID, first.ThreadID)
+	}
+	waitEnded("s2")
+
+	// Another provider starts a new thread, and keeps the conversation busy
+	// while it runs.
+	third, err := start("s3", "proj", "other", repo)
+	if err != nil {
+		t.Fatalf("Start s3: %v", err)
+	}
+	if third.ResumedFrom != "" {
+		t.Fatalf("s3 ResumedFrom=%q want none", third.ResumedFrom)
+	}
+	if _, err := start("s4", "proj", "agent", repo); !errors.Is(err, ErrSessionRunning) {
+		t.Fatalf("Start while s3 runs: err=%v want ErrSessionRunning", err)
+	}
+	if err := sup.Stop("s3", true); err != nil {
+		t.Fatalf("Stop s3: %v", err)
+	}
+	waitEnded("s3")
+	if _, err := start("s5", "other-proj", "agent", t.TempDir()); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("Start from another project: err=%v want ErrPermissionDenied", err)
+	}
+
+	sessions, err := sup.ConversationHistory("conv-1")
+	if err != nil {
+		t.Fatalf("ConversationHistory: %v", err)
+	}
+	var ids []string
+	for _, h := range sessions {
+		ids = append(ids, h.Info.SessionID)
+	}
+	if strings.Join(ids, ",") != "s1,s2,s3" {
+		t.Fatalf("conversation sessions=%v", ids)
+	}
+
+	md, err := RenderConversation("conv-1", sessions, TranscriptMarkdown)
+	if err != nil {
+		t.Fatalf("RenderConversation markdown: %v", err)
+	}
+	text := string(md)
+	if !strings.HasPrefix(text, "# Conversation conv-1\n") || !strings.Contains(text, "\n## Session s1\n") || !strings.Contains(text, "\n### Agent\n") {
+		t.Fatalf("markdown:\n%s", text)
+	}
+	if i, j := strings.Index(text, "new thread"), strings.Index(text, "resumed thread"); i < 0 || j < i {
+		t.Fatalf("markdown out of order:\n%s", text)
+	}
+
+	jsonl, err := RenderConversation("conv-1", sessions, TranscriptJSONL)
+	if err != nil {
+		t.Fatalf("RenderConversation jsonl: %v", err)
+	}
+	for _, line := range bytes.Split(bytes.TrimSpace(jsonl), []byte("\n")) {
+		var ev transcriptEvent
+		if err := json.Unmarshal(line, &ev); err != nil || ev.SessionID == "" {
+			t.Fatalf("jsonl line %s: session_id=%q err=%v", line, ev.SessionID, err)
+		}
+	}
+
+	if _, err := sup.ConversationHistory("missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("missing conversation err=%v want ErrSessionNotFound", err)
+	}
+
+	// Without the thread's ID the agent would have to resume whichever
+	// thread last ran in the directory, so it starts a new one.
+	for _, id := range []string{"u1", "u2"} {
+		info, err := sup.Start(context.Background(), SessionConfig{
+			ProjectID:      "proj",
+			SessionID:      id,
+			RepoPath:       repo,
+			Options:        map[string]string{"provider": "unnamed"},
+			ConversationID: "conv-2",
+		})
+		if err != nil {
+			t.Fatalf("Start %s: %v", id, err)
+		}
+		if info.ResumedFrom != "" || info.ThreadID != "" {
+			t.Fatalf("%s ResumedFrom=%q ThreadID=%q want a new thread", id, info.ResumedFrom, info.ThreadID)
+		}
+		waitEnded(id)
+	}
+}
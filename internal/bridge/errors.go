@@ -12,8 +12,23 @@ var (
 	ErrClientNotAttached          = errors.New("client is not attached")
 	ErrClientMismatch             = errors.New("client does not own attached session")
 	ErrProviderUnavailable        = errors.New("provider unavailable")
+	ErrProviderAlreadyExists      = errors.New("provider already exists")
+	ErrProviderNotFound           = errors.New("provider not found")
 	ErrSessionLimitReached        = errors.New("session limit reached")
 	ErrInputTooLarge              = errors.New("input too large")
+	ErrInputQueueFull             = errors.New("input queue full")
+	ErrInputNotFound              = errors.New("input not found")
+	ErrApprovalNotFound           = errors.New("approval not found")
+	ErrPermissionDenied           = errors.New("permission denied")
+	ErrFileNotFound               = errors.New("file not found")
+	ErrFileTooLarge               = errors.New("file too large")
+	ErrNotGitRepo                 = errors.New("not a git repository")
+	ErrGitFailed                  = errors.New("git command failed")
+	ErrWorkspaceQuotaExceeded     = errors.New("workspace quota exceeded")
+	ErrBudgetExceeded             = errors.New("budget exceeded")
+	ErrPolicyViolation            = errors.New("content policy violation")
+	ErrNoSnapshot                 = errors.New("no workspace snapshot")
+	ErrSessionRunning             = errors.New("session is running")
 	// ErrWriterConflict is returned by ClaimWriter when another client already
 	// holds the active-writer slot and force was not requested.
 	ErrWriterConflict = errors.New("session already has an active writer")
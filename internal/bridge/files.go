@@ -0,0 +1,178 @@
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileEntry describes one entry returned by ListDir.
+type FileEntry struct {
+	Name       string
+	Dir        bool
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// ReadFile returns the contents of path, relative to the session's repo.
+func (s *Supervisor) ReadFile(sessionID, path string) ([]byte, time.Time, error) {
+	root, err := s.openSessionRoot(sessionID, path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer root.Close()
+
+	f, err := root.Open(cleanRepoPath(path))
+	if err != nil {
+		return nil, time.Time{}, fileError(path, err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, time.Time{}, fileError(path, err)
+	}
+	if fi.IsDir() {
+		return nil, time.Time{}, fmt.Errorf("%w: %q is a directory", ErrInvalidArgument, path)
+	}
+	policy := s.currentPolicy()
+	if err := policy.ValidateFileSize(fi.Size()); err != nil {
+		return nil, time.Time{}, err
+	}
+	data := make([]byte, fi.Size())
+	n, err := f.ReadAt(data, 0)
+	if err != nil && n < len(data) {
+		return nil, time.Time{}, fileError(path, err)
+	}
+	return data, fi.ModTime(), nil
+}
+
+// WriteFile replaces the contents of path, relative to the session's repo,
+// creating the file and any missing parent directories.
+func (s *Supervisor) WriteFile(sessionID, path string, data []byte) error {
+	policy := s.currentPolicy()
+	if err := policy.ValidateFileSize(int64(len(data))); err != nil {
+		return err
+	}
+	root, err := s.openSessionRoot(sessionID, path)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	name := cleanRepoPath(path)
+	if dir := filepath.Dir(name); dir != "." {
+		if err := root.MkdirAll(dir, 0o755); err != nil {
+			return fileError(path, err)
+		}
+	}
+	if err := root.WriteFile(name, data, 0o644); err != nil {
+		return fileError(path, err)
+	}
+	logger().Info("session file written", "session_id", sessionID, "path", name, "bytes", len(data))
+	return nil
+}
+
+// ListDir returns the entries of path, relative to the session's repo,
+// sorted by name. An empty path lists the repo root.
+func (s *Supervisor) ListDir(sessionID, path string) ([]FileEntry, error) {
+	root, err := s.openSessionRoot(sessionID, path)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	f, err := root.Open(cleanRepoPath(path))
+	if err != nil {
+		return nil, fileError(path, err)
+	}
+	defer f.Close()
+	dirents, err := f.ReadDir(-1)
+	if err != nil {
+		return nil, fileError(path, err)
+	}
+	entries := make([]FileEntry, 0, len(dirents))
+	for _, de := range dirents {
+		fi, err := de.Info()
+		if err != nil {
+			// Removed since ReadDir returned it.
+			continue
+		}
+		entries = append(entries, FileEntry{
+			Name:       de.Name(),
+			Dir:        de.IsDir(),
+			Size:       fi.Size(),
+			ModifiedAt: fi.ModTime(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// openSessionRoot checks path and opens the session's repo as an os.Root,
+// which keeps every lookup, symlinks included, inside the repo.
+func (s *Supervisor) openSessionRoot(sessionID, path string) (*os.Root, error) {
+	if path != "" && !filepath.IsLocal(path) {
+		return nil, fmt.Errorf("%w: path %q must be relative to the repo and stay inside it", ErrInvalidArgument, path)
+	}
+	repoPath, err := s.sessionRepo(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(repoPath)
+	if err != nil {
+		return nil, fileError(repoPath, err)
+	}
+	return root, nil
+}
+
+// sessionRepo returns the repo path of a live session. The repo is checked
+// against the current allowed_paths, which may have been narrowed since the
+// session started.
+func (s *Supervisor) sessionRepo(sessionID string) (string, error) {
+	s.mu.RLock()
+	ms, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	ms.mu.Lock()
+	repoPath := ms.cfg.RepoPath
+	projectID := ms.cfg.ProjectID
+	cloned := ms.cfg.Source != nil
+	ms.mu.Unlock()
+	if repoPath == "" {
+		return "", fmt.Errorf("%w: session %q has no repo path", ErrSessionRecoveryUnavailable, sessionID)
+	}
+	if cloned {
+		// Cloned workspaces are managed by the bridge, not allowed_paths.
+		return repoPath, nil
+	}
+	policy := s.currentPolicy()
+	if err := policy.ValidateRepoPath(projectID, repoPath); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrPermissionDenied, err)
+	}
+	return repoPath, nil
+}
+
+func cleanRepoPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return filepath.Clean(path)
+}
+
+// fileError maps a filesystem error onto the bridge error kinds.
+func fileError(path string, err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%w: %q", ErrFileNotFound, path)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("%w: %v", ErrPermissionDenied, err)
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+}
@@ -0,0 +1,115 @@
+package bridge
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newFileTestSupervisor registers a session rooted at a temp repo without
+// starting a process.
+func newFileTestSupervisor(t *testing.T, policy Policy) (*Supervisor, string) {
+	t.Helper()
+	repo := t.TempDir()
+	sup := NewSupervisor(NewRegistry(), policy, 1024, time.Minute)
+	sup.sessions["files"] = &managedSession{cfg: SessionConfig{SessionID: "files", RepoPath: repo}}
+	t.Cleanup(func() {
+		sup.mu.Lock()
+		delete(sup.sessions, "files")
+		sup.mu.Unlock()
+		sup.Close()
+	})
+	return sup, repo
+}
+
+func TestSessionFiles(t *testing.T) {
+	sup, repo := newFileTestSupervisor(t, DefaultPolicy())
+
+	if err := sup.WriteFile("files", "tasks/TASK.md", []byte("fix the bug\n")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(repo, "tasks", "TASK.md"))
+	if err != nil || string(got) != "fix the bug\n" {
+		t.Fatalf("file on disk = %q, %v", got, err)
+	}
+
+	data, modified, err := sup.ReadFile("files", "tasks/TASK.md")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "fix the bug\n" || modified.IsZero() {
+		t.Fatalf("ReadFile = %q at %v", data, modified)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "b.txt"), []byte("12345"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := sup.ListDir("files", "")
+	if err != nil {
+		t.Fatalf("ListDir: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "b.txt" || entries[0].Size != 5 || entries[1].Name != "tasks" || !entries[1].Dir {
+		t.Fatalf("ListDir = %+v", entries)
+	}
+
+	if _, _, err := sup.ReadFile("files", "missing.txt"); !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("ReadFile missing err = %v, want ErrFileNotFound", err)
+	}
+	if _, _, err := sup.ReadFile("files", "tasks"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("ReadFile dir err = %v, want ErrInvalidArgument", err)
+	}
+	if _, err := sup.ListDir("nope", ""); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("ListDir unknown session err = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestSessionFilesStayInRepo(t *testing.T) {
+	sup, repo := newFileTestSupervisor(t, DefaultPolicy())
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("s3cret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(repo, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"../secret", "/etc/passwd", "a/../../secret"} {
+		if _, _, err := sup.ReadFile("files", path); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("ReadFile(%q) err = %v, want ErrInvalidArgument", path, err)
+		}
+	}
+	if _, _, err := sup.ReadFile("files", "escape/secret"); err == nil {
+		t.Fatal("ReadFile followed a symlink out of the repo")
+	}
+	if err := sup.WriteFile("files", "escape/planted", []byte("x")); err == nil {
+		t.Fatal("WriteFile followed a symlink out of the repo")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "planted")); !os.IsNotExist(err) {
+		t.Fatalf("planted file exists outside the repo: %v", err)
+	}
+}
+
+func TestSessionFilesPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.MaxFileBytes = 4
+	sup, repo := newFileTestSupervisor(t, policy)
+
+	if err := sup.WriteFile("files", "big", []byte("12345")); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("WriteFile err = %v, want ErrFileTooLarge", err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "big"), []byte("12345"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := sup.ReadFile("files", "big"); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("ReadFile err = %v, want ErrFileTooLarge", err)
+	}
+
+	// Narrowing allowed_paths after the session started cuts off access.
+	policy.AllowedPaths = []string{filepath.Join(t.TempDir(), "*")}
+	sup.SetPolicy(policy)
+	if _, err := sup.ListDir("files", ""); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("ListDir err = %v, want ErrPermissionDenied", err)
+	}
+}
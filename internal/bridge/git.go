@@ -0,0 +1,334 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Default commit identity used when the policy does not set one.
+const (
+	DefaultGitAuthorName  = "AI Agent Bridge"
+	DefaultGitAuthorEmail = "bridge@localhost"
+)
+
+// gitTimeout bounds each git invocation.
+const gitTimeout = 30 * time.Second
+
+// GitStatus is the state of a session repo's working tree. File paths are
+// relative to the top of the work tree.
+type GitStatus struct {
+	Branch string // empty when HEAD is detached
+	Head   string // empty before the first commit
+	Files  []GitFileStatus
+}
+
+// Clean reports whether the working tree has no changes.
+func (g GitStatus) Clean() bool { return len(g.Files) == 0 }
+
+// GitFileStatus is one changed path, using the two-letter codes of
+// git status --porcelain.
+type GitFileStatus struct {
+	Path     string
+	OrigPath string // source path of a rename or copy
+	Index    byte
+	Worktree byte
+}
+
+// GitDiffOptions selects what GitDiff compares.
+type GitDiffOptions struct {
+	// Staged diffs the index against Base (HEAD when empty) instead of the
+	// working tree against the index.
+	Staged bool
+	// Base is a revision to diff against.
+	Base string
+	// Paths limits the diff to these repo-relative paths.
+	Paths []string
+}
+
+// GitCommitOptions describes a commit made by GitCommit.
+type GitCommitOptions struct {
+	Message string
+	// All stages every change, including untracked files, before
+	// committing. Otherwise Paths are staged, and with neither the commit
+	// takes whatever is already staged.
+	All   bool
+	Paths []string
+}
+
+// GitStatus returns the working tree state of the session's repo.
+func (s *Supervisor) GitStatus(ctx context.Context, sessionID string) (*GitStatus, error) {
+	repo, err := s.sessionGitRepo(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	out, err := runGit(ctx, repo, nil, "status", "--porcelain=v1", "-z", "--branch", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	status := parseGitStatus(out)
+	if head, err := runGit(ctx, repo, nil, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		status.Head = strings.TrimSpace(string(head))
+	}
+	return status, nil
+}
+
+// GitDiff returns a unified diff of the session's repo. The diff is cut at
+// the policy's MaxFileBytes; truncated reports whether it was.
+func (s *Supervisor) GitDiff(ctx context.Context, sessionID string, opts GitDiffOptions) (diff []byte, truncated bool, err error) {
+	if err := validateGitPaths(opts.Paths); err != nil {
+		return nil, false, err
+	}
+	if strings.HasPrefix(opts.Base, "-") {
+		return nil, false, fmt.Errorf("%w: base %q is not a revision", ErrInvalidArgument, opts.Base)
+	}
+	repo, err := s.sessionGitRepo(ctx, sessionID)
+	if err != nil {
+		return nil, false, err
+	}
+	args := []string{"diff", "--no-ext-diff", "--no-textconv", "--no-color"}
+	if opts.Staged {
+		args = append(args, "--cached")
+	}
+	if opts.Base != "" {
+		args = append(args, opts.Base)
+	}
+	args = append(args, "--")
+	args = append(args, opts.Paths...)
+	out, err := runGit(ctx, repo, nil, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	policy := s.currentPolicy()
+	if policy.MaxFileBytes > 0 && int64(len(out)) > policy.MaxFileBytes {
+		return out[:policy.MaxFileBytes], true, nil
+	}
+	return out, false, nil
+}
+
+// GitCommit commits to the session's repo as the policy's git identity and
+// returns the new commit hash. Repo hooks are not run.
+func (s *Supervisor) GitCommit(ctx context.Context, sessionID string, opts GitCommitOptions) (string, error) {
+	if strings.TrimSpace(opts.Message) == "" {
+		return "", fmt.Errorf("%w: commit message is required", ErrInvalidArgument)
+	}
+	if err := validateGitPaths(opts.Paths); err != nil {
+		return "", err
+	}
+	repo, err := s.sessionGitRepo(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case opts.All:
+		_, err = runGit(ctx, repo, nil, "add", "--all")
+	case len(opts.Paths) > 0:
+		_, err = runGit(ctx, repo, nil, append([]string{"add", "--all", "--"}, opts.Paths...)...)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	policy := s.currentPolicy()
+	name, email := policy.GitAuthorName, policy.GitAuthorEmail
+	if name == "" {
+		name = DefaultGitAuthorName
+	}
+	if email == "" {
+		email = DefaultGitAuthorEmail
+	}
+	env := []string{
+		"GIT_AUTHOR_NAME=" + name,
+		"GIT_AUTHOR_EMAIL=" + email,
+		"GIT_COMMITTER_NAME=" + name,
+		"GIT_COMMITTER_EMAIL=" + email,
+	}
+	if _, err := runGit(ctx, repo, env, "commit", "--quiet", "--no-verify", "--message="+opts.Message); err != nil {
+		return "", err
+	}
+	head, err := runGit(ctx, repo, nil, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	sha := strings.TrimSpace(string(head))
+	logger().Info("session git commit", "session_id", sessionID, "commit", sha, "author", email)
+	return sha, nil
+}
+
+// sessionGitRepo returns the session's repo path after checking that it is
+// inside a git work tree.
+func (s *Supervisor) sessionGitRepo(ctx context.Context, sessionID string) (string, error) {
+	repo, err := s.sessionRepo(sessionID)
+	if err != nil {
+		return "", err
+	}
+	out, err := runGit(ctx, repo, nil, "rev-parse", "--is-inside-work-tree")
+	if err != nil || strings.TrimSpace(string(out)) != "true" {
+		return "", fmt.Errorf("%w: %s", ErrNotGitRepo, repo)
+	}
+	return repo, nil
+}
+
+// watchRepo polls the session repo's status every interval until ctx is
+// done and broadcasts ChunkTypeRepoDirty whenever the set of changes differs
+// from the last poll and the tree is not clean. It stops quietly when the
+// repo is not a git work tree.
+func (s *Supervisor) watchRepo(ctx context.Context, ms *managedSession, interval time.Duration) {
+	ms.mu.Lock()
+	repo := ms.cfg.RepoPath
+	ms.mu.Unlock()
+	if _, err := runGit(ctx, repo, nil, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last []byte
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		out, err := runGit(ctx, repo, nil, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(out, last) {
+			continue
+		}
+		last = out
+		if len(out) > 0 {
+			s.fanoutControlEvent(ms, ChunkTypeRepoDirty, nil)
+		}
+	}
+}
+
+// runGit runs git in repo with extra environment entries. Hooks, fsmonitor
+// and filter drivers, which the agent could point at its own programs
+// through the repo's config, are disabled.
+func runGit(ctx context.Context, repo string, env []string, args ...string) ([]byte, error) {
+	return runGitTimeout(ctx, gitTimeout, repo, env, args...)
+}
+
+// gitSafeArgs are the -c overrides every bridge git command runs with.
+// core.attributesFile and GIT_ATTR_NOSYSTEM keep attributes outside the
+// repo from selecting drivers; the repo's own drivers are cleared by
+// gitFilterOverrides.
+var gitSafeArgs = []string{
+	"-c", "core.hooksPath=" + os.DevNull,
+	"-c", "core.fsmonitor=false",
+	"-c", "commit.gpgsign=false",
+	"-c", "core.attributesFile=" + os.DevNull,
+}
+
+// gitFilterKeys matches the config keys of filter driver commands, which git
+// runs on file contents when adding or checking out files.
+const gitFilterKeys = `^filter\..+\.(clean|smudge|process)$`
+
+// gitFilterOverrides returns environment entries that blank every filter
+// driver command configured for repo, so git stores and checks out file
+// contents unconverted. They are passed as GIT_CONFIG_KEY_n/VALUE_n rather
+// than -c so driver names containing "=" cannot escape. Repos relying on a
+// filter (git-lfs, for one) are committed and restored as their raw
+// working-tree bytes.
+func gitFilterOverrides(ctx context.Context, repo string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", append(slices.Clone(gitSafeArgs), "config", "--null", "--get-regexp", gitFilterKeys)...)
+	cmd.Dir = repo
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ATTR_NOSYSTEM=1", "LC_ALL=C")
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return []string{"GIT_CONFIG_COUNT=0"}, nil // no filter drivers configured
+		}
+		return nil, fmt.Errorf("git config: %w", err)
+	}
+	var env []string
+	n := 0
+	for entry := range strings.SplitSeq(string(out), "\x00") {
+		key, _, _ := strings.Cut(entry, "\n")
+		if key == "" {
+			continue
+		}
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", n, key), fmt.Sprintf("GIT_CONFIG_VALUE_%d=", n))
+		n++
+	}
+	return append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", n)), nil
+}
+
+// runGitTimeout is runGit with a caller-chosen timeout.
+func runGitTimeout(ctx context.Context, timeout time.Duration, repo string, env []string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	overrides, err := gitFilterOverrides(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	full := append(slices.Clone(gitSafeArgs), args...)
+	cmd := exec.CommandContext(ctx, "git", full...)
+	cmd.Dir = repo
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_OPTIONAL_LOCKS=0", "GIT_ATTR_NOSYSTEM=1", "LC_ALL=C")
+	cmd.Env = append(cmd.Env, overrides...)
+	cmd.Env = append(cmd.Env, env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = strings.TrimSpace(stdout.String())
+			}
+			return nil, fmt.Errorf("%w: git %s: %s", ErrGitFailed, args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// parseGitStatus parses git status --porcelain=v1 -z --branch output.
+func parseGitStatus(out []byte) *GitStatus {
+	status := &GitStatus{}
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if len(f) < 3 {
+			continue
+		}
+		if branch, ok := strings.CutPrefix(f, "## "); ok {
+			branch, _, _ = strings.Cut(branch, "...")
+			branch = strings.TrimPrefix(branch, "No commits yet on ")
+			if branch == "HEAD (no branch)" {
+				branch = ""
+			}
+			status.Branch = branch
+			continue
+		}
+		fs := GitFileStatus{Index: f[0], Worktree: f[1], Path: f[3:]}
+		// Renames and copies are followed by their source path.
+		if (fs.Index == 'R' || fs.Index == 'C') && i+1 < len(fields) {
+			i++
+			fs.OrigPath = fields[i]
+		}
+		status.Files = append(status.Files, fs)
+	}
+	return status
+}
+
+func validateGitPaths(paths []string) error {
+	for _, p := range paths {
+		if !filepath.IsLocal(p) {
+			return fmt.Errorf("%w: path %q must be relative to the repo and stay inside it", ErrInvalidArgument, p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newGitTestSupervisor is newFileTestSupervisor with the repo initialised
+// as a git work tree holding one commit.
+func newGitTestSupervisor(t *testing.T, policy Policy) (*Supervisor, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	sup, repo := newFileTestSupervisor(t, policy)
+	gitCmd(t, repo, "init", "--quiet", "--initial-branch=main")
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, repo, "add", "README.md")
+	gitCmd(t, repo, "-c", "user.name=Setup", "-c", "user.email=setup@example.com", "commit", "--quiet", "-m", "initial")
+	return sup, repo
+}
+
+func gitCmd(t *testing.T, repo string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestGitStatusDiffCommit(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.GitAuthorName = "Release Bot"
+	policy.GitAuthorEmail = "bot@example.com"
+	sup, repo := newGitTestSupervisor(t, policy)
+	ctx := context.Background()
+
+	st, err := sup.GitStatus(ctx, "files")
+	if err != nil {
+		t.Fatalf("GitStatus: %v", err)
+	}
+	if st.Branch != "main" || st.Head == "" || !st.Clean() {
+		t.Fatalf("GitStatus clean = %+v", st)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\nworld\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "new.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	st, err = sup.GitStatus(ctx, "files")
+	if err != nil {
+		t.Fatalf("GitStatus: %v", err)
+	}
+	if len(st.Files) != 2 || st.Files[0].Path != "README.md" || st.Files[0].Worktree != 'M' || st.Files[1].Path != "new.txt" || st.Files[1].Index != '?' {
+		t.Fatalf("GitStatus dirty = %+v", st.Files)
+	}
+
+	diff, truncated, err := sup.GitDiff(ctx, "files", GitDiffOptions{})
+	if err != nil {
+		t.Fatalf("GitDiff: %v", err)
+	}
+	if truncated || !strings.Contains(string(diff), "+world") {
+		t.Fatalf("GitDiff = %q truncated=%v", diff, truncated)
+	}
+
+	// Hooks planted in the repo must not run.
+	hook := filepath.Join(repo, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\ntouch \"$(git rev-parse --git-dir)/hook-ran\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sha, err := sup.GitCommit(ctx, "files", GitCommitOptions{Message: "update readme", All: true})
+	if err != nil {
+		t.Fatalf("GitCommit: %v", err)
+	}
+	if got := gitCmd(t, repo, "log", "-1", "--format=%H %an <%ae> %s"); got != sha+" Release Bot <bot@example.com> update readme" {
+		t.Fatalf("commit = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".git", "hook-ran")); !os.IsNotExist(err) {
+		t.Fatal("pre-commit hook ran")
+	}
+
+	if _, err := sup.GitCommit(ctx, "files", GitCommitOptions{Message: "nothing"}); !errors.Is(err, ErrGitFailed) {
+		t.Fatalf("empty GitCommit err = %v, want ErrGitFailed", err)
+	}
+	if _, _, err := sup.GitDiff(ctx, "files", GitDiffOptions{Base: "--output=/tmp/x"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("GitDiff option base err = %v, want ErrInvalidArgument", err)
+	}
+	if _, err := sup.GitCommit(ctx, "files", GitCommitOptions{Message: "m", Paths: []string{"../x"}}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("GitCommit outside path err = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestGitSkipsFilterDrivers(t *testing.T) {
+	sup, repo := newGitTestSupervisor(t, DefaultPolicy())
+	ctx := context.Background()
+
+	// A filter driver planted by the agent must not run when the bridge
+	// stages or inspects files.
+	marker := filepath.Join(t.TempDir(), "filter-ran")
+	for _, kind := range []string{"clean", "smudge", "process"} {
+		gitCmd(t, repo, "config", "filter.evil="+kind+"."+kind, "touch "+marker+"; cat")
+		gitCmd(t, repo, "config", "filter.plain."+kind, "touch "+marker+"; cat")
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte("*.txt filter=plain\n*.md filter=evil=clean\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("secret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sup.GitStatus(ctx, "files"); err != nil {
+		t.Fatalf("GitStatus: %v", err)
+	}
+	if _, err := sup.GitCommit(ctx, "files", GitCommitOptions{Message: "add notes", All: true}); err != nil {
+		t.Fatalf("GitCommit: %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("filter driver ran")
+	}
+	if got := gitCmd(t, repo, "-c", "filter.plain.clean=", "-c", "filter.plain.process=", "show", "HEAD:notes.txt"); got != "secret" {
+		t.Fatalf("committed notes.txt = %q", got)
+	}
+}
+
+func TestGitNotARepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	sup, _ := newFileTestSupervisor(t, DefaultPolicy())
+	if _, err := sup.GitStatus(context.Background(), "files"); !errors.Is(err, ErrNotGitRepo) {
+		t.Fatalf("GitStatus err = %v, want ErrNotGitRepo", err)
+	}
+}
+
+func TestParseGitStatus(t *testing.T) {
+	out := "## main...origin/main [ahead 1]\x00R  new.go\x00old.go\x00 M a.go\x00?? b.go\x00"
+	st := parseGitStatus([]byte(out))
+	if st.Branch != "main" || len(st.Files) != 3 {
+		t.Fatalf("parseGitStatus = %+v", st)
+	}
+	if f := st.Files[0]; f.Path != "new.go" || f.OrigPath != "old.go" || f.Index != 'R' {
+		t.Fatalf("rename = %+v", f)
+	}
+	if f := st.Files[1]; f.Path != "a.go" || f.Index != ' ' || f.Worktree != 'M' {
+		t.Fatalf("modified = %+v", f)
+	}
+	if st := parseGitStatus([]byte("## HEAD (no branch)\x00")); st.Branch != "" {
+		t.Fatalf("detached branch = %q", st.Branch)
+	}
+	if st := parseGitStatus([]byte("## No commits yet on main\x00")); st.Branch != "main" {
+		t.Fatalf("unborn branch = %q", st.Branch)
+	}
+}
+
+func TestWatchRepoEmitsRepoDirty(t *testing.T) {
+	sup, repo := newGitTestSupervisor(t, DefaultPolicy())
+	sup.mu.RLock()
+	ms := sup.sessions["files"]
+	sup.mu.RUnlock()
+	ch := make(chan OutputChunk, 8)
+	ms.observers = map[string]*observerEntry{"c": {ch: ch}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sup.watchRepo(ctx, ms, 10*time.Millisecond)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	if err := os.WriteFile(filepath.Join(repo, "agent.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case chunk := <-ch:
+		if chunk.Type != ChunkTypeRepoDirty {
+			t.Fatalf("chunk type = %v, want ChunkTypeRepoDirty", chunk.Type)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no REPO_DIRTY event")
+	}
+
+	// An unchanged tree is not reported again.
+	select {
+	case chunk := <-ch:
+		t.Fatalf("unexpected event %+v", chunk)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
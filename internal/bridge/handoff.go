@@ -0,0 +1,121 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HandoffVersion is the SessionHandoff format written by ExportState.
+// ImportState rejects other versions.
+const HandoffVersion = 1
+
+// handoffDrainTimeout bounds how long ExportState waits, beyond the
+// provider's stop grace period, for a stopped session's output to drain.
+const handoffDrainTimeout = 2 * time.Second
+
+// SessionHandoff carries a session between bridge instances. The agent
+// process does not move: ImportState starts a new one from Config, while
+// buffered output, the input log and usage carry over so clients can
+// reattach on the new instance with the last seq they saw.
+type SessionHandoff struct {
+	Version    int
+	Config     SessionConfig
+	Info       SessionInfo
+	Chunks     []OutputChunk
+	Inputs     []InputRecord `json:",omitempty"`
+	ExportedAt time.Time
+}
+
+// ExportState returns the handoff state of an in-memory session. With stop
+// set the session is stopped first and its output drained, so the export
+// includes everything the agent wrote; otherwise output produced after the
+// export stays on this instance only.
+func (s *Supervisor) ExportState(ctx context.Context, sessionID string, stop bool) (*SessionHandoff, error) {
+	s.mu.RLock()
+	ms, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	ms.mu.Lock()
+	recovered := ms.recovered
+	grace := ms.stopGrace
+	ms.mu.Unlock()
+	if recovered {
+		return nil, fmt.Errorf("%w: %q has no start configuration to hand off", ErrSessionRecoveryUnavailable, sessionID)
+	}
+
+	if stop {
+		if err := s.Stop(sessionID, false); err != nil {
+			return nil, err
+		}
+		if err := waitLiveClosed(ctx, ms, grace+handoffDrainTimeout); err != nil {
+			return nil, fmt.Errorf("drain session %q: %w", sessionID, err)
+		}
+	}
+
+	snapshot := ms.snapshotHistory()
+	ms.mu.Lock()
+	cfg := ms.cfg
+	ms.mu.Unlock()
+	// The snapshot leaves chunks spilled to disk behind: the import is
+	// sized for one in-memory buffer.
+	return &SessionHandoff{
+		Version:    HandoffVersion,
+		Config:     cfg,
+		Info:       snapshot.Info,
+		Chunks:     snapshot.Chunks,
+		Inputs:     snapshot.Inputs,
+		ExportedAt: nowUTC(),
+	}, nil
+}
+
+// waitLiveClosed waits until the session's read loop has finished.
+func waitLiveClosed(ctx context.Context, ms *managedSession, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ms.mu.Lock()
+		closed := ms.liveClosed
+		ms.mu.Unlock()
+		if closed {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("output still open after %s", timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(25 * time.Millisecond):
+		}
+	}
+}
+
+// ImportState starts a session handed off from another bridge instance.
+// The new agent process is subject to the same policy checks as Start.
+// Sequence numbers continue after the exported LastSeq.
+func (s *Supervisor) ImportState(ctx context.Context, h *SessionHandoff) (*SessionInfo, error) {
+	if h == nil || h.Version != HandoffVersion {
+		return nil, fmt.Errorf("%w: unsupported session handoff version", ErrInvalidArgument)
+	}
+	if h.Config.SessionID != h.Info.SessionID || h.Config.ProjectID != h.Info.ProjectID {
+		return nil, fmt.Errorf("%w: session handoff config does not match its session", ErrInvalidArgument)
+	}
+	return s.start(ctx, h.Config, h)
+}
+
+// restore seeds a new session with handed-off state. It runs before the
+// session is published, so no lock is needed.
+func (ms *managedSession) restore(h *SessionHandoff) {
+	for _, c := range h.Chunks {
+		ms.buf.AppendChunk(c)
+	}
+	ms.buf.ResumeAfter(h.Info.LastSeq)
+	ms.inputs = append([]InputRecord(nil), h.Inputs...)
+	ms.info.CreatedAt = h.Info.CreatedAt
+	ms.info.Usage = h.Info.Usage
+	ms.info.ResumedFrom = h.Info.ResumedFrom
+	ms.info.OldestSeq = ms.buf.OldestSeq()
+	ms.info.LastSeq = ms.buf.LastSeq()
+}
@@ -0,0 +1,90 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSessionHandoffPreservesSeq(t *testing.T) {
+	src := newTestSupervisor(t)
+	startTestSession(t, src, "handoff-1")
+	state, err := src.Attach("handoff-1", "client-a", 0, AttachRoleWriter)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if _, err := src.WriteInput("handoff-1", "client-a", []byte("before\n")); err != nil {
+		t.Fatalf("WriteInput: %v", err)
+	}
+	waitForChunk(t, state.Live, "before")
+	if err := src.Detach("handoff-1", "client-a"); err != nil {
+		t.Fatalf("Detach: %v", err)
+	}
+
+	h, err := src.ExportState(context.Background(), "handoff-1", true)
+	if err != nil {
+		t.Fatalf("ExportState: %v", err)
+	}
+	if h.Version != HandoffVersion || h.Config.RepoPath == "" || h.Info.LastSeq == 0 || !containsPayload(h.Chunks, "before") {
+		t.Fatalf("ExportState=%+v want config and buffered output", h)
+	}
+	if len(h.Inputs) != 1 {
+		t.Fatalf("exported inputs=%d want 1", len(h.Inputs))
+	}
+
+	dst := newTestSupervisor(t)
+	info, err := dst.ImportState(context.Background(), h)
+	if err != nil {
+		t.Fatalf("ImportState: %v", err)
+	}
+	if info.SessionID != "handoff-1" || info.LastSeq != h.Info.LastSeq || !info.CreatedAt.Equal(h.Info.CreatedAt) {
+		t.Fatalf("imported info=%+v want LastSeq %d", info, h.Info.LastSeq)
+	}
+
+	// A client reattaching with the last seq it saw gets no replay, and new
+	// output continues the numbering.
+	resumed, err := dst.Attach("handoff-1", "client-a", h.Info.LastSeq, AttachRoleWriter)
+	if err != nil {
+		t.Fatalf("Attach imported: %v", err)
+	}
+	if len(resumed.Replay) != 0 {
+		t.Fatalf("replay after last seq=%+v want none", resumed.Replay)
+	}
+	if _, err := dst.WriteInput("handoff-1", "client-a", []byte("after\n")); err != nil {
+		t.Fatalf("WriteInput imported: %v", err)
+	}
+	if chunk := waitForChunk(t, resumed.Live, "after"); chunk.Seq <= h.Info.LastSeq {
+		t.Fatalf("new chunk seq=%d want > %d", chunk.Seq, h.Info.LastSeq)
+	}
+
+	if _, err := dst.ImportState(context.Background(), h); !errors.Is(err, ErrSessionAlreadyExists) {
+		t.Fatalf("second ImportState error=%v want %v", err, ErrSessionAlreadyExists)
+	}
+}
+
+func TestImportStateRejectsInvalidHandoff(t *testing.T) {
+	sup := newTestSupervisor(t)
+	tests := []struct {
+		name string
+		h    *SessionHandoff
+	}{
+		{name: "nil", h: nil},
+		{name: "unknown version", h: &SessionHandoff{Version: 99}},
+		{name: "mismatched ids", h: &SessionHandoff{
+			Version: HandoffVersion,
+			Config:  SessionConfig{SessionID: "a", ProjectID: "p"},
+			Info:    SessionInfo{SessionID: "b", ProjectID: "p"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := sup.ImportState(context.Background(), tt.h); !errors.Is(err, ErrInvalidArgument) {
+				t.Fatalf("ImportState error=%v want %v", err, ErrInvalidArgument)
+			}
+		})
+	}
+
+	if _, err := sup.ExportState(context.Background(), "missing", false); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("ExportState missing error=%v want %v", err, ErrSessionNotFound)
+	}
+}
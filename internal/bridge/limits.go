@@ -0,0 +1,39 @@
+package bridge
+
+// ResourceLimits caps the resources of one agent process. Zero fields are
+// unlimited.
+type ResourceLimits struct {
+	// MemoryBytes caps the agent's private memory.
+	MemoryBytes int64
+	// CPUSeconds caps the CPU time of each agent process; the process is
+	// killed when it runs out.
+	CPUSeconds int64
+	// Processes caps the number of processes and threads.
+	Processes int
+}
+
+// IsZero reports whether no limit is set.
+func (l ResourceLimits) IsZero() bool {
+	return l == ResourceLimits{}
+}
+
+// Tighter returns the stricter of l and o for each limit, so a per-project
+// limit can only narrow a per-provider one and vice versa.
+func (l ResourceLimits) Tighter(o ResourceLimits) ResourceLimits {
+	return ResourceLimits{
+		MemoryBytes: tighter(l.MemoryBytes, o.MemoryBytes),
+		CPUSeconds:  tighter(l.CPUSeconds, o.CPUSeconds),
+		Processes:   tighter(l.Processes, o.Processes),
+	}
+}
+
+func tighter[T int | int64](a, b T) T {
+	switch {
+	case a == 0:
+		return b
+	case b == 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
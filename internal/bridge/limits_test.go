@@ -0,0 +1,66 @@
+package bridge
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResourceLimitsTighter(t *testing.T) {
+	provider := ResourceLimits{MemoryBytes: 4 << 30, CPUSeconds: 3600}
+	project := ResourceLimits{MemoryBytes: 1 << 30, Processes: 64}
+	want := ResourceLimits{MemoryBytes: 1 << 30, CPUSeconds: 3600, Processes: 64}
+	if got := provider.Tighter(project); got != want {
+		t.Fatalf("Tighter = %+v want %+v", got, want)
+	}
+	if got := project.Tighter(provider); got != want {
+		t.Fatalf("Tighter is not symmetric: %+v", got)
+	}
+	if !(ResourceLimits{}).Tighter(ResourceLimits{}).IsZero() {
+		t.Fatal("zero limits did not stay unlimited")
+	}
+}
+
+// limitsProvider records the limits the supervisor passes to BuildCommand.
+type limitsProvider struct {
+	registryProvider
+	mu  sync.Mutex
+	got []ResourceLimits
+}
+
+func (p *limitsProvider) BuildCommand(_ context.Context, cfg SessionConfig) (*exec.Cmd, error) {
+	p.mu.Lock()
+	p.got = append(p.got, cfg.Limits)
+	p.mu.Unlock()
+	return exec.Command(trueBin), nil
+}
+
+func TestSupervisorPassesProjectLimits(t *testing.T) {
+	registry := NewRegistry()
+	prov := &limitsProvider{registryProvider: registryProvider{id: "agent"}}
+	if err := registry.Register(prov); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	policy := DefaultPolicy()
+	policy.ProjectLimits = map[string]ResourceLimits{"batch": {MemoryBytes: 1 << 30}}
+	sup := NewSupervisor(registry, policy, 1024, time.Minute)
+	defer sup.Close()
+
+	for i, project := range []string{"batch", "interactive"} {
+		if _, err := sup.Start(context.Background(), SessionConfig{
+			ProjectID: project,
+			SessionID: []string{"11111111-1111-1111-1111-111111111111", "22222222-2222-2222-2222-222222222222"}[i],
+			RepoPath:  t.TempDir(),
+			Options:   map[string]string{"provider": "agent"},
+		}); err != nil {
+			t.Fatalf("Start %s: %v", project, err)
+		}
+	}
+	prov.mu.Lock()
+	defer prov.mu.Unlock()
+	if len(prov.got) != 2 || prov.got[0].MemoryBytes != 1<<30 || !prov.got[1].IsZero() {
+		t.Fatalf("limits passed to provider = %+v", prov.got)
+	}
+}
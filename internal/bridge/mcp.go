@@ -0,0 +1,72 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// MCPConfigDir is where the MCP server config of each session is written,
+// relative to the session's repo, as <session-id>.json.
+const MCPConfigDir = ".bridge/mcp"
+
+// MCP server transports.
+const (
+	MCPTransportStdio = "stdio"
+	MCPTransportHTTP  = "http"
+	MCPTransportSSE   = "sse"
+)
+
+// MCPServer is an MCP server an agent is given, in the shape of an
+// mcpServers entry of Claude Code's .mcp.json. Stdio servers are run by the
+// agent with Command, Args and Env; http and sse servers are reached at URL.
+type MCPServer struct {
+	Type    string            `json:"type,omitempty"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// MCPConfigProvider is implemented by providers whose agent reads MCP
+// servers from a config file named on its command line.
+type MCPConfigProvider interface {
+	// MCPConfigFlag is the flag that precedes the config file's path, e.g.
+	// "--mcp-config". Empty means the agent takes no MCP config.
+	MCPConfigFlag() string
+}
+
+// providerMCPConfigFlag returns provider's MCP config flag, if any.
+func providerMCPConfigFlag(provider Provider) string {
+	if mp, ok := provider.(MCPConfigProvider); ok {
+		return mp.MCPConfigFlag()
+	}
+	return ""
+}
+
+// writeMCPConfig writes servers to MCPConfigDir/<sessionID>.json in repo
+// and returns the file's path relative to repo. The file may hold secrets
+// from the servers' env and headers, so only the owner can read it.
+func writeMCPConfig(repo, sessionID string, servers map[string]MCPServer) (string, error) {
+	data, err := json.MarshalIndent(map[string]any{"mcpServers": servers}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode MCP config: %w", err)
+	}
+	root, err := os.OpenRoot(repo)
+	if err != nil {
+		return "", fmt.Errorf("write MCP config: %w", err)
+	}
+	defer root.Close()
+	dir := filepath.FromSlash(MCPConfigDir)
+	if err := root.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("write MCP config: %w", err)
+	}
+	rel := path.Join(MCPConfigDir, sessionID+".json")
+	if err := root.WriteFile(filepath.FromSlash(rel), data, 0o600); err != nil {
+		return "", fmt.Errorf("write MCP config: %w", err)
+	}
+	return rel, nil
+}
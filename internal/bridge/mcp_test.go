@@ -0,0 +1,82 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mcpTestProvider is a testProvider that takes an MCP config and records
+// the one it was given.
+type mcpTestProvider struct {
+	testProvider
+	mcpConfig string
+}
+
+func (p *mcpTestProvider) MCPConfigFlag() string { return "--mcp-config" }
+
+func (p *mcpTestProvider) BuildCommand(ctx context.Context, cfg SessionConfig) (*exec.Cmd, error) {
+	p.mcpConfig = cfg.MCPConfig
+	return p.testProvider.BuildCommand(ctx, cfg)
+}
+
+func TestStartWritesMCPConfig(t *testing.T) {
+	registry := NewRegistry()
+	mcp := &mcpTestProvider{testProvider: testProvider{id: "mcp"}}
+	for _, p := range []Provider{mcp, &testProvider{id: "plain"}} {
+		if err := registry.Register(p); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	policy := DefaultPolicy()
+	policy.Projects = map[string]ProjectPolicy{
+		"project-a": {MCPServers: map[string]MCPServer{
+			"docs":   {Type: MCPTransportStdio, Command: "docs-mcp", Args: []string{"--ro"}},
+			"issues": {Type: MCPTransportHTTP, URL: "https://issues.example/mcp"},
+		}},
+	}
+	s := NewSupervisor(registry, policy, 1024, time.Minute)
+	defer s.Close()
+
+	repo := t.TempDir()
+	start := func(sessionID, providerID string) {
+		t.Helper()
+		if _, err := s.Start(context.Background(), SessionConfig{
+			ProjectID: "project-a",
+			SessionID: sessionID,
+			RepoPath:  repo,
+			Options:   map[string]string{"provider": providerID},
+		}); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		t.Cleanup(func() { _ = s.Stop(sessionID, true) })
+	}
+
+	start("session-a", "mcp")
+	if mcp.mcpConfig != MCPConfigDir+"/session-a.json" {
+		t.Fatalf("MCPConfig = %q", mcp.mcpConfig)
+	}
+	data, err := os.ReadFile(filepath.Join(repo, mcp.mcpConfig))
+	if err != nil {
+		t.Fatalf("read MCP config: %v", err)
+	}
+	var file struct {
+		MCPServers map[string]MCPServer `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("decode MCP config: %v", err)
+	}
+	if file.MCPServers["docs"].Command != "docs-mcp" || file.MCPServers["issues"].URL != "https://issues.example/mcp" {
+		t.Fatalf("MCP config = %s", data)
+	}
+
+	// A provider that takes no MCP config starts without one.
+	start("session-b", "plain")
+	if _, err := os.Stat(filepath.Join(repo, MCPConfigDir, "session-b.json")); !os.IsNotExist(err) {
+		t.Fatalf("MCP config written for a provider without a flag: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// openCodeEvent is the JSON shape emitted by `opencode run --format json`.
+// Each line carries a top-level event type and, for message parts, the part
+// itself. Only the fields we inspect are declared.
+type openCodeEvent struct {
+	Type string `json:"type"`
+	Part *struct {
+		Type   string `json:"type"`
+		Text   string `json:"text,omitempty"`
+		Tool   string `json:"tool,omitempty"`
+		CallID string `json:"callID,omitempty"`
+		State  *struct {
+			Input json.RawMessage `json:"input,omitempty"`
+		} `json:"state,omitempty"`
+		Reason string  `json:"reason,omitempty"`
+		Cost   float64 `json:"cost,omitempty"`
+		Tokens *struct {
+			Input  int64 `json:"input"`
+			Output int64 `json:"output"`
+			Cache  struct {
+				Read  int64 `json:"read"`
+				Write int64 `json:"write"`
+			} `json:"cache"`
+		} `json:"tokens,omitempty"`
+	} `json:"part,omitempty"`
+	Error *struct {
+		Name string `json:"name"`
+		Data struct {
+			Message string `json:"message"`
+		} `json:"data"`
+	} `json:"error,omitempty"`
+}
+
+// decodeOpenCodeLine maps one opencode JSON event onto typed chunks:
+//
+//   - "text" parts become ChunkTypeOutput
+//   - "reasoning" parts become ChunkTypeThinking
+//   - "tool_use" events become ChunkTypeToolUse with the tool's name, and
+//     its call ID and input as the chunk's ToolCall
+//   - "step_finish" reports the step's token and cost usage; with any reason
+//     other than "tool-calls" it also ends the turn and becomes
+//     ChunkTypeResponseComplete
+//   - "error" events are surfaced as output so attached clients see them;
+//     opencode emits no step_finish after one, so they also end the turn
+//
+// Step starts are dropped; they carry no user-facing text.
+func decodeOpenCodeLine(line []byte) ([]streamChunk, bool) {
+	var ev openCodeEvent
+	if err := json.Unmarshal(line, &ev); err != nil {
+		return nil, false
+	}
+	switch ev.Type {
+	case "text":
+		if ev.Part != nil && ev.Part.Text != "" {
+			return []streamChunk{{ctype: ChunkTypeOutput, payload: []byte(ev.Part.Text)}}, true
+		}
+	case "reasoning":
+		if ev.Part != nil && ev.Part.Text != "" {
+			return []streamChunk{{ctype: ChunkTypeThinking, payload: []byte(ev.Part.Text)}}, true
+		}
+	case "tool_use":
+		if p := ev.Part; p != nil && p.Tool != "" {
+			var input json.RawMessage
+			if p.State != nil {
+				input = p.State.Input
+			}
+			return []streamChunk{{ctype: ChunkTypeToolUse, payload: []byte(p.Tool), data: toolCallData(p.CallID, p.Tool, input)}}, true
+		}
+	case "step_finish":
+		var out []streamChunk
+		final := ev.Part == nil || ev.Part.Reason != "tool-calls"
+		if ev.Part != nil {
+			usage := Usage{CostUSD: ev.Part.Cost}
+			if t := ev.Part.Tokens; t != nil {
+				usage.InputTokens = t.Input + t.Cache.Read + t.Cache.Write
+				usage.OutputTokens = t.Output
+			}
+			if final {
+				usage.Turns = 1
+			}
+			if usage != (Usage{}) {
+				out = append(out, streamChunk{usage: &usage})
+			}
+		}
+		// A step that ends in tool calls is followed by another step within
+		// the same response; only the final step completes the turn.
+		if final {
+			out = append(out, streamChunk{ctype: ChunkTypeResponseComplete})
+		}
+		return out, true
+	case "error":
+		if ev.Error != nil {
+			msg := ev.Error.Data.Message
+			if msg == "" {
+				msg = ev.Error.Name
+			}
+			return []streamChunk{
+				{ctype: ChunkTypeOutput, payload: fmt.Appendf(nil, "opencode error: %s\n", msg)},
+				{ctype: ChunkTypeResponseComplete},
+			}, true
+		}
+	}
+	return nil, true
+}
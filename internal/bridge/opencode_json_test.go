@@ -0,0 +1,116 @@
+package bridge
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestDecodeOpenCodeLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		ok      bool
+		want    []ChunkType
+		payload string
+		data    string
+	}{
+		{name: "text", line: `{"type":"text","part":{"type":"text","text":"hello"}}`, ok: true, want: []ChunkType{ChunkTypeOutput}, payload: "hello"},
+		{name: "reasoning", line: `{"type":"reasoning","part":{"type":"reasoning","text":"hmm"}}`, ok: true, want: []ChunkType{ChunkTypeThinking}, payload: "hmm"},
+		{name: "final step", line: `{"type":"step_finish","part":{"type":"step-finish","reason":"stop"}}`, ok: true, want: []ChunkType{ChunkTypeResponseComplete}},
+		{name: "tool step", line: `{"type":"step_finish","part":{"type":"step-finish","reason":"tool-calls"}}`, ok: true},
+		{name: "error", line: `{"type":"error","error":{"name":"APIError","data":{"message":"boom"}}}`, ok: true, want: []ChunkType{ChunkTypeOutput, ChunkTypeResponseComplete}, payload: "opencode error: boom\n"},
+		{name: "tool use", line: `{"type":"tool_use","part":{"type":"tool","tool":"bash"}}`, ok: true, want: []ChunkType{ChunkTypeToolUse}, payload: "bash", data: `{"name":"bash"}`},
+		{name: "tool use with input", line: `{"type":"tool_use","part":{"type":"tool","callID":"call_1","tool":"bash","state":{"status":"completed","input":{"command":"ls"},"output":"a.go"}}}`, ok: true, want: []ChunkType{ChunkTypeToolUse}, payload: "bash", data: `{"id":"call_1","name":"bash","input":{"command":"ls"}}`},
+		{name: "unnamed tool dropped", line: `{"type":"tool_use","part":{"type":"tool"}}`, ok: true},
+		{name: "empty text dropped", line: `{"type":"text","part":{"type":"text","text":""}}`, ok: true},
+		{name: "not json", line: `plain output`, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all, ok := decodeOpenCodeLine([]byte(tt.line))
+			var chunks []streamChunk
+			for _, c := range all {
+				if c.usage == nil {
+					chunks = append(chunks, c)
+				}
+			}
+			if ok != tt.ok {
+				t.Fatalf("ok=%v want %v", ok, tt.ok)
+			}
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("chunks=%d want %d", len(chunks), len(tt.want))
+			}
+			for i, c := range chunks {
+				if c.ctype != tt.want[i] {
+					t.Fatalf("chunk %d type=%d want %d", i, c.ctype, tt.want[i])
+				}
+			}
+			if tt.payload != "" && string(chunks[0].payload) != tt.payload {
+				t.Fatalf("payload=%q want %q", chunks[0].payload, tt.payload)
+			}
+			if tt.data != "" && string(chunks[0].data) != tt.data {
+				t.Fatalf("data=%s want %s", chunks[0].data, tt.data)
+			}
+		})
+	}
+}
+
+func TestDecodeOpenCodeLineUsage(t *testing.T) {
+	line := `{"type":"step_finish","part":{"type":"step-finish","reason":"stop","cost":0.02,"tokens":{"input":100,"output":40,"reasoning":0,"cache":{"read":50,"write":10}}}}`
+	chunks, ok := decodeOpenCodeLine([]byte(line))
+	if !ok {
+		t.Fatal("expected valid JSON")
+	}
+	if len(chunks) != 2 || chunks[0].usage == nil || chunks[1].ctype != ChunkTypeResponseComplete {
+		t.Fatalf("chunks=%+v want usage then response complete", chunks)
+	}
+	want := Usage{InputTokens: 160, OutputTokens: 40, CostUSD: 0.02, Turns: 1}
+	if *chunks[0].usage != want {
+		t.Fatalf("usage=%+v want %+v", *chunks[0].usage, want)
+	}
+
+	// Intermediate tool-call steps report usage without completing the turn.
+	line = `{"type":"step_finish","part":{"type":"step-finish","reason":"tool-calls","cost":0.01,"tokens":{"input":10,"output":5,"cache":{}}}}`
+	chunks, _ = decodeOpenCodeLine([]byte(line))
+	if len(chunks) != 1 || chunks[0].usage == nil || chunks[0].usage.Turns != 0 {
+		t.Fatalf("chunks=%+v want one usage delta with no turn", chunks)
+	}
+}
+
+func TestReadLoopStreamJSONOpenCodeFormat(t *testing.T) {
+	sup := NewSupervisor(NewRegistry(), DefaultPolicy(), 64*1024, time.Minute)
+	defer sup.Close()
+
+	ms := &managedSession{
+		buf:        NewByteBuffer(64 * 1024),
+		info:       SessionInfo{SessionID: "test-opencode"},
+		jsonFormat: JSONFormatOpenCode,
+	}
+
+	lines := []string{
+		`{"type":"step_start","part":{"type":"step-start"}}`,
+		`{"type":"text","part":{"type":"text","text":"done"}}`,
+		`{"type":"step_finish","part":{"type":"step-finish","reason":"stop"}}`,
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		for _, line := range lines {
+			_, _ = pw.Write([]byte(line + "\n"))
+		}
+		_ = pw.Close()
+	}()
+
+	sup.readLoopStreamJSON(ms, pr, nil, nil)
+
+	chunks := ms.buf.After(0)
+	if len(chunks) != 2 {
+		t.Fatalf("chunks len=%d want 2: %+v", len(chunks), chunks)
+	}
+	if chunks[0].Type != ChunkTypeOutput || string(chunks[0].Payload) != "done" {
+		t.Fatalf("first chunk=%+v want output 'done'", chunks[0])
+	}
+	if chunks[1].Type != ChunkTypeResponseComplete {
+		t.Fatalf("second chunk type=%d want ChunkTypeResponseComplete", chunks[1].Type)
+	}
+}
@@ -0,0 +1,279 @@
+package bridge
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Output filters a provider can apply to the text its agent prints, in the
+// order the provider lists them, before the text is buffered and streamed.
+const (
+	// OutputFilterStripANSI removes ANSI escape sequences.
+	OutputFilterStripANSI = "strip_ansi"
+	// OutputFilterCollapseCR keeps only the last redraw of a line that is
+	// rewritten with bare carriage returns, such as a progress bar.
+	OutputFilterCollapseCR = "collapse_cr"
+	// OutputFilterNormalizeFences rewrites markdown code fences to start
+	// at the beginning of their line with backticks.
+	OutputFilterNormalizeFences = "normalize_fences"
+)
+
+// OutputFilterProvider is implemented by providers whose output passes
+// through output filters (OutputFilter*).
+type OutputFilterProvider interface {
+	OutputFilters() []string
+}
+
+// ValidateOutputFilters checks that names are known output filters, each
+// listed once.
+func ValidateOutputFilters(names []string) error {
+	for i, name := range names {
+		switch name {
+		case OutputFilterStripANSI, OutputFilterCollapseCR, OutputFilterNormalizeFences:
+		default:
+			return fmt.Errorf("unknown output filter %q", name)
+		}
+		if slices.Contains(names[:i], name) {
+			return fmt.Errorf("output filter %q is listed twice", name)
+		}
+	}
+	return nil
+}
+
+// providerOutputFilters returns the output filters of provider. A provider
+// asking for ANSI stripping with StripANSIProvider gets it first.
+func providerOutputFilters(provider Provider) []string {
+	var names []string
+	if sap, ok := provider.(StripANSIProvider); ok && sap.IsStripANSI() {
+		names = append(names, OutputFilterStripANSI)
+	}
+	if ofp, ok := provider.(OutputFilterProvider); ok {
+		for _, name := range ofp.OutputFilters() {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// appendOutput filters output text from the agent and appends what the
+// filters pass on as a ChunkTypeOutput chunk.
+func (s *Supervisor) appendOutput(ms *managedSession, p []byte) {
+	if p = ms.output.process(p); len(p) > 0 {
+		s.appendChunk(ms, p, ChunkTypeOutput)
+	}
+}
+
+// flushOutput appends the output the filters hold back. It is called before
+// chunks that must follow the output, and when the output ends.
+func (s *Supervisor) flushOutput(ms *managedSession) {
+	if p := ms.output.flush(); len(p) > 0 {
+		s.appendChunk(ms, p, ChunkTypeOutput)
+	}
+}
+
+// outputStage is one filter of an outputPipeline. A stage may hold back the
+// end of a chunk that it cannot filter until more output arrives; flush
+// returns what it holds.
+type outputStage interface {
+	process(p []byte) []byte
+	flush() []byte
+}
+
+// outputPipeline runs a session's output through its provider's filters.
+// It is used by the goroutine reading the agent's output only.
+type outputPipeline struct {
+	stages []outputStage
+}
+
+// newOutputPipeline returns the pipeline for the named filters, or nil when
+// there are none. Names are assumed valid; unknown ones are skipped.
+func newOutputPipeline(names []string) *outputPipeline {
+	var p outputPipeline
+	for _, name := range names {
+		switch name {
+		case OutputFilterStripANSI:
+			p.stages = append(p.stages, &ansiStripper{})
+		case OutputFilterCollapseCR:
+			p.stages = append(p.stages, &redrawCollapser{})
+		case OutputFilterNormalizeFences:
+			p.stages = append(p.stages, &fenceNormalizer{lineStart: true})
+		}
+	}
+	if len(p.stages) == 0 {
+		return nil
+	}
+	return &p
+}
+
+// process filters one chunk of output. It may return less than it was
+// given, or nothing, when a stage holds output back.
+func (p *outputPipeline) process(b []byte) []byte {
+	if p == nil {
+		return b
+	}
+	for _, st := range p.stages {
+		b = st.process(b)
+	}
+	return b
+}
+
+// flush returns the output the stages hold back, filtered by the stages
+// after the one holding it.
+func (p *outputPipeline) flush() []byte {
+	if p == nil {
+		return nil
+	}
+	var b []byte
+	for _, st := range p.stages {
+		b = append(st.process(b), st.flush()...)
+	}
+	return b
+}
+
+// partialEscape matches the start of an escape sequence cut off at the end
+// of a chunk.
+var partialEscape = regexp.MustCompile(`\x1b(?:\[[0-9;?=<>]*)?$`)
+
+// ansiStripper removes escape sequences, holding back one that a read
+// split in two.
+type ansiStripper struct {
+	held []byte
+}
+
+func (s *ansiStripper) process(p []byte) []byte {
+	if len(s.held) > 0 {
+		p = append(s.held, p...)
+		s.held = nil
+	}
+	if loc := partialEscape.FindIndex(p); loc != nil {
+		s.held = append([]byte(nil), p[loc[0]:]...)
+		p = p[:loc[0]]
+	}
+	return ansiEscape.ReplaceAll(p, nil)
+}
+
+func (s *ansiStripper) flush() []byte {
+	held := s.held
+	s.held = nil
+	return held
+}
+
+// redrawCollapser drops the text a bare carriage return would overwrite.
+// Only redraws within one chunk can be collapsed: earlier chunks have been
+// sent. A line continued from the previous chunk keeps a leading "\r" so
+// that terminals still overwrite what they showed.
+type redrawCollapser struct {
+	midLine bool
+}
+
+func (c *redrawCollapser) process(p []byte) []byte {
+	if len(p) == 0 {
+		return p
+	}
+	lines := bytes.SplitAfter(p, []byte{'\n'})
+	out := make([]byte, 0, len(p))
+	for i, line := range lines {
+		out = append(out, collapseRedraws(line, i == 0 && c.midLine)...)
+	}
+	c.midLine = p[len(p)-1] != '\n'
+	return out
+}
+
+func (c *redrawCollapser) flush() []byte { return nil }
+
+// collapseRedraws returns line, which may end in "\n" or "\r\n", with only
+// the last non-empty text between bare carriage returns kept.
+func collapseRedraws(line []byte, continued bool) []byte {
+	body := line
+	var end []byte
+	switch {
+	case bytes.HasSuffix(body, []byte("\r\n")):
+		body, end = body[:len(body)-2], []byte("\r\n")
+	case bytes.HasSuffix(body, []byte("\n")):
+		body, end = body[:len(body)-1], []byte("\n")
+	case bytes.HasSuffix(body, []byte("\r")):
+		// Possibly the first half of a "\r\n" split across chunks.
+		body, end = body[:len(body)-1], []byte("\r")
+	}
+	if !bytes.Contains(body, []byte{'\r'}) {
+		return line
+	}
+	parts := bytes.Split(body, []byte{'\r'})
+	keep := parts[len(parts)-1]
+	for i := len(parts) - 1; i >= 0 && len(keep) == 0; i-- {
+		keep = parts[i]
+	}
+	out := make([]byte, 0, len(keep)+len(end)+1)
+	if continued {
+		out = append(out, '\r')
+	}
+	out = append(out, keep...)
+	return append(out, end...)
+}
+
+// fenceOpen matches a markdown fence line: up to three spaces of indent
+// and a run of three or more backticks or tildes.
+var fenceOpen = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+
+// fencePrefix matches the start of a line that could still become a fence.
+var fencePrefix = regexp.MustCompile("^ {0,3}(`{0,2}|~{0,2})$")
+
+// fenceNormalizer rewrites fence lines to "```" fences without indent. It
+// holds back the start of a line that could still become a fence, so fences
+// streamed in several pieces are normalized too.
+type fenceNormalizer struct {
+	lineStart bool // the next byte starts a line
+	held      []byte
+}
+
+func (f *fenceNormalizer) process(p []byte) []byte {
+	if len(f.held) > 0 {
+		p = append(f.held, p...)
+		f.held = nil
+		f.lineStart = true
+	}
+	out := make([]byte, 0, len(p))
+	for len(p) > 0 {
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line = p[:i+1]
+		}
+		p = p[len(line):]
+		complete := line[len(line)-1] == '\n'
+		if f.lineStart {
+			if !complete && len(p) == 0 && fencePrefix.Match(line) {
+				f.held = append([]byte(nil), line...)
+				return out
+			}
+			line = normalizeFence(line)
+		}
+		out = append(out, line...)
+		f.lineStart = complete
+	}
+	return out
+}
+
+func (f *fenceNormalizer) flush() []byte {
+	held := f.held
+	if len(held) > 0 {
+		f.held = nil
+		f.lineStart = false
+	}
+	return held
+}
+
+// normalizeFence rewrites line if it opens or closes a fence.
+func normalizeFence(line []byte) []byte {
+	loc := fenceOpen.FindSubmatchIndex(line)
+	if loc == nil {
+		return line
+	}
+	run := loc[3] - loc[2]
+	out := make([]byte, 0, len(line))
+	out = append(out, bytes.Repeat([]byte{'`'}, run)...)
+	return append(out, line[loc[1]:]...)
+}
@@ -0,0 +1,92 @@
+package bridge
+
+import (
+	"testing"
+)
+
+func TestValidateOutputFilters(t *testing.T) {
+	if err := ValidateOutputFilters([]string{OutputFilterStripANSI, OutputFilterCollapseCR, OutputFilterNormalizeFences}); err != nil {
+		t.Fatalf("valid filters: %v", err)
+	}
+	for _, names := range [][]string{{"markdown"}, {OutputFilterCollapseCR, OutputFilterCollapseCR}} {
+		if err := ValidateOutputFilters(names); err == nil {
+			t.Fatalf("ValidateOutputFilters(%q) succeeded", names)
+		}
+	}
+}
+
+// runPipeline feeds chunks through a pipeline for names and returns what it
+// emits, flush included.
+func runPipeline(names []string, chunks ...string) string {
+	p := newOutputPipeline(names)
+	var out []byte
+	for _, c := range chunks {
+		out = append(out, p.process([]byte(c))...)
+	}
+	return string(append(out, p.flush()...))
+}
+
+func TestOutputPipelineStripANSI(t *testing.T) {
+	names := []string{OutputFilterStripANSI}
+	if got := runPipeline(names, "\x1b[32mok\x1b[0m\n"); got != "ok\n" {
+		t.Fatalf("got %q", got)
+	}
+	// An escape sequence split across reads is removed as a whole.
+	if got := runPipeline(names, "a\x1b[3", "2mb"); got != "ab" {
+		t.Fatalf("split escape: got %q", got)
+	}
+	p := newOutputPipeline(names)
+	if got := string(p.process([]byte("a\x1b["))); got != "a" {
+		t.Fatalf("held escape: got %q", got)
+	}
+	if got := string(p.flush()); got != "\x1b[" {
+		t.Fatalf("flush: got %q", got)
+	}
+}
+
+func TestOutputPipelineCollapseCR(t *testing.T) {
+	names := []string{OutputFilterCollapseCR}
+	for in, want := range map[string]string{
+		"10%\r50%\r100%\ndone\n": "100%\ndone\n",
+		"a\r\nb\r\n":             "a\r\nb\r\n",
+		"progress 1\r\r":         "progress 1\r",
+		"no redraw":              "no redraw",
+	} {
+		if got := runPipeline(names, in); got != want {
+			t.Fatalf("%q: got %q want %q", in, got, want)
+		}
+	}
+	// A line continued from an earlier chunk still overwrites what was sent.
+	if got := runPipeline(names, "10%", "\r50%\r90%\n"); got != "10%\r90%\n" {
+		t.Fatalf("continued line: got %q", got)
+	}
+}
+
+func TestOutputPipelineNormalizeFences(t *testing.T) {
+	names := []string{OutputFilterNormalizeFences}
+	if got := runPipeline(names, "text\n  ~~~go\nx := 1\n   ~~~\n"); got != "text\n```go\nx := 1\n```\n" {
+		t.Fatalf("got %q", got)
+	}
+	// Indented code and fences of more than three spaces are left alone.
+	if got := runPipeline(names, "    ```\n"); got != "    ```\n" {
+		t.Fatalf("indented: got %q", got)
+	}
+	if got := runPipeline(names, "  ~~", "~go\n", "x\n"); got != "```go\nx\n" {
+		t.Fatalf("split fence: got %q", got)
+	}
+	// A held line start that never becomes a fence is flushed unchanged.
+	if got := runPipeline(names, "a\n ~"); got != "a\n ~" {
+		t.Fatalf("flushed prefix: got %q", got)
+	}
+}
+
+func TestOutputPipelineChain(t *testing.T) {
+	names := []string{OutputFilterStripANSI, OutputFilterCollapseCR, OutputFilterNormalizeFences}
+	got := runPipeline(names, "\x1b[1m~~~\x1b[0m\n", "1/3\r3/3\n", "~~~\n")
+	if got != "```\n3/3\n```\n" {
+		t.Fatalf("got %q", got)
+	}
+	if newOutputPipeline(nil) != nil {
+		t.Fatal("pipeline without filters is not nil")
+	}
+}
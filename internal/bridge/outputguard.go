@@ -0,0 +1,113 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// OutputLimits caps what the agent may produce in one response, so that an
+// agent stuck in a loop cannot flood the session's buffer and its clients.
+// A response is the output attributed to one input. Zero fields are
+// unlimited.
+type OutputLimits struct {
+	// MaxResponseBytes caps the output and thinking text of a response.
+	MaxResponseBytes int64
+	// MaxResponseEvents caps the output and thinking fragments and tool
+	// calls of a response.
+	MaxResponseEvents int
+	// CancelResponse interrupts the agent, as CancelResponse does, once a
+	// limit is reached. Otherwise the agent runs on and the rest of its
+	// response is dropped.
+	CancelResponse bool
+}
+
+func (l OutputLimits) enabled() bool {
+	return l.MaxResponseBytes > 0 || l.MaxResponseEvents > 0
+}
+
+// OutputTruncated describes a response cut short by OutputLimits. It is the
+// Data of a ChunkTypeOutputTruncated chunk.
+type OutputTruncated struct {
+	InputID string `json:"input_id,omitempty"`
+	// Limit is the limit reached: "max_response_bytes" or
+	// "max_response_events".
+	Limit     string `json:"limit"`
+	Max       int64  `json:"max"`
+	Bytes     int64  `json:"bytes"`
+	Events    int    `json:"events"`
+	Cancelled bool   `json:"cancelled"`
+}
+
+func (t OutputTruncated) String() string {
+	s := fmt.Sprintf("response output truncated: %s of %d reached", t.Limit, t.Max)
+	if t.Cancelled {
+		s += "; response cancelled"
+	}
+	return s
+}
+
+// outputGuard counts the output of a session's current response against
+// its OutputLimits.
+type outputGuard struct {
+	mu      sync.Mutex
+	limits  OutputLimits
+	input   string // the input of the response being counted
+	bytes   int64
+	events  int
+	tripped bool
+}
+
+// admit counts an event of n bytes of inputID's response. It reports
+// whether the event may be published and, for the event that reaches a
+// limit, what was truncated.
+func (g *outputGuard) admit(inputID string, n int) (bool, *OutputTruncated) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.limits.enabled() {
+		return true, nil
+	}
+	if inputID != g.input {
+		g.input, g.bytes, g.events, g.tripped = inputID, 0, 0, false
+	}
+	if g.tripped {
+		return false, nil
+	}
+	g.bytes += int64(n)
+	g.events++
+	t := &OutputTruncated{InputID: inputID, Bytes: g.bytes, Events: g.events, Cancelled: g.limits.CancelResponse}
+	switch {
+	case g.limits.MaxResponseBytes > 0 && g.bytes > g.limits.MaxResponseBytes:
+		t.Limit, t.Max = "max_response_bytes", g.limits.MaxResponseBytes
+	case g.limits.MaxResponseEvents > 0 && g.events > g.limits.MaxResponseEvents:
+		t.Limit, t.Max = "max_response_events", int64(g.limits.MaxResponseEvents)
+	default:
+		return true, nil
+	}
+	g.tripped = true
+	return false, t
+}
+
+// guardOutput checks an event of the agent's response against the
+// session's OutputLimits. It reports whether the event may be published;
+// the event that reaches a limit is replaced by a ChunkTypeOutputTruncated
+// chunk, and the response is cancelled if the limits say so.
+func (s *Supervisor) guardOutput(ms *managedSession, payload []byte, inputID string) bool {
+	ok, truncated := ms.guard.admit(inputID, len(payload))
+	if truncated == nil {
+		return ok
+	}
+	logger().Warn("response output truncated", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "input_id", inputID, "limit", truncated.Limit, "max", truncated.Max, "cancel", truncated.Cancelled)
+	data, _ := json.Marshal(truncated)
+	s.appendRequestChunk(ms, []byte(truncated.String()), ChunkTypeOutputTruncated, data, "")
+	if truncated.Cancelled {
+		// Not from the read loop, which the agent may be blocked on while
+		// the interrupt is written to its stdin.
+		go func() {
+			if err := s.interruptResponse(ms); err != nil {
+				logger().Warn("cancel runaway response", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "error", err)
+			}
+		}()
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestOutputGuardAdmit(t *testing.T) {
+	g := outputGuard{limits: OutputLimits{MaxResponseBytes: 10, MaxResponseEvents: 3}}
+	for i, n := range []int{4, 4} {
+		if ok, tr := g.admit("in-1", n); !ok || tr != nil {
+			t.Fatalf("event %d: ok=%v truncated=%+v", i, ok, tr)
+		}
+	}
+	ok, tr := g.admit("in-1", 4)
+	if ok || tr == nil || tr.Limit != "max_response_bytes" || tr.Max != 10 || tr.Bytes != 12 || tr.Events != 3 {
+		t.Fatalf("over bytes: ok=%v truncated=%+v", ok, tr)
+	}
+	if ok, tr := g.admit("in-1", 1); ok || tr != nil {
+		t.Fatalf("after truncation: ok=%v truncated=%+v", ok, tr)
+	}
+
+	// A new input starts a new response.
+	for range 3 {
+		if ok, _ := g.admit("in-2", 1); !ok {
+			t.Fatal("new response refused")
+		}
+	}
+	if ok, tr := g.admit("in-2", 1); ok || tr == nil || tr.Limit != "max_response_events" || tr.Max != 3 {
+		t.Fatalf("over events: ok=%v truncated=%+v", ok, tr)
+	}
+
+	var unlimited outputGuard
+	for range 100 {
+		if ok, _ := unlimited.admit("", 1<<20); !ok {
+			t.Fatal("unlimited guard refused output")
+		}
+	}
+}
+
+func TestOutputLimitsCancelRunawayResponse(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&scriptProvider{testProvider: testProvider{id: "loop"}, script: `yes looping`}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	policy := DefaultPolicy()
+	policy.OutputLimits = OutputLimits{MaxResponseBytes: 4096, CancelResponse: true}
+	supervisor := NewSupervisor(registry, policy, 1024, time.Minute)
+	defer supervisor.Close()
+	if _, err := supervisor.Start(context.Background(), SessionConfig{
+		ProjectID: "project-a",
+		SessionID: "runaway",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "loop"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer func() { _ = supervisor.Stop("runaway", true) }()
+
+	// Ctrl-C ends `yes`, and with it the session.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info, err := supervisor.Get("runaway")
+		if err == nil && (info.State == SessionStateStopped || info.State == SessionStateFailed) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("runaway agent not cancelled: info=%+v err=%v", info, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	h, err := supervisor.History("runaway")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	var output int
+	var truncated *OutputTruncated
+	for _, c := range h.Chunks {
+		switch c.Type {
+		case ChunkTypeOutput:
+			output += len(c.Payload)
+		case ChunkTypeOutputTruncated:
+			if truncated != nil {
+				t.Fatal("more than one OUTPUT_TRUNCATED chunk")
+			}
+			truncated = &OutputTruncated{}
+			if err := json.Unmarshal(c.Data, truncated); err != nil {
+				t.Fatalf("OUTPUT_TRUNCATED data %s: %v", c.Data, err)
+			}
+		}
+	}
+	if truncated == nil || truncated.Limit != "max_response_bytes" || !truncated.Cancelled {
+		t.Fatalf("truncated=%+v", truncated)
+	}
+	if output > 4096 {
+		t.Fatalf("output bytes=%d, want <= 4096", output)
+	}
+}
@@ -3,7 +3,9 @@ package bridge
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 )
 
 // Policy defines runtime limits and guards for the bridge.
@@ -11,29 +13,124 @@ type Policy struct {
 	MaxPerProject int
 	MaxGlobal     int
 	MaxInputBytes int
-	AllowedPaths  []string // glob patterns for allowed repo_path values
+	// MaxStreamInputBytes caps an input assembled from a SendInputStream.
+	// Zero means no limit.
+	MaxStreamInputBytes int
+	// MaxAttachmentBytes caps the inline content of the attachments sent
+	// with one input. Zero means no limit.
+	MaxAttachmentBytes int
+	AllowedPaths       []string // glob patterns for allowed repo_path values
+	// ProjectProviders restricts projects to a set of provider IDs. Projects
+	// without an entry may use any registered provider.
+	ProjectProviders map[string][]string
+	// AllowedEnv lists environment variable names that callers may set per
+	// session. A trailing "*" matches any suffix (e.g. "FEATURE_*"). Empty
+	// means no per-session environment is accepted.
+	AllowedEnv []string
+	// ProjectLimits caps the resources of each agent a project starts.
+	// Providers apply the tighter of these and their own limits.
+	ProjectLimits map[string]ResourceLimits
+	// InputQueueDepth is how many inputs a stream-JSON session holds while
+	// a response is in flight. Queued inputs are delivered in order as each
+	// response completes. Zero writes input to the agent immediately.
+	InputQueueDepth int
+	// MaxFileBytes caps the files ReadFile returns and WriteFile accepts.
+	// Zero means no limit.
+	MaxFileBytes int64
+	// GitAuthorName and GitAuthorEmail are the identity GitCommit commits
+	// as. Empty values use DefaultGitAuthorName and DefaultGitAuthorEmail.
+	GitAuthorName  string
+	GitAuthorEmail string
+	// GitWatchInterval is how often a running session's repo is checked for
+	// changes to report as ChunkTypeRepoDirty. Zero disables the check.
+	GitWatchInterval time.Duration
+	// CloneURLs lists path.Match patterns for the repo URLs sessions may be
+	// cloned from. Empty disables clone-on-start.
+	CloneURLs []string
+	// WorkspaceQuotaBytes caps the disk used by each project's cloned
+	// workspaces. Zero means no limit.
+	WorkspaceQuotaBytes int64
+	// Restart controls relaunching sessions whose agent crashes. The zero
+	// value disables restarts.
+	Restart RestartPolicy
+	// OutputLimits caps each response of an agent. Sessions keep the limits
+	// they started with.
+	OutputLimits OutputLimits
+	// PromptTemplates are the prompts clients may send by name.
+	PromptTemplates map[string]PromptTemplate
+	// ContentPolicy blocks or redacts prompts and agent output.
+	ContentPolicy ContentPolicy
+	// Projects overrides the settings above for individual projects.
+	Projects map[string]ProjectPolicy
+	// RestrictProjects refuses sessions from projects not in Projects.
+	RestrictProjects bool
+}
+
+// ProjectPolicy overrides the global Policy for one project's sessions.
+// Zero fields keep the global setting. A project's providers and resource
+// limits are kept in Policy.ProjectProviders and Policy.ProjectLimits.
+type ProjectPolicy struct {
+	// AllowedPaths replaces Policy.AllowedPaths for the project.
+	AllowedPaths []string
+	// MaxSessions replaces Policy.MaxPerProject for the project.
+	MaxSessions int
+	// MCPServers are given, by name, to the agents of the project's sessions
+	// whose provider takes an MCP config (MCPConfigProvider).
+	MCPServers map[string]MCPServer
+	// RequireApproval makes the agents of the project's sessions ask for
+	// approval of tool calls that need permission (ApprovalProvider).
+	// Sessions whose provider cannot ask are refused.
+	RequireApproval bool
+	// Budget caps what the project's sessions may spend per UTC day.
+	Budget Budget
+	// RepoSummary gives the agents of the project's sessions a summary of
+	// their repo with the first prompt.
+	RepoSummary RepoSummary
+	// Retention overrides how long the project's stopped sessions are
+	// kept in memory and in the archive.
+	Retention Retention
 }
 
 // DefaultPolicy returns sensible defaults.
 func DefaultPolicy() Policy {
 	return Policy{
-		MaxPerProject: 5,
-		MaxGlobal:     20,
-		MaxInputBytes: 65536,
+		MaxPerProject:       5,
+		MaxGlobal:           20,
+		MaxInputBytes:       65536,
+		MaxStreamInputBytes: 16 << 20,
+		MaxAttachmentBytes:  1 << 20,
+		MaxFileBytes:        1 << 20,
 	}
 }
 
-// ValidateRepoPath checks that the given path is under one of the allowed path patterns.
-// If no patterns are configured, all paths are allowed.
-func (p *Policy) ValidateRepoPath(repoPath string) error {
-	if len(p.AllowedPaths) == 0 {
+// CheckProject verifies that projectID may start sessions: with
+// RestrictProjects, it must be listed in Projects.
+func (p *Policy) CheckProject(projectID string) error {
+	if !p.RestrictProjects {
+		return nil
+	}
+	if _, ok := p.Projects[projectID]; ok {
+		return nil
+	}
+	return fmt.Errorf("%w: project %q is not registered", ErrPermissionDenied, projectID)
+}
+
+// ValidateRepoPath checks that the given path is under one of the allowed path patterns:
+// the project's own when it has any, else the global ones. If no patterns are
+// configured, all paths are allowed.
+func (p *Policy) ValidateRepoPath(projectID, repoPath string) error {
+	patterns := p.AllowedPaths
+	if pp := p.Projects[projectID].AllowedPaths; len(pp) > 0 {
+		patterns = pp
+	}
+	if len(patterns) == 0 {
 		return nil
 	}
 	abs, err := filepath.Abs(repoPath)
 	if err != nil {
 		return fmt.Errorf("%w: resolve path: %v", ErrInvalidArgument, err)
 	}
-	for _, pattern := range p.AllowedPaths {
+	for _, pattern := range patterns {
 		matched, err := filepath.Match(pattern, abs)
 		if err != nil {
 			continue
@@ -51,6 +148,68 @@ func (p *Policy) ValidateRepoPath(repoPath string) error {
 	return fmt.Errorf("%w: repo_path %q is not under any allowed path", ErrInvalidArgument, repoPath)
 }
 
+// CheckProvider verifies that projectID may start sessions with providerID.
+func (p *Policy) CheckProvider(projectID, providerID string) error {
+	allowed, ok := p.ProjectProviders[projectID]
+	if !ok {
+		return nil
+	}
+	if slices.Contains(allowed, providerID) {
+		return nil
+	}
+	return fmt.Errorf("%w: project %q may not use provider %q", ErrPermissionDenied, projectID, providerID)
+}
+
+// ValidateEnv checks that every key in env is a valid variable name permitted
+// by AllowedEnv. This keeps callers from overriding credentials or loader
+// variables such as LD_PRELOAD.
+func (p *Policy) ValidateEnv(env map[string]string) error {
+	for key, value := range env {
+		if !validEnvName(key) {
+			return fmt.Errorf("%w: invalid env name %q", ErrInvalidArgument, key)
+		}
+		if strings.ContainsRune(value, 0) {
+			return fmt.Errorf("%w: env %q contains a NUL byte", ErrInvalidArgument, key)
+		}
+		if !EnvMatch(p.AllowedEnv, key) {
+			return fmt.Errorf("%w: env %q is not in allowed_env", ErrPermissionDenied, key)
+		}
+	}
+	return nil
+}
+
+// EnvMatch reports whether the environment variable key is named by
+// patterns. A pattern ending in "*" matches a prefix. It is the matcher for
+// AllowedEnv and for the providers' env allowlists and blocklists.
+func EnvMatch(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// validEnvName reports whether name matches [A-Za-z_][A-Za-z0-9_]*.
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // ValidateInput checks that input text does not exceed the maximum size.
 func (p *Policy) ValidateInput(text string) error {
 	if p.MaxInputBytes > 0 && len(text) > p.MaxInputBytes {
@@ -59,6 +218,24 @@ func (p *Policy) ValidateInput(text string) error {
 	return nil
 }
 
+// ValidateStreamInputSize checks that a streamed input of n bytes does not
+// exceed MaxStreamInputBytes.
+func (p *Policy) ValidateStreamInputSize(n int) error {
+	if p.MaxStreamInputBytes > 0 && n > p.MaxStreamInputBytes {
+		return fmt.Errorf("%w: streamed input size %d exceeds max %d bytes", ErrInputTooLarge, n, p.MaxStreamInputBytes)
+	}
+	return nil
+}
+
+// ValidateAttachmentSize checks that n bytes of inline attachment content
+// do not exceed MaxAttachmentBytes.
+func (p *Policy) ValidateAttachmentSize(n int) error {
+	if p.MaxAttachmentBytes > 0 && n > p.MaxAttachmentBytes {
+		return fmt.Errorf("%w: attachment content %d exceeds max %d bytes", ErrInputTooLarge, n, p.MaxAttachmentBytes)
+	}
+	return nil
+}
+
 // ValidateInputBytes checks that PTY input bytes do not exceed the maximum size.
 func (p *Policy) ValidateInputBytes(data []byte) error {
 	if p.MaxInputBytes > 0 && len(data) > p.MaxInputBytes {
@@ -67,10 +244,23 @@ func (p *Policy) ValidateInputBytes(data []byte) error {
 	return nil
 }
 
-// CheckSessionLimits verifies that creating a new session would not exceed limits.
-func (p *Policy) CheckSessionLimits(projectCount, globalCount int) error {
-	if p.MaxPerProject > 0 && projectCount >= p.MaxPerProject {
-		return fmt.Errorf("%w: project limit (%d/%d)", ErrSessionLimitReached, projectCount, p.MaxPerProject)
+// ValidateFileSize checks that a file read or write does not exceed the maximum size.
+func (p *Policy) ValidateFileSize(size int64) error {
+	if p.MaxFileBytes > 0 && size > p.MaxFileBytes {
+		return fmt.Errorf("%w: file size %d exceeds max %d bytes", ErrFileTooLarge, size, p.MaxFileBytes)
+	}
+	return nil
+}
+
+// CheckSessionLimits verifies that creating a new session for projectID would not exceed
+// limits. projectCount counts the project's live sessions.
+func (p *Policy) CheckSessionLimits(projectID string, projectCount, globalCount int) error {
+	perProject := p.MaxPerProject
+	if n := p.Projects[projectID].MaxSessions; n > 0 {
+		perProject = n
+	}
+	if perProject > 0 && projectCount >= perProject {
+		return fmt.Errorf("%w: project limit (%d/%d)", ErrSessionLimitReached, projectCount, perProject)
 	}
 	if p.MaxGlobal > 0 && globalCount >= p.MaxGlobal {
 		return fmt.Errorf("%w: global limit (%d/%d)", ErrSessionLimitReached, globalCount, p.MaxGlobal)
@@ -45,10 +45,10 @@ func TestPolicyValidationAndRegistryHealth(t *testing.T) {
 		MaxInputBytes: 4,
 		AllowedPaths:  []string{repo},
 	}
-	if err := policy.ValidateRepoPath(repo); err != nil {
+	if err := policy.ValidateRepoPath("p", repo); err != nil {
 		t.Fatalf("ValidateRepoPath: %v", err)
 	}
-	if err := policy.ValidateRepoPath(t.TempDir()); !errors.Is(err, ErrInvalidArgument) {
+	if err := policy.ValidateRepoPath("p", t.TempDir()); !errors.Is(err, ErrInvalidArgument) {
 		t.Fatalf("ValidateRepoPath disallowed error=%v want %v", err, ErrInvalidArgument)
 	}
 	if err := policy.ValidateInput("1234"); err != nil {
@@ -76,3 +76,139 @@ func TestPolicyValidationAndRegistryHealth(t *testing.T) {
 		t.Fatalf("HealthAll=%v", results)
 	}
 }
+
+func TestRegistryReplace(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&registryProvider{id: "old"}); err != nil {
+		t.Fatalf("Register old: %v", err)
+	}
+	registry.Replace([]Provider{&registryProvider{id: "new-a"}, &registryProvider{id: "new-b"}})
+
+	if _, err := registry.Get("old"); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("Get old error=%v want %v", err, ErrProviderUnavailable)
+	}
+	if got := registry.List(); len(got) != 2 {
+		t.Fatalf("List=%v want 2 providers", got)
+	}
+	if err := registry.Register(&registryProvider{id: "new-a"}); err == nil {
+		t.Fatal("Register duplicate after Replace succeeded")
+	}
+}
+
+func TestPolicyCheckProvider(t *testing.T) {
+	policy := Policy{ProjectProviders: map[string][]string{
+		"prod-docs": {"claude-chat"},
+	}}
+	tests := []struct {
+		project  string
+		provider string
+		wantErr  bool
+	}{
+		{project: "prod-docs", provider: "claude-chat"},
+		{project: "prod-docs", provider: "codex", wantErr: true},
+		{project: "scratch", provider: "codex"},
+	}
+	for _, tt := range tests {
+		err := policy.CheckProvider(tt.project, tt.provider)
+		if tt.wantErr != errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("CheckProvider(%q, %q) error=%v wantErr=%v", tt.project, tt.provider, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSupervisorStartRejectsDisallowedProvider(t *testing.T) {
+	registry := NewRegistry()
+	for _, id := range []string{"allowed", "blocked"} {
+		if err := registry.Register(&registryProvider{id: id}); err != nil {
+			t.Fatalf("Register %s: %v", id, err)
+		}
+	}
+	policy := DefaultPolicy()
+	policy.ProjectProviders = map[string][]string{"prod-docs": {"allowed"}}
+	sup := NewSupervisor(registry, policy, 1024, time.Minute)
+	defer sup.Close()
+
+	_, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "prod-docs",
+		SessionID: "s1",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "blocked"},
+	})
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("Start error=%v want %v", err, ErrPermissionDenied)
+	}
+}
+
+func TestPolicyProjectOverrides(t *testing.T) {
+	shared, acme := t.TempDir(), t.TempDir()
+	policy := Policy{
+		MaxPerProject: 5,
+		AllowedPaths:  []string{shared},
+		Projects: map[string]ProjectPolicy{
+			"acme":  {AllowedPaths: []string{acme}, MaxSessions: 1},
+			"plain": {},
+		},
+	}
+	if err := policy.ValidateRepoPath("acme", acme); err != nil {
+		t.Fatalf("acme repo: %v", err)
+	}
+	if err := policy.ValidateRepoPath("acme", shared); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("acme using the global path: err=%v want %v", err, ErrInvalidArgument)
+	}
+	if err := policy.ValidateRepoPath("plain", shared); err != nil {
+		t.Fatalf("project without paths: %v", err)
+	}
+	if err := policy.CheckSessionLimits("acme", 1, 1); !errors.Is(err, ErrSessionLimitReached) {
+		t.Fatalf("acme second session: err=%v want %v", err, ErrSessionLimitReached)
+	}
+	if err := policy.CheckSessionLimits("plain", 1, 1); err != nil {
+		t.Fatalf("plain second session: %v", err)
+	}
+
+	if err := policy.CheckProject("unknown"); err != nil {
+		t.Fatalf("unrestricted CheckProject: %v", err)
+	}
+	policy.RestrictProjects = true
+	if err := policy.CheckProject("plain"); err != nil {
+		t.Fatalf("registered project: %v", err)
+	}
+	if err := policy.CheckProject("unknown"); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("unregistered project: err=%v want %v", err, ErrPermissionDenied)
+	}
+}
+
+func TestPolicyValidateEnv(t *testing.T) {
+	policy := Policy{AllowedEnv: []string{"ANTHROPIC_MODEL", "FEATURE_*"}}
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr error
+	}{
+		{name: "empty"},
+		{name: "exact", env: map[string]string{"ANTHROPIC_MODEL": "claude-sonnet"}},
+		{name: "prefix", env: map[string]string{"FEATURE_FAST": "1"}},
+		{name: "credential", env: map[string]string{"ANTHROPIC_API_KEY": "sk"}, wantErr: ErrPermissionDenied},
+		{name: "loader", env: map[string]string{"LD_PRELOAD": "/tmp/x.so"}, wantErr: ErrPermissionDenied},
+		{name: "bad name", env: map[string]string{"FEATURE_A=B": "1"}, wantErr: ErrInvalidArgument},
+		{name: "leading digit", env: map[string]string{"1FEATURE": "1"}, wantErr: ErrInvalidArgument},
+		{name: "nul value", env: map[string]string{"FEATURE_X": "a\x00b"}, wantErr: ErrInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.ValidateEnv(tt.env)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ValidateEnv: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateEnv error=%v want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := (&Policy{}).ValidateEnv(map[string]string{"ANTHROPIC_MODEL": "x"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("empty allowlist error=%v want %v", err, ErrPermissionDenied)
+	}
+}
@@ -0,0 +1,258 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/redact"
+)
+
+const (
+	// DefaultProviderLogMaxBytes is the size at which a session's provider
+	// log is rotated when WithProviderLogs is given no limit.
+	DefaultProviderLogMaxBytes = 10 << 20
+	// DefaultProviderLogMaxFiles is how many rotated provider logs are kept
+	// per session when WithProviderLogs is given no count.
+	DefaultProviderLogMaxFiles = 3
+)
+
+// Provider log streams. PTY sessions have one stream carrying both stdout
+// and stderr; stream-JSON sessions have separate pipes.
+const (
+	providerLogPTY    = "pty"
+	providerLogStdout = "stdout"
+	providerLogStderr = "stderr"
+)
+
+// WithProviderLogs writes everything each agent process prints, before it is
+// parsed, ANSI-stripped or buffered, to <dir>/<session id>.log as JSON
+// lines. A log is rotated to .log.1, .log.2 and so on once it reaches
+// maxBytes (DefaultProviderLogMaxBytes when maxBytes <= 0), keeping maxFiles
+// rotated files (DefaultProviderLogMaxFiles when maxFiles <= 0). Logs
+// outlive the session's buffer and are deleted with its archive entry.
+func WithProviderLogs(dir string, maxBytes int64, maxFiles int) SupervisorOption {
+	return func(s *Supervisor) {
+		if maxBytes <= 0 {
+			maxBytes = DefaultProviderLogMaxBytes
+		}
+		if maxFiles <= 0 {
+			maxFiles = DefaultProviderLogMaxFiles
+		}
+		s.providerLogDir = dir
+		s.providerLogMaxBytes = maxBytes
+		s.providerLogMaxFiles = maxFiles
+	}
+}
+
+// providerLogRecord is one line of a provider log. Data records carry
+// Stream and Data; process start and exit records carry Event.
+type providerLogRecord struct {
+	Time     time.Time `json:"time"`
+	PID      int       `json:"pid,omitempty"`
+	Event    string    `json:"event,omitempty"`
+	ExitCode *int      `json:"exit_code,omitempty"`
+	Stream   string    `json:"stream,omitempty"`
+	Data     string    `json:"data,omitempty"`
+}
+
+// providerLog is the raw output log of one session. It spans every process
+// the session runs, so restarts append to the same file. The file is opened
+// on the first record. A nil *providerLog discards everything.
+type providerLog struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	maxFiles int
+	redactor *redact.Redactor
+	f        *os.File
+	size     int64
+	pid      int
+	// streams hold back output that may continue a secret, per stream.
+	streams map[string]*redact.Stream
+}
+
+// newProviderLog returns the provider log for a new session, or nil when
+// provider logs are off.
+func (s *Supervisor) newProviderLog(sessionID string) *providerLog {
+	if s.providerLogDir == "" {
+		return nil
+	}
+	return &providerLog{
+		path:     providerLogPath(s.providerLogDir, sessionID),
+		maxBytes: s.providerLogMaxBytes,
+		maxFiles: s.providerLogMaxFiles,
+		redactor: s.redactor,
+	}
+}
+
+func providerLogPath(dir, sessionID string) string {
+	return filepath.Join(dir, url.PathEscape(sessionID)+".log")
+}
+
+// start records the launch of the process pid; later output is attributed
+// to it.
+func (l *providerLog) start(pid int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pid = pid
+	l.writeLocked(providerLogRecord{Event: "start"})
+}
+
+// write logs data read from stream. With a redactor, text is held back
+// until it can no longer be part of a secret continuing in a later read.
+func (l *providerLog) write(stream string, data []byte) {
+	if l == nil || len(data) == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.redactor != nil {
+		if l.streams == nil {
+			l.streams = make(map[string]*redact.Stream)
+		}
+		rs, ok := l.streams[stream]
+		if !ok {
+			rs = l.redactor.NewStream(0)
+			l.streams[stream] = rs
+		}
+		data = rs.Write(data)
+		if len(data) == 0 {
+			return
+		}
+	}
+	l.writeLocked(providerLogRecord{Stream: stream, Data: string(data)})
+}
+
+// flush writes out text held back for redaction. It is called when the
+// agent goes quiet and when its process exits.
+func (l *providerLog) flush() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flushLocked()
+}
+
+func (l *providerLog) flushLocked() {
+	for stream, rs := range l.streams {
+		if data := rs.Flush(); len(data) > 0 {
+			l.writeLocked(providerLogRecord{Stream: stream, Data: string(data)})
+		}
+	}
+}
+
+// exit records that the current process exited with exitCode, once all of
+// its output has been logged.
+func (l *providerLog) exit(exitCode int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flushLocked()
+	l.writeLocked(providerLogRecord{Event: "exit", ExitCode: &exitCode})
+}
+
+// close closes the log file. A later record reopens it.
+func (l *providerLog) close() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flushLocked()
+	if l.f != nil {
+		_ = l.f.Close()
+		l.f = nil
+	}
+}
+
+// writeLocked appends rec as one JSON line, rotating first when the line
+// would take the file past maxBytes. Failures are logged and the record is
+// dropped: the provider log must never hold up the session.
+func (l *providerLog) writeLocked(rec providerLogRecord) {
+	rec.Time = time.Now().UTC()
+	rec.PID = l.pid
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+	if l.f != nil && l.size > 0 && l.size+int64(len(line)) > l.maxBytes {
+		if err := l.rotateLocked(); err != nil {
+			logger().Warn("provider log: failed to rotate", "path", l.path, "error", err)
+		}
+	}
+	if l.f == nil {
+		if err := l.openLocked(); err != nil {
+			logger().Warn("provider log: failed to open", "path", l.path, "error", err)
+			return
+		}
+	}
+	n, err := l.f.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		logger().Warn("provider log: failed to write", "path", l.path, "error", err)
+	}
+}
+
+func (l *providerLog) openLocked() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	l.f, l.size = f, st.Size()
+	return nil
+}
+
+// rotateLocked shifts <path>.<n> to <path>.<n+1>, dropping the oldest, and
+// moves the current file to <path>.1. The next write opens a new file.
+func (l *providerLog) rotateLocked() error {
+	_ = l.f.Close()
+	l.f, l.size = nil, 0
+	if err := os.Remove(fmt.Sprintf("%s.%d", l.path, l.maxFiles)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for n := l.maxFiles - 1; n >= 1; n-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", l.path, n), fmt.Sprintf("%s.%d", l.path, n+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(l.path, l.path+".1")
+}
+
+// removeProviderLogs deletes a session's provider log and its rotated
+// files.
+func (s *Supervisor) removeProviderLogs(sessionID string) {
+	if s.providerLogDir == "" {
+		return
+	}
+	path := providerLogPath(s.providerLogDir, sessionID)
+	paths := []string{path}
+	for n := 1; n <= s.providerLogMaxFiles; n++ {
+		paths = append(paths, fmt.Sprintf("%s.%d", path, n))
+	}
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger().Warn("provider log: failed to remove", "path", p, "error", err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/redact"
+)
+
+// readProviderLog decodes every record of a provider log file.
+func readProviderLog(t *testing.T, path string) []providerLogRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open provider log: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	var recs []providerLogRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec providerLogRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("record %q: %v", sc.Text(), err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestProviderLogRotates(t *testing.T) {
+	dir := t.TempDir()
+	sup := &Supervisor{}
+	WithProviderLogs(dir, 512, 2)(sup)
+	l := sup.newProviderLog("rot/1")
+	l.start(42)
+	for i := range 40 {
+		l.write(providerLogPTY, []byte(fmt.Sprintf("line %02d\n", i)))
+	}
+	l.exit(0)
+	l.close()
+
+	path := filepath.Join(dir, "rot%2F1.log")
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		st, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("stat %s: %v", p, err)
+		}
+		if st.Size() > 512 {
+			t.Fatalf("%s is %d bytes, want <= 512", p, st.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("%s.3 exists, want only 2 rotated files", path)
+	}
+	recs := readProviderLog(t, path)
+	last := recs[len(recs)-1]
+	if last.Event != "exit" || last.ExitCode == nil || *last.ExitCode != 0 || last.PID != 42 {
+		t.Fatalf("last record=%+v want exit 0 of pid 42", last)
+	}
+	if got := readProviderLog(t, path+".1"); got[0].Stream != providerLogPTY || !strings.HasPrefix(got[0].Data, "line ") {
+		t.Fatalf("rotated record=%+v", got[0])
+	}
+
+	sup.removeProviderLogs("rot/1")
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*")); len(matches) != 0 {
+		t.Fatalf("files left after removal: %v", matches)
+	}
+}
+
+func TestStreamJSONSessionWritesProviderLog(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&stderrTestProvider{streamJSONTestProvider{testProvider: testProvider{id: "stderr-fake"}}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	redactor, err := redact.New([]string{`quota`})
+	if err != nil {
+		t.Fatalf("redact.New: %v", err)
+	}
+	dir := t.TempDir()
+	sup := NewSupervisor(registry, DefaultPolicy(), 64*1024, time.Minute, WithProviderLogs(dir, 0, 0), WithRedactor(redactor))
+	t.Cleanup(sup.Close)
+
+	if _, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "proj-log",
+		SessionID: "log-1",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "stderr-fake"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitForStopped(t, sup, "log-1")
+
+	path := filepath.Join(dir, "log-1.log")
+	var recs []providerLogRecord
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		recs = readProviderLog(t, path)
+		if n := len(recs); n > 0 && recs[n-1].Event == "exit" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no exit record in %+v", recs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if recs[0].Event != "start" || recs[0].PID == 0 {
+		t.Fatalf("first record=%+v want start with a pid", recs[0])
+	}
+	streams := map[string]string{}
+	for _, rec := range recs {
+		streams[rec.Stream] += rec.Data
+	}
+	// stdout is logged as the agent wrote it, before the JSON is decoded.
+	if want := `{"type":"content_block_delta","delta":{"type":"text_delta","text":"ok"}}` + "\n"; streams[providerLogStdout] != want {
+		t.Fatalf("stdout=%q want %q", streams[providerLogStdout], want)
+	}
+	if want := "retrying request\nfatal: [REDACTED] exceeded\n"; streams[providerLogStderr] != want {
+		t.Fatalf("stderr=%q want %q", streams[providerLogStderr], want)
+	}
+}
@@ -0,0 +1,68 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// PromptTemplate is a prompt kept in the bridge's config, which clients
+// send by name with the variables it needs instead of sending its text.
+// The text is a Go text/template whose data is the variables, e.g.
+// "Review this diff:\n{{.diff}}".
+type PromptTemplate struct {
+	Name string
+	tmpl *template.Template
+}
+
+// ParsePromptTemplate parses text as the template called name. Referring to
+// a variable the input does not set is an error when the template is used.
+func ParsePromptTemplate(name, text string) (PromptTemplate, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return PromptTemplate{}, fmt.Errorf("parse prompt template %q: %w", name, err)
+	}
+	return PromptTemplate{Name: name, tmpl: tmpl}, nil
+}
+
+// RenderPrompt renders the prompt template called name with vars as an
+// input for sessionID: a PTY prompt ends in a carriage return and a
+// stream-JSON prompt is wrapped in a user message, as the agent expects.
+func (s *Supervisor) RenderPrompt(sessionID, name string, vars map[string]string) ([]byte, error) {
+	policy := s.currentPolicy()
+	pt, ok := policy.PromptTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown prompt template %q", ErrInvalidArgument, name)
+	}
+	s.mu.RLock()
+	ms, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	ms.mu.Lock()
+	streamJSON := ms.streamJSON
+	ms.mu.Unlock()
+
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	var b bytes.Buffer
+	if err := pt.tmpl.Execute(&b, vars); err != nil {
+		return nil, fmt.Errorf("%w: prompt template %q: %v", ErrInvalidArgument, name, err)
+	}
+	if !streamJSON {
+		b.WriteString("\r")
+		return b.Bytes(), nil
+	}
+	msg := map[string]any{
+		"type":    "user",
+		"message": map[string]string{"role": "user", "content": b.String()},
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: prompt template %q: %v", ErrInvalidArgument, name, err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRenderPrompt(t *testing.T) {
+	registry := NewRegistry()
+	for _, p := range []Provider{
+		&testProvider{id: "pty"},
+		&streamJSONScriptProvider{scriptProvider{testProvider: testProvider{id: "json"}, script: `cat >/dev/null`}},
+	} {
+		if err := registry.Register(p); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	review, err := ParsePromptTemplate("review", "Review {{.file}}:\n{{.diff}}")
+	if err != nil {
+		t.Fatalf("ParsePromptTemplate: %v", err)
+	}
+	policy := DefaultPolicy()
+	policy.PromptTemplates = map[string]PromptTemplate{"review": review}
+	supervisor := NewSupervisor(registry, policy, 1024, time.Minute)
+	defer supervisor.Close()
+	for _, id := range []string{"pty", "json"} {
+		if _, err := supervisor.Start(context.Background(), SessionConfig{
+			ProjectID: "project-a",
+			SessionID: id,
+			RepoPath:  t.TempDir(),
+			Options:   map[string]string{"provider": id},
+		}); err != nil {
+			t.Fatalf("Start %s: %v", id, err)
+		}
+		t.Cleanup(func() { _ = supervisor.Stop(id, true) })
+	}
+
+	vars := map[string]string{"file": "main.go", "diff": "+x"}
+	got, err := supervisor.RenderPrompt("pty", "review", vars)
+	if err != nil || string(got) != "Review main.go:\n+x\r" {
+		t.Fatalf("PTY prompt=%q err=%v", got, err)
+	}
+	got, err = supervisor.RenderPrompt("json", "review", vars)
+	if err != nil || string(got) != `{"message":{"content":"Review main.go:\n+x","role":"user"},"type":"user"}`+"\n" {
+		t.Fatalf("stream-JSON prompt=%q err=%v", got, err)
+	}
+	if text := inputText(string(got)); text != "Review main.go:\n+x" {
+		t.Fatalf("stream-JSON text=%q", text)
+	}
+
+	if _, err := supervisor.RenderPrompt("pty", "review", map[string]string{"file": "main.go"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("missing var err=%v want ErrInvalidArgument", err)
+	}
+	if _, err := supervisor.RenderPrompt("pty", "summarize", vars); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("unknown template err=%v want ErrInvalidArgument", err)
+	}
+	if _, err := supervisor.RenderPrompt("missing", "review", vars); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("missing session err=%v want ErrSessionNotFound", err)
+	}
+}
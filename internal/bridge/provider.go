@@ -2,6 +2,7 @@ package bridge
 
 import (
 	"context"
+	"encoding/json"
 	"os/exec"
 	"regexp"
 	"time"
@@ -20,18 +21,58 @@ type Provider interface {
 	Version(ctx context.Context) (string, error)
 }
 
+// VariantProvider is implemented by providers that group several variants
+// of one agent, such as stable and canary builds of its CLI, under one ID.
+// A session picks one with its "variant" option and runs with that
+// variant's provider.
+type VariantProvider interface {
+	// Variants lists the variant names, the default first.
+	Variants() []string
+	// Variant returns the provider of variant name, or of the default
+	// variant when name is empty, along with the variant's name.
+	Variant(name string) (Provider, string, error)
+}
+
 // SessionConfig holds configuration for starting a new provider session.
 type SessionConfig struct {
 	ProjectID string
 	SessionID string
 	RepoPath  string
-	Options   map[string]string
+	// Source, when set, has the supervisor clone a repo into a managed
+	// workspace and use it as RepoPath.
+	Source *RepoSource `json:",omitempty"`
+	// Snapshot records the repo's state before the agent starts so that
+	// Rollback can restore it. The repo must be a git work tree.
+	Snapshot bool `json:",omitempty"`
+	Options  map[string]string
 	// Fallbacks is an ordered list of provider IDs to try if the primary
 	// provider (Options["provider"]) is unavailable. At most 2 entries are
 	// meaningful; extras are silently ignored.
 	Fallbacks   []string
 	InitialCols uint32
 	InitialRows uint32
+	// Env holds extra environment variables for the agent process. Keys must
+	// be permitted by Policy.AllowedEnv.
+	Env map[string]string
+	// Limits are the project's resource limits from Policy.ProjectLimits,
+	// filled in by the supervisor. Providers must enforce them.
+	Limits ResourceLimits
+	// MCPConfig is the path, relative to RepoPath, of the MCP config the
+	// supervisor wrote for the session's agent; empty when there is none.
+	// Providers pass it after their MCPConfigFlag.
+	MCPConfig string `json:",omitempty"`
+	// RequireApproval is set by the supervisor from the project's policy.
+	// Providers then pass their ApprovalArgs.
+	RequireApproval bool `json:",omitempty"`
+	// WriterSubjects, when set, are the only token subjects that may drive
+	// the session; others may only observe it. The API server enforces it.
+	WriterSubjects []string `json:",omitempty"`
+	// ConversationID links the session to earlier sessions of the same
+	// conversation, which Supervisor.ConversationHistory renders as one
+	// transcript. A session that runs the same resumable provider in the
+	// same directory as the conversation's latest session continues its
+	// agent thread.
+	ConversationID string `json:",omitempty"`
 }
 
 // SessionState represents the lifecycle state of a session.
@@ -70,6 +111,58 @@ type SessionInfo struct {
 	ActiveWriterClientID string
 	// ObserverCount is the number of read-only observer clients currently attached.
 	ObserverCount int
+	// Usage is the token and cost total accumulated over the session. Only
+	// stream-JSON providers report usage; it stays zero for PTY sessions.
+	Usage Usage
+	// Restarts is how many times the agent was relaunched after crashing
+	// under Policy.Restart.
+	Restarts int
+	// PendingApprovals are the tool calls the agent is waiting to have
+	// approved. They belong to the running process and are not persisted.
+	PendingApprovals []Approval `json:"-"`
+	// WriterSubjects is SessionConfig.WriterSubjects, kept so that the
+	// restriction survives a daemon restart.
+	WriterSubjects []string `json:",omitempty"`
+	// Variant is the variant of Provider the session runs, for providers
+	// with variants.
+	Variant string `json:",omitempty"`
+	// RepoPath is the directory the agent runs in.
+	RepoPath string `json:",omitempty"`
+	// ConversationID is SessionConfig.ConversationID.
+	ConversationID string `json:",omitempty"`
+	// ResumedFrom is the earlier session of the conversation whose agent
+	// thread the session continues; empty when it started a new thread.
+	ResumedFrom string `json:",omitempty"`
+	// ThreadID is the agent CLI's own ID for the thread the session runs,
+	// e.g. claude's session ID, which is how a relaunched agent resumes
+	// it. Empty when the bridge does not know it.
+	ThreadID string `json:",omitempty"`
+	// BufferBytes and BufferCapacity are the replay buffer's use and size
+	// in bytes.
+	BufferBytes    int `json:"-"`
+	BufferCapacity int `json:"-"`
+}
+
+// Usage accumulates token counts and cost reported by a provider.
+type Usage struct {
+	InputTokens  int64
+	OutputTokens int64
+	CostUSD      float64
+	// Duration is the provider-reported wall time spent producing responses.
+	Duration time.Duration
+	// Turns is the number of completed agent responses.
+	Turns int
+}
+
+// Add returns the sum of u and other.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+		CostUSD:      u.CostUSD + other.CostUSD,
+		Duration:     u.Duration + other.Duration,
+		Turns:        u.Turns + other.Turns,
+	}
 }
 
 // ChunkType classifies an OutputChunk's content.
@@ -86,6 +179,56 @@ const (
 	// ChunkTypeWriterReleased is a control event broadcast when the writer
 	// releases its role. It is never appended to the replay buffer.
 	ChunkTypeWriterReleased ChunkType = 3
+	// ChunkTypeResponseComplete marks the end of an agent response. It carries
+	// no payload and is only emitted by stream-JSON providers, whose protocols
+	// signal turn completion explicitly.
+	ChunkTypeResponseComplete ChunkType = 4
+	// ChunkTypeUsage is a control event broadcast when a provider reports
+	// token or cost usage. Its Usage field holds the session's running total.
+	// It is never appended to the replay buffer.
+	ChunkTypeUsage ChunkType = 5
+	// ChunkTypeStderr is one line a stream-JSON provider wrote to stderr. Its
+	// Severity field holds the classification from the provider's rules.
+	ChunkTypeStderr ChunkType = 6
+	// ChunkTypeInputAcked marks an accepted WriteInput. Its InputID names
+	// the input; it carries no payload.
+	ChunkTypeInputAcked ChunkType = 7
+	// ChunkTypeRepoDirty is a control event broadcast when the session
+	// repo's uncommitted changes differ from the last check. It carries no
+	// payload and is never appended to the replay buffer.
+	ChunkTypeRepoDirty ChunkType = 8
+	// ChunkTypeSessionRestarted marks the relaunch of an agent that crashed.
+	// Its Restart field describes the crash; it carries no payload.
+	ChunkTypeSessionRestarted ChunkType = 9
+	// ChunkTypeToolUse marks a tool call by a stream-JSON provider. Its
+	// payload is the tool's name and its Data a ToolCall.
+	ChunkTypeToolUse ChunkType = 10
+	// ChunkTypeApprovalRequested marks a tool call the agent waits to have
+	// approved. Its payload is the tool's name and its Data an Approval.
+	ChunkTypeApprovalRequested ChunkType = 11
+	// ChunkTypeApprovalResolved marks the answer to an approval request.
+	// Its payload is the tool's name and its Data an ApprovalResolution.
+	ChunkTypeApprovalResolved ChunkType = 12
+	// ChunkTypeControlChanged marks a TakeControl. Its payload is the new
+	// writer's client ID and its Data a ControlChange. Unlike the writer
+	// control events it is replayed, so the takeover stays on record.
+	ChunkTypeControlChanged ChunkType = 13
+	// ChunkTypeBudgetExceeded marks the moment the session's project spent
+	// its daily budget. Its payload describes the cap reached and its Data
+	// is a BudgetStatus.
+	ChunkTypeBudgetExceeded ChunkType = 14
+	// ChunkTypeOutputTruncated marks the point where a response reached the
+	// session's OutputLimits; the rest of it is dropped. Its payload
+	// describes the limit reached and its Data is an OutputTruncated.
+	ChunkTypeOutputTruncated ChunkType = 15
+	// ChunkTypePolicyViolation marks a prompt or a piece of output that the
+	// content policy blocked or redacted. Its payload describes the rule
+	// that matched and its Data is a PolicyViolation.
+	ChunkTypePolicyViolation ChunkType = 16
+	// ChunkTypeError reports an input that could not be delivered to the
+	// agent, such as a queued stream-JSON input whose write failed. Its
+	// payload describes the failure and its InputID names the input.
+	ChunkTypeError ChunkType = 17
 )
 
 // OutputChunk is one retained output chunk from an agent session.
@@ -93,7 +236,56 @@ type OutputChunk struct {
 	Seq       uint64
 	Timestamp time.Time
 	Payload   []byte
-	Type      ChunkType // defaults to ChunkTypeOutput
+	Type      ChunkType    // defaults to ChunkTypeOutput
+	Usage     *Usage       `json:",omitempty"` // set on ChunkTypeUsage only
+	Severity  Severity     `json:",omitempty"` // set on ChunkTypeStderr only
+	Restart   *RestartInfo `json:",omitempty"` // set on ChunkTypeSessionRestarted only
+	// InputID is the input this chunk responds to: the most recent input
+	// delivered to a PTY agent, or the input a stream-JSON response answers.
+	// Empty before the first input.
+	InputID string `json:",omitempty"`
+	// Attachments lists the repo paths of the files sent with an input. Set
+	// on ChunkTypeInputAcked only.
+	Attachments []string `json:",omitempty"`
+	// RequestID is the ID of the RPC that produced the chunk, for chunks
+	// recording a client's action: ChunkTypeInputAcked,
+	// ChunkTypeApprovalResolved and ChunkTypeControlChanged. Empty when the
+	// action did not come with one.
+	RequestID string `json:",omitempty"`
+	// Data is a JSON object of machine-readable detail accompanying
+	// Payload's display text, such as the ToolCall of a ChunkTypeToolUse
+	// chunk. Nil when the chunk has none.
+	Data json.RawMessage `json:",omitempty"`
+}
+
+// size is the number of bytes chunk holds against the buffer's capacity.
+func (c OutputChunk) size() int { return len(c.Payload) + len(c.Data) }
+
+// ToolCall is the Data of a ChunkTypeToolUse chunk, in the same shape for
+// every stream-JSON dialect.
+type ToolCall struct {
+	// ID is the provider's identifier for the call, if it reports one.
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+	// Input is the tool's arguments as the provider reported them with
+	// the call. Providers that stream arguments after announcing the call
+	// leave it unset.
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// toolCallData encodes a ToolCall for OutputChunk.Data. An empty input
+// object is dropped.
+func toolCallData(id, name string, input json.RawMessage) json.RawMessage {
+	if s := string(input); s == "{}" || s == "null" {
+		input = nil
+	}
+	data, err := json.Marshal(ToolCall{ID: id, Name: name, Input: input})
+	if err != nil {
+		// input is a RawMessage taken from valid JSON, so this is not
+		// reached; drop the detail rather than the event.
+		return nil
+	}
+	return data
 }
 
 // StreamJSONProvider is implemented by providers that emit structured JSONL
@@ -104,8 +296,39 @@ type StreamJSONProvider interface {
 	IsStreamJSON() bool
 }
 
+// Stream-JSON dialects understood by the supervisor's stdout parser.
+const (
+	// JSONFormatClaude is the `claude --output-format stream-json` protocol.
+	// It is the default for stream-JSON providers.
+	JSONFormatClaude = "claude"
+	// JSONFormatOpenCode is the `opencode run --format json` event protocol.
+	JSONFormatOpenCode = "opencode"
+)
+
+// JSONFormatProvider is implemented by stream-JSON providers to select the
+// JSONL dialect used to parse their stdout. Providers that do not implement
+// it (or return "") are parsed as JSONFormatClaude.
+type JSONFormatProvider interface {
+	JSONFormat() string
+}
+
 // StripANSIProvider is implemented by providers that should have ANSI escape
 // codes stripped from their PTY output before forwarding to clients.
 type StripANSIProvider interface {
 	IsStripANSI() bool
 }
+
+// SessionCleanupProvider is implemented by providers whose agents can
+// outlive the process the supervisor started, such as a docker container
+// left running when the docker CLI is killed. CleanupSession is called
+// after each of the session's agent processes exits.
+type SessionCleanupProvider interface {
+	CleanupSession(sessionID string)
+}
+
+// StderrClassifierProvider is implemented by providers with rules that
+// classify the lines they write to stderr. Lines matching no rule are
+// reported as SeverityWarning.
+type StderrClassifierProvider interface {
+	StderrRules() []StderrRule
+}
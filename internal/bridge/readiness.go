@@ -0,0 +1,187 @@
+package bridge
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/markcallen/ai-agent-bridge/internal/agentproc"
+)
+
+// ReadinessProvider is implemented by PTY providers that bound how long
+// their agent may take to print its prompt.
+type ReadinessProvider interface {
+	// ReadyTimeout is how long a new agent process may run before its
+	// output matches PromptPattern. Zero waits forever.
+	ReadyTimeout() time.Duration
+}
+
+// ReadinessStats describes how long a provider's agents took to print
+// their first prompt.
+type ReadinessStats struct {
+	// Ready counts the agent processes whose prompt appeared.
+	Ready uint64
+	// TimedOut counts the agent processes killed by the ready timeout.
+	TimedOut uint64
+	// Total and Max are the summed and longest latencies of Ready.
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Mean is the average ready latency, or zero when none was recorded.
+func (r ReadinessStats) Mean() time.Duration {
+	if r.Ready == 0 {
+		return 0
+	}
+	return r.Total / time.Duration(r.Ready)
+}
+
+// readyTailBytes is how much of an agent's latest output is kept while
+// waiting for its prompt.
+const readyTailBytes = 4096
+
+// readyDiagnosisBytes caps the output quoted in a ready timeout's error.
+const readyDiagnosisBytes = 200
+
+// readiness tracks one PTY agent process until it prints its prompt.
+type readiness struct {
+	re      *regexp.Regexp
+	since   time.Time
+	timeout time.Duration
+	// tail is the latest output, matched against re. Prompts are short,
+	// so a match split across reads is found within it.
+	tail  []byte
+	timer *time.Timer
+}
+
+// watchReadiness starts waiting for proc to print its provider's prompt.
+// When the provider has a ready timeout, an agent still without a prompt
+// when it expires is killed and its session fails. Stream-JSON agents and
+// providers without a prompt pattern are not watched.
+func (s *Supervisor) watchReadiness(ms *managedSession, proc *agentProcess) {
+	re := ms.provider.PromptPattern()
+	if proc.ptmx == nil || re == nil {
+		return
+	}
+	r := &readiness{re: re, since: time.Now()}
+	if rp, ok := ms.provider.(ReadinessProvider); ok {
+		r.timeout = rp.ReadyTimeout()
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.ready = r
+	if r.timeout > 0 {
+		r.timer = time.AfterFunc(r.timeout, func() { s.readyTimedOut(ms, proc, r) })
+	}
+}
+
+// checkReady matches output p of a session waiting for its prompt and
+// records the ready latency once it appears.
+func (s *Supervisor) checkReady(ms *managedSession, p []byte) {
+	ms.mu.Lock()
+	r := ms.ready
+	if r == nil {
+		ms.mu.Unlock()
+		return
+	}
+	r.tail = append(r.tail, p...)
+	if len(r.tail) > readyTailBytes {
+		r.tail = r.tail[len(r.tail)-readyTailBytes:]
+	}
+	if !r.re.Match(r.tail) {
+		ms.mu.Unlock()
+		return
+	}
+	ms.ready = nil
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	sessionID, providerID := ms.info.SessionID, ms.info.Provider
+	ms.mu.Unlock()
+
+	latency := time.Since(r.since)
+	s.recordReadiness(providerID, func(st *ReadinessStats) {
+		st.Ready++
+		st.Total += latency
+		st.Max = max(st.Max, latency)
+	})
+	logger().Info("agent ready", "session_id", sessionID, "provider", providerID, "ready_ms", latency.Milliseconds())
+}
+
+// stopReadiness stops waiting for the prompt of a process that exited.
+func (ms *managedSession) stopReadiness() {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.ready != nil && ms.ready.timer != nil {
+		ms.ready.timer.Stop()
+	}
+	ms.ready = nil
+}
+
+// readyTimedOut fails a session whose agent process proc has not printed
+// its prompt within the ready timeout: the diagnosis becomes the session's
+// error and the process group is killed, so that waitLoop marks the
+// session failed without restarting it.
+func (s *Supervisor) readyTimedOut(ms *managedSession, proc *agentProcess, r *readiness) {
+	ms.mu.Lock()
+	if ms.ready != r || ms.proc != proc || ms.info.State != SessionStateRunning && ms.info.State != SessionStateAttached {
+		ms.mu.Unlock()
+		return
+	}
+	ms.ready = nil
+	ms.readyFailed = true
+	ms.info.Error = readyDiagnosis(ms.info.Provider, r)
+	sessionID, providerID, diagnosis := ms.info.SessionID, ms.info.Provider, ms.info.Error
+	ms.mu.Unlock()
+
+	s.recordReadiness(providerID, func(st *ReadinessStats) { st.TimedOut++ })
+	logger().Warn("agent not ready, killing it", "session_id", sessionID, "provider", providerID, "ready_timeout", r.timeout, "error", diagnosis)
+	_ = agentproc.KillProcessGroup(proc.cmd.Process.Pid)
+}
+
+// readyDiagnosis explains a ready timeout, quoting the end of what the
+// agent printed so that a login screen or an error is easy to spot.
+func readyDiagnosis(providerID string, r *readiness) string {
+	msg := fmt.Sprintf("provider %q did not print a prompt matching %q within ready_timeout %s", providerID, r.re.String(), r.timeout)
+	out := bytes.TrimSpace(ansiEscape.ReplaceAll(r.tail, nil))
+	if len(out) == 0 {
+		return msg + "; it printed nothing"
+	}
+	if len(out) > readyDiagnosisBytes {
+		out = out[len(out)-readyDiagnosisBytes:]
+		// Do not start the quote in the middle of a rune.
+		for len(out) > 0 && !utf8.RuneStart(out[0]) {
+			out = out[1:]
+		}
+	}
+	return fmt.Sprintf("%s; last output: %q", msg, out)
+}
+
+// recordReadiness updates the readiness stats of providerID with fn.
+func (s *Supervisor) recordReadiness(providerID string, fn func(*ReadinessStats)) {
+	s.readyMu.Lock()
+	defer s.readyMu.Unlock()
+	if s.readyStats == nil {
+		s.readyStats = make(map[string]*ReadinessStats)
+	}
+	st := s.readyStats[providerID]
+	if st == nil {
+		st = &ReadinessStats{}
+		s.readyStats[providerID] = st
+	}
+	fn(st)
+}
+
+// Readiness reports, per provider, how long agents took to print their
+// first prompt since the supervisor started.
+func (s *Supervisor) Readiness() map[string]ReadinessStats {
+	s.readyMu.Lock()
+	defer s.readyMu.Unlock()
+	out := make(map[string]ReadinessStats, len(s.readyStats))
+	for id, st := range s.readyStats {
+		out[id] = *st
+	}
+	return out
+}
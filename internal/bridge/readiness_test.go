@@ -0,0 +1,87 @@
+package bridge
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readyScriptProvider is a PTY script whose prompt is "> " with a ready
+// timeout.
+type readyScriptProvider struct {
+	scriptProvider
+	timeout time.Duration
+}
+
+func (p *readyScriptProvider) PromptPattern() *regexp.Regexp { return regexp.MustCompile(`(?m)^> $`) }
+func (p *readyScriptProvider) ReadyTimeout() time.Duration   { return p.timeout }
+
+func TestReadyTimeout(t *testing.T) {
+	registry := NewRegistry()
+	for _, p := range []Provider{
+		&readyScriptProvider{scriptProvider{testProvider{id: "prompting"}, `sleep 0.1; printf 'hello\n> '; while :; do sleep 0.1; done`}, 5 * time.Second},
+		&readyScriptProvider{scriptProvider{testProvider{id: "stuck"}, `echo 'Please log in at https://example.com'; while :; do sleep 0.1; done`}, 300 * time.Millisecond},
+	} {
+		if err := registry.Register(p); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	policy := DefaultPolicy()
+	// A ready timeout fails the session even when crashes are restarted.
+	policy.Restart = RestartPolicy{MaxRestarts: 3}
+	sup := NewSupervisor(registry, policy, 64*1024, time.Minute)
+	defer sup.Close()
+
+	start := func(id, provider string) {
+		t.Helper()
+		if _, err := sup.Start(context.Background(), SessionConfig{
+			ProjectID: "proj",
+			SessionID: id,
+			RepoPath:  t.TempDir(),
+			Options:   map[string]string{"provider": provider},
+		}); err != nil {
+			t.Fatalf("Start %s: %v", provider, err)
+		}
+	}
+	start("ready-ok", "prompting")
+	defer func() { _ = sup.Stop("ready-ok", true) }()
+	start("ready-stuck", "stuck")
+
+	var info *SessionInfo
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		info, _ = sup.Get("ready-stuck")
+		if info != nil && info.State == SessionStateFailed {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if info == nil || info.State != SessionStateFailed {
+		t.Fatalf("stuck session = %+v, want failed", info)
+	}
+	if !strings.Contains(info.Error, "ready_timeout 300ms") || !strings.Contains(info.Error, "Please log in") {
+		t.Fatalf("stuck session error = %q, want the timeout and last output", info.Error)
+	}
+	if info.Restarts != 0 {
+		t.Fatalf("stuck session restarted %d times", info.Restarts)
+	}
+
+	var stats map[string]ReadinessStats
+	for time.Now().Before(deadline) {
+		if stats = sup.Readiness(); stats["prompting"].Ready == 1 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if st := stats["prompting"]; st.Ready != 1 || st.TimedOut != 0 || st.Max < 100*time.Millisecond || st.Mean() != st.Max {
+		t.Fatalf("prompting readiness = %+v", st)
+	}
+	if st := stats["stuck"]; st.Ready != 0 || st.TimedOut != 1 {
+		t.Fatalf("stuck readiness = %+v", st)
+	}
+	if info, err := sup.Get("ready-ok"); err != nil || info.State != SessionStateRunning {
+		t.Fatalf("ready session = %+v, err %v, want running", info, err)
+	}
+}
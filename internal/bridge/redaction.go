@@ -0,0 +1,170 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/redact"
+)
+
+// redactFlushDelay is how long redacted output may be held back once the
+// agent goes quiet. Agents that pause longer than this in the middle of a
+// secret can still have it split across two chunks.
+const redactFlushDelay = 150 * time.Millisecond
+
+// sessionRedaction scrubs a session's output and stderr as continuous
+// streams rather than chunk by chunk, so a secret or content rule match
+// split across two reads is still matched whole. Each stream holds back its tail until more text
+// arrives, the agent goes quiet for redactFlushDelay, or output ends.
+type sessionRedaction struct {
+	mu      sync.Mutex // held while publishing so chunks keep their order
+	out     *redact.Stream
+	outType ChunkType
+	stderr  *redact.Stream
+	rules   []StderrRule // classify the lines released from stderr
+	timer   *time.Timer
+}
+
+// appendChunk adds a new chunk with the given type to the session buffer and
+// fans it out to all attached observers. Chunks for slow observers are dropped
+// with a warning; the observer remains attached.
+func (s *Supervisor) appendChunk(ms *managedSession, payload []byte, ctype ChunkType) {
+	s.appendChunkData(ms, payload, ctype, nil)
+}
+
+// appendChunkData is appendChunk for a chunk with structured Data. Output
+// and thinking text is redacted as a stream and published in pieces, so
+// data is kept only on other chunk types. The agent's output, thinking and
+// tool calls are checked against the session's OutputLimits first, and
+// output and thinking text against the content policy as it is published.
+func (s *Supervisor) appendChunkData(ms *managedSession, payload []byte, ctype ChunkType, data json.RawMessage) {
+	if (ctype == ChunkTypeOutput || ctype == ChunkTypeThinking || ctype == ChunkTypeToolUse) && !s.guardOutput(ms, payload, ms.currentInput()) {
+		return
+	}
+	if ctype != ChunkTypeOutput && ctype != ChunkTypeThinking {
+		s.appendRequestChunk(ms, payload, ctype, data, "")
+		return
+	}
+	rd := &ms.redaction
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	if rd.out == nil {
+		cp := s.currentPolicy().ContentPolicy
+		if s.redactor == nil && !cp.checks(ContentDirectionOutput) {
+			if payload = s.checkOutput(ms, payload); len(payload) > 0 {
+				s.emitChunk(ms, OutputChunk{Payload: payload, Type: ctype, InputID: ms.currentInput(), Data: data})
+			}
+			return
+		}
+		// Content rules are held back like secrets, so a match split
+		// across two reads is checked whole.
+		rd.out = s.redactor.NewStream(0, cp.patterns(ContentDirectionOutput)...)
+	} else if rd.outType != ctype {
+		s.publishOutputLocked(ms, rd.out.Flush())
+	}
+	rd.outType = ctype
+	s.publishOutputLocked(ms, rd.out.Write(payload))
+	s.armRedactionFlushLocked(ms)
+}
+
+// appendRequestChunk adds a chunk other than output or thinking text,
+// recording that the RPC requestID produced it.
+func (s *Supervisor) appendRequestChunk(ms *managedSession, payload []byte, ctype ChunkType, data json.RawMessage, requestID string) {
+	chunk := OutputChunk{Payload: payload, Type: ctype, InputID: ms.currentInput(), Data: data, RequestID: requestID}
+	rd := &ms.redaction
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	// Markers such as ChunkTypeResponseComplete follow the text they close.
+	if s.redactor != nil || rd.out != nil {
+		s.flushRedactionLocked(ms)
+	}
+	if s.redactor != nil {
+		chunk.Payload, chunk.Data = s.redactor.RedactBytes(payload), s.redactor.RedactJSON(data)
+	}
+	s.emitChunk(ms, chunk)
+}
+
+// appendStderr adds a stderr line to the session's stderr stream. Redacted
+// lines are classified with rules and fanned out like appendChunk. partial
+// is set when line is the first piece of a line too long to read at once,
+// and continues in the next call.
+func (s *Supervisor) appendStderr(ms *managedSession, line []byte, partial bool, rules []StderrRule) {
+	if !partial {
+		line = append(line[:len(line):len(line)], '\n')
+	}
+	if s.redactor == nil {
+		s.publishStderr(ms, line, rules)
+		return
+	}
+	rd := &ms.redaction
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	if rd.stderr == nil {
+		rd.stderr = s.redactor.NewStream(0)
+	}
+	rd.rules = rules
+	s.publishStderr(ms, rd.stderr.Write(line), rules)
+	s.armRedactionFlushLocked(ms)
+}
+
+// publishOutputLocked appends redacted output text that the content
+// policy lets through as one chunk of the output stream's current type.
+func (s *Supervisor) publishOutputLocked(ms *managedSession, payload []byte) {
+	if payload = s.checkOutput(ms, payload); len(payload) == 0 {
+		return
+	}
+	s.emitChunk(ms, OutputChunk{Payload: payload, Type: ms.redaction.outType, InputID: ms.currentInput()})
+}
+
+// publishStderr appends each line of redacted stderr text as a
+// ChunkTypeStderr chunk classified with rules.
+func (s *Supervisor) publishStderr(ms *managedSession, text []byte, rules []StderrRule) {
+	for len(text) > 0 {
+		line := text
+		if i := bytes.IndexByte(text, '\n'); i >= 0 {
+			line, text = text[:i], text[i+1:]
+		} else {
+			text = nil
+		}
+		if len(line) == 0 {
+			continue
+		}
+		s.emitChunk(ms, OutputChunk{Payload: line, Type: ChunkTypeStderr, Severity: ClassifyStderr(rules, line), InputID: ms.currentInput()})
+	}
+}
+
+// armRedactionFlushLocked schedules held text to be released once the
+// agent has been quiet for redactFlushDelay.
+func (s *Supervisor) armRedactionFlushLocked(ms *managedSession) {
+	rd := &ms.redaction
+	if rd.timer != nil {
+		rd.timer.Reset(redactFlushDelay)
+		return
+	}
+	rd.timer = time.AfterFunc(redactFlushDelay, func() { s.flushRedaction(ms) })
+}
+
+// flushRedaction releases all held output and stderr text. It is called
+// when the agent goes quiet and when its output ends, before observers are
+// closed.
+func (s *Supervisor) flushRedaction(ms *managedSession) {
+	ms.redaction.mu.Lock()
+	defer ms.redaction.mu.Unlock()
+	s.flushRedactionLocked(ms)
+}
+
+func (s *Supervisor) flushRedactionLocked(ms *managedSession) {
+	rd := &ms.redaction
+	if rd.timer != nil {
+		rd.timer.Stop()
+	}
+	if rd.out != nil {
+		s.publishOutputLocked(ms, rd.out.Flush())
+	}
+	if rd.stderr != nil {
+		s.publishStderr(ms, rd.stderr.Flush(), rd.rules)
+	}
+	ms.plog.flush()
+}
@@ -29,6 +29,19 @@ func (r *Registry) Register(p Provider) error {
 	return nil
 }
 
+// Replace swaps the registered provider set for providers in one step.
+// Sessions already running keep the provider instance they started with.
+// When providers contains duplicate IDs the last one wins.
+func (r *Registry) Replace(providers []Provider) {
+	next := make(map[string]Provider, len(providers))
+	for _, p := range providers {
+		next[p.ID()] = p
+	}
+	r.mu.Lock()
+	r.providers = next
+	r.mu.Unlock()
+}
+
 // Get returns a provider by ID.
 func (r *Registry) Get(id string) (Provider, error) {
 	r.mu.RLock()
@@ -0,0 +1,259 @@
+package bridge
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ContextDir is where the repo summary of each session is written,
+// relative to the session's repo.
+const ContextDir = ".bridge/context"
+
+// RepoSummary configures the summary of the repo a session's agent is given
+// before its first prompt: a file tree, the start of the README and the
+// languages the repo is written in. Zero limits use the defaults.
+type RepoSummary struct {
+	Enabled bool
+	// TreeDepth is how many directory levels the file tree lists.
+	TreeDepth int
+	// MaxTreeEntries caps the lines of the file tree.
+	MaxTreeEntries int
+	// ReadmeLines caps the README excerpt.
+	ReadmeLines int
+}
+
+const (
+	defaultSummaryTreeDepth   = 3
+	defaultSummaryTreeEntries = 200
+	defaultSummaryReadmeLines = 40
+	// maxSummaryFiles caps the files counted for the language stats, so
+	// that a huge repo does not hold up the session's start.
+	maxSummaryFiles = 20000
+)
+
+// summarySkipDirs are not listed or counted: VCS metadata, dependencies,
+// build output and the bridge's own files.
+var summarySkipDirs = map[string]bool{
+	".git": true, ".hg": true, ".svn": true, ".bridge": true,
+	"node_modules": true, "vendor": true, "dist": true, "build": true,
+	"target": true, "__pycache__": true, ".venv": true, "venv": true,
+}
+
+// summaryLanguages names the language of a file by its extension.
+var summaryLanguages = map[string]string{
+	".go": "Go", ".ts": "TypeScript", ".tsx": "TypeScript", ".js": "JavaScript",
+	".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript", ".py": "Python",
+	".rb": "Ruby", ".rs": "Rust", ".java": "Java", ".kt": "Kotlin", ".swift": "Swift",
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".hpp": "C++", ".cs": "C#",
+	".php": "PHP", ".scala": "Scala", ".sh": "Shell", ".bash": "Shell", ".sql": "SQL",
+	".html": "HTML", ".css": "CSS", ".scss": "CSS", ".proto": "Protocol Buffers",
+	".md": "Markdown", ".yaml": "YAML", ".yml": "YAML", ".json": "JSON", ".toml": "TOML",
+	".tf": "HCL", ".lua": "Lua", ".ex": "Elixir", ".exs": "Elixir", ".dart": "Dart",
+}
+
+// repoSummaryEnabled reports whether a session of cfg gets a repo summary:
+// its "repo_summary" option, "true" or "false", overrides the project's
+// setting.
+func repoSummaryEnabled(cfg SessionConfig, project RepoSummary) (bool, error) {
+	opt, ok := cfg.Options["repo_summary"]
+	if !ok {
+		return project.Enabled, nil
+	}
+	on, err := strconv.ParseBool(opt)
+	if err != nil {
+		return false, fmt.Errorf("%w: repo_summary option %q must be true or false", ErrInvalidArgument, opt)
+	}
+	return on, nil
+}
+
+// writeRepoSummary summarizes repo and writes the summary to
+// ContextDir/<sessionID>.md in it. It returns the file's path relative to
+// repo, with forward slashes.
+func writeRepoSummary(repo, sessionID string, cfg RepoSummary) (string, error) {
+	root, err := os.OpenRoot(repo)
+	if err != nil {
+		return "", fmt.Errorf("write repo summary: %w", err)
+	}
+	defer root.Close()
+	summary, err := buildRepoSummary(root.FS(), cfg)
+	if err != nil {
+		return "", fmt.Errorf("write repo summary: %w", err)
+	}
+	if err := root.MkdirAll(filepath.FromSlash(ContextDir), 0o755); err != nil {
+		return "", fmt.Errorf("write repo summary: %w", err)
+	}
+	rel := path.Join(ContextDir, sessionID+".md")
+	if err := root.WriteFile(filepath.FromSlash(rel), summary, 0o644); err != nil {
+		return "", fmt.Errorf("write repo summary: %w", err)
+	}
+	return rel, nil
+}
+
+// buildRepoSummary renders the summary of the repo in fsys as markdown.
+func buildRepoSummary(fsys fs.FS, cfg RepoSummary) ([]byte, error) {
+	depth := cmp.Or(cfg.TreeDepth, defaultSummaryTreeDepth)
+	maxEntries := cmp.Or(cfg.MaxTreeEntries, defaultSummaryTreeEntries)
+
+	var (
+		tree      []string
+		omitted   int
+		files     int
+		truncated bool
+		langs     = make(map[string]*languageStat)
+	)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// An unreadable directory is left out rather than failing the
+			// summary.
+			if d != nil && d.IsDir() && p != "." {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if p == "." {
+			return nil
+		}
+		if d.IsDir() && summarySkipDirs[d.Name()] {
+			return fs.SkipDir
+		}
+		level := strings.Count(p, "/")
+		if level < depth {
+			if len(tree) < maxEntries {
+				name := d.Name()
+				if d.IsDir() {
+					name += "/"
+				}
+				tree = append(tree, strings.Repeat("  ", level)+name)
+			} else {
+				omitted++
+			}
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		if files == maxSummaryFiles {
+			truncated = true
+			return fs.SkipAll
+		}
+		files++
+		if lang, ok := summaryLanguages[strings.ToLower(path.Ext(p))]; ok {
+			st := langs[lang]
+			if st == nil {
+				st = &languageStat{name: lang}
+				langs[lang] = st
+			}
+			st.files++
+			if fi, err := d.Info(); err == nil {
+				st.bytes += fi.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("# Repository summary\n\n")
+	b.WriteString("Generated by the bridge when the session started, for context.\n")
+
+	b.WriteString("\n## Files\n\n```\n")
+	for _, line := range tree {
+		b.WriteString(line + "\n")
+	}
+	if omitted > 0 {
+		fmt.Fprintf(&b, "... %d more\n", omitted)
+	}
+	b.WriteString("```\n")
+
+	if stats := sortedLanguages(langs); len(stats) > 0 {
+		var total int64
+		for _, st := range stats {
+			total += st.bytes
+		}
+		b.WriteString("\n## Languages\n\n")
+		for _, st := range stats {
+			pct := 0.0
+			if total > 0 {
+				pct = float64(st.bytes) * 100 / float64(total)
+			}
+			unit := "files"
+			if st.files == 1 {
+				unit = "file"
+			}
+			fmt.Fprintf(&b, "- %s: %.1f%% (%d %s)\n", st.name, pct, st.files, unit)
+		}
+		if truncated {
+			fmt.Fprintf(&b, "\nCounted the first %d files only.\n", maxSummaryFiles)
+		}
+	}
+
+	name, excerpt, err := readmeExcerpt(fsys, cmp.Or(cfg.ReadmeLines, defaultSummaryReadmeLines))
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		fmt.Fprintf(&b, "\n## %s\n\n%s", name, excerpt)
+	}
+	return []byte(b.String()), nil
+}
+
+type languageStat struct {
+	name  string
+	files int
+	bytes int64
+}
+
+// sortedLanguages orders stats by size, largest first.
+func sortedLanguages(langs map[string]*languageStat) []*languageStat {
+	out := make([]*languageStat, 0, len(langs))
+	for _, st := range langs {
+		out = append(out, st)
+	}
+	slices.SortFunc(out, func(a, b *languageStat) int {
+		if c := cmp.Compare(b.bytes, a.bytes); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.name, b.name)
+	})
+	return out
+}
+
+// readmeExcerpt returns the name and first lines of the repo's README, or
+// an empty name when it has none.
+func readmeExcerpt(fsys fs.FS, lines int) (string, string, error) {
+	for _, name := range []string{"README.md", "README", "README.rst", "README.txt"} {
+		f, err := fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+		var b strings.Builder
+		sc := bufio.NewScanner(f)
+		for i := 0; i < lines && sc.Scan(); i++ {
+			b.WriteString(sc.Text() + "\n")
+		}
+		more := sc.Scan()
+		err = sc.Err()
+		f.Close()
+		if err != nil {
+			return "", "", fmt.Errorf("read %s: %w", name, err)
+		}
+		if more {
+			b.WriteString("...\n")
+		}
+		return name, b.String(), nil
+	}
+	return "", "", nil
+}
@@ -0,0 +1,122 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestBuildRepoSummary(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.md":                 {Data: []byte("# Demo\n\nline 3\nline 4\n")},
+		"go.mod":                    {Data: []byte("module demo\n")},
+		"main.go":                   {Data: []byte(strings.Repeat("x", 300))},
+		"web/app.ts":                {Data: []byte(strings.Repeat("x", 100))},
+		"web/deep/er/util.ts":       {Data: []byte("x")},
+		"node_modules/dep/index.js": {Data: []byte("x")},
+		".git/HEAD":                 {Data: []byte("ref")},
+	}
+	got, err := buildRepoSummary(fsys, RepoSummary{TreeDepth: 2, ReadmeLines: 2})
+	if err != nil {
+		t.Fatalf("buildRepoSummary: %v", err)
+	}
+	text := string(got)
+	for _, want := range []string{
+		"README.md\n", "main.go\n", "web/\n  app.ts\n  deep/\n",
+		"- Go: 70.9% (1 file)\n- TypeScript: 23.9% (2 files)\n- Markdown: 5.2% (1 file)\n",
+		"## README.md\n\n# Demo\n\n...\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("summary missing %q:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"er/", "node_modules", ".git", "JavaScript", "line 3"} {
+		if strings.Contains(text, unwanted) {
+			t.Fatalf("summary has %q:\n%s", unwanted, text)
+		}
+	}
+
+	got, err = buildRepoSummary(fsys, RepoSummary{MaxTreeEntries: 2})
+	if err != nil {
+		t.Fatalf("buildRepoSummary: %v", err)
+	}
+	if !strings.Contains(string(got), "... 5 more\n") {
+		t.Fatalf("capped tree:\n%s", got)
+	}
+}
+
+func TestRepoSummaryPreamble(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&testProvider{id: "fake"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	policy := DefaultPolicy()
+	policy.Projects = map[string]ProjectPolicy{"project-a": {RepoSummary: RepoSummary{Enabled: true}}}
+	supervisor := NewSupervisor(registry, policy, 1024, time.Minute)
+	defer supervisor.Close()
+
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("# Demo\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	start := func(sessionID string, opts map[string]string) error {
+		opts["provider"] = "fake"
+		_, err := supervisor.Start(context.Background(), SessionConfig{
+			ProjectID: "project-a",
+			SessionID: sessionID,
+			RepoPath:  repo,
+			Options:   opts,
+		})
+		if err == nil {
+			t.Cleanup(func() { _ = supervisor.Stop(sessionID, true) })
+		}
+		return err
+	}
+	if err := start("session-a", map[string]string{}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	summary := ContextDir + "/session-a.md"
+	if data, err := os.ReadFile(filepath.Join(repo, filepath.FromSlash(summary))); err != nil || !strings.Contains(string(data), "# Demo") {
+		t.Fatalf("summary=%q err=%v", data, err)
+	}
+	state, err := supervisor.Attach("session-a", "client-a", 0, AttachRoleWriter)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+
+	// An input that is not delivered leaves the summary for the next one.
+	if _, err := supervisor.SendInputWithAttachments(context.Background(), "session-a", "client-a", []byte("hi\n"), []Attachment{{Path: "missing.go"}}); !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("missing path err=%v want ErrFileNotFound", err)
+	}
+	if _, err := supervisor.SendInputWithAttachments(context.Background(), "session-a", "client-a", []byte("first\n"), nil); err != nil {
+		t.Fatalf("SendInputWithAttachments: %v", err)
+	}
+	waitForChunk(t, state.Live, "first")
+	if _, err := supervisor.SendInputWithAttachments(context.Background(), "session-a", "client-a", []byte("second\n"), nil); err != nil {
+		t.Fatalf("SendInputWithAttachments: %v", err)
+	}
+	waitForChunk(t, state.Live, "second")
+	ms := supervisor.sessions["session-a"]
+	if got := string(ms.inputs[0].Data); got != "@"+summary+" first\n" {
+		t.Fatalf("first input=%q", got)
+	}
+	if got := string(ms.inputs[1].Data); got != "second\n" {
+		t.Fatalf("second input=%q", got)
+	}
+
+	// The session's option overrides the project.
+	if err := start("session-b", map[string]string{"repo_summary": "false"}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, filepath.FromSlash(ContextDir), "session-b.md")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("summary written with repo_summary=false: %v", err)
+	}
+	if err := start("session-c", map[string]string{"repo_summary": "maybe"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("bad repo_summary err=%v want ErrInvalidArgument", err)
+	}
+}
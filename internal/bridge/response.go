@@ -0,0 +1,102 @@
+package bridge
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Response is an agent's reply to one input, assembled from the session's
+// output chunks.
+type Response struct {
+	InputID string
+	// InputSeq is the seq of the input's ChunkTypeInputAcked chunk.
+	InputSeq uint64
+	// Prompt is the input as the transcript renders it.
+	Prompt string
+	// Text is the output attributed to the input, with terminal escape
+	// sequences removed.
+	Text     string
+	Thinking string
+	// Complete is set once the agent reported the response complete.
+	// PTY agents never do; their response is the output up to the next
+	// input.
+	Complete bool
+	// LastSeq is the seq of the last chunk read, the ChunkTypeResponseComplete
+	// chunk when Complete is set. Polling can stop once it stops growing.
+	LastSeq uint64
+}
+
+// Response assembles the reply to the input named by inputID or, when
+// inputID is empty, by inputSeq, the seq of its ChunkTypeInputAcked chunk.
+// It reads live sessions' in-memory buffer and stopped sessions' archive,
+// so the input must still be retained there.
+func (s *Supervisor) Response(sessionID, inputID string, inputSeq uint64) (*Response, error) {
+	h, err := s.History(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return AssembleResponse(h, inputID, inputSeq)
+}
+
+// AssembleResponse finds an input in h, as Supervisor.Response does, and
+// collects the output chunks attributed to it.
+func AssembleResponse(h *ArchivedSession, inputID string, inputSeq uint64) (*Response, error) {
+	start := -1
+	for i, c := range h.Chunks {
+		if c.Type != ChunkTypeInputAcked {
+			continue
+		}
+		if inputID != "" && c.InputID == inputID || inputID == "" && c.Seq == inputSeq {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		if inputID != "" {
+			return nil, fmt.Errorf("%w: input %q is not in the session's retained output", ErrInputNotFound, inputID)
+		}
+		return nil, fmt.Errorf("%w: no input at seq %d in the session's retained output", ErrInputNotFound, inputSeq)
+	}
+	ack := h.Chunks[start]
+	resp := &Response{InputID: ack.InputID, InputSeq: ack.Seq, LastSeq: ack.Seq}
+	for _, in := range h.Inputs {
+		if in.ID == ack.InputID {
+			resp.Prompt = strings.TrimSpace(inputText(string(in.Data)))
+			break
+		}
+	}
+
+	var text, thinking strings.Builder
+	// later holds the inputs acked after this one. Output of one of them
+	// ends a PTY response; a stream-JSON input acked while this response
+	// was in flight is queued behind it, so its output comes after
+	// RESPONSE_COMPLETE anyway.
+	later := map[string]bool{}
+	for _, c := range h.Chunks[start+1:] {
+		if c.Type == ChunkTypeInputAcked {
+			later[c.InputID] = true
+			continue
+		}
+		if c.InputID != ack.InputID {
+			if c.Type == ChunkTypeOutput && later[c.InputID] {
+				break
+			}
+			continue
+		}
+		resp.LastSeq = c.Seq
+		switch c.Type {
+		case ChunkTypeOutput:
+			text.Write(c.Payload)
+		case ChunkTypeThinking:
+			thinking.Write(c.Payload)
+		case ChunkTypeResponseComplete:
+			resp.Complete = true
+		}
+		if resp.Complete {
+			break
+		}
+	}
+	resp.Text = strings.TrimSpace(cleanTerminalText(text.String()))
+	resp.Thinking = strings.TrimSpace(cleanTerminalText(thinking.String()))
+	return resp, nil
+}
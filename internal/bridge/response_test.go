@@ -0,0 +1,60 @@
+package bridge
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAssembleResponse(t *testing.T) {
+	h := &ArchivedSession{
+		Chunks: []OutputChunk{
+			{Seq: 1, Type: ChunkTypeInputAcked, InputID: "a"},
+			{Seq: 2, Payload: []byte("\x1b[1mHello"), InputID: "a"},
+			{Seq: 3, Type: ChunkTypeThinking, Payload: []byte("pondering"), InputID: "a"},
+			// b is queued while a's response is in flight.
+			{Seq: 4, Type: ChunkTypeInputAcked, InputID: "b"},
+			{Seq: 5, Payload: []byte(" world\x1b[0m\r\n"), InputID: "a"},
+			{Seq: 6, Type: ChunkTypeStderr, Payload: []byte("warning"), InputID: "a"},
+			{Seq: 7, Type: ChunkTypeResponseComplete, InputID: "a"},
+			{Seq: 8, Payload: []byte("second"), InputID: "b"},
+		},
+		Inputs: []InputRecord{
+			{ID: "a", AfterSeq: 0, Data: []byte(`{"type":"user","message":{"role":"user","content":"greet"}}` + "\n")},
+			{ID: "b", AfterSeq: 3, Data: []byte("next\r")},
+		},
+	}
+
+	resp, err := AssembleResponse(h, "a", 0)
+	if err != nil {
+		t.Fatalf("AssembleResponse: %v", err)
+	}
+	want := Response{InputID: "a", InputSeq: 1, Prompt: "greet", Text: "Hello world", Thinking: "pondering", Complete: true, LastSeq: 7}
+	if *resp != want {
+		t.Fatalf("response=%+v want %+v", *resp, want)
+	}
+
+	resp, err = AssembleResponse(h, "", 4)
+	if err != nil || resp.InputID != "b" || resp.Text != "second" || resp.Complete || resp.LastSeq != 8 {
+		t.Fatalf("response by seq=%+v err=%v", resp, err)
+	}
+
+	// A PTY response ends where the next input's output starts.
+	pty := &ArchivedSession{Chunks: []OutputChunk{
+		{Seq: 1, Type: ChunkTypeInputAcked, InputID: "a"},
+		{Seq: 2, Payload: []byte("one"), InputID: "a"},
+		{Seq: 3, Type: ChunkTypeInputAcked, InputID: "b"},
+		{Seq: 4, Payload: []byte("two"), InputID: "b"},
+		{Seq: 5, Payload: []byte("late"), InputID: "a"},
+	}}
+	resp, err = AssembleResponse(pty, "a", 0)
+	if err != nil || resp.Text != "one" || resp.LastSeq != 2 {
+		t.Fatalf("PTY response=%+v err=%v", resp, err)
+	}
+
+	if _, err := AssembleResponse(h, "missing", 0); !errors.Is(err, ErrInputNotFound) {
+		t.Fatalf("unknown input err=%v want ErrInputNotFound", err)
+	}
+	if _, err := AssembleResponse(h, "", 2); !errors.Is(err, ErrInputNotFound) {
+		t.Fatalf("seq of an output chunk err=%v want ErrInputNotFound", err)
+	}
+}
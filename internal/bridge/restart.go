@@ -0,0 +1,303 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markcallen/ai-agent-bridge/internal/agentproc"
+)
+
+// RestartPolicy controls whether a session whose agent process crashes is
+// relaunched. A crash is an exit with an error (non-zero status or a signal)
+// that Stop did not cause; clean exits always end the session.
+type RestartPolicy struct {
+	// MaxRestarts is how many times one session may be relaunched. Zero
+	// disables restarts: a crashed session fails.
+	MaxRestarts int
+	// Backoff is the wait before the first relaunch. It doubles for each
+	// later one, up to MaxBackoff (one minute when zero). Zero relaunches
+	// immediately.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// delay returns the wait before the given relaunch, counting from 1.
+func (p RestartPolicy) delay(attempt int) time.Duration {
+	limit := p.MaxBackoff
+	if limit <= 0 {
+		limit = time.Minute
+	}
+	d := p.Backoff
+	for i := 1; i < attempt && d > 0 && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// RestartInfo describes a relaunch announced by a ChunkTypeSessionRestarted
+// chunk.
+type RestartInfo struct {
+	// Count is the session's number of restarts so far, including this one.
+	Count int
+	// ExitCode is the exit code of the process that crashed, or -1 when it
+	// was killed by a signal.
+	ExitCode int
+}
+
+// ResumeProvider is implemented by providers whose CLI can continue an
+// agent thread by its ID, e.g. `claude --resume <id>`. When Resumable
+// reports true the supervisor relaunches a crashed session on its own thread
+// with BuildResumeCommand instead of BuildCommand. A session whose thread ID
+// it does not know is relaunched afresh: resuming the last thread in the
+// directory, as `claude --continue` does, could pick up the thread of
+// another session in the same repo.
+type ResumeProvider interface {
+	Resumable() bool
+	BuildResumeCommand(ctx context.Context, cfg SessionConfig, threadID string) (*exec.Cmd, error)
+}
+
+// ThreadIDProvider is implemented by providers whose CLI can be told the ID
+// of the thread a new agent starts, e.g. `claude --session-id <uuid>`. It is
+// how the supervisor learns the thread of an agent whose output does not
+// name it, such as one running in a terminal.
+type ThreadIDProvider interface {
+	AssignsThreadID() bool
+	BuildThreadCommand(ctx context.Context, cfg SessionConfig, threadID string) (*exec.Cmd, error)
+}
+
+// agentCommand builds the command that starts the agent of a session. It
+// resumes thread when that is set and provider can; otherwise it starts a
+// new thread, under an ID the supervisor picks when provider takes one. It
+// returns the agent's thread ID, or "" while it is unknown.
+func agentCommand(ctx context.Context, provider Provider, cfg SessionConfig, thread string) (*exec.Cmd, string, error) {
+	if rp, ok := provider.(ResumeProvider); ok && rp.Resumable() && thread != "" {
+		cmd, err := rp.BuildResumeCommand(ctx, cfg, thread)
+		return cmd, thread, err
+	}
+	if tp, ok := provider.(ThreadIDProvider); ok && tp.AssignsThreadID() {
+		thread = uuid.NewString()
+		cmd, err := tp.BuildThreadCommand(ctx, cfg, thread)
+		return cmd, thread, err
+	}
+	cmd, err := provider.BuildCommand(ctx, cfg)
+	return cmd, "", err
+}
+
+// agentProcess is one launch of a session's agent. A session that is
+// restarted after a crash goes through several.
+type agentProcess struct {
+	cmd    *exec.Cmd
+	ptmx   agentproc.Terminal // PTY sessions
+	stdin  io.WriteCloser     // stream-JSON sessions
+	stdout *os.File           // stream-JSON sessions
+	stderr *os.File           // stream-JSON sessions
+	// readDone is closed when the read loop has consumed all output.
+	readDone chan struct{}
+}
+
+// close releases the process's terminal or pipes.
+func (p *agentProcess) close() {
+	if p.ptmx != nil {
+		_ = p.ptmx.Close()
+	}
+	if p.stdin != nil {
+		_ = p.stdin.Close()
+	}
+	if p.stdout != nil {
+		_ = p.stdout.Close()
+	}
+	if p.stderr != nil {
+		_ = p.stderr.Close()
+	}
+}
+
+// spawn starts cmd as the agent process of ms, over a PTY or, for
+// stream-JSON providers, over pipes, and makes it the session's current
+// process. Output is not read until run is called.
+func (s *Supervisor) spawn(ms *managedSession, cmd *exec.Cmd) (*agentProcess, error) {
+	proc := &agentProcess{cmd: cmd, readDone: make(chan struct{})}
+	ms.mu.Lock()
+	streamJSON := ms.streamJSON
+	cols, rows := ms.info.Cols, ms.info.Rows
+	ms.mu.Unlock()
+
+	if streamJSON {
+		agentproc.SetProcessGroup(cmd)
+		stdinPipe, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, fmt.Errorf("get stdin pipe: %w", err)
+		}
+		// Use os.Pipe directly so that cmd.Wait does not close the read end via
+		// closeAfterWait. readLoopStreamJSON owns the read end and receives a
+		// natural EOF when the child process exits and the write end closes.
+		stdoutR, stdoutW, err := os.Pipe()
+		if err != nil {
+			_ = stdinPipe.Close()
+			return nil, fmt.Errorf("create stdout pipe: %w", err)
+		}
+		cmd.Stdout = stdoutW
+		stderrR, stderrW, err := os.Pipe()
+		if err != nil {
+			_ = stdinPipe.Close()
+			_ = stdoutR.Close()
+			_ = stdoutW.Close()
+			return nil, fmt.Errorf("create stderr pipe: %w", err)
+		}
+		cmd.Stderr = stderrW
+		if err := cmd.Start(); err != nil {
+			_ = stdinPipe.Close()
+			_ = stdoutR.Close()
+			_ = stdoutW.Close()
+			_ = stderrR.Close()
+			_ = stderrW.Close()
+			return nil, fmt.Errorf("start stream-json session: %w", err)
+		}
+		// Close the write ends in the parent; only the child holds them now.
+		_ = stdoutW.Close()
+		_ = stderrW.Close()
+		proc.stdin, proc.stdout, proc.stderr = stdinPipe, stdoutR, stderrR
+	} else {
+		ptmx, err := agentproc.StartTerminal(cmd, uint16(cols), uint16(rows))
+		if err != nil {
+			return nil, fmt.Errorf("start pty session: %w", err)
+		}
+		proc.ptmx = ptmx
+	}
+	if err := agentproc.TrackProcessGroup(cmd); err != nil {
+		logger().Warn("agent process group not tracked; stopping it may leave its children running", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "error", err)
+	}
+
+	ms.mu.Lock()
+	ms.proc = proc
+	ms.cmd = cmd
+	ms.ptmx = proc.ptmx
+	ms.stdin = proc.stdin
+	ms.info.ProcessID = cmd.Process.Pid
+	ms.mu.Unlock()
+	return proc, nil
+}
+
+// run starts reading the output of proc and waiting for it to exit.
+func (s *Supervisor) run(ms *managedSession, proc *agentProcess) {
+	ms.plog.start(proc.cmd.Process.Pid)
+	s.watchReadiness(ms, proc)
+	if proc.ptmx != nil {
+		go s.readLoop(ms, proc.ptmx)
+	} else {
+		go s.readLoopStreamJSON(ms, proc.stdout, proc.stderr, ms.stderrRules)
+	}
+	go s.waitLoop(ms, proc)
+}
+
+// outputEnded runs when a read loop has consumed all output. For a session
+// with a tracked process, waitLoop closes the observer channels once it has
+// decided the session is not being restarted; otherwise they close now.
+func (s *Supervisor) outputEnded(ms *managedSession) {
+	s.flushOutput(ms)
+	s.flushRedaction(ms)
+	s.flushCoalesced(ms)
+	ms.mu.Lock()
+	proc := ms.proc
+	ms.mu.Unlock()
+	if proc == nil {
+		s.closeLive(ms)
+		return
+	}
+	close(proc.readDone)
+}
+
+// restartDelay reports whether a session whose process exited with err
+// should be relaunched under the current policy, and after what delay. It
+// counts the restart when it allows one.
+func (s *Supervisor) restartDelay(ms *managedSession, err error) (time.Duration, bool) {
+	policy := s.currentPolicy().Restart
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if err == nil || ms.forceStop || ms.recovered || ms.readyFailed || policy.MaxRestarts <= 0 || ms.restarts >= policy.MaxRestarts {
+		return 0, false
+	}
+	if ms.info.State != SessionStateRunning && ms.info.State != SessionStateAttached {
+		return 0, false
+	}
+	ms.restarts++
+	return policy.delay(ms.restarts), true
+}
+
+// relaunch starts a new agent process for a session whose process crashed
+// with exitCode, after waiting delay. Sequence numbers continue from the
+// crashed process's output, and a ChunkTypeSessionRestarted chunk marks the
+// point of the restart. It returns false, leaving the session to end, when
+// the session was stopped during the wait or the agent cannot be started.
+func (s *Supervisor) relaunch(ms *managedSession, exitCode int, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	select {
+	case <-timer.C:
+	case <-ms.restartWake:
+	case <-s.done:
+	}
+	timer.Stop()
+
+	ms.mu.Lock()
+	state := ms.info.State
+	attempt := ms.restarts
+	provider := ms.provider
+	cfg := ms.cfg
+	ctx := ms.ctx
+	thread := ms.info.ThreadID
+	ms.mu.Unlock()
+	if state != SessionStateRunning && state != SessionStateAttached || ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	logger().Warn("restarting crashed session", "session_id", cfg.SessionID, "provider", provider.ID(), "exit_code", exitCode, "restart", attempt)
+	cmd, thread, err := agentCommand(ctx, provider, cfg, thread)
+	var proc *agentProcess
+	if err == nil {
+		proc, err = s.spawn(ms, cmd)
+	}
+	if err != nil {
+		logger().Warn("session restart failed", "session_id", cfg.SessionID, "provider", provider.ID(), "error", err)
+		ms.mu.Lock()
+		ms.info.Error = fmt.Sprintf("restart after crash: %v", err)
+		ms.mu.Unlock()
+		return false
+	}
+
+	ms.mu.Lock()
+	ms.info.Error = ""
+	ms.info.Restarts = attempt
+	ms.info.ThreadID = thread
+	// A stream-JSON response in flight when the agent crashed will never
+	// complete; move on to the next queued input instead.
+	responding := ms.responding
+	ms.mu.Unlock()
+	s.emitChunk(ms, OutputChunk{
+		Type:    ChunkTypeSessionRestarted,
+		Restart: &RestartInfo{Count: attempt, ExitCode: exitCode},
+	})
+	s.persistSession(ms.snapshotInfo())
+	s.run(ms, proc)
+	if responding {
+		s.deliverPending(ms)
+	}
+
+	// Stop may have run while the process was starting and signalled the
+	// crashed one instead.
+	ms.mu.Lock()
+	stopping := ms.info.State == SessionStateStopping
+	ms.mu.Unlock()
+	if stopping {
+		_ = agentproc.KillProcessGroup(cmd.Process.Pid)
+	}
+	return true
+}
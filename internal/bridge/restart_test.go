@@ -0,0 +1,268 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestartPolicyDelay(t *testing.T) {
+	p := RestartPolicy{MaxRestarts: 10, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 9: 5 * time.Second} {
+		if got := p.delay(attempt); got != want {
+			t.Errorf("delay(%d)=%v want %v", attempt, got, want)
+		}
+	}
+	if got := (RestartPolicy{Backoff: 40 * time.Second}).delay(3); got != time.Minute {
+		t.Errorf("default cap delay=%v want %v", got, time.Minute)
+	}
+	if got := (RestartPolicy{}).delay(3); got != 0 {
+		t.Errorf("zero backoff delay=%v want 0", got)
+	}
+}
+
+// resumeScriptProvider relaunches crashed sessions on their thread with a
+// separate script, which gets the thread ID as $1. Unless noThread is set it
+// is told the thread ID of new agents.
+type resumeScriptProvider struct {
+	scriptProvider
+	resume   string
+	noThread bool
+}
+
+func (p *resumeScriptProvider) Resumable() bool       { return true }
+func (p *resumeScriptProvider) AssignsThreadID() bool { return !p.noThread }
+
+func (p *resumeScriptProvider) BuildThreadCommand(ctx context.Context, cfg SessionConfig, threadID string) (*exec.Cmd, error) {
+	return p.BuildCommand(ctx, cfg)
+}
+
+func (p *resumeScriptProvider) BuildResumeCommand(ctx context.Context, cfg SessionConfig, threadID string) (*exec.Cmd, error) {
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", p.resume, "sh", threadID)
+	cmd.Dir = cfg.RepoPath
+	return cmd, nil
+}
+
+func newRestartSupervisor(t *testing.T, p Provider, policy RestartPolicy) *Supervisor {
+	t.Helper()
+	registry := NewRegistry()
+	if err := registry.Register(p); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	pol := DefaultPolicy()
+	pol.Restart = policy
+	sup := NewSupervisor(registry, pol, 64*1024, time.Minute)
+	t.Cleanup(func() { sup.Close() })
+	return sup
+}
+
+func TestSupervisorRestartsCrashedSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		want     string
+	}{
+		{
+			name: "rebuild",
+			provider: &scriptProvider{
+				testProvider: testProvider{id: "agent"},
+				script:       `if [ -e crashed ]; then echo resumed; while :; do sleep 0.1; done; fi; touch crashed; echo first; exit 3`,
+			},
+			want: "resumed",
+		},
+		{
+			name: "resume",
+			provider: &resumeScriptProvider{
+				scriptProvider: scriptProvider{
+					testProvider: testProvider{id: "agent"},
+					script:       `echo first; exit 3`,
+				},
+				resume: `echo resumed thread $1; while :; do sleep 0.1; done`,
+			},
+			want: "resumed thread ",
+		},
+		{
+			// Without the crashed agent's thread ID the provider cannot
+			// resume it, so the agent starts afresh.
+			name: "unknown thread",
+			provider: &resumeScriptProvider{
+				scriptProvider: scriptProvider{
+					testProvider: testProvider{id: "agent"},
+					script:       `if [ -e crashed ]; then echo resumed; while :; do sleep 0.1; done; fi; touch crashed; echo first; exit 3`,
+				},
+				resume:   `echo resumed thread $1; exit 9`,
+				noThread: true,
+			},
+			want: "resumed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sup := newRestartSupervisor(t, tt.provider, RestartPolicy{MaxRestarts: 1})
+			if _, err := sup.Start(context.Background(), SessionConfig{
+				ProjectID: "proj",
+				SessionID: "restart-1",
+				RepoPath:  t.TempDir(),
+				Options:   map[string]string{"provider": "agent"},
+			}); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			state, err := sup.Attach("restart-1", "client-a", 0, AttachRoleObserver)
+			if err != nil {
+				t.Fatalf("Attach: %v", err)
+			}
+
+			chunks := append([]OutputChunk(nil), state.Replay...)
+			timeout := time.After(5 * time.Second)
+			for !bytes.Contains(bytes.Join(chunkPayloads(chunks), nil), []byte(tt.want)) {
+				select {
+				case c, ok := <-state.Live:
+					if !ok {
+						t.Fatalf("live stream closed after crash; chunks=%+v", chunks)
+					}
+					chunks = append(chunks, c)
+				case <-timeout:
+					t.Fatalf("timed out waiting for the restarted agent; chunks=%+v", chunks)
+				}
+			}
+
+			var restart *OutputChunk
+			var lastSeq uint64
+			for i, c := range chunks {
+				if c.Seq <= lastSeq {
+					t.Fatalf("seq %d after %d: seqs must increase across the restart", c.Seq, lastSeq)
+				}
+				lastSeq = c.Seq
+				if c.Type == ChunkTypeSessionRestarted {
+					restart = &chunks[i]
+				}
+			}
+			if restart == nil || restart.Restart == nil {
+				t.Fatalf("no session restarted chunk in %+v", chunks)
+			}
+			if *restart.Restart != (RestartInfo{Count: 1, ExitCode: 3}) {
+				t.Fatalf("restart info=%+v", *restart.Restart)
+			}
+
+			info, err := sup.Get("restart-1")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if info.State != SessionStateRunning || info.Restarts != 1 || info.ExitRecorded {
+				t.Fatalf("info after restart=%+v", info)
+			}
+			output := string(bytes.Join(chunkPayloads(chunks), nil))
+			if tt.name == "resume" && (info.ThreadID == "" || !strings.Contains(output, "resumed thread "+info.ThreadID)) {
+				t.Fatalf("thread %q not resumed; output=%q", info.ThreadID, output)
+			}
+			if tt.name == "unknown thread" && (info.ThreadID != "" || strings.Contains(output, "resumed thread")) {
+				t.Fatalf("resumed an unknown thread %q; output=%q", info.ThreadID, output)
+			}
+
+			if err := sup.Stop("restart-1", true); err != nil {
+				t.Fatalf("Stop: %v", err)
+			}
+			waitForStopped(t, sup, "restart-1")
+		})
+	}
+}
+
+func TestSupervisorRestartLimit(t *testing.T) {
+	sup := newRestartSupervisor(t, &scriptProvider{
+		testProvider: testProvider{id: "agent"},
+		script:       `echo crash; exit 1`,
+	}, RestartPolicy{MaxRestarts: 2})
+	if _, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "proj",
+		SessionID: "restart-limit",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "agent"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitForStopped(t, sup, "restart-limit")
+
+	info, err := sup.Get("restart-limit")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if info.State != SessionStateFailed || info.Restarts != 2 || info.ExitCode != 1 {
+		t.Fatalf("info=%+v want failed after 2 restarts", info)
+	}
+}
+
+func TestSupervisorStopDuringRestartBackoff(t *testing.T) {
+	sup := newRestartSupervisor(t, &scriptProvider{
+		testProvider: testProvider{id: "agent"},
+		script:       `exit 1`,
+	}, RestartPolicy{MaxRestarts: 1, Backoff: time.Hour})
+	if _, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "proj",
+		SessionID: "restart-stop",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "agent"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	// Wait until the crash has been counted and the restart is pending.
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		sup.mu.RLock()
+		ms := sup.sessions["restart-stop"]
+		sup.mu.RUnlock()
+		ms.mu.Lock()
+		pending := ms.restarts == 1
+		ms.mu.Unlock()
+		if pending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the crash")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := sup.Stop("restart-stop", false); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	waitForStopped(t, sup, "restart-stop")
+	info, err := sup.Get("restart-stop")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if info.State != SessionStateStopped || info.Restarts != 0 {
+		t.Fatalf("info=%+v want stopped without restarting", info)
+	}
+}
+
+func TestSupervisorRecordsStreamJSONThread(t *testing.T) {
+	sup := newRestartSupervisor(t, &streamJSONScriptProvider{scriptProvider{
+		testProvider: testProvider{id: "agent"},
+		script:       `printf '{"type":"system","subtype":"init","session_id":"thread-7"}\n'; while :; do sleep 0.1; done`,
+	}}, RestartPolicy{})
+	if _, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "proj",
+		SessionID: "thread-1",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "agent"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		info, err := sup.Get("thread-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if info.ThreadID == "thread-7" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ThreadID=%q want thread-7", info.ThreadID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
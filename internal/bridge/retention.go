@@ -0,0 +1,112 @@
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Retention overrides how long a project's stopped sessions are kept. Zero
+// fields use the supervisor's; see WithArchive and WithArchiveRetention.
+type Retention struct {
+	// EventBuffer is how long a stopped session's output stays in memory
+	// before it is archived.
+	EventBuffer time.Duration
+	// Transcripts is how long archived transcripts are kept. Negative
+	// keeps them forever.
+	Transcripts time.Duration
+}
+
+// retention returns the archive TTL and the archive retention of
+// projectID's sessions. A zero retention keeps archives forever.
+func (s *Supervisor) retention(policy *Policy, projectID string) (ttl, keep time.Duration) {
+	ttl, keep = s.archiveTTL, s.archiveRetention
+	r := policy.Projects[projectID].Retention
+	if r.EventBuffer > 0 {
+		ttl = r.EventBuffer
+	}
+	if r.Transcripts != 0 {
+		keep = max(r.Transcripts, 0)
+	}
+	return ttl, keep
+}
+
+// prunesArchive reports whether any archived session can expire.
+func (s *Supervisor) prunesArchive(policy *Policy) bool {
+	if s.archiveRetention > 0 {
+		return true
+	}
+	for _, p := range policy.Projects {
+		if p.Retention.Transcripts > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Purge deletes everything the supervisor keeps about a stopped session:
+// its buffered output and spill segment, archived transcript, persisted
+// record and chunks, provider logs, schedule transcripts, cloned workspace
+// and snapshot ref. It
+// is meant for deletion requests; the session is gone from Get, List and
+// History afterwards. Running sessions are refused with ErrSessionRunning.
+func (s *Supervisor) Purge(sessionID string) error {
+	if sessionID == "" {
+		return fmt.Errorf("%w: session_id is required", ErrInvalidArgument)
+	}
+	s.mu.Lock()
+	ms, live := s.sessions[sessionID]
+	if live {
+		ms.mu.Lock()
+		if ms.info.State != SessionStateStopped && ms.info.State != SessionStateFailed {
+			ms.mu.Unlock()
+			s.mu.Unlock()
+			return fmt.Errorf("%w: stop session %q before purging it", ErrSessionRunning, sessionID)
+		}
+		// Attach treats an archived session as removed.
+		ms.archived = true
+		ms.mu.Unlock()
+		delete(s.sessions, sessionID)
+	}
+	s.mu.Unlock()
+
+	s.histMu.Lock()
+	_, known := s.history[sessionID]
+	delete(s.history, sessionID)
+	s.histMu.Unlock()
+
+	var errs []error
+	if live {
+		s.dropSnapshot(ms)
+		s.removeWorkspace(ms)
+		if err := ms.buf.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("remove spill segment: %w", err))
+		}
+	}
+	if s.archive != nil {
+		err := s.archive.Delete(sessionID)
+		switch {
+		case err == nil:
+			known = true
+		case !errors.Is(err, ErrSessionNotFound):
+			errs = append(errs, err)
+		}
+	}
+	if s.store != nil {
+		if err := s.store.Delete(sessionID); err != nil {
+			errs = append(errs, fmt.Errorf("delete persisted session %q: %w", sessionID, err))
+		}
+	}
+	s.removeProviderLogs(sessionID)
+	if err := s.removeScheduleTranscripts(sessionID); err != nil {
+		errs = append(errs, err)
+	}
+	if !live && !known && len(errs) == 0 {
+		return fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	logger().Info("session purged", "session_id", sessionID)
+	return nil
+}
@@ -0,0 +1,203 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSupervisorProjectRetention(t *testing.T) {
+	dir := t.TempDir()
+	archive, err := NewFileArchive(dir)
+	if err != nil {
+		t.Fatalf("NewFileArchive: %v", err)
+	}
+	policy := DefaultPolicy()
+	policy.Projects = map[string]ProjectPolicy{
+		"short":   {Retention: Retention{Transcripts: time.Hour}},
+		"forever": {Retention: Retention{Transcripts: -1}},
+	}
+	scheduleDir := t.TempDir()
+	sup := NewSupervisor(NewRegistry(), policy, 1024, time.Minute,
+		WithArchive(archive, time.Minute), WithArchiveRetention(24*time.Hour), WithScheduleTranscripts(scheduleDir))
+	t.Cleanup(sup.Close)
+	transcript := func(id string) string {
+		return filepath.Join(scheduleDir, "nightly", "20260101T030000Z-"+id+".md")
+	}
+	if err := os.MkdirAll(filepath.Join(scheduleDir, "nightly"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	age := 2 * time.Hour
+	for id, project := range map[string]string{"s-short": "short", "s-default": "other", "s-forever": "forever"} {
+		info := SessionInfo{SessionID: id, ProjectID: project, State: SessionStateStopped}
+		if err := archive.Save(ArchivedSession{Info: info, ArchivedAt: now.Add(-age)}); err != nil {
+			t.Fatalf("Save %s: %v", id, err)
+		}
+		if err := os.Chtimes(filepath.Join(dir, id+".json"), now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+		if err := os.WriteFile(transcript(id), []byte("# run"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		sup.histMu.Lock()
+		sup.history[id] = info
+		sup.histMu.Unlock()
+	}
+
+	sup.archiveExpired(now)
+	if _, err := sup.History("s-short"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("History(s-short) error=%v want %v", err, ErrSessionNotFound)
+	}
+	if _, err := sup.History("s-default"); err != nil {
+		t.Fatalf("History(s-default): %v", err)
+	}
+	// Schedule transcripts go with the archived transcript.
+	if _, err := os.Stat(transcript("s-short")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("schedule transcript of s-short: %v", err)
+	}
+	if _, err := os.Stat(transcript("s-default")); err != nil {
+		t.Fatalf("schedule transcript of s-default: %v", err)
+	}
+
+	sup.archiveExpired(now.Add(48 * time.Hour))
+	if _, err := sup.History("s-default"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("History(s-default) after 48h error=%v want %v", err, ErrSessionNotFound)
+	}
+	if _, err := sup.History("s-forever"); err != nil {
+		t.Fatalf("History(s-forever): %v", err)
+	}
+}
+
+func TestSupervisorProjectEventBuffer(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&testProvider{id: "fake"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	archive, err := NewFileArchive(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileArchive: %v", err)
+	}
+	policy := DefaultPolicy()
+	policy.Projects = map[string]ProjectPolicy{"brief": {Retention: Retention{EventBuffer: time.Second}}}
+	sup := NewSupervisor(registry, policy, 1024, time.Minute, WithArchive(archive, time.Hour))
+	t.Cleanup(sup.Close)
+
+	for _, project := range []string{"brief", "other"} {
+		if _, err := sup.Start(context.Background(), SessionConfig{
+			ProjectID: project,
+			SessionID: project + "-1",
+			RepoPath:  t.TempDir(),
+			Options:   map[string]string{"provider": "fake"},
+		}); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		if err := sup.Stop(project+"-1", true); err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+		waitForStopped(t, sup, project+"-1")
+	}
+
+	sup.archiveExpired(time.Now().Add(time.Minute))
+	sup.mu.RLock()
+	_, briefLive := sup.sessions["brief-1"]
+	_, otherLive := sup.sessions["other-1"]
+	sup.mu.RUnlock()
+	if briefLive || !otherLive {
+		t.Fatalf("in memory after a minute: brief=%v other=%v want false, true", briefLive, otherLive)
+	}
+}
+
+func TestSupervisorPurge(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&testProvider{id: "fake"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	archive, err := NewFileArchive(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileArchive: %v", err)
+	}
+	store, err := NewBoltSessionStore(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatalf("NewBoltSessionStore: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	scheduleDir := t.TempDir()
+	sup := NewSupervisor(registry, DefaultPolicy(), 1024, time.Minute,
+		WithArchive(archive, time.Minute), WithStore(store), WithScheduleTranscripts(scheduleDir))
+	t.Cleanup(sup.Close)
+	if err := os.MkdirAll(filepath.Join(scheduleDir, "audit"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	transcript := func(id string) string {
+		return filepath.Join(scheduleDir, "audit", "20260101T030000Z-"+id+".md")
+	}
+	other := transcript("other-1")
+	for _, path := range []string{transcript("live-1"), transcript("archived-1"), other} {
+		if err := os.WriteFile(path, []byte("# run"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, id := range []string{"live-1", "archived-1"} {
+		if _, err := sup.Start(context.Background(), SessionConfig{
+			ProjectID: "proj-a",
+			SessionID: id,
+			RepoPath:  t.TempDir(),
+			Options:   map[string]string{"provider": "fake"},
+		}); err != nil {
+			t.Fatalf("Start %s: %v", id, err)
+		}
+	}
+	if err := sup.Purge("live-1"); !errors.Is(err, ErrSessionRunning) {
+		t.Fatalf("Purge running error=%v want %v", err, ErrSessionRunning)
+	}
+	stop := func(id string) {
+		t.Helper()
+		if err := sup.Stop(id, true); err != nil {
+			t.Fatalf("Stop %s: %v", id, err)
+		}
+		waitForStopped(t, sup, id)
+	}
+	// archived-1 leaves memory for the archive while live-1 still runs.
+	stop("archived-1")
+	sup.archiveExpired(time.Now().Add(2 * time.Minute))
+	if _, err := archive.Load("archived-1"); err != nil {
+		t.Fatalf("archive.Load: %v", err)
+	}
+	stop("live-1")
+
+	for _, id := range []string{"live-1", "archived-1"} {
+		if err := sup.Purge(id); err != nil {
+			t.Fatalf("Purge %s: %v", id, err)
+		}
+		if _, err := sup.Get(id); !errors.Is(err, ErrSessionNotFound) {
+			t.Fatalf("Get(%s) after Purge error=%v want %v", id, err, ErrSessionNotFound)
+		}
+		if _, err := sup.History(id); !errors.Is(err, ErrSessionNotFound) {
+			t.Fatalf("History(%s) after Purge error=%v want %v", id, err, ErrSessionNotFound)
+		}
+		if _, err := archive.Load(id); !errors.Is(err, ErrSessionNotFound) {
+			t.Fatalf("archive.Load(%s) after Purge error=%v want %v", id, err, ErrSessionNotFound)
+		}
+		if chunks, _ := store.LoadChunks(id); len(chunks) != 0 {
+			t.Fatalf("store chunks of %s after Purge: %d", id, len(chunks))
+		}
+		if _, err := os.Stat(transcript(id)); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("schedule transcript of %s after Purge: %v", id, err)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("another session's schedule transcript: %v", err)
+	}
+	if infos, _ := store.LoadAll(); len(infos) != 0 {
+		t.Fatalf("store after Purge=%+v want empty", infos)
+	}
+	if err := sup.Purge("live-1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("second Purge error=%v want %v", err, ErrSessionNotFound)
+	}
+}
@@ -0,0 +1,315 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultScheduleTimeout bounds a scheduled run whose job sets no timeout.
+const DefaultScheduleTimeout = 30 * time.Minute
+
+// ScheduledJob starts a session for each of its repos whenever Cron fires,
+// sends Prompt, waits for the agent to answer, stops the session, and saves
+// the transcript.
+type ScheduledJob struct {
+	Name      string
+	Cron      *CronSchedule
+	ProjectID string
+	Provider  string
+	RepoPaths []string
+	Prompt    string
+	// Timeout bounds each repo's run, from start until the agent completes
+	// its response. Zero uses DefaultScheduleTimeout.
+	Timeout time.Duration
+}
+
+// ScheduledRun is the outcome of one repo's run of a job.
+type ScheduledRun struct {
+	Job            string
+	SessionID      string
+	RepoPath       string
+	StartedAt      time.Time
+	TranscriptPath string
+	// TimedOut is set when the agent had not completed its response within
+	// the job's timeout; the transcript holds whatever it produced.
+	TimedOut bool
+	Err      error
+}
+
+// Scheduler runs ScheduledJobs on their cron schedules against a
+// Supervisor. Transcripts are written as Markdown to
+// <dir>/<job>/<start time>-<session id>.md.
+type Scheduler struct {
+	sup *Supervisor
+	dir string
+
+	mu      sync.Mutex
+	jobs    []ScheduledJob
+	running map[string]bool
+	changed chan struct{}
+	now     func() time.Time
+}
+
+// NewScheduler returns a scheduler that writes transcripts under dir. It
+// runs nothing until Run is called.
+func NewScheduler(sup *Supervisor, dir string) *Scheduler {
+	return &Scheduler{
+		sup:     sup,
+		dir:     dir,
+		running: make(map[string]bool),
+		changed: make(chan struct{}, 1),
+		now:     time.Now,
+	}
+}
+
+// SetJobs replaces the scheduled jobs. Runs already in progress finish.
+func (s *Scheduler) SetJobs(jobs []ScheduledJob) {
+	s.mu.Lock()
+	s.jobs = append([]ScheduledJob(nil), jobs...)
+	s.mu.Unlock()
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Jobs returns the scheduled jobs.
+func (s *Scheduler) Jobs() []ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ScheduledJob(nil), s.jobs...)
+}
+
+// Run fires jobs as their schedules come due until ctx is cancelled. A job
+// whose previous run is still going when it comes due again is skipped for
+// that occurrence.
+func (s *Scheduler) Run(ctx context.Context) {
+	var last time.Time
+	for {
+		now := s.now()
+		if now.Before(last) {
+			now = last
+		}
+		var next time.Time
+		for _, job := range s.Jobs() {
+			if t := job.Cron.Next(now); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+		var fire <-chan time.Time
+		var timer *time.Timer
+		if !next.IsZero() {
+			timer = time.NewTimer(next.Sub(now))
+			fire = timer.C
+		}
+		fired := false
+		select {
+		case <-ctx.Done():
+		case <-s.changed:
+		case <-fire:
+			fired = true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if !fired {
+			continue
+		}
+		last = next
+		for _, job := range s.Jobs() {
+			if !job.Cron.Next(now).Equal(next) {
+				continue
+			}
+			if !s.begin(job.Name) {
+				logger().Warn("scheduled job still running; skipping", "job", job.Name, "due", next)
+				continue
+			}
+			go func(job ScheduledJob) {
+				defer s.end(job.Name)
+				s.RunJob(ctx, job)
+			}(job)
+		}
+	}
+}
+
+func (s *Scheduler) begin(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+func (s *Scheduler) end(name string) {
+	s.mu.Lock()
+	delete(s.running, name)
+	s.mu.Unlock()
+}
+
+// RunJob runs job once against each of its repos in turn and returns the
+// outcome of each run.
+func (s *Scheduler) RunJob(ctx context.Context, job ScheduledJob) []ScheduledRun {
+	runs := make([]ScheduledRun, 0, len(job.RepoPaths))
+	for _, repo := range job.RepoPaths {
+		if ctx.Err() != nil {
+			break
+		}
+		run := s.runRepo(ctx, job, repo)
+		if run.Err != nil {
+			logger().Warn("scheduled run failed", "job", job.Name, "repo_path", repo, "session_id", run.SessionID, "error", run.Err)
+		} else {
+			logger().Info("scheduled run finished", "job", job.Name, "repo_path", repo, "session_id", run.SessionID, "timed_out", run.TimedOut, "transcript", run.TranscriptPath)
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+func (s *Scheduler) runRepo(ctx context.Context, job ScheduledJob, repo string) ScheduledRun {
+	run := ScheduledRun{Job: job.Name, SessionID: uuid.NewString(), RepoPath: repo, StartedAt: s.now().UTC()}
+	timeout := job.Timeout
+	if timeout <= 0 {
+		timeout = DefaultScheduleTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if _, err := s.sup.Start(ctx, SessionConfig{
+		ProjectID:   job.ProjectID,
+		SessionID:   run.SessionID,
+		RepoPath:    repo,
+		Options:     map[string]string{"provider": job.Provider},
+		InitialCols: 200,
+		InitialRows: 50,
+	}); err != nil {
+		run.Err = fmt.Errorf("start session: %w", err)
+		return run
+	}
+	stopped := false
+	defer func() {
+		if !stopped {
+			_ = s.sup.Stop(run.SessionID, false)
+		}
+	}()
+
+	clientID := "scheduler-" + job.Name
+	state, err := s.sup.Attach(run.SessionID, clientID, 0, AttachRoleWriter)
+	if err != nil {
+		run.Err = fmt.Errorf("attach session: %w", err)
+		return run
+	}
+	defer func() { _ = s.sup.Detach(run.SessionID, clientID) }()
+
+	// A PTY agent submits on carriage return; a stream-JSON agent reads
+	// newline-delimited input.
+	submit := "\r"
+	if state.StreamJSON {
+		submit = "\n"
+	}
+	// Sent as a prompt, so that the repo summary goes with it.
+	if _, err := s.sup.SendInputWithAttachments(ctx, run.SessionID, clientID, []byte(job.Prompt+submit), nil); err != nil {
+		run.Err = fmt.Errorf("send prompt: %w", err)
+		return run
+	}
+
+	run.TimedOut = !waitResponse(ctx, state.Live)
+	stopped = true
+	if err := s.sup.Stop(run.SessionID, false); err != nil {
+		logger().Debug("scheduled run: stop session", "session_id", run.SessionID, "error", err)
+	}
+	run.TranscriptPath, run.Err = s.saveTranscript(job.Name, run)
+	return run
+}
+
+// waitResponse drains live until the agent completes a response or exits,
+// reporting false if ctx ends first.
+func waitResponse(ctx context.Context, live <-chan OutputChunk) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case chunk, ok := <-live:
+			if !ok || chunk.Type == ChunkTypeResponseComplete {
+				return true
+			}
+		}
+	}
+}
+
+func (s *Scheduler) saveTranscript(job string, run ScheduledRun) (string, error) {
+	h, err := s.sup.History(run.SessionID)
+	if err != nil {
+		return "", fmt.Errorf("load transcript: %w", err)
+	}
+	data, err := RenderTranscript(h, TranscriptMarkdown)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(s.dir, job)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("create transcript dir %q: %w", dir, err)
+	}
+	path := filepath.Join(dir, run.StartedAt.Format("20060102T150405Z")+"-"+run.SessionID+".md")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("write transcript: %w", err)
+	}
+	return path, nil
+}
+
+// WithScheduleTranscripts tells the supervisor that scheduled runs write
+// their transcripts under dir, as a Scheduler given dir does. A session's
+// transcripts there are deleted by Purge and along with its archive entry.
+func WithScheduleTranscripts(dir string) SupervisorOption {
+	return func(s *Supervisor) {
+		s.scheduleDir = dir
+	}
+}
+
+// removeScheduleTranscripts deletes the transcripts that scheduled runs of
+// sessionID wrote to <scheduleDir>/<job>/.
+func (s *Supervisor) removeScheduleTranscripts(sessionID string) error {
+	if s.scheduleDir == "" || sessionID == "" {
+		return nil
+	}
+	jobs, err := os.ReadDir(s.scheduleDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read schedule dir: %w", err)
+	}
+	suffix := "-" + sessionID + ".md"
+	var errs []error
+	for _, job := range jobs {
+		if !job.IsDir() {
+			continue
+		}
+		dir := filepath.Join(s.scheduleDir, job.Name())
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read schedule dir: %w", err))
+			continue
+		}
+		for _, e := range entries {
+			if e.Type().IsRegular() && strings.HasSuffix(e.Name(), suffix) {
+				if err := os.Remove(filepath.Join(dir, e.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+					errs = append(errs, fmt.Errorf("remove schedule transcript: %w", err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
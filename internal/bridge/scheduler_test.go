@@ -0,0 +1,139 @@
+package bridge
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// promptedStreamProvider is a stream-JSON agent that waits for one line of
+// input, answers it, and stays running until stopped.
+type promptedStreamProvider struct {
+	testProvider
+}
+
+func (p *promptedStreamProvider) IsStreamJSON() bool { return true }
+
+func (p *promptedStreamProvider) BuildCommand(ctx context.Context, cfg SessionConfig) (*exec.Cmd, error) {
+	script := `read -r line; ` +
+		`printf '{"type":"content_block_delta","delta":{"type":"text_delta","text":"audited: %s"}}\n' "$line"; ` +
+		`printf '{"type":"result","total_cost_usd":0.01}\n'; ` +
+		`sleep 30`
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", script)
+	cmd.Dir = cfg.RepoPath
+	return cmd, nil
+}
+
+func TestSchedulerRunJobSavesTranscripts(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&promptedStreamProvider{testProvider{id: "audit"}}); err != nil {
+		t.Fatal(err)
+	}
+	sup := NewSupervisor(registry, DefaultPolicy(), 1<<20, time.Minute)
+	t.Cleanup(sup.Close)
+
+	cron, err := ParseCron("@daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sched := NewScheduler(sup, t.TempDir())
+	job := ScheduledJob{
+		Name:      "nightly-audit",
+		Cron:      cron,
+		ProjectID: "project-test",
+		Provider:  "audit",
+		RepoPaths: []string{t.TempDir(), t.TempDir()},
+		Prompt:    "check dependencies",
+		Timeout:   10 * time.Second,
+	}
+	runs := sched.RunJob(context.Background(), job)
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	for _, run := range runs {
+		if run.Err != nil || run.TimedOut {
+			t.Fatalf("run %+v failed", run)
+		}
+		data, err := os.ReadFile(run.TranscriptPath)
+		if err != nil {
+			t.Fatalf("read transcript: %v", err)
+		}
+		if !strings.Contains(string(data), "audited: check dependencies") {
+			t.Fatalf("transcript missing agent response:\n%s", data)
+		}
+		if !strings.Contains(run.TranscriptPath, "nightly-audit") {
+			t.Fatalf("transcript path %q not grouped by job", run.TranscriptPath)
+		}
+		waitForStopped(t, sup, run.SessionID)
+	}
+}
+
+func TestSchedulerRunJobTimesOut(t *testing.T) {
+	sup := newTestSupervisor(t)
+	cron, err := ParseCron("@hourly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sched := NewScheduler(sup, t.TempDir())
+	runs := sched.RunJob(context.Background(), ScheduledJob{
+		Name:      "pty",
+		Cron:      cron,
+		ProjectID: "project-test",
+		Provider:  "fake",
+		RepoPaths: []string{t.TempDir()},
+		Prompt:    "hello",
+		Timeout:   300 * time.Millisecond,
+	})
+	if len(runs) != 1 || !runs[0].TimedOut || runs[0].Err != nil {
+		t.Fatalf("runs = %+v, want one timed-out run", runs)
+	}
+	if _, err := os.Stat(runs[0].TranscriptPath); err != nil {
+		t.Fatalf("timed-out run should still save its transcript: %v", err)
+	}
+}
+
+func TestSchedulerRunFiresDueJobs(t *testing.T) {
+	sup := newTestSupervisor(t)
+	cron, err := ParseCron("* * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transcripts := t.TempDir()
+	sched := NewScheduler(sup, transcripts)
+	// Start the clock a moment before a minute boundary so the job is due
+	// almost immediately.
+	offset := time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)) - 50*time.Millisecond
+	sched.now = func() time.Time { return time.Now().Add(offset) }
+	sched.SetJobs([]ScheduledJob{{
+		Name:      "every-minute",
+		Cron:      cron,
+		ProjectID: "project-test",
+		Provider:  "fake",
+		RepoPaths: []string{t.TempDir()},
+		Prompt:    "hello",
+		Timeout:   100 * time.Millisecond,
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sched.Run(ctx)
+
+	// Wait for the run to save its transcript so that nothing writes to the
+	// temp dir after the test returns.
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		files, _ := filepath.Glob(filepath.Join(transcripts, "every-minute", "*"))
+		if len(files) > 0 {
+			if len(sup.List("project-test")) == 0 {
+				t.Fatal("scheduler saved a transcript without starting a session")
+			}
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("scheduler did not run the due job")
+}
@@ -18,6 +18,9 @@ type SessionStore interface {
 	LoadAll() ([]SessionInfo, error)
 	SaveChunk(sessionID string, chunk OutputChunk) error
 	LoadChunks(sessionID string) ([]OutputChunk, error)
+	// Delete removes the session and its chunks. Deleting a session that
+	// is not stored is not an error.
+	Delete(sessionID string) error
 	Close() error
 }
 
@@ -94,6 +97,23 @@ func (s *BoltSessionStore) LoadChunks(sessionID string) ([]OutputChunk, error) {
 	return chunks, err
 }
 
+// Delete removes the session record and every chunk of sessionID.
+func (s *BoltSessionStore) Delete(sessionID string) error {
+	prefix := []byte(sessionID + "/")
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(sessionsBucket).Delete([]byte(sessionID)); err != nil {
+			return err
+		}
+		c := tx.Bucket(chunksBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // LoadAll returns every session record currently in the store.
 func (s *BoltSessionStore) LoadAll() ([]SessionInfo, error) {
 	var infos []SessionInfo
@@ -209,3 +209,42 @@ func TestBoltSessionStore_PersistsAcrossReopen(t *testing.T) {
 		t.Fatalf("expected durable session, got %+v", infos)
 	}
 }
+
+func TestBoltSessionStore_Delete(t *testing.T) {
+	store, err := NewBoltSessionStore(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatalf("NewBoltSessionStore: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	for _, id := range []string{"sess-1", "sess-10"} {
+		if err := store.Save(SessionInfo{SessionID: id, State: SessionStateStopped}); err != nil {
+			t.Fatalf("Save %s: %v", id, err)
+		}
+		for seq := uint64(1); seq <= 3; seq++ {
+			if err := store.SaveChunk(id, OutputChunk{Seq: seq, Payload: []byte("x")}); err != nil {
+				t.Fatalf("SaveChunk %s: %v", id, err)
+			}
+		}
+	}
+	if err := store.Delete("sess-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete("never-stored"); err != nil {
+		t.Fatalf("Delete unknown: %v", err)
+	}
+
+	infos, err := store.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if len(infos) != 1 || infos[0].SessionID != "sess-10" {
+		t.Fatalf("LoadAll after Delete=%+v want only sess-10", infos)
+	}
+	if got, _ := store.LoadChunks("sess-1"); len(got) != 0 {
+		t.Fatalf("LoadChunks(sess-1) after Delete len=%d want 0", len(got))
+	}
+	if got, _ := store.LoadChunks("sess-10"); len(got) != 3 {
+		t.Fatalf("LoadChunks(sess-10) len=%d want 3", len(got))
+	}
+}
@@ -0,0 +1,163 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// snapshotRefPrefix names the refs that keep snapshot commits alive.
+const snapshotRefPrefix = "refs/bridge/snapshots/"
+
+// WorkspaceSnapshot records a session repo's state before its agent started.
+type WorkspaceSnapshot struct {
+	// Commit holds the working tree, untracked files included, with Head as
+	// its parent.
+	Commit string
+	// Head is the commit HEAD pointed at; empty before the first commit.
+	Head string
+	// Branch is the ref HEAD was attached to; empty when detached.
+	Branch string
+	// Index is the tree of what was staged.
+	Index string
+}
+
+// takeSnapshot records the state of repo without changing the working tree,
+// the index or HEAD. Ignored files are not included.
+func takeSnapshot(ctx context.Context, repo, sessionID string) (*WorkspaceSnapshot, error) {
+	if out, err := runGit(ctx, repo, nil, "rev-parse", "--is-inside-work-tree"); err != nil || strings.TrimSpace(string(out)) != "true" {
+		return nil, fmt.Errorf("%w: %s", ErrNotGitRepo, repo)
+	}
+	snap := &WorkspaceSnapshot{}
+	if out, err := runGit(ctx, repo, nil, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		snap.Head = strings.TrimSpace(string(out))
+	}
+	if out, err := runGit(ctx, repo, nil, "symbolic-ref", "--quiet", "HEAD"); err == nil {
+		snap.Branch = strings.TrimSpace(string(out))
+	}
+	out, err := runGit(ctx, repo, nil, "write-tree")
+	if err != nil {
+		return nil, err
+	}
+	snap.Index = strings.TrimSpace(string(out))
+
+	// Stage everything into a scratch index so the real one is untouched.
+	scratch, err := os.CreateTemp("", "bridge-snapshot-index-*")
+	if err != nil {
+		return nil, fmt.Errorf("create snapshot index: %w", err)
+	}
+	scratch.Close()
+	defer os.Remove(scratch.Name())
+	env := []string{"GIT_INDEX_FILE=" + scratch.Name()}
+	if _, err := runGit(ctx, repo, env, "read-tree", snap.Index); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, repo, env, "add", "--all"); err != nil {
+		return nil, err
+	}
+	out, err = runGit(ctx, repo, env, "write-tree")
+	if err != nil {
+		return nil, err
+	}
+	tree := strings.TrimSpace(string(out))
+
+	args := []string{"commit-tree", tree, "-m", "bridge snapshot of session " + sessionID}
+	if snap.Head != "" {
+		args = append(args, "-p", snap.Head)
+	}
+	identity := []string{
+		"GIT_AUTHOR_NAME=" + DefaultGitAuthorName,
+		"GIT_AUTHOR_EMAIL=" + DefaultGitAuthorEmail,
+		"GIT_COMMITTER_NAME=" + DefaultGitAuthorName,
+		"GIT_COMMITTER_EMAIL=" + DefaultGitAuthorEmail,
+	}
+	out, err = runGit(ctx, repo, identity, args...)
+	if err != nil {
+		return nil, err
+	}
+	snap.Commit = strings.TrimSpace(string(out))
+	if _, err := runGit(ctx, repo, nil, "update-ref", snapshotRefPrefix+sessionID, snap.Commit); err != nil {
+		return nil, err
+	}
+	logger().Info("session workspace snapshot taken", "session_id", sessionID, "commit", snap.Commit, "head", snap.Head)
+	return snap, nil
+}
+
+// Rollback restores a stopped session's repo to the snapshot taken when it
+// started: HEAD, the branch it was on, the index and the working tree.
+// Files the agent created are removed; ignored files are left alone.
+func (s *Supervisor) Rollback(ctx context.Context, sessionID string) (*WorkspaceSnapshot, error) {
+	s.mu.RLock()
+	ms, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	ms.mu.Lock()
+	snap := ms.snapshot
+	state := ms.info.State
+	ms.mu.Unlock()
+	if snap == nil {
+		return nil, fmt.Errorf("%w: session %q was started without a snapshot", ErrNoSnapshot, sessionID)
+	}
+	if state != SessionStateStopped && state != SessionStateFailed {
+		return nil, fmt.Errorf("%w: stop session %q before rolling it back", ErrSessionRunning, sessionID)
+	}
+	repo, err := s.sessionGitRepo(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Move HEAD back first; the working tree is rewritten below.
+	switch {
+	case snap.Branch != "" && snap.Head != "":
+		_, err = runGit(ctx, repo, nil, "update-ref", snap.Branch, snap.Head)
+	case snap.Branch != "":
+		_, err = runGit(ctx, repo, nil, "update-ref", "-d", snap.Branch)
+	default:
+		_, err = runGit(ctx, repo, nil, "update-ref", "--no-deref", "HEAD", snap.Head)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if snap.Branch != "" {
+		if _, err := runGit(ctx, repo, nil, "symbolic-ref", "HEAD", snap.Branch); err != nil {
+			return nil, err
+		}
+	}
+	// Check out the snapshot's tree, then drop whatever it does not track,
+	// then put back what was staged.
+	if _, err := runGit(ctx, repo, nil, "read-tree", "-u", "--reset", snap.Commit+"^{tree}"); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, repo, nil, "clean", "--force", "-d", "--quiet", "--", ":/"); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, repo, nil, "read-tree", snap.Index); err != nil {
+		return nil, err
+	}
+	logger().Info("session workspace rolled back", "session_id", sessionID, "commit", snap.Commit, "head", snap.Head)
+	restored := *snap
+	return &restored, nil
+}
+
+// dropSnapshot deletes the ref that keeps the session's snapshot alive.
+func (s *Supervisor) dropSnapshot(ms *managedSession) {
+	ms.mu.Lock()
+	snap := ms.snapshot
+	ms.snapshot = nil
+	repo := ms.cfg.RepoPath
+	sessionID := ms.info.SessionID
+	ms.mu.Unlock()
+	if snap == nil {
+		return
+	}
+	// The repo may already be gone with a cloned workspace.
+	if _, err := os.Stat(repo); err != nil {
+		return
+	}
+	if _, err := runGit(context.Background(), repo, nil, "update-ref", "-d", snapshotRefPrefix+sessionID); err != nil {
+		logger().Warn("failed to delete workspace snapshot", "session_id", sessionID, "error", err)
+	}
+}
@@ -0,0 +1,109 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSupervisorSnapshotRollback(t *testing.T) {
+	sup, repo := newGitTestSupervisor(t, DefaultPolicy())
+	// Swap the placeholder session for a real one started with a snapshot.
+	sup.mu.Lock()
+	delete(sup.sessions, "files")
+	sup.mu.Unlock()
+	if err := sup.registry.Register(&testProvider{id: "fake"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// Pre-existing state: a staged edit and an untracked file.
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(repo, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("README.md", "hello\nstaged\n")
+	gitCmd(t, repo, "add", "README.md")
+	write("notes.txt", "mine\n")
+	head := gitCmd(t, repo, "rev-parse", "HEAD")
+
+	const sessionID = "66666666-6666-6666-6666-666666666666"
+	ctx := context.Background()
+	if _, err := sup.Start(ctx, SessionConfig{
+		ProjectID: "project-test",
+		SessionID: sessionID,
+		RepoPath:  repo,
+		Snapshot:  true,
+		Options:   map[string]string{"provider": "fake"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := sup.Rollback(ctx, sessionID); !errors.Is(err, ErrSessionRunning) {
+		t.Fatalf("Rollback while running err = %v, want ErrSessionRunning", err)
+	}
+
+	// What the agent does: edit, delete, add files, and commit.
+	write("README.md", "agent was here\n")
+	if err := os.Remove(filepath.Join(repo, "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+	write("src/evil.sh", "rm -rf /\n")
+	gitCmd(t, repo, "add", "--all")
+	gitCmd(t, repo, "-c", "user.name=Agent", "-c", "user.email=agent@example.com", "commit", "--quiet", "-m", "agent")
+	write("scratch.txt", "tmp\n")
+
+	if err := sup.Stop(sessionID, true); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	waitForStopped(t, sup, sessionID)
+	snap, err := sup.Rollback(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if snap.Head != head || snap.Branch != "refs/heads/main" {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+
+	if got := gitCmd(t, repo, "rev-parse", "HEAD"); got != head {
+		t.Fatalf("HEAD = %s, want %s", got, head)
+	}
+	if got := gitCmd(t, repo, "symbolic-ref", "HEAD"); got != "refs/heads/main" {
+		t.Fatalf("HEAD ref = %s", got)
+	}
+	if got := gitCmd(t, repo, "status", "--porcelain"); got != "M  README.md\n?? notes.txt" {
+		t.Fatalf("status = %q", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "README.md")); string(data) != "hello\nstaged\n" {
+		t.Fatalf("README.md = %q", data)
+	}
+	for _, gone := range []string{"src", "scratch.txt"} {
+		if _, err := os.Stat(filepath.Join(repo, gone)); !os.IsNotExist(err) {
+			t.Fatalf("%s survived the rollback: %v", gone, err)
+		}
+	}
+
+	// The snapshot ref goes away with the session.
+	archive, err := NewFileArchive(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sup.archive, sup.archiveTTL = archive, time.Minute
+	sup.archiveExpired(time.Now().Add(2 * time.Minute))
+	if refs := gitCmd(t, repo, "for-each-ref", snapshotRefPrefix); refs != "" {
+		t.Fatalf("snapshot refs left after archival: %s", refs)
+	}
+}
+
+func TestSupervisorRollbackWithoutSnapshot(t *testing.T) {
+	sup, _ := newGitTestSupervisor(t, DefaultPolicy())
+	if _, err := sup.Rollback(context.Background(), "files"); !errors.Is(err, ErrNoSnapshot) {
+		t.Fatalf("Rollback err = %v, want ErrNoSnapshot", err)
+	}
+}
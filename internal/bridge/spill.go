@@ -0,0 +1,295 @@
+package bridge
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+const (
+	// DefaultSpillMaxBytes caps the disk used by one session's spill
+	// segment when WithSpillDir is given no limit.
+	DefaultSpillMaxBytes = 256 << 20
+	// spillFiles is how many files a segment is split across. The oldest is
+	// deleted whole once the segment outgrows its cap.
+	spillFiles = 4
+	// spillIndexStride is how many bytes of records share one index entry;
+	// a read scans forward from the entry before the seq it wants.
+	spillIndexStride = 64 << 10
+	// spillPageBytes bounds the record bytes read back per replay page.
+	spillPageBytes = 1 << 20
+)
+
+// WithSpillDir makes session buffers spill chunks evicted from memory to a
+// per-session segment in dir, so that attach replay can serve sequence
+// numbers older than the in-memory buffer instead of reporting a gap. Each
+// segment is capped at maxBytes (DefaultSpillMaxBytes when maxBytes <= 0);
+// beyond that its oldest chunks are deleted and replay from before them
+// reports a gap again. The segment is removed when the session is archived.
+func WithSpillDir(dir string, maxBytes int64) SupervisorOption {
+	return func(s *Supervisor) {
+		s.spillDir = dir
+		s.spillMaxBytes = maxBytes
+	}
+}
+
+// newBuffer returns the output buffer for a new session.
+func (s *Supervisor) newBuffer(sessionID string) *ByteBuffer {
+	if s.spillDir == "" {
+		return NewByteBuffer(s.bufSize)
+	}
+	return NewSpillingByteBuffer(s.bufSize, filepath.Join(s.spillDir, url.PathEscape(sessionID)+".spill"), s.spillMaxBytes)
+}
+
+// spillSegment is an append-only log of chunks evicted from a ByteBuffer,
+// split across up to spillFiles files named <path>.<n>. Each record is a
+// 4-byte big-endian length followed by the JSON chunk sealed with
+// AES-256-GCM under a random key held only in memory, so that the files
+// cannot be read from the disk, during or after the session. Files are
+// created, replacing any stale ones, as they are first written.
+type spillSegment struct {
+	path     string
+	maxBytes int64
+	aead     cipher.AEAD  // created with the first file
+	files    []*spillFile // oldest first
+	next     int          // number of the next file to create
+	size     int64        // bytes across files
+	lastSeq  uint64
+}
+
+// spillFile is one file of a segment with a sparse index of its records.
+type spillFile struct {
+	path  string
+	f     *os.File
+	size  int64
+	index []spillEntry // the first record, then one per spillIndexStride
+}
+
+type spillEntry struct {
+	seq uint64
+	off int64
+}
+
+// write appends chunk to the segment, starting a new file when the current
+// one has its share of maxBytes and deleting the oldest file once the
+// segment is over its cap.
+func (sg *spillSegment) write(chunk OutputChunk) error {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return fmt.Errorf("marshal chunk seq=%d: %w", chunk.Seq, err)
+	}
+	cur := sg.current()
+	if cur == nil || cur.size >= sg.maxBytes/spillFiles {
+		if cur, err = sg.rotate(); err != nil {
+			return err
+		}
+	}
+	nonce := make([]byte, sg.aead.NonceSize(), sg.aead.NonceSize()+len(data)+sg.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("seal chunk seq=%d: %w", chunk.Seq, err)
+	}
+	sealed := sg.aead.Seal(nonce, nonce, data, nil)
+	rec := make([]byte, 4+len(sealed))
+	binary.BigEndian.PutUint32(rec, uint32(len(sealed)))
+	copy(rec[4:], sealed)
+	if _, err := cur.f.WriteAt(rec, cur.size); err != nil {
+		return fmt.Errorf("write chunk seq=%d: %w", chunk.Seq, err)
+	}
+	if len(cur.index) == 0 || cur.size-cur.index[len(cur.index)-1].off >= spillIndexStride {
+		cur.index = append(cur.index, spillEntry{seq: chunk.Seq, off: cur.size})
+	}
+	cur.size += int64(len(rec))
+	sg.size += int64(len(rec))
+	sg.lastSeq = chunk.Seq
+	for sg.size > sg.maxBytes && len(sg.files) > 1 {
+		old := sg.files[0]
+		sg.files = sg.files[1:]
+		sg.size -= old.size
+		if err := old.remove(); err != nil {
+			logger().Warn("output buffer: failed to remove spill file", "path", old.path, "error", err)
+		}
+	}
+	return nil
+}
+
+func (sg *spillSegment) current() *spillFile {
+	if len(sg.files) == 0 {
+		return nil
+	}
+	return sg.files[len(sg.files)-1]
+}
+
+// rotate creates the segment's next file, and the segment's key with its
+// first one.
+func (sg *spillSegment) rotate() (*spillFile, error) {
+	if sg.aead == nil {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("create spill key: %w", err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if sg.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(sg.path), 0o700); err != nil {
+		return nil, fmt.Errorf("create spill dir: %w", err)
+	}
+	path := sg.path + "." + strconv.Itoa(sg.next)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open spill segment: %w", err)
+	}
+	sg.next++
+	sf := &spillFile{path: path, f: f}
+	sg.files = append(sg.files, sf)
+	return sf, nil
+}
+
+// page reads spilled chunks with a seq greater than afterSeq, in order,
+// until maxBytes of records have been read or the segment ends. It always
+// returns at least one chunk when any follows afterSeq.
+func (sg *spillSegment) page(afterSeq uint64, maxBytes int) ([]OutputChunk, error) {
+	// The file holding afterSeq+1 is the last one starting at or before it.
+	i := sort.Search(len(sg.files), func(i int) bool { return sg.files[i].index[0].seq > afterSeq+1 })
+	if i > 0 {
+		i--
+	}
+	var out []OutputChunk
+	total := 0
+	for ; i < len(sg.files) && total < maxBytes; i++ {
+		chunks, n, err := sg.files[i].read(sg.aead, afterSeq, maxBytes-total)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunks...)
+		total += n
+	}
+	return out, nil
+}
+
+// read returns the file's chunks with a seq greater than afterSeq until
+// maxBytes of their records have been read, and the number of bytes read.
+// Records are opened with aead.
+func (sf *spillFile) read(aead cipher.AEAD, afterSeq uint64, maxBytes int) ([]OutputChunk, int, error) {
+	j := sort.Search(len(sf.index), func(j int) bool { return sf.index[j].seq > afterSeq })
+	if j > 0 {
+		j--
+	}
+	start := sf.index[j].off
+	r := bufio.NewReader(io.NewSectionReader(sf.f, start, sf.size-start))
+	var (
+		out   []OutputChunk
+		total int
+		hdr   [4]byte
+	)
+	for total < maxBytes {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, 0, fmt.Errorf("read spill segment: %w", err)
+		}
+		sealed := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+		if _, err := io.ReadFull(r, sealed); err != nil {
+			return nil, 0, fmt.Errorf("read spill segment: truncated record: %w", err)
+		}
+		if len(sealed) < aead.NonceSize() {
+			return nil, 0, errors.New("read spill segment: record too short")
+		}
+		data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+		if err != nil {
+			return nil, 0, fmt.Errorf("open spilled chunk: %w", err)
+		}
+		var chunk OutputChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return nil, 0, fmt.Errorf("unmarshal spilled chunk: %w", err)
+		}
+		if chunk.Seq <= afterSeq {
+			continue
+		}
+		out = append(out, chunk)
+		total += len(data)
+	}
+	return out, total, nil
+}
+
+func (sg *spillSegment) oldestSeq() uint64 {
+	if len(sg.files) == 0 {
+		return 0
+	}
+	return sg.files[0].index[0].seq
+}
+
+// remove closes and deletes the segment's files.
+func (sg *spillSegment) remove() error {
+	var errs []error
+	for _, sf := range sg.files {
+		errs = append(errs, sf.remove())
+	}
+	sg.files = nil
+	sg.size = 0
+	return errors.Join(errs...)
+}
+
+func (sf *spillFile) remove() error {
+	_ = sf.f.Close()
+	if err := os.Remove(sf.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// SpillReplay reads back the part of an attach replay that was spilled to
+// disk, a page at a time, so a long session is never loaded whole.
+type SpillReplay struct {
+	buf  *ByteBuffer
+	last uint64 // seq of the last chunk returned
+	end  uint64 // first seq replayed from memory instead
+}
+
+// Next returns the next page of spilled chunks, or nil once the replay has
+// reached the chunks held in memory. gap is set when chunks between the
+// previous page and this one were deleted from disk before they could be
+// read, or could not be read at all.
+func (r *SpillReplay) Next() (chunks []OutputChunk, gap bool) {
+	if r.last+1 >= r.end {
+		return nil, false
+	}
+	r.buf.mu.RLock()
+	if sg := r.buf.spill; sg != nil {
+		var err error
+		if chunks, err = sg.page(r.last, spillPageBytes); err != nil {
+			logger().Warn("output buffer: failed to read spilled chunks", "path", sg.path, "error", err)
+		}
+	}
+	r.buf.mu.RUnlock()
+	for i, c := range chunks {
+		if c.Seq >= r.end {
+			chunks = chunks[:i]
+			break
+		}
+	}
+	if len(chunks) == 0 {
+		// Nothing left on disk before memory: skip to it.
+		r.last = r.end - 1
+		return nil, true
+	}
+	gap = chunks[0].Seq > r.last+1
+	r.last = chunks[len(chunks)-1].Seq
+	return chunks, gap
+}
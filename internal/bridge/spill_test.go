@@ -0,0 +1,223 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// replayAll collects a buffer's replay after afterSeq, recording the seq
+// each gap resumes from.
+func replayAll(t *testing.T, buf *ByteBuffer, afterSeq uint64) (chunks []OutputChunk, gaps []uint64) {
+	t.Helper()
+	spilled, memory := buf.Replay(afterSeq)
+	state := &AttachState{Replay: memory, Spilled: spilled}
+	err := state.EachReplay(func(c OutputChunk) error {
+		chunks = append(chunks, c)
+		return nil
+	}, func(oldest uint64) error {
+		gaps = append(gaps, oldest)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachReplay: %v", err)
+	}
+	return chunks, gaps
+}
+
+func TestSpillSegmentIsEncrypted(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spill")
+	buf := NewSpillingByteBuffer(16, filepath.Join(dir, "s1.spill"), 0)
+	defer func() { _ = buf.Close() }()
+	for i := range 4 {
+		buf.Append([]byte(fmt.Sprintf("secret output %d", i)))
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "s1.spill.*"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("spill files=%v err=%v", files, err)
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("secret output")) || bytes.Contains(data, []byte(`"Seq"`)) {
+			t.Fatalf("%s holds plaintext: %q", f, data)
+		}
+	}
+	items, gaps := replayAll(t, buf, 0)
+	if len(items) != 4 || len(gaps) != 0 || string(items[0].Payload) != "secret output 0" {
+		t.Fatalf("replay=%+v gaps=%v", items, gaps)
+	}
+}
+
+func TestSpillingByteBufferServesEvictedChunks(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spill")
+	buf := NewSpillingByteBuffer(5, filepath.Join(dir, "s1.spill"), 0)
+	for i := range 10 {
+		buf.AppendStderr([]byte(fmt.Sprintf("c%d", i)), SeverityError)
+	}
+
+	if got := buf.OldestSeq(); got != 1 {
+		t.Fatalf("OldestSeq=%d want 1", got)
+	}
+	if got := buf.LastSeq(); got != 10 {
+		t.Fatalf("LastSeq=%d want 10", got)
+	}
+	if got := buf.memoryOldestSeq(); got != 9 {
+		t.Fatalf("memoryOldestSeq=%d want 9", got)
+	}
+	if got := buf.After(3); len(got) != 2 {
+		t.Fatalf("After(3) len=%d want the 2 chunks in memory", len(got))
+	}
+	items, gaps := replayAll(t, buf, 3)
+	if len(items) != 7 || len(gaps) != 0 {
+		t.Fatalf("replay after 3: %d chunks, gaps %v; want 7 and none", len(items), gaps)
+	}
+	for i, c := range items {
+		want := fmt.Sprintf("c%d", i+3)
+		if c.Seq != uint64(i+4) || string(c.Payload) != want || c.Type != ChunkTypeStderr || c.Severity != SeverityError {
+			t.Fatalf("item %d = %+v want seq %d payload %q", i, c, i+4, want)
+		}
+	}
+
+	if err := buf.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Fatalf("spill files still present after Close: %v", files)
+	}
+	if got := buf.OldestSeq(); got != 9 {
+		t.Fatalf("OldestSeq after Close=%d want 9", got)
+	}
+}
+
+func TestSpillReplayPages(t *testing.T) {
+	buf := NewSpillingByteBuffer(1, filepath.Join(t.TempDir(), "s1.spill"), 0)
+	payload := bytes.Repeat([]byte("x"), 64<<10)
+	for range 40 {
+		buf.Append(payload)
+	}
+	spilled, memory := buf.Replay(0)
+	// Every chunk outgrows the 1-byte buffer, so all of them are spilled.
+	if spilled == nil || len(memory) != 0 {
+		t.Fatalf("Replay(0) = %v, %d in memory; want a spill replay and none", spilled, len(memory))
+	}
+	var seq uint64
+	for {
+		page, gap := spilled.Next()
+		if gap {
+			t.Fatal("unexpected gap")
+		}
+		if len(page) == 0 {
+			break
+		}
+		if n := len(page) * len(payload); n > spillPageBytes+len(payload)*2 {
+			t.Fatalf("page of %d bytes exceeds the page bound", n)
+		}
+		for _, c := range page {
+			if c.Seq != seq+1 {
+				t.Fatalf("seq %d follows %d", c.Seq, seq)
+			}
+			seq = c.Seq
+		}
+	}
+	if seq != 40 {
+		t.Fatalf("spilled replay ended at %d want 40", seq)
+	}
+}
+
+func TestSpillingByteBufferCapsDisk(t *testing.T) {
+	dir := t.TempDir()
+	const maxBytes = 4 << 10
+	buf := NewSpillingByteBuffer(1, filepath.Join(dir, "s1.spill"), maxBytes)
+	defer buf.Close()
+	payload := bytes.Repeat([]byte("y"), 100)
+	for range 200 {
+		buf.Append(payload)
+	}
+	var size int64
+	files, _ := filepath.Glob(filepath.Join(dir, "s1.spill.*"))
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size += fi.Size()
+	}
+	if size > maxBytes+maxBytes/spillFiles {
+		t.Fatalf("spill files hold %d bytes, cap %d", size, maxBytes)
+	}
+	oldest := buf.OldestSeq()
+	if oldest <= 1 {
+		t.Fatalf("OldestSeq=%d, want old chunks deleted", oldest)
+	}
+
+	// Resuming from before the deleted chunks replays what is left; the
+	// caller reports the gap from OldestSeq.
+	items, gaps := replayAll(t, buf, 1)
+	if len(gaps) != 0 || len(items) == 0 || items[0].Seq != oldest || items[len(items)-1].Seq != 200 {
+		t.Fatalf("replay after 1: %d chunks from %d, gaps %v; want %d..200", len(items), items[0].Seq, gaps, oldest)
+	}
+
+	// Chunks deleted before a replay reaches them show up as a gap.
+	spilled, _ := buf.Replay(oldest - 1)
+	for range 200 {
+		buf.Append(payload)
+	}
+	if page, gap := spilled.Next(); !gap || len(page) != 0 {
+		t.Fatalf("after deletion: %d chunks, gap %v; want a gap to memory", len(page), gap)
+	}
+}
+
+func TestSupervisorSpillDirReplaysWholeSession(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&scriptProvider{
+		testProvider: testProvider{id: "agent"},
+		script:       `i=0; while [ $i -lt 200 ]; do echo "line $i"; i=$((i+1)); done`,
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	dir := t.TempDir()
+	sup := NewSupervisor(registry, DefaultPolicy(), 64, time.Minute, WithSpillDir(dir, 0))
+	defer sup.Close()
+	if _, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "proj",
+		SessionID: "spill-1",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "agent"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitForStopped(t, sup, "spill-1")
+
+	state, err := sup.Attach("spill-1", "client-a", 0, AttachRoleObserver)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if state.ReplayGap {
+		t.Fatal("replay gap with spilling enabled")
+	}
+	if len(state.Replay) >= 200 {
+		t.Fatalf("Replay holds %d chunks, want only the in-memory ones", len(state.Replay))
+	}
+	var out []byte
+	if err := state.EachReplay(func(c OutputChunk) error {
+		out = append(out, c.Payload...)
+		return nil
+	}, nil); err != nil {
+		t.Fatalf("EachReplay: %v", err)
+	}
+	for _, want := range []string{"line 0\r\n", "line 199\r\n"} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Fatalf("replay missing %q", want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "spill-1.spill.0")); err != nil {
+		t.Fatalf("spill segment: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package bridge
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+// Severity classifies a stderr line reported by a provider. Values match
+// bridge.v1.Severity.
+type Severity uint8
+
+const (
+	SeverityUnspecified Severity = 0
+	// SeverityProgress is status noise such as spinners and download progress.
+	SeverityProgress Severity = 1
+	// SeverityWarning is the default for stderr lines matching no rule.
+	SeverityWarning Severity = 2
+	// SeverityError marks a line reporting a failure.
+	SeverityError Severity = 3
+)
+
+// ParseSeverity converts a config severity name ("progress", "warning" or
+// "error") to a Severity.
+func ParseSeverity(name string) (Severity, error) {
+	switch name {
+	case "progress":
+		return SeverityProgress, nil
+	case "warning":
+		return SeverityWarning, nil
+	case "error":
+		return SeverityError, nil
+	default:
+		return SeverityUnspecified, fmt.Errorf("%w: unknown severity %q", ErrInvalidArgument, name)
+	}
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityProgress:
+		return "progress"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unspecified"
+	}
+}
+
+// StderrRule assigns Severity to stderr lines matching Pattern.
+type StderrRule struct {
+	Pattern  *regexp.Regexp
+	Severity Severity
+}
+
+// ClassifyStderr returns the severity of the first rule matching line, or
+// SeverityWarning when none match.
+func ClassifyStderr(rules []StderrRule, line []byte) Severity {
+	for _, rule := range rules {
+		if rule.Pattern.Match(line) {
+			return rule.Severity
+		}
+	}
+	return SeverityWarning
+}
+
+// maxStderrLine bounds a single stderr chunk; longer lines are split.
+const maxStderrLine = 64 * 1024
+
+// readLoopStderr reads a stream-JSON provider's stderr line by line and
+// appends each non-empty line as a classified ChunkTypeStderr chunk.
+func (s *Supervisor) readLoopStderr(ms *managedSession, r io.ReadCloser, rules []StderrRule) {
+	defer func() { _ = r.Close() }()
+	reader := bufio.NewReaderSize(r, maxStderrLine)
+	for {
+		line, partial, err := reader.ReadLine()
+		if len(line) > 0 {
+			raw := line
+			if !partial {
+				raw = append(line[:len(line):len(line)], '\n')
+			}
+			ms.plog.write(providerLogStderr, raw)
+		}
+		if line = ansiEscape.ReplaceAll(line, nil); len(line) > 0 {
+			s.appendStderr(ms, line, partial, rules)
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, os.ErrClosed) {
+				logger().Warn("session stderr read error", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "error", err)
+			}
+			return
+		}
+	}
+}
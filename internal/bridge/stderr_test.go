@@ -0,0 +1,117 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestClassifyStderr(t *testing.T) {
+	rules := []StderrRule{
+		{Pattern: regexp.MustCompile(`(?i)^error\b`), Severity: SeverityError},
+		{Pattern: regexp.MustCompile(`\d+%`), Severity: SeverityProgress},
+		{Pattern: regexp.MustCompile(`.*`), Severity: SeverityWarning},
+	}
+	tests := []struct {
+		name  string
+		rules []StderrRule
+		line  string
+		want  Severity
+	}{
+		{name: "error rule", rules: rules, line: "Error: rate limited", want: SeverityError},
+		{name: "progress rule", rules: rules, line: "downloading 42%", want: SeverityProgress},
+		{name: "first match wins", rules: rules, line: "error at 50%", want: SeverityError},
+		{name: "catch-all", rules: rules, line: "deprecated flag", want: SeverityWarning},
+		{name: "no rules", line: "anything", want: SeverityWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyStderr(tt.rules, []byte(tt.line)); got != tt.want {
+				t.Fatalf("ClassifyStderr(%q)=%v want %v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, want := range []Severity{SeverityProgress, SeverityWarning, SeverityError} {
+		got, err := ParseSeverity(want.String())
+		if err != nil || got != want {
+			t.Fatalf("ParseSeverity(%q)=%v, %v want %v", want.String(), got, err, want)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("ParseSeverity(fatal) error=%v want %v", err, ErrInvalidArgument)
+	}
+}
+
+// stderrTestProvider is a stream-JSON provider whose command writes a JSON
+// line to stdout and two lines to stderr.
+type stderrTestProvider struct {
+	streamJSONTestProvider
+}
+
+func (p *stderrTestProvider) StderrRules() []StderrRule {
+	return []StderrRule{{Pattern: regexp.MustCompile(`^fatal:`), Severity: SeverityError}}
+}
+
+func (p *stderrTestProvider) BuildCommand(ctx context.Context, cfg SessionConfig) (*exec.Cmd, error) {
+	script := `printf '%s\n' '{"type":"content_block_delta","delta":{"type":"text_delta","text":"ok"}}'; ` +
+		`printf 'retrying request\nfatal: quota exceeded\n' >&2`
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", script)
+	cmd.Dir = cfg.RepoPath
+	return cmd, nil
+}
+
+func TestStreamJSONSessionClassifiesStderr(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&stderrTestProvider{streamJSONTestProvider{testProvider: testProvider{id: "stderr-fake"}}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	sup := NewSupervisor(registry, DefaultPolicy(), 64*1024, time.Minute)
+	t.Cleanup(sup.Close)
+
+	if _, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "proj-stderr",
+		SessionID: "stderr-1",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "stderr-fake"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	state, err := sup.Attach("stderr-1", "client-a", 0, AttachRoleObserver)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	// The live channel closes only after both stdout and stderr are drained.
+	chunks := append([]OutputChunk(nil), state.Replay...)
+	timeout := time.After(5 * time.Second)
+drainLoop:
+	for {
+		select {
+		case c, ok := <-state.Live:
+			if !ok {
+				break drainLoop
+			}
+			chunks = append(chunks, c)
+		case <-timeout:
+			t.Fatal("timed out waiting for session output to close")
+		}
+	}
+
+	got := map[string]Severity{}
+	for _, c := range chunks {
+		if c.Type == ChunkTypeStderr {
+			got[string(c.Payload)] = c.Severity
+		}
+	}
+	if got["retrying request"] != SeverityWarning || got["fatal: quota exceeded"] != SeverityError {
+		t.Fatalf("stderr chunks=%v want warning + error", got)
+	}
+	if !containsPayload(chunks, "ok") {
+		t.Fatalf("stdout chunk missing: %+v", chunks)
+	}
+}
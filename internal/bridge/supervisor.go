@@ -12,14 +12,27 @@ import (
 	"maps"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"sync"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
-	"github.com/creack/pty"
+	"github.com/google/uuid"
+
+	"github.com/markcallen/ai-agent-bridge/internal/agentproc"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"github.com/markcallen/ai-agent-bridge/internal/redact"
 )
 
+// logger is the default logger tagged as the supervisor component, so that
+// its level can be set apart from the rest of the daemon's.
+func logger() *slog.Logger {
+	return slog.Default().With(logging.ComponentKey, logging.ComponentSupervisor)
+}
+
 // ansiEscape matches ANSI/VT100 escape sequences (CSI sequences and 2-char
 // escape sequences) so they can be stripped from PTY output when needed.
 var ansiEscape = regexp.MustCompile(`\x1b(?:\[[0-9;?=<>]*[a-zA-Z~]|[@-Z\x5c-_])`)
@@ -39,9 +52,13 @@ const (
 // AttachState is returned by Supervisor.Attach and holds the replay buffer,
 // live output channel, and session metadata for the attaching client.
 type AttachState struct {
-	ClientID     string
-	Role         AttachRole
+	ClientID string
+	Role     AttachRole
+	// Replay holds the replayed chunks still in memory. Older ones that
+	// were spilled to disk come first, read through Spilled; EachReplay
+	// walks both.
 	Replay       []OutputChunk
+	Spilled      *SpillReplay
 	Live         <-chan OutputChunk
 	ReplayGap    bool
 	OldestSeq    uint64
@@ -50,6 +67,42 @@ type AttachState struct {
 	ExitCode     int
 	Cols         uint32
 	Rows         uint32
+	// StreamJSON is set when the session runs without a PTY, so its output
+	// is not terminal bytes.
+	StreamJSON bool
+}
+
+// EachReplay calls fn with every replayed chunk in seq order, reading
+// spilled chunks back from disk a page at a time. When spilled chunks were
+// deleted before they could be read, onGap, if set, is called with the seq
+// replay resumes from.
+func (st *AttachState) EachReplay(fn func(OutputChunk) error, onGap func(oldest uint64) error) error {
+	for st.Spilled != nil {
+		page, gap := st.Spilled.Next()
+		if gap && onGap != nil {
+			oldest := st.Spilled.end
+			if len(page) > 0 {
+				oldest = page[0].Seq
+			}
+			if err := onGap(oldest); err != nil {
+				return err
+			}
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, c := range page {
+			if err := fn(c); err != nil {
+				return err
+			}
+		}
+	}
+	for _, c := range st.Replay {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // observerEntry holds the live channel for a single attached client.
@@ -69,9 +122,20 @@ func WithStore(store SessionStore) SupervisorOption {
 	}
 }
 
+// WithRedactor scrubs agent output and the recorded input log with r before
+// they are buffered, so replays, persisted chunks, archives, transcripts
+// and provider logs never hold the redacted values. Input written to the
+// agent is unchanged.
+func WithRedactor(r *redact.Redactor) SupervisorOption {
+	return func(s *Supervisor) {
+		s.redactor = r
+	}
+}
+
 // Supervisor manages the lifecycle of PTY-backed provider sessions.
 type Supervisor struct {
 	registry        *Registry
+	policyMu        sync.RWMutex
 	policy          Policy
 	bufSize         int
 	idleTimeout     time.Duration
@@ -84,6 +148,36 @@ type Supervisor struct {
 	store   SessionStore
 	histMu  sync.RWMutex
 	history map[string]SessionInfo
+
+	archive    SessionArchive
+	archiveTTL time.Duration
+	// archiveRetention is how long archived sessions are kept; zero keeps
+	// them forever.
+	archiveRetention time.Duration
+
+	workspaceDir  string
+	spillDir      string
+	spillMaxBytes int64
+
+	providerLogDir      string
+	providerLogMaxBytes int64
+	providerLogMaxFiles int
+
+	scheduleDir string
+
+	redactor *redact.Redactor
+
+	// coalesceInterval and coalesceMaxBytes configure output coalescing;
+	// a zero interval publishes every fragment as it arrives.
+	coalesceInterval time.Duration
+	coalesceMaxBytes int
+
+	readyMu    sync.Mutex
+	readyStats map[string]*ReadinessStats
+
+	// spend is each project's usage today, checked against its Budget.
+	budgetMu sync.Mutex
+	spend    map[string]*projectSpend
 }
 
 type managedSession struct {
@@ -91,17 +185,42 @@ type managedSession struct {
 	info         SessionInfo
 	provider     Provider
 	cmd          *exec.Cmd
-	ptmx         *os.File       // non-nil for PTY-backed sessions
-	stdin        io.WriteCloser // non-nil for stream-JSON sessions
-	streamJSON   bool           // true when provider uses stream-JSON mode
+	ptmx         agentproc.Terminal // non-nil for PTY-backed sessions
+	stdin        io.WriteCloser     // non-nil for stream-JSON sessions
+	streamJSON   bool               // true when provider uses stream-JSON mode
+	jsonFormat   string             // stream-JSON dialect (JSONFormatClaude when empty)
 	buf          *ByteBuffer
+	plog         *providerLog    // raw agent output; nil when provider logs are off
+	ctx          context.Context // scopes the agent processes; cancelled when the session ends
 	cancel       context.CancelFunc
 	stopGrace    time.Duration
 	lastActivity time.Time
 	forceStop    bool
 	recovered    bool
 
-	stripANSI bool // strip ANSI escape codes from PTY output before forwarding
+	// output filters the agent's output text before it is buffered. Nil
+	// when the provider has no output filters. Used by the read loop only.
+	output *outputPipeline
+
+	stderrRules []StderrRule // classify stream-JSON stderr lines
+
+	// redaction scrubs output and stderr across chunk boundaries.
+	redaction sessionRedaction
+	// guard truncates responses that exceed the session's OutputLimits.
+	guard outputGuard
+	// coalesce merges adjacent output fragments into one chunk.
+	coalesce sessionCoalescer
+
+	// proc is the running agent process. It is replaced when the session is
+	// restarted after a crash; restarts counts those relaunches and
+	// restartWake cuts the backoff before one short when the session stops.
+	proc        *agentProcess
+	restarts    int
+	restartWake chan struct{}
+	// ready is set while a PTY agent has not printed its prompt yet;
+	// readyFailed once the ready timeout killed it.
+	ready       *readiness
+	readyFailed bool
 
 	// Multi-observer state. All fields below are protected by ms.mu.
 	//
@@ -109,6 +228,42 @@ type managedSession struct {
 	// The writer (if any) is always in observers too — activeWriter names it.
 	observers  map[string]*observerEntry
 	liveClosed bool // set by closeLive; new observers receive a pre-closed channel
+	archived   bool // set under s.mu when the session is archived and removed from s.sessions
+	// controlTaken is set while the writer holds the slot through
+	// TakeControl; a forced ClaimWriter cannot evict it.
+	controlTaken bool
+
+	// inputs logs accepted WriteInput payloads for transcript export.
+	inputs []InputRecord
+
+	// responding is set while a stream-JSON response is in flight and
+	// pending holds inputs waiting for it to complete.
+	responding bool
+	pending    []pendingInput
+	// activeInput is the ID of the input that output is attributed to.
+	activeInput string
+	// approvals are the agent's permission prompts awaiting ResolveApproval,
+	// oldest first.
+	approvals []Approval
+
+	// cfg is the configuration the session was started with, kept so the
+	// session can be handed off to another bridge instance.
+	cfg SessionConfig
+
+	// workspace is the directory cloned for cfg.Source, removed when the
+	// session is archived (or stops, with CleanOnStop).
+	workspace string
+	// snapshot is the repo state taken for cfg.Snapshot.
+	snapshot *WorkspaceSnapshot
+	// preamble is the repo path of the repo summary still to be sent with
+	// the session's first prompt; empty once sent or when there is none.
+	preamble string
+}
+
+// pendingInput is an accepted input queued behind a stream-JSON response.
+type pendingInput struct {
+	id   string
+	data []byte
 }
 
 func NewSupervisor(registry *Registry, policy Policy, outputBufSize int, idleTimeout time.Duration, opts ...SupervisorOption) *Supervisor {
@@ -132,6 +287,26 @@ func NewSupervisor(registry *Registry, policy Policy, outputBufSize int, idleTim
 	return s
 }
 
+// SetPolicy replaces the policy applied to new sessions and input. Running
+// sessions are not re-validated against the new limits.
+func (s *Supervisor) SetPolicy(p Policy) {
+	s.policyMu.Lock()
+	s.policy = p
+	s.policyMu.Unlock()
+}
+
+func (s *Supervisor) currentPolicy() Policy {
+	s.policyMu.RLock()
+	defer s.policyMu.RUnlock()
+	return s.policy
+}
+
+// RedactionHits reports how many matches each redaction pattern has
+// replaced. It is nil when no redactor is configured.
+func (s *Supervisor) RedactionHits() map[string]uint64 {
+	return s.redactor.Hits()
+}
+
 // LoadHistory reads all persisted sessions from the store and places them in
 // the in-memory history map so they are visible via Get and List. Sessions
 // that were not in a terminal state (i.e. the daemon crashed mid-flight) are
@@ -149,7 +324,12 @@ func (s *Supervisor) LoadHistory() error {
 	}
 	s.histMu.Lock()
 	defer s.histMu.Unlock()
+	today := nowUTC().Truncate(24 * time.Hour)
 	for _, info := range infos {
+		// Today's spending is rebuilt from the sessions started today.
+		if !info.CreatedAt.Before(today) {
+			s.addSpend(info.ProjectID, info.Usage)
+		}
 		if info.State != SessionStateStopped && info.State != SessionStateFailed {
 			if s.recoverProcess(&info) {
 				continue
@@ -163,7 +343,7 @@ func (s *Supervisor) LoadHistory() error {
 			}
 			// Best-effort: ignore write errors during startup.
 			if saveErr := s.store.Save(info); saveErr != nil {
-				slog.Warn("session store: failed to update orphaned session", "session_id", info.SessionID, "error", saveErr)
+				logger().Warn("session store: failed to update orphaned session", "session_id", info.SessionID, "error", saveErr)
 			}
 		}
 		s.history[info.SessionID] = info
@@ -172,7 +352,7 @@ func (s *Supervisor) LoadHistory() error {
 }
 
 func (s *Supervisor) recoverProcess(info *SessionInfo) bool {
-	if info.ProcessID <= 0 || !processAlive(info.ProcessID) {
+	if info.ProcessID <= 0 || !agentproc.Alive(info.ProcessID) {
 		return false
 	}
 
@@ -190,8 +370,15 @@ func (s *Supervisor) recoverProcess(info *SessionInfo) bool {
 			ExitCode:     info.ExitCode,
 			Cols:         info.Cols,
 			Rows:         info.Rows,
+			// The process is unreachable, but the session can still be
+			// stopped; keep who may do so.
+			WriterSubjects: info.WriterSubjects,
+			Variant:        info.Variant,
+			RepoPath:       info.RepoPath,
+			ConversationID: info.ConversationID,
+			ResumedFrom:    info.ResumedFrom,
 		},
-		buf:          NewByteBuffer(s.bufSize),
+		buf:          s.newBuffer(info.SessionID),
 		stopGrace:    500 * time.Millisecond,
 		lastActivity: time.Now(),
 		recovered:    true,
@@ -202,7 +389,7 @@ func (s *Supervisor) recoverProcess(info *SessionInfo) bool {
 			ms.buf.AppendChunk(chunk)
 		}
 	} else {
-		slog.Warn("session store: failed to load chunks for recovered session", "session_id", info.SessionID, "error", err)
+		logger().Warn("session store: failed to load chunks for recovered session", "session_id", info.SessionID, "error", err)
 	}
 	ms.info.OldestSeq = ms.buf.OldestSeq()
 	ms.info.LastSeq = ms.buf.LastSeq()
@@ -215,14 +402,6 @@ func (s *Supervisor) recoverProcess(info *SessionInfo) bool {
 	return true
 }
 
-func processAlive(pid int) bool {
-	if pid <= 0 {
-		return false
-	}
-	err := syscall.Kill(pid, 0)
-	return err == nil || errors.Is(err, syscall.EPERM)
-}
-
 func (s *Supervisor) monitorRecoveredProcess(ms *managedSession) {
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
@@ -238,7 +417,7 @@ func (s *Supervisor) monitorRecoveredProcess(ms *managedSession) {
 			if state == SessionStateStopped || state == SessionStateFailed {
 				return
 			}
-			if processAlive(pid) {
+			if agentproc.Alive(pid) {
 				continue
 			}
 			ms.mu.Lock()
@@ -261,7 +440,7 @@ func (s *Supervisor) persistSession(info SessionInfo) {
 		return
 	}
 	if err := s.store.Save(info); err != nil {
-		slog.Warn("session store: failed to persist session", "session_id", info.SessionID, "error", err)
+		logger().Warn("session store: failed to persist session", "session_id", info.SessionID, "error", err)
 	}
 }
 
@@ -272,27 +451,19 @@ func (s *Supervisor) persistChunk(sessionID string, chunk OutputChunk) {
 		return
 	}
 	if err := s.store.SaveChunk(sessionID, chunk); err != nil {
-		slog.Warn("session store: failed to persist chunk", "session_id", sessionID, "seq", chunk.Seq, "error", err)
+		logger().Warn("session store: failed to persist chunk", "session_id", sessionID, "seq", chunk.Seq, "error", err)
 	}
 }
 
-// attachHistory serves a read-only replay for a session that exists only in
-// the persisted history (i.e. from a previous daemon lifetime). Returns
-// ErrSessionNotFound if the session is not in history or has no store.
+// attachHistory serves a read-only replay for a session that is no longer in
+// memory: one archived by this daemon or persisted in a previous lifetime.
+// Returns ErrSessionNotFound if neither the archive nor the store has it.
 func (s *Supervisor) attachHistory(sessionID, clientID string, afterSeq uint64) (*AttachState, error) {
-	if s.store == nil {
-		return nil, ErrSessionNotFound
-	}
-	s.histMu.RLock()
-	info, ok := s.history[sessionID]
-	s.histMu.RUnlock()
-	if !ok {
-		return nil, ErrSessionNotFound
-	}
-	chunks, err := s.store.LoadChunks(sessionID)
+	session, err := s.loadHistory(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("load chunks for %q: %w", sessionID, err)
+		return nil, err
 	}
+	info, chunks := session.Info, session.Chunks
 	var replay []OutputChunk
 	for _, c := range chunks {
 		if c.Seq > afterSeq {
@@ -329,10 +500,11 @@ func (s *Supervisor) cleanupLoop() {
 		case <-s.done:
 			return
 		case <-ticker.C:
-			// No-op: sessions are only stopped explicitly via Stop() or
-			// when the supervisor shuts down via Close(). The idle timeout
-			// field is retained for future use but does not reap running
-			// or attached sessions.
+			// Sessions are only stopped explicitly via Stop() or when the
+			// supervisor shuts down via Close(). The idle timeout field is
+			// retained for future use but does not reap running or attached
+			// sessions; only terminal sessions are archived.
+			s.archiveExpired(time.Now())
 		}
 	}
 }
@@ -340,7 +512,7 @@ func (s *Supervisor) cleanupLoop() {
 // resolveProvider tries the primary provider ID, then each fallback in order,
 // returning the first one that is registered and passes its Health check. If
 // no candidate succeeds, the last error is returned.
-func (s *Supervisor) resolveProvider(ctx context.Context, primary string, fallbacks []string) (Provider, error) {
+func (s *Supervisor) resolveProvider(ctx context.Context, primary, variant string, fallbacks []string) (resolvedProvider, error) {
 	candidates := make([]string, 0, 1+len(fallbacks))
 	candidates = append(candidates, primary)
 	candidates = append(candidates, fallbacks...)
@@ -351,32 +523,74 @@ func (s *Supervisor) resolveProvider(ctx context.Context, primary string, fallba
 			lastErr = err
 			continue
 		}
-		if err := p.Health(ctx); err != nil {
+		rp := resolvedProvider{Provider: p, id: id}
+		if vp, ok := p.(VariantProvider); ok {
+			if rp.Provider, rp.variant, err = vp.Variant(variant); err != nil {
+				if id == primary {
+					return resolvedProvider{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+				}
+				lastErr = fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+				continue
+			}
+		} else if variant != "" {
+			if id == primary {
+				return resolvedProvider{}, fmt.Errorf("%w: provider %q has no variants", ErrInvalidArgument, id)
+			}
+			lastErr = fmt.Errorf("%w: provider %q has no variant %q", ErrProviderUnavailable, id, variant)
+			continue
+		}
+		if err := rp.Health(ctx); err != nil {
 			lastErr = fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
-			slog.Warn("provider unavailable, trying fallback", "provider", id, "error", err)
+			logger().Warn("provider unavailable, trying fallback", "provider", id, "variant", rp.variant, "error", err)
 			continue
 		}
 		if id != primary {
-			slog.Info("using fallback provider", "requested", primary, "selected", id)
+			logger().Info("using fallback provider", "requested", primary, "selected", id)
 		}
-		return p, nil
+		return rp, nil
 	}
-	return nil, lastErr
+	return resolvedProvider{}, lastErr
+}
+
+// resolvedProvider is the provider a session starts with: for a provider
+// with variants, the provider of the chosen variant.
+type resolvedProvider struct {
+	Provider
+	// id is the registered provider ID.
+	id      string
+	variant string
 }
 
 func (s *Supervisor) Start(ctx context.Context, cfg SessionConfig) (*SessionInfo, error) {
+	return s.start(ctx, cfg, nil)
+}
+
+// start launches a session. When prior is non-nil the session continues a
+// handed-off session: its buffer, input log, usage and creation time are
+// restored before the agent process starts so seq numbering carries on.
+func (s *Supervisor) start(ctx context.Context, cfg SessionConfig, prior *SessionHandoff) (*SessionInfo, error) {
 	if cfg.SessionID == "" {
 		return nil, fmt.Errorf("%w: session_id is required", ErrInvalidArgument)
 	}
 	if cfg.ProjectID == "" {
 		return nil, fmt.Errorf("%w: project_id is required", ErrInvalidArgument)
 	}
-	if cfg.RepoPath == "" {
+	if cfg.RepoPath == "" && cfg.Source == nil {
 		return nil, fmt.Errorf("%w: repo_path is required", ErrInvalidArgument)
 	}
-	if err := s.policy.ValidateRepoPath(cfg.RepoPath); err != nil {
+	policy := s.currentPolicy()
+	if err := policy.CheckProject(cfg.ProjectID); err != nil {
+		return nil, err
+	}
+	if cfg.Source == nil {
+		if err := policy.ValidateRepoPath(cfg.ProjectID, cfg.RepoPath); err != nil {
+			return nil, err
+		}
+	}
+	if err := policy.ValidateEnv(cfg.Env); err != nil {
 		return nil, err
 	}
+	cfg.Limits = cfg.Limits.Tighter(policy.ProjectLimits[cfg.ProjectID])
 
 	s.mu.Lock()
 	if _, exists := s.sessions[cfg.SessionID]; exists {
@@ -386,23 +600,117 @@ func (s *Supervisor) Start(ctx context.Context, cfg SessionConfig) (*SessionInfo
 	projectCount := 0
 	globalCount := 0
 	for _, ms := range s.sessions {
-		if ms.info.State == SessionStateRunning || ms.info.State == SessionStateStarting || ms.info.State == SessionStateAttached {
+		ms.mu.Lock()
+		state, projectID := ms.info.State, ms.info.ProjectID
+		ms.mu.Unlock()
+		if state == SessionStateRunning || state == SessionStateStarting || state == SessionStateAttached {
 			globalCount++
-			if ms.info.ProjectID == cfg.ProjectID {
+			if projectID == cfg.ProjectID {
 				projectCount++
 			}
 		}
 	}
-	if err := s.policy.CheckSessionLimits(projectCount, globalCount); err != nil {
+	if err := policy.CheckSessionLimits(cfg.ProjectID, projectCount, globalCount); err != nil {
 		s.mu.Unlock()
 		return nil, err
 	}
 	s.mu.Unlock()
 
-	provider, err := s.resolveProvider(ctx, cfg.Options["provider"], cfg.Fallbacks)
+	if err := s.checkBudget(cfg.ProjectID); err != nil {
+		return nil, err
+	}
+	// A handed-off session keeps its place in its conversation.
+	var prev *SessionInfo
+	if prior == nil {
+		var err error
+		if prev, err = s.joinConversation(cfg); err != nil {
+			return nil, err
+		}
+	}
+	primary := cfg.Options["provider"]
+	if err := policy.CheckProvider(cfg.ProjectID, primary); err != nil {
+		return nil, err
+	}
+	// Fallbacks the project may not use are dropped rather than rejected so a
+	// shared fallback chain still works for restricted projects.
+	fallbacks := make([]string, 0, len(cfg.Fallbacks))
+	for _, id := range cfg.Fallbacks {
+		if policy.CheckProvider(cfg.ProjectID, id) == nil {
+			fallbacks = append(fallbacks, id)
+		}
+	}
+	resolved, err := s.resolveProvider(ctx, primary, cfg.Options["variant"], fallbacks)
 	if err != nil {
 		return nil, err
 	}
+	provider := resolved.Provider
+
+	workspace := ""
+	if cfg.Source != nil {
+		workspace, err = s.provisionWorkspace(ctx, cfg, policy)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RepoPath = workspace
+	}
+	var snapshot *WorkspaceSnapshot
+	if cfg.Snapshot {
+		snapshot, err = takeSnapshot(ctx, cfg.RepoPath, cfg.SessionID)
+		if err != nil {
+			if workspace != "" {
+				_ = os.RemoveAll(workspace)
+			}
+			return nil, err
+		}
+	}
+	// Until the session is published, a failed start removes the workspace,
+	// the snapshot, the MCP config and the repo summary.
+	published := false
+	preamble := ""
+	defer func() {
+		if published {
+			return
+		}
+		if workspace != "" {
+			_ = os.RemoveAll(workspace)
+			return
+		}
+		if snapshot != nil {
+			_, _ = runGit(context.Background(), cfg.RepoPath, nil, "update-ref", "-d", snapshotRefPrefix+cfg.SessionID)
+		}
+		if cfg.MCPConfig != "" {
+			_ = os.Remove(filepath.Join(cfg.RepoPath, filepath.FromSlash(cfg.MCPConfig)))
+		}
+		if preamble != "" {
+			_ = os.Remove(filepath.Join(cfg.RepoPath, filepath.FromSlash(preamble)))
+		}
+	}()
+
+	cfg.RequireApproval = policy.Projects[cfg.ProjectID].RequireApproval
+	if cfg.RequireApproval && !providerSupportsApproval(provider) {
+		return nil, fmt.Errorf("%w: project %q requires approval of tool calls, which provider %q cannot ask for", ErrInvalidArgument, cfg.ProjectID, provider.ID())
+	}
+
+	cfg.MCPConfig = ""
+	if servers := policy.Projects[cfg.ProjectID].MCPServers; len(servers) > 0 {
+		if providerMCPConfigFlag(provider) == "" {
+			logger().Warn("provider takes no MCP config; project MCP servers not passed", "session_id", cfg.SessionID, "project_id", cfg.ProjectID, "provider", provider.ID())
+		} else if cfg.MCPConfig, err = writeMCPConfig(cfg.RepoPath, cfg.SessionID, servers); err != nil {
+			return nil, err
+		}
+	}
+
+	// A handed-off session has had its first prompt already.
+	summary := policy.Projects[cfg.ProjectID].RepoSummary
+	if on, err := repoSummaryEnabled(cfg, summary); err != nil {
+		return nil, err
+	} else if on && prior == nil {
+		// The summary is a convenience: a repo it cannot be written to
+		// still gets its session.
+		if preamble, err = writeRepoSummary(cfg.RepoPath, cfg.SessionID, summary); err != nil {
+			logger().Warn("repo summary not written", "session_id", cfg.SessionID, "project_id", cfg.ProjectID, "error", err)
+		}
+	}
 
 	if cfg.InitialCols == 0 {
 		cfg.InitialCols = 120
@@ -411,8 +719,13 @@ func (s *Supervisor) Start(ctx context.Context, cfg SessionConfig) (*SessionInfo
 		cfg.InitialRows = 40
 	}
 
+	resumedFrom, thread := "", ""
+	if resumesThread(prev, cfg, provider, resolved.id, resolved.variant) {
+		resumedFrom, thread = prev.SessionID, prev.ThreadID
+		logger().Info("session continues conversation thread", "session_id", cfg.SessionID, "conversation_id", cfg.ConversationID, "resumed_from", resumedFrom, "provider", provider.ID())
+	}
 	sessionCtx, cancel := context.WithCancel(context.Background())
-	cmd, err := provider.BuildCommand(sessionCtx, cfg)
+	cmd, thread, err := agentCommand(sessionCtx, provider, cfg, thread)
 	if err != nil {
 		cancel()
 		return nil, err
@@ -424,99 +737,77 @@ func (s *Supervisor) Start(ctx context.Context, cfg SessionConfig) (*SessionInfo
 		useStreamJSON = true
 	}
 
-	// Detect whether the provider requests ANSI escape code stripping.
-	stripANSI := false
-	if sap, ok := provider.(StripANSIProvider); ok && sap.IsStripANSI() {
-		stripANSI = true
+	jsonFormat := ""
+	if jfp, ok := provider.(JSONFormatProvider); ok {
+		jsonFormat = jfp.JSONFormat()
+	}
+
+	var stderrRules []StderrRule
+	if scp, ok := provider.(StderrClassifierProvider); ok {
+		stderrRules = scp.StderrRules()
 	}
 
+	output := newOutputPipeline(providerOutputFilters(provider))
+
 	now := nowUTC()
 	ms := &managedSession{
 		info: SessionInfo{
-			SessionID: cfg.SessionID,
-			ProjectID: cfg.ProjectID,
-			Provider:  provider.ID(),
-			State:     SessionStateRunning,
-			CreatedAt: now,
-			Cols:      cfg.InitialCols,
-			Rows:      cfg.InitialRows,
+			SessionID:      cfg.SessionID,
+			ProjectID:      cfg.ProjectID,
+			Provider:       resolved.id,
+			Variant:        resolved.variant,
+			RepoPath:       cfg.RepoPath,
+			ConversationID: cfg.ConversationID,
+			ResumedFrom:    resumedFrom,
+			ThreadID:       thread,
+			State:          SessionStateRunning,
+			CreatedAt:      now,
+			Cols:           cfg.InitialCols,
+			Rows:           cfg.InitialRows,
+			WriterSubjects: slices.Clone(cfg.WriterSubjects),
 		},
 		provider:     provider,
 		cmd:          cmd,
 		streamJSON:   useStreamJSON,
-		stripANSI:    stripANSI,
-		buf:          NewByteBuffer(s.bufSize),
+		jsonFormat:   jsonFormat,
+		output:       output,
+		stderrRules:  stderrRules,
+		restartWake:  make(chan struct{}, 1),
+		buf:          s.newBuffer(cfg.SessionID),
+		plog:         s.newProviderLog(cfg.SessionID),
+		ctx:          sessionCtx,
 		cancel:       cancel,
 		stopGrace:    provider.StopGrace(),
 		lastActivity: time.Now(),
+		cfg:          cfg,
+		workspace:    workspace,
+		snapshot:     snapshot,
+		preamble:     preamble,
+		guard:        outputGuard{limits: policy.OutputLimits},
+	}
+	if prior != nil {
+		ms.restore(prior)
 	}
 
-	if useStreamJSON {
-		if cmd.SysProcAttr == nil {
-			cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-		} else {
-			cmd.SysProcAttr.Setpgid = true
-		}
-		stdinPipe, err := cmd.StdinPipe()
-		if err != nil {
-			cancel()
-			return nil, fmt.Errorf("get stdin pipe: %w", err)
-		}
-		// Use os.Pipe directly so that cmd.Wait does not close the read end via
-		// closeAfterWait. readLoopStreamJSON owns the read end and receives a
-		// natural EOF when the child process exits and the write end closes.
-		stdoutR, stdoutW, err := os.Pipe()
-		if err != nil {
-			cancel()
-			_ = stdinPipe.Close()
-			return nil, fmt.Errorf("create stdout pipe: %w", err)
-		}
-		cmd.Stdout = stdoutW
-		if err := cmd.Start(); err != nil {
-			cancel()
-			_ = stdinPipe.Close()
-			_ = stdoutR.Close()
-			_ = stdoutW.Close()
-			return nil, fmt.Errorf("start stream-json session: %w", err)
-		}
-		// Close the write end in the parent; only the child holds it now.
-		_ = stdoutW.Close()
-		stdoutPipe := stdoutR
-		ms.stdin = stdinPipe
-		ms.info.ProcessID = cmd.Process.Pid
-		s.mu.Lock()
-		if _, exists := s.sessions[cfg.SessionID]; exists {
-			s.mu.Unlock()
-			cancel()
-			_ = stdinPipe.Close()
-			return nil, fmt.Errorf("%w: %q", ErrSessionAlreadyExists, cfg.SessionID)
-		}
-		s.sessions[cfg.SessionID] = ms
-		s.mu.Unlock()
-		go s.readLoopStreamJSON(ms, stdoutPipe)
-		go s.waitLoop(ms)
-	} else {
-		ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{
-			Cols: uint16(cfg.InitialCols),
-			Rows: uint16(cfg.InitialRows),
-		})
-		if err != nil {
-			cancel()
-			return nil, fmt.Errorf("start pty session: %w", err)
-		}
-		ms.ptmx = ptmx
-		ms.info.ProcessID = cmd.Process.Pid
-		s.mu.Lock()
-		if _, exists := s.sessions[cfg.SessionID]; exists {
-			s.mu.Unlock()
-			cancel()
-			_ = ptmx.Close()
-			return nil, fmt.Errorf("%w: %q", ErrSessionAlreadyExists, cfg.SessionID)
-		}
-		s.sessions[cfg.SessionID] = ms
+	proc, err := s.spawn(ms, cmd)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	s.mu.Lock()
+	if _, exists := s.sessions[cfg.SessionID]; exists {
 		s.mu.Unlock()
-		go s.readLoop(ms)
-		go s.waitLoop(ms)
+		cancel()
+		proc.close()
+		return nil, fmt.Errorf("%w: %q", ErrSessionAlreadyExists, cfg.SessionID)
+	}
+	s.sessions[cfg.SessionID] = ms
+	s.mu.Unlock()
+	published = true
+	s.run(ms, proc)
+
+	if policy.GitWatchInterval > 0 {
+		go s.watchRepo(sessionCtx, ms, policy.GitWatchInterval)
 	}
 
 	info := ms.snapshotInfo()
@@ -524,24 +815,34 @@ func (s *Supervisor) Start(ctx context.Context, cfg SessionConfig) (*SessionInfo
 	return &info, nil
 }
 
-func (s *Supervisor) readLoop(ms *managedSession) {
-	defer s.closeLive(ms)
+func (s *Supervisor) readLoop(ms *managedSession, ptmx io.Reader) {
+	defer s.outputEnded(ms)
 	buf := make([]byte, 8192)
+	// held is the start of a multi-byte rune that the last read cut off;
+	// it is sent with the rest of the rune so that no chunk splits one.
+	var held []byte
 	for {
-		n, err := ms.ptmx.Read(buf)
+		n, err := ptmx.Read(buf)
 		if n > 0 {
 			chunk := buf[:n]
-			if ms.stripANSI {
-				chunk = ansiEscape.ReplaceAll(chunk, nil)
+			ms.plog.write(providerLogPTY, chunk)
+			logger().Debug("provider output", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "bytes", len(chunk))
+			if len(held) > 0 {
+				chunk = append(held, chunk...)
 			}
-			slog.Debug("provider output", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "bytes", len(chunk))
-			s.appendChunk(ms, chunk, ChunkTypeOutput)
+			cut := len(chunk) - partialRuneLen(chunk)
+			s.appendOutput(ms, chunk[:cut])
+			s.checkReady(ms, chunk[:cut])
+			held = append([]byte(nil), chunk[cut:]...)
 		}
 		if err != nil {
+			if len(held) > 0 {
+				s.appendOutput(ms, held)
+			}
 			if errors.Is(err, io.EOF) {
-				slog.Info("session PTY closed", "session_id", ms.info.SessionID, "provider", ms.info.Provider)
+				logger().Info("session PTY closed", "session_id", ms.info.SessionID, "provider", ms.info.Provider)
 			} else {
-				slog.Warn("session PTY read error", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "error", err)
+				logger().Warn("session PTY read error", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "error", err)
 				ms.mu.Lock()
 				if ms.info.Error == "" && !ms.info.ExitRecorded {
 					ms.info.Error = err.Error()
@@ -553,27 +854,182 @@ func (s *Supervisor) readLoop(ms *managedSession) {
 	}
 }
 
+// partialRuneLen returns the length of the incomplete UTF-8 sequence at the
+// end of p: the start of a multi-byte rune whose remaining bytes have not
+// been read yet. Invalid bytes are not held back.
+func partialRuneLen(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-(utf8.UTFMax-1); i-- {
+		if utf8.RuneStart(p[i]) {
+			if utf8.FullRune(p[i:]) {
+				return 0
+			}
+			return len(p) - i
+		}
+	}
+	return 0
+}
+
 // claudeStreamEvent is the JSON shape emitted by `claude --output-format stream-json`.
 // Only the fields we inspect are declared; unknown fields are discarded.
 type claudeStreamEvent struct {
-	Type  string `json:"type"`
+	Type string `json:"type"`
+	// Set on the "system" event that starts each process: the ID of the
+	// thread the agent runs, which a relaunch resumes.
+	SessionID    string `json:"session_id,omitempty"`
+	ContentBlock *struct {
+		Type  string          `json:"type"`
+		ID    string          `json:"id,omitempty"`
+		Name  string          `json:"name,omitempty"`
+		Input json.RawMessage `json:"input,omitempty"`
+	} `json:"content_block,omitempty"`
 	Delta *struct {
 		Type     string `json:"type"`
 		Text     string `json:"text,omitempty"`
 		Thinking string `json:"thinking,omitempty"`
 	} `json:"delta,omitempty"`
+	// Set on "control_request" events, which the agent sends when run
+	// with --permission-prompt-tool stdio and a tool needs permission.
+	RequestID string `json:"request_id,omitempty"`
+	Request   *struct {
+		Subtype   string          `json:"subtype"`
+		ToolName  string          `json:"tool_name,omitempty"`
+		Input     json.RawMessage `json:"input,omitempty"`
+		ToolUseID string          `json:"tool_use_id,omitempty"`
+	} `json:"request,omitempty"`
+	// Set on the final "result" event of each response. TotalCostUSD is
+	// the cost of the whole process so far, not of the response.
+	TotalCostUSD float64 `json:"total_cost_usd,omitempty"`
+	DurationMS   int64   `json:"duration_ms,omitempty"`
+	Usage        *struct {
+		InputTokens              int64 `json:"input_tokens"`
+		OutputTokens             int64 `json:"output_tokens"`
+		CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	} `json:"usage,omitempty"`
+}
+
+// streamChunk is one typed payload decoded from a stream-JSON line.
+// When usage is set the chunk is a usage delta, not buffered output. If
+// costTotal is also set, usage.CostUSD is the process's running total and
+// only its increase is recorded. When approval is set the chunk is a
+// permission prompt the agent is blocked on.
+type streamChunk struct {
+	ctype     ChunkType
+	payload   []byte
+	data      json.RawMessage // OutputChunk.Data
+	usage     *Usage
+	costTotal bool
+	approval  *Approval
+	threadID  string // the agent's thread ID; see SessionInfo.ThreadID
+}
+
+// streamDecoder converts one JSONL line into zero or more typed chunks. It
+// returns false when the line is not valid JSON for the dialect.
+type streamDecoder func(line []byte) ([]streamChunk, bool)
+
+// streamDecoderFor returns the stdout decoder for a stream-JSON dialect.
+func streamDecoderFor(format string) streamDecoder {
+	switch format {
+	case JSONFormatOpenCode:
+		return decodeOpenCodeLine
+	default:
+		return decodeClaudeLine
+	}
+}
+
+// streamInterruptFor returns the stdin message that interrupts the current
+// response of a stream-JSON dialect, or nil when the dialect has none and
+// the agent must be signalled instead.
+func streamInterruptFor(format string) []byte {
+	switch format {
+	case JSONFormatOpenCode:
+		// opencode run answers one prompt per process.
+		return nil
+	default:
+		return fmt.Appendf(nil, `{"type":"control_request","request_id":%q,"request":{"subtype":"interrupt"}}`+"\n", uuid.NewString())
+	}
+}
+
+// decodeClaudeLine extracts thinking and text deltas from a claude
+// stream-json line. A "result" event marks the end of the response, a
+// "can_use_tool" control request asks for approval of a tool call, and the
+// "system" event names the agent's thread.
+func decodeClaudeLine(line []byte) ([]streamChunk, bool) {
+	var ev claudeStreamEvent
+	if err := json.Unmarshal(line, &ev); err != nil {
+		return nil, false
+	}
+	if ev.Type == "system" && ev.SessionID != "" {
+		return []streamChunk{{threadID: ev.SessionID}}, true
+	}
+	if ev.Type == "control_request" && ev.Request != nil && ev.Request.Subtype == "can_use_tool" {
+		return []streamChunk{{approval: &Approval{
+			ID:        ev.RequestID,
+			ToolName:  ev.Request.ToolName,
+			Input:     ev.Request.Input,
+			ToolUseID: ev.Request.ToolUseID,
+		}}}, true
+	}
+	if ev.Type == "result" {
+		usage := Usage{
+			CostUSD:  ev.TotalCostUSD,
+			Duration: time.Duration(ev.DurationMS) * time.Millisecond,
+			Turns:    1,
+		}
+		if ev.Usage != nil {
+			usage.InputTokens = ev.Usage.InputTokens + ev.Usage.CacheCreationInputTokens + ev.Usage.CacheReadInputTokens
+			usage.OutputTokens = ev.Usage.OutputTokens
+		}
+		return []streamChunk{{usage: &usage, costTotal: true}, {ctype: ChunkTypeResponseComplete}}, true
+	}
+	if ev.Type == "content_block_start" && ev.ContentBlock != nil && ev.ContentBlock.Type == "tool_use" {
+		cb := ev.ContentBlock
+		return []streamChunk{{ctype: ChunkTypeToolUse, payload: []byte(cb.Name), data: toolCallData(cb.ID, cb.Name, cb.Input)}}, true
+	}
+	if ev.Type != "content_block_delta" || ev.Delta == nil {
+		return nil, true
+	}
+	switch ev.Delta.Type {
+	case "thinking_delta":
+		if ev.Delta.Thinking != "" {
+			return []streamChunk{{ctype: ChunkTypeThinking, payload: []byte(ev.Delta.Thinking)}}, true
+		}
+	case "text_delta":
+		if ev.Delta.Text != "" {
+			return []streamChunk{{ctype: ChunkTypeOutput, payload: []byte(ev.Delta.Text)}}, true
+		}
+	}
+	return nil, true
 }
 
 // readLoopStreamJSON reads newline-delimited JSON from a stream-JSON provider's
-// stdout, parses thinking and text deltas, and appends typed OutputChunks.
-func (s *Supervisor) readLoopStreamJSON(ms *managedSession, r io.ReadCloser) {
+// stdout, decodes it with the session's dialect, and appends typed OutputChunks.
+// When stderr is non-nil its lines are classified with rules and appended as
+// ChunkTypeStderr chunks; observers are closed only after both streams end.
+func (s *Supervisor) readLoopStreamJSON(ms *managedSession, r io.ReadCloser, stderr io.ReadCloser, rules []StderrRule) {
 	defer func() { _ = r.Close() }()
-	defer s.closeLive(ms)
+	stderrDone := make(chan struct{})
+	if stderr != nil {
+		go func() {
+			defer close(stderrDone)
+			s.readLoopStderr(ms, stderr, rules)
+		}()
+	} else {
+		close(stderrDone)
+	}
+	defer func() {
+		<-stderrDone
+		s.outputEnded(ms)
+	}()
+	decode := streamDecoderFor(ms.jsonFormat)
+	// processCost is the last running cost total this process reported.
+	var processCost float64
 	reader := bufio.NewReader(r)
 	for {
 		line, err := reader.ReadBytes('\n')
+		ms.plog.write(providerLogStdout, line)
 		if errors.Is(err, io.EOF) && len(line) == 0 {
-			slog.Info("session stream-JSON pipe closed", "session_id", ms.info.SessionID, "provider", ms.info.Provider)
+			logger().Info("session stream-JSON pipe closed", "session_id", ms.info.SessionID, "provider", ms.info.Provider)
 			return
 		}
 		line = bytes.TrimSuffix(line, []byte{'\n'})
@@ -581,39 +1037,58 @@ func (s *Supervisor) readLoopStreamJSON(ms *managedSession, r io.ReadCloser) {
 		if len(line) == 0 {
 			if err != nil {
 				// EOF or pipe closed by cmd.Wait — either way, no more data.
-				slog.Info("session stream-JSON pipe closed", "session_id", ms.info.SessionID, "provider", ms.info.Provider)
+				logger().Info("session stream-JSON pipe closed", "session_id", ms.info.SessionID, "provider", ms.info.Provider)
 				return
 			}
 			continue
 		}
-		var ev claudeStreamEvent
-		if err := json.Unmarshal(line, &ev); err != nil {
+		chunks, ok := decode(line)
+		if !ok {
 			// Non-JSON line (e.g. a log or warning): emit as raw output.
-			s.appendChunk(ms, line, ChunkTypeOutput)
+			s.appendOutput(ms, line)
 			continue
 		}
-		if ev.Type == "content_block_delta" && ev.Delta != nil {
-			switch ev.Delta.Type {
-			case "thinking_delta":
-				if ev.Delta.Thinking != "" {
-					s.appendChunk(ms, []byte(ev.Delta.Thinking), ChunkTypeThinking)
-				}
-			case "text_delta":
-				if ev.Delta.Text != "" {
-					s.appendChunk(ms, []byte(ev.Delta.Text), ChunkTypeOutput)
+		for _, c := range chunks {
+			if c.threadID != "" {
+				s.recordThread(ms, c.threadID)
+				continue
+			}
+			if c.usage != nil {
+				usage := *c.usage
+				if c.costTotal {
+					total := usage.CostUSD
+					usage.CostUSD = max(total-processCost, 0)
+					processCost = total
 				}
+				s.recordUsage(ms, usage)
+				continue
+			}
+			if c.approval != nil {
+				s.flushOutput(ms)
+				s.requestApproval(ms, *c.approval)
+				continue
+			}
+			if c.ctype == ChunkTypeOutput {
+				s.appendOutput(ms, c.payload)
+				continue
+			}
+			// Output held back by the filters precedes this chunk.
+			s.flushOutput(ms)
+			s.appendChunkData(ms, c.payload, c.ctype, c.data)
+			if c.ctype == ChunkTypeResponseComplete {
+				s.deliverPending(ms)
 			}
 		}
 		if err != nil {
 			if !errors.Is(err, io.EOF) {
-				slog.Warn("session stream-JSON read error", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "error", err)
+				logger().Warn("session stream-JSON read error", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "error", err)
 				ms.mu.Lock()
 				if ms.info.Error == "" && !ms.info.ExitRecorded {
 					ms.info.Error = err.Error()
 				}
 				ms.mu.Unlock()
 			} else {
-				slog.Info("session stream-JSON pipe closed", "session_id", ms.info.SessionID, "provider", ms.info.Provider)
+				logger().Info("session stream-JSON pipe closed", "session_id", ms.info.SessionID, "provider", ms.info.Provider)
 			}
 			return
 		}
@@ -621,8 +1096,8 @@ func (s *Supervisor) readLoopStreamJSON(ms *managedSession, r io.ReadCloser) {
 }
 
 // closeLive marks the session output as exhausted and closes every observer
-// channel. Must only be called from readLoop or readLoopStreamJSON — after all
-// sends to observer channels are complete.
+// channel. Must only be called once the session's output has ended and will
+// not resume — after all sends to observer channels are complete.
 // The observers map is kept intact so deferred Detach calls (from AttachSession
 // goroutines draining their channels) can still clean up session state.
 func (s *Supervisor) closeLive(ms *managedSession) {
@@ -636,11 +1111,9 @@ func (s *Supervisor) closeLive(ms *managedSession) {
 	}
 }
 
-// appendChunk adds a new chunk with the given type to the session buffer and
-// fans it out to all attached observers. Chunks for slow observers are dropped
-// with a warning; the observer remains attached.
-func (s *Supervisor) appendChunk(ms *managedSession, payload []byte, ctype ChunkType) {
-	chunk := ms.buf.AppendTyped(payload, ctype)
+// publishChunk persists a chunk already appended to the session buffer and
+// sends it to every attached observer.
+func (s *Supervisor) publishChunk(ms *managedSession, chunk OutputChunk) {
 	s.persistChunk(ms.info.SessionID, chunk)
 	ms.mu.Lock()
 	ms.info.OldestSeq = ms.buf.OldestSeq()
@@ -655,15 +1128,46 @@ func (s *Supervisor) appendChunk(ms *managedSession, payload []byte, ctype Chunk
 		select {
 		case entry.ch <- chunk:
 		default:
-			slog.Warn("observer channel full, dropping chunk", "session_id", ms.info.SessionID, "client_id", clientID)
+			logger().Warn("observer channel full, dropping chunk", "session_id", ms.info.SessionID, "client_id", clientID)
 		}
 	}
 }
 
+// recordUsage adds delta to the session's usage total, persists the updated
+// session info, broadcasts the new total as a ChunkTypeUsage event and
+// charges delta to the project's budget.
+func (s *Supervisor) recordUsage(ms *managedSession, delta Usage) {
+	ms.mu.Lock()
+	ms.info.Usage = ms.info.Usage.Add(delta)
+	total := ms.info.Usage
+	info := ms.info
+	ms.mu.Unlock()
+	s.persistSession(info)
+	s.fanoutChunk(ms, OutputChunk{Type: ChunkTypeUsage, Timestamp: nowUTC(), Usage: &total})
+	s.chargeBudget(info.ProjectID, delta)
+}
+
+// recordThread records the thread ID the agent reported, which differs
+// from the one it was started with when its CLI forks a resumed thread.
+func (s *Supervisor) recordThread(ms *managedSession, threadID string) {
+	ms.mu.Lock()
+	changed := ms.info.ThreadID != threadID
+	ms.info.ThreadID = threadID
+	info := ms.info
+	ms.mu.Unlock()
+	if changed {
+		s.persistSession(info)
+	}
+}
+
 // fanoutControlEvent broadcasts a control chunk to all current observers
 // without appending it to the replay buffer or persisting it.
 func (s *Supervisor) fanoutControlEvent(ms *managedSession, ctype ChunkType, payload []byte) {
-	chunk := OutputChunk{Type: ctype, Payload: payload}
+	s.fanoutChunk(ms, OutputChunk{Type: ctype, Payload: payload})
+}
+
+func (s *Supervisor) fanoutChunk(ms *managedSession, chunk OutputChunk) {
+	ctype := chunk.Type
 	ms.mu.Lock()
 	obs := make(map[string]*observerEntry, len(ms.observers))
 	maps.Copy(obs, ms.observers)
@@ -673,7 +1177,7 @@ func (s *Supervisor) fanoutControlEvent(ms *managedSession, ctype ChunkType, pay
 		select {
 		case entry.ch <- chunk:
 		default:
-			slog.Warn("observer channel full, dropping control event", "session_id", ms.info.SessionID, "client_id", clientID, "type", ctype)
+			logger().Warn("observer channel full, dropping control event", "session_id", ms.info.SessionID, "client_id", clientID, "type", ctype)
 		}
 	}
 }
@@ -703,8 +1207,17 @@ func (s *Supervisor) NotifyWriterReleased(sessionID, releasingClientID string) {
 	s.fanoutControlEvent(ms, ChunkTypeWriterReleased, []byte(releasingClientID))
 }
 
-func (s *Supervisor) waitLoop(ms *managedSession) {
-	err := ms.cmd.Wait()
+// waitLoop waits for proc to exit. A crashed process is relaunched when the
+// restart policy allows; otherwise the exit is recorded and, once all output
+// has been read, the observer channels are closed.
+func (s *Supervisor) waitLoop(ms *managedSession, proc *agentProcess) {
+	err := proc.cmd.Wait()
+	agentproc.ReleaseProcessGroup(proc.cmd.Process.Pid)
+	ms.stopReadiness()
+	ms.dropApprovals()
+	if c, ok := ms.provider.(SessionCleanupProvider); ok {
+		c.CleanupSession(ms.info.SessionID)
+	}
 
 	exitCode := 0
 	if err != nil {
@@ -716,6 +1229,21 @@ func (s *Supervisor) waitLoop(ms *managedSession) {
 		}
 	}
 
+	if delay, ok := s.restartDelay(ms, err); ok {
+		<-proc.readDone
+		ms.plog.exit(exitCode)
+		proc.close()
+		if s.relaunch(ms, exitCode, delay) {
+			return
+		}
+		ms.mu.Lock()
+		if ms.info.State == SessionStateStopping {
+			// Stopped while waiting to restart: the crash is not a failure.
+			err = nil
+		}
+		ms.mu.Unlock()
+	}
+
 	ms.mu.Lock()
 	ms.info.StoppedAt = nowUTC()
 	ms.info.ExitRecorded = true
@@ -726,15 +1254,23 @@ func (s *Supervisor) waitLoop(ms *managedSession) {
 		if ms.info.Error == "" {
 			ms.info.Error = err.Error()
 		}
-		slog.Warn("session process failed", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "exit_code", exitCode, "error", err)
+		logger().Warn("session process failed", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "exit_code", exitCode, "error", err)
 	} else {
 		ms.info.State = SessionStateStopped
-		slog.Info("session process exited", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "exit_code", exitCode)
+		logger().Info("session process exited", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "exit_code", exitCode)
 	}
 	ms.cancel()
+	cleanOnStop := ms.cfg.Source != nil && ms.cfg.Source.CleanOnStop
 	ms.mu.Unlock()
 
 	s.persistSession(ms.snapshotInfo())
+	<-proc.readDone
+	ms.plog.exit(exitCode)
+	ms.plog.close()
+	s.closeLive(ms)
+	if cleanOnStop {
+		s.removeWorkspace(ms)
+	}
 }
 
 func (s *Supervisor) Stop(sessionID string, force bool) error {
@@ -747,11 +1283,11 @@ func (s *Supervisor) Stop(sessionID string, force bool) error {
 
 	ms.mu.Lock()
 	if ms.info.State == SessionStateStopped || ms.info.State == SessionStateFailed {
-		slog.Debug("stop called on already-terminated session", "session_id", sessionID, "state", ms.info.State)
+		logger().Debug("stop called on already-terminated session", "session_id", sessionID, "state", ms.info.State)
 		ms.mu.Unlock()
 		return nil
 	}
-	slog.Info("stopping session process", "session_id", sessionID, "provider", ms.info.Provider, "force", force, "pid", ms.info.ProcessID)
+	logger().Info("stopping session process", "session_id", sessionID, "provider", ms.info.Provider, "force", force, "pid", ms.info.ProcessID)
 	if ms.recovered {
 		ms.info.State = SessionStateStopping
 		ms.forceStop = force
@@ -761,16 +1297,16 @@ func (s *Supervisor) Stop(sessionID string, force bool) error {
 
 		if force {
 			if pid > 0 {
-				_ = syscall.Kill(-pid, syscall.SIGKILL)
+				_ = agentproc.KillProcessGroup(pid)
 			}
 		} else if pid > 0 {
-			_ = syscall.Kill(-pid, syscall.SIGTERM)
+			_ = agentproc.TerminateProcessGroup(pid)
 		}
 
 		go func() {
 			deadline := time.Now().Add(grace)
 			for time.Now().Before(deadline) {
-				if !processAlive(pid) {
+				if !agentproc.Alive(pid) {
 					ms.mu.Lock()
 					ms.info.State = SessionStateStopped
 					ms.info.StoppedAt = nowUTC()
@@ -781,8 +1317,8 @@ func (s *Supervisor) Stop(sessionID string, force bool) error {
 				}
 				time.Sleep(100 * time.Millisecond)
 			}
-			if !force && pid > 0 && processAlive(pid) {
-				_ = syscall.Kill(-pid, syscall.SIGKILL)
+			if !force && pid > 0 && agentproc.Alive(pid) {
+				_ = agentproc.KillProcessGroup(pid)
 			}
 			ms.mu.Lock()
 			ms.info.State = SessionStateStopped
@@ -800,6 +1336,12 @@ func (s *Supervisor) Stop(sessionID string, force bool) error {
 	stdin := ms.stdin
 	ms.mu.Unlock()
 
+	// A session waiting to restart after a crash has no process to signal.
+	select {
+	case ms.restartWake <- struct{}{}:
+	default:
+	}
+
 	// Closing stdin signals EOF to stream-JSON providers that read from stdin.
 	if stdin != nil {
 		_ = stdin.Close()
@@ -807,12 +1349,12 @@ func (s *Supervisor) Stop(sessionID string, force bool) error {
 
 	if force {
 		if pid > 0 {
-			_ = syscall.Kill(-pid, syscall.SIGKILL)
+			_ = agentproc.KillProcessGroup(pid)
 		}
 		return nil
 	}
 	if pid > 0 {
-		_ = syscall.Kill(-pid, syscall.SIGTERM)
+		_ = agentproc.TerminateProcessGroup(pid)
 	}
 
 	go func() {
@@ -822,47 +1364,212 @@ func (s *Supervisor) Stop(sessionID string, force bool) error {
 		pid := ms.cmd.Process.Pid
 		ms.mu.Unlock()
 		if state == SessionStateStopping && pid > 0 {
-			_ = syscall.Kill(-pid, syscall.SIGKILL)
+			_ = agentproc.KillProcessGroup(pid)
 		}
 	}()
 	return nil
 }
 
+// InputAck describes an input accepted by SendInput.
+type InputAck struct {
+	// ID correlates the input with the ChunkTypeInputAcked event and the
+	// output chunks that respond to it.
+	ID string
+	// Bytes is the number of bytes written to the agent, or queued.
+	Bytes int
+	// Queued is set when the input waits for the current stream-JSON
+	// response to complete.
+	Queued bool
+	// Seq is the seq of the ChunkTypeInputAcked event, from which a
+	// subscriber can read the response.
+	Seq uint64
+}
+
+// WriteInput sends data to the session's agent and returns the number of
+// bytes accepted. See SendInput.
 func (s *Supervisor) WriteInput(sessionID, clientID string, data []byte) (int, error) {
-	if err := s.policy.ValidateInputBytes(data); err != nil {
-		return 0, err
+	ack, err := s.SendInput(context.Background(), sessionID, clientID, data)
+	return ack.Bytes, err
+}
+
+// SendInput sends data to the session's agent on behalf of the active
+// writer. Each accepted input gets an ID that is announced in a
+// ChunkTypeInputAcked chunk and carried by the output that follows it.
+// The chunk also carries ctx's request ID (see logging.RequestID). data is
+// checked against the content policy as a whole; a *PolicyViolationError
+// is returned when it is blocked.
+func (s *Supervisor) SendInput(ctx context.Context, sessionID, clientID string, data []byte) (InputAck, error) {
+	policy := s.currentPolicy()
+	if err := policy.ValidateInputBytes(data); err != nil {
+		return InputAck{}, err
+	}
+	if policy.ContentPolicy.checks(ContentDirectionInput) {
+		s.mu.RLock()
+		ms, ok := s.sessions[sessionID]
+		s.mu.RUnlock()
+		if !ok {
+			return InputAck{}, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+		}
+		// Only the writer's input is checked, so that a client that may not
+		// write records no violations.
+		ms.mu.Lock()
+		err := ms.checkWriterLocked(clientID)
+		streamJSON := ms.streamJSON
+		ms.mu.Unlock()
+		if err != nil {
+			return InputAck{}, err
+		}
+		if data, err = s.checkInput(ctx, policy, ms, clientID, data, streamJSON); err != nil {
+			return InputAck{}, err
+		}
 	}
+	return s.sendInput(ctx, policy, sessionID, clientID, uuid.NewString(), data, nil)
+}
+
+// CheckStreamInputSize reports whether a streamed input of n bytes is
+// within the policy, so that a stream can be refused before all of it
+// arrives.
+func (s *Supervisor) CheckStreamInputSize(n int) error {
+	policy := s.currentPolicy()
+	return policy.ValidateStreamInputSize(n)
+}
+
+// SendStreamInput is SendInput for an input reassembled from a
+// SendInputStream. It is capped by MaxStreamInputBytes instead of
+// MaxInputBytes and is delivered to the agent as a single input.
+func (s *Supervisor) SendStreamInput(ctx context.Context, sessionID, clientID string, data []byte) (InputAck, error) {
+	policy := s.currentPolicy()
+	if err := policy.ValidateStreamInputSize(len(data)); err != nil {
+		return InputAck{}, err
+	}
+	return s.sendPrompt(ctx, policy, sessionID, clientID, data, nil)
+}
+
+// sendInput delivers data to the agent as input id. attachments are the
+// repo paths reported with the ChunkTypeInputAcked chunk.
+func (s *Supervisor) sendInput(ctx context.Context, policy Policy, sessionID, clientID, id string, data []byte, attachments []string) (InputAck, error) {
+	requestID := logging.RequestID(ctx)
 	s.mu.RLock()
 	ms, ok := s.sessions[sessionID]
 	s.mu.RUnlock()
 	if !ok {
-		return 0, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+		return InputAck{}, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
 	}
 	ms.mu.Lock()
-	if ms.recovered {
+	if err := ms.checkWriterLocked(clientID); err != nil {
 		ms.mu.Unlock()
-		return 0, ErrSessionRecoveryUnavailable
+		return InputAck{}, err
 	}
-	if ms.info.ActiveWriterClientID == "" {
-		ms.mu.Unlock()
-		return 0, ErrClientNotAttached
-	}
-	if ms.info.ActiveWriterClientID != clientID {
+	if err := s.checkBudget(ms.info.ProjectID); err != nil {
 		ms.mu.Unlock()
-		return 0, ErrClientMismatch
+		return InputAck{}, err
 	}
 	ms.lastActivity = time.Now()
 	streamJSON := ms.streamJSON
 	stdin := ms.stdin
 	ptmx := ms.ptmx
+	ack := InputAck{ID: id}
+	if streamJSON && policy.InputQueueDepth > 0 {
+		if ms.responding {
+			if len(ms.pending) >= policy.InputQueueDepth {
+				ms.mu.Unlock()
+				return InputAck{}, fmt.Errorf("%w: %d inputs waiting", ErrInputQueueFull, len(ms.pending))
+			}
+			ms.pending = append(ms.pending, pendingInput{id: ack.ID, data: append([]byte(nil), data...)})
+			ms.mu.Unlock()
+			ack.Seq = s.appendInputAcked(ms, ack.ID, attachments, requestID)
+			ack.Bytes, ack.Queued = len(data), true
+			return ack, nil
+		}
+		ms.responding = true
+	}
+	ms.activeInput = ack.ID
 	ms.mu.Unlock()
-	slog.Debug("provider input", "session_id", sessionID, "provider", ms.info.Provider, "bytes", len(data), "data", string(data))
+	ack.Seq = s.appendInputAcked(ms, ack.ID, attachments, requestID)
+	logger().Debug("provider input", "session_id", sessionID, "provider", ms.info.Provider, "input_id", ack.ID, logging.RequestIDKey, requestID, "bytes", len(data), "data", string(data))
+	var err error
 	if streamJSON {
-		n, err := stdin.Write(data)
-		return n, err
+		ack.Bytes, err = stdin.Write(data)
+	} else {
+		ack.Bytes, err = ptmx.Write(data)
+	}
+	ms.mu.Lock()
+	if ack.Bytes > 0 {
+		ms.recordInput(ack.ID, s.redactor.RedactBytes(data[:ack.Bytes]))
+	} else if err != nil {
+		ms.responding = false
 	}
-	n, err := ptmx.Write(data)
-	return n, err
+	ms.mu.Unlock()
+	return ack, err
+}
+
+// checkWriterLocked reports why clientID may not send input to the session,
+// if it may not. The caller holds ms.mu.
+func (ms *managedSession) checkWriterLocked(clientID string) error {
+	switch {
+	case ms.recovered:
+		return ErrSessionRecoveryUnavailable
+	case ms.info.ActiveWriterClientID == "":
+		return ErrClientNotAttached
+	case ms.info.ActiveWriterClientID != clientID:
+		return ErrClientMismatch
+	}
+	return nil
+}
+
+// appendInputAcked announces an accepted input, and the repo paths of any
+// files sent with it, to observers and the replay buffer. It returns the
+// announcement's seq.
+func (s *Supervisor) appendInputAcked(ms *managedSession, inputID string, attachments []string, requestID string) uint64 {
+	return s.emitChunk(ms, OutputChunk{Type: ChunkTypeInputAcked, InputID: inputID, Attachments: attachments, RequestID: requestID})
+}
+
+// deliverPending writes the next queued input once a stream-JSON response
+// completes, or marks the session idle when nothing is queued. An input
+// that cannot be written is answered with a ChunkTypeError chunk instead,
+// and the one queued behind it is tried.
+func (s *Supervisor) deliverPending(ms *managedSession) {
+	for {
+		ms.mu.Lock()
+		if len(ms.pending) == 0 {
+			ms.responding = false
+			ms.activeInput = ""
+			ms.mu.Unlock()
+			return
+		}
+		next := ms.pending[0]
+		ms.pending = ms.pending[1:]
+		ms.activeInput = next.id
+		stdin := ms.stdin
+		ms.mu.Unlock()
+		n, err := stdin.Write(next.data)
+		ms.mu.Lock()
+		if n > 0 {
+			ms.recordInput(next.id, s.redactor.RedactBytes(next.data[:n]))
+		}
+		ms.mu.Unlock()
+		if err == nil {
+			return
+		}
+		logger().Warn("queued input write failed", "session_id", ms.info.SessionID, "provider", ms.info.Provider, "input_id", next.id, "error", err)
+		// The agent will not answer an input it did not get.
+		payload := s.redactor.RedactBytes([]byte(fmt.Sprintf("queued input not delivered: %v", err)))
+		s.emitChunk(ms, OutputChunk{Payload: payload, Type: ChunkTypeError, InputID: next.id})
+	}
+}
+
+// abandonResponse ends an in-flight stream-JSON response that will not
+// complete with a result, marking the session idle. Queued inputs are
+// dropped: they were sent after a turn the client has given up on. It
+// returns the number of inputs dropped.
+func (ms *managedSession) abandonResponse() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	dropped := len(ms.pending)
+	ms.responding = false
+	ms.activeInput = ""
+	ms.pending = nil
+	return dropped
 }
 
 func (s *Supervisor) Resize(sessionID, clientID string, cols, rows uint32) error {
@@ -894,7 +1601,74 @@ func (s *Supervisor) Resize(sessionID, clientID string, cols, rows uint32) error
 	if streamJSON {
 		return nil // no PTY to resize for stream-JSON sessions
 	}
-	return pty.Setsize(ptmx, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+	return ptmx.Resize(uint16(cols), uint16(rows))
+}
+
+// CancelResponse interrupts the agent's in-flight response without stopping
+// the session. PTY agents receive Ctrl-C through the terminal, exactly as if
+// a user had typed it. Stream-JSON agents have no terminal: those whose
+// dialect has an interrupt message (claude) are sent it on stdin, since a
+// signal would end the whole conversation; one-shot agents are sent SIGINT.
+// A cancelled stream-JSON response may never produce a result, so the
+// session is marked idle and inputs queued behind it are dropped.
+func (s *Supervisor) CancelResponse(sessionID, clientID string) error {
+	s.mu.RLock()
+	ms, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	ms.mu.Lock()
+	if ms.recovered {
+		ms.mu.Unlock()
+		return ErrSessionRecoveryUnavailable
+	}
+	if ms.info.ActiveWriterClientID == "" {
+		ms.mu.Unlock()
+		return ErrClientNotAttached
+	}
+	if ms.info.ActiveWriterClientID != clientID {
+		ms.mu.Unlock()
+		return ErrClientMismatch
+	}
+	ms.lastActivity = time.Now()
+	ms.mu.Unlock()
+	logger().Info("cancelling agent response", "session_id", sessionID, "provider", ms.info.Provider)
+	return s.interruptResponse(ms)
+}
+
+// interruptResponse interrupts the agent's in-flight response as described
+// for CancelResponse.
+func (s *Supervisor) interruptResponse(ms *managedSession) error {
+	ms.mu.Lock()
+	sessionID := ms.info.SessionID
+	streamJSON := ms.streamJSON
+	ptmx := ms.ptmx
+	stdin := ms.stdin
+	jsonFormat := ms.jsonFormat
+	cmd := ms.cmd
+	ms.mu.Unlock()
+	if !streamJSON {
+		_, err := ptmx.Write([]byte{0x03})
+		return err
+	}
+	if msg := streamInterruptFor(jsonFormat); msg != nil {
+		if _, err := stdin.Write(msg); err != nil {
+			if errors.Is(err, os.ErrClosed) || errors.Is(err, syscall.EPIPE) {
+				return fmt.Errorf("%w: %q", ErrSessionNotRunning, sessionID)
+			}
+			return err
+		}
+	} else if err := agentproc.Interrupt(cmd.Process); err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return fmt.Errorf("%w: %q", ErrSessionNotRunning, sessionID)
+		}
+		return err
+	}
+	if dropped := ms.abandonResponse(); dropped > 0 {
+		logger().Info("dropped queued input after cancel", "session_id", sessionID, "provider", ms.info.Provider, "inputs", dropped)
+	}
+	return nil
 }
 
 // Attach connects clientID to the session. role controls whether the client
@@ -909,26 +1683,31 @@ func (s *Supervisor) Attach(sessionID, clientID string, afterSeq uint64, role At
 	ms, ok := s.sessions[sessionID]
 	s.mu.RUnlock()
 	if !ok {
-		// For stopped/failed sessions that were persisted in a previous daemon
-		// lifetime, serve the stored chunks in read-only mode (no live channel).
-		if state, err := s.attachHistory(sessionID, clientID, afterSeq); state != nil || err != ErrSessionNotFound {
-			return state, err
-		}
-		return nil, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+		// For stopped/failed sessions that were archived or persisted in a
+		// previous daemon lifetime, serve the stored chunks in read-only mode
+		// (no live channel).
+		return s.attachHistory(sessionID, clientID, afterSeq)
 	}
 
 	ms.mu.Lock()
+	if ms.archived {
+		// Archived after it was looked up; serve it from the archive.
+		ms.mu.Unlock()
+		return s.attachHistory(sessionID, clientID, afterSeq)
+	}
 	defer ms.mu.Unlock()
 
 	if ms.recovered {
 		oldest := ms.buf.OldestSeq()
 		last := ms.buf.LastSeq()
+		spilled, replay := ms.buf.Replay(afterSeq)
 		closed := make(chan OutputChunk)
 		close(closed)
 		return &AttachState{
 			ClientID:     clientID,
 			Role:         AttachRoleObserver,
-			Replay:       ms.buf.After(afterSeq),
+			Replay:       replay,
+			Spilled:      spilled,
 			Live:         closed,
 			ReplayGap:    oldest > 0 && afterSeq > 0 && afterSeq < oldest-1,
 			OldestSeq:    oldest,
@@ -937,6 +1716,7 @@ func (s *Supervisor) Attach(sessionID, clientID string, afterSeq uint64, role At
 			ExitCode:     ms.info.ExitCode,
 			Cols:         ms.info.Cols,
 			Rows:         ms.info.Rows,
+			StreamJSON:   ms.streamJSON,
 		}, nil
 	}
 
@@ -978,10 +1758,12 @@ func (s *Supervisor) Attach(sessionID, clientID string, afterSeq uint64, role At
 
 	oldest := ms.buf.OldestSeq()
 	last := ms.buf.LastSeq()
+	spilled, replay := ms.buf.Replay(afterSeq)
 	return &AttachState{
 		ClientID:     clientID,
 		Role:         role,
-		Replay:       ms.buf.After(afterSeq),
+		Replay:       replay,
+		Spilled:      spilled,
 		Live:         liveCh,
 		ReplayGap:    oldest > 0 && afterSeq > 0 && afterSeq < oldest-1,
 		OldestSeq:    oldest,
@@ -990,6 +1772,7 @@ func (s *Supervisor) Attach(sessionID, clientID string, afterSeq uint64, role At
 		ExitCode:     ms.info.ExitCode,
 		Cols:         ms.info.Cols,
 		Rows:         ms.info.Rows,
+		StreamJSON:   ms.streamJSON,
 	}, nil
 }
 
@@ -1034,6 +1817,7 @@ func (s *Supervisor) Detach(sessionID, clientID string) error {
 		ms.info.ActiveWriterClientID = ""
 		ms.info.Attached = false
 		ms.info.AttachedClientID = ""
+		ms.controlTaken = false
 	}
 	ms.info.ObserverCount = s.countObservers(ms)
 	if len(ms.observers) == 0 && ms.info.State == SessionStateAttached {
@@ -1057,6 +1841,13 @@ func (s *Supervisor) Get(sessionID string) (*SessionInfo, error) {
 	if ok {
 		return &info, nil
 	}
+	// Archived sessions from a previous daemon lifetime are not in history
+	// unless a store is configured.
+	if s.archive != nil {
+		if session, err := s.archive.Load(sessionID); err == nil {
+			return &session.Info, nil
+		}
+	}
 	return nil, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
 }
 
@@ -1112,7 +1903,8 @@ type ClaimWriterResult struct {
 // ClaimWriter promotes clientID to the active-writer slot. If force is true
 // and another client holds the slot, that client is evicted (its channel is
 // not closed here; the server must send them a WRITER_RELEASED event). Returns
-// ErrWriterConflict when force is false and the slot is taken.
+// ErrWriterConflict when force is false and the slot is taken, or when its
+// holder took control with TakeControl.
 func (s *Supervisor) ClaimWriter(sessionID, clientID string, force bool) (*ClaimWriterResult, error) {
 	s.mu.RLock()
 	ms, ok := s.sessions[sessionID]
@@ -1122,7 +1914,14 @@ func (s *Supervisor) ClaimWriter(sessionID, clientID string, force bool) (*Claim
 	}
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
+	if ms.controlTaken && ms.info.ActiveWriterClientID != clientID {
+		force = false
+	}
+	return s.claimWriterLocked(ms, clientID, force)
+}
 
+// claimWriterLocked moves the writer slot to clientID. ms.mu must be held.
+func (s *Supervisor) claimWriterLocked(ms *managedSession, clientID string, force bool) (*ClaimWriterResult, error) {
 	if ms.recovered {
 		return nil, ErrSessionRecoveryUnavailable
 	}
@@ -1155,6 +1954,7 @@ func (s *Supervisor) ClaimWriter(sessionID, clientID string, force bool) (*Claim
 		ms.info.State = SessionStateAttached
 	}
 	ms.info.ObserverCount = s.countObservers(ms)
+	ms.controlTaken = false
 	return &ClaimWriterResult{PreviousWriterClientID: prevWriter}, nil
 }
 
@@ -1184,6 +1984,7 @@ func (s *Supervisor) ReleaseWriter(sessionID, clientID string) error {
 	ms.info.ActiveWriterClientID = ""
 	ms.info.Attached = false
 	ms.info.AttachedClientID = ""
+	ms.controlTaken = false
 	ms.info.ObserverCount = s.countObservers(ms)
 	if len(ms.observers) == 0 && ms.info.State == SessionStateAttached {
 		ms.info.State = SessionStateRunning
@@ -1191,11 +1992,20 @@ func (s *Supervisor) ReleaseWriter(sessionID, clientID string) error {
 	return nil
 }
 
+// currentInput returns the ID of the input output is attributed to.
+func (ms *managedSession) currentInput() string {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.activeInput
+}
+
 func (ms *managedSession) snapshotInfo() SessionInfo {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	info := ms.info
 	info.OldestSeq = ms.buf.OldestSeq()
 	info.LastSeq = ms.buf.LastSeq()
+	info.BufferBytes, info.BufferCapacity = ms.buf.Size()
+	info.PendingApprovals = slices.Clone(ms.approvals)
 	return info
 }
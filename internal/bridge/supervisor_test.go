@@ -3,14 +3,22 @@ package bridge
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
-	"syscall"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
+
+	"github.com/markcallen/ai-agent-bridge/internal/agentproc"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
 )
 
 type testProvider struct {
@@ -73,13 +81,23 @@ func TestSupervisorSessionLifecycle(t *testing.T) {
 		t.Fatalf("Resize wrong client error=%v want %v", err, ErrClientMismatch)
 	}
 
-	if _, err := supervisor.WriteInput("session-a", "client-a", []byte("hello\n")); err != nil {
-		t.Fatalf("WriteInput: %v", err)
+	ack, err := supervisor.SendInput(logging.ContextWithRequestID(context.Background(), "req-7"), "session-a", "client-a", []byte("hello\n"))
+	if err != nil {
+		t.Fatalf("SendInput: %v", err)
+	}
+	if ack.ID == "" || ack.Bytes != 6 || ack.Queued {
+		t.Fatalf("ack=%+v", ack)
 	}
 	chunk := waitForChunk(t, state.Live, "hello")
 	if !bytes.Contains(chunk.Payload, []byte("hello")) {
 		t.Fatalf("chunk payload=%q does not contain hello", string(chunk.Payload))
 	}
+	if chunk.InputID != ack.ID {
+		t.Fatalf("output InputID=%q want %q", chunk.InputID, ack.ID)
+	}
+	if first := supervisor.sessions["session-a"].buf.After(0)[0]; first.Type != ChunkTypeInputAcked || first.InputID != ack.ID || first.RequestID != "req-7" {
+		t.Fatalf("first chunk=%+v, want INPUT_ACKED for %s from req-7", first, ack.ID)
+	}
 
 	if err := supervisor.Resize("session-a", "client-a", 100, 40); err != nil {
 		t.Fatalf("Resize: %v", err)
@@ -299,13 +317,13 @@ func TestSupervisorLoadHistoryRecoversRunningProcess(t *testing.T) {
 	}
 
 	cmd := exec.Command("/bin/sh", "-c", "sleep 30")
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	agentproc.SetProcessGroup(cmd)
 	if err := cmd.Start(); err != nil {
 		t.Fatalf("start helper process: %v", err)
 	}
 	t.Cleanup(func() {
 		if cmd.Process != nil {
-			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+			_ = agentproc.KillProcessGroup(cmd.Process.Pid)
 			_, _ = cmd.Process.Wait()
 		}
 	})
@@ -517,6 +535,7 @@ func TestReadLoopStreamJSONParsing(t *testing.T) {
 	lines := []string{
 		`{"type":"content_block_delta","delta":{"type":"text_delta","text":"hello world"}}`,
 		`{"type":"content_block_delta","delta":{"type":"thinking_delta","thinking":"deep thought"}}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"Bash","input":{}}}`,
 		`not json at all`,
 		`{"type":"other_event"}`,
 	}
@@ -529,20 +548,22 @@ func TestReadLoopStreamJSONParsing(t *testing.T) {
 	}()
 
 	// readLoopStreamJSON blocks until EOF; closeLive closes ms.live on return.
-	sup.readLoopStreamJSON(ms, pr)
+	sup.readLoopStreamJSON(ms, pr, nil, nil)
 
 	chunks := ms.buf.After(0)
 	if len(chunks) == 0 {
 		t.Fatal("expected chunks in buffer, got none")
 	}
 
-	var textChunks, thinkingChunks, rawChunks []OutputChunk
+	var textChunks, thinkingChunks, toolChunks, rawChunks []OutputChunk
 	for _, c := range chunks {
 		switch c.Type {
 		case ChunkTypeOutput:
 			textChunks = append(textChunks, c)
 		case ChunkTypeThinking:
 			thinkingChunks = append(thinkingChunks, c)
+		case ChunkTypeToolUse:
+			toolChunks = append(toolChunks, c)
 		}
 		rawChunks = append(rawChunks, c)
 	}
@@ -561,6 +582,13 @@ func TestReadLoopStreamJSONParsing(t *testing.T) {
 		t.Errorf("expected 'hello world' in ChunkTypeOutput chunks, got %v", textChunks)
 	}
 
+	// tool_use content block → ChunkTypeToolUse
+	if len(toolChunks) != 1 || string(toolChunks[0].Payload) != "Bash" {
+		t.Errorf("tool chunks = %v, want one naming Bash", toolChunks)
+	} else if got := string(toolChunks[0].Data); got != `{"id":"toolu_1","name":"Bash"}` {
+		t.Errorf("tool data = %s, want the call without its empty input", got)
+	}
+
 	// thinking_delta → ChunkTypeThinking
 	if len(thinkingChunks) == 0 {
 		t.Error("expected at least one ChunkTypeThinking chunk")
@@ -605,7 +633,7 @@ func TestReadLoopStreamJSONHandlesLargeLines(t *testing.T) {
 		_ = pw.Close()
 	}()
 
-	sup.readLoopStreamJSON(ms, pr)
+	sup.readLoopStreamJSON(ms, pr, nil, nil)
 
 	chunks := ms.buf.After(0)
 	if len(chunks) != 1 {
@@ -804,6 +832,84 @@ func TestSupervisorFallbackProvider(t *testing.T) {
 	})
 }
 
+// variantTestProvider implements VariantProvider over testProviders; the
+// first variant is the default.
+type variantTestProvider struct {
+	testProvider
+	names    []string
+	variants map[string]*testProvider
+}
+
+func (p *variantTestProvider) Variants() []string { return p.names }
+func (p *variantTestProvider) Variant(name string) (Provider, string, error) {
+	if name == "" {
+		name = p.names[0]
+	}
+	v, ok := p.variants[name]
+	if !ok {
+		return nil, "", fmt.Errorf("no variant %q", name)
+	}
+	return v, name, nil
+}
+
+func TestSupervisorProviderVariants(t *testing.T) {
+	registry := NewRegistry()
+	_ = registry.Register(&variantTestProvider{
+		testProvider: testProvider{id: "agent"},
+		names:        []string{"stable", "canary", "nightly"},
+		variants: map[string]*testProvider{
+			"stable":  {id: "agent@stable"},
+			"canary":  {id: "agent@canary"},
+			"nightly": {id: "agent@nightly", healthErr: errors.New("not installed")},
+		},
+	})
+	_ = registry.Register(&testProvider{id: "plain"})
+	supervisor := NewSupervisor(registry, DefaultPolicy(), 1024, time.Minute)
+	defer supervisor.Close()
+	repo := t.TempDir()
+
+	start := func(id string, opts map[string]string, fallbacks ...string) (*SessionInfo, error) {
+		t.Helper()
+		info, err := supervisor.Start(context.Background(), SessionConfig{
+			ProjectID: "project-a",
+			SessionID: id,
+			RepoPath:  repo,
+			Options:   opts,
+			Fallbacks: fallbacks,
+		})
+		if err == nil {
+			t.Cleanup(func() {
+				_ = supervisor.Stop(id, true)
+				waitForStopped(t, supervisor, id)
+			})
+		}
+		return info, err
+	}
+
+	for variant, want := range map[string]string{"": "stable", "canary": "canary"} {
+		info, err := start("s-"+want, map[string]string{"provider": "agent", "variant": variant})
+		if err != nil {
+			t.Fatalf("Start variant %q: %v", variant, err)
+		}
+		if info.Provider != "agent" || info.Variant != want {
+			t.Fatalf("Start variant %q: provider=%q variant=%q, want agent/%s", variant, info.Provider, info.Variant, want)
+		}
+		if got, _ := supervisor.Get("s-" + want); got == nil || got.Variant != want {
+			t.Fatalf("Get(s-%s) = %+v, want variant %s", want, got, want)
+		}
+	}
+	if _, err := start("s-unknown", map[string]string{"provider": "agent", "variant": "beta"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("Start unknown variant err = %v, want %v", err, ErrInvalidArgument)
+	}
+	if _, err := start("s-plain", map[string]string{"provider": "plain", "variant": "canary"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("Start variant of plain provider err = %v, want %v", err, ErrInvalidArgument)
+	}
+	// A fallback without the variant cannot stand in for it.
+	if _, err := start("s-nightly", map[string]string{"provider": "agent", "variant": "nightly"}, "plain"); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("Start unavailable variant err = %v, want %v", err, ErrProviderUnavailable)
+	}
+}
+
 // stripANSITestProvider wraps testProvider and implements StripANSIProvider.
 type stripANSITestProvider struct {
 	testProvider
@@ -926,6 +1032,56 @@ func startTestSession(t *testing.T, sup *Supervisor, sessionID string) *SessionI
 	return info
 }
 
+func TestPartialRuneLen(t *testing.T) {
+	for in, want := range map[string]int{
+		"":                 0,
+		"ascii":            0,
+		"caf\xc3\xa9":      0,
+		"caf\xc3":          1,
+		"\xe2\x82":         2,
+		"\xf0\x9f\x98":     3,
+		"\xf0\x9f\x98\x80": 0,
+		"bad\xff":          0,
+		"bad\x80":          0,
+	} {
+		if got := partialRuneLen([]byte(in)); got != want {
+			t.Fatalf("partialRuneLen(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestReadLoopKeepsRunesWhole(t *testing.T) {
+	sup := NewSupervisor(NewRegistry(), DefaultPolicy(), 64*1024, time.Minute)
+	defer sup.Close()
+	ms := &managedSession{buf: NewByteBuffer(64 * 1024), info: SessionInfo{SessionID: "test-utf8"}}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	go func() {
+		// "é" and "😀" are each cut in two between writes.
+		for _, piece := range []string{"caf\xc3", "\xa9 \xf0\x9f", "\x98\x80 ok\n", "end\xe2"} {
+			_, _ = w.Write([]byte(piece))
+			time.Sleep(10 * time.Millisecond)
+		}
+		_ = w.Close()
+	}()
+	sup.readLoop(ms, r)
+
+	var all []byte
+	for _, c := range ms.buf.After(0) {
+		all = append(all, c.Payload...)
+		if !utf8.Valid(c.Payload) && !bytes.HasSuffix(c.Payload, []byte("\xe2")) {
+			t.Fatalf("chunk %d splits a rune: %q", c.Seq, c.Payload)
+		}
+	}
+	// A rune cut off by the end of the output is still delivered.
+	if string(all) != "café 😀 ok\nend\xe2" {
+		t.Fatalf("output = %q", all)
+	}
+}
+
 func TestMultiObserverFanOut(t *testing.T) {
 	sup := newTestSupervisor(t)
 	startTestSession(t, sup, "fan-out")
@@ -1004,6 +1160,49 @@ func TestClaimWriterForce(t *testing.T) {
 	}
 }
 
+func TestTakeControl(t *testing.T) {
+	sup := newTestSupervisor(t)
+	startTestSession(t, sup, "takeover")
+
+	if _, err := sup.Attach("takeover", "bot", 0, AttachRoleWriter); err != nil {
+		t.Fatalf("Attach bot: %v", err)
+	}
+	state, err := sup.Attach("takeover", "human", 0, AttachRoleObserver)
+	if err != nil {
+		t.Fatalf("Attach human: %v", err)
+	}
+
+	result, err := sup.TakeControl(context.Background(), "takeover", "human", "fixing the migration by hand")
+	if err != nil {
+		t.Fatalf("TakeControl: %v", err)
+	}
+	if result.PreviousWriterClientID != "bot" {
+		t.Errorf("PreviousWriterClientID=%q want bot", result.PreviousWriterClientID)
+	}
+	chunk := waitForChunkType(t, state.Live, ChunkTypeControlChanged)
+	var change ControlChange
+	if err := json.Unmarshal(chunk.Data, &change); err != nil || string(chunk.Payload) != "human" ||
+		change != (ControlChange{From: "bot", To: "human", Reason: "fixing the migration by hand"}) {
+		t.Fatalf("control change %s %s, err %v", chunk.Payload, chunk.Data, err)
+	}
+
+	// The evicted writer cannot force its way back while control is held.
+	if _, err := sup.ClaimWriter("takeover", "bot", true); !errors.Is(err, ErrWriterConflict) {
+		t.Fatalf("forced ClaimWriter after TakeControl err=%v want %v", err, ErrWriterConflict)
+	}
+	if _, err := sup.WriteInput("takeover", "bot", []byte("x")); !errors.Is(err, ErrClientMismatch) {
+		t.Fatalf("WriteInput from evicted writer err=%v want %v", err, ErrClientMismatch)
+	}
+
+	// Once the controller releases, a forced claim works again.
+	if err := sup.ReleaseWriter("takeover", "human"); err != nil {
+		t.Fatalf("ReleaseWriter: %v", err)
+	}
+	if _, err := sup.ClaimWriter("takeover", "bot", true); err != nil {
+		t.Fatalf("ClaimWriter after release: %v", err)
+	}
+}
+
 func TestClaimWriterNoForceConflict(t *testing.T) {
 	sup := newTestSupervisor(t)
 	startTestSession(t, sup, "claim-noforce")
@@ -1212,3 +1411,423 @@ func TestControlEventSeqIsZero(t *testing.T) {
 		t.Fatal("timed out waiting for control chunk")
 	}
 }
+
+func TestReadLoopStreamJSONAccumulatesUsage(t *testing.T) {
+	sup := NewSupervisor(NewRegistry(), DefaultPolicy(), 64*1024, time.Minute)
+	defer sup.Close()
+
+	liveCh := make(chan OutputChunk, 100)
+	ms := &managedSession{
+		buf: NewByteBuffer(64 * 1024),
+		observers: map[string]*observerEntry{
+			"test-client": {ch: liveCh, role: AttachRoleWriter},
+		},
+		info: SessionInfo{SessionID: "test-usage"},
+	}
+
+	// total_cost_usd is the process's running total, so the second response
+	// cost 0.25 as well.
+	first := `{"type":"result","total_cost_usd":0.25,"duration_ms":1500,"usage":{"input_tokens":10,"cache_read_input_tokens":90,"output_tokens":20}}`
+	second := `{"type":"result","total_cost_usd":0.5,"duration_ms":1500,"usage":{"input_tokens":10,"cache_read_input_tokens":90,"output_tokens":20}}`
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte(first + "\n" + second + "\n"))
+		_ = pw.Close()
+	}()
+	sup.readLoopStreamJSON(ms, pr, nil, nil)
+
+	want := Usage{InputTokens: 200, OutputTokens: 40, CostUSD: 0.5, Duration: 3 * time.Second, Turns: 2}
+	if got := ms.snapshotInfo().Usage; got != want {
+		t.Fatalf("usage=%+v want %+v", got, want)
+	}
+	for _, c := range ms.buf.After(0) {
+		if c.Type == ChunkTypeUsage {
+			t.Fatal("usage events must not be buffered")
+		}
+	}
+
+	var last *Usage
+	for c := range liveCh {
+		if c.Type == ChunkTypeUsage {
+			last = c.Usage
+		}
+	}
+	if last == nil || *last != want {
+		t.Fatalf("last usage event=%+v want %+v", last, want)
+	}
+}
+
+func TestWriteInputQueuesWhileResponding(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.InputQueueDepth = 1
+	sup := NewSupervisor(NewRegistry(), policy, 64*1024, time.Minute)
+	defer sup.Close()
+
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+	ms := &managedSession{
+		buf:        NewByteBuffer(64 * 1024),
+		observers:  map[string]*observerEntry{},
+		stdin:      stdinW,
+		streamJSON: true,
+		info:       SessionInfo{SessionID: "queue", ActiveWriterClientID: "writer"},
+	}
+	sup.mu.Lock()
+	sup.sessions["queue"] = ms
+	sup.mu.Unlock()
+
+	received := make(chan string, 10)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := stdinR.Read(buf)
+			if err != nil {
+				return
+			}
+			received <- string(buf[:n])
+		}
+	}()
+	loopDone := make(chan struct{})
+	go func() {
+		defer close(loopDone)
+		sup.readLoopStreamJSON(ms, stdoutR, nil, nil)
+	}()
+	defer func() {
+		_ = stdoutW.Close()
+		<-loopDone
+		// The session has no process for Close to stop.
+		sup.mu.Lock()
+		delete(sup.sessions, "queue")
+		sup.mu.Unlock()
+	}()
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-received:
+			if got != want {
+				t.Fatalf("agent received %q want %q", got, want)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+	write := func(data string) error {
+		t.Helper()
+		n, err := sup.WriteInput("queue", "writer", []byte(data))
+		if err == nil && n != len(data) {
+			t.Fatalf("WriteInput n=%d want %d", n, len(data))
+		}
+		return err
+	}
+	result := []byte(`{"type":"result"}` + "\n")
+
+	if err := write("first\n"); err != nil {
+		t.Fatalf("WriteInput first: %v", err)
+	}
+	expect("first\n")
+	if err := write("second\n"); err != nil {
+		t.Fatalf("WriteInput second: %v", err)
+	}
+	if err := write("third\n"); !errors.Is(err, ErrInputQueueFull) {
+		t.Fatalf("WriteInput third err=%v want ErrInputQueueFull", err)
+	}
+	select {
+	case got := <-received:
+		t.Fatalf("queued input %q delivered before the response completed", got)
+	default:
+	}
+
+	// Completing the first response delivers the queued input.
+	_, _ = stdoutW.Write(result)
+	expect("second\n")
+
+	// Once the queue drains the session is idle and input goes straight through.
+	_, _ = stdoutW.Write(result)
+	time.Sleep(50 * time.Millisecond)
+	if err := write("fourth\n"); err != nil {
+		t.Fatalf("WriteInput fourth: %v", err)
+	}
+	expect("fourth\n")
+
+	inputs := ms.inputs
+	if len(inputs) != 3 || string(inputs[1].Data) != "second\n" {
+		t.Fatalf("input log=%+v, want first, second, fourth", inputs)
+	}
+
+	// Each response is attributed to the input it answers, including the
+	// queued one.
+	var acked, completed []string
+	for _, c := range ms.buf.After(0) {
+		switch c.Type {
+		case ChunkTypeInputAcked:
+			acked = append(acked, c.InputID)
+		case ChunkTypeResponseComplete:
+			completed = append(completed, c.InputID)
+		}
+	}
+	want := []string{inputs[0].ID, inputs[1].ID, inputs[2].ID}
+	if !slices.Equal(acked, want) {
+		t.Fatalf("acked=%v want %v", acked, want)
+	}
+	if !slices.Equal(completed, want[:2]) {
+		t.Fatalf("response complete ids=%v want %v", completed, want[:2])
+	}
+}
+
+// brokenStdin is an agent stdin whose writes fail once broken is set.
+type brokenStdin struct {
+	broken atomic.Bool
+}
+
+func (w *brokenStdin) Write(p []byte) (int, error) {
+	if w.broken.Load() {
+		return 0, io.ErrClosedPipe
+	}
+	return len(p), nil
+}
+
+func (w *brokenStdin) Close() error { return nil }
+
+func TestQueuedInputWriteFailure(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.InputQueueDepth = 1
+	sup := NewSupervisor(NewRegistry(), policy, 64*1024, time.Minute)
+	defer sup.Close()
+
+	stdin := &brokenStdin{}
+	stdoutR, stdoutW := io.Pipe()
+	ms := &managedSession{
+		buf:        NewByteBuffer(64 * 1024),
+		observers:  map[string]*observerEntry{},
+		stdin:      stdin,
+		streamJSON: true,
+		info:       SessionInfo{SessionID: "broken", ActiveWriterClientID: "writer"},
+	}
+	sup.mu.Lock()
+	sup.sessions["broken"] = ms
+	sup.mu.Unlock()
+	loopDone := make(chan struct{})
+	go func() {
+		defer close(loopDone)
+		sup.readLoopStreamJSON(ms, stdoutR, nil, nil)
+	}()
+	defer func() {
+		_ = stdoutW.Close()
+		<-loopDone
+		sup.mu.Lock()
+		delete(sup.sessions, "broken")
+		sup.mu.Unlock()
+	}()
+
+	if _, err := sup.SendInput(context.Background(), "broken", "writer", []byte("first\n")); err != nil {
+		t.Fatalf("SendInput first: %v", err)
+	}
+	queued, err := sup.SendInput(context.Background(), "broken", "writer", []byte("second\n"))
+	if err != nil || !queued.Queued {
+		t.Fatalf("SendInput second ack=%+v err=%v, want queued", queued, err)
+	}
+
+	// The agent's stdin breaks before the queued input is written.
+	stdin.broken.Store(true)
+	_, _ = stdoutW.Write([]byte(`{"type":"result"}` + "\n"))
+	deadline := time.Now().Add(3 * time.Second)
+	var failed *OutputChunk
+	for failed == nil && time.Now().Before(deadline) {
+		for _, c := range ms.buf.After(0) {
+			if c.Type == ChunkTypeError {
+				failed = &c
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if failed == nil {
+		t.Fatal("no error chunk for the undelivered input")
+	}
+	if failed.InputID != queued.ID || !bytes.Contains(failed.Payload, []byte("closed pipe")) {
+		t.Fatalf("error chunk=%+v, want input %s", failed, queued.ID)
+	}
+	// The session is idle again: the next input is written, not queued
+	// behind a response that will never come.
+	ms.mu.Lock()
+	responding, active := ms.responding, ms.activeInput
+	ms.mu.Unlock()
+	if responding || active != "" {
+		t.Fatalf("responding=%v activeInput=%q after failed delivery", responding, active)
+	}
+	if ack, err := sup.SendInput(context.Background(), "broken", "writer", []byte("third\n")); err == nil || ack.Queued {
+		t.Fatalf("SendInput third ack=%+v err=%v, want a write error", ack, err)
+	}
+}
+
+func TestCancelResponseDropsQueuedInput(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&streamJSONScriptProvider{scriptProvider{
+		testProvider: testProvider{id: "agent"},
+		script: `echo ready; while read -r line; do
+			case "$line" in *control_request*) ;; *) echo "got $line";; esac
+		done`,
+	}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	policy := DefaultPolicy()
+	policy.InputQueueDepth = 1
+	sup := NewSupervisor(registry, policy, 64*1024, time.Minute)
+	defer sup.Close()
+	if _, err := sup.Start(context.Background(), SessionConfig{
+		ProjectID: "proj",
+		SessionID: "cancel-queue",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "agent"},
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	state, err := sup.Attach("cancel-queue", "client-a", 0, AttachRoleWriter)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if !bytes.Contains(bytes.Join(chunkPayloads(state.Replay), nil), []byte("ready")) {
+		waitForChunk(t, state.Live, "ready")
+	}
+
+	send := func(data string) InputAck {
+		t.Helper()
+		ack, err := sup.SendInput(context.Background(), "cancel-queue", "client-a", []byte(data))
+		if err != nil {
+			t.Fatalf("SendInput %q: %v", data, err)
+		}
+		return ack
+	}
+	send("first\n")
+	waitForChunk(t, state.Live, "got first")
+	if ack := send("second\n"); !ack.Queued {
+		t.Fatal("second input not queued behind the first response")
+	}
+
+	// The agent never answers with a result; cancelling must still let the
+	// next input through, and the queued one is dropped.
+	if err := sup.CancelResponse("cancel-queue", "client-a"); err != nil {
+		t.Fatalf("CancelResponse: %v", err)
+	}
+	if ack := send("third\n"); ack.Queued {
+		t.Fatal("input after cancel was queued")
+	}
+	if c := waitForChunk(t, state.Live, "got "); !bytes.Contains(c.Payload, []byte("got third")) {
+		t.Fatalf("agent output %q, want the input sent after cancel", c.Payload)
+	}
+}
+
+// scriptProvider runs a shell script as the agent.
+type scriptProvider struct {
+	testProvider
+	script string
+}
+
+func (p *scriptProvider) BuildCommand(ctx context.Context, cfg SessionConfig) (*exec.Cmd, error) {
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", p.script)
+	cmd.Dir = cfg.RepoPath
+	return cmd, nil
+}
+
+type streamJSONScriptProvider struct{ scriptProvider }
+
+func (p *streamJSONScriptProvider) IsStreamJSON() bool { return true }
+
+type openCodeScriptProvider struct{ streamJSONScriptProvider }
+
+func (p *openCodeScriptProvider) JSONFormat() string { return JSONFormatOpenCode }
+
+func TestCancelResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		want     func(OutputChunk) bool
+	}{
+		{
+			name: "pty",
+			provider: &scriptProvider{
+				testProvider: testProvider{id: "agent"},
+				script:       `trap 'echo interrupted' INT; echo ready; while :; do sleep 0.1; done`,
+			},
+			want: func(c OutputChunk) bool { return bytes.Contains(c.Payload, []byte("interrupted")) },
+		},
+		{
+			// claude stays running: the interrupt arrives on stdin, and a
+			// SIGINT would end the conversation.
+			name: "stream-json",
+			provider: &streamJSONScriptProvider{scriptProvider{
+				testProvider: testProvider{id: "agent"},
+				script: `trap 'exit 1' INT; echo ready; while read -r line; do
+					case "$line" in *'"subtype":"interrupt"'*) echo '{"type":"result"}';; esac
+				done`,
+			}},
+			want: func(c OutputChunk) bool { return c.Type == ChunkTypeResponseComplete },
+		},
+		{
+			name: "one-shot stream-json",
+			provider: &openCodeScriptProvider{streamJSONScriptProvider{scriptProvider{
+				testProvider: testProvider{id: "agent"},
+				script:       `trap 'echo "{\"type\":\"step_finish\"}"' INT; echo ready; while :; do sleep 0.1; done`,
+			}}},
+			want: func(c OutputChunk) bool { return c.Type == ChunkTypeResponseComplete },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			if err := registry.Register(tt.provider); err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+			sup := NewSupervisor(registry, DefaultPolicy(), 64*1024, time.Minute)
+			defer sup.Close()
+			if _, err := sup.Start(context.Background(), SessionConfig{
+				ProjectID: "proj",
+				SessionID: "cancel-1",
+				RepoPath:  t.TempDir(),
+				Options:   map[string]string{"provider": "agent"},
+			}); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			state, err := sup.Attach("cancel-1", "client-a", 0, AttachRoleWriter)
+			if err != nil {
+				t.Fatalf("Attach: %v", err)
+			}
+			// The trap is installed once the script prints "ready".
+			if !bytes.Contains(bytes.Join(chunkPayloads(state.Replay), nil), []byte("ready")) {
+				waitForChunk(t, state.Live, "ready")
+			}
+
+			if err := sup.CancelResponse("cancel-1", "client-b"); !errors.Is(err, ErrClientMismatch) {
+				t.Fatalf("CancelResponse wrong client err=%v want %v", err, ErrClientMismatch)
+			}
+			if err := sup.CancelResponse("cancel-1", "client-a"); err != nil {
+				t.Fatalf("CancelResponse: %v", err)
+			}
+			timeout := time.After(3 * time.Second)
+			for done := false; !done; {
+				select {
+				case c := <-state.Live:
+					done = tt.want(c)
+				case <-timeout:
+					t.Fatal("timed out waiting for the agent to handle the interrupt")
+				}
+			}
+
+			info, err := sup.Get("cancel-1")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if info.ExitRecorded {
+				t.Fatalf("session exited after CancelResponse: %+v", info)
+			}
+		})
+	}
+}
+
+func chunkPayloads(chunks []OutputChunk) [][]byte {
+	out := make([][]byte, len(chunks))
+	for i, c := range chunks {
+		out[i] = c.Payload
+	}
+	return out
+}
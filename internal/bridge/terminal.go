@@ -0,0 +1,63 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+)
+
+// terminalLineEnd holds the keys that end a line of terminal input: Enter,
+// Ctrl-C and Ctrl-D.
+const terminalLineEnd = "\r\n\x03\x04"
+
+// defaultTerminalLineMax bounds the keystrokes a TerminalInput holds when
+// the policy sets no MaxInputBytes.
+const defaultTerminalLineMax = 64 << 10
+
+// TerminalInput writes keystrokes from a terminal emulator to a PTY
+// session. A content rule cannot match text typed a key at a time, so
+// while the content policy checks input, keystrokes are held until a key
+// in terminalLineEnd and the line up to it is sent as one input, checked
+// like any other. A line the policy blocks is not sent at all. Until then
+// the agent has not seen the keys, so they are not echoed. A TerminalInput
+// is not safe for concurrent use.
+type TerminalInput struct {
+	s         *Supervisor
+	sessionID string
+	clientID  string
+	held      []byte
+}
+
+// NewTerminalInput returns a TerminalInput that writes to sessionID on
+// behalf of clientID.
+func (s *Supervisor) NewTerminalInput(sessionID, clientID string) *TerminalInput {
+	return &TerminalInput{s: s, sessionID: sessionID, clientID: clientID}
+}
+
+// Write sends data to the agent, or holds it until the end of its line.
+// It returns a *PolicyViolationError when the content policy blocks the
+// line. Keys held when the terminal detaches are dropped.
+func (t *TerminalInput) Write(ctx context.Context, data []byte) error {
+	policy := t.s.currentPolicy()
+	if len(t.held) == 0 && !policy.ContentPolicy.checks(ContentDirectionInput) {
+		_, err := t.s.SendInput(ctx, t.sessionID, t.clientID, data)
+		return err
+	}
+	t.held = append(t.held, data...)
+	n := bytes.LastIndexAny(t.held, terminalLineEnd) + 1
+	if n == 0 {
+		limit := policy.MaxInputBytes
+		if limit <= 0 {
+			limit = defaultTerminalLineMax
+		}
+		if len(t.held) < limit {
+			return nil
+		}
+		// A line this long is checked as it stands rather than held
+		// without bound.
+		n = len(t.held)
+	}
+	line := t.held[:n]
+	t.held = append([]byte(nil), t.held[n:]...)
+	_, err := t.s.SendInput(ctx, t.sessionID, t.clientID, line)
+	return err
+}
@@ -0,0 +1,331 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxInputRecords bounds the per-session input log kept for transcripts.
+const maxInputRecords = 4096
+
+// InputRecord is one WriteInput call kept for transcript export. AfterSeq is
+// the last output seq at the time of the write, which orders the input
+// relative to the output chunks.
+type InputRecord struct {
+	ID        string `json:",omitempty"`
+	AfterSeq  uint64
+	Timestamp time.Time
+	Data      []byte
+}
+
+// recordInput appends data to the session's input log and drops records
+// that precede the oldest retained output chunk. Caller must hold ms.mu.
+func (ms *managedSession) recordInput(id string, data []byte) {
+	ms.inputs = append(ms.inputs, InputRecord{
+		ID:        id,
+		AfterSeq:  ms.buf.LastSeq(),
+		Timestamp: nowUTC(),
+		Data:      append([]byte(nil), data...),
+	})
+	oldest := ms.buf.OldestSeq()
+	drop := 0
+	for drop < len(ms.inputs) && oldest > 0 && ms.inputs[drop].AfterSeq+1 < oldest {
+		drop++
+	}
+	if over := len(ms.inputs) - drop - maxInputRecords; over > 0 {
+		drop += over
+	}
+	if drop > 0 {
+		ms.inputs = append([]InputRecord(nil), ms.inputs[drop:]...)
+	}
+}
+
+// Transcript formats accepted by RenderTranscript.
+const (
+	TranscriptMarkdown = "markdown"
+	TranscriptJSONL    = "jsonl"
+)
+
+// oscEscape matches OSC sequences (e.g. terminal title updates), which the
+// CSI-oriented ansiEscape pattern only partially removes.
+var oscEscape = regexp.MustCompile(`\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// transcriptEvent is one entry of a rendered transcript, with inputs merged
+// into the output stream in seq order.
+type transcriptEvent struct {
+	// SessionID is set in conversation transcripts, whose seqs are per
+	// session.
+	SessionID string          `json:"session_id,omitempty"`
+	Seq       uint64          `json:"seq"`
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Text      string          `json:"text,omitempty"`
+	Severity  string          `json:"severity,omitempty"`
+	InputID   string          `json:"input_id,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// transcriptEvents merges a session's inputs and output chunks. An input is
+// placed after the output chunk whose seq it recorded.
+func transcriptEvents(h *ArchivedSession) []transcriptEvent {
+	events := make([]transcriptEvent, 0, len(h.Chunks)+len(h.Inputs))
+	inputs := h.Inputs
+	flushInputs := func(before uint64) {
+		for len(inputs) > 0 && inputs[0].AfterSeq < before {
+			in := inputs[0]
+			events = append(events, transcriptEvent{Seq: in.AfterSeq, Type: "input", Timestamp: in.Timestamp, Text: string(in.Data), InputID: in.ID})
+			inputs = inputs[1:]
+		}
+	}
+	for _, c := range h.Chunks {
+		flushInputs(c.Seq)
+		if c.Type == ChunkTypeInputAcked {
+			// The input itself is rendered from h.Inputs.
+			continue
+		}
+		ev := transcriptEvent{Seq: c.Seq, Timestamp: c.Timestamp, Text: string(c.Payload), InputID: c.InputID, Data: c.Data}
+		switch c.Type {
+		case ChunkTypeThinking:
+			ev.Type = "thinking"
+		case ChunkTypeResponseComplete:
+			ev.Type = "response_complete"
+		case ChunkTypeStderr:
+			ev.Type = "stderr"
+			ev.Severity = c.Severity.String()
+		case ChunkTypeToolUse:
+			ev.Type = "tool_use"
+		case ChunkTypeApprovalRequested:
+			ev.Type = "approval_requested"
+		case ChunkTypeApprovalResolved:
+			ev.Type = "approval_resolved"
+		case ChunkTypeControlChanged:
+			ev.Type = "control_changed"
+		case ChunkTypeBudgetExceeded:
+			ev.Type = "budget_exceeded"
+		case ChunkTypeOutputTruncated:
+			ev.Type = "output_truncated"
+		case ChunkTypePolicyViolation:
+			ev.Type = "policy_violation"
+		case ChunkTypeError:
+			ev.Type = "error"
+		case ChunkTypeSessionRestarted:
+			ev.Type = "session_restarted"
+			if r := c.Restart; r != nil {
+				ev.Text = fmt.Sprintf("agent exited with code %d and was restarted (restart %d)", r.ExitCode, r.Count)
+			}
+		default:
+			ev.Type = "output"
+		}
+		events = append(events, ev)
+	}
+	flushInputs(^uint64(0))
+	return events
+}
+
+// RenderTranscript renders a session transcript in the given format.
+// Markdown groups user prompts and agent responses into sections and omits
+// provider stderr; JSONL emits one JSON object per input or output event.
+func RenderTranscript(h *ArchivedSession, format string) ([]byte, error) {
+	switch format {
+	case TranscriptMarkdown, "":
+		return renderMarkdown(h), nil
+	case TranscriptJSONL:
+		return renderJSONL(h)
+	default:
+		return nil, fmt.Errorf("%w: unsupported transcript format %q", ErrInvalidArgument, format)
+	}
+}
+
+func renderJSONL(h *ArchivedSession) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, ev := range transcriptEvents(h) {
+		if err := enc.Encode(ev); err != nil {
+			return nil, fmt.Errorf("encode transcript event seq=%d: %w", ev.Seq, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func renderMarkdown(h *ArchivedSession) []byte {
+	var b strings.Builder
+	writeMarkdown(&b, h, 1)
+	return []byte(b.String())
+}
+
+// writeMarkdown writes the Markdown transcript of h to b, headed at the
+// given heading level.
+func writeMarkdown(b *strings.Builder, h *ArchivedSession, level int) {
+	heading := strings.Repeat("#", level)
+	info := h.Info
+	fmt.Fprintf(b, "%s Session %s\n\n", heading, info.SessionID)
+	fmt.Fprintf(b, "- Project: %s\n", info.ProjectID)
+	fmt.Fprintf(b, "- Provider: %s\n", info.Provider)
+	if !info.CreatedAt.IsZero() {
+		fmt.Fprintf(b, "- Started: %s\n", info.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	if !info.StoppedAt.IsZero() {
+		fmt.Fprintf(b, "- Stopped: %s\n", info.StoppedAt.UTC().Format(time.RFC3339))
+	}
+	if u := info.Usage; u != (Usage{}) {
+		fmt.Fprintf(b, "- Usage: %d input tokens, %d output tokens, $%.4f over %d turns\n", u.InputTokens, u.OutputTokens, u.CostUSD, u.Turns)
+	}
+
+	// section is "user", "agent" or "thinking"; text accumulates until the
+	// section changes.
+	var (
+		section string
+		text    strings.Builder
+	)
+	flush := func() {
+		body := strings.TrimSpace(text.String())
+		text.Reset()
+		if body == "" {
+			return
+		}
+		switch section {
+		case "user":
+			fmt.Fprintf(b, "\n%s# User\n\n%s\n", heading, body)
+		case "agent":
+			fmt.Fprintf(b, "\n%s# Agent\n\n%s\n", heading, body)
+		case "thinking":
+			fmt.Fprintf(b, "\n<details><summary>Thinking</summary>\n\n%s\n\n</details>\n", body)
+		}
+	}
+	enter := func(next string) {
+		if section != next {
+			flush()
+			section = next
+		}
+	}
+	for _, ev := range transcriptEvents(h) {
+		switch ev.Type {
+		case "input":
+			enter("user")
+			text.WriteString(inputText(ev.Text))
+			text.WriteByte('\n')
+		case "thinking":
+			enter("thinking")
+			text.WriteString(cleanTerminalText(ev.Text))
+		case "response_complete":
+			flush()
+			section = ""
+		case "tool_use":
+			enter("agent")
+			fmt.Fprintf(&text, "\n_Tool: %s_\n", ev.Text)
+		case "approval_requested":
+			enter("agent")
+			fmt.Fprintf(&text, "\n_Approval requested: %s_\n", ev.Text)
+		case "approval_resolved":
+			enter("agent")
+			text.WriteString(approvalText(ev))
+		case "control_changed":
+			flush()
+			section = ""
+			fmt.Fprintf(b, "\n_%s_\n", controlText(ev))
+		case "stderr":
+			// Provider diagnostics are not part of the conversation.
+		case "session_restarted", "budget_exceeded", "output_truncated", "policy_violation", "error":
+			flush()
+			section = ""
+			fmt.Fprintf(b, "\n_%s_\n", ev.Text)
+		default:
+			enter("agent")
+			text.WriteString(cleanTerminalText(ev.Text))
+		}
+	}
+	flush()
+}
+
+// approvalText describes an approval_resolved event.
+func approvalText(ev transcriptEvent) string {
+	var res ApprovalResolution
+	_ = json.Unmarshal(ev.Data, &res)
+	if res.Approved {
+		return fmt.Sprintf("\n_Approved %s (%s)_\n", ev.Text, res.ClientID)
+	}
+	return fmt.Sprintf("\n_Denied %s (%s): %s_\n", ev.Text, res.ClientID, res.Message)
+}
+
+// controlText describes a control_changed event.
+func controlText(ev transcriptEvent) string {
+	var change ControlChange
+	_ = json.Unmarshal(ev.Data, &change)
+	text := "Control taken by " + change.To
+	if change.From != "" {
+		text += " from " + change.From
+	}
+	if change.Reason != "" {
+		text += ": " + change.Reason
+	}
+	return text
+}
+
+// inputText extracts the prompt from one WriteInput payload. Stream-JSON
+// user messages are unwrapped to their text; anything else is treated as
+// terminal keystrokes.
+func inputText(data string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		var msg struct {
+			Type    string `json:"type"`
+			Message struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		}
+		if json.Unmarshal([]byte(line), &msg) != nil || msg.Type != "user" {
+			return cleanTerminalText(applyBackspaces(data))
+		}
+		var s string
+		if json.Unmarshal(msg.Message.Content, &s) == nil {
+			lines = append(lines, s)
+			continue
+		}
+		var blocks []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if json.Unmarshal(msg.Message.Content, &blocks) == nil {
+			for _, blk := range blocks {
+				if blk.Type == "text" {
+					lines = append(lines, blk.Text)
+				}
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// applyBackspaces applies DEL/BS keystrokes to the preceding characters.
+func applyBackspaces(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		if r == '\b' || r == 0x7f {
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// cleanTerminalText strips escape sequences and control characters other
+// than newlines and tabs, and normalises carriage returns to newlines.
+func cleanTerminalText(s string) string {
+	s = oscEscape.ReplaceAllString(s, "")
+	s = ansiEscape.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\n' && r != '\t' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,179 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// cloneTimeout bounds a clone-on-start.
+const cloneTimeout = 10 * time.Minute
+
+// cloneProtocols are the git transports a clone may use. Local paths and
+// file:// URLs are left out: they would let callers copy any repo the
+// daemon can read, whatever the allowed URL patterns say.
+var cloneProtocols = "https:http:ssh:git"
+
+// RepoSource is a git repo cloned into a managed workspace when a session
+// starts.
+type RepoSource struct {
+	URL string
+	// Ref is the branch or tag to check out. Empty uses the remote's
+	// default branch.
+	Ref string
+	// Depth limits the clone to that many commits. Zero clones the full
+	// history.
+	Depth int
+	// CleanOnStop removes the workspace as soon as the session stops rather
+	// than when it is archived.
+	CleanOnStop bool
+}
+
+// WithWorkspaces enables clone-on-start: sessions started with a RepoSource
+// are cloned into dir/<project>/<session>. Which URLs may be cloned, and how
+// much disk each project may use, comes from the policy.
+func WithWorkspaces(dir string) SupervisorOption {
+	return func(s *Supervisor) {
+		s.workspaceDir = dir
+	}
+}
+
+// provisionWorkspace clones src into the session's workspace and returns its
+// path. A failed clone leaves nothing behind.
+func (s *Supervisor) provisionWorkspace(ctx context.Context, cfg SessionConfig, policy Policy) (string, error) {
+	src := cfg.Source
+	if s.workspaceDir == "" || len(policy.CloneURLs) == 0 {
+		return "", fmt.Errorf("%w: cloning repos is not enabled", ErrPermissionDenied)
+	}
+	if src.URL == "" || strings.HasPrefix(src.URL, "-") {
+		return "", fmt.Errorf("%w: repo url %q is not valid", ErrInvalidArgument, src.URL)
+	}
+	if strings.HasPrefix(src.Ref, "-") {
+		return "", fmt.Errorf("%w: ref %q is not a branch or tag", ErrInvalidArgument, src.Ref)
+	}
+	if src.Depth < 0 {
+		return "", fmt.Errorf("%w: depth must be >= 0", ErrInvalidArgument)
+	}
+	if !cloneURLAllowed(policy.CloneURLs, src.URL) {
+		return "", fmt.Errorf("%w: repo url %q is not in workspaces.allowed_urls", ErrPermissionDenied, src.URL)
+	}
+	projectDir, err := s.projectWorkspaceDir(cfg.ProjectID)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsLocal(cfg.SessionID) || strings.ContainsRune(cfg.SessionID, filepath.Separator) {
+		return "", fmt.Errorf("%w: session_id %q cannot name a workspace", ErrInvalidArgument, cfg.SessionID)
+	}
+	if err := checkWorkspaceQuota(projectDir, policy.WorkspaceQuotaBytes); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		return "", fmt.Errorf("create workspace dir: %w", err)
+	}
+	workspace := filepath.Join(projectDir, cfg.SessionID)
+	if _, err := os.Lstat(workspace); err == nil {
+		return "", fmt.Errorf("%w: workspace for %q already exists", ErrSessionAlreadyExists, cfg.SessionID)
+	}
+
+	args := []string{"clone", "--quiet"}
+	if src.Depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", src.Depth))
+	}
+	if src.Ref != "" {
+		args = append(args, "--branch="+src.Ref)
+	}
+	args = append(args, "--", src.URL, workspace)
+	env := []string{"GIT_ALLOW_PROTOCOL=" + cloneProtocols}
+	if _, err := runGitTimeout(ctx, cloneTimeout, projectDir, env, args...); err != nil {
+		_ = os.RemoveAll(workspace)
+		return "", err
+	}
+	if err := checkWorkspaceQuota(projectDir, policy.WorkspaceQuotaBytes); err != nil {
+		_ = os.RemoveAll(workspace)
+		return "", err
+	}
+	logger().Info("session workspace cloned", "session_id", cfg.SessionID, "project_id", cfg.ProjectID, "url", src.URL, "ref", src.Ref, "path", workspace)
+	return workspace, nil
+}
+
+// removeWorkspace deletes the session's cloned workspace, if it has one.
+func (s *Supervisor) removeWorkspace(ms *managedSession) {
+	ms.mu.Lock()
+	workspace := ms.workspace
+	ms.workspace = ""
+	sessionID := ms.info.SessionID
+	ms.mu.Unlock()
+	if workspace == "" {
+		return
+	}
+	if err := os.RemoveAll(workspace); err != nil {
+		logger().Warn("failed to remove session workspace", "session_id", sessionID, "path", workspace, "error", err)
+		return
+	}
+	logger().Info("session workspace removed", "session_id", sessionID, "path", workspace)
+}
+
+// projectWorkspaceDir returns the directory holding a project's workspaces.
+// The project ID is escaped so that it is always a single path element.
+func (s *Supervisor) projectWorkspaceDir(projectID string) (string, error) {
+	name := url.PathEscape(projectID)
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("%w: project_id %q cannot name a workspace", ErrInvalidArgument, projectID)
+	}
+	return filepath.Join(s.workspaceDir, name), nil
+}
+
+func cloneURLAllowed(patterns []string, rawURL string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, rawURL); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWorkspaceQuota fails when the workspaces in projectDir use more than
+// quota bytes. The check runs before and after each clone, so concurrent
+// clones can overshoot the quota by up to one repo each.
+func checkWorkspaceQuota(projectDir string, quota int64) error {
+	if quota <= 0 {
+		return nil
+	}
+	used, err := diskUsage(projectDir)
+	if err != nil {
+		return fmt.Errorf("measure workspace usage: %w", err)
+	}
+	if used > quota {
+		return fmt.Errorf("%w: project workspaces use %d of %d bytes", ErrWorkspaceQuotaExceeded, used, quota)
+	}
+	return nil
+}
+
+// diskUsage sums the sizes of the regular files under dir. A missing dir
+// uses nothing.
+func diskUsage(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			fi, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			total += fi.Size()
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,146 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newWorkspaceTestSupervisor returns a supervisor that may clone upstream, a
+// local repo with one commit.
+func newWorkspaceTestSupervisor(t *testing.T) (sup *Supervisor, upstream, workspaces string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	saved := cloneProtocols
+	cloneProtocols += ":file"
+	t.Cleanup(func() { cloneProtocols = saved })
+
+	upstream = t.TempDir()
+	gitCmd(t, upstream, "init", "--quiet", "--initial-branch=main")
+	if err := os.WriteFile(filepath.Join(upstream, "README.md"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, upstream, "add", "README.md")
+	gitCmd(t, upstream, "-c", "user.name=Setup", "-c", "user.email=setup@example.com", "commit", "--quiet", "-m", "initial")
+
+	registry := NewRegistry()
+	if err := registry.Register(&testProvider{id: "fake"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	archive, err := NewFileArchive(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := DefaultPolicy()
+	// Cloned workspaces are not subject to allowed_paths.
+	policy.AllowedPaths = []string{filepath.Join(t.TempDir(), "*")}
+	policy.CloneURLs = []string{upstream}
+	workspaces = t.TempDir()
+	sup = NewSupervisor(registry, policy, 1024*1024, time.Minute, WithArchive(archive, time.Minute), WithWorkspaces(workspaces))
+	t.Cleanup(func() { sup.Close() })
+	return sup, upstream, workspaces
+}
+
+func startClonedSession(sup *Supervisor, sessionID string, src RepoSource) (*SessionInfo, error) {
+	return sup.Start(context.Background(), SessionConfig{
+		ProjectID: "project-test",
+		SessionID: sessionID,
+		Source:    &src,
+		Options:   map[string]string{"provider": "fake"},
+	})
+}
+
+func TestSupervisorClonesWorkspace(t *testing.T) {
+	sup, upstream, workspaces := newWorkspaceTestSupervisor(t)
+	const sessionID = "11111111-1111-1111-1111-111111111111"
+	if _, err := startClonedSession(sup, sessionID, RepoSource{URL: upstream, Ref: "main", Depth: 1}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	workspace := filepath.Join(workspaces, "project-test", sessionID)
+	data, _, err := sup.ReadFile(sessionID, "README.md")
+	if err != nil || string(data) != "hello\n" {
+		t.Fatalf("ReadFile = %q, %v", data, err)
+	}
+	if got := gitCmd(t, workspace, "rev-list", "--count", "HEAD"); got != "1" {
+		t.Fatalf("commits = %s", got)
+	}
+
+	if err := sup.Stop(sessionID, true); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	waitForStopped(t, sup, sessionID)
+	if _, err := os.Stat(workspace); err != nil {
+		t.Fatalf("workspace removed before archival: %v", err)
+	}
+	sup.archiveExpired(time.Now().Add(2 * time.Minute))
+	if _, err := os.Stat(workspace); !os.IsNotExist(err) {
+		t.Fatalf("workspace still exists after archival: %v", err)
+	}
+}
+
+func TestSupervisorCloneCleanOnStop(t *testing.T) {
+	sup, upstream, workspaces := newWorkspaceTestSupervisor(t)
+	const sessionID = "22222222-2222-2222-2222-222222222222"
+	if _, err := startClonedSession(sup, sessionID, RepoSource{URL: upstream, CleanOnStop: true}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := sup.Stop(sessionID, true); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	waitForStopped(t, sup, sessionID)
+	workspace := filepath.Join(workspaces, "project-test", sessionID)
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if _, err := os.Stat(workspace); os.IsNotExist(err) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("workspace still exists after stop")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestSupervisorClonePolicy(t *testing.T) {
+	sup, upstream, workspaces := newWorkspaceTestSupervisor(t)
+
+	if _, err := startClonedSession(sup, "33333333-3333-3333-3333-333333333333", RepoSource{URL: "https://example.com/other.git"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("unlisted url err = %v, want ErrPermissionDenied", err)
+	}
+	if _, err := startClonedSession(sup, "33333333-3333-3333-3333-333333333333", RepoSource{URL: upstream, Ref: "no-such-branch"}); !errors.Is(err, ErrGitFailed) {
+		t.Fatalf("missing ref err = %v, want ErrGitFailed", err)
+	}
+	if _, err := os.Stat(filepath.Join(workspaces, "project-test", "33333333-3333-3333-3333-333333333333")); !os.IsNotExist(err) {
+		t.Fatalf("failed clone left a workspace: %v", err)
+	}
+
+	// Local repos can only be cloned because the test allows the file
+	// transport.
+	cloneProtocols = "https:http:ssh:git"
+	if _, err := startClonedSession(sup, "33333333-3333-3333-3333-333333333333", RepoSource{URL: upstream}); !errors.Is(err, ErrGitFailed) {
+		t.Fatalf("local clone err = %v, want ErrGitFailed", err)
+	}
+	cloneProtocols += ":file"
+
+	policy := sup.currentPolicy()
+	policy.WorkspaceQuotaBytes = 1
+	sup.SetPolicy(policy)
+	if _, err := startClonedSession(sup, "44444444-4444-4444-4444-444444444444", RepoSource{URL: upstream}); !errors.Is(err, ErrWorkspaceQuotaExceeded) {
+		t.Fatalf("over quota err = %v, want ErrWorkspaceQuotaExceeded", err)
+	}
+	if _, err := os.Stat(filepath.Join(workspaces, "project-test", "44444444-4444-4444-4444-444444444444")); !os.IsNotExist(err) {
+		t.Fatalf("over-quota clone was kept: %v", err)
+	}
+
+	policy.CloneURLs = nil
+	sup.SetPolicy(policy)
+	if _, err := startClonedSession(sup, "55555555-5555-5555-5555-555555555555", RepoSource{URL: upstream}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("cloning disabled err = %v, want ErrPermissionDenied", err)
+	}
+}
@@ -2,14 +2,38 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"math"
+	"net"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"github.com/markcallen/ai-agent-bridge/internal/provider"
+	"github.com/markcallen/ai-agent-bridge/internal/secrets"
 	"gopkg.in/yaml.v3"
 )
 
+// envNamePattern matches a valid environment variable name.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// variantNamePattern matches the names of provider variants.
+var variantNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// promptTemplateNamePattern matches the names of prompt templates.
+var promptTemplateNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// versionPattern matches the min_version and max_version of providers.
+var versionPattern = regexp.MustCompile(`^v?\d+\.\d+(\.\d+)?(-[0-9A-Za-z.-]+)?$`)
+
 // Config is the top-level bridge daemon configuration.
 type Config struct {
 	Server       ServerConfig              `yaml:"server"`
@@ -18,12 +42,38 @@ type Config struct {
 	FeatureFlags FeatureFlagsConfig        `yaml:"feature_flags"`
 	Sessions     SessionsConfig            `yaml:"sessions"`
 	Input        InputConfig               `yaml:"input"`
+	Files        FilesConfig               `yaml:"files"`
+	Git          GitConfig                 `yaml:"git"`
+	Workspaces   WorkspacesConfig          `yaml:"workspaces"`
+	Secrets      SecretsConfig             `yaml:"secrets"`
 	RateLimits   RateLimitsConfig          `yaml:"rate_limits"`
 	Persistence  PersistenceConfig         `yaml:"persistence"`
 	Runtime      RuntimeConfig             `yaml:"runtime"`
 	Providers    map[string]ProviderConfig `yaml:"providers"`
 	AllowedPaths []string                  `yaml:"allowed_paths"`
-	Logging      LoggingConfig             `yaml:"logging"`
+	// ProjectProviders maps a project ID to the provider IDs it may use.
+	// Projects not listed may use any provider.
+	ProjectProviders map[string][]string `yaml:"project_providers"`
+	// ProjectLimits caps the resources of every agent a project starts, on
+	// top of any per-provider limits.
+	ProjectLimits map[string]ResourceLimitsConfig `yaml:"project_limits"`
+	// Projects declares per-project settings that replace the global ones
+	// for that project's sessions.
+	Projects map[string]ProjectConfig `yaml:"projects"`
+	// RestrictProjects refuses sessions from projects not listed in
+	// Projects.
+	RestrictProjects bool `yaml:"restrict_projects"`
+	// AllowedEnv lists environment variable names that StartSession callers
+	// may set per session. A trailing "*" matches any suffix.
+	AllowedEnv []string      `yaml:"allowed_env"`
+	Logging    LoggingConfig `yaml:"logging"`
+	// Schedules run a fixed prompt against repos on cron schedules.
+	Schedules []ScheduleConfig `yaml:"schedules"`
+	// PromptTemplates are prompts, by name, that clients send with
+	// WriteInput's template and vars instead of their text.
+	PromptTemplates map[string]PromptTemplateConfig `yaml:"prompt_templates"`
+	// ContentPolicy blocks or redacts prompts and agent output.
+	ContentPolicy ContentPolicyConfig `yaml:"content_policy"`
 }
 
 // RuntimeConfig controls how the bridge locates provider CLIs and the Node.js
@@ -36,28 +86,184 @@ type RuntimeConfig struct {
 }
 
 type ServerConfig struct {
-	Listen string `yaml:"listen"`
+	Listen      string            `yaml:"listen"`
+	Compression CompressionConfig `yaml:"compression"`
+	// Reflection serves the gRPC reflection service so that tools such as
+	// grpcurl can list and call RPCs without the proto files. Meant for
+	// development; defaults to false.
+	Reflection bool `yaml:"reflection"`
+	// HealthListen, when set, serves plain HTTP /livez and /readyz probes
+	// on this address (e.g. ":8080") for orchestrators that cannot speak
+	// gRPC health checks. The endpoints are unauthenticated.
+	HealthListen string `yaml:"health_listen"`
+	// UIListen, when set, serves the read-only web dashboard over plain
+	// HTTP on this address (e.g. "127.0.0.1:8090"). In secure mode each
+	// request needs a token with events:read; local mode only allows
+	// loopback addresses.
+	UIListen string `yaml:"ui_listen"`
+	// ShutdownDrain is how long the server reports not ready before it
+	// stops on SIGTERM, so load balancers stop routing new clients to it
+	// first. Empty or "0s" stops immediately.
+	ShutdownDrain string `yaml:"shutdown_drain"`
+	// InsecureDevMode runs the daemon in local mode, on a unix socket
+	// without TLS or authentication, for development. Listen must then be
+	// empty.
+	InsecureDevMode bool `yaml:"insecure_dev_mode"`
+}
+
+// CompressionConfig controls gzip compression of responses for clients that
+// request it. Level and MaxStreams bound the CPU spent compressing.
+type CompressionConfig struct {
+	// Enabled allows compressed responses. Defaults to true.
+	Enabled *bool `yaml:"enabled"`
+	// Level is the gzip level, 1 (fastest) to 9 (smallest). Zero keeps
+	// the gzip default (6).
+	Level int `yaml:"level"`
+	// MaxStreams caps how many streams are compressed at once. Zero means
+	// no cap.
+	MaxStreams int `yaml:"max_streams"`
 }
 
 type TLSConfig struct {
 	CABundle string `yaml:"ca_bundle"`
 	Cert     string `yaml:"cert"`
 	Key      string `yaml:"key"`
+	// CRL is a PEM CRL file; client certificates it lists are rejected.
+	CRL string `yaml:"crl"`
+	// ACME obtains the server certificate from an ACME CA instead of
+	// Cert/Key. Client certificates are still checked against CABundle.
+	ACME ACMEConfig `yaml:"acme"`
+}
+
+type ACMEConfig struct {
+	Domains []string `yaml:"domains"`
+	Email   string   `yaml:"email"`
+	// DirectoryURL defaults to Let's Encrypt production.
+	DirectoryURL string `yaml:"directory_url"`
+	// CacheDir holds the account key and certificates; defaults to
+	// <state dir>/acme.
+	CacheDir string `yaml:"cache_dir"`
+	// HTTPAddr, when set, serves HTTP-01 challenges on this address (e.g.
+	// ":80"). TLS-ALPN-01 is always answered on the gRPC listener.
+	HTTPAddr string `yaml:"http_addr"`
 }
 
 type AuthConfig struct {
 	JWTPublicKeys []JWTKeyConfig `yaml:"jwt_public_keys"`
 	JWTAudience   string         `yaml:"jwt_audience"`
 	JWTMaxTTL     string         `yaml:"jwt_max_ttl"`
+	// JWKSURL is a JWKS endpoint whose Ed25519 keys verify tokens carrying
+	// a kid header. JWKSIssuer, when set, limits those keys to one issuer.
+	JWKSURL             string `yaml:"jwks_url"`
+	JWKSIssuer          string `yaml:"jwks_issuer"`
+	JWKSRefreshInterval string `yaml:"jwks_refresh_interval"`
+	// OIDC accepts RS256/ES256 tokens from an external OpenID Connect
+	// provider. Disabled when Issuer is empty.
+	OIDC OIDCConfig `yaml:"oidc"`
+	// SPIFFE accepts SPIRE X.509 SVIDs as client certificates and maps
+	// their SPIFFE IDs to projects; such clients need no JWT.
+	SPIFFE SPIFFEConfig `yaml:"spiffe"`
+	// Kubernetes accepts projected service account tokens and maps their
+	// service accounts to projects. Disabled when Issuer is empty.
+	Kubernetes KubernetesConfig `yaml:"kubernetes"`
+	// AdminCertOU makes clients whose certificate carries this
+	// organizational unit admins for AdminService. Empty leaves only
+	// tokens with the admin scope.
+	AdminCertOU string `yaml:"admin_cert_ou"`
+	// CertBindings, when set, limit each client certificate to the
+	// projects its tokens may name. Certificates matching no binding
+	// cannot use tokens at all.
+	CertBindings []CertBindingConfig `yaml:"cert_bindings"`
+	// SessionTokenKey is the Ed25519 key session tokens are signed with: a
+	// PKCS#11 URI or a PEM file. Empty generates one in the state dir.
+	SessionTokenKey string `yaml:"session_token_key"`
+}
+
+// DefaultKubernetesJWKSURL is the API server's service account JWKS as
+// seen from inside the cluster.
+const DefaultKubernetesJWKSURL = "https://kubernetes.default.svc/openid/v1/jwks"
+
+type KubernetesConfig struct {
+	// Issuer is the cluster's --service-account-issuer.
+	Issuer string `yaml:"issuer"`
+	// Audience is the audience of the clients' projected token volumes.
+	Audience string `yaml:"audience"`
+	// JWKSURL defaults to the API server's in-cluster JWKS endpoint.
+	JWKSURL string `yaml:"jwks_url"`
+	// CAFile and TokenFile authenticate the JWKS fetch. With the default
+	// JWKSURL they default to the bridge pod's own service account mount;
+	// other endpoints are fetched with the system roots and no token
+	// unless they are set.
+	CAFile    string `yaml:"ca_file"`
+	TokenFile string `yaml:"token_file"`
+	// Projects maps "namespace/serviceaccount", or "namespace/*", to the
+	// project it may access.
+	Projects map[string]string `yaml:"projects"`
+}
+
+// CertBindingConfig binds client certificates to projects. A certificate
+// matches when every selector that is set matches.
+type CertBindingConfig struct {
+	CN string `yaml:"cn"`
+	OU string `yaml:"ou"`
+	// URI matches a URI SAN, such as a SPIFFE ID.
+	URI string `yaml:"uri"`
+	// Projects lists the project IDs tokens may name; "*" allows all.
+	Projects []string `yaml:"projects"`
+}
+
+type SPIFFEConfig struct {
+	TrustDomain string `yaml:"trust_domain"`
+	// Bundle is the PEM X.509 bundle of the trust domain.
+	Bundle string `yaml:"bundle"`
+	// Projects maps a SPIFFE ID to the project it may access.
+	Projects map[string]string `yaml:"projects"`
+}
+
+type OIDCConfig struct {
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// ProjectClaim names the token claim mapped to project_id.
+	ProjectClaim string `yaml:"project_claim"`
+	// JWKSURL overrides the jwks_uri from the issuer's discovery document.
+	JWKSURL string `yaml:"jwks_url"`
 }
 
 type FeatureFlagsConfig struct {
 	ProviderFallbacks bool `yaml:"provider_fallbacks"`
 }
 
+// JWTKeyConfig is one issuer public key. An issuer may have several, e.g.
+// the outgoing and incoming keys of a rotation.
 type JWTKeyConfig struct {
 	Issuer  string `yaml:"issuer"`
 	KeyPath string `yaml:"key_path"`
+	// KID matches the kid header of tokens signed with this key. Empty uses
+	// the key's JWK thumbprint, which the bridge's own issuers put there.
+	KID string `yaml:"kid"`
+	// NotBefore and NotAfter (RFC 3339) bound when the key is accepted, so
+	// that the old and new keys overlap during a rotation. Empty leaves that
+	// side open.
+	NotBefore string `yaml:"not_before"`
+	NotAfter  string `yaml:"not_after"`
+}
+
+// Window parses NotBefore and NotAfter; an empty value is the zero time.
+func (k JWTKeyConfig) Window() (notBefore, notAfter time.Time, err error) {
+	if k.NotBefore != "" {
+		if notBefore, err = time.Parse(time.RFC3339, k.NotBefore); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("not_before: %w", err)
+		}
+	}
+	if k.NotAfter != "" {
+		if notAfter, err = time.Parse(time.RFC3339, k.NotAfter); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("not_after: %w", err)
+		}
+	}
+	if !notBefore.IsZero() && !notAfter.IsZero() && !notAfter.After(notBefore) {
+		return time.Time{}, time.Time{}, fmt.Errorf("not_after must be after not_before")
+	}
+	return notBefore, notAfter, nil
 }
 
 type SessionsConfig struct {
@@ -68,10 +274,134 @@ type SessionsConfig struct {
 	EventBufferSize          int    `yaml:"event_buffer_size"`
 	MaxSubscribersPerSession int    `yaml:"max_subscribers_per_session"`
 	SubscriberTTL            string `yaml:"subscriber_ttl"`
+	// ArchiveTTL is how long a stopped session stays in memory before it is
+	// archived to disk and pruned.
+	ArchiveTTL string `yaml:"archive_ttl"`
+	// ArchiveRetention is how long archived sessions are kept on disk. "0"
+	// keeps them forever.
+	ArchiveRetention string `yaml:"archive_retention"`
+	// InputQueueDepth is how many inputs a stream-JSON session queues while
+	// the agent is responding. Zero delivers input immediately.
+	InputQueueDepth int `yaml:"input_queue_depth"`
+	// Restart relaunches sessions whose agent crashes.
+	Restart RestartConfig `yaml:"restart"`
+	// SpillToDisk moves output evicted from a session's event buffer to a
+	// per-session file instead of dropping it, so replay can start from
+	// any sequence number.
+	SpillToDisk bool `yaml:"spill_to_disk"`
+	// SpillMaxBytes caps each session's spill file; older output is deleted
+	// beyond it. Zero uses the default (256 MiB).
+	SpillMaxBytes int64 `yaml:"spill_max_bytes"`
+	// OutputFlushInterval merges adjacent output fragments into one event
+	// for up to this long (e.g. "50ms"). Empty or "0" publishes every
+	// fragment as it is read.
+	OutputFlushInterval string `yaml:"output_flush_interval"`
+	// OutputMaxChunkBytes caps a merged output event. Zero uses the
+	// default (8 KiB).
+	OutputMaxChunkBytes int `yaml:"output_max_chunk_bytes"`
+	// OutputLimits caps each response of an agent.
+	OutputLimits OutputLimitsConfig `yaml:"output_limits"`
+}
+
+// OutputLimitsConfig caps what an agent may produce in one response. Zero
+// fields are unlimited.
+type OutputLimitsConfig struct {
+	// MaxResponseBytes caps the output and thinking text of a response.
+	MaxResponseBytes int64 `yaml:"max_response_bytes"`
+	// MaxResponseEvents caps the output and thinking fragments and tool
+	// calls of a response.
+	MaxResponseEvents int `yaml:"max_response_events"`
+	// CancelResponse interrupts the agent once a limit is reached, instead
+	// of only dropping the rest of its response.
+	CancelResponse bool `yaml:"cancel_response"`
+}
+
+// RestartConfig controls relaunching crashed agents. Providers with
+// resume_args continue their thread when its ID is known.
+type RestartConfig struct {
+	// MaxRestarts is how many times one session may be relaunched. Zero
+	// (default) lets crashed sessions fail.
+	MaxRestarts int `yaml:"max_restarts"`
+	// Backoff is the wait before the first relaunch, doubling for each later
+	// one up to MaxBackoff (default 1m).
+	Backoff    string `yaml:"backoff"`
+	MaxBackoff string `yaml:"max_backoff"`
 }
 
 type InputConfig struct {
 	MaxSizeBytes int `yaml:"max_size_bytes"`
+	// MaxStreamBytes caps an input sent in chunks with SendInputStream.
+	MaxStreamBytes int `yaml:"max_stream_bytes"`
+	// MaxAttachmentBytes caps the inline attachment content of one
+	// WriteInput.
+	MaxAttachmentBytes int `yaml:"max_attachment_bytes"`
+}
+
+// FilesConfig limits the ReadFile and WriteFile RPCs.
+type FilesConfig struct {
+	MaxSizeBytes int64 `yaml:"max_size_bytes"`
+}
+
+// GitConfig controls the GitStatus, GitDiff and GitCommit RPCs.
+type GitConfig struct {
+	// AuthorName and AuthorEmail are the identity GitCommit commits as.
+	AuthorName  string `yaml:"author_name"`
+	AuthorEmail string `yaml:"author_email"`
+	// WatchInterval is how often running sessions' repos are checked for
+	// changes to report as REPO_DIRTY events. "0" disables the check.
+	WatchInterval string `yaml:"watch_interval"`
+}
+
+// WorkspacesConfig controls cloning repos for StartSession requests that
+// carry a repo_source.
+type WorkspacesConfig struct {
+	// Dir holds the cloned workspaces. Empty uses <state dir>/workspaces.
+	Dir string `yaml:"dir"`
+	// AllowedURLs lists glob patterns for the repo URLs that may be cloned.
+	// Empty disables cloning.
+	AllowedURLs []string `yaml:"allowed_urls"`
+	// ProjectQuotaBytes caps the disk used by each project's workspaces.
+	// 0 means unlimited.
+	ProjectQuotaBytes int64 `yaml:"project_quota_bytes"`
+	// MinFreeBytes is the free disk space below which readiness probes
+	// fail. 0 uses 1 GiB; negative disables the check.
+	MinFreeBytes int64 `yaml:"min_free_bytes"`
+}
+
+// SecretsConfig selects the backend that resolves the secret:// references
+// in providers.*.secret_env.
+type SecretsConfig struct {
+	// Backend is "env" (default), "file", "vault" or "aws".
+	Backend string `yaml:"backend"`
+	// EnvPrefix prefixes the variables the env backend reads. Defaults to
+	// "BRIDGE_SECRET_".
+	EnvPrefix string `yaml:"env_prefix"`
+	// Dir holds one file per secret for the file backend.
+	Dir   string           `yaml:"dir"`
+	Vault VaultConfig      `yaml:"vault"`
+	AWS   AWSSecretsConfig `yaml:"aws"`
+}
+
+// VaultConfig locates a HashiCorp Vault KV version 2 engine.
+type VaultConfig struct {
+	Address   string `yaml:"address"`
+	Mount     string `yaml:"mount"` // defaults to "secret"
+	Namespace string `yaml:"namespace"`
+	// TokenEnv names the daemon environment variable holding the Vault
+	// token. Defaults to VAULT_TOKEN.
+	TokenEnv string `yaml:"token_env"`
+	// TokenFile, when set, is read for the token on every lookup instead,
+	// e.g. a Vault Agent sink that keeps a renewed token in it.
+	TokenFile string `yaml:"token_file"`
+}
+
+// AWSSecretsConfig locates AWS Secrets Manager. Credentials are found like
+// the AWS SDKs do: environment, shared credentials file, web identity,
+// container role or instance role (see secrets.AWSCredentialChain).
+type AWSSecretsConfig struct {
+	// Region defaults to the daemon's AWS_REGION.
+	Region   string `yaml:"region"`
+	Endpoint string `yaml:"endpoint"`
 }
 
 type RateLimitsConfig struct {
@@ -81,26 +411,129 @@ type RateLimitsConfig struct {
 	StartSessionPerClientBurst int     `yaml:"start_session_per_client_burst"`
 	SendInputPerSessionRPS     float64 `yaml:"send_input_per_session_rps"`
 	SendInputPerSessionBurst   int     `yaml:"send_input_per_session_burst"`
+	// ProjectRPS and ProjectBurst size a bucket per project shared by its
+	// StartSession and WriteInput calls. Zero leaves projects unlimited.
+	ProjectRPS   float64 `yaml:"project_rps"`
+	ProjectBurst int     `yaml:"project_burst"`
+	// InteractiveReserve is the share of the global and project buckets
+	// that batch-priority input cannot use. Zero puts both lanes on an
+	// equal footing.
+	InteractiveReserve float64 `yaml:"interactive_reserve"`
 }
 
 type ProviderConfig struct {
-	Binary          string   `yaml:"binary"`
-	Mode            string   `yaml:"mode"` // deprecated: no longer supported; remove from config
-	Args            []string `yaml:"args"`
+	Binary string   `yaml:"binary"`
+	Mode   string   `yaml:"mode"` // deprecated: no longer supported; remove from config
+	Args   []string `yaml:"args"`
+	// ResumeArgs are appended to Args when a crashed agent is relaunched so
+	// that it continues its thread, named by a "{thread_id}" argument.
+	ResumeArgs []string `yaml:"resume_args"`
+	// ThreadIDArgs are appended to Args when an agent starts a new thread,
+	// to give it the ID in their "{thread_id}" argument.
+	ThreadIDArgs    []string `yaml:"thread_id_args"`
 	StartupTimeout  string   `yaml:"startup_timeout"`
 	ValidateStartup *bool    `yaml:"validate_startup"`
 	StartupProbe    string   `yaml:"startup_probe"`
 	RequiredEnv     []string `yaml:"required_env"`
-	PTY             *bool    `yaml:"pty"` // deprecated: PTY is the default; remove this field
-	StreamJSON      bool     `yaml:"stream_json"`
-	StripANSI       bool     `yaml:"strip_ansi"`
-	// PromptPattern is a regex matched against PTY output lines. When it
-	// matches the first time, AGENT_READY is emitted; on subsequent matches
-	// after output, RESPONSE_COMPLETE is emitted.
+	// EnvAllowlist names daemon environment variables the agent inherits on
+	// top of the safe defaults and RequiredEnv; "PREFIX_*" matches a prefix
+	// and "*" passes everything. EnvBlocklist is never inherited.
+	EnvAllowlist []string `yaml:"env_allowlist"`
+	EnvBlocklist []string `yaml:"env_blocklist"`
+	// SecretEnv maps variable names to secret:// references resolved with
+	// the secrets backend when an agent starts.
+	SecretEnv    map[string]string `yaml:"secret_env"`
+	PTY          *bool             `yaml:"pty"` // deprecated: PTY is the default; remove this field
+	StreamJSON   bool              `yaml:"stream_json"`
+	JSONFormat   string            `yaml:"json_format"` // "claude" (default) or "opencode"; requires stream_json
+	StripANSI    bool              `yaml:"strip_ansi"`
+	Sandbox      string            `yaml:"sandbox"`       // "none" (default), "bwrap" or "docker"
+	SandboxImage string            `yaml:"sandbox_image"` // container image; required for sandbox: docker
+	// SandboxBinds are extra absolute host paths a bwrap-sandboxed agent
+	// may read.
+	SandboxBinds []string `yaml:"sandbox_binds"`
+	// StderrClassifiers tag stream-JSON stderr lines with a severity. The
+	// first matching classifier wins; unmatched lines are warnings.
+	StderrClassifiers []StderrClassifierConfig `yaml:"stderr_classifiers"`
+	// OutputFilters post-process the agent's output text, in order:
+	// "strip_ansi", "collapse_cr" and "normalize_fences".
+	OutputFilters []string `yaml:"output_filters"`
+	// PromptPattern is a regex matched against PTY output. The agent is
+	// ready once its output first matches it.
 	PromptPattern string `yaml:"prompt_pattern"`
+	// ReadyTimeout is how long a session's agent may take to print a
+	// prompt matching PromptPattern. An agent that does not is killed and
+	// its session fails. Empty or zero waits forever.
+	ReadyTimeout string `yaml:"ready_timeout"`
+	// MinVersion and MaxVersion bound, inclusively, the version the binary
+	// prints with --version, e.g. "1.0" or "2.3.1". Outside them the
+	// provider is unavailable.
+	MinVersion string `yaml:"min_version"`
+	MaxVersion string `yaml:"max_version"`
+	// InstallHint is the command that installs Binary, shown when it
+	// cannot be found. The built-in CLIs (claude, codex, opencode, gemini)
+	// have one already.
+	InstallHint string `yaml:"install_hint"`
+	// Variants are alternative builds of the provider, e.g. stable and
+	// canary binaries, that sessions pick with the "variant" agent option.
+	// Each one inherits the fields above and overrides those it sets.
+	Variants map[string]ProviderVariantConfig `yaml:"variants"`
+	// DefaultVariant is the variant of sessions that do not pick one.
+	// Required with Variants.
+	DefaultVariant string `yaml:"default_variant"`
 	// Fallbacks is an ordered list of provider IDs to try when this provider
 	// is unavailable at session start time. At most 2 entries are allowed.
 	Fallbacks []string `yaml:"fallbacks"`
+	// Limits caps the resources of each agent process.
+	Limits ResourceLimitsConfig `yaml:"limits"`
+	// MCPConfigFlag is the flag the agent takes an MCP config file with,
+	// e.g. "--mcp-config" for claude. Projects' mcp_servers are passed to
+	// the agent only when it is set.
+	MCPConfigFlag string `yaml:"mcp_config_flag"`
+	// ApprovalArgs make a stream_json agent ask the bridge to approve tool
+	// calls, e.g. ["--permission-prompt-tool", "stdio"] for claude. They
+	// are passed to sessions of projects with require_approval.
+	ApprovalArgs []string `yaml:"approval_args"`
+}
+
+// ProviderVariantConfig overrides the fields of a provider for one of its
+// variants. Empty fields keep the provider's value.
+type ProviderVariantConfig struct {
+	Binary      string   `yaml:"binary"`
+	Args        []string `yaml:"args"`
+	MinVersion  string   `yaml:"min_version"`
+	MaxVersion  string   `yaml:"max_version"`
+	InstallHint string   `yaml:"install_hint"`
+}
+
+// Variant returns the definition of variant name of p: p with the
+// variant's fields applied and no variants of its own.
+func (p ProviderConfig) Variant(name string) ProviderConfig {
+	v := p.Variants[name]
+	p.Variants, p.DefaultVariant = nil, ""
+	if v.Binary != "" {
+		p.Binary = v.Binary
+	}
+	if v.Args != nil {
+		p.Args = v.Args
+	}
+	if v.MinVersion != "" {
+		p.MinVersion = v.MinVersion
+	}
+	if v.MaxVersion != "" {
+		p.MaxVersion = v.MaxVersion
+	}
+	if v.InstallHint != "" {
+		p.InstallHint = v.InstallHint
+	}
+	return p
+}
+
+// StderrClassifierConfig assigns Severity ("progress", "warning" or "error")
+// to stderr lines matching the Pattern regex.
+type StderrClassifierConfig struct {
+	Pattern  string `yaml:"pattern"`
+	Severity string `yaml:"severity"`
 }
 
 func (p ProviderConfig) ShouldValidateStartup() bool {
@@ -117,12 +550,205 @@ type PersistenceConfig struct {
 	// planned for a future release; this field is reserved for configuration
 	// compatibility.
 	ChunkStorageBytes int `yaml:"chunk_storage_bytes"`
+	// ArchiveDir is the directory that receives transcripts of archived
+	// sessions. Empty uses <state dir>/archive.
+	ArchiveDir string `yaml:"archive_dir"`
+	// ArchiveEncryptionKey, when set, encrypts archived transcripts at
+	// rest. It is a secret:// reference resolved with the secrets backend,
+	// or the path of a file; either holds a base64-encoded 32-byte key.
+	ArchiveEncryptionKey string `yaml:"archive_encryption_key"`
+	// ScheduleDir receives the Markdown transcripts of scheduled runs.
+	// Empty uses <state dir>/schedules.
+	ScheduleDir string `yaml:"schedule_dir"`
+}
+
+// ScheduleConfig starts a session for each repo whenever Cron fires, sends
+// Prompt, and saves the transcript once the agent has answered.
+type ScheduleConfig struct {
+	// Name identifies the schedule in logs and names its transcript
+	// directory.
+	Name string `yaml:"name"`
+	// Cron is a five-field cron expression or @daily-style shorthand,
+	// evaluated in the daemon's local time zone.
+	Cron      string   `yaml:"cron"`
+	ProjectID string   `yaml:"project_id"`
+	Provider  string   `yaml:"provider"`
+	RepoPaths []string `yaml:"repo_paths"`
+	Prompt    string   `yaml:"prompt"`
+	// Timeout bounds each repo's run. Empty uses 30m.
+	Timeout string `yaml:"timeout"`
+}
+
+// PromptTemplateConfig is a named prompt. Template is a Go text/template
+// whose data is the input's vars, e.g. "Review this diff:\n{{.diff}}".
+type PromptTemplateConfig struct {
+	// Description says what the prompt is for; it is not sent.
+	Description string `yaml:"description"`
+	Template    string `yaml:"template"`
+}
+
+// ContentPolicyConfig checks prompts sent to agents and the text agents
+// print. Rules run first, in order, then the classifier.
+type ContentPolicyConfig struct {
+	Rules      []ContentRuleConfig     `yaml:"rules"`
+	Classifier ContentClassifierConfig `yaml:"classifier"`
+}
+
+// ContentRuleConfig blocks or redacts text matching Pattern, a Go regular
+// expression.
+type ContentRuleConfig struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+	// Action is "block" or "redact".
+	Action string `yaml:"action"`
+	// Directions are "input" and "output". Empty checks both.
+	Directions []string `yaml:"directions"`
+}
+
+// ContentClassifierConfig is an HTTP service that is sent text as JSON and
+// answers whether to allow, block or redact it.
+type ContentClassifierConfig struct {
+	URL string `yaml:"url"`
+	// TokenEnv names the daemon environment variable holding a bearer
+	// token for the service.
+	TokenEnv string `yaml:"token_env"`
+	// Timeout bounds each request. Empty uses 2s.
+	Timeout string `yaml:"timeout"`
+	// Directions are "input" and "output". Empty checks both.
+	Directions []string `yaml:"directions"`
+	// FailClosed blocks text the service could not check.
+	FailClosed bool `yaml:"fail_closed"`
+}
+
+// ResourceLimitsConfig caps an agent's resources. Empty fields are
+// unlimited.
+// ProjectConfig is one project's entry in the projects registry. Unset
+// fields keep the global setting.
+type ProjectConfig struct {
+	// AllowedPaths replaces allowed_paths for the project.
+	AllowedPaths []string `yaml:"allowed_paths"`
+	// Providers lists the provider IDs the project may use, like
+	// project_providers.
+	Providers []string `yaml:"providers"`
+	// MaxSessions replaces sessions.max_per_project for the project.
+	MaxSessions int `yaml:"max_sessions"`
+	// Limits caps the resources of the project's agents, like
+	// project_limits.
+	Limits ResourceLimitsConfig `yaml:"limits"`
+	// RateLimits replaces the per-client StartSession and per-session
+	// WriteInput rate limits for the project.
+	RateLimits ProjectRateLimitsConfig `yaml:"rate_limits"`
+	// MCPServers are the MCP servers, by name, given to the project's
+	// agents whose provider sets mcp_config_flag.
+	MCPServers map[string]MCPServerConfig `yaml:"mcp_servers"`
+	// RequireApproval makes the project's agents wait for a client to
+	// approve each tool call that needs permission. Sessions whose provider
+	// sets no approval_args are refused.
+	RequireApproval bool `yaml:"require_approval"`
+	// Budget caps what the project's sessions may spend per UTC day.
+	Budget BudgetConfig `yaml:"budget"`
+	// RepoSummary gives the project's agents a summary of their repo with
+	// the first prompt.
+	RepoSummary RepoSummaryConfig `yaml:"repo_summary"`
+	// Retention replaces how long the project's session output,
+	// transcripts and audit records are kept.
+	Retention RetentionConfig `yaml:"retention"`
+}
+
+// RetentionConfig is a project's data retention. Unset fields use the
+// global settings.
+type RetentionConfig struct {
+	// EventBuffer replaces sessions.archive_ttl: how long a stopped
+	// session's output stays in memory before it is archived.
+	EventBuffer string `yaml:"event_buffer"`
+	// Transcripts replaces sessions.archive_retention. "0" keeps them
+	// forever.
+	Transcripts string `yaml:"transcripts"`
+	// AuditLogs replaces logging.audit_retention. "0" keeps them forever.
+	AuditLogs string `yaml:"audit_logs"`
+}
+
+// RepoSummaryConfig configures the repo summary. Zero limits use the
+// defaults: 3 levels, 200 entries and 40 README lines.
+type RepoSummaryConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// TreeDepth is how many directory levels the file tree lists.
+	TreeDepth int `yaml:"tree_depth"`
+	// MaxTreeEntries caps the lines of the file tree.
+	MaxTreeEntries int `yaml:"max_tree_entries"`
+	// ReadmeLines caps the README excerpt.
+	ReadmeLines int `yaml:"readme_lines"`
+}
+
+// BudgetConfig is a project's daily spending cap. Zero fields are
+// unlimited.
+type BudgetConfig struct {
+	// DailyUSD caps the cost providers report, in US dollars.
+	DailyUSD float64 `yaml:"daily_usd"`
+	// DailyTokens caps input plus output tokens.
+	DailyTokens int64 `yaml:"daily_tokens"`
+}
+
+// MCPServerConfig is an MCP server an agent is given. Type "stdio" (the
+// default) runs Command with Args and Env; "http" and "sse" connect to URL
+// with Headers.
+type MCPServerConfig struct {
+	Type    string            `yaml:"type"`
+	Command string            `yaml:"command"`
+	Args    []string          `yaml:"args"`
+	Env     map[string]string `yaml:"env"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// ProjectRateLimitsConfig is the subset of rate_limits a project can
+// override. Zero fields keep the global limit.
+type ProjectRateLimitsConfig struct {
+	StartSessionPerClientRPS   float64 `yaml:"start_session_per_client_rps"`
+	StartSessionPerClientBurst int     `yaml:"start_session_per_client_burst"`
+	SendInputPerSessionRPS     float64 `yaml:"send_input_per_session_rps"`
+	SendInputPerSessionBurst   int     `yaml:"send_input_per_session_burst"`
+	ProjectRPS                 float64 `yaml:"project_rps"`
+	ProjectBurst               int     `yaml:"project_burst"`
+}
+
+type ResourceLimitsConfig struct {
+	Memory       string `yaml:"memory"`   // e.g. "2GiB"
+	CPUTime      string `yaml:"cpu_time"` // CPU time per process, e.g. "30m"
+	MaxProcesses int    `yaml:"max_processes"`
 }
 
 type LoggingConfig struct {
-	Level          string   `yaml:"level"`
-	Format         string   `yaml:"format"`
-	RedactPatterns []string `yaml:"redact_patterns"`
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"` // "json" (default) or "text"
+	// Components sets the level of auth, supervisor, provider and server
+	// logging apart from Level, e.g. {"auth": "debug"}.
+	Components     map[string]string `yaml:"components"`
+	RedactPatterns []string          `yaml:"redact_patterns"`
+	// RedactBuiltins applies the built-in API key and token detectors
+	// alongside RedactPatterns. Defaults to true.
+	RedactBuiltins *bool `yaml:"redact_builtins"`
+	// ProviderLogs writes raw agent output to per-session files.
+	ProviderLogs ProviderLogsConfig `yaml:"provider_logs"`
+	// AuditDir, when set, also writes the RPC audit records to files in
+	// it, one per project per day.
+	AuditDir string `yaml:"audit_dir"`
+	// AuditRetention is how long audit files are kept. "0" keeps them
+	// forever. Defaults to 2160h (90 days).
+	AuditRetention string `yaml:"audit_retention"`
+}
+
+// ProviderLogsConfig controls the per-session logs of everything agent
+// processes print, before the bridge parses it. Logs are off unless Dir is
+// set.
+type ProviderLogsConfig struct {
+	Dir string `yaml:"dir"`
+	// MaxBytes is the size at which a log is rotated. Zero uses the
+	// default (10 MiB).
+	MaxBytes int64 `yaml:"max_bytes"`
+	// MaxFiles is how many rotated logs are kept per session. Zero uses
+	// the default (3).
+	MaxFiles int `yaml:"max_files"`
 }
 
 // Load reads and parses a YAML configuration file.
@@ -136,6 +762,9 @@ func Load(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
+	if err := applyEnv(cfg, os.LookupEnv); err != nil {
+		return nil, err
+	}
 
 	applyDefaults(cfg)
 	if err := validate(cfg); err != nil {
@@ -157,7 +786,7 @@ func ParseDuration(s string, fallback time.Duration) time.Duration {
 }
 
 func applyDefaults(cfg *Config) {
-	if cfg.Server.Listen == "" {
+	if cfg.Server.Listen == "" && !cfg.Server.InsecureDevMode {
 		cfg.Server.Listen = "0.0.0.0:9445"
 	}
 	if cfg.Auth.JWTAudience == "" {
@@ -166,6 +795,15 @@ func applyDefaults(cfg *Config) {
 	if cfg.Auth.JWTMaxTTL == "" {
 		cfg.Auth.JWTMaxTTL = "5m"
 	}
+	if cfg.Auth.JWKSRefreshInterval == "" {
+		cfg.Auth.JWKSRefreshInterval = "5m"
+	}
+	if cfg.Auth.OIDC.Issuer != "" && cfg.Auth.OIDC.ProjectClaim == "" {
+		cfg.Auth.OIDC.ProjectClaim = "project_id"
+	}
+	if cfg.Auth.Kubernetes.Issuer != "" && cfg.Auth.Kubernetes.JWKSURL == "" {
+		cfg.Auth.Kubernetes.JWKSURL = DefaultKubernetesJWKSURL
+	}
 	if cfg.Sessions.MaxPerProject == 0 {
 		cfg.Sessions.MaxPerProject = 5
 	}
@@ -187,9 +825,27 @@ func applyDefaults(cfg *Config) {
 	if cfg.Sessions.SubscriberTTL == "" {
 		cfg.Sessions.SubscriberTTL = "30m"
 	}
+	if cfg.Sessions.ArchiveTTL == "" {
+		cfg.Sessions.ArchiveTTL = "1h"
+	}
+	if cfg.Sessions.ArchiveRetention == "" {
+		cfg.Sessions.ArchiveRetention = "720h"
+	}
 	if cfg.Input.MaxSizeBytes == 0 {
 		cfg.Input.MaxSizeBytes = 65536
 	}
+	if cfg.Input.MaxStreamBytes == 0 {
+		cfg.Input.MaxStreamBytes = 16 << 20
+	}
+	if cfg.Input.MaxAttachmentBytes == 0 {
+		cfg.Input.MaxAttachmentBytes = 1 << 20
+	}
+	if cfg.Files.MaxSizeBytes == 0 {
+		cfg.Files.MaxSizeBytes = 1 << 20
+	}
+	if cfg.Git.WatchInterval == "" {
+		cfg.Git.WatchInterval = "5s"
+	}
 	if cfg.RateLimits.GlobalRPS == 0 {
 		cfg.RateLimits.GlobalRPS = 50
 	}
@@ -208,27 +864,104 @@ func applyDefaults(cfg *Config) {
 	if cfg.RateLimits.SendInputPerSessionBurst == 0 {
 		cfg.RateLimits.SendInputPerSessionBurst = 20
 	}
+	if cfg.Secrets.Backend == "" {
+		cfg.Secrets.Backend = "env"
+	}
+	if cfg.Secrets.EnvPrefix == "" {
+		cfg.Secrets.EnvPrefix = "BRIDGE_SECRET_"
+	}
+	if cfg.Secrets.Vault.Mount == "" {
+		cfg.Secrets.Vault.Mount = "secret"
+	}
+	if cfg.Secrets.Vault.TokenEnv == "" {
+		cfg.Secrets.Vault.TokenEnv = "VAULT_TOKEN"
+	}
 	if cfg.Logging.Level == "" {
 		cfg.Logging.Level = "info"
 	}
 	if cfg.Logging.Format == "" {
 		cfg.Logging.Format = "json"
 	}
+	if cfg.Logging.AuditRetention == "" {
+		cfg.Logging.AuditRetention = "2160h"
+	}
 }
 
 func validate(cfg *Config) error {
-	if cfg.Server.Listen == "" {
+	if cfg.Server.Listen == "" && !cfg.Server.InsecureDevMode {
 		return fmt.Errorf("config: server.listen is required")
 	}
+	if cfg.Server.Listen != "" && cfg.Server.InsecureDevMode {
+		return fmt.Errorf("config: server.insecure_dev_mode cannot be combined with server.listen")
+	}
+	if c := cfg.Server.Compression; c.Level < 0 || c.Level > 9 {
+		return fmt.Errorf("config: server.compression.level must be between 1 and 9")
+	}
+	if cfg.Server.Compression.MaxStreams < 0 {
+		return fmt.Errorf("config: server.compression.max_streams must be >= 0")
+	}
+	if d := cfg.Server.ShutdownDrain; d != "" {
+		if v, err := time.ParseDuration(d); err != nil {
+			return fmt.Errorf("config: server.shutdown_drain: %w", err)
+		} else if v < 0 {
+			return fmt.Errorf("config: server.shutdown_drain must be >= 0")
+		}
+	}
 	if cfg.Input.MaxSizeBytes <= 0 {
 		return fmt.Errorf("config: input.max_size_bytes must be > 0")
 	}
+	if cfg.Input.MaxStreamBytes <= 0 {
+		return fmt.Errorf("config: input.max_stream_bytes must be > 0")
+	}
+	if cfg.Input.MaxAttachmentBytes <= 0 {
+		return fmt.Errorf("config: input.max_attachment_bytes must be > 0")
+	}
+	if cfg.Files.MaxSizeBytes <= 0 {
+		return fmt.Errorf("config: files.max_size_bytes must be > 0")
+	}
 	if cfg.Sessions.MaxPerProject < 0 || cfg.Sessions.MaxGlobal < 0 {
 		return fmt.Errorf("config: session limits must be >= 0")
 	}
+	if cfg.Sessions.InputQueueDepth < 0 {
+		return fmt.Errorf("config: sessions.input_queue_depth must be >= 0")
+	}
+	if err := validateRestart(cfg.Sessions.Restart); err != nil {
+		return err
+	}
+	if ol := cfg.Sessions.OutputLimits; ol.MaxResponseBytes < 0 || ol.MaxResponseEvents < 0 {
+		return fmt.Errorf("config: sessions.output_limits must be >= 0")
+	}
 	if cfg.Sessions.EventBufferSize <= 0 {
 		return fmt.Errorf("config: sessions.event_buffer_size must be > 0")
 	}
+	if cfg.Sessions.SpillMaxBytes < 0 {
+		return fmt.Errorf("config: sessions.spill_max_bytes must be >= 0")
+	}
+	if cfg.Sessions.OutputMaxChunkBytes < 0 {
+		return fmt.Errorf("config: sessions.output_max_chunk_bytes must be >= 0")
+	}
+	if _, err := logging.ParseLevel(cfg.Logging.Level); err != nil {
+		return fmt.Errorf("config: logging.level: %w", err)
+	}
+	if f := cfg.Logging.Format; f != logging.FormatText && f != logging.FormatJSON {
+		return fmt.Errorf("config: logging.format must be one of text, json")
+	}
+	for component, level := range cfg.Logging.Components {
+		if !slices.Contains(logging.Components, component) {
+			return fmt.Errorf("config: logging.components: unknown component %q (want one of %s)", component, strings.Join(logging.Components, ", "))
+		}
+		if _, err := logging.ParseLevel(level); err != nil {
+			return fmt.Errorf("config: logging.components.%s: %w", component, err)
+		}
+	}
+	if cfg.Logging.ProviderLogs.MaxBytes < 0 || cfg.Logging.ProviderLogs.MaxFiles < 0 {
+		return fmt.Errorf("config: logging.provider_logs.max_bytes/max_files must be >= 0")
+	}
+	if d, err := time.ParseDuration(cfg.Logging.AuditRetention); err != nil {
+		return fmt.Errorf("config: logging.audit_retention: %w", err)
+	} else if d < 0 {
+		return fmt.Errorf("config: logging.audit_retention must be >= 0")
+	}
 	if cfg.Sessions.MaxSubscribersPerSession <= 0 {
 		return fmt.Errorf("config: sessions.max_subscribers_per_session must be > 0")
 	}
@@ -241,12 +974,58 @@ func validate(cfg *Config) error {
 	if cfg.RateLimits.SendInputPerSessionRPS <= 0 || cfg.RateLimits.SendInputPerSessionBurst <= 0 {
 		return fmt.Errorf("config: rate_limits.send_input_per_session_rps/send_input_per_session_burst must be > 0")
 	}
+	if cfg.RateLimits.ProjectRPS < 0 || cfg.RateLimits.ProjectBurst < 0 {
+		return fmt.Errorf("config: rate_limits.project_rps/project_burst must be >= 0")
+	}
+	if cfg.RateLimits.InteractiveReserve < 0 || cfg.RateLimits.InteractiveReserve >= 1 {
+		return fmt.Errorf("config: rate_limits.interactive_reserve must be >= 0 and < 1")
+	}
 	if cfg.Runtime.ProviderRoot != "" && !filepath.IsAbs(cfg.Runtime.ProviderRoot) {
 		return fmt.Errorf("config: runtime.provider_root must be an absolute path, got %q", cfg.Runtime.ProviderRoot)
 	}
 	if _, err := time.ParseDuration(cfg.Auth.JWTMaxTTL); err != nil {
 		return fmt.Errorf("config: auth.jwt_max_ttl: %w", err)
 	}
+	if cfg.Auth.JWKSURL != "" {
+		if err := validateHTTPSURL(cfg.Auth.JWKSURL); err != nil {
+			return fmt.Errorf("config: auth.jwks_url: %w", err)
+		}
+	}
+	if d, err := time.ParseDuration(cfg.Auth.JWKSRefreshInterval); err != nil {
+		return fmt.Errorf("config: auth.jwks_refresh_interval: %w", err)
+	} else if d < time.Minute {
+		return fmt.Errorf("config: auth.jwks_refresh_interval must be at least 1m")
+	}
+	if oidc := cfg.Auth.OIDC; oidc.Issuer != "" {
+		if err := validateHTTPSURL(oidc.Issuer); err != nil {
+			return fmt.Errorf("config: auth.oidc.issuer: %w", err)
+		}
+		if oidc.Audience == "" {
+			return fmt.Errorf("config: auth.oidc.audience is required")
+		}
+		if oidc.JWKSURL != "" {
+			if err := validateHTTPSURL(oidc.JWKSURL); err != nil {
+				return fmt.Errorf("config: auth.oidc.jwks_url: %w", err)
+			}
+		}
+	} else if cfg.Auth.OIDC != (OIDCConfig{}) {
+		return fmt.Errorf("config: auth.oidc.issuer is required when auth.oidc is set")
+	}
+	if err := validateACME(cfg.TLS); err != nil {
+		return err
+	}
+	if err := validateSPIFFE(cfg.Auth.SPIFFE); err != nil {
+		return err
+	}
+	if err := validateKubernetes(cfg.Auth.Kubernetes); err != nil {
+		return err
+	}
+	if err := validateCertBindings(cfg.Auth.CertBindings); err != nil {
+		return err
+	}
+	if err := validateJWTKeys(cfg.Auth.JWTPublicKeys); err != nil {
+		return err
+	}
 	if _, err := time.ParseDuration(cfg.Sessions.IdleTimeout); err != nil {
 		return fmt.Errorf("config: sessions.idle_timeout: %w", err)
 	}
@@ -256,32 +1035,60 @@ func validate(cfg *Config) error {
 	if _, err := time.ParseDuration(cfg.Sessions.SubscriberTTL); err != nil {
 		return fmt.Errorf("config: sessions.subscriber_ttl: %w", err)
 	}
-	for name, provider := range cfg.Providers {
-		if provider.Binary == "" {
-			return fmt.Errorf("config: providers.%s.binary is required", name)
+	if ttl, err := time.ParseDuration(cfg.Sessions.ArchiveTTL); err != nil {
+		return fmt.Errorf("config: sessions.archive_ttl: %w", err)
+	} else if ttl <= 0 {
+		return fmt.Errorf("config: sessions.archive_ttl must be > 0")
+	}
+	if d, err := time.ParseDuration(cfg.Sessions.ArchiveRetention); err != nil {
+		return fmt.Errorf("config: sessions.archive_retention: %w", err)
+	} else if d < 0 {
+		return fmt.Errorf("config: sessions.archive_retention must be >= 0")
+	}
+	if cfg.Sessions.OutputFlushInterval != "" {
+		if d, err := time.ParseDuration(cfg.Sessions.OutputFlushInterval); err != nil {
+			return fmt.Errorf("config: sessions.output_flush_interval: %w", err)
+		} else if d < 0 {
+			return fmt.Errorf("config: sessions.output_flush_interval must be >= 0")
 		}
-		if provider.Mode != "" {
-			return fmt.Errorf("config: providers.%s.mode is no longer supported; remove the field and use stream_json: true only for JSONL providers", name)
+	}
+	if d, err := time.ParseDuration(cfg.Git.WatchInterval); err != nil {
+		return fmt.Errorf("config: git.watch_interval: %w", err)
+	} else if d < 0 {
+		return fmt.Errorf("config: git.watch_interval must be >= 0")
+	}
+	if strings.ContainsAny(cfg.Git.AuthorName, "<>\n") || strings.ContainsAny(cfg.Git.AuthorEmail, "<>\n") {
+		return fmt.Errorf("config: git.author_name and git.author_email may not contain '<', '>' or newlines")
+	}
+	if cfg.Workspaces.ProjectQuotaBytes < 0 {
+		return fmt.Errorf("config: workspaces.project_quota_bytes must be >= 0")
+	}
+	for _, pattern := range cfg.Workspaces.AllowedURLs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("config: workspaces.allowed_urls %q: %w", pattern, err)
 		}
-		if provider.PTY != nil {
-			return fmt.Errorf("config: providers.%s.pty is no longer supported; PTY is the default and stream_json: true opts out of PTY allocation", name)
+	}
+	switch cfg.Secrets.Backend {
+	case "env", "aws":
+	case "file":
+		if cfg.Secrets.Dir == "" {
+			return fmt.Errorf("config: secrets.dir is required for the file backend")
 		}
-		if provider.StartupProbe != "" {
-			switch provider.StartupProbe {
-			case "prompt", "output", "none":
-			default:
-				return fmt.Errorf("config: providers.%s.startup_probe must be one of prompt, output, none", name)
-			}
+	case "vault":
+		if u, err := url.Parse(cfg.Secrets.Vault.Address); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("config: secrets.vault.address must be an http or https URL")
 		}
-		if provider.StartupTimeout != "" {
-			if _, err := time.ParseDuration(provider.StartupTimeout); err != nil {
-				return fmt.Errorf("config: providers.%s.startup_timeout: %w", name, err)
-			}
+	default:
+		return fmt.Errorf("config: secrets.backend must be one of env, file, vault, aws")
+	}
+	if ref := cfg.Persistence.ArchiveEncryptionKey; secrets.IsRef(ref) {
+		if _, err := secrets.ParseRef(ref); err != nil {
+			return fmt.Errorf("config: persistence.archive_encryption_key: %w", err)
 		}
-		for i, envName := range provider.RequiredEnv {
-			if strings.TrimSpace(envName) == "" {
-				return fmt.Errorf("config: providers.%s.required_env[%d] must not be empty", name, i)
-			}
+	}
+	for name, provider := range cfg.Providers {
+		if err := ValidateProvider(name, provider); err != nil {
+			return err
 		}
 		if len(provider.Fallbacks) > 2 {
 			return fmt.Errorf("config: providers.%s.fallbacks must have at most 2 entries", name)
@@ -295,5 +1102,613 @@ func validate(cfg *Config) error {
 			}
 		}
 	}
+	for i, name := range cfg.AllowedEnv {
+		if strings.TrimSuffix(name, "*") == "" {
+			return fmt.Errorf("config: allowed_env[%d] must name a variable or prefix", i)
+		}
+	}
+	for project, ids := range cfg.ProjectProviders {
+		if len(ids) == 0 {
+			return fmt.Errorf("config: project_providers.%s must list at least one provider", project)
+		}
+		for i, id := range ids {
+			if strings.TrimSpace(id) == "" {
+				return fmt.Errorf("config: project_providers.%s[%d] must not be empty", project, i)
+			}
+		}
+	}
+	for project, limits := range cfg.ProjectLimits {
+		if err := validateLimits("project_limits."+project, limits); err != nil {
+			return err
+		}
+	}
+	if err := validateProjects(cfg); err != nil {
+		return err
+	}
+	if err := validatePromptTemplates(cfg.PromptTemplates); err != nil {
+		return err
+	}
+	if err := validateContentPolicy(cfg.ContentPolicy); err != nil {
+		return err
+	}
+	return validateSchedules(cfg.Schedules)
+}
+
+func validateProjects(cfg *Config) error {
+	if cfg.RestrictProjects && len(cfg.Projects) == 0 {
+		return fmt.Errorf("config: restrict_projects requires at least one entry in projects")
+	}
+	for project, pc := range cfg.Projects {
+		field := "projects." + project
+		if strings.TrimSpace(project) == "" {
+			return fmt.Errorf("config: projects must not have an empty project ID")
+		}
+		for i, pattern := range pc.AllowedPaths {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("config: %s.allowed_paths[%d]: %w", field, i, err)
+			}
+		}
+		for i, id := range pc.Providers {
+			if strings.TrimSpace(id) == "" {
+				return fmt.Errorf("config: %s.providers[%d] must not be empty", field, i)
+			}
+		}
+		if len(pc.Providers) > 0 && cfg.ProjectProviders[project] != nil {
+			return fmt.Errorf("config: %s.providers and project_providers.%s are both set", field, project)
+		}
+		if pc.MaxSessions < 0 {
+			return fmt.Errorf("config: %s.max_sessions must be >= 0", field)
+		}
+		if err := validateLimits(field+".limits", pc.Limits); err != nil {
+			return err
+		}
+		if _, ok := cfg.ProjectLimits[project]; ok && pc.Limits != (ResourceLimitsConfig{}) {
+			return fmt.Errorf("config: %s.limits and project_limits.%s are both set", field, project)
+		}
+		rl := pc.RateLimits
+		if rl.StartSessionPerClientRPS < 0 || rl.StartSessionPerClientBurst < 0 || rl.SendInputPerSessionRPS < 0 || rl.SendInputPerSessionBurst < 0 || rl.ProjectRPS < 0 || rl.ProjectBurst < 0 {
+			return fmt.Errorf("config: %s.rate_limits must be >= 0", field)
+		}
+		if pc.Budget.DailyUSD < 0 || pc.Budget.DailyTokens < 0 {
+			return fmt.Errorf("config: %s.budget must be >= 0", field)
+		}
+		if rs := pc.RepoSummary; rs.TreeDepth < 0 || rs.MaxTreeEntries < 0 || rs.ReadmeLines < 0 {
+			return fmt.Errorf("config: %s.repo_summary limits must be >= 0", field)
+		}
+		if err := validateRetention(field+".retention", pc.Retention); err != nil {
+			return err
+		}
+		for name, ms := range pc.MCPServers {
+			if err := validateMCPServer(fmt.Sprintf("%s.mcp_servers.%s", field, name), ms); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
+
+func validateRetention(field string, r RetentionConfig) error {
+	if r.EventBuffer != "" {
+		if d, err := time.ParseDuration(r.EventBuffer); err != nil {
+			return fmt.Errorf("config: %s.event_buffer: %w", field, err)
+		} else if d <= 0 {
+			return fmt.Errorf("config: %s.event_buffer must be > 0", field)
+		}
+	}
+	for _, f := range []struct{ name, value string }{{"transcripts", r.Transcripts}, {"audit_logs", r.AuditLogs}} {
+		if f.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(f.value); err != nil {
+			return fmt.Errorf("config: %s.%s: %w", field, f.name, err)
+		} else if d < 0 {
+			return fmt.Errorf("config: %s.%s must be >= 0", field, f.name)
+		}
+	}
+	return nil
+}
+
+func validateMCPServer(field string, ms MCPServerConfig) error {
+	switch ms.Type {
+	case "", "stdio":
+		if ms.Command == "" {
+			return fmt.Errorf("config: %s.command is required", field)
+		}
+		if ms.URL != "" || len(ms.Headers) > 0 {
+			return fmt.Errorf("config: %s: url and headers need type http or sse", field)
+		}
+	case "http", "sse":
+		u, err := url.Parse(ms.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("config: %s.url must be an http or https URL, got %q", field, ms.URL)
+		}
+		if ms.Command != "" || len(ms.Args) > 0 || len(ms.Env) > 0 {
+			return fmt.Errorf("config: %s: command, args and env need type stdio", field)
+		}
+	default:
+		return fmt.Errorf("config: %s.type must be stdio, http or sse, got %q", field, ms.Type)
+	}
+	return nil
+}
+
+func validateSchedules(schedules []ScheduleConfig) error {
+	seen := make(map[string]bool, len(schedules))
+	for i, sc := range schedules {
+		// The name becomes a directory under persistence.schedule_dir.
+		if sc.Name == "" || sc.Name != filepath.Base(sc.Name) || strings.HasPrefix(sc.Name, ".") {
+			return fmt.Errorf("config: schedules[%d].name must be a non-empty file name, got %q", i, sc.Name)
+		}
+		if seen[sc.Name] {
+			return fmt.Errorf("config: schedules[%d]: duplicate name %q", i, sc.Name)
+		}
+		seen[sc.Name] = true
+		if _, err := bridge.ParseCron(sc.Cron); err != nil {
+			return fmt.Errorf("config: schedules.%s.cron: %w", sc.Name, err)
+		}
+		if sc.ProjectID == "" || sc.Provider == "" || sc.Prompt == "" {
+			return fmt.Errorf("config: schedules.%s: project_id, provider and prompt are required", sc.Name)
+		}
+		if len(sc.RepoPaths) == 0 {
+			return fmt.Errorf("config: schedules.%s.repo_paths must list at least one repo", sc.Name)
+		}
+		for j, repo := range sc.RepoPaths {
+			if !filepath.IsAbs(repo) {
+				return fmt.Errorf("config: schedules.%s.repo_paths[%d] must be an absolute path, got %q", sc.Name, j, repo)
+			}
+		}
+		if sc.Timeout != "" {
+			if d, err := time.ParseDuration(sc.Timeout); err != nil {
+				return fmt.Errorf("config: schedules.%s.timeout: %w", sc.Name, err)
+			} else if d <= 0 {
+				return fmt.Errorf("config: schedules.%s.timeout must be > 0", sc.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func validatePromptTemplates(templates map[string]PromptTemplateConfig) error {
+	for _, name := range slices.Sorted(maps.Keys(templates)) {
+		pt := templates[name]
+		if !promptTemplateNamePattern.MatchString(name) {
+			return fmt.Errorf("config: prompt_templates: invalid name %q", name)
+		}
+		if strings.TrimSpace(pt.Template) == "" {
+			return fmt.Errorf("config: prompt_templates.%s.template is required", name)
+		}
+		if _, err := bridge.ParsePromptTemplate(name, pt.Template); err != nil {
+			return fmt.Errorf("config: prompt_templates.%s.template: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func validateContentPolicy(cp ContentPolicyConfig) error {
+	names := make(map[string]bool, len(cp.Rules))
+	for i, r := range cp.Rules {
+		if strings.TrimSpace(r.Name) == "" {
+			return fmt.Errorf("config: content_policy.rules[%d].name is required", i)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("config: content_policy.rules: duplicate name %q", r.Name)
+		}
+		names[r.Name] = true
+		if r.Pattern == "" {
+			return fmt.Errorf("config: content_policy.rules.%s.pattern is required", r.Name)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("config: content_policy.rules.%s.pattern: %w", r.Name, err)
+		}
+		if r.Action != bridge.ContentActionBlock && r.Action != bridge.ContentActionRedact {
+			return fmt.Errorf("config: content_policy.rules.%s.action must be block or redact", r.Name)
+		}
+		if err := validateContentDirections("content_policy.rules."+r.Name, r.Directions); err != nil {
+			return err
+		}
+	}
+	c := cp.Classifier
+	if c.URL == "" {
+		if c.TokenEnv != "" || c.Timeout != "" || len(c.Directions) > 0 || c.FailClosed {
+			return fmt.Errorf("config: content_policy.classifier.url is required")
+		}
+		return nil
+	}
+	if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("config: content_policy.classifier.url must be an http or https URL")
+	}
+	if c.Timeout != "" {
+		if d, err := time.ParseDuration(c.Timeout); err != nil {
+			return fmt.Errorf("config: content_policy.classifier.timeout: %w", err)
+		} else if d <= 0 {
+			return fmt.Errorf("config: content_policy.classifier.timeout must be > 0")
+		}
+	}
+	return validateContentDirections("content_policy.classifier", c.Directions)
+}
+
+func validateContentDirections(field string, directions []string) error {
+	for _, d := range directions {
+		if d != bridge.ContentDirectionInput && d != bridge.ContentDirectionOutput {
+			return fmt.Errorf("config: %s.directions: unknown direction %q (want input or output)", field, d)
+		}
+	}
+	return nil
+}
+
+func validateRestart(r RestartConfig) error {
+	if r.MaxRestarts < 0 {
+		return fmt.Errorf("config: sessions.restart.max_restarts must be >= 0")
+	}
+	for _, f := range []struct{ name, value string }{{"backoff", r.Backoff}, {"max_backoff", r.MaxBackoff}} {
+		if f.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(f.value); err != nil {
+			return fmt.Errorf("config: sessions.restart.%s: %w", f.name, err)
+		} else if d < 0 {
+			return fmt.Errorf("config: sessions.restart.%s must be >= 0", f.name)
+		}
+	}
+	return nil
+}
+
+// ValidateProvider checks the definition of provider name. References to
+// other providers, such as fallbacks, are checked by Load.
+func ValidateProvider(name string, p ProviderConfig) error {
+	if len(p.Variants) > 0 {
+		if err := validateVariants(name, p); err != nil {
+			return err
+		}
+	} else if p.DefaultVariant != "" {
+		return fmt.Errorf("config: providers.%s.default_variant requires variants", name)
+	} else if p.Binary == "" {
+		return fmt.Errorf("config: providers.%s.binary is required", name)
+	}
+	if p.Mode != "" {
+		return fmt.Errorf("config: providers.%s.mode is no longer supported; remove the field and use stream_json: true only for JSONL providers", name)
+	}
+	if p.PTY != nil {
+		return fmt.Errorf("config: providers.%s.pty is no longer supported; PTY is the default and stream_json: true opts out of PTY allocation", name)
+	}
+	if p.StartupProbe != "" {
+		switch p.StartupProbe {
+		case "prompt", "output", "none":
+		default:
+			return fmt.Errorf("config: providers.%s.startup_probe must be one of prompt, output, none", name)
+		}
+	}
+	switch p.JSONFormat {
+	case "", "claude", "opencode":
+	default:
+		return fmt.Errorf("config: providers.%s.json_format must be one of claude, opencode", name)
+	}
+	if p.JSONFormat != "" && !p.StreamJSON {
+		return fmt.Errorf("config: providers.%s.json_format requires stream_json: true", name)
+	}
+	for _, f := range []struct {
+		name string
+		args []string
+	}{{"resume_args", p.ResumeArgs}, {"thread_id_args", p.ThreadIDArgs}} {
+		if len(f.args) > 0 && !slices.ContainsFunc(f.args, func(arg string) bool { return strings.Contains(arg, provider.ThreadIDArg) }) {
+			return fmt.Errorf("config: providers.%s.%s must name the thread with a %q argument", name, f.name, provider.ThreadIDArg)
+		}
+	}
+	if len(p.ApprovalArgs) > 0 && (!p.StreamJSON || p.JSONFormat == "opencode") {
+		return fmt.Errorf("config: providers.%s.approval_args requires stream_json: true with the claude json_format", name)
+	}
+	if err := bridge.ValidateOutputFilters(p.OutputFilters); err != nil {
+		return fmt.Errorf("config: providers.%s.output_filters: %w", name, err)
+	}
+	switch p.Sandbox {
+	case "", "none", "bwrap":
+	case "docker":
+		if p.SandboxImage == "" {
+			return fmt.Errorf("config: providers.%s.sandbox_image is required when sandbox is docker", name)
+		}
+	default:
+		return fmt.Errorf("config: providers.%s.sandbox must be one of none, bwrap, docker", name)
+	}
+	for i, path := range p.SandboxBinds {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("config: providers.%s.sandbox_binds[%d] must be an absolute path, got %q", name, i, path)
+		}
+	}
+	for i, sc := range p.StderrClassifiers {
+		if sc.Pattern == "" {
+			return fmt.Errorf("config: providers.%s.stderr_classifiers[%d].pattern is required", name, i)
+		}
+		if _, err := regexp.Compile(sc.Pattern); err != nil {
+			return fmt.Errorf("config: providers.%s.stderr_classifiers[%d].pattern: %w", name, i, err)
+		}
+		switch sc.Severity {
+		case "progress", "warning", "error":
+		default:
+			return fmt.Errorf("config: providers.%s.stderr_classifiers[%d].severity must be one of progress, warning, error", name, i)
+		}
+	}
+	if p.MCPConfigFlag != "" && (!strings.HasPrefix(p.MCPConfigFlag, "-") || strings.ContainsAny(p.MCPConfigFlag, " \t")) {
+		return fmt.Errorf("config: providers.%s.mcp_config_flag must be a single flag such as --mcp-config, got %q", name, p.MCPConfigFlag)
+	}
+	if p.StartupTimeout != "" {
+		if _, err := time.ParseDuration(p.StartupTimeout); err != nil {
+			return fmt.Errorf("config: providers.%s.startup_timeout: %w", name, err)
+		}
+	}
+	if p.ReadyTimeout != "" {
+		d, err := time.ParseDuration(p.ReadyTimeout)
+		switch {
+		case err != nil:
+			return fmt.Errorf("config: providers.%s.ready_timeout: %w", name, err)
+		case d < 0:
+			return fmt.Errorf("config: providers.%s.ready_timeout must not be negative", name)
+		case d > 0 && (p.PromptPattern == "" || p.StreamJSON):
+			return fmt.Errorf("config: providers.%s.ready_timeout requires prompt_pattern on a PTY provider", name)
+		}
+	}
+	if err := validateVersionRange(name, p); err != nil {
+		return err
+	}
+	for i, envName := range p.RequiredEnv {
+		if strings.TrimSpace(envName) == "" {
+			return fmt.Errorf("config: providers.%s.required_env[%d] must not be empty", name, i)
+		}
+	}
+	for envName, ref := range p.SecretEnv {
+		if !envNamePattern.MatchString(envName) {
+			return fmt.Errorf("config: providers.%s.secret_env: invalid variable name %q", name, envName)
+		}
+		if _, err := secrets.ParseRef(ref); err != nil {
+			return fmt.Errorf("config: providers.%s.secret_env.%s: %w", name, envName, err)
+		}
+	}
+	for i, envName := range p.EnvAllowlist {
+		if strings.TrimSpace(envName) == "" {
+			return fmt.Errorf("config: providers.%s.env_allowlist[%d] must not be empty", name, i)
+		}
+	}
+	for i, envName := range p.EnvBlocklist {
+		if strings.TrimSuffix(envName, "*") == "" {
+			return fmt.Errorf("config: providers.%s.env_blocklist[%d] must name a variable or prefix", name, i)
+		}
+	}
+	return validateLimits("providers."+name+".limits", p.Limits)
+}
+
+// validateVariants checks the variants of provider name, each as a
+// provider of its own.
+func validateVariants(name string, p ProviderConfig) error {
+	if p.DefaultVariant == "" {
+		return fmt.Errorf("config: providers.%s.default_variant is required with variants", name)
+	}
+	if _, ok := p.Variants[p.DefaultVariant]; !ok {
+		return fmt.Errorf("config: providers.%s.default_variant %q is not one of its variants", name, p.DefaultVariant)
+	}
+	for _, v := range slices.Sorted(maps.Keys(p.Variants)) {
+		if !variantNamePattern.MatchString(v) {
+			return fmt.Errorf("config: providers.%s.variants: invalid name %q", name, v)
+		}
+		if err := ValidateProvider(name+".variants."+v, p.Variant(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateVersionRange checks the min_version and max_version of provider
+// name.
+func validateVersionRange(name string, p ProviderConfig) error {
+	if p.MinVersion == "" && p.MaxVersion == "" {
+		return nil
+	}
+	if p.Sandbox == "docker" {
+		return fmt.Errorf("config: providers.%s.min_version/max_version cannot be checked with sandbox: docker", name)
+	}
+	var lo, hi provider.Version
+	for _, f := range []struct {
+		field, value string
+		dst          *provider.Version
+	}{{"min_version", p.MinVersion, &lo}, {"max_version", p.MaxVersion, &hi}} {
+		if f.value == "" {
+			continue
+		}
+		if !versionPattern.MatchString(f.value) {
+			return fmt.Errorf("config: providers.%s.%s must be a version such as 1.2 or 1.2.3, got %q", name, f.field, f.value)
+		}
+		*f.dst, _ = provider.ParseVersion(f.value)
+	}
+	if p.MinVersion != "" && p.MaxVersion != "" && lo.Compare(hi) > 0 {
+		return fmt.Errorf("config: providers.%s.min_version %s is above max_version %s", name, p.MinVersion, p.MaxVersion)
+	}
+	return nil
+}
+
+func validateLimits(field string, l ResourceLimitsConfig) error {
+	if l.Memory != "" {
+		if n, err := ParseByteSize(l.Memory); err != nil {
+			return fmt.Errorf("config: %s.memory: %w", field, err)
+		} else if n < 1<<20 {
+			return fmt.Errorf("config: %s.memory must be at least 1MiB", field)
+		}
+	}
+	if l.CPUTime != "" {
+		if d, err := time.ParseDuration(l.CPUTime); err != nil {
+			return fmt.Errorf("config: %s.cpu_time: %w", field, err)
+		} else if d < time.Second {
+			return fmt.Errorf("config: %s.cpu_time must be at least 1s", field)
+		}
+	}
+	if l.MaxProcesses < 0 {
+		return fmt.Errorf("config: %s.max_processes must be >= 0", field)
+	}
+	return nil
+}
+
+// byteUnits maps size suffixes to multipliers. Bare K, M and G are binary,
+// as in ulimit and docker.
+var byteUnits = map[string]int64{
+	"": 1, "B": 1,
+	"K": 1 << 10, "KiB": 1 << 10, "KB": 1e3,
+	"M": 1 << 20, "MiB": 1 << 20, "MB": 1e6,
+	"G": 1 << 30, "GiB": 1 << 30, "GB": 1e9,
+	"T": 1 << 40, "TiB": 1 << 40, "TB": 1e12,
+}
+
+// ParseByteSize parses a size such as "512MiB", "2G" or "1048576".
+func ParseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.TrimSpace(s[i:])
+	mult, ok := byteUnits[unit]
+	if num == "" || !ok {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n > math.MaxInt64/mult {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * mult, nil
+}
+
+func validateACME(tls TLSConfig) error {
+	acme := tls.ACME
+	if len(acme.Domains) == 0 {
+		if acme.Email != "" || acme.DirectoryURL != "" || acme.CacheDir != "" || acme.HTTPAddr != "" {
+			return fmt.Errorf("config: tls.acme.domains is required when tls.acme is set")
+		}
+		return nil
+	}
+	if tls.CABundle == "" {
+		return fmt.Errorf("config: tls.acme requires tls.ca_bundle to verify client certificates")
+	}
+	if tls.Cert != "" || tls.Key != "" {
+		return fmt.Errorf("config: tls.acme cannot be combined with tls.cert/tls.key")
+	}
+	for i, d := range acme.Domains {
+		// HTTP-01 and TLS-ALPN-01 cannot validate wildcard names.
+		if d == "" || strings.Contains(d, "*") {
+			return fmt.Errorf("config: tls.acme.domains[%d] must be a DNS name, got %q", i, d)
+		}
+	}
+	if acme.DirectoryURL != "" {
+		if err := validateHTTPSURL(acme.DirectoryURL); err != nil {
+			return fmt.Errorf("config: tls.acme.directory_url: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateJWTKeys(keys []JWTKeyConfig) error {
+	kids := make(map[string]bool)
+	for i, k := range keys {
+		if k.Issuer == "" || k.KeyPath == "" {
+			return fmt.Errorf("config: auth.jwt_public_keys[%d]: issuer and key_path are required", i)
+		}
+		if _, _, err := k.Window(); err != nil {
+			return fmt.Errorf("config: auth.jwt_public_keys[%d]: %w", i, err)
+		}
+		if k.KID == "" {
+			continue
+		}
+		if kids[k.Issuer+"\x00"+k.KID] {
+			return fmt.Errorf("config: auth.jwt_public_keys[%d]: duplicate kid %q for issuer %q", i, k.KID, k.Issuer)
+		}
+		kids[k.Issuer+"\x00"+k.KID] = true
+	}
+	return nil
+}
+
+func validateSPIFFE(s SPIFFEConfig) error {
+	if s.TrustDomain == "" {
+		if s.Bundle != "" || len(s.Projects) > 0 {
+			return fmt.Errorf("config: auth.spiffe.trust_domain is required when auth.spiffe is set")
+		}
+		return nil
+	}
+	if strings.Contains(s.TrustDomain, "/") || strings.Contains(s.TrustDomain, ":") {
+		return fmt.Errorf("config: auth.spiffe.trust_domain must be a bare trust domain name, got %q", s.TrustDomain)
+	}
+	if s.Bundle == "" {
+		return fmt.Errorf("config: auth.spiffe.bundle is required")
+	}
+	prefix := "spiffe://" + s.TrustDomain + "/"
+	for id, project := range s.Projects {
+		if !strings.HasPrefix(id, prefix) {
+			return fmt.Errorf("config: auth.spiffe.projects: %q is not a SPIFFE ID in trust domain %s", id, s.TrustDomain)
+		}
+		// An empty project would grant access to every project.
+		if project == "" {
+			return fmt.Errorf("config: auth.spiffe.projects[%q] must not be empty", id)
+		}
+	}
+	return nil
+}
+
+func validateCertBindings(bindings []CertBindingConfig) error {
+	for i, b := range bindings {
+		if b.CN == "" && b.OU == "" && b.URI == "" {
+			return fmt.Errorf("config: auth.cert_bindings[%d] needs cn, ou or uri", i)
+		}
+		if len(b.Projects) == 0 {
+			return fmt.Errorf("config: auth.cert_bindings[%d].projects is required", i)
+		}
+		for _, p := range b.Projects {
+			if p == "" {
+				return fmt.Errorf("config: auth.cert_bindings[%d].projects must not contain empty IDs", i)
+			}
+		}
+	}
+	return nil
+}
+
+func validateKubernetes(k KubernetesConfig) error {
+	if k.Issuer == "" {
+		if k.Audience != "" || k.JWKSURL != "" || k.CAFile != "" || k.TokenFile != "" || len(k.Projects) > 0 {
+			return fmt.Errorf("config: auth.kubernetes.issuer is required when auth.kubernetes is set")
+		}
+		return nil
+	}
+	if k.Audience == "" {
+		return fmt.Errorf("config: auth.kubernetes.audience is required")
+	}
+	if err := validateHTTPSURL(k.JWKSURL); err != nil {
+		return fmt.Errorf("config: auth.kubernetes.jwks_url: %w", err)
+	}
+	for account, project := range k.Projects {
+		namespace, name, ok := strings.Cut(account, "/")
+		if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("config: auth.kubernetes.projects: %q is not namespace/serviceaccount", account)
+		}
+		// An empty project would grant access to every project.
+		if project == "" {
+			return fmt.Errorf("config: auth.kubernetes.projects[%q] must not be empty", account)
+		}
+	}
+	return nil
+}
+
+// validateHTTPSURL requires an absolute https URL. Plain http is accepted
+// only for loopback hosts, e.g. a local identity provider in development.
+func validateHTTPSURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("must be an absolute URL, got %q", raw)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+		return fmt.Errorf("must use https, got %q", raw)
+	default:
+		return fmt.Errorf("must use https, got %q", raw)
+	}
+}
@@ -25,3 +25,28 @@ func TestShouldValidateStartupAndParseDuration(t *testing.T) {
 		t.Fatalf("ParseDuration invalid=%v want fallback 3s", got)
 	}
 }
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "1048576", want: 1 << 20},
+		{in: "512MiB", want: 512 << 20},
+		{in: "2G", want: 2 << 30},
+		{in: "1GB", want: 1e9},
+		{in: "64 KiB", want: 64 << 10},
+		{in: "", wantErr: true},
+		{in: "MiB", wantErr: true},
+		{in: "1.5GiB", wantErr: true},
+		{in: "3 bananas", wantErr: true},
+		{in: "99999999999T", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("ParseByteSize(%q) = %d, %v want %d, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -34,6 +34,15 @@ sessions:
 	if cfg.Input.MaxSizeBytes == 0 {
 		t.Fatal("expected default input.max_size_bytes")
 	}
+	if cfg.Input.MaxStreamBytes != 16<<20 {
+		t.Fatalf("input.max_stream_bytes = %d, want 16 MiB default", cfg.Input.MaxStreamBytes)
+	}
+	if cfg.Input.MaxAttachmentBytes != 1<<20 {
+		t.Fatalf("input.max_attachment_bytes = %d, want 1 MiB default", cfg.Input.MaxAttachmentBytes)
+	}
+	if cfg.Files.MaxSizeBytes != 1<<20 {
+		t.Fatalf("files.max_size_bytes = %d, want 1 MiB default", cfg.Files.MaxSizeBytes)
+	}
 	if cfg.RateLimits.GlobalRPS == 0 || cfg.RateLimits.GlobalBurst == 0 {
 		t.Fatal("expected default global rate limits")
 	}
@@ -68,6 +77,125 @@ sessions:
 	}
 }
 
+func TestLoadValidateInputQueueDepth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.yaml")
+	content := `
+server:
+  listen: "127.0.0.1:9445"
+auth:
+  jwt_max_ttl: "5m"
+sessions:
+  idle_timeout: "30m"
+  stop_grace_period: "10s"
+  subscriber_ttl: "30m"
+  input_queue_depth: -1
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "sessions.input_queue_depth must be >= 0") {
+		t.Fatalf("err=%v want input_queue_depth error", err)
+	}
+}
+
+func TestLoadValidateOutputCoalescing(t *testing.T) {
+	tests := []struct {
+		fields  string
+		wantErr string
+	}{
+		{fields: "output_flush_interval: \"50ms\"\n  output_max_chunk_bytes: 4096"},
+		{fields: "output_flush_interval: \"soon\"", wantErr: "sessions.output_flush_interval"},
+		{fields: "output_flush_interval: \"-1s\"", wantErr: "sessions.output_flush_interval must be >= 0"},
+		{fields: "output_max_chunk_bytes: -1", wantErr: "sessions.output_max_chunk_bytes must be >= 0"},
+		{fields: "output_limits:\n    max_response_bytes: 1048576\n    max_response_events: 5000\n    cancel_response: true"},
+		{fields: "output_limits:\n    max_response_bytes: -1", wantErr: "sessions.output_limits must be >= 0"},
+		{fields: "output_limits:\n    max_response_events: -1", wantErr: "sessions.output_limits must be >= 0"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "bridge.yaml")
+		content := `
+server:
+  listen: "127.0.0.1:9445"
+auth:
+  jwt_max_ttl: "5m"
+sessions:
+  idle_timeout: "30m"
+  ` + tt.fields + `
+`
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		_, err := Load(path)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Fatalf("%s: Load: %v", tt.fields, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("%s: err=%v want %q", tt.fields, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadValidateProviderLogs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.yaml")
+	content := `
+server:
+  listen: "127.0.0.1:9445"
+logging:
+  provider_logs:
+    dir: "/var/log/bridge/providers"
+    max_files: -1
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "logging.provider_logs.max_bytes/max_files must be >= 0") {
+		t.Fatalf("err=%v want provider_logs error", err)
+	}
+}
+
+func TestLoadValidateLogging(t *testing.T) {
+	tests := []struct {
+		name    string
+		logging string
+		wantErr string
+	}{
+		{name: "text", logging: "format: text\n  level: debug\n  components:\n    auth: warn"},
+		{name: "bad level", logging: "level: loud", wantErr: "logging.level"},
+		{name: "bad format", logging: "format: xml", wantErr: "logging.format must be one of text, json"},
+		{name: "unknown component", logging: "components:\n    ui: debug", wantErr: `unknown component "ui"`},
+		{name: "bad component level", logging: "components:\n    auth: loud", wantErr: "logging.components.auth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bridge.yaml")
+			content := "server:\n  listen: \"127.0.0.1:9445\"\nlogging:\n  " + tt.logging + "\n"
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			cfg, err := Load(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if cfg.Logging.Components["auth"] != "warn" {
+					t.Fatalf("components = %v", cfg.Logging.Components)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err=%v want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestLoadValidateBadRequiredEnv(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "bridge.yaml")
@@ -98,6 +226,38 @@ sessions:
 	}
 }
 
+func TestLoadValidateProviderEnvLists(t *testing.T) {
+	for name, tc := range map[string]struct {
+		lists   string
+		wantErr string
+	}{
+		"valid":          {lists: `env_allowlist: ["ANTHROPIC_*", "*"]` + "\n    env_blocklist: [\"ANTHROPIC_MODEL\"]"},
+		"empty allow":    {lists: `env_allowlist: [" "]`, wantErr: "env_allowlist[0]"},
+		"block wildcard": {lists: `env_blocklist: ["*"]`, wantErr: "env_blocklist[0]"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bridge.yaml")
+			content := "providers:\n  claude:\n    binary: \"claude\"\n    " + tc.lists + "\n"
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			cfg, err := Load(path)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if got := cfg.Providers["claude"].EnvBlocklist; len(got) != 1 || got[0] != "ANTHROPIC_MODEL" {
+					t.Fatalf("EnvBlocklist = %v", got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
 func TestLoadValidateProviderFallbacks(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -280,6 +440,61 @@ sessions:
 	}
 }
 
+func TestLoadValidateJSONFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  string
+		wantErr string
+	}{
+		{name: "opencode", fields: "stream_json: true\n    json_format: opencode"},
+		{name: "default", fields: "stream_json: true"},
+		{name: "unknown", fields: "stream_json: true\n    json_format: yaml", wantErr: "json_format must be one of"},
+		{name: "without stream_json", fields: "json_format: opencode", wantErr: "requires stream_json"},
+		{name: "bwrap sandbox", fields: "sandbox: bwrap"},
+		{name: "docker sandbox", fields: "sandbox: docker\n    sandbox_image: agents:latest"},
+		{name: "docker without image", fields: "sandbox: docker", wantErr: "sandbox_image is required"},
+		{name: "unknown sandbox", fields: "sandbox: chroot", wantErr: "sandbox must be one of"},
+		{name: "stderr classifier", fields: "stderr_classifiers:\n      - pattern: '^error'\n        severity: error"},
+		{name: "stderr classifier bad regex", fields: "stderr_classifiers:\n      - pattern: '('\n        severity: error", wantErr: "stderr_classifiers[0].pattern"},
+		{name: "stderr classifier bad severity", fields: "stderr_classifiers:\n      - pattern: 'x'\n        severity: fatal", wantErr: "severity must be one of"},
+		{name: "output filters", fields: "output_filters: [collapse_cr, normalize_fences]"},
+		{name: "unknown output filter", fields: "output_filters: [markdown]", wantErr: "output_filters: unknown output filter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "bridge.yaml")
+			content := `
+server:
+  listen: "127.0.0.1:9445"
+auth:
+  jwt_max_ttl: "5m"
+providers:
+  agent:
+    binary: "opencode"
+    ` + tt.fields + `
+sessions:
+  idle_timeout: "30m"
+  stop_grace_period: "10s"
+  subscriber_ttl: "30m"
+`
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			_, err := Load(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err=%v want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestLoadRuntimeProviderRoot(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -358,3 +573,518 @@ sessions:
 		})
 	}
 }
+
+func TestLoadValidateProjectProviders(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		wantErr string
+	}{
+		{name: "valid", section: "project_providers:\n  prod-docs: [\"agent\"]"},
+		{name: "empty list", section: "project_providers:\n  prod-docs: []", wantErr: "must list at least one provider"},
+		{name: "blank id", section: "project_providers:\n  prod-docs: [\"\"]", wantErr: "project_providers.prod-docs[0] must not be empty"},
+		{name: "limits", section: "project_providers:\n  prod-docs: [\"agent\"]\nproject_limits:\n  prod-docs:\n    memory: 2GiB\n    cpu_time: 30m\n    max_processes: 128"},
+		{name: "bad memory", section: "project_limits:\n  prod-docs:\n    memory: lots", wantErr: "project_limits.prod-docs.memory: invalid size"},
+		{name: "tiny memory", section: "project_limits:\n  prod-docs:\n    memory: 10K", wantErr: "must be at least 1MiB"},
+		{name: "bad cpu time", section: "project_limits:\n  prod-docs:\n    cpu_time: 5", wantErr: "project_limits.prod-docs.cpu_time"},
+		{name: "negative processes", section: "project_limits:\n  prod-docs:\n    max_processes: -1", wantErr: "max_processes must be >= 0"},
+		{name: "provider limits", section: "providers:\n  agent:\n    binary: agent\n    limits:\n      cpu_time: 100ms", wantErr: "providers.agent.limits.cpu_time must be at least 1s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bridge.yaml")
+			content := `
+server:
+  listen: "127.0.0.1:9445"
+auth:
+  jwt_max_ttl: "5m"
+sessions:
+  idle_timeout: "30m"
+  stop_grace_period: "10s"
+  subscriber_ttl: "30m"
+` + tt.section + "\n"
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			cfg, err := Load(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if got := cfg.ProjectProviders["prod-docs"]; len(got) != 1 || got[0] != "agent" {
+					t.Fatalf("ProjectProviders=%v", cfg.ProjectProviders)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err=%v want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadValidateProjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		wantErr string
+	}{
+		{name: "valid", section: "restrict_projects: true\nprojects:\n  prod-docs:\n    allowed_paths: [\"/srv/docs/*\"]\n    providers: [\"agent\"]\n    max_sessions: 3\n    limits:\n      memory: 1GiB\n    rate_limits:\n      send_input_per_session_rps: 2\n      send_input_per_session_burst: 4\n    budget:\n      daily_usd: 25\n      daily_tokens: 2000000\n    repo_summary:\n      enabled: true\n      tree_depth: 2\n    retention:\n      event_buffer: 10m\n      transcripts: 168h\n      audit_logs: \"0\"\n    mcp_servers:\n      docs:\n        command: docs-mcp\n        args: [\"--ro\"]\n      issues:\n        type: http\n        url: https://issues.example/mcp"},
+		{name: "negative budget", section: "projects:\n  prod-docs:\n    budget:\n      daily_usd: -5", wantErr: "projects.prod-docs.budget must be >= 0"},
+		{name: "negative repo summary", section: "projects:\n  prod-docs:\n    repo_summary:\n      readme_lines: -1", wantErr: "projects.prod-docs.repo_summary limits must be >= 0"},
+		{name: "bad event buffer", section: "projects:\n  prod-docs:\n    retention:\n      event_buffer: \"0\"", wantErr: "projects.prod-docs.retention.event_buffer must be > 0"},
+		{name: "bad transcripts", section: "projects:\n  prod-docs:\n    retention:\n      transcripts: weekly", wantErr: "projects.prod-docs.retention.transcripts"},
+		{name: "negative audit logs", section: "projects:\n  prod-docs:\n    retention:\n      audit_logs: -1h", wantErr: "projects.prod-docs.retention.audit_logs must be >= 0"},
+		{name: "negative audit retention", section: "logging:\n  audit_retention: -1h", wantErr: "logging.audit_retention must be >= 0"},
+		{name: "restrict without projects", section: "restrict_projects: true", wantErr: "restrict_projects requires at least one entry"},
+		{name: "bad path", section: "projects:\n  prod-docs:\n    allowed_paths: [\"/srv/[\"]", wantErr: "projects.prod-docs.allowed_paths[0]"},
+		{name: "blank provider", section: "projects:\n  prod-docs:\n    providers: [\" \"]", wantErr: "projects.prod-docs.providers[0] must not be empty"},
+		{name: "negative sessions", section: "projects:\n  prod-docs:\n    max_sessions: -1", wantErr: "projects.prod-docs.max_sessions must be >= 0"},
+		{name: "bad limits", section: "projects:\n  prod-docs:\n    limits:\n      memory: lots", wantErr: "projects.prod-docs.limits.memory"},
+		{name: "negative rate", section: "projects:\n  prod-docs:\n    rate_limits:\n      start_session_per_client_rps: -1", wantErr: "projects.prod-docs.rate_limits must be >= 0"},
+		{name: "negative project bucket", section: "rate_limits:\n  project_rps: -1", wantErr: "rate_limits.project_rps/project_burst must be >= 0"},
+		{name: "interactive reserve out of range", section: "rate_limits:\n  interactive_reserve: 1", wantErr: "rate_limits.interactive_reserve must be >= 0 and < 1"},
+		{name: "providers set twice", section: "project_providers:\n  prod-docs: [\"agent\"]\nprojects:\n  prod-docs:\n    providers: [\"agent\"]", wantErr: "are both set"},
+		{name: "limits set twice", section: "project_limits:\n  prod-docs:\n    memory: 1GiB\nprojects:\n  prod-docs:\n    limits:\n      memory: 1GiB", wantErr: "are both set"},
+		{name: "mcp stdio without command", section: "projects:\n  prod-docs:\n    mcp_servers:\n      docs:\n        args: [\"--ro\"]", wantErr: "projects.prod-docs.mcp_servers.docs.command is required"},
+		{name: "mcp http without url", section: "projects:\n  prod-docs:\n    mcp_servers:\n      issues:\n        type: sse", wantErr: "mcp_servers.issues.url must be an http or https URL"},
+		{name: "mcp mixed transport", section: "projects:\n  prod-docs:\n    mcp_servers:\n      issues:\n        type: http\n        url: https://issues.example/mcp\n        command: x", wantErr: "need type stdio"},
+		{name: "mcp bad type", section: "projects:\n  prod-docs:\n    mcp_servers:\n      docs:\n        type: ws", wantErr: "type must be stdio, http or sse"},
+		{name: "mcp config flag", section: "providers:\n  agent:\n    binary: agent\n    mcp_config_flag: mcp-config", wantErr: "providers.agent.mcp_config_flag must be a single flag"},
+		{name: "bad ready timeout", section: "providers:\n  agent:\n    binary: agent\n    prompt_pattern: \"> $\"\n    ready_timeout: soon", wantErr: "providers.agent.ready_timeout"},
+		{name: "ready timeout without prompt", section: "providers:\n  agent:\n    binary: agent\n    ready_timeout: 30s", wantErr: "providers.agent.ready_timeout requires prompt_pattern"},
+		{name: "bad min version", section: "providers:\n  agent:\n    binary: agent\n    min_version: latest", wantErr: "providers.agent.min_version must be a version"},
+		{name: "inverted version range", section: "providers:\n  agent:\n    binary: agent\n    min_version: \"2.0\"\n    max_version: 1.9.3", wantErr: "providers.agent.min_version 2.0 is above max_version 1.9.3"},
+		{name: "variants without default", section: "providers:\n  agent:\n    variants:\n      stable:\n        binary: agent", wantErr: "providers.agent.default_variant is required with variants"},
+		{name: "unknown default variant", section: "providers:\n  agent:\n    default_variant: canary\n    variants:\n      stable:\n        binary: agent", wantErr: `providers.agent.default_variant "canary" is not one of its variants`},
+		{name: "variant without binary", section: "providers:\n  agent:\n    default_variant: stable\n    variants:\n      stable: {}", wantErr: "providers.agent.variants.stable.binary is required"},
+		{name: "bad variant version", section: "providers:\n  agent:\n    binary: agent\n    default_variant: stable\n    variants:\n      stable: {}\n      canary:\n        binary: agent-canary\n        min_version: next", wantErr: "providers.agent.variants.canary.min_version must be a version"},
+		{name: "default variant without variants", section: "providers:\n  agent:\n    binary: agent\n    default_variant: stable", wantErr: "providers.agent.default_variant requires variants"},
+		{name: "resume args without thread", section: "providers:\n  agent:\n    binary: agent\n    resume_args: [\"--continue\"]", wantErr: `providers.agent.resume_args must name the thread with a "{thread_id}" argument`},
+		{name: "thread id args without thread", section: "providers:\n  agent:\n    binary: agent\n    thread_id_args: [\"--session-id\"]", wantErr: `providers.agent.thread_id_args must name the thread`},
+		{name: "approval args without stream json", section: "providers:\n  agent:\n    binary: agent\n    approval_args: [\"--permission-prompt-tool\", \"stdio\"]", wantErr: "providers.agent.approval_args requires stream_json: true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bridge.yaml")
+			content := `
+server:
+  listen: "127.0.0.1:9445"
+` + tt.section + "\n"
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			cfg, err := Load(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				pc := cfg.Projects["prod-docs"]
+				if !cfg.RestrictProjects || pc.MaxSessions != 3 || len(pc.Providers) != 1 || pc.RateLimits.SendInputPerSessionBurst != 4 || len(pc.MCPServers) != 2 || pc.Budget.DailyUSD != 25 || pc.Budget.DailyTokens != 2000000 || pc.Retention.Transcripts != "168h" {
+					t.Fatalf("Projects=%+v", cfg.Projects)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err=%v want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadValidateRemoteKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    string
+		tls     string
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "https", auth: `  jwks_url: "https://idp.example.com/.well-known/jwks.json"`},
+		{name: "loopback http", auth: `  jwks_url: "http://127.0.0.1:8080/jwks"`},
+		{name: "remote http", auth: `  jwks_url: "http://idp.example.com/jwks"`, wantErr: "auth.jwks_url: must use https"},
+		{name: "relative", auth: `  jwks_url: "/jwks"`, wantErr: "must be an absolute URL"},
+		{name: "bad interval", auth: `  jwks_refresh_interval: "soon"`, wantErr: "auth.jwks_refresh_interval"},
+		{name: "short interval", auth: `  jwks_refresh_interval: "5s"`, wantErr: "at least 1m"},
+		{name: "oidc", auth: "  oidc:\n    issuer: \"https://sso.example.com\"\n    audience: \"bridge\""},
+		{name: "oidc without audience", auth: "  oidc:\n    issuer: \"https://sso.example.com\"", wantErr: "auth.oidc.audience is required"},
+		{name: "oidc without issuer", auth: "  oidc:\n    audience: \"bridge\"", wantErr: "auth.oidc.issuer is required"},
+		{name: "oidc http issuer", auth: "  oidc:\n    issuer: \"http://sso.example.com\"\n    audience: \"bridge\"", wantErr: "auth.oidc.issuer: must use https"},
+		{name: "spiffe", auth: "  spiffe:\n    trust_domain: example.org\n    bundle: bundle.pem\n    projects:\n      spiffe://example.org/ci: ci"},
+		{name: "spiffe without trust domain", auth: "  spiffe:\n    bundle: bundle.pem", wantErr: "auth.spiffe.trust_domain is required"},
+		{name: "spiffe without bundle", auth: "  spiffe:\n    trust_domain: example.org", wantErr: "auth.spiffe.bundle is required"},
+		{name: "spiffe url trust domain", auth: "  spiffe:\n    trust_domain: spiffe://example.org\n    bundle: bundle.pem", wantErr: "bare trust domain"},
+		{name: "spiffe foreign id", auth: "  spiffe:\n    trust_domain: example.org\n    bundle: bundle.pem\n    projects:\n      spiffe://other.org/ci: ci", wantErr: "not a SPIFFE ID in trust domain"},
+		{name: "spiffe empty project", auth: "  spiffe:\n    trust_domain: example.org\n    bundle: bundle.pem\n    projects:\n      spiffe://example.org/ci: \"\"", wantErr: "must not be empty"},
+		{name: "kubernetes", auth: "  kubernetes:\n    issuer: \"https://kubernetes.default.svc.cluster.local\"\n    audience: ai-agent-bridge\n    projects:\n      team-a/runner: alpha\n      team-b/*: beta"},
+		{name: "kubernetes without audience", auth: "  kubernetes:\n    issuer: \"https://kubernetes.default.svc.cluster.local\"", wantErr: "auth.kubernetes.audience is required"},
+		{name: "kubernetes without issuer", auth: "  kubernetes:\n    audience: ai-agent-bridge", wantErr: "auth.kubernetes.issuer is required"},
+		{name: "kubernetes bad account", auth: "  kubernetes:\n    issuer: \"https://kubernetes.default.svc.cluster.local\"\n    audience: ai-agent-bridge\n    projects:\n      runner: alpha", wantErr: "is not namespace/serviceaccount"},
+		{name: "kubernetes empty project", auth: "  kubernetes:\n    issuer: \"https://kubernetes.default.svc.cluster.local\"\n    audience: ai-agent-bridge\n    projects:\n      team-a/runner: \"\"", wantErr: "must not be empty"},
+		{name: "cert bindings", auth: "  cert_bindings:\n    - {cn: orchestrator-1, projects: [alpha, beta]}\n    - {ou: ops, uri: \"spiffe://example.org/ops\", projects: [\"*\"]}"},
+		{name: "cert binding without selector", auth: "  cert_bindings:\n    - {projects: [alpha]}", wantErr: "auth.cert_bindings[0] needs cn, ou or uri"},
+		{name: "cert binding without projects", auth: "  cert_bindings:\n    - {cn: orchestrator-1}", wantErr: "auth.cert_bindings[0].projects is required"},
+		{name: "cert binding empty project", auth: "  cert_bindings:\n    - {cn: orchestrator-1, projects: [\"\"]}", wantErr: "must not contain empty IDs"},
+		{name: "rotating jwt keys", auth: "  jwt_public_keys:\n    - {issuer: ci, key_path: old.pub, not_after: \"2026-02-01T00:00:00Z\"}\n    - {issuer: ci, key_path: new.pub, kid: ci-2026, not_before: \"2026-01-01T00:00:00Z\"}"},
+		{name: "jwt key without path", auth: "  jwt_public_keys:\n    - {issuer: ci}", wantErr: "issuer and key_path are required"},
+		{name: "jwt key bad window", auth: "  jwt_public_keys:\n    - {issuer: ci, key_path: a.pub, not_before: \"2026-02-01T00:00:00Z\", not_after: \"2026-01-01T00:00:00Z\"}", wantErr: "not_after must be after not_before"},
+		{name: "jwt key bad time", auth: "  jwt_public_keys:\n    - {issuer: ci, key_path: a.pub, not_after: tomorrow}", wantErr: "auth.jwt_public_keys[0]: not_after"},
+		{name: "jwt key duplicate kid", auth: "  jwt_public_keys:\n    - {issuer: ci, key_path: a.pub, kid: k}\n    - {issuer: ci, key_path: b.pub, kid: k}", wantErr: "duplicate kid"},
+		{name: "acme", tls: "  ca_bundle: ca-bundle.crt\n  acme:\n    domains: [bridge.example.com]"},
+		{name: "acme without bundle", tls: "  acme:\n    domains: [bridge.example.com]", wantErr: "tls.acme requires tls.ca_bundle"},
+		{name: "acme with cert", tls: "  ca_bundle: ca-bundle.crt\n  cert: server.crt\n  key: server.key\n  acme:\n    domains: [bridge.example.com]", wantErr: "cannot be combined with tls.cert"},
+		{name: "acme wildcard", tls: "  ca_bundle: ca-bundle.crt\n  acme:\n    domains: [\"*.example.com\"]", wantErr: "tls.acme.domains[0] must be a DNS name"},
+		{name: "acme without domains", tls: "  ca_bundle: ca-bundle.crt\n  acme:\n    email: ops@example.com", wantErr: "tls.acme.domains is required"},
+		{name: "acme http directory", tls: "  ca_bundle: ca-bundle.crt\n  acme:\n    domains: [bridge.example.com]\n    directory_url: \"http://acme.example.com/dir\"", wantErr: "tls.acme.directory_url: must use https"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bridge.yaml")
+			content := `
+server:
+  listen: "127.0.0.1:9445"
+tls:
+` + tt.tls + `
+auth:
+  jwt_max_ttl: "5m"
+` + tt.auth + `
+sessions:
+  idle_timeout: "30m"
+  stop_grace_period: "10s"
+  subscriber_ttl: "30m"
+`
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			cfg, err := Load(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if cfg.Auth.JWKSRefreshInterval != "5m" {
+					t.Fatalf("JWKSRefreshInterval=%q want default 5m", cfg.Auth.JWKSRefreshInterval)
+				}
+				if cfg.Auth.OIDC.Issuer != "" && cfg.Auth.OIDC.ProjectClaim != "project_id" {
+					t.Fatalf("OIDC.ProjectClaim=%q want default project_id", cfg.Auth.OIDC.ProjectClaim)
+				}
+				if cfg.Auth.Kubernetes.Issuer != "" && cfg.Auth.Kubernetes.JWKSURL != "https://kubernetes.default.svc/openid/v1/jwks" {
+					t.Fatalf("Kubernetes.JWKSURL=%q want in-cluster default", cfg.Auth.Kubernetes.JWKSURL)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err=%v want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadValidateGit(t *testing.T) {
+	for _, tc := range []struct {
+		git  string
+		want string
+	}{
+		{"  watch_interval: \"-1s\"", "git.watch_interval must be >= 0"},
+		{"  watch_interval: \"often\"", "git.watch_interval"},
+		{"  author_email: \"<bot@example.com>\"", "git.author_name and git.author_email"},
+	} {
+		path := filepath.Join(t.TempDir(), "bridge.yaml")
+		content := `
+server:
+  listen: "127.0.0.1:9445"
+auth:
+  jwt_max_ttl: "5m"
+git:
+` + tc.git + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("git %q: err=%v want %q", tc.git, err, tc.want)
+		}
+	}
+}
+
+func TestLoadValidateSessionRestart(t *testing.T) {
+	for _, tc := range []struct {
+		restart string
+		want    string
+	}{
+		{"    max_restarts: -1", "sessions.restart.max_restarts must be >= 0"},
+		{"    backoff: \"soon\"", "sessions.restart.backoff"},
+		{"    max_backoff: \"-1s\"", "sessions.restart.max_backoff must be >= 0"},
+	} {
+		path := filepath.Join(t.TempDir(), "bridge.yaml")
+		content := `
+server:
+  listen: "127.0.0.1:9445"
+auth:
+  jwt_max_ttl: "5m"
+sessions:
+  restart:
+` + tc.restart + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("restart %q: err=%v want %q", tc.restart, err, tc.want)
+		}
+	}
+}
+
+func TestLoadValidateServerCompression(t *testing.T) {
+	for _, tc := range []struct {
+		compression string
+		want        string
+	}{
+		{"    level: 10", "server.compression.level must be between 1 and 9"},
+		{"    level: -1", "server.compression.level must be between 1 and 9"},
+		{"    max_streams: -1", "server.compression.max_streams must be >= 0"},
+		{"    level: 1\n  shutdown_drain: soon", "server.shutdown_drain"},
+		{"    level: 1\n  shutdown_drain: -5s", "server.shutdown_drain must be >= 0"},
+	} {
+		path := filepath.Join(t.TempDir(), "bridge.yaml")
+		content := `
+server:
+  listen: "127.0.0.1:9445"
+  compression:
+` + tc.compression + `
+auth:
+  jwt_max_ttl: "5m"
+`
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("compression %q: err=%v want %q", tc.compression, err, tc.want)
+		}
+	}
+}
+
+func TestLoadInsecureDevMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  insecure_dev_mode: true\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Server.InsecureDevMode || cfg.Server.Listen != "" {
+		t.Fatalf("server = %+v, want insecure_dev_mode without the default listen", cfg.Server)
+	}
+
+	if err := os.WriteFile(path, []byte("server:\n  listen: \"127.0.0.1:9445\"\n  insecure_dev_mode: true\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "server.insecure_dev_mode cannot be combined with server.listen") {
+		t.Fatalf("listen with insecure_dev_mode: err=%v", err)
+	}
+}
+
+func TestLoadValidateArchiveEncryptionKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.yaml")
+	content := `
+server:
+  listen: "127.0.0.1:9445"
+persistence:
+  archive_encryption_key: "secret://bridge/../archive-key"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "persistence.archive_encryption_key") {
+		t.Fatalf("err=%v want persistence.archive_encryption_key error", err)
+	}
+}
+
+func TestLoadValidateWorkspaces(t *testing.T) {
+	for _, tc := range []struct {
+		workspaces string
+		want       string
+	}{
+		{"  project_quota_bytes: -1", "workspaces.project_quota_bytes must be >= 0"},
+		{"  allowed_urls: [\"https://github.com/[org/*\"]", "workspaces.allowed_urls"},
+	} {
+		path := filepath.Join(t.TempDir(), "bridge.yaml")
+		content := `
+server:
+  listen: "127.0.0.1:9445"
+auth:
+  jwt_max_ttl: "5m"
+workspaces:
+` + tc.workspaces + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("workspaces %q: err=%v want %q", tc.workspaces, err, tc.want)
+		}
+	}
+}
+
+func TestLoadValidateSecrets(t *testing.T) {
+	for name, tc := range map[string]struct {
+		yaml    string
+		wantErr string
+	}{
+		"default env":   {yaml: "providers:\n  claude:\n    binary: claude\n    secret_env:\n      ANTHROPIC_API_KEY: secret://anthropic/api-key\n"},
+		"vault":         {yaml: "secrets:\n  backend: vault\n  vault:\n    address: https://vault.example.com:8200\n"},
+		"vault no addr": {yaml: "secrets:\n  backend: vault\n", wantErr: "secrets.vault.address"},
+		"file no dir":   {yaml: "secrets:\n  backend: file\n", wantErr: "secrets.dir"},
+		"unknown":       {yaml: "secrets:\n  backend: keychain\n", wantErr: "secrets.backend"},
+		"bad ref":       {yaml: "providers:\n  claude:\n    binary: claude\n    secret_env:\n      ANTHROPIC_API_KEY: vault://x\n", wantErr: "secret_env.ANTHROPIC_API_KEY"},
+		"bad name":      {yaml: "providers:\n  claude:\n    binary: claude\n    secret_env:\n      \"A-B\": secret://a/b\n", wantErr: "invalid variable name"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bridge.yaml")
+			if err := os.WriteFile(path, []byte(tc.yaml), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			cfg, err := Load(path)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if cfg.Secrets.Vault.Mount != "secret" || cfg.Secrets.Vault.TokenEnv != "VAULT_TOKEN" || cfg.Secrets.EnvPrefix != "BRIDGE_SECRET_" {
+					t.Fatalf("Secrets defaults = %+v", cfg.Secrets)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadValidatePromptTemplates(t *testing.T) {
+	for name, tc := range map[string]struct {
+		yaml    string
+		wantErr string
+	}{
+		"valid":        {yaml: "  review-diff:\n    description: Review a diff\n    template: \"Review this diff:\\n{{.diff}}\"\n"},
+		"bad name":     {yaml: "  ../x:\n    template: hi\n", wantErr: "prompt_templates: invalid name"},
+		"no template":  {yaml: "  review:\n    description: empty\n", wantErr: "prompt_templates.review.template is required"},
+		"bad template": {yaml: "  review:\n    template: \"{{.diff\"\n", wantErr: "prompt_templates.review.template"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bridge.yaml")
+			if err := os.WriteFile(path, []byte("prompt_templates:\n"+tc.yaml), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			cfg, err := Load(path)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if cfg.PromptTemplates["review-diff"].Template != "Review this diff:\n{{.diff}}" {
+					t.Fatalf("PromptTemplates = %+v", cfg.PromptTemplates)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadValidateContentPolicy(t *testing.T) {
+	rule := "  rules:\n    - name: aws-key\n      pattern: 'AKIA[0-9A-Z]{16}'\n      action: redact\n"
+	classifier := "  classifier:\n    url: https://dlp.example/classify\n    timeout: 1s\n    directions: [input]\n"
+	for name, tc := range map[string]struct {
+		yaml    string
+		wantErr string
+	}{
+		"valid":             {yaml: rule + classifier},
+		"duplicate rule":    {yaml: rule + strings.TrimPrefix(rule, "  rules:\n"), wantErr: "duplicate name \"aws-key\""},
+		"bad pattern":       {yaml: strings.Replace(rule, "[0-9A-Z]", "[0-9A-Z", 1), wantErr: "content_policy.rules.aws-key.pattern"},
+		"bad action":        {yaml: strings.Replace(rule, "redact", "mask", 1), wantErr: "action must be block or redact"},
+		"bad direction":     {yaml: rule + "      directions: [stderr]\n", wantErr: "unknown direction \"stderr\""},
+		"bad url":           {yaml: strings.Replace(classifier, "https://dlp.example/classify", "dlp.example", 1), wantErr: "classifier.url must be an http or https URL"},
+		"no url":            {yaml: "  classifier:\n    fail_closed: true\n", wantErr: "classifier.url is required"},
+		"bad timeout":       {yaml: strings.Replace(classifier, "1s", "0s", 1), wantErr: "classifier.timeout must be > 0"},
+		"missing rule name": {yaml: strings.Replace(rule, "name: aws-key", "name: \"\"", 1), wantErr: "rules[0].name is required"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bridge.yaml")
+			if err := os.WriteFile(path, []byte("content_policy:\n"+tc.yaml), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			cfg, err := Load(path)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if len(cfg.ContentPolicy.Rules) != 1 || cfg.ContentPolicy.Classifier.URL == "" {
+					t.Fatalf("ContentPolicy = %+v", cfg.ContentPolicy)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadValidateSchedules(t *testing.T) {
+	valid := "  - name: nightly\n    cron: \"0 3 * * *\"\n    project_id: dev\n    provider: claude\n    repo_paths: [/repos/a]\n    prompt: audit\n"
+	for name, tc := range map[string]struct {
+		yaml    string
+		wantErr string
+	}{
+		"valid":          {yaml: valid},
+		"bad cron":       {yaml: strings.Replace(valid, "0 3 * * *", "0 3 * *", 1), wantErr: "schedules.nightly.cron"},
+		"bad name":       {yaml: strings.Replace(valid, "nightly", "../x", 1), wantErr: "schedules[0].name"},
+		"duplicate":      {yaml: valid + valid, wantErr: "duplicate name"},
+		"relative repo":  {yaml: strings.Replace(valid, "/repos/a", "repos/a", 1), wantErr: "must be an absolute path"},
+		"missing prompt": {yaml: strings.Replace(valid, "    prompt: audit\n", "", 1), wantErr: "prompt are required"},
+		"bad timeout":    {yaml: valid + "    timeout: soon\n", wantErr: "schedules.nightly.timeout"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bridge.yaml")
+			if err := os.WriteFile(path, []byte("schedules:\n"+tc.yaml), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			cfg, err := Load(path)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if len(cfg.Schedules) != 1 || cfg.Schedules[0].RepoPaths[0] != "/repos/a" {
+					t.Fatalf("Schedules = %+v", cfg.Schedules)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderVariant(t *testing.T) {
+	pc := ProviderConfig{
+		Binary:         "claude",
+		Args:           []string{"--verbose"},
+		MinVersion:     "2.0",
+		DefaultVariant: "stable",
+		Variants: map[string]ProviderVariantConfig{
+			"stable": {},
+			"canary": {Binary: "/opt/claude-canary/bin/claude", MinVersion: "2.1.0-beta"},
+		},
+	}
+	if err := ValidateProvider("claude", pc); err != nil {
+		t.Fatalf("ValidateProvider: %v", err)
+	}
+	stable := pc.Variant("stable")
+	if stable.Binary != "claude" || stable.MinVersion != "2.0" || stable.Variants != nil || stable.DefaultVariant != "" {
+		t.Fatalf("Variant(stable) = %+v, want the provider's fields", stable)
+	}
+	canary := pc.Variant("canary")
+	if canary.Binary != "/opt/claude-canary/bin/claude" || canary.MinVersion != "2.1.0-beta" || len(canary.Args) != 1 {
+		t.Fatalf("Variant(canary) = %+v, want its overrides on the provider's fields", canary)
+	}
+}
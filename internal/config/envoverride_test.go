@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnvOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.yaml")
+	content := `
+server:
+  listen: "127.0.0.1:9445"
+workspaces:
+  project_quota_bytes: 100
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	t.Setenv("BRIDGE_SERVER_LISTEN", "0.0.0.0:9000")
+	t.Setenv("BRIDGE_SERVER_COMPRESSION_ENABLED", "false")
+	t.Setenv("BRIDGE_SERVER_REFLECTION", "true")
+	t.Setenv("BRIDGE_WORKSPACES_PROJECT_QUOTA_BYTES", "200")
+	t.Setenv("BRIDGE_RATE_LIMITS_GLOBAL_RPS", "7.5")
+	t.Setenv("BRIDGE_ALLOWED_PATHS", "/repos, /workspace")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Listen != "0.0.0.0:9000" {
+		t.Fatalf("Server.Listen=%q", cfg.Server.Listen)
+	}
+	if e := cfg.Server.Compression.Enabled; e == nil || *e {
+		t.Fatalf("Server.Compression.Enabled=%v want false", e)
+	}
+	if !cfg.Server.Reflection {
+		t.Fatal("Server.Reflection not set from env")
+	}
+	if cfg.Workspaces.ProjectQuotaBytes != 200 {
+		t.Fatalf("Workspaces.ProjectQuotaBytes=%d want 200", cfg.Workspaces.ProjectQuotaBytes)
+	}
+	if cfg.RateLimits.GlobalRPS != 7.5 {
+		t.Fatalf("RateLimits.GlobalRPS=%v want 7.5", cfg.RateLimits.GlobalRPS)
+	}
+	if want := []string{"/repos", "/workspace"}; !slices.Equal(cfg.AllowedPaths, want) {
+		t.Fatalf("AllowedPaths=%v want %v", cfg.AllowedPaths, want)
+	}
+}
+
+func TestLoadEnvOverrideErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  listen: \"127.0.0.1:9445\"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	for key, val := range map[string]string{
+		"BRIDGE_SERVER_REFLECTION":              "maybe",
+		"BRIDGE_WORKSPACES_PROJECT_QUOTA_BYTES": "lots",
+		"BRIDGE_AUTH_JWT_PUBLIC_KEYS":           "ops",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, val)
+			if _, err := Load(path); err == nil || !strings.Contains(err.Error(), key) {
+				t.Fatalf("Load with %s=%q: err=%v", key, val, err)
+			}
+		})
+	}
+}
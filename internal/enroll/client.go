@@ -0,0 +1,154 @@
+package enroll
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxResponseBytes bounds the bundle and certificate a server returns.
+const maxResponseBytes = 1 << 20
+
+// Fingerprint returns the SHA-256 fingerprint of a certificate as
+// "sha256:<hex>", the form Client.CAFingerprint takes.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Client enrolls a host with an enrollment server.
+type Client struct {
+	// URL is the server's base URL, e.g. https://ca.example.com:8443.
+	URL string
+	// Bundle is the PEM trust bundle to verify the server with. When
+	// empty, the server's own bundle is fetched and used only if it
+	// contains a CA whose Fingerprint is CAFingerprint.
+	Bundle        []byte
+	CAFingerprint string
+}
+
+// Enroll redeems token for a client certificate for key.
+func (c *Client) Enroll(ctx context.Context, token string, key crypto.Signer) (*Response, error) {
+	bundle := c.Bundle
+	if len(bundle) == 0 {
+		var err error
+		if bundle, err = c.fetchBundle(ctx); err != nil {
+			return nil, err
+		}
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(bundle) {
+		return nil, errors.New("trust bundle holds no certificates")
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+	if err != nil {
+		return nil, fmt.Errorf("create csr: %w", err)
+	}
+	body, err := json.Marshal(Request{
+		Token: token,
+		CSR:   string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.URL, "/")+"/v1/enroll", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	data, err := do(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parse enrollment response: %w", err)
+	}
+	block, _ := pem.Decode([]byte(resp.Certificate))
+	if block == nil {
+		return nil, errors.New("enrollment response holds no certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse issued certificate: %w", err)
+	}
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.PublicKey) {
+		return nil, errors.New("issued certificate is not for this key")
+	}
+	return &resp, nil
+}
+
+// fetchBundle downloads the server's trust bundle without verifying the
+// connection, then accepts it only if it holds the pinned CA. The
+// enrollment itself is made over a connection verified with the bundle.
+func (c *Client) fetchBundle(ctx context.Context) ([]byte, error) {
+	want := normalizeFingerprint(c.CAFingerprint)
+	if want == "" {
+		return nil, errors.New("a trust bundle or CA fingerprint is required")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.URL, "/")+"/v1/ca-bundle", nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := do(&tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}, req)
+	if err != nil {
+		return nil, err
+	}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if cert.IsCA && normalizeFingerprint(Fingerprint(cert)) == want {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("server trust bundle holds no CA with fingerprint %s", c.CAFingerprint)
+}
+
+// normalizeFingerprint accepts fingerprints with or without the sha256:
+// prefix, in either case, with or without colons between bytes.
+func normalizeFingerprint(fp string) string {
+	fp = strings.ToLower(strings.TrimSpace(fp))
+	fp = strings.TrimPrefix(fp, "sha256:")
+	return strings.ReplaceAll(fp, ":", "")
+}
+
+func do(tlsCfg *tls.Config, req *http.Request) ([]byte, error) {
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsCfg},
+		Timeout:   30 * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
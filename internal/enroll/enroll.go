@@ -0,0 +1,216 @@
+// Package enroll hands client certificates to new hosts. An operator
+// creates a one-time enrollment token; the host generates its own key,
+// presents the token with a certificate signing request, and gets back a
+// certificate and the trust bundle. The private key never leaves the host.
+package enroll
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+)
+
+// ErrInvalidToken is returned for a token that does not exist, has
+// expired, or was already used.
+var ErrInvalidToken = errors.New("enrollment token is invalid, expired or already used")
+
+// maxRequestBytes bounds an enrollment request; a CSR is a few KiB.
+const maxRequestBytes = 64 << 10
+
+// Token is what an enrollment token entitles its holder to.
+type Token struct {
+	CommonName          string    `json:"common_name"`
+	OrganizationalUnits []string  `json:"organizational_units,omitempty"`
+	ExpiresAt           time.Time `json:"expires_at"`
+}
+
+// CreateToken stores t in dir and returns the token that redeems it. Only
+// a hash of the token is written, so the directory does not hold usable
+// tokens.
+func CreateToken(dir string, t Token) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", fmt.Errorf("marshal token: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("mkdir %s: %w", dir, err)
+	}
+	f, err := os.OpenFile(tokenPath(dir, token), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("write token: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("write token: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("write token: %w", err)
+	}
+	return token, nil
+}
+
+func tokenPath(dir, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// redeemToken removes token from dir and returns what it grants. Removing
+// the file is what makes a token single use, also when several servers
+// share the directory.
+func redeemToken(dir, token string, now time.Time) (*Token, error) {
+	path := tokenPath(dir, token)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read token: %w", err)
+	}
+	if err := os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		return nil, ErrInvalidToken // redeemed concurrently
+	} else if err != nil {
+		return nil, fmt.Errorf("remove token: %w", err)
+	}
+	var t Token
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse token: %w", err)
+	}
+	if !now.Before(t.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	return &t, nil
+}
+
+// Request is the body of POST /v1/enroll.
+type Request struct {
+	Token string `json:"token"`
+	// CSR is a PEM certificate signing request. Its subject is ignored;
+	// the certificate gets the token's.
+	CSR string `json:"csr"`
+}
+
+// Response is the reply to POST /v1/enroll.
+type Response struct {
+	// Certificate is the PEM client certificate, followed by the issuing
+	// CA when that is an intermediate.
+	Certificate string `json:"certificate"`
+	// CABundle is the PEM trust bundle for verifying the bridge.
+	CABundle string `json:"ca_bundle"`
+}
+
+// Server issues client certificates for enrollment tokens. Its handler
+// serves
+//
+//	GET  /v1/ca-bundle  the trust bundle, which clients check against a pinned fingerprint
+//	POST /v1/enroll     a client certificate for a Request
+type Server struct {
+	CACert *x509.Certificate
+	CAKey  *ecdsa.PrivateKey
+	// Bundle is the PEM trust bundle handed to clients.
+	Bundle []byte
+	// TokenDir holds the tokens CreateToken wrote.
+	TokenDir string
+	Logger   *slog.Logger
+}
+
+// Handler returns the enrollment HTTP handler.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/ca-bundle", s.handleBundle)
+	mux.HandleFunc("POST /v1/enroll", s.handleEnroll)
+	return mux
+}
+
+func (s *Server) handleBundle(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	_, _ = w.Write(s.Bundle)
+}
+
+func (s *Server) handleEnroll(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	csr, err := parseCSR(req.CSR)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The CSR is checked first so that a malformed one does not use up
+	// the token.
+	tok, err := redeemToken(s.TokenDir, req.Token, time.Now())
+	if errors.Is(err, ErrInvalidToken) {
+		s.Logger.Warn("enrollment rejected", "remote_addr", r.RemoteAddr, "error", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		s.Logger.Error("enrollment failed", "remote_addr", r.RemoteAddr, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	der, err := pki.SignCert(s.CACert, s.CAKey, pki.CertTypeClient, tok.CommonName, tok.OrganizationalUnits, nil, csr.PublicKey)
+	if err != nil {
+		s.Logger.Error("enrollment failed", "remote_addr", r.RemoteAddr, "cn", tok.CommonName, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if !pki.IsRootCA(s.CACert) {
+		chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.CACert.Raw})...)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	s.Logger.Info("issued client certificate", "remote_addr", r.RemoteAddr, "cn", tok.CommonName, "serial", cert.SerialNumber.String())
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(Response{Certificate: string(chain), CABundle: string(s.Bundle)})
+}
+
+// parseCSR parses a PEM CSR, checks its signature and that its key is one
+// the bridge accepts.
+func parseCSR(data string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("csr: no CERTIFICATE REQUEST block")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("csr: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("csr: %w", err)
+	}
+	switch pub := csr.PublicKey.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return nil, errors.New("csr: rsa keys must be at least 2048 bits")
+		}
+	default:
+		return nil, fmt.Errorf("csr: unsupported key type %T", pub)
+	}
+	return csr, nil
+}
@@ -0,0 +1,170 @@
+package enroll
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/pki"
+)
+
+// newTestServer starts an enrollment server whose certificate is issued
+// by a fresh CA and returns it with the CA certificate.
+func newTestServer(t *testing.T) (*httptest.Server, *x509.Certificate, string) {
+	t.Helper()
+	dir := t.TempDir()
+	caCertPath, caKeyPath, err := pki.InitCA("test-ca", dir)
+	if err != nil {
+		t.Fatalf("InitCA: %v", err)
+	}
+	caCert, caKey, err := pki.LoadCA(caCertPath, caKeyPath)
+	if err != nil {
+		t.Fatalf("LoadCA: %v", err)
+	}
+	certPath, keyPath, err := pki.IssueCert(caCert, caKey, pki.CertTypeServer, "localhost", []string{"127.0.0.1"}, dir)
+	if err != nil {
+		t.Fatalf("IssueCert: %v", err)
+	}
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair: %v", err)
+	}
+	bundle, err := os.ReadFile(caCertPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenDir := filepath.Join(dir, "enroll-tokens")
+	s := &Server{
+		CACert:   caCert,
+		CAKey:    caKey,
+		Bundle:   bundle,
+		TokenDir: tokenDir,
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	srv := httptest.NewUnstartedServer(s.Handler())
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{pair}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv, caCert, tokenDir
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestEnroll(t *testing.T) {
+	srv, caCert, tokenDir := newTestServer(t)
+	ctx := context.Background()
+	token, err := CreateToken(tokenDir, Token{CommonName: "orchestrator-1", OrganizationalUnits: []string{"ops"}, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	if entries, _ := os.ReadDir(tokenDir); len(entries) != 1 || strings.Contains(entries[0].Name(), token) {
+		t.Fatalf("token dir entries = %v", entries)
+	}
+
+	// The wrong fingerprint is refused before the token is sent.
+	bad := &Client{URL: srv.URL, CAFingerprint: "sha256:" + strings.Repeat("00", 32)}
+	if _, err := bad.Enroll(ctx, token, newKey(t)); err == nil || !strings.Contains(err.Error(), "fingerprint") {
+		t.Fatalf("wrong fingerprint err=%v", err)
+	}
+
+	key := newKey(t)
+	c := &Client{URL: srv.URL, CAFingerprint: strings.ToUpper(strings.TrimPrefix(Fingerprint(caCert), "sha256:"))}
+	resp, err := c.Enroll(ctx, token, key)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	pair, err := tls.X509KeyPair([]byte(resp.Certificate), pemKey(t, key))
+	if err != nil {
+		t.Fatalf("X509KeyPair: %v", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "orchestrator-1" || len(cert.Subject.OrganizationalUnit) != 1 || cert.Subject.OrganizationalUnit[0] != "ops" {
+		t.Fatalf("subject = %v", cert.Subject)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(resp.CABundle)) {
+		t.Fatal("no CA bundle in response")
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	// Tokens are single use.
+	if _, err := c.Enroll(ctx, token, newKey(t)); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("reused token err=%v", err)
+	}
+}
+
+func TestEnrollRejectsExpiredToken(t *testing.T) {
+	srv, caCert, tokenDir := newTestServer(t)
+	token, err := CreateToken(tokenDir, Token{CommonName: "late", ExpiresAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	bundle := pemCert(caCert)
+	c := &Client{URL: srv.URL, Bundle: bundle}
+	if _, err := c.Enroll(context.Background(), token, newKey(t)); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expired token err=%v", err)
+	}
+	if _, err := redeemToken(tokenDir, token, time.Now()); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expired token was kept: err=%v", err)
+	}
+}
+
+func TestParseCSR(t *testing.T) {
+	if _, err := parseCSR("not a csr"); err == nil {
+		t.Error("accepted garbage")
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := string(pemBlock("CERTIFICATE REQUEST", csr))
+	if _, err := parseCSR(good); err != nil {
+		t.Errorf("valid csr: %v", err)
+	}
+	csr[len(csr)-1] ^= 0xff
+	if _, err := parseCSR(string(pemBlock("CERTIFICATE REQUEST", csr))); err == nil {
+		t.Error("accepted a csr with a bad signature")
+	}
+}
+
+func pemBlock(typ string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+func pemCert(cert *x509.Certificate) []byte {
+	return pemBlock("CERTIFICATE", cert.Raw)
+}
+
+func pemKey(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pemBlock("PRIVATE KEY", der)
+}
@@ -1,11 +1,21 @@
 package localserver
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/config"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"github.com/markcallen/ai-agent-bridge/internal/redact"
+	"github.com/markcallen/ai-agent-bridge/internal/server"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -32,9 +42,10 @@ providers:
 	require.NoError(t, os.WriteFile(configPath, []byte(yaml), 0o644))
 
 	srv, err := Start(Config{
-		StateDir:   stateDir,
-		ConfigPath: configPath,
-		Logger:     testLogger(),
+		AllowInsecure: true,
+		StateDir:      stateDir,
+		ConfigPath:    configPath,
+		Logger:        testLogger(),
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -51,9 +62,10 @@ func TestStartWithMissingConfigPath(t *testing.T) {
 	missingPath := filepath.Join(t.TempDir(), "subdir", "bridge.yaml")
 
 	srv, err := Start(Config{
-		StateDir:   stateDir,
-		ConfigPath: missingPath,
-		Logger:     testLogger(),
+		AllowInsecure: true,
+		StateDir:      stateDir,
+		ConfigPath:    missingPath,
+		Logger:        testLogger(),
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -65,9 +77,10 @@ func TestStartWithEmptyConfigPath(t *testing.T) {
 	stateDir := t.TempDir()
 
 	srv, err := Start(Config{
-		StateDir:   stateDir,
-		ConfigPath: "",
-		Logger:     testLogger(),
+		AllowInsecure: true,
+		StateDir:      stateDir,
+		ConfigPath:    "",
+		Logger:        testLogger(),
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -102,9 +115,10 @@ providers:
 	require.NoError(t, os.WriteFile(configPath, []byte(yaml), 0o644))
 
 	srv, err := Start(Config{
-		StateDir:   stateDir,
-		ConfigPath: configPath,
-		Logger:     testLogger(),
+		AllowInsecure: true,
+		StateDir:      stateDir,
+		ConfigPath:    configPath,
+		Logger:        testLogger(),
 	})
 	require.NoError(t, err)
 	defer srv.Stop()
@@ -134,10 +148,405 @@ sessions:
 	require.NoError(t, os.WriteFile(configPath, []byte(invalidYAML), 0o644))
 
 	_, err := Start(Config{
-		StateDir:   stateDir,
-		ConfigPath: configPath,
-		Logger:     testLogger(),
+		AllowInsecure: true,
+		StateDir:      stateDir,
+		ConfigPath:    configPath,
+		Logger:        testLogger(),
 	})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "load config")
 }
+
+// TestReloadAppliesConfigChanges verifies that Reload swaps providers,
+// policy, and fallbacks from the rewritten config file, and that a broken
+// file leaves the running configuration untouched.
+func TestReloadAppliesConfigChanges(t *testing.T) {
+	stateDir := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+
+	write := func(content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0o644))
+	}
+	write(`
+providers:
+  first:
+    binary: "cat"
+    startup_probe: "none"
+`)
+
+	srv, err := Start(Config{
+		AllowInsecure: true,
+		StateDir:      stateDir,
+		ConfigPath:    configPath,
+		Logger:        testLogger(),
+	})
+	require.NoError(t, err)
+	defer srv.Stop()
+	require.Contains(t, registeredProviders(srv), "first")
+
+	allowed := t.TempDir()
+	write(fmt.Sprintf(`
+providers:
+  second:
+    binary: "cat"
+    startup_probe: "none"
+    fallbacks: ["spare"]
+  spare:
+    binary: "cat"
+    startup_probe: "none"
+allowed_paths:
+  - %q
+project_providers:
+  prod-docs: ["second"]
+`, allowed))
+	require.NoError(t, srv.Reload())
+
+	providers := registeredProviders(srv)
+	assert.Contains(t, providers, "second")
+	assert.NotContains(t, providers, "first")
+	assert.Contains(t, providers, "echo", "echo provider survives reload")
+
+	// The reloaded allowed_paths apply to new sessions.
+	_, err = srv.supervisor.Start(t.Context(), bridge.SessionConfig{
+		ProjectID: "p",
+		SessionID: "reload-policy",
+		RepoPath:  t.TempDir(),
+		Options:   map[string]string{"provider": "echo"},
+	})
+	require.ErrorIs(t, err, bridge.ErrInvalidArgument)
+
+	// The reloaded project_providers allowlist applies to new sessions.
+	_, err = srv.supervisor.Start(t.Context(), bridge.SessionConfig{
+		ProjectID: "prod-docs",
+		SessionID: "reload-allowlist",
+		RepoPath:  allowed,
+		Options:   map[string]string{"provider": "echo"},
+	})
+	require.ErrorIs(t, err, bridge.ErrPermissionDenied)
+
+	// An invalid file is rejected and the previous configuration stays live.
+	write(`
+providers:
+  third:
+    binary: ""
+`)
+	require.Error(t, srv.Reload())
+	assert.Contains(t, registeredProviders(srv), "second")
+}
+
+func TestResolveConfigProjectLimits(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+project_limits:
+  batch:
+    memory: "2GiB"
+    cpu_time: "30m"
+    max_processes: 128
+`), 0o644))
+
+	cfg, _, err := resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	want := bridge.ResourceLimits{MemoryBytes: 2 << 30, CPUSeconds: 1800, Processes: 128}
+	assert.Equal(t, want, buildPolicy(cfg).ProjectLimits["batch"])
+
+	// Explicit limits win over the file.
+	explicit := map[string]bridge.ResourceLimits{"batch": {Processes: 8}}
+	cfg, _, err = resolveConfig(Config{ConfigPath: configPath, ProjectLimits: explicit})
+	require.NoError(t, err)
+	assert.Equal(t, explicit, cfg.ProjectLimits)
+}
+
+func TestResolveConfigProjects(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+restrict_projects: true
+rate_limits:
+  project_rps: 4
+  project_burst: 8
+  interactive_reserve: 0.25
+project_providers:
+  docs: ["claude"]
+projects:
+  docs:
+    allowed_paths: ["/srv/docs/*"]
+    max_sessions: 2
+    rate_limits:
+      send_input_per_session_burst: 5
+      project_burst: 20
+  batch:
+    providers: ["codex"]
+    limits:
+      max_processes: 16
+    require_approval: true
+    mcp_servers:
+      issues:
+        type: http
+        url: https://issues.example/mcp
+`), 0o644))
+
+	cfg, _, err := resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	policy := buildPolicy(cfg)
+	assert.True(t, policy.RestrictProjects)
+	assert.Equal(t, bridge.ProjectPolicy{AllowedPaths: []string{"/srv/docs/*"}, MaxSessions: 2}, policy.Projects["docs"])
+	assert.Equal(t, map[string][]string{"docs": {"claude"}, "batch": {"codex"}}, policy.ProjectProviders)
+	assert.Equal(t, bridge.ResourceLimits{Processes: 16}, policy.ProjectLimits["batch"])
+	assert.Equal(t, map[string]bridge.MCPServer{"issues": {Type: bridge.MCPTransportHTTP, URL: "https://issues.example/mcp"}}, policy.Projects["batch"].MCPServers)
+	assert.True(t, policy.Projects["batch"].RequireApproval)
+	assert.Equal(t, 4.0, cfg.RateLimits.ProjectRPS)
+	assert.Equal(t, 8, cfg.RateLimits.ProjectBurst)
+	assert.Equal(t, 0.25, cfg.RateLimits.InteractiveReserve)
+	assert.Equal(t, server.ProjectRateLimits{SendInputPerSessionBurst: 5, ProjectBurst: 20}, cfg.RateLimits.Projects["docs"])
+	require.ErrorIs(t, policy.CheckProject("other"), bridge.ErrPermissionDenied)
+}
+
+func TestResolveConfigRetention(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+sessions:
+  archive_retention: "0"
+logging:
+  audit_dir: /var/log/bridge-audit
+projects:
+  gdpr:
+    retention:
+      event_buffer: 5m
+      transcripts: 72h
+      audit_logs: 720h
+  legal-hold:
+    retention:
+      transcripts: "0"
+      audit_logs: "0"
+`), 0o644))
+
+	cfg, _, err := resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(-1), cfg.ArchiveRetention)
+	assert.Equal(t, "/var/log/bridge-audit", cfg.AuditDir)
+	assert.Equal(t, 90*24*time.Hour, cfg.AuditRetention)
+	assert.Equal(t, map[string]time.Duration{"gdpr": 720 * time.Hour, "legal-hold": -1}, cfg.ProjectAuditRetention)
+	policy := buildPolicy(cfg)
+	assert.Equal(t, bridge.Retention{EventBuffer: 5 * time.Minute, Transcripts: 72 * time.Hour}, policy.Projects["gdpr"].Retention)
+	assert.Equal(t, bridge.Retention{Transcripts: -1}, policy.Projects["legal-hold"].Retention)
+}
+
+func TestResolveConfigMaxFileBytes(t *testing.T) {
+	cfg, _, err := resolveConfig(Config{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1<<20), buildPolicy(cfg).MaxFileBytes)
+	assert.Equal(t, 16<<20, buildPolicy(cfg).MaxStreamInputBytes)
+	assert.Equal(t, 1<<20, buildPolicy(cfg).MaxAttachmentBytes)
+
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+files:
+  max_size_bytes: 4096
+input:
+  max_stream_bytes: 8192
+  max_attachment_bytes: 2048
+`), 0o644))
+	cfg, _, err = resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4096), buildPolicy(cfg).MaxFileBytes)
+	assert.Equal(t, 8192, buildPolicy(cfg).MaxStreamInputBytes)
+	assert.Equal(t, 2048, buildPolicy(cfg).MaxAttachmentBytes)
+}
+
+func TestResolveConfigOutputCoalescing(t *testing.T) {
+	cfg, _, err := resolveConfig(Config{})
+	require.NoError(t, err)
+	assert.Zero(t, cfg.OutputFlushInterval)
+
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+sessions:
+  output_flush_interval: "50ms"
+  output_max_chunk_bytes: 4096
+`), 0o644))
+	cfg, _, err = resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	assert.Equal(t, 50*time.Millisecond, cfg.OutputFlushInterval)
+	assert.Equal(t, 4096, cfg.OutputMaxChunkBytes)
+}
+
+func TestResolveConfigGit(t *testing.T) {
+	cfg, _, err := resolveConfig(Config{})
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, buildPolicy(cfg).GitWatchInterval)
+
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+git:
+  author_name: "Release Bot"
+  author_email: "bot@example.com"
+  watch_interval: "0"
+`), 0o644))
+	cfg, _, err = resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	policy := buildPolicy(cfg)
+	assert.Equal(t, "Release Bot", policy.GitAuthorName)
+	assert.Equal(t, "bot@example.com", policy.GitAuthorEmail)
+	assert.Zero(t, policy.GitWatchInterval)
+}
+
+func TestResolveConfigWorkspaces(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+workspaces:
+  dir: "/srv/workspaces"
+  allowed_urls: ["https://github.com/example/*"]
+  project_quota_bytes: 1073741824
+`), 0o644))
+	cfg, _, err := resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/workspaces", cfg.WorkspaceDir)
+	policy := buildPolicy(cfg)
+	assert.Equal(t, []string{"https://github.com/example/*"}, policy.CloneURLs)
+	assert.Equal(t, int64(1<<30), policy.WorkspaceQuotaBytes)
+}
+
+func TestResolveConfigSchedules(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+persistence:
+  schedule_dir: "/srv/schedules"
+schedules:
+  - name: nightly-audit
+    cron: "@daily"
+    project_id: dev
+    provider: claude
+    repo_paths: ["/repos/a", "/repos/b"]
+    prompt: "Audit the dependencies"
+    timeout: 10m
+`), 0o644))
+	cfg, _, err := resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/schedules", cfg.ScheduleDir)
+	require.Len(t, cfg.Schedules, 1)
+	job := cfg.Schedules[0]
+	assert.Equal(t, "nightly-audit", job.Name)
+	assert.Equal(t, "@daily", job.Cron.String())
+	assert.Equal(t, []string{"/repos/a", "/repos/b"}, job.RepoPaths)
+	assert.Equal(t, 10*time.Minute, job.Timeout)
+}
+
+func TestResolveConfigRedaction(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+logging:
+  redact_patterns: ['internal-\d+']
+  redact_builtins: false
+`), 0o644))
+	cfg, _, err := resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`internal-\d+`}, cfg.RedactPatterns)
+	assert.True(t, cfg.NoBuiltinRedaction)
+
+	cfg, _, err = resolveConfig(Config{})
+	require.NoError(t, err)
+	assert.False(t, cfg.NoBuiltinRedaction, "built-in detectors are on by default")
+}
+
+func TestResolveConfigLogging(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+logging:
+  level: error
+  format: text
+  components:
+    auth: debug
+`), 0o644))
+	cfg, _, err := resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	assert.Equal(t, "error", cfg.LogLevel)
+	assert.Equal(t, "text", cfg.LogFormat)
+
+	// Flags win over the file.
+	flagged, _, err := resolveConfig(Config{ConfigPath: configPath, LogLevel: "debug", LogFormat: "json"})
+	require.NoError(t, err)
+	assert.Equal(t, "debug", flagged.LogLevel)
+	assert.Equal(t, "json", flagged.LogFormat)
+
+	levels := logging.NewLevels(slog.LevelWarn)
+	require.NoError(t, levels.Set(logging.ComponentProvider, slog.LevelDebug))
+	require.NoError(t, applyLogLevels(levels, cfg))
+	assert.Equal(t, slog.LevelError, levels.Level(""))
+	assert.Equal(t, slog.LevelDebug, levels.Level(logging.ComponentAuth))
+	assert.Equal(t, slog.LevelError, levels.Level(logging.ComponentProvider), "components left out of the config follow the default")
+
+	require.NoError(t, applyLogLevels(levels, Config{Verbose: true}))
+	assert.Equal(t, slog.LevelInfo, levels.Level(logging.ComponentAuth))
+	assert.Error(t, applyLogLevels(levels, Config{LogLevel: "loud"}))
+}
+
+func TestResolveConfigSecrets(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "secrets", "anthropic"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secrets", "anthropic", "api-key"), []byte("sk-file-secret-value\n"), 0o600))
+	configPath := filepath.Join(dir, "bridge.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+secrets:
+  backend: file
+  dir: "`+filepath.Join(dir, "secrets")+`"
+providers:
+  claude:
+    binary: "claude"
+    secret_env:
+      ANTHROPIC_API_KEY: "secret://anthropic/api-key"
+`), 0o644))
+	_, fp, err := resolveConfig(Config{ConfigPath: configPath})
+	require.NoError(t, err)
+	assert.Equal(t, "secret://anthropic/api-key", fp.defs["claude"].SecretEnv["ANTHROPIC_API_KEY"])
+
+	redactor, err := redact.New(nil)
+	require.NoError(t, err)
+	resolver := newSecretResolver(fp.secrets, redactor)
+	value, err := resolver.Resolve(context.Background(), "secret://anthropic/api-key")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-file-secret-value", value)
+	assert.Equal(t, "key=[REDACTED]", redactor.Redact("key=sk-file-secret-value"), "resolved secrets must be redacted")
+
+	// Without a config file the env backend is used.
+	t.Setenv("BRIDGE_SECRET_OPENAI_API_KEY", "sk-env-secret-value")
+	value, err = newSecretResolver(config.SecretsConfig{}, nil).Resolve(context.Background(), "secret://openai/api-key")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-env-secret-value", value)
+}
+
+func TestLoadArchiveKey(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{1}, bridge.ArchiveKeySize)
+	encoded := base64.StdEncoding.EncodeToString(key)
+	keyFile := filepath.Join(dir, "archive.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(encoded+"\n"), 0o600))
+	redactor, err := redact.New(nil)
+	require.NoError(t, err)
+	resolver := newSecretResolver(config.SecretsConfig{}, redactor)
+
+	got, err := loadArchiveKey(keyFile, resolver)
+	require.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	t.Setenv("BRIDGE_SECRET_BRIDGE_ARCHIVE_KEY", encoded)
+	got, err = loadArchiveKey("secret://bridge/archive-key", resolver)
+	require.NoError(t, err)
+	assert.Equal(t, key, got)
+	assert.Equal(t, "[REDACTED]", redactor.Redact(encoded), "the key must be redacted")
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("not base64!"), 0o600))
+	_, err = loadArchiveKey(keyFile, resolver)
+	assert.ErrorContains(t, err, "base64")
+
+	// A key file others can read is refused.
+	require.NoError(t, os.WriteFile(keyFile, []byte(encoded), 0o600))
+	require.NoError(t, os.Chmod(keyFile, 0o644))
+	_, err = loadArchiveKey(keyFile, resolver)
+	assert.ErrorContains(t, err, "readable by group or others")
+	require.NoError(t, os.Chmod(keyFile, 0o600))
+
+	// A key of the wrong size stops the daemon from starting.
+	require.NoError(t, os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString([]byte("short"))), 0o600))
+	_, err = Start(Config{AllowInsecure: true, StateDir: t.TempDir(), ArchiveEncryptionKey: keyFile, Logger: testLogger()})
+	assert.ErrorContains(t, err, "archive_encryption_key")
+}
@@ -5,32 +5,43 @@ package localserver
 
 import (
 	"context"
-	"crypto/ed25519"
+	"crypto"
 	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net"
+	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
 	"github.com/markcallen/ai-agent-bridge/internal/auth"
 	"github.com/markcallen/ai-agent-bridge/internal/bridge"
 	"github.com/markcallen/ai-agent-bridge/internal/config"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
 	"github.com/markcallen/ai-agent-bridge/internal/pki"
 	"github.com/markcallen/ai-agent-bridge/internal/provider"
 	"github.com/markcallen/ai-agent-bridge/internal/redact"
+	"github.com/markcallen/ai-agent-bridge/internal/secrets"
 	"github.com/markcallen/ai-agent-bridge/internal/server"
+	"github.com/markcallen/ai-agent-bridge/internal/systemd"
+	"golang.org/x/crypto/acme/autocert"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 // StateDir returns the ai-agent-bridge state directory. It respects the
@@ -64,15 +75,44 @@ func AddrPath() string {
 
 // Server wraps all the components needed for a local bridge server.
 type Server struct {
-	grpcServer *grpc.Server
-	supervisor *bridge.Supervisor
-	store      bridge.SessionStore // non-nil when persistence is enabled
-	registry   *bridge.Registry
-	listener   net.Listener
-	logger     *slog.Logger
-	stateDir   string
-	mu         sync.Mutex
-	stopped    bool
+	grpcServer   *grpc.Server
+	bridgeServer *server.BridgeServer
+	supervisor   *bridge.Supervisor
+	store        bridge.SessionStore // non-nil when persistence is enabled
+	registry     *bridge.Registry
+	verifier     *auth.JWTVerifier // non-nil in secure mode
+	pki          *PKIMaterial      // non-nil in secure mode
+	baseCfg      Config            // caller's Config before file merge; reused by Reload
+	listener     net.Listener
+	logger       *slog.Logger
+	stateDir     string
+	redactor     *redact.Redactor
+	certs        *auth.CertReloader // non-nil in secure mode
+	auditLog     *auth.AuditLog     // non-nil in secure mode with AuditDir
+	stopRefresh  context.CancelFunc // stops the certificate and JWKS refresh loops
+	scheduler    *bridge.Scheduler
+	stopSchedule context.CancelFunc // stops the scheduler and its in-flight runs
+	stopHealth   context.CancelFunc // stops grpc.health.v1 reporting, marking the services NOT_SERVING
+	acmeHTTP     *http.Server       // HTTP-01 challenge server; nil unless configured
+	healthHTTP   *http.Server       // /livez and /readyz server; nil unless configured
+	healthAddr   string
+	uiHTTP       *http.Server    // web dashboard server; nil unless configured
+	logLevels    *logging.Levels // nil when the caller supplied Logger
+	uiAddr       string
+	healthServer *health.Server
+	drain        time.Duration
+	stopping     atomic.Bool
+	activated    bool               // listener passed by systemd socket activation
+	stopWatchdog context.CancelFunc // nil unless the systemd watchdog is enabled
+	mu           sync.Mutex
+	stopped      bool
+
+	// providersMu serializes rebuilds of the registry from fileProviders
+	// and the providers registered at runtime in dynamic.
+	providersMu   sync.Mutex
+	fileProviders fileProviders
+	dynamic       *dynamicProviders
+	resolver      *secrets.Resolver
 }
 
 // ServerMode represents how the server is running.
@@ -111,20 +151,48 @@ func DiscoverMode(stateDir string) ServerMode {
 type Config struct {
 	// StateDir overrides the default ~/.ai-agent-bridge directory.
 	StateDir string
-	// Logger overrides the default logger. Nil uses a default logger at
-	// Warn level; set Verbose to lower it to Info.
+	// Logger overrides the default logger. Nil builds one from LogLevel,
+	// LogFormat and LogComponents, whose levels AdminService.SetLogLevel
+	// and Reload can change.
 	Logger *slog.Logger
-	// Verbose enables Info-level logging (session lifecycle events).
-	// Ignored when Logger is explicitly provided.
+	// Verbose enables Info-level logging (session lifecycle events) when
+	// LogLevel is not set. Ignored when Logger is explicitly provided.
 	Verbose bool
+	// LogLevel is the default log level: debug, info, warn or error.
+	// Empty means warn, or info in secure mode or with Verbose. Populated
+	// from logging.level.
+	LogLevel string
+	// LogFormat is text or json. Empty means text. Populated from
+	// logging.format.
+	LogFormat string
+	// LogComponents sets the level of components (auth, supervisor,
+	// provider, server) apart from LogLevel. Populated from
+	// logging.components.
+	LogComponents map[string]string
 	// AllowedPaths restricts which repo paths sessions may use.
 	// Empty means allow all.
 	AllowedPaths []string
+	// ProjectProviders restricts projects to specific provider IDs.
+	// Projects without an entry may use any provider.
+	ProjectProviders map[string][]string
+	// AllowedEnv lists environment variables StartSession callers may set.
+	// Empty means per-session environment is rejected.
+	AllowedEnv []string
+	// ProjectLimits caps the resources of each agent a project starts.
+	ProjectLimits map[string]bridge.ResourceLimits
+	// Projects overrides allowed paths and session limits per project.
+	Projects map[string]bridge.ProjectPolicy
+	// RestrictProjects refuses sessions from projects not in Projects.
+	RestrictProjects bool
 
 	// ListenAddr, when set, enables secure mode: the server binds to this
 	// TCP address with mTLS + JWT instead of a unix socket. Example:
 	// "10.0.0.1:9445" or "0.0.0.0:9445".
 	ListenAddr string
+	// AllowInsecure permits local mode, which has no TLS or
+	// authentication. Without it Start refuses to run unless ListenAddr
+	// is set. Populated from server.insecure_dev_mode.
+	AllowInsecure bool
 	// ServerSANs are additional DNS names or IP addresses for the server
 	// certificate. The host from ListenAddr is added automatically.
 	ServerSANs []string
@@ -144,21 +212,156 @@ type Config struct {
 	ProviderFallbacks map[string][]string
 
 	// RedactPatterns are compiled into a Redactor that scrubs sensitive
-	// values from log output.
+	// values from log output and from session output, recorded input and
+	// transcripts. The redact.BuiltinDetectors are always applied unless
+	// NoBuiltinRedaction is set.
 	RedactPatterns []string
+	// NoBuiltinRedaction turns off the built-in API key and token
+	// detectors. Populated from logging.redact_builtins.
+	NoBuiltinRedaction bool
 
 	// RateLimits overrides the default rate-limit config. Zero values keep
 	// the built-in defaults.
 	RateLimits server.RateLimitConfig
 
+	// Compression bounds gzip compression of responses to clients that
+	// request it. Populated from server.compression.
+	Compression server.CompressionConfig
+	// Reflection registers the gRPC reflection service. Populated from
+	// server.reflection.
+	Reflection bool
+	// HealthListen, when set, serves HTTP /livez and /readyz probes on this
+	// address. In local mode it must be a loopback address. Populated from
+	// server.health_listen.
+	HealthListen string
+	// UIListen, when set, serves the web dashboard on this address. In
+	// local mode it must be a loopback address. Populated from
+	// server.ui_listen.
+	UIListen string
+	// ShutdownDrain is how long Drain reports the server not ready before
+	// returning. Populated from server.shutdown_drain.
+	ShutdownDrain time.Duration
+
 	// EventBufferSize overrides the per-session output ring-buffer size in
 	// bytes. Zero uses the default (8 MiB).
 	EventBufferSize int
 
+	// SpillToDisk writes output evicted from a session's buffer to
+	// <StateDir>/spill so that replay can start from any sequence number.
+	SpillToDisk bool
+
+	// SpillMaxBytes caps each session's spill files. Zero uses the default
+	// (256 MiB).
+	SpillMaxBytes int64
+
+	// OutputFlushInterval merges adjacent output fragments into one event
+	// for up to this long. Zero publishes every fragment as it is read.
+	OutputFlushInterval time.Duration
+
+	// OutputMaxChunkBytes caps a merged output event. Zero uses the
+	// default (8 KiB).
+	OutputMaxChunkBytes int
+
+	// ProviderLogDir, when set, receives a log of each session's raw agent
+	// output. Populated from logging.provider_logs.dir.
+	ProviderLogDir string
+
+	// ProviderLogMaxBytes and ProviderLogMaxFiles control rotation of
+	// provider logs. Zero uses the defaults (10 MiB, 3 files).
+	ProviderLogMaxBytes int64
+	ProviderLogMaxFiles int
+
+	// AuditDir, when set, also writes the secure-mode RPC audit records to
+	// files in it, one per project per day. Populated from
+	// logging.audit_dir.
+	AuditDir string
+
+	// AuditRetention is how long audit files are kept. Zero uses the
+	// default (90 days); negative keeps them forever.
+	AuditRetention time.Duration
+
+	// ProjectAuditRetention replaces AuditRetention per project. Negative
+	// keeps the project's audit files forever.
+	ProjectAuditRetention map[string]time.Duration
+
 	// IdleTimeout overrides the session idle-timeout. Zero uses the
 	// default (30 minutes).
 	IdleTimeout time.Duration
 
+	// InputQueueDepth is how many inputs a stream-JSON session queues
+	// while the agent is responding. Zero delivers input immediately.
+	InputQueueDepth int
+
+	// Restart relaunches sessions whose agent crashes. The zero value lets
+	// crashed sessions fail.
+	Restart bridge.RestartPolicy
+
+	// OutputLimits caps each response of an agent. Populated from
+	// sessions.output_limits.
+	OutputLimits bridge.OutputLimits
+
+	// MaxFileBytes caps ReadFile and WriteFile payloads. Zero uses the
+	// default (1 MiB).
+	MaxFileBytes int64
+
+	// MaxStreamInputBytes caps an input sent with SendInputStream. Zero
+	// uses the default (16 MiB).
+	MaxStreamInputBytes int
+
+	// MaxAttachmentBytes caps the inline attachment content of one
+	// WriteInput. Zero uses the default (1 MiB).
+	MaxAttachmentBytes int
+
+	// GitAuthorName and GitAuthorEmail are the identity GitCommit commits
+	// as. Empty uses the bridge defaults.
+	GitAuthorName  string
+	GitAuthorEmail string
+	// GitWatchInterval is how often session repos are checked for changes
+	// to report as REPO_DIRTY. Zero uses the default (5s); negative
+	// disables the check.
+	GitWatchInterval time.Duration
+
+	// WorkspaceDir holds repos cloned for StartSession requests with a
+	// repo_source. Empty uses <StateDir>/workspaces.
+	WorkspaceDir string
+	// CloneURLs lists glob patterns for the repo URLs that may be cloned.
+	// Empty disables cloning.
+	CloneURLs []string
+	// WorkspaceQuotaBytes caps the disk used by each project's cloned
+	// workspaces. Zero means unlimited.
+	WorkspaceQuotaBytes int64
+	// WorkspaceMinFreeBytes is the free space on the workspace filesystem
+	// below which readiness probes fail. Zero uses the default (1 GiB);
+	// negative disables the check.
+	WorkspaceMinFreeBytes int64
+
+	// ArchiveDir overrides where stopped sessions are archived. Empty uses
+	// <StateDir>/archive.
+	ArchiveDir string
+	// ArchiveEncryptionKey, when set, encrypts archived sessions with the
+	// base64 key read from this file or secret:// reference. Populated from
+	// persistence.archive_encryption_key.
+	ArchiveEncryptionKey string
+	// ArchiveTTL is how long a stopped session stays in memory before it
+	// is archived and pruned. Zero uses the default (1 hour).
+	ArchiveTTL time.Duration
+	// ArchiveRetention is how long archived sessions are kept on disk.
+	// Zero uses the default (30 days); negative keeps them forever.
+	ArchiveRetention time.Duration
+
+	// Schedules are jobs that run a fixed prompt against repos on cron
+	// schedules. Populated from schedules.
+	Schedules []bridge.ScheduledJob
+	// PromptTemplates are the prompts clients may send by name. Populated
+	// from prompt_templates.
+	PromptTemplates map[string]bridge.PromptTemplate
+	// ContentPolicy blocks or redacts prompts and agent output. Populated
+	// from content_policy.
+	ContentPolicy bridge.ContentPolicy
+	// ScheduleDir receives the transcripts of scheduled runs. Empty uses
+	// <StateDir>/schedules.
+	ScheduleDir string
+
 	// Explicit TLS cert paths. When set, these override auto-PKI generation
 	// so pre-issued certificates (e.g. from a CI/CD pipeline) can be used.
 	// All three (CABundlePath, TLSCertPath, TLSKeyPath) must be provided
@@ -166,38 +369,762 @@ type Config struct {
 	CABundlePath string
 	TLSCertPath  string
 	TLSKeyPath   string
-
-	// JWTPublicKeys maps issuer name to public key file path for JWT
-	// verification in explicit-cert mode. Populated from auth.jwt_public_keys
-	// in the config file.
-	JWTPublicKeys map[string]string
+	// CRLPath names a CRL of revoked client certificates. Auto-PKI mode
+	// always uses <StateDir>/certs/ca.crl.
+	CRLPath string
+	// ACME, when Domains is set alongside CABundlePath, obtains and renews
+	// the server certificate from an ACME CA instead of TLSCertPath and
+	// TLSKeyPath. Populated from tls.acme.
+	ACME config.ACMEConfig
+
+	// JWTPublicKeys are the issuer public keys for JWT verification in
+	// explicit-cert mode. Populated from auth.jwt_public_keys in the config
+	// file.
+	JWTPublicKeys []config.JWTKeyConfig
+	// JWKSURL, when set in secure mode, adds keys fetched from a JWKS
+	// endpoint for tokens that carry a kid header. JWKSIssuer limits them
+	// to one issuer. Populated from auth.jwks_url and auth.jwks_issuer.
+	JWKSURL    string
+	JWKSIssuer string
+	// JWKSRefreshInterval is how often JWKS endpoints are refetched. Zero
+	// uses the default (5 minutes).
+	JWKSRefreshInterval time.Duration
+	// OIDC, when Issuer is set in secure mode, also accepts RS256/ES256
+	// tokens from that OpenID Connect provider. Populated from auth.oidc.
+	OIDC config.OIDCConfig
+	// SPIFFE, when TrustDomain is set in secure mode, accepts X.509 SVIDs
+	// from that trust domain as client certificates and authenticates the
+	// mapped SPIFFE IDs without a JWT. Populated from auth.spiffe.
+	SPIFFE config.SPIFFEConfig
+	// Kubernetes, when Issuer is set in secure mode, accepts projected
+	// service account tokens and maps their service accounts to projects.
+	// Populated from auth.kubernetes.
+	Kubernetes config.KubernetesConfig
+	// AdminCertOU, in secure mode, makes clients whose certificate has
+	// this organizational unit AdminService callers. Populated from
+	// auth.admin_cert_ou.
+	AdminCertOU string
+	// CertBindings, in secure mode, limits the projects tokens may name to
+	// those bound to the caller's client certificate. Populated from
+	// auth.cert_bindings.
+	CertBindings []config.CertBindingConfig
+	// SessionTokenKey, in secure mode, is the key session tokens are
+	// signed with: a PKCS#11 URI or a PEM file. Empty generates one in
+	// <StateDir>/certs. Populated from auth.session_token_key.
+	SessionTokenKey string
 }
 
-// Start launches a local bridge gRPC server. In local mode (default) it
-// listens on a unix socket (or TCP localhost on Windows) without auth.
-// In secure mode (ListenAddr set) it binds to TCP with mTLS + JWT.
+// Start launches a local bridge gRPC server. In local mode it listens on
+// a unix socket (or TCP localhost on Windows) without auth; this needs
+// AllowInsecure. In secure mode (ListenAddr set) it binds to TCP with
+// mTLS + JWT.
 func Start(cfg Config) (*Server, error) {
+	baseCfg := cfg
+	cfg, fp, err := resolveConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ListenAddr == "" && !cfg.AllowInsecure {
+		return nil, errors.New("refusing to start without TLS and authentication: set server.listen (--listen) for mTLS + JWT, " +
+			"or server.insecure_dev_mode (--allow-insecure) for a local development server")
+	}
+
+	stateDir := cfg.StateDir
+	if stateDir == "" {
+		stateDir = StateDir()
+	}
+	if err := os.MkdirAll(stateDir, 0o700); err != nil {
+		return nil, fmt.Errorf("create state dir %q: %w", stateDir, err)
+	}
+
+	logger := cfg.Logger
+	var logLevels *logging.Levels
+	if logger == nil {
+		logLevels = logging.NewLevels(slog.LevelWarn)
+		if err := applyLogLevels(logLevels, cfg); err != nil {
+			return nil, err
+		}
+		h, err := logging.NewHandler(os.Stderr, cfg.LogFormat, logLevels)
+		if err != nil {
+			return nil, fmt.Errorf("config: logging.format: %w", err)
+		}
+		logger = slog.New(h)
+	}
+
+	// The same redactor scrubs logs and session output so its hit counts
+	// cover both.
+	newRedactor := redact.NewWithBuiltins
+	if cfg.NoBuiltinRedaction {
+		newRedactor = redact.New
+	}
+	redactor, err := newRedactor(cfg.RedactPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("compile redact patterns: %w", err)
+	}
+	// Always wrapped: resolved provider secrets are added to the redactor
+	// later, even when no patterns are configured.
+	logger = slog.New(&redactingHandler{inner: logger.Handler(), redactor: redactor})
+
+	// Install as the default so internal packages that call slog.Warn etc.
+	// (e.g. supervisor's slow-observer warning) use the same configured logger.
+	slog.SetDefault(logger)
+
+	// Build provider registry. Config-file providers take precedence, then
+	// those registered at runtime; the auto-detect path fills in any
+	// providers not explicitly configured.
+	dynamic, err := loadDynamicProviders(filepath.Join(stateDir, dynamicProvidersFile))
+	if err != nil {
+		return nil, err
+	}
+	resolver := newSecretResolver(fp.secrets, redactor)
+	registry := bridge.NewRegistry()
+	for _, p := range buildProviders(fp, dynamic.snapshot(), resolver, logger) {
+		if err := registry.Register(p); err != nil {
+			logger.Warn("skip provider", "provider", p.ID(), "error", err)
+		}
+	}
+
+	policy := buildPolicy(cfg)
+
+	// Supervisor options: archive for stopped sessions, plus a persistence
+	// store when DBPath is set.
+	archiveDir := cfg.ArchiveDir
+	if archiveDir == "" {
+		archiveDir = filepath.Join(stateDir, "archive")
+	}
+	archive, err := bridge.NewFileArchive(archiveDir)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ArchiveEncryptionKey != "" {
+		key, err := loadArchiveKey(cfg.ArchiveEncryptionKey, resolver)
+		if err != nil {
+			return nil, err
+		}
+		if err := archive.SetEncryptionKey(key); err != nil {
+			return nil, fmt.Errorf("persistence.archive_encryption_key: %w", err)
+		}
+	}
+	workspaceDir := cfg.WorkspaceDir
+	if workspaceDir == "" {
+		workspaceDir = filepath.Join(stateDir, "workspaces")
+	}
+	scheduleDir := cfg.ScheduleDir
+	if scheduleDir == "" {
+		scheduleDir = filepath.Join(stateDir, "schedules")
+	}
+	supOpts := []bridge.SupervisorOption{
+		bridge.WithArchive(archive, cfg.ArchiveTTL),
+		bridge.WithArchiveRetention(max(cfg.ArchiveRetention, 0)),
+		bridge.WithWorkspaces(workspaceDir),
+		bridge.WithScheduleTranscripts(scheduleDir),
+		bridge.WithRedactor(redactor),
+	}
+	if cfg.SpillToDisk {
+		// Segments left by a previous run belong to no session.
+		spillDir := filepath.Join(stateDir, "spill")
+		if err := os.RemoveAll(spillDir); err != nil {
+			return nil, fmt.Errorf("clear spill dir: %w", err)
+		}
+		supOpts = append(supOpts, bridge.WithSpillDir(spillDir, cfg.SpillMaxBytes))
+	}
+	if cfg.OutputFlushInterval > 0 {
+		supOpts = append(supOpts, bridge.WithOutputCoalescing(cfg.OutputFlushInterval, cfg.OutputMaxChunkBytes))
+	}
+	if cfg.ProviderLogDir != "" {
+		supOpts = append(supOpts, bridge.WithProviderLogs(cfg.ProviderLogDir, cfg.ProviderLogMaxBytes, cfg.ProviderLogMaxFiles))
+	}
+	var store bridge.SessionStore
+	if cfg.DBPath != "" {
+		var err error
+		store, err = bridge.NewBoltSessionStore(cfg.DBPath)
+		if err != nil {
+			return nil, fmt.Errorf("open session store %q: %w", cfg.DBPath, err)
+		}
+		supOpts = append(supOpts, bridge.WithStore(store))
+	}
+
+	sup := bridge.NewSupervisor(registry, policy, cfg.EventBufferSize, cfg.IdleTimeout, supOpts...)
+	if store != nil {
+		if err := sup.LoadHistory(); err != nil {
+			logger.Warn("failed to load session history", "error", err)
+		}
+	}
+
+	// Server instance ID
+	instanceID := generateInstanceID()
+
+	// Determine server mode and build gRPC options accordingly.
+	mode := ModeLocal
+	var grpcOpts []grpc.ServerOption
+	var pkiMat *PKIMaterial
+	var verifier *auth.JWTVerifier
+	var sessionTokenKey crypto.Signer
+	var certs *auth.CertReloader
+	var auditLog *auth.AuditLog
+	var acmeMgr *autocert.Manager
+
+	if cfg.ListenAddr != "" {
+		// Secure mode: TCP + mTLS + JWT.
+		// TODO(windows): Secure mode (mTLS+JWT) is not yet supported on Windows.
+		// Windows support requires named-pipe ACLs or equivalent transport security.
+		if runtime.GOOS == "windows" {
+			sup.Close()
+			if store != nil {
+				_ = store.Close()
+			}
+			return nil, fmt.Errorf("secure mode (--listen) is not yet supported on Windows")
+		}
+
+		mode = ModeSecure
+		authLogger := logger.With(logging.ComponentKey, logging.ComponentAuth)
+
+		var mat *PKIMaterial
+		if cfg.CABundlePath != "" {
+			// Use pre-issued certificates from Config (e.g. provided via config file).
+			if len(cfg.ACME.Domains) > 0 {
+				if cfg.TLSCertPath != "" || cfg.TLSKeyPath != "" {
+					sup.Close()
+					if store != nil {
+						_ = store.Close()
+					}
+					return nil, fmt.Errorf("ACME cannot be combined with TLSCertPath and TLSKeyPath")
+				}
+				cacheDir := cfg.ACME.CacheDir
+				if cacheDir == "" {
+					cacheDir = filepath.Join(stateDir, "acme")
+				}
+				acmeMgr = auth.NewACMEManager(auth.ACMEConfig{
+					Domains:      cfg.ACME.Domains,
+					Email:        cfg.ACME.Email,
+					DirectoryURL: cfg.ACME.DirectoryURL,
+					CacheDir:     cacheDir,
+				})
+			} else if cfg.TLSCertPath == "" || cfg.TLSKeyPath == "" {
+				sup.Close()
+				if store != nil {
+					_ = store.Close()
+				}
+				return nil, fmt.Errorf("CABundlePath requires TLSCertPath and TLSKeyPath to also be set")
+			}
+			mat = &PKIMaterial{
+				CABundlePath:   cfg.CABundlePath,
+				ServerCertPath: cfg.TLSCertPath,
+				ServerKeyPath:  cfg.TLSKeyPath,
+				CRLPath:        cfg.CRLPath,
+			}
+		} else {
+			// Auto-generate PKI material if not present.
+			sans := buildServerSANs(cfg.ListenAddr, cfg.ServerSANs)
+			var pkiErr error
+			mat, pkiErr = EnsurePKI(stateDir, sans, authLogger)
+			if pkiErr != nil {
+				sup.Close()
+				if store != nil {
+					_ = store.Close()
+				}
+				return nil, fmt.Errorf("ensure PKI: %w", pkiErr)
+			}
+		}
+
+		pkiMat = mat
+		// The key is registered before the verifier loads its keys.
+		sessionTokenKey, err = EnsureSessionTokenKey(stateDir, cfg.SessionTokenKey)
+		if err != nil {
+			sup.Close()
+			if store != nil {
+				_ = store.Close()
+			}
+			return nil, err
+		}
+		verifier, err = newJWTVerifier(mat, stateDir, authLogger, cfg.JWTPublicKeys)
+		if err != nil {
+			sup.Close()
+			if store != nil {
+				_ = store.Close()
+			}
+			return nil, fmt.Errorf("build JWT verifier: %w", err)
+		}
+		if cfg.JWKSURL != "" {
+			jwks := &auth.JWKSCache{URL: cfg.JWKSURL, Issuer: cfg.JWKSIssuer}
+			// An unreachable identity provider must not keep the bridge
+			// down; file-based keys keep working and Run retries.
+			if err := jwks.Refresh(context.Background()); err != nil {
+				authLogger.Warn("initial jwks fetch failed", "url", cfg.JWKSURL, "error", err)
+			}
+			verifier.JWKS = jwks
+		}
+		if cfg.OIDC.Issuer != "" {
+			oidc, oidcErr := newOIDCProvider(cfg.OIDC, authLogger)
+			if oidcErr != nil {
+				sup.Close()
+				if store != nil {
+					_ = store.Close()
+				}
+				return nil, oidcErr
+			}
+			verifier.OIDC = oidc
+		}
+		if cfg.SPIFFE.TrustDomain != "" {
+			verifier.SPIFFE = &auth.SPIFFEIdentities{
+				TrustDomain: cfg.SPIFFE.TrustDomain,
+				BundlePath:  cfg.SPIFFE.Bundle,
+			}
+			verifier.SPIFFE.SetProjects(cfg.SPIFFE.Projects)
+		}
+		if cfg.Kubernetes.Issuer != "" {
+			k8s, k8sErr := newKubernetesTokens(cfg.Kubernetes, authLogger)
+			if k8sErr != nil {
+				sup.Close()
+				if store != nil {
+					_ = store.Close()
+				}
+				return nil, k8sErr
+			}
+			verifier.Kubernetes = k8s
+		}
+		verifier.AdminCertOU = cfg.AdminCertOU
+		verifier.CertBindings = &auth.CertBindings{}
+		verifier.CertBindings.Set(certBindings(cfg.CertBindings))
+		revocations, revErr := auth.LoadTokenRevocations(filepath.Join(stateDir, "revoked-tokens.json"))
+		if revErr != nil {
+			sup.Close()
+			if store != nil {
+				_ = store.Close()
+			}
+			return nil, revErr
+		}
+		verifier.Revocations = revocations
+		auditLogger := authLogger
+		if cfg.AuditDir != "" {
+			if auditLog, err = auth.OpenAuditLog(cfg.AuditDir); err != nil {
+				sup.Close()
+				if store != nil {
+					_ = store.Close()
+				}
+				return nil, fmt.Errorf("logging.audit_dir: %w", err)
+			}
+			auditLog.SetRetention(cfg.AuditRetention, cfg.ProjectAuditRetention)
+			// The files are redacted like the daemon's own log.
+			auditLogger = slog.New(&teeHandler{
+				handlers: []slog.Handler{authLogger.Handler(), &redactingHandler{inner: auditLog.Handler(), redactor: redactor}},
+			})
+		}
+		var secureOpts []grpc.ServerOption
+		secureOpts, certs, err = buildSecureGRPCOpts(mat, acmeMgr, verifier, authLogger, auditLogger)
+		if err != nil {
+			sup.Close()
+			if store != nil {
+				_ = store.Close()
+			}
+			return nil, fmt.Errorf("build secure gRPC options: %w", err)
+		}
+		grpcOpts = secureOpts
+	} else {
+		// Local mode: unix socket, anonymous passthrough auth.
+		grpcOpts = []grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(auth.UnaryPassthroughInterceptor()),
+			grpc.ChainStreamInterceptor(auth.StreamPassthroughInterceptor()),
+		}
+	}
+
+	// Request IDs come first so that every later interceptor, the audit
+	// log in particular, sees them.
+	grpcOpts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(auth.UnaryRequestIDInterceptor()),
+		grpc.ChainStreamInterceptor(auth.StreamRequestIDInterceptor()),
+	}, grpcOpts...)
+
+	// ImportSessionState carries a session's whole output buffer, base64
+	// encoded in JSON, which can exceed gRPC's default 4 MiB message limit.
+	grpcOpts = append(grpcOpts, grpc.MaxRecvMsgSize(2*cfg.EventBufferSize+(4<<20)))
+
+	compression, err := server.NewCompressionLimiter(cfg.Compression)
+	if err != nil {
+		sup.Close()
+		if store != nil {
+			_ = store.Close()
+		}
+		return nil, err
+	}
+	grpcOpts = append(grpcOpts,
+		grpc.ChainUnaryInterceptor(compression.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(compression.StreamInterceptor()),
+	)
+
+	grpcServer := grpc.NewServer(grpcOpts...)
+
+	providerFallbacks := cfg.ProviderFallbacks
+
+	bridgeServer := server.New(sup, registry, logger.With(logging.ComponentKey, logging.ComponentServer), cfg.RateLimits, instanceID, providerFallbacks)
+	if sessionTokenKey != nil {
+		bridgeServer.SetSessionTokenIssuer(&auth.JWTIssuer{
+			Issuer:   SessionTokenIssuer,
+			Audience: verifier.Audience,
+			Key:      sessionTokenKey,
+			TTL:      verifier.MaxTTL,
+		})
+	}
+	if certs != nil && acmeMgr == nil {
+		bridgeServer.AddReadinessCheck("certificate", func(context.Context) error {
+			return checkCertExpiry(certs.NotAfter(), time.Now())
+		})
+	}
+	if minFree := cfg.WorkspaceMinFreeBytes; minFree >= 0 {
+		if minFree == 0 {
+			minFree = defaultWorkspaceMinFree
+		}
+		bridgeServer.AddReadinessCheck("disk", func(context.Context) error {
+			return checkDiskFree(workspaceDir, uint64(minFree))
+		})
+	}
+	s := &Server{drain: cfg.ShutdownDrain}
+	bridgeServer.AddReadinessCheck("draining", func(context.Context) error {
+		if bridgeServer.Draining() {
+			return errors.New("server is draining")
+		}
+		return nil
+	})
+	bridgev1.RegisterBridgeServiceServer(grpcServer, bridgeServer)
+	var revocations *auth.TokenRevocations
+	if verifier != nil {
+		revocations = verifier.Revocations
+	}
+	admin := server.NewAdmin(bridgeServer, revocations, s.Reload, s.markNotReady)
+	admin.SetProviderRegistrar(s)
+	if logLevels != nil {
+		admin.SetLogLevels(logLevels)
+	}
+	bridgev1.RegisterAdminServiceServer(grpcServer, admin)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if cfg.Reflection {
+		reflection.Register(grpcServer)
+		logger.Warn("gRPC reflection enabled; RPCs can be listed without the proto files")
+	}
+
+	// Listen: TCP for secure mode, unix socket for local mode, unless
+	// systemd passed a socket.
+	var ln net.Listener
+	var listenAddr string
+	activated, err := activatedListener(mode, logger)
+	if err != nil {
+		sup.Close()
+		return nil, err
+	}
+	if activated != nil {
+		ln = activated
+		listenAddr = ln.Addr().String()
+		logger.Info("using socket from systemd", "addr", listenAddr)
+	} else if mode == ModeSecure {
+		ln, err = net.Listen("tcp", cfg.ListenAddr)
+		if err != nil {
+			sup.Close()
+			return nil, fmt.Errorf("listen tcp %s: %w", cfg.ListenAddr, err)
+		}
+		listenAddr = ln.Addr().String()
+	} else {
+		ln, listenAddr, err = listen(stateDir)
+		if err != nil {
+			sup.Close()
+			return nil, fmt.Errorf("listen: %w", err)
+		}
+	}
+
+	// TLS-ALPN-01 needs the gRPC listener on port 443; HTTP-01 can be
+	// answered on a separate port-80 listener instead.
+	var acmeLn net.Listener
+	if acmeMgr != nil && cfg.ACME.HTTPAddr != "" {
+		acmeLn, err = net.Listen("tcp", cfg.ACME.HTTPAddr)
+		if err != nil {
+			_ = ln.Close()
+			sup.Close()
+			return nil, fmt.Errorf("listen acme http %s: %w", cfg.ACME.HTTPAddr, err)
+		}
+	}
+
+	var healthLn net.Listener
+	if cfg.HealthListen != "" {
+		healthLn, err = listenHTTP("health", cfg.HealthListen, mode)
+		if err != nil {
+			_ = ln.Close()
+			if acmeLn != nil {
+				_ = acmeLn.Close()
+			}
+			sup.Close()
+			return nil, err
+		}
+	}
+	var uiLn net.Listener
+	if cfg.UIListen != "" {
+		uiLn, err = listenHTTP("ui", cfg.UIListen, mode)
+		if err != nil {
+			_ = ln.Close()
+			if acmeLn != nil {
+				_ = acmeLn.Close()
+			}
+			if healthLn != nil {
+				_ = healthLn.Close()
+			}
+			sup.Close()
+			return nil, err
+		}
+	}
+	closeListeners := func() {
+		_ = ln.Close()
+		if acmeLn != nil {
+			_ = acmeLn.Close()
+		}
+		if healthLn != nil {
+			_ = healthLn.Close()
+		}
+		if uiLn != nil {
+			_ = uiLn.Close()
+		}
+	}
+
+	// Write PID file.
+	pidFile := filepath.Join(stateDir, "server.pid")
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		closeListeners()
+		sup.Close()
+		return nil, fmt.Errorf("write pid file: %w", err)
+	}
+
+	// Write address file.
+	addrFile := filepath.Join(stateDir, "server.addr")
+	if err := os.WriteFile(addrFile, []byte(listenAddr), 0o644); err != nil {
+		closeListeners()
+		sup.Close()
+		return nil, fmt.Errorf("write addr file: %w", err)
+	}
+
+	// Write mode file so discovery knows how to connect.
+	modeFile := filepath.Join(stateDir, "server.mode")
+	if err := os.WriteFile(modeFile, []byte(string(mode)), 0o644); err != nil {
+		closeListeners()
+		sup.Close()
+		return nil, fmt.Errorf("write mode file: %w", err)
+	}
+
+	logger.Info("server starting", "mode", mode, "addr", listenAddr, "pid", os.Getpid())
+
+	scheduler := bridge.NewScheduler(sup, scheduleDir)
+	scheduler.SetJobs(cfg.Schedules)
+	scheduleCtx, stopSchedule := context.WithCancel(context.Background())
+	go scheduler.Run(scheduleCtx)
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	go bridgeServer.ReportHealth(healthCtx, healthServer)
+
+	s.grpcServer = grpcServer
+	s.bridgeServer = bridgeServer
+	s.supervisor = sup
+	s.store = store
+	s.registry = registry
+	s.fileProviders = fp
+	s.dynamic = dynamic
+	s.resolver = resolver
+	s.verifier = verifier
+	s.certs = certs
+	s.auditLog = auditLog
+	s.pki = pkiMat
+	s.baseCfg = baseCfg
+	s.listener = ln
+	s.logger = logger
+	s.logLevels = logLevels
+	s.stateDir = stateDir
+	s.redactor = redactor
+	s.scheduler = scheduler
+	s.stopSchedule = stopSchedule
+	s.stopHealth = stopHealth
+	s.healthServer = healthServer
+	s.activated = activated != nil
+
+	if mode == ModeSecure {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopRefresh = cancel
+		go certs.Run(ctx, certReloadInterval)
+		authLogger := logger.With(logging.ComponentKey, logging.ComponentAuth)
+		if verifier.JWKS != nil {
+			go verifier.JWKS.Run(ctx, cfg.JWKSRefreshInterval, authLogger)
+		}
+		if verifier.OIDC != nil {
+			go verifier.OIDC.JWKS.Run(ctx, cfg.JWKSRefreshInterval, authLogger)
+		}
+		if verifier.Kubernetes != nil {
+			go verifier.Kubernetes.JWKS.Run(ctx, cfg.JWKSRefreshInterval, authLogger)
+		}
+		if auditLog != nil {
+			go auditLog.Run(ctx, auditPruneInterval, authLogger)
+		}
+	}
+
+	if acmeLn != nil {
+		s.acmeHTTP = &http.Server{
+			Handler:           acmeMgr.HTTPHandler(http.NotFoundHandler()),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := s.acmeHTTP.Serve(acmeLn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("acme http serve", "error", err)
+			}
+		}()
+	}
+
+	if healthLn != nil {
+		s.healthHTTP = &http.Server{
+			Handler:           bridgeServer.HealthHandler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := s.healthHTTP.Serve(healthLn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("health http serve", "error", err)
+			}
+		}()
+		s.healthAddr = healthLn.Addr().String()
+		logger.Info("serving http health probes", "addr", s.healthAddr)
+	}
+
+	if uiLn != nil {
+		var verify func(string) (*auth.BridgeClaims, error)
+		if verifier != nil {
+			verify = verifier.Verify
+		}
+		s.uiHTTP = &http.Server{
+			Handler:           bridgeServer.UIHandler(verify),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := s.uiHTTP.Serve(uiLn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("ui http serve", "error", err)
+			}
+		}()
+		s.uiAddr = uiLn.Addr().String()
+		logger.Info("serving web dashboard", "url", "http://"+s.uiAddr+"/ui/")
+	}
+
+	go func() {
+		if err := grpcServer.Serve(ln); err != nil {
+			logger.Error("grpc serve", "error", err)
+		}
+	}()
+
+	notify(logger, systemd.Ready)
+	if interval := systemd.WatchdogInterval(); interval > 0 {
+		watchdogCtx, stopWatchdog := context.WithCancel(context.Background())
+		s.stopWatchdog = stopWatchdog
+		go runWatchdog(watchdogCtx, interval/2, logger)
+	}
+
+	return s, nil
+}
+
+// activatedListener returns the gRPC socket passed by systemd socket
+// activation, or nil when the daemon was not socket-activated. A socket
+// named "grpc" is preferred; otherwise the first one is used. The socket
+// must match the mode: a unix socket in local mode, TCP in secure mode,
+// so that an activated TCP socket is never served without auth.
+func activatedListener(mode ServerMode, logger *slog.Logger) (net.Listener, error) {
+	lns, err := systemd.Listeners()
+	if err != nil || len(lns) == 0 {
+		return nil, err
+	}
+	return chooseActivated(mode, lns, logger)
+}
+
+func chooseActivated(mode ServerMode, lns []systemd.Listener, logger *slog.Logger) (net.Listener, error) {
+	chosen := 0
+	for i, l := range lns {
+		if l.Name == "grpc" {
+			chosen = i
+			break
+		}
+	}
+	for i, l := range lns {
+		if i != chosen {
+			logger.Warn("ignoring extra socket from systemd", "name", l.Name, "addr", l.Addr().String())
+			_ = l.Close()
+		}
+	}
+	ln := lns[chosen].Listener
+	_, isUnix := ln.Addr().(*net.UnixAddr)
+	switch {
+	case mode == ModeSecure && isUnix:
+		_ = ln.Close()
+		return nil, fmt.Errorf("socket activation: secure mode needs a TCP socket, got unix socket %s", ln.Addr())
+	case mode != ModeSecure && !isUnix:
+		_ = ln.Close()
+		return nil, fmt.Errorf("socket activation: TCP socket %s needs secure mode (--listen or server.listen)", ln.Addr())
+	}
+	return ln, nil
+}
+
+// notify sends state to systemd when it supervises the daemon.
+func notify(logger *slog.Logger, state string) {
+	if _, err := systemd.Notify(state); err != nil {
+		logger.Warn("systemd notify failed", "error", err)
+	}
+}
+
+// runWatchdog pings the systemd watchdog every interval until ctx is done.
+func runWatchdog(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			notify(logger, systemd.Watchdog)
+		}
+	}
+}
+
+// fileProviders holds the provider definitions read from the config file.
+type fileProviders struct {
+	defs    map[string]config.ProviderConfig
+	root    string // runtime.provider_root
+	secrets config.SecretsConfig
+}
+
+// resolveConfig merges the YAML file at cfg.ConfigPath into cfg and fills in
+// built-in defaults. Explicit fields in cfg take precedence over file values.
+// It is called once by Start and again by Reload with the caller's original
+// Config, so a reload sees the same precedence rules as startup.
+func resolveConfig(cfg Config) (Config, fileProviders, error) {
 	// Merge YAML config file values into cfg. Explicit fields in cfg take
 	// precedence: we only apply file values when the cfg field is still at
 	// its zero value.
-	var configProviderDefs map[string]config.ProviderConfig
-	var providerRoot string
+	var fp fileProviders
 	if cfg.ConfigPath != "" {
 		fileCfg, err := config.Load(cfg.ConfigPath)
 		if err != nil && !errors.Is(err, os.ErrNotExist) {
-			return nil, fmt.Errorf("load config %q: %w", cfg.ConfigPath, err)
+			return cfg, fp, fmt.Errorf("load config %q: %w", cfg.ConfigPath, err)
 		}
 		if fileCfg != nil {
 			if len(fileCfg.Providers) > 0 {
-				configProviderDefs = fileCfg.Providers
+				fp.defs = fileCfg.Providers
 			}
-			providerRoot = fileCfg.Runtime.ProviderRoot
+			fp.root = fileCfg.Runtime.ProviderRoot
+			fp.secrets = fileCfg.Secrets
 			if cfg.DBPath == "" && fileCfg.Persistence.DBPath != "" {
 				cfg.DBPath = fileCfg.Persistence.DBPath
 			}
+			if cfg.LogLevel == "" {
+				cfg.LogLevel = fileCfg.Logging.Level
+			}
+			if cfg.LogFormat == "" {
+				cfg.LogFormat = fileCfg.Logging.Format
+			}
+			if cfg.LogComponents == nil {
+				cfg.LogComponents = fileCfg.Logging.Components
+			}
 			if cfg.RedactPatterns == nil && len(fileCfg.Logging.RedactPatterns) > 0 {
 				cfg.RedactPatterns = fileCfg.Logging.RedactPatterns
 			}
+			if fileCfg.Logging.RedactBuiltins != nil && !*fileCfg.Logging.RedactBuiltins {
+				cfg.NoBuiltinRedaction = true
+			}
 			if cfg.RateLimits.GlobalRPS == 0 && fileCfg.RateLimits.GlobalRPS > 0 {
 				cfg.RateLimits.GlobalRPS = fileCfg.RateLimits.GlobalRPS
 			}
@@ -216,28 +1143,208 @@ func Start(cfg Config) (*Server, error) {
 			if cfg.RateLimits.SendInputPerSessionBurst == 0 && fileCfg.RateLimits.SendInputPerSessionBurst > 0 {
 				cfg.RateLimits.SendInputPerSessionBurst = fileCfg.RateLimits.SendInputPerSessionBurst
 			}
+			if cfg.RateLimits.ProjectRPS == 0 && fileCfg.RateLimits.ProjectRPS > 0 {
+				cfg.RateLimits.ProjectRPS = fileCfg.RateLimits.ProjectRPS
+			}
+			if cfg.RateLimits.ProjectBurst == 0 && fileCfg.RateLimits.ProjectBurst > 0 {
+				cfg.RateLimits.ProjectBurst = fileCfg.RateLimits.ProjectBurst
+			}
+			if cfg.RateLimits.InteractiveReserve == 0 && fileCfg.RateLimits.InteractiveReserve > 0 {
+				cfg.RateLimits.InteractiveReserve = fileCfg.RateLimits.InteractiveReserve
+			}
 			if cfg.EventBufferSize == 0 && fileCfg.Sessions.EventBufferSize > 0 {
 				cfg.EventBufferSize = fileCfg.Sessions.EventBufferSize
 			}
+			if fileCfg.Sessions.SpillToDisk {
+				cfg.SpillToDisk = true
+			}
+			if cfg.SpillMaxBytes == 0 {
+				cfg.SpillMaxBytes = fileCfg.Sessions.SpillMaxBytes
+			}
+			if cfg.OutputFlushInterval == 0 && fileCfg.Sessions.OutputFlushInterval != "" {
+				cfg.OutputFlushInterval = config.ParseDuration(fileCfg.Sessions.OutputFlushInterval, 0)
+			}
+			if cfg.OutputMaxChunkBytes == 0 {
+				cfg.OutputMaxChunkBytes = fileCfg.Sessions.OutputMaxChunkBytes
+			}
+			if cfg.ProviderLogDir == "" {
+				cfg.ProviderLogDir = fileCfg.Logging.ProviderLogs.Dir
+			}
+			if cfg.ProviderLogMaxBytes == 0 {
+				cfg.ProviderLogMaxBytes = fileCfg.Logging.ProviderLogs.MaxBytes
+			}
+			if cfg.ProviderLogMaxFiles == 0 {
+				cfg.ProviderLogMaxFiles = fileCfg.Logging.ProviderLogs.MaxFiles
+			}
+			if cfg.AuditDir == "" {
+				cfg.AuditDir = fileCfg.Logging.AuditDir
+			}
+			if cfg.AuditRetention == 0 && fileCfg.Logging.AuditRetention != "" {
+				cfg.AuditRetention = keepDuration(fileCfg.Logging.AuditRetention)
+			}
 			if cfg.IdleTimeout == 0 && fileCfg.Sessions.IdleTimeout != "" {
 				cfg.IdleTimeout = config.ParseDuration(fileCfg.Sessions.IdleTimeout, 0)
 			}
+			if cfg.InputQueueDepth == 0 && fileCfg.Sessions.InputQueueDepth > 0 {
+				cfg.InputQueueDepth = fileCfg.Sessions.InputQueueDepth
+			}
+			if cfg.Restart == (bridge.RestartPolicy{}) && fileCfg.Sessions.Restart.MaxRestarts > 0 {
+				rc := fileCfg.Sessions.Restart
+				cfg.Restart = bridge.RestartPolicy{
+					MaxRestarts: rc.MaxRestarts,
+					Backoff:     config.ParseDuration(rc.Backoff, 0),
+					MaxBackoff:  config.ParseDuration(rc.MaxBackoff, 0),
+				}
+			}
+			if cfg.OutputLimits == (bridge.OutputLimits{}) {
+				ol := fileCfg.Sessions.OutputLimits
+				cfg.OutputLimits = bridge.OutputLimits{
+					MaxResponseBytes:  ol.MaxResponseBytes,
+					MaxResponseEvents: ol.MaxResponseEvents,
+					CancelResponse:    ol.CancelResponse,
+				}
+			}
+			if cfg.MaxFileBytes == 0 && fileCfg.Files.MaxSizeBytes > 0 {
+				cfg.MaxFileBytes = fileCfg.Files.MaxSizeBytes
+			}
+			if cfg.MaxStreamInputBytes == 0 && fileCfg.Input.MaxStreamBytes > 0 {
+				cfg.MaxStreamInputBytes = fileCfg.Input.MaxStreamBytes
+			}
+			if cfg.MaxAttachmentBytes == 0 && fileCfg.Input.MaxAttachmentBytes > 0 {
+				cfg.MaxAttachmentBytes = fileCfg.Input.MaxAttachmentBytes
+			}
+			if cfg.GitAuthorName == "" && fileCfg.Git.AuthorName != "" {
+				cfg.GitAuthorName = fileCfg.Git.AuthorName
+			}
+			if cfg.GitAuthorEmail == "" && fileCfg.Git.AuthorEmail != "" {
+				cfg.GitAuthorEmail = fileCfg.Git.AuthorEmail
+			}
+			if cfg.GitWatchInterval == 0 && fileCfg.Git.WatchInterval != "" {
+				cfg.GitWatchInterval = config.ParseDuration(fileCfg.Git.WatchInterval, 0)
+				if cfg.GitWatchInterval == 0 {
+					cfg.GitWatchInterval = -1
+				}
+			}
+			if cfg.WorkspaceDir == "" && fileCfg.Workspaces.Dir != "" {
+				cfg.WorkspaceDir = fileCfg.Workspaces.Dir
+			}
+			if cfg.CloneURLs == nil && len(fileCfg.Workspaces.AllowedURLs) > 0 {
+				cfg.CloneURLs = fileCfg.Workspaces.AllowedURLs
+			}
+			if cfg.WorkspaceQuotaBytes == 0 && fileCfg.Workspaces.ProjectQuotaBytes > 0 {
+				cfg.WorkspaceQuotaBytes = fileCfg.Workspaces.ProjectQuotaBytes
+			}
+			if cfg.WorkspaceMinFreeBytes == 0 && fileCfg.Workspaces.MinFreeBytes != 0 {
+				cfg.WorkspaceMinFreeBytes = fileCfg.Workspaces.MinFreeBytes
+			}
+			if cfg.ArchiveTTL == 0 && fileCfg.Sessions.ArchiveTTL != "" {
+				cfg.ArchiveTTL = config.ParseDuration(fileCfg.Sessions.ArchiveTTL, 0)
+			}
+			if cfg.ArchiveRetention == 0 && fileCfg.Sessions.ArchiveRetention != "" {
+				cfg.ArchiveRetention = keepDuration(fileCfg.Sessions.ArchiveRetention)
+			}
+			if cfg.ArchiveDir == "" && fileCfg.Persistence.ArchiveDir != "" {
+				cfg.ArchiveDir = fileCfg.Persistence.ArchiveDir
+			}
+			if cfg.ArchiveEncryptionKey == "" {
+				cfg.ArchiveEncryptionKey = fileCfg.Persistence.ArchiveEncryptionKey
+			}
+			if cfg.ScheduleDir == "" && fileCfg.Persistence.ScheduleDir != "" {
+				cfg.ScheduleDir = fileCfg.Persistence.ScheduleDir
+			}
+			if cfg.Schedules == nil && len(fileCfg.Schedules) > 0 {
+				cfg.Schedules = scheduledJobs(fileCfg.Schedules)
+			}
+			if cfg.PromptTemplates == nil && len(fileCfg.PromptTemplates) > 0 {
+				cfg.PromptTemplates = promptTemplates(fileCfg.PromptTemplates)
+			}
+			if !contentPolicySet(cfg.ContentPolicy) {
+				cfg.ContentPolicy = contentPolicy(fileCfg.ContentPolicy)
+			}
 			if cfg.AllowedPaths == nil && len(fileCfg.AllowedPaths) > 0 {
 				cfg.AllowedPaths = fileCfg.AllowedPaths
 			}
+			if cfg.ProjectProviders == nil && len(fileCfg.ProjectProviders) > 0 {
+				cfg.ProjectProviders = fileCfg.ProjectProviders
+			}
+			if cfg.AllowedEnv == nil && len(fileCfg.AllowedEnv) > 0 {
+				cfg.AllowedEnv = fileCfg.AllowedEnv
+			}
+			if cfg.ProjectLimits == nil && len(fileCfg.ProjectLimits) > 0 {
+				cfg.ProjectLimits = make(map[string]bridge.ResourceLimits, len(fileCfg.ProjectLimits))
+				for project, l := range fileCfg.ProjectLimits {
+					cfg.ProjectLimits[project] = resourceLimits(l)
+				}
+			}
+			if cfg.Projects == nil && len(fileCfg.Projects) > 0 {
+				applyProjects(&cfg, fileCfg.Projects)
+			}
+			if !cfg.RestrictProjects {
+				cfg.RestrictProjects = fileCfg.RestrictProjects
+			}
 			if cfg.ListenAddr == "" && fileCfg.Server.Listen != "" {
 				cfg.ListenAddr = fileCfg.Server.Listen
 			}
+			if !cfg.AllowInsecure {
+				cfg.AllowInsecure = fileCfg.Server.InsecureDevMode
+			}
+			if !cfg.Reflection {
+				cfg.Reflection = fileCfg.Server.Reflection
+			}
+			if cfg.HealthListen == "" {
+				cfg.HealthListen = fileCfg.Server.HealthListen
+			}
+			if cfg.UIListen == "" {
+				cfg.UIListen = fileCfg.Server.UIListen
+			}
+			if cfg.ShutdownDrain == 0 && fileCfg.Server.ShutdownDrain != "" {
+				cfg.ShutdownDrain = config.ParseDuration(fileCfg.Server.ShutdownDrain, 0)
+			}
+			if !cfg.Compression.Disabled && fileCfg.Server.Compression.Enabled != nil {
+				cfg.Compression.Disabled = !*fileCfg.Server.Compression.Enabled
+			}
+			if cfg.Compression.Level == 0 {
+				cfg.Compression.Level = fileCfg.Server.Compression.Level
+			}
+			if cfg.Compression.MaxStreams == 0 {
+				cfg.Compression.MaxStreams = fileCfg.Server.Compression.MaxStreams
+			}
 			if cfg.CABundlePath == "" && fileCfg.TLS.CABundle != "" {
 				cfg.CABundlePath = fileCfg.TLS.CABundle
 				cfg.TLSCertPath = fileCfg.TLS.Cert
 				cfg.TLSKeyPath = fileCfg.TLS.Key
+				cfg.CRLPath = fileCfg.TLS.CRL
+			}
+			if len(cfg.ACME.Domains) == 0 && len(fileCfg.TLS.ACME.Domains) > 0 {
+				cfg.ACME = fileCfg.TLS.ACME
 			}
 			if cfg.JWTPublicKeys == nil && len(fileCfg.Auth.JWTPublicKeys) > 0 {
-				cfg.JWTPublicKeys = make(map[string]string, len(fileCfg.Auth.JWTPublicKeys))
-				for _, k := range fileCfg.Auth.JWTPublicKeys {
-					cfg.JWTPublicKeys[k.Issuer] = k.KeyPath
-				}
+				cfg.JWTPublicKeys = fileCfg.Auth.JWTPublicKeys
+			}
+			if cfg.JWKSURL == "" && fileCfg.Auth.JWKSURL != "" {
+				cfg.JWKSURL = fileCfg.Auth.JWKSURL
+				cfg.JWKSIssuer = fileCfg.Auth.JWKSIssuer
+			}
+			if cfg.OIDC.Issuer == "" && fileCfg.Auth.OIDC.Issuer != "" {
+				cfg.OIDC = fileCfg.Auth.OIDC
+			}
+			if cfg.SPIFFE.TrustDomain == "" && fileCfg.Auth.SPIFFE.TrustDomain != "" {
+				cfg.SPIFFE = fileCfg.Auth.SPIFFE
+			}
+			if cfg.Kubernetes.Issuer == "" && fileCfg.Auth.Kubernetes.Issuer != "" {
+				cfg.Kubernetes = fileCfg.Auth.Kubernetes
+			}
+			if cfg.AdminCertOU == "" {
+				cfg.AdminCertOU = fileCfg.Auth.AdminCertOU
+			}
+			if cfg.CertBindings == nil {
+				cfg.CertBindings = fileCfg.Auth.CertBindings
+			}
+			if cfg.SessionTokenKey == "" {
+				cfg.SessionTokenKey = fileCfg.Auth.SessionTokenKey
+			}
+			if cfg.JWKSRefreshInterval == 0 && fileCfg.Auth.JWKSRefreshInterval != "" {
+				cfg.JWKSRefreshInterval = config.ParseDuration(fileCfg.Auth.JWKSRefreshInterval, 0)
 			}
 		}
 	}
@@ -267,301 +1374,580 @@ func Start(cfg Config) (*Server, error) {
 	if cfg.IdleTimeout <= 0 {
 		cfg.IdleTimeout = 30 * time.Minute
 	}
-
-	stateDir := cfg.StateDir
-	if stateDir == "" {
-		stateDir = StateDir()
+	if cfg.ArchiveTTL <= 0 {
+		cfg.ArchiveTTL = time.Hour
 	}
-	if err := os.MkdirAll(stateDir, 0o700); err != nil {
-		return nil, fmt.Errorf("create state dir %q: %w", stateDir, err)
+	if cfg.ArchiveRetention == 0 {
+		cfg.ArchiveRetention = 30 * 24 * time.Hour
 	}
-
-	logger := cfg.Logger
-	if logger == nil {
-		level := slog.LevelWarn
-		if cfg.Verbose {
-			level = slog.LevelInfo
-		}
-		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	if cfg.AuditRetention == 0 {
+		cfg.AuditRetention = 90 * 24 * time.Hour
 	}
-
-	// Apply log redaction when patterns are configured.
-	if len(cfg.RedactPatterns) > 0 {
-		redactor, err := redact.New(cfg.RedactPatterns)
-		if err != nil {
-			return nil, fmt.Errorf("compile redact patterns: %w", err)
-		}
-		logger = slog.New(&redactingHandler{inner: logger.Handler(), redactor: redactor})
+	if cfg.MaxFileBytes <= 0 {
+		cfg.MaxFileBytes = 1 << 20
 	}
-
-	// Install as the default so internal packages that call slog.Warn etc.
-	// (e.g. supervisor's slow-observer warning) use the same configured logger.
-	slog.SetDefault(logger)
-
-	// Build provider registry. Config-file providers take precedence; the
-	// auto-detect path fills in any providers not explicitly configured.
-	registry := bridge.NewRegistry()
-
-	// Register providers explicitly declared in the config file.
-	for id, pc := range configProviderDefs {
-		timeout := config.ParseDuration(pc.StartupTimeout, 60*time.Second)
-		p := provider.NewStdioProvider(provider.StdioConfig{
-			ProviderID:     id,
-			Binary:         pc.Binary,
-			DefaultArgs:    pc.Args,
-			StartupTimeout: timeout,
-			StopGrace:      10 * time.Second,
-			StartupProbe:   pc.StartupProbe,
-			PromptPattern:  pc.PromptPattern,
-			RequiredEnv:    pc.RequiredEnv,
-			StreamJSON:     pc.StreamJSON,
-			StripANSI:      pc.StripANSI,
-			ProviderRoot:   providerRoot,
-		})
-		if err := registry.Register(p); err != nil {
-			logger.Warn("skip config provider", "provider", id, "error", err)
-			continue
-		}
-		logger.Info("registered config provider", "provider", id, "binary", pc.Binary)
+	if cfg.MaxStreamInputBytes <= 0 {
+		cfg.MaxStreamInputBytes = 16 << 20
+	}
+	if cfg.MaxAttachmentBytes <= 0 {
+		cfg.MaxAttachmentBytes = 1 << 20
+	}
+	if cfg.GitWatchInterval == 0 {
+		cfg.GitWatchInterval = 5 * time.Second
+	}
+	if cfg.JWKSRefreshInterval <= 0 {
+		cfg.JWKSRefreshInterval = 5 * time.Minute
 	}
-
 	// Build fallbacks map from config providers (merged with any set on cfg).
-	if cfg.ProviderFallbacks == nil && len(configProviderDefs) > 0 {
+	if cfg.ProviderFallbacks == nil && len(fp.defs) > 0 {
 		cfg.ProviderFallbacks = make(map[string][]string)
 	}
-	for id, pc := range configProviderDefs {
+	for id, pc := range fp.defs {
 		if len(pc.Fallbacks) > 0 {
 			if _, already := cfg.ProviderFallbacks[id]; !already {
 				cfg.ProviderFallbacks[id] = pc.Fallbacks
 			}
 		}
 	}
+	return cfg, fp, nil
+}
+
+// buildProviders returns the provider set for the registry: config-file
+// providers first, then providers registered at runtime and auto-detected
+// providers whose IDs were not configured, then the always-present echo
+// provider.
+func buildProviders(fp fileProviders, dynamic map[string]config.ProviderConfig, resolver *secrets.Resolver, logger *slog.Logger) []bridge.Provider {
+	logger = logger.With(logging.ComponentKey, logging.ComponentProvider)
+	var providers []bridge.Provider
+	seen := make(map[string]bool)
+	add := func(p bridge.Provider) {
+		if seen[p.ID()] {
+			return
+		}
+		seen[p.ID()] = true
+		providers = append(providers, p)
+	}
+
+	for id, pc := range fp.defs {
+		add(configProvider(id, pc, fp.root, resolver))
+		logger.Info("registered config provider", "provider", id, "binary", pc.Binary, "variants", len(pc.Variants))
+	}
+	for id, pc := range dynamic {
+		if seen[id] {
+			logger.Warn("registered provider is shadowed by the config file", "provider", id)
+			continue
+		}
+		add(configProvider(id, pc, fp.root, resolver))
+		logger.Info("registered runtime provider", "provider", id, "binary", pc.Binary, "variants", len(pc.Variants))
+	}
 
-	// Auto-detect additional providers not already registered via config.
 	for _, pd := range detectProviders() {
-		if _, err := registry.Get(pd.ID); err == nil {
+		if seen[pd.ID] {
 			continue // already registered from config
 		}
-		p := provider.NewStdioProvider(provider.StdioConfig{
+		add(provider.NewStdioProvider(provider.StdioConfig{
 			ProviderID:     pd.ID,
 			Binary:         pd.Binary,
 			DefaultArgs:    pd.Args,
+			ResumeArgs:     pd.ResumeArgs,
+			ThreadIDArgs:   pd.ThreadIDArgs,
 			StartupTimeout: pd.StartupTimeout,
 			StopGrace:      10 * time.Second,
 			StartupProbe:   pd.StartupProbe,
 			PromptPattern:  pd.PromptPattern,
 			RequiredEnv:    pd.RequiredEnv,
+			EnvAllowlist:   pd.EnvAllowlist,
 			StreamJSON:     pd.StreamJSON,
-		})
-		if err := registry.Register(p); err != nil {
-			logger.Warn("skip provider", "provider", pd.ID, "error", err)
-			continue
-		}
+			JSONFormat:     pd.JSONFormat,
+			MCPConfigFlag:  pd.MCPConfigFlag,
+			ApprovalArgs:   pd.ApprovalArgs,
+		}))
 		logger.Info("registered provider", "provider", pd.ID, "binary", pd.Binary)
 	}
 
 	// Always register the echo provider for testing.
-	echoProv := provider.NewStdioProvider(provider.StdioConfig{
+	add(provider.NewStdioProvider(provider.StdioConfig{
 		ProviderID:     "echo",
 		Binary:         "cat",
 		StartupTimeout: 5 * time.Second,
 		StopGrace:      2 * time.Second,
 		StartupProbe:   "none",
-	})
-	if err := registry.Register(echoProv); err != nil {
-		logger.Debug("echo provider already registered", "error", err)
-	}
-
-	// Policy
-	policy := bridge.Policy{
-		MaxPerProject: 10,
-		MaxGlobal:     20,
-		MaxInputBytes: 65536,
-		AllowedPaths:  cfg.AllowedPaths,
-	}
-
-	// Supervisor options: persistence store when DBPath is set.
-	var supOpts []bridge.SupervisorOption
-	var store bridge.SessionStore
-	if cfg.DBPath != "" {
-		var err error
-		store, err = bridge.NewBoltSessionStore(cfg.DBPath)
-		if err != nil {
-			return nil, fmt.Errorf("open session store %q: %w", cfg.DBPath, err)
-		}
-		supOpts = append(supOpts, bridge.WithStore(store))
-	}
-
-	sup := bridge.NewSupervisor(registry, policy, cfg.EventBufferSize, cfg.IdleTimeout, supOpts...)
-	if store != nil {
-		if err := sup.LoadHistory(); err != nil {
-			logger.Warn("failed to load session history", "error", err)
-		}
-	}
-
-	// Server instance ID
-	instanceID := generateInstanceID()
-
-	// Determine server mode and build gRPC options accordingly.
-	mode := ModeLocal
-	var grpcOpts []grpc.ServerOption
-
-	if cfg.ListenAddr != "" {
-		// Secure mode: TCP + mTLS + JWT.
-		// TODO(windows): Secure mode (mTLS+JWT) is not yet supported on Windows.
-		// Windows support requires named-pipe ACLs or equivalent transport security.
-		if runtime.GOOS == "windows" {
-			sup.Close()
-			if store != nil {
-				_ = store.Close()
-			}
-			return nil, fmt.Errorf("secure mode (--listen) is not yet supported on Windows")
-		}
+	}))
+	return providers
+}
 
-		mode = ModeSecure
+// configProvider returns provider id defined by pc: a StdioProvider, or a
+// VariantSet of them when pc has variants. Each variant is its own
+// StdioProvider with ID "id@variant", so that its errors name it.
+func configProvider(id string, pc config.ProviderConfig, root string, resolver *secrets.Resolver) bridge.Provider {
+	if len(pc.Variants) == 0 {
+		p := provider.NewStdioProvider(stdioConfig(id, pc, root, resolver))
+		// An unsupported version is logged now; Health keeps reporting it.
+		_ = p.CheckVersion(context.Background())
+		return p
+	}
+	variants := make(map[string]bridge.Provider, len(pc.Variants))
+	for name := range pc.Variants {
+		p := provider.NewStdioProvider(stdioConfig(id+"@"+name, pc.Variant(name), root, resolver))
+		_ = p.CheckVersion(context.Background())
+		variants[name] = p
+	}
+	return provider.NewVariantSet(id, pc.DefaultVariant, variants)
+}
 
-		var mat *PKIMaterial
-		if cfg.CABundlePath != "" {
-			// Use pre-issued certificates from Config (e.g. provided via config file).
-			if cfg.TLSCertPath == "" || cfg.TLSKeyPath == "" {
-				sup.Close()
-				if store != nil {
-					_ = store.Close()
+// stdioConfig returns the StdioProvider configuration for provider id
+// defined by pc.
+func stdioConfig(id string, pc config.ProviderConfig, root string, resolver *secrets.Resolver) provider.StdioConfig {
+	return provider.StdioConfig{
+		ProviderID:     id,
+		Binary:         pc.Binary,
+		DefaultArgs:    pc.Args,
+		ResumeArgs:     pc.ResumeArgs,
+		ThreadIDArgs:   pc.ThreadIDArgs,
+		StartupTimeout: config.ParseDuration(pc.StartupTimeout, 60*time.Second),
+		StopGrace:      10 * time.Second,
+		StartupProbe:   pc.StartupProbe,
+		PromptPattern:  pc.PromptPattern,
+		ReadyTimeout:   config.ParseDuration(pc.ReadyTimeout, 0),
+		MinVersion:     pc.MinVersion,
+		MaxVersion:     pc.MaxVersion,
+		InstallHint:    pc.InstallHint,
+		RequiredEnv:    pc.RequiredEnv,
+		EnvAllowlist:   pc.EnvAllowlist,
+		EnvBlocklist:   pc.EnvBlocklist,
+		SecretEnv:      pc.SecretEnv,
+		Secrets:        resolver,
+		StreamJSON:     pc.StreamJSON,
+		JSONFormat:     pc.JSONFormat,
+		StripANSI:      pc.StripANSI,
+		OutputFilters:  pc.OutputFilters,
+		StderrRules:    stderrRules(pc.StderrClassifiers),
+		Sandbox:        pc.Sandbox,
+		SandboxImage:   pc.SandboxImage,
+		SandboxBinds:   pc.SandboxBinds,
+		ProviderRoot:   root,
+		Limits:         resourceLimits(pc.Limits),
+		MCPConfigFlag:  pc.MCPConfigFlag,
+		ApprovalArgs:   pc.ApprovalArgs,
+	}
+}
+
+// newSecretResolver returns the resolver for secret:// references in
+// provider secret_env. Resolved values are added to redactor so they never
+// reach logs or session output.
+func newSecretResolver(sc config.SecretsConfig, redactor *redact.Redactor) *secrets.Resolver {
+	var backend secrets.Backend
+	switch sc.Backend {
+	case "file":
+		backend = secrets.FileBackend{Dir: sc.Dir}
+	case "vault":
+		tokenEnv := sc.Vault.TokenEnv
+		if tokenEnv == "" {
+			tokenEnv = "VAULT_TOKEN"
+		}
+		tokenFile := sc.Vault.TokenFile
+		backend = secrets.VaultBackend{
+			Address:   sc.Vault.Address,
+			Mount:     sc.Vault.Mount,
+			Namespace: sc.Vault.Namespace,
+			TokenFunc: func() (string, error) {
+				if tokenFile == "" {
+					return os.Getenv(tokenEnv), nil
 				}
-				return nil, fmt.Errorf("CABundlePath requires TLSCertPath and TLSKeyPath to also be set")
-			}
-			mat = &PKIMaterial{
-				CABundlePath:   cfg.CABundlePath,
-				ServerCertPath: cfg.TLSCertPath,
-				ServerKeyPath:  cfg.TLSKeyPath,
-			}
-		} else {
-			// Auto-generate PKI material if not present.
-			sans := buildServerSANs(cfg.ListenAddr, cfg.ServerSANs)
-			var pkiErr error
-			mat, pkiErr = EnsurePKI(stateDir, sans, logger)
-			if pkiErr != nil {
-				sup.Close()
-				if store != nil {
-					_ = store.Close()
+				b, err := os.ReadFile(tokenFile)
+				if err != nil {
+					return "", err
 				}
-				return nil, fmt.Errorf("ensure PKI: %w", pkiErr)
-			}
+				return strings.TrimSpace(string(b)), nil
+			},
+		}
+	case "aws":
+		region := sc.AWS.Region
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+		backend = secrets.AWSBackend{
+			Region:      region,
+			Endpoint:    sc.AWS.Endpoint,
+			Credentials: &secrets.AWSCredentialChain{},
 		}
+	default:
+		prefix := sc.EnvPrefix
+		if prefix == "" {
+			prefix = "BRIDGE_SECRET_"
+		}
+		backend = secrets.EnvBackend{Prefix: prefix}
+	}
+	return &secrets.Resolver{Backend: backend, Observe: redactor.AddValue}
+}
 
-		secureOpts, err := buildSecureGRPCOpts(mat, stateDir, logger, cfg.JWTPublicKeys)
+// loadArchiveKey reads the base64 archive encryption key from a secret://
+// reference or a file. A key file others can read is refused.
+func loadArchiveKey(ref string, resolver *secrets.Resolver) ([]byte, error) {
+	var value string
+	if secrets.IsRef(ref) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		v, err := resolver.Resolve(ctx, ref)
 		if err != nil {
-			sup.Close()
-			if store != nil {
-				_ = store.Close()
-			}
-			return nil, fmt.Errorf("build secure gRPC options: %w", err)
+			return nil, fmt.Errorf("persistence.archive_encryption_key: %w", err)
 		}
-		grpcOpts = secureOpts
+		value = v
 	} else {
-		// Local mode: unix socket, anonymous passthrough auth.
-		grpcOpts = []grpc.ServerOption{
-			grpc.ChainUnaryInterceptor(auth.UnaryPassthroughInterceptor()),
-			grpc.ChainStreamInterceptor(auth.StreamPassthroughInterceptor()),
+		info, err := os.Stat(ref)
+		if err != nil {
+			return nil, fmt.Errorf("persistence.archive_encryption_key: %w", err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+			return nil, fmt.Errorf("persistence.archive_encryption_key: key file %s is readable by group or others (mode %04o); chmod 600 it", ref, info.Mode().Perm())
+		}
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return nil, fmt.Errorf("persistence.archive_encryption_key: %w", err)
 		}
+		value = string(data)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, errors.New("persistence.archive_encryption_key: key is not valid base64")
 	}
+	return key, nil
+}
 
-	grpcServer := grpc.NewServer(grpcOpts...)
+// stderrRules compiles validated stderr classifiers into supervisor rules.
+func stderrRules(classifiers []config.StderrClassifierConfig) []bridge.StderrRule {
+	rules := make([]bridge.StderrRule, 0, len(classifiers))
+	for _, sc := range classifiers {
+		severity, err := bridge.ParseSeverity(sc.Severity)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, bridge.StderrRule{Pattern: regexp.MustCompile(sc.Pattern), Severity: severity})
+	}
+	return rules
+}
 
-	providerFallbacks := cfg.ProviderFallbacks
+// applyProjects merges the projects section of a config file into cfg. A
+// project's providers, limits and rate limits join the per-project maps
+// that already exist for them; entries set in cfg are kept.
+func applyProjects(cfg *Config, projects map[string]config.ProjectConfig) {
+	cfg.Projects = make(map[string]bridge.ProjectPolicy, len(projects))
+	providers := maps.Clone(cfg.ProjectProviders)
+	limits := maps.Clone(cfg.ProjectLimits)
+	rates := maps.Clone(cfg.RateLimits.Projects)
+	audit := maps.Clone(cfg.ProjectAuditRetention)
+	for project, pc := range projects {
+		cfg.Projects[project] = bridge.ProjectPolicy{
+			AllowedPaths:    pc.AllowedPaths,
+			MaxSessions:     pc.MaxSessions,
+			MCPServers:      mcpServers(pc.MCPServers),
+			RequireApproval: pc.RequireApproval,
+			Budget:          bridge.Budget{DailyUSD: pc.Budget.DailyUSD, DailyTokens: pc.Budget.DailyTokens},
+			RepoSummary: bridge.RepoSummary{
+				Enabled:        pc.RepoSummary.Enabled,
+				TreeDepth:      pc.RepoSummary.TreeDepth,
+				MaxTreeEntries: pc.RepoSummary.MaxTreeEntries,
+				ReadmeLines:    pc.RepoSummary.ReadmeLines,
+			},
+			Retention: bridge.Retention{
+				EventBuffer: config.ParseDuration(pc.Retention.EventBuffer, 0),
+				Transcripts: keepDuration(pc.Retention.Transcripts),
+			},
+		}
+		if _, ok := providers[project]; !ok && len(pc.Providers) > 0 {
+			if providers == nil {
+				providers = make(map[string][]string)
+			}
+			providers[project] = pc.Providers
+		}
+		if _, ok := limits[project]; !ok && pc.Limits != (config.ResourceLimitsConfig{}) {
+			if limits == nil {
+				limits = make(map[string]bridge.ResourceLimits)
+			}
+			limits[project] = resourceLimits(pc.Limits)
+		}
+		if _, ok := rates[project]; !ok && pc.RateLimits != (config.ProjectRateLimitsConfig{}) {
+			if rates == nil {
+				rates = make(map[string]server.ProjectRateLimits)
+			}
+			rates[project] = server.ProjectRateLimits(pc.RateLimits)
+		}
+		if _, ok := audit[project]; !ok && pc.Retention.AuditLogs != "" {
+			if audit == nil {
+				audit = make(map[string]time.Duration)
+			}
+			audit[project] = keepDuration(pc.Retention.AuditLogs)
+		}
+	}
+	cfg.ProjectProviders = providers
+	cfg.ProjectLimits = limits
+	cfg.RateLimits.Projects = rates
+	cfg.ProjectAuditRetention = audit
+}
 
-	bridgeServer := server.New(sup, registry, logger, cfg.RateLimits, instanceID, providerFallbacks)
-	bridgev1.RegisterBridgeServiceServer(grpcServer, bridgeServer)
+// keepDuration parses a validated retention period from the config file,
+// where "0" keeps data forever, into the negative duration that means the
+// same in Config. Unset is zero, which uses the default.
+func keepDuration(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	if d := config.ParseDuration(s, 0); d > 0 {
+		return d
+	}
+	return -1
+}
 
-	// Listen: TCP for secure mode, unix socket for local mode.
-	var ln net.Listener
-	var listenAddr string
-	var err error
-	if mode == ModeSecure {
-		ln, err = net.Listen("tcp", cfg.ListenAddr)
-		if err != nil {
-			sup.Close()
-			return nil, fmt.Errorf("listen tcp %s: %w", cfg.ListenAddr, err)
+// mcpServers converts a project's validated mcp_servers to the servers its
+// agents are given.
+func mcpServers(servers map[string]config.MCPServerConfig) map[string]bridge.MCPServer {
+	if len(servers) == 0 {
+		return nil
+	}
+	out := make(map[string]bridge.MCPServer, len(servers))
+	for name, ms := range servers {
+		typ := ms.Type
+		if typ == "" {
+			typ = bridge.MCPTransportStdio
 		}
-		listenAddr = ln.Addr().String()
-	} else {
-		ln, listenAddr, err = listen(stateDir)
-		if err != nil {
-			sup.Close()
-			return nil, fmt.Errorf("listen: %w", err)
+		out[name] = bridge.MCPServer{
+			Type:    typ,
+			Command: ms.Command,
+			Args:    ms.Args,
+			Env:     ms.Env,
+			URL:     ms.URL,
+			Headers: ms.Headers,
 		}
 	}
+	return out
+}
 
-	// Write PID file.
-	pidFile := filepath.Join(stateDir, "server.pid")
-	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
-		_ = ln.Close()
-		sup.Close()
-		return nil, fmt.Errorf("write pid file: %w", err)
+// buildPolicy returns the session policy for cfg.
+func buildPolicy(cfg Config) bridge.Policy {
+	return bridge.Policy{
+		MaxPerProject:       10,
+		MaxGlobal:           20,
+		MaxInputBytes:       65536,
+		MaxStreamInputBytes: cfg.MaxStreamInputBytes,
+		MaxAttachmentBytes:  cfg.MaxAttachmentBytes,
+		AllowedPaths:        cfg.AllowedPaths,
+		ProjectProviders:    cfg.ProjectProviders,
+		AllowedEnv:          cfg.AllowedEnv,
+		ProjectLimits:       cfg.ProjectLimits,
+		InputQueueDepth:     cfg.InputQueueDepth,
+		Restart:             cfg.Restart,
+		OutputLimits:        cfg.OutputLimits,
+		PromptTemplates:     cfg.PromptTemplates,
+		ContentPolicy:       cfg.ContentPolicy,
+		MaxFileBytes:        cfg.MaxFileBytes,
+		GitAuthorName:       cfg.GitAuthorName,
+		GitAuthorEmail:      cfg.GitAuthorEmail,
+		GitWatchInterval:    max(cfg.GitWatchInterval, 0),
+		CloneURLs:           cfg.CloneURLs,
+		WorkspaceQuotaBytes: cfg.WorkspaceQuotaBytes,
+		Projects:            cfg.Projects,
+		RestrictProjects:    cfg.RestrictProjects,
 	}
+}
 
-	// Write address file.
-	addrFile := filepath.Join(stateDir, "server.addr")
-	if err := os.WriteFile(addrFile, []byte(listenAddr), 0o644); err != nil {
-		_ = ln.Close()
-		sup.Close()
-		return nil, fmt.Errorf("write addr file: %w", err)
+// scheduledJobs converts validated schedules from the config file.
+func scheduledJobs(schedules []config.ScheduleConfig) []bridge.ScheduledJob {
+	jobs := make([]bridge.ScheduledJob, 0, len(schedules))
+	for _, sc := range schedules {
+		cron, err := bridge.ParseCron(sc.Cron)
+		if err != nil {
+			continue // rejected by config validation
+		}
+		jobs = append(jobs, bridge.ScheduledJob{
+			Name:      sc.Name,
+			Cron:      cron,
+			ProjectID: sc.ProjectID,
+			Provider:  sc.Provider,
+			RepoPaths: sc.RepoPaths,
+			Prompt:    sc.Prompt,
+			Timeout:   config.ParseDuration(sc.Timeout, bridge.DefaultScheduleTimeout),
+		})
 	}
+	return jobs
+}
 
-	// Write mode file so discovery knows how to connect.
-	modeFile := filepath.Join(stateDir, "server.mode")
-	if err := os.WriteFile(modeFile, []byte(string(mode)), 0o644); err != nil {
-		_ = ln.Close()
-		sup.Close()
-		return nil, fmt.Errorf("write mode file: %w", err)
+// promptTemplates parses validated prompt templates.
+func promptTemplates(templates map[string]config.PromptTemplateConfig) map[string]bridge.PromptTemplate {
+	out := make(map[string]bridge.PromptTemplate, len(templates))
+	for name, pt := range templates {
+		t, err := bridge.ParsePromptTemplate(name, pt.Template)
+		if err != nil {
+			continue // rejected by config validation
+		}
+		out[name] = t
 	}
+	return out
+}
 
-	logger.Info("server starting", "mode", mode, "addr", listenAddr, "pid", os.Getpid())
+// contentPolicySet reports whether cp checks anything.
+func contentPolicySet(cp bridge.ContentPolicy) bool {
+	return len(cp.Rules) > 0 || cp.Classifier != nil
+}
 
-	s := &Server{
-		grpcServer: grpcServer,
-		supervisor: sup,
-		store:      store,
-		registry:   registry,
-		listener:   ln,
-		logger:     logger,
-		stateDir:   stateDir,
+// contentPolicy compiles a validated content policy. The classifier's
+// token is read from the environment now, so a reload picks up a new one.
+func contentPolicy(cp config.ContentPolicyConfig) bridge.ContentPolicy {
+	var out bridge.ContentPolicy
+	for _, r := range cp.Rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			continue // rejected by config validation
+		}
+		out.Rules = append(out.Rules, bridge.ContentRule{Name: r.Name, Pattern: re, Action: r.Action, Directions: r.Directions})
 	}
-
-	go func() {
-		if err := grpcServer.Serve(ln); err != nil {
-			logger.Error("grpc serve", "error", err)
+	if c := cp.Classifier; c.URL != "" {
+		classifier := bridge.HTTPContentClassifier{URL: c.URL}
+		if c.TokenEnv != "" {
+			classifier.Token = os.Getenv(c.TokenEnv)
 		}
-	}()
+		out.Classifier = classifier
+		out.ClassifierDirections = c.Directions
+		out.ClassifierTimeout = config.ParseDuration(c.Timeout, 0)
+		out.FailClosed = c.FailClosed
+	}
+	return out
+}
 
-	return s, nil
+// resourceLimits converts validated config limits.
+func resourceLimits(l config.ResourceLimitsConfig) bridge.ResourceLimits {
+	var out bridge.ResourceLimits
+	out.MemoryBytes, _ = config.ParseByteSize(l.Memory)
+	// Validation guarantees at least one second.
+	out.CPUSeconds = int64(config.ParseDuration(l.CPUTime, 0) / time.Second)
+	out.Processes = l.MaxProcesses
+	return out
 }
 
-// buildSecureGRPCOpts returns gRPC server options for mTLS + JWT mode.
-// extraKeys maps issuer name to public key file path for JWT verification
-// when using pre-issued certificates instead of auto-PKI.
-func buildSecureGRPCOpts(mat *PKIMaterial, stateDir string, logger *slog.Logger, extraKeys map[string]string) ([]grpc.ServerOption, error) {
+// certReloadInterval is how often the server certificate, key and CA
+// bundle are checked for changes.
+const certReloadInterval = 30 * time.Second
+
+// auditPruneInterval is how often expired audit files are deleted.
+const auditPruneInterval = time.Hour
+
+// buildSecureGRPCOpts returns gRPC server options for mTLS + JWT mode. The
+// returned reloader serves the TLS material and must be run to pick up
+// renewed certificates. A non-nil acme supplies the server certificate in
+// place of mat's.
+func buildSecureGRPCOpts(mat *PKIMaterial, acme *autocert.Manager, verifier *auth.JWTVerifier, logger, auditLogger *slog.Logger) ([]grpc.ServerOption, *auth.CertReloader, error) {
 	// TLS credentials with client cert verification.
-	tlsCfg, err := auth.ServerTLSConfig(auth.TLSConfig{
+	certs, err := auth.NewCertReloader(auth.TLSConfig{
 		CABundlePath: mat.CABundlePath,
 		CertPath:     mat.ServerCertPath,
 		KeyPath:      mat.ServerKeyPath,
-	})
+		CRLPath:      mat.CRLPath,
+		ACME:         acme,
+		SPIFFE:       verifier.SPIFFE,
+	}, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("server TLS config: %w", err)
+	}
+
+	return []grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(certs.TLSConfig())),
+		grpc.ChainUnaryInterceptor(
+			auth.UnaryJWTInterceptor(verifier, logger),
+			auth.UnaryAuditInterceptor(auditLogger),
+		),
+		grpc.ChainStreamInterceptor(
+			auth.StreamJWTInterceptor(verifier, logger),
+			auth.StreamAuditInterceptor(auditLogger),
+		),
+	}, certs, nil
+}
+
+// newJWTVerifier builds the verifier used by the secure-mode interceptors.
+func newJWTVerifier(mat *PKIMaterial, stateDir string, logger *slog.Logger, extraKeys []config.JWTKeyConfig) (*auth.JWTVerifier, error) {
+	keys, err := loadJWTKeys(mat, stateDir, logger, extraKeys)
 	if err != nil {
-		return nil, fmt.Errorf("server TLS config: %w", err)
+		return nil, err
+	}
+	return &auth.JWTVerifier{
+		Keys:     keys,
+		Audience: "bridge",
+		MaxTTL:   10 * time.Minute,
+	}, nil
+}
+
+// newOIDCProvider resolves the provider's JWKS endpoint, through discovery
+// unless it is configured, and fetches its keys. Discovery must succeed
+// since there is nowhere else to get keys from; a failed key fetch is
+// retried by the refresh loop.
+func newOIDCProvider(cfg config.OIDCConfig, logger *slog.Logger) (*auth.OIDCProvider, error) {
+	jwksURL := cfg.JWKSURL
+	if jwksURL == "" {
+		var err error
+		if jwksURL, err = auth.DiscoverJWKSURL(context.Background(), cfg.Issuer, nil); err != nil {
+			return nil, fmt.Errorf("oidc issuer %q: %w", cfg.Issuer, err)
+		}
+	}
+	jwks := &auth.JWKSCache{URL: jwksURL}
+	if err := jwks.Refresh(context.Background()); err != nil {
+		logger.Warn("initial oidc jwks fetch failed", "issuer", cfg.Issuer, "url", jwksURL, "error", err)
+	}
+	logger.Info("accepting oidc tokens", "issuer", cfg.Issuer, "project_claim", cfg.ProjectClaim)
+	return &auth.OIDCProvider{
+		Issuer:       cfg.Issuer,
+		Audience:     cfg.Audience,
+		ProjectClaim: cfg.ProjectClaim,
+		JWKS:         jwks,
+	}, nil
+}
+
+// certBindings converts auth.cert_bindings for the verifier.
+func certBindings(cfg []config.CertBindingConfig) []auth.CertBinding {
+	bindings := make([]auth.CertBinding, 0, len(cfg))
+	for _, b := range cfg {
+		bindings = append(bindings, auth.CertBinding{CommonName: b.CN, OU: b.OU, URI: b.URI, Projects: b.Projects})
+	}
+	return bindings
+}
+
+// newKubernetesTokens builds the projected service account token verifier.
+// With the in-cluster JWKS endpoint, or an explicit CA or token file, keys
+// are fetched with the bridge pod's service account credentials. A failed
+// fetch is retried by the refresh loop.
+func newKubernetesTokens(cfg config.KubernetesConfig, logger *slog.Logger) (*auth.KubernetesTokens, error) {
+	jwks := &auth.JWKSCache{URL: cfg.JWKSURL}
+	if cfg.CAFile != "" || cfg.TokenFile != "" || cfg.JWKSURL == config.DefaultKubernetesJWKSURL {
+		client, err := auth.KubernetesAPIClient(cfg.CAFile, cfg.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes jwks client: %w", err)
+		}
+		jwks.Client = client
 	}
+	if err := jwks.Refresh(context.Background()); err != nil {
+		logger.Warn("initial kubernetes jwks fetch failed", "issuer", cfg.Issuer, "url", cfg.JWKSURL, "error", err)
+	}
+	logger.Info("accepting kubernetes service account tokens", "issuer", cfg.Issuer, "audience", cfg.Audience)
+	k8s := &auth.KubernetesTokens{Issuer: cfg.Issuer, Audience: cfg.Audience, JWKS: jwks}
+	k8s.SetProjects(cfg.Projects)
+	return k8s, nil
+}
 
-	// JWT verifier: load the local key plus any per-client keys.
-	keys := make(map[string]ed25519.PublicKey)
+// loadJWTKeys returns the issuer→public keys map for JWT verification.
+// extraKeys lists issuer keys when using pre-issued certificates instead of
+// auto-PKI. Per-client keys from certs/jwt-clients/*.pub are always added.
+func loadJWTKeys(mat *PKIMaterial, stateDir string, logger *slog.Logger, extraKeys []config.JWTKeyConfig) (map[string][]auth.IssuerKey, error) {
+	keys := make(map[string][]auth.IssuerKey)
 
 	if len(extraKeys) > 0 {
-		// Load explicit issuer→key mappings from config (explicit cert mode).
-		for issuer, keyPath := range extraKeys {
-			pub, keyErr := pki.LoadEd25519PublicKey(keyPath)
+		// Load explicit issuer keys from config (explicit cert mode).
+		for _, k := range extraKeys {
+			pub, keyErr := pki.LoadEd25519PublicKey(k.KeyPath)
 			if keyErr != nil {
-				return nil, fmt.Errorf("load JWT public key for issuer %q: %w", issuer, keyErr)
+				return nil, fmt.Errorf("load JWT public key for issuer %q: %w", k.Issuer, keyErr)
 			}
-			keys[issuer] = pub
+			notBefore, notAfter, err := k.Window()
+			if err != nil {
+				return nil, fmt.Errorf("JWT public key for issuer %q: %w", k.Issuer, err)
+			}
+			keys[k.Issuer] = append(keys[k.Issuer], auth.IssuerKey{KID: k.KID, Key: pub, NotBefore: notBefore, NotAfter: notAfter})
 		}
 	} else if mat.JWTSigningPub != "" {
 		// Auto-PKI mode: load the locally generated key as the "local" verifier.
@@ -569,7 +1955,7 @@ func buildSecureGRPCOpts(mat *PKIMaterial, stateDir string, logger *slog.Logger,
 		if keyErr != nil {
 			return nil, fmt.Errorf("load JWT public key: %w", keyErr)
 		}
-		keys["local"] = localPub
+		keys["local"] = []auth.IssuerKey{{Key: localPub}}
 	}
 
 	// Load per-client JWT public keys from certs/jwt-clients/*.pub.
@@ -585,27 +1971,11 @@ func buildSecureGRPCOpts(mat *PKIMaterial, stateDir string, logger *slog.Logger,
 			logger.Warn("skip client JWT key", "file", e.Name(), "error", err)
 			continue
 		}
-		keys[issuer] = pub
+		keys[issuer] = append(keys[issuer], auth.IssuerKey{Key: pub})
 		logger.Info("loaded client JWT key", "issuer", issuer)
 	}
 
-	verifier := &auth.JWTVerifier{
-		Keys:     keys,
-		Audience: "bridge",
-		MaxTTL:   10 * time.Minute,
-	}
-
-	return []grpc.ServerOption{
-		grpc.Creds(credentials.NewTLS(tlsCfg)),
-		grpc.ChainUnaryInterceptor(
-			auth.UnaryJWTInterceptor(verifier, logger),
-			auth.UnaryAuditInterceptor(logger),
-		),
-		grpc.ChainStreamInterceptor(
-			auth.StreamJWTInterceptor(verifier, logger),
-			auth.StreamAuditInterceptor(logger),
-		),
-	}, nil
+	return keys, nil
 }
 
 // buildServerSANs extracts the host from listenAddr and merges it with
@@ -657,6 +2027,171 @@ func (s *Server) Target() string {
 	return addr.String()
 }
 
+// Reload re-reads the config file and applies the settings that can change
+// without a restart: providers and their fallbacks, rate limits, session
+// policy, schedules, prompt templates, the content policy, JWT verification keys, the SPIFFE ID and
+// Kubernetes service account to project mappings, and client certificate project bindings. It also checks the TLS certificate files, which are otherwise
+// polled every certReloadInterval. Running
+// sessions keep the provider they were started with and the gRPC listener
+// is never closed. Listen address, TLS file paths, ACME, JWKS and OIDC
+// settings, persistence, and buffer sizes still require a restart.
+//
+// Nothing is applied when the file fails to load or validate; the server
+// keeps its current configuration and the error is returned.
+func (s *Server) Reload() error {
+	notify(s.logger, systemd.Reloading())
+	// systemd waits for READY=1 after RELOADING=1 whether or not the
+	// reload succeeded.
+	defer notify(s.logger, systemd.Ready)
+	cfg, fp, err := resolveConfig(s.baseCfg)
+	if err != nil {
+		return err
+	}
+	var keys map[string][]auth.IssuerKey
+	if s.verifier != nil {
+		keys, err = loadJWTKeys(s.pki, s.stateDir, s.logger, cfg.JWTPublicKeys)
+		if err != nil {
+			return fmt.Errorf("reload JWT keys: %w", err)
+		}
+	}
+
+	s.providersMu.Lock()
+	s.fileProviders = fp
+	s.resolver = newSecretResolver(fp.secrets, s.redactor)
+	s.applyProvidersLocked()
+	s.providersMu.Unlock()
+	s.supervisor.SetPolicy(buildPolicy(cfg))
+	s.scheduler.SetJobs(cfg.Schedules)
+	if s.logLevels != nil {
+		if err := applyLogLevels(s.logLevels, cfg); err != nil {
+			s.logger.Warn("log levels not reloaded", "error", err)
+		}
+	}
+	s.bridgeServer.SetRateLimits(cfg.RateLimits)
+	s.bridgeServer.SetProviderFallbacks(cfg.ProviderFallbacks)
+	if s.verifier != nil {
+		s.verifier.SetKeys(keys)
+		if s.verifier.SPIFFE != nil {
+			s.verifier.SPIFFE.SetProjects(cfg.SPIFFE.Projects)
+		}
+		if s.verifier.Kubernetes != nil {
+			s.verifier.Kubernetes.SetProjects(cfg.Kubernetes.Projects)
+		}
+		s.verifier.CertBindings.Set(certBindings(cfg.CertBindings))
+	}
+	if s.auditLog != nil {
+		s.auditLog.SetRetention(cfg.AuditRetention, cfg.ProjectAuditRetention)
+	}
+	if s.certs != nil {
+		// A bad certificate must not undo the rest of the reload; the
+		// current one stays in use.
+		if reloaded, err := s.certs.Reload(); err != nil {
+			s.logger.Warn("tls reload failed; keeping current certificate", "error", err)
+		} else if reloaded {
+			s.logger.Info("tls certificate reloaded")
+		}
+	}
+	s.logger.Info("configuration reloaded", "config", s.baseCfg.ConfigPath, "providers", len(s.registry.List()))
+	return nil
+}
+
+// applyLogLevels sets levels to cfg's LogLevel and LogComponents. Components
+// not in LogComponents follow the default level again.
+func applyLogLevels(levels *logging.Levels, cfg Config) error {
+	def := slog.LevelWarn
+	if cfg.Verbose || cfg.ListenAddr != "" {
+		def = slog.LevelInfo
+	}
+	if cfg.LogLevel != "" {
+		var err error
+		if def, err = logging.ParseLevel(cfg.LogLevel); err != nil {
+			return fmt.Errorf("config: logging.level: %w", err)
+		}
+	}
+	overrides := make(map[string]slog.Level, len(cfg.LogComponents))
+	for component, name := range cfg.LogComponents {
+		level, err := logging.ParseLevel(name)
+		if err != nil {
+			return fmt.Errorf("config: logging.components.%s: %w", component, err)
+		}
+		overrides[component] = level
+	}
+	for _, component := range logging.Components {
+		if _, ok := overrides[component]; !ok {
+			levels.Reset(component)
+		}
+	}
+	for component, level := range overrides {
+		if err := levels.Set(component, level); err != nil {
+			return fmt.Errorf("config: logging.components: %w", err)
+		}
+	}
+	_ = levels.Set("", def)
+	return nil
+}
+
+// HealthAddr returns the address of the HTTP health probe listener, or ""
+// when HealthListen is not set.
+func (s *Server) HealthAddr() string {
+	return s.healthAddr
+}
+
+// UIAddr returns the address of the web dashboard listener, or "" when
+// UIListen is not set.
+func (s *Server) UIAddr() string {
+	return s.uiAddr
+}
+
+// listenHTTP opens the listener of the named HTTP endpoint, "ui" or
+// "health". Local mode has no authentication, so there they are only
+// served on loopback addresses.
+func listenHTTP(name, addr string, mode ServerMode) (net.Listener, error) {
+	if mode == ModeLocal {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("%s listen %s: %w", name, addr, err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, fmt.Errorf("%s listen %s: local mode has no authentication; use a loopback address such as 127.0.0.1", name, addr)
+		}
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s http %s: %w", name, addr, err)
+	}
+	return ln, nil
+}
+
+// Drain marks the server not ready, so health probes fail and load
+// balancers stop sending new clients, and refuses new sessions, then waits
+// out ShutdownDrain or until ctx is done. Existing sessions and streams
+// keep working; call Stop afterwards.
+func (s *Server) Drain(ctx context.Context) {
+	if s.stopping.Swap(true) {
+		return
+	}
+	notify(s.logger, systemd.Stopping)
+	if !s.bridgeServer.StartDrain() {
+		s.markNotReady()
+	}
+	if s.drain <= 0 {
+		return
+	}
+	s.logger.Info("draining before shutdown", "duration", s.drain)
+	t := time.NewTimer(s.drain)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// markNotReady flips the standard health service to NOT_SERVING without
+// waiting for the next ReportHealth tick.
+func (s *Server) markNotReady() {
+	s.healthServer.SetServingStatus(bridgev1.BridgeService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
 // Stop gracefully shuts down the server and cleans up state files.
 func (s *Server) Stop() {
 	s.mu.Lock()
@@ -667,6 +2202,26 @@ func (s *Server) Stop() {
 	s.stopped = true
 
 	s.logger.Info("stopping local server")
+	if !s.stopping.Load() {
+		notify(s.logger, systemd.Stopping)
+	}
+	if s.stopWatchdog != nil {
+		s.stopWatchdog()
+	}
+	if s.stopRefresh != nil {
+		s.stopRefresh()
+	}
+	s.stopSchedule()
+	s.stopHealth()
+	if s.acmeHTTP != nil {
+		_ = s.acmeHTTP.Close()
+	}
+	if s.healthHTTP != nil {
+		_ = s.healthHTTP.Close()
+	}
+	if s.uiHTTP != nil {
+		_ = s.uiHTTP.Close()
+	}
 
 	// Bounded graceful shutdown: try graceful first, then force-stop after
 	// 5 seconds. GracefulStop can block indefinitely if long-lived streams
@@ -685,6 +2240,11 @@ func (s *Server) Stop() {
 
 	s.supervisor.Close()
 	_ = s.listener.Close()
+	if s.auditLog != nil {
+		if err := s.auditLog.Close(); err != nil {
+			s.logger.Warn("close audit log", "error", err)
+		}
+	}
 	if s.store != nil {
 		if err := s.store.Close(); err != nil {
 			s.logger.Warn("close session store", "error", err)
@@ -695,8 +2255,12 @@ func (s *Server) Stop() {
 	_ = os.Remove(filepath.Join(s.stateDir, "server.pid"))
 	_ = os.Remove(filepath.Join(s.stateDir, "server.addr"))
 	_ = os.Remove(filepath.Join(s.stateDir, "server.mode"))
-	_ = os.Remove(filepath.Join(s.stateDir, "server.sock"))
-	_ = os.Remove(filepath.Join(s.stateDir, "server.lock"))
+	// An activated socket belongs to systemd, which keeps listening on it
+	// to start the daemon again.
+	if !s.activated {
+		_ = os.Remove(filepath.Join(s.stateDir, "server.sock"))
+		_ = os.Remove(filepath.Join(s.stateDir, "server.lock"))
+	}
 }
 
 // listen creates the appropriate listener for the platform.
@@ -867,17 +2431,44 @@ type providerDef struct {
 	ID             string
 	Binary         string
 	Args           []string
+	ResumeArgs     []string
+	ThreadIDArgs   []string
 	StartupTimeout time.Duration
 	StartupProbe   string
 	PromptPattern  string
 	RequiredEnv    []string
+	EnvAllowlist   []string
 	StreamJSON     bool
+	JSONFormat     string
+	MCPConfigFlag  string
+	ApprovalArgs   []string
+}
+
+// providerDefFromConfig describes a provider whose definition lives in the
+// provider package.
+func providerDefFromConfig(cfg provider.StdioConfig) providerDef {
+	return providerDef{
+		ID:             cfg.ProviderID,
+		Binary:         cfg.Binary,
+		Args:           cfg.DefaultArgs,
+		ResumeArgs:     cfg.ResumeArgs,
+		ThreadIDArgs:   cfg.ThreadIDArgs,
+		StartupTimeout: cfg.StartupTimeout,
+		StartupProbe:   cfg.StartupProbe,
+		PromptPattern:  cfg.PromptPattern,
+		RequiredEnv:    cfg.RequiredEnv,
+		EnvAllowlist:   cfg.EnvAllowlist,
+		StreamJSON:     cfg.StreamJSON,
+		JSONFormat:     cfg.JSONFormat,
+		MCPConfigFlag:  cfg.MCPConfigFlag,
+		ApprovalArgs:   cfg.ApprovalArgs,
+	}
 }
 
 func detectProviders() []providerDef {
 	var found []providerDef
 	for _, pd := range knownProviders() {
-		if _, err := exec.LookPath(pd.Binary); err != nil {
+		if _, err := provider.LookupBinary(pd.Binary); err != nil {
 			continue
 		}
 		found = append(found, pd)
@@ -885,26 +2476,37 @@ func detectProviders() []providerDef {
 	return found
 }
 
+// openCodeEnv covers the model providers opencode can be configured with.
+var openCodeEnv = []string{"OPENCODE_*", "OPENAI_*", "ANTHROPIC_*", "OPENROUTER_*", "GEMINI_*", "GOOGLE_*"}
+
 func knownProviders() []providerDef {
 	return []providerDef{
 		{
 			ID:             "claude",
 			Binary:         "claude",
 			Args:           []string{"--verbose"},
+			ResumeArgs:     []string{"--resume", provider.ThreadIDArg},
+			ThreadIDArgs:   []string{"--session-id", provider.ThreadIDArg},
 			StartupTimeout: 60 * time.Second,
 			StartupProbe:   "prompt",
 			PromptPattern:  `(?m)(❯|>\s*$)`,
 			// No RequiredEnv: local-server mode relies on native CLI auth
-			// (e.g. claude auth login). Env vars are still forwarded to the
+			// (e.g. claude auth login). API keys are still forwarded to the
 			// subprocess if present in the environment.
+			EnvAllowlist:  []string{"ANTHROPIC_*", "CLAUDE_CODE_*", "CLAUDE_CONFIG_DIR"},
+			MCPConfigFlag: "--mcp-config",
 		},
 		{
-			ID:             "codex",
-			Binary:         "codex",
-			Args:           nil,
+			ID:     "codex",
+			Binary: "codex",
+			Args:   nil,
+			// codex cannot be given a thread ID and its terminal does not
+			// print one, so a crashed agent starts a new thread rather
+			// than `resume --last`, which may be another session's.
 			StartupTimeout: 60 * time.Second,
 			StartupProbe:   "prompt",
 			PromptPattern:  `(?m)(>\s*$|›)`,
+			EnvAllowlist:   []string{"OPENAI_*", "CODEX_*"},
 		},
 		{
 			ID:             "opencode",
@@ -913,7 +2515,11 @@ func knownProviders() []providerDef {
 			StartupTimeout: 60 * time.Second,
 			StartupProbe:   "output",
 			PromptPattern:  `❯`,
+			EnvAllowlist:   openCodeEnv,
 		},
+		// opencode-json drives the same binary through its JSON event
+		// protocol, which signals turn completion explicitly.
+		providerDefFromConfig(provider.OpenCodeJSONConfig()),
 		{
 			ID:             "gemini",
 			Binary:         "gemini",
@@ -921,6 +2527,7 @@ func knownProviders() []providerDef {
 			StartupTimeout: 60 * time.Second,
 			StartupProbe:   "prompt",
 			PromptPattern:  `^\s*>\s*$`,
+			EnvAllowlist:   []string{"GEMINI_*", "GOOGLE_*"},
 		},
 	}
 }
@@ -975,3 +2582,43 @@ func (h *redactingHandler) redactAttr(a slog.Attr) slog.Attr {
 	}
 	return a
 }
+
+// teeHandler hands each record to every handler that is enabled for it.
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, inner := range h.handlers {
+		if inner.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, inner := range h.handlers {
+		if inner.Enabled(ctx, r.Level) {
+			errs = append(errs, inner.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, inner := range h.handlers {
+		handlers[i] = inner.WithAttrs(attrs)
+	}
+	return &teeHandler{handlers: handlers}
+}
+
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, inner := range h.handlers {
+		handlers[i] = inner.WithGroup(name)
+	}
+	return &teeHandler{handlers: handlers}
+}
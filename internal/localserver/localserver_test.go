@@ -3,16 +3,30 @@ package localserver
 import (
 	"bytes"
 	"context"
+	"io"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/config"
 	"github.com/markcallen/ai-agent-bridge/internal/redact"
 	"github.com/markcallen/ai-agent-bridge/internal/server"
+	"github.com/markcallen/ai-agent-bridge/internal/systemd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 // startLocalServer starts a server in local mode using a temp state dir and
@@ -22,6 +36,7 @@ func startLocalServer(t *testing.T, cfg Config) *Server {
 	if cfg.StateDir == "" {
 		cfg.StateDir = t.TempDir()
 	}
+	cfg.AllowInsecure = true
 	srv, err := Start(cfg)
 	require.NoError(t, err)
 	t.Cleanup(func() { srv.Stop() })
@@ -35,6 +50,27 @@ func TestStartDefaultConfig(t *testing.T) {
 	assert.NotEmpty(t, srv.Addr())
 }
 
+// TestStartRequiresAllowInsecure verifies that local mode, which has no
+// TLS or authentication, must be asked for, and that its HTTP listeners
+// are limited to loopback.
+func TestStartRequiresAllowInsecure(t *testing.T) {
+	_, err := Start(Config{StateDir: t.TempDir()})
+	assert.ErrorContains(t, err, "--allow-insecure")
+
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "bridge.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte("server:\n  insecure_dev_mode: true\n"), 0o644))
+	srv, err := Start(Config{StateDir: dir, ConfigPath: cfgFile})
+	require.NoError(t, err)
+	mode, err := os.ReadFile(filepath.Join(dir, "server.mode"))
+	require.NoError(t, err)
+	assert.Equal(t, string(ModeLocal), string(mode))
+	srv.Stop()
+
+	_, err = Start(Config{AllowInsecure: true, StateDir: t.TempDir(), HealthListen: "0.0.0.0:0"})
+	assert.ErrorContains(t, err, "loopback")
+}
+
 // TestStartWithDBPath verifies that Start() opens a BoltDB store when DBPath
 // is set and that Stop() closes it without error.
 func TestStartWithDBPath(t *testing.T) {
@@ -64,8 +100,9 @@ func TestStartWithInvalidDBPath(t *testing.T) {
 	badPath := filepath.Join(dir, "no-such-dir", "sessions.db")
 
 	cfg := Config{
-		StateDir: dir,
-		DBPath:   badPath,
+		StateDir:      dir,
+		DBPath:        badPath,
+		AllowInsecure: true,
 	}
 	_, err := Start(cfg)
 	assert.Error(t, err, "Start should fail when the BoltDB path is invalid")
@@ -160,6 +197,7 @@ func TestStartWithRedactPatterns(t *testing.T) {
 func TestStartWithInvalidRedactPattern(t *testing.T) {
 	dir := t.TempDir()
 	_, err := Start(Config{
+		AllowInsecure:  true,
 		StateDir:       dir,
 		RedactPatterns: []string{`[invalid`},
 	})
@@ -267,6 +305,133 @@ func TestIsServerRunningAndDiscoverTarget(t *testing.T) {
 	assert.Equal(t, ModeLocal, mode)
 }
 
+// TestStandardHealthService verifies that the grpc.health.v1 service is
+// registered, reports the daemon SERVING and BridgeService by readiness, and
+// marks both NOT_SERVING on Stop.
+func TestStandardHealthService(t *testing.T) {
+	srv := startLocalServer(t, Config{})
+	conn, err := grpc.NewClient(srv.Target(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "bridge.v1.BridgeService"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	go srv.Stop()
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("stream ended before NOT_SERVING: %v", err)
+		}
+		if update.Status == healthpb.HealthCheckResponse_NOT_SERVING {
+			return
+		}
+	}
+}
+
+// TestHealthListenAndDrain verifies the HTTP probes served on
+// server.health_listen and that Drain fails readiness while liveness keeps
+// passing.
+func TestHealthListenAndDrain(t *testing.T) {
+	srv := startLocalServer(t, Config{HealthListen: "127.0.0.1:0", ShutdownDrain: time.Minute})
+	base := "http://" + srv.HealthAddr()
+
+	probe := func(path string) (int, *bridgev1.HealthResponse) {
+		t.Helper()
+		resp, err := http.Get(base + path)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		var health bridgev1.HealthResponse
+		require.NoError(t, protojson.Unmarshal(body, &health))
+		return resp.StatusCode, &health
+	}
+	code, _ := probe("/livez")
+	assert.Equal(t, http.StatusOK, code)
+	_, ready := probe("/readyz")
+	draining := func(resp *bridgev1.HealthResponse) *bridgev1.HealthCheckResult {
+		for _, c := range resp.Checks {
+			if c.Name == "draining" {
+				return c
+			}
+		}
+		return nil
+	}
+	require.NotNil(t, draining(ready))
+	assert.True(t, draining(ready).Ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // skip the wait; the server is marked draining regardless
+	srv.Drain(ctx)
+	code, ready = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, draining(ready).Ok)
+	code, _ = probe("/livez")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+// TestUIListen verifies that server.ui_listen serves the dashboard and that
+// local mode refuses to serve it beyond loopback.
+func TestUIListen(t *testing.T) {
+	srv := startLocalServer(t, Config{UIListen: "127.0.0.1:0"})
+	base := "http://" + srv.UIAddr()
+	for path, contentType := range map[string]string{"/ui/": "text/html", "/ui/api/sessions": "application/json"} {
+		resp, err := http.Get(base + path)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Contains(t, resp.Header.Get("Content-Type"), contentType, path)
+	}
+
+	_, err := Start(Config{AllowInsecure: true, StateDir: t.TempDir(), UIListen: "0.0.0.0:0"})
+	assert.ErrorContains(t, err, "loopback")
+}
+
+// TestReflection verifies that server.reflection serves the gRPC reflection
+// service and that it is off by default.
+func TestReflection(t *testing.T) {
+	listServices := func(t *testing.T, srv *Server) ([]string, error) {
+		t.Helper()
+		conn, err := grpc.NewClient(srv.Target(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+		require.NoError(t, err)
+		err = stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		})
+		require.NoError(t, err)
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, svc := range resp.GetListServicesResponse().GetService() {
+			names = append(names, svc.Name)
+		}
+		return names, nil
+	}
+
+	names, err := listServices(t, startLocalServer(t, Config{Reflection: true}))
+	require.NoError(t, err)
+	assert.Contains(t, names, "bridge.v1.BridgeService")
+
+	_, err = listServices(t, startLocalServer(t, Config{}))
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
 // TestIsServerRunningFalseWhenNoServer verifies that IsServerRunning returns
 // false for an empty state dir.
 func TestIsServerRunningFalseWhenNoServer(t *testing.T) {
@@ -323,6 +488,38 @@ func TestStartSecureMode(t *testing.T) {
 	assert.Equal(t, ModeSecure, mode)
 }
 
+// TestStartSecureModeACME verifies that tls.acme replaces the server
+// certificate files while client auth still uses the CA bundle, and that
+// the HTTP-01 challenge listener is started and stopped with the server.
+func TestStartSecureModeACME(t *testing.T) {
+	pkiDir := t.TempDir()
+	mat, err := EnsurePKI(pkiDir, []string{"localhost"}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	acme := config.ACMEConfig{Domains: []string{"bridge.example.test"}, HTTPAddr: "127.0.0.1:0"}
+
+	_, err = Start(Config{
+		StateDir:     t.TempDir(),
+		ListenAddr:   "127.0.0.1:0",
+		CABundlePath: mat.CABundlePath,
+		TLSCertPath:  mat.ServerCertPath,
+		TLSKeyPath:   mat.ServerKeyPath,
+		ACME:         acme,
+	})
+	require.ErrorContains(t, err, "ACME cannot be combined")
+
+	dir := t.TempDir()
+	srv, err := Start(Config{
+		StateDir:     dir,
+		ListenAddr:   "127.0.0.1:0",
+		CABundlePath: mat.CABundlePath,
+		ACME:         acme,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { srv.Stop() })
+	assert.NotNil(t, srv.acmeHTTP, "HTTP-01 challenge server not started")
+	assert.Equal(t, ModeSecure, DiscoverMode(dir))
+}
+
 // TestIsServerRunningSecureMode verifies IsServerRunning detects a secure-mode
 // server. This also exercises the secure probeHealth path.
 func TestIsServerRunningSecureMode(t *testing.T) {
@@ -405,3 +602,129 @@ func TestRedactingHandlerWithGroup(t *testing.T) {
 	// Must not panic and must still implement slog.Handler.
 	assert.NotNil(t, gh)
 }
+
+// TestTeeHandler verifies that records reach every handler enabled for
+// their level, so that audit files get info records a warn-level daemon
+// log drops.
+func TestTeeHandler(t *testing.T) {
+	var warnBuf, infoBuf bytes.Buffer
+	h := &teeHandler{handlers: []slog.Handler{
+		slog.NewTextHandler(&warnBuf, &slog.HandlerOptions{Level: slog.LevelWarn}),
+		slog.NewTextHandler(&infoBuf, &slog.HandlerOptions{Level: slog.LevelInfo}),
+	}}
+	assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+
+	logger := slog.New(h).With("component", "auth")
+	logger.Info("rpc audit", "result", "ok")
+	logger.Warn("rpc audit", "result", "error")
+
+	assert.NotContains(t, warnBuf.String(), "result=ok")
+	assert.Contains(t, warnBuf.String(), "result=error")
+	assert.Contains(t, infoBuf.String(), "msg=\"rpc audit\" component=auth result=ok")
+	assert.Contains(t, infoBuf.String(), "result=error")
+}
+
+// TestChooseActivated verifies which systemd socket serves gRPC and that a
+// socket is refused when it does not match the server mode.
+func TestChooseActivated(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	tcp := func() systemd.Listener {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		return systemd.Listener{Listener: ln, Name: "bridge.socket"}
+	}
+	unix := func() systemd.Listener {
+		ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "s.sock"))
+		require.NoError(t, err)
+		return systemd.Listener{Listener: ln, Name: "bridge.socket"}
+	}
+
+	health, grpcLn := tcp(), tcp()
+	grpcLn.Name = "grpc"
+	ln, err := chooseActivated(ModeSecure, []systemd.Listener{health, grpcLn}, logger)
+	require.NoError(t, err)
+	assert.Equal(t, grpcLn.Addr().String(), ln.Addr().String())
+	_ = ln.Close()
+	_, err = health.Accept()
+	assert.Error(t, err, "unused socket should be closed")
+
+	ln, err = chooseActivated(ModeLocal, []systemd.Listener{unix()}, logger)
+	require.NoError(t, err)
+	_ = ln.Close()
+
+	_, err = chooseActivated(ModeLocal, []systemd.Listener{tcp()}, logger)
+	assert.ErrorContains(t, err, "needs secure mode")
+	_, err = chooseActivated(ModeSecure, []systemd.Listener{unix()}, logger)
+	assert.ErrorContains(t, err, "needs a TCP socket")
+}
+
+// TestSystemdNotify verifies READY=1 on start and STOPPING=1 on stop when
+// NOTIFY_SOCKET is set.
+func TestSystemdNotify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	recv := func() string {
+		t.Helper()
+		buf := make([]byte, 256)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+	srv := startLocalServer(t, Config{})
+	assert.Equal(t, systemd.Ready, recv())
+	require.NoError(t, srv.Reload())
+	assert.Contains(t, recv(), "RELOADING=1")
+	assert.Equal(t, systemd.Ready, recv())
+	srv.Stop()
+	assert.Equal(t, systemd.Stopping, recv())
+}
+
+func TestRegisterProvider(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "bridge.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte(`
+providers:
+  filed:
+    binary: "cat"
+`), 0o644))
+	cfg := Config{StateDir: dir, ConfigPath: cfgFile}
+	srv, err := Start(cfg)
+	require.NoError(t, err)
+
+	def := server.ProviderDefinition{ID: "mycat", Binary: "cat", PromptPattern: `\$ $`, StartupTimeout: 5 * time.Second}
+	replaced, err := srv.RegisterProvider(def, false)
+	require.NoError(t, err)
+	assert.False(t, replaced)
+	_, err = srv.registry.Get("mycat")
+	require.NoError(t, err)
+
+	_, err = srv.RegisterProvider(def, false)
+	require.ErrorIs(t, err, bridge.ErrProviderAlreadyExists)
+	replaced, err = srv.RegisterProvider(def, true)
+	require.NoError(t, err)
+	assert.True(t, replaced)
+	_, err = srv.RegisterProvider(server.ProviderDefinition{ID: "filed", Binary: "cat"}, true)
+	require.ErrorIs(t, err, bridge.ErrProviderAlreadyExists)
+	_, err = srv.RegisterProvider(server.ProviderDefinition{ID: "bad", Binary: "cat", StartupProbe: "ping"}, false)
+	require.ErrorIs(t, err, bridge.ErrInvalidArgument)
+
+	// Registrations survive a reload and a restart.
+	require.NoError(t, srv.Reload())
+	_, err = srv.registry.Get("mycat")
+	require.NoError(t, err)
+	srv.Stop()
+	srv = startLocalServer(t, cfg)
+	_, err = srv.registry.Get("mycat")
+	require.NoError(t, err)
+
+	require.NoError(t, srv.UnregisterProvider("mycat"))
+	_, err = srv.registry.Get("mycat")
+	require.ErrorIs(t, err, bridge.ErrProviderUnavailable)
+	require.ErrorIs(t, srv.UnregisterProvider("mycat"), bridge.ErrProviderNotFound)
+	require.ErrorIs(t, srv.UnregisterProvider("filed"), bridge.ErrPermissionDenied)
+}
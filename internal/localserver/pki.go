@@ -1,6 +1,10 @@
 package localserver
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"log/slog"
 	"os"
@@ -24,6 +28,7 @@ type PKIMaterial struct {
 	CABundlePath    string // certs/ca-bundle.crt
 	JWTSigningKey   string // certs/jwt-signing.key
 	JWTSigningPub   string // certs/jwt-signing.pub
+	CRLPath         string // certs/ca.crl; may not exist until a revocation
 }
 
 // CertsDir returns the path to the certs subdirectory within the state dir.
@@ -45,6 +50,7 @@ func LoadPKIMaterial(stateDir string) *PKIMaterial {
 		CABundlePath:    filepath.Join(dir, "ca-bundle.crt"),
 		JWTSigningKey:   filepath.Join(dir, "jwt-signing.key"),
 		JWTSigningPub:   filepath.Join(dir, "jwt-signing.pub"),
+		CRLPath:         filepath.Join(dir, "ca.crl"),
 	}
 }
 
@@ -134,6 +140,9 @@ func IssueClientCert(stateDir, clientName string, logger *slog.Logger) (certPath
 	if !safeNameRe.MatchString(clientName) {
 		return "", "", fmt.Errorf("invalid client name %q: must be alphanumeric with hyphens, underscores, or dots", clientName)
 	}
+	if clientName == SessionTokenIssuer {
+		return "", "", fmt.Errorf("invalid client name %q: reserved for session tokens", clientName)
+	}
 
 	mat := LoadPKIMaterial(stateDir)
 
@@ -173,3 +182,44 @@ func IssueClientCert(stateDir, clientName string, logger *slog.Logger) (certPath
 	logger.Info("issued client credentials", "name", clientName, "cert", certPath, "jwt_key", jwtKeyPath)
 	return certPath, keyPath, nil
 }
+
+// SessionTokenIssuer is the issuer of the tokens MintSessionToken returns.
+const SessionTokenIssuer = "bridge-session-tokens"
+
+// EnsureSessionTokenKey returns the key that session tokens are signed
+// with: the one keyRef names, a PKCS#11 URI or a PEM file, or, when keyRef
+// is empty, one generated in the state dir on first use. Its public key is
+// registered in certs/jwt-clients like a client's, so the verifier trusts
+// the tokens.
+func EnsureSessionTokenKey(stateDir, keyRef string) (crypto.Signer, error) {
+	certsDir := CertsDir(stateDir)
+	if keyRef == "" {
+		keyRef = filepath.Join(certsDir, "session-token.key")
+		if _, err := os.Stat(keyRef); os.IsNotExist(err) {
+			if _, _, err := pki.GenerateJWTKeypair(certsDir, "session-token"); err != nil {
+				return nil, fmt.Errorf("generate session token keypair: %w", err)
+			}
+		}
+	}
+	key, err := pki.LoadJWTSigner(keyRef, nil)
+	if err != nil {
+		return nil, fmt.Errorf("load session token key: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("marshal session token public key: %w", err)
+	}
+	pubData := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	serverJWTDir := filepath.Join(certsDir, "jwt-clients")
+	registered := filepath.Join(serverJWTDir, SessionTokenIssuer+".pub")
+	if existing, err := os.ReadFile(registered); err == nil && bytes.Equal(existing, pubData) {
+		return key, nil
+	}
+	if err := os.MkdirAll(serverJWTDir, 0o700); err != nil {
+		return nil, fmt.Errorf("create jwt-clients dir: %w", err)
+	}
+	if err := os.WriteFile(registered, pubData, 0o644); err != nil {
+		return nil, fmt.Errorf("register session token public key: %w", err)
+	}
+	return key, nil
+}
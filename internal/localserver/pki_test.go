@@ -1,6 +1,7 @@
 package localserver
 
 import (
+	"crypto/ed25519"
 	"crypto/x509"
 	"log/slog"
 	"os"
@@ -181,6 +182,39 @@ func TestIssueClientCert_RejectsPathTraversal(t *testing.T) {
 	}
 }
 
+func TestEnsureSessionTokenKey(t *testing.T) {
+	stateDir := t.TempDir()
+	logger := testLogger()
+
+	key, err := EnsureSessionTokenKey(stateDir, "")
+	require.NoError(t, err)
+	again, err := EnsureSessionTokenKey(stateDir, "")
+	require.NoError(t, err)
+	assert.True(t, key.Public().(ed25519.PublicKey).Equal(again.Public()), "key should be reused")
+
+	// The public key is trusted like a client's.
+	keys, err := loadJWTKeys(&PKIMaterial{}, stateDir, logger, nil)
+	require.NoError(t, err)
+	require.Len(t, keys[SessionTokenIssuer], 1)
+	assert.True(t, keys[SessionTokenIssuer][0].Key.Equal(key.Public()))
+
+	_, err = EnsurePKI(stateDir, []string{"127.0.0.1"}, logger)
+	require.NoError(t, err)
+	_, _, err = IssueClientCert(stateDir, SessionTokenIssuer, logger)
+	assert.Error(t, err, "the session token issuer name is reserved")
+
+	// A configured key replaces the generated one.
+	_, keyPath, err := pki.GenerateJWTKeypair(t.TempDir(), "external")
+	require.NoError(t, err)
+	external, err := EnsureSessionTokenKey(stateDir, keyPath)
+	require.NoError(t, err)
+	assert.False(t, external.Public().(ed25519.PublicKey).Equal(key.Public()))
+	keys, err = loadJWTKeys(&PKIMaterial{}, stateDir, logger, nil)
+	require.NoError(t, err)
+	require.Len(t, keys[SessionTokenIssuer], 1)
+	assert.True(t, keys[SessionTokenIssuer][0].Key.Equal(external.Public()))
+}
+
 func TestLoadPKIMaterial(t *testing.T) {
 	stateDir := filepath.Join(os.TempDir(), "test-state")
 	mat := LoadPKIMaterial(stateDir)
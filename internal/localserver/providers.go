@@ -0,0 +1,164 @@
+package localserver
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/config"
+	"github.com/markcallen/ai-agent-bridge/internal/server"
+	"gopkg.in/yaml.v3"
+)
+
+// dynamicProvidersFile holds the providers added with
+// AdminService.RegisterProvider, in the state dir.
+const dynamicProvidersFile = "providers.yaml"
+
+// dynamicProviders holds the provider definitions added at runtime. They
+// are kept in a YAML file shaped like the config file's providers section,
+// so they survive restarts.
+type dynamicProviders struct {
+	path string
+
+	mu   sync.Mutex
+	defs map[string]config.ProviderConfig
+}
+
+// loadDynamicProviders reads the definitions kept at path. A missing file
+// yields none.
+func loadDynamicProviders(path string) (*dynamicProviders, error) {
+	d := &dynamicProviders{path: path, defs: map[string]config.ProviderConfig{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read registered providers: %w", err)
+	}
+	var file struct {
+		Providers map[string]config.ProviderConfig `yaml:"providers"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse registered providers %s: %w", path, err)
+	}
+	for id, pc := range file.Providers {
+		if err := config.ValidateProvider(id, pc); err != nil {
+			return nil, fmt.Errorf("registered providers %s: %w", path, err)
+		}
+		d.defs[id] = pc
+	}
+	return d, nil
+}
+
+// snapshot returns a copy of the definitions.
+func (d *dynamicProviders) snapshot() map[string]config.ProviderConfig {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return maps.Clone(d.defs)
+}
+
+func (d *dynamicProviders) saveLocked() error {
+	data, err := yaml.Marshal(map[string]any{"providers": d.defs})
+	if err != nil {
+		return fmt.Errorf("write registered providers: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(d.path), ".providers-*")
+	if err != nil {
+		return fmt.Errorf("write registered providers: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write registered providers: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write registered providers: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), d.path); err != nil {
+		return fmt.Errorf("write registered providers: %w", err)
+	}
+	return nil
+}
+
+// RegisterProvider implements server.ProviderRegistrar. Providers defined
+// in the config file cannot be overridden. Running sessions keep the
+// provider they were started with.
+func (s *Server) RegisterProvider(def server.ProviderDefinition, replace bool) (bool, error) {
+	pc := config.ProviderConfig{
+		Binary:        def.Binary,
+		Args:          def.Args,
+		StreamJSON:    def.StreamJSON,
+		JSONFormat:    def.JSONFormat,
+		PromptPattern: def.PromptPattern,
+		StartupProbe:  def.StartupProbe,
+		RequiredEnv:   def.RequiredEnv,
+	}
+	if def.StartupTimeout > 0 {
+		pc.StartupTimeout = def.StartupTimeout.String()
+	}
+	if err := config.ValidateProvider(def.ID, pc); err != nil {
+		return false, fmt.Errorf("%w: %v", bridge.ErrInvalidArgument, err)
+	}
+
+	s.providersMu.Lock()
+	defer s.providersMu.Unlock()
+	if _, ok := s.fileProviders.defs[def.ID]; ok {
+		return false, fmt.Errorf("%w: %q is defined in the config file", bridge.ErrProviderAlreadyExists, def.ID)
+	}
+	d := s.dynamic
+	d.mu.Lock()
+	prev, replaced := d.defs[def.ID]
+	if replaced && !replace {
+		d.mu.Unlock()
+		return false, fmt.Errorf("%w: %q is already registered; set replace to overwrite it", bridge.ErrProviderAlreadyExists, def.ID)
+	}
+	d.defs[def.ID] = pc
+	if err := d.saveLocked(); err != nil {
+		if replaced {
+			d.defs[def.ID] = prev
+		} else {
+			delete(d.defs, def.ID)
+		}
+		d.mu.Unlock()
+		return false, err
+	}
+	d.mu.Unlock()
+	s.applyProvidersLocked()
+	return replaced, nil
+}
+
+// UnregisterProvider implements server.ProviderRegistrar. Only providers
+// added with RegisterProvider can be removed.
+func (s *Server) UnregisterProvider(id string) error {
+	s.providersMu.Lock()
+	defer s.providersMu.Unlock()
+	d := s.dynamic
+	d.mu.Lock()
+	prev, ok := d.defs[id]
+	if !ok {
+		d.mu.Unlock()
+		if _, inFile := s.fileProviders.defs[id]; inFile {
+			return fmt.Errorf("%w: %q is defined in the config file", bridge.ErrPermissionDenied, id)
+		}
+		return fmt.Errorf("%w: %q was not registered at runtime", bridge.ErrProviderNotFound, id)
+	}
+	delete(d.defs, id)
+	if err := d.saveLocked(); err != nil {
+		d.defs[id] = prev
+		d.mu.Unlock()
+		return err
+	}
+	d.mu.Unlock()
+	s.applyProvidersLocked()
+	return nil
+}
+
+// applyProvidersLocked rebuilds the registry from the config file's
+// providers and the registered ones. s.providersMu must be held.
+func (s *Server) applyProvidersLocked() {
+	s.registry.Replace(buildProviders(s.fileProviders, s.dynamic.snapshot(), s.resolver, s.logger))
+}
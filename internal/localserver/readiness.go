@@ -0,0 +1,45 @@
+package localserver
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultWorkspaceMinFree is the free space on the workspace filesystem
+// below which the daemon reports not ready.
+const defaultWorkspaceMinFree = 1 << 30
+
+// checkCertExpiry fails once the server certificate has expired. A zero
+// notAfter, as with ACME certificates, always passes.
+func checkCertExpiry(notAfter, now time.Time) error {
+	if !notAfter.IsZero() && now.After(notAfter) {
+		return fmt.Errorf("server certificate expired at %s", notAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// checkDiskFree fails when less than minFree bytes are free on the
+// filesystem that holds dir, or would hold it once created.
+func checkDiskFree(dir string, minFree uint64) error {
+	for {
+		if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	free, err := diskFree(dir)
+	if err != nil {
+		return fmt.Errorf("disk free %s: %w", dir, err)
+	}
+	if free < minFree {
+		return fmt.Errorf("%d bytes free in %s, want at least %d", free, dir, minFree)
+	}
+	return nil
+}
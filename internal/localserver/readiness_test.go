@@ -0,0 +1,35 @@
+package localserver
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckCertExpiry(t *testing.T) {
+	now := time.Now()
+	if err := checkCertExpiry(time.Time{}, now); err != nil {
+		t.Fatalf("zero NotAfter: %v", err)
+	}
+	if err := checkCertExpiry(now.Add(time.Hour), now); err != nil {
+		t.Fatalf("valid cert: %v", err)
+	}
+	if err := checkCertExpiry(now.Add(-time.Hour), now); err == nil {
+		t.Fatal("expired cert passed")
+	}
+}
+
+func TestCheckDiskFree(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkDiskFree(dir, 1); err != nil {
+		t.Fatalf("checkDiskFree: %v", err)
+	}
+	// A workspace dir that does not exist yet is checked on its parent.
+	if err := checkDiskFree(filepath.Join(dir, "workspaces", "nested"), 1); err != nil {
+		t.Fatalf("checkDiskFree missing dir: %v", err)
+	}
+	if err := checkDiskFree(dir, math.MaxUint64); err == nil {
+		t.Fatal("checkDiskFree passed with an impossible minimum")
+	}
+}
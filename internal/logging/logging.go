@@ -0,0 +1,195 @@
+// Package logging builds the daemon's slog handler: text or JSON output
+// whose level can be set per component and changed while it runs.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ComponentKey is the attribute that names the component a logger belongs
+// to, e.g. logger.With(ComponentKey, ComponentAuth).
+const ComponentKey = "component"
+
+// Components whose level can be set apart from the default.
+const (
+	// ComponentAuth is JWT, certificate and audit logging.
+	ComponentAuth = "auth"
+	// ComponentSupervisor is the session supervisor: session lifecycle,
+	// attachments and input.
+	ComponentSupervisor = "supervisor"
+	// ComponentProvider is provider discovery, health and agent startup.
+	ComponentProvider = "provider"
+	// ComponentServer is the gRPC handlers.
+	ComponentServer = "server"
+)
+
+// Components lists the components in the order they are reported.
+var Components = []string{ComponentAuth, ComponentSupervisor, ComponentProvider, ComponentServer}
+
+// Output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ParseLevel parses debug, info, warn (or warning) and error, in any case.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+}
+
+// LevelName is the lower-case name ParseLevel accepts for level.
+func LevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// Levels holds the default level and the levels of components that
+// override it. It is safe for concurrent use; loggers built on it see
+// changes at once.
+type Levels struct {
+	def slog.LevelVar
+
+	mu         sync.RWMutex
+	components map[string]slog.Level
+}
+
+// NewLevels returns levels at def with no component overrides.
+func NewLevels(def slog.Level) *Levels {
+	l := &Levels{components: make(map[string]slog.Level)}
+	l.def.Set(def)
+	return l
+}
+
+// Set sets the level of component, or the default when component is
+// empty. Components are those in Components.
+func (l *Levels) Set(component string, level slog.Level) error {
+	if component == "" {
+		l.def.Set(level)
+		return nil
+	}
+	if !slices.Contains(Components, component) {
+		return fmt.Errorf("unknown log component %q (want one of %s)", component, strings.Join(Components, ", "))
+	}
+	l.mu.Lock()
+	l.components[component] = level
+	l.mu.Unlock()
+	return nil
+}
+
+// Reset makes component follow the default level again.
+func (l *Levels) Reset(component string) {
+	l.mu.Lock()
+	delete(l.components, component)
+	l.mu.Unlock()
+}
+
+// Level returns the level in effect for component.
+func (l *Levels) Level(component string) slog.Level {
+	if component != "" {
+		l.mu.RLock()
+		level, ok := l.components[component]
+		l.mu.RUnlock()
+		if ok {
+			return level
+		}
+	}
+	return l.def.Level()
+}
+
+// Snapshot returns the default level under "" and the level in effect for
+// every component, by name.
+func (l *Levels) Snapshot() map[string]string {
+	out := map[string]string{"": LevelName(l.def.Level())}
+	for _, c := range Components {
+		out[c] = LevelName(l.Level(c))
+	}
+	return out
+}
+
+// NewHandler returns a handler writing format ("text" or "json") to w at
+// the levels in levels. Records are filtered by the level of the
+// component named by their logger's ComponentKey attribute.
+func NewHandler(w io.Writer, format string, levels *Levels) (slog.Handler, error) {
+	// The inner handler lets everything through; Enabled filters.
+	opts := &slog.HandlerOptions{Level: slog.Level(-100)}
+	var inner slog.Handler
+	switch format {
+	case FormatText, "":
+		inner = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		inner = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want text or json)", format)
+	}
+	return &handler{inner: inner, levels: levels}, nil
+}
+
+type handler struct {
+	inner     slog.Handler
+	levels    *Levels
+	component string
+	grouped   bool
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.levels.Level(h.component)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.inner = h.inner.WithAttrs(attrs)
+	if !h.grouped {
+		for _, a := range attrs {
+			if a.Key == ComponentKey {
+				next.component = a.Value.String()
+			}
+		}
+	}
+	return &next
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	next := *h
+	next.inner = h.inner.WithGroup(name)
+	next.grouped = next.grouped || name != ""
+	return &next
+}
+
+// RequestIDKey is the attribute, and the AttachSessionEvent field, that
+// carries the ID of the RPC a record or event came from.
+const RequestIDKey = "request_id"
+
+type ctxKeyRequestID struct{}
+
+// ContextWithRequestID stores the ID of the RPC being served in ctx.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKeyRequestID{}, id)
+}
+
+// RequestID returns the RPC ID stored in ctx, or "" when there is none.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKeyRequestID{}).(string)
+	return id
+}
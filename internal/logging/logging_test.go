@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestHandlerComponentLevels(t *testing.T) {
+	var buf bytes.Buffer
+	levels := NewLevels(slog.LevelWarn)
+	h, err := NewHandler(&buf, FormatJSON, levels)
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	logger := slog.New(h)
+	auth := logger.With(ComponentKey, ComponentAuth)
+
+	logger.Info("default info")
+	auth.Info("auth info")
+	if buf.Len() != 0 {
+		t.Fatalf("logged below warn: %s", buf.String())
+	}
+
+	if err := levels.Set(ComponentAuth, slog.LevelDebug); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	auth.Debug("auth debug")
+	logger.Info("default info")
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if rec["msg"] != "auth debug" || rec[ComponentKey] != ComponentAuth {
+		t.Fatalf("record = %v", rec)
+	}
+
+	// A component attribute inside a group does not select a level.
+	buf.Reset()
+	logger.WithGroup("req").With(ComponentKey, ComponentAuth).Debug("grouped")
+	if buf.Len() != 0 {
+		t.Fatalf("grouped attribute changed the level: %s", buf.String())
+	}
+
+	levels.Reset(ComponentAuth)
+	auth.Info("auth info")
+	if buf.Len() != 0 {
+		t.Fatalf("reset component still at debug: %s", buf.String())
+	}
+
+	if err := levels.Set("ui", slog.LevelDebug); err == nil {
+		t.Fatal("Set accepted an unknown component")
+	}
+	snap := levels.Snapshot()
+	if snap[""] != "warn" || snap[ComponentProvider] != "warn" || len(snap) != len(Components)+1 {
+		t.Fatalf("Snapshot = %v", snap)
+	}
+}
+
+func TestNewHandlerFormats(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewHandler(&buf, "", NewLevels(slog.LevelInfo))
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	slog.New(h).Info("hello", "n", 1)
+	if !strings.Contains(buf.String(), "msg=hello n=1") {
+		t.Fatalf("text output %q", buf.String())
+	}
+	if _, err := NewHandler(&buf, "xml", NewLevels(slog.LevelInfo)); err == nil {
+		t.Fatal("NewHandler accepted an unknown format")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warning": slog.LevelWarn, "error": slog.LevelError} {
+		got, err := ParseLevel(in)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v want %v", in, got, err, want)
+		}
+		if _, err := ParseLevel(LevelName(want)); err != nil {
+			t.Errorf("LevelName(%v) does not parse: %v", want, err)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel accepted loud")
+	}
+}
@@ -0,0 +1,299 @@
+// Package mcp serves a bridge as a Model Context Protocol server, so that
+// MCP clients such as Claude Desktop and IDEs can use it without a gRPC
+// client: sessions are listed as resources, and starting a session, sending
+// it input and stopping it are tools. Messages are JSON-RPC 2.0, one per
+// line, as in the MCP stdio transport.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+)
+
+// ProtocolVersion is the newest MCP revision the server implements. Clients
+// asking for an older supported revision get that one.
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions lists the MCP revisions the server can speak.
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC and MCP error codes.
+const (
+	codeParseError       = -32700
+	codeInvalidRequest   = -32600
+	codeMethodNotFound   = -32601
+	codeInvalidParams    = -32602
+	codeInternalError    = -32603
+	codeResourceNotFound = -32002
+)
+
+// Options configures a Server.
+type Options struct {
+	// ProjectID is the project sessions are started in and listed for.
+	ProjectID string
+	// Version is reported to clients as the server's version.
+	Version string
+	// Logger receives protocol errors. Logs are discarded when nil; never
+	// log to stdout, which carries the protocol.
+	Logger *slog.Logger
+}
+
+// Server answers MCP requests by calling a bridge.
+type Server struct {
+	client *bridgeclient.Client
+	opts   Options
+
+	outMu sync.Mutex
+	out   io.Writer
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc // by request ID
+}
+
+// NewServer returns a Server that calls the bridge through client.
+func NewServer(client *bridgeclient.Client, opts Options) *Server {
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.DiscardHandler)
+	}
+	return &Server{client: client, opts: opts, inflight: map[string]context.CancelFunc{}}
+}
+
+// rpcError is a JSON-RPC error object.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+func errorf(code int, format string, args ...any) *rpcError {
+	return &rpcError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// Serve reads requests from r and writes responses to w until r ends or ctx
+// is done. Requests are handled concurrently, so a long send_input call
+// does not hold up others. When r ends, requests still running are
+// cancelled, and Serve returns once they have finished.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.out = w
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("read request: %w", err)
+		case line := <-lines:
+			var req request
+			if err := json.Unmarshal(line, &req); err != nil {
+				s.write(response{ID: json.RawMessage("null"), Error: errorf(codeParseError, "parse error: %v", err)})
+				continue
+			}
+			if req.JSONRPC != "2.0" || req.Method == "" {
+				s.write(response{ID: nullID(req.ID), Error: errorf(codeInvalidRequest, "invalid request")})
+				continue
+			}
+			if isNotification(req.ID) {
+				s.notify(req)
+				continue
+			}
+			reqCtx, reqCancel := context.WithCancel(ctx)
+			s.track(req.ID, reqCancel)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer s.untrack(req.ID)
+				result, err := s.handle(reqCtx, req.Method, req.Params)
+				if reqCtx.Err() != nil && ctx.Err() == nil {
+					// Cancelled by the client, which expects no answer.
+					return
+				}
+				resp := response{ID: req.ID, Result: result}
+				if err != nil {
+					var re *rpcError
+					if !errors.As(err, &re) {
+						re = errorf(codeInternalError, "%v", err)
+					}
+					resp.Result, resp.Error = nil, re
+				}
+				s.write(resp)
+			}()
+		}
+	}
+}
+
+func isNotification(id json.RawMessage) bool {
+	return len(id) == 0 || string(id) == "null"
+}
+
+func nullID(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+func (s *Server) track(id json.RawMessage, cancel context.CancelFunc) {
+	s.mu.Lock()
+	s.inflight[string(id)] = cancel
+	s.mu.Unlock()
+}
+
+func (s *Server) untrack(id json.RawMessage) {
+	s.mu.Lock()
+	if cancel, ok := s.inflight[string(id)]; ok {
+		cancel()
+		delete(s.inflight, string(id))
+	}
+	s.mu.Unlock()
+}
+
+// notify handles a notification. Only cancellation needs an action.
+func (s *Server) notify(req request) {
+	if req.Method != "notifications/cancelled" {
+		return
+	}
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if err := json.Unmarshal(req.Params, &p); err != nil {
+		return
+	}
+	s.mu.Lock()
+	if cancel, ok := s.inflight[string(p.RequestID)]; ok {
+		cancel()
+	}
+	s.mu.Unlock()
+}
+
+func (s *Server) write(resp response) {
+	resp.JSONRPC = "2.0"
+	data, err := json.Marshal(resp)
+	if err != nil {
+		s.opts.Logger.Error("mcp: encode response", "error", err)
+		return
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if _, err := s.out.Write(append(data, '\n')); err != nil {
+		s.opts.Logger.Error("mcp: write response", "error", err)
+	}
+}
+
+// handle answers one request.
+func (s *Server) handle(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		version := ProtocolVersion
+		if slices.Contains(supportedVersions, p.ProtocolVersion) {
+			version = p.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities": map[string]any{
+				"tools":     map[string]any{},
+				"resources": map[string]any{},
+			},
+			"serverInfo": map[string]any{
+				"name":    "ai-agent-bridge",
+				"version": s.opts.Version,
+			},
+			"instructions": "Start AI agent sessions with start_session, prompt them with send_input and stop them with stop_session. Each session is a resource; its transcript is a second resource.",
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.callTool(ctx, p.Name, p.Arguments)
+	case "resources/list":
+		return s.listResources(ctx)
+	case "resources/templates/list":
+		return map[string]any{"resourceTemplates": resourceTemplates}, nil
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.readResource(ctx, p.URI)
+	default:
+		return nil, errorf(codeMethodNotFound, "method %q not found", method)
+	}
+}
+
+// decodeParams decodes params, which may be absent, into v.
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return errorf(codeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgetest"
+)
+
+// mcpConn is the client side of a Server under test.
+type mcpConn struct {
+	t      *testing.T
+	w      io.Writer
+	r      *bufio.Reader
+	nextID int
+}
+
+func startServer(t *testing.T) *mcpConn {
+	t.Helper()
+	b := bridgetest.New(t)
+	srv := NewServer(b.Client, Options{ProjectID: "test", Version: "test"})
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(context.Background(), inR, outW)
+		_ = outW.Close()
+	}()
+	t.Cleanup(func() {
+		_ = inW.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return &mcpConn{t: t, w: inW, r: bufio.NewReader(outR)}
+}
+
+func (c *mcpConn) send(line string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.w, line+"\n"); err != nil {
+		c.t.Fatalf("write: %v", err)
+	}
+}
+
+// call sends a request and returns its response.
+func (c *mcpConn) call(method string, params any) (result json.RawMessage, rpcErr *rpcError) {
+	c.t.Helper()
+	c.nextID++
+	data, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.send(string(data))
+	return c.recv(fmt.Sprint(c.nextID))
+}
+
+func (c *mcpConn) recv(wantID string) (json.RawMessage, *rpcError) {
+	c.t.Helper()
+	line, err := c.r.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatalf("read response: %v", err)
+	}
+	var resp struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  json.RawMessage `json:"result"`
+		Error   *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		c.t.Fatalf("decode %s: %v", line, err)
+	}
+	if resp.JSONRPC != "2.0" || string(resp.ID) != wantID {
+		c.t.Fatalf("response %s, want id %s", line, wantID)
+	}
+	return resp.Result, resp.Error
+}
+
+// callTool calls a tool and returns its result.
+func (c *mcpConn) callTool(name string, args map[string]any) toolResult {
+	c.t.Helper()
+	raw, rpcErr := c.call("tools/call", map[string]any{"name": name, "arguments": args})
+	if rpcErr != nil {
+		c.t.Fatalf("%s: %v", name, rpcErr)
+	}
+	var res toolResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		c.t.Fatalf("decode %s result: %v", name, err)
+	}
+	return res
+}
+
+func TestInitialize(t *testing.T) {
+	c := startServer(t)
+	raw, rpcErr := c.call("initialize", map[string]any{"protocolVersion": "2025-03-26", "capabilities": map[string]any{}})
+	if rpcErr != nil {
+		t.Fatalf("initialize: %v", rpcErr)
+	}
+	var res struct {
+		ProtocolVersion string                     `json:"protocolVersion"`
+		Capabilities    map[string]json.RawMessage `json:"capabilities"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.ProtocolVersion != "2025-03-26" || res.Capabilities["tools"] == nil || res.Capabilities["resources"] == nil {
+		t.Fatalf("initialize result %s", raw)
+	}
+	c.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	// An unknown revision is answered with the newest one.
+	raw, _ = c.call("initialize", map[string]any{"protocolVersion": "1999-01-01"})
+	if err := json.Unmarshal(raw, &res); err != nil || res.ProtocolVersion != ProtocolVersion {
+		t.Fatalf("initialize result %s", raw)
+	}
+
+	if _, rpcErr := c.call("sessions/frobnicate", nil); rpcErr == nil || rpcErr.Code != codeMethodNotFound {
+		t.Fatalf("unknown method error = %v", rpcErr)
+	}
+	c.send(`{not json`)
+	if _, rpcErr := c.recv("null"); rpcErr == nil || rpcErr.Code != codeParseError {
+		t.Fatalf("parse error = %v", rpcErr)
+	}
+	raw, _ = c.call("tools/list", nil)
+	for _, name := range []string{"start_session", "send_input", "stop_session"} {
+		if !strings.Contains(string(raw), `"name":"`+name+`"`) {
+			t.Fatalf("tools/list %s lacks %s", raw, name)
+		}
+	}
+}
+
+func TestSessionTools(t *testing.T) {
+	c := startServer(t)
+
+	res := c.callTool("start_session", map[string]any{"provider": bridgetest.EchoProvider, "repo_path": t.TempDir()})
+	out, _ := res.StructuredContent.(map[string]any)
+	id, _ := out["session_id"].(string)
+	if res.IsError || id == "" {
+		t.Fatalf("start_session: %+v", res)
+	}
+	uri := "bridge://sessions/" + id
+
+	res = c.callTool("send_input", map[string]any{"session_id": id, "text": "hello mcp", "idle_timeout_ms": 300})
+	if res.IsError || !strings.Contains(res.Content[0].Text, "hello mcp") {
+		t.Fatalf("send_input: %+v", res)
+	}
+
+	raw, rpcErr := c.call("resources/list", nil)
+	if rpcErr != nil {
+		t.Fatalf("resources/list: %v", rpcErr)
+	}
+	if !strings.Contains(string(raw), `"uri":"`+uri+`"`) || !strings.Contains(string(raw), `"uri":"`+uri+`/transcript"`) {
+		t.Fatalf("resources/list = %s", raw)
+	}
+	raw, rpcErr = c.call("resources/read", map[string]any{"uri": uri})
+	if rpcErr != nil || !strings.Contains(string(raw), `\"sessionId\":\"`+id+`\"`) {
+		t.Fatalf("resources/read = %s, %v", raw, rpcErr)
+	}
+	raw, rpcErr = c.call("resources/read", map[string]any{"uri": uri + "/transcript"})
+	if rpcErr != nil || !strings.Contains(string(raw), "hello mcp") {
+		t.Fatalf("resources/read transcript = %s, %v", raw, rpcErr)
+	}
+	if _, rpcErr := c.call("resources/read", map[string]any{"uri": "bridge://sessions/" + uuid.NewString()}); rpcErr == nil || rpcErr.Code != codeResourceNotFound {
+		t.Fatalf("missing resource error = %v", rpcErr)
+	}
+
+	if res := c.callTool("stop_session", map[string]any{"session_id": id, "force": true}); res.IsError {
+		t.Fatalf("stop_session: %+v", res)
+	}
+	// Bridge errors are tool results the model can read.
+	res = c.callTool("send_input", map[string]any{"session_id": uuid.NewString(), "text": "hi"})
+	if !res.IsError || !strings.Contains(res.Content[0].Text, "not found") {
+		t.Fatalf("send_input to missing session: %+v", res)
+	}
+}
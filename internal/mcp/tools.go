@@ -0,0 +1,411 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Defaults for send_input when it waits for the agent's reply.
+const (
+	defaultReplyTimeout = 5 * time.Minute
+	defaultIdleTimeout  = 3 * time.Second
+)
+
+// tool describes an MCP tool.
+type tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+var tools = []tool{
+	{
+		Name:        "start_session",
+		Description: "Start an AI agent session in a repository on the bridge host. Returns the session ID to pass to send_input and stop_session.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"provider": {"type": "string", "description": "Agent provider, e.g. claude or codex"},
+				"repo_path": {"type": "string", "description": "Absolute path of the repository the agent works in"},
+				"session_id": {"type": "string", "description": "Session UUID; generated when omitted"}
+			},
+			"required": ["provider", "repo_path"]
+		}`),
+	},
+	{
+		Name:        "send_input",
+		Description: "Send a prompt to a running session and, unless wait is false, return the agent's reply. A reply ends when the agent reports it complete, exits, or is silent for idle_timeout_ms.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"session_id": {"type": "string"},
+				"text": {"type": "string", "description": "Prompt to send"},
+				"wait": {"type": "boolean", "description": "Wait for the reply (default true)"},
+				"timeout_ms": {"type": "integer", "minimum": 0, "description": "Longest time to wait for the reply (default 300000)"},
+				"idle_timeout_ms": {"type": "integer", "minimum": 0, "description": "Silence after output that ends the reply (default 3000)"}
+			},
+			"required": ["session_id", "text"]
+		}`),
+	},
+	{
+		Name:        "stop_session",
+		Description: "Stop a session.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"session_id": {"type": "string"},
+				"force": {"type": "boolean", "description": "Kill the agent instead of stopping it gracefully"}
+			},
+			"required": ["session_id"]
+		}`),
+	},
+}
+
+// sessionURIPrefix starts the URI of every session resource.
+const sessionURIPrefix = "bridge://sessions/"
+
+var resourceTemplates = []map[string]string{
+	{
+		"uriTemplate": sessionURIPrefix + "{session_id}",
+		"name":        "session",
+		"description": "A session's status, as JSON",
+		"mimeType":    "application/json",
+	},
+	{
+		"uriTemplate": sessionURIPrefix + "{session_id}/transcript",
+		"name":        "transcript",
+		"description": "A session's transcript, as markdown",
+		"mimeType":    "text/markdown",
+	},
+}
+
+// toolResult is the result of tools/call. Failures the agent should see,
+// such as an unknown session, are results with isError set rather than
+// protocol errors.
+type toolResult struct {
+	Content           []textContent `json:"content"`
+	StructuredContent any           `json:"structuredContent,omitempty"`
+	IsError           bool          `json:"isError,omitempty"`
+}
+
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func textResult(text string, structured any) *toolResult {
+	return &toolResult{Content: []textContent{{Type: "text", Text: text}}, StructuredContent: structured}
+}
+
+func errorResult(err error) *toolResult {
+	return &toolResult{Content: []textContent{{Type: "text", Text: err.Error()}}, IsError: true}
+}
+
+// callTool runs the named tool with args.
+func (s *Server) callTool(ctx context.Context, name string, args json.RawMessage) (any, error) {
+	var (
+		res *toolResult
+		err error
+	)
+	switch name {
+	case "start_session":
+		var a struct {
+			Provider  string `json:"provider"`
+			RepoPath  string `json:"repo_path"`
+			SessionID string `json:"session_id"`
+		}
+		if err := decodeArgs(args, &a); err != nil {
+			return nil, err
+		}
+		res, err = s.startSession(ctx, a.Provider, a.RepoPath, a.SessionID)
+	case "send_input":
+		var a sendArgs
+		if err := decodeArgs(args, &a); err != nil {
+			return nil, err
+		}
+		res, err = s.sendInput(ctx, a)
+	case "stop_session":
+		var a struct {
+			SessionID string `json:"session_id"`
+			Force     bool   `json:"force"`
+		}
+		if err := decodeArgs(args, &a); err != nil {
+			return nil, err
+		}
+		res, err = s.stopSession(ctx, a.SessionID, a.Force)
+	default:
+		return nil, errorf(codeInvalidParams, "unknown tool %q", name)
+	}
+	if err != nil {
+		return errorResult(err), nil
+	}
+	return res, nil
+}
+
+func decodeArgs(args json.RawMessage, v any) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(args, v); err != nil {
+		return errorf(codeInvalidParams, "invalid arguments: %v", err)
+	}
+	return nil
+}
+
+func (s *Server) startSession(ctx context.Context, providerID, repoPath, sessionID string) (*toolResult, error) {
+	if providerID == "" || repoPath == "" {
+		return nil, errors.New("provider and repo_path are required")
+	}
+	if sessionID == "" {
+		sessionID = uuid.NewString()
+	}
+	resp, err := s.client.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId:   s.opts.ProjectID,
+		SessionId:   sessionID,
+		RepoPath:    repoPath,
+		Provider:    providerID,
+		InitialCols: 200,
+		InitialRows: 50,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("start session: %w", err)
+	}
+	out := map[string]any{"session_id": resp.SessionId, "status": statusString(resp.Status)}
+	return textResult(fmt.Sprintf("Started %s session %s.", providerID, resp.SessionId), out), nil
+}
+
+type sendArgs struct {
+	SessionID     string `json:"session_id"`
+	Text          string `json:"text"`
+	Wait          *bool  `json:"wait"`
+	TimeoutMS     int64  `json:"timeout_ms"`
+	IdleTimeoutMS int64  `json:"idle_timeout_ms"`
+}
+
+// errReplyDone ends the event stream once the reply is complete.
+var errReplyDone = errors.New("reply complete")
+
+// sendInput attaches to the session as its writer, sends the prompt, and
+// collects the reply. Only events after the prompt are read, so the
+// session's earlier output is not replayed.
+func (s *Server) sendInput(ctx context.Context, a sendArgs) (*toolResult, error) {
+	if a.SessionID == "" || a.Text == "" {
+		return nil, errors.New("session_id and text are required")
+	}
+	wait := a.Wait == nil || *a.Wait
+	timeout, idle := defaultReplyTimeout, defaultIdleTimeout
+	if a.TimeoutMS > 0 {
+		timeout = time.Duration(a.TimeoutMS) * time.Millisecond
+	}
+	if a.IdleTimeoutMS > 0 {
+		idle = time.Duration(a.IdleTimeoutMS) * time.Millisecond
+	}
+
+	info, err := s.client.GetSession(ctx, &bridgev1.GetSessionRequest{SessionId: a.SessionID})
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	stream, err := s.client.AttachSession(ctx, &bridgev1.AttachSessionRequest{
+		SessionId: a.SessionID,
+		AfterSeq:  info.LastSeq,
+		Role:      bridgev1.AttachRole_ATTACH_ROLE_WRITER,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("attach session: %w", err)
+	}
+
+	streamCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	errTimeout := errors.New("timed out waiting for the reply")
+	errIdle := errors.New("agent idle")
+	deadline := time.AfterFunc(timeout, func() { cancel(errTimeout) })
+	defer deadline.Stop()
+	var idleTimer *time.Timer
+	resetIdle := func() {
+		if idleTimer == nil {
+			idleTimer = time.AfterFunc(idle, func() { cancel(errIdle) })
+			return
+		}
+		idleTimer.Reset(idle)
+	}
+	defer func() {
+		if idleTimer != nil {
+			idleTimer.Stop()
+		}
+	}()
+
+	var (
+		ack      *bridgev1.WriteInputResponse
+		exited   bool
+		exitCode int32
+	)
+	router := &bridgeclient.EventRouter{
+		OnAttached: func(*bridgev1.AttachSessionEvent) error {
+			if ack != nil {
+				return nil
+			}
+			var err error
+			ack, err = s.client.WriteInput(streamCtx, &bridgev1.WriteInputRequest{
+				SessionId: a.SessionID,
+				ClientId:  stream.ClientID(),
+				Data:      []byte(a.Text + "\r"),
+			})
+			if err != nil {
+				return fmt.Errorf("send input: %w", err)
+			}
+			if !wait {
+				return errReplyDone
+			}
+			return nil
+		},
+		OnStdout: func([]byte) error {
+			resetIdle()
+			return nil
+		},
+		OnThinking: func(string) error {
+			resetIdle()
+			return nil
+		},
+		OnComplete: func(inputID string) error {
+			if ack != nil && inputID == ack.InputId {
+				return errReplyDone
+			}
+			return nil
+		},
+		OnTerminal: func(ev *bridgev1.AttachSessionEvent) error {
+			if ev.Type == bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ERROR {
+				return fmt.Errorf("session error: %s", ev.Error)
+			}
+			exited, exitCode = true, ev.ExitCode
+			return errReplyDone
+		},
+	}
+	err = stream.RecvAll(streamCtx, router.Handle)
+	switch cause := context.Cause(streamCtx); {
+	case errors.Is(err, errReplyDone), errors.Is(cause, errIdle):
+	case errors.Is(cause, errTimeout) && ack != nil:
+		// Return what the agent has said so far.
+	case errors.Is(cause, errTimeout):
+		return nil, errTimeout
+	case err == nil:
+		return nil, errors.New("event stream ended before the agent answered")
+	default:
+		return nil, err
+	}
+
+	out := map[string]any{"input_id": ack.InputId}
+	if !wait {
+		out["queued"] = ack.Queued
+		return textResult(fmt.Sprintf("Sent input %s.", ack.InputId), out), nil
+	}
+	reply, err := s.client.GetResponse(ctx, &bridgev1.GetResponseRequest{SessionId: a.SessionID, InputId: ack.InputId})
+	if err != nil {
+		return nil, fmt.Errorf("get response: %w", err)
+	}
+	out["text"] = reply.Text
+	out["complete"] = reply.Complete
+	if exited {
+		out["exited"] = true
+		out["exit_code"] = exitCode
+	}
+	return textResult(reply.Text, out), nil
+}
+
+func (s *Server) stopSession(ctx context.Context, sessionID string, force bool) (*toolResult, error) {
+	if sessionID == "" {
+		return nil, errors.New("session_id is required")
+	}
+	resp, err := s.client.StopSession(ctx, &bridgev1.StopSessionRequest{SessionId: sessionID, Force: force})
+	if err != nil {
+		return nil, fmt.Errorf("stop session: %w", err)
+	}
+	out := map[string]any{"session_id": sessionID, "status": statusString(resp.Status)}
+	return textResult(fmt.Sprintf("Stopped session %s.", sessionID), out), nil
+}
+
+// listResources lists two resources per session of the project: its status
+// and its transcript.
+func (s *Server) listResources(ctx context.Context) (any, error) {
+	resp, err := s.client.ListSessions(ctx, &bridgev1.ListSessionsRequest{ProjectId: s.opts.ProjectID})
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	resources := make([]map[string]string, 0, 2*len(resp.Sessions))
+	for _, info := range resp.Sessions {
+		uri := sessionURIPrefix + info.SessionId
+		resources = append(resources,
+			map[string]string{
+				"uri":         uri,
+				"name":        info.SessionId,
+				"description": fmt.Sprintf("%s session, %s", info.Provider, statusString(info.Status)),
+				"mimeType":    "application/json",
+			},
+			map[string]string{
+				"uri":         uri + "/transcript",
+				"name":        info.SessionId + " transcript",
+				"description": fmt.Sprintf("Transcript of the %s session", info.Provider),
+				"mimeType":    "text/markdown",
+			},
+		)
+	}
+	return map[string]any{"resources": resources}, nil
+}
+
+// readResource returns the session status or transcript named by uri.
+func (s *Server) readResource(ctx context.Context, uri string) (any, error) {
+	rest, ok := strings.CutPrefix(uri, sessionURIPrefix)
+	if !ok || rest == "" {
+		return nil, errorf(codeResourceNotFound, "resource %q not found", uri)
+	}
+	sessionID, transcript := strings.CutSuffix(rest, "/transcript")
+	if strings.Contains(sessionID, "/") {
+		return nil, errorf(codeResourceNotFound, "resource %q not found", uri)
+	}
+	content := map[string]string{"uri": uri}
+	if transcript {
+		resp, err := s.client.ExportTranscript(ctx, &bridgev1.ExportTranscriptRequest{
+			SessionId: sessionID,
+			Format:    bridgev1.TranscriptFormat_TRANSCRIPT_FORMAT_MARKDOWN,
+		})
+		if err != nil {
+			return nil, resourceError(uri, err)
+		}
+		content["mimeType"] = "text/markdown"
+		content["text"] = string(resp.Content)
+	} else {
+		resp, err := s.client.GetSession(ctx, &bridgev1.GetSessionRequest{SessionId: sessionID})
+		if err != nil {
+			return nil, resourceError(uri, err)
+		}
+		data, err := protojson.Marshal(resp)
+		if err != nil {
+			return nil, err
+		}
+		content["mimeType"] = "application/json"
+		content["text"] = string(data)
+	}
+	return map[string]any{"contents": []map[string]string{content}}, nil
+}
+
+// resourceError reports an unknown session as a missing resource.
+func resourceError(uri string, err error) error {
+	if errors.Is(err, bridgeclient.ErrSessionNotFound) {
+		return errorf(codeResourceNotFound, "resource %q not found", uri)
+	}
+	return err
+}
+
+// statusString returns a session status without its enum prefix, e.g.
+// "running".
+func statusString(s bridgev1.SessionStatus) string {
+	return strings.ToLower(strings.TrimPrefix(s.String(), "SESSION_STATUS_"))
+}
@@ -1,6 +1,7 @@
 package pki
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -15,8 +16,9 @@ import (
 )
 
 const (
-	caValidityYears  = 10
-	certValidityDays = 90
+	caValidityYears           = 10
+	intermediateValidityYears = 5
+	certValidityDays          = 90
 )
 
 // InitCA generates a new ECDSA P-384 CA keypair and self-signed certificate.
@@ -73,8 +75,86 @@ func InitCA(name, outDir string) (certPath, keyPath string, err error) {
 	return certPath, keyPath, nil
 }
 
-// LoadCA loads a CA certificate and private key from PEM files.
+// InitIntermediateCA generates an ECDSA P-384 intermediate CA keypair and a
+// certificate for it signed by the root CA. The intermediate issues leaf
+// certificates only, and expires no later than the root.
+func InitIntermediateCA(rootCert *x509.Certificate, rootKey *ecdsa.PrivateKey, name, outDir string) (certPath, keyPath string, err error) {
+	if !rootCert.IsCA || rootCert.MaxPathLenZero {
+		return "", "", fmt.Errorf("%q cannot sign intermediate CAs", rootCert.Subject.CommonName)
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("generate intermediate key: %w", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	notAfter := now.AddDate(intermediateValidityYears, 0, 0)
+	if notAfter.After(rootCert.NotAfter) {
+		notAfter = rootCert.NotAfter
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   name,
+			Organization: rootCert.Subject.Organization,
+		},
+		NotBefore:             now,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            0,
+		MaxPathLenZero:        true,
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, rootCert, &priv.PublicKey, rootKey)
+	if err != nil {
+		return "", "", fmt.Errorf("create intermediate cert: %w", err)
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return "", "", fmt.Errorf("mkdir %s: %w", outDir, err)
+	}
+
+	certPath = filepath.Join(outDir, "intermediate.crt")
+	keyPath = filepath.Join(outDir, "intermediate.key")
+
+	if err := writePEM(certPath, "CERTIFICATE", certDER, 0o644); err != nil {
+		return "", "", err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return "", "", fmt.Errorf("marshal intermediate key: %w", err)
+	}
+	if err := writePEM(keyPath, "EC PRIVATE KEY", keyDER, 0o600); err != nil {
+		return "", "", err
+	}
+
+	return certPath, keyPath, nil
+}
+
+// IsRootCA reports whether cert is self-signed, i.e. a trust anchor rather
+// than an intermediate.
+func IsRootCA(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// LoadCA loads a CA certificate and private key from PEM files. An
+// encrypted key is decrypted with the passphrase from EnvPassphrase.
 func LoadCA(certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	return LoadCAWithPassphrase(certPath, keyPath, nil)
+}
+
+// LoadCAWithPassphrase is LoadCA with the passphrase of an encrypted key
+// from pass.
+func LoadCAWithPassphrase(certPath, keyPath string, pass Passphrase) (*x509.Certificate, *ecdsa.PrivateKey, error) {
 	cert, err := LoadCert(certPath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("load ca cert: %w", err)
@@ -85,15 +165,14 @@ func LoadCA(certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, err
 		return nil, nil, fmt.Errorf("read ca key: %w", err)
 	}
 
-	block, _ := pem.Decode(keyPEM)
-	if block == nil {
-		return nil, nil, fmt.Errorf("decode ca key pem: no block found")
-	}
-
-	key, err := x509.ParseECPrivateKey(block.Bytes)
+	signer, err := ParsePrivateKeyPEM(keyPath, keyPEM, pass)
 	if err != nil {
 		return nil, nil, fmt.Errorf("parse ca key: %w", err)
 	}
+	key, ok := signer.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("parse ca key: key is not ecdsa")
+	}
 
 	return cert, key, nil
 }
@@ -118,6 +197,31 @@ func LoadCert(path string) (*x509.Certificate, error) {
 	return cert, nil
 }
 
+// LoadCertChain loads every certificate in a PEM file, leaf first, such as
+// a fullchain file.
+func LoadCertChain(path string) ([]*x509.Certificate, error) {
+	certPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cert: %w", err)
+	}
+
+	var chain []*x509.Certificate
+	for block, rest := pem.Decode(certPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse cert: %w", err)
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("decode cert pem: no certificate found")
+	}
+	return chain, nil
+}
+
 func randomSerial() (*big.Int, error) {
 	max := new(big.Int).Lsh(big.NewInt(1), 128)
 	serial, err := rand.Int(rand.Reader, max)
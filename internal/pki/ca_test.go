@@ -1,6 +1,7 @@
 package pki
 
 import (
+	"crypto/tls"
 	"crypto/x509"
 	"os"
 	"path/filepath"
@@ -70,6 +71,68 @@ func TestIssueCert(t *testing.T) {
 	}
 }
 
+func TestIntermediateCA(t *testing.T) {
+	dir := t.TempDir()
+	rootPath, rootKeyPath, err := InitCA("root-ca", dir)
+	if err != nil {
+		t.Fatalf("InitCA: %v", err)
+	}
+	root, rootKey, err := LoadCA(rootPath, rootKeyPath)
+	if err != nil {
+		t.Fatalf("LoadCA: %v", err)
+	}
+
+	interPath, interKeyPath, err := InitIntermediateCA(root, rootKey, "issuing-ca", dir)
+	if err != nil {
+		t.Fatalf("InitIntermediateCA: %v", err)
+	}
+	inter, interKey, err := LoadCA(interPath, interKeyPath)
+	if err != nil {
+		t.Fatalf("LoadCA intermediate: %v", err)
+	}
+	if !IsRootCA(root) || IsRootCA(inter) {
+		t.Fatalf("IsRootCA: root=%v intermediate=%v", IsRootCA(root), IsRootCA(inter))
+	}
+	if inter.NotAfter.After(root.NotAfter) {
+		t.Errorf("intermediate outlives root: %v > %v", inter.NotAfter, root.NotAfter)
+	}
+	if _, _, err := InitIntermediateCA(inter, interKey, "nested-ca", t.TempDir()); err == nil {
+		t.Error("intermediate signed another intermediate")
+	}
+
+	certPath, keyPath, err := IssueCert(inter, interKey, CertTypeServer, "bridge.local", []string{"bridge.local"}, dir)
+	if err != nil {
+		t.Fatalf("IssueCert: %v", err)
+	}
+	chain, err := LoadCertChain(FullchainPath(certPath))
+	if err != nil {
+		t.Fatalf("LoadCertChain: %v", err)
+	}
+	if len(chain) != 2 || chain[0].Subject.CommonName != "bridge.local" || !chain[1].Equal(inter) {
+		t.Fatalf("fullchain = %d certs", len(chain))
+	}
+	if _, err := tls.LoadX509KeyPair(FullchainPath(certPath), keyPath); err != nil {
+		t.Fatalf("LoadX509KeyPair fullchain: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(chain[1])
+	if _, err := chain[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+		t.Errorf("chain verification failed: %v", err)
+	}
+
+	// Certificates issued by the root need no chain file.
+	rootIssued, _, err := IssueCert(root, rootKey, CertTypeClient, "client", nil, dir)
+	if err != nil {
+		t.Fatalf("IssueCert: %v", err)
+	}
+	if _, err := os.Stat(FullchainPath(rootIssued)); !os.IsNotExist(err) {
+		t.Errorf("root-issued fullchain: err=%v", err)
+	}
+}
+
 func TestCrossSign(t *testing.T) {
 	dirA := t.TempDir()
 	dirB := t.TempDir()
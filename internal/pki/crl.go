@@ -0,0 +1,133 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// crlValidityDays is how far ahead a CRL's NextUpdate is set. The bridge
+// does not reject a CRL past NextUpdate; it is informational for other
+// relying parties.
+const crlValidityDays = 30
+
+// RevokeCert adds serial to the CRL at crlPath, creating the CRL if it does
+// not exist, and re-signs it with the CA. Revoking an already revoked
+// serial only refreshes the CRL. The file is replaced atomically so a
+// server polling it never reads a partial CRL.
+func RevokeCert(caCert *x509.Certificate, caKey *ecdsa.PrivateKey, crlPath string, serial *big.Int, reason int) error {
+	var entries []x509.RevocationListEntry
+	number := big.NewInt(1)
+	existing, err := LoadCRL(crlPath)
+	switch {
+	case err == nil:
+		if err := existing.CheckSignatureFrom(caCert); err != nil {
+			return fmt.Errorf("existing crl %s was not issued by this CA: %w", crlPath, err)
+		}
+		entries = existing.RevokedCertificateEntries
+		number.Add(existing.Number, big.NewInt(1))
+	case errors.Is(err, os.ErrNotExist):
+	default:
+		return err
+	}
+
+	now := time.Now()
+	revoked := false
+	for _, e := range entries {
+		if e.SerialNumber.Cmp(serial) == 0 {
+			revoked = true
+			break
+		}
+	}
+	if !revoked {
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: now,
+			ReasonCode:     reason,
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    number,
+		ThisUpdate:                now,
+		NextUpdate:                now.AddDate(0, 0, crlValidityDays),
+		RevokedCertificateEntries: entries,
+	}, caCert, caKey)
+	if err != nil {
+		return fmt.Errorf("create crl: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(crlPath), ".crl-*")
+	if err != nil {
+		return fmt.Errorf("create crl: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err := pem.Encode(tmp, &pem.Block{Type: "X509 CRL", Bytes: der}); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write crl: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write crl: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write crl: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), crlPath); err != nil {
+		return fmt.Errorf("write crl: %w", err)
+	}
+	return nil
+}
+
+// LoadCRL loads a single PEM or DER encoded CRL.
+func LoadCRL(path string) (*x509.RevocationList, error) {
+	crls, err := LoadCRLs(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(crls) != 1 {
+		return nil, fmt.Errorf("crl %s: want 1 CRL, found %d", path, len(crls))
+	}
+	return crls[0], nil
+}
+
+// LoadCRLs loads every CRL in a file: one or more PEM "X509 CRL" blocks, or
+// a single DER CRL. Signatures are not checked.
+func LoadCRLs(path string) ([]*x509.RevocationList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read crl: %w", err)
+	}
+	var crls []*x509.RevocationList
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "X509 CRL" {
+			continue
+		}
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse crl %s: %w", path, err)
+		}
+		crls = append(crls, crl)
+	}
+	if len(crls) == 0 {
+		crl, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse crl %s: %w", path, err)
+		}
+		crls = append(crls, crl)
+	}
+	return crls, nil
+}
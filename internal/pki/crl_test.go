@@ -0,0 +1,69 @@
+package pki
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRevokeCert(t *testing.T) {
+	dir := t.TempDir()
+	caCertPath, caKeyPath, err := InitCA("test-ca", dir)
+	if err != nil {
+		t.Fatalf("InitCA: %v", err)
+	}
+	caCert, caKey, err := LoadCA(caCertPath, caKeyPath)
+	if err != nil {
+		t.Fatalf("LoadCA: %v", err)
+	}
+	crlPath := filepath.Join(dir, "ca.crl")
+
+	if err := RevokeCert(caCert, caKey, crlPath, big.NewInt(100), 1); err != nil {
+		t.Fatalf("RevokeCert first: %v", err)
+	}
+	if err := RevokeCert(caCert, caKey, crlPath, big.NewInt(200), 0); err != nil {
+		t.Fatalf("RevokeCert second: %v", err)
+	}
+	if err := RevokeCert(caCert, caKey, crlPath, big.NewInt(100), 1); err != nil {
+		t.Fatalf("RevokeCert repeat: %v", err)
+	}
+
+	crl, err := LoadCRL(crlPath)
+	if err != nil {
+		t.Fatalf("LoadCRL: %v", err)
+	}
+	if err := crl.CheckSignatureFrom(caCert); err != nil {
+		t.Fatalf("CRL signature: %v", err)
+	}
+	if crl.Number.Int64() != 3 {
+		t.Errorf("CRL number=%d want 3", crl.Number)
+	}
+	if n := len(crl.RevokedCertificateEntries); n != 2 {
+		t.Fatalf("revoked entries=%d want 2", n)
+	}
+	if crl.RevokedCertificateEntries[0].ReasonCode != 1 {
+		t.Errorf("reason=%d want key compromise", crl.RevokedCertificateEntries[0].ReasonCode)
+	}
+
+	// A CRL from another CA is never overwritten.
+	otherDir := t.TempDir()
+	otherCertPath, otherKeyPath, err := InitCA("other-ca", otherDir)
+	if err != nil {
+		t.Fatalf("InitCA other: %v", err)
+	}
+	otherCert, otherKey, err := LoadCA(otherCertPath, otherKeyPath)
+	if err != nil {
+		t.Fatalf("LoadCA other: %v", err)
+	}
+	if err := RevokeCert(otherCert, otherKey, crlPath, big.NewInt(300), 0); err == nil {
+		t.Fatal("RevokeCert updated a CRL issued by a different CA")
+	}
+
+	if err := os.WriteFile(crlPath, []byte("garbage"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := LoadCRL(crlPath); err == nil {
+		t.Fatal("LoadCRL accepted garbage")
+	}
+}
@@ -1,11 +1,13 @@
 package pki
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"net"
 	"os"
@@ -24,21 +26,75 @@ const (
 
 // IssueCert generates a new ECDSA P-384 keypair and certificate signed by the given CA.
 func IssueCert(caCert *x509.Certificate, caKey *ecdsa.PrivateKey, ct CertType, cn string, sans []string, outDir string) (certPath, keyPath string, err error) {
+	return IssueCertOU(caCert, caKey, ct, cn, nil, sans, outDir)
+}
+
+// IssueCertOU is IssueCert with organizational units in the subject, such
+// as the OU that makes a client an AdminService caller. When caCert is an
+// intermediate, the certificate followed by caCert is also written to
+// FullchainPath(certPath), for servers and clients to present.
+func IssueCertOU(caCert *x509.Certificate, caKey *ecdsa.PrivateKey, ct CertType, cn string, ous, sans []string, outDir string) (certPath, keyPath string, err error) {
 	priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
 	if err != nil {
 		return "", "", fmt.Errorf("generate key: %w", err)
 	}
 
-	serial, err := randomSerial()
+	certDER, err := SignCert(caCert, caKey, ct, cn, ous, sans, &priv.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return "", "", fmt.Errorf("mkdir %s: %w", outDir, err)
+	}
+
+	baseName := strings.ReplaceAll(cn, " ", "-")
+	certPath = filepath.Join(outDir, baseName+".crt")
+	keyPath = filepath.Join(outDir, baseName+".key")
+
+	if err := writePEM(certPath, "CERTIFICATE", certDER, 0o644); err != nil {
+		return "", "", err
+	}
+	if !IsRootCA(caCert) {
+		chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+		chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw})...)
+		if err := os.WriteFile(FullchainPath(certPath), chain, 0o644); err != nil {
+			return "", "", fmt.Errorf("write fullchain: %w", err)
+		}
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(priv)
 	if err != nil {
+		return "", "", fmt.Errorf("marshal key: %w", err)
+	}
+	if err := writePEM(keyPath, "EC PRIVATE KEY", keyDER, 0o600); err != nil {
 		return "", "", err
 	}
 
+	return certPath, keyPath, nil
+}
+
+// FullchainPath is where IssueCertOU writes the chain of the certificate at
+// certPath: bridge.local.crt becomes bridge.local-fullchain.crt.
+func FullchainPath(certPath string) string {
+	return strings.TrimSuffix(certPath, ".crt") + "-fullchain.crt"
+}
+
+// SignCert issues a certificate for a public key whose private key stays
+// with its owner, such as the key of a certificate signing request, and
+// returns it DER encoded.
+func SignCert(caCert *x509.Certificate, caKey *ecdsa.PrivateKey, ct CertType, cn string, ous, sans []string, pub crypto.PublicKey) ([]byte, error) {
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	tmpl := &x509.Certificate{
 		SerialNumber: serial,
 		Subject: pkix.Name{
-			CommonName: cn,
+			CommonName:         cn,
+			OrganizationalUnit: ous,
 		},
 		NotBefore: now,
 		NotAfter:  now.AddDate(0, 0, certValidityDays),
@@ -65,30 +121,9 @@ func IssueCert(caCert *x509.Certificate, caKey *ecdsa.PrivateKey, ct CertType, c
 		}
 	}
 
-	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &priv.PublicKey, caKey)
-	if err != nil {
-		return "", "", fmt.Errorf("create cert: %w", err)
-	}
-
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
-		return "", "", fmt.Errorf("mkdir %s: %w", outDir, err)
-	}
-
-	baseName := strings.ReplaceAll(cn, " ", "-")
-	certPath = filepath.Join(outDir, baseName+".crt")
-	keyPath = filepath.Join(outDir, baseName+".key")
-
-	if err := writePEM(certPath, "CERTIFICATE", certDER, 0o644); err != nil {
-		return "", "", err
-	}
-
-	keyDER, err := x509.MarshalECPrivateKey(priv)
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, pub, caKey)
 	if err != nil {
-		return "", "", fmt.Errorf("marshal key: %w", err)
+		return nil, fmt.Errorf("create cert: %w", err)
 	}
-	if err := writePEM(keyPath, "EC PRIVATE KEY", keyDER, 0o600); err != nil {
-		return "", "", err
-	}
-
-	return certPath, keyPath, nil
+	return certDER, nil
 }
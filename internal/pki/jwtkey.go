@@ -45,17 +45,20 @@ func GenerateJWTKeypair(outDir, baseName string) (pubPath, privPath string, err
 	return pubPath, privPath, nil
 }
 
-// LoadEd25519PrivateKey loads an Ed25519 private key from a PEM file.
+// LoadEd25519PrivateKey loads an Ed25519 private key from a PEM file. An
+// encrypted key is decrypted with the passphrase from EnvPassphrase.
 func LoadEd25519PrivateKey(path string) (ed25519.PrivateKey, error) {
+	return LoadEd25519PrivateKeyWithPassphrase(path, nil)
+}
+
+// LoadEd25519PrivateKeyWithPassphrase is LoadEd25519PrivateKey with the
+// passphrase of an encrypted key from pass.
+func LoadEd25519PrivateKeyWithPassphrase(path string, pass Passphrase) (ed25519.PrivateKey, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read key: %w", err)
 	}
-	block, _ := pem.Decode(data)
-	if block == nil {
-		return nil, fmt.Errorf("decode pem: no block found")
-	}
-	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	key, err := ParsePrivateKeyPEM(path, data, pass)
 	if err != nil {
 		return nil, fmt.Errorf("parse key: %w", err)
 	}
@@ -0,0 +1,196 @@
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Environment variables that supply the passphrase of encrypted private
+// keys to EnvPassphrase.
+const (
+	PassphraseEnv     = "BRIDGE_KEY_PASSPHRASE"
+	PassphraseFileEnv = "BRIDGE_KEY_PASSPHRASE_FILE"
+)
+
+var (
+	// ErrPassphraseRequired is returned for an encrypted key when no
+	// passphrase is available.
+	ErrPassphraseRequired = errors.New("passphrase required")
+	// ErrIncorrectPassphrase is returned when a key does not decrypt with
+	// the passphrase given.
+	ErrIncorrectPassphrase = errors.New("incorrect passphrase")
+)
+
+// Passphrase returns the passphrase of the encrypted private key at
+// keyPath. It is called only for encrypted keys.
+type Passphrase func(keyPath string) ([]byte, error)
+
+// EnvPassphrase reads the passphrase from BRIDGE_KEY_PASSPHRASE, or else
+// from the file BRIDGE_KEY_PASSPHRASE_FILE names, without its trailing
+// newline. It is the Passphrase used when none is given.
+func EnvPassphrase(keyPath string) ([]byte, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return []byte(p), nil
+	}
+	if path := os.Getenv(PassphraseFileEnv); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read passphrase file: %w", err)
+		}
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+	return nil, fmt.Errorf("%w: %s is encrypted; set %s or %s", ErrPassphraseRequired, keyPath, PassphraseEnv, PassphraseFileEnv)
+}
+
+// PromptPassphrase is EnvPassphrase for interactive commands: with neither
+// variable set and a terminal on stdin, it asks for the passphrase there.
+func PromptPassphrase(keyPath string) ([]byte, error) {
+	p, err := EnvPassphrase(keyPath)
+	if !errors.Is(err, ErrPassphraseRequired) || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return p, err
+	}
+	fmt.Fprintf(os.Stderr, "Passphrase for %s: ", keyPath)
+	p, err = term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("read passphrase: %w", err)
+	}
+	return p, nil
+}
+
+// ParsePrivateKeyPEM parses the private key in the first PEM block of
+// data: PKCS#8, encrypted PKCS#8, SEC 1 (EC) or PKCS#1 (RSA). For an
+// encrypted key the passphrase comes from pass, or EnvPassphrase when pass
+// is nil; keyPath is passed to it and names the key in errors.
+func ParsePrivateKeyPEM(keyPath string, data []byte, pass Passphrase) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("decode pem: no block found")
+	}
+	der := block.Bytes
+	switch {
+	case block.Headers["Proc-Type"] == "4,ENCRYPTED":
+		return nil, fmt.Errorf("%s uses legacy PEM encryption; convert it with `openssl pkcs8 -topk8`", keyPath)
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		if pass == nil {
+			pass = EnvPassphrase
+		}
+		passphrase, err := pass(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		if der, err = decryptPKCS8(der, passphrase); err != nil {
+			return nil, fmt.Errorf("decrypt %s: %w", keyPath, err)
+		}
+	case block.Type == "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(der)
+	case block.Type == "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(der)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		if block.Type == "ENCRYPTED PRIVATE KEY" {
+			return nil, fmt.Errorf("decrypt %s: %w", keyPath, ErrIncorrectPassphrase)
+		}
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// LoadPrivateKey loads a private key from a PEM file, as
+// ParsePrivateKeyPEM parses it.
+func LoadPrivateKey(path string, pass Passphrase) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key: %w", err)
+	}
+	return ParsePrivateKeyPEM(path, data, pass)
+}
+
+// LoadX509KeyPair is tls.LoadX509KeyPair that also reads encrypted
+// PKCS#8 keys, with the passphrase from pass or EnvPassphrase, and keys in
+// a hardware token named by a PKCS#11 URI in keyPath.
+func LoadX509KeyPair(certPath, keyPath string, pass Passphrase) (tls.Certificate, error) {
+	if IsPKCS11URI(keyPath) {
+		return loadTokenKeyPair(certPath, keyPath)
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if block, _ := pem.Decode(keyPEM); block == nil || block.Type != "ENCRYPTED PRIVATE KEY" {
+		return tls.LoadX509KeyPair(certPath, keyPath)
+	}
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	key, err := ParsePrivateKeyPEM(keyPath, keyPEM, pass)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// loadTokenKeyPair pairs the certificate chain at certPath with the token
+// key at keyURI.
+func loadTokenKeyPair(certPath, keyURI string) (tls.Certificate, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	var cert tls.Certificate
+	for block, rest := pem.Decode(certPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, block.Bytes)
+		}
+	}
+	if len(cert.Certificate) == 0 {
+		return tls.Certificate{}, fmt.Errorf("no certificate in %s", certPath)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return tls.Certificate{}, fmt.Errorf("parse cert: %w", err)
+	}
+	signer, err := LoadSigner(keyURI, nil)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.Leaf.PublicKey) {
+		return tls.Certificate{}, errors.New("private key does not match public key")
+	}
+	cert.PrivateKey = signer
+	return cert, nil
+}
+
+// WritePrivateKey writes key to path as PKCS#8, encrypted with passphrase
+// unless it is empty.
+func WritePrivateKey(path string, key crypto.Signer, passphrase []byte) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("marshal key: %w", err)
+	}
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	if len(passphrase) > 0 {
+		if block, err = EncryptPKCS8(der, passphrase); err != nil {
+			return fmt.Errorf("encrypt key: %w", err)
+		}
+	}
+	return writePEM(path, block.Type, block.Bytes, 0o600)
+}
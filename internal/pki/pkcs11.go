@@ -0,0 +1,188 @@
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PKCS11PINEnv holds the user PIN of PKCS#11 tokens whose URI gives
+// neither pin-value nor pin-source.
+const PKCS11PINEnv = "BRIDGE_PKCS11_PIN"
+
+// pkcs11URI is a PKCS#11 URI (RFC 7512) naming a private key in a token,
+// for example
+//
+//	pkcs11:token=bridge;object=server?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=/etc/bridge/pin
+//
+// HSMs are reached through their vendor's module, TPMs through
+// tpm2-pkcs11 and YubiKeys through ykcs11.
+type pkcs11URI struct {
+	module    string // module-path
+	token     string // token label
+	serial    string // token serial number
+	slot      int    // slot-id; -1 when not given
+	object    string // key label (CKA_LABEL)
+	id        []byte // key ID (CKA_ID)
+	pin       string // pin-value
+	pinSource string // pin-source
+}
+
+// IsPKCS11URI reports whether a key reference is a PKCS#11 URI rather than
+// a file path.
+func IsPKCS11URI(ref string) bool {
+	return strings.HasPrefix(ref, "pkcs11:")
+}
+
+func parsePKCS11URI(ref string) (*pkcs11URI, error) {
+	rest, ok := strings.CutPrefix(ref, "pkcs11:")
+	if !ok {
+		return nil, fmt.Errorf("pkcs11 uri must start with pkcs11:")
+	}
+	u := &pkcs11URI{slot: -1}
+	path, query, _ := strings.Cut(rest, "?")
+	attrs := func(s, sep string, set func(name, value string) error) error {
+		for _, attr := range strings.Split(s, sep) {
+			if attr == "" {
+				continue
+			}
+			name, raw, ok := strings.Cut(attr, "=")
+			if !ok {
+				return fmt.Errorf("pkcs11 uri attribute %q has no value", name)
+			}
+			value, err := url.PathUnescape(raw)
+			if err != nil {
+				return fmt.Errorf("pkcs11 uri attribute %s: %w", name, err)
+			}
+			if err := set(name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := attrs(path, ";", func(name, value string) error {
+		switch name {
+		case "token":
+			u.token = value
+		case "serial":
+			u.serial = value
+		case "slot-id":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("pkcs11 uri slot-id %q is not a slot number", value)
+			}
+			u.slot = n
+		case "object":
+			u.object = value
+		case "id":
+			u.id = []byte(value)
+		case "type":
+			if value != "private" {
+				return fmt.Errorf("pkcs11 uri type %q: only private keys are used", value)
+			}
+		}
+		// Other attributes, such as manufacturer and model, do not narrow
+		// the search further.
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = attrs(query, "&", func(name, value string) error {
+		switch name {
+		case "module-path":
+			u.module = value
+		case "pin-value":
+			u.pin = value
+		case "pin-source":
+			u.pinSource = strings.TrimPrefix(value, "file:")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if u.module == "" {
+		return nil, fmt.Errorf("pkcs11 uri needs module-path")
+	}
+	if u.token == "" && u.serial == "" && u.slot < 0 {
+		return nil, fmt.Errorf("pkcs11 uri needs token, serial or slot-id")
+	}
+	if u.object == "" && len(u.id) == 0 {
+		return nil, fmt.Errorf("pkcs11 uri needs object or id")
+	}
+	return u, nil
+}
+
+// String names the key in errors and logs, without its PIN.
+func (u *pkcs11URI) String() string {
+	var b strings.Builder
+	b.WriteString("pkcs11:")
+	var attrs []string
+	if u.token != "" {
+		attrs = append(attrs, "token="+url.PathEscape(u.token))
+	}
+	if u.serial != "" {
+		attrs = append(attrs, "serial="+url.PathEscape(u.serial))
+	}
+	if u.slot >= 0 {
+		attrs = append(attrs, "slot-id="+strconv.Itoa(u.slot))
+	}
+	if u.object != "" {
+		attrs = append(attrs, "object="+url.PathEscape(u.object))
+	}
+	if len(u.id) > 0 {
+		attrs = append(attrs, "id="+url.PathEscape(string(u.id)))
+	}
+	b.WriteString(strings.Join(attrs, ";"))
+	return b.String()
+}
+
+// readPIN returns the user PIN: pin-value, the contents of pin-source, or
+// BRIDGE_PKCS11_PIN. Empty means the token needs no login.
+func (u *pkcs11URI) readPIN() (string, error) {
+	if u.pin != "" {
+		return u.pin, nil
+	}
+	if u.pinSource != "" {
+		data, err := os.ReadFile(u.pinSource)
+		if err != nil {
+			return "", fmt.Errorf("read pkcs11 pin-source: %w", err)
+		}
+		return string(bytes.TrimRight(data, "\r\n")), nil
+	}
+	return os.Getenv(PKCS11PINEnv), nil
+}
+
+// LoadSigner returns the private key ref names: a key in a hardware token
+// given by a PKCS#11 URI, or a PEM file read as LoadPrivateKey reads it.
+// Token keys never leave the token; the signer asks it to sign.
+func LoadSigner(ref string, pass Passphrase) (crypto.Signer, error) {
+	if !IsPKCS11URI(ref) {
+		return LoadPrivateKey(ref, pass)
+	}
+	u, err := parsePKCS11URI(ref)
+	if err != nil {
+		return nil, err
+	}
+	return openPKCS11Signer(u)
+}
+
+// LoadJWTSigner is LoadSigner for a JWT signing key, which must be
+// Ed25519.
+func LoadJWTSigner(ref string, pass Passphrase) (crypto.Signer, error) {
+	key, err := LoadSigner(ref, pass)
+	if err != nil {
+		return nil, fmt.Errorf("load key: %w", err)
+	}
+	if _, ok := key.Public().(ed25519.PublicKey); !ok {
+		return nil, errors.New("key is not ed25519")
+	}
+	return key, nil
+}
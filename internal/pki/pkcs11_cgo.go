@@ -0,0 +1,343 @@
+//go:build cgo
+
+package pki
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// PKCS#11 3.0 Edwards-curve key type and mechanism, which the bindings
+// predate.
+const (
+	ckkECEdwards = 0x40
+	ckmEdDSA     = 0x1057
+)
+
+var oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+// DigestInfo prefixes of PKCS#1 v1.5 signatures, which CKM_RSA_PKCS
+// expects the caller to add.
+var pkcs1Prefixes = map[crypto.Hash][]byte{
+	crypto.SHA1:   {0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// pssParams maps a hash to the PKCS#11 hash and MGF of RSA-PSS.
+var pssParams = map[crypto.Hash][2]uint{
+	crypto.SHA256: {pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256},
+	crypto.SHA384: {pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384},
+	crypto.SHA512: {pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512},
+}
+
+// A module is loaded and initialized once and stays loaded, and a key is
+// opened once, so that certificate reloads reuse its session.
+var pkcs11State struct {
+	sync.Mutex
+	modules map[string]*pkcs11.Ctx
+	signers map[string]*pkcs11Signer
+}
+
+func openPKCS11Signer(u *pkcs11URI) (crypto.Signer, error) {
+	pkcs11State.Lock()
+	defer pkcs11State.Unlock()
+	key := u.module + "\x00" + u.String()
+	if s := pkcs11State.signers[key]; s != nil {
+		return s, nil
+	}
+	ctx := pkcs11State.modules[u.module]
+	if ctx == nil {
+		ctx = pkcs11.New(u.module)
+		if ctx == nil {
+			return nil, fmt.Errorf("load pkcs11 module %s", u.module)
+		}
+		if err := ctx.Initialize(); err != nil && !isCKR(err, pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+			ctx.Destroy()
+			return nil, fmt.Errorf("initialize pkcs11 module %s: %w", u.module, err)
+		}
+		if pkcs11State.modules == nil {
+			pkcs11State.modules = make(map[string]*pkcs11.Ctx)
+			pkcs11State.signers = make(map[string]*pkcs11Signer)
+		}
+		pkcs11State.modules[u.module] = ctx
+	}
+	pin, err := u.readPIN()
+	if err != nil {
+		return nil, err
+	}
+	s := &pkcs11Signer{ctx: ctx, uri: u, pin: pin}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	pkcs11State.signers[key] = s
+	return s, nil
+}
+
+// pkcs11Signer signs with a private key held in a PKCS#11 token. Its
+// session signs one message at a time.
+type pkcs11Signer struct {
+	ctx *pkcs11.Ctx
+	uri *pkcs11URI
+	pin string
+
+	mu      sync.Mutex
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	keyType uint
+	pub     crypto.PublicKey
+}
+
+func isCKR(err error, code uint) bool {
+	var e pkcs11.Error
+	return errors.As(err, &e) && uint(e) == code
+}
+
+// open opens a session on the key's token, logs in and finds the key.
+func (s *pkcs11Signer) open() error {
+	slot, err := s.findSlot()
+	if err != nil {
+		return err
+	}
+	session, err := s.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("open pkcs11 session: %w", err)
+	}
+	if s.pin != "" {
+		if err := s.ctx.Login(session, pkcs11.CKU_USER, s.pin); err != nil && !isCKR(err, pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+			_ = s.ctx.CloseSession(session)
+			return fmt.Errorf("log in to pkcs11 token: %w", err)
+		}
+	}
+	key, err := s.findObject(session, pkcs11.CKO_PRIVATE_KEY)
+	if err == nil && s.pub == nil {
+		s.keyType, s.pub, err = s.publicKey(session)
+	}
+	if err != nil {
+		_ = s.ctx.CloseSession(session)
+		return err
+	}
+	s.session, s.key = session, key
+	return nil
+}
+
+func (s *pkcs11Signer) findSlot() (uint, error) {
+	slots, err := s.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("list pkcs11 slots: %w", err)
+	}
+	for _, slot := range slots {
+		if s.uri.slot >= 0 && slot != uint(s.uri.slot) {
+			continue
+		}
+		info, err := s.ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		if (s.uri.token == "" || info.Label == s.uri.token) && (s.uri.serial == "" || info.SerialNumber == s.uri.serial) {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("no pkcs11 token matches %s", s.uri)
+}
+
+// findObject finds the one object of class that the URI names.
+func (s *pkcs11Signer) findObject(session pkcs11.SessionHandle, class uint) (pkcs11.ObjectHandle, error) {
+	tmpl := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, class)}
+	if s.uri.object != "" {
+		tmpl = append(tmpl, pkcs11.NewAttribute(pkcs11.CKA_LABEL, s.uri.object))
+	}
+	if len(s.uri.id) > 0 {
+		tmpl = append(tmpl, pkcs11.NewAttribute(pkcs11.CKA_ID, s.uri.id))
+	}
+	if err := s.ctx.FindObjectsInit(session, tmpl); err != nil {
+		return 0, fmt.Errorf("find pkcs11 key: %w", err)
+	}
+	objs, _, err := s.ctx.FindObjects(session, 2)
+	_ = s.ctx.FindObjectsFinal(session)
+	kind := "private key"
+	if class == pkcs11.CKO_PUBLIC_KEY {
+		kind = "public key"
+	}
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("find pkcs11 key: %w", err)
+	case len(objs) == 0:
+		return 0, fmt.Errorf("no pkcs11 %s matches %s", kind, s.uri)
+	case len(objs) > 1:
+		return 0, fmt.Errorf("more than one pkcs11 %s matches %s", kind, s.uri)
+	}
+	return objs[0], nil
+}
+
+// publicKey reads the public half of the key from its public key object.
+func (s *pkcs11Signer) publicKey(session pkcs11.SessionHandle) (uint, crypto.PublicKey, error) {
+	obj, err := s.findObject(session, pkcs11.CKO_PUBLIC_KEY)
+	if err != nil {
+		return 0, nil, err
+	}
+	attrs, err := s.ctx.GetAttributeValue(session, obj, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil)})
+	if err != nil {
+		return 0, nil, fmt.Errorf("read pkcs11 key type: %w", err)
+	}
+	keyType, ok := attrUint(attrs[0].Value)
+	if !ok {
+		return 0, nil, errors.New("read pkcs11 key type: malformed attribute")
+	}
+	switch keyType {
+	case pkcs11.CKK_RSA:
+		attrs, err := s.ctx.GetAttributeValue(session, obj, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return 0, nil, fmt.Errorf("read pkcs11 rsa key: %w", err)
+		}
+		return keyType, &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attrs[0].Value),
+			E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+		}, nil
+	case pkcs11.CKK_EC:
+		attrs, err := s.ctx.GetAttributeValue(session, obj, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return 0, nil, fmt.Errorf("read pkcs11 ec key: %w", err)
+		}
+		// The public key is decoded as a SubjectPublicKeyInfo of the curve
+		// and point, which leaves curve support to crypto/x509.
+		spki, err := asn1.Marshal(struct {
+			Algorithm pkix.AlgorithmIdentifier
+			Point     asn1.BitString
+		}{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidECPublicKey, Parameters: asn1.RawValue{FullBytes: attrs[0].Value}},
+			Point:     asn1.BitString{Bytes: ecPoint(attrs[1].Value), BitLength: 8 * len(ecPoint(attrs[1].Value))},
+		})
+		if err != nil {
+			return 0, nil, err
+		}
+		pub, err := x509.ParsePKIXPublicKey(spki)
+		if err != nil {
+			return 0, nil, fmt.Errorf("parse pkcs11 ec key: %w", err)
+		}
+		return keyType, pub, nil
+	case ckkECEdwards:
+		attrs, err := s.ctx.GetAttributeValue(session, obj, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil)})
+		if err != nil {
+			return 0, nil, fmt.Errorf("read pkcs11 eddsa key: %w", err)
+		}
+		point := ecPoint(attrs[0].Value)
+		if len(point) != ed25519.PublicKeySize {
+			return 0, nil, errors.New("pkcs11 eddsa key is not ed25519")
+		}
+		return keyType, ed25519.PublicKey(point), nil
+	default:
+		return 0, nil, fmt.Errorf("unsupported pkcs11 key type %#x", keyType)
+	}
+}
+
+// ecPoint unwraps CKA_EC_POINT, which tokens give as a DER OCTET STRING
+// or, against the standard, as the bare point.
+func ecPoint(v []byte) []byte {
+	var point []byte
+	if rest, err := asn1.Unmarshal(v, &point); err == nil && len(rest) == 0 {
+		return point
+	}
+	return v
+}
+
+// attrUint decodes a CK_ULONG attribute, which is in host byte order.
+func attrUint(v []byte) (uint, bool) {
+	switch len(v) {
+	case 8:
+		return uint(binary.NativeEndian.Uint64(v)), true
+	case 4:
+		return uint(binary.NativeEndian.Uint32(v)), true
+	}
+	return 0, false
+}
+
+func (s *pkcs11Signer) Public() crypto.PublicKey { return s.pub }
+
+// Sign signs digest, or for Ed25519 the message, in the token. A session
+// lost to a token reset or re-insertion is reopened once.
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	mech, data, err := s.mechanism(digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sig, err := s.sign(mech, data)
+	if isCKR(err, pkcs11.CKR_SESSION_HANDLE_INVALID) || isCKR(err, pkcs11.CKR_SESSION_CLOSED) ||
+		isCKR(err, pkcs11.CKR_USER_NOT_LOGGED_IN) || isCKR(err, pkcs11.CKR_DEVICE_REMOVED) || isCKR(err, pkcs11.CKR_TOKEN_NOT_PRESENT) {
+		_ = s.ctx.CloseSession(s.session)
+		if err := s.open(); err != nil {
+			return nil, err
+		}
+		sig, err = s.sign(mech, data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11 sign: %w", err)
+	}
+	if s.keyType == pkcs11.CKK_EC {
+		// The token returns r and s concatenated; Go expects ASN.1.
+		half := len(sig) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			R: new(big.Int).SetBytes(sig[:half]),
+			S: new(big.Int).SetBytes(sig[half:]),
+		})
+	}
+	return sig, nil
+}
+
+func (s *pkcs11Signer) sign(mech []*pkcs11.Mechanism, data []byte) ([]byte, error) {
+	if err := s.ctx.SignInit(s.session, mech, s.key); err != nil {
+		return nil, err
+	}
+	return s.ctx.Sign(s.session, data)
+}
+
+// mechanism returns the signing mechanism for opts and the data to give
+// it.
+func (s *pkcs11Signer) mechanism(digest []byte, opts crypto.SignerOpts) ([]*pkcs11.Mechanism, []byte, error) {
+	switch s.keyType {
+	case pkcs11.CKK_EC:
+		return []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, digest, nil
+	case ckkECEdwards:
+		if opts.HashFunc() != 0 {
+			return nil, nil, errors.New("ed25519 keys sign messages, not digests")
+		}
+		return []*pkcs11.Mechanism{pkcs11.NewMechanism(ckmEdDSA, nil)}, digest, nil
+	}
+	if pss, ok := opts.(*rsa.PSSOptions); ok {
+		p, ok := pssParams[pss.Hash]
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported rsa-pss hash %s", pss.Hash)
+		}
+		salt := pss.SaltLength
+		if salt == rsa.PSSSaltLengthEqualsHash || salt == rsa.PSSSaltLengthAuto {
+			salt = pss.Hash.Size()
+		}
+		return []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, pkcs11.NewPSSParams(p[0], p[1], uint(salt)))}, digest, nil
+	}
+	prefix, ok := pkcs1Prefixes[opts.HashFunc()]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported rsa hash %s", opts.HashFunc())
+	}
+	return []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil)}, append(append([]byte(nil), prefix...), digest...), nil
+}
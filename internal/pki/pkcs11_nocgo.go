@@ -0,0 +1,12 @@
+//go:build !cgo
+
+package pki
+
+import (
+	"crypto"
+	"errors"
+)
+
+func openPKCS11Signer(*pkcs11URI) (crypto.Signer, error) {
+	return nil, errors.New("pkcs11 keys need a build with cgo enabled")
+}
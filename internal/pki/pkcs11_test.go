@@ -0,0 +1,93 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePKCS11URI(t *testing.T) {
+	pinFile := filepath.Join(t.TempDir(), "pin")
+	if err := os.WriteFile(pinFile, []byte("1234\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	u, err := parsePKCS11URI("pkcs11:token=bridge%20hsm;object=server;id=%01%02;manufacturer=x?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=file:" + pinFile)
+	if err != nil {
+		t.Fatalf("parsePKCS11URI: %v", err)
+	}
+	if u.module != "/usr/lib/softhsm/libsofthsm2.so" || u.token != "bridge hsm" || u.object != "server" || string(u.id) != "\x01\x02" || u.slot != -1 {
+		t.Fatalf("uri=%+v", u)
+	}
+	if pin, err := u.readPIN(); err != nil || pin != "1234" {
+		t.Fatalf("pin=%q err=%v", pin, err)
+	}
+	if got := u.String(); got != "pkcs11:token=bridge%20hsm;object=server;id=%01%02" {
+		t.Fatalf("String=%q", got)
+	}
+
+	u, err = parsePKCS11URI("pkcs11:slot-id=3;object=jwt?module-path=/lib/p11.so&pin-value=secret")
+	if err != nil || u.slot != 3 || u.pin != "secret" {
+		t.Fatalf("uri=%+v err=%v", u, err)
+	}
+	if strings.Contains(u.String(), "secret") {
+		t.Fatalf("String leaks the pin: %s", u)
+	}
+	t.Setenv(PKCS11PINEnv, "from-env")
+	u, _ = parsePKCS11URI("pkcs11:token=t;object=k?module-path=/lib/p11.so")
+	if pin, _ := u.readPIN(); pin != "from-env" {
+		t.Fatalf("pin=%q", pin)
+	}
+
+	for _, bad := range []string{
+		"pkcs11:token=t;object=k",
+		"pkcs11:object=k?module-path=/lib/p11.so",
+		"pkcs11:token=t?module-path=/lib/p11.so",
+		"pkcs11:token=t;object=k;type=cert?module-path=/lib/p11.so",
+		"pkcs11:slot-id=x;object=k?module-path=/lib/p11.so",
+		"pkcs11:token=%zz;object=k?module-path=/lib/p11.so",
+	} {
+		if _, err := parsePKCS11URI(bad); err == nil {
+			t.Errorf("%s: accepted", bad)
+		}
+	}
+}
+
+// TestPKCS11Signer signs with a key in a real token, such as SoftHSM, when
+// BRIDGE_TEST_PKCS11_URI names one.
+func TestPKCS11Signer(t *testing.T) {
+	uri := os.Getenv("BRIDGE_TEST_PKCS11_URI")
+	if uri == "" {
+		t.Skip("BRIDGE_TEST_PKCS11_URI not set")
+	}
+	signer, err := LoadSigner(uri, nil)
+	if err != nil {
+		t.Fatalf("LoadSigner: %v", err)
+	}
+	msg := []byte("bridge")
+	digest := sha256.Sum256(msg)
+	switch pub := signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		sig, err := signer.Sign(nil, digest[:], crypto.SHA256)
+		if err != nil || !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			t.Fatalf("ecdsa: err=%v", err)
+		}
+	case *rsa.PublicKey:
+		sig, err := signer.Sign(nil, digest[:], &rsa.PSSOptions{Hash: crypto.SHA256, SaltLength: rsa.PSSSaltLengthEqualsHash})
+		if err != nil || rsa.VerifyPSS(pub, crypto.SHA256, digest[:], sig, nil) != nil {
+			t.Fatalf("rsa-pss: err=%v", err)
+		}
+	case ed25519.PublicKey:
+		sig, err := signer.Sign(nil, msg, crypto.Hash(0))
+		if err != nil || !ed25519.Verify(pub, msg, sig) {
+			t.Fatalf("ed25519: err=%v", err)
+		}
+	default:
+		t.Fatalf("unexpected public key %T", pub)
+	}
+}
@@ -8,10 +8,14 @@ func NewClaudeProvider() *StdioProvider {
 		ProviderID:     "claude",
 		Binary:         "claude",
 		DefaultArgs:    []string{"--verbose"},
+		ResumeArgs:     []string{"--resume", ThreadIDArg},
+		ThreadIDArgs:   []string{"--session-id", ThreadIDArg},
 		StartupTimeout: 60 * time.Second,
 		StopGrace:      10 * time.Second,
 		StartupProbe:   "prompt",
 		RequiredEnv:    []string{"CLAUDE_CODE_OAUTH_TOKEN"},
+		EnvAllowlist:   []string{"ANTHROPIC_*", "CLAUDE_CODE_*", "CLAUDE_CONFIG_DIR"},
 		PromptPattern:  `(?m)(❯|\>\s*$)`,
+		MCPConfigFlag:  "--mcp-config",
 	})
 }
@@ -3,18 +3,26 @@ package provider
 import "time"
 
 // NewClaudeChatProvider creates a stream-JSON provider that runs
-// `claude --output-format stream-json --verbose` without a PTY.
-// stdout is newline-delimited JSON; the supervisor's readLoopStreamJSON
-// parser extracts text and thinking deltas as typed OutputChunks.
+// `claude --output-format stream-json --input-format stream-json --verbose`
+// without a PTY. stdout is newline-delimited JSON; the supervisor's
+// readLoopStreamJSON parser extracts text and thinking deltas as typed
+// OutputChunks. stdin takes stream-JSON messages too, which is how
+// CancelResponse interrupts a response without ending the process, and
+// how approvals are answered for projects that require them.
 func NewClaudeChatProvider() *StdioProvider {
 	return NewStdioProvider(StdioConfig{
 		ProviderID:     "claude-chat",
 		Binary:         "claude",
-		DefaultArgs:    []string{"--output-format", "stream-json", "--verbose"},
+		DefaultArgs:    []string{"--output-format", "stream-json", "--input-format", "stream-json", "--verbose"},
+		ResumeArgs:     []string{"--resume", ThreadIDArg},
+		ThreadIDArgs:   []string{"--session-id", ThreadIDArg},
 		StartupTimeout: 60 * time.Second,
 		StopGrace:      10 * time.Second,
 		StartupProbe:   "none",
 		RequiredEnv:    []string{"CLAUDE_CODE_OAUTH_TOKEN"},
+		EnvAllowlist:   []string{"ANTHROPIC_*", "CLAUDE_CODE_*", "CLAUDE_CONFIG_DIR"},
 		StreamJSON:     true,
+		MCPConfigFlag:  "--mcp-config",
+		ApprovalArgs:   []string{"--permission-prompt-tool", "stdio"},
 	})
 }
@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// InstallHints are the commands that install the CLIs of the built-in
+// providers, keyed by binary name. They are added to "not found" errors.
+var InstallHints = map[string]string{
+	"claude":   "npm i -g @anthropic-ai/claude-code",
+	"codex":    "npm i -g @openai/codex",
+	"opencode": "npm i -g opencode-ai",
+	"gemini":   "npm i -g @google/gemini-cli",
+}
+
+// InstallDirs returns the directories, other than PATH, where CLIs are
+// commonly installed: the npm global prefix, ~/.local/bin, asdf shims,
+// version managers' bin directories and Homebrew. A daemon started by
+// systemd or launchd often has none of them on its PATH.
+func InstallDirs() []string {
+	home, _ := os.UserHomeDir()
+	var dirs []string
+	add := func(dir string) {
+		if dir != "" && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, key := range []string{"NPM_CONFIG_PREFIX", "npm_config_prefix"} {
+		if prefix := os.Getenv(key); prefix != "" {
+			add(filepath.Join(prefix, "bin"))
+		}
+	}
+	asdf := os.Getenv("ASDF_DATA_DIR")
+	if asdf == "" && home != "" {
+		asdf = filepath.Join(home, ".asdf")
+	}
+	if asdf != "" {
+		add(filepath.Join(asdf, "shims"))
+	}
+	if home != "" {
+		add(filepath.Join(home, ".npm-global", "bin"))
+		add(filepath.Join(home, ".local", "bin"))
+		add(filepath.Join(home, ".volta", "bin"))
+		add(filepath.Join(home, ".bun", "bin"))
+		// nvm installs each Node version's global packages next to it;
+		// the newest version is tried first.
+		nvm, _ := filepath.Glob(filepath.Join(home, ".nvm", "versions", "node", "*", "bin"))
+		slices.Reverse(nvm)
+		for _, dir := range nvm {
+			add(dir)
+		}
+	}
+	add("/usr/local/bin")
+	add("/opt/homebrew/bin")
+	return dirs
+}
+
+// LookupBinary finds the executable name in PATH or, failing that, in
+// InstallDirs.
+func LookupBinary(name string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err == nil || !errors.Is(err, exec.ErrNotFound) {
+		return path, err
+	}
+	dirs := InstallDirs()
+	for _, dir := range dirs {
+		candidate := filepath.Join(dir, name)
+		if fi, statErr := os.Stat(candidate); statErr == nil && fi.Mode().IsRegular() && fi.Mode()&0o111 != 0 {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w or in %s", err, strings.Join(dirs, ", "))
+}
+
+// installHint returns the provider's install command, if it has one.
+func (p *StdioProvider) installHint() string {
+	if p.cfg.InstallHint != "" {
+		return p.cfg.InstallHint
+	}
+	return InstallHints[filepath.Base(p.cfg.Binary)]
+}
+
+// binaryNotFound describes a binary that cannot be resolved, with how to
+// install it when known.
+func (p *StdioProvider) binaryNotFound(err error) error {
+	if hint := p.installHint(); hint != "" {
+		return fmt.Errorf("binary %q not found: %w; install with: %s", p.cfg.Binary, err, hint)
+	}
+	return fmt.Errorf("binary %q not found: %w", p.cfg.Binary, err)
+}
+
+// withBinDir adds the directory of binPath to the end of env's PATH when it
+// is not already there, so that a CLI found through InstallDirs can find
+// its interpreter and sibling tools (e.g. node next to an nvm install).
+func withBinDir(env []string, binPath string) []string {
+	dir := filepath.Dir(binPath)
+	for i, e := range env {
+		value, ok := strings.CutPrefix(e, "PATH=")
+		if !ok {
+			continue
+		}
+		if slices.Contains(filepath.SplitList(value), dir) {
+			return env
+		}
+		out := slices.Clone(env)
+		out[i] = e + string(os.PathListSeparator) + dir
+		return out
+	}
+	return env
+}
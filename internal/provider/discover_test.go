@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLookupBinarySearchesInstallDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("ASDF_DATA_DIR", "")
+	t.Setenv("NPM_CONFIG_PREFIX", filepath.Join(home, "npm"))
+
+	localBin := filepath.Join(home, ".local", "bin")
+	nvmBin := filepath.Join(home, ".nvm", "versions", "node", "v22.1.0", "bin")
+	for _, dir := range []string{localBin, nvmBin} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+	}
+	for _, path := range []string{filepath.Join(localBin, "agent"), filepath.Join(nvmBin, "claude")} {
+		if err := os.WriteFile(path, []byte("#!/bin/sh\necho 1.0.0\n"), 0o755); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	dirs := InstallDirs()
+	if dirs[0] != filepath.Join(home, "npm", "bin") || dirs[1] != filepath.Join(home, ".asdf", "shims") {
+		t.Fatalf("InstallDirs = %v", dirs)
+	}
+	for name, want := range map[string]string{
+		"agent":  filepath.Join(localBin, "agent"),
+		"claude": filepath.Join(nvmBin, "claude"),
+	} {
+		if got, err := LookupBinary(name); err != nil || got != want {
+			t.Errorf("LookupBinary(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := LookupBinary("missing"); err == nil || !strings.Contains(err.Error(), localBin) {
+		t.Fatalf("LookupBinary(missing) err = %v, want the searched dirs", err)
+	}
+
+	// A discovered binary is healthy and its directory joins the agent's
+	// PATH.
+	p := NewStdioProvider(StdioConfig{ProviderID: "claude", Binary: "claude"})
+	if err := p.Health(context.Background()); err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	env := withBinDir([]string{"PATH=/usr/bin", "HOME=" + home}, filepath.Join(nvmBin, "claude"))
+	if env[0] != "PATH=/usr/bin:"+nvmBin {
+		t.Fatalf("withBinDir env = %v", env)
+	}
+	if again := withBinDir(env, filepath.Join(nvmBin, "node")); again[0] != env[0] {
+		t.Fatalf("withBinDir added the directory twice: %v", again)
+	}
+}
+
+func TestHealthInstallHint(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PATH", t.TempDir())
+
+	// A name no machine has, so that /usr/local/bin cannot provide it.
+	InstallHints["bridge-test-agent"] = "npm i -g bridge-test-agent"
+	defer delete(InstallHints, "bridge-test-agent")
+	p := NewStdioProvider(StdioConfig{ProviderID: "agent", Binary: "bridge-test-agent"})
+	err := p.Health(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "install with: npm i -g bridge-test-agent") {
+		t.Fatalf("Health err = %v, want the built-in install hint", err)
+	}
+	p = NewStdioProvider(StdioConfig{ProviderID: "aider", Binary: "bridge-test-aider", InstallHint: "pipx install aider-chat"})
+	if err := p.Health(context.Background()); err == nil || !strings.HasSuffix(err.Error(), "; install with: pipx install aider-chat") {
+		t.Fatalf("Health err = %v, want the configured install hint", err)
+	}
+	p = NewStdioProvider(StdioConfig{ProviderID: "other", Binary: "bridge-test-other"})
+	if err := p.Health(context.Background()); err == nil || strings.Contains(err.Error(), "install with") {
+		t.Fatalf("Health err = %v, want no install hint", err)
+	}
+}
@@ -12,6 +12,7 @@ func NewOpenCodeProvider() *StdioProvider {
 		StopGrace:      10 * time.Second,
 		StartupProbe:   "output",
 		RequiredEnv:    []string{"OPENAI_API_KEY"},
+		EnvAllowlist:   []string{"OPENCODE_*", "OPENAI_*", "ANTHROPIC_*", "OPENROUTER_*", "GEMINI_*", "GOOGLE_*"},
 		PromptPattern:  `❯`,
 	})
 }
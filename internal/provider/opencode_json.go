@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+)
+
+// OpenCodeJSONConfig is the configuration of the opencode-json provider. It
+// is shared by NewOpenCodeJSONProvider and the local server's auto-detected
+// providers so the two cannot drift. No RequiredEnv: opencode can be
+// configured with several model providers, or with its own stored auth.
+func OpenCodeJSONConfig() StdioConfig {
+	return StdioConfig{
+		ProviderID:     "opencode-json",
+		Binary:         "opencode",
+		DefaultArgs:    []string{"run", "--format", "json"},
+		StartupTimeout: 60 * time.Second,
+		StopGrace:      10 * time.Second,
+		StartupProbe:   "none",
+		EnvAllowlist:   []string{"OPENCODE_*", "OPENAI_*", "ANTHROPIC_*", "OPENROUTER_*", "GEMINI_*", "GOOGLE_*"},
+		StreamJSON:     true,
+		JSONFormat:     bridge.JSONFormatOpenCode,
+	}
+}
+
+// NewOpenCodeJSONProvider creates a stream-JSON provider that runs
+// `opencode run --format json` without a PTY. stdout is opencode's JSON event
+// protocol; the supervisor maps text and reasoning parts to typed
+// OutputChunks and step_finish events to RESPONSE_COMPLETE, so no prompt
+// pattern is needed to detect turn boundaries.
+func NewOpenCodeJSONProvider() *StdioProvider {
+	return NewStdioProvider(OpenCodeJSONConfig())
+}
@@ -0,0 +1,288 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+)
+
+// Sandbox modes accepted by StdioConfig.Sandbox.
+const (
+	SandboxNone   = "none"
+	SandboxDocker = "docker"
+	SandboxBwrap  = "bwrap"
+)
+
+// sandboxEnvSkip lists variables that are not forwarded into a docker
+// container; the image supplies its own values.
+var sandboxEnvSkip = map[string]bool{
+	"PATH": true,
+	"HOME": true,
+}
+
+// bwrapSystemPaths are the host paths a bwrap sandbox sees, read-only:
+// binaries, shared libraries, and the parts of /etc needed for name
+// resolution, TLS roots and user lookup. Paths missing on the host are
+// skipped.
+var bwrapSystemPaths = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64",
+	"/etc/alternatives", "/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d",
+	"/etc/ssl", "/etc/pki", "/etc/ca-certificates",
+	"/etc/resolv.conf", "/etc/hosts", "/etc/nsswitch.conf",
+	"/etc/passwd", "/etc/group", "/etc/localtime",
+}
+
+// containerName is the name of the docker container running sessionID's
+// agent, so that it can be removed if the docker CLI is killed. Probes,
+// which have no session, get a unique name.
+func containerName(sessionID string) string {
+	if sessionID == "" {
+		return fmt.Sprintf("ai-agent-bridge-probe-%d-%d", os.Getpid(), time.Now().UnixNano())
+	}
+	name := []byte(sessionID)
+	for i, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-') {
+			name[i] = '-'
+		}
+	}
+	return "ai-agent-bridge-" + string(name)
+}
+
+// removeContainer force-removes a docker sandbox container, killing the
+// agent if it is still running. A container already removed by --rm is
+// not an error worth reporting.
+func removeContainer(docker, name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, docker, "rm", "-f", name).CombinedOutput(); err != nil && !strings.Contains(string(out), "No such container") {
+		logger().Warn("docker sandbox: remove container failed", "container", name, "error", err, "output", strings.TrimSpace(string(out)))
+	}
+}
+
+// CleanupSession implements bridge.SessionCleanupProvider. Killing the
+// docker CLI does not stop its container, so after each agent process
+// exits the session's container is removed.
+func (p *StdioProvider) CleanupSession(sessionID string) {
+	if p.cfg.Sandbox != SandboxDocker {
+		return
+	}
+	docker, err := exec.LookPath("docker")
+	if err != nil {
+		return
+	}
+	removeContainer(docker, containerName(sessionID))
+}
+
+// sandboxed reports whether the provider runs inside a sandbox.
+func (p *StdioProvider) sandboxed() bool {
+	return p.cfg.Sandbox != "" && p.cfg.Sandbox != SandboxNone
+}
+
+// sandboxBinary returns the host binary that launches the agent: the
+// sandbox runtime when one is configured, otherwise the agent itself.
+func (p *StdioProvider) sandboxBinary() (string, error) {
+	switch p.cfg.Sandbox {
+	case SandboxDocker:
+		return exec.LookPath("docker")
+	case SandboxBwrap:
+		return exec.LookPath("bwrap")
+	default:
+		return resolveBinaryPath(p.cfg.Binary, p.cfg.ProviderRoot)
+	}
+}
+
+// command builds the exec.Cmd that runs sessionID's agent with args in dir.
+// When a sandbox is configured the agent is wrapped so that dir is the only
+// writable host path; tty tells docker whether the agent is attached to a
+// PTY. Non-zero limits are enforced by docker's cgroup flags, or otherwise
+// by running the agent under prlimit(1).
+func (p *StdioProvider) command(ctx context.Context, sessionID, dir string, env []string, tty bool, args []string, limits bridge.ResourceLimits) (*exec.Cmd, error) {
+	var (
+		name      string
+		argv      []string
+		container string
+	)
+	switch p.cfg.Sandbox {
+	case SandboxDocker:
+		if p.cfg.SandboxImage == "" {
+			return nil, fmt.Errorf("provider %q: sandbox_image is required for docker sandbox", p.cfg.ProviderID)
+		}
+		docker, err := exec.LookPath("docker")
+		if err != nil {
+			return nil, fmt.Errorf("docker sandbox: %w", err)
+		}
+		// Inside the container the binary and args are image paths, so
+		// they are passed through unresolved.
+		container = containerName(sessionID)
+		name, argv = docker, dockerArgs(container, p.cfg.SandboxImage, dir, env, tty, limits, p.cfg.Binary, p.cfg.DefaultArgs, args)
+	case SandboxBwrap:
+		bwrap, err := exec.LookPath("bwrap")
+		if err != nil {
+			return nil, fmt.Errorf("bwrap sandbox: %w", err)
+		}
+		binPath, resolved, err := p.resolvedCommand()
+		if err != nil {
+			return nil, err
+		}
+		name, argv = bwrap, bwrapArgs(dir, binPath, p.bwrapBinds(binPath), append(resolved, args...))
+		env = withBinDir(env, binPath)
+	case "", SandboxNone:
+		binPath, resolved, err := p.resolvedCommand()
+		if err != nil {
+			return nil, err
+		}
+		name, argv = binPath, append(resolved, args...)
+		env = withBinDir(env, binPath)
+	default:
+		return nil, fmt.Errorf("provider %q has unsupported sandbox %q", p.cfg.ProviderID, p.cfg.Sandbox)
+	}
+	if p.cfg.Sandbox != SandboxDocker && !limits.IsZero() {
+		prlimit, err := exec.LookPath("prlimit")
+		if err != nil {
+			return nil, fmt.Errorf("resource limits: %w", err)
+		}
+		name, argv = prlimit, prlimitArgs(limits, name, argv)
+	}
+	cmd := exec.CommandContext(ctx, name, argv...)
+	cmd.Dir = dir
+	cmd.Env = env
+	if container != "" {
+		// Cancelling kills the docker CLI, which leaves the container
+		// running; remove it first.
+		cmd.Cancel = func() error {
+			removeContainer(name, container)
+			return cmd.Process.Kill()
+		}
+	}
+	return cmd, nil
+}
+
+// resolvedCommand resolves the configured binary and default args on the host.
+func (p *StdioProvider) resolvedCommand() (string, []string, error) {
+	binPath, err := resolveBinaryPath(p.cfg.Binary, p.cfg.ProviderRoot)
+	if err != nil {
+		return "", nil, p.binaryNotFound(err)
+	}
+	args, err := resolveCommandArgs(p.cfg.DefaultArgs, p.cfg.ProviderRoot)
+	if err != nil {
+		return "", nil, fmt.Errorf("resolve args for %q: %w", p.cfg.ProviderID, err)
+	}
+	return binPath, args, nil
+}
+
+// bwrapBinds returns the host paths besides bwrapSystemPaths that the
+// agent needs to read: the directory holding the agent binary (following
+// symlinks, for CLIs installed outside /usr), ProviderRoot, and the
+// provider's SandboxBinds.
+func (p *StdioProvider) bwrapBinds(binPath string) []string {
+	binds := []string{filepath.Dir(binPath)}
+	if real, err := filepath.EvalSymlinks(binPath); err == nil && filepath.Dir(real) != binds[0] {
+		binds = append(binds, filepath.Dir(real))
+	}
+	if p.cfg.ProviderRoot != "" {
+		binds = append(binds, p.cfg.ProviderRoot)
+	}
+	return append(binds, p.cfg.SandboxBinds...)
+}
+
+// bwrapArgs returns bubblewrap arguments that expose only bwrapSystemPaths
+// and binds read-only, give the agent private /tmp, /dev and /proc, and
+// bind dir read-write. The rest of the host filesystem, including the
+// bridge's state and keys, is not visible. The agent dies with the bridge
+// and runs in its own PID namespace so it cannot signal host processes.
+func bwrapArgs(dir, binPath string, binds []string, args []string) []string {
+	var argv []string
+	for _, path := range bwrapSystemPaths {
+		argv = append(argv, "--ro-bind-try", path, path)
+	}
+	for _, path := range binds {
+		if !underSystemPath(path) {
+			argv = append(argv, "--ro-bind", path, path)
+		}
+	}
+	argv = append(argv,
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	)
+	if dir != "" {
+		argv = append(argv, "--bind", dir, dir, "--chdir", dir)
+	}
+	argv = append(argv, "--unshare-pid", "--die-with-parent", "--", binPath)
+	return append(argv, args...)
+}
+
+// underSystemPath reports whether path is already visible through one of
+// bwrapSystemPaths.
+func underSystemPath(path string) bool {
+	for _, sys := range bwrapSystemPaths {
+		if path == sys || strings.HasPrefix(path, sys+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// prlimitArgs returns prlimit(1) arguments that run name with limits. Memory
+// is capped with RLIMIT_DATA rather than RLIMIT_AS because JavaScript
+// runtimes reserve far more address space than they use. RLIMIT_NPROC
+// counts every process of the bridge user, not just this agent's.
+func prlimitArgs(limits bridge.ResourceLimits, name string, argv []string) []string {
+	var out []string
+	if limits.MemoryBytes > 0 {
+		out = append(out, "--data="+strconv.FormatInt(limits.MemoryBytes, 10))
+	}
+	if limits.CPUSeconds > 0 {
+		out = append(out, "--cpu="+strconv.FormatInt(limits.CPUSeconds, 10))
+	}
+	if limits.Processes > 0 {
+		out = append(out, "--nproc="+strconv.Itoa(limits.Processes))
+	}
+	out = append(out, "--", name)
+	return append(out, argv...)
+}
+
+// dockerArgs returns `docker run` arguments for a throwaway container named
+// name that mounts dir at the same path and runs as the bridge user so
+// files written to the repo keep their ownership. Environment values are
+// forwarded by name only (-e KEY) so secrets never appear in the process
+// list. Memory and process limits apply to the whole container through its
+// cgroup.
+func dockerArgs(name, image, dir string, env []string, tty bool, limits bridge.ResourceLimits, binary string, defaultArgs, args []string) []string {
+	argv := []string{"run", "--rm", "-i", "--init", "--name", name}
+	if tty {
+		argv = append(argv, "-t")
+	}
+	if limits.MemoryBytes > 0 {
+		// An equal swap limit keeps the container from swapping past it.
+		mem := strconv.FormatInt(limits.MemoryBytes, 10)
+		argv = append(argv, "--memory", mem, "--memory-swap", mem)
+	}
+	if limits.CPUSeconds > 0 {
+		argv = append(argv, "--ulimit", "cpu="+strconv.FormatInt(limits.CPUSeconds, 10))
+	}
+	if limits.Processes > 0 {
+		argv = append(argv, "--pids-limit", strconv.Itoa(limits.Processes))
+	}
+	argv = append(argv, "--user", strconv.Itoa(os.Getuid())+":"+strconv.Itoa(os.Getgid()))
+	if dir != "" {
+		argv = append(argv, "-v", dir+":"+dir, "-w", dir)
+	}
+	for _, e := range env {
+		key, _, ok := strings.Cut(e, "=")
+		if !ok || sandboxEnvSkip[key] {
+			continue
+		}
+		argv = append(argv, "-e", key)
+	}
+	argv = append(argv, image, binary)
+	argv = append(argv, defaultArgs...)
+	return append(argv, args...)
+}
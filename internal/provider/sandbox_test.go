@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+)
+
+func TestBwrapArgsBindsOnlyRepoReadWrite(t *testing.T) {
+	argv := bwrapArgs("/repos/app", "/usr/bin/agent", []string{"/usr/bin", "/opt/node"}, []string{"--verbose"})
+	joined := strings.Join(argv, " ")
+
+	for _, want := range []string{
+		"--ro-bind-try /usr /usr",
+		"--ro-bind-try /etc/resolv.conf /etc/resolv.conf",
+		"--ro-bind /opt/node /opt/node",
+		"--bind /repos/app /repos/app",
+		"--chdir /repos/app",
+		"--die-with-parent",
+		"-- /usr/bin/agent --verbose",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("bwrap args %q missing %q", joined, want)
+		}
+	}
+	for _, hidden := range []string{"--ro-bind / /", "/home", "/root", " /etc /etc", "/usr/bin /usr/bin"} {
+		if strings.Contains(joined, hidden) {
+			t.Fatalf("bwrap args %q expose %q", joined, hidden)
+		}
+	}
+	if n := strings.Count(joined, "--bind "); n != 1 {
+		t.Fatalf("bwrap args have %d read-write binds, want 1: %q", n, joined)
+	}
+}
+
+func TestBwrapBinds(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "versions", "agent-1.0")
+	if err := os.MkdirAll(filepath.Dir(real), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(real, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "bin", "agent")
+	if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(real, link); err != nil {
+		t.Fatal(err)
+	}
+	p := NewStdioProvider(StdioConfig{ProviderID: "a", Binary: link, SandboxBinds: []string{"/opt/node"}})
+	got := p.bwrapBinds(link)
+	want := []string{filepath.Dir(link), filepath.Dir(real), "/opt/node"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("binds=%v want %v", got, want)
+	}
+}
+
+func TestContainerName(t *testing.T) {
+	if got := containerName("0b9f7c4e-1d2a-4f6b-9c3d-5e6f7a8b9c0d"); got != "ai-agent-bridge-0b9f7c4e-1d2a-4f6b-9c3d-5e6f7a8b9c0d" {
+		t.Fatalf("containerName=%q", got)
+	}
+	if got := containerName("a/b c"); got != "ai-agent-bridge-a-b-c" {
+		t.Fatalf("containerName sanitized=%q", got)
+	}
+	if containerName("") == containerName("") {
+		t.Fatal("probe container names must be unique")
+	}
+}
+
+func TestDockerArgs(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "HOME=/home/bridge", "ANTHROPIC_API_KEY=secret", "TERM=xterm-256color"}
+	tests := []struct {
+		name    string
+		tty     bool
+		wantTTY bool
+	}{
+		{name: "pty", tty: true, wantTTY: true},
+		{name: "stream json", tty: false, wantTTY: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argv := dockerArgs("ai-agent-bridge-s1", "agents:latest", "/repos/app", env, tt.tty, bridge.ResourceLimits{}, "claude", []string{"--verbose"}, []string{"--model", "x"})
+			joined := strings.Join(argv, " ")
+
+			if got := slices.Contains(argv, "-t"); got != tt.wantTTY {
+				t.Fatalf("tty flag present=%v want %v: %q", got, tt.wantTTY, joined)
+			}
+			for _, want := range []string{
+				"run --rm -i --init --name ai-agent-bridge-s1",
+				"-v /repos/app:/repos/app -w /repos/app",
+				"-e ANTHROPIC_API_KEY",
+				"agents:latest claude --verbose --model x",
+			} {
+				if !strings.Contains(joined, want) {
+					t.Fatalf("docker args %q missing %q", joined, want)
+				}
+			}
+			if strings.Contains(joined, "secret") {
+				t.Fatalf("docker args leak env values: %q", joined)
+			}
+			if strings.Contains(joined, "-e PATH") || strings.Contains(joined, "-e HOME") {
+				t.Fatalf("docker args forward host PATH/HOME: %q", joined)
+			}
+		})
+	}
+}
+
+func TestBuildCommandSandboxErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  StdioConfig
+	}{
+		{name: "unsupported", cfg: StdioConfig{ProviderID: "a", Binary: "/bin/echo", Sandbox: "jail"}},
+		{name: "docker without image", cfg: StdioConfig{ProviderID: "a", Binary: "/bin/echo", Sandbox: SandboxDocker}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewStdioProvider(tt.cfg)
+			_, err := p.BuildCommand(context.Background(), bridge.SessionConfig{RepoPath: t.TempDir()})
+			if !errors.Is(err, bridge.ErrProviderUnavailable) {
+				t.Fatalf("BuildCommand error=%v want %v", err, bridge.ErrProviderUnavailable)
+			}
+		})
+	}
+}
+
+func TestDockerArgsResourceLimits(t *testing.T) {
+	limits := bridge.ResourceLimits{MemoryBytes: 1 << 30, CPUSeconds: 600, Processes: 256}
+	joined := strings.Join(dockerArgs("ai-agent-bridge-s1", "agents:latest", "/repos/app", nil, false, limits, "claude", nil, nil), " ")
+	for _, want := range []string{
+		"--memory 1073741824 --memory-swap 1073741824",
+		"--ulimit cpu=600",
+		"--pids-limit 256",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("docker args %q missing %q", joined, want)
+		}
+	}
+	if !strings.HasSuffix(joined, "agents:latest claude") {
+		t.Fatalf("limit flags placed after the image: %q", joined)
+	}
+}
+
+func TestBuildCommandResourceLimits(t *testing.T) {
+	if _, err := exec.LookPath("prlimit"); err != nil {
+		t.Skip("prlimit not installed")
+	}
+	p := NewStdioProvider(StdioConfig{
+		ProviderID:  "sh",
+		Binary:      "/bin/sh",
+		DefaultArgs: []string{"-c", "ulimit -t; ulimit -d"},
+		Limits:      bridge.ResourceLimits{MemoryBytes: 1 << 30, CPUSeconds: 600},
+	})
+	// The project's CPU limit is tighter; the provider's memory limit stays.
+	cmd, err := p.BuildCommand(context.Background(), bridge.SessionConfig{
+		RepoPath: t.TempDir(),
+		Limits:   bridge.ResourceLimits{CPUSeconds: 60},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand: %v", err)
+	}
+	if filepath.Base(cmd.Path) != "prlimit" {
+		t.Fatalf("command %q not wrapped in prlimit", cmd.Args)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := strings.Fields(string(out)); !slices.Equal(got, []string{"60", "1048576"}) {
+		t.Fatalf("agent limits cpu, data(KiB) = %v want [60 1048576]", got)
+	}
+
+	// Without limits the agent is not wrapped.
+	p = NewStdioProvider(StdioConfig{ProviderID: "sh", Binary: "/bin/sh"})
+	cmd, err = p.BuildCommand(context.Background(), bridge.SessionConfig{RepoPath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("BuildCommand: %v", err)
+	}
+	if cmd.Path != "/bin/sh" {
+		t.Fatalf("unlimited command = %q want /bin/sh", cmd.Path)
+	}
+}
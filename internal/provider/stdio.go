@@ -4,20 +4,29 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
-	"github.com/creack/pty"
+	"github.com/markcallen/ai-agent-bridge/internal/agentproc"
 	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"github.com/markcallen/ai-agent-bridge/internal/secrets"
 )
 
+// logger is the default logger tagged as the provider component.
+func logger() *slog.Logger {
+	return slog.Default().With(logging.ComponentKey, logging.ComponentProvider)
+}
+
 // StdioConfig configures an interactive PTY-backed provider.
 type StdioConfig struct {
 	ProviderID     string
@@ -28,20 +37,88 @@ type StdioConfig struct {
 	StartupProbe   string
 	PromptPattern  string
 	RequiredEnv    []string
-	StreamJSON     bool // if true, the provider uses stream-JSON mode (no PTY)
-	StripANSI      bool // if true, ANSI escape codes are stripped from PTY output
+	// ReadyTimeout is how long a session's agent may take to print a
+	// prompt matching PromptPattern before the session fails. Zero waits
+	// forever.
+	ReadyTimeout time.Duration
+	// MinVersion and MaxVersion bound, inclusively, the version the binary
+	// reports with --version. Outside them the provider is unavailable.
+	// Empty means no bound.
+	MinVersion string
+	MaxVersion string
+	// InstallHint is the command that installs Binary, quoted when it
+	// cannot be found. Empty uses InstallHints.
+	InstallHint string
+	// EnvAllowlist names the daemon environment variables the agent
+	// inherits in addition to DefaultEnvAllowlist and RequiredEnv. Entries
+	// ending in "*" match a prefix; "*" alone passes everything.
+	EnvAllowlist []string
+	// EnvBlocklist names variables the agent never inherits, on top of
+	// DefaultEnvBlocklist. It overrides the allowlists.
+	EnvBlocklist []string
+	// SecretEnv maps environment variable names to secret:// references
+	// resolved with Secrets each time an agent starts. The values go only
+	// to the agent and take precedence over every other source.
+	SecretEnv  map[string]string
+	Secrets    *secrets.Resolver
+	StreamJSON bool   // if true, the provider uses stream-JSON mode (no PTY)
+	JSONFormat string // stream-JSON dialect (bridge.JSONFormat*); empty means claude
+	StripANSI  bool   // if true, ANSI escape codes are stripped from PTY output
+	// OutputFilters post-process the agent's output text in order
+	// (bridge.OutputFilter*). StripANSI adds OutputFilterStripANSI first.
+	OutputFilters []string
+	// StderrRules classify stderr lines of stream-JSON sessions; the first
+	// matching rule wins. PTY sessions merge stderr into the terminal output.
+	StderrRules []bridge.StderrRule
+	// ResumeArgs are appended to DefaultArgs, with ThreadIDArg replaced by
+	// the thread's ID, when the supervisor relaunches an agent that crashed
+	// so that it continues its thread (e.g. "--resume {thread_id}" for
+	// claude). Args that do not name the thread are never used, and empty
+	// restarts the agent afresh.
+	ResumeArgs []string
+	// ThreadIDArgs are appended to DefaultArgs, with ThreadIDArg replaced by
+	// an ID the supervisor picks, when an agent starts a new thread (e.g.
+	// "--session-id {thread_id}" for claude). They let a relaunch resume
+	// the thread of an agent whose output does not name it.
+	ThreadIDArgs []string
+	// Sandbox selects how the agent is isolated: SandboxNone (default),
+	// SandboxBwrap or SandboxDocker. Sandboxed agents can write only to the
+	// session repo path.
+	Sandbox string
+	// SandboxImage is the container image used when Sandbox is SandboxDocker.
+	// Binary and DefaultArgs are then interpreted as paths inside the image.
+	SandboxImage string
+	// SandboxBinds are extra host paths a SandboxBwrap agent may read, such
+	// as the runtime of a CLI installed under a home directory.
+	SandboxBinds []string
 	// ProviderRoot is an optional absolute path used as the base for resolving
 	// relative Binary and DefaultArgs paths. When empty, relative paths are
 	// resolved against the daemon working directory (legacy behaviour).
 	ProviderRoot string
+	// Limits caps the resources of each agent process. A session's project
+	// limits (SessionConfig.Limits) can only tighten them.
+	Limits bridge.ResourceLimits
+	// MCPConfigFlag is the flag the agent takes an MCP config file with
+	// (e.g. "--mcp-config" for claude). When set, sessions of projects with
+	// MCP servers get their config passed after it.
+	MCPConfigFlag string
+	// ApprovalArgs make a stream-JSON agent send its permission prompts to
+	// the bridge (e.g. "--permission-prompt-tool stdio" for claude). They
+	// are passed to sessions of projects that require approval.
+	ApprovalArgs []string
 }
 
+// ThreadIDArg stands for the agent's thread ID in ResumeArgs and
+// ThreadIDArgs.
+const ThreadIDArg = "{thread_id}"
+
 // StdioProvider defines how to launch and validate one interactive CLI.
 type StdioProvider struct {
 	cfg            StdioConfig
 	promptRe       *regexp.Regexp
 	mu             sync.RWMutex
 	unavailableErr error
+	version        *versionCheck // last MinVersion/MaxVersion check
 }
 
 // SetUnavailable persists a startup-time error so that Health() reports the
@@ -74,6 +151,7 @@ func (p *StdioProvider) ID() string                    { return p.cfg.ProviderID
 func (p *StdioProvider) Binary() string                { return p.cfg.Binary }
 func (p *StdioProvider) PromptPattern() *regexp.Regexp { return p.promptRe }
 func (p *StdioProvider) StartupTimeout() time.Duration { return p.cfg.StartupTimeout }
+func (p *StdioProvider) ReadyTimeout() time.Duration   { return p.cfg.ReadyTimeout }
 func (p *StdioProvider) StopGrace() time.Duration      { return p.cfg.StopGrace }
 
 // IsStreamJSON implements bridge.StreamJSONProvider. It returns true when the
@@ -81,33 +159,108 @@ func (p *StdioProvider) StopGrace() time.Duration      { return p.cfg.StopGrace
 // instead of raw PTY bytes).
 func (p *StdioProvider) IsStreamJSON() bool { return p.cfg.StreamJSON }
 
+// JSONFormat implements bridge.JSONFormatProvider. It returns the configured
+// stream-JSON dialect so the supervisor can pick the matching stdout parser.
+func (p *StdioProvider) JSONFormat() string { return p.cfg.JSONFormat }
+
 // IsStripANSI implements bridge.StripANSIProvider. It returns true when the
 // provider is configured with StripANSI: true so the supervisor strips ANSI
 // escape codes from PTY output before forwarding to clients.
 func (p *StdioProvider) IsStripANSI() bool { return p.cfg.StripANSI }
 
+// OutputFilters implements bridge.OutputFilterProvider.
+func (p *StdioProvider) OutputFilters() []string { return p.cfg.OutputFilters }
+
+// MCPConfigFlag implements bridge.MCPConfigProvider.
+func (p *StdioProvider) MCPConfigFlag() string { return p.cfg.MCPConfigFlag }
+
+// ApprovalArgs implements bridge.ApprovalProvider. Only a stream-JSON agent
+// can ask the bridge for approval; PTY agents prompt in their terminal.
+func (p *StdioProvider) ApprovalArgs() []string {
+	if !p.cfg.StreamJSON {
+		return nil
+	}
+	return p.cfg.ApprovalArgs
+}
+
+// StderrRules implements bridge.StderrClassifierProvider.
+func (p *StdioProvider) StderrRules() []bridge.StderrRule { return p.cfg.StderrRules }
+
 func (p *StdioProvider) BuildCommand(ctx context.Context, cfg bridge.SessionConfig) (*exec.Cmd, error) {
-	binPath, err := resolveBinaryPath(p.cfg.Binary, p.cfg.ProviderRoot)
-	if err != nil {
-		return nil, fmt.Errorf("%w: resolve binary %q: %v", bridge.ErrProviderUnavailable, p.cfg.Binary, err)
+	return p.buildCommand(ctx, cfg, nil)
+}
+
+// Resumable reports whether the provider has ResumeArgs that name the
+// thread to resume.
+func (p *StdioProvider) Resumable() bool {
+	return namesThread(p.cfg.ResumeArgs)
+}
+
+// BuildResumeCommand builds the command that relaunches a crashed session
+// on threadID with ResumeArgs.
+func (p *StdioProvider) BuildResumeCommand(ctx context.Context, cfg bridge.SessionConfig, threadID string) (*exec.Cmd, error) {
+	return p.buildCommand(ctx, cfg, threadArgs(p.cfg.ResumeArgs, threadID))
+}
+
+// AssignsThreadID reports whether the provider has ThreadIDArgs that name
+// the new thread.
+func (p *StdioProvider) AssignsThreadID() bool {
+	return namesThread(p.cfg.ThreadIDArgs)
+}
+
+// BuildThreadCommand builds the command that starts a session on a new
+// thread with ID threadID, with ThreadIDArgs.
+func (p *StdioProvider) BuildThreadCommand(ctx context.Context, cfg bridge.SessionConfig, threadID string) (*exec.Cmd, error) {
+	return p.buildCommand(ctx, cfg, threadArgs(p.cfg.ThreadIDArgs, threadID))
+}
+
+// namesThread reports whether args contain ThreadIDArg.
+func namesThread(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool { return strings.Contains(arg, ThreadIDArg) })
+}
+
+// threadArgs returns args with ThreadIDArg replaced by threadID.
+func threadArgs(args []string, threadID string) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = strings.ReplaceAll(arg, ThreadIDArg, threadID)
 	}
-	args, err := resolveCommandArgs(p.cfg.DefaultArgs, p.cfg.ProviderRoot)
-	if err != nil {
-		return nil, fmt.Errorf("%w: resolve args for %q: %v", bridge.ErrProviderUnavailable, p.cfg.ProviderID, err)
+	return out
+}
+
+// buildCommand builds the agent command with extra args placed before the
+// session's approval args, MCP config and "arg:" options.
+func (p *StdioProvider) buildCommand(ctx context.Context, cfg bridge.SessionConfig, extra []string) (*exec.Cmd, error) {
+	args := append([]string(nil), extra...)
+	if cfg.RequireApproval {
+		args = append(args, p.ApprovalArgs()...)
+	}
+	if cfg.MCPConfig != "" && p.cfg.MCPConfigFlag != "" {
+		// The agent runs in the repo, so the relative path also holds
+		// inside a sandbox.
+		args = append(args, p.cfg.MCPConfigFlag, cfg.MCPConfig)
 	}
 	for key, value := range cfg.Options {
 		if strings.HasPrefix(key, "arg:") {
 			args = append(args, value)
 		}
 	}
-	cmd := exec.CommandContext(ctx, binPath, args...)
-	cmd.Dir = cfg.RepoPath
-	cmd.Env = filterEnv(os.Environ())
+	env, err := p.agentEnv(ctx, cfg.Env)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", bridge.ErrProviderUnavailable, err)
+	}
+	cmd, err := p.command(ctx, cfg.SessionID, cfg.RepoPath, env, !p.cfg.StreamJSON, args, p.cfg.Limits.Tighter(cfg.Limits))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", bridge.ErrProviderUnavailable, err)
+	}
 	return cmd, nil
 }
 
 func (p *StdioProvider) ValidateStartup(ctx context.Context) error {
 	for _, envName := range p.cfg.RequiredEnv {
+		if _, ok := p.cfg.SecretEnv[envName]; ok {
+			continue
+		}
 		if strings.TrimSpace(os.Getenv(envName)) == "" {
 			return fmt.Errorf("provider %q requires env var %q", p.cfg.ProviderID, envName)
 		}
@@ -136,49 +289,45 @@ func (p *StdioProvider) validateStartupPrompt(ctx context.Context) error {
 	probeCtx, cancel := context.WithTimeout(ctx, p.cfg.StartupTimeout)
 	defer cancel()
 
-	binPath, err := resolveBinaryPath(p.cfg.Binary, p.cfg.ProviderRoot)
+	wd, _ := os.Getwd()
+	env, err := p.agentEnv(probeCtx, nil)
 	if err != nil {
-		return err
+		return fmt.Errorf("provider %q startup probe: %w", p.cfg.ProviderID, err)
 	}
-	args, err := resolveCommandArgs(p.cfg.DefaultArgs, p.cfg.ProviderRoot)
+	cmd, err := p.command(probeCtx, "", wd, env, true, nil, bridge.ResourceLimits{})
 	if err != nil {
 		return err
 	}
-	wd, _ := os.Getwd()
-	cmd := exec.CommandContext(probeCtx, binPath, args...)
-	cmd.Dir = wd
-	cmd.Env = filterEnv(os.Environ())
 
-	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: 120, Rows: 40})
+	term, err := agentproc.StartTerminal(cmd, 120, 40)
 	if err != nil {
 		return fmt.Errorf("provider %q startup probe: %w", p.cfg.ProviderID, err)
 	}
 	defer func() {
-		_ = ptmx.Close()
+		_ = term.Close()
 		if cmd.Process != nil {
-			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+			_ = agentproc.TerminateProcessGroup(cmd.Process.Pid)
+			agentproc.ReleaseProcessGroup(cmd.Process.Pid)
 		}
 	}()
 
-	buf := make([]byte, 4096)
+	reads := readTerminal(term, probeCtx.Done())
 	var seen bytes.Buffer
 	for {
-		if probeCtx.Err() != nil {
+		var r terminalRead
+		select {
+		case <-probeCtx.Done():
 			return fmt.Errorf("provider %q startup probe timed out waiting for prompt %q; output:\n%s", p.cfg.ProviderID, p.cfg.PromptPattern, seen.String())
+		case r = <-reads:
 		}
-		_ = ptmx.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
-		n, readErr := ptmx.Read(buf)
-		if n > 0 {
-			seen.Write(buf[:n])
+		if len(r.data) > 0 {
+			seen.Write(r.data)
 			if p.promptRe.Match(seen.Bytes()) {
 				return nil
 			}
 		}
-		if readErr != nil {
-			if os.IsTimeout(readErr) {
-				continue
-			}
-			return fmt.Errorf("provider %q startup probe failed: %v; output:\n%s", p.cfg.ProviderID, readErr, seen.String())
+		if r.err != nil {
+			return fmt.Errorf("provider %q startup probe failed: %v; output:\n%s", p.cfg.ProviderID, r.err, seen.String())
 		}
 	}
 }
@@ -187,57 +336,81 @@ func (p *StdioProvider) validateStartupOutput(ctx context.Context) error {
 	probeCtx, cancel := context.WithTimeout(ctx, p.cfg.StartupTimeout)
 	defer cancel()
 
-	binPath, err := resolveBinaryPath(p.cfg.Binary, p.cfg.ProviderRoot)
+	wd, _ := os.Getwd()
+	env, err := p.agentEnv(probeCtx, nil)
 	if err != nil {
-		return err
+		return fmt.Errorf("provider %q startup probe: %w", p.cfg.ProviderID, err)
 	}
-	args, err := resolveCommandArgs(p.cfg.DefaultArgs, p.cfg.ProviderRoot)
+	cmd, err := p.command(probeCtx, "", wd, env, true, nil, bridge.ResourceLimits{})
 	if err != nil {
 		return err
 	}
-	wd, _ := os.Getwd()
-	cmd := exec.CommandContext(probeCtx, binPath, args...)
-	cmd.Dir = wd
-	cmd.Env = filterEnv(os.Environ())
 
-	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: 120, Rows: 40})
+	term, err := agentproc.StartTerminal(cmd, 120, 40)
 	if err != nil {
 		return fmt.Errorf("provider %q startup probe: %w", p.cfg.ProviderID, err)
 	}
 	defer func() {
-		_ = ptmx.Close()
+		_ = term.Close()
 		if cmd.Process != nil {
-			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+			_ = agentproc.TerminateProcessGroup(cmd.Process.Pid)
+			agentproc.ReleaseProcessGroup(cmd.Process.Pid)
 		}
 	}()
 
-	buf := make([]byte, 4096)
+	reads := readTerminal(term, probeCtx.Done())
 	var seen bytes.Buffer
 	for {
-		if probeCtx.Err() != nil {
+		var r terminalRead
+		select {
+		case <-probeCtx.Done():
 			return fmt.Errorf("provider %q startup probe timed out waiting for output; output:\n%s", p.cfg.ProviderID, seen.String())
+		case r = <-reads:
 		}
-		_ = ptmx.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
-		n, readErr := ptmx.Read(buf)
-		if n > 0 {
-			seen.Write(buf[:n])
-			slog.Debug("provider startup probe output", "provider", p.cfg.ProviderID, "bytes", n)
+		if len(r.data) > 0 {
+			seen.Write(r.data)
+			logger().Debug("provider startup probe output", "provider", p.cfg.ProviderID, "bytes", len(r.data))
 			time.Sleep(250 * time.Millisecond)
 			return nil
 		}
-		if readErr != nil {
-			if os.IsTimeout(readErr) {
-				continue
-			}
-			return fmt.Errorf("provider %q startup probe failed: %v; output:\n%s", p.cfg.ProviderID, readErr, seen.String())
+		if r.err != nil {
+			return fmt.Errorf("provider %q startup probe failed: %v; output:\n%s", p.cfg.ProviderID, r.err, seen.String())
 		}
 	}
 }
 
+// terminalRead is one read from a startup probe's terminal.
+type terminalRead struct {
+	data []byte
+	err  error
+}
+
+// readTerminal reads term until a read fails or done is closed, sending
+// each read on the returned channel. A terminal has no read deadline on
+// every platform, so the probes wait on the channel instead.
+func readTerminal(term io.Reader, done <-chan struct{}) <-chan terminalRead {
+	reads := make(chan terminalRead)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := term.Read(buf)
+			select {
+			case reads <- terminalRead{data: bytes.Clone(buf[:n]), err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return reads
+}
+
 func (p *StdioProvider) Version(ctx context.Context) (string, error) {
 	path, err := resolveBinaryPath(p.cfg.Binary, p.cfg.ProviderRoot)
 	if err != nil {
-		return "", fmt.Errorf("binary %q not found: %w", p.cfg.Binary, err)
+		return "", p.binaryNotFound(err)
 	}
 	cmd := exec.CommandContext(ctx, path, "--version")
 	// Use a minimal environment for version probes. Passing auth tokens (e.g.
@@ -259,9 +432,12 @@ func (p *StdioProvider) Health(ctx context.Context) error {
 	if unavailErr != nil {
 		return unavailErr
 	}
-	path, err := resolveBinaryPath(p.cfg.Binary, p.cfg.ProviderRoot)
+	path, err := p.sandboxBinary()
 	if err != nil {
-		return fmt.Errorf("binary %q not found: %w", p.cfg.Binary, err)
+		if p.sandboxed() {
+			return fmt.Errorf("%s sandbox not available: %w", p.cfg.Sandbox, err)
+		}
+		return p.binaryNotFound(err)
 	}
 	info, err := os.Stat(path)
 	if err != nil {
@@ -271,11 +447,14 @@ func (p *StdioProvider) Health(ctx context.Context) error {
 		return fmt.Errorf("binary %q is not executable", path)
 	}
 	for _, envName := range p.cfg.RequiredEnv {
+		if _, ok := p.cfg.SecretEnv[envName]; ok {
+			continue
+		}
 		if strings.TrimSpace(os.Getenv(envName)) == "" {
 			return fmt.Errorf("required env var %s not set", envName)
 		}
 	}
-	return nil
+	return p.CheckVersion(ctx)
 }
 
 // absRoot returns root as an absolute path. If root is already absolute it is
@@ -292,7 +471,8 @@ func absRoot(root string) (string, error) {
 
 // resolveBinaryPath resolves a provider binary to an absolute path. When root
 // is non-empty and binary is a relative path containing a slash, binary is
-// resolved relative to root instead of the process working directory.
+// resolved relative to root instead of the process working directory. A
+// bare name is looked up with LookupBinary.
 func resolveBinaryPath(binary, root string) (string, error) {
 	if strings.Contains(binary, "/") {
 		if filepath.IsAbs(binary) {
@@ -307,7 +487,7 @@ func resolveBinaryPath(binary, root string) (string, error) {
 		}
 		return filepath.Abs(binary)
 	}
-	return exec.LookPath(binary)
+	return LookupBinary(binary)
 }
 
 // resolveCommandArgs converts standalone relative path arguments to absolute
@@ -384,21 +564,61 @@ func versionProbeEnv() []string {
 	return env
 }
 
-// filterEnv returns a filtered environment excluding sensitive variables and
-// variables that interfere with subprocess behaviour.
-func filterEnv(env []string) []string {
-	blocked := map[string]bool{
-		"AWS_SECRET_ACCESS_KEY": true,
-		"AWS_SESSION_TOKEN":     true,
-		"SLACK_BOT_TOKEN":       true,
-		"SLACK_SIGNING_SECRET":  true,
-		"DISCORD_TOKEN":         true,
-		"CLAUDECODE":            true,
+// DefaultEnvAllowlist is what every agent inherits from the daemon
+// environment: enough to find binaries, locate its home and config, render a
+// terminal and reach the network through a proxy, but no credentials.
+var DefaultEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL",
+	"LANG", "LANGUAGE", "LC_*", "TZ",
+	"TERM", "COLORTERM", "NO_COLOR", "FORCE_COLOR",
+	"TMPDIR", "TMP", "TEMP", "XDG_*",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+	"SSL_CERT_FILE", "SSL_CERT_DIR", "NODE_EXTRA_CA_CERTS",
+}
+
+// DefaultEnvBlocklist is never inherited, whatever the allowlist says.
+// CLAUDECODE makes a nested claude CLI believe it runs inside another one.
+var DefaultEnvBlocklist = []string{
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"SLACK_BOT_TOKEN",
+	"SLACK_SIGNING_SECRET",
+	"DISCORD_TOKEN",
+	"CLAUDECODE",
+}
+
+// inheritedEnv returns the part of the daemon environment the provider's
+// agents may see.
+func (p *StdioProvider) inheritedEnv() []string {
+	allow := make([]string, 0, len(DefaultEnvAllowlist)+len(p.cfg.RequiredEnv)+len(p.cfg.EnvAllowlist))
+	allow = append(allow, DefaultEnvAllowlist...)
+	allow = append(allow, p.cfg.RequiredEnv...)
+	allow = append(allow, p.cfg.EnvAllowlist...)
+	block := append(append([]string(nil), DefaultEnvBlocklist...), p.cfg.EnvBlocklist...)
+	return filterEnv(os.Environ(), allow, block)
+}
+
+// agentEnv returns the environment for an agent process: the inherited
+// variables, then the session's env, then the provider's resolved secrets.
+func (p *StdioProvider) agentEnv(ctx context.Context, sessionEnv map[string]string) ([]string, error) {
+	env := mergeEnv(p.inheritedEnv(), sessionEnv)
+	if len(p.cfg.SecretEnv) == 0 {
+		return env, nil
+	}
+	resolved, err := p.cfg.Secrets.ResolveEnv(ctx, p.cfg.SecretEnv)
+	if err != nil {
+		return nil, fmt.Errorf("provider %q secret_env: %w", p.cfg.ProviderID, err)
 	}
+	return mergeEnv(env, resolved), nil
+}
+
+// filterEnv returns the entries of env whose names match allow and not
+// block, with TERM and COLORTERM defaulted for the PTY.
+func filterEnv(env, allow, block []string) []string {
 	filtered := make([]string, 0, len(env))
 	for _, e := range env {
 		key, _, ok := strings.Cut(e, "=")
-		if ok && blocked[key] {
+		if !ok || !bridge.EnvMatch(allow, key) || bridge.EnvMatch(block, key) {
 			continue
 		}
 		filtered = append(filtered, e)
@@ -412,6 +632,32 @@ func filterEnv(env []string) []string {
 	return filtered
 }
 
+// mergeEnv returns env with the entries of extra added, replacing any
+// existing values for the same keys. Extra keys are appended in sorted order
+// so the resulting environment is deterministic.
+func mergeEnv(env []string, extra map[string]string) []string {
+	if len(extra) == 0 {
+		return env
+	}
+	merged := make([]string, 0, len(env)+len(extra))
+	for _, e := range env {
+		key, _, ok := strings.Cut(e, "=")
+		if _, override := extra[key]; ok && override {
+			continue
+		}
+		merged = append(merged, e)
+	}
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		merged = append(merged, key+"="+extra[key])
+	}
+	return merged
+}
+
 func hasEnvKey(env []string, key string) bool {
 	for _, e := range env {
 		k, _, ok := strings.Cut(e, "=")
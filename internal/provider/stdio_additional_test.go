@@ -2,11 +2,16 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/secrets"
 )
 
 func TestNewStdioProviderDefaultsAndHealth(t *testing.T) {
@@ -97,17 +102,89 @@ func TestResolveBinaryPathAndFilterEnv(t *testing.T) {
 	env := filterEnv([]string{
 		"AWS_SECRET_ACCESS_KEY=secret",
 		"KEEP=value",
-	})
-	for _, item := range env {
-		if strings.HasPrefix(item, "AWS_SECRET_ACCESS_KEY=") {
-			t.Fatalf("blocked secret env leaked: %v", env)
-		}
+		"KEEP_PREFIXED=value",
+		"OTHER=value",
+	}, []string{"*"}, []string{"AWS_SECRET_ACCESS_KEY", "KEEP_*"})
+	if hasEnvKey(env, "AWS_SECRET_ACCESS_KEY") || hasEnvKey(env, "KEEP_PREFIXED") {
+		t.Fatalf("blocked env leaked: %v", env)
+	}
+	if !hasEnvKey(env, "KEEP") || !hasEnvKey(env, "OTHER") {
+		t.Fatalf("allowed env dropped: %v", env)
 	}
 	if !hasEnvKey(env, "TERM") || !hasEnvKey(env, "COLORTERM") {
 		t.Fatalf("TERM and COLORTERM were not injected: %v", env)
 	}
 }
 
+func TestInheritedEnvDefaultsToAllowlist(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("LC_ALL", "C")
+	t.Setenv("GITHUB_TOKEN", "ghp-secret")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-secret")
+	t.Setenv("ANTHROPIC_MODEL", "opus")
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "oauth")
+	t.Setenv("CLAUDECODE", "1")
+
+	p := NewStdioProvider(StdioConfig{
+		ProviderID:   "claude",
+		Binary:       "claude",
+		RequiredEnv:  []string{"CLAUDE_CODE_OAUTH_TOKEN"},
+		EnvAllowlist: []string{"ANTHROPIC_*", "CLAUDECODE"},
+		EnvBlocklist: []string{"ANTHROPIC_MODEL"},
+	})
+	env := p.inheritedEnv()
+	for _, key := range []string{"PATH", "LC_ALL", "ANTHROPIC_API_KEY", "CLAUDE_CODE_OAUTH_TOKEN"} {
+		if !hasEnvKey(env, key) {
+			t.Errorf("%s not inherited: %v", key, env)
+		}
+	}
+	for _, key := range []string{"GITHUB_TOKEN", "ANTHROPIC_MODEL", "CLAUDECODE"} {
+		if hasEnvKey(env, key) {
+			t.Errorf("%s inherited: %v", key, env)
+		}
+	}
+}
+
+func TestBuildCommandSecretEnv(t *testing.T) {
+	t.Setenv("BRIDGE_SECRET_ANTHROPIC_API_KEY", "sk-from-vault-0123")
+	var observed []string
+	p := NewStdioProvider(StdioConfig{
+		ProviderID:  "claude",
+		Binary:      "/bin/sh",
+		RequiredEnv: []string{"ANTHROPIC_API_KEY"},
+		SecretEnv:   map[string]string{"ANTHROPIC_API_KEY": "secret://anthropic/api-key"},
+		Secrets: &secrets.Resolver{
+			Backend: secrets.EnvBackend{Prefix: "BRIDGE_SECRET_"},
+			Observe: func(v string) { observed = append(observed, v) },
+		},
+	})
+	if err := p.Health(context.Background()); err != nil {
+		t.Fatalf("required_env backed by a secret not accepted: %v", err)
+	}
+	cmd, err := p.BuildCommand(context.Background(), bridge.SessionConfig{
+		RepoPath: t.TempDir(),
+		Env:      map[string]string{"ANTHROPIC_API_KEY": "caller-override"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand: %v", err)
+	}
+	if !slices.Contains(cmd.Env, "ANTHROPIC_API_KEY=sk-from-vault-0123") || slices.Contains(cmd.Env, "ANTHROPIC_API_KEY=caller-override") {
+		t.Fatalf("secret not injected over session env: %v", cmd.Env)
+	}
+	if hasEnvKey(cmd.Env, "BRIDGE_SECRET_ANTHROPIC_API_KEY") {
+		t.Fatal("secrets backend variable leaked to the agent")
+	}
+	if len(observed) != 1 {
+		t.Fatalf("observed = %v", observed)
+	}
+
+	os.Unsetenv("BRIDGE_SECRET_ANTHROPIC_API_KEY")
+	_, err = p.BuildCommand(context.Background(), bridge.SessionConfig{RepoPath: t.TempDir()})
+	if !errors.Is(err, bridge.ErrProviderUnavailable) || strings.Contains(err.Error(), "sk-from-vault") {
+		t.Fatalf("unresolved secret err = %v", err)
+	}
+}
+
 func TestVersionProbeEnvExcludesAuthTokens(t *testing.T) {
 	// Auth tokens present in the process environment must not appear in the
 	// version probe environment, so that provider binaries which validate
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/markcallen/ai-agent-bridge/internal/bridge"
@@ -39,6 +40,73 @@ func TestBuildCommandIncludesProviderArgs(t *testing.T) {
 	}
 }
 
+func TestBuildCommandPassesMCPConfig(t *testing.T) {
+	cfg := bridge.SessionConfig{ProjectID: "test", SessionID: "session", RepoPath: ".", MCPConfig: ".bridge/mcp/session.json"}
+	p := NewStdioProvider(StdioConfig{ProviderID: "fake", Binary: "/bin/echo", DefaultArgs: []string{"hello"}, MCPConfigFlag: "--mcp-config"})
+	cmd, err := p.BuildCommand(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("BuildCommand: %v", err)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != "hello --mcp-config .bridge/mcp/session.json" {
+		t.Fatalf("args = %q", got)
+	}
+	// A provider without the flag ignores the config.
+	p = NewStdioProvider(StdioConfig{ProviderID: "fake", Binary: "/bin/echo"})
+	if cmd, err = p.BuildCommand(context.Background(), cfg); err != nil || len(cmd.Args) != 1 {
+		t.Fatalf("args = %v, err %v", cmd.Args, err)
+	}
+}
+
+func TestBuildCommandPassesApprovalArgs(t *testing.T) {
+	cfg := bridge.SessionConfig{ProjectID: "test", SessionID: "session", RepoPath: ".", RequireApproval: true}
+	p := NewStdioProvider(StdioConfig{ProviderID: "fake", Binary: "/bin/echo", StreamJSON: true, ApprovalArgs: []string{"--permission-prompt-tool", "stdio"}})
+	cmd, err := p.BuildCommand(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("BuildCommand: %v", err)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != "--permission-prompt-tool stdio" {
+		t.Fatalf("args = %q", got)
+	}
+	// Sessions that do not require approval run without them.
+	cfg.RequireApproval = false
+	if cmd, err = p.BuildCommand(context.Background(), cfg); err != nil || len(cmd.Args) != 1 {
+		t.Fatalf("args = %v, err %v", cmd.Args, err)
+	}
+}
+
+func TestBuildCommandNamesThread(t *testing.T) {
+	cfg := bridge.SessionConfig{ProjectID: "test", SessionID: "session", RepoPath: "."}
+	p := NewStdioProvider(StdioConfig{
+		ProviderID:   "fake",
+		Binary:       "/bin/echo",
+		DefaultArgs:  []string{"hello"},
+		ResumeArgs:   []string{"--resume", ThreadIDArg},
+		ThreadIDArgs: []string{"--session-id=" + ThreadIDArg},
+	})
+	if !p.Resumable() || !p.AssignsThreadID() {
+		t.Fatalf("Resumable=%v AssignsThreadID=%v", p.Resumable(), p.AssignsThreadID())
+	}
+	cmd, err := p.BuildResumeCommand(context.Background(), cfg, "thread-1")
+	if err != nil {
+		t.Fatalf("BuildResumeCommand: %v", err)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != "hello --resume thread-1" {
+		t.Fatalf("resume args = %q", got)
+	}
+	if cmd, err = p.BuildThreadCommand(context.Background(), cfg, "thread-2"); err != nil {
+		t.Fatalf("BuildThreadCommand: %v", err)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != "hello --session-id=thread-2" {
+		t.Fatalf("thread args = %q", got)
+	}
+	// Args that do not name the thread, such as "--continue", would
+	// resume whichever thread ran last in the directory.
+	p = NewStdioProvider(StdioConfig{ProviderID: "fake", Binary: "/bin/echo", ResumeArgs: []string{"--continue"}})
+	if p.Resumable() || p.AssignsThreadID() {
+		t.Fatalf("Resumable=%v AssignsThreadID=%v without a thread argument", p.Resumable(), p.AssignsThreadID())
+	}
+}
+
 func TestBuildCommandAbsolutizesRelativeScriptArgForNode(t *testing.T) {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -184,3 +252,27 @@ func TestBuildCommandResolvesArgsFromProviderRoot(t *testing.T) {
 		t.Fatalf("cmd.Args=%v want second arg %q", cmd.Args, want)
 	}
 }
+
+func TestBuildCommandAppliesSessionEnv(t *testing.T) {
+	t.Setenv("ANTHROPIC_MODEL", "host-default")
+	p := NewStdioProvider(StdioConfig{ProviderID: "fake", Binary: "/bin/echo"})
+
+	cmd, err := p.BuildCommand(context.Background(), bridge.SessionConfig{
+		RepoPath: ".",
+		Env:      map[string]string{"ANTHROPIC_MODEL": "claude-sonnet", "FEATURE_X": "1"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand: %v", err)
+	}
+	got := map[string][]string{}
+	for _, e := range cmd.Env {
+		key, value, _ := strings.Cut(e, "=")
+		got[key] = append(got[key], value)
+	}
+	if v := got["ANTHROPIC_MODEL"]; len(v) != 1 || v[0] != "claude-sonnet" {
+		t.Fatalf("ANTHROPIC_MODEL=%v want [claude-sonnet]", v)
+	}
+	if v := got["FEATURE_X"]; len(v) != 1 || v[0] != "1" {
+		t.Fatalf("FEATURE_X=%v want [1]", v)
+	}
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+)
+
+// VariantSet groups the variants of one provider, e.g. stable and canary
+// builds of its CLI, under one ID. It implements bridge.VariantProvider:
+// sessions run with the provider of the variant they pick. The Provider
+// methods act on the default variant, except Health, which succeeds while
+// any variant is available.
+type VariantSet struct {
+	id       string
+	names    []string // the default first, then the others sorted
+	variants map[string]bridge.Provider
+}
+
+// NewVariantSet returns provider id with variants, of which def is the
+// default. def must be one of them.
+func NewVariantSet(id, def string, variants map[string]bridge.Provider) *VariantSet {
+	names := []string{def}
+	for _, name := range slices.Sorted(maps.Keys(variants)) {
+		if name != def {
+			names = append(names, name)
+		}
+	}
+	return &VariantSet{id: id, names: names, variants: variants}
+}
+
+// Variants implements bridge.VariantProvider.
+func (v *VariantSet) Variants() []string { return slices.Clone(v.names) }
+
+// Variant implements bridge.VariantProvider.
+func (v *VariantSet) Variant(name string) (bridge.Provider, string, error) {
+	if name == "" {
+		name = v.names[0]
+	}
+	p, ok := v.variants[name]
+	if !ok {
+		return nil, "", fmt.Errorf("provider %q has no variant %q (want one of %s)", v.id, name, strings.Join(v.names, ", "))
+	}
+	return p, name, nil
+}
+
+func (v *VariantSet) def() bridge.Provider { return v.variants[v.names[0]] }
+
+func (v *VariantSet) ID() string                    { return v.id }
+func (v *VariantSet) Binary() string                { return v.def().Binary() }
+func (v *VariantSet) PromptPattern() *regexp.Regexp { return v.def().PromptPattern() }
+func (v *VariantSet) StartupTimeout() time.Duration { return v.def().StartupTimeout() }
+func (v *VariantSet) StopGrace() time.Duration      { return v.def().StopGrace() }
+
+func (v *VariantSet) BuildCommand(ctx context.Context, cfg bridge.SessionConfig) (*exec.Cmd, error) {
+	p, _, err := v.Variant(cfg.Options["variant"])
+	if err != nil {
+		return nil, err
+	}
+	return p.BuildCommand(ctx, cfg)
+}
+
+func (v *VariantSet) ValidateStartup(ctx context.Context) error { return v.def().ValidateStartup(ctx) }
+func (v *VariantSet) Version(ctx context.Context) (string, error) {
+	return v.def().Version(ctx)
+}
+
+// Health reports the provider available while any of its variants is. When
+// none is, it returns the errors of all of them.
+func (v *VariantSet) Health(ctx context.Context) error {
+	var errs []error
+	for _, name := range v.names {
+		err := v.variants[name].Health(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("variant %s: %w", name, err))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+)
+
+func TestVariantSet(t *testing.T) {
+	dir := t.TempDir()
+	stable := filepath.Join(dir, "agent-stable")
+	if err := os.WriteFile(stable, []byte("#!/bin/sh\necho 'agent 1.0.0'\n"), 0o755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	set := NewVariantSet("agent", "stable", map[string]bridge.Provider{
+		"stable": NewStdioProvider(StdioConfig{ProviderID: "agent@stable", Binary: stable}),
+		"canary": NewStdioProvider(StdioConfig{ProviderID: "agent@canary", Binary: filepath.Join(dir, "agent-canary")}),
+		"beta":   NewStdioProvider(StdioConfig{ProviderID: "agent@beta", Binary: filepath.Join(dir, "agent-beta")}),
+	})
+
+	if got := set.Variants(); !slices.Equal(got, []string{"stable", "beta", "canary"}) {
+		t.Fatalf("Variants = %v, want the default first", got)
+	}
+	if p, name, err := set.Variant(""); err != nil || name != "stable" || p.Binary() != stable {
+		t.Fatalf("Variant(\"\") = %v, %q, %v; want the stable variant", p, name, err)
+	}
+	if _, _, err := set.Variant("nightly"); err == nil || !strings.Contains(err.Error(), "stable, beta, canary") {
+		t.Fatalf("Variant(nightly) err = %v, want the known variants", err)
+	}
+
+	// The provider is available while one variant is.
+	if err := set.Health(context.Background()); err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	canary, _, _ := set.Variant("canary")
+	if err := canary.Health(context.Background()); err == nil {
+		t.Fatal("Health of the missing canary binary succeeded")
+	}
+	if err := os.Remove(stable); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := set.Health(context.Background()); err == nil || !strings.Contains(err.Error(), "variant canary") {
+		t.Fatalf("Health err = %v, want every variant's error", err)
+	}
+}
@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Version is a dotted release number such as 1.2.3, as printed by a
+// provider's `--version`. Missing parts are zero.
+type Version struct {
+	Major, Minor, Patch int
+	// Pre is the pre-release suffix, e.g. "beta.1" in 1.2.3-beta.1. A
+	// pre-release sorts before the release it precedes.
+	Pre string
+}
+
+// versionNumber matches the first release number in --version output, such
+// as "2.1.3" in "2.1.3 (Claude Code)" or "0.46.0" in "codex-cli 0.46.0".
+var versionNumber = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?`)
+
+// ParseVersion finds the first version number in s.
+func ParseVersion(s string) (Version, error) {
+	m := versionNumber.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("no version number in %q", s)
+	}
+	var v Version
+	for i, dst := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return Version{}, fmt.Errorf("version %q: %w", m[0], err)
+		}
+		*dst = n
+	}
+	v.Pre = m[4]
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 as v sorts before, with or after w.
+func (v Version) Compare(w Version) int {
+	if c := cmp.Or(cmp.Compare(v.Major, w.Major), cmp.Compare(v.Minor, w.Minor), cmp.Compare(v.Patch, w.Patch)); c != 0 {
+		return c
+	}
+	switch {
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	}
+	return cmp.Compare(v.Pre, w.Pre)
+}
+
+// versionCheck is the result of comparing one build of the binary with
+// MinVersion and MaxVersion.
+type versionCheck struct {
+	path    string
+	modTime time.Time
+	size    int64
+	err     error
+}
+
+// checkVersion reports whether the binary at path is within MinVersion and
+// MaxVersion. Its --version output is read once per build: the result is
+// kept until the file changes, so Health stays cheap and still notices an
+// upgrade.
+func (p *StdioProvider) checkVersion(ctx context.Context, path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat %q: %w", path, err)
+	}
+	p.mu.RLock()
+	last := p.version
+	p.mu.RUnlock()
+	if last != nil && last.path == path && last.modTime.Equal(fi.ModTime()) && last.size == fi.Size() {
+		return last.err
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, p.cfg.StartupTimeout)
+	defer cancel()
+	out, err := p.Version(checkCtx)
+	if err != nil {
+		if ctx.Err() != nil {
+			// The caller gave up; try again next time.
+			return err
+		}
+		err = fmt.Errorf("provider %q: %w", p.cfg.ProviderID, err)
+	} else {
+		err = p.versionInRange(out)
+	}
+	p.mu.Lock()
+	p.version = &versionCheck{path: path, modTime: fi.ModTime(), size: fi.Size(), err: err}
+	p.mu.Unlock()
+	if err != nil {
+		logger().Warn("provider version not supported", "provider", p.cfg.ProviderID, "error", err)
+	}
+	return err
+}
+
+// versionInRange checks --version output out against MinVersion and
+// MaxVersion, both inclusive. A MaxVersion without a patch number, such as
+// 1.5, admits every 1.5.x release.
+func (p *StdioProvider) versionInRange(out string) error {
+	v, err := ParseVersion(out)
+	if err != nil {
+		return fmt.Errorf("provider %q: cannot check min_version/max_version: %w", p.cfg.ProviderID, err)
+	}
+	if p.cfg.MinVersion != "" {
+		if lo, err := ParseVersion(p.cfg.MinVersion); err == nil && v.Compare(lo) < 0 {
+			return fmt.Errorf("provider %q: installed version %s is older than min_version %s", p.cfg.ProviderID, v, p.cfg.MinVersion)
+		}
+	}
+	if p.cfg.MaxVersion != "" {
+		if hi, err := ParseVersion(p.cfg.MaxVersion); err == nil {
+			if m := versionNumber.FindStringSubmatch(p.cfg.MaxVersion); m[3] == "" && hi.Pre == "" {
+				hi.Patch = math.MaxInt
+			}
+			if v.Compare(hi) > 0 {
+				return fmt.Errorf("provider %q: installed version %s is newer than max_version %s", p.cfg.ProviderID, v, p.cfg.MaxVersion)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckVersion checks the installed binary against MinVersion and
+// MaxVersion, as Health does. The daemon calls it when it registers the
+// provider so that an unsupported CLI is reported straight away.
+func (p *StdioProvider) CheckVersion(ctx context.Context) error {
+	if p.cfg.MinVersion == "" && p.cfg.MaxVersion == "" {
+		return nil
+	}
+	path, err := resolveBinaryPath(p.cfg.Binary, p.cfg.ProviderRoot)
+	if err != nil {
+		return p.binaryNotFound(err)
+	}
+	return p.checkVersion(ctx, path)
+}
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"2.1.3 (Claude Code)", "2.1.3"},
+		{"codex-cli 0.46.0", "0.46.0"},
+		{"opencode v1.2\n", "1.2.0"},
+		{"tool 3.0.0-beta.2 (build 7)", "3.0.0-beta.2"},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.in)
+		if err != nil || v.String() != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, %v; want %s", tt.in, v, err, tt.want)
+		}
+	}
+	if _, err := ParseVersion("unknown"); err == nil {
+		t.Fatal("ParseVersion without a number succeeded")
+	}
+
+	order := []string{"0.9.9", "1.0.0-beta", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.10.0"}
+	for i := 1; i < len(order); i++ {
+		a, _ := ParseVersion(order[i-1])
+		b, _ := ParseVersion(order[i])
+		if a.Compare(b) >= 0 || b.Compare(a) <= 0 {
+			t.Errorf("%s does not sort before %s", a, b)
+		}
+	}
+}
+
+func TestHealthChecksVersionRange(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "agent")
+	writeAgent := func(version string) {
+		t.Helper()
+		if err := os.WriteFile(bin, []byte("#!/bin/sh\necho 'agent "+version+"'\n"), 0o755); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	tests := []struct {
+		name     string
+		version  string
+		min, max string
+		wantErr  string
+	}{
+		{name: "in range", version: "1.4.2", min: "1.2", max: "1.5"},
+		{name: "max without patch", version: "1.5.9", max: "1.5"},
+		{name: "too old", version: "1.1.0", min: "1.2", wantErr: `installed version 1.1.0 is older than min_version 1.2`},
+		{name: "too new", version: "2.0.0", max: "1.5", wantErr: `installed version 2.0.0 is newer than max_version 1.5`},
+		{name: "pre-release of min", version: "1.2.0-rc.1", min: "1.2.0", wantErr: "older than min_version"},
+		{name: "no version", version: "dev build", min: "1.0", wantErr: `no version number in "agent dev build"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeAgent(tt.version)
+			p := NewStdioProvider(StdioConfig{ProviderID: "agent", Binary: bin, MinVersion: tt.min, MaxVersion: tt.max})
+			err := p.Health(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Health: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Health err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The result is kept until the binary changes.
+	writeAgent("1.0.0")
+	p := NewStdioProvider(StdioConfig{ProviderID: "agent", Binary: bin, MinVersion: "1.2"})
+	if err := p.CheckVersion(context.Background()); err == nil {
+		t.Fatal("CheckVersion of 1.0.0 against min_version 1.2 succeeded")
+	}
+	writeAgent("1.3.0")
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(bin, future, future); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	if err := p.Health(context.Background()); err != nil {
+		t.Fatalf("Health after upgrade: %v", err)
+	}
+}
@@ -1,40 +1,223 @@
 package redact
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 const replacement = "[REDACTED]"
 
+// minValueLen is the shortest value AddValue redacts; shorter strings would
+// match ordinary output.
+const minValueLen = 8
+
+// valuesRule is the Hits key for values added with AddValue.
+const valuesRule = "secret_values"
+
+// Detector is a named pattern for a well-known kind of secret.
+type Detector struct {
+	Name    string
+	Pattern string
+}
+
+// BuiltinDetectors match common API keys and tokens. They are added by
+// NewWithBuiltins ahead of any configured patterns.
+var BuiltinDetectors = []Detector{
+	{Name: "openai_key", Pattern: `\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}`},
+	{Name: "aws_access_key", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "github_token", Pattern: `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`},
+	{Name: "slack_token", Pattern: `\bxox[abposr]-[A-Za-z0-9-]{10,}`},
+	{Name: "private_key", Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+}
+
+type rule struct {
+	name string
+	re   *regexp.Regexp
+	hits atomic.Uint64
+}
+
 // Redactor applies configured regex patterns to redact sensitive content.
+// It is safe for concurrent use.
 type Redactor struct {
-	patterns []*regexp.Regexp
+	rules []*rule
+
+	valuesMu   sync.Mutex
+	values     map[string]bool
+	valuesRe   atomic.Pointer[regexp.Regexp]
+	valuesHits atomic.Uint64
 }
 
 // New compiles redact patterns and returns a redactor.
 func New(patterns []string) (*Redactor, error) {
 	r := &Redactor{
-		patterns: make([]*regexp.Regexp, 0, len(patterns)),
+		rules: make([]*rule, 0, len(patterns)),
 	}
-	for _, pattern := range patterns {
+	for i, pattern := range patterns {
 		re, err := regexp.Compile(pattern)
 		if err != nil {
 			return nil, fmt.Errorf("compile redact pattern %q: %w", pattern, err)
 		}
-		r.patterns = append(r.patterns, re)
+		r.rules = append(r.rules, &rule{name: fmt.Sprintf("redact_patterns[%d]", i), re: re})
+	}
+	return r, nil
+}
+
+// NewWithBuiltins is New with BuiltinDetectors applied first.
+func NewWithBuiltins(patterns []string) (*Redactor, error) {
+	r, err := New(patterns)
+	if err != nil {
+		return nil, err
+	}
+	builtins := make([]*rule, 0, len(BuiltinDetectors)+len(r.rules))
+	for _, d := range BuiltinDetectors {
+		builtins = append(builtins, &rule{name: d.Name, re: regexp.MustCompile(d.Pattern)})
 	}
+	r.rules = append(builtins, r.rules...)
 	return r, nil
 }
 
+// AddValue redacts every later occurrence of value, such as a credential the
+// bridge resolved and handed to an agent. Values shorter than 8 bytes are
+// ignored.
+func (r *Redactor) AddValue(value string) {
+	if r == nil || len(value) < minValueLen {
+		return
+	}
+	r.valuesMu.Lock()
+	defer r.valuesMu.Unlock()
+	if r.values[value] {
+		return
+	}
+	if r.values == nil {
+		r.values = make(map[string]bool)
+	}
+	r.values[value] = true
+	quoted := make([]string, 0, len(r.values))
+	for v := range r.values {
+		quoted = append(quoted, regexp.QuoteMeta(v))
+	}
+	// Longest first so a value is not partly redacted by a prefix of it.
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	r.valuesRe.Store(regexp.MustCompile(strings.Join(quoted, "|")))
+}
+
 // Redact returns text with all configured patterns replaced.
 func (r *Redactor) Redact(text string) string {
-	if r == nil || len(r.patterns) == 0 || text == "" {
+	if r == nil || text == "" {
 		return text
 	}
 	redacted := text
-	for _, re := range r.patterns {
-		redacted = re.ReplaceAllString(redacted, replacement)
+	if re := r.valuesRe.Load(); re != nil {
+		if n := len(re.FindAllStringIndex(redacted, -1)); n > 0 {
+			r.valuesHits.Add(uint64(n))
+			redacted = re.ReplaceAllString(redacted, replacement)
+		}
+	}
+	for _, rl := range r.rules {
+		if n := len(rl.re.FindAllStringIndex(redacted, -1)); n > 0 {
+			rl.hits.Add(uint64(n))
+			redacted = rl.re.ReplaceAllString(redacted, replacement)
+		}
 	}
 	return redacted
 }
+
+// RedactBytes is Redact for byte slices. It returns b itself when nothing
+// matched.
+func (r *Redactor) RedactBytes(b []byte) []byte {
+	if r == nil || len(b) == 0 {
+		return b
+	}
+	redacted := b
+	if re := r.valuesRe.Load(); re != nil {
+		if n := len(re.FindAllIndex(redacted, -1)); n > 0 {
+			r.valuesHits.Add(uint64(n))
+			redacted = re.ReplaceAll(redacted, []byte(replacement))
+		}
+	}
+	for _, rl := range r.rules {
+		if n := len(rl.re.FindAllIndex(redacted, -1)); n > 0 {
+			rl.hits.Add(uint64(n))
+			redacted = rl.re.ReplaceAll(redacted, []byte(replacement))
+		}
+	}
+	return redacted
+}
+
+// RedactJSON is Redact for the string values of a JSON document, so that
+// the result is still valid JSON. It returns data itself when nothing
+// matched, and falls back to RedactBytes when data is not JSON.
+func (r *Redactor) RedactJSON(data []byte) []byte {
+	if r == nil || len(data) == 0 {
+		return data
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return r.RedactBytes(data)
+	}
+	v, changed := r.redactValue(v)
+	if !changed {
+		return data
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return r.RedactBytes(data)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redactValue redacts the strings in a decoded JSON value and reports
+// whether any changed.
+func (r *Redactor) redactValue(v any) (any, bool) {
+	switch t := v.(type) {
+	case string:
+		red := r.Redact(t)
+		return red, red != t
+	case []any:
+		changed := false
+		for i, e := range t {
+			var c bool
+			t[i], c = r.redactValue(e)
+			changed = changed || c
+		}
+		return t, changed
+	case map[string]any:
+		changed := false
+		for k, e := range t {
+			var c bool
+			t[k], c = r.redactValue(e)
+			changed = changed || c
+		}
+		return t, changed
+	}
+	return v, false
+}
+
+// Hits returns how many matches each pattern has redacted, keyed by the
+// detector name or, for configured patterns, "redact_patterns[i]" so the
+// patterns themselves are not disclosed. Patterns that never matched are
+// included with a zero count. Values added with AddValue are counted
+// together as "secret_values".
+func (r *Redactor) Hits() map[string]uint64 {
+	if r == nil {
+		return nil
+	}
+	hits := make(map[string]uint64, len(r.rules))
+	for _, rl := range r.rules {
+		hits[rl.name] += rl.hits.Load()
+	}
+	if r.valuesRe.Load() != nil {
+		hits[valuesRule] = r.valuesHits.Load()
+	}
+	return hits
+}
@@ -0,0 +1,103 @@
+package redact
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"unicode/utf8"
+)
+
+// DefaultStreamWindow is the number of trailing bytes a Stream holds back by
+// default: longer than any built-in detector's secret, PEM private keys up
+// to RSA-4096 included.
+const DefaultStreamWindow = 4096
+
+// Stream redacts text that arrives in pieces, such as agent output read in
+// arbitrary chunks. Redacting each piece on its own misses a secret split
+// across two of them, so a Stream holds back the last window bytes it has
+// seen, and any match that reaches into them, until later writes show
+// where the text ends. Held bytes are released by Flush. A Stream is not
+// safe for concurrent use.
+type Stream struct {
+	r      *Redactor
+	window int
+	also   []*regexp.Regexp
+	held   []byte
+}
+
+// NewStream returns a Stream that redacts with r, holding back window bytes
+// (DefaultStreamWindow when window <= 0). Matches of the also patterns, such
+// as a caller's own content rules, are held back whole like secrets but not
+// redacted. r may be nil for a Stream that only holds them back.
+func (r *Redactor) NewStream(window int, also ...*regexp.Regexp) *Stream {
+	if window <= 0 {
+		window = DefaultStreamWindow
+	}
+	return &Stream{r: r, window: window, also: also}
+}
+
+// Write adds p to the stream and returns the redacted text that can no
+// longer be part of a secret continuing into later writes. It returns nil
+// while everything is still held.
+func (s *Stream) Write(p []byte) []byte {
+	buf := append(s.held, p...)
+	cut := len(buf) - s.window
+	if cut <= 0 {
+		s.held = buf
+		return nil
+	}
+	// A match running into the held tail may be the start of a longer
+	// secret; hold it whole, unless it already fills a window of its own.
+	for _, m := range s.spans(buf) {
+		if m[0] < cut && m[1] > cut && m[0] >= cut-s.window {
+			cut = m[0]
+			break
+		}
+	}
+	// Prefer releasing whole lines, and never release part of a rune.
+	if i := bytes.LastIndexByte(buf[:cut], '\n'); i >= 0 {
+		cut = i + 1
+	} else {
+		for cut > 0 && !utf8.RuneStart(buf[cut]) {
+			cut--
+		}
+	}
+	if cut == 0 {
+		s.held = buf
+		return nil
+	}
+	out := s.r.RedactBytes(buf[:cut])
+	s.held = append(s.held[:0:0], buf[cut:]...)
+	return out
+}
+
+// Flush returns everything the stream still holds, redacted, and empties
+// it. It returns nil when nothing is held.
+func (s *Stream) Flush() []byte {
+	if len(s.held) == 0 {
+		return nil
+	}
+	out := s.r.RedactBytes(s.held)
+	s.held = nil
+	return out
+}
+
+// spans returns the byte ranges in b matched by any rule or added value of
+// the stream's redactor, or by its also patterns, ordered by start. Matches
+// are not counted in Hits.
+func (s *Stream) spans(b []byte) [][]int {
+	var spans [][]int
+	if r := s.r; r != nil {
+		if re := r.valuesRe.Load(); re != nil {
+			spans = append(spans, re.FindAllIndex(b, -1)...)
+		}
+		for _, rl := range r.rules {
+			spans = append(spans, rl.re.FindAllIndex(b, -1)...)
+		}
+	}
+	for _, re := range s.also {
+		spans = append(spans, re.FindAllIndex(b, -1)...)
+	}
+	slices.SortFunc(spans, func(a, b []int) int { return a[0] - b[0] })
+	return spans
+}
@@ -0,0 +1,90 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStreamRedactsAcrossWrites(t *testing.T) {
+	r, err := NewWithBuiltins(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		pieces []string
+		want   string
+	}{
+		{name: "openai key", pieces: []string{"key sk-proj-abcdefgh", "ijklmnopqrstuvwx done\n"}, want: "key [REDACTED] done\n"},
+		{name: "aws key", pieces: []string{"id AKIAIOSF", "ODNN7EXAMPLE\n"}, want: "id [REDACTED]\n"},
+		{name: "no secret", pieces: []string{"plain ", "text"}, want: "plain text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := r.NewStream(0)
+			var got strings.Builder
+			for _, p := range tt.pieces {
+				got.Write(s.Write([]byte(p)))
+			}
+			got.Write(s.Flush())
+			if got.String() != tt.want {
+				t.Fatalf("stream = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamReleasesBeyondWindow(t *testing.T) {
+	r, err := NewWithBuiltins(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := r.NewStream(16)
+	if out := s.Write([]byte("short")); out != nil {
+		t.Fatalf("Write released %q inside the window", out)
+	}
+	// Whole lines before the window are released; the tail stays held.
+	out := s.Write([]byte(" line\nid AKIAIOSFODNN7EXAMP"))
+	if string(out) != "short line\n" {
+		t.Fatalf("Write released %q, want the first line", out)
+	}
+	// A line longer than the window is released up to the window.
+	out = s.Write([]byte("LE and more text after it"))
+	if string(out) != "id [REDACTED] and mo" {
+		t.Fatalf("Write released %q", out)
+	}
+	if out := s.Flush(); string(out) != "re text after it" {
+		t.Fatalf("Flush = %q", out)
+	}
+	if out := s.Flush(); out != nil {
+		t.Fatalf("second Flush = %q, want nothing held", out)
+	}
+}
+
+func TestStreamKeepsRunesWhole(t *testing.T) {
+	r, err := NewWithBuiltins(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := r.NewStream(4)
+	// The window would cut "é" in two; the rune is held with the tail.
+	if out := s.Write([]byte("abcdé123")); string(out) != "abcd" {
+		t.Fatalf("Write released %q", out)
+	}
+	if out := s.Flush(); string(out) != "é123" {
+		t.Fatalf("Flush = %q", out)
+	}
+}
+
+func TestStreamHoldsAlsoPatterns(t *testing.T) {
+	var r *Redactor
+	s := r.NewStream(4, regexp.MustCompile(`leak \d+`))
+	// The match runs into the held tail, so it is held whole and left as is.
+	if out := s.Write([]byte("some text leak 12")); string(out) != "some text " {
+		t.Fatalf("Write released %q", out)
+	}
+	if out := s.Flush(); string(out) != "leak 12" {
+		t.Fatalf("Flush = %q", out)
+	}
+}
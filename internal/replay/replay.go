@@ -0,0 +1,351 @@
+// Package replay plays a recorded session transcript back as if it were an
+// agent, so that clients can be tested against real event sequences without
+// an AI binary. Transcripts are the JSONL rendering of ExportTranscript: the
+// output events between two inputs form one turn, and each turn is played
+// when the next input arrives.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Output formats.
+const (
+	// FormatStreamJSON writes claude stream-json, for providers configured
+	// with stream_json: true. Thinking, tool use, stderr and response
+	// completion are replayed as their own event types.
+	FormatStreamJSON = "stream-json"
+	// FormatRaw writes output text as is, for PTY providers. Thinking and
+	// tool use events are dropped.
+	FormatRaw = "raw"
+)
+
+// Event is one entry of a JSONL transcript.
+type Event struct {
+	Seq       uint64    `json:"seq"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Text      string    `json:"text,omitempty"`
+	Severity  string    `json:"severity,omitempty"`
+	InputID   string    `json:"input_id,omitempty"`
+	// Data is the event's structured detail; a tool_use event's holds
+	// the call's id and input.
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Load reads a JSONL transcript.
+func Load(r io.Reader) ([]Event, error) {
+	var events []Event
+	dec := json.NewDecoder(r)
+	for {
+		var ev Event
+		err := dec.Decode(&ev)
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transcript event %d: %w", len(events)+1, err)
+		}
+		events = append(events, ev)
+	}
+}
+
+// Options control playback.
+type Options struct {
+	// Format is FormatStreamJSON (default) or FormatRaw.
+	Format string
+	// Speed scales the recorded gaps between events: 2 plays twice as
+	// fast. Zero plays without delays.
+	Speed float64
+	// MaxDelay caps a single gap after scaling. Zero leaves gaps uncapped.
+	MaxDelay time.Duration
+}
+
+// turn is the output recorded in response to one input, or before the
+// first input when input is false.
+type turn struct {
+	input  bool
+	start  time.Time // timestamp of the input, or of the first event
+	events []Event
+}
+
+func splitTurns(events []Event) []turn {
+	var turns []turn
+	cur := turn{}
+	for _, ev := range events {
+		if ev.Type == "input" {
+			if cur.input || len(cur.events) > 0 {
+				turns = append(turns, cur)
+			}
+			cur = turn{input: true, start: ev.Timestamp}
+			continue
+		}
+		if cur.start.IsZero() {
+			cur.start = ev.Timestamp
+		}
+		cur.events = append(cur.events, ev)
+	}
+	if cur.input || len(cur.events) > 0 {
+		turns = append(turns, cur)
+	}
+	return turns
+}
+
+// Play replays events: output recorded before the first input is written
+// at once, and each later turn is written when a line arrives on in, with
+// the recorded gaps between events. Inputs that arrive while a turn plays
+// are answered in order. Once the transcript is exhausted, further inputs
+// get an empty response. Play returns when in reaches EOF or ctx is done.
+//
+// With FormatStreamJSON a claude interrupt control request on in cuts the
+// current turn short.
+func Play(ctx context.Context, events []Event, in io.Reader, out, errOut io.Writer, opts Options) error {
+	if opts.Format == "" {
+		opts.Format = FormatStreamJSON
+	}
+	if opts.Format != FormatStreamJSON && opts.Format != FormatRaw {
+		return fmt.Errorf("unsupported replay format %q", opts.Format)
+	}
+	p := &player{out: out, errOut: errOut, opts: opts, lines: make(chan []byte, 64)}
+	go p.readInput(in)
+
+	turns := splitTurns(events)
+	if len(turns) > 0 && !turns[0].input {
+		if err := p.playTurn(ctx, turns[0]); err != nil {
+			return err
+		}
+		turns = turns[1:]
+	}
+	for {
+		if p.pending == 0 {
+			if err := p.waitInput(ctx); err != nil {
+				return ignoreEOF(err)
+			}
+		}
+		p.pending--
+		if len(turns) == 0 {
+			fmt.Fprintln(p.errOut, "replay: transcript exhausted")
+			if err := p.complete(false); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := p.playTurn(ctx, turns[0]); err != nil {
+			return ignoreEOF(err)
+		}
+		turns = turns[1:]
+	}
+}
+
+func ignoreEOF(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+type player struct {
+	out, errOut io.Writer
+	opts        Options
+	lines       chan []byte // input lines; closed at EOF
+	eof         bool
+	// pending counts inputs received and not yet answered.
+	pending int
+}
+
+func (p *player) readInput(in io.Reader) {
+	defer close(p.lines)
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		p.lines <- append([]byte(nil), sc.Bytes()...)
+	}
+}
+
+// receive handles one input line. It reports whether the line is an
+// interrupt request.
+func (p *player) receive(line []byte) bool {
+	if p.opts.Format == FormatStreamJSON && isInterrupt(line) {
+		return true
+	}
+	if len(bytes.TrimSpace(line)) > 0 {
+		p.pending++
+	}
+	return false
+}
+
+// waitInput blocks until an input is pending. It returns io.EOF once input
+// has ended.
+func (p *player) waitInput(ctx context.Context) error {
+	for p.pending == 0 {
+		if p.eof {
+			return io.EOF
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line, ok := <-p.lines:
+			if !ok {
+				p.eof = true
+				continue
+			}
+			p.receive(line)
+		}
+	}
+	return nil
+}
+
+// sleep waits d while taking in new inputs. It returns false when the wait
+// was cut short by an interrupt.
+func (p *player) sleep(ctx context.Context, d time.Duration) (bool, error) {
+	if d <= 0 {
+		return true, nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		lines := p.lines
+		if p.eof {
+			lines = nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-timer.C:
+			return true, nil
+		case line, ok := <-lines:
+			if !ok {
+				p.eof = true
+				continue
+			}
+			if p.receive(line) {
+				return false, nil
+			}
+		}
+	}
+}
+
+// delay returns the scaled gap between two recorded timestamps.
+func (p *player) delay(from, to time.Time) time.Duration {
+	if p.opts.Speed <= 0 || from.IsZero() || to.IsZero() || !to.After(from) {
+		return 0
+	}
+	d := time.Duration(float64(to.Sub(from)) / p.opts.Speed)
+	if p.opts.MaxDelay > 0 {
+		d = min(d, p.opts.MaxDelay)
+	}
+	return d
+}
+
+func (p *player) playTurn(ctx context.Context, t turn) error {
+	last := t.start
+	completed := false
+	for _, ev := range t.events {
+		ok, err := p.sleep(ctx, p.delay(last, ev.Timestamp))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return p.complete(true)
+		}
+		if !ev.Timestamp.IsZero() {
+			last = ev.Timestamp
+		}
+		if err := p.write(ev); err != nil {
+			return err
+		}
+		completed = ev.Type == "response_complete"
+	}
+	// A stream-JSON client waits for the result before sending queued
+	// input, so a turn recorded without one still ends with it.
+	if t.input && !completed {
+		return p.complete(false)
+	}
+	return nil
+}
+
+// write emits one recorded event.
+func (p *player) write(ev Event) error {
+	if ev.Type == "stderr" {
+		_, err := io.WriteString(p.errOut, ev.Text+"\n")
+		return err
+	}
+	if p.opts.Format == FormatRaw {
+		if ev.Type != "output" {
+			return nil
+		}
+		_, err := io.WriteString(p.out, ev.Text)
+		return err
+	}
+	var msg any
+	switch ev.Type {
+	case "output":
+		msg = map[string]any{"type": "content_block_delta", "delta": map[string]string{"type": "text_delta", "text": ev.Text}}
+	case "thinking":
+		msg = map[string]any{"type": "content_block_delta", "delta": map[string]string{"type": "thinking_delta", "thinking": ev.Text}}
+	case "tool_use":
+		block := map[string]any{"type": "tool_use", "name": ev.Text}
+		var call struct {
+			ID    string          `json:"id"`
+			Input json.RawMessage `json:"input"`
+		}
+		if json.Unmarshal(ev.Data, &call) == nil {
+			if call.ID != "" {
+				block["id"] = call.ID
+			}
+			if call.Input != nil {
+				block["input"] = call.Input
+			}
+		}
+		msg = map[string]any{"type": "content_block_start", "content_block": block}
+	case "response_complete":
+		msg = map[string]any{"type": "result", "subtype": "success"}
+	default:
+		// session_restarted and other bridge-generated events are not
+		// agent output.
+		return nil
+	}
+	return p.writeJSON(msg)
+}
+
+// complete ends a response: with a result event in stream-json mode, or
+// nothing in raw mode, where the bridge detects the prompt instead.
+func (p *player) complete(interrupted bool) error {
+	if p.opts.Format != FormatStreamJSON {
+		return nil
+	}
+	subtype := "success"
+	if interrupted {
+		subtype = "error_during_execution"
+	}
+	return p.writeJSON(map[string]any{"type": "result", "subtype": subtype})
+}
+
+func (p *player) writeJSON(msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = p.out.Write(append(data, '\n'))
+	return err
+}
+
+// isInterrupt reports whether line is a claude interrupt control request.
+func isInterrupt(line []byte) bool {
+	var msg struct {
+		Type    string `json:"type"`
+		Request struct {
+			Subtype string `json:"subtype"`
+		} `json:"request"`
+	}
+	if json.Unmarshal(line, &msg) != nil {
+		return false
+	}
+	return msg.Type == "control_request" && msg.Request.Subtype == "interrupt"
+}
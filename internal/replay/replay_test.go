@@ -0,0 +1,165 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+const transcript = `{"seq":1,"type":"output","timestamp":"2026-03-01T12:00:00Z","text":"hello"}
+{"seq":1,"type":"input","timestamp":"2026-03-01T12:00:01Z","text":"first"}
+{"seq":2,"type":"thinking","timestamp":"2026-03-01T12:00:02Z","text":"hmm"}
+{"seq":3,"type":"tool_use","timestamp":"2026-03-01T12:00:03Z","text":"Bash","data":{"id":"toolu_1","name":"Bash","input":{"command":"ls"}}}
+{"seq":4,"type":"stderr","timestamp":"2026-03-01T12:00:03Z","text":"warning: slow","severity":"warning"}
+{"seq":5,"type":"output","timestamp":"2026-03-01T12:00:04Z","text":"done"}
+{"seq":6,"type":"response_complete","timestamp":"2026-03-01T12:00:04Z"}
+{"seq":6,"type":"input","timestamp":"2026-03-01T12:00:10Z","text":"second"}
+{"seq":7,"type":"output","timestamp":"2026-03-01T12:00:11Z","text":"again"}
+`
+
+type streamEvent struct {
+	Type         string            `json:"type"`
+	Subtype      string            `json:"subtype"`
+	Delta        map[string]string `json:"delta"`
+	ContentBlock map[string]any    `json:"content_block"`
+}
+
+// playing starts Play in the background and returns its stdin, a reader of
+// its stdout lines, its stderr and its result.
+func playing(t *testing.T, opts Options) (io.WriteCloser, *bufio.Scanner, *bytes.Buffer, <-chan error) {
+	t.Helper()
+	events, err := Load(strings.NewReader(transcript))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	var errOut bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- Play(context.Background(), events, inR, outW, &errOut, opts)
+		_ = outW.Close()
+	}()
+	t.Cleanup(func() { _ = inW.Close() })
+	return inW, bufio.NewScanner(outR), &errOut, done
+}
+
+func next(t *testing.T, sc *bufio.Scanner) streamEvent {
+	t.Helper()
+	if !sc.Scan() {
+		t.Fatalf("output ended: %v", sc.Err())
+	}
+	var ev streamEvent
+	if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+		t.Fatalf("line %q: %v", sc.Text(), err)
+	}
+	return ev
+}
+
+func TestLoad(t *testing.T) {
+	events, err := Load(strings.NewReader(transcript))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(events) != 9 || events[0].Text != "hello" || !events[0].Timestamp.Equal(t0) || events[3].Type != "tool_use" {
+		t.Fatalf("events=%+v", events)
+	}
+	if _, err := Load(strings.NewReader("{not json}\n")); err == nil {
+		t.Fatal("Load accepted invalid JSON")
+	}
+}
+
+func TestPlayStreamJSON(t *testing.T) {
+	in, out, errOut, done := playing(t, Options{})
+	// Zero Speed plays without delays.
+
+	if ev := next(t, out); ev.Type != "content_block_delta" || ev.Delta["text"] != "hello" {
+		t.Fatalf("preamble=%+v", ev)
+	}
+
+	_, _ = io.WriteString(in, `{"type":"user","message":{"role":"user","content":"first"}}`+"\n")
+	if ev := next(t, out); ev.Delta["type"] != "thinking_delta" || ev.Delta["thinking"] != "hmm" {
+		t.Fatalf("thinking=%+v", ev)
+	}
+	if ev := next(t, out); ev.Type != "content_block_start" || ev.ContentBlock["name"] != "Bash" || ev.ContentBlock["id"] != "toolu_1" || ev.ContentBlock["input"] == nil {
+		t.Fatalf("tool use=%+v", ev)
+	}
+	if ev := next(t, out); ev.Delta["text"] != "done" {
+		t.Fatalf("output=%+v", ev)
+	}
+	if ev := next(t, out); ev.Type != "result" || ev.Subtype != "success" {
+		t.Fatalf("result=%+v", ev)
+	}
+
+	// The second turn was recorded without a result; one is added.
+	_, _ = io.WriteString(in, "second\n")
+	if ev := next(t, out); ev.Delta["text"] != "again" {
+		t.Fatalf("output=%+v", ev)
+	}
+	if ev := next(t, out); ev.Type != "result" {
+		t.Fatalf("result=%+v", ev)
+	}
+
+	_, _ = io.WriteString(in, "third\n")
+	if ev := next(t, out); ev.Type != "result" {
+		t.Fatalf("result after transcript=%+v", ev)
+	}
+	_ = in.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if got := errOut.String(); got != "warning: slow\nreplay: transcript exhausted\n" {
+		t.Fatalf("stderr=%q", got)
+	}
+}
+
+func TestPlayInterrupt(t *testing.T) {
+	// At real speed the first turn waits a second before its first event;
+	// the interrupt cuts it short.
+	in, out, _, done := playing(t, Options{Speed: 1})
+	next(t, out)
+	_, _ = io.WriteString(in, "first\n")
+	start := time.Now()
+	_, _ = io.WriteString(in, `{"type":"control_request","request_id":"r1","request":{"subtype":"interrupt"}}`+"\n")
+	if ev := next(t, out); ev.Type != "result" || ev.Subtype != "error_during_execution" {
+		t.Fatalf("result=%+v", ev)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Fatalf("interrupt took %v", d)
+	}
+	_ = in.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+}
+
+func TestPlayRaw(t *testing.T) {
+	events, err := Load(strings.NewReader(transcript))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	var out, errOut bytes.Buffer
+	in := strings.NewReader("first\nsecond\n")
+	if err := Play(context.Background(), events, in, &out, &errOut, Options{Format: FormatRaw}); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if got := out.String(); got != "hellodoneagain" {
+		t.Fatalf("stdout=%q want only output text", got)
+	}
+	if got := errOut.String(); got != "warning: slow\n" {
+		t.Fatalf("stderr=%q", got)
+	}
+}
+
+func TestPlayRejectsUnknownFormat(t *testing.T) {
+	if err := Play(context.Background(), nil, strings.NewReader(""), io.Discard, io.Discard, Options{Format: "xml"}); err == nil {
+		t.Fatal("Play accepted an unknown format")
+	}
+}
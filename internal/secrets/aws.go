@@ -0,0 +1,157 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSBackend reads secrets from AWS Secrets Manager. The path
+// "anthropic/api-key" is the "api-key" key of the JSON secret "anthropic".
+// Requests are signed with Signature Version 4.
+type AWSBackend struct {
+	Region string
+	// Endpoint overrides the regional endpoint, e.g. for a VPC endpoint.
+	Endpoint string
+	// Credentials, when set, supplies the credentials for each lookup,
+	// such as an AWSCredentialChain. Otherwise AccessKeyID, SecretAccessKey
+	// and SessionToken are used; SessionToken is only needed for temporary
+	// credentials.
+	Credentials     AWSCredentialProvider
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Client is used for requests. Nil uses http.DefaultClient.
+	Client *http.Client
+
+	now func() time.Time
+}
+
+func (b AWSBackend) Lookup(ctx context.Context, path string) (string, error) {
+	name, key, err := splitKey(path)
+	if err != nil {
+		return "", err
+	}
+	creds := AWSCredentials{AccessKeyID: b.AccessKeyID, SecretAccessKey: b.SecretAccessKey, SessionToken: b.SessionToken}
+	if b.Credentials != nil {
+		if creds, err = b.Credentials.Retrieve(ctx); err != nil {
+			return "", fmt.Errorf("aws secrets manager: %w", err)
+		}
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return "", errors.New("aws secrets manager: no credentials")
+	}
+	if b.Region == "" {
+		return "", errors.New("aws secrets manager: no region")
+	}
+	endpoint := b.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + b.Region + ".amazonaws.com"
+	}
+	payload, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("aws request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	now := time.Now
+	if b.now != nil {
+		now = b.now
+	}
+	b.sign(req, payload, now().UTC(), creds)
+
+	body, err := fetch(b.Client, req, "aws secrets manager")
+	if err != nil {
+		// Secrets Manager reports a missing secret as 400 with this type.
+		if bytes.Contains(body, []byte("ResourceNotFoundException")) {
+			return "", fmt.Errorf("%w: aws secret %q", ErrNotFound, name)
+		}
+		return "", err
+	}
+	var resp struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("parse aws response: %w", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(resp.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("aws secret %q is not a JSON object", name)
+	}
+	value, ok := fields[key].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("%w: aws secret %q has no key %q", ErrNotFound, name, key)
+	}
+	return value, nil
+}
+
+// sign adds Signature Version 4 headers for the secretsmanager service.
+func (b AWSBackend) sign(req *http.Request, payload []byte, t time.Time, creds AWSCredentials) {
+	const service = "secretsmanager"
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	signed := []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date", "x-amz-target"}
+	if creds.SessionToken != "" {
+		signed = append(signed, "x-amz-security-token")
+		sort.Strings(signed)
+	}
+	var canonicalHeaders strings.Builder
+	for _, h := range signed {
+		v := req.Header.Get(h)
+		if h == "host" {
+			v = req.URL.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(v) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + b.Region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, b.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
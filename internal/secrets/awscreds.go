@@ -0,0 +1,321 @@
+package secrets
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// metadataTimeout bounds a request to the EC2 or container metadata
+	// endpoints, which are only reachable on AWS.
+	metadataTimeout = 2 * time.Second
+	// credentialRefreshWindow is how long before expiry temporary
+	// credentials are fetched again.
+	credentialRefreshWindow = 5 * time.Minute
+
+	defaultIMDSEndpoint      = "http://169.254.169.254"
+	defaultContainerEndpoint = "http://169.254.170.2"
+)
+
+// AWSCredentials sign requests to AWS. SessionToken and Expires are set for
+// temporary credentials only.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time
+}
+
+// AWSCredentialProvider returns the credentials to sign the next request
+// with. AWSBackend calls it for every lookup.
+type AWSCredentialProvider interface {
+	Retrieve(ctx context.Context) (AWSCredentials, error)
+}
+
+// AWSCredentialChain finds credentials the way the AWS SDKs do, trying in
+// order:
+//
+//  1. AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+//  2. the AWS_PROFILE (or "default") profile of the shared credentials file,
+//     AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials
+//  3. web identity (EKS IRSA): AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN,
+//     exchanged with STS AssumeRoleWithWebIdentity
+//  4. the ECS container endpoint, AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or
+//     AWS_CONTAINER_CREDENTIALS_FULL_URI
+//  5. the EC2 instance role, through IMDSv2, unless
+//     AWS_EC2_METADATA_DISABLED is "true"
+//
+// Environment and file credentials are read again on every Retrieve, so
+// rotated keys are picked up. Temporary credentials from the other sources
+// are cached until shortly before they expire.
+type AWSCredentialChain struct {
+	// Client is used for STS and metadata requests. Nil uses
+	// http.DefaultClient.
+	Client *http.Client
+	// Getenv reads the environment. Nil uses os.Getenv.
+	Getenv func(string) string
+
+	mu     sync.Mutex
+	cached AWSCredentials
+}
+
+func (c *AWSCredentialChain) Retrieve(ctx context.Context) (AWSCredentials, error) {
+	if creds, ok := c.static(); ok {
+		return creds, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached.AccessKeyID != "" && time.Until(c.cached.Expires) > credentialRefreshWindow {
+		return c.cached, nil
+	}
+	creds, err := c.temporary(ctx)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	c.cached = creds
+	return creds, nil
+}
+
+func (c *AWSCredentialChain) getenv(key string) string {
+	if c.Getenv != nil {
+		return c.Getenv(key)
+	}
+	return os.Getenv(key)
+}
+
+// static returns long-lived credentials from the environment or the shared
+// credentials file.
+func (c *AWSCredentialChain) static() (AWSCredentials, bool) {
+	if id, secret := c.getenv("AWS_ACCESS_KEY_ID"), c.getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return AWSCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: c.getenv("AWS_SESSION_TOKEN")}, true
+	}
+	path := c.getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home := c.getenv("HOME")
+		if home == "" {
+			return AWSCredentials{}, false
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	profile := c.getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	creds, err := readSharedCredentials(path, profile)
+	if err != nil || creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return AWSCredentials{}, false
+	}
+	return creds, true
+}
+
+// temporary fetches expiring credentials from web identity, the container
+// endpoint or the instance role, whichever is configured first.
+func (c *AWSCredentialChain) temporary(ctx context.Context) (AWSCredentials, error) {
+	if tokenFile, role := c.getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), c.getenv("AWS_ROLE_ARN"); tokenFile != "" && role != "" {
+		return c.webIdentity(ctx, tokenFile, role)
+	}
+	if rel, full := c.getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"), c.getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); rel != "" || full != "" {
+		endpoint := full
+		if rel != "" {
+			endpoint = defaultContainerEndpoint + rel
+		}
+		return c.container(ctx, endpoint)
+	}
+	if strings.EqualFold(c.getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return AWSCredentials{}, errors.New("aws: no credentials found")
+	}
+	creds, err := c.instanceRole(ctx)
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("aws: no credentials found (instance role: %w)", err)
+	}
+	return creds, nil
+}
+
+// webIdentity exchanges the token in tokenFile for credentials of role.
+// The token file is read on every call since it is rotated in place.
+func (c *AWSCredentialChain) webIdentity(ctx context.Context, tokenFile, role string) (AWSCredentials, error) {
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("aws web identity token: %w", err)
+	}
+	endpoint := c.getenv("AWS_ENDPOINT_URL_STS")
+	if endpoint == "" {
+		endpoint = "https://sts.amazonaws.com"
+		if region := c.getenv("AWS_REGION"); region != "" {
+			endpoint = "https://sts." + region + ".amazonaws.com"
+		}
+	}
+	session := c.getenv("AWS_ROLE_SESSION_NAME")
+	if session == "" {
+		session = "ai-agent-bridge"
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {role},
+		"RoleSessionName":  {session},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("sts request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body, err := fetch(c.Client, req, "sts")
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	var resp struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return AWSCredentials{}, fmt.Errorf("parse sts response: %w", err)
+	}
+	creds := resp.Credentials
+	if creds.AccessKeyID == "" {
+		return AWSCredentials{}, errors.New("sts response has no credentials")
+	}
+	return AWSCredentials{AccessKeyID: creds.AccessKeyID, SecretAccessKey: creds.SecretAccessKey, SessionToken: creds.SessionToken, Expires: creds.Expiration}, nil
+}
+
+// container fetches the ECS task role's credentials from endpoint.
+func (c *AWSCredentialChain) container(ctx context.Context, endpoint string) (AWSCredentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("container credentials request: %w", err)
+	}
+	auth := c.getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := c.getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return AWSCredentials{}, fmt.Errorf("container authorization token: %w", err)
+		}
+		auth = strings.TrimSpace(string(b))
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	body, err := fetch(c.Client, req, "container credentials")
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	return parseMetadataCredentials(body)
+}
+
+// instanceRole fetches the EC2 instance role's credentials through IMDSv2.
+func (c *AWSCredentialChain) instanceRole(ctx context.Context) (AWSCredentials, error) {
+	endpoint := strings.TrimRight(c.getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), "/")
+	if endpoint == "" {
+		endpoint = defaultIMDSEndpoint
+	}
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "300")
+	token, err := fetch(c.Client, req, "imds")
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	get := func(path string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+		return fetch(c.Client, req, "imds")
+	}
+	const credsPath = "/latest/meta-data/iam/security-credentials/"
+	roles, err := get(credsPath)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+	if role == "" {
+		return AWSCredentials{}, errors.New("imds: instance has no role")
+	}
+	body, err := get(credsPath + url.PathEscape(role))
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	return parseMetadataCredentials(body)
+}
+
+// parseMetadataCredentials parses the credentials document served by the
+// EC2 and container metadata endpoints.
+func parseMetadataCredentials(body []byte) (AWSCredentials, error) {
+	var doc struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return AWSCredentials{}, fmt.Errorf("parse credentials response: %w", err)
+	}
+	if doc.AccessKeyID == "" || doc.SecretAccessKey == "" {
+		return AWSCredentials{}, errors.New("credentials response has no keys")
+	}
+	return AWSCredentials{AccessKeyID: doc.AccessKeyID, SecretAccessKey: doc.SecretAccessKey, SessionToken: doc.Token, Expires: doc.Expiration}, nil
+}
+
+// readSharedCredentials reads one profile of an AWS shared credentials file.
+func readSharedCredentials(path, profile string) (AWSCredentials, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	defer f.Close()
+	var creds AWSCredentials
+	in := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "["); ok {
+			in = strings.TrimSpace(strings.TrimSuffix(name, "]")) == profile
+			continue
+		}
+		if !in {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = value
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = value
+		case "aws_session_token":
+			creds.SessionToken = value
+		}
+	}
+	return creds, scanner.Err()
+}
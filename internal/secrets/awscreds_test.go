@@ -0,0 +1,145 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// envMap is an AWSCredentialChain.Getenv backed by a map.
+type envMap map[string]string
+
+func (e envMap) get(key string) string { return e[key] }
+
+func TestAWSCredentialChainStatic(t *testing.T) {
+	env := envMap{"AWS_EC2_METADATA_DISABLED": "true", "HOME": t.TempDir()}
+	chain := &AWSCredentialChain{Getenv: env.get}
+	if _, err := chain.Retrieve(context.Background()); err == nil {
+		t.Fatal("Retrieve found credentials in an empty environment")
+	}
+
+	// The shared credentials file is read on every call.
+	path := filepath.Join(env["HOME"], ".aws", "credentials")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	write := func(id string) {
+		t.Helper()
+		content := fmt.Sprintf("[default]\naws_access_key_id = AKIDDEFAULT\naws_secret_access_key = default-secret\n\n[ci]\naws_access_key_id = %s\naws_secret_access_key = ci-secret\naws_session_token = ci-token\n", id)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("AKIDCI1")
+	env["AWS_PROFILE"] = "ci"
+	creds, err := chain.Retrieve(context.Background())
+	if err != nil || creds != (AWSCredentials{AccessKeyID: "AKIDCI1", SecretAccessKey: "ci-secret", SessionToken: "ci-token"}) {
+		t.Fatalf("file credentials = %+v, %v", creds, err)
+	}
+	write("AKIDCI2")
+	if creds, err := chain.Retrieve(context.Background()); err != nil || creds.AccessKeyID != "AKIDCI2" {
+		t.Fatalf("rotated file credentials = %+v, %v", creds, err)
+	}
+
+	// The environment wins over the file.
+	env["AWS_ACCESS_KEY_ID"], env["AWS_SECRET_ACCESS_KEY"] = "AKIDENV", "env-secret"
+	if creds, err := chain.Retrieve(context.Background()); err != nil || creds.AccessKeyID != "AKIDENV" || creds.SessionToken != "" {
+		t.Fatalf("env credentials = %+v, %v", creds, err)
+	}
+}
+
+func TestAWSCredentialChainWebIdentity(t *testing.T) {
+	var calls atomic.Int32
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_ = r.ParseForm()
+		if r.Form.Get("Action") != "AssumeRoleWithWebIdentity" || r.Form.Get("RoleArn") != "arn:aws:iam::123:role/bridge" || r.Form.Get("WebIdentityToken") != "jwt-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>ASIAWEB</AccessKeyId><SecretAccessKey>web-secret</SecretAccessKey><SessionToken>web-token</SessionToken><Expiration>%s</Expiration>
+</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`, expires.Format(time.RFC3339))
+	}))
+	defer srv.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("jwt-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := envMap{
+		"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile,
+		"AWS_ROLE_ARN":                "arn:aws:iam::123:role/bridge",
+		"AWS_ENDPOINT_URL_STS":        srv.URL,
+	}
+	chain := &AWSCredentialChain{Client: srv.Client(), Getenv: env.get}
+	for range 2 {
+		creds, err := chain.Retrieve(context.Background())
+		if err != nil {
+			t.Fatalf("Retrieve: %v", err)
+		}
+		if creds.AccessKeyID != "ASIAWEB" || creds.SessionToken != "web-token" || !creds.Expires.Equal(expires) {
+			t.Fatalf("credentials = %+v", creds)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("STS called %d times, want the credentials cached", n)
+	}
+
+	// Credentials about to expire are fetched again.
+	chain.cached.Expires = time.Now().Add(time.Minute)
+	if _, err := chain.Retrieve(context.Background()); err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("STS called %d times after expiry, want 2", n)
+	}
+}
+
+func TestAWSCredentialChainMetadata(t *testing.T) {
+	const doc = `{"Code":"Success","AccessKeyId":"ASIAMETA","SecretAccessKey":"meta-secret","Token":"meta-token","Expiration":"2099-01-01T00:00:00Z"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			_, _ = io.WriteString(w, "imds-token")
+		case r.Header.Get("X-Aws-Ec2-Metadata-Token") != "imds-token" && r.URL.Path != "/task":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			_, _ = io.WriteString(w, "bridge-role\n")
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/bridge-role":
+			_, _ = io.WriteString(w, doc)
+		case r.URL.Path == "/task" && r.Header.Get("Authorization") == "task-auth":
+			_, _ = io.WriteString(w, doc)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		env  envMap
+	}{
+		{name: "instance role", env: envMap{"AWS_EC2_METADATA_SERVICE_ENDPOINT": srv.URL}},
+		{name: "container", env: envMap{"AWS_CONTAINER_CREDENTIALS_FULL_URI": srv.URL + "/task", "AWS_CONTAINER_AUTHORIZATION_TOKEN": "task-auth"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := &AWSCredentialChain{Client: srv.Client(), Getenv: tt.env.get}
+			creds, err := chain.Retrieve(context.Background())
+			if err != nil {
+				t.Fatalf("Retrieve: %v", err)
+			}
+			if creds.AccessKeyID != "ASIAMETA" || creds.SecretAccessKey != "meta-secret" || creds.SessionToken != "meta-token" {
+				t.Fatalf("credentials = %+v", creds)
+			}
+		})
+	}
+}
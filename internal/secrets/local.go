@@ -0,0 +1,75 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxFileSecretSize bounds a secret read by FileBackend.
+const maxFileSecretSize = 64 << 10
+
+// EnvBackend reads secrets from the daemon's environment. The path
+// "anthropic/api-key" is the variable Prefix+"ANTHROPIC_API_KEY".
+type EnvBackend struct {
+	Prefix string
+}
+
+// EnvName returns the variable that holds path.
+func (b EnvBackend) EnvName(path string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, path)
+	return b.Prefix + name
+}
+
+func (b EnvBackend) Lookup(_ context.Context, path string) (string, error) {
+	value, ok := os.LookupEnv(b.EnvName(path))
+	if !ok || value == "" {
+		return "", fmt.Errorf("%w: %s is not set", ErrNotFound, b.EnvName(path))
+	}
+	return value, nil
+}
+
+// FileBackend reads secrets from files under Dir: "anthropic/api-key" is
+// Dir/anthropic/api-key. Trailing newlines are trimmed.
+type FileBackend struct {
+	Dir string
+}
+
+func (b FileBackend) Lookup(_ context.Context, path string) (string, error) {
+	root, err := os.OpenRoot(b.Dir)
+	if err != nil {
+		return "", fmt.Errorf("open secrets dir: %w", err)
+	}
+	defer root.Close()
+	f, err := root.Open(filepath.FromSlash(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		return "", err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxFileSecretSize+1))
+	if err != nil {
+		return "", fmt.Errorf("read secret %s: %w", path, err)
+	}
+	if len(data) > maxFileSecretSize {
+		return "", fmt.Errorf("secret %s exceeds %d bytes", path, maxFileSecretSize)
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("%w: %s is empty", ErrNotFound, path)
+	}
+	return value, nil
+}
@@ -0,0 +1,107 @@
+// Package secrets resolves secret:// references in provider config against
+// a secrets backend, so credentials can be handed to agent processes without
+// living in the daemon's environment or config file.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Scheme prefixes a secret reference, e.g. "secret://anthropic/api-key".
+const Scheme = "secret://"
+
+// ErrNotFound is returned when a backend has no secret at the path.
+var ErrNotFound = errors.New("secret not found")
+
+// Backend looks up secrets by path, the part of a reference after Scheme.
+type Backend interface {
+	Lookup(ctx context.Context, path string) (string, error)
+}
+
+// IsRef reports whether v is a secret reference.
+func IsRef(v string) bool {
+	return strings.HasPrefix(v, Scheme)
+}
+
+// ParseRef returns the path of a secret reference. Paths are slash-separated
+// names of letters, digits, '.', '_' and '-'; empty, "." and ".." elements
+// are rejected.
+func ParseRef(ref string) (string, error) {
+	path, ok := strings.CutPrefix(ref, Scheme)
+	if !ok {
+		return "", fmt.Errorf("secret reference %q must start with %s", ref, Scheme)
+	}
+	if path == "" {
+		return "", fmt.Errorf("secret reference %q has no path", ref)
+	}
+	for _, elem := range strings.Split(path, "/") {
+		if elem == "" || elem == "." || elem == ".." {
+			return "", fmt.Errorf("secret reference %q has an invalid path", ref)
+		}
+		for _, r := range elem {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+				return "", fmt.Errorf("secret reference %q contains %q", ref, r)
+			}
+		}
+	}
+	return path, nil
+}
+
+// splitKey splits a path into the secret holding several values and the
+// key of one of them: "anthropic/api-key" is key "api-key" of secret
+// "anthropic".
+func splitKey(path string) (name, key string, err error) {
+	i := strings.LastIndexByte(path, '/')
+	if i < 0 {
+		return "", "", fmt.Errorf("secret path %q must be <name>/<key>", path)
+	}
+	return path[:i], path[i+1:], nil
+}
+
+// Resolver resolves references against a Backend.
+type Resolver struct {
+	Backend Backend
+	// Observe, when set, is called with every resolved value, for example
+	// to have the log redactor scrub it.
+	Observe func(value string)
+}
+
+// Resolve returns the value a reference names. Errors never include the
+// value.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	path, err := ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	if r == nil || r.Backend == nil {
+		return "", fmt.Errorf("resolve %s: no secrets backend configured", ref)
+	}
+	value, err := r.Backend.Lookup(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", ref, err)
+	}
+	if r.Observe != nil {
+		r.Observe(value)
+	}
+	return value, nil
+}
+
+// ResolveEnv resolves every reference in env, a map of variable name to
+// reference, and returns the variables with their values.
+func (r *Resolver) ResolveEnv(ctx context.Context, env map[string]string) (map[string]string, error) {
+	if len(env) == 0 {
+		return nil, nil
+	}
+	resolved := make(map[string]string, len(env))
+	for name, ref := range env {
+		value, err := r.Resolve(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		resolved[name] = value
+	}
+	return resolved, nil
+}
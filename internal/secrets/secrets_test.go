@@ -0,0 +1,165 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRef(t *testing.T) {
+	if path, err := ParseRef("secret://anthropic/api-key"); err != nil || path != "anthropic/api-key" {
+		t.Fatalf("ParseRef = %q, %v", path, err)
+	}
+	for _, ref := range []string{"anthropic/api-key", "secret://", "secret://a//b", "secret://a/../b", "secret:///a", "secret://a/b?c"} {
+		if _, err := ParseRef(ref); err == nil {
+			t.Errorf("ParseRef(%q) succeeded", ref)
+		}
+	}
+}
+
+func TestResolverEnvBackend(t *testing.T) {
+	t.Setenv("BRIDGE_SECRET_ANTHROPIC_API_KEY", "sk-from-env")
+	var observed []string
+	r := &Resolver{
+		Backend: EnvBackend{Prefix: "BRIDGE_SECRET_"},
+		Observe: func(v string) { observed = append(observed, v) },
+	}
+	env, err := r.ResolveEnv(context.Background(), map[string]string{"ANTHROPIC_API_KEY": "secret://anthropic/api-key"})
+	if err != nil {
+		t.Fatalf("ResolveEnv: %v", err)
+	}
+	if env["ANTHROPIC_API_KEY"] != "sk-from-env" || len(observed) != 1 {
+		t.Fatalf("env = %v observed = %v", env, observed)
+	}
+	_, err = r.Resolve(context.Background(), "secret://openai/api-key")
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "BRIDGE_SECRET_OPENAI_API_KEY") {
+		t.Fatalf("missing secret err = %v", err)
+	}
+}
+
+func TestFileBackend(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "anthropic"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "anthropic", "api-key"), []byte("sk-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc/passwd", filepath.Join(dir, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	b := FileBackend{Dir: dir}
+	if v, err := b.Lookup(context.Background(), "anthropic/api-key"); err != nil || v != "sk-from-file" {
+		t.Fatalf("Lookup = %q, %v", v, err)
+	}
+	if _, err := b.Lookup(context.Background(), "anthropic/missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing err = %v", err)
+	}
+	if _, err := b.Lookup(context.Background(), "escape"); err == nil {
+		t.Fatal("Lookup followed a symlink out of the secrets dir")
+	}
+}
+
+func TestVaultBackend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/anthropic" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"data":{"api-key":"sk-from-vault"},"metadata":{"version":3}}}`)
+	}))
+	defer srv.Close()
+
+	b := VaultBackend{Address: srv.URL, Mount: "kv", Token: "vault-token", Namespace: "team", Client: srv.Client()}
+	if v, err := b.Lookup(context.Background(), "anthropic/api-key"); err != nil || v != "sk-from-vault" {
+		t.Fatalf("Lookup = %q, %v", v, err)
+	}
+	if _, err := b.Lookup(context.Background(), "anthropic/other"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing field err = %v", err)
+	}
+	if _, err := b.Lookup(context.Background(), "openai/api-key"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing secret err = %v", err)
+	}
+	b.Token = "wrong"
+	if _, err := b.Lookup(context.Background(), "anthropic/api-key"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("forbidden err = %v", err)
+	}
+
+	// TokenFunc is asked for the current token on every lookup.
+	token := "wrong"
+	b.TokenFunc = func() (string, error) { return token, nil }
+	if _, err := b.Lookup(context.Background(), "anthropic/api-key"); err == nil {
+		t.Fatal("Lookup succeeded with a stale token")
+	}
+	token = "vault-token"
+	if v, err := b.Lookup(context.Background(), "anthropic/api-key"); err != nil || v != "sk-from-vault" {
+		t.Fatalf("Lookup with renewed token = %q, %v", v, err)
+	}
+}
+
+func TestAWSBackend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260301/us-east-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token;x-amz-target, Signature=") {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		var req struct{ SecretId string }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.SecretId != "anthropic" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"__type":"ResourceNotFoundException"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"Name":"anthropic","SecretString":"{\"api-key\":\"sk-from-aws\"}"}`)
+	}))
+	defer srv.Close()
+
+	b := AWSBackend{
+		Region:          "us-east-1",
+		Endpoint:        srv.URL,
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+		Client:          srv.Client(),
+		now:             func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) },
+	}
+	if v, err := b.Lookup(context.Background(), "anthropic/api-key"); err != nil || v != "sk-from-aws" {
+		t.Fatalf("Lookup = %q, %v", v, err)
+	}
+	if _, err := b.Lookup(context.Background(), "openai/api-key"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing secret err = %v", err)
+	}
+}
+
+func TestAWSSign(t *testing.T) {
+	// Expected signature computed independently from the SigV4 spec.
+	req := httptest.NewRequest(http.MethodPost, "https://secretsmanager.us-east-1.amazonaws.com/", nil)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	b := AWSBackend{Region: "us-east-1"}
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	b.sign(req, []byte(`{"SecretId":"anthropic"}`), time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC), creds)
+	auth := req.Header.Get("Authorization")
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-target, Signature=98a4b142e7a78c267ccc8e8a31935f5efad53191be798676c2655a6e797043d8"
+	if auth != want {
+		t.Fatalf("Authorization = %q", auth)
+	}
+	if req.Header.Get("X-Amz-Date") != "20150830T123600Z" {
+		t.Fatalf("X-Amz-Date = %q", req.Header.Get("X-Amz-Date"))
+	}
+}
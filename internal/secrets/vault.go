@@ -0,0 +1,122 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// maxResponseSize bounds a secrets API response.
+	maxResponseSize = 1 << 20
+	// fetchTimeout bounds a single secrets API request.
+	fetchTimeout = 10 * time.Second
+)
+
+// VaultBackend reads secrets from a HashiCorp Vault KV version 2 engine.
+// The path "anthropic/api-key" is the "api-key" field of the secret
+// "anthropic" under Mount.
+type VaultBackend struct {
+	// Address is the Vault server URL, e.g. "https://vault.example.com:8200".
+	Address string
+	// Mount is the KV engine's mount path. Empty uses "secret".
+	Mount string
+	// Token authenticates the requests. TokenFunc, when set, is called for
+	// every lookup instead, so a token renewed or replaced outside the
+	// daemon (for example by Vault Agent) is picked up.
+	Token     string
+	TokenFunc func() (string, error)
+	// Namespace is sent as X-Vault-Namespace when set (Vault Enterprise).
+	Namespace string
+	// Client is used for requests. Nil uses http.DefaultClient.
+	Client *http.Client
+}
+
+func (b VaultBackend) Lookup(ctx context.Context, path string) (string, error) {
+	name, key, err := splitKey(path)
+	if err != nil {
+		return "", err
+	}
+	token := b.Token
+	if b.TokenFunc != nil {
+		if token, err = b.TokenFunc(); err != nil {
+			return "", fmt.Errorf("vault token: %w", err)
+		}
+	}
+	if token == "" {
+		return "", errors.New("vault: no token")
+	}
+	mount := strings.Trim(b.Mount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	endpoint := strings.TrimRight(b.Address, "/") + "/v1/" + mount + "/data/" + name
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if b.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.Namespace)
+	}
+	body, err := fetch(b.Client, req, "vault")
+	if err != nil {
+		return "", err
+	}
+	var doc struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "", fmt.Errorf("parse vault response: %w", err)
+	}
+	value, ok := doc.Data.Data[key].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("%w: vault secret %q has no field %q", ErrNotFound, name, key)
+	}
+	return value, nil
+}
+
+// fetch sends req and returns the response body, mapping 404 to ErrNotFound.
+// Error bodies are not included in errors since they may echo request data.
+func fetch(client *http.Client, req *http.Request, service string) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// url.Error repeats the URL, which holds nothing secret.
+		return nil, fmt.Errorf("%s request: %w", service, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s response: %w", service, err)
+	}
+	if len(body) > maxResponseSize {
+		return nil, fmt.Errorf("%s response exceeds %d bytes", service, maxResponseSize)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s %s", ErrNotFound, service, redactURL(req.URL))
+	case resp.StatusCode != http.StatusOK:
+		return body, fmt.Errorf("%s request: %s", service, resp.Status)
+	}
+	return body, nil
+}
+
+// redactURL drops the query string, which may carry signatures.
+func redactURL(u *url.URL) string {
+	c := *u
+	c.RawQuery = ""
+	return c.String()
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AdminServer implements AdminService. The auth interceptors admit only
+// admin callers to it; each RPC checks again so that a server wired
+// without them is not left open.
+type AdminServer struct {
+	bridgev1.UnimplementedAdminServiceServer
+
+	bridge      *BridgeServer
+	revocations *auth.TokenRevocations
+	startedAt   time.Time
+	reload      func() error
+	onDrain     func()
+	providers   ProviderRegistrar
+	logLevels   *logging.Levels
+}
+
+// ProviderDefinition is a provider added at runtime with
+// AdminService.RegisterProvider.
+type ProviderDefinition struct {
+	ID             string
+	Binary         string
+	Args           []string
+	StreamJSON     bool
+	JSONFormat     string
+	PromptPattern  string
+	StartupProbe   string
+	StartupTimeout time.Duration
+	RequiredEnv    []string
+}
+
+// ProviderRegistrar adds and removes the providers of
+// AdminService.RegisterProvider and UnregisterProvider. Errors wrap
+// bridge.ErrInvalidArgument, ErrProviderAlreadyExists or
+// ErrProviderNotFound.
+type ProviderRegistrar interface {
+	// RegisterProvider adds def, overwriting an earlier registration of
+	// the same ID when replace is set, and reports whether it did.
+	RegisterProvider(def ProviderDefinition, replace bool) (replaced bool, err error)
+	UnregisterProvider(id string) error
+}
+
+// NewAdmin returns the AdminService for bs. reload re-reads the daemon
+// configuration and onDrain is called when Drain starts draining, e.g. to
+// update health at once. Any of revocations, reload and onDrain may be nil;
+// RevokeToken and ReloadConfig then fail.
+func NewAdmin(bs *BridgeServer, revocations *auth.TokenRevocations, reload func() error, onDrain func()) *AdminServer {
+	return &AdminServer{bridge: bs, revocations: revocations, startedAt: time.Now(), reload: reload, onDrain: onDrain}
+}
+
+// SetProviderRegistrar enables RegisterProvider and UnregisterProvider,
+// which fail with Unimplemented until it is called.
+func (a *AdminServer) SetProviderRegistrar(r ProviderRegistrar) {
+	a.providers = r
+}
+
+// SetLogLevels enables SetLogLevel on levels, the levels of the daemon's
+// log handler. SetLogLevel fails with Unimplemented until it is called.
+func (a *AdminServer) SetLogLevels(levels *logging.Levels) {
+	a.logLevels = levels
+}
+
+func requireAdmin(ctx context.Context) (*auth.BridgeClaims, error) {
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeAdmin); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (a *AdminServer) StopSession(ctx context.Context, req *bridgev1.AdminStopSessionRequest) (*bridgev1.AdminStopSessionResponse, error) {
+	claims, err := requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateUUIDField("session_id", req.SessionId); err != nil {
+		return nil, err
+	}
+	info, err := a.bridge.supervisor.Get(req.SessionId)
+	if err != nil {
+		return nil, mapBridgeError(err, "stop session")
+	}
+	a.bridge.logger.Info("admin stopping session", "session_id", req.SessionId, "project_id", info.ProjectID, "force", req.Force, "caller_sub", claims.Subject)
+	if err := a.bridge.supervisor.Stop(req.SessionId, req.Force); err != nil {
+		return nil, mapBridgeError(err, "stop session")
+	}
+	return &bridgev1.AdminStopSessionResponse{ProjectId: info.ProjectID, Status: bridgev1.SessionStatus_SESSION_STATUS_STOPPING}, nil
+}
+
+func (a *AdminServer) Drain(ctx context.Context, _ *bridgev1.DrainRequest) (*bridgev1.DrainResponse, error) {
+	claims, err := requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	already := a.bridge.StartDrain()
+	if !already {
+		a.bridge.logger.Info("admin started drain", "caller_sub", claims.Subject)
+		if a.onDrain != nil {
+			a.onDrain()
+		}
+	}
+	return &bridgev1.DrainResponse{AlreadyDraining: already}, nil
+}
+
+func (a *AdminServer) ReloadConfig(ctx context.Context, _ *bridgev1.ReloadConfigRequest) (*bridgev1.ReloadConfigResponse, error) {
+	claims, err := requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.reload == nil {
+		return nil, status.Error(codes.Unimplemented, "reload is not supported by this server")
+	}
+	if err := a.reload(); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "reload config: %v", err)
+	}
+	a.bridge.logger.Info("admin reloaded config", "caller_sub", claims.Subject)
+	return &bridgev1.ReloadConfigResponse{}, nil
+}
+
+func (a *AdminServer) GetMetrics(ctx context.Context, _ *bridgev1.GetMetricsRequest) (*bridgev1.GetMetricsResponse, error) {
+	if _, err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	resp := &bridgev1.GetMetricsResponse{
+		ServerInstanceId:  a.bridge.serverInstanceID,
+		StartedAt:         timestamppb.New(a.startedAt),
+		Draining:          a.bridge.Draining(),
+		SessionsByStatus:  map[string]uint32{},
+		SessionsByProject: map[string]uint32{},
+		RedactionHits:     a.bridge.supervisor.RedactionHits(),
+		RevokedTokens:     uint32(a.revocations.Len()),
+		RateLimiters:      a.bridge.rateLimiterStates(),
+	}
+	for _, info := range a.bridge.supervisor.List("") {
+		st := mapState(info.State)
+		resp.SessionsByStatus[strings.ToLower(strings.TrimPrefix(st.String(), "SESSION_STATUS_"))]++
+		if info.State != bridge.SessionStateStopped && info.State != bridge.SessionStateFailed {
+			resp.SessionsByProject[info.ProjectID]++
+		}
+	}
+	readiness := a.bridge.supervisor.Readiness()
+	for _, id := range slices.Sorted(maps.Keys(readiness)) {
+		st := readiness[id]
+		resp.ProviderReadiness = append(resp.ProviderReadiness, &bridgev1.ProviderReadiness{
+			Provider:    id,
+			Ready:       st.Ready,
+			TimedOut:    st.TimedOut,
+			MeanReadyMs: uint32(st.Mean().Milliseconds()),
+			MaxReadyMs:  uint32(st.Max.Milliseconds()),
+		})
+	}
+	return resp, nil
+}
+
+func (a *AdminServer) RevokeToken(ctx context.Context, req *bridgev1.RevokeTokenRequest) (*bridgev1.RevokeTokenResponse, error) {
+	claims, err := requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.TokenId == "" && req.Subject == "" {
+		return nil, status.Error(codes.InvalidArgument, "token_id or subject is required")
+	}
+	if req.TokenId != "" {
+		if err := validateStringField("token_id", req.TokenId, maxTokenFieldLen, false); err != nil {
+			return nil, err
+		}
+	}
+	if req.Subject != "" {
+		if err := validateStringField("subject", req.Subject, maxTokenFieldLen, false); err != nil {
+			return nil, err
+		}
+	}
+	if a.revocations == nil {
+		return nil, status.Error(codes.FailedPrecondition, "token revocation is not available without JWT authentication")
+	}
+	now := time.Now()
+	if req.TokenId != "" {
+		if err := a.revocations.RevokeID(req.TokenId, now); err != nil {
+			return nil, status.Errorf(codes.Internal, "revoke token: %v", err)
+		}
+	}
+	if req.Subject != "" {
+		if err := a.revocations.RevokeSubject(req.Subject, now); err != nil {
+			return nil, status.Errorf(codes.Internal, "revoke token: %v", err)
+		}
+	}
+	a.bridge.logger.Warn("admin revoked token", "token_id", req.TokenId, "subject", req.Subject, "reason", req.Reason, "caller_sub", claims.Subject)
+	return &bridgev1.RevokeTokenResponse{}, nil
+}
+
+func (a *AdminServer) RegisterProvider(ctx context.Context, req *bridgev1.RegisterProviderRequest) (*bridgev1.RegisterProviderResponse, error) {
+	claims, err := requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateStringField("provider_id", req.ProviderId, maxProviderLen, false); err != nil {
+		return nil, err
+	}
+	if err := validateStringField("binary", req.Binary, maxRepoPathLen, false); err != nil {
+		return nil, err
+	}
+	if a.providers == nil {
+		return nil, status.Error(codes.Unimplemented, "provider registration is not supported by this server")
+	}
+	replaced, err := a.providers.RegisterProvider(ProviderDefinition{
+		ID:             req.ProviderId,
+		Binary:         req.Binary,
+		Args:           req.Args,
+		StreamJSON:     req.StreamJson,
+		JSONFormat:     req.JsonFormat,
+		PromptPattern:  req.PromptPattern,
+		StartupProbe:   req.StartupProbe,
+		StartupTimeout: time.Duration(req.StartupTimeoutMs) * time.Millisecond,
+		RequiredEnv:    req.RequiredEnv,
+	}, req.Replace)
+	if err != nil {
+		return nil, mapBridgeError(err, "register provider")
+	}
+	a.bridge.logger.Info("admin registered provider", "provider", req.ProviderId, "binary", req.Binary, "replaced", replaced, "caller_sub", claims.Subject)
+	return &bridgev1.RegisterProviderResponse{Replaced: replaced}, nil
+}
+
+func (a *AdminServer) UnregisterProvider(ctx context.Context, req *bridgev1.UnregisterProviderRequest) (*bridgev1.UnregisterProviderResponse, error) {
+	claims, err := requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateStringField("provider_id", req.ProviderId, maxProviderLen, false); err != nil {
+		return nil, err
+	}
+	if a.providers == nil {
+		return nil, status.Error(codes.Unimplemented, "provider registration is not supported by this server")
+	}
+	if err := a.providers.UnregisterProvider(req.ProviderId); err != nil {
+		return nil, mapBridgeError(err, "unregister provider")
+	}
+	a.bridge.logger.Info("admin unregistered provider", "provider", req.ProviderId, "caller_sub", claims.Subject)
+	return &bridgev1.UnregisterProviderResponse{}, nil
+}
+
+func (a *AdminServer) SetLogLevel(ctx context.Context, req *bridgev1.SetLogLevelRequest) (*bridgev1.SetLogLevelResponse, error) {
+	claims, err := requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.logLevels == nil {
+		return nil, status.Error(codes.Unimplemented, "log levels cannot be changed on this server")
+	}
+	if req.Level != "" {
+		level, err := logging.ParseLevel(req.Level)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err := a.logLevels.Set(req.Component, level); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		// Logged at warn so that the change is recorded whatever the level.
+		a.bridge.logger.Warn("admin set log level", "log_component", req.Component, "log_level", logging.LevelName(level), "caller_sub", claims.Subject)
+	}
+	return &bridgev1.SetLogLevelResponse{Levels: a.logLevels.Snapshot()}, nil
+}
+
+func (a *AdminServer) PurgeSession(ctx context.Context, req *bridgev1.PurgeSessionRequest) (*bridgev1.PurgeSessionResponse, error) {
+	claims, err := requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateUUIDField("session_id", req.SessionId); err != nil {
+		return nil, err
+	}
+	// An archive left by an earlier daemon may be all that is left of the
+	// session, so a session Get does not know can still be purged.
+	var projectID string
+	if info, err := a.bridge.supervisor.Get(req.SessionId); err == nil {
+		projectID = info.ProjectID
+	}
+	if err := a.bridge.supervisor.Purge(req.SessionId); err != nil {
+		return nil, mapBridgeError(err, "purge session")
+	}
+	// Logged at warn so that the deletion is recorded whatever the level.
+	a.bridge.logger.Warn("admin purged session", "session_id", req.SessionId, "project_id", projectID, "caller_sub", claims.Subject)
+	return &bridgev1.PurgeSessionResponse{ProjectId: projectID}, nil
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdminServer(t *testing.T) {
+	s, _ := newServerWithSupervisor(t)
+	const sid = "3f1c7a52-8d4b-4e09-a6f2-5b0e9c1d7a38"
+	startServerSession(t, s, sid)
+
+	revocations, err := auth.LoadTokenRevocations("")
+	if err != nil {
+		t.Fatalf("LoadTokenRevocations: %v", err)
+	}
+	reloads, drains := 0, 0
+	admin := NewAdmin(s, revocations, func() error {
+		reloads++
+		if reloads > 1 {
+			return errors.New("bad config")
+		}
+		return nil
+	}, func() { drains++ })
+
+	// A project token is refused even without the interceptors.
+	project := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "proj"})
+	if _, err := admin.GetMetrics(project, &bridgev1.GetMetricsRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("GetMetrics with project token: code=%v want PermissionDenied", status.Code(err))
+	}
+
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{Scopes: []string{auth.ScopeAdmin}})
+	metrics, err := admin.GetMetrics(ctx, &bridgev1.GetMetricsRequest{})
+	if err != nil {
+		t.Fatalf("GetMetrics: %v", err)
+	}
+	if metrics.ServerInstanceId != "test" || metrics.SessionsByProject["proj"] != 1 || metrics.Draining {
+		t.Fatalf("metrics=%+v", metrics)
+	}
+	if len(metrics.RateLimiters) != 4 || metrics.RateLimiters[0].Name != "global" || metrics.RateLimiters[3].Name != "project" {
+		t.Fatalf("rate limiters=%+v", metrics.RateLimiters)
+	}
+
+	stop, err := admin.StopSession(ctx, &bridgev1.AdminStopSessionRequest{SessionId: sid, Force: true})
+	if err != nil || stop.ProjectId != "proj" {
+		t.Fatalf("StopSession resp=%+v err=%v", stop, err)
+	}
+
+	if _, err := admin.ReloadConfig(ctx, &bridgev1.ReloadConfigRequest{}); err != nil {
+		t.Fatalf("ReloadConfig: %v", err)
+	}
+	if _, err := admin.ReloadConfig(ctx, &bridgev1.ReloadConfigRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("failing ReloadConfig: code=%v want FailedPrecondition", status.Code(err))
+	}
+
+	if _, err := admin.RevokeToken(ctx, &bridgev1.RevokeTokenRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("empty RevokeToken: code=%v want InvalidArgument", status.Code(err))
+	}
+	if _, err := admin.RevokeToken(ctx, &bridgev1.RevokeTokenRequest{Subject: "leaked-ci", Reason: "leaked"}); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+	if revocations.Len() != 1 {
+		t.Fatalf("revocations=%d want 1", revocations.Len())
+	}
+
+	resp, err := admin.Drain(ctx, &bridgev1.DrainRequest{})
+	if err != nil || resp.AlreadyDraining {
+		t.Fatalf("Drain resp=%+v err=%v", resp, err)
+	}
+	if resp, _ := admin.Drain(ctx, &bridgev1.DrainRequest{}); !resp.AlreadyDraining || drains != 1 {
+		t.Fatalf("second Drain resp=%+v drains=%d", resp, drains)
+	}
+	_, err = s.StartSession(project, &bridgev1.StartSessionRequest{
+		ProjectId: "proj",
+		SessionId: "7d2e4b91-0c5a-4f83-9e6d-1a8b3c5f2e70",
+		RepoPath:  t.TempDir(),
+		Provider:  "cat",
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("StartSession while draining: code=%v want Unavailable", status.Code(err))
+	}
+}
+
+func TestAdminPurgeSession(t *testing.T) {
+	s, sup := newServerWithSupervisor(t)
+	const sid = "9b6d2f40-3e1a-4c7b-8f25-6a0d4e9c1b73"
+	startServerSession(t, s, sid)
+	admin := NewAdmin(s, nil, nil, nil)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{Scopes: []string{auth.ScopeAdmin}})
+
+	project := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "proj"})
+	if _, err := admin.PurgeSession(project, &bridgev1.PurgeSessionRequest{SessionId: sid}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("PurgeSession with project token: code=%v want PermissionDenied", status.Code(err))
+	}
+	if _, err := admin.PurgeSession(ctx, &bridgev1.PurgeSessionRequest{SessionId: "not-a-uuid"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("PurgeSession bad id: code=%v want InvalidArgument", status.Code(err))
+	}
+	if _, err := admin.PurgeSession(ctx, &bridgev1.PurgeSessionRequest{SessionId: sid}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("PurgeSession running: code=%v want FailedPrecondition", status.Code(err))
+	}
+
+	if err := sup.Stop(sid, true); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info, err := sup.Get(sid)
+		if err == nil && (info.State == bridge.SessionStateStopped || info.State == bridge.SessionStateFailed) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session did not stop: info=%+v err=%v", info, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp, err := admin.PurgeSession(ctx, &bridgev1.PurgeSessionRequest{SessionId: sid})
+	if err != nil || resp.ProjectId != "proj" {
+		t.Fatalf("PurgeSession resp=%+v err=%v", resp, err)
+	}
+	if _, err := sup.Get(sid); !errors.Is(err, bridge.ErrSessionNotFound) {
+		t.Fatalf("Get after PurgeSession err=%v want %v", err, bridge.ErrSessionNotFound)
+	}
+	if _, err := admin.PurgeSession(ctx, &bridgev1.PurgeSessionRequest{SessionId: sid}); status.Code(err) != codes.NotFound {
+		t.Fatalf("second PurgeSession: code=%v want NotFound", status.Code(err))
+	}
+}
+
+// fakeRegistrar records provider registrations for AdminServer tests.
+type fakeRegistrar struct {
+	defs map[string]ProviderDefinition
+}
+
+func (f *fakeRegistrar) RegisterProvider(def ProviderDefinition, replace bool) (bool, error) {
+	_, exists := f.defs[def.ID]
+	if exists && !replace {
+		return false, bridge.ErrProviderAlreadyExists
+	}
+	f.defs[def.ID] = def
+	return exists, nil
+}
+
+func (f *fakeRegistrar) UnregisterProvider(id string) error {
+	if _, ok := f.defs[id]; !ok {
+		return bridge.ErrProviderNotFound
+	}
+	delete(f.defs, id)
+	return nil
+}
+
+func TestAdminRegisterProvider(t *testing.T) {
+	s, _ := newServerWithSupervisor(t)
+	admin := NewAdmin(s, nil, nil, nil)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{Scopes: []string{auth.ScopeAdmin}})
+	req := &bridgev1.RegisterProviderRequest{ProviderId: "aider", Binary: "aider", Args: []string{"--no-pretty"}, StartupTimeoutMs: 2000}
+
+	if _, err := admin.RegisterProvider(ctx, req); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("without registrar: code=%v want Unimplemented", status.Code(err))
+	}
+	reg := &fakeRegistrar{defs: map[string]ProviderDefinition{}}
+	admin.SetProviderRegistrar(reg)
+
+	project := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "proj"})
+	if _, err := admin.RegisterProvider(project, req); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("project token: code=%v want PermissionDenied", status.Code(err))
+	}
+	if _, err := admin.RegisterProvider(ctx, &bridgev1.RegisterProviderRequest{ProviderId: "aider"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("no binary: code=%v want InvalidArgument", status.Code(err))
+	}
+	resp, err := admin.RegisterProvider(ctx, req)
+	if err != nil || resp.Replaced {
+		t.Fatalf("RegisterProvider resp=%+v err=%v", resp, err)
+	}
+	if def := reg.defs["aider"]; def.Binary != "aider" || def.StartupTimeout != 2*time.Second || len(def.Args) != 1 {
+		t.Fatalf("registered %+v", def)
+	}
+	if _, err := admin.RegisterProvider(ctx, req); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("duplicate: code=%v want AlreadyExists", status.Code(err))
+	}
+
+	if _, err := admin.UnregisterProvider(ctx, &bridgev1.UnregisterProviderRequest{ProviderId: "aider"}); err != nil {
+		t.Fatalf("UnregisterProvider: %v", err)
+	}
+	if _, err := admin.UnregisterProvider(ctx, &bridgev1.UnregisterProviderRequest{ProviderId: "aider"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("second UnregisterProvider: code=%v want NotFound", status.Code(err))
+	}
+}
+
+func TestAdminSetLogLevel(t *testing.T) {
+	s, _ := newServerWithSupervisor(t)
+	admin := NewAdmin(s, nil, nil, nil)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{Scopes: []string{auth.ScopeAdmin}})
+
+	if _, err := admin.SetLogLevel(ctx, &bridgev1.SetLogLevelRequest{Level: "debug"}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("without levels: code=%v want Unimplemented", status.Code(err))
+	}
+	levels := logging.NewLevels(slog.LevelInfo)
+	admin.SetLogLevels(levels)
+
+	project := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "proj"})
+	if _, err := admin.SetLogLevel(project, &bridgev1.SetLogLevelRequest{Level: "debug"}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("project token: code=%v want PermissionDenied", status.Code(err))
+	}
+	if _, err := admin.SetLogLevel(ctx, &bridgev1.SetLogLevelRequest{Level: "loud"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("bad level: code=%v want InvalidArgument", status.Code(err))
+	}
+	if _, err := admin.SetLogLevel(ctx, &bridgev1.SetLogLevelRequest{Component: "ui", Level: "debug"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("bad component: code=%v want InvalidArgument", status.Code(err))
+	}
+
+	resp, err := admin.SetLogLevel(ctx, &bridgev1.SetLogLevelRequest{Component: logging.ComponentAuth, Level: "debug"})
+	if err != nil {
+		t.Fatalf("SetLogLevel: %v", err)
+	}
+	if resp.Levels[logging.ComponentAuth] != "debug" || resp.Levels[""] != "info" || resp.Levels[logging.ComponentServer] != "info" {
+		t.Fatalf("levels = %v", resp.Levels)
+	}
+	if got := levels.Level(logging.ComponentAuth); got != slog.LevelDebug {
+		t.Fatalf("auth level = %v want debug", got)
+	}
+	// No level only reports.
+	resp, err = admin.SetLogLevel(ctx, &bridgev1.SetLogLevelRequest{})
+	if err != nil || resp.Levels[logging.ComponentAuth] != "debug" {
+		t.Fatalf("report levels=%v err=%v", resp.GetLevels(), err)
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxBroadcastSessions caps the sessions one BroadcastInput writes to.
+const maxBroadcastSessions = 32
+
+// BroadcastInput sends one input to several sessions, as WriteInput would
+// to each of them. The request is validated and the global rate limit
+// checked once; authorization, the per-session and per-project limits and
+// delivery happen per session, concurrently, and a session that refuses the
+// input is reported in its result rather than failing the call.
+func (s *BridgeServer) BroadcastInput(ctx context.Context, req *bridgev1.BroadcastInputRequest) (*bridgev1.BroadcastInputResponse, error) {
+	batch := req.Priority == bridgev1.InputPriority_INPUT_PRIORITY_BATCH
+	if err := s.checkGlobalLane(ctx, batch); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	if len(req.SessionIds) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "session_ids is required")
+	}
+	if len(req.SessionIds) > maxBroadcastSessions {
+		return nil, status.Errorf(codes.InvalidArgument, "session_ids exceeds max count %d", maxBroadcastSessions)
+	}
+	seen := make(map[string]bool, len(req.SessionIds))
+	for _, id := range req.SessionIds {
+		if err := validateUUIDField("session_ids", id); err != nil {
+			return nil, err
+		}
+		if seen[id] {
+			return nil, status.Errorf(codes.InvalidArgument, "session_ids: duplicate session %s", id)
+		}
+		seen[id] = true
+	}
+	if err := validateStringField("client_id", req.ClientId, maxSessionIDLen, false); err != nil {
+		return nil, err
+	}
+	if err := validateInputData(req.Data, req.Template, req.Vars); err != nil {
+		return nil, err
+	}
+	if _, ok := bridgev1.InputPriority_name[int32(req.Priority)]; !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown priority %d", req.Priority)
+	}
+	attachments, err := inputAttachments(req.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	in := inputRequest{
+		clientID:    req.ClientId,
+		data:        req.Data,
+		template:    req.Template,
+		vars:        req.Vars,
+		attachments: attachments,
+		batch:       batch,
+	}
+
+	results := make([]*bridgev1.BroadcastInputResult, len(req.SessionIds))
+	var wg sync.WaitGroup
+	for i, id := range req.SessionIds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := &bridgev1.BroadcastInputResult{SessionId: id}
+			ack, err := s.writeInput(ctx, claims, id, in)
+			if err != nil {
+				st := status.Convert(err)
+				res.Error, res.Code = st.Message(), int32(st.Code())
+			} else {
+				res.Accepted, res.InputId, res.Seq = true, ack.ID, ack.Seq
+				res.Queued, res.BytesWritten = ack.Queued, uint32(ack.Bytes)
+			}
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	accepted := 0
+	for _, res := range results {
+		if res.Accepted {
+			accepted++
+		}
+	}
+	s.logger.Info("input broadcast", "client_id", req.ClientId, "sessions", len(results), "accepted", accepted, logging.RequestIDKey, logging.RequestID(ctx))
+	return &bridgev1.BroadcastInputResponse{Results: results}, nil
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBroadcastInput(t *testing.T) {
+	s, supervisor := newTerminalTestServer(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a"})
+	var ids []string
+	for range 3 {
+		id := uuid.NewString()
+		if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{ProjectId: "project-a", SessionId: id, RepoPath: t.TempDir(), Provider: "cat"}); err != nil {
+			t.Fatalf("StartSession: %v", err)
+		}
+		t.Cleanup(func() { _ = supervisor.Stop(id, true) })
+		ids = append(ids, id)
+	}
+	// The third session has no writer, so it refuses the input.
+	for _, id := range ids[:2] {
+		if _, err := supervisor.Attach(id, "writer", 0, bridge.AttachRoleWriter); err != nil {
+			t.Fatalf("Attach: %v", err)
+		}
+	}
+	missing := uuid.NewString()
+
+	resp, err := s.BroadcastInput(ctx, &bridgev1.BroadcastInputRequest{
+		SessionIds: append(append([]string{}, ids...), missing),
+		ClientId:   "writer",
+		Data:       []byte("compare\r"),
+	})
+	if err != nil {
+		t.Fatalf("BroadcastInput: %v", err)
+	}
+	if len(resp.Results) != 4 {
+		t.Fatalf("results=%d want 4", len(resp.Results))
+	}
+	for i, res := range resp.Results[:2] {
+		if res.SessionId != ids[i] || !res.Accepted || res.InputId == "" || res.Seq == 0 || res.BytesWritten != 8 {
+			t.Fatalf("result %d=%+v", i, res)
+		}
+		st, err := supervisor.Attach(ids[i], "observer", res.Seq-1, bridge.AttachRoleObserver)
+		if err != nil {
+			t.Fatalf("Attach observer: %v", err)
+		}
+		if len(st.Replay) == 0 || st.Replay[0].Type != bridge.ChunkTypeInputAcked || st.Replay[0].InputID != res.InputId {
+			t.Fatalf("session %d replay after seq %d=%+v", i, res.Seq-1, st.Replay)
+		}
+	}
+	if res := resp.Results[2]; res.Accepted || codes.Code(res.Code) != codes.PermissionDenied || res.Error == "" {
+		t.Fatalf("unattached result=%+v", res)
+	}
+	if res := resp.Results[3]; res.SessionId != missing || res.Accepted || codes.Code(res.Code) != codes.NotFound {
+		t.Fatalf("missing result=%+v", res)
+	}
+
+	for name, req := range map[string]*bridgev1.BroadcastInputRequest{
+		"no sessions": {ClientId: "writer", Data: []byte("x")},
+		"duplicate":   {SessionIds: []string{ids[0], ids[0]}, ClientId: "writer", Data: []byte("x")},
+		"bad id":      {SessionIds: []string{"nope"}, ClientId: "writer", Data: []byte("x")},
+		"too many":    {SessionIds: make([]string, maxBroadcastSessions+1), ClientId: "writer", Data: []byte("x")},
+	} {
+		if _, err := s.BroadcastInput(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("%s: code=%v want InvalidArgument", name, status.Code(err))
+		}
+	}
+}
+
+func TestWriteInputTemplate(t *testing.T) {
+	s, supervisor := newTerminalTestServer(t)
+	review, err := bridge.ParsePromptTemplate("review", "Review {{.file}}")
+	if err != nil {
+		t.Fatalf("ParsePromptTemplate: %v", err)
+	}
+	policy := bridge.DefaultPolicy()
+	policy.PromptTemplates = map[string]bridge.PromptTemplate{"review": review}
+	supervisor.SetPolicy(policy)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a"})
+	id := uuid.NewString()
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{ProjectId: "project-a", SessionId: id, RepoPath: t.TempDir(), Provider: "cat"}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	t.Cleanup(func() { _ = supervisor.Stop(id, true) })
+	if _, err := supervisor.Attach(id, "writer", 0, bridge.AttachRoleWriter); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+
+	resp, err := s.WriteInput(ctx, &bridgev1.WriteInputRequest{SessionId: id, ClientId: "writer", Template: "review", Vars: map[string]string{"file": "main.go"}})
+	if err != nil {
+		t.Fatalf("WriteInput: %v", err)
+	}
+	if want := len("Review main.go\r"); int(resp.BytesWritten) != want {
+		t.Fatalf("bytes_written=%d want %d", resp.BytesWritten, want)
+	}
+
+	for name, req := range map[string]*bridgev1.WriteInputRequest{
+		"data and template": {Data: []byte("x"), Template: "review"},
+		"vars only":         {Data: []byte("x"), Vars: map[string]string{"file": "a"}},
+		"unknown template":  {Template: "summarize"},
+		"missing var":       {Template: "review"},
+	} {
+		req.SessionId, req.ClientId = id, "writer"
+		if _, err := s.WriteInput(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("%s: code=%v want InvalidArgument", name, status.Code(err))
+		}
+	}
+}
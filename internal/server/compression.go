@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// CompressionConfig controls gzip compression of responses. gRPC compresses
+// a call's responses with the compressor its request used, so compression is
+// negotiated per call by the client (bridgeclient.WithCompression).
+type CompressionConfig struct {
+	// Disabled sends every response uncompressed.
+	Disabled bool
+	// Level is the gzip level, 1 (fastest) to 9 (smallest). Zero keeps
+	// the gzip default (6).
+	Level int
+	// MaxStreams caps how many streams are compressed at once; streams
+	// opened beyond it are sent uncompressed. Zero means no cap.
+	MaxStreams int
+}
+
+// CompressionLimiter enforces a CompressionConfig through gRPC interceptors.
+type CompressionLimiter struct {
+	disabled   bool
+	maxStreams int64
+	active     atomic.Int64
+}
+
+// NewCompressionLimiter returns the limiter for cfg. A non-zero Level sets
+// the process-wide gzip level, so it must be called before serving.
+func NewCompressionLimiter(cfg CompressionConfig) (*CompressionLimiter, error) {
+	if cfg.Level != 0 {
+		if err := gzip.SetLevel(cfg.Level); err != nil {
+			return nil, fmt.Errorf("compression level %d: %w", cfg.Level, err)
+		}
+	}
+	return &CompressionLimiter{disabled: cfg.Disabled, maxStreams: int64(cfg.MaxStreams)}, nil
+}
+
+// sendCompressor reports the compressor gRPC chose for a call's responses.
+func sendCompressor(ctx context.Context) string {
+	st, ok := grpc.ServerTransportStreamFromContext(ctx).(interface{ SendCompress() string })
+	if !ok {
+		return ""
+	}
+	return st.SendCompress()
+}
+
+func compressed(ctx context.Context) bool {
+	name := sendCompressor(ctx)
+	return name != "" && name != encoding.Identity
+}
+
+// UnaryInterceptor sends unary responses uncompressed when compression is
+// disabled. Unary responses are small, so they are not counted against
+// MaxStreams.
+func (l *CompressionLimiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if l.disabled && compressed(ctx) {
+			_ = grpc.SetSendCompressor(ctx, encoding.Identity)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor sends a stream uncompressed when compression is disabled
+// or MaxStreams compressed streams are already open.
+func (l *CompressionLimiter) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		if !compressed(ctx) {
+			return handler(srv, ss)
+		}
+		if !l.disabled {
+			if n := l.active.Add(1); l.maxStreams <= 0 || n <= l.maxStreams {
+				defer l.active.Add(-1)
+				return handler(srv, ss)
+			}
+			l.active.Add(-1)
+		}
+		_ = grpc.SetSendCompressor(ctx, encoding.Identity)
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// countingCompressor is gzip under another name, counting decompressed
+// messages in either direction.
+type countingCompressor struct {
+	encoding.Compressor
+	decompressed atomic.Int64
+}
+
+func (c *countingCompressor) Name() string { return "counting-gzip" }
+
+func (c *countingCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	c.decompressed.Add(1)
+	return c.Compressor.Decompress(r)
+}
+
+var testCompressor = func() *countingCompressor {
+	c := &countingCompressor{Compressor: encoding.GetCompressor("gzip")}
+	encoding.RegisterCompressor(c)
+	return c
+}()
+
+func TestCompressionLimiter(t *testing.T) {
+	if _, err := NewCompressionLimiter(CompressionConfig{Level: 12}); err == nil {
+		t.Fatal("expected an error for gzip level 12")
+	}
+
+	tests := []struct {
+		name string
+		cfg  CompressionConfig
+		// want is whether each of three concurrently open streams gets
+		// compressed responses.
+		want []bool
+	}{
+		{name: "unlimited", cfg: CompressionConfig{}, want: []bool{true, true, true}},
+		{name: "max streams", cfg: CompressionConfig{MaxStreams: 2}, want: []bool{true, true, false}},
+		{name: "disabled", cfg: CompressionConfig{Disabled: true}, want: []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, err := NewCompressionLimiter(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewCompressionLimiter: %v", err)
+			}
+			srv := grpc.NewServer(grpc.ChainStreamInterceptor(limiter.StreamInterceptor()))
+			hs := health.NewServer()
+			hs.SetServingStatus("bridge", healthpb.HealthCheckResponse_SERVING)
+			healthpb.RegisterHealthServer(srv, hs)
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Listen: %v", err)
+			}
+			go func() { _ = srv.Serve(ln) }()
+			defer srv.Stop()
+
+			conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			defer func() { _ = conn.Close() }()
+			client := healthpb.NewHealthClient(conn)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			for i, want := range tt.want {
+				before := testCompressor.decompressed.Load()
+				stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "bridge"}, grpc.UseCompressor(testCompressor.Name()))
+				if err != nil {
+					t.Fatalf("Watch: %v", err)
+				}
+				if _, err := stream.Recv(); err != nil {
+					t.Fatalf("Recv: %v", err)
+				}
+				// The request is always decompressed by the server; the
+				// response only when it was compressed.
+				got := testCompressor.decompressed.Load()-before == 2
+				if got != want {
+					t.Fatalf("stream %d compressed=%v want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
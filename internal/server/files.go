@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ReadFile returns a file from the session's repo.
+func (s *BridgeServer) ReadFile(ctx context.Context, req *bridgev1.ReadFileRequest) (*bridgev1.ReadFileResponse, error) {
+	if err := s.authorizeFileRequest(ctx, auth.ScopeEventsRead, req.SessionId, req.Path, true); err != nil {
+		return nil, err
+	}
+	data, modified, err := s.supervisor.ReadFile(req.SessionId, req.Path)
+	if err != nil {
+		return nil, mapBridgeError(err, "read file")
+	}
+	return &bridgev1.ReadFileResponse{Data: data, ModifiedAt: timestamppb.New(modified)}, nil
+}
+
+// WriteFile creates or replaces a file in the session's repo.
+func (s *BridgeServer) WriteFile(ctx context.Context, req *bridgev1.WriteFileRequest) (*bridgev1.WriteFileResponse, error) {
+	if err := s.authorizeFileRequest(ctx, auth.ScopeSessionsControl, req.SessionId, req.Path, true); err != nil {
+		return nil, err
+	}
+	if err := s.supervisor.WriteFile(req.SessionId, req.Path, req.Data); err != nil {
+		return nil, mapBridgeError(err, "write file")
+	}
+	return &bridgev1.WriteFileResponse{BytesWritten: uint32(len(req.Data))}, nil
+}
+
+// ListDir lists a directory in the session's repo.
+func (s *BridgeServer) ListDir(ctx context.Context, req *bridgev1.ListDirRequest) (*bridgev1.ListDirResponse, error) {
+	if err := s.authorizeFileRequest(ctx, auth.ScopeEventsRead, req.SessionId, req.Path, false); err != nil {
+		return nil, err
+	}
+	entries, err := s.supervisor.ListDir(req.SessionId, req.Path)
+	if err != nil {
+		return nil, mapBridgeError(err, "list dir")
+	}
+	resp := &bridgev1.ListDirResponse{Entries: make([]*bridgev1.DirEntry, 0, len(entries))}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, &bridgev1.DirEntry{
+			Name:       e.Name,
+			IsDir:      e.Dir,
+			Size:       e.Size,
+			ModifiedAt: timestamppb.New(e.ModifiedAt),
+		})
+	}
+	return resp, nil
+}
+
+// authorizeFileRequest applies the checks shared by the file RPCs. The
+// path itself is confined to the repo by the supervisor.
+func (s *BridgeServer) authorizeFileRequest(ctx context.Context, scope, sessionID, path string, pathRequired bool) error {
+	if pathRequired {
+		if err := validateStringField("path", path, maxFilePathLen, false); err != nil {
+			return err
+		}
+	} else if err := validateOptionalStringField("path", path, maxFilePathLen, false); err != nil {
+		return err
+	}
+	return s.authorizeRepoRequest(ctx, scope, sessionID)
+}
+
+// authorizeRepoRequest applies the rate limit, scope and project checks
+// shared by the RPCs that work on a session's repo.
+func (s *BridgeServer) authorizeRepoRequest(ctx context.Context, scope, sessionID string) error {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireScope(claims, scope); err != nil {
+		return err
+	}
+	if err := validateUUIDField("session_id", sessionID); err != nil {
+		return err
+	}
+	return s.authorizeSession(claims, sessionID, scope)
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBridgeServerSessionFiles(t *testing.T) {
+	s, _ := newTerminalTestServer(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a"})
+	repo := t.TempDir()
+	sessionID := uuid.NewString()
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId: "project-a",
+		SessionId: sessionID,
+		RepoPath:  repo,
+		Provider:  "cat",
+	}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = s.StopSession(ctx, &bridgev1.StopSessionRequest{SessionId: sessionID, Force: true})
+	})
+
+	writeResp, err := s.WriteFile(ctx, &bridgev1.WriteFileRequest{SessionId: sessionID, Path: "docs/TASK.md", Data: []byte("do it")})
+	if err != nil || writeResp.GetBytesWritten() != 5 {
+		t.Fatalf("WriteFile resp=%+v err=%v", writeResp, err)
+	}
+	if got, err := os.ReadFile(filepath.Join(repo, "docs", "TASK.md")); err != nil || string(got) != "do it" {
+		t.Fatalf("file on disk = %q, %v", got, err)
+	}
+
+	readResp, err := s.ReadFile(ctx, &bridgev1.ReadFileRequest{SessionId: sessionID, Path: "docs/TASK.md"})
+	if err != nil || string(readResp.GetData()) != "do it" {
+		t.Fatalf("ReadFile resp=%+v err=%v", readResp, err)
+	}
+
+	listResp, err := s.ListDir(ctx, &bridgev1.ListDirRequest{SessionId: sessionID})
+	if err != nil {
+		t.Fatalf("ListDir: %v", err)
+	}
+	if len(listResp.GetEntries()) != 1 || listResp.GetEntries()[0].GetName() != "docs" || !listResp.GetEntries()[0].GetIsDir() {
+		t.Fatalf("ListDir entries=%+v", listResp.GetEntries())
+	}
+
+	if _, err := s.ReadFile(ctx, &bridgev1.ReadFileRequest{SessionId: sessionID, Path: "../outside"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("ReadFile outside repo err=%v want InvalidArgument", err)
+	}
+	if _, err := s.ReadFile(ctx, &bridgev1.ReadFileRequest{SessionId: sessionID, Path: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("ReadFile missing err=%v want NotFound", err)
+	}
+	if _, err := s.ReadFile(ctx, &bridgev1.ReadFileRequest{SessionId: sessionID}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("ReadFile without path err=%v want InvalidArgument", err)
+	}
+
+	other := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-b"})
+	if _, err := s.ListDir(other, &bridgev1.ListDirRequest{SessionId: sessionID}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("ListDir from another project err=%v want PermissionDenied", err)
+	}
+}
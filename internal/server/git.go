@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxGitPaths      = 256
+	maxCommitMessage = 64 * 1024
+	maxGitRevision   = 256
+)
+
+// GitStatus reports the working tree state of the session's repo.
+func (s *BridgeServer) GitStatus(ctx context.Context, req *bridgev1.GitStatusRequest) (*bridgev1.GitStatusResponse, error) {
+	if err := s.authorizeRepoRequest(ctx, auth.ScopeEventsRead, req.SessionId); err != nil {
+		return nil, err
+	}
+	st, err := s.supervisor.GitStatus(ctx, req.SessionId)
+	if err != nil {
+		return nil, mapBridgeError(err, "git status")
+	}
+	resp := &bridgev1.GitStatusResponse{
+		Branch: st.Branch,
+		Head:   st.Head,
+		Clean:  st.Clean(),
+		Files:  make([]*bridgev1.GitFileStatus, 0, len(st.Files)),
+	}
+	for _, f := range st.Files {
+		resp.Files = append(resp.Files, &bridgev1.GitFileStatus{
+			Path:           f.Path,
+			OrigPath:       f.OrigPath,
+			IndexStatus:    string(f.Index),
+			WorktreeStatus: string(f.Worktree),
+		})
+	}
+	return resp, nil
+}
+
+// GitDiff returns a unified diff of the session's repo.
+func (s *BridgeServer) GitDiff(ctx context.Context, req *bridgev1.GitDiffRequest) (*bridgev1.GitDiffResponse, error) {
+	if err := validateGitPaths(req.Paths); err != nil {
+		return nil, err
+	}
+	if err := validateOptionalStringField("base", req.Base, maxGitRevision, false); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeRepoRequest(ctx, auth.ScopeEventsRead, req.SessionId); err != nil {
+		return nil, err
+	}
+	diff, truncated, err := s.supervisor.GitDiff(ctx, req.SessionId, bridge.GitDiffOptions{
+		Staged: req.Staged,
+		Base:   req.Base,
+		Paths:  req.Paths,
+	})
+	if err != nil {
+		return nil, mapBridgeError(err, "git diff")
+	}
+	return &bridgev1.GitDiffResponse{Diff: diff, Truncated: truncated}, nil
+}
+
+// GitCommit commits to the session's repo as the configured identity.
+func (s *BridgeServer) GitCommit(ctx context.Context, req *bridgev1.GitCommitRequest) (*bridgev1.GitCommitResponse, error) {
+	if err := validateStringField("message", req.Message, maxCommitMessage, true); err != nil {
+		return nil, err
+	}
+	if err := validateGitPaths(req.Paths); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeRepoRequest(ctx, auth.ScopeSessionsControl, req.SessionId); err != nil {
+		return nil, err
+	}
+	commit, err := s.supervisor.GitCommit(ctx, req.SessionId, bridge.GitCommitOptions{
+		Message: req.Message,
+		All:     req.All,
+		Paths:   req.Paths,
+	})
+	if err != nil {
+		return nil, mapBridgeError(err, "git commit")
+	}
+	return &bridgev1.GitCommitResponse{Commit: commit}, nil
+}
+
+func validateGitPaths(paths []string) error {
+	if len(paths) > maxGitPaths {
+		return status.Errorf(codes.InvalidArgument, "paths exceeds max count %d", maxGitPaths)
+	}
+	for _, p := range paths {
+		if err := validateStringField("paths", p, maxFilePathLen, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RollbackWorkspace restores a stopped session's repo to its start snapshot.
+func (s *BridgeServer) RollbackWorkspace(ctx context.Context, req *bridgev1.RollbackWorkspaceRequest) (*bridgev1.RollbackWorkspaceResponse, error) {
+	if err := s.authorizeRepoRequest(ctx, auth.ScopeSessionsControl, req.SessionId); err != nil {
+		return nil, err
+	}
+	snap, err := s.supervisor.Rollback(ctx, req.SessionId)
+	if err != nil {
+		return nil, mapBridgeError(err, "rollback workspace")
+	}
+	s.logger.Info("workspace rolled back", "session_id", req.SessionId, "head", snap.Head)
+	return &bridgev1.RollbackWorkspaceResponse{Head: snap.Head, SnapshotCommit: snap.Commit}, nil
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBridgeServerGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	s, _ := newTerminalTestServer(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a"})
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "-C", repo, "init", "--quiet", "--initial-branch=main").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	sessionID := uuid.NewString()
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId: "project-a",
+		SessionId: sessionID,
+		RepoPath:  repo,
+		Provider:  "cat",
+	}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = s.StopSession(ctx, &bridgev1.StopSessionRequest{SessionId: sessionID, Force: true})
+	})
+
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	statusResp, err := s.GitStatus(ctx, &bridgev1.GitStatusRequest{SessionId: sessionID})
+	if err != nil {
+		t.Fatalf("GitStatus: %v", err)
+	}
+	if statusResp.GetClean() || len(statusResp.GetFiles()) != 1 || statusResp.GetFiles()[0].GetIndexStatus() != "?" {
+		t.Fatalf("GitStatus resp=%+v", statusResp)
+	}
+
+	commitResp, err := s.GitCommit(ctx, &bridgev1.GitCommitRequest{SessionId: sessionID, Message: "add main", All: true})
+	if err != nil || commitResp.GetCommit() == "" {
+		t.Fatalf("GitCommit resp=%+v err=%v", commitResp, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	diffResp, err := s.GitDiff(ctx, &bridgev1.GitDiffRequest{SessionId: sessionID})
+	if err != nil || !strings.Contains(string(diffResp.GetDiff()), "+func main() {}") {
+		t.Fatalf("GitDiff resp=%+v err=%v", diffResp, err)
+	}
+
+	if _, err := s.GitCommit(ctx, &bridgev1.GitCommitRequest{SessionId: sessionID}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("GitCommit without message err=%v want InvalidArgument", err)
+	}
+	if _, err := s.GitDiff(ctx, &bridgev1.GitDiffRequest{SessionId: sessionID, Paths: []string{"../x"}}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("GitDiff outside repo err=%v want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultHealthTTL is how long provider health results are reused, and how
+// often HealthWatch re-evaluates readiness.
+const defaultHealthTTL = 10 * time.Second
+
+// readinessCheck is one named check run by readiness probes.
+type readinessCheck struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// healthCache holds the last provider health results so that frequent
+// probes do not run every provider's Health check.
+type healthCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	checked time.Time
+	results map[string]error
+}
+
+func (c *healthCache) providers(ctx context.Context, probe func(context.Context) map[string]error) map[string]error {
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.results == nil || now().Sub(c.checked) >= c.ttl {
+		c.results = probe(ctx)
+		c.checked = now()
+	}
+	return c.results
+}
+
+// AddReadinessCheck adds a check that readiness probes run after the
+// provider check. A non-nil error marks the daemon not serving.
+func (s *BridgeServer) AddReadinessCheck(name string, fn func(context.Context) error) {
+	s.mu.Lock()
+	s.readiness = append(s.readiness, readinessCheck{name: name, fn: fn})
+	s.mu.Unlock()
+}
+
+func (s *BridgeServer) Health(ctx context.Context, req *bridgev1.HealthRequest) (*bridgev1.HealthResponse, error) {
+	return s.health(ctx, req.Check), nil
+}
+
+// HealthWatch sends the current health, then re-evaluates it every cache
+// TTL and sends it again whenever it changed.
+func (s *BridgeServer) HealthWatch(req *bridgev1.HealthRequest, stream bridgev1.BridgeService_HealthWatchServer) error {
+	ctx := stream.Context()
+	last := s.health(ctx, req.Check)
+	if err := stream.Send(last); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(s.providerHealth.ttl)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		next := s.health(ctx, req.Check)
+		if sameHealth(last, next) {
+			continue
+		}
+		if err := stream.Send(next); err != nil {
+			return err
+		}
+		last = next
+	}
+}
+
+// ReportHealth keeps the standard grpc.health.v1 service in step with this
+// server until ctx is done, then marks every service NOT_SERVING. The
+// overall status ("") follows liveness and is SERVING while the daemon
+// runs; bridge.v1.BridgeService follows readiness and is re-evaluated
+// every cache TTL.
+func (s *BridgeServer) ReportHealth(ctx context.Context, hs *health.Server) {
+	service := bridgev1.BridgeService_ServiceDesc.ServiceName
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	ticker := time.NewTicker(s.providerHealth.ttl)
+	defer ticker.Stop()
+	for {
+		st := healthpb.HealthCheckResponse_SERVING
+		if s.health(ctx, bridgev1.HealthCheck_HEALTH_CHECK_READINESS).Status != "serving" {
+			st = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		hs.SetServingStatus(service, st)
+		select {
+		case <-ctx.Done():
+			hs.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// HealthHandler serves liveness on GET /livez and readiness on GET /readyz
+// for HTTP probes. The body is the HealthResponse as JSON; the status code
+// is 200 when serving and 503 otherwise. It performs no authentication, so
+// it belongs on a listener reachable only by the orchestrator.
+func (s *BridgeServer) HealthHandler() http.Handler {
+	probe := func(check bridgev1.HealthCheck) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			resp := s.health(r.Context(), check)
+			body, err := protojson.Marshal(resp)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if resp.Status != "serving" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			_, _ = w.Write(body)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /livez", probe(bridgev1.HealthCheck_HEALTH_CHECK_LIVENESS))
+	mux.Handle("GET /readyz", probe(bridgev1.HealthCheck_HEALTH_CHECK_READINESS))
+	return mux
+}
+
+// health builds the response for one probe. Liveness only reports that the
+// daemon answers; readiness runs the provider check, which passes when at
+// least one provider is available, and every added check.
+func (s *BridgeServer) health(ctx context.Context, check bridgev1.HealthCheck) *bridgev1.HealthResponse {
+	resp := &bridgev1.HealthResponse{
+		Status:           "serving",
+		ServerInstanceId: s.serverInstanceID,
+	}
+	if s.supervisor != nil {
+		resp.RedactionHits = s.supervisor.RedactionHits()
+	}
+	if check == bridgev1.HealthCheck_HEALTH_CHECK_LIVENESS {
+		return resp
+	}
+
+	results := s.providerHealth.providers(ctx, s.registry.HealthAll)
+	var available bool
+	for _, id := range slices.Sorted(maps.Keys(results)) {
+		err := results[id]
+		item := &bridgev1.ProviderHealth{Provider: id, Available: err == nil}
+		if err != nil {
+			item.Error = err.Error()
+		} else {
+			available = true
+		}
+		resp.Providers = append(resp.Providers, item)
+	}
+	var providersErr error
+	if !available {
+		providersErr = errors.New("no provider available")
+	}
+	resp.Checks = append(resp.Checks, checkResult("providers", providersErr))
+
+	s.mu.RLock()
+	checks := s.readiness
+	s.mu.RUnlock()
+	for _, c := range checks {
+		resp.Checks = append(resp.Checks, checkResult(c.name, c.fn(ctx)))
+	}
+	for _, c := range resp.Checks {
+		if !c.Ok {
+			resp.Status = "not_serving"
+		}
+	}
+	return resp
+}
+
+func checkResult(name string, err error) *bridgev1.HealthCheckResult {
+	r := &bridgev1.HealthCheckResult{Name: name, Ok: err == nil}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// sameHealth reports whether two responses differ only in redaction hits,
+// which change too often to be worth a HealthWatch update.
+func sameHealth(a, b *bridgev1.HealthResponse) bool {
+	a = proto.CloneOf(a)
+	b = proto.CloneOf(b)
+	a.RedactionHits, b.RedactionHits = nil, nil
+	return proto.Equal(a, b)
+}
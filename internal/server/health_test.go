@@ -0,0 +1,301 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+type healthStream struct {
+	ctx  context.Context
+	sent chan *bridgev1.HealthResponse
+}
+
+func (s *healthStream) SetHeader(metadata.MD) error  { return nil }
+func (s *healthStream) SendHeader(metadata.MD) error { return nil }
+func (s *healthStream) SetTrailer(metadata.MD)       {}
+func (s *healthStream) Context() context.Context     { return s.ctx }
+func (s *healthStream) SendMsg(any) error            { return nil }
+func (s *healthStream) RecvMsg(any) error            { return nil }
+func (s *healthStream) Send(resp *bridgev1.HealthResponse) error {
+	s.sent <- resp
+	return nil
+}
+
+// countingProvider counts its Health calls.
+type countingProvider struct {
+	serverTestProvider
+	calls atomic.Int32
+}
+
+func (p *countingProvider) Health(ctx context.Context) error {
+	p.calls.Add(1)
+	return p.serverTestProvider.Health(ctx)
+}
+
+func checkNamed(resp *bridgev1.HealthResponse, name string) *bridgev1.HealthCheckResult {
+	for _, c := range resp.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestHealthLivenessRunsNoChecks(t *testing.T) {
+	p := &countingProvider{serverTestProvider: serverTestProvider{id: "broken", healthErr: errors.New("down")}}
+	registry := bridge.NewRegistry()
+	if err := registry.Register(p); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s := New(nil, registry, slog.Default(), RateLimitConfig{}, "test-instance", nil)
+	s.AddReadinessCheck("disk", func(context.Context) error { return errors.New("full") })
+
+	resp, err := s.Health(context.Background(), &bridgev1.HealthRequest{Check: bridgev1.HealthCheck_HEALTH_CHECK_LIVENESS})
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if resp.Status != "serving" || len(resp.Checks) != 0 || len(resp.Providers) != 0 || resp.ServerInstanceId != "test-instance" {
+		t.Fatalf("liveness=%+v", resp)
+	}
+	if n := p.calls.Load(); n != 0 {
+		t.Fatalf("liveness probed providers %d times", n)
+	}
+}
+
+func TestHealthReadiness(t *testing.T) {
+	registry := bridge.NewRegistry()
+	if err := registry.Register(&serverTestProvider{id: "broken", healthErr: errors.New("down")}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s := New(nil, registry, slog.Default(), RateLimitConfig{}, "test-instance", nil)
+	var diskErr error
+	s.AddReadinessCheck("disk", func(context.Context) error { return diskErr })
+
+	resp, err := s.Health(context.Background(), &bridgev1.HealthRequest{})
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if resp.Status != "not_serving" {
+		t.Fatalf("Status=%q with no provider available", resp.Status)
+	}
+	if c := checkNamed(resp, "providers"); c == nil || c.Ok || c.Error == "" {
+		t.Fatalf("providers check=%+v", c)
+	}
+	if c := checkNamed(resp, "disk"); c == nil || !c.Ok {
+		t.Fatalf("disk check=%+v", c)
+	}
+
+	if err := registry.Register(&serverTestProvider{id: "healthy"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s.providerHealth.ttl = 0
+	diskErr = errors.New("full")
+	resp, err = s.Health(context.Background(), &bridgev1.HealthRequest{Check: bridgev1.HealthCheck_HEALTH_CHECK_READINESS})
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if c := checkNamed(resp, "providers"); c == nil || !c.Ok {
+		t.Fatalf("providers check=%+v with a healthy provider", c)
+	}
+	if c := checkNamed(resp, "disk"); c == nil || c.Ok || c.Error != "full" || resp.Status != "not_serving" {
+		t.Fatalf("disk check=%+v status=%q", c, resp.Status)
+	}
+
+	diskErr = nil
+	resp, _ = s.Health(context.Background(), &bridgev1.HealthRequest{})
+	if resp.Status != "serving" {
+		t.Fatalf("Status=%q with every check passing: %+v", resp.Status, resp.Checks)
+	}
+}
+
+func TestHealthCachesProviderChecks(t *testing.T) {
+	p := &countingProvider{serverTestProvider: serverTestProvider{id: "healthy"}}
+	registry := bridge.NewRegistry()
+	if err := registry.Register(p); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s := New(nil, registry, slog.Default(), RateLimitConfig{}, "test-instance", nil)
+	now := time.Now()
+	s.providerHealth.now = func() time.Time { return now }
+
+	for range 3 {
+		if _, err := s.Health(context.Background(), &bridgev1.HealthRequest{}); err != nil {
+			t.Fatalf("Health: %v", err)
+		}
+	}
+	if n := p.calls.Load(); n != 1 {
+		t.Fatalf("provider probed %d times within TTL, want 1", n)
+	}
+	now = now.Add(defaultHealthTTL)
+	if _, err := s.Health(context.Background(), &bridgev1.HealthRequest{}); err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if n := p.calls.Load(); n != 2 {
+		t.Fatalf("provider probed %d times after TTL, want 2", n)
+	}
+}
+
+func TestHealthWatchSendsChanges(t *testing.T) {
+	registry := bridge.NewRegistry()
+	if err := registry.Register(&serverTestProvider{id: "healthy"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s := New(nil, registry, slog.Default(), RateLimitConfig{}, "test-instance", nil)
+	s.providerHealth.ttl = 10 * time.Millisecond
+	var failing atomic.Bool
+	s.AddReadinessCheck("disk", func(context.Context) error {
+		if failing.Load() {
+			return errors.New("full")
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &healthStream{ctx: ctx, sent: make(chan *bridgev1.HealthResponse, 16)}
+	done := make(chan error, 1)
+	go func() { done <- s.HealthWatch(&bridgev1.HealthRequest{}, stream) }()
+
+	recv := func() *bridgev1.HealthResponse {
+		t.Helper()
+		select {
+		case resp := <-stream.sent:
+			return resp
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for HealthWatch")
+			return nil
+		}
+	}
+	if resp := recv(); resp.Status != "serving" {
+		t.Fatalf("initial Status=%q", resp.Status)
+	}
+	// Unchanged health is not resent.
+	select {
+	case resp := <-stream.sent:
+		t.Fatalf("unchanged health resent: %+v", resp)
+	case <-time.After(50 * time.Millisecond):
+	}
+	failing.Store(true)
+	if resp := recv(); resp.Status != "not_serving" {
+		t.Fatalf("Status=%q after a check failed", resp.Status)
+	}
+	failing.Store(false)
+	if resp := recv(); resp.Status != "serving" {
+		t.Fatalf("Status=%q after the check recovered", resp.Status)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("HealthWatch: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("HealthWatch did not return after cancel")
+	}
+}
+
+func TestReportHealthFollowsReadiness(t *testing.T) {
+	registry := bridge.NewRegistry()
+	if err := registry.Register(&serverTestProvider{id: "healthy"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s := New(nil, registry, slog.Default(), RateLimitConfig{}, "test-instance", nil)
+	s.providerHealth.ttl = 10 * time.Millisecond
+	var failing atomic.Bool
+	s.AddReadinessCheck("disk", func(context.Context) error {
+		if failing.Load() {
+			return errors.New("full")
+		}
+		return nil
+	})
+
+	hs := health.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.ReportHealth(ctx, hs)
+		close(done)
+	}()
+
+	waitStatus := func(service string, want healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := hs.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			if err == nil && resp.Status == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("service %q status=%v err=%v want %v", service, resp.GetStatus(), err, want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	service := bridgev1.BridgeService_ServiceDesc.ServiceName
+	waitStatus("", healthpb.HealthCheckResponse_SERVING)
+	waitStatus(service, healthpb.HealthCheckResponse_SERVING)
+	failing.Store(true)
+	waitStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	waitStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	cancel()
+	<-done
+	waitStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func TestHealthHandler(t *testing.T) {
+	registry := bridge.NewRegistry()
+	if err := registry.Register(&serverTestProvider{id: "healthy"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s := New(nil, registry, slog.Default(), RateLimitConfig{}, "test-instance", nil)
+	var diskErr error
+	s.AddReadinessCheck("disk", func(context.Context) error { return diskErr })
+	srv := httptest.NewServer(s.HealthHandler())
+	defer srv.Close()
+
+	get := func(path string) (int, map[string]any) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+		return resp.StatusCode, body
+	}
+	if code, body := get("/readyz"); code != http.StatusOK || body["status"] != "serving" {
+		t.Fatalf("/readyz=%d %v", code, body)
+	}
+	diskErr = errors.New("full")
+	if code, body := get("/readyz"); code != http.StatusServiceUnavailable || body["status"] != "not_serving" {
+		t.Fatalf("/readyz=%d %v with a failing check", code, body)
+	}
+	if code, body := get("/livez"); code != http.StatusOK || body["serverInstanceId"] != "test-instance" {
+		t.Fatalf("/livez=%d %v", code, body)
+	}
+	resp, err := http.Post(srv.URL+"/readyz", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST /readyz: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("POST /readyz=%d", resp.StatusCode)
+	}
+}
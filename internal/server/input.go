@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxInputChunkLen caps each SendInputStream message, as WriteInput caps
+// its data.
+const maxInputChunkLen = 1 << 20
+
+// SendInputStream reassembles an input sent in chunks and delivers it like
+// WriteInput. The checks and rate limits of WriteInput run once, on the
+// first message, so a client that may not write is refused before it sends
+// the rest.
+func (s *BridgeServer) SendInputStream(stream bridgev1.BridgeService_SendInputStreamServer) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "send input stream: no messages")
+	}
+	if err != nil {
+		return err
+	}
+	batch := first.Priority == bridgev1.InputPriority_INPUT_PRIORITY_BATCH
+	if err := s.checkGlobalLane(ctx, batch); err != nil {
+		return err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return err
+	}
+	if err := validateUUIDField("session_id", first.SessionId); err != nil {
+		return err
+	}
+	if err := validateStringField("client_id", first.ClientId, maxSessionIDLen, false); err != nil {
+		return err
+	}
+	if _, ok := bridgev1.InputPriority_name[int32(first.Priority)]; !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown priority %d", first.Priority)
+	}
+	if first.TotalSize > 0 {
+		if err := s.supervisor.CheckStreamInputSize(int(min(first.TotalSize, math.MaxInt))); err != nil {
+			return mapBridgeError(err, "send input stream")
+		}
+	}
+	info, err := s.supervisor.Get(first.SessionId)
+	if err != nil {
+		return mapBridgeError(err, "authorize session")
+	}
+	if err := authorizeSessionInfo(claims, info, auth.ScopeSessionsControl); err != nil {
+		return err
+	}
+	switch info.ActiveWriterClientID {
+	case "":
+		return mapBridgeError(bridge.ErrClientNotAttached, "send input stream")
+	case first.ClientId:
+	default:
+		return mapBridgeError(bridge.ErrClientMismatch, "send input stream")
+	}
+	limits := s.limitersFor(info.ProjectID)
+	if err := checkLimit(ctx, limits.write, first.SessionId, "write input rate limit exceeded for session"); err != nil {
+		return err
+	}
+	if err := checkLaneLimit(ctx, limits.project, info.ProjectID, batch, "rate limit exceeded for project"); err != nil {
+		return err
+	}
+
+	var data bytes.Buffer
+	for msg := first; ; {
+		if len(msg.Data) > maxInputChunkLen {
+			return status.Errorf(codes.InvalidArgument, "data exceeds max length %d", maxInputChunkLen)
+		}
+		if err := s.supervisor.CheckStreamInputSize(data.Len() + len(msg.Data)); err != nil {
+			return mapBridgeError(err, "send input stream")
+		}
+		data.Write(msg.Data)
+		msg, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if data.Len() == 0 {
+		return status.Error(codes.InvalidArgument, "send input stream: data is required")
+	}
+	if first.TotalSize > 0 && uint64(data.Len()) != first.TotalSize {
+		return status.Errorf(codes.InvalidArgument, "send input stream: received %d bytes, total_size is %d", data.Len(), first.TotalSize)
+	}
+
+	ack, err := s.supervisor.SendStreamInput(stream.Context(), first.SessionId, first.ClientId, data.Bytes())
+	if err != nil {
+		return mapBridgeError(err, "send input stream")
+	}
+	s.logger.Info("streamed input delivered", "session_id", first.SessionId, "client_id", first.ClientId, "input_id", ack.ID, "bytes", data.Len(), logging.RequestIDKey, logging.RequestID(stream.Context()))
+	return stream.SendAndClose(&bridgev1.WriteInputResponse{Accepted: true, BytesWritten: uint32(ack.Bytes), InputId: ack.ID, Queued: ack.Queued, Seq: ack.Seq})
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// inputStream feeds SendInputStream a fixed list of chunks.
+type inputStream struct {
+	ctx    context.Context
+	chunks []*bridgev1.SendInputStreamRequest
+	resp   *bridgev1.WriteInputResponse
+}
+
+func (s *inputStream) SetHeader(metadata.MD) error  { return nil }
+func (s *inputStream) SendHeader(metadata.MD) error { return nil }
+func (s *inputStream) SetTrailer(metadata.MD)       {}
+func (s *inputStream) Context() context.Context     { return s.ctx }
+func (s *inputStream) SendMsg(any) error            { return nil }
+func (s *inputStream) RecvMsg(any) error            { return nil }
+
+func (s *inputStream) Recv() (*bridgev1.SendInputStreamRequest, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	req := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return req, nil
+}
+
+func (s *inputStream) SendAndClose(resp *bridgev1.WriteInputResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func TestSendInputStream(t *testing.T) {
+	s, supervisor := newTerminalTestServer(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a"})
+	sessionID := uuid.NewString()
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{ProjectId: "project-a", SessionId: sessionID, RepoPath: t.TempDir(), Provider: "cat"}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	t.Cleanup(func() { _ = supervisor.Stop(sessionID, true) })
+	if _, err := supervisor.Attach(sessionID, "writer", 0, bridge.AttachRoleWriter); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+
+	send := func(chunks ...*bridgev1.SendInputStreamRequest) (*bridgev1.WriteInputResponse, error) {
+		stream := &inputStream{ctx: ctx, chunks: chunks}
+		err := s.SendInputStream(stream)
+		return stream.resp, err
+	}
+
+	resp, err := send(
+		&bridgev1.SendInputStreamRequest{SessionId: sessionID, ClientId: "writer", Data: []byte("hello "), TotalSize: 13},
+		&bridgev1.SendInputStreamRequest{Data: []byte("stream\n")},
+	)
+	if err != nil || !resp.Accepted || resp.BytesWritten != 13 || resp.InputId == "" {
+		t.Fatalf("SendInputStream resp=%+v err=%v", resp, err)
+	}
+
+	if _, err := send(&bridgev1.SendInputStreamRequest{SessionId: sessionID, ClientId: "writer", Data: []byte("short"), TotalSize: 10}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("total_size mismatch: code=%v want InvalidArgument", status.Code(err))
+	}
+	if _, err := send(&bridgev1.SendInputStreamRequest{SessionId: sessionID, ClientId: "other", Data: []byte("x")}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("non-writer: code=%v want PermissionDenied", status.Code(err))
+	}
+
+	policy := bridge.DefaultPolicy()
+	policy.MaxStreamInputBytes = 8
+	supervisor.SetPolicy(policy)
+	if _, err := send(&bridgev1.SendInputStreamRequest{SessionId: sessionID, ClientId: "writer", Data: []byte("x"), TotalSize: 9}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("declared oversize: code=%v want ResourceExhausted", status.Code(err))
+	}
+	if _, err := send(
+		&bridgev1.SendInputStreamRequest{SessionId: sessionID, ClientId: "writer", Data: []byte("12345")},
+		&bridgev1.SendInputStreamRequest{Data: []byte("6789")},
+	); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("streamed oversize: code=%v want ResourceExhausted", status.Code(err))
+	}
+}
+
+func TestWriteInputAttachments(t *testing.T) {
+	s, supervisor := newTerminalTestServer(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a"})
+	sessionID := uuid.NewString()
+	repo := t.TempDir()
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{ProjectId: "project-a", SessionId: sessionID, RepoPath: repo, Provider: "cat"}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	t.Cleanup(func() { _ = supervisor.Stop(sessionID, true) })
+	if _, err := supervisor.Attach(sessionID, "writer", 0, bridge.AttachRoleWriter); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+
+	resp, err := s.WriteInput(ctx, &bridgev1.WriteInputRequest{
+		SessionId:   sessionID,
+		ClientId:    "writer",
+		Data:        []byte("summarize\r"),
+		Attachments: []*bridgev1.InputAttachment{{Name: "spec.md", Content: []byte("# Spec\n")}},
+	})
+	if err != nil {
+		t.Fatalf("WriteInput: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(repo, ".bridge", "attachments", resp.InputId, "spec.md")); err != nil || string(data) != "# Spec\n" {
+		t.Fatalf("attachment=%q err=%v", data, err)
+	}
+	ev := chunkToProto(sessionID, bridge.OutputChunk{Type: bridge.ChunkTypeInputAcked, InputID: resp.InputId, Attachments: []string{"spec.md"}}, false)
+	if ev.Type != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_INPUT_ACKED || len(ev.Attachments) != 1 {
+		t.Fatalf("INPUT_ACKED event=%+v", ev)
+	}
+
+	for name, list := range map[string][]*bridgev1.InputAttachment{
+		"escape":   {{Path: "../outside"}},
+		"too many": make([]*bridgev1.InputAttachment, maxInputAttachments+1),
+		"control":  {{Name: "a\x01b"}},
+	} {
+		for i := range list {
+			if list[i] == nil {
+				list[i] = &bridgev1.InputAttachment{Path: fmt.Sprintf("f%d", i)}
+			}
+		}
+		_, err := s.WriteInput(ctx, &bridgev1.WriteInputRequest{SessionId: sessionID, ClientId: "writer", Data: []byte("x"), Attachments: list})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("%s: code=%v want InvalidArgument", name, status.Code(err))
+		}
+	}
+}
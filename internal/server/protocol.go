@@ -0,0 +1,80 @@
+package server
+
+import (
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+)
+
+// ProtocolVersion is the newest event protocol this bridge speaks. Bump it
+// when adding an AttachEventType, and record the new type in
+// eventTypeVersions, so that clients built against an older version receive
+// it as ATTACH_EVENT_TYPE_EXTENSION instead of an enum value they cannot
+// name.
+const ProtocolVersion uint32 = 6
+
+// eventTypeVersions maps each event type to the protocol version that
+// introduced it. Version 1 is every type that existed when versioning was
+// added, including EXTENSION itself.
+var eventTypeVersions = map[bridgev1.AttachEventType]uint32{
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED:           1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:             1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_REPLAY_GAP:         1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_EXIT:       1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ERROR:              1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_THINKING:           1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WRITER_CLAIMED:     1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WRITER_RELEASED:    1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE:  1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_USAGE:              1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WARNING:            1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_INPUT_ACKED:        1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_REPO_DIRTY:         1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_RESTARTED:  1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_BATCH:              1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_TOOL_USE:           1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_EXTENSION:          1,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_APPROVAL_REQUESTED: 2,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_APPROVAL_RESOLVED:  2,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_CONTROL_CHANGED:    3,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_BUDGET_EXCEEDED:    4,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT_TRUNCATED:   5,
+	bridgev1.AttachEventType_ATTACH_EVENT_TYPE_POLICY_VIOLATION:   6,
+}
+
+// negotiateProtocol returns the version a request is served with: the lower
+// of the client's and the bridge's, or 0, which disables event mapping, for
+// clients that did not send one.
+func negotiateProtocol(requested uint32) uint32 {
+	return min(requested, ProtocolVersion)
+}
+
+// reportedProtocol is the version reported back to a client: the
+// negotiated one, or the bridge's own to a client that did not send one.
+func reportedProtocol(requested uint32) uint32 {
+	if requested == 0 {
+		return ProtocolVersion
+	}
+	return negotiateProtocol(requested)
+}
+
+// downgradeEvent returns ev as a client speaking version sees it: unchanged
+// when the client knows ev's type, and as an EXTENSION event otherwise.
+// Types missing from eventTypeVersions count as newer than every version.
+func downgradeEvent(ev *bridgev1.AttachSessionEvent, version uint32) *bridgev1.AttachSessionEvent {
+	if version == 0 {
+		return ev
+	}
+	if v, ok := eventTypeVersions[ev.Type]; ok && v <= version {
+		return ev
+	}
+	return &bridgev1.AttachSessionEvent{
+		Type:          bridgev1.AttachEventType_ATTACH_EVENT_TYPE_EXTENSION,
+		Seq:           ev.Seq,
+		Timestamp:     ev.Timestamp,
+		SessionId:     ev.SessionId,
+		Payload:       ev.Payload,
+		Replay:        ev.Replay,
+		InputId:       ev.InputId,
+		ExtensionType: ev.Type.String(),
+		DataJson:      ev.DataJson,
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+)
+
+// Every event type needs a version, or versioned clients receive it as
+// EXTENSION.
+func TestEventTypeVersionsComplete(t *testing.T) {
+	for n, name := range bridgev1.AttachEventType_name {
+		typ := bridgev1.AttachEventType(n)
+		if typ == bridgev1.AttachEventType_ATTACH_EVENT_TYPE_UNSPECIFIED {
+			continue
+		}
+		v, ok := eventTypeVersions[typ]
+		if !ok {
+			t.Errorf("%s has no protocol version", name)
+		} else if v > ProtocolVersion {
+			t.Errorf("%s introduced in version %d, after ProtocolVersion %d", name, v, ProtocolVersion)
+		}
+	}
+}
+
+func TestNegotiateProtocol(t *testing.T) {
+	for _, tc := range []struct{ requested, negotiated, reported uint32 }{
+		{0, 0, ProtocolVersion},
+		{1, 1, 1},
+		{ProtocolVersion + 5, ProtocolVersion, ProtocolVersion},
+	} {
+		if got := negotiateProtocol(tc.requested); got != tc.negotiated {
+			t.Errorf("negotiateProtocol(%d)=%d want %d", tc.requested, got, tc.negotiated)
+		}
+		if got := reportedProtocol(tc.requested); got != tc.reported {
+			t.Errorf("reportedProtocol(%d)=%d want %d", tc.requested, got, tc.reported)
+		}
+	}
+}
+
+func TestDowngradeEvent(t *testing.T) {
+	output := &bridgev1.AttachSessionEvent{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, Seq: 3, Payload: []byte("hi")}
+	if got := downgradeEvent(output, 1); got != output {
+		t.Fatalf("known type changed: %+v", got)
+	}
+
+	// 99 stands for a type added after the client's version.
+	future := &bridgev1.AttachSessionEvent{Type: 99, Seq: 4, SessionId: "s", Payload: []byte("data"), InputId: "in-1", Replay: true}
+	if got := downgradeEvent(future, 0); got != future {
+		t.Fatal("version 0 mapped an event")
+	}
+	got := downgradeEvent(future, 1)
+	if got.Type != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_EXTENSION || got.ExtensionType != "99" {
+		t.Fatalf("downgraded=%+v want EXTENSION of 99", got)
+	}
+	if got.Seq != 4 || got.SessionId != "s" || string(got.Payload) != "data" || got.InputId != "in-1" || !got.Replay {
+		t.Fatalf("downgraded=%+v lost fields", got)
+	}
+}
+
+func TestStartSessionReportsProtocol(t *testing.T) {
+	s, _ := newServerWithSupervisor(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "proj"})
+	resp, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId:       "proj",
+		SessionId:       "6a1f0e2d-3c4b-4a59-8e7f-0d1c2b3a4f5e",
+		RepoPath:        t.TempDir(),
+		Provider:        "cat",
+		ProtocolVersion: ProtocolVersion + 1,
+	})
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if resp.ProtocolVersion != ProtocolVersion {
+		t.Fatalf("protocol_version=%d want %d", resp.ProtocolVersion, ProtocolVersion)
+	}
+}
@@ -1,10 +1,28 @@
 package server
 
 import (
+	"context"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
+// retryAfterTrailer is the trailer that carries, in whole seconds, how long
+// a rate-limited caller should wait, for clients that do not decode status
+// details.
+const retryAfterTrailer = "retry-after"
+
 type tokenBucket struct {
 	rate     float64
 	burst    float64
@@ -24,6 +42,13 @@ func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
 }
 
 func (b *tokenBucket) allow(now time.Time) bool {
+	ok, _ := b.take(now, 0)
+	return ok
+}
+
+// take spends a token if at least one is left above floor. Otherwise it
+// returns how long until the bucket holds enough again.
+func (b *tokenBucket) take(now time.Time, floor float64) (bool, time.Duration) {
 	elapsed := now.Sub(b.last).Seconds()
 	if elapsed > 0 {
 		b.tokens += elapsed * b.rate
@@ -33,11 +58,11 @@ func (b *tokenBucket) allow(now time.Time) bool {
 		b.last = now
 	}
 	b.lastSeen = now
-	if b.tokens < 1 {
-		return false
+	if b.tokens < 1+floor {
+		return false, time.Duration((1 + floor - b.tokens) / b.rate * float64(time.Second))
 	}
 	b.tokens--
-	return true
+	return true, 0
 }
 
 type keyedLimiter struct {
@@ -46,6 +71,14 @@ type keyedLimiter struct {
 	burst   int
 	buckets map[string]*tokenBucket
 	ttl     time.Duration
+	// reserve is the share of each bucket's burst that batch requests
+	// cannot spend, so interactive requests still get through while batch
+	// traffic keeps the bucket near empty.
+	reserve float64
+	// rejected counts the requests refused since the limiter was created,
+	// and rejectedBatch the batch requests among them.
+	rejected      uint64
+	rejectedBatch uint64
 }
 
 func newKeyedLimiter(rate float64, burst int) *keyedLimiter {
@@ -57,22 +90,83 @@ func newKeyedLimiter(rate float64, burst int) *keyedLimiter {
 	}
 }
 
+// setLimits changes the rate and burst for new and existing buckets.
+func (l *keyedLimiter) setLimits(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.burst = burst
+	for _, b := range l.buckets {
+		b.rate = rate
+		b.burst = float64(burst)
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+}
+
+// setReserve changes the share of the burst held back from batch requests.
+func (l *keyedLimiter) setReserve(reserve float64) {
+	l.mu.Lock()
+	l.reserve = reserve
+	l.mu.Unlock()
+}
+
 func (l *keyedLimiter) allow(key string) bool {
-	if l == nil || l.rate <= 0 || l.burst <= 0 {
-		return true
+	ok, _ := l.take(key, false)
+	return ok
+}
+
+// take spends a token from key's bucket; a batch request leaves the
+// reserve untouched. When it is refused, take returns how long until a
+// retry can succeed.
+func (l *keyedLimiter) take(key string, batch bool) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
 	}
 	now := time.Now()
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if l.rate <= 0 || l.burst <= 0 {
+		return true, 0
+	}
 
 	b := l.buckets[key]
 	if b == nil {
 		b = newTokenBucket(l.rate, l.burst, now)
 		l.buckets[key] = b
 	}
-	allowed := b.allow(now)
+	floor := 0.0
+	if batch {
+		floor = l.reserve * float64(l.burst)
+	}
+	allowed, wait := b.take(now, floor)
+	if !allowed {
+		l.rejected++
+		if batch {
+			l.rejectedBatch++
+		}
+	}
 	l.cleanupLocked(now)
-	return allowed
+	return allowed, wait
+}
+
+// state reports the limiter's limits, its buckets seen within the TTL and
+// its rejections.
+func (l *keyedLimiter) state(name, projectID string) *bridgev1.RateLimiterState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cleanupLocked(time.Now())
+	return &bridgev1.RateLimiterState{
+		Name:               name,
+		ProjectId:          projectID,
+		Rate:               l.rate,
+		Burst:              uint32(max(l.burst, 0)),
+		ActiveBuckets:      uint32(len(l.buckets)),
+		Rejected:           l.rejected,
+		InteractiveReserve: l.reserve,
+		RejectedBatch:      l.rejectedBatch,
+	}
 }
 
 func (l *keyedLimiter) cleanupLocked(now time.Time) {
@@ -82,3 +176,144 @@ func (l *keyedLimiter) cleanupLocked(now time.Time) {
 		}
 	}
 }
+
+// checkLimit takes a token for key from l. When l refuses it, checkLimit
+// returns a ResourceExhausted error with msg that tells the caller when to
+// retry, as a google.rpc.RetryInfo detail and in the retry-after trailer.
+func checkLimit(ctx context.Context, l *keyedLimiter, key, msg string) error {
+	return checkLaneLimit(ctx, l, key, false, msg)
+}
+
+// checkLaneLimit is checkLimit for a request in the batch lane when batch
+// is set.
+func checkLaneLimit(ctx context.Context, l *keyedLimiter, key string, batch bool, msg string) error {
+	ok, wait := l.take(key, batch)
+	if ok {
+		return nil
+	}
+	secs := max(int64(math.Ceil(wait.Seconds())), 1)
+	// SetTrailer fails only outside an RPC, e.g. in tests calling the
+	// handlers directly; the status detail still carries the hint.
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(retryAfterTrailer, strconv.FormatInt(secs, 10)))
+	st := status.New(codes.ResourceExhausted, msg)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// waitLimit takes a token for key from l, waiting for one to be left
+// rather than refusing the request. It returns ctx's error if ctx ends
+// first.
+func waitLimit(ctx context.Context, l *keyedLimiter, key string) error {
+	for {
+		ok, wait := l.take(key, false)
+		if ok {
+			return nil
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// checkGlobalLimit applies the server-wide RPC limit.
+func (s *BridgeServer) checkGlobalLimit(ctx context.Context) error {
+	return s.checkGlobalLane(ctx, false)
+}
+
+func (s *BridgeServer) checkGlobalLane(ctx context.Context, batch bool) error {
+	return checkLaneLimit(ctx, s.globalRL, "global", batch, "global RPC rate limit exceeded")
+}
+
+// projectLimiters are the limiters that apply to one project's
+// StartSession and WriteInput calls.
+type projectLimiters struct {
+	start   *keyedLimiter
+	write   *keyedLimiter
+	project *keyedLimiter
+}
+
+// setProjectRateLimits applies rl.Projects. Limiters of projects that keep
+// an override are updated in place so their buckets survive a reload.
+func (s *BridgeServer) setProjectRateLimits(rl RateLimitConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := make(map[string]*projectLimiters, len(rl.Projects))
+	for project, p := range rl.Projects {
+		startRPS, startBurst := rl.StartSessionPerClientRPS, rl.StartSessionPerClientBurst
+		if p.StartSessionPerClientRPS != 0 {
+			startRPS = p.StartSessionPerClientRPS
+		}
+		if p.StartSessionPerClientBurst != 0 {
+			startBurst = p.StartSessionPerClientBurst
+		}
+		writeRPS, writeBurst := rl.SendInputPerSessionRPS, rl.SendInputPerSessionBurst
+		if p.SendInputPerSessionRPS != 0 {
+			writeRPS = p.SendInputPerSessionRPS
+		}
+		if p.SendInputPerSessionBurst != 0 {
+			writeBurst = p.SendInputPerSessionBurst
+		}
+		projectRPS, projectBurst := rl.ProjectRPS, rl.ProjectBurst
+		if p.ProjectRPS != 0 {
+			projectRPS = p.ProjectRPS
+		}
+		if p.ProjectBurst != 0 {
+			projectBurst = p.ProjectBurst
+		}
+		l := s.projectOverrides[project]
+		if l == nil {
+			l = &projectLimiters{
+				start:   newKeyedLimiter(startRPS, startBurst),
+				write:   newKeyedLimiter(writeRPS, writeBurst),
+				project: newKeyedLimiter(projectRPS, projectBurst),
+			}
+			l.project.setReserve(rl.InteractiveReserve)
+		} else {
+			l.start.setLimits(startRPS, startBurst)
+			l.write.setLimits(writeRPS, writeBurst)
+			l.project.setLimits(projectRPS, projectBurst)
+			l.project.setReserve(rl.InteractiveReserve)
+		}
+		next[project] = l
+	}
+	s.projectOverrides = next
+}
+
+// limitersFor returns the limiters that apply to projectID.
+func (s *BridgeServer) limitersFor(projectID string) projectLimiters {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if l := s.projectOverrides[projectID]; l != nil {
+		return *l
+	}
+	return projectLimiters{start: s.startRL, write: s.writeRL, project: s.projectRL}
+}
+
+// rateLimiterStates reports every limiter for GetMetrics: the shared ones
+// first, then those of projects with their own limits, sorted by project.
+func (s *BridgeServer) rateLimiterStates() []*bridgev1.RateLimiterState {
+	states := []*bridgev1.RateLimiterState{
+		s.globalRL.state("global", ""),
+		s.startRL.state("start_session", ""),
+		s.writeRL.state("write_input", ""),
+		s.projectRL.state("project", ""),
+	}
+	s.mu.RLock()
+	overrides := maps.Clone(s.projectOverrides)
+	s.mu.RUnlock()
+	for _, project := range slices.Sorted(maps.Keys(overrides)) {
+		l := overrides[project]
+		states = append(states,
+			l.start.state("start_session", project),
+			l.write.state("write_input", project),
+			l.project.state("project", project),
+		)
+	}
+	return states
+}
@@ -3,17 +3,27 @@ package server
 import (
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
 	"github.com/markcallen/ai-agent-bridge/internal/auth"
 	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/logging"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -26,15 +36,31 @@ func generateID() string {
 type BridgeServer struct {
 	bridgev1.UnimplementedBridgeServiceServer
 
-	supervisor       *bridge.Supervisor
-	registry         *bridge.Registry
-	logger           *slog.Logger
-	globalRL         *keyedLimiter
-	startRL          *keyedLimiter
-	writeRL          *keyedLimiter
+	supervisor *bridge.Supervisor
+	registry   *bridge.Registry
+	logger     *slog.Logger
+	globalRL   *keyedLimiter
+	startRL    *keyedLimiter
+	writeRL    *keyedLimiter
+	// projectRL is the bucket per project shared by StartSession and
+	// WriteInput.
+	projectRL        *keyedLimiter
 	serverInstanceID string
+	// draining refuses new sessions once set.
+	draining atomic.Bool
+
+	mu sync.RWMutex
+	// projectOverrides holds the limiters of projects with their own rate
+	// limits; other projects share startRL, writeRL and projectRL.
+	projectOverrides map[string]*projectLimiters
 	// providerFallbacks maps each provider ID to its ordered fallback list.
 	providerFallbacks map[string][]string
+	// readiness holds the checks added with AddReadinessCheck.
+	readiness []readinessCheck
+	// sessionTokens signs MintSessionToken tokens; nil disables it.
+	sessionTokens *auth.JWTIssuer
+
+	providerHealth *healthCache
 }
 
 type RateLimitConfig struct {
@@ -44,102 +70,230 @@ type RateLimitConfig struct {
 	StartSessionPerClientBurst int
 	SendInputPerSessionRPS     float64
 	SendInputPerSessionBurst   int
+	// ProjectRPS and ProjectBurst size a bucket per project ID that
+	// StartSession and WriteInput both draw from. Zero disables it.
+	ProjectRPS   float64
+	ProjectBurst int
+	// InteractiveReserve is the share, from 0 to 1, of the global and
+	// project buckets that batch WriteInput calls cannot spend.
+	InteractiveReserve float64
+	// Projects overrides the StartSession, WriteInput and project limits
+	// per project ID. Zero fields keep the limits above.
+	Projects map[string]ProjectRateLimits
+}
+
+// ProjectRateLimits are one project's StartSession, WriteInput and
+// project bucket limits.
+type ProjectRateLimits struct {
+	StartSessionPerClientRPS   float64
+	StartSessionPerClientBurst int
+	SendInputPerSessionRPS     float64
+	SendInputPerSessionBurst   int
+	ProjectRPS                 float64
+	ProjectBurst               int
 }
 
 func New(supervisor *bridge.Supervisor, registry *bridge.Registry, logger *slog.Logger, rl RateLimitConfig, serverInstanceID string, providerFallbacks map[string][]string) *BridgeServer {
 	if logger == nil {
 		logger = slog.Default()
 	}
-	return &BridgeServer{
+	s := &BridgeServer{
 		supervisor:        supervisor,
 		registry:          registry,
 		logger:            logger,
 		globalRL:          newKeyedLimiter(rl.GlobalRPS, rl.GlobalBurst),
 		startRL:           newKeyedLimiter(rl.StartSessionPerClientRPS, rl.StartSessionPerClientBurst),
 		writeRL:           newKeyedLimiter(rl.SendInputPerSessionRPS, rl.SendInputPerSessionBurst),
+		projectRL:         newKeyedLimiter(rl.ProjectRPS, rl.ProjectBurst),
 		serverInstanceID:  serverInstanceID,
 		providerFallbacks: providerFallbacks,
+		providerHealth:    &healthCache{ttl: defaultHealthTTL},
 	}
+	s.globalRL.setReserve(rl.InteractiveReserve)
+	s.projectRL.setReserve(rl.InteractiveReserve)
+	s.setProjectRateLimits(rl)
+	return s
 }
 
-func (s *BridgeServer) StartSession(ctx context.Context, req *bridgev1.StartSessionRequest) (*bridgev1.StartSessionResponse, error) {
-	if !s.globalRL.allow("global") {
-		return nil, status.Error(codes.ResourceExhausted, "global RPC rate limit exceeded")
+// SetRateLimits applies new rate limits to all limiters. Existing buckets
+// keep their current tokens and refill at the new rate.
+func (s *BridgeServer) SetRateLimits(rl RateLimitConfig) {
+	s.globalRL.setLimits(rl.GlobalRPS, rl.GlobalBurst)
+	s.startRL.setLimits(rl.StartSessionPerClientRPS, rl.StartSessionPerClientBurst)
+	s.writeRL.setLimits(rl.SendInputPerSessionRPS, rl.SendInputPerSessionBurst)
+	s.projectRL.setLimits(rl.ProjectRPS, rl.ProjectBurst)
+	s.globalRL.setReserve(rl.InteractiveReserve)
+	s.projectRL.setReserve(rl.InteractiveReserve)
+	s.setProjectRateLimits(rl)
+}
+
+// StartDrain makes the server refuse new sessions until it restarts and
+// reports whether it already did. Running sessions are not affected.
+func (s *BridgeServer) StartDrain() bool {
+	return s.draining.Swap(true)
+}
+
+// Draining reports whether StartDrain was called.
+func (s *BridgeServer) Draining() bool {
+	return s.draining.Load()
+}
+
+// SetProviderFallbacks replaces the provider fallback map used by
+// StartSession.
+func (s *BridgeServer) SetProviderFallbacks(fallbacks map[string][]string) {
+	s.mu.Lock()
+	s.providerFallbacks = fallbacks
+	s.mu.Unlock()
+}
+
+func (s *BridgeServer) fallbacksFor(providerID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.providerFallbacks[providerID]
+}
+
+// admitSession applies the checks every new session passes before the
+// supervisor starts it, whether it comes from StartSession or from an
+// imported handoff: field validation, project authorization, the per-client
+// start and per-project rate limits and repo_path access. Fallbacks and resource limits are
+// always taken from this server's configuration, never from the caller.
+func (s *BridgeServer) admitSession(ctx context.Context, claims *auth.BridgeClaims, cfg *bridge.SessionConfig) error {
+	if s.draining.Load() {
+		return status.Error(codes.Unavailable, "server is draining; not accepting new sessions")
+	}
+	if err := validateStringField("project_id", cfg.ProjectID, maxProjectIDLen, false); err != nil {
+		return err
 	}
-	claims, err := mustClaims(ctx)
-	if err != nil {
-		return nil, err
+	if err := validateUUIDField("session_id", cfg.SessionID); err != nil {
+		return err
 	}
-	if err := validateStringField("project_id", req.ProjectId, maxProjectIDLen, false); err != nil {
-		return nil, err
+	if err := validateRepoSource(cfg.RepoPath, cfg.Source); err != nil {
+		return err
 	}
-	if err := validateUUIDField("session_id", req.SessionId); err != nil {
-		return nil, err
+	if cfg.ConversationID != "" {
+		if err := validateConversationID(cfg.ConversationID); err != nil {
+			return err
+		}
 	}
-	if err := validateStringField("repo_path", req.RepoPath, maxRepoPathLen, false); err != nil {
-		return nil, err
+	providerID := cfg.Options["provider"]
+	if err := validateStringField("provider", providerID, maxProviderLen, false); err != nil {
+		return err
 	}
-	if err := validateStringField("provider", req.Provider, maxProviderLen, false); err != nil {
-		return nil, err
+	if err := authorizeProject(claims, cfg.ProjectID); err != nil {
+		return err
 	}
-	if err := authorizeProject(claims, req.ProjectId); err != nil {
-		return nil, err
+	if claims.SessionID != "" {
+		return status.Errorf(codes.PermissionDenied, "token is scoped to session %q and cannot start sessions", claims.SessionID)
+	}
+	if len(cfg.WriterSubjects) > maxWriterSubjects {
+		return status.Errorf(codes.InvalidArgument, "writer_subjects exceeds max count %d", maxWriterSubjects)
+	}
+	for _, sub := range cfg.WriterSubjects {
+		if err := validateStringField("writer_subjects", sub, maxTokenFieldLen, false); err != nil {
+			return err
+		}
+	}
+	// The caller may always control what it started.
+	if len(cfg.WriterSubjects) > 0 && claims.Subject != "" && !slices.Contains(cfg.WriterSubjects, claims.Subject) {
+		cfg.WriterSubjects = append(slices.Clone(cfg.WriterSubjects), claims.Subject)
 	}
 
 	clientID := claims.Subject
 	if clientID == "" {
 		clientID = claims.ProjectID
 	}
-	if !s.startRL.allow(clientID) {
-		return nil, status.Error(codes.ResourceExhausted, "start session rate limit exceeded for client")
+	limits := s.limitersFor(cfg.ProjectID)
+	if err := checkLimit(ctx, limits.start, clientID, "start session rate limit exceeded for client"); err != nil {
+		return err
+	}
+	if err := checkLimit(ctx, limits.project, cfg.ProjectID, "rate limit exceeded for project"); err != nil {
+		return err
 	}
 
-	if err := checkDirReadWrite(req.RepoPath); err != nil {
-		return nil, status.Errorf(codes.PermissionDenied, "repo_path %q: %v", req.RepoPath, err)
+	if cfg.Source == nil {
+		if err := checkDirReadWrite(cfg.RepoPath); err != nil {
+			return status.Errorf(codes.PermissionDenied, "repo_path %q: %v", cfg.RepoPath, err)
+		}
 	}
+	cfg.Fallbacks = s.fallbacksFor(providerID)
+	cfg.Limits = bridge.ResourceLimits{}
+	return nil
+}
 
+func (s *BridgeServer) StartSession(ctx context.Context, req *bridgev1.StartSessionRequest) (*bridgev1.StartSessionResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	var source *bridge.RepoSource
+	if src := req.RepoSource; src != nil {
+		source = &bridge.RepoSource{
+			URL:         src.Url,
+			Ref:         src.Ref,
+			Depth:       int(src.Depth),
+			CleanOnStop: src.CleanOnStop,
+		}
+	}
 	opts := map[string]string{"provider": req.Provider}
 	for k, v := range req.AgentOpts {
 		opts[k] = v
 	}
+	cfg := bridge.SessionConfig{
+		SessionID:      req.SessionId,
+		ProjectID:      req.ProjectId,
+		RepoPath:       req.RepoPath,
+		Source:         source,
+		Snapshot:       req.Snapshot,
+		Options:        opts,
+		InitialCols:    req.InitialCols,
+		InitialRows:    req.InitialRows,
+		Env:            req.Env,
+		WriterSubjects: req.WriterSubjects,
+		ConversationID: req.ConversationId,
+	}
+	if err := s.admitSession(ctx, claims, &cfg); err != nil {
+		return nil, err
+	}
 
-	s.logger.Info("starting session", "session_id", req.SessionId, "project_id", req.ProjectId, "provider", req.Provider, "repo_path", req.RepoPath)
-	info, err := s.supervisor.Start(ctx, bridge.SessionConfig{
-		SessionID:   req.SessionId,
-		ProjectID:   req.ProjectId,
-		RepoPath:    req.RepoPath,
-		Options:     opts,
-		Fallbacks:   s.providerFallbacks[req.Provider],
-		InitialCols: req.InitialCols,
-		InitialRows: req.InitialRows,
-	})
+	s.logger.Info("starting session", "session_id", req.SessionId, "project_id", req.ProjectId, "provider", req.Provider, "repo_path", req.RepoPath, "repo_url", req.GetRepoSource().GetUrl(), logging.RequestIDKey, logging.RequestID(ctx))
+	info, err := s.supervisor.Start(ctx, cfg)
 	if err != nil {
-		s.logger.Warn("start session failed", "session_id", req.SessionId, "error", err)
+		s.logger.Warn("start session failed", "session_id", req.SessionId, "error", err, logging.RequestIDKey, logging.RequestID(ctx))
 		return nil, mapBridgeError(err, "start session")
 	}
 	s.logger.Info("session started", "session_id", info.SessionID, "provider", info.Provider, "pid", info.ProcessID)
 	return &bridgev1.StartSessionResponse{
-		SessionId: info.SessionID,
-		Status:    mapState(info.State),
-		CreatedAt: timestamppb.New(info.CreatedAt),
+		SessionId:       info.SessionID,
+		Status:          mapState(info.State),
+		CreatedAt:       timestamppb.New(info.CreatedAt),
+		ProtocolVersion: reportedProtocol(req.ProtocolVersion),
 	}, nil
 }
 
 func (s *BridgeServer) StopSession(ctx context.Context, req *bridgev1.StopSessionRequest) (*bridgev1.StopSessionResponse, error) {
-	if !s.globalRL.allow("global") {
-		return nil, status.Error(codes.ResourceExhausted, "global RPC rate limit exceeded")
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
 	}
 	claims, err := mustClaims(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
 	if err := validateUUIDField("session_id", req.SessionId); err != nil {
 		return nil, err
 	}
-	if err := s.authorizeSession(claims, req.SessionId); err != nil {
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeSessionsControl); err != nil {
 		return nil, err
 	}
-	s.logger.Info("stopping session", "session_id", req.SessionId, "force", req.Force)
+	s.logger.Info("stopping session", "session_id", req.SessionId, "force", req.Force, logging.RequestIDKey, logging.RequestID(ctx))
 	if err := s.supervisor.Stop(req.SessionId, req.Force); err != nil {
 		s.logger.Warn("stop session failed", "session_id", req.SessionId, "error", err)
 		return nil, mapBridgeError(err, "stop session")
@@ -148,17 +302,20 @@ func (s *BridgeServer) StopSession(ctx context.Context, req *bridgev1.StopSessio
 }
 
 func (s *BridgeServer) GetSession(ctx context.Context, req *bridgev1.GetSessionRequest) (*bridgev1.GetSessionResponse, error) {
-	if !s.globalRL.allow("global") {
-		return nil, status.Error(codes.ResourceExhausted, "global RPC rate limit exceeded")
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
 	}
 	claims, err := mustClaims(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := requireScope(claims, auth.ScopeEventsRead); err != nil {
+		return nil, err
+	}
 	if err := validateUUIDField("session_id", req.SessionId); err != nil {
 		return nil, err
 	}
-	if err := s.authorizeSession(claims, req.SessionId); err != nil {
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeEventsRead); err != nil {
 		return nil, err
 	}
 	info, err := s.supervisor.Get(req.SessionId)
@@ -168,14 +325,246 @@ func (s *BridgeServer) GetSession(ctx context.Context, req *bridgev1.GetSessionR
 	return sessionInfoToProto(info), nil
 }
 
+func (s *BridgeServer) GetSessionHistory(ctx context.Context, req *bridgev1.GetSessionHistoryRequest) (*bridgev1.GetSessionHistoryResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeEventsRead); err != nil {
+		return nil, err
+	}
+	if err := validateUUIDField("session_id", req.SessionId); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeEventsRead); err != nil {
+		return nil, err
+	}
+	history, err := s.supervisor.History(req.SessionId)
+	if err != nil {
+		return nil, mapBridgeError(err, "get session history")
+	}
+	resp := &bridgev1.GetSessionHistoryResponse{
+		Session: sessionInfoToProto(&history.Info),
+		Events:  make([]*bridgev1.AttachSessionEvent, 0, len(history.Chunks)),
+	}
+	version := negotiateProtocol(req.ProtocolVersion)
+	for _, chunk := range history.Chunks {
+		if chunk.Seq > req.AfterSeq {
+			resp.Events = append(resp.Events, downgradeEvent(chunkToProto(req.SessionId, chunk, true), version))
+		}
+	}
+	if !history.ArchivedAt.IsZero() {
+		resp.ArchivedAt = timestamppb.New(history.ArchivedAt)
+	}
+	return resp, nil
+}
+
+func (s *BridgeServer) ExportTranscript(ctx context.Context, req *bridgev1.ExportTranscriptRequest) (*bridgev1.ExportTranscriptResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeEventsRead); err != nil {
+		return nil, err
+	}
+	if err := validateUUIDField("session_id", req.SessionId); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeEventsRead); err != nil {
+		return nil, err
+	}
+	format, contentType, ext, err := transcriptFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.supervisor.History(req.SessionId)
+	if err != nil {
+		return nil, mapBridgeError(err, "export transcript")
+	}
+	content, err := bridge.RenderTranscript(history, format)
+	if err != nil {
+		return nil, mapBridgeError(err, "export transcript")
+	}
+	return &bridgev1.ExportTranscriptResponse{
+		Content:     content,
+		ContentType: contentType,
+		Filename:    req.SessionId + ext,
+	}, nil
+}
+
+// transcriptFormat maps a requested transcript format to the supervisor's
+// name for it, its content type and its file extension.
+func transcriptFormat(f bridgev1.TranscriptFormat) (format, contentType, ext string, err error) {
+	switch f {
+	case bridgev1.TranscriptFormat_TRANSCRIPT_FORMAT_UNSPECIFIED, bridgev1.TranscriptFormat_TRANSCRIPT_FORMAT_MARKDOWN:
+		return bridge.TranscriptMarkdown, "text/markdown", ".md", nil
+	case bridgev1.TranscriptFormat_TRANSCRIPT_FORMAT_JSONL:
+		return bridge.TranscriptJSONL, "application/x-ndjson", ".jsonl", nil
+	default:
+		return "", "", "", status.Errorf(codes.InvalidArgument, "unsupported transcript format %v", f)
+	}
+}
+
+func (s *BridgeServer) GetConversation(ctx context.Context, req *bridgev1.GetConversationRequest) (*bridgev1.GetConversationResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeEventsRead); err != nil {
+		return nil, err
+	}
+	if err := validateConversationID(req.ConversationId); err != nil {
+		return nil, err
+	}
+	format, contentType, ext, err := transcriptFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.supervisor.ConversationHistory(req.ConversationId)
+	if err != nil {
+		return nil, mapBridgeError(err, "get conversation")
+	}
+	resp := &bridgev1.GetConversationResponse{
+		Sessions:    make([]*bridgev1.GetSessionResponse, 0, len(sessions)),
+		ContentType: contentType,
+		Filename:    req.ConversationId + ext,
+	}
+	for _, h := range sessions {
+		if err := authorizeSessionInfo(claims, &h.Info, auth.ScopeEventsRead); err != nil {
+			return nil, err
+		}
+		resp.Sessions = append(resp.Sessions, sessionInfoToProto(&h.Info))
+	}
+	if resp.Content, err = bridge.RenderConversation(req.ConversationId, sessions, format); err != nil {
+		return nil, mapBridgeError(err, "get conversation")
+	}
+	return resp, nil
+}
+
+func (s *BridgeServer) GetResponse(ctx context.Context, req *bridgev1.GetResponseRequest) (*bridgev1.GetResponseResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeEventsRead); err != nil {
+		return nil, err
+	}
+	if err := validateUUIDField("session_id", req.SessionId); err != nil {
+		return nil, err
+	}
+	if req.InputId != "" {
+		if err := validateUUIDField("input_id", req.InputId); err != nil {
+			return nil, err
+		}
+	} else if req.InputSeq == 0 {
+		return nil, status.Error(codes.InvalidArgument, "input_id or input_seq is required")
+	}
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeEventsRead); err != nil {
+		return nil, err
+	}
+	resp, err := s.supervisor.Response(req.SessionId, req.InputId, req.InputSeq)
+	if err != nil {
+		return nil, mapBridgeError(err, "get response")
+	}
+	return &bridgev1.GetResponseResponse{
+		InputId:  resp.InputID,
+		InputSeq: resp.InputSeq,
+		Prompt:   resp.Prompt,
+		Text:     resp.Text,
+		Thinking: resp.Thinking,
+		Complete: resp.Complete,
+		LastSeq:  resp.LastSeq,
+	}, nil
+}
+
+func (s *BridgeServer) ExportSessionState(ctx context.Context, req *bridgev1.ExportSessionStateRequest) (*bridgev1.ExportSessionStateResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	if err := validateUUIDField("session_id", req.SessionId); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	s.logger.Info("exporting session state", "session_id", req.SessionId, "stop", req.Stop)
+	handoff, err := s.supervisor.ExportState(ctx, req.SessionId, req.Stop)
+	if err != nil {
+		return nil, mapBridgeError(err, "export session state")
+	}
+	state, err := json.Marshal(handoff)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "export session state: %v", err)
+	}
+	return &bridgev1.ExportSessionStateResponse{State: state, LastSeq: handoff.Info.LastSeq}, nil
+}
+
+func (s *BridgeServer) ImportSessionState(ctx context.Context, req *bridgev1.ImportSessionStateRequest) (*bridgev1.ImportSessionStateResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	var handoff bridge.SessionHandoff
+	if err := json.Unmarshal(req.State, &handoff); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "state: %v", err)
+	}
+	// The exporting instance's workspace path means nothing here: a
+	// clone-backed session is cloned again on this host, and everything
+	// else passes the same checks as StartSession.
+	cfg := handoff.Config
+	if cfg.Source != nil {
+		cfg.RepoPath = ""
+	}
+	if err := s.admitSession(ctx, claims, &cfg); err != nil {
+		return nil, err
+	}
+	handoff.Config = cfg
+
+	s.logger.Info("importing session state", "session_id", cfg.SessionID, "project_id", cfg.ProjectID, "last_seq", handoff.Info.LastSeq)
+	info, err := s.supervisor.ImportState(ctx, &handoff)
+	if err != nil {
+		s.logger.Warn("import session state failed", "session_id", cfg.SessionID, "error", err)
+		return nil, mapBridgeError(err, "import session state")
+	}
+	return &bridgev1.ImportSessionStateResponse{Session: sessionInfoToProto(info)}, nil
+}
+
 func (s *BridgeServer) ListSessions(ctx context.Context, req *bridgev1.ListSessionsRequest) (*bridgev1.ListSessionsResponse, error) {
-	if !s.globalRL.allow("global") {
-		return nil, status.Error(codes.ResourceExhausted, "global RPC rate limit exceeded")
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
 	}
 	claims, err := mustClaims(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := requireScope(claims, auth.ScopeEventsRead); err != nil {
+		return nil, err
+	}
 	projectID := req.ProjectId
 	if claims.ProjectID != "" {
 		if projectID != "" && projectID != claims.ProjectID {
@@ -189,14 +578,17 @@ func (s *BridgeServer) ListSessions(ctx context.Context, req *bridgev1.ListSessi
 	}
 	for i := range items {
 		info := items[i]
+		if claims.SessionID != "" && info.SessionID != claims.SessionID {
+			continue
+		}
 		resp.Sessions = append(resp.Sessions, sessionInfoToProto(&info))
 	}
 	return resp, nil
 }
 
 func (s *BridgeServer) AttachSession(req *bridgev1.AttachSessionRequest, stream bridgev1.BridgeService_AttachSessionServer) error {
-	if !s.globalRL.allow("global") {
-		return status.Error(codes.ResourceExhausted, "global RPC rate limit exceeded")
+	if err := s.checkGlobalLimit(stream.Context()); err != nil {
+		return err
 	}
 	claims, err := mustClaims(stream.Context())
 	if err != nil {
@@ -208,18 +600,28 @@ func (s *BridgeServer) AttachSession(req *bridgev1.AttachSessionRequest, stream
 	if err := validateOptionalStringField("client_id", req.ClientId, maxSessionIDLen, false); err != nil {
 		return err
 	}
-	if err := s.authorizeSession(claims, req.SessionId); err != nil {
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeEventsRead); err != nil {
 		return err
 	}
 	clientID := req.ClientId
 	if clientID == "" {
 		clientID = generateID()
 	}
-	role := bridge.AttachRoleWriter
-	if req.Role == bridgev1.AttachRole_ATTACH_ROLE_OBSERVER {
-		role = bridge.AttachRoleObserver
+	role, err := s.attachRole(claims, req.SessionId, req.Role)
+	if err != nil {
+		return err
+	}
+	version := negotiateProtocol(req.ProtocolVersion)
+	filter, err := newAttachFilter(req.EventTypes, req.Match)
+	if err != nil {
+		return err
+	}
+	batch, err := newEventBatcher(stream, req.SessionId, req.MaxBatchSize, req.MaxBatchDelayMs)
+	if err != nil {
+		return err
 	}
-	s.logger.Info("attaching to session", "session_id", req.SessionId, "client_id", clientID, "after_seq", req.AfterSeq, "role", role)
+	defer batch.stop()
+	s.logger.Info("attaching to session", "session_id", req.SessionId, "client_id", clientID, "after_seq", req.AfterSeq, "role", role, logging.RequestIDKey, logging.RequestID(stream.Context()))
 	state, err := s.supervisor.Attach(req.SessionId, clientID, req.AfterSeq, role)
 	if err != nil {
 		s.logger.Warn("attach session failed", "session_id", req.SessionId, "client_id", clientID, "error", err)
@@ -232,14 +634,15 @@ func (s *BridgeServer) AttachSession(req *bridgev1.AttachSessionRequest, stream
 	}()
 
 	if err := stream.Send(&bridgev1.AttachSessionEvent{
-		Type:         bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED,
-		SessionId:    req.SessionId,
-		OldestSeq:    state.OldestSeq,
-		LastSeq:      state.LastSeq,
-		ExitRecorded: state.ExitRecorded,
-		ExitCode:     int32(state.ExitCode),
-		Cols:         state.Cols,
-		Rows:         state.Rows,
+		Type:            bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED,
+		SessionId:       req.SessionId,
+		OldestSeq:       state.OldestSeq,
+		LastSeq:         state.LastSeq,
+		ExitRecorded:    state.ExitRecorded,
+		ExitCode:        int32(state.ExitCode),
+		Cols:            state.Cols,
+		Rows:            state.Rows,
+		ProtocolVersion: reportedProtocol(req.ProtocolVersion),
 	}); err != nil {
 		return err
 	}
@@ -254,96 +657,369 @@ func (s *BridgeServer) AttachSession(req *bridgev1.AttachSessionRequest, stream
 		}
 	}
 	lastSeq := req.AfterSeq
-	for _, chunk := range state.Replay {
-		if err := stream.Send(chunkToProto(req.SessionId, chunk, true)); err != nil {
+	err = state.EachReplay(func(chunk bridge.OutputChunk) error {
+		lastSeq = chunk.Seq
+		if ev := downgradeEvent(chunkToProto(req.SessionId, chunk, true), version); filter.allows(ev) {
+			return batch.add(ev)
+		}
+		return nil
+	}, func(oldest uint64) error {
+		// Spilled output was deleted while it was being replayed.
+		if err := batch.flush(); err != nil {
 			return err
 		}
-		lastSeq = chunk.Seq
+		return stream.Send(&bridgev1.AttachSessionEvent{
+			Type:      bridgev1.AttachEventType_ATTACH_EVENT_TYPE_REPLAY_GAP,
+			SessionId: req.SessionId,
+			OldestSeq: oldest,
+			LastSeq:   state.LastSeq,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if err := batch.flush(); err != nil {
+		return err
 	}
 	for {
 		select {
 		case <-stream.Context().Done():
 			s.logger.Info("attach stream context done", "session_id", req.SessionId, "client_id", clientID)
 			return nil
+		case <-batch.due():
+			if err := batch.flush(); err != nil {
+				return err
+			}
 		case chunk, ok := <-state.Live:
 			if !ok {
+				if err := batch.flush(); err != nil {
+					return err
+				}
 				// Agent process exited; send a SESSION_EXIT event so
 				// the client learns the exit code without a separate
-				// GetSession call.  The live channel closes from the
-				// read-loop goroutine while waitLoop records the exit
-				// code concurrently, so we poll briefly for the exit
-				// to be recorded.
+				// GetSession call.
 				exitEvt := &bridgev1.AttachSessionEvent{
 					Type:      bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_EXIT,
 					SessionId: req.SessionId,
 				}
-				deadline := time.Now().Add(2 * time.Second)
-				for time.Now().Before(deadline) {
-					if info, err := s.supervisor.Get(req.SessionId); err == nil && info.ExitRecorded {
-						exitEvt.ExitRecorded = true
-						exitEvt.ExitCode = int32(info.ExitCode)
-						break
-					}
-					time.Sleep(10 * time.Millisecond)
-				}
+				exitEvt.ExitRecorded, exitEvt.ExitCode, exitEvt.Error = s.waitForExit(req.SessionId)
 				s.logger.Info("agent process exited", "session_id", req.SessionId, "client_id", clientID, "exit_code", exitEvt.ExitCode, "exit_recorded", exitEvt.ExitRecorded)
 				if err := stream.Send(exitEvt); err != nil {
 					s.logger.Warn("failed to send session exit event", "session_id", req.SessionId, "client_id", clientID, "error", err)
 				}
 				return nil
 			}
-			isControl := chunk.Type == bridge.ChunkTypeWriterClaimed || chunk.Type == bridge.ChunkTypeWriterReleased
+			isControl := chunk.Type == bridge.ChunkTypeWriterClaimed || chunk.Type == bridge.ChunkTypeWriterReleased || chunk.Type == bridge.ChunkTypeUsage
 			if !isControl {
 				if chunk.Seq <= lastSeq {
 					continue
 				}
 				lastSeq = chunk.Seq
 			}
-			if err := stream.Send(chunkToProto(req.SessionId, chunk, false)); err != nil {
+			ev := downgradeEvent(chunkToProto(req.SessionId, chunk, false), version)
+			if !filter.allows(ev) {
+				continue
+			}
+			if err := batch.add(ev); err != nil {
 				return err
 			}
 		}
 	}
 }
 
+// attachRole resolves the role a client attaches with. Read-only tokens
+// attach as observers unless they explicitly ask to write, which is refused.
+func (s *BridgeServer) attachRole(claims *auth.BridgeClaims, sessionID string, requested bridgev1.AttachRole) (bridge.AttachRole, error) {
+	if err := requireScope(claims, auth.ScopeEventsRead); err != nil {
+		return 0, err
+	}
+	if requested == bridgev1.AttachRole_ATTACH_ROLE_OBSERVER {
+		return bridge.AttachRoleObserver, nil
+	}
+	err := requireScope(claims, auth.ScopeSessionsControl)
+	if err == nil {
+		err = s.authorizeSession(claims, sessionID, auth.ScopeSessionsControl)
+	}
+	if err != nil {
+		if requested == bridgev1.AttachRole_ATTACH_ROLE_WRITER {
+			return 0, err
+		}
+		return bridge.AttachRoleObserver, nil
+	}
+	return bridge.AttachRoleWriter, nil
+}
+
+// maxAttachMatchLen caps AttachSessionRequest.match.
+const maxAttachMatchLen = 1024
+
+// attachFilter selects the events an AttachSession stream sends. The zero
+// value sends everything.
+type attachFilter struct {
+	types map[bridgev1.AttachEventType]bool
+	match *regexp.Regexp
+}
+
+func newAttachFilter(types []bridgev1.AttachEventType, match string) (attachFilter, error) {
+	var f attachFilter
+	for _, t := range types {
+		if _, ok := bridgev1.AttachEventType_name[int32(t)]; !ok || t == bridgev1.AttachEventType_ATTACH_EVENT_TYPE_UNSPECIFIED {
+			return f, status.Errorf(codes.InvalidArgument, "event_types contains unknown type %d", t)
+		}
+		if f.types == nil {
+			f.types = make(map[bridgev1.AttachEventType]bool)
+		}
+		f.types[t] = true
+	}
+	if err := validateOptionalStringField("match", match, maxAttachMatchLen, false); err != nil {
+		return f, err
+	}
+	if match != "" {
+		re, err := regexp.Compile(match)
+		if err != nil {
+			return f, status.Errorf(codes.InvalidArgument, "match: %v", err)
+		}
+		f.match = re
+	}
+	return f, nil
+}
+
+// allows reports whether ev passes the filter.
+func (f attachFilter) allows(ev *bridgev1.AttachSessionEvent) bool {
+	if f.types != nil && !f.types[ev.Type] {
+		return false
+	}
+	if f.match == nil {
+		return true
+	}
+	switch ev.Type {
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WARNING:
+		return f.match.Match(ev.Payload)
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_THINKING:
+		return f.match.MatchString(ev.ThinkingText)
+	}
+	return true
+}
+
+// Limits and default for AttachSessionRequest batching.
+const (
+	maxAttachBatchSize      = 1000
+	maxAttachBatchDelay     = 10 * time.Second
+	defaultAttachBatchDelay = 100 * time.Millisecond
+	// maxAttachBatchBytes flushes a batch once its events' encoded size
+	// reaches it, keeping BATCH events well under the 4 MiB message limit
+	// clients receive with by default.
+	maxAttachBatchBytes = 1 << 20
+)
+
+// eventBatcher groups AttachSession events into BATCH events for clients
+// that asked for batching, and sends events one by one otherwise.
+type eventBatcher struct {
+	stream    bridgev1.BridgeService_AttachSessionServer
+	sessionID string
+	size      int // 0 disables batching
+	delay     time.Duration
+	pending   []*bridgev1.AttachSessionEvent
+	bytes     int // encoded size of pending
+	timer     *time.Timer
+}
+
+func newEventBatcher(stream bridgev1.BridgeService_AttachSessionServer, sessionID string, size, delayMS uint32) (*eventBatcher, error) {
+	if size > maxAttachBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "max_batch_size exceeds %d", maxAttachBatchSize)
+	}
+	delay := time.Duration(delayMS) * time.Millisecond
+	if delay > maxAttachBatchDelay {
+		return nil, status.Errorf(codes.InvalidArgument, "max_batch_delay_ms exceeds %d", maxAttachBatchDelay.Milliseconds())
+	}
+	if delay == 0 {
+		delay = defaultAttachBatchDelay
+	}
+	b := &eventBatcher{stream: stream, sessionID: sessionID, delay: delay}
+	if size > 1 {
+		b.size = int(size)
+	}
+	return b, nil
+}
+
+// add sends ev, or queues it until the batch is full, reaches
+// maxAttachBatchBytes, or its delay passes.
+func (b *eventBatcher) add(ev *bridgev1.AttachSessionEvent) error {
+	if b.size == 0 {
+		return b.stream.Send(ev)
+	}
+	n := proto.Size(ev)
+	if len(b.pending) > 0 && b.bytes+n > maxAttachBatchBytes {
+		if err := b.flush(); err != nil {
+			return err
+		}
+	}
+	b.pending = append(b.pending, ev)
+	b.bytes += n
+	if len(b.pending) >= b.size || b.bytes >= maxAttachBatchBytes {
+		return b.flush()
+	}
+	if b.timer == nil {
+		b.timer = time.NewTimer(b.delay)
+	}
+	return nil
+}
+
+// due fires when the pending partial batch should be flushed.
+func (b *eventBatcher) due() <-chan time.Time {
+	if b.timer == nil {
+		return nil
+	}
+	return b.timer.C
+}
+
+// flush sends the pending events as one BATCH event.
+func (b *eventBatcher) flush() error {
+	b.stop()
+	if len(b.pending) == 0 {
+		return nil
+	}
+	events := b.pending
+	b.pending = nil
+	b.bytes = 0
+	batch := &bridgev1.AttachSessionEvent{
+		Type:      bridgev1.AttachEventType_ATTACH_EVENT_TYPE_BATCH,
+		SessionId: b.sessionID,
+		Replay:    events[len(events)-1].Replay,
+		Batch:     &bridgev1.AttachSessionEventBatch{Events: events},
+	}
+	for _, ev := range events {
+		batch.Seq = max(batch.Seq, ev.Seq)
+	}
+	return b.stream.Send(batch)
+}
+
+func (b *eventBatcher) stop() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+}
+
+// waitForExit returns the exit code of a session whose output just ended,
+// and its error when it failed. The live channel closes from the read loop
+// while waitLoop records the exit code concurrently, so it polls briefly
+// for the exit to be recorded.
+func (s *BridgeServer) waitForExit(sessionID string) (bool, int32, string) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, err := s.supervisor.Get(sessionID); err == nil && info.ExitRecorded {
+			var msg string
+			if info.State == bridge.SessionStateFailed {
+				msg = info.Error
+			}
+			return true, int32(info.ExitCode), msg
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false, 0, ""
+}
+
 func (s *BridgeServer) WriteInput(ctx context.Context, req *bridgev1.WriteInputRequest) (*bridgev1.WriteInputResponse, error) {
-	if !s.globalRL.allow("global") {
-		return nil, status.Error(codes.ResourceExhausted, "global RPC rate limit exceeded")
+	batch := req.Priority == bridgev1.InputPriority_INPUT_PRIORITY_BATCH
+	if err := s.checkGlobalLane(ctx, batch); err != nil {
+		return nil, err
 	}
 	claims, err := mustClaims(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
 	if err := validateUUIDField("session_id", req.SessionId); err != nil {
 		return nil, err
 	}
 	if err := validateStringField("client_id", req.ClientId, maxSessionIDLen, false); err != nil {
 		return nil, err
 	}
-	if err := validateByteField("data", req.Data, 1<<20); err != nil {
+	if err := validateInputData(req.Data, req.Template, req.Vars); err != nil {
 		return nil, err
 	}
-	if !s.writeRL.allow(req.SessionId) {
-		return nil, status.Error(codes.ResourceExhausted, "write input rate limit exceeded for session")
+	if _, ok := bridgev1.InputPriority_name[int32(req.Priority)]; !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown priority %d", req.Priority)
 	}
-	if err := s.authorizeSession(claims, req.SessionId); err != nil {
+	attachments, err := inputAttachments(req.Attachments)
+	if err != nil {
 		return nil, err
 	}
-	n, err := s.supervisor.WriteInput(req.SessionId, req.ClientId, req.Data)
+	ack, err := s.writeInput(ctx, claims, req.SessionId, inputRequest{
+		clientID:    req.ClientId,
+		data:        req.Data,
+		template:    req.Template,
+		vars:        req.Vars,
+		attachments: attachments,
+		batch:       batch,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bridgev1.WriteInputResponse{Accepted: true, BytesWritten: uint32(ack.Bytes), InputId: ack.ID, Queued: ack.Queued, Seq: ack.Seq}, nil
+}
+
+// inputRequest is an input whose fields WriteInput or BroadcastInput has
+// validated.
+type inputRequest struct {
+	clientID string
+	data     []byte
+	// template, when set, is rendered with vars in place of data.
+	template    string
+	vars        map[string]string
+	attachments []bridge.Attachment
+	batch       bool
+}
+
+// writeInput authorizes and rate-limits an input to sessionID and sends
+// it.
+func (s *BridgeServer) writeInput(ctx context.Context, claims *auth.BridgeClaims, sessionID string, in inputRequest) (bridge.InputAck, error) {
+	info, err := s.supervisor.Get(sessionID)
+	if err != nil {
+		return bridge.InputAck{}, mapBridgeError(err, "authorize session")
+	}
+	if err := authorizeSessionInfo(claims, info, auth.ScopeSessionsControl); err != nil {
+		return bridge.InputAck{}, err
+	}
+	limits := s.limitersFor(info.ProjectID)
+	if err := checkLimit(ctx, limits.write, sessionID, "write input rate limit exceeded for session"); err != nil {
+		return bridge.InputAck{}, err
+	}
+	if err := checkLaneLimit(ctx, limits.project, info.ProjectID, in.batch, "rate limit exceeded for project"); err != nil {
+		return bridge.InputAck{}, err
+	}
+	data := in.data
+	if in.template != "" {
+		if data, err = s.supervisor.RenderPrompt(sessionID, in.template, in.vars); err != nil {
+			return bridge.InputAck{}, mapBridgeError(err, "render prompt template")
+		}
+	}
+	ack, err := s.supervisor.SendInputWithAttachments(ctx, sessionID, in.clientID, data, in.attachments)
 	if err != nil {
-		return nil, mapBridgeError(err, "write input")
+		return bridge.InputAck{}, mapBridgeError(err, "write input")
+	}
+	if in.template != "" {
+		s.logger.Info("input rendered from prompt template", "session_id", sessionID, "input_id", ack.ID, "template", in.template, logging.RequestIDKey, logging.RequestID(ctx))
+	}
+	if len(in.attachments) > 0 {
+		s.logger.Info("input attachments delivered", "session_id", sessionID, "input_id", ack.ID, "attachments", len(in.attachments), logging.RequestIDKey, logging.RequestID(ctx))
 	}
-	return &bridgev1.WriteInputResponse{Accepted: true, BytesWritten: uint32(n)}, nil
+	return ack, nil
 }
 
 func (s *BridgeServer) ResizeSession(ctx context.Context, req *bridgev1.ResizeSessionRequest) (*bridgev1.ResizeSessionResponse, error) {
-	if !s.globalRL.allow("global") {
-		return nil, status.Error(codes.ResourceExhausted, "global RPC rate limit exceeded")
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
 	}
 	claims, err := mustClaims(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
 	if err := validateUUIDField("session_id", req.SessionId); err != nil {
 		return nil, err
 	}
@@ -353,7 +1029,7 @@ func (s *BridgeServer) ResizeSession(ctx context.Context, req *bridgev1.ResizeSe
 	if req.Cols == 0 || req.Rows == 0 {
 		return nil, status.Error(codes.InvalidArgument, "cols and rows must be > 0")
 	}
-	if err := s.authorizeSession(claims, req.SessionId); err != nil {
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeSessionsControl); err != nil {
 		return nil, err
 	}
 	if err := s.supervisor.Resize(req.SessionId, req.ClientId, req.Cols, req.Rows); err != nil {
@@ -362,6 +1038,67 @@ func (s *BridgeServer) ResizeSession(ctx context.Context, req *bridgev1.ResizeSe
 	return &bridgev1.ResizeSessionResponse{Applied: true}, nil
 }
 
+func (s *BridgeServer) CancelResponse(ctx context.Context, req *bridgev1.CancelResponseRequest) (*bridgev1.CancelResponseResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	if err := validateUUIDField("session_id", req.SessionId); err != nil {
+		return nil, err
+	}
+	if err := validateStringField("client_id", req.ClientId, maxSessionIDLen, false); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	if err := s.supervisor.CancelResponse(req.SessionId, req.ClientId); err != nil {
+		return nil, mapBridgeError(err, "cancel response")
+	}
+	return &bridgev1.CancelResponseResponse{Delivered: true}, nil
+}
+
+func (s *BridgeServer) ResolveApproval(ctx context.Context, req *bridgev1.ResolveApprovalRequest) (*bridgev1.ResolveApprovalResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	if err := validateUUIDField("session_id", req.SessionId); err != nil {
+		return nil, err
+	}
+	if err := validateStringField("client_id", req.ClientId, maxSessionIDLen, false); err != nil {
+		return nil, err
+	}
+	if err := validateStringField("approval_id", req.ApprovalId, maxTokenFieldLen, false); err != nil {
+		return nil, err
+	}
+	if req.Message != "" {
+		if err := validateStringField("message", req.Message, maxApprovalMessageLen, true); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	if err := s.supervisor.ResolveApproval(ctx, req.SessionId, req.ClientId, req.ApprovalId, req.Approve, req.Message); err != nil {
+		return nil, mapBridgeError(err, "resolve approval")
+	}
+	s.logger.Info("approval resolved", "session_id", req.SessionId, "client_id", req.ClientId, "approval_id", req.ApprovalId, "approved", req.Approve, "subject", claims.Subject, logging.RequestIDKey, logging.RequestID(ctx))
+	return &bridgev1.ResolveApprovalResponse{Delivered: true}, nil
+}
+
 func mustClaims(ctx context.Context) (*auth.BridgeClaims, error) {
 	claims, ok := auth.ClaimsFromContext(ctx)
 	if !ok {
@@ -370,6 +1107,14 @@ func mustClaims(ctx context.Context) (*auth.BridgeClaims, error) {
 	return claims, nil
 }
 
+// requireScope rejects tokens that do not grant scope.
+func requireScope(claims *auth.BridgeClaims, scope string) error {
+	if !claims.HasScope(scope) {
+		return status.Errorf(codes.PermissionDenied, "token lacks scope %q", scope)
+	}
+	return nil
+}
+
 func authorizeProject(claims *auth.BridgeClaims, projectID string) error {
 	if claims.ProjectID != "" && claims.ProjectID != projectID {
 		return status.Errorf(codes.PermissionDenied, "token project_id %q does not match request %q", claims.ProjectID, projectID)
@@ -377,64 +1122,106 @@ func authorizeProject(claims *auth.BridgeClaims, projectID string) error {
 	return nil
 }
 
-func (s *BridgeServer) authorizeSession(claims *auth.BridgeClaims, sessionID string) error {
+// authorizeSession checks that claims may use scope on sessionID; see
+// authorizeSessionInfo.
+func (s *BridgeServer) authorizeSession(claims *auth.BridgeClaims, sessionID, scope string) error {
 	info, err := s.supervisor.Get(sessionID)
 	if err != nil {
 		return mapBridgeError(err, "authorize session")
 	}
-	return authorizeProject(claims, info.ProjectID)
+	return authorizeSessionInfo(claims, info, scope)
+}
+
+// authorizeSessionInfo checks that the token's project and session claims
+// match the session and, when scope is ScopeSessionsControl, that its
+// subject is one of the session's writer subjects, if it has any. Other
+// subjects may only observe. Admins are not restricted.
+func authorizeSessionInfo(claims *auth.BridgeClaims, info *bridge.SessionInfo, scope string) error {
+	if err := authorizeProject(claims, info.ProjectID); err != nil {
+		return err
+	}
+	if claims.SessionID != "" && claims.SessionID != info.SessionID {
+		return status.Errorf(codes.PermissionDenied, "token is scoped to session %q", claims.SessionID)
+	}
+	if scope == auth.ScopeSessionsControl && len(info.WriterSubjects) > 0 && !claims.IsAdmin() && !slices.Contains(info.WriterSubjects, claims.Subject) {
+		return status.Errorf(codes.PermissionDenied, "subject %q may only observe session %q", claims.Subject, info.SessionID)
+	}
+	return nil
 }
 
 func mapBridgeError(err error, op string) error {
+	var budget *bridge.BudgetExceededError
+	if errors.As(err, &budget) {
+		return budgetStatus(budget, op)
+	}
 	switch {
 	case errors.Is(err, bridge.ErrInvalidArgument), errors.Is(err, bridge.ErrSessionNotRunning):
 		return status.Errorf(codes.InvalidArgument, "%s: %v", op, err)
-	case errors.Is(err, bridge.ErrSessionNotFound):
+	case errors.Is(err, bridge.ErrSessionNotFound), errors.Is(err, bridge.ErrFileNotFound), errors.Is(err, bridge.ErrInputNotFound), errors.Is(err, bridge.ErrProviderNotFound), errors.Is(err, bridge.ErrApprovalNotFound):
 		return status.Errorf(codes.NotFound, "%s: %v", op, err)
-	case errors.Is(err, bridge.ErrSessionAlreadyExists), errors.Is(err, bridge.ErrWriterConflict):
+	case errors.Is(err, bridge.ErrSessionAlreadyExists), errors.Is(err, bridge.ErrWriterConflict), errors.Is(err, bridge.ErrProviderAlreadyExists):
 		return status.Errorf(codes.AlreadyExists, "%s: %v", op, err)
-	case errors.Is(err, bridge.ErrSessionAlreadyAttached), errors.Is(err, bridge.ErrInputTooLarge):
+	case errors.Is(err, bridge.ErrSessionAlreadyAttached), errors.Is(err, bridge.ErrInputTooLarge), errors.Is(err, bridge.ErrInputQueueFull), errors.Is(err, bridge.ErrFileTooLarge):
 		return status.Errorf(codes.ResourceExhausted, "%s: %v", op, err)
-	case errors.Is(err, bridge.ErrClientNotAttached), errors.Is(err, bridge.ErrClientMismatch):
+	case errors.Is(err, bridge.ErrClientNotAttached), errors.Is(err, bridge.ErrClientMismatch), errors.Is(err, bridge.ErrPermissionDenied), errors.Is(err, bridge.ErrPolicyViolation):
 		return status.Errorf(codes.PermissionDenied, "%s: %v", op, err)
 	case errors.Is(err, bridge.ErrProviderUnavailable), errors.Is(err, bridge.ErrSessionRecoveryUnavailable):
 		return status.Errorf(codes.Unavailable, "%s: %v", op, err)
-	case errors.Is(err, bridge.ErrSessionLimitReached):
+	case errors.Is(err, bridge.ErrSessionLimitReached), errors.Is(err, bridge.ErrWorkspaceQuotaExceeded):
 		return status.Errorf(codes.ResourceExhausted, "%s: %v", op, err)
+	case errors.Is(err, bridge.ErrNotGitRepo), errors.Is(err, bridge.ErrGitFailed), errors.Is(err, bridge.ErrNoSnapshot), errors.Is(err, bridge.ErrSessionRunning):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", op, err)
 	default:
 		return status.Errorf(codes.Internal, "%s: %v", op, err)
 	}
 }
 
-func (s *BridgeServer) Health(ctx context.Context, req *bridgev1.HealthRequest) (*bridgev1.HealthResponse, error) {
-	results := s.registry.HealthAll(ctx)
-	providers := make([]*bridgev1.ProviderHealth, 0, len(results))
-	for id, err := range results {
-		item := &bridgev1.ProviderHealth{Provider: id, Available: err == nil}
-		if err != nil {
-			item.Error = err.Error()
-		}
-		providers = append(providers, item)
-	}
-	return &bridgev1.HealthResponse{
-		Status:           "serving",
-		Providers:        providers,
-		ServerInstanceId: s.serverInstanceID,
-	}, nil
+// budgetStatus is the RESOURCE_EXHAUSTED status of a call refused because
+// the project spent its daily budget. Its details name the cap reached and
+// when the budget resets.
+func budgetStatus(e *bridge.BudgetExceededError, op string) error {
+	st := status.Newf(codes.ResourceExhausted, "%s: %v", op, e)
+	detailed, err := st.WithDetails(
+		&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{
+			Subject:     "project:" + e.ProjectID,
+			Description: e.BudgetStatus.String(),
+		}}},
+		&errdetails.ErrorInfo{
+			Reason: "BUDGET_EXCEEDED",
+			Domain: "ai-agent-bridge",
+			Metadata: map[string]string{
+				"project_id":   e.ProjectID,
+				"limit":        e.Limit,
+				"daily_usd":    strconv.FormatFloat(e.DailyUSD, 'f', -1, 64),
+				"daily_tokens": strconv.FormatInt(e.DailyTokens, 10),
+				"spent_usd":    strconv.FormatFloat(e.SpentUSD, 'f', -1, 64),
+				"spent_tokens": strconv.FormatInt(e.SpentTokens, 10),
+				"reset_at":     e.ResetAt.Format(time.RFC3339),
+			},
+		},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Until(e.ResetAt))},
+	)
+	if err == nil {
+		st = detailed
+	}
+	return st.Err()
 }
 
 func (s *BridgeServer) ClaimWriter(ctx context.Context, req *bridgev1.ClaimWriterRequest) (*bridgev1.ClaimWriterResponse, error) {
-	if !s.globalRL.allow("global") {
-		return nil, status.Error(codes.ResourceExhausted, "global RPC rate limit exceeded")
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
 	}
 	claims, err := mustClaims(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
 	if err := validateUUIDField("session_id", req.SessionId); err != nil {
 		return nil, err
 	}
-	if err := s.authorizeSession(claims, req.SessionId); err != nil {
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeSessionsControl); err != nil {
 		return nil, err
 	}
 	clientID := req.ClientId
@@ -458,17 +1245,20 @@ func (s *BridgeServer) ClaimWriter(ctx context.Context, req *bridgev1.ClaimWrite
 }
 
 func (s *BridgeServer) ReleaseWriter(ctx context.Context, req *bridgev1.ReleaseWriterRequest) (*bridgev1.ReleaseWriterResponse, error) {
-	if !s.globalRL.allow("global") {
-		return nil, status.Error(codes.ResourceExhausted, "global RPC rate limit exceeded")
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
 	}
 	claims, err := mustClaims(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
 	if err := validateUUIDField("session_id", req.SessionId); err != nil {
 		return nil, err
 	}
-	if err := s.authorizeSession(claims, req.SessionId); err != nil {
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeSessionsControl); err != nil {
 		return nil, err
 	}
 	clientID := req.ClientId
@@ -482,25 +1272,97 @@ func (s *BridgeServer) ReleaseWriter(ctx context.Context, req *bridgev1.ReleaseW
 	return &bridgev1.ReleaseWriterResponse{Released: true}, nil
 }
 
+func (s *BridgeServer) TakeControl(ctx context.Context, req *bridgev1.TakeControlRequest) (*bridgev1.TakeControlResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	if err := validateUUIDField("session_id", req.SessionId); err != nil {
+		return nil, err
+	}
+	if err := validateStringField("client_id", req.ClientId, maxSessionIDLen, false); err != nil {
+		return nil, err
+	}
+	if req.Reason != "" {
+		if err := validateStringField("reason", req.Reason, maxControlReasonLen, false); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.authorizeSession(claims, req.SessionId, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	result, err := s.supervisor.TakeControl(ctx, req.SessionId, req.ClientId, req.Reason)
+	if err != nil {
+		return nil, mapBridgeError(err, "take control")
+	}
+	if result.PreviousWriterClientID != "" {
+		s.supervisor.NotifyWriterReleased(req.SessionId, result.PreviousWriterClientID)
+	}
+	s.supervisor.NotifyWriterClaimed(req.SessionId, req.ClientId)
+	return &bridgev1.TakeControlResponse{
+		Taken:                      true,
+		PreviousControllerClientId: result.PreviousWriterClientID,
+	}, nil
+}
+
 func (s *BridgeServer) ListProviders(ctx context.Context, req *bridgev1.ListProvidersRequest) (*bridgev1.ListProvidersResponse, error) {
 	ids := s.registry.List()
 	results := s.registry.HealthAll(ctx)
 	items := make([]*bridgev1.ProviderInfo, 0, len(ids))
 	for _, id := range ids {
-		var version string
+		var version, errMsg string
 		if p, err := s.registry.Get(id); err == nil && results[id] == nil {
 			version, _ = p.Version(ctx)
 		}
+		if results[id] != nil {
+			errMsg = results[id].Error()
+		}
 		items = append(items, &bridgev1.ProviderInfo{
 			Provider:  id,
 			Available: results[id] == nil,
 			Binary:    "",
 			Version:   version,
+			Error:     errMsg,
+			Variants:  s.providerVariants(ctx, id),
 		})
 	}
 	return &bridgev1.ListProvidersResponse{Providers: items}, nil
 }
 
+// providerVariants describes the variants of provider id, if it has any.
+func (s *BridgeServer) providerVariants(ctx context.Context, id string) []*bridgev1.ProviderVariant {
+	p, err := s.registry.Get(id)
+	if err != nil {
+		return nil
+	}
+	vp, ok := p.(bridge.VariantProvider)
+	if !ok {
+		return nil
+	}
+	var items []*bridgev1.ProviderVariant
+	for i, name := range vp.Variants() {
+		vprov, _, err := vp.Variant(name)
+		if err != nil {
+			continue
+		}
+		item := &bridgev1.ProviderVariant{Name: name, Binary: vprov.Binary(), Default: i == 0}
+		if err := vprov.Health(ctx); err != nil {
+			item.Error = err.Error()
+		} else {
+			item.Available = true
+			item.Version, _ = vprov.Version(ctx)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
 func sessionInfoToProto(info *bridge.SessionInfo) *bridgev1.GetSessionResponse {
 	resp := &bridgev1.GetSessionResponse{
 		SessionId:            info.SessionID,
@@ -519,13 +1381,39 @@ func sessionInfoToProto(info *bridge.SessionInfo) *bridgev1.GetSessionResponse {
 		Rows:                 info.Rows,
 		ActiveWriterClientId: info.ActiveWriterClientID,
 		ObserverCount:        int32(info.ObserverCount),
+		Usage:                usageToProto(info.Usage),
+		RestartCount:         int32(info.Restarts),
+		WriterSubjects:       info.WriterSubjects,
+		BufferBytes:          uint64(info.BufferBytes),
+		BufferCapacity:       uint64(info.BufferCapacity),
+		Variant:              info.Variant,
+		ConversationId:       info.ConversationID,
+		ResumedFrom:          info.ResumedFrom,
 	}
 	if !info.StoppedAt.IsZero() {
 		resp.StoppedAt = timestamppb.New(info.StoppedAt)
 	}
+	for _, a := range info.PendingApprovals {
+		resp.PendingApprovals = append(resp.PendingApprovals, &bridgev1.Approval{
+			Id:        a.ID,
+			ToolName:  a.ToolName,
+			InputJson: a.Input,
+			ToolUseId: a.ToolUseID,
+		})
+	}
 	return resp
 }
 
+func usageToProto(u bridge.Usage) *bridgev1.Usage {
+	return &bridgev1.Usage{
+		InputTokens:  u.InputTokens,
+		OutputTokens: u.OutputTokens,
+		CostUsd:      u.CostUSD,
+		DurationMs:   u.Duration.Milliseconds(),
+		Turns:        int32(u.Turns),
+	}
+}
+
 func mapState(s bridge.SessionState) bridgev1.SessionStatus {
 	switch s {
 	case bridge.SessionStateStarting:
@@ -567,18 +1455,66 @@ func checkDirReadWrite(dir string) error {
 
 func chunkToProto(sessionID string, chunk bridge.OutputChunk, replay bool) *bridgev1.AttachSessionEvent {
 	ev := &bridgev1.AttachSessionEvent{
-		Type:      bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT,
-		Seq:       chunk.Seq,
-		Timestamp: timestamppb.New(chunk.Timestamp),
-		SessionId: sessionID,
-		Payload:   chunk.Payload,
-		Replay:    replay,
+		Type:        bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT,
+		Seq:         chunk.Seq,
+		Timestamp:   timestamppb.New(chunk.Timestamp),
+		SessionId:   sessionID,
+		Payload:     chunk.Payload,
+		Replay:      replay,
+		InputId:     chunk.InputID,
+		DataJson:    chunk.Data,
+		Attachments: chunk.Attachments,
+		RequestId:   chunk.RequestID,
 	}
 	switch chunk.Type {
 	case bridge.ChunkTypeThinking:
 		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_THINKING
 		ev.ThinkingText = string(chunk.Payload)
 		ev.Payload = nil
+	case bridge.ChunkTypeResponseComplete:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE
+		ev.Payload = nil
+	case bridge.ChunkTypeInputAcked:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_INPUT_ACKED
+		ev.Payload = nil
+	case bridge.ChunkTypeRepoDirty:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_REPO_DIRTY
+		ev.Payload = nil
+	case bridge.ChunkTypeToolUse:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_TOOL_USE
+	case bridge.ChunkTypeApprovalRequested:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_APPROVAL_REQUESTED
+	case bridge.ChunkTypeApprovalResolved:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_APPROVAL_RESOLVED
+	case bridge.ChunkTypeControlChanged:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_CONTROL_CHANGED
+		ev.WriterClientId = string(chunk.Payload)
+		ev.Payload = nil
+	case bridge.ChunkTypeBudgetExceeded:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_BUDGET_EXCEEDED
+	case bridge.ChunkTypeOutputTruncated:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT_TRUNCATED
+	case bridge.ChunkTypePolicyViolation:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_POLICY_VIOLATION
+	case bridge.ChunkTypeError:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ERROR
+		ev.Error = string(chunk.Payload)
+		ev.Payload = nil
+	case bridge.ChunkTypeSessionRestarted:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_RESTARTED
+		ev.Payload = nil
+		if chunk.Restart != nil {
+			ev.ExitCode = int32(chunk.Restart.ExitCode)
+			ev.RestartCount = int32(chunk.Restart.Count)
+		}
+	case bridge.ChunkTypeUsage:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_USAGE
+		if chunk.Usage != nil {
+			ev.Usage = usageToProto(*chunk.Usage)
+		}
+	case bridge.ChunkTypeStderr:
+		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WARNING
+		ev.Severity = bridgev1.Severity(chunk.Severity)
 	case bridge.ChunkTypeWriterClaimed:
 		ev.Type = bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WRITER_CLAIMED
 		ev.WriterClientId = string(chunk.Payload)
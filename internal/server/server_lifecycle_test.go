@@ -3,12 +3,16 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"testing"
 	"time"
 
+	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 
 	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
@@ -123,13 +127,24 @@ func TestBridgeServerSessionLifecycle(t *testing.T) {
 	if err != nil {
 		t.Fatalf("WriteInput: %v", err)
 	}
-	if !writeResp.GetAccepted() {
+	if !writeResp.GetAccepted() || writeResp.GetInputId() == "" {
 		t.Fatalf("WriteInput resp=%+v", writeResp)
 	}
+	if _, err := s.WriteInput(ctx, &bridgev1.WriteInputRequest{SessionId: sessionID, ClientId: "client-a", Data: []byte("x"), Priority: 7}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("WriteInput with unknown priority: code=%v want InvalidArgument", status.Code(err))
+	}
 
 	if err := waitForAttachOutput(stream, "hello"); err != nil {
 		t.Fatal(err)
 	}
+	for _, ev := range stream.snapshot() {
+		switch ev.GetType() {
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_INPUT_ACKED, bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:
+			if ev.GetInputId() != writeResp.GetInputId() {
+				t.Fatalf("%v event input_id=%q want %q", ev.GetType(), ev.GetInputId(), writeResp.GetInputId())
+			}
+		}
+	}
 
 	resizeResp, err := s.ResizeSession(ctx, &bridgev1.ResizeSessionRequest{
 		SessionId: sessionID,
@@ -175,6 +190,23 @@ func TestBridgeServerValidationAndPermissions(t *testing.T) {
 	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{}); status.Code(err) != codes.InvalidArgument {
 		t.Fatalf("StartSession code=%v want %v", status.Code(err), codes.InvalidArgument)
 	}
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId:  "project-a",
+		SessionId:  uuid.NewString(),
+		RepoPath:   t.TempDir(),
+		RepoSource: &bridgev1.RepoSource{Url: "https://github.com/example/repo.git"},
+		Provider:   "cat",
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("StartSession repo_path and repo_source code=%v want %v", status.Code(err), codes.InvalidArgument)
+	}
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId:  "project-a",
+		SessionId:  uuid.NewString(),
+		RepoSource: &bridgev1.RepoSource{Url: "https://github.com/example/repo.git"},
+		Provider:   "cat",
+	}); status.Code(err) == codes.InvalidArgument {
+		t.Fatalf("StartSession repo_source rejected as invalid: %v", err)
+	}
 	if _, err := s.GetSession(ctx, &bridgev1.GetSessionRequest{SessionId: uuid.NewString()}); status.Code(err) != codes.NotFound {
 		t.Fatalf("GetSession code=%v want %v", status.Code(err), codes.NotFound)
 	}
@@ -395,3 +427,281 @@ func waitForAttachOutput(stream *attachStream, needle string) error {
 	}
 	return status.Error(codes.DeadlineExceeded, "timed out waiting for attach output")
 }
+
+func TestBridgeServerEnforcesTokenScopes(t *testing.T) {
+	registry := bridge.NewRegistry()
+	if err := registry.Register(&serverTestProvider{id: "cat", version: "1"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	supervisor := bridge.NewSupervisor(registry, bridge.DefaultPolicy(), 1024, time.Minute)
+	defer supervisor.Close()
+	s := New(supervisor, registry, nil, RateLimitConfig{
+		GlobalRPS:                  100,
+		GlobalBurst:                100,
+		StartSessionPerClientRPS:   10,
+		StartSessionPerClientBurst: 10,
+		SendInputPerSessionRPS:     10,
+		SendInputPerSessionBurst:   10,
+	}, "test-instance", nil)
+
+	control := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{
+		ProjectID: "project-a",
+		Scopes:    []string{auth.ScopeSessionsControl},
+	})
+	readOnly := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{
+		ProjectID: "project-a",
+		Scopes:    []string{auth.ScopeEventsRead},
+	})
+
+	if _, err := s.StartSession(readOnly, &bridgev1.StartSessionRequest{
+		ProjectId: "project-a", SessionId: uuid.NewString(), RepoPath: t.TempDir(), Provider: "cat",
+	}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("StartSession with events:read err=%v want PermissionDenied", err)
+	}
+	sessionID := uuid.NewString()
+	if _, err := s.StartSession(control, &bridgev1.StartSessionRequest{
+		ProjectId: "project-a", SessionId: sessionID, RepoPath: t.TempDir(), Provider: "cat",
+	}); err != nil {
+		t.Fatalf("StartSession with sessions:control: %v", err)
+	}
+
+	// sessions:control implies events:read.
+	if _, err := s.GetSession(control, &bridgev1.GetSessionRequest{SessionId: sessionID}); err != nil {
+		t.Fatalf("GetSession with sessions:control: %v", err)
+	}
+	if _, err := s.ListSessions(readOnly, &bridgev1.ListSessionsRequest{ProjectId: "project-a"}); err != nil {
+		t.Fatalf("ListSessions with events:read: %v", err)
+	}
+
+	// A read-only token asking for the writer slot is refused; with no role
+	// it attaches as an observer.
+	writer := newAttachStream(readOnly)
+	if err := s.AttachSession(&bridgev1.AttachSessionRequest{
+		SessionId: sessionID, ClientId: "dash", Role: bridgev1.AttachRole_ATTACH_ROLE_WRITER,
+	}, writer); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("AttachSession writer with events:read err=%v want PermissionDenied", err)
+	}
+	observer := newAttachStream(readOnly)
+	attachDone := make(chan error, 1)
+	go func() {
+		attachDone <- s.AttachSession(&bridgev1.AttachSessionRequest{SessionId: sessionID, ClientId: "dash"}, observer)
+	}()
+	waitForAttachEvent(t, observer, bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED)
+	got, err := s.GetSession(readOnly, &bridgev1.GetSessionRequest{SessionId: sessionID})
+	if err != nil {
+		t.Fatalf("GetSession with events:read: %v", err)
+	}
+	if got.GetObserverCount() != 1 || got.GetActiveWriterClientId() != "" {
+		t.Fatalf("session=%+v want dash attached as observer", got)
+	}
+
+	denied := []struct {
+		name string
+		call func() error
+	}{
+		{"WriteInput", func() error {
+			_, err := s.WriteInput(readOnly, &bridgev1.WriteInputRequest{SessionId: sessionID, ClientId: "dash", Data: []byte("x")})
+			return err
+		}},
+		{"ResizeSession", func() error {
+			_, err := s.ResizeSession(readOnly, &bridgev1.ResizeSessionRequest{SessionId: sessionID, ClientId: "dash", Cols: 80, Rows: 24})
+			return err
+		}},
+		{"CancelResponse", func() error {
+			_, err := s.CancelResponse(readOnly, &bridgev1.CancelResponseRequest{SessionId: sessionID, ClientId: "dash"})
+			return err
+		}},
+		{"ResolveApproval", func() error {
+			_, err := s.ResolveApproval(readOnly, &bridgev1.ResolveApprovalRequest{SessionId: sessionID, ClientId: "dash", ApprovalId: "req-1", Approve: true})
+			return err
+		}},
+		{"WriteFile", func() error {
+			_, err := s.WriteFile(readOnly, &bridgev1.WriteFileRequest{SessionId: sessionID, Path: "TASK.md", Data: []byte("x")})
+			return err
+		}},
+		{"RollbackWorkspace", func() error {
+			_, err := s.RollbackWorkspace(readOnly, &bridgev1.RollbackWorkspaceRequest{SessionId: sessionID})
+			return err
+		}},
+		{"GitCommit", func() error {
+			_, err := s.GitCommit(readOnly, &bridgev1.GitCommitRequest{SessionId: sessionID, Message: "x", All: true})
+			return err
+		}},
+		{"ClaimWriter", func() error {
+			_, err := s.ClaimWriter(readOnly, &bridgev1.ClaimWriterRequest{SessionId: sessionID, ClientId: "dash"})
+			return err
+		}},
+		{"TakeControl", func() error {
+			_, err := s.TakeControl(readOnly, &bridgev1.TakeControlRequest{SessionId: sessionID, ClientId: "dash"})
+			return err
+		}},
+		{"MintSessionToken", func() error {
+			_, err := s.MintSessionToken(readOnly, &bridgev1.MintSessionTokenRequest{SessionId: sessionID})
+			return err
+		}},
+		{"StopSession", func() error {
+			_, err := s.StopSession(readOnly, &bridgev1.StopSessionRequest{SessionId: sessionID})
+			return err
+		}},
+	}
+	for _, tt := range denied {
+		if err := tt.call(); status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("%s with events:read err=%v want PermissionDenied", tt.name, err)
+		}
+	}
+
+	observer.cancel()
+	if err := <-attachDone; err != nil {
+		t.Fatalf("AttachSession observer: %v", err)
+	}
+	if _, err := s.StopSession(control, &bridgev1.StopSessionRequest{SessionId: sessionID, Force: true}); err != nil {
+		t.Fatalf("StopSession with sessions:control: %v", err)
+	}
+}
+
+func TestBridgeServerEnforcesSessionACL(t *testing.T) {
+	registry := bridge.NewRegistry()
+	if err := registry.Register(&serverTestProvider{id: "cat", version: "1"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	supervisor := bridge.NewSupervisor(registry, bridge.DefaultPolicy(), 1024, time.Minute)
+	defer supervisor.Close()
+	s := New(supervisor, registry, nil, RateLimitConfig{
+		GlobalRPS:                  100,
+		GlobalBurst:                100,
+		StartSessionPerClientRPS:   10,
+		StartSessionPerClientBurst: 10,
+		SendInputPerSessionRPS:     10,
+		SendInputPerSessionBurst:   10,
+	}, "test-instance", nil)
+
+	as := func(subject string) context.Context {
+		return auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{
+			ProjectID:        "project-a",
+			RegisteredClaims: jwt.RegisteredClaims{Subject: subject},
+		})
+	}
+	bot, alice, carol := as("bot"), as("alice"), as("carol")
+
+	sessionID := uuid.NewString()
+	if _, err := s.StartSession(bot, &bridgev1.StartSessionRequest{
+		ProjectId: "project-a", SessionId: sessionID, RepoPath: t.TempDir(), Provider: "cat",
+		WriterSubjects: []string{"alice"},
+	}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	got, err := s.GetSession(carol, &bridgev1.GetSessionRequest{SessionId: sessionID})
+	if err != nil {
+		t.Fatalf("GetSession as observer subject: %v", err)
+	}
+	// The starting subject is added so that it can stop its own session.
+	if !slices.Equal(got.GetWriterSubjects(), []string{"alice", "bot"}) {
+		t.Fatalf("writer_subjects=%v want [alice bot]", got.GetWriterSubjects())
+	}
+
+	// carol has sessions:control but is not a writer subject.
+	if err := s.AttachSession(&bridgev1.AttachSessionRequest{
+		SessionId: sessionID, ClientId: "carol", Role: bridgev1.AttachRole_ATTACH_ROLE_WRITER,
+	}, newAttachStream(carol)); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("AttachSession writer as carol err=%v want PermissionDenied", err)
+	}
+	if _, err := s.WriteInput(carol, &bridgev1.WriteInputRequest{SessionId: sessionID, ClientId: "carol", Data: []byte("x")}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("WriteInput as carol err=%v want PermissionDenied", err)
+	}
+	if _, err := s.StopSession(carol, &bridgev1.StopSessionRequest{SessionId: sessionID, Force: true}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("StopSession as carol err=%v want PermissionDenied", err)
+	}
+
+	// A token scoped to one session reaches only that session.
+	otherID := uuid.NewString()
+	if _, err := s.StartSession(alice, &bridgev1.StartSessionRequest{
+		ProjectId: "project-a", SessionId: otherID, RepoPath: t.TempDir(), Provider: "cat",
+	}); err != nil {
+		t.Fatalf("StartSession other: %v", err)
+	}
+	scoped := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{
+		ProjectID: "project-a",
+		Scopes:    []string{auth.ScopeEventsRead},
+		SessionID: sessionID,
+	})
+	if _, err := s.GetSession(scoped, &bridgev1.GetSessionRequest{SessionId: sessionID}); err != nil {
+		t.Fatalf("GetSession with session-scoped token: %v", err)
+	}
+	if _, err := s.GetSession(scoped, &bridgev1.GetSessionRequest{SessionId: otherID}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("GetSession other session err=%v want PermissionDenied", err)
+	}
+	list, err := s.ListSessions(scoped, &bridgev1.ListSessionsRequest{})
+	if err != nil || len(list.GetSessions()) != 1 || list.GetSessions()[0].GetSessionId() != sessionID {
+		t.Fatalf("ListSessions with session-scoped token=%v err=%v", list, err)
+	}
+	if _, err := s.StartSession(auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a", SessionID: sessionID}), &bridgev1.StartSessionRequest{
+		ProjectId: "project-a", SessionId: uuid.NewString(), RepoPath: t.TempDir(), Provider: "cat",
+	}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("StartSession with session-scoped token err=%v want PermissionDenied", err)
+	}
+
+	for _, id := range []string{sessionID, otherID} {
+		if _, err := s.StopSession(alice, &bridgev1.StopSessionRequest{SessionId: id, Force: true}); err != nil {
+			t.Fatalf("StopSession as alice: %v", err)
+		}
+	}
+}
+
+func TestBridgeServerMintSessionToken(t *testing.T) {
+	registry := bridge.NewRegistry()
+	if err := registry.Register(&serverTestProvider{id: "cat", version: "1"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	supervisor := bridge.NewSupervisor(registry, bridge.DefaultPolicy(), 1024, time.Minute)
+	defer supervisor.Close()
+	s := New(supervisor, registry, nil, RateLimitConfig{GlobalRPS: 100, GlobalBurst: 100, StartSessionPerClientRPS: 10, StartSessionPerClientBurst: 10}, "test-instance", nil)
+
+	alice := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{
+		ProjectID:        "project-a",
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "alice"},
+	})
+	sessionID := uuid.NewString()
+	if _, err := s.StartSession(alice, &bridgev1.StartSessionRequest{
+		ProjectId: "project-a", SessionId: sessionID, RepoPath: t.TempDir(), Provider: "cat",
+	}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	defer func() { _ = supervisor.Stop(sessionID, true) }()
+
+	if _, err := s.MintSessionToken(alice, &bridgev1.MintSessionTokenRequest{SessionId: sessionID}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("MintSessionToken without issuer err=%v want FailedPrecondition", err)
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetSessionTokenIssuer(&auth.JWTIssuer{Issuer: "sessions", Audience: "bridge", Key: priv, TTL: 10 * time.Minute})
+
+	if _, err := s.MintSessionToken(alice, &bridgev1.MintSessionTokenRequest{SessionId: sessionID, TtlSeconds: 3600}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("MintSessionToken over max TTL err=%v want InvalidArgument", err)
+	}
+	resp, err := s.MintSessionToken(alice, &bridgev1.MintSessionTokenRequest{SessionId: sessionID, TtlSeconds: 60})
+	if err != nil {
+		t.Fatalf("MintSessionToken: %v", err)
+	}
+	verifier := &auth.JWTVerifier{Audience: "bridge", MaxTTL: 10 * time.Minute, Keys: map[string][]auth.IssuerKey{"sessions": {{Key: pub}}}}
+	claims, err := verifier.Verify(resp.GetToken())
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.SessionID != sessionID || claims.ProjectID != "project-a" || claims.Subject != "alice" || claims.ID != resp.GetTokenId() {
+		t.Fatalf("claims=%+v", claims)
+	}
+	if ttl := resp.GetExpiresAt().AsTime().Sub(claims.IssuedAt.Time); ttl != time.Minute {
+		t.Fatalf("token TTL=%s want 1m", ttl)
+	}
+
+	// The token observes the session and nothing else.
+	shared := auth.ContextWithClaims(context.Background(), claims)
+	if _, err := s.GetSession(shared, &bridgev1.GetSessionRequest{SessionId: sessionID}); err != nil {
+		t.Fatalf("GetSession with session token: %v", err)
+	}
+	if _, err := s.WriteInput(shared, &bridgev1.WriteInputRequest{SessionId: sessionID, ClientId: "viewer", Data: []byte("x")}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("WriteInput with session token err=%v want PermissionDenied", err)
+	}
+}
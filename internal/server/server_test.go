@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os/exec"
 	"regexp"
@@ -10,11 +12,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
 	"github.com/markcallen/ai-agent-bridge/internal/auth"
 	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/redact"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // trueBin is the absolute path to the "true" binary, resolved once via
@@ -93,6 +99,144 @@ func TestRateLimiters(t *testing.T) {
 	if exists {
 		t.Fatal("stale bucket still existed after cleanup")
 	}
+
+	reloaded := newKeyedLimiter(1, 1)
+	if !reloaded.allow("client-a") || reloaded.allow("client-a") {
+		t.Fatal("limiter did not enforce initial burst of 1")
+	}
+	reloaded.setLimits(0, 0)
+	if !reloaded.allow("client-a") {
+		t.Fatal("limiter with zero rate should allow everything")
+	}
+	reloaded.setLimits(1, 3)
+	reloaded.mu.Lock()
+	reloaded.buckets["client-a"].tokens = 3
+	reloaded.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		if !reloaded.allow("client-a") {
+			t.Fatalf("allow %d after raising burst was false", i)
+		}
+	}
+}
+
+func TestProjectRateLimits(t *testing.T) {
+	s := New(nil, nil, nil, RateLimitConfig{
+		StartSessionPerClientRPS:   1,
+		StartSessionPerClientBurst: 1,
+		SendInputPerSessionRPS:     1,
+		SendInputPerSessionBurst:   1,
+		ProjectRPS:                 1,
+		ProjectBurst:               2,
+		Projects: map[string]ProjectRateLimits{
+			"busy": {SendInputPerSessionBurst: 3},
+		},
+	}, "test", nil)
+	if s.limitersFor("busy").start == s.startRL || s.limitersFor("other").start != s.startRL {
+		t.Fatal("limitersFor did not pick the project limiter")
+	}
+	for i := 0; i < 3; i++ {
+		if !s.limitersFor("busy").write.allow("sess") {
+			t.Fatalf("write %d within project burst was refused", i)
+		}
+	}
+	if s.limitersFor("busy").write.allow("sess") {
+		t.Fatal("write beyond project burst was allowed")
+	}
+	if !s.limitersFor("other").write.allow("sess") || s.limitersFor("other").write.allow("sess") {
+		t.Fatal("other project did not get the global burst of 1")
+	}
+
+	// The project bucket is shared by all of a project's sessions and
+	// clients, and an override inherits the global size.
+	other := s.limitersFor("other").project
+	if !other.allow("other") || !other.allow("other") || other.allow("other") {
+		t.Fatal("project bucket did not enforce its burst of 2")
+	}
+	if busy := s.limitersFor("busy").project; busy == other || busy.burst != 2 {
+		t.Fatal("project override did not inherit the project bucket size")
+	}
+
+	// A reload keeps the project's buckets and drops removed projects.
+	busy := s.limitersFor("busy").write
+	s.SetRateLimits(RateLimitConfig{Projects: map[string]ProjectRateLimits{"busy": {SendInputPerSessionRPS: 5, SendInputPerSessionBurst: 5}}})
+	if s.limitersFor("busy").write != busy || busy.rate != 5 {
+		t.Fatal("reload replaced the project limiter instead of updating it")
+	}
+	s.SetRateLimits(RateLimitConfig{})
+	if s.limitersFor("busy").write != s.writeRL {
+		t.Fatal("removed project kept its limiter")
+	}
+}
+
+func TestRateLimiterBatchLane(t *testing.T) {
+	l := newKeyedLimiter(0.001, 4)
+	l.setReserve(0.5)
+	// Batch requests stop once half the burst is left for interactive ones.
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.take("global", true); !ok {
+			t.Fatalf("batch request %d refused above the reserve", i)
+		}
+	}
+	if ok, _ := l.take("global", true); ok {
+		t.Fatal("batch request spent the interactive reserve")
+	}
+	for i := 0; i < 2; i++ {
+		if !l.allow("global") {
+			t.Fatalf("interactive request %d refused within the reserve", i)
+		}
+	}
+	if l.allow("global") {
+		t.Fatal("interactive request allowed on an empty bucket")
+	}
+	if st := l.state("global", ""); st.Rejected != 2 || st.RejectedBatch != 1 || st.InteractiveReserve != 0.5 {
+		t.Fatalf("state=%+v", st)
+	}
+}
+
+func TestCheckLimitRetryInfo(t *testing.T) {
+	l := newKeyedLimiter(0.5, 1)
+	if err := checkLimit(context.Background(), l, "k", "slow down"); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	err := checkLimit(context.Background(), l, "k", "slow down")
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted || st.Message() != "slow down" {
+		t.Fatalf("err=%v want ResourceExhausted", err)
+	}
+	var delay time.Duration
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			delay = info.RetryDelay.AsDuration()
+		}
+	}
+	if delay <= time.Second || delay > 2*time.Second {
+		t.Fatalf("retry delay=%v want about 2s", delay)
+	}
+
+	state := l.state("test", "")
+	if state.Rejected != 1 || state.ActiveBuckets != 1 || state.Burst != 1 || state.Rate != 0.5 {
+		t.Fatalf("state=%+v", state)
+	}
+}
+
+func TestWaitLimit(t *testing.T) {
+	l := newKeyedLimiter(20, 1)
+	if err := waitLimit(context.Background(), l, "k"); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	// The bucket is empty; the next token is free after 50ms.
+	start := time.Now()
+	if err := waitLimit(context.Background(), l, "k"); err != nil {
+		t.Fatalf("second call: %v", err)
+	}
+	if waited := time.Since(start); waited < 30*time.Millisecond {
+		t.Fatalf("waited %v want about 50ms", waited)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := waitLimit(ctx, l, "k"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled wait err=%v", err)
+	}
 }
 
 func TestBridgeHelpersAndProviderResponses(t *testing.T) {
@@ -152,11 +296,15 @@ func TestBridgeHelpersAndProviderResponses(t *testing.T) {
 		LastSeq:          2,
 		Cols:             80,
 		Rows:             24,
+		Usage:            bridge.Usage{InputTokens: 100, OutputTokens: 50, CostUSD: 0.1, Duration: 2 * time.Second, Turns: 1},
 	}
 	resp := sessionInfoToProto(info)
 	if resp.GetSessionId() != "session-a" || resp.GetStatus() != bridgev1.SessionStatus_SESSION_STATUS_ATTACHED {
 		t.Fatalf("sessionInfoToProto=%+v", resp)
 	}
+	if u := resp.GetUsage(); u.GetInputTokens() != 100 || u.GetOutputTokens() != 50 || u.GetCostUsd() != 0.1 || u.GetDurationMs() != 2000 || u.GetTurns() != 1 {
+		t.Fatalf("sessionInfoToProto usage=%+v", u)
+	}
 
 	chunk := chunkToProto("session-a", bridge.OutputChunk{
 		Seq:       7,
@@ -166,6 +314,85 @@ func TestBridgeHelpersAndProviderResponses(t *testing.T) {
 	if chunk.GetSeq() != 7 || !chunk.GetReplay() {
 		t.Fatalf("chunkToProto=%+v", chunk)
 	}
+
+	usageEv := chunkToProto("session-a", bridge.OutputChunk{
+		Type:  bridge.ChunkTypeUsage,
+		Usage: &bridge.Usage{OutputTokens: 3},
+	}, false)
+	if usageEv.GetType() != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_USAGE || usageEv.GetUsage().GetOutputTokens() != 3 {
+		t.Fatalf("chunkToProto usage=%+v", usageEv)
+	}
+
+	warnEv := chunkToProto("session-a", bridge.OutputChunk{
+		Type:     bridge.ChunkTypeStderr,
+		Severity: bridge.SeverityError,
+		Payload:  []byte("fatal: rate limited"),
+	}, false)
+	if warnEv.GetType() != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WARNING || warnEv.GetSeverity() != bridgev1.Severity_SEVERITY_ERROR || string(warnEv.GetPayload()) != "fatal: rate limited" {
+		t.Fatalf("chunkToProto stderr=%+v", warnEv)
+	}
+
+	toolEv := chunkToProto("session-a", bridge.OutputChunk{
+		Type:    bridge.ChunkTypeToolUse,
+		Payload: []byte("Bash"),
+		Data:    []byte(`{"name":"Bash","input":{"command":"ls"}}`),
+	}, false)
+	if toolEv.GetType() != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_TOOL_USE || string(toolEv.GetDataJson()) != `{"name":"Bash","input":{"command":"ls"}}` {
+		t.Fatalf("chunkToProto tool use=%+v", toolEv)
+	}
+
+	approvalEv := chunkToProto("session-a", bridge.OutputChunk{
+		Type:    bridge.ChunkTypeApprovalRequested,
+		Payload: []byte("Bash"),
+		Data:    []byte(`{"id":"req-1","tool_name":"Bash"}`),
+	}, false)
+	if approvalEv.GetType() != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_APPROVAL_REQUESTED || string(approvalEv.GetPayload()) != "Bash" || string(approvalEv.GetDataJson()) != `{"id":"req-1","tool_name":"Bash"}` {
+		t.Fatalf("chunkToProto approval=%+v", approvalEv)
+	}
+	// Version 1 clients see approvals as extensions.
+	if ev := downgradeEvent(approvalEv, 1); ev.GetType() != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_EXTENSION || ev.GetExtensionType() != "ATTACH_EVENT_TYPE_APPROVAL_REQUESTED" {
+		t.Fatalf("downgraded approval=%+v", ev)
+	}
+
+	controlEv := chunkToProto("session-a", bridge.OutputChunk{
+		Type:    bridge.ChunkTypeControlChanged,
+		Payload: []byte("web"),
+		Data:    []byte(`{"from":"bot","to":"web"}`),
+	}, false)
+	if controlEv.GetType() != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_CONTROL_CHANGED || controlEv.GetWriterClientId() != "web" || controlEv.GetPayload() != nil || string(controlEv.GetDataJson()) != `{"from":"bot","to":"web"}` {
+		t.Fatalf("chunkToProto control change=%+v", controlEv)
+	}
+
+	errEv := chunkToProto("session-a", bridge.OutputChunk{
+		Type:    bridge.ChunkTypeError,
+		Payload: []byte("queued input not delivered: io: read/write on closed pipe"),
+		InputID: "in-2",
+	}, false)
+	if errEv.GetType() != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ERROR || errEv.GetInputId() != "in-2" || errEv.GetPayload() != nil || !strings.Contains(errEv.GetError(), "not delivered") {
+		t.Fatalf("chunkToProto error=%+v", errEv)
+	}
+}
+
+func TestBridgeServerHealthRedactionHits(t *testing.T) {
+	redactor, err := redact.NewWithBuiltins([]string{`internal-\d+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactor.Redact("internal-1 internal-2")
+	supervisor := bridge.NewSupervisor(bridge.NewRegistry(), bridge.DefaultPolicy(), 1024, time.Minute, bridge.WithRedactor(redactor))
+	defer supervisor.Close()
+
+	s := New(supervisor, bridge.NewRegistry(), slog.Default(), RateLimitConfig{}, "test-instance", nil)
+	health, err := s.Health(context.Background(), &bridgev1.HealthRequest{})
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if got := health.RedactionHits["redact_patterns[0]"]; got != 2 {
+		t.Fatalf("RedactionHits=%v want redact_patterns[0]=2", health.RedactionHits)
+	}
+	if _, ok := health.RedactionHits["github_token"]; !ok {
+		t.Fatalf("RedactionHits=%v missing built-in detectors", health.RedactionHits)
+	}
 }
 
 func TestMapBridgeErrorAndState(t *testing.T) {
@@ -178,9 +405,18 @@ func TestMapBridgeErrorAndState(t *testing.T) {
 		{err: bridge.ErrSessionAlreadyExists, code: codes.AlreadyExists},
 		{err: bridge.ErrSessionAlreadyAttached, code: codes.ResourceExhausted},
 		{err: bridge.ErrClientMismatch, code: codes.PermissionDenied},
+		{err: bridge.ErrPermissionDenied, code: codes.PermissionDenied},
 		{err: bridge.ErrProviderUnavailable, code: codes.Unavailable},
 		{err: bridge.ErrSessionRecoveryUnavailable, code: codes.Unavailable},
 		{err: bridge.ErrSessionLimitReached, code: codes.ResourceExhausted},
+		{err: bridge.ErrInputQueueFull, code: codes.ResourceExhausted},
+		{err: bridge.ErrFileNotFound, code: codes.NotFound},
+		{err: bridge.ErrFileTooLarge, code: codes.ResourceExhausted},
+		{err: bridge.ErrNotGitRepo, code: codes.FailedPrecondition},
+		{err: bridge.ErrGitFailed, code: codes.FailedPrecondition},
+		{err: bridge.ErrWorkspaceQuotaExceeded, code: codes.ResourceExhausted},
+		{err: bridge.ErrNoSnapshot, code: codes.FailedPrecondition},
+		{err: bridge.ErrSessionRunning, code: codes.FailedPrecondition},
 		{err: errors.New("boom"), code: codes.Internal},
 	}
 	for _, tc := range cases {
@@ -314,6 +550,37 @@ func TestMapBridgeErrorWriterConflict(t *testing.T) {
 	}
 }
 
+func TestMapBridgeErrorBudgetDetails(t *testing.T) {
+	reset := time.Now().Add(time.Hour).UTC()
+	err := mapBridgeError(fmt.Errorf("start: %w", &bridge.BudgetExceededError{BudgetStatus: bridge.BudgetStatus{
+		ProjectID: "proj",
+		Limit:     "daily_usd",
+		DailyUSD:  10,
+		SpentUSD:  10.5,
+		ResetAt:   reset,
+	}}), "send input")
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted || !strings.Contains(st.Message(), "daily_usd budget of $10.00") {
+		t.Fatalf("err=%v want ResourceExhausted with the budget", err)
+	}
+	var info *errdetails.ErrorInfo
+	var delay time.Duration
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.RetryInfo:
+			delay = d.RetryDelay.AsDuration()
+		}
+	}
+	if info == nil || info.Reason != "BUDGET_EXCEEDED" || info.Metadata["spent_usd"] != "10.5" || info.Metadata["reset_at"] != reset.Format(time.RFC3339) {
+		t.Fatalf("ErrorInfo=%v", info)
+	}
+	if delay <= 59*time.Minute || delay > time.Hour {
+		t.Fatalf("retry delay=%v want the time until the reset", delay)
+	}
+}
+
 func TestStopWriteResizeRPCs(t *testing.T) {
 	s, sup := newServerWithSupervisor(t)
 	const sid = "a1b2c3d4-e5f6-7890-abcd-ef1234567890"
@@ -426,3 +693,369 @@ func TestMapStateAllVariants(t *testing.T) {
 		}
 	}
 }
+
+func TestStartSessionRejectsDisallowedEnv(t *testing.T) {
+	s, _ := newServerWithSupervisor(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "proj"})
+	_, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId: "proj",
+		SessionId: "9a4c3a52-6f0e-4c55-9d1b-2f9a7c1e8b40",
+		RepoPath:  t.TempDir(),
+		Provider:  "cat",
+		Env:       map[string]string{"ANTHROPIC_API_KEY": "sk-injected"},
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("StartSession code=%v want PermissionDenied (err=%v)", status.Code(err), err)
+	}
+}
+
+func TestGetSessionHistoryRPC(t *testing.T) {
+	const sessionID = "3f0c2d1e-8b7a-4c6d-9e5f-1a2b3c4d5e6f"
+	s, sup := newServerWithSupervisor(t)
+	startServerSession(t, s, sessionID)
+	if err := sup.Stop(sessionID, true); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "proj"})
+	resp, err := s.GetSessionHistory(ctx, &bridgev1.GetSessionHistoryRequest{SessionId: sessionID})
+	if err != nil {
+		t.Fatalf("GetSessionHistory: %v", err)
+	}
+	if resp.GetSession().GetSessionId() != sessionID || resp.GetSession().GetProjectId() != "proj" {
+		t.Fatalf("GetSessionHistory session=%+v", resp.GetSession())
+	}
+
+	otherCtx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "other"})
+	if _, err := s.GetSessionHistory(otherCtx, &bridgev1.GetSessionHistoryRequest{SessionId: sessionID}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("GetSessionHistory other project code=%v want PermissionDenied", status.Code(err))
+	}
+	if _, err := s.GetSessionHistory(ctx, &bridgev1.GetSessionHistoryRequest{SessionId: "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("GetSessionHistory unknown code=%v want NotFound", status.Code(err))
+	}
+}
+
+func TestSessionStateHandoffRPC(t *testing.T) {
+	const sessionID = "3f1d2c9e-8b7a-4c6d-9e0f-1a2b3c4d5e6f"
+	src, _ := newServerWithSupervisor(t)
+	dst, _ := newServerWithSupervisor(t)
+	startServerSession(t, src, sessionID)
+
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "proj"})
+	exported, err := src.ExportSessionState(ctx, &bridgev1.ExportSessionStateRequest{SessionId: sessionID, Stop: true})
+	if err != nil {
+		t.Fatalf("ExportSessionState: %v", err)
+	}
+
+	other := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "other"})
+	if _, err := dst.ImportSessionState(other, &bridgev1.ImportSessionStateRequest{State: exported.State}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("ImportSessionState other project code=%v want PermissionDenied", status.Code(err))
+	}
+	if _, err := dst.ImportSessionState(ctx, &bridgev1.ImportSessionStateRequest{State: []byte("not json")}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("ImportSessionState bad state code=%v want InvalidArgument", status.Code(err))
+	}
+
+	imported, err := dst.ImportSessionState(ctx, &bridgev1.ImportSessionStateRequest{State: exported.State})
+	if err != nil {
+		t.Fatalf("ImportSessionState: %v", err)
+	}
+	if imported.GetSession().GetSessionId() != sessionID || imported.GetSession().GetProjectId() != "proj" {
+		t.Fatalf("ImportSessionState session=%+v", imported.GetSession())
+	}
+	if _, err := dst.GetSession(ctx, &bridgev1.GetSessionRequest{SessionId: sessionID}); err != nil {
+		t.Fatalf("GetSession on destination: %v", err)
+	}
+}
+
+func TestImportSessionStateValidatesConfig(t *testing.T) {
+	const sessionID = "5d2e8f1a-3b4c-4d6e-8f0a-1b2c3d4e5f60"
+	src, _ := newServerWithSupervisor(t)
+	dst, _ := newServerWithSupervisor(t)
+	startServerSession(t, src, sessionID)
+
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "proj"})
+	exported, err := src.ExportSessionState(ctx, &bridgev1.ExportSessionStateRequest{SessionId: sessionID, Stop: true})
+	if err != nil {
+		t.Fatalf("ExportSessionState: %v", err)
+	}
+	tamper := func(edit func(*bridge.SessionHandoff)) []byte {
+		var h bridge.SessionHandoff
+		if err := json.Unmarshal(exported.State, &h); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		edit(&h)
+		state, err := json.Marshal(&h)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		return state
+	}
+
+	tests := []struct {
+		name string
+		edit func(*bridge.SessionHandoff)
+	}{
+		{name: "missing provider", edit: func(h *bridge.SessionHandoff) { h.Config.Options = nil }},
+		{name: "provider control characters", edit: func(h *bridge.SessionHandoff) { h.Config.Options["provider"] = "cat\n" }},
+		{name: "missing repo_path", edit: func(h *bridge.SessionHandoff) { h.Config.RepoPath = "" }},
+		{name: "project_id too long", edit: func(h *bridge.SessionHandoff) {
+			h.Config.ProjectID = strings.Repeat("p", maxProjectIDLen+1)
+			h.Info.ProjectID = h.Config.ProjectID
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dst.ImportSessionState(ctx, &bridgev1.ImportSessionStateRequest{State: tamper(tt.edit)})
+			if status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("ImportSessionState code=%v want InvalidArgument (err=%v)", status.Code(err), err)
+			}
+		})
+	}
+}
+
+func TestExportTranscriptRPC(t *testing.T) {
+	const sessionID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	s, _ := newServerWithSupervisor(t)
+	startServerSession(t, s, sessionID)
+
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "proj"})
+	tests := []struct {
+		format      bridgev1.TranscriptFormat
+		contentType string
+		filename    string
+	}{
+		{format: bridgev1.TranscriptFormat_TRANSCRIPT_FORMAT_UNSPECIFIED, contentType: "text/markdown", filename: sessionID + ".md"},
+		{format: bridgev1.TranscriptFormat_TRANSCRIPT_FORMAT_JSONL, contentType: "application/x-ndjson", filename: sessionID + ".jsonl"},
+	}
+	for _, tt := range tests {
+		resp, err := s.ExportTranscript(ctx, &bridgev1.ExportTranscriptRequest{SessionId: sessionID, Format: tt.format})
+		if err != nil {
+			t.Fatalf("ExportTranscript(%v): %v", tt.format, err)
+		}
+		if resp.GetContentType() != tt.contentType || resp.GetFilename() != tt.filename {
+			t.Fatalf("ExportTranscript(%v) content_type=%q filename=%q", tt.format, resp.GetContentType(), resp.GetFilename())
+		}
+	}
+
+	if _, err := s.ExportTranscript(ctx, &bridgev1.ExportTranscriptRequest{SessionId: sessionID, Format: 99}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("ExportTranscript bad format code=%v want InvalidArgument", status.Code(err))
+	}
+}
+
+func TestGetConversationRPC(t *testing.T) {
+	s, supervisor := newTerminalTestServer(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a"})
+	sessionID := uuid.NewString()
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{ProjectId: "project-a", SessionId: sessionID, RepoPath: t.TempDir(), Provider: "cat", ConversationId: "review-42"}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	t.Cleanup(func() { _ = supervisor.Stop(sessionID, true) })
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{ProjectId: "project-a", SessionId: uuid.NewString(), RepoPath: t.TempDir(), Provider: "cat", ConversationId: "review-42"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("StartSession in busy conversation code=%v want FailedPrecondition", status.Code(err))
+	}
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{ProjectId: "project-a", SessionId: uuid.NewString(), RepoPath: t.TempDir(), Provider: "cat", ConversationId: "../x"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("StartSession bad conversation_id code=%v want InvalidArgument", status.Code(err))
+	}
+
+	resp, err := s.GetConversation(ctx, &bridgev1.GetConversationRequest{ConversationId: "review-42", Format: bridgev1.TranscriptFormat_TRANSCRIPT_FORMAT_JSONL})
+	if err != nil {
+		t.Fatalf("GetConversation: %v", err)
+	}
+	if len(resp.Sessions) != 1 || resp.Sessions[0].SessionId != sessionID || resp.Sessions[0].ConversationId != "review-42" ||
+		resp.ContentType != "application/x-ndjson" || resp.Filename != "review-42.jsonl" {
+		t.Fatalf("GetConversation=%+v", resp)
+	}
+
+	other := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-b"})
+	if _, err := s.GetConversation(other, &bridgev1.GetConversationRequest{ConversationId: "review-42"}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("GetConversation from another project code=%v want PermissionDenied", status.Code(err))
+	}
+	if _, err := s.GetConversation(ctx, &bridgev1.GetConversationRequest{ConversationId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("GetConversation missing code=%v want NotFound", status.Code(err))
+	}
+}
+
+func TestGetResponseRPC(t *testing.T) {
+	s, supervisor := newTerminalTestServer(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a"})
+	sessionID := uuid.NewString()
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{ProjectId: "project-a", SessionId: sessionID, RepoPath: t.TempDir(), Provider: "cat"}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	t.Cleanup(func() { _ = supervisor.Stop(sessionID, true) })
+	if _, err := supervisor.Attach(sessionID, "writer", 0, bridge.AttachRoleWriter); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	write, err := s.WriteInput(ctx, &bridgev1.WriteInputRequest{SessionId: sessionID, ClientId: "writer", Data: []byte("ping\n")})
+	if err != nil {
+		t.Fatalf("WriteInput: %v", err)
+	}
+
+	var resp *bridgev1.GetResponseResponse
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		resp, err = s.GetResponse(ctx, &bridgev1.GetResponseRequest{SessionId: sessionID, InputId: write.InputId})
+		if err != nil {
+			t.Fatalf("GetResponse: %v", err)
+		}
+		if strings.Contains(resp.Text, "ping") || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(resp.Text, "ping") || resp.Prompt != "ping" || resp.Complete {
+		t.Fatalf("GetResponse=%+v, want the echoed ping", resp)
+	}
+	bySeq, err := s.GetResponse(ctx, &bridgev1.GetResponseRequest{SessionId: sessionID, InputSeq: resp.InputSeq})
+	if err != nil || bySeq.InputId != write.InputId {
+		t.Fatalf("GetResponse by seq=%+v err=%v", bySeq, err)
+	}
+
+	if _, err := s.GetResponse(ctx, &bridgev1.GetResponseRequest{SessionId: sessionID}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("GetResponse without input code=%v want InvalidArgument", status.Code(err))
+	}
+	if _, err := s.GetResponse(ctx, &bridgev1.GetResponseRequest{SessionId: sessionID, InputId: uuid.NewString()}); status.Code(err) != codes.NotFound {
+		t.Fatalf("GetResponse unknown input code=%v want NotFound", status.Code(err))
+	}
+}
+
+func TestAttachFilter(t *testing.T) {
+	output := func(text string) *bridgev1.AttachSessionEvent {
+		return &bridgev1.AttachSessionEvent{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, Payload: []byte(text)}
+	}
+	thinking := &bridgev1.AttachSessionEvent{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_THINKING, ThinkingText: "planning the fix"}
+	complete := &bridgev1.AttachSessionEvent{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE}
+
+	all, err := newAttachFilter(nil, "")
+	if err != nil {
+		t.Fatalf("newAttachFilter: %v", err)
+	}
+	if !all.allows(output("x")) || !all.allows(complete) {
+		t.Fatal("empty filter dropped events")
+	}
+
+	lifecycle, err := newAttachFilter([]bridgev1.AttachEventType{bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE}, "")
+	if err != nil {
+		t.Fatalf("newAttachFilter: %v", err)
+	}
+	if lifecycle.allows(output("x")) || !lifecycle.allows(complete) {
+		t.Fatal("event_types filter did not select RESPONSE_COMPLETE only")
+	}
+
+	match, err := newAttachFilter(nil, `(?i)error|fix`)
+	if err != nil {
+		t.Fatalf("newAttachFilter: %v", err)
+	}
+	if match.allows(output("all good")) || !match.allows(output("ERROR: build failed")) {
+		t.Fatal("match did not filter OUTPUT payloads")
+	}
+	if !match.allows(thinking) || !match.allows(complete) {
+		t.Fatal("match filtered THINKING text or a non-text event")
+	}
+
+	for _, tc := range []struct {
+		types []bridgev1.AttachEventType
+		match string
+	}{
+		{types: []bridgev1.AttachEventType{99}},
+		{types: []bridgev1.AttachEventType{bridgev1.AttachEventType_ATTACH_EVENT_TYPE_UNSPECIFIED}},
+		{match: "("},
+		{match: strings.Repeat("a", maxAttachMatchLen+1)},
+	} {
+		if _, err := newAttachFilter(tc.types, tc.match); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("newAttachFilter(%v, %q) code=%v want InvalidArgument", tc.types, tc.match, status.Code(err))
+		}
+	}
+}
+
+func TestEventBatcher(t *testing.T) {
+	stream := newAttachStream(context.Background())
+	defer stream.cancel()
+	output := func(seq uint64) *bridgev1.AttachSessionEvent {
+		return &bridgev1.AttachSessionEvent{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, Seq: seq}
+	}
+
+	b, err := newEventBatcher(stream, "session-a", 3, 0)
+	if err != nil {
+		t.Fatalf("newEventBatcher: %v", err)
+	}
+	defer b.stop()
+	for seq := uint64(1); seq <= 2; seq++ {
+		if err := b.add(output(seq)); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	if got := len(stream.snapshot()); got != 0 || b.due() == nil {
+		t.Fatalf("partial batch: sent=%d due=%v, want nothing sent and a pending flush", got, b.due())
+	}
+	// Control events carry seq 0; the batch seq is the highest in it.
+	if err := b.add(&bridgev1.AttachSessionEvent{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_USAGE}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := b.add(output(3)); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := b.flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	sent := stream.snapshot()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d events, want 2 batches", len(sent))
+	}
+	for i, want := range []struct {
+		seq uint64
+		n   int
+	}{{2, 3}, {3, 1}} {
+		ev := sent[i]
+		if ev.GetType() != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_BATCH || ev.GetSeq() != want.seq || len(ev.GetBatch().GetEvents()) != want.n || ev.GetSessionId() != "session-a" {
+			t.Fatalf("batch %d = %+v, want seq %d with %d events", i, ev, want.seq, want.n)
+		}
+	}
+	if b.due() != nil {
+		t.Fatal("flush left a pending timer")
+	}
+
+	unbatched, err := newEventBatcher(stream, "session-a", 1, 0)
+	if err != nil {
+		t.Fatalf("newEventBatcher: %v", err)
+	}
+	if err := unbatched.add(output(4)); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if sent := stream.snapshot(); sent[len(sent)-1].GetType() != bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT {
+		t.Fatalf("max_batch_size 1 batched the event: %+v", sent[len(sent)-1])
+	}
+
+	// Large events close a batch by size long before it fills by count.
+	big, err := newEventBatcher(stream, "session-a", maxAttachBatchSize, 0)
+	if err != nil {
+		t.Fatalf("newEventBatcher: %v", err)
+	}
+	before := len(stream.snapshot())
+	for seq := uint64(10); seq < 30; seq++ {
+		ev := output(seq)
+		ev.Payload = make([]byte, 300<<10)
+		if err := big.add(ev); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	if err := big.flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	batches := stream.snapshot()[before:]
+	if len(batches) < 6 {
+		t.Fatalf("20 events of 300 KiB sent in %d batches, want them split by size", len(batches))
+	}
+	for _, ev := range batches {
+		if n := proto.Size(ev); n > maxAttachBatchBytes+64 {
+			t.Fatalf("batch of %d bytes exceeds %d", n, maxAttachBatchBytes)
+		}
+	}
+
+	if _, err := newEventBatcher(stream, "session-a", maxAttachBatchSize+1, 0); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("oversized batch code=%v want InvalidArgument", status.Code(err))
+	}
+	if _, err := newEventBatcher(stream, "session-a", 10, 10001); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("oversized delay code=%v want InvalidArgument", status.Code(err))
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SetSessionTokenIssuer enables MintSessionToken. iss signs the tokens and
+// must be trusted by the server's verifier; its TTL is both the default and
+// the longest lifetime a caller may ask for. Nil disables the RPC.
+func (s *BridgeServer) SetSessionTokenIssuer(iss *auth.JWTIssuer) {
+	s.mu.Lock()
+	s.sessionTokens = iss
+	s.mu.Unlock()
+}
+
+// MintSessionToken returns a read-only token for one session. It carries
+// the caller's subject, so that audit logs name who shared the session and
+// revoking the subject also revokes its share tokens.
+func (s *BridgeServer) MintSessionToken(ctx context.Context, req *bridgev1.MintSessionTokenRequest) (*bridgev1.MintSessionTokenResponse, error) {
+	if err := s.checkGlobalLimit(ctx); err != nil {
+		return nil, err
+	}
+	claims, err := mustClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+	if err := validateUUIDField("session_id", req.SessionId); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	base := s.sessionTokens
+	s.mu.RUnlock()
+	if base == nil {
+		return nil, status.Error(codes.FailedPrecondition, "session tokens are not enabled on this bridge")
+	}
+	ttl := base.TTL
+	if req.TtlSeconds > 0 {
+		ttl = time.Duration(req.TtlSeconds) * time.Second
+		if ttl > base.TTL {
+			return nil, status.Errorf(codes.InvalidArgument, "ttl_seconds exceeds max %d", int64(base.TTL/time.Second))
+		}
+	}
+	info, err := s.supervisor.Get(req.SessionId)
+	if err != nil {
+		return nil, mapBridgeError(err, "authorize session")
+	}
+	if err := authorizeSessionInfo(claims, info, auth.ScopeSessionsControl); err != nil {
+		return nil, err
+	}
+
+	iss := *base
+	iss.TTL = ttl
+	iss.Scopes = []string{auth.ScopeEventsRead}
+	iss.SessionID = info.SessionID
+	token, minted, err := iss.MintClaims(claims.Subject, info.ProjectID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "mint session token: %v", err)
+	}
+	s.logger.Info("session token minted", "session_id", info.SessionID, "subject", claims.Subject, "token_id", minted.ID, "ttl", ttl)
+	return &bridgev1.MintSessionTokenResponse{
+		Token:     token,
+		ExpiresAt: timestamppb.New(minted.ExpiresAt.Time),
+		TokenId:   minted.ID,
+	}, nil
+}
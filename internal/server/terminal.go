@@ -0,0 +1,176 @@
+package server
+
+import (
+	"errors"
+	"io"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AttachTerminal mirrors a PTY session to a terminal emulator. Output is
+// forwarded as raw bytes with control events and stream-JSON chunk types
+// left out; input frames are written to the PTY like WriteInput, a line at
+// a time while the content policy checks input (see bridge.TerminalInput).
+func (s *BridgeServer) AttachTerminal(stream bridgev1.BridgeService_AttachTerminalServer) error {
+	if err := s.checkGlobalLimit(stream.Context()); err != nil {
+		return err
+	}
+	claims, err := mustClaims(stream.Context())
+	if err != nil {
+		return err
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	open := first.GetOpen()
+	if open == nil {
+		return status.Error(codes.InvalidArgument, "first message must be open")
+	}
+	if err := validateUUIDField("session_id", open.SessionId); err != nil {
+		return err
+	}
+	if err := validateOptionalStringField("client_id", open.ClientId, maxSessionIDLen, false); err != nil {
+		return err
+	}
+	if err := s.authorizeSession(claims, open.SessionId, auth.ScopeEventsRead); err != nil {
+		return err
+	}
+	role, err := s.attachRole(claims, open.SessionId, open.Role)
+	if err != nil {
+		return err
+	}
+	sessionID, clientID := open.SessionId, open.ClientId
+	if clientID == "" {
+		clientID = generateID()
+	}
+
+	state, err := s.supervisor.Attach(sessionID, clientID, open.AfterSeq, role)
+	if err != nil {
+		return mapBridgeError(err, "attach terminal")
+	}
+	defer func() {
+		_ = s.supervisor.Detach(sessionID, clientID)
+		s.logger.Info("terminal detached", "session_id", sessionID, "client_id", clientID)
+	}()
+	if state.StreamJSON {
+		return status.Error(codes.FailedPrecondition, "attach terminal: session has no terminal; use AttachSession")
+	}
+	s.logger.Info("terminal attached", "session_id", sessionID, "client_id", clientID, "role", role, "replay_chunks", len(state.Replay))
+
+	protoRole := bridgev1.AttachRole_ATTACH_ROLE_WRITER
+	if state.Role == bridge.AttachRoleObserver {
+		protoRole = bridgev1.AttachRole_ATTACH_ROLE_OBSERVER
+	}
+	if err := stream.Send(&bridgev1.AttachTerminalResponse{Frame: &bridgev1.AttachTerminalResponse_Attached{Attached: &bridgev1.TerminalAttached{
+		ClientId:  clientID,
+		Role:      protoRole,
+		Cols:      state.Cols,
+		Rows:      state.Rows,
+		OldestSeq: state.OldestSeq,
+		LastSeq:   state.LastSeq,
+		ReplayGap: state.ReplayGap,
+	}}}); err != nil {
+		return err
+	}
+	lastSeq := open.AfterSeq
+	err = state.EachReplay(func(chunk bridge.OutputChunk) error {
+		if chunk.Type != bridge.ChunkTypeOutput {
+			return nil
+		}
+		lastSeq = chunk.Seq
+		return stream.Send(terminalOutput(chunk, true))
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	// Input is read on its own goroutine; gRPC streams allow one concurrent
+	// Recv and one concurrent Send.
+	inputErr := make(chan error, 1)
+	go func() {
+		inputErr <- s.terminalInput(stream, sessionID, clientID)
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case err := <-inputErr:
+			if err != nil {
+				return err
+			}
+			// The client closed its side; keep mirroring output.
+			inputErr = nil
+		case chunk, ok := <-state.Live:
+			if !ok {
+				exit := &bridgev1.TerminalExit{}
+				exit.ExitRecorded, exit.ExitCode, _ = s.waitForExit(sessionID)
+				return stream.Send(&bridgev1.AttachTerminalResponse{Frame: &bridgev1.AttachTerminalResponse_Exit{Exit: exit}})
+			}
+			if chunk.Type != bridge.ChunkTypeOutput || chunk.Seq <= lastSeq {
+				continue
+			}
+			lastSeq = chunk.Seq
+			if err := stream.Send(terminalOutput(chunk, false)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// terminalInput applies input and resize frames until the client closes its
+// side of the stream, which returns nil. Each input frame spends a token of
+// the session's write limit; a client typing faster than it allows is slowed
+// down rather than detached.
+func (s *BridgeServer) terminalInput(stream bridgev1.BridgeService_AttachTerminalServer, sessionID, clientID string) error {
+	ctx := stream.Context()
+	info, err := s.supervisor.Get(sessionID)
+	if err != nil {
+		return mapBridgeError(err, "terminal input")
+	}
+	limits := s.limitersFor(info.ProjectID)
+	input := s.supervisor.NewTerminalInput(sessionID, clientID)
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch frame := req.Frame.(type) {
+		case *bridgev1.AttachTerminalRequest_Input:
+			if err := validateByteField("input", frame.Input, 1<<20); err != nil {
+				return err
+			}
+			if err := waitLimit(ctx, limits.write, sessionID); err != nil {
+				return status.FromContextError(err).Err()
+			}
+			if err := input.Write(ctx, frame.Input); err != nil {
+				return mapBridgeError(err, "terminal input")
+			}
+		case *bridgev1.AttachTerminalRequest_Resize:
+			if frame.Resize.Cols == 0 || frame.Resize.Rows == 0 {
+				return status.Error(codes.InvalidArgument, "cols and rows must be > 0")
+			}
+			if err := s.supervisor.Resize(sessionID, clientID, frame.Resize.Cols, frame.Resize.Rows); err != nil {
+				return mapBridgeError(err, "terminal resize")
+			}
+		default:
+			return status.Error(codes.InvalidArgument, "open may only be sent once")
+		}
+	}
+}
+
+func terminalOutput(chunk bridge.OutputChunk, replay bool) *bridgev1.AttachTerminalResponse {
+	return &bridgev1.AttachTerminalResponse{Frame: &bridgev1.AttachTerminalResponse_Output{Output: &bridgev1.TerminalOutput{
+		Seq:    chunk.Seq,
+		Data:   chunk.Payload,
+		Replay: replay,
+	}}}
+}
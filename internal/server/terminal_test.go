@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type terminalStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	recv   chan *bridgev1.AttachTerminalRequest
+
+	mu     sync.Mutex
+	frames []*bridgev1.AttachTerminalResponse
+}
+
+func newTerminalStream(ctx context.Context) *terminalStream {
+	streamCtx, cancel := context.WithCancel(ctx)
+	return &terminalStream{ctx: streamCtx, cancel: cancel, recv: make(chan *bridgev1.AttachTerminalRequest, 8)}
+}
+
+func (s *terminalStream) SetHeader(metadata.MD) error  { return nil }
+func (s *terminalStream) SendHeader(metadata.MD) error { return nil }
+func (s *terminalStream) SetTrailer(metadata.MD)       {}
+func (s *terminalStream) Context() context.Context     { return s.ctx }
+func (s *terminalStream) SendMsg(any) error            { return nil }
+func (s *terminalStream) RecvMsg(any) error            { return nil }
+
+func (s *terminalStream) Recv() (*bridgev1.AttachTerminalRequest, error) {
+	select {
+	case req, ok := <-s.recv:
+		if !ok {
+			return nil, io.EOF
+		}
+		return req, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func (s *terminalStream) Send(resp *bridgev1.AttachTerminalResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frames = append(s.frames, resp)
+	return nil
+}
+
+func (s *terminalStream) snapshot() []*bridgev1.AttachTerminalResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*bridgev1.AttachTerminalResponse, len(s.frames))
+	copy(out, s.frames)
+	return out
+}
+
+func (s *terminalStream) output() []byte {
+	var out []byte
+	for _, f := range s.snapshot() {
+		out = append(out, f.GetOutput().GetData()...)
+	}
+	return out
+}
+
+func newTerminalTestServer(t *testing.T) (*BridgeServer, *bridge.Supervisor) {
+	t.Helper()
+	registry := bridge.NewRegistry()
+	if err := registry.Register(&serverTestProvider{id: "cat", version: "1"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	supervisor := bridge.NewSupervisor(registry, bridge.DefaultPolicy(), 1024, time.Minute)
+	t.Cleanup(supervisor.Close)
+	s := New(supervisor, registry, nil, RateLimitConfig{
+		GlobalRPS:                  10,
+		GlobalBurst:                10,
+		StartSessionPerClientRPS:   10,
+		StartSessionPerClientBurst: 10,
+		SendInputPerSessionRPS:     10,
+		SendInputPerSessionBurst:   10,
+	}, "test-instance", nil)
+	return s, supervisor
+}
+
+func TestAttachTerminal(t *testing.T) {
+	s, _ := newTerminalTestServer(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a"})
+	sessionID := uuid.NewString()
+	if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId:   "project-a",
+		SessionId:   sessionID,
+		RepoPath:    t.TempDir(),
+		Provider:    "cat",
+		InitialCols: 80,
+		InitialRows: 24,
+	}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+
+	stream := newTerminalStream(ctx)
+	done := make(chan error, 1)
+	go func() { done <- s.AttachTerminal(stream) }()
+
+	stream.recv <- &bridgev1.AttachTerminalRequest{Frame: &bridgev1.AttachTerminalRequest_Open{Open: &bridgev1.TerminalOpen{
+		SessionId: sessionID,
+		ClientId:  "term-1",
+	}}}
+	stream.recv <- &bridgev1.AttachTerminalRequest{Frame: &bridgev1.AttachTerminalRequest_Resize{Resize: &bridgev1.TerminalResize{Cols: 100, Rows: 30}}}
+	stream.recv <- &bridgev1.AttachTerminalRequest{Frame: &bridgev1.AttachTerminalRequest_Input{Input: []byte("hello terminal\n")}}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !bytes.Contains(stream.output(), []byte("hello terminal")) {
+		if time.Now().After(deadline) {
+			t.Fatalf("terminal output missing echo; got %q", stream.output())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	frames := stream.snapshot()
+	attached := frames[0].GetAttached()
+	if attached == nil {
+		t.Fatalf("first frame = %v, want attached", frames[0])
+	}
+	if attached.GetClientId() != "term-1" || attached.GetRole() != bridgev1.AttachRole_ATTACH_ROLE_WRITER {
+		t.Fatalf("attached = %+v", attached)
+	}
+	for _, f := range frames[1:] {
+		if f.GetOutput() == nil {
+			t.Fatalf("unexpected non-output frame %v", f)
+		}
+	}
+
+	getResp, err := s.GetSession(ctx, &bridgev1.GetSessionRequest{SessionId: sessionID})
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if getResp.GetCols() != 100 || getResp.GetRows() != 30 {
+		t.Fatalf("size = %dx%d, want 100x30", getResp.GetCols(), getResp.GetRows())
+	}
+
+	if _, err := s.StopSession(ctx, &bridgev1.StopSessionRequest{SessionId: sessionID, Force: true}); err != nil {
+		t.Fatalf("StopSession: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("AttachTerminal: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("AttachTerminal did not return after stop")
+	}
+	frames = stream.snapshot()
+	if frames[len(frames)-1].GetExit() == nil {
+		t.Fatalf("last frame = %v, want exit", frames[len(frames)-1])
+	}
+}
+
+func TestAttachTerminalRequiresOpenFirst(t *testing.T) {
+	s, _ := newTerminalTestServer(t)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: "project-a"})
+
+	stream := newTerminalStream(ctx)
+	stream.recv <- &bridgev1.AttachTerminalRequest{Frame: &bridgev1.AttachTerminalRequest_Input{Input: []byte("x")}}
+	err := s.AttachTerminal(stream)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("AttachTerminal err=%v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+//go:embed ui/index.html
+var uiIndex []byte
+
+// UIHandler serves the read-only web dashboard: the page on GET /ui/, the
+// session list as JSON on GET /ui/api/sessions, and a session's events as
+// server-sent events on GET /ui/api/sessions/{id}/events. Requests are
+// answered by ListSessions and AttachSession as an observer, so a token
+// with events:read is enough and grants nothing more. The token is taken
+// from the Authorization header or, for EventSource and share links, the
+// token query parameter, and checked with verify. A nil verify serves
+// every request as admin, as the local-mode interceptors do.
+func (s *BridgeServer) UIHandler(verify func(token string) (*auth.BridgeClaims, error)) http.Handler {
+	authed := func(h func(http.ResponseWriter, *http.Request, context.Context)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims := &auth.BridgeClaims{Admin: true}
+			if verify != nil {
+				token := r.URL.Query().Get("token")
+				if authz := r.Header.Get("Authorization"); authz != "" {
+					scheme, value, _ := strings.Cut(authz, " ")
+					if !strings.EqualFold(scheme, "bearer") {
+						http.Error(w, "expected Bearer <token>", http.StatusUnauthorized)
+						return
+					}
+					token = strings.TrimSpace(value)
+				}
+				if token == "" {
+					http.Error(w, "missing token", http.StatusUnauthorized)
+					return
+				}
+				var err error
+				if claims, err = verify(token); err != nil {
+					http.Error(w, "invalid token: "+err.Error(), http.StatusUnauthorized)
+					return
+				}
+			}
+			h(w, r, auth.ContextWithClaims(r.Context(), claims))
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", http.RedirectHandler("/ui/", http.StatusFound))
+	mux.HandleFunc("GET /ui/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		_, _ = w.Write(uiIndex)
+	})
+	mux.Handle("GET /ui/api/sessions", authed(func(w http.ResponseWriter, r *http.Request, ctx context.Context) {
+		resp, err := s.ListSessions(ctx, &bridgev1.ListSessionsRequest{ProjectId: r.URL.Query().Get("project")})
+		if err != nil {
+			writeUIError(w, err)
+			return
+		}
+		writeUIJSON(w, resp)
+	}))
+	mux.Handle("GET /ui/api/sessions/{id}/events", authed(func(w http.ResponseWriter, r *http.Request, ctx context.Context) {
+		// A reconnecting EventSource resumes after the last event it saw.
+		var after uint64
+		if id := r.Header.Get("Last-Event-ID"); id != "" {
+			after, _ = strconv.ParseUint(id, 10, 64)
+		}
+		stream := &sseStream{ctx: ctx, w: w, rc: http.NewResponseController(w)}
+		err := s.AttachSession(&bridgev1.AttachSessionRequest{
+			SessionId:       r.PathValue("id"),
+			ClientId:        "ui-" + generateID(),
+			AfterSeq:        after,
+			Role:            bridgev1.AttachRole_ATTACH_ROLE_OBSERVER,
+			ProtocolVersion: ProtocolVersion,
+		}, stream)
+		if err != nil && !stream.started {
+			writeUIError(w, err)
+		}
+	}))
+	return mux
+}
+
+// sseStream sends AttachSession events to a browser as server-sent events,
+// one protojson event per message with its seq as the event ID.
+type sseStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	started bool
+}
+
+func (s *sseStream) Context() context.Context { return s.ctx }
+
+func (s *sseStream) Send(ev *bridgev1.AttachSessionEvent) error {
+	data, err := protojson.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if !s.started {
+		s.started = true
+		s.w.Header().Set("Content-Type", "text/event-stream")
+		s.w.Header().Set("Cache-Control", "no-cache")
+	}
+	if ev.Seq > 0 {
+		if _, err := fmt.Fprintf(s.w, "id: %d\n", ev.Seq); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func writeUIJSON(w http.ResponseWriter, m proto.Message) {
+	body, err := protojson.Marshal(m)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// writeUIError answers with the HTTP status closest to err's gRPC code.
+func writeUIError(w http.ResponseWriter, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	code := http.StatusInternalServerError
+	switch st.Code() {
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.ResourceExhausted:
+		code = http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		code = http.StatusConflict
+	case codes.Unavailable:
+		code = http.StatusServiceUnavailable
+	}
+	http.Error(w, st.Message(), code)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestUIHandler(t *testing.T) {
+	s, supervisor := newTerminalTestServer(t)
+	start := func(projectID string) string {
+		t.Helper()
+		ctx := auth.ContextWithClaims(context.Background(), &auth.BridgeClaims{ProjectID: projectID})
+		sessionID := uuid.NewString()
+		if _, err := s.StartSession(ctx, &bridgev1.StartSessionRequest{
+			ProjectId: projectID, SessionId: sessionID, RepoPath: t.TempDir(), Provider: "cat",
+		}); err != nil {
+			t.Fatalf("StartSession: %v", err)
+		}
+		t.Cleanup(func() { _ = supervisor.Stop(sessionID, true) })
+		return sessionID
+	}
+	sessionA := start("project-a")
+	start("project-b")
+	if _, err := supervisor.Attach(sessionA, "writer", 0, bridge.AttachRoleWriter); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if _, err := supervisor.WriteInput(sessionA, "writer", []byte("hello dashboard\n")); err != nil {
+		t.Fatalf("WriteInput: %v", err)
+	}
+
+	tokens := map[string]*auth.BridgeClaims{
+		"reader": {ProjectID: "project-a", Scopes: []string{auth.ScopeEventsRead}},
+		"other":  {ProjectID: "project-b", Scopes: []string{auth.ScopeEventsRead}},
+	}
+	srv := httptest.NewServer(s.UIHandler(func(token string) (*auth.BridgeClaims, error) {
+		if c, ok := tokens[token]; ok {
+			return c, nil
+		}
+		return nil, errors.New("unknown token")
+	}))
+	defer srv.Close()
+
+	get := func(ctx context.Context, path, token string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		return resp
+	}
+
+	resp := get(context.Background(), "/ui/", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("GET /ui/ = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "bogus": http.StatusUnauthorized} {
+		resp := get(context.Background(), "/ui/api/sessions", token)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("sessions with token %q = %d want %d", token, resp.StatusCode, want)
+		}
+	}
+
+	// The session list is limited to the token's project.
+	resp = get(context.Background(), "/ui/api/sessions", "reader")
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	var list bridgev1.ListSessionsResponse
+	if err := protojson.Unmarshal(body, &list); err != nil {
+		t.Fatalf("decode sessions %s: %v", body, err)
+	}
+	if len(list.Sessions) != 1 || list.Sessions[0].SessionId != sessionA {
+		t.Fatalf("sessions = %v", list.Sessions)
+	}
+
+	resp = get(context.Background(), "/ui/api/sessions/"+sessionA+"/events", "other")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("events of another project = %d want 403", resp.StatusCode)
+	}
+
+	// Events stream as server-sent events; the token may be in the query.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp = get(ctx, "/ui/api/sessions/"+sessionA+"/events?token=reader", "")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("events = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	var sawAttached, sawOutput bool
+	scanner := bufio.NewScanner(resp.Body)
+	for !sawOutput && scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var ev bridgev1.AttachSessionEvent
+		if err := protojson.Unmarshal([]byte(data), &ev); err != nil {
+			t.Fatalf("decode event %s: %v", data, err)
+		}
+		switch ev.Type {
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED:
+			sawAttached = true
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:
+			sawOutput = strings.Contains(string(ev.Payload), "hello dashboard")
+		}
+	}
+	if !sawAttached || !sawOutput {
+		t.Fatalf("attached=%v output=%v, scan err %v", sawAttached, sawOutput, scanner.Err())
+	}
+	// The dashboard only ever observes.
+	if info, err := supervisor.Get(sessionA); err != nil || info.ObserverCount != 1 || info.ActiveWriterClientID != "writer" {
+		t.Fatalf("observers=%d writer=%q, err %v", info.ObserverCount, info.ActiveWriterClientID, err)
+	}
+}
@@ -1,9 +1,13 @@
 package server
 
 import (
+	"fmt"
+	"regexp"
 	"unicode/utf8"
 
 	"github.com/google/uuid"
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -12,12 +16,80 @@ const (
 	maxProjectIDLen  = 128
 	maxSessionIDLen  = 64
 	maxRepoPathLen   = 4096
+	maxRepoURLLen    = 2048
+	maxFilePathLen   = 4096
 	maxProviderLen   = 64
 	maxAgentOptKey   = 128
 	maxAgentOptValue = 4096
 	maxListProjectID = 128
+	maxTokenFieldLen = 512
+	maxFileNameLen   = 255
+	// maxInputAttachments caps the files sent with one WriteInput.
+	maxInputAttachments = 32
+	// maxApprovalMessageLen caps the reason sent with a denied approval.
+	maxApprovalMessageLen = 4096
+	// maxControlReasonLen caps the reason given for a TakeControl.
+	maxControlReasonLen = 512
+	// maxWriterSubjects caps StartSessionRequest.writer_subjects.
+	maxWriterSubjects = 32
+	// maxConversationIDLen caps StartSessionRequest.conversation_id.
+	maxConversationIDLen = 128
+	// maxPromptTemplateLen caps the template name of an input.
+	maxPromptTemplateLen = 128
+	// maxPromptVars caps the vars of a templated input.
+	maxPromptVars = 64
+	// maxInputDataLen caps the data of an input, or its template vars.
+	maxInputDataLen = 1 << 20
 )
 
+// conversationIDPattern keeps conversation IDs usable as file names, as
+// GetConversation suggests one.
+var conversationIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func validateConversationID(value string) error {
+	if err := validateStringField("conversation_id", value, maxConversationIDLen, false); err != nil {
+		return err
+	}
+	if !conversationIDPattern.MatchString(value) {
+		return status.Error(codes.InvalidArgument, "conversation_id may only contain letters, digits, '.', '_' and '-'")
+	}
+	return nil
+}
+
+// validateInputData checks the prompt of an input: data, or a template
+// with its vars.
+func validateInputData(data []byte, template string, vars map[string]string) error {
+	if template == "" {
+		if len(vars) > 0 {
+			return status.Error(codes.InvalidArgument, "vars need a template")
+		}
+		return validateByteField("data", data, maxInputDataLen)
+	}
+	if len(data) > 0 {
+		return status.Error(codes.InvalidArgument, "data and template are mutually exclusive")
+	}
+	if err := validateStringField("template", template, maxPromptTemplateLen, false); err != nil {
+		return err
+	}
+	if len(vars) > maxPromptVars {
+		return status.Errorf(codes.InvalidArgument, "vars exceed max count %d", maxPromptVars)
+	}
+	total := 0
+	for k, v := range vars {
+		if err := validateStringField("vars key", k, maxAgentOptKey, false); err != nil {
+			return err
+		}
+		if !utf8.ValidString(v) {
+			return status.Errorf(codes.InvalidArgument, "vars.%s must be valid UTF-8", k)
+		}
+		total += len(v)
+	}
+	if total > maxInputDataLen {
+		return status.Errorf(codes.InvalidArgument, "vars exceed max length %d", maxInputDataLen)
+	}
+	return nil
+}
+
 func validateUUIDField(name, value string) error {
 	if err := validateStringField(name, value, maxSessionIDLen, false); err != nil {
 		return err
@@ -65,3 +137,37 @@ func validateByteField(name string, value []byte, maxLen int) error {
 	}
 	return nil
 }
+
+// inputAttachments checks the attachments of a WriteInput and converts
+// them. The supervisor checks the paths themselves.
+func inputAttachments(list []*bridgev1.InputAttachment) ([]bridge.Attachment, error) {
+	if len(list) > maxInputAttachments {
+		return nil, status.Errorf(codes.InvalidArgument, "attachments exceed max count %d", maxInputAttachments)
+	}
+	out := make([]bridge.Attachment, 0, len(list))
+	for i, a := range list {
+		if err := validateOptionalStringField(fmt.Sprintf("attachments[%d].path", i), a.GetPath(), maxFilePathLen, false); err != nil {
+			return nil, err
+		}
+		if err := validateOptionalStringField(fmt.Sprintf("attachments[%d].name", i), a.GetName(), maxFileNameLen, false); err != nil {
+			return nil, err
+		}
+		out = append(out, bridge.Attachment{Path: a.GetPath(), Name: a.GetName(), Content: a.GetContent()})
+	}
+	return out, nil
+}
+
+// validateRepoSource checks that a session names exactly one of repo_path
+// and repo_source.
+func validateRepoSource(repoPath string, src *bridge.RepoSource) error {
+	if src == nil {
+		return validateStringField("repo_path", repoPath, maxRepoPathLen, false)
+	}
+	if repoPath != "" {
+		return status.Error(codes.InvalidArgument, "repo_path and repo_source are mutually exclusive")
+	}
+	if err := validateStringField("repo_source.url", src.URL, maxRepoURLLen, false); err != nil {
+		return err
+	}
+	return validateOptionalStringField("repo_source.ref", src.Ref, maxGitRevision, false)
+}
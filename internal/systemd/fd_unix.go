@@ -0,0 +1,7 @@
+//go:build !windows
+
+package systemd
+
+import "syscall"
+
+func closeOnExec(fd int) { syscall.CloseOnExec(fd) }
@@ -0,0 +1,4 @@
+package systemd
+
+// Windows has no socket activation; listeners never runs there.
+func closeOnExec(int) {}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package systemd
+
+import "time"
+
+// monotonicNow is only needed under systemd, i.e. on Linux.
+func monotonicNow() time.Duration { return 0 }
@@ -0,0 +1,128 @@
+// Package systemd implements the parts of the systemd service protocol the
+// daemon uses: sockets passed by socket activation (sd_listen_fds) and
+// readiness, reload, stop and watchdog notifications (sd_notify). Outside
+// systemd every function is a no-op.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd.
+const listenFDsStart = 3
+
+// Notification states sent with Notify.
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// Reloading returns the RELOADING=1 notification, stamped with the
+// CLOCK_MONOTONIC time that Type=notify-reload units must send with it.
+// Send Ready once the reload is done.
+func Reloading() string {
+	return "RELOADING=1\nMONOTONIC_USEC=" + strconv.FormatInt(monotonicNow().Microseconds(), 10)
+}
+
+// Listener is a socket passed by systemd, with the name given by the
+// socket unit's FileDescriptorName= (the unit name when unset).
+type Listener struct {
+	net.Listener
+	Name string
+}
+
+// SocketActivated reports whether systemd passed sockets to this process.
+// Unlike Listeners it leaves the environment untouched.
+func SocketActivated() bool {
+	n, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	return n > 0 && os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getpid())
+}
+
+// Listeners returns the sockets passed to this process by socket
+// activation, or nil when there are none. The LISTEN_* variables are
+// cleared so that child processes do not inherit them.
+func Listeners() ([]Listener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	return listeners(os.Getenv, os.Getpid(), listenFDsStart)
+}
+
+func listeners(getenv func(string) string, pid, first int) ([]Listener, error) {
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	var names []string
+	if v := getenv("LISTEN_FDNAMES"); v != "" {
+		names = strings.Split(v, ":")
+	}
+	out := make([]Listener, 0, n)
+	for i := range n {
+		fd := first + i
+		closeOnExec(fd)
+		name := "unknown"
+		if i < len(names) {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		// FileListener dups the descriptor; the original is not needed.
+		_ = f.Close()
+		if err != nil {
+			for _, l := range out {
+				_ = l.Close()
+			}
+			return nil, fmt.Errorf("systemd socket %d (%s): %w", fd, name, err)
+		}
+		out = append(out, Listener{Listener: ln, Name: name})
+	}
+	return out, nil
+}
+
+// Notify sends state to the service manager. It reports false, with no
+// error, when the process was not started with NOTIFY_SOCKET.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// A leading @ names a socket in the abstract namespace.
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("sd_notify: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("sd_notify: %w", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the unit's WatchdogSec=, or zero when the
+// watchdog is not enabled for this process. Send Watchdog at least every
+// half interval.
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
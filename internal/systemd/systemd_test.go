@@ -0,0 +1,98 @@
+//go:build !windows
+
+package systemd
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestListeners(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("File: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	// listeners takes ownership of the descriptors it is given.
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatalf("Dup: %v", err)
+	}
+
+	env := map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "1", "LISTEN_FDNAMES": "grpc"}
+	got, err := listeners(func(k string) string { return env[k] }, 42, fd)
+	if err != nil {
+		t.Fatalf("listeners: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "grpc" || got[0].Addr().String() != ln.Addr().String() {
+		t.Fatalf("listeners=%+v want grpc on %s", got, ln.Addr())
+	}
+	_ = got[0].Close()
+
+	// Variables meant for another process are ignored.
+	if got, err := listeners(func(k string) string { return env[k] }, 43, fd); err != nil || got != nil {
+		t.Fatalf("listeners for another pid=%v err=%v", got, err)
+	}
+	if got, err := listeners(func(string) string { return "" }, 42, fd); err != nil || got != nil {
+		t.Fatalf("listeners without LISTEN_FDS=%v err=%v", got, err)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	if ok, err := Notify(Ready); ok || err != nil {
+		t.Fatalf("Notify without NOTIFY_SOCKET=%v, %v", ok, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("ListenUnixgram: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	for _, state := range []string{Ready, Reloading(), Stopping} {
+		if ok, err := Notify(state); !ok || err != nil {
+			t.Fatalf("Notify(%q)=%v, %v", state, ok, err)
+		}
+		buf := make([]byte, 256)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if string(buf[:n]) != state {
+			t.Fatalf("received %q want %q", buf[:n], state)
+		}
+	}
+	if !strings.HasPrefix(Reloading(), "RELOADING=1\nMONOTONIC_USEC=") {
+		t.Fatalf("Reloading()=%q", Reloading())
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", "")
+	if got := WatchdogInterval(); got != 30*time.Second {
+		t.Fatalf("WatchdogInterval=%v want 30s", got)
+	}
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(1))
+	if got := WatchdogInterval(); got != 0 {
+		t.Fatalf("WatchdogInterval for another pid=%v", got)
+	}
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "")
+	if got := WatchdogInterval(); got != 0 {
+		t.Fatalf("WatchdogInterval unset=%v", got)
+	}
+}
@@ -14,16 +14,31 @@ import {
   BridgeClientOptions,
   Logger,
   ProtoAttachSessionEvent,
+  ProtoAttachTerminalResponse,
   ProtoGetSessionResponse,
   ProtoHealthResponse,
   ProtoListProvidersResponse,
   ProtoListSessionsResponse,
   ProtoResizeSessionResponse,
+  ProtoCancelResponseResponse,
+  ProtoResolveApprovalResponse,
+  ProtoTakeControlResponse,
+  ProtoMintSessionTokenResponse,
+  ProtoListDirResponse,
+  ProtoReadFileResponse,
+  ProtoWriteFileResponse,
+  ProtoGitStatusResponse,
+  ProtoGitDiffResponse,
+  ProtoGitCommitResponse,
+  ProtoRollbackWorkspaceResponse,
   ProtoStartSessionResponse,
   ProtoStopSessionResponse,
   ProtoWriteInputResponse,
+  ProtoBroadcastInputResponse,
+  ProviderVariant,
   SessionInfo,
   SessionStatus,
+  Severity,
 } from "./types";
 
 // Resolve the proto file regardless of where the package is installed.
@@ -126,6 +141,9 @@ const ATTACH_EVENT_TYPE_MAP: Record<string, AttachEventType> = {
   ATTACH_EVENT_TYPE_REPLAY_GAP: "replay_gap",
   ATTACH_EVENT_TYPE_SESSION_EXIT: "session_exit",
   ATTACH_EVENT_TYPE_ERROR: "error",
+  ATTACH_EVENT_TYPE_WARNING: "warning",
+  ATTACH_EVENT_TYPE_INPUT_ACKED: "input_acked",
+  ATTACH_EVENT_TYPE_REPO_DIRTY: "repo_dirty",
 };
 
 const ATTACH_EVENT_TYPE_BY_NUMBER: Record<number, AttachEventType> = {
@@ -135,6 +153,23 @@ const ATTACH_EVENT_TYPE_BY_NUMBER: Record<number, AttachEventType> = {
   3: "replay_gap",
   4: "session_exit",
   5: "error",
+  11: "warning",
+  12: "input_acked",
+  13: "repo_dirty",
+};
+
+const SEVERITY_MAP: Record<string, Severity> = {
+  SEVERITY_UNSPECIFIED: "unspecified",
+  SEVERITY_PROGRESS: "progress",
+  SEVERITY_WARNING: "warning",
+  SEVERITY_ERROR: "error",
+};
+
+const SEVERITY_BY_NUMBER: Record<number, Severity> = {
+  0: "unspecified",
+  1: "progress",
+  2: "warning",
+  3: "error",
 };
 
 const SESSION_STATUS_MAP: Record<string, SessionStatus> = {
@@ -164,6 +199,13 @@ function toAttachEventType(raw: string | number): AttachEventType {
   return ATTACH_EVENT_TYPE_BY_NUMBER[raw] ?? "unspecified";
 }
 
+function toSeverity(raw: string | number | undefined): Severity {
+  if (typeof raw === "string") {
+    return SEVERITY_MAP[raw] ?? "unspecified";
+  }
+  return SEVERITY_BY_NUMBER[raw ?? 0] ?? "unspecified";
+}
+
 function toSessionStatus(raw: string | number): SessionStatus {
   if (typeof raw === "string") {
     return SESSION_STATUS_MAP[raw] ?? "unspecified";
@@ -175,6 +217,11 @@ function toLong(v: number | { toNumber(): number }): number {
   return typeof v === "object" ? v.toNumber() : v;
 }
 
+function inputBytes(data?: Buffer | string): Buffer {
+  if (data === undefined) return Buffer.alloc(0);
+  return typeof data === "string" ? Buffer.from(data, "utf8") : data;
+}
+
 function toTimestampString(ts?: {
   seconds: number | { toNumber(): number };
   nanos?: number;
@@ -196,6 +243,9 @@ function toSessionInfo(r: ProtoGetSessionResponse): SessionInfo {
       ? toTimestampString(r.stopped_at as Parameters<typeof toTimestampString>[0])
       : undefined,
     error: r.error || undefined,
+    variant: r.variant || undefined,
+    conversationId: r.conversation_id || undefined,
+    resumedFrom: r.resumed_from || undefined,
   };
 }
 
@@ -217,9 +267,48 @@ export interface AttachEvent {
   error: string;
   cols: number;
   rows: number;
+  /** Classification of a provider stderr line; set on "warning" events */
+  severity: Severity;
+  /** Input this event belongs to; set on "input_acked" and later output */
+  inputId: string;
   timestamp: string;
 }
 
+function toTerminalFrame(raw: ProtoAttachTerminalResponse): TerminalFrame | null {
+  if (raw.attached) {
+    const a = raw.attached;
+    return {
+      type: "attached",
+      clientId: a.client_id,
+      role:
+        a.role === "ATTACH_ROLE_OBSERVER" || a.role === 2
+          ? "observer"
+          : "writer",
+      cols: a.cols,
+      rows: a.rows,
+      oldestSeq: toLong(a.oldest_seq),
+      lastSeq: toLong(a.last_seq),
+      replayGap: a.replay_gap,
+    };
+  }
+  if (raw.output) {
+    return {
+      type: "output",
+      seq: toLong(raw.output.seq),
+      data: Buffer.from(raw.output.data),
+      replay: raw.output.replay,
+    };
+  }
+  if (raw.exit) {
+    return {
+      type: "exit",
+      exitRecorded: raw.exit.exit_recorded,
+      exitCode: raw.exit.exit_code,
+    };
+  }
+  return null;
+}
+
 function toAttachEvent(raw: ProtoAttachSessionEvent): AttachEvent {
   return {
     type: toAttachEventType(raw.type),
@@ -236,6 +325,8 @@ function toAttachEvent(raw: ProtoAttachSessionEvent): AttachEvent {
     error: raw.error,
     cols: raw.cols,
     rows: raw.rows,
+    severity: toSeverity(raw.severity),
+    inputId: raw.input_id ?? "",
     timestamp: toTimestampString(raw.timestamp as Parameters<typeof toTimestampString>[0]),
   };
 }
@@ -269,16 +360,79 @@ interface BridgeServiceStub {
     req: object,
     metadata: grpc.Metadata
   ): grpc.ClientReadableStream<ProtoAttachSessionEvent>;
+  AttachTerminal(
+    metadata: grpc.Metadata
+  ): grpc.ClientDuplexStream<object, ProtoAttachTerminalResponse>;
   WriteInput(
     req: object,
     metadata: grpc.Metadata,
     cb: grpc.requestCallback<ProtoWriteInputResponse>
   ): grpc.ClientUnaryCall;
+  BroadcastInput(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoBroadcastInputResponse>
+  ): grpc.ClientUnaryCall;
   ResizeSession(
     req: object,
     metadata: grpc.Metadata,
     cb: grpc.requestCallback<ProtoResizeSessionResponse>
   ): grpc.ClientUnaryCall;
+  CancelResponse(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoCancelResponseResponse>
+  ): grpc.ClientUnaryCall;
+  ResolveApproval(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoResolveApprovalResponse>
+  ): grpc.ClientUnaryCall;
+  TakeControl(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoTakeControlResponse>
+  ): grpc.ClientUnaryCall;
+  MintSessionToken(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoMintSessionTokenResponse>
+  ): grpc.ClientUnaryCall;
+  ReadFile(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoReadFileResponse>
+  ): grpc.ClientUnaryCall;
+  WriteFile(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoWriteFileResponse>
+  ): grpc.ClientUnaryCall;
+  ListDir(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoListDirResponse>
+  ): grpc.ClientUnaryCall;
+  GitStatus(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoGitStatusResponse>
+  ): grpc.ClientUnaryCall;
+  GitDiff(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoGitDiffResponse>
+  ): grpc.ClientUnaryCall;
+  GitCommit(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoGitCommitResponse>
+  ): grpc.ClientUnaryCall;
+  RollbackWorkspace(
+    req: object,
+    metadata: grpc.Metadata,
+    cb: grpc.requestCallback<ProtoRollbackWorkspaceResponse>
+  ): grpc.ClientUnaryCall;
   Health(
     req: object,
     metadata: grpc.Metadata,
@@ -308,15 +462,124 @@ export interface StopSessionResult {
 export interface WriteInputResult {
   accepted: boolean;
   bytesWritten: number;
+  /** Correlates the input with "input_acked" and later attach events */
+  inputId: string;
+  /** The input waits for the agent's current response to complete */
+  queued: boolean;
+  /** Seq of the "input_acked" event; attach after seq - 1 to read the reply */
+  seq: number;
+}
+
+export interface BroadcastInputResult extends WriteInputResult {
+  sessionId: string;
+  /** Why the session refused the input; empty when accepted */
+  error: string;
+  /** gRPC status code of the refusal; 0 when accepted */
+  code: number;
+}
+
+/** A git repo the bridge clones for a new session. */
+export interface RepoSource {
+  url: string;
+  /** Branch or tag; defaults to the remote's default branch */
+  ref?: string;
+  /** Shallow-clone depth; 0 or omitted clones the full history */
+  depth?: number;
+  /** Remove the clone when the session stops instead of when it is archived */
+  cleanOnStop?: boolean;
 }
 
 export interface ResizeSessionResult {
   applied: boolean;
 }
 
+export interface CancelResponseResult {
+  delivered: boolean;
+}
+
+export interface ResolveApprovalResult {
+  delivered: boolean;
+}
+
+export interface TakeControlResult {
+  taken: boolean;
+  previousControllerClientId: string;
+}
+
+export interface MintSessionTokenResult {
+  token: string;
+  expiresAt: string;
+  tokenId: string;
+}
+
+export interface ReadFileResult {
+  data: Buffer;
+  modifiedAt: string;
+}
+
+export interface WriteFileResult {
+  bytesWritten: number;
+}
+
+export interface DirEntryResult {
+  name: string;
+  isDir: boolean;
+  size: number;
+  modifiedAt: string;
+}
+
+export interface GitStatusResult {
+  /** Empty when HEAD is detached */
+  branch: string;
+  /** Empty before the first commit */
+  head: string;
+  clean: boolean;
+  files: Array<{
+    path: string;
+    origPath: string;
+    /** `git status --porcelain` codes, e.g. "M", "A", "?" or " " */
+    indexStatus: string;
+    worktreeStatus: string;
+  }>;
+}
+
+export interface GitDiffResult {
+  diff: string;
+  truncated: boolean;
+}
+
+/** A frame received from `attachTerminal`. */
+export type TerminalFrame =
+  | {
+      type: "attached";
+      clientId: string;
+      role: "writer" | "observer";
+      cols: number;
+      rows: number;
+      oldestSeq: number;
+      lastSeq: number;
+      replayGap: boolean;
+    }
+  | { type: "output"; seq: number; data: Buffer; replay: boolean }
+  | { type: "exit"; exitRecorded: boolean; exitCode: number };
+
+/** An open `attachTerminal` stream. */
+export interface TerminalAttachment {
+  /** Frames from the daemon, ending after "exit" or when the stream closes. */
+  frames: AsyncGenerator<TerminalFrame>;
+  /** Write raw keystrokes to the PTY. Requires the writer role. */
+  write(data: Buffer | string): void;
+  /** Resize the PTY. Requires the writer role. */
+  resize(cols: number, rows: number): void;
+  /** Close the stream; the daemon detaches the client. */
+  close(): void;
+}
+
 export interface HealthResult {
   status: string;
   providers: Array<{ provider: string; available: boolean; error: string }>;
+  /** Values each redaction pattern has replaced since the daemon started. */
+  redactionHits: Record<string, number>;
 }
 
 export interface ProviderInfoResult {
@@ -324,6 +587,10 @@ export interface ProviderInfoResult {
   available: boolean;
   binary: string;
   version: string;
+  /** Why the provider is unavailable, e.g. an unsupported CLI version. */
+  error: string;
+  /** The provider's variants, the default first; empty without variants. */
+  variants: ProviderVariant[];
 }
 
 export class BridgeGrpcClient {
@@ -394,25 +661,49 @@ export class BridgeGrpcClient {
   // Session lifecycle
   // ---------------------------------------------------------------------------
 
+  /**
+   * Start a session in `repoPath`, or in a fresh clone of `repoSource`
+   * managed by the bridge. Set exactly one of the two.
+   */
   async startSession(opts: {
     projectId: string;
     sessionId?: string;
-    repoPath: string;
+    repoPath?: string;
+    repoSource?: RepoSource;
     provider: string;
     agentOpts?: Record<string, string>;
     initialCols?: number;
     initialRows?: number;
+    env?: Record<string, string>;
+    /** Snapshot the repo first so `rollbackWorkspace` can undo the agent's changes */
+    snapshot?: boolean;
+    /** Token subjects allowed to control the session; others may only observe */
+    writerSubjects?: string[];
+    /** Links the session to earlier sessions of the same conversation */
+    conversationId?: string;
   }): Promise<StartSessionResult> {
     const resp = await this.unary<object, ProtoStartSessionResponse>(
       this.stub.StartSession,
       {
         project_id: opts.projectId,
         session_id: opts.sessionId ?? "",
-        repo_path: opts.repoPath,
+        repo_path: opts.repoPath ?? "",
+        repo_source: opts.repoSource
+          ? {
+              url: opts.repoSource.url,
+              ref: opts.repoSource.ref ?? "",
+              depth: opts.repoSource.depth ?? 0,
+              clean_on_stop: opts.repoSource.cleanOnStop ?? false,
+            }
+          : undefined,
         provider: opts.provider,
         agent_opts: opts.agentOpts ?? {},
         initial_cols: opts.initialCols ?? 0,
         initial_rows: opts.initialRows ?? 0,
+        env: opts.env ?? {},
+        snapshot: opts.snapshot ?? false,
+        writer_subjects: opts.writerSubjects ?? [],
+        conversation_id: opts.conversationId ?? "",
       }
     );
     return {
@@ -499,6 +790,62 @@ export class BridgeGrpcClient {
     }
   }
 
+  /**
+   * Attach a terminal emulator to a PTY session over one bidirectional
+   * stream. Output arrives as raw bytes with no control events, ready to
+   * pass to xterm.js `write`; keystrokes and resizes go back on the same
+   * stream. Stream-JSON sessions are rejected with FAILED_PRECONDITION.
+   *
+   * @example
+   * const term = client.attachTerminal({ sessionId });
+   * xterm.onData((d) => term.write(d));
+   * for await (const f of term.frames) {
+   *   if (f.type === "output") xterm.write(f.data);
+   * }
+   */
+  attachTerminal(opts: {
+    sessionId: string;
+    clientId?: string;
+    afterSeq?: number;
+    role?: "writer" | "observer";
+  }): TerminalAttachment {
+    const stream = this.stub.AttachTerminal(this.metadata);
+    stream.write({
+      open: {
+        session_id: opts.sessionId,
+        client_id: opts.clientId ?? "",
+        after_seq: opts.afterSeq ?? 0,
+        role:
+          opts.role === "writer"
+            ? "ATTACH_ROLE_WRITER"
+            : opts.role === "observer"
+              ? "ATTACH_ROLE_OBSERVER"
+              : "ATTACH_ROLE_UNSPECIFIED",
+      },
+    });
+
+    async function* frames(): AsyncGenerator<TerminalFrame> {
+      try {
+        for await (const raw of stream) {
+          const frame = toTerminalFrame(raw as ProtoAttachTerminalResponse);
+          if (frame) yield frame;
+        }
+      } finally {
+        stream.destroy();
+      }
+    }
+
+    return {
+      frames: frames(),
+      write: (data) =>
+        stream.write({
+          input: typeof data === "string" ? Buffer.from(data, "utf8") : data,
+        }),
+      resize: (cols, rows) => stream.write({ resize: { cols, rows } }),
+      close: () => stream.end(),
+    };
+  }
+
   // ---------------------------------------------------------------------------
   // Input and resize
   // ---------------------------------------------------------------------------
@@ -507,25 +854,67 @@ export class BridgeGrpcClient {
    * Send raw input bytes to the session PTY.
    * Accepts a `Buffer` or a `string` (which is UTF-8 encoded to bytes).
    * For interactive PTY sessions pass `\r` (not `\n`) to send Enter.
+   * Instead of `data`, pass the name of a prompt template from the bridge's
+   * config as `template`, with its `vars`; the bridge renders and submits it.
    */
   async writeInput(opts: {
     sessionId: string;
     clientId: string;
-    data: Buffer | string;
+    data?: Buffer | string;
+    template?: string;
+    vars?: Record<string, string>;
   }): Promise<WriteInputResult> {
-    const data =
-      typeof opts.data === "string"
-        ? Buffer.from(opts.data, "utf8")
-        : opts.data;
     const resp = await this.unary<object, ProtoWriteInputResponse>(
       this.stub.WriteInput,
       {
         session_id: opts.sessionId,
         client_id: opts.clientId,
-        data,
+        data: inputBytes(opts.data),
+        template: opts.template ?? "",
+        vars: opts.vars ?? {},
       }
     );
-    return { accepted: resp.accepted, bytesWritten: resp.bytes_written };
+    return {
+      accepted: resp.accepted,
+      bytesWritten: resp.bytes_written,
+      inputId: resp.input_id ?? "",
+      queued: resp.queued ?? false,
+      seq: toLong(resp.seq ?? 0),
+    };
+  }
+
+  /**
+   * Send the same input to several sessions, e.g. to compare providers on
+   * one repo. Returns one result per session, in the order of `sessionIds`;
+   * a session that refuses the input does not fail the call.
+   */
+  async broadcastInput(opts: {
+    sessionIds: string[];
+    clientId: string;
+    data?: Buffer | string;
+    template?: string;
+    vars?: Record<string, string>;
+  }): Promise<BroadcastInputResult[]> {
+    const resp = await this.unary<object, ProtoBroadcastInputResponse>(
+      this.stub.BroadcastInput,
+      {
+        session_ids: opts.sessionIds,
+        client_id: opts.clientId,
+        data: inputBytes(opts.data),
+        template: opts.template ?? "",
+        vars: opts.vars ?? {},
+      }
+    );
+    return (resp.results ?? []).map((r) => ({
+      sessionId: r.session_id,
+      accepted: r.accepted,
+      bytesWritten: r.bytes_written ?? 0,
+      inputId: r.input_id ?? "",
+      queued: r.queued ?? false,
+      seq: toLong(r.seq ?? 0),
+      error: r.error ?? "",
+      code: r.code ?? 0,
+    }));
   }
 
   /** Resize the session PTY to the given dimensions. */
@@ -547,6 +936,232 @@ export class BridgeGrpcClient {
     return { applied: resp.applied };
   }
 
+  /**
+   * Interrupt the agent's in-flight response without stopping the session,
+   * like pressing Ctrl-C in its terminal. `clientId` must hold the writer slot.
+   */
+  async cancelResponse(opts: {
+    sessionId: string;
+    clientId: string;
+  }): Promise<CancelResponseResult> {
+    const resp = await this.unary<object, ProtoCancelResponseResponse>(
+      this.stub.CancelResponse,
+      {
+        session_id: opts.sessionId,
+        client_id: opts.clientId,
+      }
+    );
+    return { delivered: resp.delivered };
+  }
+
+  /**
+   * Allow or deny a tool call the agent is waiting on. `approvalId` is the
+   * `id` of the request's data_json. `clientId` must hold the writer slot.
+   */
+  async resolveApproval(opts: {
+    sessionId: string;
+    clientId: string;
+    approvalId: string;
+    approve: boolean;
+    message?: string;
+  }): Promise<ResolveApprovalResult> {
+    const resp = await this.unary<object, ProtoResolveApprovalResponse>(
+      this.stub.ResolveApproval,
+      {
+        session_id: opts.sessionId,
+        client_id: opts.clientId,
+        approval_id: opts.approvalId,
+        approve: opts.approve,
+        message: opts.message ?? "",
+      }
+    );
+    return { delivered: resp.delivered };
+  }
+
+  /**
+   * Take interactive control of a session, evicting its current writer.
+   * `clientId` must be attached. It keeps the writer slot until it
+   * releases it or detaches.
+   */
+  async takeControl(opts: {
+    sessionId: string;
+    clientId: string;
+    reason?: string;
+  }): Promise<TakeControlResult> {
+    const resp = await this.unary<object, ProtoTakeControlResponse>(
+      this.stub.TakeControl,
+      {
+        session_id: opts.sessionId,
+        client_id: opts.clientId,
+        reason: opts.reason ?? "",
+      }
+    );
+    return {
+      taken: resp.taken,
+      previousControllerClientId: resp.previous_controller_client_id,
+    };
+  }
+
+  /**
+   * Mint a short-lived token that can only observe `sessionId`, e.g. for a
+   * share link. `ttlSeconds` defaults to, and may not exceed, the bridge's
+   * maximum token lifetime.
+   */
+  async mintSessionToken(opts: {
+    sessionId: string;
+    ttlSeconds?: number;
+  }): Promise<MintSessionTokenResult> {
+    const resp = await this.unary<object, ProtoMintSessionTokenResponse>(
+      this.stub.MintSessionToken,
+      {
+        session_id: opts.sessionId,
+        ttl_seconds: opts.ttlSeconds ?? 0,
+      }
+    );
+    return {
+      token: resp.token,
+      expiresAt: toTimestampString(resp.expires_at as Parameters<typeof toTimestampString>[0]),
+      tokenId: resp.token_id,
+    };
+  }
+
+  // ---------------------------------------------------------------------------
+  // Repo files
+  // ---------------------------------------------------------------------------
+
+  /** Read a file; `path` is relative to the session's repo_path. */
+  async readFile(opts: {
+    sessionId: string;
+    path: string;
+  }): Promise<ReadFileResult> {
+    const resp = await this.unary<object, ProtoReadFileResponse>(
+      this.stub.ReadFile,
+      { session_id: opts.sessionId, path: opts.path }
+    );
+    return {
+      data: Buffer.from(resp.data ?? []),
+      modifiedAt: toTimestampString(resp.modified_at as Parameters<typeof toTimestampString>[0]),
+    };
+  }
+
+  /**
+   * Create or replace a file; `path` is relative to the session's repo_path.
+   * Strings are UTF-8 encoded.
+   */
+  async writeFile(opts: {
+    sessionId: string;
+    path: string;
+    data: Buffer | string;
+  }): Promise<WriteFileResult> {
+    const data =
+      typeof opts.data === "string"
+        ? Buffer.from(opts.data, "utf8")
+        : opts.data;
+    const resp = await this.unary<object, ProtoWriteFileResponse>(
+      this.stub.WriteFile,
+      { session_id: opts.sessionId, path: opts.path, data }
+    );
+    return { bytesWritten: resp.bytes_written };
+  }
+
+  /** List a directory in the session's repo; omit `path` for the repo root. */
+  async listDir(opts: {
+    sessionId: string;
+    path?: string;
+  }): Promise<DirEntryResult[]> {
+    const resp = await this.unary<object, ProtoListDirResponse>(
+      this.stub.ListDir,
+      { session_id: opts.sessionId, path: opts.path ?? "" }
+    );
+    return (resp.entries ?? []).map((e) => ({
+      name: e.name,
+      isDir: e.is_dir,
+      size: toLong(e.size),
+      modifiedAt: toTimestampString(e.modified_at as Parameters<typeof toTimestampString>[0]),
+    }));
+  }
+
+  // ---------------------------------------------------------------------------
+  // Git
+  // ---------------------------------------------------------------------------
+
+  async gitStatus(opts: { sessionId: string }): Promise<GitStatusResult> {
+    const resp = await this.unary<object, ProtoGitStatusResponse>(
+      this.stub.GitStatus,
+      { session_id: opts.sessionId }
+    );
+    return {
+      branch: resp.branch,
+      head: resp.head,
+      clean: resp.clean,
+      files: (resp.files ?? []).map((f) => ({
+        path: f.path,
+        origPath: f.orig_path,
+        indexStatus: f.index_status,
+        worktreeStatus: f.worktree_status,
+      })),
+    };
+  }
+
+  /**
+   * Unified diff of the working tree against the index, or with `staged` the
+   * index against `base` (default HEAD).
+   */
+  async gitDiff(opts: {
+    sessionId: string;
+    staged?: boolean;
+    base?: string;
+    paths?: string[];
+  }): Promise<GitDiffResult> {
+    const resp = await this.unary<object, ProtoGitDiffResponse>(
+      this.stub.GitDiff,
+      {
+        session_id: opts.sessionId,
+        staged: opts.staged ?? false,
+        base: opts.base ?? "",
+        paths: opts.paths ?? [],
+      }
+    );
+    return {
+      diff: Buffer.from(resp.diff ?? []).toString("utf8"),
+      truncated: resp.truncated,
+    };
+  }
+
+  /**
+   * Commit as the bridge's configured git identity. `all` stages every
+   * change first; otherwise `paths` are staged. Returns the commit hash.
+   */
+  async gitCommit(opts: {
+    sessionId: string;
+    message: string;
+    all?: boolean;
+    paths?: string[];
+  }): Promise<string> {
+    const resp = await this.unary<object, ProtoGitCommitResponse>(
+      this.stub.GitCommit,
+      {
+        session_id: opts.sessionId,
+        message: opts.message,
+        all: opts.all ?? false,
+        paths: opts.paths ?? [],
+      }
+    );
+    return resp.commit;
+  }
+
+  /**
+   * Restore a stopped session's repo to the snapshot taken when it started
+   * with `snapshot: true`. Resolves to the commit HEAD points at again.
+   */
+  async rollbackWorkspace(opts: { sessionId: string }): Promise<string> {
+    const resp = await this.unary<object, ProtoRollbackWorkspaceResponse>(
+      this.stub.RollbackWorkspace,
+      { session_id: opts.sessionId }
+    );
+    return resp.head;
+  }
+
   // ---------------------------------------------------------------------------
   // Health and discovery
   // ---------------------------------------------------------------------------
@@ -563,6 +1178,9 @@ export class BridgeGrpcClient {
         available: p.available,
         error: p.error,
       })),
+      redactionHits: Object.fromEntries(
+        Object.entries(resp.redactionHits ?? {}).map(([k, v]) => [k, toLong(v)])
+      ),
     };
   }
 
@@ -576,6 +1194,8 @@ export class BridgeGrpcClient {
       available: p.available,
       binary: p.binary,
       version: p.version,
+      error: p.error,
+      variants: p.variants ?? [],
     }));
   }
 }
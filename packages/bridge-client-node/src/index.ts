@@ -7,10 +7,23 @@
 export { BridgeGrpcClient } from "./grpc-client";
 export type {
   AttachEvent,
+  RepoSource,
   StartSessionResult,
   StopSessionResult,
   WriteInputResult,
+  BroadcastInputResult,
   ResizeSessionResult,
+  CancelResponseResult,
+  ResolveApprovalResult,
+  TakeControlResult,
+  MintSessionTokenResult,
+  ReadFileResult,
+  WriteFileResult,
+  DirEntryResult,
+  GitStatusResult,
+  GitDiffResult,
+  TerminalAttachment,
+  TerminalFrame,
   HealthResult,
   ProviderInfoResult,
 } from "./grpc-client";
@@ -18,6 +31,9 @@ export type {
 export { createBridgeWebSocketHandler } from "./websocket-handler";
 export type { BridgeWebSocketHandlerOptions } from "./websocket-handler";
 
+export { createBridgeTerminalHandler } from "./terminal-handler";
+export type { BridgeTerminalHandlerOptions } from "./terminal-handler";
+
 export { createNextJsBridgeRoute, customServerSnippet } from "./nextjs";
 
 export type {
@@ -47,8 +63,10 @@ export type {
   SessionInfo,
   ProviderInfo,
   ProviderHealth,
+  ProviderVariant,
   AttachEventType,
   SessionStatus,
+  Severity,
   BridgeClientOptions,
   Logger,
 } from "./types";
@@ -0,0 +1,130 @@
+/**
+ * WebSocket terminal proxy for the ai-agent-bridge.
+ *
+ * Relays the AttachTerminal gRPC stream to a browser terminal such as
+ * xterm.js. PTY output is sent as binary WebSocket frames and can be passed
+ * straight to `term.write`; binary frames from the browser are keystrokes.
+ * Text frames carry JSON control messages:
+ *
+ *   browser → proxy  {"sessionId":"…","clientId":"…","afterSeq":0,"role":"writer"}  (first frame)
+ *                    {"type":"resize","cols":120,"rows":40}
+ *   proxy → browser  {"type":"attached",…} | {"type":"exit",…} | {"type":"error",…}
+ *
+ * Usage:
+ *   const wss = createBridgeTerminalHandler({ bridgeAddr: "localhost:9445" });
+ *   server.on("upgrade", (req, socket, head) => {
+ *     if (req.url === "/terminal") wss.handleUpgrade(req, socket, head, (ws) => wss.emit("connection", ws, req));
+ *   });
+ */
+
+import { WebSocketServer, WebSocket, RawData } from "ws";
+import { v4 as uuidv4 } from "uuid";
+import { BridgeGrpcClient, TerminalAttachment } from "./grpc-client";
+import { BridgeWebSocketHandlerOptions } from "./websocket-handler";
+import { Logger } from "./types";
+
+export type BridgeTerminalHandlerOptions = BridgeWebSocketHandlerOptions;
+
+/**
+ * Creates a `WebSocketServer` where each connection mirrors one PTY session
+ * through `BridgeGrpcClient.attachTerminal`.
+ */
+export function createBridgeTerminalHandler(
+  options: BridgeTerminalHandlerOptions
+): WebSocketServer {
+  const logger: Logger = options.logger ?? {
+    info: (msg, ...a) => console.info("[bridge-terminal]", msg, ...a),
+    warn: (msg, ...a) => console.warn("[bridge-terminal]", msg, ...a),
+    error: (msg, ...a) => console.error("[bridge-terminal]", msg, ...a),
+    debug: (msg, ...a) => console.debug("[bridge-terminal]", msg, ...a),
+  };
+
+  const wss = new WebSocketServer(options.wssOptions ?? { noServer: true });
+
+  wss.on("connection", (ws: WebSocket) => {
+    const connId = uuidv4();
+    logger.info("Terminal connected", { connId });
+
+    const grpcClient = new BridgeGrpcClient({
+      bridgeAddr: options.bridgeAddr,
+      credentials: options.credentials,
+      metadata: options.metadata,
+      channelOptions: options.channelOptions,
+      logger,
+    });
+    let term: TerminalAttachment | null = null;
+
+    function sendJSON(msg: object): void {
+      if (ws.readyState === WebSocket.OPEN) {
+        ws.send(JSON.stringify(msg));
+      }
+    }
+
+    async function pump(t: TerminalAttachment): Promise<void> {
+      try {
+        for await (const frame of t.frames) {
+          if (ws.readyState !== WebSocket.OPEN) break;
+          if (frame.type === "output") {
+            ws.send(frame.data, { binary: true });
+          } else {
+            sendJSON(frame);
+          }
+        }
+      } catch (err) {
+        sendJSON({
+          type: "error",
+          code: "ATTACH_FAILED",
+          message: err instanceof Error ? err.message : String(err),
+        });
+      } finally {
+        ws.close();
+      }
+    }
+
+    ws.on("message", (raw: RawData, isBinary: boolean) => {
+      if (isBinary) {
+        if (term) term.write(toBuffer(raw));
+        return;
+      }
+      let msg: Record<string, unknown>;
+      try {
+        msg = JSON.parse(raw.toString()) as Record<string, unknown>;
+      } catch {
+        sendJSON({ type: "error", code: "INVALID_JSON", message: "Message is not valid JSON" });
+        return;
+      }
+      if (!term) {
+        if (typeof msg.sessionId !== "string") {
+          sendJSON({ type: "error", code: "INVALID_REQUEST", message: "First message must name a sessionId" });
+          ws.close();
+          return;
+        }
+        term = grpcClient.attachTerminal({
+          sessionId: msg.sessionId,
+          clientId: typeof msg.clientId === "string" ? msg.clientId : undefined,
+          afterSeq: typeof msg.afterSeq === "number" ? msg.afterSeq : undefined,
+          role: msg.role === "writer" || msg.role === "observer" ? msg.role : undefined,
+        });
+        void pump(term);
+        return;
+      }
+      if (msg.type === "resize" && typeof msg.cols === "number" && typeof msg.rows === "number") {
+        term.resize(msg.cols, msg.rows);
+      }
+    });
+
+    ws.on("close", () => {
+      logger.info("Terminal disconnected", { connId });
+      term?.close();
+      grpcClient.close();
+    });
+  });
+
+  return wss;
+}
+
+function toBuffer(raw: RawData): Buffer {
+  if (Buffer.isBuffer(raw)) return raw;
+  if (Array.isArray(raw)) return Buffer.concat(raw);
+  return Buffer.from(raw);
+}
@@ -15,7 +15,13 @@ export type AttachEventType =
   | "output"
   | "replay_gap"
   | "session_exit"
-  | "error";
+  | "error"
+  | "warning"
+  | "input_acked"
+  | "repo_dirty";
+
+/** Severity of a provider stderr line carried by a "warning" event. */
+export type Severity = "unspecified" | "progress" | "warning" | "error";
 
 export type SessionStatus =
   | "unspecified"
@@ -38,6 +44,12 @@ export interface SessionInfo {
   createdAt: string; // ISO 8601
   stoppedAt?: string; // ISO 8601, if stopped
   error?: string;
+  /** Provider variant the session runs, for providers with variants. */
+  variant?: string;
+  /** Conversation the session belongs to, set with `conversationId`. */
+  conversationId?: string;
+  /** Earlier session of the conversation whose agent thread this one continues. */
+  resumedFrom?: string;
 }
 
 export interface ProviderInfo {
@@ -45,6 +57,20 @@ export interface ProviderInfo {
   available: boolean;
   binary: string;
   version: string;
+  /** Why the provider is unavailable, e.g. an unsupported CLI version. */
+  error: string;
+  /** The provider's variants, the default first; empty without variants. */
+  variants: ProviderVariant[];
+}
+
+/** A variant of a provider, chosen with the "variant" agent option. */
+export interface ProviderVariant {
+  name: string;
+  available: boolean;
+  binary: string;
+  version: string;
+  error: string;
+  default: boolean;
 }
 
 export interface ProviderHealth {
@@ -66,6 +92,8 @@ export interface StartSessionMsg {
   agentOpts?: Record<string, string>;
   initialCols?: number;
   initialRows?: number;
+  /** Extra agent environment; keys must be in the daemon's allowed_env. */
+  env?: Record<string, string>;
 }
 
 /** Send text input to a running session. Text is UTF-8 encoded to bytes. */
@@ -152,12 +180,16 @@ export interface AttachEventMsg {
   error: string;
   cols: number;
   rows: number;
+  severity: Severity; // set on "warning" events
+  inputId: string; // input this event belongs to; set on "input_acked" and later output
 }
 
 export interface InputAcceptedMsg {
   type: "input_accepted";
   accepted: boolean;
   bytesWritten: number;
+  inputId: string;
+  queued: boolean;
 }
 
 export interface SessionStoppedMsg {
@@ -223,6 +255,8 @@ export interface ProtoAttachSessionEvent {
   error: string;
   cols: number;
   rows: number;
+  severity?: string | number; // Severity enum value
+  input_id?: string;
 }
 
 export interface ProtoStartSessionResponse {
@@ -251,6 +285,9 @@ export interface ProtoGetSessionResponse {
   last_seq: number | Long;
   cols: number;
   rows: number;
+  variant?: string;
+  conversation_id?: string;
+  resumed_from?: string;
 }
 
 export interface ProtoListSessionsResponse {
@@ -260,15 +297,112 @@ export interface ProtoListSessionsResponse {
 export interface ProtoWriteInputResponse {
   accepted: boolean;
   bytes_written: number;
+  input_id?: string;
+  queued?: boolean;
+  seq?: number | Long;
+}
+
+export interface ProtoBroadcastInputResult {
+  session_id: string;
+  accepted: boolean;
+  input_id?: string;
+  seq?: number | Long;
+  queued?: boolean;
+  bytes_written?: number;
+  error?: string;
+  code?: number;
+}
+
+export interface ProtoBroadcastInputResponse {
+  results: ProtoBroadcastInputResult[];
 }
 
 export interface ProtoResizeSessionResponse {
   applied: boolean;
 }
 
+export interface ProtoCancelResponseResponse {
+  delivered: boolean;
+}
+
+export interface ProtoResolveApprovalResponse {
+  delivered: boolean;
+}
+
+export interface ProtoTakeControlResponse {
+  taken: boolean;
+  previous_controller_client_id: string;
+}
+
+export interface ProtoMintSessionTokenResponse {
+  token: string;
+  expires_at?: { seconds: number | Long; nanos: number };
+  token_id: string;
+}
+
+export interface ProtoAttachTerminalResponse {
+  frame?: "attached" | "output" | "exit";
+  attached?: {
+    client_id: string;
+    role: string | number; // AttachRole enum value
+    cols: number;
+    rows: number;
+    oldest_seq: number | Long;
+    last_seq: number | Long;
+    replay_gap: boolean;
+  };
+  output?: { seq: number | Long; data: Buffer | Uint8Array; replay: boolean };
+  exit?: { exit_recorded: boolean; exit_code: number };
+}
+
+export interface ProtoReadFileResponse {
+  data: Buffer | Uint8Array;
+  modified_at?: { seconds: number | Long; nanos: number };
+}
+
+export interface ProtoWriteFileResponse {
+  bytes_written: number;
+}
+
+export interface ProtoListDirResponse {
+  entries: Array<{
+    name: string;
+    is_dir: boolean;
+    size: number | Long;
+    modified_at?: { seconds: number | Long; nanos: number };
+  }>;
+}
+
+export interface ProtoGitStatusResponse {
+  branch: string;
+  head: string;
+  clean: boolean;
+  files: Array<{
+    path: string;
+    orig_path: string;
+    index_status: string;
+    worktree_status: string;
+  }>;
+}
+
+export interface ProtoGitDiffResponse {
+  diff: Buffer | Uint8Array;
+  truncated: boolean;
+}
+
+export interface ProtoGitCommitResponse {
+  commit: string;
+}
+
+export interface ProtoRollbackWorkspaceResponse {
+  head: string;
+  snapshot_commit: string;
+}
+
 export interface ProtoHealthResponse {
   status: string;
   providers: Array<{ provider: string; available: boolean; error: string }>;
+  redactionHits?: Record<string, number | { toNumber(): number }>;
 }
 
 export interface ProtoListProvidersResponse {
@@ -277,6 +411,8 @@ export interface ProtoListProvidersResponse {
     available: boolean;
     binary: string;
     version: string;
+    error: string;
+    variants?: ProviderVariant[];
   }>;
 }
 
@@ -100,6 +100,7 @@ export function createBridgeWebSocketHandler(
               agentOpts: msg.agentOpts,
               initialCols: msg.initialCols,
               initialRows: msg.initialRows,
+              env: msg.env,
             });
             send({
               type: "session_started",
@@ -122,6 +123,8 @@ export function createBridgeWebSocketHandler(
               type: "input_accepted",
               accepted: result.accepted,
               bytesWritten: result.bytesWritten,
+              inputId: result.inputId,
+              queued: result.queued,
             });
             break;
           }
@@ -174,6 +177,8 @@ export function createBridgeWebSocketHandler(
                     error: event.error,
                     cols: event.cols,
                     rows: event.rows,
+                    severity: event.severity,
+                    inputId: event.inputId,
                   });
                 }
               } catch (err) {
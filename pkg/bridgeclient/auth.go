@@ -2,6 +2,9 @@ package bridgeclient
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,7 +25,7 @@ type jwtCredentials struct {
 }
 
 func newJWTCredentials(cfg *JWTConfig) (*jwtCredentials, error) {
-	privKey, err := pki.LoadEd25519PrivateKey(cfg.PrivateKeyPath)
+	privKey, err := pki.LoadJWTSigner(cfg.PrivateKeyPath, cfg.KeyPassphrase)
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +41,8 @@ func newJWTCredentials(cfg *JWTConfig) (*jwtCredentials, error) {
 			Audience: cfg.Audience,
 			Key:      privKey,
 			TTL:      ttl,
+			Scopes:   cfg.Scopes,
+			KeyID:    cfg.KeyID,
 		},
 		subject: cfg.Issuer, // default subject = issuer
 	}, nil
@@ -76,13 +81,47 @@ func (j *jwtCredentials) RequireTransportSecurity() bool {
 	return false // Allow insecure for dev; mTLS handles transport security
 }
 
+// staticTokenCredentials implements grpc.PerRPCCredentials with a fixed
+// bearer token.
+type staticTokenCredentials string
+
+func (t staticTokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		"authorization": "Bearer " + string(t),
+	}, nil
+}
+
+func (t staticTokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// tokenFileCredentials implements grpc.PerRPCCredentials with a bearer
+// token read from a file on every call.
+type tokenFileCredentials string
+
+func (t tokenFileCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := os.ReadFile(string(t))
+	if err != nil {
+		return nil, fmt.Errorf("read token file: %w", err)
+	}
+	return map[string]string{
+		"authorization": "Bearer " + strings.TrimSpace(string(token)),
+	}, nil
+}
+
+func (t tokenFileCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
 // buildTransportCredentials creates gRPC transport credentials from mTLS config.
 func buildTransportCredentials(cfg *MTLSConfig) (credentials.TransportCredentials, error) {
 	tlsCfg, err := auth.ClientTLSConfig(auth.TLSConfig{
-		CABundlePath: cfg.CABundlePath,
-		CertPath:     cfg.CertPath,
-		KeyPath:      cfg.KeyPath,
-		ServerName:   cfg.ServerName,
+		CABundlePath:  cfg.CABundlePath,
+		CertPath:      cfg.CertPath,
+		KeyPath:       cfg.KeyPath,
+		ServerName:    cfg.ServerName,
+		SystemRoots:   cfg.SystemRoots,
+		KeyPassphrase: cfg.KeyPassphrase,
 	})
 	if err != nil {
 		return nil, err
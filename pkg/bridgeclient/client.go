@@ -2,22 +2,45 @@ package bridgeclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // registers the gzip compressor for WithCompression
+	"google.golang.org/grpc/metadata"
 )
 
-// Client is a typed wrapper around the BridgeService gRPC client.
+// RequestIDHeader is the metadata key of an RPC's request ID. The bridge
+// logs it in its audit record and on the events the RPC produces, and
+// returns it in the response header of the same name.
+const RequestIDHeader = "x-request-id"
+
+// ContextWithRequestID returns ctx with id as the request ID of the RPCs
+// made with it, so that they can be traced in the bridge's logs and events
+// under an ID from the caller's own system. Without one the bridge
+// generates an ID. IDs are up to 128 printable ASCII characters without
+// spaces; others are replaced.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, RequestIDHeader, id)
+}
+
+// Client is a typed wrapper around the BridgeService gRPC client. With
+// several targets it fails over between bridges and routes session RPCs to
+// the bridge that owns the session.
 type Client struct {
-	conn    *grpc.ClientConn
-	rpc     bridgev1.BridgeServiceClient
-	timeout time.Duration
-	retry   RetryConfig
-	jwtCred *jwtCredentials
-	cursors CursorStore
+	backends []*backend
+	affinity affinity
+	timeout  time.Duration
+	retry    RetryConfig
+	policies map[string]RetryPolicy
+	jwtCred  *jwtCredentials
+	cursors  CursorStore
+	// streamOpts are applied to the streaming RPCs.
+	streamOpts []grpc.CallOption
 }
 
 // New creates a new bridge client with the given options.
@@ -47,8 +70,13 @@ func New(opts ...Option) (*Client, error) {
 		cfg.cursorStore = NewMemoryCursorStore()
 	}
 
-	if cfg.target == "" {
-		return nil, fmt.Errorf("target address is required (use WithTarget)")
+	targets := cfg.allTargets()
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("target address is required (use WithTarget or WithTargets)")
+	}
+
+	if cfg.compression != "" && encoding.GetCompressor(cfg.compression) == nil {
+		return nil, fmt.Errorf("unknown compressor %q", cfg.compression)
 	}
 
 	var dialOpts []grpc.DialOption
@@ -65,6 +93,18 @@ func New(opts ...Option) (*Client, error) {
 	}
 
 	// Per-RPC JWT credentials
+	if cfg.jwt != nil && cfg.staticToken != "" {
+		return nil, fmt.Errorf("WithJWT and WithStaticToken are mutually exclusive")
+	}
+	if cfg.tokenFile != "" && (cfg.jwt != nil || cfg.staticToken != "") {
+		return nil, fmt.Errorf("WithTokenFile cannot be combined with WithJWT or WithStaticToken")
+	}
+	if cfg.staticToken != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(staticTokenCredentials(cfg.staticToken)))
+	}
+	if cfg.tokenFile != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(tokenFileCredentials(cfg.tokenFile)))
+	}
 	var jwtCred *jwtCredentials
 	if cfg.jwt != nil {
 		var err error
@@ -75,24 +115,49 @@ func New(opts ...Option) (*Client, error) {
 		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(jwtCred))
 	}
 
-	conn, err := grpc.NewClient(cfg.target, dialOpts...)
-	if err != nil {
-		return nil, fmt.Errorf("dial bridge: %w", err)
-	}
+	dialOpts = append(dialOpts, cfg.dialOpts...)
 
-	return &Client{
-		conn:    conn,
-		rpc:     bridgev1.NewBridgeServiceClient(conn),
-		timeout: cfg.timeout,
-		retry:   cfg.retry,
-		jwtCred: jwtCred,
-		cursors: cfg.cursorStore,
-	}, nil
+	c := &Client{
+		timeout:  cfg.timeout,
+		retry:    cfg.retry,
+		policies: cfg.policies,
+		jwtCred:  jwtCred,
+		cursors:  cfg.cursorStore,
+	}
+	if cfg.compression != "" {
+		c.streamOpts = append(c.streamOpts, grpc.UseCompressor(cfg.compression))
+	}
+	for _, target := range targets {
+		conn, err := grpc.NewClient(target, dialOpts...)
+		if err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("dial bridge %s: %w", target, err)
+		}
+		c.backends = append(c.backends, &backend{
+			target: target,
+			conn:   conn,
+			rpc:    bridgev1.NewBridgeServiceClient(conn),
+		})
+	}
+	return c, nil
 }
 
-// Close releases the gRPC connection.
+// Close releases the gRPC connections.
 func (c *Client) Close() error {
-	return c.conn.Close()
+	var errs []error
+	for _, b := range c.backends {
+		if b.conn != nil {
+			errs = append(errs, b.conn.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Admin returns an AdminService client on the first target. Its calls need
+// an admin caller: tokens minted with the "admin" scope (JWTConfig.Scopes),
+// or a client certificate with the daemon's admin OU.
+func (c *Client) Admin() bridgev1.AdminServiceClient {
+	return bridgev1.NewAdminServiceClient(c.backends[0].conn)
 }
 
 // SetProject configures the project_id for auto-minted JWTs.
@@ -101,10 +166,3 @@ func (c *Client) SetProject(projectID string) {
 		c.jwtCred.SetProject(projectID)
 	}
 }
-
-func (c *Client) ctx(parent context.Context) (context.Context, context.CancelFunc) {
-	if c.timeout > 0 {
-		return context.WithTimeout(parent, c.timeout)
-	}
-	return parent, func() {}
-}
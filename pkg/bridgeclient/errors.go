@@ -3,7 +3,9 @@ package bridgeclient
 import (
 	"errors"
 	"strings"
+	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -19,6 +21,33 @@ var (
 	ErrRateLimited          = errors.New("rate limited")
 )
 
+// RateLimitError is returned when a server rate limit refused a call. It
+// matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	// RetryAfter is the server's estimate of when a retry can succeed.
+	// Zero when the server sent none.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string { return ErrRateLimited.Error() }
+
+func (e *RateLimitError) Is(target error) bool { return target == ErrRateLimited }
+
+// retryDelay returns the delay of the google.rpc.RetryInfo detail the
+// server attaches to rate-limit errors.
+func retryDelay(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return 0, false
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+			return info.RetryDelay.AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
 // mapError converts gRPC status errors to typed SDK errors.
 func mapError(err error) error {
 	if err == nil {
@@ -40,7 +69,8 @@ func mapError(err error) error {
 	case codes.ResourceExhausted:
 		msg := strings.ToLower(st.Message())
 		if strings.Contains(msg, "rate limit") {
-			return ErrRateLimited
+			d, _ := retryDelay(err)
+			return &RateLimitError{RetryAfter: d}
 		}
 		return ErrSessionLimitReached
 	case codes.Unavailable:
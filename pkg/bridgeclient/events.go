@@ -2,10 +2,12 @@ package bridgeclient
 
 import (
 	"context"
+	"errors"
 	"io"
 
 	"github.com/google/uuid"
 	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"google.golang.org/grpc"
 )
 
 // OutputStream wraps the PTY output stream for one attached client.
@@ -15,6 +17,30 @@ type OutputStream struct {
 	clientID string
 	afterSeq uint64
 	role     bridgev1.AttachRole
+	// eventTypes and match are the request's server-side event filter, and
+	// batchSize and batchDelayMS its batching, reapplied when the stream is
+	// reopened on another bridge.
+	eventTypes   []bridgev1.AttachEventType
+	match        string
+	batchSize    uint32
+	batchDelayMS uint32
+	protocol     uint32
+}
+
+// ProtocolVersion is the event protocol this package understands. It is
+// sent on StartSession, AttachSession and GetSessionHistory requests that
+// do not set protocol_version, so that event types added to the bridge
+// later arrive as ATTACH_EVENT_TYPE_EXTENSION rather than as unknown enum
+// values.
+const ProtocolVersion uint32 = 6
+
+// withProtocol returns the protocol version to request: v, or
+// ProtocolVersion when v is unset.
+func withProtocol(v uint32) uint32 {
+	if v == 0 {
+		return ProtocolVersion
+	}
+	return v
 }
 
 func (c *Client) AttachSession(ctx context.Context, req *bridgev1.AttachSessionRequest) (*OutputStream, error) {
@@ -30,34 +56,67 @@ func (c *Client) AttachSession(ctx context.Context, req *bridgev1.AttachSessionR
 		}
 	}
 	return &OutputStream{
-		client:   c,
-		session:  req.SessionId,
-		clientID: clientID,
-		afterSeq: afterSeq,
-		role:     req.Role,
+		client:       c,
+		session:      req.SessionId,
+		clientID:     clientID,
+		afterSeq:     afterSeq,
+		role:         req.Role,
+		eventTypes:   req.EventTypes,
+		match:        req.Match,
+		batchSize:    req.MaxBatchSize,
+		batchDelayMS: req.MaxBatchDelayMs,
+		protocol:     withProtocol(req.ProtocolVersion),
 	}, nil
 }
 
 func (s *OutputStream) ClientID() string { return s.clientID }
 
+// RecvAll delivers events to callback until the stream ends. The stream is
+// opened on the bridge owning the session, falling back to the other
+// configured bridges when it is unavailable or does not know the session.
+// If the stream breaks, it is reopened after the last delivered event as
+// the client's AttachSession retry policy allows.
 func (s *OutputStream) RecvAll(ctx context.Context, callback func(*bridgev1.AttachSessionEvent) error) error {
-	stream, err := s.client.rpc.AttachSession(ctx, &bridgev1.AttachSessionRequest{
-		SessionId: s.session,
-		ClientId:  s.clientID,
-		AfterSeq:  s.afterSeq,
-		Role:      s.role,
-	})
-	if err != nil {
-		return mapError(err)
-	}
-	for {
-		ev, err := stream.Recv()
-		if err == io.EOF {
+	p := s.client.policy("AttachSession")
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		opened, err := s.recv(ctx, callback)
+		var cbErr callbackError
+		if errors.As(err, &cbErr) {
+			return cbErr.err
+		}
+		if err == nil {
 			return nil
 		}
-		if err != nil {
+		if !p.retryable(err) || attempt >= p.MaxAttempts {
+			if !opened {
+				return mapError(err)
+			}
+			return err
+		}
+		if err := sleep(ctx, backoff); err != nil {
 			return err
 		}
+		backoff = min(backoff*2, p.MaxBackoff)
+	}
+}
+
+// callbackError carries an error returned by the RecvAll callback, which is
+// passed back unchanged rather than retried.
+type callbackError struct{ err error }
+
+func (e callbackError) Error() string { return e.err.Error() }
+
+// recv streams events to callback from one attach. It returns nil when the
+// stream ends, callback errors as callbackError, and stream errors as
+// received; opened reports whether the stream was opened at all.
+func (s *OutputStream) recv(ctx context.Context, callback func(*bridgev1.AttachSessionEvent) error) (opened bool, err error) {
+	stream, ev, cancel, err := s.open(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer cancel()
+	for ev != nil {
 		if ev.Seq > s.afterSeq {
 			s.afterSeq = ev.Seq
 			if s.client.cursors != nil {
@@ -65,9 +124,56 @@ func (s *OutputStream) RecvAll(ctx context.Context, callback func(*bridgev1.Atta
 			}
 		}
 		if err := callback(ev); err != nil {
-			return err
+			return true, callbackError{err}
+		}
+		ev, err = stream.Recv()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return true, err
 		}
 	}
+	return true, nil
+}
+
+// open attaches to the first candidate bridge that accepts the session.
+// Stream errors surface on Recv, so the first event is read before a bridge
+// is chosen; it is returned for delivery (nil if the stream ended at once).
+func (s *OutputStream) open(ctx context.Context) (grpc.ServerStreamingClient[bridgev1.AttachSessionEvent], *bridgev1.AttachSessionEvent, context.CancelFunc, error) {
+	req := &bridgev1.AttachSessionRequest{
+		SessionId:       s.session,
+		ClientId:        s.clientID,
+		AfterSeq:        s.afterSeq,
+		Role:            s.role,
+		EventTypes:      s.eventTypes,
+		Match:           s.match,
+		MaxBatchSize:    s.batchSize,
+		MaxBatchDelayMs: s.batchDelayMS,
+		ProtocolVersion: s.protocol,
+	}
+	var lastErr error
+	for _, b := range s.client.candidates(s.session) {
+		streamCtx, cancel := context.WithCancel(ctx)
+		stream, err := b.rpc.AttachSession(streamCtx, req, s.client.streamOpts...)
+		if err == nil {
+			var ev *bridgev1.AttachSessionEvent
+			ev, err = stream.Recv()
+			if err == nil || err == io.EOF {
+				s.client.affinity.set(s.session, b)
+				return stream, ev, cancel, nil
+			}
+		}
+		cancel()
+		lastErr = err
+		if !shouldFailover(err, true) {
+			break
+		}
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no bridge targets configured")
+	}
+	return nil, nil, nil, lastErr
 }
 
 func generateClientID() string {
@@ -1,6 +1,9 @@
 package bridgeclient_test
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
@@ -18,3 +21,22 @@ func ExampleNew() {
 		bridgeclient.WithCursorStore(bridgeclient.NewMemoryCursorStore()),
 	)
 }
+
+func ExampleRunPrompt() {
+	client, err := bridgeclient.New(bridgeclient.WithTarget("127.0.0.1:9445"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	res, err := bridgeclient.RunPrompt(context.Background(), client, bridgeclient.RunPromptOptions{
+		ProjectID: "dev",
+		Provider:  "claude-chat",
+		RepoPath:  "/repos/app",
+		Prompt:    "Summarize the README in one paragraph.",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s\n", res.Output)
+}
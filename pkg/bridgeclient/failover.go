@@ -0,0 +1,123 @@
+package bridgeclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+// backend is one bridge daemon the client can route RPCs to.
+type backend struct {
+	target string
+	conn   *grpc.ClientConn
+	rpc    bridgev1.BridgeServiceClient
+}
+
+// reachable reports whether the backend's connection is not known to be
+// failing. Idle and connecting backends count as reachable.
+func (b *backend) reachable() bool {
+	if b.conn == nil {
+		return true
+	}
+	switch b.conn.GetState() {
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		return false
+	default:
+		return true
+	}
+}
+
+// affinity tracks which backend owns each session the client has seen.
+type affinity struct {
+	mu     sync.Mutex
+	owners map[string]*backend
+}
+
+func (a *affinity) owner(sessionID string) *backend {
+	if sessionID == "" {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.owners[sessionID]
+}
+
+func (a *affinity) set(sessionID string, b *backend) {
+	if sessionID == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.owners == nil {
+		a.owners = make(map[string]*backend)
+	}
+	a.owners[sessionID] = b
+}
+
+// candidates returns the backends to try for an RPC, in order: the owner of
+// sessionID when known, then reachable backends in priority order, then
+// backends whose connection is currently failing.
+func (c *Client) candidates(sessionID string) []*backend {
+	owner := c.affinity.owner(sessionID)
+	out := make([]*backend, 0, len(c.backends))
+	if owner != nil {
+		out = append(out, owner)
+	}
+	var failing []*backend
+	for _, b := range c.backends {
+		switch {
+		case b == owner:
+		case b.reachable():
+			out = append(out, b)
+		default:
+			failing = append(failing, b)
+		}
+	}
+	return append(out, failing...)
+}
+
+// call runs fn under method's retry policy (see invoke), trying each
+// candidate backend in turn within an attempt. sessionID is empty for RPCs
+// not bound to a session.
+func (c *Client) call(ctx context.Context, method, sessionID string, fn func(context.Context, *backend) error) error {
+	return c.invoke(ctx, method, func(callCtx context.Context) error {
+		return c.failover(callCtx, sessionID, fn)
+	})
+}
+
+// failover runs fn against each candidate backend until one succeeds. It
+// moves on when a bridge is unavailable or, for session RPCs, does not know
+// the session (it may have been handed off). Any other error is returned
+// as is. The backend that succeeds becomes the session's owner.
+func (c *Client) failover(ctx context.Context, sessionID string, fn func(context.Context, *backend) error) error {
+	lastErr := errors.New("no bridge targets configured")
+	for _, b := range c.candidates(sessionID) {
+		err := fn(ctx, b)
+		if err == nil {
+			c.affinity.set(sessionID, b)
+			return nil
+		}
+		lastErr = err
+		if !shouldFailover(err, sessionID != "") {
+			return err
+		}
+	}
+	return lastErr
+}
+
+func shouldFailover(err error, sessionScoped bool) bool {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return true
+	case codes.NotFound:
+		return sessionScoped
+	default:
+		return false
+	}
+}
@@ -0,0 +1,93 @@
+package bridgeclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newFailoverClient(fakes ...*fakeRPCClient) *Client {
+	c := &Client{retry: RetryConfig{MaxAttempts: 1}, timeout: time.Second}
+	for _, f := range fakes {
+		c.backends = append(c.backends, &backend{rpc: f})
+	}
+	return c
+}
+
+func TestStartSessionFailsOverAndTracksAffinity(t *testing.T) {
+	primary := &fakeRPCClient{err: status.Error(codes.Unavailable, "connection refused")}
+	secondary := &fakeRPCClient{
+		startResp: &bridgev1.StartSessionResponse{SessionId: "session-a"},
+		getResp:   &bridgev1.GetSessionResponse{SessionId: "session-a", Provider: "secondary"},
+	}
+	c := newFailoverClient(primary, secondary)
+
+	resp, err := c.StartSession(context.Background(), &bridgev1.StartSessionRequest{SessionId: "session-a"})
+	if err != nil || resp.GetSessionId() != "session-a" {
+		t.Fatalf("StartSession resp=%+v err=%v", resp, err)
+	}
+
+	// The primary recovers, but the session lives on the secondary.
+	primary.err = nil
+	primary.getResp = &bridgev1.GetSessionResponse{SessionId: "session-a", Provider: "primary"}
+	got, err := c.GetSession(context.Background(), &bridgev1.GetSessionRequest{SessionId: "session-a"})
+	if err != nil || got.GetProvider() != "secondary" {
+		t.Fatalf("GetSession routed to %q err=%v, want secondary", got.GetProvider(), err)
+	}
+}
+
+func TestSessionRPCFailsOverOnNotFound(t *testing.T) {
+	primary := &fakeRPCClient{err: status.Error(codes.NotFound, "session not found")}
+	secondary := &fakeRPCClient{getResp: &bridgev1.GetSessionResponse{SessionId: "handed-off"}}
+	c := newFailoverClient(primary, secondary)
+
+	got, err := c.GetSession(context.Background(), &bridgev1.GetSessionRequest{SessionId: "handed-off"})
+	if err != nil || got.GetSessionId() != "handed-off" {
+		t.Fatalf("GetSession resp=%+v err=%v", got, err)
+	}
+	if owner := c.affinity.owner("handed-off"); owner != c.backends[1] {
+		t.Fatal("session affinity not recorded for the answering bridge")
+	}
+
+	// NotFound is final for RPCs without a session.
+	primary.err = status.Error(codes.NotFound, "no such provider")
+	secondary.providersResp = &bridgev1.ListProvidersResponse{}
+	if _, err := c.ListProviders(context.Background()); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("ListProviders err=%v want NotFound from primary", err)
+	}
+}
+
+func TestFailoverStopsOnNonTransportError(t *testing.T) {
+	primary := &fakeRPCClient{err: status.Error(codes.PermissionDenied, "denied")}
+	secondary := &fakeRPCClient{startResp: &bridgev1.StartSessionResponse{SessionId: "session-a"}}
+	c := newFailoverClient(primary, secondary)
+
+	if _, err := c.StartSession(context.Background(), &bridgev1.StartSessionRequest{SessionId: "session-a"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("StartSession err=%v want %v", err, ErrPermissionDenied)
+	}
+}
+
+func TestListSessionsMergesBridges(t *testing.T) {
+	a := &fakeRPCClient{listResp: &bridgev1.ListSessionsResponse{Sessions: []*bridgev1.GetSessionResponse{{SessionId: "on-a"}}}}
+	b := &fakeRPCClient{listResp: &bridgev1.ListSessionsResponse{Sessions: []*bridgev1.GetSessionResponse{{SessionId: "on-b"}}}}
+	down := &fakeRPCClient{err: status.Error(codes.Unavailable, "down")}
+	c := newFailoverClient(a, down, b)
+
+	resp, err := c.ListSessions(context.Background(), &bridgev1.ListSessionsRequest{})
+	if err != nil || len(resp.GetSessions()) != 2 {
+		t.Fatalf("ListSessions resp=%+v err=%v", resp, err)
+	}
+	if c.affinity.owner("on-b") != c.backends[2] {
+		t.Fatal("ListSessions did not record affinity for on-b")
+	}
+
+	all := newFailoverClient(down)
+	if _, err := all.ListSessions(context.Background(), &bridgev1.ListSessionsRequest{}); err == nil {
+		t.Fatal("ListSessions with no reachable bridge should fail")
+	}
+}
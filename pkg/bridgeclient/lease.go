@@ -0,0 +1,140 @@
+package bridgeclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// LeaseStore coordinates replicas that consume the same subscriber, so that
+// only one of them attaches at a time. A lease expires unless its holder
+// renews it within ttl, which lets another replica take over when the holder
+// dies.
+type LeaseStore interface {
+	// AcquireLease takes the lease on key for holder, or renews it when
+	// holder already has it, and reports whether holder now holds it.
+	AcquireLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error)
+	// ReleaseLease gives up holder's lease on key. It does nothing when
+	// another holder has the lease.
+	ReleaseLease(ctx context.Context, key, holder string) error
+}
+
+// ErrLeaseLost is the cause of the context passed to ConsumeExclusive's
+// callback when the lease could not be renewed.
+var ErrLeaseLost = errors.New("subscriber lease lost")
+
+// ConsumeExclusive runs consume while holder holds the lease on the
+// session/subscriber pair, waiting for it first if another replica has it.
+// The lease is renewed every ttl/3; if a renewal fails, consume's context
+// is cancelled with ErrLeaseLost and ConsumeExclusive waits for the lease
+// again and reruns consume. It returns consume's result otherwise, releasing
+// the lease first.
+//
+// Pair it with a shared CursorStore and a ClientId equal to subscriberID so
+// that the replica taking over resumes where the previous one stopped.
+func ConsumeExclusive(ctx context.Context, leases LeaseStore, sessionID, subscriberID, holder string, ttl time.Duration, consume func(context.Context) error) error {
+	key := cursorKey(sessionID, subscriberID)
+	for {
+		if err := waitLease(ctx, leases, key, holder, ttl); err != nil {
+			return err
+		}
+		err := holdLease(ctx, leases, key, holder, ttl, consume)
+		if !errors.Is(err, ErrLeaseLost) {
+			return err
+		}
+	}
+}
+
+// waitLease polls until holder acquires the lease on key.
+func waitLease(ctx context.Context, leases LeaseStore, key, holder string, ttl time.Duration) error {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		ok, err := leases.AcquireLease(ctx, key, holder, ttl)
+		if err == nil && ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// holdLease runs consume while renewing the lease, returning ErrLeaseLost
+// when a renewal fails before consume returns.
+func holdLease(ctx context.Context, leases LeaseStore, key, holder string, ttl time.Duration, consume func(context.Context) error) error {
+	leaseCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-leaseCtx.Done():
+				return
+			case <-ticker.C:
+				if ok, err := leases.AcquireLease(leaseCtx, key, holder, ttl); err != nil || !ok {
+					cancel(ErrLeaseLost)
+					return
+				}
+			}
+		}
+	}()
+	err := consume(leaseCtx)
+	lost := errors.Is(context.Cause(leaseCtx), ErrLeaseLost)
+	cancel(nil)
+	wg.Wait()
+	if lost {
+		return ErrLeaseLost
+	}
+	releaseCtx, done := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer done()
+	_ = leases.ReleaseLease(releaseCtx, key, holder)
+	return err
+}
+
+// MemoryLeaseStore keeps leases in memory, coordinating consumers within
+// one process.
+type MemoryLeaseStore struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease
+	now    func() time.Time
+}
+
+type memoryLease struct {
+	holder  string
+	expires time.Time
+}
+
+// NewMemoryLeaseStore creates an in-memory lease store.
+func NewMemoryLeaseStore() *MemoryLeaseStore {
+	return &MemoryLeaseStore{leases: make(map[string]memoryLease), now: time.Now}
+}
+
+func (s *MemoryLeaseStore) AcquireLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	_ = ctx
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if l, ok := s.leases[key]; ok && l.holder != holder && now.Before(l.expires) {
+		return false, nil
+	}
+	s.leases[key] = memoryLease{holder: holder, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryLeaseStore) ReleaseLease(ctx context.Context, key, holder string) error {
+	_ = ctx
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.leases[key]; ok && l.holder == holder {
+		delete(s.leases, key)
+	}
+	return nil
+}
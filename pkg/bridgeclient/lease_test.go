@@ -0,0 +1,102 @@
+package bridgeclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryLeaseStore(t *testing.T) {
+	store := NewMemoryLeaseStore()
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if ok, _ := store.AcquireLease(ctx, "k", "a", time.Second); !ok {
+		t.Fatal("a could not acquire a free lease")
+	}
+	if ok, _ := store.AcquireLease(ctx, "k", "b", time.Second); ok {
+		t.Fatal("b acquired a lease a holds")
+	}
+	now = now.Add(2 * time.Second)
+	if ok, _ := store.AcquireLease(ctx, "k", "b", time.Second); !ok {
+		t.Fatal("b could not acquire an expired lease")
+	}
+	_ = store.ReleaseLease(ctx, "k", "a")
+	if ok, _ := store.AcquireLease(ctx, "k", "a", time.Second); ok {
+		t.Fatal("a's release freed b's lease")
+	}
+}
+
+func TestConsumeExclusive(t *testing.T) {
+	store := NewMemoryLeaseStore()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	const ttl = 30 * time.Millisecond
+
+	// a holds the lease until told to stop; b waits for it meanwhile.
+	aRunning := make(chan struct{})
+	aStop := make(chan struct{})
+	aDone := make(chan error, 1)
+	go func() {
+		aDone <- ConsumeExclusive(ctx, store, "s", "sub", "a", ttl, func(context.Context) error {
+			close(aRunning)
+			<-aStop
+			return nil
+		})
+	}()
+	<-aRunning
+
+	bRunning := make(chan struct{})
+	bDone := make(chan error, 1)
+	go func() {
+		bDone <- ConsumeExclusive(ctx, store, "s", "sub", "b", ttl, func(context.Context) error {
+			close(bRunning)
+			return errors.New("b done")
+		})
+	}()
+	select {
+	case <-bRunning:
+		t.Fatal("b consumed while a held the lease")
+	case <-time.After(3 * ttl):
+	}
+
+	close(aStop)
+	if err := <-aDone; err != nil {
+		t.Fatalf("a ConsumeExclusive: %v", err)
+	}
+	if err := <-bDone; err == nil || err.Error() != "b done" {
+		t.Fatalf("b ConsumeExclusive = %v, want b done", err)
+	}
+}
+
+func TestConsumeExclusiveLeaseLost(t *testing.T) {
+	store := NewMemoryLeaseStore()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	const ttl = 30 * time.Millisecond
+
+	runs := 0
+	err := ConsumeExclusive(ctx, store, "s", "sub", "a", ttl, func(ctx context.Context) error {
+		runs++
+		if runs == 1 {
+			// Another replica steals the lease; a must stop and wait.
+			store.mu.Lock()
+			store.leases["s:sub"] = memoryLease{holder: "b", expires: time.Now().Add(ttl)}
+			store.mu.Unlock()
+			<-ctx.Done()
+			if !errors.Is(context.Cause(ctx), ErrLeaseLost) {
+				t.Errorf("cause = %v, want ErrLeaseLost", context.Cause(ctx))
+			}
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ConsumeExclusive: %v", err)
+	}
+	if runs != 2 {
+		t.Fatalf("consume ran %d times, want 2", runs)
+	}
+}
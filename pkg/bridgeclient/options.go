@@ -1,21 +1,40 @@
 package bridgeclient
 
-import "time"
+import (
+	"time"
+
+	"google.golang.org/grpc"
+)
 
 // MTLSConfig holds paths for mTLS client credentials.
 type MTLSConfig struct {
 	CABundlePath string // Trust bundle (own CA + cross-signed CAs)
 	CertPath     string // Client certificate
-	KeyPath      string // Client private key
+	KeyPath      string // Client private key, or a PKCS#11 URI
 	ServerName   string // Expected server name for verification
+	// SystemRoots also trusts the system root CAs for the server
+	// certificate, for bridges that use an ACME certificate.
+	SystemRoots bool
+	// KeyPassphrase returns the passphrase of an encrypted KeyPath. Nil
+	// reads BRIDGE_KEY_PASSPHRASE or BRIDGE_KEY_PASSPHRASE_FILE.
+	KeyPassphrase func(keyPath string) ([]byte, error)
 }
 
 // JWTConfig holds configuration for automatic JWT minting.
 type JWTConfig struct {
-	PrivateKeyPath string // Ed25519 private key for signing
+	PrivateKeyPath string // Ed25519 private key for signing, or a PKCS#11 URI
 	Issuer         string // JWT issuer claim
 	Audience       string // JWT audience claim
 	TTL            time.Duration
+	// Scopes limits what minted tokens may do, e.g. []string{"events:read"}
+	// for a read-only dashboard. Empty mints unrestricted tokens.
+	Scopes []string
+	// KeyID is the kid header of minted tokens. Empty uses the key's JWK
+	// thumbprint, which the bridge matches without further configuration.
+	KeyID string
+	// KeyPassphrase returns the passphrase of an encrypted PrivateKeyPath,
+	// as MTLSConfig.KeyPassphrase does.
+	KeyPassphrase func(keyPath string) ([]byte, error)
 }
 
 // Option configures a Client.
@@ -30,11 +49,17 @@ type RetryConfig struct {
 
 type clientConfig struct {
 	target      string
+	targets     []string
 	mtls        *MTLSConfig
 	jwt         *JWTConfig
+	staticToken string
+	tokenFile   string
 	timeout     time.Duration
 	retry       RetryConfig
+	policies    map[string]RetryPolicy
 	cursorStore CursorStore
+	compression string
+	dialOpts    []grpc.DialOption
 }
 
 // WithTarget sets the bridge daemon address (host:port).
@@ -42,6 +67,28 @@ func WithTarget(addr string) Option {
 	return func(c *clientConfig) { c.target = addr }
 }
 
+// WithTargets adds bridge daemon addresses in priority order. StartSession
+// and other RPCs not bound to a session go to the first reachable bridge;
+// session RPCs go to the bridge that owns the session, falling back to the
+// others if it is unavailable. A WithTarget address is tried first.
+func WithTargets(addrs ...string) Option {
+	return func(c *clientConfig) { c.targets = append(c.targets, addrs...) }
+}
+
+// allTargets returns the configured addresses without blanks or duplicates.
+func (c *clientConfig) allTargets() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, t := range append([]string{c.target}, c.targets...) {
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
 // WithMTLS configures mTLS credentials for the connection.
 func WithMTLS(cfg MTLSConfig) Option {
 	return func(c *clientConfig) { c.mtls = &cfg }
@@ -52,6 +99,22 @@ func WithJWT(cfg JWTConfig) Option {
 	return func(c *clientConfig) { c.jwt = &cfg }
 }
 
+// WithStaticToken authenticates every RPC with a bearer token obtained
+// elsewhere, e.g. from `ai-agent-bridge-ca jwt-mint` or a token service, for
+// clients that do not hold a signing key. The token is not renewed; build a
+// new Client once it expires. It cannot be combined with WithJWT.
+func WithStaticToken(token string) Option {
+	return func(c *clientConfig) { c.staticToken = token }
+}
+
+// WithTokenFile authenticates every RPC with the bearer token in path,
+// re-reading the file on each call so that a token rotated in place is
+// picked up, e.g. a Kubernetes projected service account token. It cannot
+// be combined with WithJWT or WithStaticToken.
+func WithTokenFile(path string) Option {
+	return func(c *clientConfig) { c.tokenFile = path }
+}
+
 // WithTimeout sets the default per-call timeout.
 func WithTimeout(d time.Duration) Option {
 	return func(c *clientConfig) { c.timeout = d }
@@ -62,7 +125,41 @@ func WithRetry(cfg RetryConfig) Option {
 	return func(c *clientConfig) { c.retry = cfg }
 }
 
+// WithMethodPolicy sets the retry policy of one RPC, named as in
+// BridgeService (e.g. "StartSession"), replacing any built-in policy for it.
+// Two methods are handled specially:
+//
+//   - StartSession: a retry that finds the session already exists means an
+//     earlier attempt started it, so the session is returned as started.
+//   - AttachSession: policies only reconnect. A stream that breaks with a
+//     retryable code is reopened from the last delivered seq; the callback
+//     then receives a new ATTACHED event.
+//
+// WriteInput is not retried unless a policy is set for it.
+func WithMethodPolicy(method string, p RetryPolicy) Option {
+	return func(c *clientConfig) {
+		if c.policies == nil {
+			c.policies = make(map[string]RetryPolicy)
+		}
+		c.policies[method] = p
+	}
+}
+
 // WithCursorStore sets persistent storage for stream cursor checkpoints.
 func WithCursorStore(store CursorStore) Option {
 	return func(c *clientConfig) { c.cursorStore = store }
 }
+
+// WithCompression compresses AttachSession and AttachTerminal streams with
+// the named gRPC compressor; the bridge then compresses the events it sends
+// on them. "gzip" is registered by this package. Unary RPCs are not
+// compressed.
+func WithCompression(name string) Option {
+	return func(c *clientConfig) { c.compression = name }
+}
+
+// WithDialOptions adds gRPC dial options, applied after the client's own,
+// e.g. grpc.WithContextDialer to connect over an in-memory listener.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(c *clientConfig) { c.dialOpts = append(c.dialOpts, opts...) }
+}
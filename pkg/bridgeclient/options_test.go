@@ -1,9 +1,15 @@
 package bridgeclient
 
 import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 )
 
@@ -23,6 +29,30 @@ func TestNew_WithTarget(t *testing.T) {
 	_ = c.Close()
 }
 
+func TestNew_WithTargets(t *testing.T) {
+	c, err := New(
+		WithTarget("bridge-a:9445"),
+		WithTargets("bridge-b:9445", "bridge-a:9445", "", "bridge-c:9445"),
+	)
+	if err != nil {
+		t.Fatalf("New with targets: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+	var got []string
+	for _, b := range c.backends {
+		got = append(got, b.target)
+	}
+	want := []string{"bridge-a:9445", "bridge-b:9445", "bridge-c:9445"}
+	if len(got) != len(want) {
+		t.Fatalf("targets=%v want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("targets=%v want %v", got, want)
+		}
+	}
+}
+
 func TestNew_WithTimeout(t *testing.T) {
 	c, err := New(
 		WithTarget("localhost:19999"),
@@ -109,6 +139,69 @@ func TestNew_WithCursorStoreNil(t *testing.T) {
 	_ = c.Close()
 }
 
+func TestNew_WithCompression(t *testing.T) {
+	c, err := New(WithTarget("localhost:19999"), WithCompression("gzip"))
+	if err != nil {
+		t.Fatalf("New with gzip: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+	if len(c.streamOpts) != 1 {
+		t.Fatalf("streamOpts=%v want one compressor option", c.streamOpts)
+	}
+
+	if _, err := New(WithTarget("localhost:19999"), WithCompression("brotli")); err == nil {
+		t.Fatal("expected error for an unregistered compressor")
+	}
+}
+
+func TestNew_WithStaticToken(t *testing.T) {
+	c, err := New(WithTarget("localhost:19999"), WithStaticToken("tok"))
+	if err != nil {
+		t.Fatalf("New with static token: %v", err)
+	}
+	_ = c.Close()
+
+	md, err := staticTokenCredentials("tok").GetRequestMetadata(context.Background())
+	if err != nil || md["authorization"] != "Bearer tok" {
+		t.Fatalf("metadata=%v err=%v want Bearer tok", md, err)
+	}
+
+	if _, err := New(WithTarget("localhost:19999"), WithStaticToken("tok"), WithJWT(JWTConfig{})); err == nil {
+		t.Fatal("expected error combining WithStaticToken and WithJWT")
+	}
+}
+
+func TestNew_WithTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	c, err := New(WithTarget("localhost:19999"), WithTokenFile(path))
+	if err != nil {
+		t.Fatalf("New with token file: %v", err)
+	}
+	_ = c.Close()
+
+	creds := tokenFileCredentials(path)
+	md, err := creds.GetRequestMetadata(context.Background())
+	if err != nil || md["authorization"] != "Bearer first" {
+		t.Fatalf("metadata=%v err=%v want Bearer first", md, err)
+	}
+	if err := os.WriteFile(path, []byte("second"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if md, err = creds.GetRequestMetadata(context.Background()); err != nil || md["authorization"] != "Bearer second" {
+		t.Fatalf("metadata=%v err=%v want the rotated token", md, err)
+	}
+	if _, err := tokenFileCredentials(filepath.Join(t.TempDir(), "missing")).GetRequestMetadata(context.Background()); err == nil {
+		t.Fatal("expected error for a missing token file")
+	}
+
+	if _, err := New(WithTarget("localhost:19999"), WithTokenFile(path), WithStaticToken("tok")); err == nil {
+		t.Fatal("expected error combining WithTokenFile and WithStaticToken")
+	}
+}
+
 func TestSetProject_NilJWT(t *testing.T) {
 	c, err := New(WithTarget("localhost:19999"))
 	if err != nil {
@@ -150,3 +243,31 @@ func TestShouldRetry(t *testing.T) {
 		}
 	}
 }
+
+func TestNew_WithDialOptions(t *testing.T) {
+	dialed := make(chan string, 1)
+	c, err := New(
+		WithTarget("passthrough:///bridge-under-test"),
+		WithTimeout(time.Second),
+		WithDialOptions(grpc.WithContextDialer(func(_ context.Context, addr string) (net.Conn, error) {
+			select {
+			case dialed <- addr:
+			default:
+			}
+			return nil, errors.New("no network in this test")
+		})),
+	)
+	if err != nil {
+		t.Fatalf("New with dial options: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+	_, _ = c.Health(context.Background())
+	select {
+	case addr := <-dialed:
+		if addr != "bridge-under-test" {
+			t.Fatalf("dialed %q", addr)
+		}
+	default:
+		t.Fatal("custom dialer was not used")
+	}
+}
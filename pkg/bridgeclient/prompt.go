@@ -0,0 +1,159 @@
+package bridgeclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+)
+
+// RunPromptOptions configures RunPrompt.
+type RunPromptOptions struct {
+	ProjectID string
+	// SessionID names the session; a random UUID is used when empty.
+	SessionID string
+	Provider  string
+	RepoPath  string
+	Prompt    string
+	// Idle ends the response once the agent has been silent this long, for
+	// PTY providers that never send RESPONSE_COMPLETE. Zero waits for
+	// RESPONSE_COMPLETE or the agent exiting.
+	Idle time.Duration
+	// Priority is the prompt's rate-limit lane. Scripts and orchestrators
+	// should use INPUT_PRIORITY_BATCH.
+	Priority bridgev1.InputPriority
+	// Attachments are files sent with the prompt; see InputAttachment.
+	Attachments []*bridgev1.InputAttachment
+}
+
+// PromptResult is the outcome of RunPrompt.
+type PromptResult struct {
+	SessionID string
+	// Output is the agent's OUTPUT payloads, concatenated. PTY output still
+	// carries terminal escape sequences.
+	Output []byte
+	// Thinking is the text of any THINKING events.
+	Thinking string
+	// Exited is set when the agent exited before completing its response,
+	// with its code in ExitCode.
+	Exited   bool
+	ExitCode int32
+}
+
+// errPromptDone ends the event stream once the response is complete.
+var errPromptDone = errors.New("prompt complete")
+
+// RunPrompt runs one prompt in a fresh session: it starts the session,
+// sends the prompt once attached, collects output until the response
+// completes, and stops the session, also when ctx ends or a step fails.
+// The output collected so far is returned with any error.
+func RunPrompt(ctx context.Context, c *Client, opts RunPromptOptions) (*PromptResult, error) {
+	if opts.Prompt == "" {
+		return nil, errors.New("prompt is required")
+	}
+	res := &PromptResult{SessionID: opts.SessionID}
+	if res.SessionID == "" {
+		res.SessionID = generateClientID()
+	}
+	if _, err := c.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId:   opts.ProjectID,
+		SessionId:   res.SessionID,
+		RepoPath:    opts.RepoPath,
+		Provider:    opts.Provider,
+		InitialCols: 200,
+		InitialRows: 50,
+	}); err != nil {
+		return nil, fmt.Errorf("start session: %w", err)
+	}
+	defer func() {
+		// Stop with a fresh context so a cancelled ctx still cleans up.
+		stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		_, _ = c.StopSession(stopCtx, &bridgev1.StopSessionRequest{SessionId: res.SessionID, Force: true})
+	}()
+
+	stream, err := c.AttachSession(ctx, &bridgev1.AttachSessionRequest{SessionId: res.SessionID})
+	if err != nil {
+		return nil, fmt.Errorf("attach session: %w", err)
+	}
+
+	streamCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	errIdle := errors.New("agent idle")
+	var idle *time.Timer
+	resetIdle := func() {
+		if opts.Idle <= 0 {
+			return
+		}
+		if idle == nil {
+			idle = time.AfterFunc(opts.Idle, func() { cancel(errIdle) })
+			return
+		}
+		idle.Reset(opts.Idle)
+	}
+	defer func() {
+		if idle != nil {
+			idle.Stop()
+		}
+	}()
+
+	var out bytes.Buffer
+	sent := false
+	router := &EventRouter{
+		OnAttached: func(*bridgev1.AttachSessionEvent) error {
+			if sent {
+				return nil
+			}
+			sent = true
+			if _, err := c.WriteInput(streamCtx, &bridgev1.WriteInputRequest{
+				SessionId:   res.SessionID,
+				ClientId:    stream.ClientID(),
+				Data:        []byte(opts.Prompt + "\r"),
+				Priority:    opts.Priority,
+				Attachments: opts.Attachments,
+			}); err != nil {
+				return fmt.Errorf("send prompt: %w", err)
+			}
+			resetIdle()
+			return nil
+		},
+		OnStdout: func(payload []byte) error {
+			out.Write(payload)
+			resetIdle()
+			return nil
+		},
+		OnThinking: func(text string) error {
+			res.Thinking += text
+			resetIdle()
+			return nil
+		},
+		OnComplete: func(string) error {
+			if sent {
+				return errPromptDone
+			}
+			return nil
+		},
+		OnTerminal: func(ev *bridgev1.AttachSessionEvent) error {
+			if ev.Type == bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ERROR {
+				return fmt.Errorf("session error: %s", ev.Error)
+			}
+			res.Exited, res.ExitCode = true, ev.ExitCode
+			return errPromptDone
+		},
+	}
+	err = stream.RecvAll(streamCtx, router.Handle)
+	res.Output = out.Bytes()
+	switch {
+	case errors.Is(err, errPromptDone):
+		return res, nil
+	case errors.Is(context.Cause(streamCtx), errIdle):
+		return res, nil
+	case err == nil:
+		return res, errors.New("event stream ended before the agent answered")
+	default:
+		return res, err
+	}
+}
@@ -0,0 +1,100 @@
+package bridgeclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+)
+
+func TestRunPrompt(t *testing.T) {
+	event := func(typ bridgev1.AttachEventType, seq uint64, payload string) *bridgev1.AttachSessionEvent {
+		return &bridgev1.AttachSessionEvent{Type: typ, Seq: seq, Payload: []byte(payload)}
+	}
+	cases := []struct {
+		name    string
+		events  []*bridgev1.AttachSessionEvent
+		idle    time.Duration
+		want    string
+		exited  bool
+		wantErr bool
+	}{
+		{
+			name: "response complete",
+			events: []*bridgev1.AttachSessionEvent{
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED, 0, ""),
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, 1, "hello "),
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, 2, "world"),
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE, 3, ""),
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, 4, "ignored"),
+			},
+			want: "hello world",
+		},
+		{
+			name: "agent exits",
+			events: []*bridgev1.AttachSessionEvent{
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED, 0, ""),
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, 1, "bye"),
+				{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_EXIT, ExitCode: 3},
+			},
+			want:   "bye",
+			exited: true,
+		},
+		{
+			name: "idle",
+			events: []*bridgev1.AttachSessionEvent{
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED, 0, ""),
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, 1, "quiet"),
+			},
+			idle: 20 * time.Millisecond,
+			want: "quiet",
+		},
+		{
+			name: "stream ends",
+			events: []*bridgev1.AttachSessionEvent{
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED, 0, ""),
+				event(bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, 1, "partial"),
+				nil,
+			},
+			want:    "partial",
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeRPCClient{
+				startResp:    &bridgev1.StartSessionResponse{},
+				stopResp:     &bridgev1.StopSessionResponse{},
+				writeResp:    &bridgev1.WriteInputResponse{Accepted: true},
+				attachEvents: tc.events,
+			}
+			c := newFailoverClient(fake)
+			res, err := RunPrompt(context.Background(), c, RunPromptOptions{Provider: "fake", RepoPath: "/repo", Prompt: "hi", Idle: tc.idle})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("RunPrompt err=%v wantErr=%v", err, tc.wantErr)
+			}
+			if string(res.Output) != tc.want || res.Exited != tc.exited {
+				t.Fatalf("result = %q exited=%v, want %q exited=%v", res.Output, res.Exited, tc.want, tc.exited)
+			}
+			if tc.exited && res.ExitCode != 3 {
+				t.Fatalf("ExitCode=%d want 3", res.ExitCode)
+			}
+			if len(fake.writes) != 1 || string(fake.writes[0].Data) != "hi\r" || fake.writes[0].SessionId != res.SessionID {
+				t.Fatalf("writes = %+v, want the prompt once", fake.writes)
+			}
+			if len(fake.stops) != 1 || fake.stops[0].SessionId != res.SessionID {
+				t.Fatalf("stops = %+v, want the session stopped", fake.stops)
+			}
+		})
+	}
+
+	if _, err := RunPrompt(context.Background(), newFailoverClient(&fakeRPCClient{}), RunPromptOptions{}); err == nil {
+		t.Fatal("empty prompt accepted")
+	}
+	failing := &fakeRPCClient{err: errors.New("boom")}
+	if _, err := RunPrompt(context.Background(), newFailoverClient(failing), RunPromptOptions{Prompt: "hi"}); err == nil {
+		t.Fatal("start failure not returned")
+	}
+}
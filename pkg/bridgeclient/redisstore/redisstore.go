@@ -0,0 +1,105 @@
+// Package redisstore provides a Redis-backed cursor and lease store for
+// bridgeclient, for consumers scaled across several replicas.
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultPrefix is prepended to every key when New is given no prefix.
+const DefaultPrefix = "bridge:"
+
+// saveCursor only moves a cursor forward, so a replica that lost its lease
+// cannot rewind the cursor its successor is advancing.
+var saveCursor = redis.NewScript(`
+local cur = tonumber(redis.call("GET", KEYS[1]) or "0")
+if tonumber(ARGV[1]) > cur then
+	redis.call("SET", KEYS[1], ARGV[1])
+end
+return 0`)
+
+// acquireLease takes a free or expired lease, or renews one already held.
+var acquireLease = redis.NewScript(`
+local cur = redis.call("GET", KEYS[1])
+if cur == false or cur == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+return 0`)
+
+// releaseLease deletes a lease only if it is still the caller's.
+var releaseLease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Store implements bridgeclient.CursorStore and bridgeclient.LeaseStore on
+// Redis. Cursors are stored under <prefix>cursor:<session>:<subscriber> and
+// leases under <prefix>lease:<session>:<subscriber>.
+type Store struct {
+	rdb    redis.UniversalClient
+	prefix string
+}
+
+var (
+	_ bridgeclient.CursorStore = (*Store)(nil)
+	_ bridgeclient.LeaseStore  = (*Store)(nil)
+)
+
+// New returns a store using rdb, which may be a single-node, sentinel or
+// cluster client. prefix namespaces the keys; DefaultPrefix is used when
+// it is empty.
+func New(rdb redis.UniversalClient, prefix string) *Store {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Store{rdb: rdb, prefix: prefix}
+}
+
+func (s *Store) LoadCursor(ctx context.Context, sessionID, subscriberID string) (uint64, error) {
+	v, err := s.rdb.Get(ctx, s.prefix+"cursor:"+sessionID+":"+subscriberID).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("load cursor: %w", err)
+	}
+	seq, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse cursor: %w", err)
+	}
+	return seq, nil
+}
+
+func (s *Store) SaveCursor(ctx context.Context, sessionID, subscriberID string, seq uint64) error {
+	key := s.prefix + "cursor:" + sessionID + ":" + subscriberID
+	if err := saveCursor.Run(ctx, s.rdb, []string{key}, seq).Err(); err != nil {
+		return fmt.Errorf("save cursor: %w", err)
+	}
+	return nil
+}
+
+// AcquireLease implements bridgeclient.LeaseStore. key is the
+// session/subscriber pair ConsumeExclusive coordinates on.
+func (s *Store) AcquireLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	n, err := acquireLease.Run(ctx, s.rdb, []string{s.prefix + "lease:" + key}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("acquire lease: %w", err)
+	}
+	return n == 1, nil
+}
+
+func (s *Store) ReleaseLease(ctx context.Context, key, holder string) error {
+	if err := releaseLease.Run(ctx, s.rdb, []string{s.prefix + "lease:" + key}, holder).Err(); err != nil {
+		return fmt.Errorf("release lease: %w", err)
+	}
+	return nil
+}
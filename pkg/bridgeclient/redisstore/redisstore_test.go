@@ -0,0 +1,76 @@
+package redisstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return New(rdb, ""), mr
+}
+
+func TestStoreCursor(t *testing.T) {
+	store, mr := newTestStore(t)
+	ctx := context.Background()
+
+	got, err := store.LoadCursor(ctx, "s1", "sub1")
+	if err != nil || got != 0 {
+		t.Fatalf("LoadCursor empty = %d, %v; want 0", got, err)
+	}
+	if err := store.SaveCursor(ctx, "s1", "sub1", 42); err != nil {
+		t.Fatalf("SaveCursor: %v", err)
+	}
+	// A stale replica cannot move the cursor back.
+	if err := store.SaveCursor(ctx, "s1", "sub1", 7); err != nil {
+		t.Fatalf("SaveCursor: %v", err)
+	}
+	got, err = store.LoadCursor(ctx, "s1", "sub1")
+	if err != nil || got != 42 {
+		t.Fatalf("LoadCursor = %d, %v; want 42", got, err)
+	}
+	if v, _ := mr.Get("bridge:cursor:s1:sub1"); v != "42" {
+		t.Fatalf("stored cursor = %q, want 42", v)
+	}
+}
+
+func TestStoreLease(t *testing.T) {
+	store, mr := newTestStore(t)
+	ctx := context.Background()
+	const key = "s1:sub1"
+
+	if ok, err := store.AcquireLease(ctx, key, "a", time.Second); err != nil || !ok {
+		t.Fatalf("a AcquireLease = %v, %v; want true", ok, err)
+	}
+	if ok, err := store.AcquireLease(ctx, key, "b", time.Second); err != nil || ok {
+		t.Fatalf("b AcquireLease while a holds = %v, %v; want false", ok, err)
+	}
+	if ok, err := store.AcquireLease(ctx, key, "a", time.Second); err != nil || !ok {
+		t.Fatalf("a renew = %v, %v; want true", ok, err)
+	}
+	// b releasing does not free a's lease.
+	if err := store.ReleaseLease(ctx, key, "b"); err != nil {
+		t.Fatalf("b ReleaseLease: %v", err)
+	}
+	if !mr.Exists("bridge:lease:" + key) {
+		t.Fatal("lease deleted by a replica not holding it")
+	}
+	// Once a's lease expires, b takes over.
+	mr.FastForward(2 * time.Second)
+	if ok, err := store.AcquireLease(ctx, key, "b", time.Second); err != nil || !ok {
+		t.Fatalf("b AcquireLease after expiry = %v, %v; want true", ok, err)
+	}
+	if err := store.ReleaseLease(ctx, key, "b"); err != nil {
+		t.Fatalf("b ReleaseLease: %v", err)
+	}
+	if ok, err := store.AcquireLease(ctx, key, "a", time.Second); err != nil || !ok {
+		t.Fatalf("a AcquireLease after release = %v, %v; want true", ok, err)
+	}
+}
@@ -2,41 +2,122 @@ package bridgeclient
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-func (c *Client) invoke(ctx context.Context, fn func(context.Context) error) error {
-	backoff := c.retry.InitialBackoff
+// RetryPolicy is the retry behaviour of one RPC, set with WithMethodPolicy.
+// Zero fields inherit from the client's RetryConfig and WithTimeout.
+type RetryPolicy struct {
+	// MaxAttempts includes the first call; 1 disables retries.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Timeout is the deadline of each attempt. It does not apply to
+	// AttachSession, whose stream stays open.
+	Timeout time.Duration
+	// RetryCodes are the status codes retried. Empty retries Unavailable
+	// and DeadlineExceeded, and ResourceExhausted when the server says
+	// when to retry.
+	RetryCodes []codes.Code
+}
+
+// defaultPolicies are the built-in per-method policies, applied unless
+// WithMethodPolicy replaces them. WriteInput, SendInputStream and
+// BroadcastInput are not retried: if the response is lost, a retry would
+// type the input a second time.
+var defaultPolicies = map[string]RetryPolicy{
+	"WriteInput":      {MaxAttempts: 1},
+	"SendInputStream": {MaxAttempts: 1},
+	"BroadcastInput":  {MaxAttempts: 1},
+}
+
+// policy resolves the retry policy of method.
+func (c *Client) policy(method string) RetryPolicy {
+	p, ok := c.policies[method]
+	if !ok {
+		p = defaultPolicies[method]
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = c.retry.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = c.retry.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = c.retry.MaxBackoff
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = c.timeout
+	}
+	return p
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if len(p.RetryCodes) == 0 {
+		return shouldRetry(err)
+	}
+	st, ok := status.FromError(err)
+	return ok && slices.Contains(p.RetryCodes, st.Code())
+}
+
+// invoke runs fn under method's retry policy, giving each attempt its own
+// deadline. When the server sends a retry delay with an error, the next
+// attempt waits at least that long; if ctx ends sooner, invoke gives up at
+// once.
+func (c *Client) invoke(ctx context.Context, method string, fn func(context.Context) error) error {
+	p := c.policy(method)
+	backoff := p.InitialBackoff
 	var lastErr error
 
-	for attempt := 1; attempt <= c.retry.MaxAttempts; attempt++ {
-		callCtx, cancel := c.ctx(ctx)
+	for attempt := 1; attempt <= p.MaxAttempts; attempt++ {
+		callCtx, cancel := withTimeout(ctx, p.Timeout)
 		err := fn(callCtx)
 		cancel()
 		if err == nil {
 			return nil
 		}
 		lastErr = err
-		if !shouldRetry(err) || attempt == c.retry.MaxAttempts {
+		if !p.retryable(err) || attempt == p.MaxAttempts {
 			return mapError(err)
 		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(backoff):
+		wait := backoff
+		if d, ok := retryDelay(err); ok {
+			wait = max(wait, d)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+				return mapError(err)
+			}
 		}
-		backoff *= 2
-		if backoff > c.retry.MaxBackoff {
-			backoff = c.retry.MaxBackoff
+		if err := sleep(ctx, wait); err != nil {
+			return err
 		}
+		backoff = min(backoff*2, p.MaxBackoff)
 	}
 	return mapError(lastErr)
 }
 
+func withTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d > 0 {
+		return context.WithTimeout(parent, d)
+	}
+	return parent, func() {}
+}
+
+// sleep waits for d or until ctx ends.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
 func shouldRetry(err error) bool {
 	st, ok := status.FromError(err)
 	if !ok {
@@ -45,6 +126,10 @@ func shouldRetry(err error) bool {
 	switch st.Code() {
 	case codes.Unavailable, codes.DeadlineExceeded:
 		return true
+	case codes.ResourceExhausted:
+		// Only rate limits carry a retry delay; session limits do not.
+		_, ok := retryDelay(err)
+		return ok
 	default:
 		return false
 	}
@@ -0,0 +1,232 @@
+package bridgeclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// scriptedRPC answers StartSession and AttachSession from per-call scripts.
+type scriptedRPC struct {
+	*fakeRPCClient
+	startErrs []error
+	attaches  []scriptedAttach
+	afterSeqs []uint64
+}
+
+type scriptedAttach struct {
+	events []*bridgev1.AttachSessionEvent
+	end    error // returned once events run out; nil reports EOF
+}
+
+func (s *scriptedRPC) StartSession(_ context.Context, req *bridgev1.StartSessionRequest, _ ...grpc.CallOption) (*bridgev1.StartSessionResponse, error) {
+	err := s.startErrs[0]
+	s.startErrs = s.startErrs[1:]
+	if err != nil {
+		return nil, err
+	}
+	return &bridgev1.StartSessionResponse{SessionId: req.SessionId}, nil
+}
+
+func (s *scriptedRPC) AttachSession(_ context.Context, req *bridgev1.AttachSessionRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[bridgev1.AttachSessionEvent], error) {
+	s.afterSeqs = append(s.afterSeqs, req.AfterSeq)
+	a := s.attaches[0]
+	s.attaches = s.attaches[1:]
+	return &scriptedStream{scriptedAttach: a}, nil
+}
+
+type scriptedStream struct {
+	grpc.ClientStream
+	scriptedAttach
+}
+
+func (s *scriptedStream) Recv() (*bridgev1.AttachSessionEvent, error) {
+	if len(s.events) == 0 {
+		if s.end == nil {
+			return nil, io.EOF
+		}
+		return nil, s.end
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func newRetryClient(rpc bridgev1.BridgeServiceClient, policies map[string]RetryPolicy) *Client {
+	return &Client{
+		backends: []*backend{{rpc: rpc}},
+		retry:    RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+		timeout:  time.Second,
+		policies: policies,
+	}
+}
+
+func TestWriteInputNotRetried(t *testing.T) {
+	fake := &fakeRPCClient{err: status.Error(codes.DeadlineExceeded, "slow")}
+	c := newRetryClient(fake, nil)
+	if _, err := c.WriteInput(context.Background(), &bridgev1.WriteInputRequest{SessionId: "s"}); err == nil {
+		t.Fatal("WriteInput succeeded")
+	}
+	if len(fake.writes) != 1 {
+		t.Fatalf("WriteInput sent %d times, want 1", len(fake.writes))
+	}
+
+	// A policy set for WriteInput replaces the built-in one.
+	fake.writes = nil
+	c = newRetryClient(fake, map[string]RetryPolicy{"WriteInput": {MaxAttempts: 2}})
+	_, _ = c.WriteInput(context.Background(), &bridgev1.WriteInputRequest{SessionId: "s"})
+	if len(fake.writes) != 2 {
+		t.Fatalf("WriteInput sent %d times with MaxAttempts 2, want 2", len(fake.writes))
+	}
+}
+
+func TestMethodPolicyRetryCodes(t *testing.T) {
+	c := newRetryClient(nil, map[string]RetryPolicy{"Health": {RetryCodes: []codes.Code{codes.Aborted}}})
+	attempts := 0
+	_ = c.invoke(context.Background(), "Health", func(context.Context) error {
+		attempts++
+		return status.Error(codes.Unavailable, "down")
+	})
+	if attempts != 1 {
+		t.Fatalf("Unavailable retried %d times under a policy retrying only Aborted", attempts-1)
+	}
+	if p := c.policy("Health"); p.MaxAttempts != 3 || p.Timeout != time.Second {
+		t.Fatalf("policy = %+v, want MaxAttempts and Timeout inherited", p)
+	}
+}
+
+func rateLimitedErr(t *testing.T, delay time.Duration) error {
+	t.Helper()
+	st, err := status.New(codes.ResourceExhausted, "global RPC rate limit exceeded").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if err != nil {
+		t.Fatalf("WithDetails: %v", err)
+	}
+	return st.Err()
+}
+
+func TestRetryHonoursRetryInfo(t *testing.T) {
+	c := newRetryClient(nil, nil)
+	attempts := 0
+	start := time.Now()
+	err := c.invoke(context.Background(), "Health", func(context.Context) error {
+		attempts++
+		if attempts == 1 {
+			return rateLimitedErr(t, 50*time.Millisecond)
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("err=%v attempts=%d, want a retry", err, attempts)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Fatalf("retried after %v, before the server's 50ms hint", d)
+	}
+
+	// A hint beyond the caller's deadline fails at once with the hint.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	attempts = 0
+	err = c.invoke(ctx, "Health", func(context.Context) error {
+		attempts++
+		return rateLimitedErr(t, time.Minute)
+	})
+	var rl *RateLimitError
+	if !errors.As(err, &rl) || rl.RetryAfter != time.Minute || !errors.Is(err, ErrRateLimited) || attempts != 1 {
+		t.Fatalf("err=%v attempts=%d, want RateLimitError after one attempt", err, attempts)
+	}
+
+	// A session limit has no hint and is not retried.
+	attempts = 0
+	_ = c.invoke(context.Background(), "Health", func(context.Context) error {
+		attempts++
+		return status.Error(codes.ResourceExhausted, "session limit reached")
+	})
+	if attempts != 1 {
+		t.Fatalf("session limit retried %d times", attempts-1)
+	}
+}
+
+func TestStartSessionRetryFindsExistingSession(t *testing.T) {
+	rpc := &scriptedRPC{
+		fakeRPCClient: &fakeRPCClient{getResp: &bridgev1.GetSessionResponse{SessionId: "s", Status: bridgev1.SessionStatus_SESSION_STATUS_RUNNING}},
+		startErrs: []error{
+			status.Error(codes.DeadlineExceeded, "response lost"),
+			status.Error(codes.AlreadyExists, "session already exists"),
+		},
+	}
+	c := newRetryClient(rpc, nil)
+	resp, err := c.StartSession(context.Background(), &bridgev1.StartSessionRequest{SessionId: "s"})
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if resp.SessionId != "s" || resp.Status != bridgev1.SessionStatus_SESSION_STATUS_RUNNING {
+		t.Fatalf("resp = %+v, want the running session", resp)
+	}
+
+	// AlreadyExists on the first attempt is a real conflict.
+	rpc.startErrs = []error{status.Error(codes.AlreadyExists, "session already exists")}
+	if _, err := c.StartSession(context.Background(), &bridgev1.StartSessionRequest{SessionId: "s"}); !errors.Is(err, ErrSessionAlreadyExists) {
+		t.Fatalf("StartSession err = %v, want ErrSessionAlreadyExists", err)
+	}
+}
+
+func TestRecvAllReconnects(t *testing.T) {
+	ev := func(typ bridgev1.AttachEventType, seq uint64) *bridgev1.AttachSessionEvent {
+		return &bridgev1.AttachSessionEvent{Type: typ, Seq: seq}
+	}
+	attached := bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED
+	output := bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT
+	script := func() []scriptedAttach {
+		return []scriptedAttach{
+			{events: []*bridgev1.AttachSessionEvent{ev(attached, 0), ev(output, 1)}, end: status.Error(codes.Unavailable, "bridge restarted")},
+			{events: []*bridgev1.AttachSessionEvent{ev(attached, 0), ev(output, 2)}},
+		}
+	}
+
+	rpc := &scriptedRPC{fakeRPCClient: &fakeRPCClient{}, attaches: script()}
+	c := newRetryClient(rpc, map[string]RetryPolicy{"AttachSession": {MaxAttempts: 2}})
+	stream, _ := c.AttachSession(context.Background(), &bridgev1.AttachSessionRequest{SessionId: "s", ClientId: "c"})
+	var seqs []uint64
+	err := stream.RecvAll(context.Background(), func(e *bridgev1.AttachSessionEvent) error {
+		if e.Type == output {
+			seqs = append(seqs, e.Seq)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RecvAll: %v", err)
+	}
+	if len(seqs) != 2 || seqs[1] != 2 {
+		t.Fatalf("output seqs = %v, want [1 2]", seqs)
+	}
+	if len(rpc.afterSeqs) != 2 || rpc.afterSeqs[1] != 1 {
+		t.Fatalf("attach after_seqs = %v, want the reconnect to resume after 1", rpc.afterSeqs)
+	}
+
+	// Without an AttachSession policy the broken stream's error is returned.
+	rpc = &scriptedRPC{fakeRPCClient: &fakeRPCClient{}, attaches: script()}
+	c = newRetryClient(rpc, map[string]RetryPolicy{"AttachSession": {MaxAttempts: 1}})
+	stream, _ = c.AttachSession(context.Background(), &bridgev1.AttachSessionRequest{SessionId: "s", ClientId: "c"})
+	err = stream.RecvAll(context.Background(), func(*bridgev1.AttachSessionEvent) error { return nil })
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("RecvAll err = %v, want Unavailable", err)
+	}
+
+	// Callback errors end the stream unchanged and are not retried.
+	rpc = &scriptedRPC{fakeRPCClient: &fakeRPCClient{}, attaches: script()}
+	c = newRetryClient(rpc, map[string]RetryPolicy{"AttachSession": {MaxAttempts: 2}})
+	stream, _ = c.AttachSession(context.Background(), &bridgev1.AttachSessionRequest{SessionId: "s", ClientId: "c"})
+	err = stream.RecvAll(context.Background(), func(*bridgev1.AttachSessionEvent) error { return io.EOF })
+	if err != io.EOF || len(rpc.afterSeqs) != 1 {
+		t.Fatalf("RecvAll err = %v after %d attaches, want io.EOF after 1", err, len(rpc.afterSeqs))
+	}
+}
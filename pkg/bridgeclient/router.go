@@ -0,0 +1,148 @@
+package bridgeclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+)
+
+// EventHandler handles one attach event. An error ends RecvAll with it.
+type EventHandler func(*bridgev1.AttachSessionEvent) error
+
+// Middleware wraps the handling of every event routed by an EventRouter,
+// for cross-cutting concerns such as logging or transcript capture.
+type Middleware func(next EventHandler) EventHandler
+
+// EventRouter dispatches attach events to typed callbacks so that callers
+// need not switch over AttachEventType themselves. Pass its Handle method
+// to OutputStream.RecvAll. BATCH events are unwrapped and their events
+// routed one at a time. Events whose callback is nil are passed to OnEvent,
+// or dropped when that is nil too.
+type EventRouter struct {
+	// OnAttached receives the ATTACHED event that opens every stream.
+	OnAttached func(ev *bridgev1.AttachSessionEvent) error
+	// OnStdout receives OUTPUT payloads.
+	OnStdout func(payload []byte) error
+	// OnStderr receives WARNING lines a stream-JSON provider wrote to stderr.
+	OnStderr func(line []byte, severity bridgev1.Severity) error
+	// OnThinking receives THINKING text.
+	OnThinking func(text string) error
+	// OnToolUse receives the name of each tool the agent calls.
+	OnToolUse func(name string) error
+	// OnApproval receives each tool call the agent waits to have approved;
+	// answer it with Client.ResolveApproval.
+	OnApproval func(approval *bridgev1.Approval) error
+	// OnComplete is called at RESPONSE_COMPLETE with the input the response
+	// answers, if known.
+	OnComplete func(inputID string) error
+	// OnTerminal receives the SESSION_EXIT or ERROR event that ends the
+	// session's output.
+	OnTerminal func(ev *bridgev1.AttachSessionEvent) error
+	// OnEvent receives events with no typed callback set.
+	OnEvent EventHandler
+
+	middleware []Middleware
+}
+
+// Use adds middleware around event handling. The first middleware added is
+// the outermost, seeing each event first.
+func (r *EventRouter) Use(mw ...Middleware) *EventRouter {
+	r.middleware = append(r.middleware, mw...)
+	return r
+}
+
+// Handle routes ev through the router's middleware to its callback.
+func (r *EventRouter) Handle(ev *bridgev1.AttachSessionEvent) error {
+	if ev.Type == bridgev1.AttachEventType_ATTACH_EVENT_TYPE_BATCH {
+		for _, e := range ev.GetBatch().GetEvents() {
+			if err := r.Handle(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	h := EventHandler(r.dispatch)
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
+	}
+	return h(ev)
+}
+
+func (r *EventRouter) dispatch(ev *bridgev1.AttachSessionEvent) error {
+	switch ev.Type {
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED:
+		if r.OnAttached != nil {
+			return r.OnAttached(ev)
+		}
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:
+		if r.OnStdout != nil {
+			return r.OnStdout(ev.Payload)
+		}
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WARNING:
+		if r.OnStderr != nil {
+			return r.OnStderr(ev.Payload, ev.Severity)
+		}
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_THINKING:
+		if r.OnThinking != nil {
+			return r.OnThinking(ev.ThinkingText)
+		}
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_TOOL_USE:
+		if r.OnToolUse != nil {
+			return r.OnToolUse(string(ev.Payload))
+		}
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_APPROVAL_REQUESTED:
+		if r.OnApproval != nil {
+			approval, err := ApprovalFromEvent(ev)
+			if err != nil {
+				return err
+			}
+			return r.OnApproval(approval)
+		}
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE:
+		if r.OnComplete != nil {
+			return r.OnComplete(ev.InputId)
+		}
+	case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_EXIT,
+		bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ERROR:
+		if r.OnTerminal != nil {
+			return r.OnTerminal(ev)
+		}
+	}
+	if r.OnEvent != nil {
+		return r.OnEvent(ev)
+	}
+	return nil
+}
+
+// ApprovalFromEvent decodes the request carried by an APPROVAL_REQUESTED
+// event's data_json.
+func ApprovalFromEvent(ev *bridgev1.AttachSessionEvent) (*bridgev1.Approval, error) {
+	var data struct {
+		ID        string          `json:"id"`
+		ToolName  string          `json:"tool_name"`
+		Input     json.RawMessage `json:"input"`
+		ToolUseID string          `json:"tool_use_id"`
+	}
+	if err := json.Unmarshal(ev.DataJson, &data); err != nil {
+		return nil, fmt.Errorf("decode approval request: %w", err)
+	}
+	return &bridgev1.Approval{Id: data.ID, ToolName: data.ToolName, InputJson: data.Input, ToolUseId: data.ToolUseID}, nil
+}
+
+// CaptureTranscript returns middleware that copies every OUTPUT payload to
+// w before passing the event on, e.g. to keep a raw transcript of a session
+// alongside whatever the callbacks do with it.
+func CaptureTranscript(w io.Writer) Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(ev *bridgev1.AttachSessionEvent) error {
+			if ev.Type == bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT {
+				if _, err := w.Write(ev.Payload); err != nil {
+					return err
+				}
+			}
+			return next(ev)
+		}
+	}
+}
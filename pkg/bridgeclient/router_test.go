@@ -0,0 +1,109 @@
+package bridgeclient
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+)
+
+func TestEventRouterDispatch(t *testing.T) {
+	var got []string
+	r := &EventRouter{
+		OnStdout: func(p []byte) error { got = append(got, "stdout:"+string(p)); return nil },
+		OnStderr: func(line []byte, sev bridgev1.Severity) error {
+			got = append(got, "stderr:"+string(line)+":"+sev.String())
+			return nil
+		},
+		OnThinking: func(text string) error { got = append(got, "thinking:"+text); return nil },
+		OnToolUse:  func(name string) error { got = append(got, "tool:"+name); return nil },
+		OnApproval: func(a *bridgev1.Approval) error {
+			got = append(got, "approval:"+a.Id+":"+a.ToolName+":"+string(a.InputJson))
+			return nil
+		},
+		OnComplete: func(id string) error { got = append(got, "complete:"+id); return nil },
+		OnTerminal: func(ev *bridgev1.AttachSessionEvent) error {
+			got = append(got, "terminal:"+ev.Type.String())
+			return nil
+		},
+		OnEvent: func(ev *bridgev1.AttachSessionEvent) error { got = append(got, "other:"+ev.Type.String()); return nil },
+	}
+	events := []*bridgev1.AttachSessionEvent{
+		{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED},
+		{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_BATCH, Batch: &bridgev1.AttachSessionEventBatch{Events: []*bridgev1.AttachSessionEvent{
+			{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, Payload: []byte("a")},
+			{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, Payload: []byte("b")},
+		}}},
+		{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_WARNING, Payload: []byte("w"), Severity: bridgev1.Severity_SEVERITY_WARNING},
+		{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_THINKING, ThinkingText: "hmm"},
+		{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_TOOL_USE, Payload: []byte("Bash")},
+		{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_APPROVAL_REQUESTED, Payload: []byte("Bash"), DataJson: []byte(`{"id":"req-1","tool_name":"Bash","input":{"command":"ls"}}`)},
+		{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE, InputId: "in-1"},
+		{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_SESSION_EXIT},
+	}
+	for _, ev := range events {
+		if err := r.Handle(ev); err != nil {
+			t.Fatalf("Handle(%v): %v", ev.Type, err)
+		}
+	}
+	want := []string{
+		"other:ATTACH_EVENT_TYPE_ATTACHED",
+		"stdout:a",
+		"stdout:b",
+		"stderr:w:SEVERITY_WARNING",
+		"thinking:hmm",
+		"tool:Bash",
+		`approval:req-1:Bash:{"command":"ls"}`,
+		"complete:in-1",
+		"terminal:ATTACH_EVENT_TYPE_SESSION_EXIT",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("dispatched %q, want %q", got, want)
+	}
+}
+
+func TestEventRouterMiddleware(t *testing.T) {
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next EventHandler) EventHandler {
+			return func(ev *bridgev1.AttachSessionEvent) error {
+				order = append(order, name)
+				return next(ev)
+			}
+		}
+	}
+	var transcript bytes.Buffer
+	stop := errors.New("stop")
+	r := (&EventRouter{
+		OnStdout:   func([]byte) error { order = append(order, "callback"); return nil },
+		OnComplete: func(string) error { return stop },
+	}).Use(tag("outer"), CaptureTranscript(&transcript), tag("inner"))
+
+	out := &bridgev1.AttachSessionEvent{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT, Payload: []byte("hello")}
+	if err := r.Handle(out); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if want := []string{"outer", "inner", "callback"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("order = %q, want %q", order, want)
+	}
+	if transcript.String() != "hello" {
+		t.Fatalf("transcript = %q, want hello", transcript.String())
+	}
+	// Unhandled events pass through middleware and are dropped.
+	if err := r.Handle(&bridgev1.AttachSessionEvent{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_USAGE}); err != nil {
+		t.Fatalf("Handle(USAGE): %v", err)
+	}
+	// Callback errors are returned to RecvAll, also from inside a batch.
+	batch := &bridgev1.AttachSessionEvent{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_BATCH, Batch: &bridgev1.AttachSessionEventBatch{Events: []*bridgev1.AttachSessionEvent{
+		{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_RESPONSE_COMPLETE},
+		out,
+	}}}
+	if err := r.Handle(batch); !errors.Is(err, stop) {
+		t.Fatalf("Handle(batch) err = %v, want stop", err)
+	}
+	if transcript.String() != "hello" {
+		t.Fatalf("events after the error were handled: transcript = %q", transcript.String())
+	}
+}
@@ -2,16 +2,38 @@ package bridgeclient
 
 import (
 	"context"
+	"io"
 
 	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+// StartSession starts a session. When a retry finds the session already
+// exists, an earlier attempt whose response was lost started it; the
+// session is looked up and returned as if that response had arrived.
 func (c *Client) StartSession(ctx context.Context, req *bridgev1.StartSessionRequest) (*bridgev1.StartSessionResponse, error) {
 	c.SetProject(req.ProjectId)
+	if req.ProtocolVersion == 0 {
+		req = proto.CloneOf(req)
+		req.ProtocolVersion = ProtocolVersion
+	}
 	var resp *bridgev1.StartSessionResponse
-	err := c.invoke(ctx, func(callCtx context.Context) error {
+	attempt := 0
+	err := c.call(ctx, "StartSession", req.SessionId, func(callCtx context.Context, b *backend) error {
+		attempt++
 		var callErr error
-		resp, callErr = c.rpc.StartSession(callCtx, req)
+		resp, callErr = b.rpc.StartSession(callCtx, req)
+		if attempt > 1 && status.Code(callErr) == codes.AlreadyExists {
+			got, getErr := b.rpc.GetSession(callCtx, &bridgev1.GetSessionRequest{SessionId: req.SessionId})
+			if getErr != nil {
+				return callErr
+			}
+			resp = &bridgev1.StartSessionResponse{SessionId: got.SessionId, Status: got.Status, CreatedAt: got.CreatedAt}
+			return nil
+		}
 		return callErr
 	})
 	return resp, err
@@ -19,9 +41,9 @@ func (c *Client) StartSession(ctx context.Context, req *bridgev1.StartSessionReq
 
 func (c *Client) StopSession(ctx context.Context, req *bridgev1.StopSessionRequest) (*bridgev1.StopSessionResponse, error) {
 	var resp *bridgev1.StopSessionResponse
-	err := c.invoke(ctx, func(callCtx context.Context) error {
+	err := c.call(ctx, "StopSession", req.SessionId, func(callCtx context.Context, b *backend) error {
 		var callErr error
-		resp, callErr = c.rpc.StopSession(callCtx, req)
+		resp, callErr = b.rpc.StopSession(callCtx, req)
 		return callErr
 	})
 	return resp, err
@@ -29,59 +51,354 @@ func (c *Client) StopSession(ctx context.Context, req *bridgev1.StopSessionReque
 
 func (c *Client) GetSession(ctx context.Context, req *bridgev1.GetSessionRequest) (*bridgev1.GetSessionResponse, error) {
 	var resp *bridgev1.GetSessionResponse
-	err := c.invoke(ctx, func(callCtx context.Context) error {
+	err := c.call(ctx, "GetSession", req.SessionId, func(callCtx context.Context, b *backend) error {
 		var callErr error
-		resp, callErr = c.rpc.GetSession(callCtx, req)
+		resp, callErr = b.rpc.GetSession(callCtx, req)
 		return callErr
 	})
 	return resp, err
 }
 
-func (c *Client) ListSessions(ctx context.Context, req *bridgev1.ListSessionsRequest) (*bridgev1.ListSessionsResponse, error) {
-	var resp *bridgev1.ListSessionsResponse
-	err := c.invoke(ctx, func(callCtx context.Context) error {
+func (c *Client) GetSessionHistory(ctx context.Context, req *bridgev1.GetSessionHistoryRequest) (*bridgev1.GetSessionHistoryResponse, error) {
+	if req.ProtocolVersion == 0 {
+		req = proto.CloneOf(req)
+		req.ProtocolVersion = ProtocolVersion
+	}
+	var resp *bridgev1.GetSessionHistoryResponse
+	err := c.call(ctx, "GetSessionHistory", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.GetSessionHistory(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+func (c *Client) ExportTranscript(ctx context.Context, req *bridgev1.ExportTranscriptRequest) (*bridgev1.ExportTranscriptResponse, error) {
+	var resp *bridgev1.ExportTranscriptResponse
+	err := c.call(ctx, "ExportTranscript", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.ExportTranscript(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// GetConversation returns the sessions of a conversation and their
+// combined transcript. The call is not tied to one session, so it goes to
+// the first available bridge.
+func (c *Client) GetConversation(ctx context.Context, req *bridgev1.GetConversationRequest) (*bridgev1.GetConversationResponse, error) {
+	var resp *bridgev1.GetConversationResponse
+	err := c.call(ctx, "GetConversation", "", func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.GetConversation(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// GetResponse returns the agent's reply to one input, assembled by the
+// server, so callers need not stitch OUTPUT events together themselves.
+func (c *Client) GetResponse(ctx context.Context, req *bridgev1.GetResponseRequest) (*bridgev1.GetResponseResponse, error) {
+	var resp *bridgev1.GetResponseResponse
+	err := c.call(ctx, "GetResponse", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.GetResponse(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// maxSessionStateSize is the largest ExportSessionState response accepted.
+// Session state carries the whole output buffer, which can exceed gRPC's
+// default 4 MiB receive limit.
+const maxSessionStateSize = 256 << 20
+
+func (c *Client) ExportSessionState(ctx context.Context, req *bridgev1.ExportSessionStateRequest) (*bridgev1.ExportSessionStateResponse, error) {
+	var resp *bridgev1.ExportSessionStateResponse
+	err := c.call(ctx, "ExportSessionState", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.ExportSessionState(callCtx, req, grpc.MaxCallRecvMsgSize(maxSessionStateSize))
+		return callErr
+	})
+	return resp, err
+}
+
+func (c *Client) ImportSessionState(ctx context.Context, req *bridgev1.ImportSessionStateRequest) (*bridgev1.ImportSessionStateResponse, error) {
+	var resp *bridgev1.ImportSessionStateResponse
+	err := c.call(ctx, "ImportSessionState", "", func(callCtx context.Context, b *backend) error {
 		var callErr error
-		resp, callErr = c.rpc.ListSessions(callCtx, req)
+		resp, callErr = b.rpc.ImportSessionState(callCtx, req)
+		if callErr == nil {
+			c.affinity.set(resp.GetSession().GetSessionId(), b)
+		}
 		return callErr
 	})
 	return resp, err
 }
 
+// ListSessions merges the sessions of every configured bridge. Bridges that
+// cannot be reached are skipped; an error is returned only if none answer.
+func (c *Client) ListSessions(ctx context.Context, req *bridgev1.ListSessionsRequest) (*bridgev1.ListSessionsResponse, error) {
+	merged := &bridgev1.ListSessionsResponse{}
+	var lastErr error
+	answered := false
+	for _, b := range c.backends {
+		var resp *bridgev1.ListSessionsResponse
+		err := c.invoke(ctx, "ListSessions", func(callCtx context.Context) error {
+			var callErr error
+			resp, callErr = b.rpc.ListSessions(callCtx, req)
+			return callErr
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		answered = true
+		for _, session := range resp.GetSessions() {
+			c.affinity.set(session.GetSessionId(), b)
+			merged.Sessions = append(merged.Sessions, session)
+		}
+	}
+	if !answered {
+		return nil, lastErr
+	}
+	return merged, nil
+}
+
 func (c *Client) WriteInput(ctx context.Context, req *bridgev1.WriteInputRequest) (*bridgev1.WriteInputResponse, error) {
 	var resp *bridgev1.WriteInputResponse
-	err := c.invoke(ctx, func(callCtx context.Context) error {
+	err := c.call(ctx, "WriteInput", req.SessionId, func(callCtx context.Context, b *backend) error {
 		var callErr error
-		resp, callErr = c.rpc.WriteInput(callCtx, req)
+		resp, callErr = b.rpc.WriteInput(callCtx, req)
 		return callErr
 	})
 	return resp, err
 }
 
+// BroadcastInput sends one input to each of req.SessionIds and returns a
+// result per session. The sessions must be on the same bridge; the call is
+// routed by the first of them. Like WriteInput it is not retried by
+// default.
+func (c *Client) BroadcastInput(ctx context.Context, req *bridgev1.BroadcastInputRequest) (*bridgev1.BroadcastInputResponse, error) {
+	var sessionID string
+	if len(req.SessionIds) > 0 {
+		sessionID = req.SessionIds[0]
+	}
+	var resp *bridgev1.BroadcastInputResponse
+	err := c.call(ctx, "BroadcastInput", sessionID, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.BroadcastInput(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// inputChunkSize is the size of each SendInputStream message.
+const inputChunkSize = 256 << 10
+
+// SendInputStream sends req.Data as a single input in chunks, for inputs
+// larger than WriteInput accepts, such as long diffs or logs. The server
+// caps the total at its input.max_stream_bytes. Like WriteInput it is not
+// retried by default.
+func (c *Client) SendInputStream(ctx context.Context, req *bridgev1.WriteInputRequest) (*bridgev1.WriteInputResponse, error) {
+	var resp *bridgev1.WriteInputResponse
+	err := c.call(ctx, "SendInputStream", req.SessionId, func(callCtx context.Context, b *backend) error {
+		stream, err := b.rpc.SendInputStream(callCtx)
+		if err != nil {
+			return err
+		}
+		msg := &bridgev1.SendInputStreamRequest{
+			SessionId: req.SessionId,
+			ClientId:  req.ClientId,
+			Priority:  req.Priority,
+			TotalSize: uint64(len(req.Data)),
+		}
+		for off := 0; off < len(req.Data); off += inputChunkSize {
+			msg.Data = req.Data[off:min(off+inputChunkSize, len(req.Data))]
+			if err := stream.Send(msg); err != nil {
+				// The server ended the stream; CloseAndRecv returns why.
+				break
+			}
+			msg = &bridgev1.SendInputStreamRequest{}
+		}
+		resp, err = stream.CloseAndRecv()
+		return err
+	})
+	return resp, err
+}
+
 func (c *Client) ResizeSession(ctx context.Context, req *bridgev1.ResizeSessionRequest) (*bridgev1.ResizeSessionResponse, error) {
 	var resp *bridgev1.ResizeSessionResponse
-	err := c.invoke(ctx, func(callCtx context.Context) error {
+	err := c.call(ctx, "ResizeSession", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.ResizeSession(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// CancelResponse interrupts the agent's in-flight response without stopping
+// the session. The caller must hold the writer slot.
+func (c *Client) CancelResponse(ctx context.Context, req *bridgev1.CancelResponseRequest) (*bridgev1.CancelResponseResponse, error) {
+	var resp *bridgev1.CancelResponseResponse
+	err := c.call(ctx, "CancelResponse", req.SessionId, func(callCtx context.Context, b *backend) error {
 		var callErr error
-		resp, callErr = c.rpc.ResizeSession(callCtx, req)
+		resp, callErr = b.rpc.CancelResponse(callCtx, req)
 		return callErr
 	})
 	return resp, err
 }
 
+// ResolveApproval allows or denies a tool call the agent is waiting on.
+// The caller must hold the writer slot.
+func (c *Client) ResolveApproval(ctx context.Context, req *bridgev1.ResolveApprovalRequest) (*bridgev1.ResolveApprovalResponse, error) {
+	var resp *bridgev1.ResolveApprovalResponse
+	err := c.call(ctx, "ResolveApproval", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.ResolveApproval(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// ReadFile returns a file from the session's repo.
+func (c *Client) ReadFile(ctx context.Context, req *bridgev1.ReadFileRequest) (*bridgev1.ReadFileResponse, error) {
+	var resp *bridgev1.ReadFileResponse
+	err := c.call(ctx, "ReadFile", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.ReadFile(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// WriteFile creates or replaces a file in the session's repo.
+func (c *Client) WriteFile(ctx context.Context, req *bridgev1.WriteFileRequest) (*bridgev1.WriteFileResponse, error) {
+	var resp *bridgev1.WriteFileResponse
+	err := c.call(ctx, "WriteFile", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.WriteFile(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// ListDir lists a directory in the session's repo.
+func (c *Client) ListDir(ctx context.Context, req *bridgev1.ListDirRequest) (*bridgev1.ListDirResponse, error) {
+	var resp *bridgev1.ListDirResponse
+	err := c.call(ctx, "ListDir", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.ListDir(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// GitStatus returns the git working tree state of the session's repo.
+func (c *Client) GitStatus(ctx context.Context, req *bridgev1.GitStatusRequest) (*bridgev1.GitStatusResponse, error) {
+	var resp *bridgev1.GitStatusResponse
+	err := c.call(ctx, "GitStatus", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.GitStatus(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// GitDiff returns a unified diff of the session's repo.
+func (c *Client) GitDiff(ctx context.Context, req *bridgev1.GitDiffRequest) (*bridgev1.GitDiffResponse, error) {
+	var resp *bridgev1.GitDiffResponse
+	err := c.call(ctx, "GitDiff", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.GitDiff(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// GitCommit commits changes in the session's repo.
+func (c *Client) GitCommit(ctx context.Context, req *bridgev1.GitCommitRequest) (*bridgev1.GitCommitResponse, error) {
+	var resp *bridgev1.GitCommitResponse
+	err := c.call(ctx, "GitCommit", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.GitCommit(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// RollbackWorkspace restores a stopped session's repo to the snapshot taken
+// when it started.
+func (c *Client) RollbackWorkspace(ctx context.Context, req *bridgev1.RollbackWorkspaceRequest) (*bridgev1.RollbackWorkspaceResponse, error) {
+	var resp *bridgev1.RollbackWorkspaceResponse
+	err := c.call(ctx, "RollbackWorkspace", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.RollbackWorkspace(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// Health runs a readiness probe; see CheckHealth.
 func (c *Client) Health(ctx context.Context) (*bridgev1.HealthResponse, error) {
+	return c.CheckHealth(ctx, bridgev1.HealthCheck_HEALTH_CHECK_READINESS)
+}
+
+// CheckHealth runs one health probe: LIVENESS only reports that the daemon
+// answers, READINESS also runs its provider, certificate and disk checks.
+func (c *Client) CheckHealth(ctx context.Context, check bridgev1.HealthCheck) (*bridgev1.HealthResponse, error) {
 	var resp *bridgev1.HealthResponse
-	err := c.invoke(ctx, func(callCtx context.Context) error {
+	err := c.call(ctx, "Health", "", func(callCtx context.Context, b *backend) error {
 		var callErr error
-		resp, callErr = c.rpc.Health(callCtx, &bridgev1.HealthRequest{})
+		resp, callErr = b.rpc.Health(callCtx, &bridgev1.HealthRequest{Check: check})
 		return callErr
 	})
 	return resp, err
 }
 
+// WatchHealth passes the daemon's current health to callback, then each
+// change to it, until ctx ends, the stream breaks or callback returns an
+// error. The stream is opened on the first bridge that answers.
+func (c *Client) WatchHealth(ctx context.Context, check bridgev1.HealthCheck, callback func(*bridgev1.HealthResponse) error) error {
+	var stream grpc.ServerStreamingClient[bridgev1.HealthResponse]
+	var resp *bridgev1.HealthResponse
+	err := c.failover(ctx, "", func(ctx context.Context, b *backend) error {
+		s, err := b.rpc.HealthWatch(ctx, &bridgev1.HealthRequest{Check: check}, c.streamOpts...)
+		if err != nil {
+			return err
+		}
+		first, err := s.Recv()
+		if err != nil {
+			return err
+		}
+		stream, resp = s, first
+		return nil
+	})
+	if err != nil {
+		return mapError(err)
+	}
+	for {
+		if err := callback(resp); err != nil {
+			return err
+		}
+		resp, err = stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+}
+
 func (c *Client) ListProviders(ctx context.Context) (*bridgev1.ListProvidersResponse, error) {
 	var resp *bridgev1.ListProvidersResponse
-	err := c.invoke(ctx, func(callCtx context.Context) error {
+	err := c.call(ctx, "ListProviders", "", func(callCtx context.Context, b *backend) error {
 		var callErr error
-		resp, callErr = c.rpc.ListProviders(callCtx, &bridgev1.ListProvidersRequest{})
+		resp, callErr = b.rpc.ListProviders(callCtx, &bridgev1.ListProvidersRequest{})
 		return callErr
 	})
 	return resp, err
@@ -89,9 +406,33 @@ func (c *Client) ListProviders(ctx context.Context) (*bridgev1.ListProvidersResp
 
 func (c *Client) ClaimWriter(ctx context.Context, req *bridgev1.ClaimWriterRequest) (*bridgev1.ClaimWriterResponse, error) {
 	var resp *bridgev1.ClaimWriterResponse
-	err := c.invoke(ctx, func(callCtx context.Context) error {
+	err := c.call(ctx, "ClaimWriter", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.ClaimWriter(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// TakeControl makes the caller the session's controller, holding the writer
+// slot against forced ClaimWriter calls until it releases it.
+func (c *Client) TakeControl(ctx context.Context, req *bridgev1.TakeControlRequest) (*bridgev1.TakeControlResponse, error) {
+	var resp *bridgev1.TakeControlResponse
+	err := c.call(ctx, "TakeControl", req.SessionId, func(callCtx context.Context, b *backend) error {
+		var callErr error
+		resp, callErr = b.rpc.TakeControl(callCtx, req)
+		return callErr
+	})
+	return resp, err
+}
+
+// MintSessionToken returns a short-lived token that can only observe the
+// session, for sharing it with someone who has no credentials of their own.
+func (c *Client) MintSessionToken(ctx context.Context, req *bridgev1.MintSessionTokenRequest) (*bridgev1.MintSessionTokenResponse, error) {
+	var resp *bridgev1.MintSessionTokenResponse
+	err := c.call(ctx, "MintSessionToken", req.SessionId, func(callCtx context.Context, b *backend) error {
 		var callErr error
-		resp, callErr = c.rpc.ClaimWriter(callCtx, req)
+		resp, callErr = b.rpc.MintSessionToken(callCtx, req)
 		return callErr
 	})
 	return resp, err
@@ -99,9 +440,9 @@ func (c *Client) ClaimWriter(ctx context.Context, req *bridgev1.ClaimWriterReque
 
 func (c *Client) ReleaseWriter(ctx context.Context, req *bridgev1.ReleaseWriterRequest) (*bridgev1.ReleaseWriterResponse, error) {
 	var resp *bridgev1.ReleaseWriterResponse
-	err := c.invoke(ctx, func(callCtx context.Context) error {
+	err := c.call(ctx, "ReleaseWriter", req.SessionId, func(callCtx context.Context, b *backend) error {
 		var callErr error
-		resp, callErr = c.rpc.ReleaseWriter(callCtx, req)
+		resp, callErr = b.rpc.ReleaseWriter(callCtx, req)
 		return callErr
 	})
 	return resp, err
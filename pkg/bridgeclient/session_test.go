@@ -1,8 +1,11 @@
 package bridgeclient
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
+	"slices"
 	"testing"
 	"time"
 
@@ -16,56 +19,208 @@ type fakeRPCClient struct {
 	startResp     *bridgev1.StartSessionResponse
 	stopResp      *bridgev1.StopSessionResponse
 	getResp       *bridgev1.GetSessionResponse
+	historyResp   *bridgev1.GetSessionHistoryResponse
+	exportResp    *bridgev1.ExportTranscriptResponse
+	responseResp  *bridgev1.GetResponseResponse
+	stateResp     *bridgev1.ExportSessionStateResponse
+	importResp    *bridgev1.ImportSessionStateResponse
 	listResp      *bridgev1.ListSessionsResponse
 	writeResp     *bridgev1.WriteInputResponse
+	broadcastResp *bridgev1.BroadcastInputResponse
+	convResp      *bridgev1.GetConversationResponse
 	resizeResp    *bridgev1.ResizeSessionResponse
+	cancelResp    *bridgev1.CancelResponseResponse
+	approvalResp  *bridgev1.ResolveApprovalResponse
+	tokenResp     *bridgev1.MintSessionTokenResponse
+	readFileResp  *bridgev1.ReadFileResponse
+	writeFileResp *bridgev1.WriteFileResponse
+	listDirResp   *bridgev1.ListDirResponse
+	gitStatusResp *bridgev1.GitStatusResponse
+	gitDiffResp   *bridgev1.GitDiffResponse
+	gitCommitResp *bridgev1.GitCommitResponse
+	rollbackResp  *bridgev1.RollbackWorkspaceResponse
 	healthResp    *bridgev1.HealthResponse
 	providersResp *bridgev1.ListProvidersResponse
 	err           error
+
+	// attachEvents are streamed by AttachSession when set.
+	attachEvents []*bridgev1.AttachSessionEvent
+	// healthWatch is streamed by HealthWatch, ending with EOF.
+	healthWatch  []*bridgev1.HealthResponse
+	healthChecks []bridgev1.HealthCheck
+	writes       []*bridgev1.WriteInputRequest
+	inputChunks  []*bridgev1.SendInputStreamRequest
+	stops        []*bridgev1.StopSessionRequest
+	starts       []*bridgev1.StartSessionRequest
+	attaches     []*bridgev1.AttachSessionRequest
+}
+
+// fakeAttachStream replays a fixed list of events, then reports EOF.
+type fakeAttachStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	events []*bridgev1.AttachSessionEvent
+}
+
+func (f *fakeAttachStream) Recv() (*bridgev1.AttachSessionEvent, error) {
+	if len(f.events) == 0 {
+		<-f.ctx.Done()
+		return nil, f.ctx.Err()
+	}
+	ev := f.events[0]
+	f.events = f.events[1:]
+	if ev == nil {
+		return nil, io.EOF
+	}
+	return ev, nil
+}
+
+// fakeInputStream records the chunks of a SendInputStream call.
+type fakeInputStream struct {
+	grpc.ClientStream
+	f *fakeRPCClient
+}
+
+func (s *fakeInputStream) Send(req *bridgev1.SendInputStreamRequest) error {
+	s.f.inputChunks = append(s.f.inputChunks, req)
+	return nil
+}
+
+func (s *fakeInputStream) CloseAndRecv() (*bridgev1.WriteInputResponse, error) {
+	return s.f.writeResp, s.f.err
+}
+
+// fakeHealthStream replays a fixed list of responses, then reports EOF.
+type fakeHealthStream struct {
+	grpc.ClientStream
+	resps []*bridgev1.HealthResponse
+}
+
+func (f *fakeHealthStream) Recv() (*bridgev1.HealthResponse, error) {
+	if len(f.resps) == 0 {
+		return nil, io.EOF
+	}
+	resp := f.resps[0]
+	f.resps = f.resps[1:]
+	return resp, nil
 }
 
-func (f *fakeRPCClient) StartSession(context.Context, *bridgev1.StartSessionRequest, ...grpc.CallOption) (*bridgev1.StartSessionResponse, error) {
+func (f *fakeRPCClient) StartSession(_ context.Context, req *bridgev1.StartSessionRequest, _ ...grpc.CallOption) (*bridgev1.StartSessionResponse, error) {
+	f.starts = append(f.starts, req)
 	return f.startResp, f.err
 }
-func (f *fakeRPCClient) StopSession(context.Context, *bridgev1.StopSessionRequest, ...grpc.CallOption) (*bridgev1.StopSessionResponse, error) {
+func (f *fakeRPCClient) StopSession(_ context.Context, req *bridgev1.StopSessionRequest, _ ...grpc.CallOption) (*bridgev1.StopSessionResponse, error) {
+	f.stops = append(f.stops, req)
 	return f.stopResp, f.err
 }
 func (f *fakeRPCClient) GetSession(context.Context, *bridgev1.GetSessionRequest, ...grpc.CallOption) (*bridgev1.GetSessionResponse, error) {
 	return f.getResp, f.err
 }
+func (f *fakeRPCClient) GetSessionHistory(context.Context, *bridgev1.GetSessionHistoryRequest, ...grpc.CallOption) (*bridgev1.GetSessionHistoryResponse, error) {
+	return f.historyResp, f.err
+}
+func (f *fakeRPCClient) ExportTranscript(context.Context, *bridgev1.ExportTranscriptRequest, ...grpc.CallOption) (*bridgev1.ExportTranscriptResponse, error) {
+	return f.exportResp, f.err
+}
+func (f *fakeRPCClient) GetResponse(context.Context, *bridgev1.GetResponseRequest, ...grpc.CallOption) (*bridgev1.GetResponseResponse, error) {
+	return f.responseResp, f.err
+}
+func (f *fakeRPCClient) ExportSessionState(context.Context, *bridgev1.ExportSessionStateRequest, ...grpc.CallOption) (*bridgev1.ExportSessionStateResponse, error) {
+	return f.stateResp, f.err
+}
+func (f *fakeRPCClient) ImportSessionState(context.Context, *bridgev1.ImportSessionStateRequest, ...grpc.CallOption) (*bridgev1.ImportSessionStateResponse, error) {
+	return f.importResp, f.err
+}
 func (f *fakeRPCClient) ListSessions(context.Context, *bridgev1.ListSessionsRequest, ...grpc.CallOption) (*bridgev1.ListSessionsResponse, error) {
 	return f.listResp, f.err
 }
-func (f *fakeRPCClient) AttachSession(context.Context, *bridgev1.AttachSessionRequest, ...grpc.CallOption) (grpc.ServerStreamingClient[bridgev1.AttachSessionEvent], error) {
-	return nil, f.err
+func (f *fakeRPCClient) AttachSession(ctx context.Context, req *bridgev1.AttachSessionRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[bridgev1.AttachSessionEvent], error) {
+	f.attaches = append(f.attaches, req)
+	if f.err != nil || f.attachEvents == nil {
+		return nil, f.err
+	}
+	return &fakeAttachStream{ctx: ctx, events: append([]*bridgev1.AttachSessionEvent(nil), f.attachEvents...)}, nil
 }
-func (f *fakeRPCClient) WriteInput(context.Context, *bridgev1.WriteInputRequest, ...grpc.CallOption) (*bridgev1.WriteInputResponse, error) {
+func (f *fakeRPCClient) WriteInput(_ context.Context, req *bridgev1.WriteInputRequest, _ ...grpc.CallOption) (*bridgev1.WriteInputResponse, error) {
+	f.writes = append(f.writes, req)
 	return f.writeResp, f.err
 }
+func (f *fakeRPCClient) SendInputStream(context.Context, ...grpc.CallOption) (grpc.ClientStreamingClient[bridgev1.SendInputStreamRequest, bridgev1.WriteInputResponse], error) {
+	return &fakeInputStream{f: f}, nil
+}
+func (f *fakeRPCClient) GetConversation(context.Context, *bridgev1.GetConversationRequest, ...grpc.CallOption) (*bridgev1.GetConversationResponse, error) {
+	return f.convResp, f.err
+}
+func (f *fakeRPCClient) BroadcastInput(context.Context, *bridgev1.BroadcastInputRequest, ...grpc.CallOption) (*bridgev1.BroadcastInputResponse, error) {
+	return f.broadcastResp, f.err
+}
 func (f *fakeRPCClient) ResizeSession(context.Context, *bridgev1.ResizeSessionRequest, ...grpc.CallOption) (*bridgev1.ResizeSessionResponse, error) {
 	return f.resizeResp, f.err
 }
-func (f *fakeRPCClient) Health(context.Context, *bridgev1.HealthRequest, ...grpc.CallOption) (*bridgev1.HealthResponse, error) {
+func (f *fakeRPCClient) ReadFile(context.Context, *bridgev1.ReadFileRequest, ...grpc.CallOption) (*bridgev1.ReadFileResponse, error) {
+	return f.readFileResp, f.err
+}
+func (f *fakeRPCClient) WriteFile(context.Context, *bridgev1.WriteFileRequest, ...grpc.CallOption) (*bridgev1.WriteFileResponse, error) {
+	return f.writeFileResp, f.err
+}
+func (f *fakeRPCClient) ListDir(context.Context, *bridgev1.ListDirRequest, ...grpc.CallOption) (*bridgev1.ListDirResponse, error) {
+	return f.listDirResp, f.err
+}
+func (f *fakeRPCClient) GitStatus(context.Context, *bridgev1.GitStatusRequest, ...grpc.CallOption) (*bridgev1.GitStatusResponse, error) {
+	return f.gitStatusResp, f.err
+}
+func (f *fakeRPCClient) GitDiff(context.Context, *bridgev1.GitDiffRequest, ...grpc.CallOption) (*bridgev1.GitDiffResponse, error) {
+	return f.gitDiffResp, f.err
+}
+func (f *fakeRPCClient) GitCommit(context.Context, *bridgev1.GitCommitRequest, ...grpc.CallOption) (*bridgev1.GitCommitResponse, error) {
+	return f.gitCommitResp, f.err
+}
+func (f *fakeRPCClient) RollbackWorkspace(context.Context, *bridgev1.RollbackWorkspaceRequest, ...grpc.CallOption) (*bridgev1.RollbackWorkspaceResponse, error) {
+	return f.rollbackResp, f.err
+}
+func (f *fakeRPCClient) AttachTerminal(context.Context, ...grpc.CallOption) (grpc.BidiStreamingClient[bridgev1.AttachTerminalRequest, bridgev1.AttachTerminalResponse], error) {
+	return nil, f.err
+}
+func (f *fakeRPCClient) CancelResponse(context.Context, *bridgev1.CancelResponseRequest, ...grpc.CallOption) (*bridgev1.CancelResponseResponse, error) {
+	return f.cancelResp, f.err
+}
+func (f *fakeRPCClient) ResolveApproval(context.Context, *bridgev1.ResolveApprovalRequest, ...grpc.CallOption) (*bridgev1.ResolveApprovalResponse, error) {
+	return f.approvalResp, f.err
+}
+func (f *fakeRPCClient) Health(_ context.Context, req *bridgev1.HealthRequest, _ ...grpc.CallOption) (*bridgev1.HealthResponse, error) {
+	f.healthChecks = append(f.healthChecks, req.Check)
 	return f.healthResp, f.err
 }
+func (f *fakeRPCClient) HealthWatch(context.Context, *bridgev1.HealthRequest, ...grpc.CallOption) (grpc.ServerStreamingClient[bridgev1.HealthResponse], error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &fakeHealthStream{resps: append([]*bridgev1.HealthResponse(nil), f.healthWatch...)}, nil
+}
 func (f *fakeRPCClient) ListProviders(context.Context, *bridgev1.ListProvidersRequest, ...grpc.CallOption) (*bridgev1.ListProvidersResponse, error) {
 	return f.providersResp, f.err
 }
 func (f *fakeRPCClient) ClaimWriter(context.Context, *bridgev1.ClaimWriterRequest, ...grpc.CallOption) (*bridgev1.ClaimWriterResponse, error) {
 	return nil, f.err
 }
+func (f *fakeRPCClient) TakeControl(context.Context, *bridgev1.TakeControlRequest, ...grpc.CallOption) (*bridgev1.TakeControlResponse, error) {
+	return nil, f.err
+}
+func (f *fakeRPCClient) MintSessionToken(context.Context, *bridgev1.MintSessionTokenRequest, ...grpc.CallOption) (*bridgev1.MintSessionTokenResponse, error) {
+	return f.tokenResp, f.err
+}
 func (f *fakeRPCClient) ReleaseWriter(context.Context, *bridgev1.ReleaseWriterRequest, ...grpc.CallOption) (*bridgev1.ReleaseWriterResponse, error) {
 	return nil, f.err
 }
 
 func TestClientSessionMethods(t *testing.T) {
+	fake := &fakeRPCClient{}
 	c := &Client{
-		rpc:     &fakeRPCClient{},
-		retry:   RetryConfig{MaxAttempts: 1},
-		timeout: time.Second,
+		backends: []*backend{{rpc: fake}},
+		retry:    RetryConfig{MaxAttempts: 1},
+		timeout:  time.Second,
 	}
 
-	fake := c.rpc.(*fakeRPCClient)
 	fake.startResp = &bridgev1.StartSessionResponse{SessionId: "session-a"}
 	startResp, err := c.StartSession(context.Background(), &bridgev1.StartSessionRequest{ProjectId: "project-a"})
 	if err != nil || startResp.GetSessionId() != "session-a" {
@@ -84,6 +239,42 @@ func TestClientSessionMethods(t *testing.T) {
 		t.Fatalf("GetSession resp=%+v err=%v", getResp, err)
 	}
 
+	fake.historyResp = &bridgev1.GetSessionHistoryResponse{Events: []*bridgev1.AttachSessionEvent{{Seq: 1}}}
+	historyResp, err := c.GetSessionHistory(context.Background(), &bridgev1.GetSessionHistoryRequest{})
+	if err != nil || len(historyResp.GetEvents()) != 1 {
+		t.Fatalf("GetSessionHistory resp=%+v err=%v", historyResp, err)
+	}
+
+	fake.exportResp = &bridgev1.ExportTranscriptResponse{Filename: "session-a.md"}
+	exportResp, err := c.ExportTranscript(context.Background(), &bridgev1.ExportTranscriptRequest{})
+	if err != nil || exportResp.GetFilename() != "session-a.md" {
+		t.Fatalf("ExportTranscript resp=%+v err=%v", exportResp, err)
+	}
+
+	fake.convResp = &bridgev1.GetConversationResponse{Filename: "conv-1.md"}
+	convResp, err := c.GetConversation(context.Background(), &bridgev1.GetConversationRequest{ConversationId: "conv-1"})
+	if err != nil || convResp.GetFilename() != "conv-1.md" {
+		t.Fatalf("GetConversation resp=%+v err=%v", convResp, err)
+	}
+
+	fake.responseResp = &bridgev1.GetResponseResponse{Text: "done", Complete: true}
+	responseResp, err := c.GetResponse(context.Background(), &bridgev1.GetResponseRequest{})
+	if err != nil || responseResp.GetText() != "done" {
+		t.Fatalf("GetResponse resp=%+v err=%v", responseResp, err)
+	}
+
+	fake.stateResp = &bridgev1.ExportSessionStateResponse{State: []byte("{}"), LastSeq: 9}
+	stateResp, err := c.ExportSessionState(context.Background(), &bridgev1.ExportSessionStateRequest{})
+	if err != nil || stateResp.GetLastSeq() != 9 {
+		t.Fatalf("ExportSessionState resp=%+v err=%v", stateResp, err)
+	}
+
+	fake.importResp = &bridgev1.ImportSessionStateResponse{Session: &bridgev1.GetSessionResponse{SessionId: "session-a"}}
+	importResp, err := c.ImportSessionState(context.Background(), &bridgev1.ImportSessionStateRequest{})
+	if err != nil || importResp.GetSession().GetSessionId() != "session-a" {
+		t.Fatalf("ImportSessionState resp=%+v err=%v", importResp, err)
+	}
+
 	fake.listResp = &bridgev1.ListSessionsResponse{Sessions: []*bridgev1.GetSessionResponse{{SessionId: "session-a"}}}
 	listResp, err := c.ListSessions(context.Background(), &bridgev1.ListSessionsRequest{})
 	if err != nil || len(listResp.GetSessions()) != 1 {
@@ -96,17 +287,104 @@ func TestClientSessionMethods(t *testing.T) {
 		t.Fatalf("WriteInput resp=%+v err=%v", writeResp, err)
 	}
 
+	fake.broadcastResp = &bridgev1.BroadcastInputResponse{Results: []*bridgev1.BroadcastInputResult{{SessionId: "session-a", Accepted: true, Seq: 7}}}
+	broadcastResp, err := c.BroadcastInput(context.Background(), &bridgev1.BroadcastInputRequest{SessionIds: []string{"session-a"}})
+	if err != nil || len(broadcastResp.GetResults()) != 1 || broadcastResp.Results[0].Seq != 7 {
+		t.Fatalf("BroadcastInput resp=%+v err=%v", broadcastResp, err)
+	}
+
+	large := bytes.Repeat([]byte("x"), inputChunkSize+10)
+	streamResp, err := c.SendInputStream(context.Background(), &bridgev1.WriteInputRequest{SessionId: "session-a", ClientId: "c", Data: large})
+	if err != nil || !streamResp.GetAccepted() {
+		t.Fatalf("SendInputStream resp=%+v err=%v", streamResp, err)
+	}
+	if len(fake.inputChunks) != 2 || fake.inputChunks[0].TotalSize != uint64(len(large)) || fake.inputChunks[0].SessionId != "session-a" ||
+		len(fake.inputChunks[0].Data) != inputChunkSize || len(fake.inputChunks[1].Data) != 10 || fake.inputChunks[1].SessionId != "" {
+		t.Fatalf("SendInputStream chunks=%d first=%+v", len(fake.inputChunks), fake.inputChunks[0].SessionId)
+	}
+
 	fake.resizeResp = &bridgev1.ResizeSessionResponse{Applied: true}
 	resizeResp, err := c.ResizeSession(context.Background(), &bridgev1.ResizeSessionRequest{})
 	if err != nil || !resizeResp.GetApplied() {
 		t.Fatalf("ResizeSession resp=%+v err=%v", resizeResp, err)
 	}
 
+	fake.cancelResp = &bridgev1.CancelResponseResponse{Delivered: true}
+	cancelResp, err := c.CancelResponse(context.Background(), &bridgev1.CancelResponseRequest{})
+	if err != nil || !cancelResp.GetDelivered() {
+		t.Fatalf("CancelResponse resp=%+v err=%v", cancelResp, err)
+	}
+
+	fake.approvalResp = &bridgev1.ResolveApprovalResponse{Delivered: true}
+	approvalResp, err := c.ResolveApproval(context.Background(), &bridgev1.ResolveApprovalRequest{})
+	if err != nil || !approvalResp.GetDelivered() {
+		t.Fatalf("ResolveApproval resp=%+v err=%v", approvalResp, err)
+	}
+
+	fake.tokenResp = &bridgev1.MintSessionTokenResponse{Token: "share", TokenId: "jti-1"}
+	tokenResp, err := c.MintSessionToken(context.Background(), &bridgev1.MintSessionTokenRequest{})
+	if err != nil || tokenResp.GetToken() != "share" {
+		t.Fatalf("MintSessionToken resp=%+v err=%v", tokenResp, err)
+	}
+
+	fake.readFileResp = &bridgev1.ReadFileResponse{Data: []byte("task")}
+	readResp, err := c.ReadFile(context.Background(), &bridgev1.ReadFileRequest{})
+	if err != nil || string(readResp.GetData()) != "task" {
+		t.Fatalf("ReadFile resp=%+v err=%v", readResp, err)
+	}
+	fake.writeFileResp = &bridgev1.WriteFileResponse{BytesWritten: 4}
+	writeFileResp, err := c.WriteFile(context.Background(), &bridgev1.WriteFileRequest{})
+	if err != nil || writeFileResp.GetBytesWritten() != 4 {
+		t.Fatalf("WriteFile resp=%+v err=%v", writeFileResp, err)
+	}
+	fake.listDirResp = &bridgev1.ListDirResponse{Entries: []*bridgev1.DirEntry{{Name: "TASK.md"}}}
+	listDirResp, err := c.ListDir(context.Background(), &bridgev1.ListDirRequest{})
+	if err != nil || len(listDirResp.GetEntries()) != 1 {
+		t.Fatalf("ListDir resp=%+v err=%v", listDirResp, err)
+	}
+
+	fake.gitStatusResp = &bridgev1.GitStatusResponse{Branch: "main", Clean: true}
+	gitStatusResp, err := c.GitStatus(context.Background(), &bridgev1.GitStatusRequest{})
+	if err != nil || gitStatusResp.GetBranch() != "main" {
+		t.Fatalf("GitStatus resp=%+v err=%v", gitStatusResp, err)
+	}
+	fake.gitDiffResp = &bridgev1.GitDiffResponse{Diff: []byte("+x")}
+	gitDiffResp, err := c.GitDiff(context.Background(), &bridgev1.GitDiffRequest{})
+	if err != nil || string(gitDiffResp.GetDiff()) != "+x" {
+		t.Fatalf("GitDiff resp=%+v err=%v", gitDiffResp, err)
+	}
+	fake.gitCommitResp = &bridgev1.GitCommitResponse{Commit: "abc123"}
+	gitCommitResp, err := c.GitCommit(context.Background(), &bridgev1.GitCommitRequest{})
+	if err != nil || gitCommitResp.GetCommit() != "abc123" {
+		t.Fatalf("GitCommit resp=%+v err=%v", gitCommitResp, err)
+	}
+	fake.rollbackResp = &bridgev1.RollbackWorkspaceResponse{Head: "abc123"}
+	rollbackResp, err := c.RollbackWorkspace(context.Background(), &bridgev1.RollbackWorkspaceRequest{})
+	if err != nil || rollbackResp.GetHead() != "abc123" {
+		t.Fatalf("RollbackWorkspace resp=%+v err=%v", rollbackResp, err)
+	}
+
 	fake.healthResp = &bridgev1.HealthResponse{Status: "serving"}
 	healthResp, err := c.Health(context.Background())
 	if err != nil || healthResp.GetStatus() != "serving" {
 		t.Fatalf("Health resp=%+v err=%v", healthResp, err)
 	}
+	if _, err := c.CheckHealth(context.Background(), bridgev1.HealthCheck_HEALTH_CHECK_LIVENESS); err != nil {
+		t.Fatalf("CheckHealth: %v", err)
+	}
+	if want := []bridgev1.HealthCheck{bridgev1.HealthCheck_HEALTH_CHECK_READINESS, bridgev1.HealthCheck_HEALTH_CHECK_LIVENESS}; !slices.Equal(fake.healthChecks, want) {
+		t.Fatalf("health checks=%v want %v", fake.healthChecks, want)
+	}
+
+	fake.healthWatch = []*bridgev1.HealthResponse{{Status: "serving"}, {Status: "not_serving"}}
+	var watched []string
+	err = c.WatchHealth(context.Background(), bridgev1.HealthCheck_HEALTH_CHECK_READINESS, func(resp *bridgev1.HealthResponse) error {
+		watched = append(watched, resp.Status)
+		return nil
+	})
+	if err != nil || !slices.Equal(watched, []string{"serving", "not_serving"}) {
+		t.Fatalf("WatchHealth watched=%v err=%v", watched, err)
+	}
 
 	fake.providersResp = &bridgev1.ListProvidersResponse{Providers: []*bridgev1.ProviderInfo{{Provider: "fake"}}}
 	providersResp, err := c.ListProviders(context.Background())
@@ -126,7 +404,7 @@ func TestInvokeRetriesAndMapsErrors(t *testing.T) {
 	}
 
 	attempts := 0
-	err := c.invoke(context.Background(), func(context.Context) error {
+	err := c.invoke(context.Background(), "Health", func(context.Context) error {
 		attempts++
 		if attempts == 1 {
 			return status.Error(codes.Unavailable, "retry me")
@@ -140,10 +418,38 @@ func TestInvokeRetriesAndMapsErrors(t *testing.T) {
 		t.Fatalf("attempts=%d want 2", attempts)
 	}
 
-	err = c.invoke(context.Background(), func(context.Context) error {
+	err = c.invoke(context.Background(), "Health", func(context.Context) error {
 		return errors.New("boom")
 	})
 	if err == nil || err.Error() != "boom" {
 		t.Fatalf("invoke error=%v want raw boom error", err)
 	}
 }
+
+func TestRequestsSendProtocolVersion(t *testing.T) {
+	fake := &fakeRPCClient{
+		startResp:    &bridgev1.StartSessionResponse{},
+		attachEvents: []*bridgev1.AttachSessionEvent{{Type: bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED}},
+	}
+	c := newFailoverClient(fake)
+	ctx := context.Background()
+
+	req := &bridgev1.StartSessionRequest{SessionId: "s"}
+	if _, err := c.StartSession(ctx, req); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if fake.starts[0].ProtocolVersion != ProtocolVersion || req.ProtocolVersion != 0 {
+		t.Fatalf("sent %d, caller's request now %d; want %d sent and the request untouched", fake.starts[0].ProtocolVersion, req.ProtocolVersion, ProtocolVersion)
+	}
+
+	for _, v := range []uint32{0, 7} {
+		stream, err := c.AttachSession(ctx, &bridgev1.AttachSessionRequest{SessionId: "s", ProtocolVersion: v})
+		if err != nil {
+			t.Fatalf("AttachSession: %v", err)
+		}
+		_ = stream.RecvAll(ctx, func(*bridgev1.AttachSessionEvent) error { return io.EOF })
+	}
+	if len(fake.attaches) != 2 || fake.attaches[0].ProtocolVersion != ProtocolVersion || fake.attaches[1].ProtocolVersion != 7 {
+		t.Fatalf("attach requests=%+v want ProtocolVersion, then the caller's 7", fake.attaches)
+	}
+}
@@ -0,0 +1,79 @@
+package bridgeclient
+
+import (
+	"context"
+	"errors"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"google.golang.org/grpc"
+)
+
+// TerminalStream is an open AttachTerminal stream: raw PTY output in, keystrokes
+// and resizes out.
+type TerminalStream struct {
+	stream   grpc.BidiStreamingClient[bridgev1.AttachTerminalRequest, bridgev1.AttachTerminalResponse]
+	attached *bridgev1.TerminalAttached
+	cancel   context.CancelFunc
+}
+
+// AttachTerminal opens a terminal stream on the bridge owning the session,
+// falling back to the other configured bridges like AttachSession. It returns
+// once the bridge has confirmed the attach.
+func (c *Client) AttachTerminal(ctx context.Context, open *bridgev1.TerminalOpen) (*TerminalStream, error) {
+	if open.ClientId == "" {
+		open.ClientId = generateClientID()
+	}
+	var lastErr error
+	for _, b := range c.candidates(open.SessionId) {
+		streamCtx, cancel := context.WithCancel(ctx)
+		stream, err := b.rpc.AttachTerminal(streamCtx, c.streamOpts...)
+		if err == nil {
+			err = stream.Send(&bridgev1.AttachTerminalRequest{Frame: &bridgev1.AttachTerminalRequest_Open{Open: open}})
+		}
+		if err == nil {
+			var resp *bridgev1.AttachTerminalResponse
+			resp, err = stream.Recv()
+			if err == nil && resp.GetAttached() == nil {
+				err = errors.New("terminal stream did not start with attached")
+			}
+			if err == nil {
+				c.affinity.set(open.SessionId, b)
+				return &TerminalStream{stream: stream, attached: resp.GetAttached(), cancel: cancel}, nil
+			}
+		}
+		cancel()
+		lastErr = err
+		if !shouldFailover(err, true) {
+			break
+		}
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no bridge targets configured")
+	}
+	return nil, mapError(lastErr)
+}
+
+// Attached returns the bridge's attach confirmation.
+func (t *TerminalStream) Attached() *bridgev1.TerminalAttached { return t.attached }
+
+// Recv returns the next output or exit frame; io.EOF follows the exit frame.
+func (t *TerminalStream) Recv() (*bridgev1.AttachTerminalResponse, error) {
+	return t.stream.Recv()
+}
+
+// Write sends keystrokes to the PTY.
+func (t *TerminalStream) Write(data []byte) error {
+	return t.stream.Send(&bridgev1.AttachTerminalRequest{Frame: &bridgev1.AttachTerminalRequest_Input{Input: data}})
+}
+
+// Resize resizes the PTY.
+func (t *TerminalStream) Resize(cols, rows uint32) error {
+	return t.stream.Send(&bridgev1.AttachTerminalRequest{Frame: &bridgev1.AttachTerminalRequest_Resize{Resize: &bridgev1.TerminalResize{Cols: cols, Rows: rows}}})
+}
+
+// Close detaches from the session.
+func (t *TerminalStream) Close() error {
+	err := t.stream.CloseSend()
+	t.cancel()
+	return err
+}
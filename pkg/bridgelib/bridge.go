@@ -30,6 +30,11 @@ type Config struct {
 
 type SessionInfo = bridge.SessionInfo
 type OutputChunk = bridge.OutputChunk
+type InputAck = bridge.InputAck
+type FileEntry = bridge.FileEntry
+type GitStatus = bridge.GitStatus
+type GitDiffOptions = bridge.GitDiffOptions
+type GitCommitOptions = bridge.GitCommitOptions
 
 type AttachState struct {
 	ClientID  string
@@ -69,6 +74,7 @@ func New(cfg Config) (*Bridge, error) {
 		MaxPerProject: cfg.MaxSessionsPerProject,
 		MaxGlobal:     cfg.MaxSessions,
 		MaxInputBytes: 65536,
+		MaxFileBytes:  1 << 20,
 		AllowedPaths:  cfg.AllowedPaths,
 	}
 	if policy.MaxPerProject == 0 {
@@ -110,9 +116,37 @@ func (b *Bridge) List(projectID string) []SessionInfo        { return b.supervis
 func (b *Bridge) WriteInput(sessionID, clientID string, data []byte) (int, error) {
 	return b.supervisor.WriteInput(sessionID, clientID, data)
 }
+func (b *Bridge) SendInput(sessionID, clientID string, data []byte) (InputAck, error) {
+	return b.supervisor.SendInput(context.Background(), sessionID, clientID, data)
+}
 func (b *Bridge) ResizeSession(sessionID, clientID string, cols, rows uint32) error {
 	return b.supervisor.Resize(sessionID, clientID, cols, rows)
 }
+func (b *Bridge) CancelResponse(sessionID, clientID string) error {
+	return b.supervisor.CancelResponse(sessionID, clientID)
+}
+func (b *Bridge) ResolveApproval(sessionID, clientID, approvalID string, approve bool, message string) error {
+	return b.supervisor.ResolveApproval(context.Background(), sessionID, clientID, approvalID, approve, message)
+}
+func (b *Bridge) ReadFile(sessionID, path string) ([]byte, error) {
+	data, _, err := b.supervisor.ReadFile(sessionID, path)
+	return data, err
+}
+func (b *Bridge) WriteFile(sessionID, path string, data []byte) error {
+	return b.supervisor.WriteFile(sessionID, path, data)
+}
+func (b *Bridge) ListDir(sessionID, path string) ([]FileEntry, error) {
+	return b.supervisor.ListDir(sessionID, path)
+}
+func (b *Bridge) GitStatus(ctx context.Context, sessionID string) (*GitStatus, error) {
+	return b.supervisor.GitStatus(ctx, sessionID)
+}
+func (b *Bridge) GitDiff(ctx context.Context, sessionID string, opts GitDiffOptions) ([]byte, bool, error) {
+	return b.supervisor.GitDiff(ctx, sessionID, opts)
+}
+func (b *Bridge) GitCommit(ctx context.Context, sessionID string, opts GitCommitOptions) (string, error) {
+	return b.supervisor.GitCommit(ctx, sessionID, opts)
+}
 func (b *Bridge) AttachSession(sessionID, clientID string, afterSeq uint64) (*bridge.AttachState, error) {
 	return b.supervisor.Attach(sessionID, clientID, afterSeq, bridge.AttachRoleWriter)
 }
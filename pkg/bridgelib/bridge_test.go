@@ -5,6 +5,8 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
 )
 
 func TestNewAppliesDefaults(t *testing.T) {
@@ -47,16 +49,22 @@ func TestBridgeLifecycle(t *testing.T) {
 		t.Fatalf("AttachSession: %v", err)
 	}
 
-	if _, err := b.WriteInput("session-a", "client-a", []byte("hello\n")); err != nil {
-		t.Fatalf("WriteInput: %v", err)
+	ack, err := b.SendInput("session-a", "client-a", []byte("hello\n"))
+	if err != nil {
+		t.Fatalf("SendInput: %v", err)
 	}
-	select {
-	case chunk := <-state.Live:
-		if !bytes.Contains(chunk.Payload, []byte("hello")) {
-			t.Fatalf("payload=%q does not contain hello", string(chunk.Payload))
+	for _, want := range []bridge.ChunkType{bridge.ChunkTypeInputAcked, bridge.ChunkTypeOutput} {
+		select {
+		case chunk := <-state.Live:
+			if chunk.Type != want || chunk.InputID != ack.ID {
+				t.Fatalf("chunk=%+v, want type %d for input %s", chunk, want, ack.ID)
+			}
+			if want == bridge.ChunkTypeOutput && !bytes.Contains(chunk.Payload, []byte("hello")) {
+				t.Fatalf("payload=%q does not contain hello", string(chunk.Payload))
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out waiting for live output")
 		}
-	case <-time.After(3 * time.Second):
-		t.Fatal("timed out waiting for live output")
 	}
 
 	if err := b.ResizeSession("session-a", "client-a", 100, 40); err != nil {
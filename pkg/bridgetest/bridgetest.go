@@ -0,0 +1,159 @@
+// Package bridgetest runs a real bridge in process for client tests. The
+// bridge serves over an in-memory bufconn listener, so tests need no ports,
+// certificates or AI binaries:
+//
+//	b := bridgetest.New(t)
+//	resp, err := b.Client.StartSession(ctx, &bridgev1.StartSessionRequest{
+//		ProjectId: "test",
+//		SessionId: uuid.NewString(),
+//		RepoPath:  t.TempDir(),
+//		Provider:  bridgetest.EchoProvider,
+//	})
+//
+// The bridge runs in local mode: every call is accepted without mTLS or JWT
+// and rate limits are off.
+package bridgetest
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+	"github.com/markcallen/ai-agent-bridge/internal/auth"
+	"github.com/markcallen/ai-agent-bridge/internal/bridge"
+	"github.com/markcallen/ai-agent-bridge/internal/provider"
+	"github.com/markcallen/ai-agent-bridge/internal/server"
+	"github.com/markcallen/ai-agent-bridge/pkg/bridgeclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// EchoProvider is the provider registered by every test bridge. It runs
+// cat, so input written to a session comes back as output.
+const EchoProvider = "echo"
+
+// Bridge is a running in-process bridge.
+type Bridge struct {
+	// Client is connected to the bridge and closed when the test ends.
+	Client *bridgeclient.Client
+
+	lis *bufconn.Listener
+}
+
+type config struct {
+	providers   []*provider.StdioProvider
+	clientOpts  []bridgeclient.Option
+	policy      bridge.Policy
+	idleTimeout time.Duration
+	logger      *slog.Logger
+}
+
+// Option configures New.
+type Option func(*config)
+
+// WithProvider registers a provider that runs binary with args, in addition
+// to the echo provider. Output is read from a PTY, and the session is
+// running as soon as the process starts.
+func WithProvider(id, binary string, args ...string) Option {
+	return func(c *config) {
+		c.providers = append(c.providers, provider.NewStdioProvider(provider.StdioConfig{
+			ProviderID:     id,
+			Binary:         binary,
+			DefaultArgs:    args,
+			StartupTimeout: 5 * time.Second,
+			StopGrace:      2 * time.Second,
+			StartupProbe:   "none",
+		}))
+	}
+}
+
+// WithClientOptions adds options to the connected Client.
+func WithClientOptions(opts ...bridgeclient.Option) Option {
+	return func(c *config) { c.clientOpts = append(c.clientOpts, opts...) }
+}
+
+// WithSessionLimits sets the maximum number of live sessions per project and
+// overall (defaults: 5 and 20).
+func WithSessionLimits(perProject, global int) Option {
+	return func(c *config) {
+		c.policy.MaxPerProject = perProject
+		c.policy.MaxGlobal = global
+	}
+}
+
+// WithIdleTimeout sets how long a session may be idle before the bridge
+// stops it (default: 1 minute).
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *config) { c.idleTimeout = d }
+}
+
+// WithLogger sets the bridge's logger. Logs are discarded by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) { c.logger = logger }
+}
+
+// New starts a bridge and a connected Client. Both are shut down, and every
+// session stopped, when t ends.
+func New(t testing.TB, opts ...Option) *Bridge {
+	t.Helper()
+	cfg := config{
+		policy:      bridge.DefaultPolicy(),
+		idleTimeout: time.Minute,
+		logger:      slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	registry := bridge.NewRegistry()
+	providers := append(cfg.providers, provider.NewStdioProvider(provider.StdioConfig{
+		ProviderID:     EchoProvider,
+		Binary:         "cat",
+		StartupTimeout: 5 * time.Second,
+		StopGrace:      2 * time.Second,
+		StartupProbe:   "none",
+	}))
+	for _, p := range providers {
+		if err := registry.Register(p); err != nil {
+			t.Fatalf("bridgetest: register provider %q: %v", p.ID(), err)
+		}
+	}
+
+	sup := bridge.NewSupervisor(registry, cfg.policy, 0, cfg.idleTimeout)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.UnaryPassthroughInterceptor()),
+		grpc.ChainStreamInterceptor(auth.StreamPassthroughInterceptor()),
+	)
+	bridgev1.RegisterBridgeServiceServer(grpcServer, server.New(sup, registry, cfg.logger, server.RateLimitConfig{}, "bridgetest", nil))
+
+	b := &Bridge{lis: bufconn.Listen(1 << 20)}
+	go func() { _ = grpcServer.Serve(b.lis) }()
+	t.Cleanup(func() {
+		grpcServer.Stop()
+		sup.Close()
+	})
+
+	b.Client = b.Dial(t, cfg.clientOpts...)
+	return b
+}
+
+// Dial returns another Client connected to the bridge, e.g. to test two
+// clients attached to one session. It is closed when t ends.
+func (b *Bridge) Dial(t testing.TB, opts ...bridgeclient.Option) *bridgeclient.Client {
+	t.Helper()
+	opts = append([]bridgeclient.Option{
+		bridgeclient.WithTarget("passthrough:///bufconn"),
+		bridgeclient.WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return b.lis.DialContext(ctx)
+		})),
+	}, opts...)
+	client, err := bridgeclient.New(opts...)
+	if err != nil {
+		t.Fatalf("bridgetest: connect: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
@@ -0,0 +1,72 @@
+package bridgetest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	bridgev1 "github.com/markcallen/ai-agent-bridge/gen/bridge/v1"
+)
+
+func TestEchoSession(t *testing.T) {
+	b := New(t)
+	sessionID := uuid.NewString()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := b.Client.StartSession(ctx, &bridgev1.StartSessionRequest{
+		ProjectId: "test",
+		SessionId: sessionID,
+		RepoPath:  t.TempDir(),
+		Provider:  EchoProvider,
+	}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+
+	// A second client writes while the first one reads.
+	writer := b.Dial(t)
+	stream, err := b.Client.AttachSession(ctx, &bridgev1.AttachSessionRequest{SessionId: sessionID})
+	if err != nil {
+		t.Fatalf("AttachSession: %v", err)
+	}
+	var out strings.Builder
+	err = stream.RecvAll(ctx, func(ev *bridgev1.AttachSessionEvent) error {
+		switch ev.Type {
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_ATTACHED:
+			if _, err := writer.WriteInput(ctx, &bridgev1.WriteInputRequest{
+				SessionId: sessionID,
+				ClientId:  stream.ClientID(),
+				Data:      []byte("ping\n"),
+			}); err != nil {
+				t.Errorf("WriteInput: %v", err)
+				cancel()
+			}
+		case bridgev1.AttachEventType_ATTACH_EVENT_TYPE_OUTPUT:
+			out.Write(ev.Payload)
+			if strings.Contains(out.String(), "ping") {
+				cancel()
+			}
+		}
+		return nil
+	})
+	if !strings.Contains(out.String(), "ping") {
+		t.Fatalf("output=%q err=%v want the input echoed", out.String(), err)
+	}
+}
+
+func TestWithProvider(t *testing.T) {
+	b := New(t, WithProvider("greeter", "sh", "-c", "echo hello; cat"))
+	resp, err := b.Client.ListProviders(context.Background())
+	if err != nil {
+		t.Fatalf("ListProviders: %v", err)
+	}
+	ids := map[string]bool{}
+	for _, p := range resp.Providers {
+		ids[p.Provider] = true
+	}
+	if !ids["greeter"] || !ids[EchoProvider] {
+		t.Fatalf("providers=%v want greeter and echo", ids)
+	}
+}
@@ -49,11 +49,13 @@ mkdir -p \
 install -m 0755 "$ROOT_DIR/bin/ai-agent-bridge-ca" "$PKG_ROOT/usr/bin/ai-agent-bridge-ca"
 install -m 0755 "$ROOT_DIR/bin/bridgectl" "$PKG_ROOT/usr/bin/bridgectl"
 
-# Default config and systemd user unit
+# Default config and systemd user units
 install -m 0644 "$ROOT_DIR/packaging/bridge.yaml" \
   "$PKG_ROOT/etc/ai-agent-bridge/bridge.yaml"
 install -m 0644 "$ROOT_DIR/packaging/bridge.user.service" \
   "$PKG_ROOT/usr/lib/systemd/user/bridge.service"
+install -m 0644 "$ROOT_DIR/packaging/bridge.user.socket" \
+  "$PKG_ROOT/usr/lib/systemd/user/bridge.socket"
 
 # Provider runtime install helper
 install -m 0755 "$ROOT_DIR/packaging/install-provider-runtime" \